@@ -18,6 +18,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
 
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/logging"
 	"github.com/tbd54566975/ssi-service/pkg/authorizationserver"
 )
 
@@ -35,14 +36,12 @@ func run() error {
 		panic(err)
 	}
 
-	if cfg.Server.LogLevel != "" {
-		level, err := logrus.ParseLevel(cfg.Server.LogLevel)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not parse log level<%s>, setting to info", cfg.Server.LogLevel)
-			logrus.SetLevel(logrus.InfoLevel)
-		} else {
-			logrus.SetLevel(level)
-		}
+	if logFile := logging.Configure(cfg.Server); logFile != nil {
+		defer func(logFile *os.File) {
+			if err := logFile.Close(); err != nil {
+				logrus.WithError(err).Error("failed to close log file")
+			}
+		}(logFile)
 	}
 
 	// create a channel of buffer size 1 to handle shutdown.
@@ -0,0 +1,767 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+)
+
+// pageQuery are the query parameters that page through a list.
+var pageQuery = []string{"pageSize", "pageToken"}
+
+// collection creates the create, get, list, and delete commands of a collection of the API, e.g. /v1/schemas. List
+// output shows the given columns.
+func (a *app) collection(path, noun string, columns ...string) []*cobra.Command {
+	return []*cobra.Command{
+		a.command(endpoint{use: "create", short: "Create a " + noun, method: http.MethodPut, path: path, data: true}),
+		a.command(endpoint{use: "get <id>", short: "Get a " + noun, method: http.MethodGet, path: path + "/{id}"}),
+		a.command(endpoint{use: "list", short: "List " + noun + "s", method: http.MethodGet, path: path, query: pageQuery, list: true, columns: columns}),
+		a.command(endpoint{use: "delete <id>", short: "Delete a " + noun, method: http.MethodDelete, path: path + "/{id}"}),
+	}
+}
+
+func (a *app) keyCommand() *cobra.Command {
+	return group("key", "Manage keys in the keystore",
+		a.command(endpoint{use: "store", short: "Store a private key", method: http.MethodPut, path: "/v1/keys", data: true}),
+		a.command(endpoint{use: "get <id>", short: "Get the details of a key", method: http.MethodGet, path: "/v1/keys/{id}"}),
+		a.command(endpoint{use: "list", short: "List the details of keys", method: http.MethodGet, path: "/v1/keys", query: pageQuery, list: true, columns: []string{"id", "type", "controller"}}),
+		a.command(endpoint{use: "revoke <id>", short: "Revoke a key", method: http.MethodDelete, path: "/v1/keys/{id}"}),
+		a.command(endpoint{use: "jwk <id>", short: "Get the public key of a key as a JWK", method: http.MethodGet, path: "/v1/keys/{id}/jwk"}),
+		a.command(endpoint{
+			use:     "jwks",
+			short:   "Get the public keys of a DID as a JWK Set",
+			long:    "Get the public keys of the --did's keys as a JWK Set, or of the did:web of the service's host without --did.",
+			method:  http.MethodGet,
+			path:    "/.well-known/jwks.json",
+			query:   []string{"did"},
+			list:    true,
+			columns: []string{"kid", "kty", "alg"},
+		}),
+	)
+}
+
+func (a *app) didCommand() *cobra.Command {
+	return group("did", "Create, list, and resolve DIDs",
+		a.command(endpoint{use: "methods", short: "List the supported DID methods", method: http.MethodGet, path: "/v1/dids"}),
+		a.command(endpoint{
+			use:    "create <method>",
+			short:  "Create a DID",
+			long:   "Create a DID with a new key of --key-type, or with the request in --data.",
+			method: http.MethodPut,
+			path:   "/v1/dids/{method}",
+			data:   true,
+			flags: func(cmd *cobra.Command) {
+				cmd.Flags().String("key-type", "", "type of key to create, e.g. Ed25519 or secp256k1")
+				cmd.Flags().String("options", "", "JSON options of the DID method")
+			},
+			body: func(cmd *cobra.Command, _ []string) (any, error) {
+				keyType, _ := cmd.Flags().GetString("key-type")
+				if keyType == "" {
+					return nil, errors.New("either --key-type or --data is required")
+				}
+				body := map[string]any{"keyType": keyType}
+				options, err := jsonFlag(cmd, "options")
+				if err != nil {
+					return nil, err
+				}
+				if options != nil {
+					body["options"] = options
+				}
+				return body, nil
+			},
+		}),
+		a.command(endpoint{use: "batch-create <method>", short: "Create DIDs in a batch", method: http.MethodPut, path: "/v1/dids/{method}/batch", data: true}),
+		a.command(endpoint{
+			use:     "list <method>",
+			short:   "List the DIDs of a method",
+			method:  http.MethodGet,
+			path:    "/v1/dids/{method}",
+			query:   []string{"deleted", "pageSize", "pageToken"},
+			list:    true,
+			columns: []string{"id"},
+		}),
+		a.command(endpoint{use: "get <method> <id>", short: "Get a DID", method: http.MethodGet, path: "/v1/dids/{method}/{id}"}),
+		a.command(endpoint{
+			use:    "delete <method> <id>",
+			short:  "Soft delete a DID",
+			long:   "Soft delete a DID, which can be restored. With --deactivate=true, a DID whose method supports it is deactivated too, which is permanent.",
+			method: http.MethodDelete,
+			path:   "/v1/dids/{method}/{id}",
+			query:  []string{"deactivate"},
+		}),
+		a.command(endpoint{use: "restore <method> <id>", short: "Restore a soft deleted DID", method: http.MethodPut, path: "/v1/dids/{method}/{id}/restore"}),
+		a.command(endpoint{use: "resolve <id>", short: "Resolve a DID", method: http.MethodGet, path: "/v1/dids/resolver/{id}"}),
+	)
+}
+
+func (a *app) schemaCommand() *cobra.Command {
+	commands := append(a.collection("/v1/schemas", "schema", "id", "version", "type", "schema.name"),
+		a.command(endpoint{
+			use:     "versions <id>",
+			short:   "List the versions of a schema",
+			long:    "List every version of a schema, oldest first. A version is resolved with schema get <id>@<version>.",
+			method:  http.MethodGet,
+			path:    "/v1/schemas/{id}/versions",
+			list:    true,
+			columns: []string{"version", "id"},
+		}))
+	return group("schema", "Manage credential schemas", commands...)
+}
+
+func (a *app) credentialCommand() *cobra.Command {
+	issue := a.command(endpoint{
+		use:    "issue",
+		short:  "Issue a credential",
+		long:   "Issue a credential built from the flags, or from the request in --data.",
+		method: http.MethodPut,
+		path:   "/v1/credentials",
+		data:   true,
+		flags: func(cmd *cobra.Command) {
+			cmd.Flags().String("issuer", "", "DID of the issuer")
+			cmd.Flags().String("verification-method", "", "ID of the issuer's verification method to sign with")
+			cmd.Flags().String("subject", "", "DID of the subject")
+			cmd.Flags().String("claims", "", "JSON object of the claims about the subject")
+			cmd.Flags().String("schema", "", "ID of the schema the claims must conform to")
+			cmd.Flags().String("expiry", "", "RFC 3339 time the credential expires")
+			cmd.Flags().Bool("revocable", false, "whether the credential can be revoked")
+			cmd.Flags().Bool("suspendable", false, "whether the credential can be suspended")
+		},
+		body: func(cmd *cobra.Command, _ []string) (any, error) {
+			flags := cmd.Flags()
+			issuer, _ := flags.GetString("issuer")
+			verificationMethod, _ := flags.GetString("verification-method")
+			subject, _ := flags.GetString("subject")
+			if issuer == "" || verificationMethod == "" || subject == "" {
+				return nil, errors.New("either --issuer, --verification-method, and --subject, or --data are required")
+			}
+			claims, err := jsonFlag(cmd, "claims")
+			if err != nil {
+				return nil, err
+			}
+			if claims == nil {
+				claims = map[string]any{}
+			}
+			schema, _ := flags.GetString("schema")
+			expiry, _ := flags.GetString("expiry")
+			revocable, _ := flags.GetBool("revocable")
+			suspendable, _ := flags.GetBool("suspendable")
+			return map[string]any{
+				"issuer":               issuer,
+				"verificationMethodId": verificationMethod,
+				"subject":              subject,
+				"data":                 claims,
+				"schemaId":             schema,
+				"expiry":               expiry,
+				"revocable":            revocable,
+				"suspendable":          suspendable,
+			}, nil
+		},
+	})
+	verify := a.command(endpoint{
+		use:    "verify",
+		short:  "Verify a credential",
+		long:   "Verify the credential JWT in --jwt, or the request in --data.",
+		method: http.MethodPut,
+		path:   "/v1/credentials/verification",
+		data:   true,
+		flags: func(cmd *cobra.Command) {
+			cmd.Flags().String("jwt", "", "credential JWT to verify")
+		},
+		body: func(cmd *cobra.Command, _ []string) (any, error) {
+			jwt, _ := cmd.Flags().GetString("jwt")
+			if jwt == "" {
+				return nil, errors.New("either --jwt or --data is required")
+			}
+			return map[string]any{"credentialJwt": jwt}, nil
+		},
+	})
+	setStatus := a.command(endpoint{
+		use:    "set-status <id>",
+		short:  "Revoke, suspend, or reinstate a credential",
+		method: http.MethodPut,
+		path:   "/v1/credentials/{id}/status",
+		dryRun: true,
+		flags: func(cmd *cobra.Command) {
+			cmd.Flags().Bool("revoked", false, "whether the credential is revoked")
+			cmd.Flags().Bool("suspended", false, "whether the credential is suspended")
+		},
+		body: func(cmd *cobra.Command, _ []string) (any, error) {
+			revoked, _ := cmd.Flags().GetBool("revoked")
+			suspended, _ := cmd.Flags().GetBool("suspended")
+			return map[string]any{"revoked": revoked, "suspended": suspended}, nil
+		},
+	})
+
+	return group("credential", "Issue, verify, and manage credentials",
+		issue,
+		a.command(endpoint{use: "batch-issue", short: "Issue credentials in a batch", method: http.MethodPut, path: "/v1/credentials/batch", data: true}),
+		a.command(endpoint{use: "get <id>", short: "Get a credential", method: http.MethodGet, path: "/v1/credentials/{id}"}),
+		a.command(endpoint{
+			use:     "list",
+			short:   "List credentials",
+			method:  http.MethodGet,
+			path:    "/v1/credentials",
+			query:   []string{"issuer", "schema", "subject", "pageSize", "pageToken"},
+			list:    true,
+			columns: []string{"id", "credential.issuer", "credential.credentialSubject.id", "revoked", "suspended"},
+		}),
+		a.command(endpoint{use: "delete <id>", short: "Delete a credential", method: http.MethodDelete, path: "/v1/credentials/{id}"}),
+		verify,
+		a.command(endpoint{use: "status <id>", short: "Get the status of a credential", method: http.MethodGet, path: "/v1/credentials/{id}/status"}),
+		setStatus,
+		a.command(endpoint{use: "status-list <id>", short: "Get a status list credential", method: http.MethodGet, path: "/v1/credentials/status/{id}"}),
+	)
+}
+
+func (a *app) operationCommand() *cobra.Command {
+	return group("operation", "Poll and cancel long running operations",
+		a.command(endpoint{
+			use:     "list",
+			short:   "List the operations of a parent resource",
+			method:  http.MethodGet,
+			path:    "/v1/operations",
+			query:   []string{"parent", "filter", "pageSize", "pageToken"},
+			list:    true,
+			columns: []string{"id", "done"},
+		}),
+		a.command(endpoint{use: "get <id>", short: "Get an operation", method: http.MethodGet, path: "/v1/operations/{*id}"}),
+		a.command(endpoint{use: "cancel <id>", short: "Cancel an operation", method: http.MethodPut, path: "/v1/operations/cancel/{*id}"}),
+	)
+}
+
+func (a *app) batchCommand() *cobra.Command {
+	return a.command(endpoint{
+		use:   "batch",
+		short: "Run several requests in order",
+		long: `batch runs the operations of --data in order, in a single request. Set "atomic": true to undo the operations
+that succeeded when one of them fails.`,
+		method:  http.MethodPost,
+		path:    "/v1/batch",
+		data:    true,
+		list:    true,
+		columns: []string{"id", "status", "undone"},
+	})
+}
+
+func (a *app) presentationCommand() *cobra.Command {
+	review := a.review("/v1/presentations/submissions/{id}/review", "submission")
+	return group("presentation", "Manage presentation definitions, requests, and submissions",
+		group("definition", "Manage presentation definitions", append(a.collection("/v1/presentations/definitions", "presentation definition", "id", "name"),
+			a.command(endpoint{
+				use:   "validate",
+				short: "Validate a presentation definition, or expand requirements into one, without creating it",
+				long: `validate reports the issues of the presentation definition of --data, given as "presentation_definition", or
+of the definition that its "requirements" are expanded into.`,
+				method: http.MethodPut,
+				path:   "/v1/presentations/definitions/validation",
+				data:   true,
+			}),
+		)...),
+		group("request", "Manage presentation requests", a.collection("/v1/presentations/requests", "presentation request", "id", "presentationDefinitionId", "issuerId")...),
+		group("submission", "Submit and review presentations",
+			a.command(endpoint{use: "create", short: "Submit a presentation", method: http.MethodPut, path: "/v1/presentations/submissions", data: true}),
+			a.command(endpoint{use: "get <id>", short: "Get a submission", method: http.MethodGet, path: "/v1/presentations/submissions/{id}"}),
+			a.command(endpoint{
+				use:     "list",
+				short:   "List submissions",
+				method:  http.MethodGet,
+				path:    "/v1/presentations/submissions",
+				query:   []string{"filter", "pageSize", "pageToken"},
+				list:    true,
+				columns: []string{"presentation_submission.id", "status", "reason"},
+			}),
+			review,
+		),
+	)
+}
+
+func (a *app) manifestCommand() *cobra.Command {
+	manifests := a.collection("/v1/manifests", "credential manifest", "id", "credential_manifest.name", "credential_manifest.issuer.id")
+	manifests[2] = a.command(endpoint{
+		use:     "list",
+		short:   "List credential manifests",
+		method:  http.MethodGet,
+		path:    "/v1/manifests",
+		query:   []string{"issuer", "schema", "subject", "pageSize", "pageToken"},
+		list:    true,
+		columns: []string{"id", "credential_manifest.name", "credential_manifest.issuer.id"},
+	})
+	manifests = append(manifests, a.command(endpoint{use: "signed <id>", short: "Get a credential manifest signed as a JWT by its issuer", method: http.MethodGet, path: "/v1/manifests/{id}/request"}))
+
+	applications := a.collection("/v1/manifests/applications", "credential application", "id", "manifest_id")
+	applications = append(applications,
+		a.command(endpoint{use: "response <id>", short: "Get the credential response to a reviewed application", method: http.MethodGet, path: "/v1/manifests/applications/{id}/response"}),
+		a.review("/v1/manifests/applications/{id}/review", "application"))
+	responses := a.collection("/v1/manifests/responses", "credential response", "id", "manifest_id", "application_id")[1:]
+
+	cmd := group("manifest", "Manage credential manifests, and the applications for them", manifests...)
+	cmd.AddCommand(
+		group("application", "Submit and review credential applications", applications...),
+		group("request", "Manage credential manifest requests", a.collection("/v1/manifests/requests", "manifest request", "id", "manifestId", "issuerId")...),
+		group("response", "Get the responses to credential applications", responses...),
+	)
+	return cmd
+}
+
+// review creates the command reviewing a submission or application.
+func (a *app) review(path, noun string) *cobra.Command {
+	return a.command(endpoint{
+		use:    "review <id>",
+		short:  "Approve or deny a " + noun,
+		long:   "Approve or deny a " + noun + " with the flags, or with the request in --data.",
+		method: http.MethodPut,
+		path:   path,
+		data:   true,
+		flags: func(cmd *cobra.Command) {
+			cmd.Flags().Bool("approved", false, "approve the "+noun)
+			cmd.Flags().String("reason", "", "reason for the decision")
+		},
+		body: func(cmd *cobra.Command, _ []string) (any, error) {
+			approved, _ := cmd.Flags().GetBool("approved")
+			reason, _ := cmd.Flags().GetString("reason")
+			return map[string]any{"approved": approved, "reason": reason}, nil
+		},
+	})
+}
+
+func (a *app) issuanceTemplateCommand() *cobra.Command {
+	return group("issuance-template", "Manage issuance templates", a.collection("/v1/issuancetemplates", "issuance template", "id", "credentialManifest", "issuer")...)
+}
+
+func (a *app) webhookCommand() *cobra.Command {
+	webhookFlags := func(cmd *cobra.Command) {
+		cmd.Flags().String("url", "", "URL the webhook is sent to")
+	}
+	webhookBody := func(cmd *cobra.Command, args []string) (any, error) {
+		url, _ := cmd.Flags().GetString("url")
+		if url == "" {
+			return nil, errors.New("--url is required")
+		}
+		return map[string]any{"noun": args[0], "verb": args[1], "url": url}, nil
+	}
+	return group("webhook", "Manage webhooks",
+		a.command(endpoint{
+			use:    "create <noun> <verb>",
+			short:  "Send a webhook to a URL when a resource is created or changed",
+			method: http.MethodPut,
+			path:   "/v1/webhooks",
+			args:   cobra.ExactArgs(2),
+			flags:  webhookFlags,
+			body:   webhookBody,
+		}),
+		a.command(endpoint{use: "list", short: "List webhooks", method: http.MethodGet, path: "/v1/webhooks", query: pageQuery, list: true, columns: []string{"webhook.noun", "webhook.verb", "webhook.urls"}}),
+		a.command(endpoint{use: "get <noun> <verb>", short: "Get the webhook of a noun and verb", method: http.MethodGet, path: "/v1/webhooks/{noun}/{verb}"}),
+		a.command(endpoint{use: "delete <noun> <verb>", short: "Stop sending a webhook to a URL", method: http.MethodDelete, path: "/v1/webhooks/{noun}/{verb}", flags: webhookFlags, body: webhookBody}),
+		a.command(endpoint{use: "nouns", short: "List the nouns webhooks can be sent for", method: http.MethodGet, path: "/v1/webhooks/nouns"}),
+		a.command(endpoint{use: "verbs", short: "List the verbs webhooks can be sent for", method: http.MethodGet, path: "/v1/webhooks/verbs"}),
+	)
+}
+
+func (a *app) challengeCommand() *cobra.Command {
+	return group("challenge", "Issue challenges for credential applications and presentation submissions to respond to",
+		a.command(endpoint{
+			use:    "create <definition|manifest> <id>",
+			short:  "Issue a challenge bound to a presentation definition or credential manifest",
+			method: http.MethodPut,
+			path:   "/v1/challenges",
+			args:   cobra.ExactArgs(2),
+			body: func(_ *cobra.Command, args []string) (any, error) {
+				return map[string]any{"target": args[0], "targetId": args[1]}, nil
+			},
+		}),
+		a.command(endpoint{use: "get <nonce>", short: "Get a challenge, and whether it was responded to", method: http.MethodGet, path: "/v1/challenges/{nonce}"}),
+	)
+}
+
+func (a *app) trustCommand() *cobra.Command {
+	trustedForFlags := func(cmd *cobra.Command) {
+		cmd.Flags().String("type", "", "type of credentials the issuers are trusted for")
+		cmd.Flags().String("schema", "", "ID of the schema of credentials the issuers are trusted for")
+	}
+	trustedFor := func(cmd *cobra.Command, body map[string]any) map[string]any {
+		if credentialType, _ := cmd.Flags().GetString("type"); credentialType != "" {
+			body["credentialType"] = credentialType
+		}
+		if schemaID, _ := cmd.Flags().GetString("schema"); schemaID != "" {
+			body["schemaId"] = schemaID
+		}
+		return body
+	}
+	return group("trust", "Manage the issuers trusted for credential types and schemas",
+		group("issuer", "Manage trusted issuers",
+			a.command(endpoint{
+				use:    "create <did>",
+				short:  "Trust an issuer for a credential type or schema",
+				method: http.MethodPut,
+				path:   "/v1/trust/issuers",
+				args:   cobra.ExactArgs(1),
+				flags:  trustedForFlags,
+				body: func(cmd *cobra.Command, args []string) (any, error) {
+					return trustedFor(cmd, map[string]any{"issuer": args[0]}), nil
+				},
+			}),
+			a.command(endpoint{use: "get <id>", short: "Get a trusted issuer", method: http.MethodGet, path: "/v1/trust/issuers/{id}"}),
+			a.command(endpoint{use: "list", short: "List trusted issuers", method: http.MethodGet, path: "/v1/trust/issuers", query: []string{"issuer", "credentialType", "schemaId", "source"}, list: true, columns: []string{"id", "issuer", "credentialType", "schemaId", "source"}}),
+			a.command(endpoint{use: "delete <id>", short: "Stop trusting an issuer", method: http.MethodDelete, path: "/v1/trust/issuers/{id}"}),
+		),
+		a.command(endpoint{
+			use:    "import <ebsi|train> <source> <file>",
+			short:  "Trust the issuers of a trust list, replacing those imported from its source before",
+			long:   "Trust the issuers of the trust list in a file, or in stdin when the file is -, replacing those imported from the same source before. Issuers the list doesn't say the types and schemas of are trusted for --type or --schema.",
+			method: http.MethodPut,
+			path:   "/v1/trust/imports",
+			args:   cobra.ExactArgs(3),
+			flags:  trustedForFlags,
+			body: func(cmd *cobra.Command, args []string) (any, error) {
+				file := args[2]
+				if file != "-" {
+					file = "@" + file
+				}
+				trustList, err := a.readData(file)
+				if err != nil {
+					return nil, err
+				}
+				return trustedFor(cmd, map[string]any{"format": args[0], "source": args[1], "trustList": trustList}), nil
+			},
+		}),
+	)
+}
+
+func (a *app) transparencyCommand() *cobra.Command {
+	return group("transparency", "Audit the transparency log of issued and revoked credentials",
+		a.command(endpoint{use: "head", short: "Get the signed tree head of the log", method: http.MethodGet, path: "/v1/transparency/head"}),
+		a.command(endpoint{use: "entries", short: "List the entries of the log", method: http.MethodGet, path: "/v1/transparency/entries", query: pageQuery, list: true, columns: []string{"index", "operation", "credentialId", "issuer", "timestamp"}}),
+		a.command(endpoint{use: "credential <id>", short: "List the entries of a credential", method: http.MethodGet, path: "/v1/transparency/credentials/{id}"}),
+		a.command(endpoint{use: "inclusion", short: "Get the proof that an entry is included in a tree", method: http.MethodGet, path: "/v1/transparency/proofs/inclusion", query: []string{"index", "treeSize"}}),
+		a.command(endpoint{use: "consistency", short: "Get the proof that a tree extends an earlier one", method: http.MethodGet, path: "/v1/transparency/proofs/consistency", query: []string{"first", "second"}}),
+	)
+}
+
+func (a *app) statsCommand() *cobra.Command {
+	return a.command(endpoint{use: "stats", short: "Get counts of DIDs and applications, and daily counts of credentials issued, revoked, and verified", method: http.MethodGet, path: "/v1/stats", query: []string{"days"}})
+}
+
+func (a *app) didConfigurationCommand() *cobra.Command {
+	return group("did-configuration", "Create and verify DID configuration resources",
+		a.command(endpoint{use: "create", short: "Create a DID configuration resource", method: http.MethodPut, path: "/v1/did-configurations", data: true}),
+		a.command(endpoint{use: "verify", short: "Verify the DID configuration resource of an origin", method: http.MethodPut, path: "/v1/did-configurations/verification", data: true}),
+	)
+}
+
+func (a *app) adminCommand() *cobra.Command {
+	apiKeys := a.collection("/admin/apikeys", "API key", "id", "name", "admin", "scopes", "revoked", "createdAt")
+	apiKeys[3] = a.command(endpoint{use: "revoke <id>", short: "Revoke an API key", method: http.MethodDelete, path: "/admin/apikeys/{id}"})
+	clientKeys := a.collection("/admin/clientkeys", "client key", "id", "name", "subject", "alg", "revoked", "createdAt")
+	clientKeys[0] = a.command(endpoint{use: "register", short: "Register a client key that signs requests", method: http.MethodPut, path: "/admin/clientkeys", data: true})
+	clientKeys[3] = a.command(endpoint{use: "revoke <id>", short: "Revoke a client key", method: http.MethodDelete, path: "/admin/clientkeys/{id}"})
+	auditQuery := []string{"actor", "tenant", "outcome", "resource", "since", "until"}
+	usageQuery := []string{"period", "tenant", "principal", "meter"}
+	return group("admin", "Manage API keys, client keys, roles, and role bindings, read the audit log, usage, signing rates, feature flags, and diagnostics, erase data subjects, lift key freezes, back up keys and DIDs, export and import storage, re-encrypt and unseal the keystore, enforce data retention, review approvals of sensitive operations, anchor state, check expiries, and purge the DID resolution cache, which requires an admin credential",
+		group("apikey", "Manage API keys", apiKeys...),
+		group("clientkey", "Manage the client keys that sign requests", clientKeys...),
+		group("role", "Manage roles", a.collection("/admin/roles", "role", "name")...),
+		group("rolebinding", "Bind roles to token subjects",
+			a.command(endpoint{use: "set", short: "Bind roles to a token subject", method: http.MethodPut, path: "/admin/rolebindings", data: true}),
+			a.command(endpoint{use: "get <subject>", short: "Get the roles bound to a token subject", method: http.MethodGet, path: "/admin/rolebindings/{subject}"}),
+			a.command(endpoint{use: "delete <subject>", short: "Unbind the roles of a token subject", method: http.MethodDelete, path: "/admin/rolebindings/{subject}"}),
+		),
+		group("erasure", "Erase the data held about data subjects",
+			a.command(endpoint{
+				use:    "erase <subject>",
+				short:  "Erase the credentials, applications, and submissions of a data subject",
+				method: http.MethodPut,
+				path:   "/admin/erasures",
+				dryRun: true,
+				args:   cobra.ExactArgs(1),
+				body: func(_ *cobra.Command, args []string) (any, error) {
+					return map[string]any{"subject": args[0]}, nil
+				},
+			}),
+			a.command(endpoint{use: "get <id>", short: "Get the report of an erasure", method: http.MethodGet, path: "/admin/erasures/{id}"}),
+			a.command(endpoint{use: "list", short: "List the reports of erasures", method: http.MethodGet, path: "/admin/erasures", query: pageQuery, list: true, columns: []string{"id", "subject", "createdAt"}}),
+		),
+		group("audit", "Read the audit log",
+			a.command(endpoint{use: "list", short: "List audit events", method: http.MethodGet, path: "/admin/audit", query: append(auditQuery, pageQuery...), list: true, columns: []string{"time", "actor", "method", "resource", "outcome"}}),
+			a.command(endpoint{use: "export", short: "Export audit events as newline delimited JSON", method: http.MethodGet, path: "/admin/audit/export", query: auditQuery}),
+			a.command(endpoint{use: "verify", short: "Verify the hash chain and signatures of audit events", method: http.MethodGet, path: "/admin/audit/verification"}),
+		),
+		group("usage", "Read how much each tenant and API key issued, verified, signed, and stored",
+			a.command(endpoint{use: "list", short: "List usage per month", method: http.MethodGet, path: "/admin/usage", query: append(usageQuery, pageQuery...), list: true, columns: []string{"period", "tenant", "principal", "meter", "count"}}),
+		),
+		group("signing", "Read how much each key and tenant signs, and lift the freezes of keys whose signing spiked, when anomaly detection is enabled",
+			a.command(endpoint{use: "rates", short: "List how much each key and tenant signed in the current window, and their baselines", method: http.MethodGet, path: "/admin/signing/rates", list: true, columns: []string{"scope", "tenant", "keyId", "count", "baseline", "spiked"}}),
+			a.command(endpoint{use: "freezes", short: "List the keys that are frozen because their signing spiked", method: http.MethodGet, path: "/admin/signing/freezes", list: true, columns: []string{"id", "tenant", "keyId", "until"}}),
+			a.command(endpoint{use: "lift <id>", short: "Lift a freeze, so that its key can sign again", method: http.MethodDelete, path: "/admin/signing/freezes/{id}"}),
+		),
+		a.backupCommand(),
+		a.adminStorageCommand(),
+		a.keyStoreCommand(),
+		group("retention", "Run the retention job, and manage the legal holds that keep data from being deleted, when data retention is enabled",
+			a.command(endpoint{use: "run", short: "Delete the applications, responses, and submissions past their retention now", method: http.MethodPut, path: "/admin/retention/runs", dryRun: true}),
+			group("hold", "Manage legal holds",
+				a.command(endpoint{
+					use:    "create <type>",
+					short:  "Hold an object, or every object of a type, so that it isn't deleted until the hold is lifted",
+					method: http.MethodPut,
+					path:   "/admin/retention/holds",
+					args:   cobra.ExactArgs(1),
+					flags: func(cmd *cobra.Command) {
+						cmd.Flags().String("tenant", "", "tenant of the objects to hold, the default tenant when empty")
+						cmd.Flags().String("object-id", "", "ID of the object to hold, every object of the type when empty")
+						cmd.Flags().String("reason", "", "why the objects are held, e.g. the case they're held for")
+					},
+					body: func(cmd *cobra.Command, args []string) (any, error) {
+						tenant, _ := cmd.Flags().GetString("tenant")
+						objectID, _ := cmd.Flags().GetString("object-id")
+						reason, _ := cmd.Flags().GetString("reason")
+						if reason == "" {
+							return nil, errors.New("--reason is required")
+						}
+						return map[string]any{"tenant": tenant, "type": args[0], "objectId": objectID, "reason": reason}, nil
+					},
+				}),
+				a.command(endpoint{use: "list", short: "List the legal holds", method: http.MethodGet, path: "/admin/retention/holds", list: true, columns: []string{"id", "tenant", "type", "objectId", "reason", "createdAt"}}),
+				a.command(endpoint{use: "lift <id>", short: "Lift a legal hold, so that what it held is deleted once it's past its retention", method: http.MethodDelete, path: "/admin/retention/holds/{id}"}),
+			),
+		),
+		group("approval", "Review the approvals of sensitive operations, which a second operator approves before they're handled, when approvals are enabled",
+			a.command(endpoint{use: "list", short: "List approvals, oldest first", method: http.MethodGet, path: "/admin/approvals", query: []string{"status"}, list: true, columns: []string{"id", "method", "uri", "requestedBy", "status", "expiresAt"}}),
+			a.command(endpoint{use: "get <id>", short: "Get an approval", method: http.MethodGet, path: "/admin/approvals/{id}"}),
+			a.command(endpoint{
+				use:    "review <id>",
+				short:  "Approve or reject an approval requested by another operator",
+				method: http.MethodPut,
+				path:   "/admin/approvals/{id}/review",
+				flags: func(cmd *cobra.Command) {
+					cmd.Flags().Bool("approved", false, "approve the operation, so that its requester can make it")
+					cmd.Flags().String("reason", "", "reason for the decision")
+				},
+				body: func(cmd *cobra.Command, _ []string) (any, error) {
+					approved, _ := cmd.Flags().GetBool("approved")
+					reason, _ := cmd.Flags().GetString("reason")
+					return map[string]any{"approved": approved, "reason": reason}, nil
+				},
+			}),
+		),
+		group("anchor", "Anchor the state of credentials, status lists, and the audit log, and verify it, when state anchoring is enabled",
+			a.command(endpoint{use: "create", short: "Anchor the state now, rather than waiting for the next scheduled anchor", method: http.MethodPut, path: "/admin/anchors"}),
+			a.command(endpoint{use: "list", short: "List the anchors that are kept, newest first", method: http.MethodGet, path: "/admin/anchors", list: true, columns: []string{"id", "createdAt", "type", "digest"}}),
+			a.command(endpoint{use: "get <id>", short: "Get an anchor, along with its receipt", method: http.MethodGet, path: "/admin/anchors/{id}"}),
+			a.command(endpoint{use: "verify <id>", short: "Verify an anchor, and list the anchored records that were changed or removed since", method: http.MethodGet, path: "/admin/anchors/{id}/verification"}),
+		),
+		group("expiry", "Check which keys, certificates of did:web hosts, and issued credentials expire soon, when expiry warnings are enabled",
+			a.command(endpoint{use: "list", short: "List what expires within the warning period, soonest first", method: http.MethodGet, path: "/admin/expiries", list: true, columns: []string{"kind", "tenant", "id", "expiresAt"}}),
+			a.command(endpoint{use: "warn", short: "Warn of what expires soon now, rather than waiting for the next scheduled check", method: http.MethodPut, path: "/admin/expiries/warnings"}),
+			a.command(endpoint{use: "sweep", short: "Record the credentials that expired as expired now, rather than waiting for the next scheduled check", method: http.MethodPut, path: "/admin/expiries/sweeps"}),
+		),
+		group("resolution", "Read the DID resolution cache, and purge it, when the resolution cache is enabled",
+			a.command(endpoint{use: "stats", short: "Get how many resolutions are cached, and how many were served from the cache", method: http.MethodGet, path: "/admin/resolution/cache"}),
+			a.command(endpoint{use: "purge", short: "Remove every cached resolution, so that every DID is resolved again", method: http.MethodDelete, path: "/admin/resolution/cache"}),
+			a.command(endpoint{use: "invalidate <id>", short: "Remove the cached resolution of a DID, so that it's resolved again", method: http.MethodDelete, path: "/admin/resolution/cache/{id}"}),
+		),
+		group("feature", "Read the feature flags of experimental capabilities",
+			a.command(endpoint{use: "list", short: "List feature flags and whom they're enabled for", method: http.MethodGet, path: "/admin/features", list: true, columns: []string{"name", "enabled", "tenants"}}),
+		),
+		group("debug", "Diagnose the running service, when enable_debug is set",
+			a.command(endpoint{use: "runtime", short: "Get the goroutines, memory use, and garbage collections of the service", method: http.MethodGet, path: "/admin/debug/runtime"}),
+			a.command(endpoint{use: "storage", short: "Get the stats of the storage provider", method: http.MethodGet, path: "/admin/debug/storage"}),
+			a.command(endpoint{use: "goroutines", short: "Dump the stacks of every goroutine", method: http.MethodGet, path: "/admin/debug/goroutines"}),
+		),
+	)
+}
+
+func (a *app) backupCommand() *cobra.Command {
+	keygen := &cobra.Command{
+		Use:   "keygen",
+		Short: "Create the keyset backups are encrypted under, without calling the service",
+		Long: `keygen writes a new private keyset, and its public keyset, as JSON. The service is configured with the public
+keyset to encrypt backups. The private keyset decrypts them, so keep it offline.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			privatePath, _ := cmd.Flags().GetString("private-keyset")
+			publicPath, _ := cmd.Flags().GetString("public-keyset")
+			if privatePath == "" || publicPath == "" {
+				return errors.New("--private-keyset and --public-keyset are required")
+			}
+			privateKeyset, publicKeyset, err := encryption.GenerateHybridKeyset()
+			if err != nil {
+				return err
+			}
+			if err = writeNewFile(privatePath, privateKeyset); err != nil {
+				return err
+			}
+			if err = writeNewFile(publicPath, publicKeyset); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(a.out, "wrote the private keyset to %s, and the public keyset to %s\n", privatePath, publicPath)
+			return err
+		},
+	}
+	keygen.Flags().String("private-keyset", "", "file to write the private keyset to")
+	keygen.Flags().String("public-keyset", "", "file to write the public keyset to")
+
+	return group("backup", "Back up, and restore, the keys and DIDs of the service, when backups are enabled",
+		a.command(endpoint{use: "create", short: "Back up the keys and DIDs now", method: http.MethodPut, path: "/admin/backups"}),
+		a.command(endpoint{use: "list", short: "List the backups at the destination", method: http.MethodGet, path: "/admin/backups"}),
+		a.command(endpoint{use: "download <name>", short: "Download a backup, encrypted", method: http.MethodGet, path: "/admin/backups/{name}"}),
+		a.command(endpoint{
+			use:   "restore <file>",
+			short: "Decrypt a backup with the private keyset, and restore its keys and DIDs",
+			long: `restore decrypts a downloaded backup locally with --private-keyset, so the private keyset never leaves the
+machine, and sends the keys and DIDs to the service. Keys and DIDs the service has already are kept as they are.`,
+			method: http.MethodPut,
+			path:   "/admin/backups/restore",
+			dryRun: true,
+			args:   cobra.ExactArgs(1),
+			flags: func(cmd *cobra.Command) {
+				cmd.Flags().String("private-keyset", "", "file with the private keyset the backup was encrypted to")
+			},
+			body: func(cmd *cobra.Command, args []string) (any, error) {
+				privateKeysetPath, _ := cmd.Flags().GetString("private-keyset")
+				if privateKeysetPath == "" {
+					return nil, errors.New("--private-keyset is required")
+				}
+				return decryptBackup(cmd.Context(), args[0], privateKeysetPath)
+			},
+		}),
+		keygen,
+	)
+}
+
+// decryptBackup decrypts the backup in a file with the private keyset in another.
+func decryptBackup(ctx context.Context, backupPath, privateKeysetPath string) (json.RawMessage, error) {
+	privateKeyset, err := os.ReadFile(privateKeysetPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading private keyset")
+	}
+	decrypter, err := encryption.NewHybridDecrypter(privateKeyset)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := os.ReadFile(backupPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading backup")
+	}
+	backup, err := decrypter.Decrypt(ctx, encrypted, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting backup, was it encrypted to this keyset?")
+	}
+	return backup, nil
+}
+
+func (a *app) keyStoreCommand() *cobra.Command {
+	return group("keystore", "Re-encrypt the keys of the keystore, and create the shares of its service key, and unseal it with them, when service key shares are enabled",
+		a.command(endpoint{
+			use:   "reencrypt",
+			short: "Re-encrypt the keys that were stored before each key was encrypted with a data key",
+			long: `reencrypt encrypts each key of the default tenant, and of each --tenant, that wasn't encrypted with a data key
+of its own yet, with one. Keys that were stored unencrypted, before encryption of the keystore was enabled, are only
+encrypted with --allow-plaintext. It's safe to run again.`,
+			method: http.MethodPut,
+			path:   "/admin/keystore/reencrypt",
+			flags: func(cmd *cobra.Command) {
+				cmd.Flags().StringArray("tenant", nil, "tenant whose keys are re-encrypted too, once for each tenant")
+				cmd.Flags().Bool("allow-plaintext", false, "encrypt keys that were stored unencrypted")
+			},
+			body: func(cmd *cobra.Command, _ []string) (any, error) {
+				tenants, _ := cmd.Flags().GetStringArray("tenant")
+				allowPlaintext, _ := cmd.Flags().GetBool("allow-plaintext")
+				return map[string]any{"tenants": tenants, "allowPlaintext": allowPlaintext}, nil
+			},
+		}),
+		a.command(endpoint{use: "status", short: "Get whether the keystore is sealed, and how many shares were submitted", method: http.MethodGet, path: "/admin/keystore/seal"}),
+		group("shares", "Manage the shares of the service key",
+			a.command(endpoint{
+				use:   "create",
+				short: "Split the service key into shares, each encrypted to the public keyset of an operator",
+				long: `create splits the service key into a share for each --public-keyset, in order, and prints the shares, each
+encrypted to its keyset. Operators create their keysets with "ssi admin backup keygen".`,
+				method: http.MethodPut,
+				path:   "/admin/keystore/shares",
+				flags: func(cmd *cobra.Command) {
+					cmd.Flags().StringArray("public-keyset", nil, "file with the public keyset of an operator, once for each share")
+				},
+				body: func(cmd *cobra.Command, _ []string) (any, error) {
+					paths, _ := cmd.Flags().GetStringArray("public-keyset")
+					if len(paths) == 0 {
+						return nil, errors.New("--public-keyset is required")
+					}
+					publicKeysets := make([]json.RawMessage, 0, len(paths))
+					for _, path := range paths {
+						publicKeyset, err := os.ReadFile(path)
+						if err != nil {
+							return nil, errors.Wrap(err, "reading public keyset")
+						}
+						publicKeysets = append(publicKeysets, publicKeyset)
+					}
+					return map[string]any{"publicKeysets": publicKeysets}, nil
+				},
+			}),
+		),
+		a.command(endpoint{
+			use:   "unseal <share>",
+			short: "Decrypt a share with the private keyset, and submit it",
+			long: `unseal decrypts a share, as printed by "ssi admin keystore shares create", locally with --private-keyset, so the
+private keyset never leaves the machine, and submits it to the service.`,
+			method: http.MethodPut,
+			path:   "/admin/keystore/unseal",
+			args:   cobra.ExactArgs(1),
+			flags: func(cmd *cobra.Command) {
+				cmd.Flags().String("private-keyset", "", "file with the private keyset the share was encrypted to")
+			},
+			body: func(cmd *cobra.Command, args []string) (any, error) {
+				privateKeysetPath, _ := cmd.Flags().GetString("private-keyset")
+				if privateKeysetPath == "" {
+					return nil, errors.New("--private-keyset is required")
+				}
+				share, err := decryptShare(cmd.Context(), args[0], privateKeysetPath)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"share": share}, nil
+			},
+		}),
+	)
+}
+
+// decryptShare decrypts a share of the service key, base64 encoded, with the private keyset it was encrypted to.
+func decryptShare(ctx context.Context, encoded, privateKeysetPath string) (string, error) {
+	privateKeyset, err := os.ReadFile(privateKeysetPath)
+	if err != nil {
+		return "", errors.Wrap(err, "reading private keyset")
+	}
+	decrypter, err := encryption.NewHybridDecrypter(privateKeyset)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding share")
+	}
+	share, err := decrypter.Decrypt(ctx, encrypted, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "decrypting share, was it encrypted to this keyset?")
+	}
+	return string(share), nil
+}
+
+// writeNewFile writes data to a file only the user can read, failing when the file exists.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "creating %s", path)
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	return errors.Wrapf(f.Close(), "closing %s", path)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+func (a *app) configCommand() *cobra.Command {
+	validate := &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Validate a config file of the service, and print the config it loads, without calling the service",
+		Long: `validate loads a TOML or YAML config file the way the service does, applying defaults and the environment
+variables that override its values, like SSI_SERVER_API_HOST, and prints the resulting config as TOML with its secrets
+masked. The path defaults to the CONFIG_PATH environment variable, and then to config/dev.toml.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := defaultConfigPath()
+			if len(args) > 0 {
+				configPath = args[0]
+			}
+			cfg, err := loadConfigFile(configPath)
+			if err != nil {
+				return err
+			}
+			return cfg.Print(a.out)
+		},
+	}
+	return group("config", "Check the config of the service, and reload it",
+		validate,
+		a.command(endpoint{
+			use:    "reload",
+			short:  "Reload the config file of the service, applying the settings that can change while it runs, which requires an admin credential",
+			method: http.MethodPut,
+			path:   "/admin/config/reload",
+		}),
+	)
+}
+
+// defaultConfigPath is the config file the service loads, which is set by the CONFIG_PATH environment variable.
+func defaultConfigPath() string {
+	return envOr(config.ConfigPath.String(), config.DefaultConfigPath)
+}
+
+// loadConfigFile loads a TOML or YAML config file the way the service does.
+func loadConfigFile(configPath string) (*config.SSIServiceConfig, error) {
+	dir, file := path.Split(configPath)
+	if dir == "" {
+		dir = "."
+	}
+	cfg, err := config.LoadConfig(file, os.DirFS(dir))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config %s", configPath)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/tbd54566975/ssi-service/pkg/client"
+)
+
+// pathParam matches the parameters of an endpoint's path. Parameters starting with * may contain slashes.
+var pathParam = regexp.MustCompile(`\{(\*?)[^}]+}`)
+
+// endpoint describes a command that calls one route of the API. Its arguments fill the parameters of the path, in
+// order, e.g. /v1/schemas/{id}.
+type endpoint struct {
+	use   string
+	short string
+	long  string
+
+	method string
+	path   string
+
+	// query lists flags that are sent as query parameters of the same name, when set.
+	query []string
+	// data adds the --data flag, which is sent as the body.
+	data bool
+	// dryRun adds the --dry-run flag, which asks the service to report what the command would change, without
+	// changing it.
+	dryRun bool
+	// flags adds the flags that body reads.
+	flags func(cmd *cobra.Command)
+	// body builds the body from the command's flags. When data is set too, --data takes precedence.
+	body func(cmd *cobra.Command, args []string) (any, error)
+	// args checks the arguments of commands that take more than the parameters of their path.
+	args cobra.PositionalArgs
+	// list is set for commands that list items, which table output prints a row for each of.
+	list bool
+	// columns are the fields shown by table output, as dotted paths into each listed item.
+	columns []string
+}
+
+func (a *app) command(e endpoint) *cobra.Command {
+	params := pathParam.FindAllString(e.path, -1)
+	var data string
+	cmd := &cobra.Command{
+		Use:   e.use,
+		Short: e.short,
+		Long:  e.long,
+		Args:  e.args,
+	}
+	if cmd.Args == nil {
+		cmd.Args = cobra.ExactArgs(len(params))
+	}
+	for _, q := range e.query {
+		cmd.Flags().String(q, "", "filter by "+q)
+	}
+	if e.dryRun {
+		cmd.Flags().Bool("dry-run", false, "report what would change, without changing it")
+	}
+	if e.data {
+		cmd.Flags().StringVarP(&data, "data", "d", "", "JSON body of the request, @file to read it from a file, or - to read it from stdin")
+	}
+	if e.flags != nil {
+		e.flags(cmd)
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		req := client.Request{Method: e.method, Path: fillPath(e.path, args), Query: url.Values{}}
+		for _, q := range e.query {
+			if value, _ := cmd.Flags().GetString(q); value != "" {
+				req.Query.Set(q, value)
+			}
+		}
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); e.dryRun && dryRun {
+			req.Query.Set("dryRun", "true")
+		}
+
+		switch {
+		case data != "":
+			body, err := a.readData(data)
+			if err != nil {
+				return err
+			}
+			req.Body = body
+		case e.body != nil:
+			body, err := e.body(cmd, args)
+			if err != nil {
+				return err
+			}
+			req.Body = body
+		case e.data:
+			return errors.New("a request body is required, set it with --data")
+		}
+
+		if e.method == http.MethodGet && a.fields != "" {
+			req.Query.Set("fields", a.fields)
+		}
+		if e.method == http.MethodPut || e.method == http.MethodPost {
+			req.IdempotencyKey = a.idempotencyKey
+			req.Async = a.async
+		}
+		return a.call(cmd.Context(), req, e.columns, e.list)
+	}
+	return cmd
+}
+
+// group creates a command that only holds subcommands.
+func group(use, short string, commands ...*cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{Use: use, Short: short}
+	cmd.AddCommand(commands...)
+	return cmd
+}
+
+func (a *app) call(ctx context.Context, req client.Request, columns []string, list bool) error {
+	c, err := a.newClient()
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	// async responses are operations, not what the command usually responds with
+	if req.Async && resp.StatusCode == http.StatusAccepted {
+		columns, list = nil, false
+	}
+	return a.print(resp.Body, columns, list)
+}
+
+// fillPath replaces the parameters of a path with args, escaping them unless the parameter may contain slashes.
+func fillPath(path string, args []string) string {
+	i := 0
+	return pathParam.ReplaceAllStringFunc(path, func(param string) string {
+		arg := args[i]
+		i++
+		if strings.HasPrefix(param, "{*") {
+			return strings.TrimPrefix(arg, "/")
+		}
+		return url.PathEscape(arg)
+	})
+}
+
+// readData reads the value of a --data flag, which is JSON, @file, or - for stdin.
+func (a *app) readData(data string) (json.RawMessage, error) {
+	var raw []byte
+	var err error
+	switch {
+	case data == "-":
+		raw, err = io.ReadAll(a.in)
+	case strings.HasPrefix(data, "@"):
+		raw, err = os.ReadFile(strings.TrimPrefix(data, "@"))
+	default:
+		raw = []byte(data)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading request body")
+	}
+	if !json.Valid(raw) {
+		return nil, errors.New("request body is not valid JSON")
+	}
+	return raw, nil
+}
+
+// jsonFlag parses a flag holding a JSON value, returning nil when it isn't set.
+func jsonFlag(cmd *cobra.Command, name string) (any, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return nil, nil
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return nil, errors.Wrapf(err, "parsing --%s as JSON", name)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,14 @@
+// Command ssi is a command line client for the HTTP API of the SSI Service, for scripting and operations.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := newRootCommand(os.Stdin, os.Stdout).Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
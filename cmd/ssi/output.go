@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// print writes a response body in the selected output format. Table output of list responses has a row per item.
+func (a *app) print(body []byte, columns []string, list bool) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	switch a.output {
+	case outputJSON:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			_, err = a.out.Write(body)
+			return err
+		}
+		indented.WriteByte('\n')
+		_, err := a.out.Write(indented.Bytes())
+		return err
+	case outputTable:
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return errors.Wrap(err, "decoding response")
+		}
+		return a.printTable(value, columns, list)
+	default:
+		return errors.Errorf("unknown output format: %s", a.output)
+	}
+}
+
+// printTable prints a list of items as a table with a row per item. Responses that wrap the list in an object, like
+// {"credentials": [...]}, are unwrapped. Anything else is printed as a row per field.
+func (a *app) printTable(value any, columns []string, list bool) error {
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	var items []any
+	ok := false
+	if list {
+		items, ok = listItems(value)
+	}
+	if !ok {
+		object, isObject := value.(map[string]any)
+		if !isObject {
+			fmt.Fprintln(w, cell(value))
+			return w.Flush()
+		}
+		fields := columns
+		if len(fields) == 0 {
+			fields = scalarFields(object)
+		}
+		for _, field := range fields {
+			fmt.Fprintf(w, "%s\t%s\n", strings.ToUpper(field), cell(lookup(object, field)))
+		}
+		return w.Flush()
+	}
+
+	if len(columns) == 0 && len(items) > 0 {
+		if object, isObject := items[0].(map[string]any); isObject {
+			columns = scalarFields(object)
+		}
+	}
+	if len(columns) == 0 {
+		for _, item := range items {
+			fmt.Fprintln(w, cell(item))
+		}
+		return w.Flush()
+	}
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, strings.ToUpper(column))
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, item := range items {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			row = append(row, cell(lookup(item, column)))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// listItems returns the items of a list response, which is either a list, or an object with a single list field.
+func listItems(value any) ([]any, bool) {
+	switch v := value.(type) {
+	case []any:
+		return v, true
+	case map[string]any:
+		var items []any
+		found := false
+		for _, field := range v {
+			if list, ok := field.([]any); ok {
+				if found {
+					return nil, false
+				}
+				items, found = list, true
+			}
+		}
+		return items, found
+	}
+	return nil, false
+}
+
+// lookup returns the value at a dotted path in value.
+func lookup(value any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// scalarFields returns the sorted names of the fields of object that aren't objects or lists.
+func scalarFields(object map[string]any) []string {
+	var fields []string
+	for field, value := range object {
+		switch value.(type) {
+		case map[string]any, []any:
+		default:
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// cell formats a value for a table, encoding objects and lists as JSON.
+func cell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/tbd54566975/ssi-service/pkg/client"
+)
+
+const (
+	defaultEndpoint = "http://localhost:3000"
+
+	endpointEnv = "SSI_ENDPOINT"
+	apiKeyEnv   = "SSI_API_KEY"
+	tokenEnv    = "SSI_TOKEN"
+
+	signingKeyEnv   = "SSI_SIGNING_KEY"
+	signingKeyIDEnv = "SSI_SIGNING_KEY_ID"
+)
+
+// app holds the global flags, and what commands read from and write to.
+type app struct {
+	in  io.Reader
+	out io.Writer
+
+	endpoint       string
+	apiKey         string
+	token          string
+	signingKey     string
+	signingKeyID   string
+	output         string
+	timeout        time.Duration
+	idempotencyKey string
+	async          bool
+	fields         string
+}
+
+func newRootCommand(in io.Reader, out io.Writer) *cobra.Command {
+	a := &app{in: in, out: out}
+	root := &cobra.Command{
+		Use:   "ssi",
+		Short: "Command line client for the SSI Service",
+		Long: `ssi calls the HTTP API of the SSI Service. Requests are authenticated with an API key or an OAuth2 access
+token, when one is set. Responses are printed as JSON, or as tables with --output table.
+
+Requests that change something are signed with a client key, when one is set, for services that require request
+signatures.
+
+The endpoint, API key, and token default to the SSI_ENDPOINT, SSI_API_KEY, and SSI_TOKEN environment variables, and
+the signing key and its ID to SSI_SIGNING_KEY and SSI_SIGNING_KEY_ID.
+
+The storage commands open the storage of a stopped service directly, instead of calling it.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetIn(in)
+	root.SetOut(out)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.endpoint, "endpoint", envOr(endpointEnv, defaultEndpoint), "URL of the SSI Service")
+	flags.StringVar(&a.apiKey, "api-key", os.Getenv(apiKeyEnv), "API key to authenticate with")
+	flags.StringVar(&a.token, "token", os.Getenv(tokenEnv), "OAuth2 access token to authenticate with")
+	flags.StringVar(&a.signingKey, "signing-key", os.Getenv(signingKeyEnv), "file with the private JWK of a client key to sign requests that change something with")
+	flags.StringVar(&a.signingKeyID, "signing-key-id", os.Getenv(signingKeyIDEnv), "ID the signing key was registered with")
+	flags.StringVarP(&a.output, "output", "o", outputJSON, "output format, json or table")
+	flags.DurationVar(&a.timeout, "timeout", 30*time.Second, "how long to wait for a response")
+	flags.StringVar(&a.idempotencyKey, "idempotency-key", "", "makes retries of a create request safe")
+	flags.BoolVar(&a.async, "async", false, "run long requests in the background, printing the operation to poll")
+	flags.StringVar(&a.fields, "fields", "", "comma separated fields to limit responses of get and list commands to, e.g. credentials.id")
+
+	root.AddCommand(
+		a.healthCommand(),
+		a.readinessCommand(),
+		a.keyCommand(),
+		a.didCommand(),
+		a.schemaCommand(),
+		a.credentialCommand(),
+		a.operationCommand(),
+		a.batchCommand(),
+		a.presentationCommand(),
+		a.manifestCommand(),
+		a.issuanceTemplateCommand(),
+		a.webhookCommand(),
+		a.challengeCommand(),
+		a.trustCommand(),
+		a.transparencyCommand(),
+		a.statsCommand(),
+		a.didConfigurationCommand(),
+		a.adminCommand(),
+		a.configCommand(),
+		a.storageCommand(),
+	)
+	return root
+}
+
+func (a *app) newClient() (*client.Client, error) {
+	opts := []client.Option{
+		client.WithAPIKey(a.apiKey),
+		client.WithBearerToken(a.token),
+		client.WithHTTPClient(&http.Client{Timeout: a.timeout}),
+		client.WithUserAgent("ssi-cli"),
+	}
+	if a.signingKey != "" {
+		if a.signingKeyID == "" {
+			return nil, errors.New("--signing-key-id is required with --signing-key")
+		}
+		key, err := readSigningKey(a.signingKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithSigningKey(a.signingKeyID, key))
+	}
+	return client.New(a.endpoint, opts...)
+}
+
+// readSigningKey reads a private key from a file with its JWK.
+func readSigningKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading signing key")
+	}
+	var keyJWK jwx.PrivateKeyJWK
+	if err = json.Unmarshal(data, &keyJWK); err != nil {
+		return nil, errors.Wrap(err, "parsing signing key")
+	}
+	key, err := keyJWK.ToPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing signing key")
+	}
+	// some keys are parsed to values, whose methods aren't those of a crypto.Signer
+	switch k := key.(type) {
+	case ecdsa.PrivateKey:
+		return &k, nil
+	case rsa.PrivateKey:
+		return &k, nil
+	case crypto.Signer:
+		return k, nil
+	}
+	return nil, errors.Errorf("signing key of type %T can't sign requests", key)
+}
+
+func (a *app) healthCommand() *cobra.Command {
+	return a.command(endpoint{use: "health", short: "Check that the service is running", method: http.MethodGet, path: "/health"})
+}
+
+func (a *app) readinessCommand() *cobra.Command {
+	return a.command(endpoint{use: "readiness", short: "Check that the service and its dependencies are ready", method: http.MethodGet, path: "/readiness"})
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
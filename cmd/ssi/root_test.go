@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestCommands(t *testing.T) {
+	type call struct {
+		method, uri, body string
+	}
+	var calls []call
+	responses := map[string]string{
+		"GET /v1/credentials": `{"credentials":[{"id":"1","credential":{"issuer":"did:key:a","credentialSubject":{"id":"did:key:b"}},"revoked":true}]}`,
+		"GET /v1/schemas/1":   `{"id":"1","type":"JsonSchema2023","schema":{"name":"Name"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, call{method: r.Method, uri: r.URL.RequestURI(), body: string(body)})
+		if response, ok := responses[r.Method+" "+r.URL.Path]; ok {
+			_, _ = w.Write([]byte(response))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	run := func(t *testing.T, stdin string, args ...string) string {
+		calls = nil
+		var out bytes.Buffer
+		cmd := newRootCommand(strings.NewReader(stdin), &out)
+		cmd.SetArgs(append([]string{"--endpoint", server.URL}, args...))
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+
+	t.Run("builds requests from flags", func(tt *testing.T) {
+		run(tt, "", "did", "create", "key", "--key-type", "Ed25519")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/v1/dids/key", body: `{"keyType":"Ed25519"}`}}, calls)
+
+		run(tt, "", "credential", "issue", "--issuer", "did:key:a", "--verification-method", "did:key:a#a", "--subject", "did:key:b", "--claims", `{"name":"Alice"}`)
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/v1/credentials", calls[0].uri)
+		assert.JSONEq(tt, `{"issuer":"did:key:a","verificationMethodId":"did:key:a#a","subject":"did:key:b","data":{"name":"Alice"},"schemaId":"","expiry":"","revocable":false,"suspendable":false}`, calls[0].body)
+
+		run(tt, "", "manifest", "application", "review", "app-1", "--approved", "--reason", "looks good")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/v1/manifests/applications/app-1/review", calls[0].uri)
+		assert.JSONEq(tt, `{"approved":true,"reason":"looks good"}`, calls[0].body)
+		run(tt, "", "manifest", "application", "response", "app-1")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/v1/manifests/applications/app-1/response"}}, calls)
+		run(tt, "", "manifest", "signed", "manifest-1")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/v1/manifests/manifest-1/request"}}, calls)
+
+		run(tt, "", "admin", "erasure", "erase", "did:key:b")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/erasures", body: `{"subject":"did:key:b"}`}}, calls)
+		run(tt, "", "admin", "erasure", "erase", "did:key:b", "--dry-run")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/erasures?dryRun=true", body: `{"subject":"did:key:b"}`}}, calls)
+
+		run(tt, "", "admin", "debug", "storage")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/admin/debug/storage"}}, calls)
+
+		run(tt, "", "admin", "signing", "lift", "freeze-1")
+		assert.Equal(tt, []call{{method: http.MethodDelete, uri: "/admin/signing/freezes/freeze-1"}}, calls)
+
+		run(tt, "", "admin", "retention", "hold", "create", "submission", "--tenant", "acme", "--reason", "case 42")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/admin/retention/holds", calls[0].uri)
+		assert.JSONEq(tt, `{"tenant":"acme","type":"submission","objectId":"","reason":"case 42"}`, calls[0].body)
+
+		run(tt, "", "admin", "approval", "review", "approval-1", "--approved", "--reason", "confirmed")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/admin/approvals/approval-1/review", calls[0].uri)
+		assert.JSONEq(tt, `{"approved":true,"reason":"confirmed"}`, calls[0].body)
+
+		run(tt, "", "admin", "anchor", "verify", "anchor-1")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/admin/anchors/anchor-1/verification"}}, calls)
+
+		run(tt, "", "admin", "audit", "verify")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/admin/audit/verification"}}, calls)
+
+		run(tt, "", "admin", "expiry", "warn")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/expiries/warnings"}}, calls)
+		run(tt, "", "admin", "expiry", "sweep")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/expiries/sweeps"}}, calls)
+
+		run(tt, "", "admin", "storage", "export", "--namespace", "schema", "--namespace", "did-key")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/admin/storage/export?namespace=schema&namespace=did-key"}}, calls)
+		export := `{"format":"ssi-service-storage-export","version":1}` + "\n"
+		run(tt, export, "admin", "storage", "import", "-", "--overwrite", "--dry-run")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/storage/import?dryRun=true&overwrite=true", body: export}}, calls)
+
+		run(tt, "", "admin", "storage", "encrypt", "--dry-run")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/storage/encrypt?dryRun=true"}}, calls)
+	})
+
+	t.Run("sends data as the body", func(tt *testing.T) {
+		run(tt, `{"name":"from stdin"}`, "schema", "create", "--data", "-")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/v1/schemas", body: `{"name":"from stdin"}`}}, calls)
+
+		run(tt, `{"requirements":[{"claims":[{"claim":"age"}]}]}`, "presentation", "definition", "validate", "--data", "-")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/v1/presentations/definitions/validation", body: `{"requirements":[{"claims":[{"claim":"age"}]}]}`}}, calls)
+
+		run(tt, "", "webhook", "delete", "Credential", "Create", "--url", "https://example.com")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, http.MethodDelete, calls[0].method)
+		assert.Equal(tt, "/v1/webhooks/Credential/Create", calls[0].uri)
+		assert.JSONEq(tt, `{"noun":"Credential","verb":"Create","url":"https://example.com"}`, calls[0].body)
+
+		run(tt, "", "challenge", "create", "definition", "123")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/v1/challenges", calls[0].uri)
+		assert.JSONEq(tt, `{"target":"definition","targetId":"123"}`, calls[0].body)
+
+		run(tt, "", "trust", "issuer", "create", "did:example:dmv", "--type", "DriversLicense")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/v1/trust/issuers", calls[0].uri)
+		assert.JSONEq(tt, `{"issuer":"did:example:dmv","credentialType":"DriversLicense"}`, calls[0].body)
+
+		run(tt, `{"TrustServiceStatusList":{}}`, "trust", "import", "train", "https://example.com/list", "-", "--schema", "schema")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/v1/trust/imports", calls[0].uri)
+		assert.JSONEq(tt, `{"format":"train","source":"https://example.com/list","trustList":{"TrustServiceStatusList":{}},"schemaId":"schema"}`, calls[0].body)
+	})
+
+	t.Run("fills paths and queries", func(tt *testing.T) {
+		run(tt, "", "operation", "get", "credentials/batch/123")
+		assert.Equal(tt, "/v1/operations/credentials/batch/123", calls[0].uri)
+
+		run(tt, "", "credential", "list", "--issuer", "did:key:a")
+		assert.Equal(tt, "/v1/credentials?issuer=did%3Akey%3Aa", calls[0].uri)
+
+		run(tt, "", "credential", "list", "--fields", "credentials.id,credentials.revoked")
+		assert.Equal(tt, "/v1/credentials?fields=credentials.id%2Ccredentials.revoked", calls[0].uri)
+
+		run(tt, "", "schema", "list", "--pageSize", "10", "--pageToken", "abc")
+		assert.Equal(tt, "/v1/schemas?pageSize=10&pageToken=abc", calls[0].uri)
+
+		run(tt, "", "schema", "versions", "1")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/v1/schemas/1/versions"}}, calls)
+
+		run(tt, "", "admin", "usage", "list", "--period", "2023-10", "--meter", "issuance")
+		assert.Equal(tt, "/admin/usage?meter=issuance&period=2023-10", calls[0].uri)
+
+		run(tt, "", "transparency", "inclusion", "--index", "3", "--treeSize", "8")
+		assert.Equal(tt, "/v1/transparency/proofs/inclusion?index=3&treeSize=8", calls[0].uri)
+
+		run(tt, "", "stats", "--days", "7")
+		assert.Equal(tt, "/v1/stats?days=7", calls[0].uri)
+	})
+
+	t.Run("prints tables", func(tt *testing.T) {
+		out := run(tt, "", "credential", "list", "-o", "table")
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(tt, lines, 2)
+		assert.Equal(tt, []string{"ID", "CREDENTIAL.ISSUER", "CREDENTIAL.CREDENTIALSUBJECT.ID", "REVOKED", "SUSPENDED"}, strings.Fields(lines[0]))
+		assert.Equal(tt, []string{"1", "did:key:a", "did:key:b", "true"}, strings.Fields(lines[1]))
+
+		out = run(tt, "", "schema", "get", "1", "-o", "table")
+		assert.Equal(tt, []string{"ID", "1", "TYPE", "JsonSchema2023"}, strings.Fields(out))
+	})
+
+	t.Run("prints json", func(tt *testing.T) {
+		out := run(tt, "", "schema", "get", "1")
+		assert.JSONEq(tt, responses["GET /v1/schemas/1"], out)
+		assert.Contains(tt, out, "\n  \"id\"")
+	})
+
+	t.Run("decrypts backups before restoring them", func(tt *testing.T) {
+		dir := tt.TempDir()
+		privateKeyset, publicKeyset := filepath.Join(dir, "private.json"), filepath.Join(dir, "public.json")
+		run(tt, "", "admin", "backup", "keygen", "--private-keyset", privateKeyset, "--public-keyset", publicKeyset)
+		assert.Empty(tt, calls)
+
+		run(tt, "", "config", "reload")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/config/reload"}}, calls)
+
+		publicKeysetBytes, err := os.ReadFile(publicKeyset)
+		require.NoError(tt, err)
+		encrypter, err := encryption.NewHybridEncrypter(publicKeysetBytes)
+		require.NoError(tt, err)
+		encrypted, err := encrypter.Encrypt(context.Background(), []byte(`{"version":1,"tenants":[]}`), nil)
+		require.NoError(tt, err)
+		backup := filepath.Join(dir, "backup.bin")
+		require.NoError(tt, os.WriteFile(backup, encrypted, 0600))
+
+		run(tt, "", "admin", "backup", "restore", backup, "--private-keyset", privateKeyset)
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/admin/backups/restore", calls[0].uri)
+		assert.JSONEq(tt, `{"version":1,"tenants":[]}`, calls[0].body)
+
+		// keysets aren't overwritten
+		cmd := newRootCommand(strings.NewReader(""), io.Discard)
+		cmd.SetArgs([]string{"admin", "backup", "keygen", "--private-keyset", privateKeyset, "--public-keyset", filepath.Join(dir, "other.json")})
+		assert.Error(tt, cmd.Execute())
+	})
+
+	t.Run("decrypts shares before submitting them", func(tt *testing.T) {
+		dir := tt.TempDir()
+		privateKeyset, publicKeyset := filepath.Join(dir, "private.json"), filepath.Join(dir, "public.json")
+		run(tt, "", "admin", "backup", "keygen", "--private-keyset", privateKeyset, "--public-keyset", publicKeyset)
+
+		run(tt, "", "admin", "keystore", "shares", "create", "--public-keyset", publicKeyset, "--public-keyset", publicKeyset)
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, "/admin/keystore/shares", calls[0].uri)
+		publicKeysetBytes, err := os.ReadFile(publicKeyset)
+		require.NoError(tt, err)
+		assert.JSONEq(tt, `{"publicKeysets":[`+string(publicKeysetBytes)+`,`+string(publicKeysetBytes)+`]}`, calls[0].body)
+
+		encrypter, err := encryption.NewHybridEncrypter(publicKeysetBytes)
+		require.NoError(tt, err)
+		encrypted, err := encrypter.Encrypt(context.Background(), []byte("share"), nil)
+		require.NoError(tt, err)
+		run(tt, "", "admin", "keystore", "unseal", base64.StdEncoding.EncodeToString(encrypted), "--private-keyset", privateKeyset)
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/admin/keystore/unseal", body: `{"share":"share"}`}}, calls)
+	})
+
+	t.Run("re-encrypts the keys of tenants", func(tt *testing.T) {
+		run(tt, "", "admin", "keystore", "reencrypt", "--tenant", "acme", "--allow-plaintext")
+		require.Len(tt, calls, 1)
+		assert.Equal(tt, http.MethodPut, calls[0].method)
+		assert.Equal(tt, "/admin/keystore/reencrypt", calls[0].uri)
+		assert.JSONEq(tt, `{"tenants":["acme"],"allowPlaintext":true}`, calls[0].body)
+	})
+
+	t.Run("deletes a DID for good, and restores one", func(tt *testing.T) {
+		run(tt, "", "did", "delete", "ion", "did:ion:abc", "--deactivate", "true")
+		assert.Equal(tt, []call{{method: http.MethodDelete, uri: "/v1/dids/ion/did:ion:abc?deactivate=true"}}, calls)
+
+		run(tt, "", "did", "restore", "key", "did:key:z6Mk")
+		assert.Equal(tt, []call{{method: http.MethodPut, uri: "/v1/dids/key/did:key:z6Mk/restore"}}, calls)
+	})
+
+	t.Run("gets the public keys of keys as JWKs", func(tt *testing.T) {
+		run(tt, "", "key", "jwk", "did:web:example.com#key-1")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/v1/keys/did:web:example.com%23key-1/jwk"}}, calls)
+		run(tt, "", "key", "jwks", "--did", "did:web:example.com")
+		assert.Equal(tt, []call{{method: http.MethodGet, uri: "/.well-known/jwks.json?did=did%3Aweb%3Aexample.com"}}, calls)
+	})
+
+	t.Run("invalidates the cached resolution of a DID", func(tt *testing.T) {
+		run(tt, "", "admin", "resolution", "invalidate", "did:key:z6Mk")
+		assert.Equal(tt, []call{{method: http.MethodDelete, uri: "/admin/resolution/cache/did:key:z6Mk"}}, calls)
+	})
+
+	t.Run("validates and prints config files", func(tt *testing.T) {
+		tt.Setenv("SSI_SERVER_API_HOST", "0.0.0.0:9000")
+		out := run(tt, "", "config", "validate", "../../config/dev.toml")
+		assert.Contains(tt, out, `api_host = "0.0.0.0:9000"`)
+		assert.Empty(tt, calls)
+
+		cmd := newRootCommand(strings.NewReader(""), io.Discard)
+		cmd.SetArgs([]string{"config", "validate", "../../config/testdata/test1.toml"})
+		assert.ErrorContains(tt, cmd.Execute(), "prod environment cannot disable key encryption")
+	})
+
+	t.Run("exports and imports storage", func(tt *testing.T) {
+		dir := tt.TempDir()
+		writeConfig := func(name string) string {
+			devConfig, err := os.ReadFile("../../config/dev.toml")
+			require.NoError(tt, err)
+			configPath := filepath.Join(dir, name+".toml")
+			boltPath := strconv.Quote(filepath.Join(dir, name+".db"))
+			require.NoError(tt, os.WriteFile(configPath, bytes.Replace(devConfig, []byte(`"bolt.db"`), []byte(boltPath), 1), 0600))
+			return configPath
+		}
+		source, destination := writeConfig("source"), writeConfig("destination")
+
+		db, err := storage.NewStorage(storage.Bolt, storage.Option{ID: storage.BoltDBFilePathOption, Option: filepath.Join(dir, "source.db")})
+		require.NoError(tt, err)
+		require.NoError(tt, db.Write(context.Background(), "schema", "1", []byte(`{"id":"1"}`)))
+		require.NoError(tt, db.Write(context.Background(), "tenants/acme/schema", "2", []byte(`{"id":"2"}`)))
+		require.NoError(tt, db.Close())
+
+		out := run(tt, "", "storage", "--config", source, "namespaces")
+		assert.JSONEq(tt, `["schema","tenants/acme/schema"]`, out)
+		export := run(tt, "", "storage", "--config", source, "export")
+		assert.Equal(tt, 3, strings.Count(export, "\n"))
+		assert.Contains(tt, export, `"format":"ssi-service-storage-export","version":1`)
+		assert.Empty(tt, calls)
+
+		out = run(tt, export, "storage", "--config", destination, "import", "-", "--dry-run")
+		assert.Equal(tt, "would import 2 values, and skipped 0 that were stored already\n", out)
+		out = run(tt, export, "storage", "--config", destination, "import", "-")
+		assert.Equal(tt, "imported 2 values, and skipped 0 that were stored already\n", out)
+		out = run(tt, export, "storage", "--config", destination, "import", "-")
+		assert.Equal(tt, "imported 0 values, and skipped 2 that were stored already\n", out)
+		// exports differ only by when they were created
+		values := func(export string) string {
+			return export[strings.Index(export, "\n"):]
+		}
+		assert.Equal(tt, values(export), values(run(tt, "", "storage", "--config", destination, "export")))
+
+		out = run(tt, "", "storage", "--config", destination, "prune-operations")
+		assert.Equal(tt, "deleted 0 expired operations\n", out)
+
+		db, err = storage.NewStorage(storage.Bolt, storage.Option{ID: storage.BoltDBFilePathOption, Option: filepath.Join(dir, "destination.db")})
+		require.NoError(tt, err)
+		require.NoError(tt, db.Write(context.Background(), "did-key", "did:key:z1", []byte(`{"id":"did:key:z1","did":{"id":"did:key:z1"}}`)))
+		require.NoError(tt, db.Write(context.Background(), "did-key", "did:key:z2", []byte(`{"id":"did:key:z2","did":{"id":"did:key:z2"},"softDeleted":true}`)))
+		require.NoError(tt, db.Write(context.Background(), "tenants/acme/did-key", "did:key:z3", []byte(`{"id":"did:key:z3","did":{"id":"did:key:z3"}}`)))
+		require.NoError(tt, db.Close())
+		out = run(tt, "", "storage", "--config", destination, "dids", "key")
+		assert.JSONEq(tt, `{"dids":[{"id":"did:key:z1"}]}`, out)
+		out = run(tt, "", "storage", "--config", destination, "dids", "key", "--deleted")
+		assert.JSONEq(tt, `{"dids":[{"id":"did:key:z2"}]}`, out)
+		out = run(tt, "", "storage", "--config", destination, "dids", "key", "--tenant", "acme")
+		assert.JSONEq(tt, `{"dids":[{"id":"did:key:z3"}]}`, out)
+	})
+
+	t.Run("requires a body", func(tt *testing.T) {
+		cmd := newRootCommand(strings.NewReader(""), io.Discard)
+		cmd.SetArgs([]string{"--endpoint", server.URL, "schema", "create"})
+		assert.ErrorContains(tt, cmd.Execute(), "--data")
+	})
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/client"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func (a *app) storageCommand() *cobra.Command {
+	var configPath string
+	cmd := group("storage", "Export, import, and maintain the storage of the service directly, while the service is stopped",
+		&cobra.Command{
+			Use:   "namespaces",
+			Short: "List the namespaces in storage",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return withStorage(configPath, func(_ *config.SSIServiceConfig, db storage.ServiceStorage) error {
+					namespaces, err := storage.ListNamespaces(cmd.Context(), db)
+					if err != nil {
+						return err
+					}
+					body, err := json.Marshal(namespaces)
+					if err != nil {
+						return err
+					}
+					return a.print(body, nil, true)
+				})
+			},
+		},
+		a.storageDIDsCommand(&configPath),
+		a.storageExportCommand(&configPath),
+		a.storageImportCommand(&configPath),
+		&cobra.Command{
+			Use:   "prune-operations",
+			Short: "Delete the asynchronous operations of every tenant whose retention period has passed",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return withStorage(configPath, func(cfg *config.SSIServiceConfig, db storage.ServiceStorage) error {
+					deleted, err := pruneOperations(cmd.Context(), cfg.Services, db)
+					if err != nil {
+						return err
+					}
+					_, err = fmt.Fprintf(a.out, "deleted %d expired operations\n", deleted)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "rotate-service-key",
+			Short: "Replace the service key that encrypts the data keys of the keystore with a new one",
+			Long: `rotate-service-key encrypts the data key of each key of every tenant, and of each value of the encrypted
+namespaces, with a new service key, and replaces the service key with it. The keys and values themselves aren't
+re-encrypted. Keys that weren't encrypted with a data key yet have to be
+re-encrypted first, with "ssi admin keystore reencrypt". Service keys that are encrypted with a master key, or split
+into shares, aren't rotated this way.`,
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return withStorage(configPath, func(cfg *config.SSIServiceConfig, db storage.ServiceStorage) error {
+					rotated, err := keystore.RotateServiceKey(cmd.Context(), db, cfg.Services)
+					if err != nil {
+						return err
+					}
+					_, err = fmt.Fprintf(a.out, "encrypted %d data keys with a new service key\n", rotated)
+					return err
+				})
+			},
+		},
+	)
+	cmd.Long = `storage opens the storage of the service, as configured by its config file, without calling the service. Bolt
+databases can only be opened by one process, so stop the service first. Listing namespaces, exporting every namespace,
+pruning operations, and rotating the service key require a storage provider that lists its namespaces, which redis
+doesn't. Storage isn't re-indexed: the service keeps no index that can fall behind what it indexes, since each is
+written in the same transaction.`
+	cmd.PersistentFlags().StringVar(&configPath, "config", defaultConfigPath(), "config file of the service, whose storage is opened")
+	return cmd
+}
+
+func (a *app) storageDIDsCommand(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dids <method>",
+		Short: "List the DIDs of a method, like \"ssi did list\" does",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenant, _ := cmd.Flags().GetString("tenant")
+			deleted, _ := cmd.Flags().GetBool("deleted")
+			if tenant != "" && !storage.IsValidTenantID(tenant) {
+				return errors.Errorf("invalid tenant: %s", tenant)
+			}
+			return withStorage(*configPath, func(cfg *config.SSIServiceConfig, db storage.ServiceStorage) error {
+				serviceStorage, err := openServiceStorage(cfg.Services, db)
+				if err != nil {
+					return err
+				}
+				didStorage, err := did.NewDIDStorage(storage.NewTenantWrapper(serviceStorage))
+				if err != nil {
+					return err
+				}
+				stored, err := didStorage.ListDIDsDefault(storage.WithTenant(cmd.Context(), tenant), args[0])
+				if err != nil {
+					return err
+				}
+				var resp struct {
+					DIDs []didsdk.Document `json:"dids"`
+				}
+				resp.DIDs = make([]didsdk.Document, 0, len(stored))
+				for _, storedDID := range stored {
+					if storedDID.SoftDeleted == deleted {
+						resp.DIDs = append(resp.DIDs, storedDID.DID)
+					}
+				}
+				body, err := json.Marshal(resp)
+				if err != nil {
+					return err
+				}
+				return a.print(body, []string{"id"}, true)
+			})
+		},
+	}
+	cmd.Flags().String("tenant", "", "tenant whose DIDs are listed, instead of the default tenant")
+	cmd.Flags().Bool("deleted", false, "list the soft deleted DIDs instead")
+	return cmd
+}
+
+func (a *app) storageExportCommand(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the values in storage as newline delimited JSON",
+		Long: `export writes a header with the format and version of the export, then every value of every namespace, or of
+each --namespace, as a line of JSON with its namespace, key, and base64 encoded value. Values are exported as they're
+stored, so encrypted values stay encrypted, but the service keys they're encrypted with are exported too, unless they're
+held by a key manager: keep exports as safe as the storage itself.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			namespaces, _ := cmd.Flags().GetStringArray("namespace")
+			return withStorage(*configPath, func(_ *config.SSIServiceConfig, db storage.ServiceStorage) error {
+				_, err := storage.Export(cmd.Context(), db, a.out, time.Now(), namespaces)
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringArray("namespace", nil, "namespace to export, once for each namespace, instead of every namespace")
+	return cmd
+}
+
+func (a *app) storageImportCommand(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import the values of an export into storage",
+		Long: `import writes the values of a file written by "ssi storage export", or "ssi admin storage export", or of stdin
+when the file is -, into storage. Exports of older versions are upgraded as they're read. Values that are stored already
+are kept as they are, unless --overwrite is set. Encrypted values can only be read by services with the keys they were
+encrypted with, so import into the storage they were exported from, or into empty storage along with the service keys.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, closeIn, err := a.openInput(args[0])
+			if err != nil {
+				return err
+			}
+			defer closeIn()
+			overwrite, _ := cmd.Flags().GetBool("overwrite")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return withStorage(*configPath, func(_ *config.SSIServiceConfig, db storage.ServiceStorage) error {
+				result, err := storage.Import(cmd.Context(), db, in, overwrite, dryRun)
+				if err != nil {
+					return err
+				}
+				verb := "imported"
+				if dryRun {
+					verb = "would import"
+				}
+				_, err = fmt.Fprintf(a.out, "%s %d values, and skipped %d that were stored already\n", verb, result.Imported, result.Skipped)
+				return err
+			})
+		},
+	}
+	cmd.Flags().Bool("overwrite", false, "replace values that are stored already")
+	cmd.Flags().Bool("dry-run", false, "count the values that would be imported, without importing them")
+	return cmd
+}
+
+// adminStorageCommand exports and imports storage through the API, while the service is running.
+func (a *app) adminStorageCommand() *cobra.Command {
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export the values in storage as newline delimited JSON, as of a single point in time",
+		Long: `export writes what "ssi storage export" does, read by the running service. Values are read as of a single point
+in time, except from redis, whose namespaces have to be given with --namespace.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			namespaces, _ := cmd.Flags().GetStringArray("namespace")
+			req := client.Request{Method: http.MethodGet, Path: "/admin/storage/export", Query: url.Values{"namespace": namespaces}}
+			return a.call(cmd.Context(), req, nil, false)
+		},
+	}
+	export.Flags().StringArray("namespace", nil, "namespace to export, once for each namespace, instead of every namespace")
+
+	imp := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import the values of an export into storage, to restore a fresh instance of the service",
+		Long: `import sends an export, or stdin when the file is -, to the service, which writes its values into its storage.
+Values that are stored already are kept as they are, unless --overwrite is set. To restore a fresh instance, import with
+--overwrite, so that the service keys of the export replace those the instance created, and restart it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, closeIn, err := a.openInput(args[0])
+			if err != nil {
+				return err
+			}
+			defer closeIn()
+			body, err := io.ReadAll(in)
+			if err != nil {
+				return errors.Wrap(err, "reading export")
+			}
+			req := client.Request{Method: http.MethodPut, Path: "/admin/storage/import", Query: url.Values{}, Body: body}
+			if overwrite, _ := cmd.Flags().GetBool("overwrite"); overwrite {
+				req.Query.Set("overwrite", "true")
+			}
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+				req.Query.Set("dryRun", "true")
+			}
+			return a.call(cmd.Context(), req, nil, false)
+		},
+	}
+	imp.Flags().Bool("overwrite", false, "replace values that are stored already")
+	imp.Flags().Bool("dry-run", false, "report what would change, without changing it")
+
+	encrypt := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the values of the encrypted namespaces that were stored before they were encrypted",
+		Long: `encrypt has the service encrypt, with the service key, the values of the namespaces listed in
+encrypted_namespaces of every tenant that were stored unencrypted, before their namespace was listed. The service keeps
+running, and encrypting again only encrypts the values that still aren't.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			req := client.Request{Method: http.MethodPut, Path: "/admin/storage/encrypt", Query: url.Values{}}
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+				req.Query.Set("dryRun", "true")
+			}
+			return a.call(cmd.Context(), req, nil, false)
+		},
+	}
+	encrypt.Flags().Bool("dry-run", false, "report what would change, without changing it")
+
+	return group("storage", "Export and import every value in storage, as it's stored, and encrypt the values of encrypted namespaces", export, imp, encrypt)
+}
+
+// openInput opens a file, or stdin when the path is -, returning a func that closes it.
+func (a *app) openInput(path string) (io.Reader, func(), error) {
+	if path == "-" {
+		return a.in, func() {}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "opening export")
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+// openServiceStorage wraps db like the service does, so that values are read and written as the service reads and
+// writes them: with the app level encryption when it's enabled, and the service key when their namespaces are
+// encrypted.
+func openServiceStorage(cfg config.ServicesConfig, db storage.ServiceStorage) (storage.ServiceStorage, error) {
+	storageEncrypter, storageDecrypter, err := keystore.NewServiceEncryption(db, cfg.AppLevelEncryptionConfiguration, keystore.ServiceDataEncryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating app level encrypter")
+	}
+	globalStorage := db
+	if storageEncrypter != nil && storageDecrypter != nil {
+		globalStorage = storage.NewEncryptedWrapper(db, storageEncrypter, storageDecrypter)
+	}
+	if len(cfg.EncryptedNamespaces) > 0 {
+		if cfg.KeyStoreConfig.ServiceKeyShares > 0 {
+			return nil, errors.New("namespaces are encrypted with a service key that's split into shares, which only the running service reads them with")
+		}
+		keyEncrypter, _, err := keystore.NewKeyEncryption(db, cfg.KeyStoreConfig.EncryptionConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating keystore encrypter")
+		}
+		envelope, ok := keyEncrypter.(*encryption.EnvelopeEncrypter)
+		if !ok {
+			return nil, errors.New("encrypted namespaces require the keystore to encrypt keys with data keys")
+		}
+		globalStorage = storage.NewNamespaceEncryptedWrapper(globalStorage, envelope, cfg.EncryptedNamespaces)
+	}
+	return globalStorage, nil
+}
+
+// pruneOperations deletes the expired asynchronous operations of the default tenant, and of every tenant that has
+// namespaces in db, returning how many were deleted.
+func pruneOperations(ctx context.Context, cfg config.ServicesConfig, db storage.ServiceStorage) (int, error) {
+	namespaces, err := storage.ListNamespaces(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	globalStorage, err := openServiceStorage(cfg, db)
+	if err != nil {
+		return 0, err
+	}
+	operations, err := operation.NewOperationService(cfg.OperationConfig, storage.NewTenantWrapper(globalStorage))
+	if err != nil {
+		return 0, err
+	}
+
+	tenants := []string{""}
+	seen := map[string]bool{"": true}
+	for _, namespace := range namespaces {
+		if tenant := storage.NamespaceTenant(namespace); !seen[tenant] {
+			seen[tenant] = true
+			tenants = append(tenants, tenant)
+		}
+	}
+	deleted := 0
+	for _, tenant := range tenants {
+		n, err := operations.DeleteExpiredOperations(storage.WithTenant(ctx, tenant))
+		deleted += n
+		if err != nil {
+			return deleted, errors.Wrapf(err, "deleting the expired operations of tenant<%s>", tenant)
+		}
+	}
+	return deleted, nil
+}
+
+// withStorage opens the storage configured by a config file, runs fn with it, and closes it.
+func withStorage(configPath string, fn func(cfg *config.SSIServiceConfig, db storage.ServiceStorage) error) error {
+	cfg, err := loadConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	// storage providers log the options they're opened with, passwords included, at the info level
+	logrus.SetLevel(logrus.WarnLevel)
+	db, err := storage.NewStorage(storage.Type(cfg.Services.StorageProvider), cfg.Services.StorageOptions...)
+	if err != nil {
+		return errors.Wrap(err, "opening storage, is the service stopped?")
+	}
+	if err = fn(cfg, db); err != nil {
+		_ = db.Close()
+		return err
+	}
+	return errors.Wrap(db.Close(), "closing storage")
+}
@@ -2,29 +2,22 @@ package main
 
 import (
 	"context"
-	"expvar"
-	"io"
 	"os"
 	"os/signal"
 	"path"
-	"strconv"
 	"syscall"
-	"time"
 
 	"github.com/TBD54566975/ssi-sdk/schema"
 	"github.com/ardanlabs/conf"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/logging"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	"github.com/tbd54566975/ssi-service/pkg/server"
-
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 )
 
 // main godoc
@@ -60,7 +53,7 @@ func run() error {
 	}
 
 	// set up logger
-	if logFile := configureLogger(cfg.Server.LogLevel, cfg.Server.LogLocation); logFile != nil {
+	if logFile := logging.Configure(cfg.Server); logFile != nil {
 		defer func(logFile *os.File) {
 			if err = logFile.Close(); err != nil {
 				logrus.WithError(err).Error("failed to close log file")
@@ -69,9 +62,11 @@ func run() error {
 	}
 
 	// set up tracer
-	tp, err := newTracerProvider(cfg)
-	if err != nil {
-		logrus.WithError(err).Error("could not instantiate tracer provider")
+	var tp *sdktrace.TracerProvider
+	if cfg.Server.TracingEnabled() {
+		if tp, err = tracing.NewTracerProvider(context.Background(), cfg.Server, cfg.Version.SVN); err != nil {
+			logrus.WithError(err).Error("could not instantiate tracer provider")
+		}
 	}
 
 	// set up schema caching based on config
@@ -88,7 +83,7 @@ func run() error {
 		}
 	}
 
-	expvar.NewString("build").Set(cfg.Version.SVN)
+	metrics.BuildInfo.WithLabelValues(cfg.Version.SVN).Set(1)
 
 	logrus.Infof("main: Started : Service initializing : env [%s] : version %q", cfg.Server.Environment, cfg.Version.SVN)
 	defer logrus.Info("main: Completed")
@@ -106,16 +101,39 @@ func run() error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
-	ssiServer, err := server.NewSSIServer(shutdown, *cfg)
+	// the config file is loaded again when the config is reloaded, on SIGHUP or through the admin API
+	loadConfig := func() (*config.SSIServiceConfig, error) {
+		logrus.Infof("main: reloading config from %s", configPath)
+		return config.LoadConfig(file, os.DirFS(dir))
+	}
+	ssiServer, err := server.NewSSIServer(shutdown, *cfg, server.WithConfigLoader(loadConfig))
 	if err != nil {
 		logrus.Fatalf("could not start http services: %s", err.Error())
 	}
 
-	serverErrors := make(chan error, 1)
+	// reload the config file and tls certificates on SIGHUP, so they can be changed without downtime
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	go func() {
+		for range reload {
+			if _, err := ssiServer.ReloadConfig(); err != nil {
+				logrus.WithError(err).Error("main: failed to reload config, continuing with the previous one")
+			}
+		}
+	}()
+
+	serverErrors := make(chan error, 2)
 	go func() {
-		logrus.Infof("main: server started and listening on -> %s", ssiServer.Server.Addr)
+		logrus.Infof("main: server started and listening on -> %s (tls: %t)", ssiServer.Server.Addr, cfg.Server.TLS.Enabled)
 		serverErrors <- ssiServer.ListenAndServe()
 	}()
+	if ssiServer.Admin != nil {
+		go func() {
+			logrus.Infof("main: admin server started and listening on -> %s (tls: %t)", ssiServer.Admin.Addr, cfg.Server.Admin.TLS.Enabled)
+			serverErrors <- errors.Wrap(ssiServer.Admin.ListenAndServe(), "admin server")
+		}()
+	}
 
 	select {
 	case err = <-serverErrors:
@@ -127,90 +145,43 @@ func run() error {
 		defer cancel()
 
 		// Handle shutdown properly so nothing leaks.
-		if tp != nil {
-			if err = tp.Shutdown(ctx); err != nil {
-				logrus.Errorf("main: failed to shutdown tracer: %s", err)
-			}
-		}
-
 		if err := ssiServer.PreShutdownHooks(ctx); err != nil {
 			logrus.WithError(err).Error("main: failed to run pre shutdown hooks")
 		}
 
+		if ssiServer.Admin != nil {
+			if err = ssiServer.Admin.Shutdown(ctx); err != nil {
+				logrus.WithError(err).Error("main: failed to stop admin server gracefully, forcing shutdown")
+				if err = ssiServer.Admin.Close(); err != nil {
+					logrus.WithError(err).Error("main: failed to close admin server")
+				}
+			}
+		}
+
 		if err = ssiServer.Shutdown(ctx); err != nil {
 			logrus.WithError(err).Error("main: failed to stop server gracefully, forcing shutdown")
 			if err = ssiServer.Close(); err != nil {
 				logrus.WithError(err).Error("main: failed to close server")
 			}
 		}
-	}
-
-	return nil
-}
 
-// newTracerProvider returns an OpenTelemetry TracerProvider configured to use
-// the Jaeger exporter that will send spans to the provided url. The returned
-// TracerProvider will also use a Resource configured with all the information
-// about the application.
-func newTracerProvider(cfg *config.SSIServiceConfig) (*sdktrace.TracerProvider, error) {
-	// Create a new tracer provider with a batch span processor and the given exporter.
-	// Create the Jaeger exporter
-	jagerHost := cfg.Server.JagerHost
-	if jagerHost == "" {
-		return nil, errors.New("no jager host provided")
-	}
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(jagerHost)))
-	if err != nil {
-		return nil, err
-	}
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		// Always be sure to batch in production.
-		sdktrace.WithBatcher(exporter),
-		// Record information about this application in a Resource.
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(config.ServiceName),
-			semconv.ServiceVersionKey.String(cfg.Version.SVN),
-		)),
-	)
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	return tp, nil
-}
+		// with no more requests coming in, background work started by earlier requests gets the rest of the timeout
+		if err = ssiServer.Drain(ctx); err != nil {
+			logrus.WithError(err).Error("main: failed to drain background work")
+		}
 
-// configureLogger configures the logger to logs to the given location and returns a file pointer to a logs
-// file that should be closed upon server shutdown
-func configureLogger(level, location string) *os.File {
-	if level != "" {
-		logLevel, err := logrus.ParseLevel(level)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not parse log level<%s>, setting to info", level)
-			logrus.SetLevel(logrus.InfoLevel)
-		} else {
-			logrus.SetLevel(logLevel)
+		// storage is closed once nothing writes to it anymore
+		if err = ssiServer.CloseStorage(); err != nil {
+			logrus.WithError(err).Error("main: failed to close storage")
 		}
-	}
 
-	logrus.SetFormatter(&logrus.JSONFormatter{
-		DisableTimestamp: false,
-		PrettyPrint:      true,
-	})
-	logrus.SetReportCaller(true)
-
-	// set logs config from config file
-	now := time.Now()
-	logrus.SetOutput(os.Stdout)
-	if location != "" {
-		logFile := location + "/" + config.ServiceName + "-" + now.Format(time.DateOnly) + "-" + strconv.FormatInt(now.Unix(), 10) + ".log"
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			logrus.WithError(err).Warn("failed to create logs file, using default stdout")
-		} else {
-			mw := io.MultiWriter(os.Stdout, file)
-			logrus.SetOutput(mw)
+		// the tracer is shut down last, so that the spans of the work drained above are exported
+		if tp != nil {
+			if err = tp.Shutdown(ctx); err != nil {
+				logrus.Errorf("main: failed to shutdown tracer: %s", err)
+			}
 		}
-		return file
 	}
+
 	return nil
 }
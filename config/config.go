@@ -1,7 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -13,6 +15,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
@@ -23,6 +26,8 @@ const (
 	Filename          = "dev.toml"
 	ServiceName       = "ssi-service"
 	Extension         = ".toml"
+	YAMLExtension     = ".yaml"
+	YMLExtension      = ".yml"
 
 	DefaultServiceEndpoint = "http://localhost:8080"
 
@@ -30,8 +35,12 @@ const (
 	EnvironmentTest Environment = "test"
 	EnvironmentProd Environment = "prod"
 
-	ConfigPath EnvironmentVariable = "CONFIG_PATH"
-	DBPassword EnvironmentVariable = "DB_PASSWORD"
+	ConfigPath         EnvironmentVariable = "CONFIG_PATH"
+	DBPassword         EnvironmentVariable = "DB_PASSWORD"
+	DBConnectionString EnvironmentVariable = "DB_CONNECTION_STRING"
+
+	AdminAPIKeyHash EnvironmentVariable = "ADMIN_API_KEY_HASH"
+	SMTPPassword    EnvironmentVariable = "SMTP_PASSWORD"
 )
 
 type (
@@ -60,8 +69,338 @@ type ServerConfig struct {
 	ShutdownTimeout     time.Duration `toml:"shutdown_timeout" conf:"default:5s"`
 	LogLocation         string        `toml:"log_location" conf:"default:log"`
 	LogLevel            string        `toml:"log_level" conf:"default:debug"`
+	LogFormat           string        `toml:"log_format" conf:"default:json"`
 	EnableSchemaCaching bool          `toml:"enable_schema_caching" conf:"default:true"`
 	EnableAllowAllCORS  bool          `toml:"enable_allow_all_cors" conf:"default:false"`
+
+	// CORSAllowedOrigins are the origins, like https://wallet.example.com, that browsers may call the API from. Any
+	// origin may when EnableAllowAllCORS is set.
+	CORSAllowedOrigins []string `toml:"cors_allowed_origins"`
+
+	// EnableAPIKeyAuth requires every request to the API to present a valid key in the X-API-Key header.
+	EnableAPIKeyAuth bool `toml:"enable_api_key_auth" conf:"default:false"`
+
+	// EnableBearerTokenAuth requires every request to the API to present a valid OAuth2 access token in the
+	// Authorization header. When combined with EnableAPIKeyAuth, either credential is accepted.
+	EnableBearerTokenAuth bool `toml:"enable_bearer_token_auth" conf:"default:false"`
+
+	// RequireIfMatch rejects requests that change a resource guarded by ETags, like deleting a schema or creating its
+	// next version, without an If-Match header holding the ETag of the resource, so that clients can't change what they
+	// haven't read.
+	RequireIfMatch bool `toml:"require_if_match" conf:"default:false"`
+
+	// ValidateRequests rejects requests whose JSON body doesn't conform to the schema of their operation in the API
+	// spec, before they reach their handler.
+	ValidateRequests bool `toml:"validate_requests" conf:"default:false"`
+
+	// FixturesFile is a YAML or JSON file of fixtures, like DIDs, schemas, and credentials, that are created on startup
+	// so that local development and demos start from a known state. Each fixture is only created once per storage.
+	// Fixtures aren't loaded in the prod environment.
+	FixturesFile string `toml:"fixtures_file"`
+
+	// LogSampling samples the access logs of successful requests to hot routes.
+	LogSampling LogSamplingConfig `toml:"log_sampling"`
+
+	// Tracing exports traces of requests, and of the work done to handle them, to an OpenTelemetry collector.
+	Tracing TracingConfig `toml:"tracing"`
+
+	// Metrics serves Prometheus metrics of the requests handled, signing, storage, and DID resolution.
+	Metrics MetricsConfig `toml:"metrics"`
+
+	RateLimit RateLimitConfig `toml:"rate_limit"`
+
+	TLS TLSConfig `toml:"tls"`
+
+	// Admin moves the admin endpoints onto a listener of their own, so they can be kept off the public network.
+	Admin AdminListenerConfig `toml:"admin"`
+
+	RequestLimits RequestLimitsConfig `toml:"request_limits"`
+
+	Timeouts TimeoutsConfig `toml:"timeouts"`
+
+	Compression CompressionConfig `toml:"compression"`
+
+	Caching CachingConfig `toml:"caching"`
+
+	RequestSignatures RequestSignaturesConfig `toml:"request_signatures"`
+
+	Audit AuditConfig `toml:"audit"`
+
+	Usage UsageConfig `toml:"usage"`
+
+	// Deprecations announce that a version of the API is going away, using headers on every response it serves.
+	Deprecations []DeprecationConfig `toml:"deprecation"`
+
+	// Features enable experimental capabilities, which ship disabled, for the deployment or for some of its tenants.
+	Features []FeatureConfig `toml:"feature"`
+}
+
+// FeatureConfig enables the experimental capability behind a feature flag.
+type FeatureConfig struct {
+	// Name of the flag, e.g. sd-jwt-credentials.
+	Name string `toml:"name"`
+
+	// Whether the capability is enabled for every tenant.
+	Enabled bool `toml:"enabled"`
+
+	// Tenants the capability is enabled for, when it isn't enabled for every tenant.
+	Tenants []string `toml:"tenants"`
+}
+
+// DeprecationConfig deprecates a version of the API. Dates are either a day, like 2024-01-31, or an RFC 3339
+// timestamp.
+type DeprecationConfig struct {
+	// Version of the API that is deprecated, e.g. v1.
+	Version string `toml:"version"`
+
+	// When the version was, or will be, deprecated. Sent in the Deprecation header.
+	Date string `toml:"date"`
+
+	// When the version will stop being served. Sent in the Sunset header, when set.
+	Sunset string `toml:"sunset"`
+
+	// Documentation of the deprecation, e.g. a migration guide. Sent in the Link header, when set.
+	Link string `toml:"link"`
+}
+
+// TLSConfig configures serving HTTPS. The files are read again when the service receives SIGHUP, so that certificates
+// can be rotated without a restart.
+type TLSConfig struct {
+	Enabled  bool   `toml:"enabled"`
+	CertFile string `toml:"cert_file"`
+	KeyFile  string `toml:"key_file"`
+
+	// PEM file of the CAs that client certificates are verified against. Client certificates are optional unless
+	// RequireClientCert is set.
+	ClientCAFile      string `toml:"client_ca_file"`
+	RequireClientCert bool   `toml:"require_client_cert"`
+}
+
+// AdminListenerConfig configures the listener that serves the admin endpoints, which manage API keys, roles, and
+// tenants. The admin endpoints always require an admin credential, wherever they're served.
+type AdminListenerConfig struct {
+	// Address of the admin listener, e.g. 127.0.0.1:3001. When empty, the admin endpoints are served on APIHost
+	// along with the rest of the API.
+	Host string `toml:"host"`
+
+	// Networks, in CIDR notation, whose addresses may call the admin endpoints, wherever they're served. Any address
+	// may when empty.
+	AllowedNetworks []string `toml:"allowed_networks"`
+
+	// TLS of the admin listener, which is separate from the API's so that operators can be required to present
+	// client certificates that API clients don't have.
+	TLS TLSConfig `toml:"tls"`
+
+	// EnableDebug serves runtime diagnostics under /admin/debug: pprof profiles, goroutine dumps, and the stats of the
+	// runtime and of storage. Profiles reveal the internals of the service, so it's best left off unless diagnosing it.
+	EnableDebug bool `toml:"enable_debug"`
+
+	// EnableUI serves a web UI under /admin/ui for browsing DIDs, credentials, schemas, and manifests, and reviewing
+	// applications and submissions. The UI asks operators for an admin credential, which its requests to the API carry.
+	EnableUI bool `toml:"enable_ui"`
+}
+
+// RequestLimitsConfig limits the size of request bodies. Requests with larger bodies are rejected with
+// 413 Request Entity Too Large.
+type RequestLimitsConfig struct {
+	// Largest body, in bytes, accepted by routes that don't have their own limit. Defaults to 10 MiB.
+	MaxBodyBytes int64 `toml:"max_body_bytes"`
+
+	// Limits for specific routes, which replace the default limit for the route.
+	Routes []RouteRequestLimitConfig `toml:"route"`
+}
+
+type RouteRequestLimitConfig struct {
+	// HTTP method of the route, e.g. PUT.
+	Method string `toml:"method"`
+
+	// Path of the route as registered, e.g. /v1/credentials/batch.
+	Path string `toml:"path"`
+
+	MaxBodyBytes int64 `toml:"max_body_bytes"`
+}
+
+// TimeoutsConfig limits how long requests take, replacing ReadTimeout and WriteTimeout for the routes it configures, so
+// that e.g. batches get longer than reads. Requests that take longer are cancelled, and answered with
+// 503 Service Unavailable when nothing was written yet.
+type TimeoutsConfig struct {
+	// Timeout of routes that don't have their own. When empty, those routes keep the ReadTimeout and WriteTimeout of
+	// the server.
+	Default time.Duration `toml:"default"`
+
+	// Timeouts for specific routes. A route's timeout is the one configured for its method and path, else for its path,
+	// else for its method.
+	Routes []RouteTimeoutConfig `toml:"route"`
+}
+
+type RouteTimeoutConfig struct {
+	// HTTP method of the route, e.g. GET. When empty, the timeout applies to every method of the path.
+	Method string `toml:"method"`
+
+	// Path of the route as registered, e.g. /v1/batch. When empty, the timeout applies to every route of the method.
+	Path string `toml:"path"`
+
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// AuditConfig configures the audit log, which records every call to the API and the admin endpoints that changes
+// something: who made it, in which tenant, on which resource, the hashes of the resource before and after, and how it
+// ended.
+type AuditConfig struct {
+	Enabled bool `toml:"enabled"`
+}
+
+// UsageConfig configures metering how much each tenant, and each API key or token subject, issues, verifies, signs,
+// and stores, and the quotas capping it. Usage is counted per UTC calendar month.
+type UsageConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Status of the responses to calls that would go over a quota, either 429 Too Many Requests (the default) or
+	// 402 Payment Required.
+	ExceededStatus int `toml:"exceeded_status"`
+
+	// Quotas capping the usage of a meter each month. Usage is metered, but not capped, when there are none.
+	Quotas []QuotaConfig `toml:"quota"`
+}
+
+type QuotaConfig struct {
+	// Meter that is capped: issuance, verification, signing, or storage.
+	Meter string `toml:"meter"`
+
+	// Most the meter may count in a month, which is bytes for storage, and calls, or items of batches, for the others.
+	Limit int64 `toml:"limit"`
+
+	// Whose usage is capped: "tenant" (the default), for the usage of a tenant as a whole, or "principal", for the
+	// usage of each API key or token subject on its own.
+	Per string `toml:"per"`
+
+	// Tenant whose usage is capped. When empty, the quota applies to every tenant without a quota of its own for the
+	// same meter and per.
+	Tenant string `toml:"tenant"`
+}
+
+// CompressionConfig configures compressing responses with brotli or gzip, for clients that accept them in their
+// Accept-Encoding header.
+type CompressionConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Smallest response, in bytes, that is compressed. Defaults to 1 KiB.
+	MinSizeBytes int `toml:"min_size_bytes"`
+}
+
+// CachingConfig configures the Cache-Control headers of public artifacts, like DID documents, status lists, and
+// schemas.
+type CachingConfig struct {
+	// How long clients and caches can reuse an artifact before revalidating it. Defaults to a minute.
+	MaxAge time.Duration `toml:"max_age"`
+}
+
+// RequestSignaturesConfig requires the requests that change something, through the API or the admin endpoints, to be
+// signed with HTTP Message Signatures (RFC 9421), by a client key registered through the admin endpoints.
+type RequestSignaturesConfig struct {
+	Required bool `toml:"required"`
+
+	// How long after it was created a signature is accepted for. Defaults to 5 minutes.
+	MaxAge time.Duration `toml:"max_age"`
+}
+
+// MetricsConfig configures the /metrics endpoint, which serves metrics in the Prometheus exposition format. It's served
+// on the admin listener when one is configured, and only to the admin networks, without requiring a credential.
+type MetricsConfig struct {
+	Enabled bool `toml:"enabled"`
+}
+
+// TracingConfig configures OpenTelemetry tracing of the API, the services, storage, and outbound requests, like
+// resolving DIDs with the universal resolver. Traces are exported with OTLP over HTTP.
+type TracingConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Where traces are exported to, either "otlp" (the default), or "jaeger" to export them to the Jaeger collector at
+	// JagerHost.
+	Exporter string `toml:"exporter"`
+
+	// URL of the traces endpoint of the OTLP/HTTP collector. Defaults to http://localhost:4318/v1/traces.
+	Endpoint string `toml:"endpoint"`
+
+	// Headers sent with every export, e.g. to authenticate with a hosted collector.
+	Headers []TracingHeaderConfig `toml:"header"`
+
+	// Fraction of the traces started by the service that are sampled, between 0 and 1. Defaults to 1, which samples
+	// all of them. Traces continued from a request's traceparent header are sampled when the caller sampled them.
+	SampleRatio float64 `toml:"sample_ratio"`
+}
+
+type TracingHeaderConfig struct {
+	Name  string `toml:"name"`
+	Value string `toml:"value"`
+}
+
+const (
+	// TracingExporterOTLP exports traces with OTLP over HTTP.
+	TracingExporterOTLP = "otlp"
+
+	// TracingExporterJaeger exports traces to a Jaeger collector.
+	TracingExporterJaeger = "jaeger"
+)
+
+// TracingEnabled returns whether traces are exported, which they are when tracing is enabled, or with the older
+// jager_enabled option, which exports them to Jaeger.
+func (c ServerConfig) TracingEnabled() bool {
+	return c.Tracing.Enabled || c.JagerEnabled
+}
+
+// LogSamplingConfig samples the access logs of successful requests, so that hot routes, like resolving DIDs, don't
+// drown out everything else. Within each second, the first First successful requests to a route are logged, and after
+// them every Thereafter-th one. Failed requests are always logged.
+type LogSamplingConfig struct {
+	// First is how many successful requests to a route are logged each second before sampling starts.
+	First int `toml:"first"`
+
+	// Thereafter is how often successful requests are logged once sampling started, e.g. 100 logs every 100th. Requests
+	// aren't sampled when it's zero.
+	Thereafter int `toml:"thereafter"`
+
+	// Routes whose requests are sampled. Every route's are when it's empty.
+	Routes []RouteLogSamplingConfig `toml:"route"`
+}
+
+type RouteLogSamplingConfig struct {
+	// HTTP method of the route, e.g. GET.
+	Method string `toml:"method"`
+
+	// Path of the route as registered, e.g. /v1/dids/resolver/:id.
+	Path string `toml:"path"`
+}
+
+// RateLimitConfig configures token bucket rate limits for the API. Limits are tracked per client, which is the API key
+// or token subject of authenticated callers, and the IP address of everyone else.
+type RateLimitConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Where buckets are kept, either "memory" (the default), or "redis" to share limits between instances.
+	Backend       string `toml:"backend"`
+	RedisAddress  string `toml:"redis_address"`
+	RedisPassword string `toml:"redis_password"`
+
+	// Limit applied to every route that doesn't have its own limit. No limit is applied when zero.
+	RequestsPerMinute int `toml:"requests_per_minute"`
+
+	// Number of requests a client may make in a burst. Defaults to RequestsPerMinute.
+	Burst int `toml:"burst"`
+
+	// Limits for specific routes, which replace the default limit for the route.
+	Routes []RouteRateLimitConfig `toml:"route"`
+}
+
+type RouteRateLimitConfig struct {
+	// HTTP method of the route, e.g. PUT.
+	Method string `toml:"method"`
+
+	// Path of the route as registered, e.g. /v1/credentials or /v1/manifests/applications/:id/review.
+	Path string `toml:"path"`
+
+	RequestsPerMinute int `toml:"requests_per_minute"`
+	Burst             int `toml:"burst"`
 }
 
 // ServicesConfig represents configurable properties for the components of the SSI Service
@@ -77,6 +416,12 @@ type ServicesConfig struct {
 	// configured KV store.
 	AppLevelEncryptionConfiguration EncryptionConfig `toml:"storage_encryption,omitempty"`
 
+	// Namespaces whose values are encrypted with the service key of the keystore, like "credential" and
+	// "application", which hold personal data. They're named without the prefix of a tenant, and encrypted for every
+	// tenant. Requires the keystore to encrypt keys. Values stored before a namespace is listed are read as they are,
+	// until they're encrypted with PUT /admin/storage/encrypt.
+	EncryptedNamespaces []string `toml:"encrypted_namespaces,omitempty"`
+
 	// Embed all service-specific configs here. The order matters: from which should be instantiated first, to last
 	KeyStoreConfig        KeyStoreServiceConfig     `toml:"keystore,omitempty"`
 	DIDConfig             DIDServiceConfig          `toml:"did,omitempty"`
@@ -87,6 +432,22 @@ type ServicesConfig struct {
 	ManifestConfig        ManifestServiceConfig     `toml:"manifest,omitempty"`
 	IssuanceServiceConfig IssuanceServiceConfig     `toml:"issuance,omitempty"`
 	WebhookConfig         WebhookServiceConfig      `toml:"webhook,omitempty"`
+	AuthConfig            AuthServiceConfig         `toml:"auth,omitempty"`
+	TransparencyConfig    TransparencyServiceConfig `toml:"transparency,omitempty"`
+	BackupConfig          BackupServiceConfig       `toml:"backup,omitempty"`
+	AnomalyConfig         AnomalyServiceConfig      `toml:"anomaly,omitempty"`
+	RetentionConfig       RetentionServiceConfig    `toml:"retention,omitempty"`
+	ApprovalConfig        ApprovalServiceConfig     `toml:"approval,omitempty"`
+	AnchorConfig          AnchorServiceConfig       `toml:"anchor,omitempty"`
+	ExpiryConfig          ExpiryServiceConfig       `toml:"expiry,omitempty"`
+	ReplayConfig          ReplayServiceConfig       `toml:"replay,omitempty"`
+	ChallengeConfig       ChallengeServiceConfig    `toml:"challenge,omitempty"`
+	TrustConfig           TrustServiceConfig        `toml:"trust,omitempty"`
+	OIDC4VCIConfig        OIDC4VCIServiceConfig     `toml:"oidc4vci,omitempty"`
+	OIDC4VPConfig         OIDC4VPServiceConfig      `toml:"oidc4vp,omitempty"`
+
+	// Faults injected into storage and DID resolution. Only meant for tests.
+	Faults FaultsConfig `toml:"faults,omitempty"`
 }
 
 // BaseServiceConfig represents configurable properties for a specific component of the SSI Service
@@ -101,6 +462,17 @@ type KeyStoreServiceConfig struct {
 
 	// Configuration describing the encryption of the private keys that are under ssi-service's custody.
 	EncryptionConfig
+
+	// When set, the service key that encrypts the private keys isn't kept in storage. Instead, it's split into this
+	// many shares when it's initialized, and reconstructed from ServiceKeyThreshold of them when the service starts,
+	// so that no single operator holds it. Can't be combined with MasterKeyURI.
+	ServiceKeyShares    int `toml:"service_key_shares"`
+	ServiceKeyThreshold int `toml:"service_key_threshold"`
+
+	// Path for the credentials of the key managers holding the keys that are stored by their URI: an AWS shared
+	// credentials file for aws-kms keys, a service account JSON file for gcp-kms keys, and a file holding a Vault
+	// token for hcvault keys. When empty, the credentials of the environment are used.
+	KeyManagerCredentialsPath string `toml:"key_manager_credentials_path"`
 }
 
 type EncryptionConfig struct {
@@ -148,13 +520,39 @@ func (k *KeyStoreServiceConfig) EncryptionEnabled() bool {
 
 type DIDServiceConfig struct {
 	*BaseServiceConfig
-	Methods                  []string `toml:"methods"`
-	LocalResolutionMethods   []string `toml:"local_resolution_methods"`
-	UniversalResolverURL     string   `toml:"universal_resolver_url"`
+	Methods                []string `toml:"methods"`
+	LocalResolutionMethods []string `toml:"local_resolution_methods"`
+	UniversalResolverURL   string   `toml:"universal_resolver_url"`
+	// Methods whose DIDs are resolved with the universal resolver when they can't be resolved locally. Every method
+	// when empty.
 	UniversalResolverMethods []string `toml:"universal_resolver_methods"`
-	IONResolverURL           string   `toml:"ion_resolver_url"`
+	// How long DIDs resolved with the universal resolver are cached, e.g. 10m. 5 minutes when empty, and not at all
+	// when negative.
+	UniversalResolverCacheTTL time.Duration `toml:"universal_resolver_cache_ttl"`
+	IONResolverURL            string        `toml:"ion_resolver_url"`
 	// BatchCreateMaxItems set's the maximum amount that can be.
 	BatchCreateMaxItems int `toml:"batch_create_max_items" conf:"default:100"`
+
+	// ResolutionCache caches the results of resolving DIDs, whichever resolver resolved them.
+	ResolutionCache DIDResolutionCacheConfig `toml:"resolution_cache"`
+}
+
+// DIDResolutionCacheConfig configures caching the results of DID resolution, so that verifying many tokens of the same
+// DIDs doesn't resolve them each time.
+type DIDResolutionCacheConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Where results are kept, either "memory" (the default), or "redis" to share them between instances.
+	Backend       string `toml:"backend"`
+	RedisAddress  string `toml:"redis_address"`
+	RedisPassword string `toml:"redis_password"`
+
+	// How long results are cached, e.g. 10m. 5 minutes when empty.
+	TTL time.Duration `toml:"ttl"`
+
+	// How many results the memory backend keeps, evicting the least recently used ones first. 10000 when empty. Redis
+	// evicts by its own maxmemory-policy.
+	MaxEntries int `toml:"max_entries"`
 }
 
 func (d *DIDServiceConfig) IsEmpty() bool {
@@ -179,10 +577,37 @@ type CredentialServiceConfig struct {
 	*BaseServiceConfig
 	// BatchCreateMaxItems set's the maximum amount that can be.
 	BatchCreateMaxItems int `toml:"batch_create_max_items" conf:"default:100"`
+	// BatchCreateConcurrency is how many credentials of a batch that reports a result per credential are created at
+	// once. Defaults to 10.
+	BatchCreateConcurrency int `toml:"batch_create_concurrency" conf:"default:10"`
+	// SchemaValidation is how credentials whose data doesn't conform to the JSON Schema of their schema are handled:
+	// SchemaValidationStrict refuses to issue them, and SchemaValidationAdvisory issues them, reporting how they don't
+	// conform. Defaults to SchemaValidationStrict.
+	SchemaValidation string `toml:"schema_validation" conf:"default:strict"`
+	// JSONLDContexts are JSON-LD contexts that credentials secured with Data Integrity proofs are canonicalized with,
+	// loaded from files rather than fetched from their URL. The contexts of the VC Data Model, the Data Integrity
+	// suites, and status lists ship with the service.
+	JSONLDContexts []JSONLDContextConfig `toml:"jsonld_context"`
+	// JSONLDOffline makes contexts that neither ship with the service nor are configured fail to load, rather than be
+	// fetched from their URL.
+	JSONLDOffline bool `toml:"jsonld_offline"`
+}
 
-	// TODO(gabe) supported key and signature types
+// JSONLDContextConfig is a JSON-LD context, and the file it's loaded from.
+type JSONLDContextConfig struct {
+	// URL that credentials refer to the context by.
+	URL string `toml:"url"`
+	// Path of the file that holds the context.
+	Path string `toml:"path"`
 }
 
+const (
+	// SchemaValidationStrict refuses to issue credentials whose data doesn't conform to their schema.
+	SchemaValidationStrict = "strict"
+	// SchemaValidationAdvisory issues credentials whose data doesn't conform to their schema, reporting how.
+	SchemaValidationAdvisory = "advisory"
+)
+
 func (c *CredentialServiceConfig) IsEmpty() bool {
 	if c == nil {
 		return true
@@ -192,6 +617,8 @@ func (c *CredentialServiceConfig) IsEmpty() bool {
 
 type OperationServiceConfig struct {
 	*BaseServiceConfig
+	// RetentionPeriod is how long the results of finished asynchronous operations are kept, e.g. "168h".
+	RetentionPeriod string `toml:"retention_period"`
 }
 
 func (o *OperationServiceConfig) IsEmpty() bool {
@@ -203,6 +630,10 @@ func (o *OperationServiceConfig) IsEmpty() bool {
 
 type PresentationServiceConfig struct {
 	*BaseServiceConfig
+
+	// How long presentation requests are valid for when they're created without an expiration, e.g. 10m. They don't
+	// expire when empty.
+	RequestTTL time.Duration `toml:"request_ttl"`
 }
 
 func (p *PresentationServiceConfig) IsEmpty() bool {
@@ -214,6 +645,45 @@ func (p *PresentationServiceConfig) IsEmpty() bool {
 
 type ManifestServiceConfig struct {
 	*BaseServiceConfig
+
+	// ReviewPolicies review the applications to manifests when they're submitted, instead of leaving them pending.
+	// The first policy that matches an application approves or denies it. Applications no policy matches are
+	// reviewed as before: issued from the issuance template of their manifest when it has one, else left pending.
+	ReviewPolicies []ReviewPolicyConfig `toml:"review_policy"`
+}
+
+// ReviewPolicyConfig approves or denies the applications it matches. An application matches when it's to one of the
+// policy's manifests, every credential it submits is issued by one of the policy's issuers, and each of the policy's
+// conditions holds for at least one of its credentials. Criteria that are left empty match every application.
+type ReviewPolicyConfig struct {
+	// Name of the policy, which names it in errors and in the reason of its reviews when it has no reason.
+	Name string `toml:"name"`
+
+	// Action taken on the applications the policy matches: "approve" or "deny".
+	Action string `toml:"action"`
+
+	// Reason recorded for the review, and given to the applicant in denials.
+	Reason string `toml:"reason"`
+
+	// IDs of the manifests whose applications the policy reviews.
+	ManifestIDs []string `toml:"manifest_ids"`
+
+	// DIDs that must have issued the submitted credentials.
+	Issuers []string `toml:"issuers"`
+
+	Conditions []ReviewConditionConfig `toml:"condition"`
+}
+
+// ReviewConditionConfig holds for a credential when a JSONPath of the credential has a value, that equals Equals, or
+// matches Pattern, when they're set.
+type ReviewConditionConfig struct {
+	// JSONPath of the value in the credential, e.g. $.credentialSubject.country.
+	Path string `toml:"path"`
+
+	Equals string `toml:"equals"`
+
+	// Regular expression the value must match, e.g. ^(US|CA)$.
+	Pattern string `toml:"pattern"`
 }
 
 func (m *ManifestServiceConfig) IsEmpty() bool {
@@ -246,8 +716,353 @@ func (p *WebhookServiceConfig) IsEmpty() bool {
 	return reflect.DeepEqual(p, &WebhookServiceConfig{})
 }
 
-// LoadConfig attempts to load a TOML config file from the given path, and coerce it into our object model.
-// Before loading, defaults are applied on certain properties, which are overwritten if specified in the TOML file.
+type AuthServiceConfig struct {
+	*BaseServiceConfig
+
+	// Hex encoded SHA-256 hash of an admin API key that's always accepted. Used to bootstrap the first keys
+	// through the admin endpoints.
+	AdminAPIKeyHash string `toml:"admin_api_key_hash"`
+
+	// Issuer of OAuth2 / OIDC JWT access tokens. Bearer tokens are only validated when this is set.
+	OAuthIssuer string `toml:"oauth_issuer"`
+
+	// URL of the JWKS used to verify access token signatures. When empty, it's discovered from the OpenID configuration
+	// of the issuer, at /.well-known/openid-configuration.
+	OAuthJWKSURL string `toml:"oauth_jwks_url"`
+
+	// Expected `aud` claim of access tokens. The audience is not checked when empty.
+	OAuthAudience string `toml:"oauth_audience"`
+
+	// Claim of access tokens that holds the tenant of the caller. Callers without it belong to the default tenant.
+	OAuthTenantClaim string `toml:"oauth_tenant_claim"`
+
+	// Scopes granted to access tokens by their claims, e.g. to the members of a group of the identity provider, along
+	// with the scopes of their scope and scp claims.
+	OAuthScopeMappings []OAuthScopeMappingConfig `toml:"oauth_scope_mapping"`
+
+	// Public key, as a JWK, that's always accepted for request signatures naming the key ID "bootstrap-client". Used to
+	// register the first client keys through the admin endpoints when request signatures are required.
+	BootstrapClientKeyJWK string `toml:"bootstrap_client_key_jwk"`
+}
+
+// OAuthScopeMappingConfig grants scopes to the access tokens whose claim is a value, or is an array that includes it.
+type OAuthScopeMappingConfig struct {
+	// Claim of the token, e.g. groups. Claims of nested objects are named by their dotted path, e.g. realm_access.roles.
+	Claim string `toml:"claim"`
+
+	Value string `toml:"value"`
+
+	// Scopes granted, e.g. credentials:issue.
+	Scopes []string `toml:"scopes"`
+}
+
+func (a *AuthServiceConfig) IsEmpty() bool {
+	if a == nil {
+		return true
+	}
+	return reflect.DeepEqual(a, &AuthServiceConfig{})
+}
+
+// TransparencyServiceConfig configures the transparency log, which records the hashes of the credentials each tenant
+// issues, revokes, and suspends in a Merkle tree, so that auditors can check that records aren't back-dated or deleted.
+type TransparencyServiceConfig struct {
+	// Whether credential operations are appended to the log, and its routes are served.
+	Enabled bool `toml:"enabled"`
+}
+
+// BackupServiceConfig configures the backups of the keys and DIDs of the service, which are encrypted under an offline
+// public key and written to cold storage, so that losing the keystore doesn't orphan every credential it issued.
+type BackupServiceConfig struct {
+	// Whether backups are taken every interval, and the backup routes are served.
+	Enabled bool `toml:"enabled"`
+
+	// URL backups are written to: s3://bucket/prefix?region=us-east-1, gs://bucket/prefix, or file:///path for local
+	// development.
+	Destination string `toml:"destination"`
+
+	// Path of the Tink public keyset, as JSON, that backups are encrypted under. Its private keyset is kept offline, and
+	// is only needed to restore a backup.
+	PublicKeysetPath string `toml:"public_keyset_path"`
+
+	// Path of the credentials of the destination: an AWS shared credentials file for s3, and a service account JSON
+	// file for gs. The credentials of the environment are used when empty.
+	CredentialsPath string `toml:"credentials_path"`
+
+	// How often backups are taken, e.g. 24h. Daily when empty.
+	Interval time.Duration `toml:"interval"`
+
+	// Tenants whose keys and DIDs are backed up, besides the default tenant.
+	Tenants []string `toml:"tenants"`
+}
+
+// AnomalyServiceConfig configures detecting spikes in how much each key, and each tenant, signs, which may be a sign
+// of credentials being minted by someone who shouldn't. Each spike is alerted, and keys that spike can be frozen.
+type AnomalyServiceConfig struct {
+	// Whether signing is monitored, and the routes listing and lifting freezes are served.
+	Enabled bool `toml:"enabled"`
+
+	// Length of the windows signatures are counted in, e.g. 1m. A minute when empty.
+	Window time.Duration `toml:"window"`
+
+	// Number of windows the baseline of a key, or tenant, is averaged over. 60 when unset.
+	BaselineWindows int `toml:"baseline_windows"`
+
+	// How many times its baseline a key, or tenant, must sign in a window for it to spike. 10 when unset.
+	Threshold float64 `toml:"threshold"`
+
+	// Fewest signatures in a window that can spike, so that keys that rarely sign don't spike when they sign a few
+	// times. 100 when unset.
+	MinSignatures int64 `toml:"min_signatures"`
+
+	// How long keys that spike are frozen for, e.g. 15m, during which they can't sign. Spikes are only alerted when
+	// it's empty.
+	FreezeFor time.Duration `toml:"freeze_for"`
+
+	// URLs each spike is posted to, as JSON.
+	AlertURLs []string `toml:"alert_urls"`
+}
+
+// RetentionServiceConfig configures deleting credential applications, credential responses, and presentation
+// submissions once they've been kept as long as a rule says, unless they're under a legal hold.
+type RetentionServiceConfig struct {
+	// Whether the retention job runs every interval, and the retention routes are served.
+	Enabled bool `toml:"enabled"`
+
+	// How often the retention job runs, e.g. 6h. Daily when empty.
+	Interval time.Duration `toml:"interval"`
+
+	// How long each type of object is kept. Objects no rule matches are kept forever.
+	Rules []RetentionRuleConfig `toml:"rules"`
+
+	// URL objects are exported to before they're deleted: s3://bucket/prefix?region=us-east-1, gs://bucket/prefix, or
+	// file:///path for local development. Objects are deleted without being exported when empty.
+	ExportDestination string `toml:"export_destination"`
+
+	// Path of the credentials of the export destination: an AWS shared credentials file for s3, and a service account
+	// JSON file for gs. The credentials of the environment are used when empty.
+	CredentialsPath string `toml:"credentials_path"`
+
+	// Tenants whose objects are deleted, besides the default tenant.
+	Tenants []string `toml:"tenants"`
+}
+
+// RetentionRuleConfig is how long objects of a type, and optionally in a status, are kept.
+type RetentionRuleConfig struct {
+	// Type of the objects: application, response, or submission.
+	Type string `toml:"type"`
+
+	// Status the objects are in: pending, fulfilled, rejected, or cancelled for applications, and pending, approved,
+	// denied, or cancelled for submissions. Responses have none. Every status when empty, unless a rule for the status
+	// says otherwise.
+	Status string `toml:"status"`
+
+	// Days objects are kept in the status before they're deleted.
+	KeepDays int `toml:"keep_days"`
+}
+
+// ApprovalServiceConfig configures four-eyes approval of sensitive operations, which only run once an operator other
+// than the one requesting them approves.
+type ApprovalServiceConfig struct {
+	// Whether requests to the sensitive routes need approval, and the approval routes are served.
+	Enabled bool `toml:"enabled"`
+
+	// Routes that need approval. Restoring and downloading backups, erasing data subjects, and revoking keys when empty.
+	Routes []ApprovalRouteConfig `toml:"route"`
+
+	// How long approvals can be reviewed, and then used, after they're requested, e.g. 1h. A day when empty.
+	ExpiresAfter time.Duration `toml:"expires_after"`
+}
+
+type ApprovalRouteConfig struct {
+	// HTTP method of the route, e.g. DELETE.
+	Method string `toml:"method"`
+
+	// Path of the route as registered, e.g. /v1/keys/:id.
+	Path string `toml:"path"`
+}
+
+// AnchorServiceConfig configures anchoring digests of the state of critical namespaces to an external anchor, so that
+// changes to them after they were anchored are evident.
+type AnchorServiceConfig struct {
+	// Whether state is anchored every interval, and the anchor routes are served.
+	Enabled bool `toml:"enabled"`
+
+	// How often state is anchored, e.g. 6h. Daily when empty.
+	Interval time.Duration `toml:"interval"`
+
+	// Namespaces whose records are hashed. Credentials, status lists, and the audit log when empty.
+	Namespaces []string `toml:"namespaces"`
+
+	// Tenants whose namespaces are hashed, besides the default tenant.
+	Tenants []string `toml:"tenants"`
+
+	// Type of the anchor: rfc3161 for a timestamping authority, or opentimestamps for a calendar that commits to the
+	// bitcoin chain.
+	Type string `toml:"type"`
+
+	// URL of the timestamping authority, or of the opentimestamps calendar, e.g. https://freetsa.org/tsr or
+	// https://alice.btc.calendar.opentimestamps.org.
+	URL string `toml:"url"`
+
+	// Path of a PEM file with the root certificates timestamps of a timestamping authority are verified with. The
+	// system roots are used when empty.
+	CertificatesPath string `toml:"certificates_path"`
+
+	// How many anchors are kept, along with the hashes of the records they cover. Older anchors are deleted. Every
+	// anchor is kept when 0.
+	Keep int `toml:"keep"`
+}
+
+// ExpiryServiceConfig configures warning operators a while before keys, the certificates of the hosts of did:web DIDs,
+// and issued credentials expire, so that they're rotated, renewed, and reissued in time.
+type ExpiryServiceConfig struct {
+	// Whether expiries are checked every interval, and the expiry routes are served.
+	Enabled bool `toml:"enabled"`
+
+	// How often expiries are checked, e.g. 6h. Daily when empty.
+	Interval time.Duration `toml:"interval"`
+
+	// How long before they expire operators are warned, e.g. 336h. 30 days when empty.
+	WarnBefore time.Duration `toml:"warn_before"`
+
+	// How long after they're created keys are due to be rotated, e.g. 8760h. Keys don't expire when empty.
+	KeyMaxAge time.Duration `toml:"key_max_age"`
+
+	// Tenants whose keys, DIDs, and credentials are checked, besides the default tenant.
+	Tenants []string `toml:"tenants"`
+
+	// Whether credentials whose expiration date passed are recorded as expired at each check, suspended when their
+	// status list is for suspension, and published to the Credential Expire webhooks.
+	ExpireCredentials bool `toml:"expire_credentials"`
+
+	// URLs each warning is posted to, as JSON.
+	AlertURLs []string `toml:"alert_urls"`
+
+	// Where each warning is emailed. Warnings aren't emailed when the SMTP address is empty.
+	Email ExpiryEmailConfig `toml:"email"`
+}
+
+// ExpiryEmailConfig configures emailing expiry warnings through an SMTP server.
+type ExpiryEmailConfig struct {
+	// Address of the SMTP server, e.g. smtp.example.com:587.
+	SMTPAddress string `toml:"smtp_address"`
+
+	// Username and password the SMTP server is authenticated to with PLAIN, unless the username is empty. The password
+	// is overridden by the SMTP_PASSWORD environment variable, which is preferred.
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+
+	From string   `toml:"from"`
+	To   []string `toml:"to"`
+}
+
+// ReplayServiceConfig configures remembering the credential application and presentation submission JWTs, and the
+// request signatures, that were accepted, so that the same signed artifact isn't accepted twice, by any route.
+type ReplayServiceConfig struct {
+	// Whether JWTs and request signatures are rejected when they were accepted before.
+	Enabled bool `toml:"enabled"`
+
+	// How long accepted JWTs are remembered, e.g. 720h, or until they expire, when that's later. 30 days when empty.
+	TTL time.Duration `toml:"ttl"`
+
+	// How often what's no longer remembered is deleted, e.g. 1h. Hourly when empty.
+	PurgeInterval time.Duration `toml:"purge_interval"`
+}
+
+// ChallengeServiceConfig configures issuing challenges, single-use nonces bound to a presentation definition or a
+// credential manifest, which the presentation submission and credential application JWTs sent to the API must carry.
+type ChallengeServiceConfig struct {
+	// Whether challenges are issued, and required of submissions and applications.
+	Enabled bool `toml:"enabled"`
+
+	// How long challenges can be responded to, e.g. 5m. 5 minutes when empty.
+	TTL time.Duration `toml:"ttl"`
+
+	// How often expired challenges are deleted, e.g. 1h. Hourly when empty.
+	PurgeInterval time.Duration `toml:"purge_interval"`
+}
+
+// TrustServiceConfig configures the trust registry, the issuers operators trust to issue credentials of a type or
+// schema, which credential verification and the evaluation of presentation submissions consult.
+type TrustServiceConfig struct {
+	// Whether trusted issuers can be registered, and the issuers of credentials are checked against them.
+	Enabled bool `toml:"enabled"`
+}
+
+// OIDC4VCIServiceConfig configures issuing the credentials of manifests to wallets with OpenID for Verifiable
+// Credential Issuance, in the pre-authorized code flow.
+type OIDC4VCIServiceConfig struct {
+	// Whether credential offers can be created, and redeemed by wallets.
+	Enabled bool `toml:"enabled"`
+
+	// URL wallets know the issuer by, which its metadata is served under, e.g. https://issuer.example.com. The service
+	// endpoint when empty.
+	CredentialIssuer string `toml:"credential_issuer"`
+
+	// How long the pre-authorized code of an offer can be redeemed, e.g. 24h. A day when empty.
+	OfferTTL time.Duration `toml:"offer_ttl"`
+
+	// How long the access tokens wallets redeem codes for are valid, e.g. 10m. 10 minutes when empty.
+	AccessTokenTTL time.Duration `toml:"access_token_ttl"`
+
+	// How long the nonces wallets sign proofs of possession over are valid, e.g. 5m. 5 minutes when empty.
+	NonceTTL time.Duration `toml:"nonce_ttl"`
+}
+
+// OIDC4VPServiceConfig configures requesting presentations of the presentation definitions from wallets with OpenID
+// for Verifiable Presentations, and Self-Issued OpenID Provider v2 ID tokens.
+type OIDC4VPServiceConfig struct {
+	// Whether authorization requests can be created, and responded to by wallets.
+	Enabled bool `toml:"enabled"`
+
+	// URL wallets reach the service at, which responses are posted under, e.g. https://verifier.example.com. The
+	// service endpoint when empty.
+	BaseURL string `toml:"base_url"`
+
+	// How long wallets can respond to an authorization request, e.g. 10m. 10 minutes when empty.
+	RequestTTL time.Duration `toml:"request_ttl"`
+}
+
+// FaultsConfig injects latency and errors into storage and DID resolution, so that tests can check how the service
+// behaves when its dependencies are slow or fail, e.g. that requests retry, time out, or partially fail. Faults can't
+// be injected in the prod environment.
+type FaultsConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Seed of the random choice of which operations a fault is injected into, so that runs can be repeated. The current
+	// time is used when it's 0.
+	Seed int64 `toml:"seed"`
+
+	Faults []FaultConfig `toml:"fault"`
+}
+
+// FaultConfig is a fault injected into the operations it matches.
+type FaultConfig struct {
+	// Target is what the fault is injected into, storage or resolver.
+	Target string `toml:"target"`
+
+	// Operations of storage the fault is injected into, any of read, write, delete, and execute. Every operation when
+	// empty. Resolvers only resolve.
+	Operations []string `toml:"operations"`
+
+	// Match limits the fault to the storage namespaces, or to the methods of the DIDs resolved, e.g. web, it lists.
+	// Namespaces of tenants match without the prefix of the tenant. Every namespace or method when empty.
+	Match []string `toml:"match"`
+
+	// Rate is the probability, between 0 and 1, that the fault is injected into a matching operation. 1 when unset.
+	Rate float64 `toml:"rate"`
+
+	// Latency added to the matching operations, e.g. 500ms. Operations whose context is done while they wait fail with
+	// the error of the context.
+	Latency time.Duration `toml:"latency"`
+
+	// Error the matching operations fail with, after the latency. Operations don't fail when it's empty.
+	Error string `toml:"error"`
+}
+
+// LoadConfig attempts to load a TOML or YAML config file from the given path, and coerce it into our object model.
+// Before loading, defaults are applied on certain properties, which are overwritten if specified in the file. Values
+// set by environment variables, like SSI_SERVER_API_HOST, take precedence over the file. See EnvPrefix.
 func LoadConfig(path string, fs fs.FS) (*SSIServiceConfig, error) {
 	if fs == nil {
 		fs = os.DirFS(".")
@@ -266,8 +1081,8 @@ func LoadConfig(path string, fs fs.FS) (*SSIServiceConfig, error) {
 	if loadDefaultConfig {
 		defaultServicesConfig := getDefaultServicesConfig()
 		config.Services = defaultServicesConfig
-	} else if err = loadTOMLConfig(path, &config, fs); err != nil {
-		return nil, errors.Wrap(err, "load toml config")
+	} else if err = loadConfigFile(path, &config, fs); err != nil {
+		return nil, errors.Wrap(err, "load config file")
 	}
 
 	if err = applyEnvVariables(&config); err != nil {
@@ -282,10 +1097,16 @@ func LoadConfig(path string, fs fs.FS) (*SSIServiceConfig, error) {
 }
 
 func validateConfig(s *SSIServiceConfig) error {
+	if len(s.Services.EncryptedNamespaces) > 0 && s.Services.KeyStoreConfig.DisableEncryption {
+		return errors.New("namespaces are encrypted with the service key of the keystore, which can't disable key encryption")
+	}
 	if s.Server.Environment == EnvironmentProd {
 		if s.Services.KeyStoreConfig.DisableEncryption {
 			return errors.New("prod environment cannot disable key encryption")
 		}
+		if s.Services.Faults.Enabled {
+			return errors.New("prod environment cannot inject faults")
+		}
 		if s.Services.AppLevelEncryptionConfiguration.DisableEncryption {
 			logrus.Warn("prod environment detected without app level encryption. This is strongly discouraged.")
 		}
@@ -299,8 +1120,8 @@ func checkValidConfigPath(path string) (bool, error) {
 	if path == "" {
 		logrus.Info("no config path provided, loading default config...")
 		defaultConfig = true
-	} else if filepath.Ext(path) != Extension {
-		return false, fmt.Errorf("file extension for path %q must be %q", path, Extension)
+	} else if ext := filepath.Ext(path); ext != Extension && ext != YAMLExtension && ext != YMLExtension {
+		return false, fmt.Errorf("file extension for path %q must be one of %q, %q, or %q", path, Extension, YAMLExtension, YMLExtension)
 	}
 	return defaultConfig, nil
 }
@@ -353,6 +1174,7 @@ func getDefaultServicesConfig() ServicesConfig {
 		},
 		OperationConfig: OperationServiceConfig{
 			BaseServiceConfig: &BaseServiceConfig{Name: "operation", ServiceEndpoint: DefaultServiceEndpoint + "/v1/operations"},
+			RetentionPeriod:   "168h",
 		},
 		PresentationConfig: PresentationServiceConfig{
 			BaseServiceConfig: &BaseServiceConfig{Name: "presentation", ServiceEndpoint: DefaultServiceEndpoint + "/v1/presentations"},
@@ -367,16 +1189,26 @@ func getDefaultServicesConfig() ServicesConfig {
 			BaseServiceConfig: &BaseServiceConfig{Name: "webhook", ServiceEndpoint: DefaultServiceEndpoint + "/v1/webhooks"},
 			WebhookTimeout:    "10s",
 		},
+		AuthConfig: AuthServiceConfig{
+			BaseServiceConfig: &BaseServiceConfig{Name: "auth", ServiceEndpoint: DefaultServiceEndpoint + "/admin/apikeys"},
+		},
 	}
 }
 
-func loadTOMLConfig(path string, config *SSIServiceConfig, fs fs.FS) error {
-	// load from TOML file
+func loadConfigFile(path string, config *SSIServiceConfig, fs fs.FS) error {
+	// load from TOML or YAML file
 	file, err := fs.Open(path)
 	if err != nil {
 		return errors.Wrapf(err, "opening path %s", path)
 	}
-	if _, err := toml.NewDecoder(file).Decode(&config); err != nil {
+	defer file.Close()
+	var reader io.Reader = file
+	if ext := filepath.Ext(path); ext == YAMLExtension || ext == YMLExtension {
+		if reader, err = yamlToTOML(file); err != nil {
+			return errors.Wrapf(err, "could not convert yaml config: %s", path)
+		}
+	}
+	if _, err := toml.NewDecoder(reader).Decode(&config); err != nil {
 		return errors.Wrapf(err, "could not load config: %s", path)
 	}
 
@@ -437,29 +1269,70 @@ func loadTOMLConfig(path string, config *SSIServiceConfig, fs fs.FS) error {
 		}
 	}
 	services.WebhookConfig.ServiceEndpoint = endpoint + "/webhooks"
+	if services.AuthConfig.IsEmpty() {
+		services.AuthConfig = AuthServiceConfig{
+			BaseServiceConfig: new(BaseServiceConfig),
+		}
+	}
+	services.AuthConfig.ServiceEndpoint = services.ServiceEndpoint + "/admin/apikeys"
 	return nil
 }
 
+// yamlToTOML converts a YAML config to TOML. YAML configs use the same keys as TOML configs, so converting them means
+// they're decoded exactly the same way, including defaults and durations.
+func yamlToTOML(r io.Reader) (io.Reader, error) {
+	var values map[string]any
+	if err := yaml.NewDecoder(r).Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(err, "decoding yaml")
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, errors.Wrap(err, "encoding toml")
+	}
+	return &buf, nil
+}
+
+// setStorageOption replaces the value of a storage option that's set in the config.
+func setStorageOption(config *SSIServiceConfig, id storage.OptionKey, value string) {
+	for i := range config.Services.StorageOptions {
+		if config.Services.StorageOptions[i].ID == id {
+			config.Services.StorageOptions[i].Option = value
+			return
+		}
+	}
+}
+
+// applyEnvVariables overrides the values of the config with the environment variables that are set, including the
+// ones of the .env file, which doesn't replace variables that are already set.
 func applyEnvVariables(config *SSIServiceConfig) error {
 	if err := godotenv.Load(DefaultEnvPath); err != nil {
 		// The error indicates that the file or directory does not exist.
-		if os.IsNotExist(err) {
-			logrus.Info("no .env file found, skipping apply env variables...")
-			return nil
+		if !os.IsNotExist(err) {
+			return errors.Wrap(err, "dotenv parsing")
 		}
-		return errors.Wrap(err, "dotenv parsing")
+		logrus.Info("no .env file found, applying the env variables of the process")
+	}
+
+	if err := applyEnvOverrides(config, os.LookupEnv); err != nil {
+		return err
 	}
 
 	dbPassword, present := os.LookupEnv(DBPassword.String())
 	if present {
-		if len(config.Services.StorageOptions) != 0 {
-			for _, storageOption := range config.Services.StorageOptions {
-				if storageOption.ID == storage.PasswordOption {
-					storageOption.Option = dbPassword
-					break
-				}
-			}
-		}
+		setStorageOption(config, storage.PasswordOption, dbPassword)
+	}
+
+	// the connection string of a sql database holds its credentials, so it may be kept out of the config file
+	if dbConnectionString, present := os.LookupEnv(DBConnectionString.String()); present {
+		setStorageOption(config, storage.SQLConnectionString, dbConnectionString)
+	}
+
+	if adminAPIKeyHash, present := os.LookupEnv(AdminAPIKeyHash.String()); present {
+		config.Services.AuthConfig.AdminAPIKeyHash = adminAPIKeyHash
+	}
+
+	if smtpPassword, present := os.LookupEnv(SMTPPassword.String()); present {
+		config.Services.ExpiryConfig.Email.Password = smtpPassword
 	}
 
 	return nil
@@ -2,9 +2,13 @@ package config
 
 import (
 	"embed"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
 //go:embed testdata
@@ -24,9 +28,115 @@ func TestLoadConfig(t *testing.T) {
 		assert.NotEmpty(t, config.Services.StorageProvider)
 	})
 
+	t.Run("loads yaml files", func(t *testing.T) {
+		config, err := LoadConfig("testdata/test2.yaml", testdata)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "0.0.0.0:3001", config.Server.APIHost)
+		assert.Equal(t, 10*time.Second, config.Server.ReadTimeout)
+		assert.True(t, config.Server.RateLimit.Enabled)
+		assert.Len(t, config.Server.RateLimit.Routes, 1)
+		assert.Equal(t, "/v1/credentials", config.Server.RateLimit.Routes[0].Path)
+
+		assert.Equal(t, "bolt", config.Services.StorageProvider)
+		assert.Len(t, config.Services.StorageOptions, 1)
+		assert.Equal(t, []string{"key", "web"}, config.Services.DIDConfig.Methods)
+		assert.Equal(t, "http://localhost:3001/v1/dids", config.Services.DIDConfig.ServiceEndpoint)
+	})
+
+	t.Run("replaces storage options that are set", func(t *testing.T) {
+		config := SSIServiceConfig{Services: ServicesConfig{StorageOptions: []storage.Option{
+			{ID: storage.SQLConnectionString, Option: "host=localhost"},
+			{ID: storage.SQLDriverName, Option: "postgres"},
+		}}}
+		setStorageOption(&config, storage.SQLConnectionString, "host=db.example.com password=secret")
+		setStorageOption(&config, storage.PasswordOption, "secret")
+
+		assert.Equal(t, []storage.Option{
+			{ID: storage.SQLConnectionString, Option: "host=db.example.com password=secret"},
+			{ID: storage.SQLDriverName, Option: "postgres"},
+		}, config.Services.StorageOptions)
+	})
+
+	t.Run("returns errors for unsupported files", func(t *testing.T) {
+		_, err := LoadConfig("testdata/test1.json", testdata)
+		assert.ErrorContains(t, err, "file extension")
+	})
+
 	t.Run("returns errors when prod disables encryption", func(t *testing.T) {
 		_, err := LoadConfig("testdata/test1.toml", testdata)
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, "prod environment cannot disable key encryption")
 	})
+
+	t.Run("overrides values with environment variables", func(t *testing.T) {
+		config, err := LoadConfig("testdata/test2.yaml", testdata)
+		assert.NoError(t, err)
+		env := map[string]string{
+			"SSI_SERVER_API_HOST":                  "0.0.0.0:9000",
+			"SSI_SERVER_READ_TIMEOUT":              "30s",
+			"SSI_SERVER_RATE_LIMIT_ENABLED":        "false",
+			"SSI_SERVICES_STORAGE":                 "redis",
+			"SSI_SERVICES_DID_METHODS":             "key, web, ion",
+			"SSI_SERVICES_DID_NAME":                "dids",
+			"SSI_SERVICES_KEYSTORE_MASTER_KEY_URI": "aws-kms://arn:aws:kms:us-east-1:000000000000:key/1",
+			"SSI_SERVICES_ANCHOR_ENABLED":          "true",
+		}
+		lookup := func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}
+		assert.NoError(t, applyEnvOverrides(config, lookup))
+
+		assert.Equal(t, "0.0.0.0:9000", config.Server.APIHost)
+		assert.Equal(t, 30*time.Second, config.Server.ReadTimeout)
+		assert.False(t, config.Server.RateLimit.Enabled)
+		assert.Equal(t, "redis", config.Services.StorageProvider)
+		assert.Equal(t, []string{"key", "web", "ion"}, config.Services.DIDConfig.Methods)
+		assert.Equal(t, "dids", config.Services.DIDConfig.Name)
+		assert.Equal(t, "aws-kms://arn:aws:kms:us-east-1:000000000000:key/1", config.Services.KeyStoreConfig.MasterKeyURI)
+		assert.True(t, config.Services.AnchorConfig.Enabled)
+
+		env = map[string]string{"SSI_SERVER_READ_TIMEOUT": "soon"}
+		assert.ErrorContains(t, applyEnvOverrides(config, lookup), "parsing SSI_SERVER_READ_TIMEOUT")
+	})
+
+	t.Run("masks secrets when printing", func(t *testing.T) {
+		config := SSIServiceConfig{
+			Server: ServerConfig{APIHost: "0.0.0.0:3000", RateLimit: RateLimitConfig{RedisPassword: "redis secret"}},
+			Services: ServicesConfig{
+				StorageProvider: "postgres",
+				StorageOptions: []storage.Option{
+					{ID: storage.SQLConnectionString, Option: "host=localhost password=sql secret"},
+					{ID: storage.SQLDriverName, Option: "postgres"},
+				},
+				AuthConfig: AuthServiceConfig{AdminAPIKeyHash: "hash secret"},
+			},
+		}
+		var out strings.Builder
+		assert.NoError(t, config.Print(&out))
+
+		assert.Contains(t, out.String(), `api_host = "0.0.0.0:3000"`)
+		assert.Contains(t, out.String(), `Option = "postgres"`)
+		assert.NotContains(t, out.String(), "secret")
+		assert.Equal(t, "redis secret", config.Server.RateLimit.RedisPassword)
+	})
+
+	t.Run("returns errors when prod injects faults", func(t *testing.T) {
+		err := validateConfig(&SSIServiceConfig{
+			Server:   ServerConfig{Environment: EnvironmentProd},
+			Services: ServicesConfig{Faults: FaultsConfig{Enabled: true}},
+		})
+		assert.ErrorContains(t, err, "prod environment cannot inject faults")
+	})
+
+	t.Run("returns errors when namespaces are encrypted without key encryption", func(t *testing.T) {
+		err := validateConfig(&SSIServiceConfig{
+			Services: ServicesConfig{
+				EncryptedNamespaces: []string{"credential"},
+				KeyStoreConfig:      KeyStoreServiceConfig{EncryptionConfig: EncryptionConfig{DisableEncryption: true}},
+			},
+		})
+		assert.ErrorContains(t, err, "can't disable key encryption")
+	})
 }
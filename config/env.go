@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EnvPrefix prefixes the environment variables that override values of the config. Each is named after the path of
+// the value in the config, upper cased and joined by underscores, e.g. SSI_SERVER_API_HOST for api_host in [server],
+// and SSI_SERVICES_DID_METHODS for methods in [services.did]. Lists are separated by commas.
+const EnvPrefix = "SSI_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides sets the values of config that have an environment variable, as looked up by lookup, so that they
+// take precedence over the config file. Lists of tables, like storage options, and maps can't be overridden.
+func applyEnvOverrides(config *SSIServiceConfig, lookup func(string) (string, bool)) error {
+	_, err := overrideFields(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup)
+	return err
+}
+
+// overrideFields overrides the fields of the struct v whose environment variable is set, returning whether any was.
+func overrideFields(v reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	overridden := false
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := tomlKey(field)
+		if !ok {
+			continue
+		}
+		env := prefix
+		if name != "" {
+			env += "_" + strings.ToUpper(name)
+		}
+		set, err := overrideValue(v.Field(i), env, lookup)
+		if err != nil {
+			return false, err
+		}
+		overridden = overridden || set
+	}
+	return overridden, nil
+}
+
+func overrideValue(v reflect.Value, env string, lookup func(string) (string, bool)) (bool, error) {
+	switch {
+	case v.Type() == durationType:
+		value, present := lookup(env)
+		if !present {
+			return false, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return false, errors.Wrapf(err, "parsing %s", env)
+		}
+		v.SetInt(int64(d))
+		return true, nil
+	case v.Kind() == reflect.Struct:
+		return overrideFields(v, env, lookup)
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct:
+		// sections missing from the config are only created when one of their values is set
+		section := v
+		if v.IsNil() {
+			section = reflect.New(v.Type().Elem())
+		}
+		set, err := overrideFields(section.Elem(), env, lookup)
+		if set && v.IsNil() {
+			v.Set(section)
+		}
+		return set, err
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		value, present := lookup(env)
+		if !present {
+			return false, nil
+		}
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = reflect.Append(items, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(items)
+		return true, nil
+	}
+
+	value, present := lookup(env)
+	if !present {
+		return false, nil
+	}
+	if err := setScalar(v, value); err != nil {
+		return false, errors.Wrapf(err, "parsing %s", env)
+	}
+	return true, nil
+}
+
+func setScalar(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("values of type %s can't be set from the environment", v.Type())
+	}
+	return nil
+}
+
+// tomlKey returns the key of field in the config file, which is empty for embedded structs whose fields are keys of
+// the table embedding them. Fields that aren't in the config file aren't ok.
+func tomlKey(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" && field.Anonymous {
+		return "", true
+	}
+	return name, name != ""
+}
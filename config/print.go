@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const maskedValue = "********"
+
+var (
+	// secretKeys are the keys of the config values that are masked when it's printed.
+	secretKeys = map[string]bool{
+		"redis_password":     true,
+		"admin_api_key_hash": true,
+		"password":           true,
+	}
+
+	// secretStorageOptions are the storage options that are masked when the config is printed.
+	secretStorageOptions = map[storage.OptionKey]bool{
+		storage.PasswordOption:      true,
+		storage.SQLConnectionString: true,
+	}
+)
+
+// Print writes the config to w as TOML, with every value it's loaded with, including defaults and the values of
+// environment variables. Secrets, like passwords, are masked.
+func (c SSIServiceConfig) Print(w io.Writer) error {
+	// the config is copied by encoding and decoding it, so that masking it leaves c as it is
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return errors.Wrap(err, "encoding config")
+	}
+	var masked SSIServiceConfig
+	if _, err := toml.NewDecoder(&buf).Decode(&masked); err != nil {
+		return errors.Wrap(err, "copying config")
+	}
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+	for i, option := range masked.Services.StorageOptions {
+		if secretStorageOptions[option.ID] {
+			masked.Services.StorageOptions[i].Option = maskedValue
+		}
+	}
+	return errors.Wrap(toml.NewEncoder(w).Encode(masked), "encoding config")
+}
+
+// maskSecrets masks the values of the struct v, and of the structs it holds, that have a key of secretKeys.
+func maskSecrets(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, ok := tomlKey(field)
+		if !field.IsExported() || !ok {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		switch {
+		case value.Kind() == reflect.Struct:
+			maskSecrets(value)
+		case value.Kind() == reflect.String && secretKeys[name] && value.String() != "":
+			value.SetString(maskedValue)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package doc embeds the API specification generated by `mage spec`, so that it's served by the service wherever it
+// runs.
+package doc
+
+import (
+	_ "embed"
+)
+
+// SwaggerYAML is the Swagger 2.0 specification of the API, generated from the annotations of the router handlers.
+//
+//go:embed swagger.yaml
+var SwaggerYAML []byte
@@ -32,11 +32,19 @@ type Container struct {
 	// `fullyQualifiedVerificationMethodId`.
 	CredentialJWT *keyaccess.JWT `json:"credentialJwt,omitempty"`
 
+	// SD-JWT representation of `credential`, whose subject's claims are selectively disclosable. Verification can be
+	// done according to `fullyQualifiedVerificationMethodId`.
+	CredentialSDJWT *keyaccess.SDJWT `json:"credentialSdJwt,omitempty"`
+
 	// Whether this credential is currently revoked.
 	Revoked bool `json:"revoked,omitempty"`
 
 	// Whether this credential is currently suspended.
 	Suspended bool `json:"suspended,omitempty"`
+
+	// Whether this credential expired, which is recorded once its expiration date passes when expiry checks are
+	// enabled.
+	Expired bool `json:"expired,omitempty"`
 }
 
 func (c Container) JWTString() string {
@@ -44,11 +52,11 @@ func (c Container) JWTString() string {
 }
 
 func (c Container) IsValid() bool {
-	return c.Credential != nil && c.Credential.ID != "" && (c.HasDataIntegrityCredential() || c.HasJWTCredential())
+	return c.Credential != nil && c.Credential.ID != "" && c.HasSignedCredential()
 }
 
 func (c Container) HasSignedCredential() bool {
-	return c.HasDataIntegrityCredential() || c.HasJWTCredential()
+	return c.HasDataIntegrityCredential() || c.HasJWTCredential() || c.HasSDJWTCredential()
 }
 
 func (c Container) HasDataIntegrityCredential() bool {
@@ -59,6 +67,10 @@ func (c Container) HasJWTCredential() bool {
 	return c.CredentialJWT != nil
 }
 
+func (c Container) HasSDJWTCredential() bool {
+	return c.CredentialSDJWT != nil
+}
+
 // NewCredentialContainerFromJWT attempts to parse a VC-JWT credential from a string into a Container
 func NewCredentialContainerFromJWT(credentialJWT string) (*Container, error) {
 	_, _, cred, err := parsing.ToCredential(credentialJWT)
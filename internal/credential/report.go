@@ -0,0 +1,203 @@
+package credential
+
+import (
+	"context"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	statussdk "github.com/TBD54566975/ssi-sdk/credential/status"
+	"github.com/TBD54566975/ssi-sdk/credential/validation"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+// Checks run when verifying a credential, as named in a Report.
+const (
+	CheckSignature = "signature"
+	CheckDataModel = "dataModel"
+	CheckExpiry    = "expiry"
+	CheckSchema    = "schema"
+	CheckStatus    = "status"
+	// CheckKeyBinding is only run on SD-JWT credentials.
+	CheckKeyBinding = "keyBinding"
+	// CheckTrust is only run when the validator has an IssuerTrust.
+	CheckTrust = "trust"
+)
+
+// CheckResult is how one check of a credential went.
+type CheckResult struct {
+	// Name of the check: signature, keyBinding, dataModel, expiry, schema, status, or trust.
+	Check string `json:"check"`
+
+	// Whether the credential passed the check.
+	Passed bool `json:"passed"`
+
+	// Why the credential didn't pass the check.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report describes how verifying a credential went, check by check. Checks that don't apply to a credential, like the
+// schema check of a credential without a schema, are left out.
+type Report struct {
+	Verified bool
+	Checks   []CheckResult
+
+	// Reason of the first check the credential didn't pass.
+	Reason string
+
+	// Whether the credential is set in its status list for revocation, or for suspension, which is only known when its
+	// status was checked. A suspended credential can be reinstated, while a revoked one is usually revoked for good.
+	Revoked   bool
+	Suspended bool
+}
+
+func (r *Report) add(check string, err error) {
+	result := CheckResult{Check: check, Passed: err == nil}
+	if err != nil {
+		result.Reason = err.Error()
+		if r.Verified {
+			r.Reason = result.Reason
+		}
+		r.Verified = false
+	}
+	r.Checks = append(r.Checks, result)
+}
+
+// StatusListResolution fetches the status list credentials that credentials point to from their credentialStatus.
+type StatusListResolution interface {
+	ResolveStatusList(ctx context.Context, id string) (*Container, error)
+}
+
+// IssuerTrust decides whether the issuers of credentials are trusted to issue them.
+type IssuerTrust interface {
+	// CheckIssuer returns why the issuer of the credential isn't trusted to issue a credential of its types or schema,
+	// or nil when it is.
+	CheckIssuer(ctx context.Context, credential credsdk.VerifiableCredential) error
+}
+
+// Report verifies a credential, which may have been issued by anyone, and reports how each check went rather than
+// stopping at the first that fails. Its signature is checked against the keys of its issuer's DID, it's checked
+// against the VC Data Model, its expiry, and its schema when it has one, and, when it has a StatusList2021Entry and
+// statusLists is set, its status is looked up in its status list. Its issuer is checked against the IssuerTrust of the
+// validator, when it has one.
+func (v Validator) Report(ctx context.Context, credential Container, statusLists StatusListResolution) Report {
+	if credential.HasSDJWTCredential() {
+		return v.ReportSDJWT(ctx, *credential.CredentialSDJWT, keyaccess.KeyBinding{}, statusLists)
+	}
+	report := Report{Verified: true}
+
+	cred := credential.Credential
+	if credential.HasJWTCredential() {
+		report.add(CheckSignature, v.verifyJWTSignature(ctx, *credential.CredentialJWT))
+		_, _, parsed, err := integrity.ParseVerifiableCredentialFromJWT(credential.CredentialJWT.String())
+		if err != nil {
+			// a JWT that can't be parsed has already failed the signature check
+			return report
+		}
+		cred = parsed
+	} else {
+		report.add(CheckSignature, v.verifyDataIntegritySignature(ctx, *cred))
+	}
+	v.reportClaims(ctx, &report, *cred, statusLists)
+	return report
+}
+
+// ReportSDJWT verifies an SD-JWT credential like Report does, with only the claims it discloses. Its key binding is
+// checked too: when it's bound to a holder's key, it must be presented with a key binding JWT signed by the key, for
+// the binding's audience and nonce.
+func (v Validator) ReportSDJWT(ctx context.Context, token keyaccess.SDJWT, binding keyaccess.KeyBinding, statusLists StatusListResolution) Report {
+	report := Report{Verified: true}
+
+	cred, claims, err := keyaccess.ParseSDJWTCredential(token)
+	if err != nil {
+		// an SD-JWT whose disclosures don't match what the issuer signed fails the signature check
+		report.add(CheckSignature, err)
+		return report
+	}
+	report.add(CheckSignature, v.verifySDJWTSignature(ctx, token, cred.IssuerID()))
+	report.add(CheckKeyBinding, keyaccess.VerifyKeyBinding(token, claims, binding))
+	v.reportClaims(ctx, &report, *cred, statusLists)
+	return report
+}
+
+// reportClaims adds the checks of what a credential claims, once its signature is checked, to a report.
+func (v Validator) reportClaims(ctx context.Context, report *Report, cred credsdk.VerifiableCredential, statusLists StatusListResolution) {
+	report.add(CheckDataModel, validation.ValidateCredential(cred))
+	report.add(CheckExpiry, validation.ValidateExpiry(cred))
+	if cred.CredentialSchema != nil {
+		report.add(CheckSchema, v.validateSchema(ctx, cred))
+	}
+	if cred.CredentialStatus != nil && statusLists != nil {
+		set, err := v.checkStatus(ctx, cred, statusLists)
+		report.add(CheckStatus, err)
+		report.Revoked = set == statussdk.StatusRevocation
+		report.Suspended = set == statussdk.StatusSuspension
+	}
+	if v.trust != nil {
+		report.add(CheckTrust, v.trust.CheckIssuer(ctx, cred))
+	}
+}
+
+// validateSchema checks that the credential conforms to its schema.
+func (v Validator) validateSchema(ctx context.Context, credential credsdk.VerifiableCredential) error {
+	opts, err := v.schemaOptions(ctx, credential)
+	if err != nil {
+		return err
+	}
+	return validation.ValidateJSONSchema(credential, opts...)
+}
+
+// checkStatus fails when the credential is set in its status list, which is when it has been revoked or suspended, and
+// returns the purpose of the status list when it's set in it.
+func (v Validator) checkStatus(ctx context.Context, credential credsdk.VerifiableCredential, statusLists StatusListResolution) (statussdk.StatusPurpose, error) {
+	statusBytes, err := json.Marshal(credential.CredentialStatus)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling credential status")
+	}
+	var entry statussdk.StatusList2021Entry
+	if err = json.Unmarshal(statusBytes, &entry); err != nil {
+		return "", errors.Wrap(err, "parsing credential status")
+	}
+	if entry.Type != statussdk.StatusList2021EntryType {
+		return "", errors.Errorf("unsupported credential status type: %s", entry.Type)
+	}
+	if entry.StatusListCredential == "" {
+		return "", errors.New("credential status has no status list credential")
+	}
+
+	statusList, err := statusLists.ResolveStatusList(ctx, entry.StatusListCredential)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolving status list credential<%s>", entry.StatusListCredential)
+	}
+	// the status list is read from what was signed, which is the claims of a JWT
+	statusListCredential := statusList.Credential
+	if statusList.HasJWTCredential() {
+		err = v.verifyJWTSignature(ctx, *statusList.CredentialJWT)
+		if err == nil {
+			_, _, statusListCredential, err = integrity.ParseVerifiableCredentialFromJWT(statusList.CredentialJWT.String())
+		}
+	} else if statusListCredential != nil {
+		err = v.verifyDataIntegritySignature(ctx, *statusListCredential)
+	} else {
+		err = errors.New("no credential")
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "verifying status list credential<%s>", entry.StatusListCredential)
+	}
+
+	// the entry is passed as a struct, which the SDK reads without the type assertions it makes on maps
+	credential.CredentialStatus = entry
+	set, err := statussdk.ValidateCredentialInStatusList(credential, *statusListCredential)
+	if err != nil {
+		return "", errors.Wrapf(err, "looking up credential in status list credential<%s>", entry.StatusListCredential)
+	}
+	if !set {
+		return "", nil
+	}
+	if entry.StatusPurpose == statussdk.StatusSuspension {
+		return entry.StatusPurpose, errors.New("credential is suspended")
+	}
+	return entry.StatusPurpose, errors.New("credential is revoked")
+}
@@ -2,6 +2,7 @@ package credential
 
 import (
 	"context"
+	gocrypto "crypto"
 	"fmt"
 
 	credsdk "github.com/TBD54566975/ssi-sdk/credential"
@@ -17,6 +18,7 @@ import (
 	"github.com/pkg/errors"
 
 	didint "github.com/tbd54566975/ssi-service/internal/did"
+	"github.com/tbd54566975/ssi-service/internal/jsonld"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/internal/schema"
 )
@@ -25,6 +27,12 @@ type Validator struct {
 	validator      *validation.CredentialValidator
 	didResolver    resolution.Resolver
 	schemaResolver schema.Resolution
+
+	// contexts canonicalizes credentials secured with JSON-LD Data Integrity proofs, like Ed25519Signature2020.
+	contexts *jsonld.ContextLoader
+
+	// trust checks the issuers of the credentials being reported on, when it's set.
+	trust IssuerTrust
 }
 
 // NewCredentialValidator creates a new credential validator which executes both signature and static verification checks.
@@ -42,19 +50,34 @@ func NewCredentialValidator(didResolver resolution.Resolver, schemaResolver sche
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create static credential validator")
 	}
+	contexts, err := jsonld.NewContextLoader(nil, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create JSON-LD context loader")
+	}
 	return &Validator{
 		validator:      validator,
 		didResolver:    didResolver,
 		schemaResolver: schemaResolver,
+		contexts:       contexts,
 	}, nil
 }
 
+// SetContextLoader sets the loader of the JSON-LD contexts of credentials secured with JSON-LD Data Integrity proofs,
+// which by default only loads the contexts that ship with the service and fetches the others.
+func (v *Validator) SetContextLoader(contexts *jsonld.ContextLoader) {
+	v.contexts = contexts
+}
+
+// SetIssuerTrust makes reports check that the issuers of credentials are trusted to issue them.
+func (v *Validator) SetIssuerTrust(trust IssuerTrust) {
+	v.trust = trust
+}
+
 // VerifyJWTCredential first parses and checks the signature on the given JWT credential. Next, it runs
 // a set of static verification checks on the credential as per the credential service's configuration.
 func (v Validator) VerifyJWTCredential(ctx context.Context, token keyaccess.JWT) error {
-	_, err := integrity.VerifyJWTCredential(ctx, token.String(), v.didResolver)
-	if err != nil {
-		return errors.Wrap(err, "verifying JWT credential")
+	if err := v.verifyJWTSignature(ctx, token); err != nil {
+		return err
 	}
 	_, _, cred, err := integrity.ParseVerifiableCredentialFromJWT(token.String())
 	if err != nil {
@@ -63,7 +86,51 @@ func (v Validator) VerifyJWTCredential(ctx context.Context, token keyaccess.JWT)
 	return v.staticValidationChecks(ctx, *cred)
 }
 
+// verifyJWTSignature checks the signature of a JWT credential against the keys of its issuer's DID.
+func (v Validator) verifyJWTSignature(ctx context.Context, token keyaccess.JWT) error {
+	if _, err := integrity.VerifyJWTCredential(ctx, token.String(), v.didResolver); err != nil {
+		return errors.Wrap(err, "verifying JWT credential")
+	}
+	return nil
+}
+
+// verifySDJWTSignature checks the signature of the issuer-signed JWT of an SD-JWT credential against the keys of its
+// issuer's DID.
+func (v Validator) verifySDJWTSignature(ctx context.Context, token keyaccess.SDJWT, issuer string) error {
+	issuerJWT, err := token.IssuerJWT()
+	if err != nil {
+		return err
+	}
+	headers, err := keyaccess.GetJWTHeaders([]byte(issuerJWT))
+	if err != nil {
+		return errors.Wrap(err, "parsing issuer-signed JWT")
+	}
+	if err = didint.VerifyTokenFromDID(ctx, v.didResolver, issuer, headers.KeyID(), issuerJWT); err != nil {
+		return errors.Wrap(err, "verifying SD-JWT credential")
+	}
+	return nil
+}
+
+// VerifySDJWTCredential checks the signatures of an SD-JWT credential, of its issuer and, when it's bound to a
+// holder's key, of its key binding, and runs the static verification checks on the claims it discloses.
+func (v Validator) VerifySDJWTCredential(ctx context.Context, token keyaccess.SDJWT, binding keyaccess.KeyBinding) error {
+	cred, claims, err := keyaccess.ParseSDJWTCredential(token)
+	if err != nil {
+		return errors.Wrap(err, "parsing SD-JWT credential")
+	}
+	if err = v.verifySDJWTSignature(ctx, token, cred.IssuerID()); err != nil {
+		return err
+	}
+	if err = keyaccess.VerifyKeyBinding(token, claims, binding); err != nil {
+		return errors.Wrap(err, "verifying key binding")
+	}
+	return v.staticValidationChecks(ctx, *cred)
+}
+
 func (v Validator) Verify(ctx context.Context, credential Container) error {
+	if credential.HasSDJWTCredential() {
+		return v.VerifySDJWTCredential(ctx, *credential.CredentialSDJWT, keyaccess.KeyBinding{})
+	}
 	if credential.HasJWTCredential() {
 		err := v.VerifyJWTCredential(ctx, *credential.CredentialJWT)
 		if err != nil {
@@ -80,24 +147,34 @@ func (v Validator) Verify(ctx context.Context, credential Container) error {
 // VerifyDataIntegrityCredential first checks the signature on the given data integrity credential. Next, it runs
 // a set of static verification checks on the credential as per the credential service's configuration.
 func (v Validator) VerifyDataIntegrityCredential(ctx context.Context, credential credsdk.VerifiableCredential) error {
-	// resolve the issuer's key material
-	issuer, ok := credential.Issuer.(string)
-	if !ok {
-		return sdkutil.LoggingNewErrorf("could not convert issuer to string: %v", credential.Issuer)
+	if err := v.verifyDataIntegritySignature(ctx, credential); err != nil {
+		return err
 	}
+	return v.staticValidationChecks(ctx, credential)
+}
 
-	maybeVerificationMethod, err := getKeyFromProof(*credential.Proof, "verificationMethod")
+// verifyDataIntegritySignature checks the proof of a data integrity credential against the keys of its issuer's DID.
+func (v Validator) verifyDataIntegritySignature(ctx context.Context, credential credsdk.VerifiableCredential) error {
+	issuer, verificationMethod, pubKey, err := v.resolveProofKey(ctx, credential)
 	if err != nil {
-		return sdkutil.LoggingErrorMsg(err, "could not get verification method from proof")
-	}
-	verificationMethod, ok := maybeVerificationMethod.(string)
-	if !ok {
-		return sdkutil.LoggingNewErrorf("could not convert verification method to string: %v", maybeVerificationMethod)
+		return err
 	}
 
-	pubKey, err := didint.ResolveKeyForDID(ctx, v.didResolver, issuer, verificationMethod)
-	if err != nil {
-		return sdkutil.LoggingError(err)
+	// JSON-LD suites, rather than JsonWebSignature2020
+	var verifyLD func(credsdk.VerifiableCredential, gocrypto.PublicKey, keyaccess.Canonicalizer) error
+	switch {
+	case keyaccess.IsEd25519Signature2020(credential.Proof):
+		verifyLD = keyaccess.VerifyEd25519Signature2020
+	case keyaccess.IsBbsBlsSignature2020(credential.Proof):
+		verifyLD = keyaccess.VerifyBbsBlsSignature2020
+	case keyaccess.IsBbsBlsSignatureProof2020(credential.Proof):
+		verifyLD = keyaccess.VerifyBbsBlsSignatureProof2020
+	}
+	if verifyLD != nil {
+		if err = verifyLD(credential, pubKey, v.contexts); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "could not verify the credential's signature")
+		}
+		return nil
 	}
 
 	// construct a signature validator from the verification information
@@ -116,8 +193,53 @@ func (v Validator) VerifyDataIntegrityCredential(ctx context.Context, credential
 	if err = cryptoSuite.Verify(verifier, &credential); err != nil {
 		return sdkutil.LoggingErrorMsg(err, "could not verify the credential's signature")
 	}
+	return nil
+}
+
+// resolveProofKey resolves the key of the verification method of a data integrity credential's proof, from its
+// issuer's DID.
+func (v Validator) resolveProofKey(ctx context.Context, credential credsdk.VerifiableCredential) (issuer, verificationMethod string, pubKey gocrypto.PublicKey, err error) {
+	if credential.Proof == nil {
+		return "", "", nil, sdkutil.LoggingNewError("credential has no proof")
+	}
 
-	return v.staticValidationChecks(ctx, credential)
+	// resolve the issuer's key material
+	issuer, ok := credential.Issuer.(string)
+	if !ok {
+		return "", "", nil, sdkutil.LoggingNewErrorf("could not convert issuer to string: %v", credential.Issuer)
+	}
+
+	maybeVerificationMethod, err := getKeyFromProof(*credential.Proof, "verificationMethod")
+	if err != nil {
+		return "", "", nil, sdkutil.LoggingErrorMsg(err, "could not get verification method from proof")
+	}
+	verificationMethod, ok = maybeVerificationMethod.(string)
+	if !ok {
+		return "", "", nil, sdkutil.LoggingNewErrorf("could not convert verification method to string: %v", maybeVerificationMethod)
+	}
+
+	pubKey, err = didint.ResolveKeyForDID(ctx, v.didResolver, issuer, verificationMethod)
+	if err != nil {
+		return "", "", nil, sdkutil.LoggingError(err)
+	}
+	return issuer, verificationMethod, pubKey, nil
+}
+
+// DeriveBBSCredential derives, from a credential secured with a BbsBlsSignature2020 proof, a credential which only
+// reveals the given fields of its subject, with a proof made with the nonce. The credential is verified first, so that
+// nothing is derived from a credential that doesn't verify.
+func (v Validator) DeriveBBSCredential(ctx context.Context, credential credsdk.VerifiableCredential, reveal []string, nonce []byte) (*credsdk.VerifiableCredential, error) {
+	if !keyaccess.IsBbsBlsSignature2020(credential.Proof) {
+		return nil, errors.Errorf("credential is not secured with a %s proof", keyaccess.BbsBlsSignature2020Type)
+	}
+	_, _, pubKey, err := v.resolveProofKey(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+	if err = keyaccess.VerifyBbsBlsSignature2020(credential, pubKey, v.contexts); err != nil {
+		return nil, errors.Wrap(err, "verifying the credential's signature")
+	}
+	return keyaccess.DeriveBbsBlsSignatureProof2020(credential, reveal, nonce, pubKey, v.contexts)
 }
 
 func getKeyFromProof(proof crypto.Proof, key string) (any, error) {
@@ -170,19 +292,9 @@ func (v Validator) VerifyJWT(ctx context.Context, did string, token keyaccess.JW
 // staticValidationChecks runs a set of static validation checks on the credential as per the credential
 // service's configuration, such as checking the credential's schema, expiration, and object validity.
 func (v Validator) staticValidationChecks(ctx context.Context, credential credsdk.VerifiableCredential) error {
-	// if the credential has a schema, resolve it before it is to be used in verification
-	var validationOpts []validation.Option
-	if credential.CredentialSchema != nil {
-		schemaID := credential.CredentialSchema.ID
-		resolvedSchema, _, err := v.schemaResolver.Resolve(ctx, schemaID)
-		if err != nil {
-			return errors.Wrapf(err, "for credential<%s> failed to resolve schemas: %s", credential.ID, schemaID)
-		}
-		schemaBytes, err := json.Marshal(resolvedSchema)
-		if err != nil {
-			return errors.Wrapf(err, "for credential<%s> failed to marshal schema: %s", credential.ID, schemaID)
-		}
-		validationOpts = append(validationOpts, validation.WithSchema(string(schemaBytes)))
+	validationOpts, err := v.schemaOptions(ctx, credential)
+	if err != nil {
+		return err
 	}
 
 	// run the configured static checks on the credential
@@ -192,3 +304,20 @@ func (v Validator) staticValidationChecks(ctx context.Context, credential credsd
 
 	return nil
 }
+
+// schemaOptions resolves the schema of the credential, if it has one, into the options its validation needs.
+func (v Validator) schemaOptions(ctx context.Context, credential credsdk.VerifiableCredential) ([]validation.Option, error) {
+	if credential.CredentialSchema == nil {
+		return nil, nil
+	}
+	schemaID := credential.CredentialSchema.ID
+	resolvedSchema, _, err := v.schemaResolver.Resolve(ctx, schemaID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "for credential<%s> failed to resolve schemas: %s", credential.ID, schemaID)
+	}
+	schemaBytes, err := json.Marshal(resolvedSchema)
+	if err != nil {
+		return nil, errors.Wrapf(err, "for credential<%s> failed to marshal schema: %s", credential.ID, schemaID)
+	}
+	return []validation.Option{validation.WithSchema(string(schemaBytes))}, nil
+}
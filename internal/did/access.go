@@ -5,9 +5,12 @@ import (
 	"crypto"
 	"github.com/sirupsen/logrus"
 
+	"github.com/TBD54566975/ssi-sdk/cryptosuite"
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
 	"github.com/TBD54566975/ssi-sdk/util"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/mr-tron/base58"
 	"github.com/pkg/errors"
 
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
@@ -21,17 +24,54 @@ func ResolveKeyForDID(ctx context.Context, resolver resolution.Resolver, did, ki
 		return nil, err
 	}
 
-	logrus.Debugln("ResolveKeyForDID kid %d", kid)
+	logrus.Debugf("ResolveKeyForDID kid %s", kid)
 
 	// next, get the verification information (key) from the did document
 	pubKey, err = didsdk.GetKeyFromVerificationMethod(resolved.Document, kid)
 	if err != nil {
+		// the SDK doesn't support BLS12381G2 keys, which BbsBlsSignature2020 proofs are verified with
+		if bbsKey, ok, bbsErr := getBBSKeyFromVerificationMethod(resolved.Document, kid); ok {
+			return bbsKey, bbsErr
+		}
 		err = errors.Wrapf(err, "getting verification information from DID Document: %s", did)
 		return nil, err
 	}
 	return pubKey, err
 }
 
+// getBBSKeyFromVerificationMethod gets the BLS12381G2 key of the verification method of a DID document for a given
+// KID, and returns false when the verification method doesn't have one.
+func getBBSKeyFromVerificationMethod(document didsdk.Document, kid string) (crypto.PublicKey, bool, error) {
+	for _, method := range document.VerificationMethod {
+		if method.ID != kid && method.ID != document.ID+kid && method.ID != document.ID+"#"+kid {
+			continue
+		}
+		var pubKeyBytes []byte
+		var err error
+		switch {
+		case method.PublicKeyJWK != nil:
+			return keyaccess.PublicKeyJWKToBBSPublicKey(*method.PublicKeyJWK)
+		case method.Type != cryptosuite.BLS12381G2Key2020:
+			return nil, false, nil
+		case method.PublicKeyBase58 != "":
+			pubKeyBytes, err = base58.Decode(method.PublicKeyBase58)
+		case method.PublicKeyMultibase != "":
+			pubKeyBytes, err = didsdk.MultiBaseToPubKeyBytes(method.PublicKeyMultibase)
+		default:
+			return nil, true, errors.Errorf("verification method<%s> has no public key", method.ID)
+		}
+		if err != nil {
+			return nil, true, errors.Wrapf(err, "decoding key of verification method<%s>", method.ID)
+		}
+		pubKey, err := bbsg2.UnmarshalPublicKey(pubKeyBytes)
+		if err != nil {
+			return nil, true, errors.Wrapf(err, "parsing key of verification method<%s>", method.ID)
+		}
+		return pubKey, true, nil
+	}
+	return nil, false, nil
+}
+
 // VerifyTokenFromDID verifies that the information in the token was digitally signed by the public key associated with
 // the public key of the verification method of the did's document. The passed in resolver is used to map from the did
 // to the did document.
@@ -41,9 +81,9 @@ func VerifyTokenFromDID(ctx context.Context, resolver resolution.Resolver, did,
 	if err != nil {
 		return errors.Wrapf(err, "resolving DID: %s", did)
 	}
-	logrus.Debugln("did IsEmpty %d", resolved.Document.IsEmpty())
-	logrus.Debugln("len verificationMethod %d", len(resolved.Document.VerificationMethod))
-	logrus.Debugln("get resolved %s", kid)
+	logrus.Debugf("did IsEmpty %t", resolved.Document.IsEmpty())
+	logrus.Debugf("len verificationMethod %d", len(resolved.Document.VerificationMethod))
+	logrus.Debugf("get resolved %s", kid)
 
 	// get the verification information from the DID document
 	pubKey, err := didsdk.GetKeyFromVerificationMethod(resolved.Document, kid)
@@ -0,0 +1,181 @@
+// Package faults injects latency and errors into storage and DID resolution, so that tests can check how the service
+// behaves when its dependencies are slow or fail.
+package faults
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// What faults are injected into.
+const (
+	TargetStorage  = "storage"
+	TargetResolver = "resolver"
+)
+
+// Operations of storage faults are injected into.
+const (
+	OperationRead    = "read"
+	OperationWrite   = "write"
+	OperationDelete  = "delete"
+	OperationExecute = "execute"
+
+	operationResolve = "resolve"
+)
+
+// ErrInjected is wrapped by the errors of the faults that are injected, so tests can tell them from real failures.
+var ErrInjected = errors.New("injected fault")
+
+// Injector decides which operations faults are injected into, and injects them. Its faults can be updated while the
+// service is running, so tests can make a dependency fail, then recover.
+type Injector struct {
+	mu     sync.Mutex
+	rand   *rand.Rand
+	faults []config.FaultConfig
+}
+
+// NewInjector creates an Injector that injects the configured faults.
+func NewInjector(cfg config.FaultsConfig) (*Injector, error) {
+	var i Injector
+	if err := i.Update(cfg); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// Update replaces the faults that are injected with the configured ones. No faults are injected when faults aren't
+// enabled.
+func (i *Injector) Update(cfg config.FaultsConfig) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	var faults []config.FaultConfig
+	if cfg.Enabled {
+		for _, fault := range cfg.Faults {
+			if fault.Rate == 0 {
+				fault.Rate = 1
+			}
+			faults = append(faults, fault)
+		}
+		logrus.Warnf("injecting %d faults into storage and did resolution", len(faults))
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rand = rand.New(rand.NewSource(seed)) // #nosec: faults don't need to be unpredictable
+	i.faults = faults
+	return nil
+}
+
+// Validate returns an error when a fault of the config can't be injected.
+func Validate(cfg config.FaultsConfig) error {
+	for _, fault := range cfg.Faults {
+		if err := validate(fault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validate(fault config.FaultConfig) error {
+	switch fault.Target {
+	case TargetStorage:
+		for _, operation := range fault.Operations {
+			switch operation {
+			case OperationRead, OperationWrite, OperationDelete, OperationExecute:
+			default:
+				return errors.Errorf("unknown storage operation of fault: %s", operation)
+			}
+		}
+	case TargetResolver:
+		if len(fault.Operations) > 0 {
+			return errors.New("faults of resolvers can't have operations")
+		}
+	default:
+		return errors.Errorf("fault target must be %s or %s, not %s", TargetStorage, TargetResolver, fault.Target)
+	}
+	if fault.Rate < 0 || fault.Rate > 1 {
+		return errors.Errorf("fault rate must be between 0 and 1, not %v", fault.Rate)
+	}
+	if fault.Latency < 0 {
+		return errors.Errorf("fault latency can't be negative: %s", fault.Latency)
+	}
+	return nil
+}
+
+// inject injects the faults that match an operation of a target on a namespace or DID method, waiting out their
+// latency, then returning the first of their errors.
+func (i *Injector) inject(ctx context.Context, target, operation string, matches ...string) error {
+	latency, injected := i.choose(target, operation, matches)
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	if injected != "" {
+		return errors.Wrap(ErrInjected, injected)
+	}
+	return nil
+}
+
+// choose returns the latency and the error, if any, of the faults injected into an operation.
+func (i *Injector) choose(target, operation string, matches []string) (time.Duration, string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var latency time.Duration
+	var injected string
+	for _, fault := range i.faults {
+		if fault.Target != target || !contains(fault.Operations, operation) || !matchesAny(fault.Match, matches) {
+			continue
+		}
+		if fault.Rate < 1 && i.rand.Float64() >= fault.Rate {
+			continue
+		}
+		latency += fault.Latency
+		if injected == "" {
+			injected = fault.Error
+		}
+	}
+	return latency, injected
+}
+
+// contains returns whether values contains value, or is empty.
+func contains(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny returns whether patterns contains any of values, or is empty.
+func matchesAny(patterns, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if contains(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package faults
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func newStorage(t *testing.T, cfg config.FaultsConfig) (*Storage, *Injector) {
+	db, err := storage.NewStorage(storage.Bolt, storage.Option{
+		ID:     storage.BoltDBFilePathOption,
+		Option: filepath.Join(t.TempDir(), "faults.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	injector, err := NewInjector(cfg)
+	require.NoError(t, err)
+	return NewStorage(db, injector), injector
+}
+
+func TestStorageFaults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("injects errors into the matching operations and namespaces", func(tt *testing.T) {
+		db, _ := newStorage(tt, config.FaultsConfig{
+			Enabled: true,
+			Faults: []config.FaultConfig{{
+				Target:     TargetStorage,
+				Operations: []string{OperationWrite},
+				Match:      []string{"credential"},
+				Error:      "disk full",
+			}},
+		})
+
+		err := db.Write(ctx, "credential", "id", []byte("value"))
+		assert.ErrorIs(tt, err, ErrInjected)
+		assert.ErrorContains(tt, err, "disk full")
+
+		// namespaces of tenants match without their prefix
+		err = db.Write(ctx, "tenants/acme/credential", "id", []byte("value"))
+		assert.ErrorIs(tt, err, ErrInjected)
+
+		assert.NoError(tt, db.Write(ctx, "schema", "id", []byte("value")))
+		_, err = db.Read(ctx, "credential", "id")
+		assert.NoError(tt, err)
+	})
+
+	t.Run("injects faults at the configured rate", func(tt *testing.T) {
+		cfg := config.FaultsConfig{
+			Enabled: true,
+			Seed:    42,
+			Faults:  []config.FaultConfig{{Target: TargetStorage, Rate: 0.5, Error: "flaky"}},
+		}
+		failures := func() []bool {
+			db, _ := newStorage(tt, cfg)
+			var failed []bool
+			for i := 0; i < 100; i++ {
+				_, err := db.Read(ctx, "credential", "id")
+				failed = append(failed, err != nil)
+			}
+			return failed
+		}
+
+		first := failures()
+		count := 0
+		for _, failed := range first {
+			if failed {
+				count++
+			}
+		}
+		assert.Greater(tt, count, 25)
+		assert.Less(tt, count, 75)
+
+		// the same seed fails the same operations
+		assert.Equal(tt, first, failures())
+	})
+
+	t.Run("adds latency until the context is done", func(tt *testing.T) {
+		db, _ := newStorage(tt, config.FaultsConfig{
+			Enabled: true,
+			Faults:  []config.FaultConfig{{Target: TargetStorage, Operations: []string{OperationRead}, Latency: 50 * time.Millisecond}},
+		})
+
+		start := time.Now()
+		_, err := db.Read(ctx, "credential", "id")
+		assert.NoError(tt, err)
+		assert.GreaterOrEqual(tt, time.Since(start), 50*time.Millisecond)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		_, err = db.Read(timeoutCtx, "credential", "id")
+		assert.ErrorIs(tt, err, context.DeadlineExceeded)
+	})
+
+	t.Run("faults can be updated", func(tt *testing.T) {
+		db, injector := newStorage(tt, config.FaultsConfig{
+			Enabled: true,
+			Faults:  []config.FaultConfig{{Target: TargetStorage, Error: "down"}},
+		})
+		assert.Error(tt, db.Write(ctx, "credential", "id", []byte("value")))
+
+		assert.Error(tt, injector.Update(config.FaultsConfig{Enabled: true, Faults: []config.FaultConfig{{Target: "network"}}}))
+		assert.Error(tt, injector.Update(config.FaultsConfig{Enabled: true, Faults: []config.FaultConfig{{Target: TargetStorage, Operations: []string{"scan"}}}}))
+		assert.Error(tt, injector.Update(config.FaultsConfig{Enabled: true, Faults: []config.FaultConfig{{Target: TargetStorage, Rate: 2}}}))
+
+		require.NoError(tt, injector.Update(config.FaultsConfig{Enabled: true}))
+		assert.NoError(tt, db.Write(ctx, "credential", "id", []byte("value")))
+	})
+}
+
+func TestResolverFaults(t *testing.T) {
+	ctx := context.Background()
+	_, didKey, err := key.GenerateDIDKey(crypto.Ed25519)
+	require.NoError(t, err)
+	keyResolver, err := resolution.NewResolver(key.Resolver{})
+	require.NoError(t, err)
+	injector, err := NewInjector(config.FaultsConfig{
+		Enabled: true,
+		Faults:  []config.FaultConfig{{Target: TargetResolver, Match: []string{didsdk.WebMethod.String()}, Error: "unreachable"}},
+	})
+	require.NoError(t, err)
+	resolver := NewResolver(keyResolver, injector)
+
+	_, err = resolver.Resolve(ctx, "did:web:example.com")
+	assert.ErrorIs(t, err, ErrInjected)
+
+	resolved, err := resolver.Resolve(ctx, didKey.String())
+	require.NoError(t, err)
+	assert.Equal(t, didKey.String(), resolved.Document.ID)
+}
@@ -0,0 +1,35 @@
+package faults
+
+import (
+	"context"
+
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+
+	"github.com/tbd54566975/ssi-service/internal/util"
+)
+
+// Resolver injects faults into the resolution of DIDs by the resolver it wraps.
+type Resolver struct {
+	r        resolution.Resolver
+	injector *Injector
+}
+
+var _ resolution.Resolver = (*Resolver)(nil)
+
+func NewResolver(r resolution.Resolver, injector *Injector) *Resolver {
+	return &Resolver{r: r, injector: injector}
+}
+
+// Resolve injects the faults that match the method of the DID, then resolves it.
+func (f Resolver) Resolve(ctx context.Context, did string, opts ...resolution.Option) (*resolution.Result, error) {
+	method, _ := util.GetMethodForDID(did)
+	if err := f.injector.inject(ctx, TargetResolver, operationResolve, string(method)); err != nil {
+		return nil, err
+	}
+	return f.r.Resolve(ctx, did, opts...)
+}
+
+func (f Resolver) Methods() []didsdk.Method {
+	return f.r.Methods()
+}
@@ -0,0 +1,163 @@
+package faults
+
+import (
+	"context"
+	"time"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Storage injects faults into the operations of the storage it wraps.
+type Storage struct {
+	s        storage.ServiceStorage
+	injector *Injector
+}
+
+var _ storage.ServiceStorage = (*Storage)(nil)
+
+func NewStorage(s storage.ServiceStorage, injector *Injector) *Storage {
+	return &Storage{s: s, injector: injector}
+}
+
+func (f Storage) inject(ctx context.Context, operation string, namespaces ...string) error {
+	for i, namespace := range namespaces {
+		namespaces[i] = storage.BaseNamespace(namespace)
+	}
+	return f.injector.inject(ctx, TargetStorage, operation, namespaces...)
+}
+
+func (f Storage) Init(opts ...storage.Option) error {
+	return f.s.Init(opts...)
+}
+
+func (f Storage) Type() storage.Type {
+	return f.s.Type()
+}
+
+func (f Storage) URI() string {
+	return f.s.URI()
+}
+
+func (f Storage) IsOpen() bool {
+	return f.s.IsOpen()
+}
+
+func (f Storage) Close() error {
+	return f.s.Close()
+}
+
+func (f Storage) Stats(ctx context.Context) (map[string]any, error) {
+	return storage.Stats(ctx, f.s)
+}
+
+func (f Storage) Write(ctx context.Context, namespace, key string, value []byte) error {
+	if err := f.inject(ctx, OperationWrite, namespace); err != nil {
+		return err
+	}
+	return f.s.Write(ctx, namespace, key, value)
+}
+
+func (f Storage) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	if err := f.inject(ctx, OperationWrite, namespace); err != nil {
+		return err
+	}
+	return storage.WriteWithTTL(ctx, f.s, namespace, key, value, ttl)
+}
+
+func (f Storage) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
+	if err := f.inject(ctx, OperationWrite, append([]string(nil), namespaces...)...); err != nil {
+		return err
+	}
+	return f.s.WriteMany(ctx, namespaces, keys, values)
+}
+
+func (f Storage) Read(ctx context.Context, namespace, key string) ([]byte, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return nil, err
+	}
+	return f.s.Read(ctx, namespace, key)
+}
+
+func (f Storage) Exists(ctx context.Context, namespace, key string) (bool, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return false, err
+	}
+	return f.s.Exists(ctx, namespace, key)
+}
+
+func (f Storage) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return nil, err
+	}
+	return f.s.ReadAll(ctx, namespace)
+}
+
+func (f Storage) ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return nil, "", err
+	}
+	return f.s.ReadPage(ctx, namespace, pageToken, pageSize)
+}
+
+func (f Storage) ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return nil, err
+	}
+	return f.s.ReadPrefix(ctx, namespace, prefix)
+}
+
+func (f Storage) ReadAllKeys(ctx context.Context, namespace string) ([]string, error) {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return nil, err
+	}
+	return f.s.ReadAllKeys(ctx, namespace)
+}
+
+func (f Storage) Iterate(ctx context.Context, namespace string, fn storage.IterateFunc) error {
+	if err := f.inject(ctx, OperationRead, namespace); err != nil {
+		return err
+	}
+	return f.s.Iterate(ctx, namespace, fn)
+}
+
+func (f Storage) Delete(ctx context.Context, namespace, key string) error {
+	if err := f.inject(ctx, OperationDelete, namespace); err != nil {
+		return err
+	}
+	return f.s.Delete(ctx, namespace, key)
+}
+
+func (f Storage) DeleteNamespace(ctx context.Context, namespace string) error {
+	if err := f.inject(ctx, OperationDelete, namespace); err != nil {
+		return err
+	}
+	return f.s.DeleteNamespace(ctx, namespace)
+}
+
+// Execute injects faults that match the namespaces of the watched keys before the business logic runs, and faults of
+// writes into the writes of the business logic.
+func (f Storage) Execute(ctx context.Context, businessLogicFunc storage.BusinessLogicFunc, watchKeys []storage.WatchKey) (any, error) {
+	namespaces := make([]string, 0, len(watchKeys))
+	for _, watchKey := range watchKeys {
+		namespaces = append(namespaces, watchKey.Namespace)
+	}
+	if err := f.inject(ctx, OperationExecute, namespaces...); err != nil {
+		return nil, err
+	}
+	return f.s.Execute(ctx, func(ctx context.Context, t storage.Tx) (any, error) {
+		return businessLogicFunc(ctx, tx{Tx: t, storage: f})
+	}, watchKeys)
+}
+
+// tx injects faults into the writes of a transaction.
+type tx struct {
+	storage.Tx
+	storage Storage
+}
+
+func (t tx) Write(ctx context.Context, namespace, key string, value []byte) error {
+	if err := t.storage.inject(ctx, OperationWrite, namespace); err != nil {
+		return err
+	}
+	return t.Tx.Write(ctx, namespace, key, value)
+}
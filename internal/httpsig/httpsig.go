@@ -0,0 +1,472 @@
+// Package httpsig signs and verifies requests with HTTP Message Signatures, as defined in RFC 9421, and checks the
+// digests of their bodies in the Content-Digest header, as defined in RFC 9530.
+package httpsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignatureHeader holds the signatures of a request, by label.
+	SignatureHeader = "Signature"
+	// SignatureInputHeader holds the covered components and parameters of the signatures of a request, by label.
+	SignatureInputHeader = "Signature-Input"
+	// ContentDigestHeader holds digests of the body of a request, which signatures cover in place of the body.
+	ContentDigestHeader = "Content-Digest"
+)
+
+// Algorithms of the HTTP Signature Algorithms registry that signatures can be made with. Only asymmetric algorithms
+// are supported, since a signature made with a shared secret doesn't prove who made it.
+const (
+	AlgorithmEd25519         = "ed25519"
+	AlgorithmECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgorithmECDSAP384SHA384 = "ecdsa-p384-sha384"
+	AlgorithmRSAPSSSHA512    = "rsa-pss-sha512"
+	AlgorithmRSAV15SHA256    = "rsa-v1_5-sha256"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature doesn't match the request it was sent with.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrUnsupportedAlgorithm is returned for algorithms that aren't supported, or that don't match the key.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+)
+
+// Signature is a signature of a request, with the parameters it was made with.
+type Signature struct {
+	// Label the signature is sent under in the Signature and Signature-Input headers.
+	Label string
+
+	// Components are the identifiers of the parts of the request the signature covers, like @method or content-digest.
+	Components []string
+
+	// Created is when the signature was made.
+	Created time.Time
+
+	// Expires is when the signature stops being valid. It's zero when the signature doesn't expire.
+	Expires time.Time
+
+	// KeyID identifies the key the signature was made with.
+	KeyID string
+
+	// Algorithm the signature was made with. It's empty when the signer left it to be derived from the key.
+	Algorithm string
+
+	// Nonce is a random value that makes the signature unique, when the signer sent one.
+	Nonce string
+
+	// Value is the signature itself.
+	Value []byte
+
+	params params
+}
+
+// Covers returns whether the signature covers the component.
+func (s Signature) Covers(component string) bool {
+	for _, c := range s.Components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSignatures reads the signatures of a request from its Signature and Signature-Input headers. A request without
+// signatures has none, and no error.
+func ParseSignatures(req *http.Request) ([]Signature, error) {
+	inputs, err := parseDictionary(strings.Join(req.Header.Values(SignatureInputHeader), ", "))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Signature-Input header")
+	}
+	values, err := parseDictionary(strings.Join(req.Header.Values(SignatureHeader), ", "))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Signature header")
+	}
+
+	signatures := make([]Signature, 0, len(inputs))
+	for _, input := range inputs {
+		sig, err := parseSignature(input, values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing signature %q", input.key)
+		}
+		signatures = append(signatures, *sig)
+	}
+	return signatures, nil
+}
+
+func parseSignature(input member, values []member) (*Signature, error) {
+	if !input.isList {
+		return nil, errors.New("signature input is not an inner list")
+	}
+	sig := Signature{Label: input.key, params: input.item.params}
+	for _, value := range values {
+		if value.key != input.key {
+			continue
+		}
+		b, ok := value.item.value.([]byte)
+		if value.isList || !ok {
+			return nil, errors.New("signature is not a byte sequence")
+		}
+		sig.Value = b
+	}
+	if sig.Value == nil {
+		return nil, errors.New("no signature was sent with the label")
+	}
+
+	for _, it := range input.innerList {
+		component, ok := it.value.(string)
+		if !ok {
+			return nil, errors.New("component identifier is not a string")
+		}
+		if len(it.params) > 0 {
+			return nil, errors.Errorf("parameters of component %q are not supported", component)
+		}
+		if sig.Covers(component) {
+			return nil, errors.Errorf("component %q is covered more than once", component)
+		}
+		sig.Components = append(sig.Components, component)
+	}
+
+	for _, p := range input.item.params {
+		var ok bool
+		switch p.key {
+		case "created", "expires":
+			var unix int64
+			if unix, ok = p.value.(int64); ok {
+				if p.key == "created" {
+					sig.Created = time.Unix(unix, 0)
+				} else {
+					sig.Expires = time.Unix(unix, 0)
+				}
+			}
+		case "keyid":
+			sig.KeyID, ok = p.value.(string)
+		case "alg":
+			sig.Algorithm, ok = p.value.(string)
+		case "nonce":
+			sig.Nonce, ok = p.value.(string)
+		default:
+			// other parameters, like tag, are signed but not interpreted
+			ok = true
+		}
+		if !ok {
+			return nil, errors.Errorf("invalid %q parameter", p.key)
+		}
+	}
+	return &sig, nil
+}
+
+// Base returns the signature base of a request, which is what the signature is made over. It fails when the request
+// lacks a covered component.
+func (s Signature) Base(req *http.Request) ([]byte, error) {
+	var b bytes.Buffer
+	for _, component := range s.Components {
+		value, err := componentValue(req, component)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(serializeString(component) + ": " + value + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + serializeInnerList(s.Components, s.params))
+	return b.Bytes(), nil
+}
+
+// componentValue returns the value of a component of a request, which is either a derived component, like @method,
+// or a header field.
+func componentValue(req *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return scheme(req) + "://" + authority(req) + req.URL.RequestURI(), nil
+	case "@authority":
+		return authority(req), nil
+	case "@scheme":
+		return scheme(req), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if path := req.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", errors.Errorf("component %q is not supported", component)
+	}
+	if component != strings.ToLower(component) {
+		return "", errors.Errorf("component %q is not lowercase", component)
+	}
+
+	// the Host header is moved to the request's Host field by net/http
+	if component == "host" && req.Host != "" {
+		return req.Host, nil
+	}
+	values := req.Header.Values(component)
+	if len(values) == 0 {
+		return "", errors.Errorf("covered header %q is missing", component)
+	}
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed = append(trimmed, strings.TrimSpace(value))
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+func scheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func authority(req *http.Request) string {
+	if req.Host != "" {
+		return strings.ToLower(req.Host)
+	}
+	return strings.ToLower(req.URL.Host)
+}
+
+// Verify checks that the signature was made over the request with the private key of key, using alg. When the
+// signature names its algorithm, it must be alg.
+func (s Signature) Verify(req *http.Request, alg string, key crypto.PublicKey) error {
+	if s.Algorithm != "" && s.Algorithm != alg {
+		return errors.Wrapf(ErrUnsupportedAlgorithm, "signature was made with %q, but the key signs with %q", s.Algorithm, alg)
+	}
+	base, err := s.Base(req)
+	if err != nil {
+		return err
+	}
+	return verify(alg, key, base, s.Value)
+}
+
+func verify(alg string, key crypto.PublicKey, base, sig []byte) error {
+	key = normalizePublicKey(key)
+	valid := false
+	switch alg {
+	case AlgorithmEd25519:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.Wrap(ErrUnsupportedAlgorithm, "key is not an ed25519 key")
+		}
+		valid = ed25519.Verify(pub, base, sig)
+	case AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384:
+		pub, ok := key.(*ecdsa.PublicKey)
+		curve, hash := ecdsaParams(alg)
+		if !ok || pub.Curve != curve {
+			return errors.Wrapf(ErrUnsupportedAlgorithm, "key is not a %s key", curve.Params().Name)
+		}
+		// the signature is r and s, each as wide as the curve, rather than ASN.1
+		size := (curve.Params().BitSize + 7) / 8
+		if len(sig) == 2*size {
+			r, sigS := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+			valid = ecdsa.Verify(pub, digest(hash, base), r, sigS)
+		}
+	case AlgorithmRSAPSSSHA512:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Wrap(ErrUnsupportedAlgorithm, "key is not an rsa key")
+		}
+		valid = rsa.VerifyPSS(pub, crypto.SHA512, digest(crypto.SHA512, base), sig, &rsa.PSSOptions{SaltLength: 64}) == nil
+	case AlgorithmRSAV15SHA256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Wrap(ErrUnsupportedAlgorithm, "key is not an rsa key")
+		}
+		valid = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest(crypto.SHA256, base), sig) == nil
+	default:
+		return errors.Wrapf(ErrUnsupportedAlgorithm, "algorithm: %q", alg)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign signs a request with key, adding the signature under label to its Signature and Signature-Input headers. The
+// signature covers the components, in order, and is created now, with a random nonce, so that signatures of identical
+// requests made at the same time are told apart. Headers that are covered, like Content-Digest, must be set
+// beforehand.
+func Sign(req *http.Request, label string, components []string, keyID, alg string, key crypto.Signer) error {
+	now := time.Now()
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return errors.Wrap(err, "generating nonce")
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	sig := Signature{
+		Label:      label,
+		Components: components,
+		Created:    now,
+		KeyID:      keyID,
+		Algorithm:  alg,
+		Nonce:      nonce,
+		params: params{
+			{key: "created", value: now.Unix()},
+			{key: "keyid", value: keyID},
+			{key: "alg", value: alg},
+			{key: "nonce", value: nonce},
+		},
+	}
+	base, err := sig.Base(req)
+	if err != nil {
+		return err
+	}
+	if sig.Value, err = sign(alg, key, base); err != nil {
+		return err
+	}
+	req.Header.Add(SignatureInputHeader, label+"="+serializeInnerList(sig.Components, sig.params))
+	req.Header.Add(SignatureHeader, label+"="+serializeBareItem(sig.Value))
+	return nil
+}
+
+func sign(alg string, key crypto.Signer, base []byte) ([]byte, error) {
+	switch alg {
+	case AlgorithmEd25519:
+		return key.Sign(rand.Reader, base, crypto.Hash(0))
+	case AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.Wrap(ErrUnsupportedAlgorithm, "key is not an ecdsa key")
+		}
+		curve, hash := ecdsaParams(alg)
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest(hash, base))
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	case AlgorithmRSAPSSSHA512:
+		return key.Sign(rand.Reader, digest(crypto.SHA512, base), &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512})
+	case AlgorithmRSAV15SHA256:
+		return key.Sign(rand.Reader, digest(crypto.SHA256, base), crypto.SHA256)
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedAlgorithm, "algorithm: %q", alg)
+	}
+}
+
+// KeyAlgorithm returns the algorithm that signatures are made with by default for a public key: ed25519 for Ed25519
+// keys, ECDSA with the hash matching the curve for P-256 and P-384 keys, and RSASSA-PSS for RSA keys.
+func KeyAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := normalizePublicKey(key).(type) {
+	case ed25519.PublicKey:
+		return AlgorithmEd25519, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return AlgorithmECDSAP256SHA256, nil
+		case elliptic.P384():
+			return AlgorithmECDSAP384SHA384, nil
+		}
+	case *rsa.PublicKey:
+		return AlgorithmRSAPSSSHA512, nil
+	}
+	return "", errors.Wrapf(ErrUnsupportedAlgorithm, "no algorithm for key type: %T", key)
+}
+
+// CheckAlgorithm returns an error unless signatures can be made with alg by the private key of key.
+func CheckAlgorithm(alg string, key crypto.PublicKey) error {
+	switch alg {
+	case AlgorithmRSAPSSSHA512, AlgorithmRSAV15SHA256:
+		if _, ok := normalizePublicKey(key).(*rsa.PublicKey); ok {
+			return nil
+		}
+	default:
+		if keyAlg, err := KeyAlgorithm(key); err != nil || keyAlg == alg {
+			return err
+		}
+	}
+	return errors.Wrapf(ErrUnsupportedAlgorithm, "algorithm %q can't be used with key type: %T", alg, key)
+}
+
+// normalizePublicKey returns the key in the form crypto/x509 parses keys to, since JWK libraries return some keys by
+// value and others by reference.
+func normalizePublicKey(key crypto.PublicKey) crypto.PublicKey {
+	switch k := key.(type) {
+	case *ed25519.PublicKey:
+		return *k
+	case ecdsa.PublicKey:
+		return &k
+	case rsa.PublicKey:
+		return &k
+	}
+	return key
+}
+
+func ecdsaParams(alg string) (elliptic.Curve, crypto.Hash) {
+	if alg == AlgorithmECDSAP384SHA384 {
+		return elliptic.P384(), crypto.SHA384
+	}
+	return elliptic.P256(), crypto.SHA256
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// ContentDigest returns the value of a Content-Digest header for a body, with its SHA-256 digest.
+func ContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=" + serializeBareItem(sum[:])
+}
+
+// VerifyContentDigest checks the digests of a Content-Digest header against the body. The header must have a SHA-256
+// or SHA-512 digest, and every digest of those algorithms must match. Digests of other algorithms are ignored.
+func VerifyContentDigest(header string, body []byte) error {
+	digests, err := parseDictionary(header)
+	if err != nil {
+		return errors.Wrap(err, "parsing Content-Digest header")
+	}
+	checked := false
+	for _, d := range digests {
+		var sum []byte
+		switch d.key {
+		case "sha-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+		value, ok := d.item.value.([]byte)
+		if d.isList || !ok {
+			return errors.Errorf("%s digest is not a byte sequence", d.key)
+		}
+		if !bytes.Equal(value, sum) {
+			return errors.Errorf("%s digest doesn't match the body", d.key)
+		}
+		checked = true
+	}
+	if !checked {
+		return errors.New("no sha-256 or sha-512 digest in Content-Digest")
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleRequest is the request that the examples of RFC 9421 sign.
+func exampleRequest(t *testing.T) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ContentDigestHeader, "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+	req.Header.Set("Content-Length", "18")
+	return req
+}
+
+func TestVerify(t *testing.T) {
+	// the Ed25519 test key of RFC 9421
+	x, err := base64.RawURLEncoding.DecodeString("JrQLj5P_89iXES9-vFgrIy29clF9CC_oPPsw3c5D0bs")
+	require.NoError(t, err)
+	publicKey := ed25519.PublicKey(x)
+
+	t.Run("verifies the example signature of the RFC", func(tt *testing.T) {
+		req := exampleRequest(tt)
+		req.Header.Set(SignatureInputHeader, `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+		req.Header.Set(SignatureHeader, "sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:")
+
+		signatures, err := ParseSignatures(req)
+		require.NoError(tt, err)
+		require.Len(tt, signatures, 1)
+		sig := signatures[0]
+		assert.Equal(tt, "sig-b26", sig.Label)
+		assert.Equal(tt, "test-key-ed25519", sig.KeyID)
+		assert.Equal(tt, time.Unix(1618884473, 0), sig.Created)
+		assert.True(tt, sig.Expires.IsZero())
+		assert.True(tt, sig.Covers("@authority"))
+		assert.False(tt, sig.Covers("content-digest"))
+
+		base, err := sig.Base(req)
+		require.NoError(tt, err)
+		assert.Equal(tt, `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`, string(base))
+
+		assert.NoError(tt, sig.Verify(req, AlgorithmEd25519, publicKey))
+
+		// the algorithm must be the key's
+		assert.ErrorIs(tt, sig.Verify(req, AlgorithmECDSAP256SHA256, publicKey), ErrUnsupportedAlgorithm)
+
+		// changing a covered component invalidates the signature
+		req.Header.Set("Content-Type", "text/plain")
+		assert.ErrorIs(tt, sig.Verify(req, AlgorithmEd25519, publicKey), ErrInvalidSignature)
+
+		// and removing one makes it unverifiable
+		req.Header.Del("Date")
+		assert.ErrorContains(tt, sig.Verify(req, AlgorithmEd25519, publicKey), `covered header "date" is missing`)
+	})
+
+	t.Run("rejects malformed signatures", func(tt *testing.T) {
+		for name, headers := range map[string][2]string{
+			"unparseable input":      {`sig1=("@method"`, "sig1=:AAAA:"},
+			"missing signature":      {`sig1=("@method");keyid="k"`, "sig2=:AAAA:"},
+			"signature not bytes":    {`sig1=("@method");keyid="k"`, `sig1="AAAA"`},
+			"component parameters":   {`sig1=("content-digest";req);keyid="k"`, "sig1=:AAAA:"},
+			"repeated component":     {`sig1=("@method" "@method");keyid="k"`, "sig1=:AAAA:"},
+			"created is not integer": {`sig1=("@method");created="now"`, "sig1=:AAAA:"},
+		} {
+			req := exampleRequest(tt)
+			req.Header.Set(SignatureInputHeader, headers[0])
+			req.Header.Set(SignatureHeader, headers[1])
+			_, err := ParseSignatures(req)
+			assert.Error(tt, err, name)
+		}
+
+		// no signatures isn't an error
+		signatures, err := ParseSignatures(exampleRequest(tt))
+		assert.NoError(tt, err)
+		assert.Empty(tt, signatures)
+	})
+}
+
+func TestSign(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		alg string
+		key crypto.Signer
+	}{
+		{AlgorithmEd25519, ed25519Key},
+		{AlgorithmECDSAP256SHA256, p256Key},
+		{AlgorithmECDSAP384SHA384, p384Key},
+		{AlgorithmRSAPSSSHA512, rsaKey},
+		{AlgorithmRSAV15SHA256, rsaKey},
+	} {
+		t.Run(test.alg, func(tt *testing.T) {
+			require.NoError(tt, CheckAlgorithm(test.alg, test.key.Public()))
+
+			req := exampleRequest(tt)
+			req.Header.Set(ContentDigestHeader, ContentDigest([]byte(`{"hello": "world"}`)))
+			components := []string{"@method", "@target-uri", "content-digest"}
+			require.NoError(tt, Sign(req, "sig1", components, "client-key", test.alg, test.key))
+
+			signatures, err := ParseSignatures(req)
+			require.NoError(tt, err)
+			require.Len(tt, signatures, 1)
+			sig := signatures[0]
+			assert.Equal(tt, components, sig.Components)
+			assert.Equal(tt, "client-key", sig.KeyID)
+			assert.Equal(tt, test.alg, sig.Algorithm)
+			assert.WithinDuration(tt, time.Now(), sig.Created, time.Minute)
+			assert.Len(tt, sig.Nonce, 32)
+			assert.NoError(tt, sig.Verify(req, test.alg, test.key.Public()))
+
+			// the signature covers the query
+			req.URL.RawQuery = "param=Other"
+			assert.ErrorIs(tt, sig.Verify(req, test.alg, test.key.Public()), ErrInvalidSignature)
+		})
+	}
+
+	t.Run("key algorithms", func(tt *testing.T) {
+		alg, err := KeyAlgorithm(ed25519Key.Public())
+		assert.NoError(tt, err)
+		assert.Equal(tt, AlgorithmEd25519, alg)
+		// as parsed from JWKs
+		alg, err = KeyAlgorithm(*p256Key.Public().(*ecdsa.PublicKey))
+		assert.NoError(tt, err)
+		assert.Equal(tt, AlgorithmECDSAP256SHA256, alg)
+		alg, err = KeyAlgorithm(p384Key.Public())
+		assert.NoError(tt, err)
+		assert.Equal(tt, AlgorithmECDSAP384SHA384, alg)
+		alg, err = KeyAlgorithm(rsaKey.Public())
+		assert.NoError(tt, err)
+		assert.Equal(tt, AlgorithmRSAPSSSHA512, alg)
+
+		assert.ErrorIs(tt, CheckAlgorithm(AlgorithmECDSAP256SHA256, p384Key.Public()), ErrUnsupportedAlgorithm)
+		assert.ErrorIs(tt, CheckAlgorithm(AlgorithmRSAV15SHA256, ed25519Key.Public()), ErrUnsupportedAlgorithm)
+		assert.ErrorIs(tt, CheckAlgorithm("hmac-sha256", ed25519Key.Public()), ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+
+	// the examples of RFC 9530
+	assert.NoError(t, VerifyContentDigest("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", body))
+	assert.NoError(t, VerifyContentDigest("sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:", body))
+	assert.NoError(t, VerifyContentDigest(ContentDigest(body), body))
+
+	assert.Error(t, VerifyContentDigest(ContentDigest(body), []byte(`{"hello": "there"}`)))
+	assert.Error(t, VerifyContentDigest("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:AAAA:", body))
+	assert.Error(t, VerifyContentDigest("md5=:AAAA:", body))
+	assert.Error(t, VerifyContentDigest("", body))
+}
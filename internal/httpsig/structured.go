@@ -0,0 +1,353 @@
+package httpsig
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// This file implements the parts of Structured Field Values (RFC 8941) that signature headers use: dictionaries whose
+// members are inner lists or items, with parameters. Decimals aren't supported, since no signature field uses them.
+
+// token is a structured field token, which is serialized without quotes.
+type token string
+
+// param is a parameter of an item or inner list. Its value is a string, token, int64, []byte, or bool.
+type param struct {
+	key   string
+	value any
+}
+
+type params []param
+
+func (ps params) get(key string) (any, bool) {
+	for _, p := range ps {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// item is a bare item with its parameters.
+type item struct {
+	value  any
+	params params
+}
+
+// member is a member of a dictionary. Its value is either an item, or an inner list of items.
+type member struct {
+	key       string
+	item      item
+	innerList []item
+	isList    bool
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+// parseDictionary parses a dictionary structured field.
+func parseDictionary(field string) ([]member, error) {
+	p := &parser{s: field}
+	p.skipSP()
+	var members []member
+	for !p.done() {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		m := member{key: key}
+		if p.peek() == '=' {
+			p.pos++
+			if p.peek() == '(' {
+				m.isList = true
+				if m.innerList, m.item.params, err = p.parseInnerList(); err != nil {
+					return nil, err
+				}
+			} else if m.item, err = p.parseItem(); err != nil {
+				return nil, err
+			}
+		} else {
+			m.item.value = true
+			if m.item.params, err = p.parseParams(); err != nil {
+				return nil, err
+			}
+		}
+		members = append(members, m)
+
+		p.skipOWS()
+		if p.done() {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, p.errorf("expected a comma")
+		}
+		p.pos++
+		p.skipOWS()
+		if p.done() {
+			return nil, p.errorf("trailing comma")
+		}
+	}
+	return members, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) skipSP() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) skipOWS() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid structured field at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseInnerList() ([]item, params, error) {
+	p.pos++
+	var items []item
+	for {
+		p.skipSP()
+		if p.done() {
+			return nil, nil, p.errorf("unterminated inner list")
+		}
+		if p.peek() == ')' {
+			p.pos++
+			ps, err := p.parseParams()
+			return items, ps, err
+		}
+		it, err := p.parseItem()
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, it)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, nil, p.errorf("expected a space or the end of the inner list")
+		}
+	}
+}
+
+func (p *parser) parseItem() (item, error) {
+	value, err := p.parseBareItem()
+	if err != nil {
+		return item{}, err
+	}
+	ps, err := p.parseParams()
+	return item{value: value, params: ps}, err
+}
+
+func (p *parser) parseParams() (params, error) {
+	var ps params
+	for p.peek() == ';' {
+		p.pos++
+		p.skipSP()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var value any = true
+		if p.peek() == '=' {
+			p.pos++
+			if value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		ps = append(ps, param{key: key, value: value})
+	}
+	return ps, nil
+}
+
+func (p *parser) parseKey() (string, error) {
+	start := p.pos
+	c := p.peek()
+	if !(c >= 'a' && c <= 'z') && c != '*' {
+		return "", p.errorf("expected a key")
+	}
+	for !p.done() {
+		c = p.peek()
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && !strings.ContainsRune("_-.*", rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *parser) parseBareItem() (any, error) {
+	c := p.peek()
+	switch {
+	case c == '"':
+		return p.parseString()
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseInteger()
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '*':
+		return p.parseToken(), nil
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *parser) parseString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\':
+			if next := p.peek(); next == '"' || next == '\\' {
+				b.WriteByte(next)
+				p.pos++
+				continue
+			}
+			return "", p.errorf("invalid escape in string")
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.errorf("invalid character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) parseByteSequence() ([]byte, error) {
+	p.pos++
+	end := strings.IndexByte(p.s[p.pos:], ':')
+	if end < 0 {
+		return nil, p.errorf("unterminated byte sequence")
+	}
+	encoded := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		// padding is optional when parsing
+		if decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "=")); err != nil {
+			return nil, errors.Wrap(err, "invalid byte sequence")
+		}
+	}
+	return decoded, nil
+}
+
+func (p *parser) parseBoolean() (bool, error) {
+	p.pos++
+	switch p.peek() {
+	case '1':
+		p.pos++
+		return true, nil
+	case '0':
+		p.pos++
+		return false, nil
+	default:
+		return false, p.errorf("invalid boolean")
+	}
+}
+
+func (p *parser) parseInteger() (int64, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	if p.peek() == '.' {
+		return 0, p.errorf("decimals are not supported")
+	}
+	digits := p.s[start:p.pos]
+	if len(strings.TrimPrefix(digits, "-")) > 15 {
+		return 0, p.errorf("integer is too long")
+	}
+	return strconv.ParseInt(digits, 10, 64)
+}
+
+func (p *parser) parseToken() token {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),;<=>?@[\]{}`, rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	return token(p.s[start:p.pos])
+}
+
+// serializeInnerList serializes an inner list of strings with parameters, which is the form of the signature
+// parameters that are signed.
+func serializeInnerList(items []string, ps params) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, it := range items {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(serializeString(it))
+	}
+	b.WriteByte(')')
+	b.WriteString(serializeParams(ps))
+	return b.String()
+}
+
+func serializeParams(ps params) string {
+	var b strings.Builder
+	for _, p := range ps {
+		b.WriteByte(';')
+		b.WriteString(p.key)
+		if v, ok := p.value.(bool); ok && v {
+			continue
+		}
+		b.WriteByte('=')
+		b.WriteString(serializeBareItem(p.value))
+	}
+	return b.String()
+}
+
+func serializeBareItem(value any) string {
+	switch v := value.(type) {
+	case string:
+		return serializeString(v)
+	case token:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []byte:
+		return ":" + base64.StdEncoding.EncodeToString(v) + ":"
+	case bool:
+		if v {
+			return "?1"
+		}
+		return "?0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func serializeString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,62 @@
+// Package inflight tracks work running in the background, so that shutdown can wait for it to finish.
+package inflight
+
+import (
+	"context"
+	"sync"
+)
+
+// Tracker counts the work in progress, and stops new work from starting once it's draining. The zero value is ready
+// to use. Unlike a sync.WaitGroup, work may be started concurrently with Drain.
+type Tracker struct {
+	mu       sync.Mutex
+	running  int
+	draining bool
+	idle     chan struct{}
+}
+
+// Start records that work is starting, and returns false when the tracker is draining, in which case the work must
+// not run. Every successful Start must be followed by a call to Done.
+func (t *Tracker) Start() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return false
+	}
+	t.running++
+	return true
+}
+
+// Done records that work started with Start has finished.
+func (t *Tracker) Done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running--
+	if t.running == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Drain stops new work from starting, and waits for the work in progress to finish, or for ctx to be done, in which
+// case it returns the error of ctx.
+func (t *Tracker) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	if t.running == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package inflight
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	t.Run("drains right away when idle", func(tt *testing.T) {
+		var tracker Tracker
+		assert.NoError(tt, tracker.Drain(context.Background()))
+		assert.False(tt, tracker.Start())
+	})
+
+	t.Run("waits for work to finish", func(tt *testing.T) {
+		var tracker Tracker
+		assert.True(tt, tracker.Start())
+		assert.True(tt, tracker.Start())
+
+		drained := make(chan error)
+		go func() { drained <- tracker.Drain(context.Background()) }()
+
+		tracker.Done()
+		select {
+		case <-drained:
+			tt.Fatal("drained while work was running")
+		case <-time.After(10 * time.Millisecond):
+		}
+		assert.False(tt, tracker.Start())
+
+		tracker.Done()
+		assert.NoError(tt, <-drained)
+	})
+
+	t.Run("stops waiting when the context is done", func(tt *testing.T) {
+		var tracker Tracker
+		assert.True(tt, tracker.Start())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(tt, tracker.Drain(ctx), context.DeadlineExceeded)
+	})
+}
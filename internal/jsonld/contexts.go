@@ -0,0 +1,140 @@
+// Package jsonld canonicalizes JSON-LD documents, like credentials secured with Data Integrity proofs, loading the
+// contexts they refer to without depending on the network when it can.
+package jsonld
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/models/ld/context/embed"
+	"github.com/hyperledger/aries-framework-go/component/models/ld/processor"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// fetchTimeout bounds how long fetching a context from its URL may take.
+const fetchTimeout = 10 * time.Second
+
+// Document is a JSON-LD context, and the URL documents refer to it by.
+type Document struct {
+	URL     string
+	Content []byte
+}
+
+// ContextLoader loads the contexts that JSON-LD documents refer to. Contexts that ship with the service, like those of
+// the VC Data Model, the Data Integrity suites, and status lists, and the contexts it's configured with, are loaded
+// from memory. Others are fetched from their URL the first time they're needed, and kept, unless the loader is
+// offline, in which case they fail to load.
+type ContextLoader struct {
+	documents map[string]*ld.RemoteDocument
+
+	// remote fetches contexts, and is nil when the loader is offline.
+	remote ld.DocumentLoader
+
+	mu      sync.RWMutex
+	fetched map[string]*ld.RemoteDocument
+}
+
+var _ ld.DocumentLoader = (*ContextLoader)(nil)
+
+// NewContextLoader creates a loader of the contexts that ship with the service and the given documents, which take
+// precedence over them. When offline, no other context is loaded.
+func NewContextLoader(documents []Document, offline bool) (*ContextLoader, error) {
+	loader := ContextLoader{
+		documents: make(map[string]*ld.RemoteDocument, len(embed.Contexts)+len(documents)),
+		fetched:   make(map[string]*ld.RemoteDocument),
+	}
+	for _, context := range embed.Contexts {
+		if err := loader.add(context.URL, context.Content); err != nil {
+			return nil, err
+		}
+	}
+	for _, document := range documents {
+		if err := loader.add(document.URL, document.Content); err != nil {
+			return nil, err
+		}
+	}
+	if !offline {
+		loader.remote = ld.NewDefaultDocumentLoader(&http.Client{Timeout: fetchTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)})
+	}
+	return &loader, nil
+}
+
+func (l *ContextLoader) add(url string, content []byte) error {
+	document, err := ld.DocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return errors.Wrapf(err, "parsing JSON-LD context<%s>", url)
+	}
+	l.documents[url] = &ld.RemoteDocument{DocumentURL: url, Document: document}
+	return nil
+}
+
+// LoadDocument returns the context at a URL.
+func (l *ContextLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	if document, ok := l.documents[url]; ok {
+		return document, nil
+	}
+	if l.remote == nil {
+		return nil, errors.Errorf("JSON-LD context<%s> is not available offline", url)
+	}
+
+	l.mu.RLock()
+	document, ok := l.fetched[url]
+	l.mu.RUnlock()
+	if ok {
+		return document, nil
+	}
+	document, err := l.remote.LoadDocument(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching JSON-LD context<%s>", url)
+	}
+	l.mu.Lock()
+	l.fetched[url] = document
+	l.mu.Unlock()
+	return document, nil
+}
+
+// Canonicalize canonicalizes a JSON-LD document into N-Quads with the URDNA2015 algorithm, which is what Data
+// Integrity proofs sign. Terms that its contexts don't define fail, rather than being dropped, so that nothing in the
+// document goes unsigned.
+func (l *ContextLoader) Canonicalize(document map[string]any) (string, error) {
+	// the processor's Normalize doesn't pass safe mode on to the conversion to RDF, so it's converted first
+	options := ld.NewJsonLdOptions("")
+	options.ProcessingMode = ld.JsonLd_1_1
+	options.DocumentLoader = l
+	options.SafeMode = true
+	rdf, err := ld.NewJsonLdProcessor().ToRDF(document, options)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing JSON-LD document")
+	}
+	dataset, ok := rdf.(*ld.RDFDataset)
+	if !ok {
+		return "", errors.New("canonicalizing JSON-LD document: unexpected RDF")
+	}
+
+	options.Format = "application/n-quads"
+	options.Algorithm = ld.AlgorithmURDNA2015
+	normalized, err := ld.NewJsonLdApi().Normalize(dataset, options)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing JSON-LD document")
+	}
+	canonical, ok := normalized.(string)
+	if !ok {
+		return "", errors.New("canonicalizing JSON-LD document: unexpected result")
+	}
+	return canonical, nil
+}
+
+// Frame frames a JSON-LD document, keeping what the frame matches, like when revealing some of the claims of a
+// credential. Blank nodes are given IDs first, of the form urn:bnid:_:c14nN, so that the framed document's statements
+// are the canonical statements of the document they come from.
+func (l *ContextLoader) Frame(document, frame map[string]any) (map[string]any, error) {
+	framed, err := processor.Default().Frame(document, frame, processor.WithDocumentLoader(l), processor.WithFrameBlankNodes())
+	if err != nil {
+		return nil, errors.Wrap(err, "framing JSON-LD document")
+	}
+	return framed, nil
+}
@@ -0,0 +1,366 @@
+package keyaccess
+
+import (
+	gocrypto "crypto"
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/pkg/errors"
+)
+
+// BbsBlsSignature2020 proofs secure credentials with a BBS+ signature of each of the credential's canonical
+// statements, from which holders derive BbsBlsSignatureProof2020 proofs, which only reveal some of the statements:
+// https://w3c-ccg.github.io/ldp-bbs2020/
+const (
+	BbsBlsSignature2020Type      = "BbsBlsSignature2020"
+	BbsBlsSignatureProof2020Type = "BbsBlsSignatureProof2020"
+	BBSContext                   = "https://w3id.org/security/bbs/v1"
+)
+
+// Framer frames JSON-LD documents, giving their blank nodes IDs, and canonicalizes them.
+type Framer interface {
+	Canonicalizer
+	Frame(document, frame map[string]any) (map[string]any, error)
+}
+
+// BBSProof is the proof of a credential secured with BbsBlsSignature2020, or derived from one, in which case it has
+// the nonce the derived proof was made with.
+type BBSProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue,omitempty"`
+	Nonce              string `json:"nonce,omitempty"`
+}
+
+// IsBbsBlsSignature2020 returns whether a proof is a BbsBlsSignature2020 proof.
+func IsBbsBlsSignature2020(proof *crypto.Proof) bool {
+	return proofType(proof) == BbsBlsSignature2020Type
+}
+
+// IsBbsBlsSignatureProof2020 returns whether a proof is a BbsBlsSignatureProof2020 proof, derived from a
+// BbsBlsSignature2020 proof.
+func IsBbsBlsSignatureProof2020(proof *crypto.Proof) bool {
+	return proofType(proof) == BbsBlsSignatureProof2020Type
+}
+
+// SignBbsBlsSignature2020 secures a credential with a BbsBlsSignature2020 proof, made with a BLS12381G2 key. The
+// context of the suite is added to the credential when it's missing, since the proof's terms are defined by it.
+func SignBbsBlsSignature2020(cred *credential.VerifiableCredential, verificationMethod string, key gocrypto.PrivateKey, canonicalizer Canonicalizer) error {
+	bbsKey, ok := key.(*bbsg2.PrivateKey)
+	if !ok {
+		return errors.Errorf("%s proofs require a BLS12381G2 key", BbsBlsSignature2020Type)
+	}
+	if err := ensureContext(cred, BBSContext); err != nil {
+		return err
+	}
+
+	cred.Proof = nil
+	proof := BBSProof{
+		Type:               BbsBlsSignature2020Type,
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       assertionMethod,
+	}
+	proofStatements, documentStatements, err := bbsStatements(*cred, proof, canonicalizer)
+	if err != nil {
+		return err
+	}
+	signature, err := bbsg2.New().SignWithKey(toMessages(proofStatements, documentStatements), bbsKey)
+	if err != nil {
+		return errors.Wrap(err, "signing credential")
+	}
+	proof.ProofValue = base64.StdEncoding.EncodeToString(signature)
+
+	var genericProof crypto.Proof = proof
+	cred.Proof = &genericProof
+	return nil
+}
+
+// VerifyBbsBlsSignature2020 verifies the BbsBlsSignature2020 proof of a credential with the BLS12381G2 public key of
+// its verification method.
+func VerifyBbsBlsSignature2020(cred credential.VerifiableCredential, key gocrypto.PublicKey, canonicalizer Canonicalizer) error {
+	publicKey, err := bbsPublicKeyBytes(key)
+	if err != nil {
+		return err
+	}
+	proof, signature, err := parseBBSProof(cred.Proof, BbsBlsSignature2020Type)
+	if err != nil {
+		return err
+	}
+
+	proof.ProofValue = ""
+	cred.Proof = nil
+	proofStatements, documentStatements, err := bbsStatements(cred, *proof, canonicalizer)
+	if err != nil {
+		return err
+	}
+	if err = bbsg2.New().Verify(toMessages(proofStatements, documentStatements), signature, publicKey); err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+	return nil
+}
+
+// DeriveBbsBlsSignatureProof2020 derives, from a credential secured with a BbsBlsSignature2020 proof, a credential
+// which only reveals the given fields of its subject, secured with a BbsBlsSignatureProof2020 proof made with the
+// nonce. The rest of the credential, like its issuer and dates, is revealed, and so is the ID of its subject.
+func DeriveBbsBlsSignatureProof2020(cred credential.VerifiableCredential, reveal []string, nonce []byte, key gocrypto.PublicKey, framer Framer) (*credential.VerifiableCredential, error) {
+	publicKey, err := bbsPublicKeyBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	proof, signature, err := parseBBSProof(cred.Proof, BbsBlsSignature2020Type)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) == 0 {
+		return nil, errors.New("a nonce is required to derive a proof")
+	}
+
+	proof.ProofValue = ""
+	cred.Proof = nil
+	proofStatements, documentStatements, err := bbsStatements(cred, *proof, framer)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := toJSONMap(cred)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling credential")
+	}
+	frame, err := revealFrame(document, reveal)
+	if err != nil {
+		return nil, err
+	}
+	revealed, err := framer.Frame(document, frame)
+	if err != nil {
+		return nil, err
+	}
+	canonicalRevealed, err := framer.Canonicalize(revealed)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing revealed credential")
+	}
+
+	// the revealed statements name blank nodes by their ID, which is how they're found among the credential's
+	indexes := make(map[string]int, len(documentStatements))
+	for i, statement := range documentStatements {
+		indexes[blankNodeToID(statement)] = len(proofStatements) + i
+	}
+	revealIndexes := make([]int, 0, len(proofStatements)+len(documentStatements))
+	for i := range proofStatements {
+		revealIndexes = append(revealIndexes, i)
+	}
+	for _, statement := range splitStatements(canonicalRevealed) {
+		index, ok := indexes[statement]
+		if !ok {
+			return nil, errors.Errorf("revealed statement is not signed: %s", statement)
+		}
+		revealIndexes = append(revealIndexes, index)
+	}
+
+	proofValue, err := bbsg2.New().DeriveProof(toMessages(proofStatements, documentStatements), signature, nonce, publicKey, revealIndexes)
+	if err != nil {
+		return nil, errors.Wrap(err, "deriving proof")
+	}
+	revealed["proof"] = BBSProof{
+		Type:               BbsBlsSignatureProof2020Type,
+		Created:            proof.Created,
+		VerificationMethod: proof.VerificationMethod,
+		ProofPurpose:       proof.ProofPurpose,
+		ProofValue:         base64.StdEncoding.EncodeToString(proofValue),
+		Nonce:              base64.StdEncoding.EncodeToString(nonce),
+	}
+
+	revealedBytes, err := json.Marshal(revealed)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling revealed credential")
+	}
+	var derived credential.VerifiableCredential
+	if err = json.Unmarshal(revealedBytes, &derived); err != nil {
+		return nil, errors.Wrap(err, "parsing revealed credential")
+	}
+	return &derived, nil
+}
+
+// VerifyBbsBlsSignatureProof2020 verifies the BbsBlsSignatureProof2020 proof of a credential, derived from a
+// BbsBlsSignature2020 proof made with the BLS12381G2 key of its verification method.
+func VerifyBbsBlsSignatureProof2020(cred credential.VerifiableCredential, key gocrypto.PublicKey, canonicalizer Canonicalizer) error {
+	publicKey, err := bbsPublicKeyBytes(key)
+	if err != nil {
+		return err
+	}
+	proof, proofValue, err := parseBBSProof(cred.Proof, BbsBlsSignatureProof2020Type)
+	if err != nil {
+		return err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(proof.Nonce)
+	if err != nil || len(nonce) == 0 {
+		return errors.New("proof has no valid nonce")
+	}
+
+	// the statements of the proof are those of the proof it was derived from
+	signed := BBSProof{
+		Type:               BbsBlsSignature2020Type,
+		Created:            proof.Created,
+		VerificationMethod: proof.VerificationMethod,
+		ProofPurpose:       proof.ProofPurpose,
+	}
+	cred.Proof = nil
+	proofStatements, documentStatements, err := bbsStatements(cred, signed, canonicalizer)
+	if err != nil {
+		return err
+	}
+
+	// revealed blank nodes are named by their ID, and the statements they're in are put back in the order they were
+	// signed in, which is the canonical order of the statements with blank nodes
+	for i, statement := range documentStatements {
+		documentStatements[i] = idToBlankNode(statement)
+	}
+	sort.Strings(documentStatements)
+	if err = bbsg2.New().VerifyProof(toMessages(proofStatements, documentStatements), proofValue, nonce, publicKey); err != nil {
+		return errors.Wrap(err, "invalid proof")
+	}
+	return nil
+}
+
+// bbsStatements returns what a BbsBlsSignature2020 proof signs, each statement separately: the canonical statements
+// of the proof options, which are the proof without its value and in the credential's context, followed by the
+// canonical statements of the credential without its proof.
+func bbsStatements(cred credential.VerifiableCredential, proof BBSProof, canonicalizer Canonicalizer) ([]string, []string, error) {
+	document, err := toJSONMap(cred)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling credential")
+	}
+	delete(document, "proof")
+	options, err := toJSONMap(proof)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling proof")
+	}
+	options["@context"] = document["@context"]
+
+	canonicalOptions, err := canonicalizer.Canonicalize(options)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "canonicalizing proof")
+	}
+	canonicalDocument, err := canonicalizer.Canonicalize(document)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "canonicalizing credential")
+	}
+	return splitStatements(canonicalOptions), splitStatements(canonicalDocument), nil
+}
+
+// revealFrame returns the JSON-LD frame which reveals the given fields of the subject of a credential, and all of
+// the rest of the credential.
+func revealFrame(document map[string]any, reveal []string) (map[string]any, error) {
+	if len(reveal) == 0 {
+		return nil, errors.New("no subject field to reveal")
+	}
+	subject, ok := document["credentialSubject"].(map[string]any)
+	if !ok {
+		return nil, errors.New("credential has no subject to reveal fields of")
+	}
+	subjectFrame := map[string]any{"@explicit": true}
+	for _, field := range reveal {
+		if _, ok = subject[field]; !ok {
+			return nil, errors.Errorf("credential subject has no field: %s", field)
+		}
+		subjectFrame[field] = map[string]any{}
+	}
+	return map[string]any{
+		"@context":          document["@context"],
+		"type":              document["type"],
+		"credentialSubject": subjectFrame,
+	}, nil
+}
+
+// parseBBSProof parses a proof of a type, and decodes its value.
+func parseBBSProof(genericProof *crypto.Proof, expectedType string) (*BBSProof, []byte, error) {
+	if genericProof == nil {
+		return nil, nil, errors.New("credential has no proof")
+	}
+	proofBytes, err := json.Marshal(genericProof)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling proof")
+	}
+	var proof BBSProof
+	if err = json.Unmarshal(proofBytes, &proof); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing proof")
+	}
+	if proof.Type != expectedType {
+		return nil, nil, errors.Errorf("proof is not a %s proof: %s", expectedType, proof.Type)
+	}
+	if proof.ProofPurpose != assertionMethod {
+		return nil, nil, errors.Errorf("unexpected proof purpose: %s", proof.ProofPurpose)
+	}
+	value, err := base64.StdEncoding.DecodeString(proof.ProofValue)
+	if err != nil || len(value) == 0 {
+		return nil, nil, errors.New("proof value is not base64 encoded")
+	}
+	return &proof, value, nil
+}
+
+func bbsPublicKeyBytes(key gocrypto.PublicKey) ([]byte, error) {
+	publicKey, ok := key.(*bbsg2.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("%s proofs must be verified with a BLS12381G2 key", BbsBlsSignature2020Type)
+	}
+	return publicKey.Marshal()
+}
+
+// blankNodeIDs matches the IDs that framing gives blank nodes, and blankNodes the blank nodes of canonical
+// statements.
+var (
+	blankNodeIDs = regexp.MustCompile(`<urn:bnid:(_:c14n[0-9]+)>`)
+	blankNodes   = regexp.MustCompile(`(^|\s)(_:c14n[0-9]+)`)
+)
+
+func blankNodeToID(statement string) string {
+	return blankNodes.ReplaceAllString(statement, "$1<urn:bnid:$2>")
+}
+
+func idToBlankNode(statement string) string {
+	return blankNodeIDs.ReplaceAllString(statement, "$1")
+}
+
+func splitStatements(canonical string) []string {
+	var statements []string
+	for _, statement := range strings.Split(canonical, "\n") {
+		if strings.TrimSpace(statement) != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func toMessages(statements ...[]string) [][]byte {
+	var messages [][]byte
+	for _, s := range statements {
+		for _, statement := range s {
+			messages = append(messages, []byte(statement))
+		}
+	}
+	return messages
+}
+
+// proofType returns the type of a proof, or nothing when it has none.
+func proofType(proof *crypto.Proof) string {
+	if proof == nil {
+		return ""
+	}
+	var typed struct {
+		Type string `json:"type"`
+	}
+	proofBytes, err := json.Marshal(proof)
+	if err != nil || json.Unmarshal(proofBytes, &typed) != nil {
+		return ""
+	}
+	return typed.Type
+}
@@ -0,0 +1,105 @@
+package keyaccess
+
+import (
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/jsonld"
+)
+
+func TestBbsBlsSignature2020(t *testing.T) {
+	// offline, so that only the contexts that ship with the service are used
+	contexts, err := jsonld.NewContextLoader([]jsonld.Document{{
+		URL: "https://example.com/contexts/person/v1",
+		Content: []byte(`{"@context": {
+			"givenName": "https://schema.org/givenName",
+			"familyName": "https://schema.org/familyName",
+			"address": "https://schema.org/address",
+			"addressCountry": "https://schema.org/addressCountry"
+		}}`),
+	}}, true)
+	require.NoError(t, err)
+
+	publicKey, privateKey, err := crypto.GenerateBBSKeyPair()
+	require.NoError(t, err)
+	newCredential := func() credential.VerifiableCredential {
+		return credential.VerifiableCredential{
+			Context:      []any{credential.VerifiableCredentialsLinkedDataContext, "https://example.com/contexts/person/v1"},
+			ID:           "https://example.com/credentials/1",
+			Type:         []string{credential.VerifiableCredentialType},
+			Issuer:       "did:example:issuer",
+			IssuanceDate: "2023-01-01T00:00:00Z",
+			CredentialSubject: credential.CredentialSubject{
+				"id":         "did:example:subject",
+				"givenName":  "Alice",
+				"familyName": "Smith",
+				"address":    map[string]any{"addressCountry": "CA"},
+			},
+		}
+	}
+	signedCredential := func(tt *testing.T) credential.VerifiableCredential {
+		cred := newCredential()
+		require.NoError(tt, SignBbsBlsSignature2020(&cred, "did:example:issuer#key-1", privateKey, contexts))
+		return cred
+	}
+
+	t.Run("signs and verifies a credential", func(tt *testing.T) {
+		cred := signedCredential(tt)
+		assert.True(tt, IsBbsBlsSignature2020(cred.Proof))
+		assert.Contains(tt, cred.Context, BBSContext)
+		assert.NoError(tt, VerifyBbsBlsSignature2020(cred, publicKey, contexts))
+	})
+
+	t.Run("fails when a claim was changed", func(tt *testing.T) {
+		cred := signedCredential(tt)
+		cred.CredentialSubject["givenName"] = "Mallory"
+		assert.ErrorContains(tt, VerifyBbsBlsSignature2020(cred, publicKey, contexts), "invalid signature")
+	})
+
+	t.Run("requires a BLS12381G2 key", func(tt *testing.T) {
+		cred := newCredential()
+		_, edKey, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, SignBbsBlsSignature2020(&cred, "did:example:issuer#key-1", edKey, contexts), "require a BLS12381G2 key")
+	})
+
+	t.Run("derives a proof which only reveals some claims", func(tt *testing.T) {
+		derived, err := DeriveBbsBlsSignatureProof2020(signedCredential(tt), []string{"givenName"}, []byte("nonce"), publicKey, contexts)
+		require.NoError(tt, err)
+		assert.True(tt, IsBbsBlsSignatureProof2020(derived.Proof))
+		assert.Equal(tt, "did:example:subject", derived.CredentialSubject.GetID())
+		assert.Equal(tt, "Alice", derived.CredentialSubject["givenName"])
+		assert.NotContains(tt, derived.CredentialSubject, "familyName")
+		assert.NotContains(tt, derived.CredentialSubject, "address")
+		assert.Equal(tt, "did:example:issuer", derived.Issuer)
+		assert.NoError(tt, VerifyBbsBlsSignatureProof2020(*derived, publicKey, contexts))
+
+		derived.CredentialSubject["givenName"] = "Mallory"
+		assert.ErrorContains(tt, VerifyBbsBlsSignatureProof2020(*derived, publicKey, contexts), "invalid proof")
+	})
+
+	t.Run("derives a proof which reveals nested claims", func(tt *testing.T) {
+		derived, err := DeriveBbsBlsSignatureProof2020(signedCredential(tt), []string{"address", "familyName"}, []byte("nonce"), publicKey, contexts)
+		require.NoError(tt, err)
+		assert.NotContains(tt, derived.CredentialSubject, "givenName")
+		assert.Equal(tt, "Smith", derived.CredentialSubject["familyName"])
+		assert.NoError(tt, VerifyBbsBlsSignatureProof2020(*derived, publicKey, contexts))
+	})
+
+	t.Run("fails to reveal a claim the subject doesn't have", func(tt *testing.T) {
+		_, err := DeriveBbsBlsSignatureProof2020(signedCredential(tt), []string{"birthDate"}, []byte("nonce"), publicKey, contexts)
+		assert.ErrorContains(tt, err, "credential subject has no field: birthDate")
+	})
+
+	t.Run("fails with another key", func(tt *testing.T) {
+		derived, err := DeriveBbsBlsSignatureProof2020(signedCredential(tt), []string{"givenName"}, []byte("nonce"), publicKey, contexts)
+		require.NoError(tt, err)
+		otherKey, _, err := crypto.GenerateBBSKeyPair()
+		require.NoError(tt, err)
+		assert.Error(tt, VerifyBbsBlsSignatureProof2020(*derived, otherKey, contexts))
+	})
+}
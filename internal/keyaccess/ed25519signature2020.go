@@ -0,0 +1,197 @@
+package keyaccess
+
+import (
+	gocrypto "crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+)
+
+// Ed25519Signature2020 proofs secure credentials as JSON-LD documents: https://w3c-ccg.github.io/di-eddsa-2020/
+const (
+	Ed25519Signature2020Type    = "Ed25519Signature2020"
+	Ed25519Signature2020Context = "https://w3id.org/security/suites/ed25519-2020/v1"
+
+	assertionMethod = "assertionMethod"
+
+	// proof values are multibase encoded, with base58btc
+	multibaseBase58BTC = 'z'
+)
+
+// Canonicalizer canonicalizes JSON-LD documents into N-Quads with the URDNA2015 algorithm.
+type Canonicalizer interface {
+	Canonicalize(document map[string]any) (string, error)
+}
+
+// Ed25519Signature2020Proof is the proof of a credential secured with Ed25519Signature2020.
+type Ed25519Signature2020Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue,omitempty"`
+}
+
+// IsEd25519Signature2020 returns whether a proof is an Ed25519Signature2020 proof.
+func IsEd25519Signature2020(proof *crypto.Proof) bool {
+	return proofType(proof) == Ed25519Signature2020Type
+}
+
+// SignEd25519Signature2020 secures a credential with an Ed25519Signature2020 proof, made with an Ed25519 key, which
+// may be an ExternalKey. The context of the suite is added to the credential when it's missing, since the proof's terms
+// are defined by it.
+func SignEd25519Signature2020(cred *credential.VerifiableCredential, verificationMethod string, key gocrypto.PrivateKey, canonicalizer Canonicalizer) error {
+	signer, ok := key.(gocrypto.Signer)
+	if ok {
+		_, ok = signer.Public().(ed25519.PublicKey)
+	}
+	if !ok {
+		return errors.Errorf("%s proofs require an Ed25519 key", Ed25519Signature2020Type)
+	}
+	if err := ensureContext(cred, Ed25519Signature2020Context); err != nil {
+		return err
+	}
+
+	cred.Proof = nil
+	proof := Ed25519Signature2020Proof{
+		Type:               Ed25519Signature2020Type,
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       assertionMethod,
+	}
+	hashData, err := ed25519Signature2020HashData(*cred, proof, canonicalizer)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(rand.Reader, hashData, gocrypto.Hash(0))
+	if err != nil {
+		return errors.Wrap(err, "signing credential")
+	}
+	proof.ProofValue = string(multibaseBase58BTC) + base58.Encode(signature)
+
+	var genericProof crypto.Proof = proof
+	cred.Proof = &genericProof
+	return nil
+}
+
+// VerifyEd25519Signature2020 verifies the Ed25519Signature2020 proof of a credential with the public key of its
+// verification method.
+func VerifyEd25519Signature2020(cred credential.VerifiableCredential, key gocrypto.PublicKey, canonicalizer Canonicalizer) error {
+	if cred.Proof == nil {
+		return errors.New("credential has no proof")
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		if pointer, isPointer := key.(*ed25519.PublicKey); isPointer && pointer != nil {
+			publicKey, ok = *pointer, true
+		}
+	}
+	if !ok {
+		return errors.Errorf("%s proofs must be verified with an Ed25519 key", Ed25519Signature2020Type)
+	}
+
+	proofBytes, err := json.Marshal(cred.Proof)
+	if err != nil {
+		return errors.Wrap(err, "marshalling proof")
+	}
+	var proof Ed25519Signature2020Proof
+	if err = json.Unmarshal(proofBytes, &proof); err != nil {
+		return errors.Wrap(err, "parsing proof")
+	}
+	if proof.Type != Ed25519Signature2020Type {
+		return errors.Errorf("proof is not an %s proof: %s", Ed25519Signature2020Type, proof.Type)
+	}
+	if proof.ProofPurpose != assertionMethod {
+		return errors.Errorf("unexpected proof purpose: %s", proof.ProofPurpose)
+	}
+	if len(proof.ProofValue) < 2 || proof.ProofValue[0] != multibaseBase58BTC {
+		return errors.New("proof value is not multibase base58btc encoded")
+	}
+	signature, err := base58.Decode(proof.ProofValue[1:])
+	if err != nil {
+		return errors.Wrap(err, "decoding proof value")
+	}
+
+	proof.ProofValue = ""
+	cred.Proof = nil
+	hashData, err := ed25519Signature2020HashData(cred, proof, canonicalizer)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, hashData, signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// ed25519Signature2020HashData is what an Ed25519Signature2020 proof signs: the hash of the canonical proof options,
+// which are the proof without its value and in the credential's context, followed by the hash of the canonical
+// credential without its proof.
+func ed25519Signature2020HashData(cred credential.VerifiableCredential, proof Ed25519Signature2020Proof, canonicalizer Canonicalizer) ([]byte, error) {
+	document, err := toJSONMap(cred)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling credential")
+	}
+	delete(document, "proof")
+	options, err := toJSONMap(proof)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling proof")
+	}
+	options["@context"] = document["@context"]
+
+	canonicalOptions, err := canonicalizer.Canonicalize(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing proof")
+	}
+	canonicalDocument, err := canonicalizer.Canonicalize(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing credential")
+	}
+	optionsHash := sha256.Sum256([]byte(canonicalOptions))
+	documentHash := sha256.Sum256([]byte(canonicalDocument))
+	return append(optionsHash[:], documentHash[:]...), nil
+}
+
+// ensureContext adds a context to the credential, unless it already has it.
+func ensureContext(cred *credential.VerifiableCredential, context string) error {
+	var contexts []any
+	switch existing := cred.Context.(type) {
+	case nil:
+	case string:
+		contexts = []any{existing}
+	case []string:
+		for _, c := range existing {
+			contexts = append(contexts, c)
+		}
+	case []any:
+		contexts = existing
+	default:
+		return errors.Errorf("unexpected credential context: %v", cred.Context)
+	}
+	for _, c := range contexts {
+		if c == context {
+			return nil
+		}
+	}
+	cred.Context = append(contexts, context)
+	return nil
+}
+
+func toJSONMap(v any) (map[string]any, error) {
+	vBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err = json.Unmarshal(vBytes, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,79 @@
+package keyaccess
+
+import (
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/jsonld"
+)
+
+func TestEd25519Signature2020(t *testing.T) {
+	// offline, so that only the contexts that ship with the service are used
+	contexts, err := jsonld.NewContextLoader([]jsonld.Document{{
+		URL:     "https://example.com/contexts/name/v1",
+		Content: []byte(`{"@context": {"givenName": "https://schema.org/givenName"}}`),
+	}}, true)
+	require.NoError(t, err)
+
+	publicKey, privateKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	newCredential := func() credential.VerifiableCredential {
+		return credential.VerifiableCredential{
+			Context:      []any{credential.VerifiableCredentialsLinkedDataContext, "https://example.com/contexts/name/v1"},
+			ID:           "https://example.com/credentials/1",
+			Type:         []string{credential.VerifiableCredentialType},
+			Issuer:       "did:example:issuer",
+			IssuanceDate: "2023-01-01T00:00:00Z",
+			CredentialSubject: credential.CredentialSubject{
+				"id":        "did:example:subject",
+				"givenName": "Alice",
+			},
+		}
+	}
+
+	t.Run("signs and verifies a credential", func(tt *testing.T) {
+		cred := newCredential()
+		require.NoError(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", privateKey, contexts))
+		assert.True(tt, IsEd25519Signature2020(cred.Proof))
+		assert.Contains(tt, cred.Context, Ed25519Signature2020Context)
+		assert.NoError(tt, VerifyEd25519Signature2020(cred, publicKey, contexts))
+	})
+
+	t.Run("fails when a claim was changed", func(tt *testing.T) {
+		cred := newCredential()
+		require.NoError(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", privateKey, contexts))
+		cred.CredentialSubject["givenName"] = "Mallory"
+		assert.ErrorContains(tt, VerifyEd25519Signature2020(cred, publicKey, contexts), "invalid signature")
+	})
+
+	t.Run("fails with another key", func(tt *testing.T) {
+		cred := newCredential()
+		require.NoError(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", privateKey, contexts))
+		otherKey, _, err := crypto.GenerateEd25519Key()
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, VerifyEd25519Signature2020(cred, otherKey, contexts), "invalid signature")
+	})
+
+	t.Run("refuses to sign claims that its contexts don't define", func(tt *testing.T) {
+		cred := newCredential()
+		cred.CredentialSubject["familyName"] = "Smith"
+		assert.Error(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", privateKey, contexts))
+	})
+
+	t.Run("requires an Ed25519 key", func(tt *testing.T) {
+		_, secp256k1Key, err := crypto.GenerateSECP256k1Key()
+		require.NoError(tt, err)
+		cred := newCredential()
+		assert.ErrorContains(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", secp256k1Key.ToECDSA(), contexts), "require an Ed25519 key")
+	})
+
+	t.Run("fails to load contexts that aren't available offline", func(tt *testing.T) {
+		cred := newCredential()
+		cred.Context = []any{credential.VerifiableCredentialsLinkedDataContext, "https://example.com/contexts/unknown/v1"}
+		assert.ErrorContains(tt, SignEd25519Signature2020(&cred, "did:example:issuer#key-1", privateKey, contexts), "not available offline")
+	})
+}
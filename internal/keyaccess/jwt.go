@@ -19,8 +19,16 @@ type JWKKeyAccess struct {
 	*jwx.Verifier
 }
 
+// ExternalKey is a private key held outside the service, e.g. in a KMS, which signs where it's held.
+type ExternalKey interface {
+	gocrypto.Signer
+
+	// KeyManagerURI is the URI of the key in the key manager holding it.
+	KeyManagerURI() string
+}
+
 // NewJWKKeyAccess creates a JWKKeyAccess object from an id, key id, and private key, generating both
-// JWT Signer and Verifier objects.
+// JWT Signer and Verifier objects. The private key may be an ExternalKey, which then signs where it's held.
 func NewJWKKeyAccess(id, kid string, key gocrypto.PrivateKey) (*JWKKeyAccess, error) {
 	if id == "" {
 		return nil, errors.New("id cannot be empty")
@@ -31,7 +39,13 @@ func NewJWKKeyAccess(id, kid string, key gocrypto.PrivateKey) (*JWKKeyAccess, er
 	if key == nil {
 		return nil, errors.New("key cannot be nil")
 	}
-	signer, err := jwx.NewJWXSigner(id, kid, key)
+	var signer *jwx.Signer
+	var err error
+	if external, ok := key.(ExternalKey); ok {
+		signer, err = newExternalSigner(id, kid, external)
+	} else {
+		signer, err = jwx.NewJWXSigner(id, kid, key)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not create JWK Key Access object for kid: %s, error creating signer", kid)
 	}
@@ -45,6 +59,28 @@ func NewJWKKeyAccess(id, kid string, key gocrypto.PrivateKey) (*JWKKeyAccess, er
 	}, nil
 }
 
+// newExternalSigner creates a signer of a key held outside the service, whose JWK only has the public key.
+func newExternalSigner(id, kid string, key ExternalKey) (*jwx.Signer, error) {
+	publicKeyJWK, err := jwx.PublicKeyToPublicKeyJWK(kid, key.Public())
+	if err != nil {
+		return nil, errors.Wrap(err, "converting public key to JWK")
+	}
+	return &jwx.Signer{
+		ID: id,
+		PrivateKeyJWK: jwx.PrivateKeyJWK{
+			KTY: publicKeyJWK.KTY,
+			CRV: publicKeyJWK.CRV,
+			X:   publicKeyJWK.X,
+			Y:   publicKeyJWK.Y,
+			N:   publicKeyJWK.N,
+			E:   publicKeyJWK.E,
+			ALG: publicKeyJWK.ALG,
+			KID: kid,
+		},
+		PrivateKey: key,
+	}, nil
+}
+
 // NewJWKKeyAccessVerifier creates JWKKeyAccess object from an id, key id, and public key, generating a JWT Verifier object.
 func NewJWKKeyAccessVerifier(id, kid string, key gocrypto.PublicKey) (*JWKKeyAccess, error) {
 	if id == "" {
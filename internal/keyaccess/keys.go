@@ -0,0 +1,92 @@
+package keyaccess
+
+import (
+	gocrypto "crypto"
+	"encoding/base64"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	bbsg2 "github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/pkg/errors"
+)
+
+// The SDK's key functions don't support BLS12381G2 keys, which sign BbsBlsSignature2020 proofs, so the functions below
+// handle them, and hand other key types to the SDK.
+
+// IsSupportedKeyType returns whether keys of a type can be generated, serialized and stored.
+func IsSupportedKeyType(kt crypto.KeyType) bool {
+	return kt == crypto.BLS12381G2 || crypto.IsSupportedKeyType(kt)
+}
+
+// GenerateKeyByKeyType generates a key pair of a type.
+func GenerateKeyByKeyType(kt crypto.KeyType) (gocrypto.PublicKey, gocrypto.PrivateKey, error) {
+	if kt == crypto.BLS12381G2 {
+		return crypto.GenerateBBSKeyPair()
+	}
+	return crypto.GenerateKeyByKeyType(kt)
+}
+
+// PrivKeyToBytes serializes a private key.
+func PrivKeyToBytes(key gocrypto.PrivateKey) ([]byte, error) {
+	if bbsKey, ok := key.(*bbsg2.PrivateKey); ok {
+		return bbsKey.Marshal()
+	}
+	return crypto.PrivKeyToBytes(key)
+}
+
+// BytesToPrivKey deserializes a private key of a type.
+func BytesToPrivKey(keyBytes []byte, kt crypto.KeyType) (gocrypto.PrivateKey, error) {
+	if kt == crypto.BLS12381G2 {
+		return bbsg2.UnmarshalPrivateKey(keyBytes)
+	}
+	return crypto.BytesToPrivKey(keyBytes, kt)
+}
+
+// PubKeyToBytes serializes a public key.
+func PubKeyToBytes(key gocrypto.PublicKey) ([]byte, error) {
+	if bbsKey, ok := key.(*bbsg2.PublicKey); ok {
+		return bbsKey.Marshal()
+	}
+	return crypto.PubKeyToBytes(key)
+}
+
+// PrivateKeyToPublicKeyJWK returns the public JWK of a private key. BLS12381G2 keys are represented as OKP keys on
+// the Bls12381G2 curve, as in https://w3c-ccg.github.io/ldp-bbs2020/#bls12-381-g2-key-pair.
+func PrivateKeyToPublicKeyJWK(kid string, key gocrypto.PrivateKey) (*jwx.PublicKeyJWK, error) {
+	bbsKey, ok := key.(*bbsg2.PrivateKey)
+	if !ok {
+		publicJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK(kid, key)
+		return publicJWK, err
+	}
+	publicBytes, err := bbsKey.PublicKey().Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "serializing BLS12381G2 public key")
+	}
+	return &jwx.PublicKeyJWK{
+		KTY: bbsJWKKeyType,
+		CRV: bbsJWKCurve,
+		X:   base64.RawURLEncoding.EncodeToString(publicBytes),
+		KID: kid,
+	}, nil
+}
+
+// PublicKeyJWKToBBSPublicKey returns the BLS12381G2 public key of a JWK, and false when the JWK isn't one.
+func PublicKeyJWKToBBSPublicKey(key jwx.PublicKeyJWK) (*bbsg2.PublicKey, bool, error) {
+	if key.KTY != bbsJWKKeyType || key.CRV != bbsJWKCurve {
+		return nil, false, nil
+	}
+	publicBytes, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, true, errors.Wrap(err, "decoding BLS12381G2 public key")
+	}
+	publicKey, err := bbsg2.UnmarshalPublicKey(publicBytes)
+	if err != nil {
+		return nil, true, errors.Wrap(err, "parsing BLS12381G2 public key")
+	}
+	return publicKey, true, nil
+}
+
+const (
+	bbsJWKKeyType = "OKP"
+	bbsJWKCurve   = "Bls12381G2"
+)
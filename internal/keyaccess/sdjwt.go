@@ -0,0 +1,577 @@
+package keyaccess
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/pkg/errors"
+)
+
+// SD-JWT VCs are issued as in https://datatracker.ietf.org/doc/draft-ietf-oauth-sd-jwt-vc/, which EU wallets
+// implement: the claims of the credential's subject are selectively disclosable, and its other properties are claims
+// of the issuer-signed JWT.
+const (
+	// SDJWTVCType is the typ header of the issuer-signed JWT of an SD-JWT VC.
+	SDJWTVCType = "vc+sd-jwt"
+	// KeyBindingJWTType is the typ header of the key binding JWT a holder presents an SD-JWT with.
+	KeyBindingJWTType = "kb+jwt"
+
+	sdJWTSeparator  = "~"
+	sdJWTDigestAlg  = "sha-256"
+	sdJWTSaltLength = 16
+)
+
+// SDJWT is a Selective Disclosure JWT, as in https://datatracker.ietf.org/doc/draft-ietf-oauth-selective-disclosure-jwt/:
+// an issuer-signed JWT with digests of the claims it selectively discloses, followed by the disclosures of the
+// claims, and, when it's presented, by a key binding JWT, all separated by tildes.
+type SDJWT string
+
+func (s SDJWT) String() string {
+	return string(s)
+}
+
+func SDJWTPtr(s string) *SDJWT {
+	sdJWT := SDJWT(s)
+	return &sdJWT
+}
+
+// KeyBinding is what the key binding JWT of a presented SD-JWT must have been made for. Empty values aren't checked.
+type KeyBinding struct {
+	Audience string
+	Nonce    string
+}
+
+// sdJWTParts are the parts of an SD-JWT.
+type sdJWTParts struct {
+	issuerJWT     string
+	disclosures   []string
+	keyBindingJWT string
+}
+
+func (s SDJWT) parts() (*sdJWTParts, error) {
+	parts := strings.Split(string(s), sdJWTSeparator)
+	if len(parts) < 2 {
+		return nil, errors.New("SD-JWT must have its disclosures after a tilde")
+	}
+	disclosures := parts[1 : len(parts)-1]
+	for _, disclosure := range disclosures {
+		if disclosure == "" {
+			return nil, errors.New("SD-JWT has an empty disclosure")
+		}
+	}
+	return &sdJWTParts{issuerJWT: parts[0], disclosures: disclosures, keyBindingJWT: parts[len(parts)-1]}, nil
+}
+
+// withoutKeyBinding is what a key binding JWT signs the hash of: the issuer-signed JWT and the disclosures.
+func (p sdJWTParts) withoutKeyBinding() string {
+	return strings.Join(append([]string{p.issuerJWT}, p.disclosures...), sdJWTSeparator) + sdJWTSeparator
+}
+
+// IssuerJWT returns the issuer-signed JWT of the SD-JWT, whose signature is checked against its issuer's keys.
+func (s SDJWT) IssuerJWT() (JWT, error) {
+	parts, err := s.parts()
+	if err != nil {
+		return "", err
+	}
+	return JWT(parts.issuerJWT), nil
+}
+
+// SignSDJWTCredential secures a credential as an SD-JWT VC, where each claim of the credential's subject, other than
+// its id, is disclosed on its own. When a holder's key is given, the SD-JWT is bound to it, so that it can only be
+// presented with a key binding JWT signed by the holder.
+func (ka JWKKeyAccess) SignSDJWTCredential(cred credential.VerifiableCredential, holderKey *jwx.PublicKeyJWK) (*SDJWT, error) {
+	if ka.Signer == nil {
+		return nil, errors.New("cannot sign with nil signer")
+	}
+	if err := cred.IsValid(); err != nil {
+		return nil, errors.New("cannot sign invalid credential")
+	}
+
+	claims, err := sdJWTVCClaims(cred)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]any, 0, len(cred.CredentialSubject))
+	var disclosures []string
+	for name, value := range cred.CredentialSubject {
+		if name == credential.VerifiableCredentialIDProperty {
+			continue
+		}
+		disclosure, err := newDisclosure(name, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "disclosing claim<%s>", name)
+		}
+		disclosures = append(disclosures, disclosure)
+		digests = append(digests, disclosureDigest(disclosure))
+	}
+	// sorted, so that the digests don't give away the order of the claims
+	sort.Slice(digests, func(i, j int) bool { return digests[i].(string) < digests[j].(string) })
+	claims["_sd"] = digests
+	claims["_sd_alg"] = sdJWTDigestAlg
+	if holderKey != nil {
+		claims["cnf"] = map[string]any{"jwk": holderKey}
+	}
+
+	issuerJWT, err := ka.signTyped(claims, SDJWTVCType)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign SD-JWT")
+	}
+	parts := sdJWTParts{issuerJWT: issuerJWT, disclosures: disclosures}
+	return SDJWTPtr(parts.withoutKeyBinding()), nil
+}
+
+// sdJWTVCClaims returns the claims of the issuer-signed JWT of a credential. Its type is the credential's most specific
+// type, and the properties of the credential which SD-JWT VCs don't have claims for are claims under their own names.
+func sdJWTVCClaims(cred credential.VerifiableCredential) (map[string]any, error) {
+	claims := map[string]any{
+		"iss": cred.IssuerID(),
+		"vct": sdJWTVCType(cred.Type),
+	}
+	if cred.ID != "" {
+		claims["jti"] = cred.ID
+	}
+	if subject := cred.CredentialSubject.GetID(); subject != "" {
+		claims["sub"] = subject
+	}
+	issuanceDate, err := time.Parse(time.RFC3339, cred.IssuanceDate)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing issuance date")
+	}
+	claims["iat"] = issuanceDate.Unix()
+	if cred.ExpirationDate != "" {
+		expirationDate, err := time.Parse(time.RFC3339, cred.ExpirationDate)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing expiration date")
+		}
+		claims["exp"] = expirationDate.Unix()
+	}
+	if cred.CredentialSchema != nil {
+		claims["credentialSchema"] = cred.CredentialSchema
+	}
+	if cred.CredentialStatus != nil {
+		claims["credentialStatus"] = cred.CredentialStatus
+	}
+	if cred.Evidence != nil {
+		claims["evidence"] = cred.Evidence
+	}
+	return claims, nil
+}
+
+func sdJWTVCType(credentialType any) string {
+	var types []string
+	switch t := credentialType.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	for i := len(types) - 1; i >= 0; i-- {
+		if types[i] != credential.VerifiableCredentialType {
+			return types[i]
+		}
+	}
+	return credential.VerifiableCredentialType
+}
+
+// newDisclosure returns the disclosure of a claim: a salted array of its name and value, base64url encoded.
+func newDisclosure(name string, value any) (string, error) {
+	salt := make([]byte, sdJWTSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "generating salt")
+	}
+	disclosureBytes, err := json.Marshal([]any{base64.RawURLEncoding.EncodeToString(salt), name, value})
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling disclosure")
+	}
+	return base64.RawURLEncoding.EncodeToString(disclosureBytes), nil
+}
+
+// disclosureDigest returns the digest an SD-JWT has of a disclosure, which is also how key binding JWTs hash SD-JWTs.
+func disclosureDigest(disclosure string) string {
+	digest := sha256.Sum256([]byte(disclosure))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// signTyped signs a payload as a JWT of a type, which its typ header names.
+func (ka JWKKeyAccess) signTyped(payload map[string]any, typ string) (string, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling payload")
+	}
+	headers := jws.NewHeaders()
+	if err = headers.Set(jws.TypeKey, typ); err != nil {
+		return "", errors.Wrap(err, "setting typ protected header")
+	}
+	if ka.Signer.KID != "" {
+		if err = headers.Set(jws.KeyIDKey, ka.Signer.KID); err != nil {
+			return "", errors.Wrap(err, "setting KID protected header")
+		}
+	}
+	signed, err := jws.Sign(payloadBytes, jws.WithKey(jwa.SignatureAlgorithm(ka.Signer.ALG), ka.Signer.PrivateKey, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
+
+// ParseSDJWTCredential parses the credential of an SD-JWT VC, with the claims of its subject that it discloses,
+// checking that each disclosure is one the issuer-signed JWT has the digest of. The signatures of the SD-JWT aren't
+// checked: the issuer's is checked against the keys of its DID with IssuerJWT, and the holder's with VerifyKeyBinding.
+// Besides the credential, the claims of the issuer-signed JWT are returned, with the disclosed claims in place.
+func ParseSDJWTCredential(token SDJWT) (*credential.VerifiableCredential, map[string]any, error) {
+	parts, err := token.parts()
+	if err != nil {
+		return nil, nil, err
+	}
+	typ, payload, err := parseJWS(parts.issuerJWT)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing issuer-signed JWT")
+	}
+	if typ != SDJWTVCType {
+		return nil, nil, errors.Errorf("issuer-signed JWT must be of typ %s", SDJWTVCType)
+	}
+	var claims map[string]any
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing claims of issuer-signed JWT")
+	}
+	if alg, ok := claims["_sd_alg"]; ok && alg != sdJWTDigestAlg {
+		return nil, nil, errors.Errorf("unsupported _sd_alg: %v", alg)
+	}
+
+	d := disclosing{byDigest: make(map[string][]any), used: make(map[string]bool)}
+	for _, disclosure := range parts.disclosures {
+		decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "decoding disclosure")
+		}
+		var values []any
+		if err = json.Unmarshal(decoded, &values); err != nil {
+			return nil, nil, errors.Wrap(err, "parsing disclosure")
+		}
+		digest := disclosureDigest(disclosure)
+		if _, ok := d.byDigest[digest]; ok {
+			return nil, nil, errors.New("SD-JWT has a disclosure more than once")
+		}
+		d.byDigest[digest] = values
+	}
+	disclosed, err := d.object(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(d.used) != len(d.byDigest) {
+		return nil, nil, errors.New("SD-JWT has a disclosure whose digest the issuer-signed JWT doesn't have")
+	}
+
+	cred, err := sdJWTVCCredential(disclosed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cred, disclosed, nil
+}
+
+// disclosing replaces the digests of claims with the claims that are disclosed, in objects and in arrays.
+type disclosing struct {
+	byDigest map[string][]any
+	used     map[string]bool
+}
+
+func (d disclosing) object(object map[string]any) (map[string]any, error) {
+	result := make(map[string]any, len(object))
+	for name, value := range object {
+		if name == "_sd" || name == "_sd_alg" {
+			continue
+		}
+		disclosedValue, err := d.value(value)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = disclosedValue
+	}
+	digests, _ := object["_sd"].([]any)
+	for _, digest := range digests {
+		values, err := d.disclose(digest, 3)
+		if err != nil {
+			return nil, err
+		}
+		// digests without disclosures are of claims that aren't disclosed, or are decoys
+		if values == nil {
+			continue
+		}
+		name, ok := values[1].(string)
+		if !ok || name == "_sd" || name == "..." {
+			return nil, errors.Errorf("disclosure has an invalid claim name: %v", values[1])
+		}
+		if _, ok = result[name]; ok {
+			return nil, errors.Errorf("disclosure of claim<%s> overrides a claim", name)
+		}
+		if result[name], err = d.value(values[2]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (d disclosing) value(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return d.object(v)
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, element := range v {
+			if object, ok := element.(map[string]any); ok && len(object) == 1 {
+				if digest, ok := object["..."]; ok {
+					values, err := d.disclose(digest, 2)
+					if err != nil {
+						return nil, err
+					}
+					if values != nil {
+						disclosedElement, err := d.value(values[1])
+						if err != nil {
+							return nil, err
+						}
+						result = append(result, disclosedElement)
+					}
+					continue
+				}
+			}
+			disclosedElement, err := d.value(element)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, disclosedElement)
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// disclose returns the disclosure of a digest, which has as many values as an object property or an array element
+// disclosure does, or nil when there's none.
+func (d disclosing) disclose(digest any, length int) ([]any, error) {
+	digestString, ok := digest.(string)
+	if !ok {
+		return nil, errors.Errorf("invalid digest: %v", digest)
+	}
+	values, ok := d.byDigest[digestString]
+	if !ok {
+		return nil, nil
+	}
+	if d.used[digestString] {
+		return nil, errors.New("SD-JWT has the digest of a disclosure more than once")
+	}
+	d.used[digestString] = true
+	if len(values) != length {
+		return nil, errors.Errorf("disclosure must have %d values, but has %d", length, len(values))
+	}
+	return values, nil
+}
+
+// sdJWTVCCredential returns the credential of the claims of an SD-JWT VC, reversing sdJWTVCClaims.
+func sdJWTVCCredential(claims map[string]any) (*credential.VerifiableCredential, error) {
+	iss, ok := claims["iss"].(string)
+	if !ok || iss == "" {
+		return nil, errors.New("SD-JWT VC must have an iss")
+	}
+	vct, ok := claims["vct"].(string)
+	if !ok || vct == "" {
+		return nil, errors.New("SD-JWT VC must have a vct")
+	}
+	types := []string{credential.VerifiableCredentialType}
+	if vct != credential.VerifiableCredentialType {
+		types = append(types, vct)
+	}
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, errors.New("SD-JWT VC must have an iat")
+	}
+
+	subject := make(map[string]any)
+	vc := map[string]any{
+		"@context":          []any{credential.VerifiableCredentialsLinkedDataContext},
+		"type":              types,
+		"issuer":            iss,
+		"issuanceDate":      time.Unix(int64(iat), 0).UTC().Format(time.RFC3339),
+		"credentialSubject": subject,
+	}
+	registered := map[string]bool{"iss": true, "vct": true, "iat": true, "nbf": true, "cnf": true}
+	for name, value := range claims {
+		switch {
+		case registered[name]:
+		case name == "jti":
+			vc["id"] = value
+		case name == "sub":
+			subject[credential.VerifiableCredentialIDProperty] = value
+		case name == "exp":
+			exp, ok := value.(float64)
+			if !ok {
+				return nil, errors.New("exp of SD-JWT VC must be a number")
+			}
+			vc["expirationDate"] = time.Unix(int64(exp), 0).UTC().Format(time.RFC3339)
+		case name == "credentialSchema" || name == "credentialStatus" || name == "evidence":
+			vc[name] = value
+		default:
+			subject[name] = value
+		}
+	}
+
+	vcBytes, err := json.Marshal(vc)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling credential")
+	}
+	var cred credential.VerifiableCredential
+	if err = json.Unmarshal(vcBytes, &cred); err != nil {
+		return nil, errors.Wrap(err, "parsing credential")
+	}
+	return &cred, nil
+}
+
+// VerifyKeyBinding checks the key binding of an SD-JWT, whose claims are those ParseSDJWTCredential returned. An
+// SD-JWT bound to a holder's key must be presented with a key binding JWT signed by the key, for the SD-JWT and its
+// disclosures, and for the audience and nonce of the binding when they're set. An SD-JWT that isn't bound to a key
+// can't be presented with a key binding JWT, nor when a binding is expected.
+func VerifyKeyBinding(token SDJWT, claims map[string]any, binding KeyBinding) error {
+	parts, err := token.parts()
+	if err != nil {
+		return err
+	}
+	cnf, bound := claims["cnf"].(map[string]any)
+	if !bound {
+		if parts.keyBindingJWT != "" {
+			return errors.New("SD-JWT isn't bound to a key, but has a key binding JWT")
+		}
+		if binding != (KeyBinding{}) {
+			return errors.New("SD-JWT isn't bound to a key")
+		}
+		return nil
+	}
+	if parts.keyBindingJWT == "" {
+		return errors.New("SD-JWT is bound to a key, but has no key binding JWT")
+	}
+
+	jwkBytes, err := json.Marshal(cnf["jwk"])
+	if err != nil {
+		return errors.Wrap(err, "marshalling cnf jwk")
+	}
+	var holderKey jwx.PublicKeyJWK
+	if err = json.Unmarshal(jwkBytes, &holderKey); err != nil || holderKey.IsEmpty() {
+		return errors.New("SD-JWT must be bound to a key in its cnf jwk")
+	}
+	verifier, err := jwx.NewJWXVerifierFromJWK("holder", holderKey)
+	if err != nil {
+		return errors.Wrap(err, "creating verifier of holder's key")
+	}
+	if err = verifier.VerifyJWS(parts.keyBindingJWT); err != nil {
+		return errors.Wrap(err, "verifying key binding JWT")
+	}
+
+	typ, payload, err := parseJWS(parts.keyBindingJWT)
+	if err != nil {
+		return errors.Wrap(err, "parsing key binding JWT")
+	}
+	if typ != KeyBindingJWTType {
+		return errors.Errorf("key binding JWT must be of typ %s", KeyBindingJWTType)
+	}
+	var kb struct {
+		IAT    *float64 `json:"iat"`
+		Aud    string   `json:"aud"`
+		Nonce  string   `json:"nonce"`
+		SDHash string   `json:"sd_hash"`
+	}
+	if err = json.Unmarshal(payload, &kb); err != nil {
+		return errors.Wrap(err, "parsing claims of key binding JWT")
+	}
+	if kb.IAT == nil {
+		return errors.New("key binding JWT must have an iat")
+	}
+	if kb.SDHash != disclosureDigest(parts.withoutKeyBinding()) {
+		return errors.New("key binding JWT isn't for the SD-JWT and its disclosures")
+	}
+	if binding.Audience != "" && kb.Aud != binding.Audience {
+		return errors.Errorf("key binding JWT must be for audience %s", binding.Audience)
+	}
+	if binding.Nonce != "" && kb.Nonce != binding.Nonce {
+		return errors.New("key binding JWT isn't made with the nonce")
+	}
+	return nil
+}
+
+// Present returns the SD-JWT a holder presents to a verifier, with the disclosures of only the given claims of the
+// credential's subject. When the holder's key access is given, a key binding JWT for the binding is added.
+func (s SDJWT) Present(claims []string, holder *JWKKeyAccess, binding KeyBinding) (*SDJWT, error) {
+	parts, err := s.parts()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]string, len(parts.disclosures))
+	for _, disclosure := range parts.disclosures {
+		decoded, err := base64.RawURLEncoding.DecodeString(disclosure)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding disclosure")
+		}
+		var values []any
+		if err = json.Unmarshal(decoded, &values); err != nil {
+			return nil, errors.Wrap(err, "parsing disclosure")
+		}
+		if len(values) == 3 {
+			if name, ok := values[1].(string); ok {
+				byName[name] = disclosure
+			}
+		}
+	}
+	presented := sdJWTParts{issuerJWT: parts.issuerJWT}
+	for _, claim := range claims {
+		disclosure, ok := byName[claim]
+		if !ok {
+			return nil, errors.Errorf("SD-JWT has no disclosure of claim: %s", claim)
+		}
+		presented.disclosures = append(presented.disclosures, disclosure)
+	}
+	if holder == nil {
+		return SDJWTPtr(presented.withoutKeyBinding()), nil
+	}
+	if holder.Signer == nil {
+		return nil, errors.New("cannot sign with nil signer")
+	}
+	kb := map[string]any{
+		"iat":     time.Now().Unix(),
+		"sd_hash": disclosureDigest(presented.withoutKeyBinding()),
+	}
+	if binding.Audience != "" {
+		kb["aud"] = binding.Audience
+	}
+	if binding.Nonce != "" {
+		kb["nonce"] = binding.Nonce
+	}
+	keyBindingJWT, err := holder.signTyped(kb, KeyBindingJWTType)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign key binding JWT")
+	}
+	return SDJWTPtr(presented.withoutKeyBinding() + keyBindingJWT), nil
+}
+
+// parseJWS returns the typ header and the payload of a compact JWS, without checking its signature.
+func parseJWS(token string) (string, []byte, error) {
+	msg, err := jws.Parse([]byte(token))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(msg.Signatures()) != 1 {
+		return "", nil, errors.Errorf("expected 1 signature, got %d", len(msg.Signatures()))
+	}
+	return msg.Signatures()[0].ProtectedHeaders().Type(), msg.Payload(), nil
+}
@@ -0,0 +1,140 @@
+package keyaccess
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDJWTCredential(t *testing.T) {
+	_, issuerKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	issuer, err := NewJWKKeyAccess("did:example:issuer", "did:example:issuer#key-1", issuerKey)
+	require.NoError(t, err)
+
+	_, holderKey, err := crypto.GenerateP256Key()
+	require.NoError(t, err)
+	holder, err := NewJWKKeyAccess("did:example:holder", "did:example:holder#key-1", holderKey)
+	require.NoError(t, err)
+	holderJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK("", holderKey)
+	require.NoError(t, err)
+
+	cred := credential.VerifiableCredential{
+		Context:        []any{credential.VerifiableCredentialsLinkedDataContext},
+		ID:             "https://example.com/credentials/1",
+		Type:           []string{credential.VerifiableCredentialType, "PersonCredential"},
+		Issuer:         "did:example:issuer",
+		IssuanceDate:   "2023-01-01T00:00:00Z",
+		ExpirationDate: "2033-01-01T00:00:00Z",
+		CredentialSubject: credential.CredentialSubject{
+			"id":         "did:example:holder",
+			"givenName":  "Alice",
+			"familyName": "Smith",
+			"address":    map[string]any{"country": "CA"},
+		},
+	}
+	binding := KeyBinding{Audience: "did:example:verifier", Nonce: "nonce"}
+
+	t.Run("discloses every claim at issuance", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, nil)
+		require.NoError(tt, err)
+		assert.True(tt, strings.HasSuffix(sdJWT.String(), "~"))
+
+		parsed, claims, err := ParseSDJWTCredential(*sdJWT)
+		require.NoError(tt, err)
+		assert.Equal(tt, cred.ID, parsed.ID)
+		assert.Equal(tt, "did:example:issuer", parsed.Issuer)
+		assert.Equal(tt, cred.IssuanceDate, parsed.IssuanceDate)
+		assert.Equal(tt, cred.ExpirationDate, parsed.ExpirationDate)
+		assert.Equal(tt, []any{credential.VerifiableCredentialType, "PersonCredential"}, parsed.Type)
+		assert.Equal(tt, "did:example:holder", parsed.CredentialSubject.GetID())
+		assert.Equal(tt, "Alice", parsed.CredentialSubject["givenName"])
+		assert.Equal(tt, map[string]any{"country": "CA"}, parsed.CredentialSubject["address"])
+		assert.Equal(tt, "PersonCredential", claims["vct"])
+		assert.NotContains(tt, claims, "_sd")
+		assert.NoError(tt, VerifyKeyBinding(*sdJWT, claims, KeyBinding{}))
+
+		issuerJWT, err := sdJWT.IssuerJWT()
+		require.NoError(tt, err)
+		assert.NoError(tt, issuer.Verify(issuerJWT))
+	})
+
+	t.Run("presents some claims with a key binding", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, holderJWK)
+		require.NoError(tt, err)
+		presented, err := sdJWT.Present([]string{"givenName"}, holder, binding)
+		require.NoError(tt, err)
+
+		parsed, claims, err := ParseSDJWTCredential(*presented)
+		require.NoError(tt, err)
+		assert.Equal(tt, "Alice", parsed.CredentialSubject["givenName"])
+		assert.NotContains(tt, parsed.CredentialSubject, "familyName")
+		assert.NotContains(tt, parsed.CredentialSubject, "address")
+		assert.NoError(tt, VerifyKeyBinding(*presented, claims, binding))
+
+		err = VerifyKeyBinding(*presented, claims, KeyBinding{Nonce: "another nonce"})
+		assert.ErrorContains(tt, err, "isn't made with the nonce")
+		err = VerifyKeyBinding(*presented, claims, KeyBinding{Audience: "did:example:another"})
+		assert.ErrorContains(tt, err, "must be for audience did:example:another")
+	})
+
+	t.Run("fails when a bound SD-JWT is presented without a key binding", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, holderJWK)
+		require.NoError(tt, err)
+		presented, err := sdJWT.Present([]string{"givenName"}, nil, KeyBinding{})
+		require.NoError(tt, err)
+		_, claims, err := ParseSDJWTCredential(*presented)
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, VerifyKeyBinding(*presented, claims, binding), "has no key binding JWT")
+	})
+
+	t.Run("fails when the key binding is signed by another key", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, holderJWK)
+		require.NoError(tt, err)
+		_, otherKey, err := crypto.GenerateP256Key()
+		require.NoError(tt, err)
+		other, err := NewJWKKeyAccess("did:example:other", "did:example:other#key-1", otherKey)
+		require.NoError(tt, err)
+		presented, err := sdJWT.Present([]string{"givenName"}, other, binding)
+		require.NoError(tt, err)
+		_, claims, err := ParseSDJWTCredential(*presented)
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, VerifyKeyBinding(*presented, claims, binding), "verifying key binding JWT")
+	})
+
+	t.Run("fails when the key binding is for other disclosures", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, holderJWK)
+		require.NoError(tt, err)
+		presented, err := sdJWT.Present([]string{"givenName"}, holder, binding)
+		require.NoError(tt, err)
+		withFamilyName, err := sdJWT.Present([]string{"givenName", "familyName"}, nil, KeyBinding{})
+		require.NoError(tt, err)
+		parts := strings.Split(presented.String(), "~")
+		tampered := SDJWT(withFamilyName.String() + parts[len(parts)-1])
+
+		_, claims, err := ParseSDJWTCredential(tampered)
+		require.NoError(tt, err)
+		assert.ErrorContains(tt, VerifyKeyBinding(tampered, claims, binding), "isn't for the SD-JWT and its disclosures")
+	})
+
+	t.Run("fails with a disclosure the issuer didn't sign", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, nil)
+		require.NoError(tt, err)
+		disclosure, err := newDisclosure("givenName", "Mallory")
+		require.NoError(tt, err)
+		_, _, err = ParseSDJWTCredential(SDJWT(sdJWT.String() + disclosure + "~"))
+		assert.ErrorContains(tt, err, "doesn't have")
+	})
+
+	t.Run("fails to present a claim it has no disclosure of", func(tt *testing.T) {
+		sdJWT, err := issuer.SignSDJWTCredential(cred, nil)
+		require.NoError(tt, err)
+		_, err = sdJWT.Present([]string{"birthDate"}, nil, KeyBinding{})
+		assert.ErrorContains(tt, err, "SD-JWT has no disclosure of claim: birthDate")
+	})
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// FieldsContextKey is the key the log fields of a request are stored under in gin contexts. It's a string because
+	// gin only looks up string keys when it's used as a context.Context.
+	FieldsContextKey = "ssi-service-log-fields"
+
+	// TraceIDField is the name of the field the ID of the trace a request is part of is logged as.
+	TraceIDField = "trace_id"
+
+	// SpanIDField is the name of the field the ID of the span an entry is logged in is logged as.
+	SpanIDField = "span_id"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx whose log entries carry the fields, along with those ctx carries already.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, merge(FieldsFromContext(ctx), fields))
+}
+
+// AddFields makes the entries logged while handling the request carry the fields, along with those it carries
+// already, whether they're logged with the gin context or with its request's context.
+func AddFields(c *gin.Context, fields logrus.Fields) {
+	merged := merge(FieldsFromContext(c), fields)
+	c.Set(FieldsContextKey, merged)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), fieldsKey{}, merged))
+}
+
+// FieldsFromContext returns the log fields carried by ctx, which must not be modified.
+func FieldsFromContext(ctx context.Context) logrus.Fields {
+	if ctx == nil {
+		return nil
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		return fields
+	}
+	if fields, ok := ctx.Value(FieldsContextKey).(logrus.Fields); ok {
+		return fields
+	}
+	return nil
+}
+
+func merge(current, fields logrus.Fields) logrus.Fields {
+	merged := make(logrus.Fields, len(current)+len(fields))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ContextHook adds the fields carried by the context of an entry, and the IDs of the trace and span it's logged in,
+// to entries logged with a context, e.g. with logrus.WithContext(ctx). Fields set on the entry itself take precedence.
+type ContextHook struct{}
+
+func (ContextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (ContextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for k, v := range FieldsFromContext(entry.Context) {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	// gin contexts only carry the span of their request when they fall back to its context
+	if c, ok := entry.Context.(*gin.Context); ok && !spanContext.IsValid() && c.Request != nil {
+		spanContext = trace.SpanContextFromContext(c.Request.Context())
+	}
+	if spanContext.IsValid() {
+		entry.Data[TraceIDField] = spanContext.TraceID().String()
+		entry.Data[SpanIDField] = spanContext.SpanID().String()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package logging configures the structured logger used throughout the service.
+package logging
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+)
+
+const (
+	// JSONFormat logs one JSON object per line, which is what log aggregators expect.
+	JSONFormat = "json"
+
+	// PrettyFormat logs indented JSON objects, which is easier to read locally.
+	PrettyFormat = "pretty"
+
+	// TextFormat logs key=value pairs.
+	TextFormat = "text"
+)
+
+// Configure sets the level, format, and destination of the standard logger from the server config, and routes gin's
+// own output, and the standard library's log package, through it. Entries logged with a context carry its request ID,
+// the fields of its request, and the IDs of its trace and span. Invalid levels and formats fall back to info and JSON.
+// When a log location is configured, logs are written to a new file in that directory as well as to stdout, and the
+// file is returned so that it can be closed on shutdown.
+func Configure(cfg config.ServerConfig) *os.File {
+	logrus.SetReportCaller(true)
+	logrus.AddHook(requestid.LogHook{})
+	logrus.AddHook(ContextHook{})
+	Reload(cfg)
+
+	var file *os.File
+	var err error
+	logrus.SetOutput(os.Stdout)
+	if cfg.LogLocation != "" {
+		now := time.Now()
+		name := config.ServiceName + "-" + now.Format(time.DateOnly) + "-" + strconv.FormatInt(now.Unix(), 10) + ".log"
+		file, err = os.OpenFile(filepath.Join(cfg.LogLocation, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			logrus.WithError(err).Warn("failed to create logs file, using default stdout")
+			file = nil
+		} else {
+			logrus.SetOutput(io.MultiWriter(os.Stdout, file))
+		}
+	}
+
+	// dependencies logging with the standard library, like net/http, are logged as structured entries too
+	log.SetFlags(0)
+	log.SetOutput(logrus.StandardLogger().WriterLevel(logrus.InfoLevel))
+
+	gin.DefaultWriter = logrus.StandardLogger().WriterLevel(logrus.DebugLevel)
+	gin.DefaultErrorWriter = logrus.StandardLogger().WriterLevel(logrus.ErrorLevel)
+	gin.DebugPrintRouteFunc = func(method, path, handler string, _ int) {
+		logrus.WithFields(logrus.Fields{"method": method, "path": path, "handler": handler}).Debug("registered route")
+	}
+	return file
+}
+
+// Reload applies the level and format from the server config to the standard logger. Unlike Configure, it can be
+// called any number of times, e.g. when the config is reloaded.
+func Reload(cfg config.ServerConfig) {
+	formatter, err := newFormatter(cfg.LogFormat)
+	if err != nil {
+		logrus.WithError(err).Errorf("could not set log format<%s>, using json", cfg.LogFormat)
+		formatter = &logrus.JSONFormatter{}
+	}
+	logrus.SetFormatter(formatter)
+
+	level := logrus.InfoLevel
+	if cfg.LogLevel != "" {
+		if level, err = logrus.ParseLevel(cfg.LogLevel); err != nil {
+			logrus.WithError(err).Errorf("could not parse log level<%s>, setting to info", cfg.LogLevel)
+			level = logrus.InfoLevel
+		}
+	}
+	logrus.SetLevel(level)
+}
+
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", JSONFormat:
+		return &logrus.JSONFormatter{}, nil
+	case PrettyFormat:
+		return &logrus.JSONFormatter{PrettyPrint: true}, nil
+	case TextFormat:
+		return &logrus.TextFormatter{FullTimestamp: true}, nil
+	default:
+		return nil, errors.Errorf("unsupported log format: %s", format)
+	}
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+func TestConfigure(t *testing.T) {
+	logger := logrus.StandardLogger()
+	level, formatter, out, hooks := logger.GetLevel(), logger.Formatter, logger.Out, logger.ReplaceHooks(make(logrus.LevelHooks))
+	stdOut, stdFlags := log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		logger.SetLevel(level)
+		logger.SetFormatter(formatter)
+		logger.SetOutput(out)
+		logger.ReplaceHooks(hooks)
+		log.SetOutput(stdOut)
+		log.SetFlags(stdFlags)
+	})
+
+	t.Run("sets level and format", func(tt *testing.T) {
+		file := Configure(config.ServerConfig{LogLevel: "warn", LogFormat: TextFormat})
+		assert.Nil(tt, file)
+		assert.Equal(tt, logrus.WarnLevel, logger.GetLevel())
+		assert.IsType(tt, &logrus.TextFormatter{}, logger.Formatter)
+	})
+
+	t.Run("falls back on invalid values", func(tt *testing.T) {
+		Configure(config.ServerConfig{LogLevel: "loud", LogFormat: "xml"})
+		assert.Equal(tt, logrus.InfoLevel, logger.GetLevel())
+		assert.IsType(tt, &logrus.JSONFormatter{}, logger.Formatter)
+	})
+
+	t.Run("writes to a file in the log location", func(tt *testing.T) {
+		dir := tt.TempDir()
+		file := Configure(config.ServerConfig{LogLevel: "info", LogLocation: dir})
+		require.NotNil(tt, file)
+		defer file.Close()
+
+		logrus.Info("written to file")
+		contents, err := os.ReadFile(file.Name())
+		require.NoError(tt, err)
+		assert.Contains(tt, string(contents), "written to file")
+	})
+}
+
+func TestContextHook(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.AddHook(ContextHook{})
+
+	t.Run("adds the fields of the context", func(tt *testing.T) {
+		ctx := WithFields(context.Background(), logrus.Fields{"route": "/v1/dids/:method", "tenant": "a"})
+		ctx = WithFields(ctx, logrus.Fields{"tenant": "b"})
+		logger.WithContext(ctx).WithField("route", "overridden").Info("logged")
+
+		entry := hook.LastEntry()
+		require.NotNil(tt, entry)
+		assert.Equal(tt, "overridden", entry.Data["route"])
+		assert.Equal(tt, "b", entry.Data["tenant"])
+		assert.NotContains(tt, entry.Data, TraceIDField)
+	})
+
+	t.Run("adds the trace and span IDs", func(tt *testing.T) {
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1, 2, 3},
+			SpanID:  trace.SpanID{4, 5, 6},
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+		logger.WithContext(ctx).Info("logged")
+
+		entry := hook.LastEntry()
+		require.NotNil(tt, entry)
+		assert.Equal(tt, spanContext.TraceID().String(), entry.Data[TraceIDField])
+		assert.Equal(tt, spanContext.SpanID().String(), entry.Data[SpanIDField])
+	})
+}
+
+func TestSampler(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	sampler := NewSampler(config.LogSamplingConfig{
+		First:      2,
+		Thereafter: 3,
+		Routes:     []config.RouteLogSamplingConfig{{Method: "GET", Path: "/v1/dids/resolver/:id"}},
+	})
+	sampler.now = func() time.Time { return now }
+
+	var sampled []bool
+	for i := 0; i < 8; i++ {
+		sampled = append(sampled, sampler.Sample("GET", "/v1/dids/resolver/:id"))
+	}
+	assert.Equal(t, []bool{true, true, false, false, true, false, false, true}, sampled)
+
+	// other routes aren't sampled
+	for i := 0; i < 8; i++ {
+		assert.True(t, sampler.Sample("PUT", "/v1/credentials"))
+	}
+
+	// counts start over every second
+	now = now.Add(time.Second)
+	assert.True(t, sampler.Sample("GET", "/v1/dids/resolver/:id"))
+
+	// nothing is sampled without a rate
+	sampler.Update(config.LogSamplingConfig{First: 1})
+	for i := 0; i < 8; i++ {
+		assert.True(t, sampler.Sample("GET", "/v1/dids/resolver/:id"))
+	}
+
+	var nilSampler *Sampler
+	assert.True(t, nilSampler.Sample("GET", "/health"))
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// Sampler samples the entries logged for hot routes, as configured by a LogSamplingConfig. It's safe for concurrent
+// use, and a nil Sampler logs every entry.
+type Sampler struct {
+	mu     sync.Mutex
+	cfg    config.LogSamplingConfig
+	routes map[string]bool
+
+	// counts of the entries of each route in the current second
+	second time.Time
+	counts map[string]int
+
+	now func() time.Time
+}
+
+// NewSampler returns a sampler applying the config.
+func NewSampler(cfg config.LogSamplingConfig) *Sampler {
+	s := Sampler{now: time.Now}
+	s.Update(cfg)
+	return &s
+}
+
+// Update replaces the config of the sampler, e.g. when the config is reloaded.
+func (s *Sampler) Update(cfg config.LogSamplingConfig) {
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.routes = routes
+	s.counts = make(map[string]int)
+}
+
+// Sample returns whether the entry of a request to the route, as registered, should be logged.
+func (s *Sampler) Sample(method, path string) bool {
+	if s == nil {
+		return true
+	}
+	route := method + " " + path
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.Thereafter <= 0 || (len(s.routes) > 0 && !s.routes[route]) {
+		return true
+	}
+	if second := s.now().Truncate(time.Second); !second.Equal(s.second) {
+		s.second = second
+		s.counts = make(map[string]int)
+	}
+	s.counts[route]++
+	count := s.counts[route]
+	return count <= s.cfg.First || (count-s.cfg.First)%s.cfg.Thereafter == 0
+}
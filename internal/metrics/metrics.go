@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ssi"
+
+const (
+	// OutcomeSuccess and OutcomeFailure label whether an operation succeeded.
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	// RateLimitAllowed, RateLimitLimited, and RateLimitError label whether a request was let through by its rate
+	// limit, rejected by it, or let through because the limit couldn't be checked.
+	RateLimitAllowed = "allowed"
+	RateLimitLimited = "limited"
+	RateLimitError   = "error"
+
+	// CacheHit and CacheMiss label whether a result was served from a cache.
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Registry holds every metric of the service, along with those of the Go runtime and the process.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// BuildInfo is always 1, labeled with the version of the service that's running.
+	BuildInfo = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Always 1, labeled with the version of the service.",
+	}, []string{"version"})
+
+	// HTTPRequests counts the requests handled, by route and status code, which error rates are computed from.
+	HTTPRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Requests handled, by method, route, and status code.",
+	}, []string{"method", "route", "code"})
+
+	// HTTPRequestDuration observes how long requests take to handle, by route.
+	HTTPRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "How long requests take to handle, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// KeystoreSignOperations counts the keys fetched from the keystore to sign with, by whether they could be.
+	KeystoreSignOperations = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "keystore",
+		Name:      "sign_operations_total",
+		Help:      "Keys fetched from the keystore to sign with, by outcome.",
+	}, []string{"outcome"})
+
+	// StorageOperationDuration observes how long storage operations take, by storage and operation.
+	StorageOperationDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "How long storage operations take, by storage, operation, and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"storage", "operation", "outcome"})
+
+	// RateLimitDecisions counts the requests checked against a rate limit, by the limit, which is either "default" or
+	// a configured route, and by the decision.
+	RateLimitDecisions = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "decisions_total",
+		Help:      "Requests checked against a rate limit, by limit and decision.",
+	}, []string{"limit", "decision"})
+
+	// DIDResolutionCacheRequests counts the resolutions looked up in the DID resolution cache, by whether they were
+	// cached, which the hit rate is computed from.
+	DIDResolutionCacheRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "did_resolution_cache",
+		Name:      "requests_total",
+		Help:      "Resolutions looked up in the DID resolution cache, by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Outcome returns the outcome label of an operation that failed with err, when it's not nil.
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
@@ -0,0 +1,71 @@
+// Package requestid carries the ID of the request being served through contexts, so that logs, error responses,
+// storage calls, and outbound webhooks can be correlated with the request that caused them.
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Header is the HTTP header requests IDs are read from and written to.
+	Header = "X-Request-ID"
+
+	// ContextKey is the key request IDs are stored under in gin contexts. It's a string because gin only looks up
+	// string keys when it's used as a context.Context.
+	ContextKey = "ssi-service-request-id"
+
+	// LogField is the name of the field request IDs are logged as.
+	LogField = "request_id"
+)
+
+type requestIDKey struct{}
+
+// validID matches request IDs accepted from callers, to keep arbitrary input out of logs and outbound headers.
+var validID = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,128}$`)
+
+// New returns a new random request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// IsValid returns whether an ID provided by a caller may be used as the request ID.
+func IsValid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// With returns a copy of ctx carrying the request ID.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	if id, ok := ctx.Value(ContextKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// LogHook adds the request ID to entries logged with a context carrying one, e.g. with logrus.WithContext(ctx).
+type LogHook struct{}
+
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if id := FromContext(entry.Context); id != "" {
+		entry.Data[LogField] = id
+	}
+	return nil
+}
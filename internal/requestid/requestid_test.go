@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Run("round trips through contexts", func(tt *testing.T) {
+		assert.Empty(tt, FromContext(context.Background()))
+
+		ctx := With(context.Background(), "abc")
+		assert.Equal(tt, "abc", FromContext(ctx))
+
+		ctx = context.WithValue(context.Background(), ContextKey, "def")
+		assert.Equal(tt, "def", FromContext(ctx))
+	})
+
+	t.Run("validates caller provided IDs", func(tt *testing.T) {
+		assert.True(tt, IsValid(New()))
+		assert.True(tt, IsValid("trace-1:span.2"))
+		assert.False(tt, IsValid(""))
+		assert.False(tt, IsValid("has spaces"))
+		assert.False(tt, IsValid("line\nbreak"))
+	})
+
+	t.Run("log hook adds the request ID", func(tt *testing.T) {
+		var buf bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&buf)
+		logger.SetFormatter(&logrus.JSONFormatter{})
+		logger.AddHook(LogHook{})
+
+		logger.WithContext(With(context.Background(), "abc")).Info("hello")
+		var entry map[string]any
+		require.NoError(tt, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(tt, "abc", entry[LogField])
+
+		buf.Reset()
+		logger.WithContext(context.Background()).Info("hello")
+		assert.NotContains(tt, buf.String(), LogField)
+	})
+}
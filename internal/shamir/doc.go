@@ -0,0 +1,10 @@
+// Package shamir splits secrets into shares with Shamir's secret sharing over GF(256), so that any threshold of the
+// shares reconstructs the secret, and fewer reveal nothing about it.
+//
+// shamir.go is the implementation of github.com/hashicorp/vault/shamir at v1.14.10, whose field arithmetic runs in
+// constant time, copied as it is under its own license, since depending on the vault module would upgrade most of
+// this module's dependencies. Changes to it should come from upstream.
+package shamir
+
+// MaxShares is the most shares a secret can be split into, since each share has a distinct, non-zero x coordinate.
+const MaxShares = 255
@@ -0,0 +1,74 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	// any threshold of the shares, in any order, reconstruct the secret
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var parts [][]byte
+		for _, i := range subset {
+			parts = append(parts, shares[i])
+		}
+		got, err := Combine(parts)
+		require.NoError(t, err)
+		assert.Equal(t, secret, got)
+	}
+
+	// fewer don't
+	got, err := Combine(shares[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, got)
+
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	assert.Error(t, err)
+	_, err = Combine([][]byte{shares[0], shares[1][:10]})
+	assert.Error(t, err)
+
+	_, err = Split(secret, 5, 1)
+	assert.Error(t, err)
+	_, err = Split(secret, 2, 3)
+	assert.Error(t, err)
+	_, err = Split(secret, 256, 3)
+	assert.Error(t, err)
+	_, err = Split(nil, 5, 3)
+	assert.Error(t, err)
+}
+
+func TestCombineEarlierShares(t *testing.T) {
+	// shares of "service key" split before the implementation of github.com/hashicorp/vault/shamir was adopted, which
+	// have the x coordinates 1 to 3
+	var shares [][]byte
+	for _, encoded := range []string{"eb9e4ee5aca9c29dbf4cae01", "58880a4bf8ec3041d837cc02", "c07336d83d2697fc0c1e1b03"} {
+		share, err := hex.DecodeString(encoded)
+		require.NoError(t, err)
+		shares = append(shares, share)
+	}
+	for _, subset := range [][][]byte{shares[:2], shares[1:], {shares[2], shares[0]}} {
+		got, err := Combine(subset)
+		require.NoError(t, err)
+		assert.Equal(t, "service key", string(got))
+	}
+}
+
+func TestField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			assert.Equal(t, byte(a), div(mult(byte(a), byte(b)), byte(b)))
+		}
+	}
+}
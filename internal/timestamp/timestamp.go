@@ -0,0 +1,353 @@
+// Package timestamp requests, and verifies, timestamps of digests from timestamping authorities, as defined in RFC 3161.
+// Tokens are CMS SignedData, as defined in RFC 5652, whose content is the TSTInfo of the timestamp.
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RequestContentType is the media type of timestamp requests.
+	RequestContentType = "application/timestamp-query"
+	// ResponseContentType is the media type of timestamp responses.
+	ResponseContentType = "application/timestamp-reply"
+)
+
+var (
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type request struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+type statusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type response struct {
+	Status statusInfo
+	Token  asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// Timestamp is what a timestamping authority attests to: that a digest existed at a time.
+type Timestamp struct {
+	Time         time.Time
+	SerialNumber *big.Int
+	Policy       asn1.ObjectIdentifier
+	// Certificate of the timestamping authority that signed the timestamp.
+	Certificate *x509.Certificate
+}
+
+// NewRequest returns a timestamp request, DER encoded, for the SHA-256 digest, with the nonce, and asking for the
+// certificate of the timestamping authority, so that the token can be verified on its own.
+func NewRequest(digest []byte, nonce *big.Int) ([]byte, error) {
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, errors.New("digest must be a SHA-256 digest")
+	}
+	return asn1.Marshal(request{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+}
+
+// ParseResponse returns the token of a timestamp response, or an error when the timestamping authority didn't grant
+// the timestamp.
+func ParseResponse(der []byte) ([]byte, error) {
+	var resp response
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, errors.Wrap(err, "parsing timestamp response")
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after timestamp response")
+	}
+	// 0 is granted, and 1 granted with modifications
+	if resp.Status.Status > 1 {
+		return nil, errors.Errorf("timestamp wasn't granted, status %d: %v", resp.Status.Status, resp.Status.StatusString)
+	}
+	if len(resp.Token.FullBytes) == 0 {
+		return nil, errors.New("timestamp response has no token")
+	}
+	return resp.Token.FullBytes, nil
+}
+
+// Stamp requests a timestamp of the SHA-256 digest from the timestamping authority at url, and returns its token,
+// once it's verified to cover the digest and the nonce of the request.
+func Stamp(ctx context.Context, client *http.Client, url string, digest []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+	body, err := NewRequest(digest, nonce)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating timestamp request")
+	}
+	req.Header.Set("Content-Type", RequestContentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting timestamp")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("timestamping authority answered %d", resp.StatusCode)
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading timestamp response")
+	}
+	token, err := ParseResponse(respBody)
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("timestamp doesn't have the nonce of the request")
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("timestamp doesn't cover the digest")
+	}
+	return token, nil
+}
+
+// Verify checks that the token is a timestamp of the SHA-256 digest, signed by a timestamping authority whose
+// certificate chains to roots, or to the system roots when roots is nil.
+func Verify(token, digest []byte, roots *x509.CertPool) (*Timestamp, error) {
+	info, signed, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, errors.New("timestamp isn't of a SHA-256 digest")
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("timestamp doesn't cover the digest")
+	}
+
+	certificates, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing certificates of timestamp")
+	}
+	if len(signed.SignerInfos) != 1 {
+		return nil, errors.New("timestamp must have exactly one signer")
+	}
+	signer, err := verifySigner(signed.SignerInfos[0], signed.ContentInfo.Content, certificates)
+	if err != nil {
+		return nil, err
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates {
+		intermediates.AddCert(certificate)
+	}
+	if _, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return nil, errors.Wrap(err, "verifying certificate of timestamping authority")
+	}
+	return &Timestamp{Time: info.GenTime, SerialNumber: info.SerialNumber, Policy: info.Policy, Certificate: signer}, nil
+}
+
+// parseToken returns the TSTInfo of a token, and the SignedData it's the content of.
+func parseToken(token []byte) (*tstInfo, *signedData, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(token, &info); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing timestamp token")
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, nil, errors.New("timestamp token isn't signed data")
+	}
+	var signed signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing signed data of timestamp token")
+	}
+	if !signed.ContentInfo.ContentType.Equal(oidTSTInfo) {
+		return nil, nil, errors.New("timestamp token doesn't hold a timestamp")
+	}
+	var tst tstInfo
+	if _, err := asn1.Unmarshal(signed.ContentInfo.Content, &tst); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing timestamp")
+	}
+	return &tst, &signed, nil
+}
+
+// verifySigner checks the signature of the signer over the content, and returns the certificate it was made with.
+func verifySigner(signer signerInfo, content []byte, certificates []*x509.Certificate) (*x509.Certificate, error) {
+	hash, err := hashOf(signer.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(signer.SignedAttrs.FullBytes) == 0 {
+		return nil, errors.New("timestamp signer has no signed attributes")
+	}
+	// the signature covers the signed attributes encoded as a SET, rather than with their implicit tag
+	signedAttrs := append([]byte{}, signer.SignedAttrs.FullBytes...)
+	signedAttrs[0] = asn1.TagSet | 0x20
+	var attributes []attribute
+	if _, err = asn1.UnmarshalWithParams(signedAttrs, &attributes, "set"); err != nil {
+		return nil, errors.Wrap(err, "parsing signed attributes of timestamp")
+	}
+	var contentType asn1.ObjectIdentifier
+	var messageDigest []byte
+	for _, attr := range attributes {
+		switch {
+		case attr.Type.Equal(oidContentType):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &contentType)
+		case attr.Type.Equal(oidMessageDigest):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &messageDigest)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing signed attribute of timestamp")
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return nil, errors.New("timestamp signer didn't sign a timestamp")
+	}
+	h := hash.New()
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), messageDigest) {
+		return nil, errors.New("timestamp signer didn't sign this timestamp")
+	}
+
+	for _, certificate := range certificates {
+		algorithm := signatureAlgorithm(certificate, hash)
+		if algorithm == x509.UnknownSignatureAlgorithm {
+			continue
+		}
+		if certificate.CheckSignature(algorithm, signedAttrs, signer.Signature) == nil {
+			return certificate, nil
+		}
+	}
+	return nil, errors.New("timestamp isn't signed by any of its certificates")
+}
+
+func hashOf(algorithm asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case algorithm.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case algorithm.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case algorithm.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, errors.Errorf("unsupported digest algorithm: %s", algorithm)
+}
+
+func signatureAlgorithm(certificate *x509.Certificate, hash crypto.Hash) x509.SignatureAlgorithm {
+	switch certificate.PublicKeyAlgorithm {
+	case x509.RSA:
+		switch hash {
+		case crypto.SHA256:
+			return x509.SHA256WithRSA
+		case crypto.SHA384:
+			return x509.SHA384WithRSA
+		case crypto.SHA512:
+			return x509.SHA512WithRSA
+		}
+	case x509.ECDSA:
+		switch hash {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512
+		}
+	case x509.Ed25519:
+		return x509.PureEd25519
+	}
+	return x509.UnknownSignatureAlgorithm
+}
@@ -0,0 +1,182 @@
+package timestamp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authority is a timestamping authority, with a certificate issued by a root, that signs timestamps as RFC 3161
+// describes.
+type authority struct {
+	root        *x509.Certificate
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	genTime     time.Time
+}
+
+func newAuthority(t *testing.T, usage x509.ExtKeyUsage) *authority {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	require.NoError(t, err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "tsa"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{root: root, certificate: certificate, key: key, genTime: time.Now().UTC().Truncate(time.Second)}
+}
+
+func (a *authority) roots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(a.root)
+	return roots
+}
+
+// respond answers a DER timestamp request with a DER timestamp response.
+func (a *authority) respond(t *testing.T, der []byte) []byte {
+	var req request
+	_, err := asn1.Unmarshal(der, &req)
+	require.NoError(t, err)
+
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(42),
+		GenTime:        a.genTime,
+		Nonce:          req.Nonce,
+	})
+	require.NoError(t, err)
+	contentDigest := sha256.Sum256(info)
+	contentTypeValue, err := asn1.Marshal(oidTSTInfo)
+	require.NoError(t, err)
+	messageDigestValue, err := asn1.Marshal(contentDigest[:])
+	require.NoError(t, err)
+	signedAttrs, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: contentTypeValue}},
+		{Type: oidMessageDigest, Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: messageDigestValue}},
+	}, "set")
+	require.NoError(t, err)
+	attrsDigest := sha256.Sum256(signedAttrs)
+	signature, err := a.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	require.NoError(t, err)
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	signed, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		ContentInfo:      encapsulatedContentInfo{ContentType: oidTSTInfo, Content: info},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.certificate.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: mustMarshal(t, struct{ Serial *big.Int }{a.certificate.SerialNumber})},
+			DigestAlgorithm:    sha256Algorithm,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedAttrs[2:]},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          signature,
+		}},
+	})
+	require.NoError(t, err)
+	token, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed}})
+	require.NoError(t, err)
+	return mustMarshal(t, response{Status: statusInfo{Status: 0}, Token: asn1.RawValue{FullBytes: token}})
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	der, err := asn1.Marshal(v)
+	require.NoError(t, err)
+	return der
+}
+
+func TestStampAndVerify(t *testing.T) {
+	tsa := newAuthority(t, x509.ExtKeyUsageTimeStamping)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, RequestContentType, r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", ResponseContentType)
+		_, _ = w.Write(tsa.respond(t, body))
+	}))
+	defer server.Close()
+
+	digest := sha256.Sum256([]byte("state"))
+	token, err := Stamp(context.Background(), server.Client(), server.URL, digest[:])
+	require.NoError(t, err)
+
+	timestamp, err := Verify(token, digest[:], tsa.roots())
+	require.NoError(t, err)
+	assert.Equal(t, tsa.genTime, timestamp.Time)
+	assert.Equal(t, int64(42), timestamp.SerialNumber.Int64())
+	assert.Equal(t, "tsa", timestamp.Certificate.Subject.CommonName)
+
+	// tokens only verify for their digest, and under the roots of their authority
+	other := sha256.Sum256([]byte("other state"))
+	_, err = Verify(token, other[:], tsa.roots())
+	assert.ErrorContains(t, err, "doesn't cover the digest")
+	_, err = Verify(token, digest[:], newAuthority(t, x509.ExtKeyUsageTimeStamping).roots())
+	assert.ErrorContains(t, err, "verifying certificate")
+	tampered := append([]byte{}, token...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Verify(tampered, digest[:], tsa.roots())
+	assert.Error(t, err)
+}
+
+func TestVerifyRequiresTimestampingCertificate(t *testing.T) {
+	tsa := newAuthority(t, x509.ExtKeyUsageServerAuth)
+	req, err := NewRequest(make([]byte, 32), nil)
+	require.NoError(t, err)
+	token, err := ParseResponse(tsa.respond(t, req))
+	require.NoError(t, err)
+	_, err = Verify(token, make([]byte, 32), tsa.roots())
+	assert.ErrorContains(t, err, "verifying certificate")
+}
+
+func TestParseResponseRejected(t *testing.T) {
+	der := mustMarshal(t, response{Status: statusInfo{Status: 2, StatusString: []string{"bad alg"}}})
+	_, err := ParseResponse(der)
+	assert.ErrorContains(t, err, "bad alg")
+
+	_, err = NewRequest([]byte("short"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+const (
+	// DefaultEndpoint is the traces endpoint of an OTLP/HTTP collector running next to the service.
+	DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+	exportTimeout = 10 * time.Second
+
+	// the field number of resource_spans in opentelemetry.proto.collector.trace.v1.ExportTraceServiceRequest
+	resourceSpansField protowire.Number = 1
+)
+
+// httpClient uploads traces to an OTLP/HTTP collector, as binary protobuf encoded export requests.
+type httpClient struct {
+	endpoint string
+	headers  []config.TracingHeaderConfig
+	client   *http.Client
+}
+
+func newHTTPClient(cfg config.TracingConfig) *httpClient {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return &httpClient{
+		endpoint: endpoint,
+		headers:  cfg.Headers,
+		// exports must not be traced themselves, so the client doesn't use an otelhttp transport
+		client: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+func (c *httpClient) Start(context.Context) error {
+	return nil
+}
+
+func (c *httpClient) Stop(context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *httpClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := marshalExportRequest(protoSpans)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating export request")
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for _, header := range c.headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "exporting traces")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("exporting traces: collector responded with %d: %s", resp.StatusCode, msg)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// marshalExportRequest encodes an ExportTraceServiceRequest of the spans, whose only field is the repeated message
+// of resource spans.
+func marshalExportRequest(protoSpans []*tracepb.ResourceSpans) ([]byte, error) {
+	var b []byte
+	for _, rs := range protoSpans {
+		encoded, err := proto.Marshal(rs)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling spans")
+		}
+		b = protowire.AppendTag(b, resourceSpansField, protowire.BytesType)
+		b = protowire.AppendBytes(b, encoded)
+	}
+	return b, nil
+}
+
+var _ otlptrace.Client = (*httpClient)(nil)
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// NewTracerProvider returns a TracerProvider that exports the traces of the service as configured, and makes it, and
+// the W3C trace context and baggage propagators, the global ones, so that traces are continued from incoming requests
+// and propagated to outgoing ones. The provider must be shut down to flush the traces it hasn't exported yet.
+func NewTracerProvider(ctx context.Context, cfg config.ServerConfig, version string) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.Tracing.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		// Always be sure to batch in production.
+		sdktrace.WithBatcher(exporter),
+		// Record information about this application in a Resource.
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(config.ServiceName),
+			semconv.ServiceVersionKey.String(version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp, nil
+}
+
+func newExporter(ctx context.Context, cfg config.ServerConfig) (sdktrace.SpanExporter, error) {
+	exporter := cfg.Tracing.Exporter
+	if exporter == "" && !cfg.Tracing.Enabled {
+		// only jager_enabled is set
+		exporter = config.TracingExporterJaeger
+	}
+	switch exporter {
+	case "", config.TracingExporterOTLP:
+		return otlptrace.New(ctx, newHTTPClient(cfg.Tracing))
+	case config.TracingExporterJaeger:
+		if cfg.JagerHost == "" {
+			return nil, errors.New("no jager host provided")
+		}
+		return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.JagerHost)))
+	default:
+		return nil, errors.Errorf("unsupported tracing exporter: %s", exporter)
+	}
+}
+
+// Start starts a span of the service, which is a child of the span ctx carries, if any. It's a no-op when tracing isn't
+// enabled.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(config.ServiceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording err as the reason it failed when it's not nil. It's meant to be deferred with a named
+// error result, e.g. defer func() { tracing.End(span, err) }().
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	t.Run("exports spans to the OTLP endpoint", func(t *testing.T) {
+		exported := make(chan *http.Request, 1)
+		var spans []*tracepb.ResourceSpans
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			spans = unmarshalExportRequest(t, body)
+			exported <- r
+		}))
+		defer collector.Close()
+
+		cfg := config.ServerConfig{Tracing: config.TracingConfig{
+			Enabled:  true,
+			Endpoint: collector.URL + "/v1/traces",
+			Headers:  []config.TracingHeaderConfig{{Name: "Authorization", Value: "Bearer token"}},
+		}}
+		tp, err := NewTracerProvider(context.Background(), cfg, "test")
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "credential.CreateCredential")
+		End(span, nil)
+		require.NoError(t, tp.ForceFlush(context.Background()))
+
+		r := <-exported
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Len(t, spans, 1)
+		require.Len(t, spans[0].ScopeSpans, 1)
+		require.Len(t, spans[0].ScopeSpans[0].Spans, 1)
+		assert.Equal(t, "credential.CreateCredential", spans[0].ScopeSpans[0].Spans[0].Name)
+		require.NoError(t, tp.Shutdown(context.Background()))
+	})
+
+	t.Run("collector errors fail the export", func(t *testing.T) {
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}))
+		defer collector.Close()
+
+		client := newHTTPClient(config.TracingConfig{Endpoint: collector.URL})
+		err := client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{{}})
+		assert.ErrorContains(t, err, "collector responded with 401")
+	})
+
+	t.Run("unsupported exporters are rejected", func(t *testing.T) {
+		cfg := config.ServerConfig{Tracing: config.TracingConfig{Enabled: true, Exporter: "zipkin"}}
+		_, err := NewTracerProvider(context.Background(), cfg, "test")
+		assert.ErrorContains(t, err, "unsupported tracing exporter")
+	})
+}
+
+func unmarshalExportRequest(t *testing.T, b []byte) []*tracepb.ResourceSpans {
+	var spans []*tracepb.ResourceSpans
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		require.Equal(t, resourceSpansField, num)
+		require.Equal(t, protowire.BytesType, typ)
+		b = b[n:]
+
+		encoded, n := protowire.ConsumeBytes(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		var rs tracepb.ResourceSpans
+		require.NoError(t, proto.Unmarshal(encoded, &rs))
+		spans = append(spans, &rs)
+	}
+	return spans
+}
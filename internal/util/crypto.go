@@ -2,6 +2,7 @@ package util
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/argon2"
@@ -94,3 +95,13 @@ func GenerateSalt(size int) ([]byte, error) {
 
 	return salt, nil
 }
+
+// RandomSecret returns 32 random bytes, base64url encoded without padding, for codes, tokens, and nonces that are
+// handed out once and must not be guessable.
+func RandomSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -36,6 +36,12 @@ func Artifact() error {
 	return sh.Run(Go, "build", "-tags", "jwx_es256k", "-o", "./bin/ssi-service", "./cmd/ssiservice")
 }
 
+// CLI builds the ssi command line client.
+func CLI() error {
+	fmt.Println("Building command line client...")
+	return sh.Run(Go, "build", "-o", "./bin/ssi", "./cmd/ssi")
+}
+
 // Vuln downloads and runs govulncheck https://go.dev/blog/vuln
 func Vuln() error {
 	fmt.Println("Vulnerability checks...")
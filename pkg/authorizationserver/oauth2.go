@@ -91,7 +91,7 @@ func NewServer(shutdown chan os.Signal, config *AuthConfig, store *storage.Memor
 	)
 
 	middlewares := gin.HandlersChain{
-		gin.Logger(),
+		middleware.AccessLog(nil),
 		gin.Recovery(),
 		middleware.Errors(shutdown),
 	}
@@ -106,7 +106,9 @@ func NewServer(shutdown chan os.Signal, config *AuthConfig, store *storage.Memor
 		os.Exit(1)
 	}
 
-	engine.GET(issuerMetadataPath, credentialIssuerMetadata(im))
+	// wallets fetch the metadata of every issuer they talk to, so it's cacheable like other public artifacts
+	caching := middleware.NewCaching(config.Server.Caching, false)
+	engine.GET(issuerMetadataPath, caching.Cache(), credentialIssuerMetadata(im))
 
 	// Set up oauth2 endpoints.
 	authService := NewAuthService(im, oauth2)
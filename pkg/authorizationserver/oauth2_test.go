@@ -68,6 +68,22 @@ func TestCredentialIssuerMetadata(t *testing.T) {
 
 	// Check that the issuer matches the DIDWebID that was fetched
 	assert.JSONEq(t, string(expectedIssuerMetadata), string(metadata))
+
+	// The metadata is cacheable, and not downloaded again while it's unchanged
+	resp, err := http.Get(server.URL + "/oidc/issuer/.well-known/openid-credential-issuer") // #nosec: testing only.
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/oidc/issuer/.well-known/openid-credential-issuer", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
 }
 
 func TestAuthorizationEndpoint(t *testing.T) {
@@ -0,0 +1,252 @@
+// Package client is a minimal client for the HTTP API of the SSI Service. It sends JSON requests, authenticates them,
+// and turns problem responses into errors, leaving the shape of requests and responses to its callers.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/httpsig"
+)
+
+const (
+	apiKeyHeader       = "X-API-Key"
+	idempotencyHeader  = "Idempotency-Key"
+	preferHeader       = "Prefer"
+	respondAsync       = "respond-async"
+	signatureLabel     = "sig1"
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+// Client sends requests to an SSI Service.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+	apiKey     string
+	token      string
+	userAgent  string
+
+	signingKeyID string
+	signingKey   crypto.Signer
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with an API key.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithBearerToken authenticates requests with an OAuth2 access token.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient sends requests with the given HTTP client, instead of one with a 30 second timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithSigningKey signs the requests that change something with HTTP Message Signatures, as services that require
+// request signatures expect. keyID is the ID that the public key was registered with as a client key.
+func WithSigningKey(keyID string, key crypto.Signer) Option {
+	return func(c *Client) {
+		c.signingKeyID = keyID
+		c.signingKey = key
+	}
+}
+
+// New creates a client for the service at endpoint, e.g. http://localhost:3000.
+func New(endpoint string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing endpoint")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, errors.Errorf("endpoint must be an http or https url: %s", endpoint)
+	}
+	c := Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c, nil
+}
+
+// Request is a request to the API.
+type Request struct {
+	Method string
+	// Path of the route, including its version, e.g. /v1/credentials.
+	Path  string
+	Query url.Values
+	// Body is encoded as JSON, unless it's a json.RawMessage or []byte, which are sent as they are.
+	Body any
+
+	// IdempotencyKey makes retries of the request safe, when set.
+	IdempotencyKey string
+	// Async asks for the request to run in the background, returning an operation.
+	Async bool
+}
+
+// Response is a successful response from the API.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       json.RawMessage
+}
+
+// Decode decodes the body of the response into v.
+func (r *Response) Decode(v any) error {
+	if len(r.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// Error is an error response from the API, which is a problem details document as described in RFC 7807.
+type Error struct {
+	StatusCode int          `json:"-"`
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance"`
+	Code       string       `json:"code"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	RequestID  string       `json:"requestId"`
+}
+
+// FieldError is an invalid field of a request payload.
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, field := range e.Errors {
+		msg += "\n  " + field.Field + ": " + field.Error
+	}
+	return msg
+}
+
+// Do sends a request, returning an *Error for responses that aren't successful.
+func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
+	target := c.endpoint + req.Path
+	if len(req.Query) > 0 {
+		target += "?" + req.Query.Encode()
+	}
+
+	var body io.Reader
+	var data []byte
+	if req.Body != nil {
+		switch b := req.Body.(type) {
+		case json.RawMessage:
+			data = b
+		case []byte:
+			data = b
+		default:
+			var err error
+			if data, err = json.Marshal(req.Body); err != nil {
+				return nil, errors.Wrap(err, "encoding request body")
+			}
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+	if req.IdempotencyKey != "" {
+		httpReq.Header.Set(idempotencyHeader, req.IdempotencyKey)
+	}
+	if req.Async {
+		httpReq.Header.Set(preferHeader, respondAsync)
+	}
+	if err = c.sign(httpReq, data); err != nil {
+		return nil, errors.Wrap(err, "signing request")
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sending %s %s", req.Method, req.Path)
+	}
+	defer httpResp.Body.Close()
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		apiErr := Error{StatusCode: httpResp.StatusCode}
+		if err = json.Unmarshal(respBody, &apiErr); err != nil || apiErr.Detail == "" {
+			apiErr.Detail = strings.TrimSpace(string(respBody))
+		}
+		return nil, &apiErr
+	}
+	return &Response{StatusCode: httpResp.StatusCode, Header: httpResp.Header, Body: respBody}, nil
+}
+
+// sign signs requests that change something, when the client has a signing key. The signature covers the method, the
+// path and query, and the digest of the body, leaving out the scheme and host, which proxies may change.
+func (c *Client) sign(req *http.Request, body []byte) error {
+	if c.signingKey == nil {
+		return nil
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	components := []string{"@method", "@request-target"}
+	if len(body) > 0 {
+		req.Header.Set(httpsig.ContentDigestHeader, httpsig.ContentDigest(body))
+		components = append(components, "content-digest")
+	}
+	alg, err := httpsig.KeyAlgorithm(c.signingKey.Public())
+	if err != nil {
+		return err
+	}
+	return httpsig.Sign(req, signatureLabel, components, c.signingKeyID, alg, c.signingKey)
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/httpsig"
+)
+
+func TestClient(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(body)
+		if r.URL.Path == "/v1/missing" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":400,"detail":"invalid request","code":"validation_failed","errors":[{"field":"keyType","error":"keyType is a required field"}],"requestId":"abc"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"123"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL+"/", WithAPIKey("key"), WithBearerToken("token"), WithUserAgent("test"))
+	require.NoError(t, err)
+
+	t.Run("sends requests", func(tt *testing.T) {
+		resp, err := c.Do(context.Background(), Request{
+			Method:         http.MethodPut,
+			Path:           "/v1/dids/key",
+			Query:          url.Values{"a": []string{"b"}},
+			Body:           map[string]any{"keyType": "Ed25519"},
+			IdempotencyKey: "retry-me",
+			Async:          true,
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, http.StatusCreated, resp.StatusCode)
+		var decoded struct {
+			ID string `json:"id"`
+		}
+		require.NoError(tt, resp.Decode(&decoded))
+		assert.Equal(tt, "123", decoded.ID)
+
+		assert.Equal(tt, http.MethodPut, got.Method)
+		assert.Equal(tt, "/v1/dids/key", got.URL.Path)
+		assert.Equal(tt, "b", got.URL.Query().Get("a"))
+		assert.JSONEq(tt, `{"keyType":"Ed25519"}`, gotBody)
+		assert.Equal(tt, "key", got.Header.Get("X-API-Key"))
+		assert.Equal(tt, "Bearer token", got.Header.Get("Authorization"))
+		assert.Equal(tt, "test", got.Header.Get("User-Agent"))
+		assert.Equal(tt, "retry-me", got.Header.Get("Idempotency-Key"))
+		assert.Equal(tt, "respond-async", got.Header.Get("Prefer"))
+	})
+
+	t.Run("returns problems as errors", func(tt *testing.T) {
+		_, err := c.Do(context.Background(), Request{Method: http.MethodGet, Path: "/v1/missing"})
+		var apiErr *Error
+		require.ErrorAs(tt, err, &apiErr)
+		assert.Equal(tt, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(tt, "validation_failed", apiErr.Code)
+		assert.Equal(tt, "abc", apiErr.RequestID)
+		assert.Equal(tt, "400 Bad Request: invalid request\n  keyType: keyType is a required field", err.Error())
+	})
+
+	t.Run("signs requests that change something", func(tt *testing.T) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(tt, err)
+		signing, err := New(server.URL, WithSigningKey("client-key", key))
+		require.NoError(tt, err)
+
+		_, err = signing.Do(context.Background(), Request{Method: http.MethodPut, Path: "/v1/dids/key", Body: map[string]any{"keyType": "Ed25519"}})
+		require.NoError(tt, err)
+		assert.NoError(tt, httpsig.VerifyContentDigest(got.Header.Get(httpsig.ContentDigestHeader), []byte(gotBody)))
+		signatures, err := httpsig.ParseSignatures(got)
+		require.NoError(tt, err)
+		require.Len(tt, signatures, 1)
+		assert.Equal(tt, "client-key", signatures[0].KeyID)
+		assert.True(tt, signatures[0].Covers("content-digest"))
+		assert.NoError(tt, signatures[0].Verify(got, httpsig.AlgorithmEd25519, key.Public()))
+
+		_, err = signing.Do(context.Background(), Request{Method: http.MethodGet, Path: "/v1/dids/key"})
+		require.NoError(tt, err)
+		assert.Empty(tt, got.Header.Get(httpsig.SignatureHeader))
+	})
+
+	t.Run("rejects endpoints that aren't http", func(tt *testing.T) {
+		_, err := New("localhost:3000")
+		assert.Error(tt, err)
+	})
+}
@@ -0,0 +1,69 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/client"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/testutil/ssitest"
+)
+
+// TestClientWithService checks that the service accepts the requests of the client, and that the client understands
+// the responses of the service.
+func TestClientWithService(t *testing.T) {
+	ctx := context.Background()
+	adminKey := "bootstrap-secret"
+	_, bootstrapKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	bootstrapJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK(auth.BootstrapClientKeyID, bootstrapKey)
+	require.NoError(t, err)
+	bootstrapJWKBytes, err := json.Marshal(bootstrapJWK)
+	require.NoError(t, err)
+
+	ssi := ssitest.New(t, ssitest.WithConfig(func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Services.AuthConfig.BootstrapClientKeyJWK = string(bootstrapJWKBytes)
+		cfg.Server.EnableAPIKeyAuth = true
+		cfg.Server.RequestSignatures.Required = true
+	}))
+	c := ssi.Client(t, client.WithAPIKey(adminKey), client.WithSigningKey(auth.BootstrapClientKeyID, bootstrapKey))
+
+	t.Run("authenticates and signs requests", func(tt *testing.T) {
+		resp, err := c.Do(ctx, client.Request{
+			Method:         http.MethodPut,
+			Path:           "/v1/dids/key",
+			Body:           map[string]any{"keyType": "Ed25519"},
+			IdempotencyKey: "create-did",
+		})
+		require.NoError(tt, err)
+		assert.Equal(tt, http.StatusCreated, resp.StatusCode)
+
+		// changes must be signed, so the service turns away clients without a signing key
+		unsigned := ssi.Client(tt, client.WithAPIKey(adminKey))
+		_, err = unsigned.Do(ctx, client.Request{Method: http.MethodPut, Path: "/v1/dids/key", Body: map[string]any{"keyType": "Ed25519"}})
+		var apiErr *client.Error
+		require.True(tt, errors.As(err, &apiErr))
+		assert.Equal(tt, http.StatusUnauthorized, apiErr.StatusCode)
+	})
+
+	t.Run("decodes problems", func(tt *testing.T) {
+		_, err := c.Do(ctx, client.Request{Method: http.MethodPut, Path: "/v1/dids/key", Body: map[string]any{}})
+		var apiErr *client.Error
+		require.True(tt, errors.As(err, &apiErr))
+		assert.Equal(tt, http.StatusBadRequest, apiErr.StatusCode)
+		assert.NotEmpty(tt, apiErr.Code)
+		assert.NotEmpty(tt, apiErr.RequestID)
+		assert.Equal(tt, "/v1/dids/key", apiErr.Instance)
+	})
+}
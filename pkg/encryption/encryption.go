@@ -1,12 +1,15 @@
 package encryption
 
 import (
+	"bytes"
 	"context"
 	"strings"
 
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/google/tink/go/aead"
 	"github.com/google/tink/go/core/registry"
+	"github.com/google/tink/go/hybrid"
+	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/integration/awskms"
 	"github.com/google/tink/go/integration/gcpkms"
 	"github.com/google/tink/go/keyset"
@@ -162,3 +165,74 @@ func NewExternalEncrypter(ctx context.Context, cfg ExternalEncryptionConfig) (En
 	}
 	return wrappedEncrypter{a}, wrappedDecrypter{a}, nil
 }
+
+type wrappedHybridEncrypter struct {
+	tink.HybridEncrypt
+}
+
+func (w wrappedHybridEncrypter) Encrypt(_ context.Context, plaintext, contextData []byte) ([]byte, error) {
+	return w.HybridEncrypt.Encrypt(plaintext, contextData)
+}
+
+var _ Encrypter = (*wrappedHybridEncrypter)(nil)
+
+type wrappedHybridDecrypter struct {
+	tink.HybridDecrypt
+}
+
+func (w wrappedHybridDecrypter) Decrypt(_ context.Context, ciphertext, contextInfo []byte) ([]byte, error) {
+	return w.HybridDecrypt.Decrypt(ciphertext, contextInfo)
+}
+
+var _ Decrypter = (*wrappedHybridDecrypter)(nil)
+
+// GenerateHybridKeyset creates a Tink keyset for HPKE (https://www.rfc-editor.org/rfc/rfc9180.html) with X25519 and
+// AES-256-GCM, and returns its private and public keysets as JSON. The private keyset isn't encrypted, so it's meant to
+// be kept offline, e.g. printed or on a hardware token, while the public keyset is given to whoever encrypts.
+func GenerateHybridKeyset() (privateKeyset, publicKeyset []byte, err error) {
+	kh, err := keyset.NewHandle(hybrid.DHKEM_X25519_HKDF_SHA256_HKDF_SHA256_AES_256_GCM_Key_Template())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating keyset handle")
+	}
+	publicHandle, err := kh.Public()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "getting public keyset handle")
+	}
+	var private, public bytes.Buffer
+	if err = insecurecleartextkeyset.Write(kh, keyset.NewJSONWriter(&private)); err != nil {
+		return nil, nil, errors.Wrap(err, "writing private keyset")
+	}
+	if err = publicHandle.WriteWithNoSecrets(keyset.NewJSONWriter(&public)); err != nil {
+		return nil, nil, errors.Wrap(err, "writing public keyset")
+	}
+	return private.Bytes(), public.Bytes(), nil
+}
+
+// NewHybridEncrypter creates an Encrypter that encrypts to the holder of the private keyset of a Tink public keyset,
+// given as JSON. Only the public keyset is needed to encrypt, so what's encrypted can't be decrypted by whoever
+// encrypted it.
+func NewHybridEncrypter(publicKeyset []byte) (Encrypter, error) {
+	kh, err := keyset.ReadWithNoSecrets(keyset.NewJSONReader(bytes.NewReader(publicKeyset)))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading public keyset")
+	}
+	e, err := hybrid.NewHybridEncrypt(kh)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating hybrid encrypt from keyset handle")
+	}
+	return wrappedHybridEncrypter{e}, nil
+}
+
+// NewHybridDecrypter creates a Decrypter of what's encrypted by the Encrypter of NewHybridEncrypter, from the private
+// Tink keyset, given as JSON.
+func NewHybridDecrypter(privateKeyset []byte) (Decrypter, error) {
+	kh, err := insecurecleartextkeyset.Read(keyset.NewJSONReader(bytes.NewReader(privateKeyset)))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading private keyset")
+	}
+	d, err := hybrid.NewHybridDecrypt(kh)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating hybrid decrypt from keyset handle")
+	}
+	return wrappedHybridDecrypter{d}, nil
+}
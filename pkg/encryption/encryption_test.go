@@ -91,3 +91,88 @@ func TestEncryptDecryptAllKeyTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestHybridEncryptDecrypt(t *testing.T) {
+	privateKeyset, publicKeyset, err := GenerateHybridKeyset()
+	assert.NoError(t, err)
+
+	// the public keyset holds no secrets, so it can't decrypt
+	_, err = NewHybridDecrypter(publicKeyset)
+	assert.Error(t, err)
+
+	encrypter, err := NewHybridEncrypter(publicKeyset)
+	assert.NoError(t, err)
+	ciphertext, err := encrypter.Encrypt(context.Background(), []byte("backup"), nil)
+	assert.NoError(t, err)
+
+	decrypter, err := NewHybridDecrypter(privateKeyset)
+	assert.NoError(t, err)
+	plaintext, err := decrypter.Decrypt(context.Background(), ciphertext, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("backup"), plaintext)
+
+	otherPrivateKeyset, _, err := GenerateHybridKeyset()
+	assert.NoError(t, err)
+	otherDecrypter, err := NewHybridDecrypter(otherPrivateKeyset)
+	assert.NoError(t, err)
+	_, err = otherDecrypter.Decrypt(context.Background(), ciphertext, nil)
+	assert.Error(t, err)
+}
+
+func TestEnvelopeEncrypter(t *testing.T) {
+	ctx := context.Background()
+	serviceKeyEncoded, err := createServiceKey()
+	assert.NoError(t, err)
+	serviceKey, err := base58.Decode(serviceKeyEncoded)
+	assert.NoError(t, err)
+	kek := NewXChaCha20Poly1305EncrypterWithKeyResolver(func(ctx context.Context) ([]byte, error) {
+		return serviceKey, nil
+	})
+	envelope := NewEnvelopeEncrypter(kek, kek, kek)
+
+	plaintext := []byte(`{"id":"key"}`)
+	ciphertext, err := envelope.Encrypt(ctx, plaintext, nil)
+	assert.NoError(t, err)
+	assert.True(t, IsEnvelope(ciphertext))
+	decrypted, err := envelope.Decrypt(ctx, ciphertext, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// each plaintext has a data key of its own
+	other, err := envelope.Encrypt(ctx, plaintext, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ciphertext[:len(envelopePrefix)+60], other[:len(envelopePrefix)+60])
+
+	// ciphertexts of the key encryption key are decrypted with the legacy decrypter
+	legacyCiphertext, err := kek.Encrypt(ctx, plaintext, nil)
+	assert.NoError(t, err)
+	assert.False(t, IsEnvelope(legacyCiphertext))
+	decrypted, err = envelope.Decrypt(ctx, legacyCiphertext, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+	_, err = NewEnvelopeEncrypter(kek, kek, nil).Decrypt(ctx, legacyCiphertext, nil)
+	assert.Error(t, err)
+
+	// rewrapping replaces the key encryption key
+	nextKey, err := createServiceKey()
+	assert.NoError(t, err)
+	nextKeyBytes, err := base58.Decode(nextKey)
+	assert.NoError(t, err)
+	nextKek := NewXChaCha20Poly1305EncrypterWithKey(nextKeyBytes)
+	rewrapped, err := envelope.Rewrap(ctx, ciphertext, nil, nextKek)
+	assert.NoError(t, err)
+	decrypted, err = NewEnvelopeEncrypter(nextKek, nextKek, nil).Decrypt(ctx, rewrapped, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+	_, err = envelope.Decrypt(ctx, rewrapped, nil)
+	assert.Error(t, err)
+	_, err = envelope.Rewrap(ctx, legacyCiphertext, nil, nextKek)
+	assert.Error(t, err)
+
+	// tampering with the ciphertext fails decryption
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = envelope.Decrypt(ctx, ciphertext, nil)
+	assert.Error(t, err)
+	_, err = envelope.Decrypt(ctx, ciphertext[:len(envelopePrefix)+1], nil)
+	assert.Error(t, err)
+}
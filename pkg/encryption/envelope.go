@@ -0,0 +1,137 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/tbd54566975/ssi-service/internal/util"
+)
+
+// envelopePrefix starts every ciphertext of an EnvelopeEncrypter, so that they're told apart from ciphertexts that were
+// encrypted before. It starts with a zero byte, which JSON never does.
+var envelopePrefix = []byte("\x00env1")
+
+// EnvelopeEncrypter encrypts each plaintext with a data key of its own, which is stored along with the ciphertext,
+// encrypted with a key encryption key. The key encryption key only ever encrypts data keys, so it can be held by a key
+// manager, or replaced, without re-encrypting every plaintext with it.
+type EnvelopeEncrypter struct {
+	kekEncrypter Encrypter
+	kekDecrypter Decrypter
+
+	// legacy decrypts ciphertexts that weren't encrypted by the EnvelopeEncrypter, so that they keep being read until
+	// they're re-encrypted.
+	legacy Decrypter
+}
+
+// NewEnvelopeEncrypter creates an EnvelopeEncrypter whose data keys are encrypted with kekEncrypter and decrypted with
+// kekDecrypter. Ciphertexts that weren't encrypted by an EnvelopeEncrypter are decrypted with legacy, which may be nil
+// when there are none.
+func NewEnvelopeEncrypter(kekEncrypter Encrypter, kekDecrypter Decrypter, legacy Decrypter) *EnvelopeEncrypter {
+	return &EnvelopeEncrypter{kekEncrypter: kekEncrypter, kekDecrypter: kekDecrypter, legacy: legacy}
+}
+
+// IsEnvelope returns whether ciphertext was encrypted by an EnvelopeEncrypter.
+func IsEnvelope(ciphertext []byte) bool {
+	return bytes.HasPrefix(ciphertext, envelopePrefix)
+}
+
+// Encrypt encrypts plaintext with a new data key, which is encrypted with the key encryption key, and contextData as
+// its associated data. The ciphertext is the prefix, the length of the encrypted data key as 2 big endian bytes, the
+// encrypted data key, and the encrypted plaintext.
+func (e EnvelopeEncrypter) Encrypt(ctx context.Context, plaintext, contextData []byte) ([]byte, error) {
+	dataKey, err := util.GenerateSalt(chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating data key")
+	}
+	encryptedKey, err := e.kekEncrypter.Encrypt(ctx, dataKey, contextData)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data key")
+	}
+	encrypted, err := util.XChaCha20Poly1305Encrypt(dataKey, plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting with data key")
+	}
+	return joinEnvelope(encryptedKey, encrypted)
+}
+
+// Decrypt decrypts a ciphertext of Encrypt, or one that was encrypted before with the legacy decrypter.
+func (e EnvelopeEncrypter) Decrypt(ctx context.Context, ciphertext, contextInfo []byte) ([]byte, error) {
+	if ciphertext == nil {
+		return nil, nil
+	}
+	if !IsEnvelope(ciphertext) {
+		if e.legacy == nil {
+			return nil, errors.New("ciphertext wasn't encrypted with a data key")
+		}
+		return e.legacy.Decrypt(ctx, ciphertext, contextInfo)
+	}
+
+	encryptedKey, encrypted, err := splitEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.kekDecrypter.Decrypt(ctx, encryptedKey, contextInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key")
+	}
+	plaintext, err := util.XChaCha20Poly1305Decrypt(dataKey, encrypted)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting with data key")
+	}
+	return plaintext, nil
+}
+
+// Rewrap encrypts the data key of a ciphertext of Encrypt with kekEncrypter instead, leaving the plaintext encrypted
+// as it is, so that the key encryption key can be replaced without decrypting every plaintext.
+func (e EnvelopeEncrypter) Rewrap(ctx context.Context, ciphertext, contextData []byte, kekEncrypter Encrypter) ([]byte, error) {
+	if !IsEnvelope(ciphertext) {
+		return nil, errors.New("ciphertext wasn't encrypted with a data key")
+	}
+	encryptedKey, encrypted, err := splitEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.kekDecrypter.Decrypt(ctx, encryptedKey, contextData)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key")
+	}
+	if encryptedKey, err = kekEncrypter.Encrypt(ctx, dataKey, contextData); err != nil {
+		return nil, errors.Wrap(err, "encrypting data key")
+	}
+	return joinEnvelope(encryptedKey, encrypted)
+}
+
+// joinEnvelope makes the ciphertext of Encrypt, which is the prefix, the length of the encrypted data key as 2 big
+// endian bytes, the encrypted data key, and the encrypted plaintext.
+func joinEnvelope(encryptedKey, encrypted []byte) ([]byte, error) {
+	if len(encryptedKey) > math.MaxUint16 {
+		return nil, errors.New("encrypted data key is too long")
+	}
+	ciphertext := make([]byte, 0, len(envelopePrefix)+2+len(encryptedKey)+len(encrypted))
+	ciphertext = append(ciphertext, envelopePrefix...)
+	ciphertext = binary.BigEndian.AppendUint16(ciphertext, uint16(len(encryptedKey)))
+	ciphertext = append(ciphertext, encryptedKey...)
+	return append(ciphertext, encrypted...), nil
+}
+
+// splitEnvelope returns the encrypted data key, and the encrypted plaintext, of a ciphertext of Encrypt.
+func splitEnvelope(ciphertext []byte) (encryptedKey, encrypted []byte, err error) {
+	rest := ciphertext[len(envelopePrefix):]
+	if len(rest) < 2 {
+		return nil, nil, errors.New("ciphertext is too short")
+	}
+	keyLength := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < keyLength {
+		return nil, nil, errors.New("ciphertext is too short")
+	}
+	return rest[:keyLength], rest[keyLength:], nil
+}
+
+var _ Encrypter = (*EnvelopeEncrypter)(nil)
+var _ Decrypter = (*EnvelopeEncrypter)(nil)
@@ -0,0 +1,22 @@
+// Package adminui embeds the admin web UI, a single page that operators browse and review what the service holds
+// with, so that it's served by the service wherever it runs. The page calls the API with the admin credential the
+// operator signs in with; it holds no data of its own.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var static embed.FS
+
+// Files are the files of the UI, with index.html at the root.
+func Files() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// the directory is embedded, so it's always there
+		panic(err)
+	}
+	return files
+}
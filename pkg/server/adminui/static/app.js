@@ -0,0 +1,301 @@
+// The admin UI: a page for browsing what the service holds, and reviewing applications and submissions, through the
+// API. Everything shown comes from the API, with the admin credential the operator signs in with, which is kept in
+// the session storage of the tab, and is forgotten when the tab is closed. Values from the API are only ever set as
+// text, never as markup.
+"use strict";
+
+const pageSize = 50;
+const credentialKey = "ssi-admin-credential";
+
+// views describe the lists the UI browses: where they're read from, the field of the response holding them, and the
+// columns shown for each item.
+const views = {
+  dids: {
+    path: (filters) => "/v1/dids/" + encodeURIComponent(filters.method || "key"),
+    field: "dids",
+    filters: [{name: "method", label: "Method", value: "key"}],
+    columns: {
+      ID: (d) => d.id,
+      Controller: (d) => d.controller,
+    },
+  },
+  credentials: {
+    path: () => "/v1/credentials",
+    field: "credentials",
+    filters: [
+      {name: "issuer", label: "Issuer"},
+      {name: "subject", label: "Subject"},
+      {name: "schema", label: "Schema"},
+    ],
+    columns: {
+      ID: (c) => c.id,
+      Issuer: (c) => c.credential && (c.credential.issuer.id || c.credential.issuer),
+      Issued: (c) => c.credential && (c.credential.issuanceDate || c.credential.validFrom),
+      Status: (c) => (c.revoked ? "revoked" : c.suspended ? "suspended" : "valid"),
+    },
+  },
+  schemas: {
+    path: () => "/v1/schemas",
+    field: "schemas",
+    columns: {
+      ID: (s) => s.id,
+      Type: (s) => s.type,
+      Name: (s) => s.schema && s.schema.name,
+    },
+  },
+  manifests: {
+    path: () => "/v1/manifests",
+    field: "manifests",
+    columns: {
+      ID: (m) => m.id,
+      Name: (m) => m.credential_manifest.name,
+      Issuer: (m) => m.credential_manifest.issuer.id,
+    },
+  },
+  applications: {
+    path: () => "/v1/manifests/applications",
+    field: "applications",
+    columns: {
+      ID: (a) => a.id,
+      Manifest: (a) => a.manifest_id,
+      Applicant: (a) => a.applicant,
+    },
+    review: (a) => "/v1/manifests/applications/" + encodeURIComponent(a.id) + "/review",
+  },
+  submissions: {
+    path: () => "/v1/presentations/submissions",
+    field: "submissions",
+    filters: [{name: "filter", label: "Filter", value: 'status="pending"'}],
+    columns: {
+      ID: (s) => submissionID(s),
+      Holder: (s) => s.verifiablePresentation && s.verifiablePresentation.holder,
+      Status: (s) => s.status,
+      Reason: (s) => s.reason,
+    },
+    review: (s) => "/v1/presentations/submissions/" + encodeURIComponent(submissionID(s)) + "/review",
+  },
+};
+
+const state = {config: {apiUrl: ""}, view: "dids", filters: {}, nextPageToken: ""};
+
+function submissionID(s) {
+  const vp = s.verifiablePresentation || {};
+  return (vp.presentation_submission || {}).id;
+}
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function credential() {
+  const stored = sessionStorage.getItem(credentialKey);
+  return stored ? JSON.parse(stored) : null;
+}
+
+// request calls the API, or the admin endpoints when admin is set, with the credential the operator signed in with.
+async function request(method, path, {query, body, admin} = {}) {
+  const url = new URL((admin ? "" : state.config.apiUrl) + path, window.location.href);
+  for (const [k, v] of Object.entries(query || {})) {
+    if (v) {
+      url.searchParams.set(k, v);
+    }
+  }
+  const headers = {Accept: "application/json"};
+  const cred = credential();
+  if (cred && cred.type === "bearer") {
+    headers.Authorization = "Bearer " + cred.value;
+  } else if (cred) {
+    headers["X-API-Key"] = cred.value;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const resp = await fetch(url, {method, headers, body: body === undefined ? undefined : JSON.stringify(body)});
+  const text = await resp.text();
+  const data = text ? JSON.parse(text) : {};
+  if (!resp.ok) {
+    const err = new Error(data.detail || resp.status + " " + resp.statusText);
+    err.status = resp.status;
+    throw err;
+  }
+  return data;
+}
+
+function show(signedIn) {
+  $("sign-in").hidden = signedIn;
+  $("app").hidden = !signedIn;
+  $("sign-out").hidden = !signedIn;
+}
+
+async function signIn(event) {
+  event.preventDefault();
+  sessionStorage.setItem(credentialKey, JSON.stringify({type: $("credential-type").value, value: $("credential").value}));
+  $("credential").value = "";
+  try {
+    // the admin endpoints only answer admins, so this checks the credential before anything is shown
+    await request("GET", "/admin/features", {admin: true});
+  } catch (err) {
+    sessionStorage.removeItem(credentialKey);
+    $("sign-in-error").textContent = err.status === 401 || err.status === 403 ? "Not an admin credential" : err.message;
+    return;
+  }
+  $("sign-in-error").textContent = "";
+  show(true);
+  selectView(state.view);
+}
+
+function signOut() {
+  sessionStorage.removeItem(credentialKey);
+  show(false);
+}
+
+function selectView(name) {
+  state.view = name;
+  state.filters = {};
+  for (const button of document.querySelectorAll("#tabs button")) {
+    button.classList.toggle("active", button.dataset.view === name);
+  }
+  renderFilters();
+  load(false);
+}
+
+function renderFilters() {
+  const container = $("filters");
+  container.replaceChildren();
+  const view = views[state.view];
+  if (!view.filters) {
+    return;
+  }
+  const form = document.createElement("form");
+  for (const filter of view.filters) {
+    const label = document.createElement("label");
+    label.textContent = filter.label + " ";
+    const input = document.createElement("input");
+    input.name = filter.name;
+    input.value = filter.value || "";
+    state.filters[filter.name] = input.value;
+    label.append(input, " ");
+    form.append(label);
+  }
+  const apply = document.createElement("button");
+  apply.type = "submit";
+  apply.textContent = "Apply";
+  form.append(apply);
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    for (const input of form.querySelectorAll("input")) {
+      state.filters[input.name] = input.value.trim();
+    }
+    load(false);
+  });
+  container.append(form);
+}
+
+// load reads the first page of the view, or the next one when more is set.
+async function load(more) {
+  const view = views[state.view];
+  if (!more) {
+    $("rows").replaceChildren();
+    $("details").hidden = true;
+    renderColumns(view);
+  }
+  $("error").textContent = "";
+  $("more").hidden = true;
+  const query = {pageSize: String(pageSize), pageToken: more ? state.nextPageToken : ""};
+  for (const [k, v] of Object.entries(state.filters)) {
+    if (k !== "method") {
+      query[k] = v;
+    }
+  }
+  try {
+    const data = await request("GET", view.path(state.filters), {query});
+    for (const item of data[view.field] || []) {
+      $("rows").append(renderRow(view, item));
+    }
+    state.nextPageToken = data.nextPageToken || "";
+    $("more").hidden = !state.nextPageToken;
+  } catch (err) {
+    if (err.status === 401) {
+      signOut();
+      return;
+    }
+    $("error").textContent = err.message;
+  }
+}
+
+function renderColumns(view) {
+  const columns = Object.keys(view.columns);
+  if (view.review) {
+    columns.push("Review");
+  }
+  $("columns").replaceChildren(...columns.map((name) => {
+    const th = document.createElement("th");
+    th.textContent = name;
+    return th;
+  }));
+}
+
+function renderRow(view, item) {
+  const tr = document.createElement("tr");
+  for (const value of Object.values(view.columns)) {
+    const td = document.createElement("td");
+    let text;
+    try {
+      text = value(item);
+    } catch (err) {
+      text = "";
+    }
+    td.textContent = text === undefined || text === null ? "" : String(text);
+    tr.append(td);
+  }
+  if (view.review) {
+    const td = document.createElement("td");
+    td.append(reviewButton(view, item, true, "Approve"), reviewButton(view, item, false, "Deny"));
+    tr.append(td);
+  }
+  tr.addEventListener("click", () => {
+    $("details").textContent = JSON.stringify(item, null, 2);
+    $("details").hidden = false;
+  });
+  return tr;
+}
+
+function reviewButton(view, item, approved, label) {
+  const button = document.createElement("button");
+  button.type = "button";
+  button.textContent = label;
+  button.addEventListener("click", async (event) => {
+    event.stopPropagation();
+    const reason = window.prompt(label + " with reason:", "");
+    if (reason === null) {
+      return;
+    }
+    try {
+      await request("PUT", view.review(item), {body: {approved, reason}});
+      load(false);
+    } catch (err) {
+      $("error").textContent = err.message;
+    }
+  });
+  return button;
+}
+
+async function start() {
+  $("sign-in-form").addEventListener("submit", signIn);
+  $("sign-out").addEventListener("click", signOut);
+  $("more").addEventListener("click", () => load(true));
+  for (const button of document.querySelectorAll("#tabs button")) {
+    button.addEventListener("click", () => selectView(button.dataset.view));
+  }
+  try {
+    state.config = await (await fetch("config.json")).json();
+  } catch (err) {
+    $("sign-in-error").textContent = "Could not load the config of the UI";
+  }
+  show(credential() !== null);
+  if (credential() !== null) {
+    selectView(state.view);
+  }
+}
+
+start();
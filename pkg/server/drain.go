@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/inflight"
+)
+
+// Drain gives the work running in the background a chance to finish, and should be called once the server stopped
+// accepting requests: scheduled jobs, which stop being scheduled, are waited for, and then the shutdown hooks of every
+// component are run, which wait for asynchronous operations, signatures being counted, and webhook deliveries. Jobs
+// are waited for first, since some of them, like the expiry check, publish webhooks. Drain
+// returns once everything has finished, or when ctx is done, in which case the operations still running are recorded
+// as interrupted so that clients know to retry them. Readiness probes fail from when Drain is called. Storage stays
+// open, for CloseStorage to close once Drain returns.
+func (s *SSIServer) Drain(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	s.stopJobs()
+
+	errs := sdkutil.NewAppendError()
+	if err := s.jobs.Drain(ctx); err != nil {
+		errs.Append(errors.Wrap(err, "waiting for scheduled jobs"))
+	}
+	if err := s.SSIService.Drain(ctx); err != nil {
+		errs.Append(err)
+	}
+	if !errs.IsEmpty() {
+		return errs.Error()
+	}
+	logrus.Info("drained background work")
+	return nil
+}
+
+// runJob runs job in the background until ctx is done, tracking it in jobs so that Drain waits for it to return.
+func runJob(ctx context.Context, jobs *inflight.Tracker, job func(ctx context.Context)) {
+	if !jobs.Start() {
+		return
+	}
+	go func() {
+		defer jobs.Done()
+		job(ctx)
+	}()
+}
@@ -0,0 +1,111 @@
+// Package features implements feature flags, which let experimental capabilities ship disabled and be enabled in
+// config, for a whole deployment or for some of its tenants.
+package features
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// Flag guards an experimental capability. Capabilities graduate by removing their flag, after which configs that still
+// name it are only warned about.
+type Flag struct {
+	// Name of the flag, as configured, e.g. sd-jwt-credentials.
+	Name string `json:"name"`
+
+	// What the flag enables.
+	Description string `json:"description"`
+}
+
+// State is whether a flag is enabled, and for whom.
+type State struct {
+	Flag
+
+	// Whether the flag is enabled for every tenant.
+	Enabled bool `json:"enabled"`
+
+	// Tenants the flag is enabled for, when it isn't enabled for every tenant.
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// Flags holds the known flags and whom they're enabled for, which can be updated while the server is running.
+type Flags struct {
+	known []Flag
+
+	mu      sync.RWMutex
+	enabled map[string]bool
+	tenants map[string]map[string]bool
+}
+
+// NewFlags creates the known flags, enabled as configured.
+func NewFlags(known []Flag, cfg []config.FeatureConfig) *Flags {
+	sorted := make([]Flag, len(known))
+	copy(sorted, known)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	f := Flags{known: sorted}
+	f.Update(cfg)
+	return &f
+}
+
+// Update enables the flags as configured, disabling every other flag. Flags that aren't known are ignored with a
+// warning, so that configs can outlive the flags they name.
+func (f *Flags) Update(cfg []config.FeatureConfig) {
+	enabled := make(map[string]bool)
+	tenants := make(map[string]map[string]bool)
+	for _, feature := range cfg {
+		if !f.isKnown(feature.Name) {
+			logrus.Warnf("ignoring unknown feature flag: %s", feature.Name)
+			continue
+		}
+		if feature.Enabled {
+			enabled[feature.Name] = true
+		}
+		for _, tenant := range feature.Tenants {
+			if tenants[feature.Name] == nil {
+				tenants[feature.Name] = make(map[string]bool)
+			}
+			tenants[feature.Name][tenant] = true
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = enabled
+	f.tenants = tenants
+}
+
+// Enabled returns whether a flag is enabled for a tenant, where the default tenant is empty.
+func (f *Flags) Enabled(name, tenant string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.enabled[name] || f.tenants[name][tenant]
+}
+
+// States returns the state of every known flag, sorted by name.
+func (f *Flags) States() []State {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	states := make([]State, 0, len(f.known))
+	for _, flag := range f.known {
+		state := State{Flag: flag, Enabled: f.enabled[flag.Name]}
+		for tenant := range f.tenants[flag.Name] {
+			state.Tenants = append(state.Tenants, tenant)
+		}
+		sort.Strings(state.Tenants)
+		states = append(states, state)
+	}
+	return states
+}
+
+func (f *Flags) isKnown(name string) bool {
+	for _, flag := range f.known {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
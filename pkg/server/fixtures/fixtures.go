@@ -0,0 +1,197 @@
+// Package fixtures loads a declarative file of fixtures, like DIDs, schemas, and credentials, through the API, so that
+// local development and demos start from a known state.
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// fixtureNamespace holds the responses to the fixtures that were created, by name, so that each is created once.
+const fixtureNamespace = "fixture"
+
+// File lists fixtures by kind. Kinds are created in the order of the fields of File, and the fixtures of each kind in
+// the order they're listed, so fixtures can refer to those created before them.
+type File struct {
+	// API key the fixtures are created with, when the API requires one.
+	APIKey string `yaml:"apiKey"`
+
+	DIDs        []Fixture `yaml:"dids"`
+	Keys        []Fixture `yaml:"keys"`
+	Schemas     []Fixture `yaml:"schemas"`
+	Definitions []Fixture `yaml:"definitions"`
+	Manifests   []Fixture `yaml:"manifests"`
+	Credentials []Fixture `yaml:"credentials"`
+}
+
+// Fixture is a resource that is created with the body of the request creating it.
+type Fixture struct {
+	// Name of the fixture, unique within the file. Later fixtures refer to the response to creating it with references
+	// like ${issuer:$.did.id}, in the same way the operations of a batch do.
+	Name string `yaml:"name"`
+
+	// Method of DIDs, e.g. key.
+	Method string `yaml:"method"`
+
+	// Body of the request creating the fixture, as accepted by the API.
+	Body any `yaml:"body"`
+}
+
+// ReadFile reads the fixtures of a YAML file, which may be JSON as well.
+func ReadFile(path string) (*File, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading fixtures file")
+	}
+	var file File
+	if err = yaml.Unmarshal(fileBytes, &file); err != nil {
+		return nil, errors.Wrapf(err, "parsing fixtures file: %s", path)
+	}
+	return &file, nil
+}
+
+// request is the request that creates a fixture.
+type request struct {
+	fixture Fixture
+	path    string
+}
+
+// requests returns the requests creating the fixtures, in the order they're made, with paths relative to the version
+// of the API.
+func (f File) requests() ([]request, error) {
+	at := func(path string) func(Fixture) (string, error) {
+		return func(Fixture) (string, error) { return path, nil }
+	}
+	kinds := []struct {
+		fixtures []Fixture
+		path     func(Fixture) (string, error)
+	}{
+		{fixtures: f.DIDs, path: func(fixture Fixture) (string, error) {
+			if fixture.Method == "" {
+				return "", errors.New("did fixture has no method")
+			}
+			return "/dids/" + fixture.Method, nil
+		}},
+		{fixtures: f.Keys, path: at("/keys")},
+		{fixtures: f.Schemas, path: at("/schemas")},
+		{fixtures: f.Definitions, path: at("/presentations/definitions")},
+		{fixtures: f.Manifests, path: at("/manifests")},
+		{fixtures: f.Credentials, path: at("/credentials")},
+	}
+
+	var requests []request
+	names := make(map[string]bool)
+	for _, kind := range kinds {
+		for _, fixture := range kind.fixtures {
+			if fixture.Name == "" {
+				return nil, errors.New("fixture has no name")
+			}
+			if names[fixture.Name] {
+				return nil, errors.Errorf("fixture name %s is used more than once", fixture.Name)
+			}
+			names[fixture.Name] = true
+			path, err := kind.path(fixture)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fixture %s", fixture.Name)
+			}
+			requests = append(requests, request{fixture: fixture, path: path})
+		}
+	}
+	return requests, nil
+}
+
+// Load creates the fixtures of the file that weren't created yet, by making the requests creating them to handler,
+// which should be the engine serving the version of the API under prefix, e.g. /v1. The responses are kept in db, so
+// that loading the file again only creates the fixtures that were added to it.
+func Load(ctx context.Context, handler http.Handler, db storage.ServiceStorage, prefix string, file File) error {
+	requests, err := file.requests()
+	if err != nil {
+		return errors.Wrap(err, "invalid fixtures")
+	}
+
+	responses := make(map[string]any, len(requests))
+	created := 0
+	for _, req := range requests {
+		name := req.fixture.Name
+		response, err := db.Read(ctx, fixtureNamespace, name)
+		if err != nil {
+			return errors.Wrapf(err, "reading fixture %s", name)
+		}
+		if len(response) == 0 {
+			if response, err = create(ctx, handler, prefix, file.APIKey, req, responses); err != nil {
+				return errors.Wrapf(err, "creating fixture %s", name)
+			}
+			if err = db.Write(ctx, fixtureNamespace, name, response); err != nil {
+				return errors.Wrapf(err, "storing fixture %s", name)
+			}
+			created++
+		}
+		var decoded any
+		if err = json.Unmarshal(response, &decoded); err != nil {
+			return errors.Wrapf(err, "decoding response of fixture %s", name)
+		}
+		responses[name] = decoded
+	}
+	logrus.Infof("loaded fixtures: created %d, %d already existed", created, len(requests)-created)
+	return nil
+}
+
+// create makes the request creating a fixture, returning the response.
+func create(ctx context.Context, handler http.Handler, prefix, apiKey string, req request, responses map[string]any) ([]byte, error) {
+	var body []byte
+	if req.fixture.Body != nil {
+		var err error
+		if body, err = json.Marshal(req.fixture.Body); err != nil {
+			return nil, errors.Wrap(err, "encoding body")
+		}
+	}
+	path, body, err := middleware.ResolveReferences(req.path, body, responses)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, prefix+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set(middleware.APIKeyHeader, apiKey)
+	}
+	w := &response{header: make(http.Header), status: http.StatusOK}
+	handler.ServeHTTP(w, httpReq)
+	if !util.Is2xxResponse(w.status) {
+		return nil, errors.Errorf("PUT %s responded %d: %s", prefix+path, w.status, w.body.String())
+	}
+	return w.body.Bytes(), nil
+}
+
+// response records the response to the request creating a fixture.
+type response struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *response) Header() http.Header {
+	return r.header
+}
+
+func (r *response) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *response) WriteHeader(status int) {
+	r.status = status
+}
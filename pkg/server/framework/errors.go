@@ -1,22 +1,79 @@
 package framework
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// ProblemContentType is the content type of error responses, which are problem details documents as described in
+	// RFC 7807.
+	ProblemContentType = "application/problem+json"
+
+	// ProblemTypeBase is the base of the URIs identifying the types of problems, each of which is documented under the
+	// anchor of its code.
+	ProblemTypeBase = "https://github.com/TBD54566975/ssi-service/blob/main/doc/service/errors.md#"
+
+	// CodeValidationFailed is the code of requests whose payload has fields that failed validation.
+	CodeValidationFailed = "validation_failed"
+	// CodeMalformedRequest is the code of requests whose payload can't be decoded.
+	CodeMalformedRequest = "malformed_request"
+	// CodePayloadTooLarge is the code of requests whose body is larger than the configured limit.
+	CodePayloadTooLarge = "payload_too_large"
+	// CodeTimedOut is the code of requests that took longer than the timeout of their route.
+	CodeTimedOut = "timed_out"
+	// CodeQuotaExceeded is the code of requests that would take the usage of a meter over its quota for the month.
+	CodeQuotaExceeded = "quota_exceeded"
+	// CodeKeyFrozen is the code of requests that would sign with a key that's frozen because its signing spiked.
+	CodeKeyFrozen = "key_frozen"
+	// CodeApprovalRequired is the code of requests for sensitive operations that weren't approved by a second operator.
+	CodeApprovalRequired = "approval_required"
+	// CodeKeyStoreSealed is the code of requests that use keys before the service key of the keystore is reconstructed
+	// from its shares.
+	CodeKeyStoreSealed = "keystore_sealed"
+	// CodeReplayed is the code of requests carrying a JWT, or a request signature, that was accepted before.
+	CodeReplayed = "replayed"
+	// CodeChallengeRequired is the code of requests carrying a JWT without a challenge, when challenges are enabled.
+	CodeChallengeRequired = "challenge_required"
+	// CodeInvalidChallenge is the code of requests carrying a JWT whose challenge is unknown, expired, or bound to
+	// another definition or manifest.
+	CodeInvalidChallenge = "invalid_challenge"
+	// CodeSchemaValidationFailed is the code of requests issuing credentials whose data doesn't conform to their schema.
+	CodeSchemaValidationFailed = "schema_validation_failed"
+)
+
 // FieldError is used to indicate an error with a field in a request payload.
 type FieldError struct {
 	Field string `json:"field"`
 	Error string `json:"error"`
 }
 
-// ErrorResponse is the structure of response error payloads sent back to the requester
-// when validation of a request payload fails.
+// ErrorResponse is the structure of response error payloads sent back to the requester. It is a problem details
+// document, as described in RFC 7807, sent with the application/problem+json content type.
 type ErrorResponse struct {
-	Error  string `json:"error"`
-	Fields string `json:"fields,omitempty"`
+	// URI identifying the type of problem, which links to its documentation.
+	Type string `json:"type"`
+	// Summary of the type of problem, which is the status text of the response's status code.
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Explanation of this occurrence of the problem.
+	Detail string `json:"detail"`
+	// Path of the request that caused the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Code identifies the type of problem, e.g. validation_failed, for clients to handle it programmatically.
+	Code string `json:"code"`
+	// Errors of the fields in the request payload that failed validation.
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// Error is the same as Detail, and kept for clients that read errors from it.
+	// Deprecated: use Detail.
+	Error string `json:"error"`
+
+	// RequestID identifies the request in the service's logs.
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // SafeError is used to pass an error during the request through the server with
@@ -26,6 +83,9 @@ type SafeError struct {
 	Err        error
 	StatusCode int
 	Fields     []FieldError
+
+	// Code identifies the type of problem. When empty, it's derived from StatusCode.
+	Code string
 }
 
 // SafeError implements the `error` interface. It uses the default message of the
@@ -34,6 +94,11 @@ func (err *SafeError) Error() string {
 	return err.Err.Error()
 }
 
+// Unwrap returns the wrapped error.
+func (err *SafeError) Unwrap() error {
+	return err.Err
+}
+
 // FieldErrors returns a string containing all field errors.
 func (err *SafeError) FieldErrors() string {
 	if len(err.Fields) == 0 {
@@ -46,10 +111,29 @@ func (err *SafeError) FieldErrors() string {
 	return strings.Join(fieldErrs, ", ")
 }
 
+// ProblemCode returns the code identifying the type of problem, which defaults to the status text of the status code,
+// e.g. not_found.
+func (err *SafeError) ProblemCode() string {
+	if err.Code != "" {
+		return err.Code
+	}
+	return statusProblemCode(err.StatusCode)
+}
+
+// statusProblemCode returns the problem code of errors that have no more specific code than their HTTP status code,
+// which is its status text in snake case, e.g. not_found.
+func statusProblemCode(statusCode int) string {
+	text := http.StatusText(statusCode)
+	if text == "" {
+		text = http.StatusText(http.StatusInternalServerError)
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
 // newRequestError wraps a provided error with an HTTP status code. This function should be used
 // when router encounter expected errors.
-func newRequestError(err error, statusCode int, fields ...FieldError) error {
-	return &SafeError{err, statusCode, fields}
+func newRequestError(err error, statusCode int, code string, fields ...FieldError) error {
+	return &SafeError{Err: err, StatusCode: statusCode, Fields: fields, Code: code}
 }
 
 // shutdown is a type used to help with graceful shutdown of a server.
@@ -1,14 +1,18 @@
 package framework
 
 import (
+	"bytes"
 	"errors"
-	"github.com/sirupsen/logrus"
+	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/util"
+	sdkvalidator "github.com/go-playground/validator/v10"
 	"github.com/goccy/go-json"
+	"github.com/sirupsen/logrus"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -53,11 +57,29 @@ func init() {
 //
 // The provided value is checked for validation tags if it's a struct.
 func Decode(r *http.Request, val any) error {
-	decoder := json.NewDecoder(r.Body)
+	// the body is read in full first, so that reading more than the body limit isn't mistaken for malformed JSON
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return newRequestError(err, http.StatusRequestEntityTooLarge, CodePayloadTooLarge)
+		}
+		return newRequestError(err, http.StatusBadRequest, CodeMalformedRequest)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
 	decoder.DisallowUnknownFields()
 
-	if err := decoder.Decode(val); err != nil {
-		return newRequestError(err, http.StatusBadRequest)
+	if err = decoder.Decode(val); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			field := jsonFieldPath(reflect.TypeOf(val), typeErr.Field)
+			return newRequestError(err, http.StatusBadRequest, CodeMalformedRequest, FieldError{
+				Field: field,
+				Error: fmt.Sprintf("%s must be a %s, not a %s", field, typeErr.Type, typeErr.Value),
+			})
+		}
+		return newRequestError(err, http.StatusBadRequest, CodeMalformedRequest)
 	}
 
 	if err := validate.Struct(val); err != nil {
@@ -85,12 +107,54 @@ func Decode(r *http.Request, val any) error {
 			Err:        errors.New("field validation error"),
 			StatusCode: http.StatusBadRequest,
 			Fields:     fieldErrors,
+			Code:       CodeValidationFailed,
 		}
 	}
 
 	return nil
 }
 
+// jsonFieldPath converts a dotted path of Go struct fields in t into the path of their JSON names, the same way
+// validation errors name fields.
+func jsonFieldPath(t reflect.Type, path string) string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			break
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			break
+		}
+		if jsonName := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]; jsonName != "" && jsonName != "-" {
+			names[i] = jsonName
+		}
+		t = field.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// ValidateRequest checks the validation tags of a request, returning the fields that failed validation.
 func ValidateRequest(request any) error {
-	return util.IsValidStruct(request)
+	err := util.IsValidStruct(request)
+	var vErrors sdkvalidator.ValidationErrors
+	if !errors.As(err, &vErrors) {
+		return err
+	}
+	fieldErrors := make([]FieldError, 0, len(vErrors))
+	for _, vError := range vErrors {
+		// the namespace starts with the name of the request's type
+		_, path, _ := strings.Cut(vError.StructNamespace(), ".")
+		field := jsonFieldPath(reflect.TypeOf(request), path)
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Error: fmt.Sprintf("%s failed the %s validation", field, vError.Tag())})
+	}
+	return &SafeError{
+		Err:        err,
+		StatusCode: http.StatusBadRequest,
+		Fields:     fieldErrors,
+		Code:       CodeValidationFailed,
+	}
 }
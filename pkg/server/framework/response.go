@@ -1,14 +1,21 @@
 package framework
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/pkg/service/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 )
 
-// Respond convert a Go value to JSON and sends it to the client.
+// Respond convert a Go value to JSON and sends it to the client. Errors are sent as problem details documents.
 func Respond(c *gin.Context, data any, statusCode int) {
 	// check if the data is an error
 	var err error
@@ -19,16 +26,17 @@ func Respond(c *gin.Context, data any, statusCode int) {
 		var safeErr *SafeError
 		if ok = errors.As(err, &safeErr); !ok {
 			statusCode = http.StatusInternalServerError
-			logrus.WithError(err).Error("unsafe error")
-			safeErr.Err = errors.New("error processing request")
+			logrus.WithContext(c).WithError(err).Error("unsafe error")
+			safeErr = &SafeError{Err: errors.New("error processing request"), StatusCode: statusCode}
 		}
 		// if the error is a `SafeError`, we can retrieve the status code and any field errors from it and use them
 		// to build the response.
-		errResp := ErrorResponse{
-			Error:  safeErr.Err.Error(),
-			Fields: safeErr.FieldErrors(),
-		}
-		c.PureJSON(statusCode, errResp)
+		RespondProblem(c, ErrorResponse{
+			Status: statusCode,
+			Detail: safeErr.Err.Error(),
+			Code:   safeErr.ProblemCode(),
+			Errors: safeErr.Fields,
+		})
 		return
 	}
 
@@ -42,16 +50,77 @@ func Respond(c *gin.Context, data any, statusCode int) {
 	c.PureJSON(statusCode, data)
 }
 
-// LoggingRespondError sends an error response back to the client as a safe error
+// RespondProblem sends a problem details document to the client. The type, title, instance, and request ID of the
+// problem are filled in when empty, and its code defaults to the one of its status.
+func RespondProblem(c *gin.Context, problem ErrorResponse) {
+	problem = Problem(c, problem)
+	c.Header("Content-Type", ProblemContentType)
+	c.PureJSON(problem.Status, problem)
+}
+
+// Problem fills in the empty fields of a problem details document the same way RespondProblem does, for problems
+// that are sent as part of another response.
+func Problem(c *gin.Context, problem ErrorResponse) ErrorResponse {
+	if problem.Code == "" {
+		problem.Code = statusProblemCode(problem.Status)
+	}
+	if problem.Type == "" {
+		problem.Type = ProblemTypeBase + problem.Code
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	if problem.RequestID == "" {
+		problem.RequestID = requestid.FromContext(c)
+	}
+	problem.Error = problem.Detail
+	return problem
+}
+
+// LoggingRespondError sends an error response back to the client as a safe error. Requests whose body was larger than
+// allowed are always rejected with 413 Request Entity Too Large.
 func LoggingRespondError(c *gin.Context, err error, statusCode int) {
 	var fieldErrors []FieldError
+	var code string
 	var safeErr *SafeError
 	if errors.As(errors.WithStack(err), &safeErr) {
 		fieldErrors = safeErr.Fields
+		code = safeErr.Code
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		statusCode = http.StatusRequestEntityTooLarge
+		code = CodePayloadTooLarge
+	}
+	// keys are frozen deep in the services that sign, so requests signing with them are answered the same everywhere
+	var frozenErr *keystore.FrozenKeyError
+	if errors.As(err, &frozenErr) {
+		statusCode = http.StatusTooManyRequests
+		code = CodeKeyFrozen
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(frozenErr.Until).Seconds()))))
+	}
+	// credentials are validated against their schemas wherever they're issued, so the fields that fail are answered
+	// the same everywhere too
+	var schemaErr *credential.SchemaValidationError
+	if errors.As(err, &schemaErr) {
+		statusCode = http.StatusBadRequest
+		code = CodeSchemaValidationFailed
+		fieldErrors = make([]FieldError, 0, len(schemaErr.Violations))
+		for _, v := range schemaErr.Violations {
+			fieldErrors = append(fieldErrors, FieldError{Field: v.Field, Error: v.Error})
+		}
+	}
+	// as is the keystore, when its service key wasn't reconstructed from its shares yet
+	if errors.Is(err, keystore.ErrSealed) || errors.Is(err, keystore.ErrNotInitialized) {
+		statusCode = http.StatusServiceUnavailable
+		code = CodeKeyStoreSealed
 	}
 
-	requestErr := newRequestError(err, statusCode, fieldErrors...)
-	logrus.WithError(err).Error(requestErr.Error())
+	requestErr := newRequestError(err, statusCode, code, fieldErrors...)
+	logrus.WithContext(c).WithError(err).Error(requestErr.Error())
 	Respond(c, requestErr, statusCode)
 }
 
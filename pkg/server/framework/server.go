@@ -26,6 +26,9 @@ type Server struct {
 	tracer      trace.Tracer
 	shutdown    chan os.Signal
 	preShutdown []func(ctx context.Context) error
+
+	// set when TLS is enabled
+	certificates *certificates
 }
 
 // RegisterPreShutdownHook registers a possibly blocking function to be run before Shutdown is called.
@@ -47,7 +50,7 @@ func (s *Server) PreShutdownHooks(ctx context.Context) error {
 // NewServer creates a Server that handles a set of routes for the application.
 func NewServer(cfg config.ServerConfig, handler *gin.Engine, shutdown chan os.Signal) *Server {
 	var tracer trace.Tracer
-	if cfg.JagerEnabled {
+	if cfg.TracingEnabled() {
 		tracer = otel.Tracer(serviceName)
 	}
 
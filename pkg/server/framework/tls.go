@@ -0,0 +1,126 @@
+package framework
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// certificates holds the server certificate and the client CAs loaded from disk, which can be reloaded while the
+// server is running so that certificates can be rotated without a restart.
+type certificates struct {
+	cfg config.TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func loadCertificates(cfg config.TLSConfig) (*certificates, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls requires both a cert file and a key file")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("requiring client certificates requires a client ca file")
+	}
+	c := certificates{cfg: cfg}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// reload reads the certificates from disk again. The previous certificates stay in use when they cannot be read.
+func (c *certificates) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "loading server certificate")
+	}
+
+	var clientCAs *x509.CertPool
+	if c.cfg.ClientCAFile != "" {
+		pemBytes, err := os.ReadFile(c.cfg.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "reading client ca file")
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pemBytes) {
+			return errors.Errorf("no certificates found in client ca file: %s", c.cfg.ClientCAFile)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	return nil
+}
+
+// tlsConfig returns a config that always uses the most recently loaded certificates.
+func (c *certificates) tlsConfig() *tls.Config {
+	clientAuth := tls.NoClientCert
+	if c.cfg.ClientCAFile != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	if c.cfg.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+	}
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.cert, nil
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = c.clientCAs
+		return cfg, nil
+	}
+	return base
+}
+
+// EnableTLS makes the server serve HTTPS with the configured certificates, optionally requiring clients to present
+// a certificate signed by the configured client CAs.
+func (s *Server) EnableTLS(cfg config.TLSConfig) error {
+	certs, err := loadCertificates(cfg)
+	if err != nil {
+		return err
+	}
+	s.certificates = certs
+	s.Server.TLSConfig = certs.tlsConfig()
+	return nil
+}
+
+// ReloadCertificates reads the certificates configured in EnableTLS from disk again. New connections use the new
+// certificates, while existing connections are unaffected. Does nothing when TLS is not enabled.
+func (s *Server) ReloadCertificates() error {
+	if s.certificates == nil {
+		return nil
+	}
+	if err := s.certificates.reload(); err != nil {
+		return err
+	}
+	logrus.Info("reloaded tls certificates")
+	return nil
+}
+
+// ListenAndServe serves HTTPS when TLS is enabled, and plain HTTP otherwise.
+func (s *Server) ListenAndServe() error {
+	if s.certificates != nil {
+		return s.Server.ListenAndServeTLS("", "")
+	}
+	return s.Server.ListenAndServe()
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -31,6 +32,23 @@ func GetQueryValue(c *gin.Context, param string) *string {
 	return &got
 }
 
+// DryRunParam is the query parameter of destructive admin operations that, when true, reports what they would change
+// without changing anything.
+const DryRunParam = "dryRun"
+
+// IsDryRun returns whether the request is a dry run, as asked for with ?dryRun=true.
+func IsDryRun(c *gin.Context) (bool, error) {
+	value := GetQueryValue(c, DryRunParam)
+	if value == nil {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(*value)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid %s query parameter", DryRunParam)
+	}
+	return dryRun, nil
+}
+
 // PeekRequestBody reads a request's body without emptying the buffer
 func PeekRequestBody(r *http.Request) (string, error) {
 	bodyBytes, err := io.ReadAll(r.Body)
@@ -0,0 +1,169 @@
+// Package idempotency stores the responses of requests made with an Idempotency-Key, so that retries of a request
+// get its original response instead of running it again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	namespace = "idempotency"
+
+	// DefaultTTL is how long the response of a request is kept for retries.
+	DefaultTTL = 24 * time.Hour
+)
+
+// Record is what's stored for a request made with an idempotency key.
+type Record struct {
+	// RequestHash identifies the request, so that reusing the key for a different request can be detected.
+	RequestHash string `json:"requestHash"`
+
+	// Done is false while the request is being handled.
+	Done bool `json:"done"`
+
+	// The response to replay. Only set when Done is true.
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Store keeps records of requests made with idempotency keys in the service's storage, so they are shared between
+// instances of the service and scoped to the tenant of the request.
+type Store struct {
+	db    storage.ServiceStorage
+	ttl   time.Duration
+	clock clock.Clock
+}
+
+func NewStore(db storage.ServiceStorage, ttl time.Duration) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{db: db, ttl: ttl, clock: clock.New()}, nil
+}
+
+// Reserve records that the request identified by requestHash is being handled under key, unless an unexpired record
+// for key already exists, in which case that record is returned and nothing is written.
+func (s *Store) Reserve(ctx context.Context, key, requestHash string) (*Record, error) {
+	id := recordID(key)
+	existing, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		record, err := s.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			return record, nil
+		}
+		return nil, s.write(ctx, tx, id, Record{RequestHash: requestHash})
+	}, []storage.WatchKey{{Namespace: namespace, Key: id}})
+	if err != nil {
+		return nil, errors.Wrap(err, "reserving idempotency key")
+	}
+	if record, ok := existing.(*Record); ok {
+		return record, nil
+	}
+	return nil, nil
+}
+
+// Complete stores the response to the request reserved under key.
+func (s *Store) Complete(ctx context.Context, key string, record Record) error {
+	record.Done = true
+	return s.write(ctx, s.db, recordID(key), record)
+}
+
+// Release deletes the record for key, so the request can be retried.
+func (s *Store) Release(ctx context.Context, key string) error {
+	return s.db.Delete(ctx, namespace, recordID(key))
+}
+
+// DeleteExpired deletes the expired records of the tenant of ctx, returning how many were deleted. Expired records
+// are never replayed, so this only frees the storage they take.
+func (s *Store) DeleteExpired(ctx context.Context) (int, error) {
+	var expired []string
+	now := s.clock.Now()
+	err := s.db.Iterate(ctx, namespace, func(id string, recordBytes []byte) (bool, error) {
+		var record Record
+		if err := json.Unmarshal(recordBytes, &record); err != nil {
+			return false, errors.Wrapf(err, "unmarshalling idempotency record<%s>", id)
+		}
+		if now.After(record.ExpiresAt) {
+			expired = append(expired, id)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "reading idempotency records")
+	}
+	for i, id := range expired {
+		if err = s.db.Delete(ctx, namespace, id); err != nil {
+			return i, errors.Wrapf(err, "deleting idempotency record<%s>", id)
+		}
+	}
+	return len(expired), nil
+}
+
+// RunRetention deletes the expired records of the default tenant every interval, until ctx is done. Records of other
+// tenants are replaced when their keys are reused after expiring.
+func (s *Store) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteExpired(ctx); err != nil {
+				logrus.WithError(err).Error("deleting expired idempotency records")
+			}
+		}
+	}
+}
+
+// get returns the record with the given id, or nil when there's none or it has expired.
+func (s *Store) get(ctx context.Context, id string) (*Record, error) {
+	recordBytes, err := s.db.Read(ctx, namespace, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading idempotency record")
+	}
+	if len(recordBytes) == 0 {
+		return nil, nil
+	}
+	var record Record
+	if err = json.Unmarshal(recordBytes, &record); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling idempotency record")
+	}
+	if s.clock.Now().After(record.ExpiresAt) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (s *Store) write(ctx context.Context, tx storage.Tx, id string, record Record) error {
+	record.ExpiresAt = s.clock.Now().Add(s.ttl)
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "marshalling idempotency record")
+	}
+	return tx.Write(ctx, namespace, id, recordBytes)
+}
+
+// recordID hashes key, so that keys of any length and content can be stored.
+func recordID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestStore(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			newStore := func(t *testing.T) (*Store, *clock.Mock) {
+				store, err := NewStore(test.ServiceStorage(t), time.Hour)
+				require.NoError(t, err)
+				mockClock := clock.NewMock()
+				store.clock = mockClock
+				return store, mockClock
+			}
+
+			t.Run("reserves keys once", func(tt *testing.T) {
+				store, _ := newStore(tt)
+				record, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				assert.Nil(tt, record)
+
+				record, err = store.Reserve(context.Background(), "key", "other")
+				require.NoError(tt, err)
+				require.NotNil(tt, record)
+				assert.Equal(tt, "hash", record.RequestHash)
+				assert.False(tt, record.Done)
+			})
+
+			t.Run("returns completed responses", func(tt *testing.T) {
+				store, _ := newStore(tt)
+				_, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				require.NoError(tt, store.Complete(context.Background(), "key", Record{
+					RequestHash: "hash",
+					Status:      201,
+					ContentType: "application/json",
+					Body:        []byte(`{"id":"123"}`),
+				}))
+
+				record, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				require.NotNil(tt, record)
+				assert.True(tt, record.Done)
+				assert.Equal(tt, 201, record.Status)
+				assert.Equal(tt, "application/json", record.ContentType)
+				assert.Equal(tt, []byte(`{"id":"123"}`), record.Body)
+			})
+
+			t.Run("released keys can be reserved again", func(tt *testing.T) {
+				store, _ := newStore(tt)
+				_, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				require.NoError(tt, store.Release(context.Background(), "key"))
+
+				record, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				assert.Nil(tt, record)
+			})
+
+			t.Run("expired keys can be reserved again", func(tt *testing.T) {
+				store, mockClock := newStore(tt)
+				_, err := store.Reserve(context.Background(), "key", "hash")
+				require.NoError(tt, err)
+				mockClock.Add(time.Hour + time.Second)
+
+				record, err := store.Reserve(context.Background(), "key", "other")
+				require.NoError(tt, err)
+				assert.Nil(tt, record)
+			})
+
+			t.Run("deletes expired records", func(tt *testing.T) {
+				store, mockClock := newStore(tt)
+				_, err := store.Reserve(context.Background(), "expiring", "hash")
+				require.NoError(tt, err)
+				mockClock.Add(time.Hour + time.Second)
+				_, err = store.Reserve(context.Background(), "current", "hash")
+				require.NoError(tt, err)
+
+				deleted, err := store.DeleteExpired(context.Background())
+				require.NoError(tt, err)
+				assert.Equal(tt, 1, deleted)
+				exists, err := store.db.Exists(context.Background(), namespace, recordID("current"))
+				require.NoError(tt, err)
+				assert.True(tt, exists)
+				exists, err = store.db.Exists(context.Background(), namespace, recordID("expiring"))
+				require.NoError(tt, err)
+				assert.False(tt, exists)
+			})
+		})
+	}
+}
@@ -9,10 +9,9 @@ To use this middleware, you need to add it to your gin router in server.go:
 
 // setUpEngine creates the gin engine and sets up the middleware based on config
 func setUpEngine(cfg config.ServerConfig, shutdown chan os.Signal) *gin.Engine {
-	gin.ForceConsoleColor()
 	middlewares := gin.HandlersChain{
 		gin.Recovery(),
-		gin.Logger(),
+		middleware.AccessLog(nil),
 		middleware.Errors(shutdown),
 		middleware.AuthMiddleware(),
 	}
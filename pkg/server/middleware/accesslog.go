@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/logging"
+)
+
+// AccessLog logs every request once it has been handled, with its status and latency. Server errors are logged as
+// errors, client errors as warnings, and everything else as info, unless the sampler leaves it out. Everything logged
+// while the request is handled carries its method and route.
+func AccessLog(sampler *logging.Sampler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.AddFields(c, logrus.Fields{"method": c.Request.Method, "route": c.FullPath()})
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		status := c.Writer.Status()
+		if status < http.StatusBadRequest && !sampler.Sample(c.Request.Method, c.FullPath()) {
+			return
+		}
+		entry := logrus.WithContext(c).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      c.FullPath(),
+			"status":     status,
+			"latency_ms": float64(latency.Microseconds()) / 1000,
+			"client_ip":  c.ClientIP(),
+			"bytes":      c.Writer.Size(),
+			"user_agent": c.Request.UserAgent(),
+		})
+		if signer := c.GetString(SignerContextKey); signer != "" {
+			entry = entry.WithField("signer", signer)
+		}
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("request handled")
+		case status >= http.StatusBadRequest:
+			entry.Warn("request handled")
+		default:
+			entry.Info("request handled")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/approval"
+)
+
+const (
+	// ApprovalIDHeader is the header requesters use to make a request with the approval that was granted for it.
+	ApprovalIDHeader = "X-Approval-ID"
+	// ApprovalReasonHeader is the header requesters use to tell reviewers why they make a request that needs approval.
+	ApprovalReasonHeader = "X-Approval-Reason"
+)
+
+// PendingApprovalResponse is the response to requests that need approval, and were made without one.
+type PendingApprovalResponse struct {
+	Approval approval.Approval `json:"approval"`
+}
+
+// Approvals requires a second operator to approve requests to sensitive routes before they're handled.
+type Approvals struct {
+	service *approval.Service
+	routes  map[string]bool
+	dryRuns map[string]bool
+}
+
+// NewApprovals returns the approvals of requests to routes, which are keyed by their method and path as registered.
+// dryRunRoutes are the routes whose handlers honor ?dryRun=true, whose dry runs are handled without approval.
+func NewApprovals(service *approval.Service, routes, dryRunRoutes []config.ApprovalRouteConfig) (*Approvals, error) {
+	if service == nil {
+		return nil, errors.New("approval service cannot be nil")
+	}
+	approvals := Approvals{service: service}
+	var err error
+	if approvals.routes, err = routeSet(routes); err != nil {
+		return nil, errors.Wrap(err, "routes that need approval")
+	}
+	if approvals.dryRuns, err = routeSet(dryRunRoutes); err != nil {
+		return nil, errors.Wrap(err, "routes that support dry runs")
+	}
+	return &approvals, nil
+}
+
+// routeSet keys routes by their method and path as registered.
+func routeSet(routes []config.ApprovalRouteConfig) (map[string]bool, error) {
+	set := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		method := strings.ToUpper(route.Method)
+		if method == "" || !strings.HasPrefix(route.Path, "/") {
+			return nil, errors.Errorf("invalid route: %s %s", route.Method, route.Path)
+		}
+		set[method+" "+route.Path] = true
+	}
+	return set, nil
+}
+
+// Handler requires approval of requests to the sensitive routes. It must run after Authenticate, since approvals are
+// requested and used by the same caller, and reviewed by another.
+//
+// A request made without the X-Approval-ID header isn't handled. Instead, a pending approval of it is created, and
+// returned with 202 Accepted. Once another operator approves it, the caller repeats the request, with the same URI and
+// body, and the ID of the approval in X-Approval-ID, and the request is handled. Each approval is used once. Requests
+// with an approval that isn't approved, or is for another request or caller, are rejected with 403 Forbidden. Dry runs
+// are handled without approval on routes that support them, and rejected with 400 Bad Request on the others.
+func (a *Approvals) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		if IsAsyncReplay(c) || !a.routes[route] {
+			c.Next()
+			return
+		}
+		// dry runs don't change anything, so they're handled without approval, for operators to see what a request
+		// would do before they request approval of it. Routes that don't support them would handle them for real.
+		if dryRun, err := framework.IsDryRun(c); err == nil && dryRun {
+			if !a.dryRuns[route] {
+				framework.LoggingRespondErrMsg(c, "this operation doesn't support dry runs", http.StatusBadRequest)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		principal := GetPrincipal(c)
+		if principal == nil {
+			framework.LoggingRespondErrMsg(c, "missing credentials, which requests that need approval require", http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c.Request, body)
+
+		id := c.GetHeader(ApprovalIDHeader)
+		if id == "" {
+			pending, err := a.service.RequestApproval(c, approval.RequestApprovalRequest{
+				Method:      c.Request.Method,
+				URI:         c.Request.URL.RequestURI(),
+				Route:       c.FullPath(),
+				RequestHash: hash,
+				Tenant:      principal.TenantID,
+				RequestedBy: principal.ID,
+				Reason:      c.GetHeader(ApprovalReasonHeader),
+			})
+			if err != nil {
+				framework.LoggingRespondErrWithMsg(c, err, "could not request approval", http.StatusInternalServerError)
+				c.Abort()
+				return
+			}
+			c.Header("Location", "/admin/approvals/"+pending.ID)
+			framework.Respond(c, PendingApprovalResponse{Approval: *pending}, http.StatusAccepted)
+			c.Abort()
+			return
+		}
+
+		if _, err = a.service.UseApproval(c, approval.UseApprovalRequest{ID: id, RequestHash: hash, RequestedBy: principal.ID}); err != nil {
+			if errors.Is(err, approval.ErrApprovalNotFound) || errors.Is(err, approval.ErrNotGranted) {
+				framework.RespondProblem(c, framework.ErrorResponse{
+					Status: http.StatusForbidden,
+					Detail: "request needs approval: " + err.Error(),
+					Code:   framework.CodeApprovalRequired,
+				})
+			} else {
+				framework.LoggingRespondErrWithMsg(c, err, "could not use approval", http.StatusInternalServerError)
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
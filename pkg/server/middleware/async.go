@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
+)
+
+const (
+	// PreferHeader is the header clients use to ask for a request to be run asynchronously, as defined in RFC 7240.
+	PreferHeader = "Prefer"
+	// RespondAsync is the preference asking for a request to be run asynchronously.
+	RespondAsync = "respond-async"
+)
+
+// asyncReplayKey marks the requests that are replayed in the background on behalf of an asynchronous operation.
+type asyncReplayKey struct{}
+
+// Async runs requests in the background on behalf of clients that send `Prefer: respond-async`. Such requests are
+// answered right away with 202 Accepted and the operation tracking them, which can be polled under /v1/operations.
+type Async struct {
+	operations *operation.Service
+	handler    http.Handler
+}
+
+// NewAsync creates an Async that replays requests through handler, which should be the engine serving them.
+func NewAsync(operationService *operation.Service, handler http.Handler) *Async {
+	return &Async{operations: operationService, handler: handler}
+}
+
+// Handler returns the middleware for a route whose work is of the given kind, like "credentials/batch". It should be
+// placed after authentication and authorization, so that requests are only accepted from clients allowed to make
+// them, and before the middleware that acts on the outcome of the request, like webhooks.
+func (a *Async) Handler(kind string) gin.HandlerFunc {
+	parent := async.Parent(kind)
+	return func(c *gin.Context) {
+		if !prefersAsync(c.Request) || IsAsyncReplay(c) {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+
+		// the request is replayed in full, so it's authenticated and authorized again, and runs the same handlers
+		replay := c.Request.Clone(context.Background())
+		replay.Header.Del(PreferHeader)
+		// the response is stored as the result of the operation, so it mustn't be compressed
+		replay.Header.Del(AcceptEncodingHeader)
+		replay.Header.Set(requestid.Header, requestid.FromContext(c))
+		op, err := a.operations.RunAsync(c, parent, func(ctx context.Context) ([]byte, error) {
+			req := replay.WithContext(context.WithValue(ctx, asyncReplayKey{}, true))
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			w := newBufferedResponse()
+			a.handler.ServeHTTP(w, req)
+			if !util.Is2xxResponse(w.status) {
+				return nil, replayError(w)
+			}
+			return w.body.Bytes(), nil
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, operation.ErrShuttingDown) {
+				status = http.StatusServiceUnavailable
+			}
+			framework.LoggingRespondErrWithMsg(c, err, "could not start async operation", status)
+			c.Abort()
+			return
+		}
+
+		framework.Respond(c, router.Operation{ID: op.ID, Done: op.Done}, http.StatusAccepted)
+		c.Abort()
+	}
+}
+
+// IsAsyncReplay returns whether the request is being replayed in the background for an asynchronous operation.
+func IsAsyncReplay(c *gin.Context) bool {
+	replay, _ := c.Request.Context().Value(asyncReplayKey{}).(bool)
+	return replay
+}
+
+func prefersAsync(req *http.Request) bool {
+	for _, value := range req.Header.Values(PreferHeader) {
+		for _, preference := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), RespondAsync) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replayError returns the error of a replayed request, using the detail of its problem response when there is one.
+func replayError(w *bufferedResponse) error {
+	var resp framework.ErrorResponse
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err == nil && resp.Detail != "" {
+		return errors.New(resp.Detail)
+	}
+	return errors.Errorf("request failed with status %d", w.status)
+}
+
+// bufferedResponse is an http.ResponseWriter that keeps the response of a replayed request in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (w *bufferedResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponse) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	w.status = status
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/audit"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Audit records the calls that change something, which are all but GET, HEAD, and OPTIONS requests, in the audit log.
+type Audit struct {
+	service   *audit.Service
+	handler   http.Handler
+	creations []BatchUndo
+}
+
+// NewAudit creates an Audit that reads the resources calls act on through handler, which should be the engine serving
+// them, to record their hashes. The resource created by a call to one of the routes of creations is the one its undo
+// deletes.
+func NewAudit(service *audit.Service, handler http.Handler, creations []BatchUndo) *Audit {
+	return &Audit{service: service, handler: handler, creations: creations}
+}
+
+// Handler returns the middleware that records calls. It should come before authentication, so that calls which are
+// denied are recorded too. Every operation of a batch, and the asynchronous replay of a call, is recorded on its own.
+func (a *Audit) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		version := routeVersion(route)
+		creation, isCreation := a.creationOf(c.Request.Method, version, route)
+		// calls on a single resource have their hash read before and after them, which creations only have after
+		onResource := !isCreation && strings.Contains(route, "/:")
+		var before string
+		if onResource {
+			before = a.hash(c, c.Request.URL.Path)
+		}
+		var body []byte
+		if isCreation {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		w := &responseWriter{ResponseWriter: c.Writer, buf: new(bytes.Buffer)}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		status := c.Writer.Status()
+		resource := c.Request.URL.Path
+		if isCreation && audit.OutcomeOf(status) == audit.OutcomeSucceeded {
+			params := make(map[string]string, len(c.Params))
+			for _, param := range c.Params {
+				params[param.Key] = param.Value
+			}
+			created, err := fillUndoPath(creation.Undo, params, batchedOperation{body: body, response: w.buf.Bytes()})
+			if err != nil {
+				logrus.WithContext(c).WithError(err).Warnf("could not find the resource created by %s %s", c.Request.Method, route)
+			} else {
+				resource, onResource = version+created, true
+			}
+		}
+		var after string
+		if onResource {
+			after = a.hash(c, resource)
+		}
+
+		event := audit.Event{
+			RequestID:  requestid.FromContext(c.Request.Context()),
+			Tenant:     c.GetString(storage.TenantContextKey),
+			Signer:     c.GetString(SignerContextKey),
+			Method:     c.Request.Method,
+			Route:      route,
+			Resource:   resource,
+			BeforeHash: before,
+			AfterHash:  after,
+			Status:     status,
+			Outcome:    audit.OutcomeOf(status),
+		}
+		if principal := GetPrincipal(c); principal != nil {
+			event.Actor = principal.ID
+		}
+		// the call already happened, so failing to record it can only be logged
+		if _, err := a.service.Record(c, event); err != nil {
+			logrus.WithContext(c).WithError(err).Errorf("could not record audit event of %s %s", c.Request.Method, resource)
+		}
+	}
+}
+
+// creationOf returns how to find the resource created by calls to a route.
+func (a *Audit) creationOf(method, version, route string) (BatchUndo, bool) {
+	for _, creation := range a.creations {
+		if creation.Method == method && version+creation.Route == route {
+			return creation, true
+		}
+	}
+	return BatchUndo{}, false
+}
+
+// hash returns the ETag of the resource at path as the caller sees it, or nothing when it can't be read.
+func (a *Audit) hash(c *gin.Context, path string) string {
+	etag, err := resourceETag(a.handler, c.Request, path)
+	if err != nil {
+		// which includes resources the caller may not read
+		logrus.WithContext(c).WithError(err).Debugf("could not hash audited resource %s", path)
+		return ""
+	}
+	return etag
+}
+
+// routeVersion returns the first segment of a route, e.g. /v1 of /v1/dids/:method.
+func routeVersion(route string) string {
+	if i := strings.Index(strings.TrimPrefix(route, "/"), "/"); i >= 0 {
+		return route[:i+1]
+	}
+	return route
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/logging"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// APIKeyHeader is the header clients use to present their API key.
+	APIKeyHeader = "X-API-Key"
+
+	// PrincipalContextKey is the gin context key under which the authenticated *auth.Principal is stored.
+	PrincipalContextKey = "principal"
+
+	bearerPrefix = "Bearer "
+)
+
+// Authenticate rejects any request that doesn't present a valid credential. Depending on what's enabled, callers
+// may present an API key in the X-API-Key header, or an OAuth2 access token in the Authorization header. On success
+// the caller is made available to later handlers via GetPrincipal.
+func Authenticate(authService *auth.Service, enableAPIKeys, enableBearerTokens bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal *auth.Principal
+		var err error
+		rawKey := c.GetHeader(APIKeyHeader)
+		authorization := c.GetHeader("Authorization")
+		switch {
+		case enableAPIKeys && rawKey != "":
+			var apiKey *auth.APIKey
+			if apiKey, err = authService.Authenticate(c, rawKey); err == nil {
+				principal = apiKey.Principal()
+			}
+		case enableBearerTokens && strings.HasPrefix(authorization, bearerPrefix):
+			principal, err = authService.VerifyAccessToken(c, strings.TrimPrefix(authorization, bearerPrefix))
+		default:
+			framework.LoggingRespondErrMsg(c, "missing credentials", http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidAPIKey) || errors.Is(err, auth.ErrInvalidAccessToken) {
+				logrus.WithError(err).Warn("rejected credentials")
+				framework.LoggingRespondErrMsg(c, "invalid credentials", http.StatusUnauthorized)
+			} else {
+				framework.LoggingRespondErrWithMsg(c, err, "could not authenticate request", http.StatusInternalServerError)
+			}
+			c.Abort()
+			return
+		}
+
+		if err = authService.ApplyRoleBindings(c, principal); err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not resolve roles", http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+
+		c.Set(PrincipalContextKey, principal)
+		logFields := logrus.Fields{"principal": principal.ID}
+		if principal.TenantID != "" {
+			// scopes all storage operations done while handling the request to the tenant of the caller
+			c.Set(storage.TenantContextKey, principal.TenantID)
+			logFields["tenant"] = principal.TenantID
+		}
+		logging.AddFields(c, logFields)
+		c.Next()
+	}
+}
+
+// APIKeyAuth rejects any request that doesn't present a valid, non-revoked API key in the X-API-Key header.
+func APIKeyAuth(authService *auth.Service) gin.HandlerFunc {
+	return Authenticate(authService, true, false)
+}
+
+// RequireAdmin rejects requests whose caller is not an admin. It must run after Authenticate.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			framework.LoggingRespondErrMsg(c, "missing credentials", http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		// admin endpoints manage the whole deployment, so they're off limits to callers scoped to a tenant
+		if !principal.Admin || principal.TenantID != "" {
+			logrus.Warnf("principal<%s> attempted to access admin endpoint: %s", principal.ID, c.FullPath())
+			framework.LoggingRespondErrMsg(c, "caller is not authorized for this endpoint", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests whose caller may not perform the operation, whether the grant comes from an
+// OAuth2 scope or a role. When the caller's grant is limited to owned resources, the resource of the given type
+// identified by the route's id parameter must have been created by the caller. Routes without an id parameter, such
+// as the ones that create resources, only require the grant. Requests without a principal, which happens when
+// authentication is disabled, are let through.
+func RequirePermission(authService *auth.Service, operation, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.Next()
+			return
+		}
+
+		decision := principal.Authorize(operation)
+		if !decision.Allowed {
+			logrus.Warnf("principal<%s> is not permitted to %s on endpoint: %s", principal.ID, operation, c.FullPath())
+			framework.LoggingRespondErrMsg(c, "caller is not permitted to perform: "+operation, http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		id := c.Param("id")
+		if decision.OwnedOnly && resourceType != "" && id != "" {
+			owned, err := authService.IsOwner(c, resourceType, id, principal.ID)
+			if err != nil {
+				framework.LoggingRespondErrWithMsg(c, err, "could not check resource ownership", http.StatusInternalServerError)
+				c.Abort()
+				return
+			}
+			if !owned {
+				logrus.Warnf("principal<%s> does not own %s<%s>", principal.ID, resourceType, id)
+				framework.LoggingRespondErrMsg(c, "caller is not permitted to perform: "+operation, http.StatusForbidden)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RejectOwnedOnly rejects requests whose caller's grant of the operation is limited to owned resources, for routes
+// that would show it every resource, like the ones listing them. Callers with an unconstrained grant, or without any,
+// are let through, so it's combined with RequirePermission on routes that require the grant. Requests without a
+// principal, which happens when authentication is disabled, are let through.
+func RejectOwnedOnly(operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal != nil && principal.Authorize(operation).OwnedOnly {
+			logrus.Warnf("principal<%s> may only %s on owned resources, so not on endpoint: %s", principal.ID, operation, c.FullPath())
+			framework.LoggingRespondErrMsg(c, "caller is only permitted to perform "+operation+" on resources it created", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RecordOwnership records the caller as the owner of the resource created by the request, so that permissions
+// limited to owned resources can be enforced later. The ID of the resource is read from the JSON response body at
+// idPath, e.g. `$.id`.
+func RecordOwnership(authService *auth.Service, resourceType, idPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.Next()
+			return
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+		c.Writer = &responseWriter{ResponseWriter: c.Writer, buf: buf}
+		c.Next()
+
+		if c.Writer.Status()/100 != 2 {
+			return
+		}
+		var body any
+		if err := json.Unmarshal(buf.Bytes(), &body); err != nil {
+			logrus.WithError(err).Errorf("could not read created %s to record its owner", resourceType)
+			return
+		}
+		id, err := jsonpath.JsonPathLookup(body, idPath)
+		if err != nil {
+			logrus.WithError(err).Errorf("could not find id of created %s at: %s", resourceType, idPath)
+			return
+		}
+		idStr, ok := id.(string)
+		if !ok || idStr == "" {
+			logrus.Errorf("id of created %s at %s is not a string", resourceType, idPath)
+			return
+		}
+		if err = authService.RecordOwner(c, resourceType, idStr, principal.ID); err != nil {
+			logrus.WithError(err).Errorf("could not record owner of %s<%s>", resourceType, idStr)
+		}
+	}
+}
+
+// GetPrincipal returns the authenticated caller of the request, or nil if there is none.
+func GetPrincipal(c *gin.Context) *auth.Principal {
+	value, ok := c.Get(PrincipalContextKey)
+	if !ok {
+		return nil
+	}
+	principal, ok := value.(*auth.Principal)
+	if !ok {
+		return nil
+	}
+	return principal
+}
@@ -0,0 +1,454 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+// batchReference matches references to the response of an earlier operation of a batch, like ${create-did:$.did.id}.
+var batchReference = regexp.MustCompile(`\$\{([^:}]+):([^}]+)}`)
+
+// undoParam matches the values an undo path is filled with, like {response:$.id} or {request:$.id}.
+var undoParam = regexp.MustCompile(`\{(response|request):([^}]+)}`)
+
+// batchedRequestKey marks the requests that are run as an operation of a batch.
+type batchedRequestKey struct{}
+
+// batchDroppedHeaders are the headers of a batch that aren't passed on to its operations, because they describe the
+// batch request itself rather than its operations.
+var batchDroppedHeaders = []string{
+	"Content-Length",
+	AcceptEncodingHeader,
+	PreferHeader,
+	IdempotencyKeyHeader,
+	IfMatchHeader,
+	IfNoneMatchHeader,
+	IfModifiedSinceHeader,
+}
+
+// BatchUndo describes how to undo a successful request to a route, which is what lets it be part of an atomic batch.
+type BatchUndo struct {
+	// Method and Route, relative to the version of the API, of the requests that are undone. E.g. PUT /dids/:method.
+	Method string
+	Route  string
+
+	// Undo is the path of the DELETE request that undoes them, relative to the version of the API. Its :params are
+	// those of Route, and {response:$.path} and {request:$.path} are the values at a JSONPath of the response and of
+	// the request body. E.g. /dids/:method/{response:$.did.id}.
+	Undo string
+}
+
+// Batch runs a list of API requests, in order, on behalf of a single request to the batch endpoint.
+type Batch struct {
+	handler http.Handler
+	undos   []BatchUndo
+}
+
+// NewBatch creates a Batch that runs operations through handler, which should be the engine serving them, and that
+// undoes the operations of atomic batches with undos.
+func NewBatch(handler http.Handler, undos []BatchUndo) *Batch {
+	return &Batch{handler: handler, undos: undos}
+}
+
+// Handler returns the handler of the batch endpoint. It must be registered directly under a version of the API, whose
+// routes are the only ones the batch may call.
+//
+//	@Summary		Run a batch of requests
+//	@Description	Runs a list of API requests in order, each authenticated and authorized as the caller. Later requests
+//	@Description	can reference the responses of earlier ones. Atomic batches stop at the first request that fails, and
+//	@Description	undo the requests that succeeded before it.
+//	@Tags			BatchAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		router.BatchRequest	true	"request body"
+//	@Success		200		{object}	router.BatchResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Router			/v1/batch [post]
+func (b *Batch) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invalidBatchRequest := "invalid batch request"
+		if IsBatchedRequest(c) {
+			framework.LoggingRespondErrMsg(c, "batches can't be nested", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		var request router.BatchRequest
+		if err := framework.Decode(c.Request, &request); err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, invalidBatchRequest, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		if err := framework.ValidateRequest(request); err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, invalidBatchRequest, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		version := path.Dir(c.FullPath())
+		if err := b.check(version, request); err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, invalidBatchRequest, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+
+		framework.Respond(c, b.run(c, version, request), http.StatusOK)
+	}
+}
+
+// IsBatchedRequest returns whether the request is being run as an operation of a batch.
+func IsBatchedRequest(c *gin.Context) bool {
+	batched, _ := c.Request.Context().Value(batchedRequestKey{}).(bool)
+	return batched
+}
+
+// check makes sure the operations of a batch may be run, before any of them is.
+func (b *Batch) check(version string, request router.BatchRequest) error {
+	ids := make(map[string]bool, len(request.Operations))
+	for i, op := range request.Operations {
+		if op.ID != "" {
+			if ids[op.ID] {
+				return errors.Errorf("operation %d: id %q is used more than once", i, op.ID)
+			}
+			ids[op.ID] = true
+		}
+		if len(op.Body) > 0 && !json.Valid(op.Body) {
+			return errors.Errorf("operation %d: body is not valid JSON", i)
+		}
+		opURL, err := url.Parse(op.Path)
+		if err != nil {
+			return errors.Wrapf(err, "operation %d: parsing path", i)
+		}
+		if opURL.IsAbs() || opURL.Host != "" || !strings.HasPrefix(path.Clean(opURL.Path), version+"/") {
+			return errors.Errorf("operation %d: path must be under %s", i, version)
+		}
+		if request.Atomic && op.Method != http.MethodGet {
+			if _, _, ok := b.undoFor(version, op.Method, opURL.Path); !ok {
+				return errors.Errorf("operation %d: %s %s can't be undone, so it can't be part of an atomic batch", i, op.Method, opURL.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// run runs the operations of a batch in order, undoing the ones that succeeded when an atomic batch fails.
+func (b *Batch) run(c *gin.Context, version string, request router.BatchRequest) router.BatchResponse {
+	resp := router.BatchResponse{Succeeded: true, Results: make([]router.BatchResult, 0, len(request.Operations))}
+	responses := make(map[string]any, len(request.Operations))
+	var done []batchedOperation
+	for i, op := range request.Operations {
+		result, ran := b.runOperation(c, op, responses)
+		ran.index = i
+		resp.Results = append(resp.Results, result)
+		if util.Is2xxResponse(result.Status) {
+			done = append(done, ran)
+			continue
+		}
+		resp.Succeeded = false
+		if request.Atomic {
+			b.undo(c, version, done, resp.Results)
+			break
+		}
+	}
+	return resp
+}
+
+// batchedOperation is an operation of a batch as it was run, with its references replaced.
+type batchedOperation struct {
+	// index of the operation in the batch
+	index    int
+	method   string
+	path     string
+	body     []byte
+	response []byte
+}
+
+// runOperation runs a single operation, recording its response under its id so later operations can reference it.
+func (b *Batch) runOperation(c *gin.Context, op router.BatchOperation, responses map[string]any) (router.BatchResult, batchedOperation) {
+	result := router.BatchResult{ID: op.ID}
+	opPath, body, err := resolveReferences(op, responses)
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Body = problemBody(c, op.Path, err)
+		return result, batchedOperation{}
+	}
+
+	w := b.serve(c, op.Method, opPath, body, nil)
+	result.Status = w.status
+	result.Body = responseBody(w.body.Bytes())
+	if op.ID != "" && util.Is2xxResponse(w.status) {
+		var response any
+		if err = json.Unmarshal(w.body.Bytes(), &response); err == nil {
+			responses[op.ID] = response
+		}
+	}
+	return result, batchedOperation{method: op.Method, path: opPath, body: body, response: w.body.Bytes()}
+}
+
+// serve runs a request through the handler as the caller of the batch, with the given headers added to the caller's.
+func (b *Batch) serve(c *gin.Context, method, target string, body []byte, header http.Header) *bufferedResponse {
+	w := newBufferedResponse()
+	ctx := context.WithValue(c.Request.Context(), batchedRequestKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		w.status = http.StatusBadRequest
+		w.body.Write(problemBody(c, target, err))
+		return w
+	}
+	req.Header = c.Request.Header.Clone()
+	for _, header := range batchDroppedHeaders {
+		req.Header.Del(header)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestid.Header, requestid.FromContext(c))
+	req.RemoteAddr = c.Request.RemoteAddr
+	b.handler.ServeHTTP(w, req)
+	return w
+}
+
+// undo undoes the operations that succeeded, in reverse order, recording the outcome in their results.
+func (b *Batch) undo(c *gin.Context, version string, done []batchedOperation, results []router.BatchResult) {
+	for i := len(done) - 1; i >= 0; i-- {
+		op := done[i]
+		if op.method == http.MethodGet {
+			continue
+		}
+		opPath, _, _ := strings.Cut(op.path, "?")
+		undo, params, ok := b.undoFor(version, op.method, opPath)
+		if !ok {
+			results[op.index].UndoError = "operation can't be undone"
+			continue
+		}
+		undoPath, err := fillUndoPath(undo.Undo, params, op)
+		if err != nil {
+			logrus.WithError(err).Errorf("could not undo %s %s of batch", op.method, op.path)
+			results[op.index].UndoError = err.Error()
+			continue
+		}
+
+		// the undo deletes whatever the operation created, which may have changed since
+		w := b.serve(c, http.MethodDelete, version+undoPath, nil, http.Header{IfMatchHeader: {"*"}})
+		if !util.Is2xxResponse(w.status) && w.status != http.StatusNotFound {
+			err = replayError(w)
+			logrus.WithError(err).Errorf("could not undo %s %s of batch", op.method, op.path)
+			results[op.index].UndoError = err.Error()
+			continue
+		}
+		results[op.index].Undone = true
+	}
+}
+
+// undoFor returns how to undo a request to opPath, a path without a query, along with the params of its route.
+func (b *Batch) undoFor(version, method, opPath string) (BatchUndo, map[string]string, bool) {
+	for _, undo := range b.undos {
+		if undo.Method != method {
+			continue
+		}
+		if params, ok := matchRoute(version+undo.Route, opPath); ok {
+			return undo, params, true
+		}
+	}
+	return BatchUndo{}, nil, false
+}
+
+// matchRoute matches a path against a route whose :params match a single segment, returning the values of the params.
+func matchRoute(route, target string) (map[string]string, bool) {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	segments := strings.Split(strings.Trim(target, "/"), "/")
+	if len(routeSegments) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, ":") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params[strings.TrimPrefix(segment, ":")] = value
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// fillUndoPath fills the params of an undo path from the route params, request body, and response of an operation.
+func fillUndoPath(undoPath string, params map[string]string, op batchedOperation) (string, error) {
+	segments := strings.Split(undoPath, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = url.PathEscape(params[name])
+			continue
+		}
+		match := undoParam.FindStringSubmatch(segment)
+		if match == nil {
+			continue
+		}
+		source := op.response
+		if match[1] == "request" {
+			source = op.body
+		}
+		var parsed any
+		if err := json.Unmarshal(source, &parsed); err != nil {
+			return "", errors.Wrapf(err, "reading %s of operation", match[1])
+		}
+		value, err := jsonpath.JsonPathLookup(parsed, match[2])
+		if err != nil {
+			return "", errors.Wrapf(err, "finding %s in %s of operation", match[2], match[1])
+		}
+		id, ok := value.(string)
+		if !ok || id == "" {
+			return "", errors.Errorf("%s in %s of operation is not a string", match[2], match[1])
+		}
+		segments[i] = url.PathEscape(id)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// ResolveReferences replaces the references to earlier responses, like ${create-did:$.did.id}, in a path and JSON body
+// the way they're replaced in the operations of a batch. responses holds the decoded responses by the name they're
+// referenced by.
+func ResolveReferences(path string, body []byte, responses map[string]any) (string, []byte, error) {
+	return resolveReferences(router.BatchOperation{Path: path, Body: body}, responses)
+}
+
+// resolveReferences replaces the references to earlier responses in the path and body of an operation.
+func resolveReferences(op router.BatchOperation, responses map[string]any) (string, []byte, error) {
+	var resolveErr error
+	opPath := batchReference.ReplaceAllStringFunc(op.Path, func(reference string) string {
+		value, err := lookupReference(reference, responses)
+		if err != nil {
+			resolveErr = err
+			return reference
+		}
+		return url.PathEscape(referenceString(value))
+	})
+	if resolveErr != nil {
+		return "", nil, resolveErr
+	}
+	if len(op.Body) == 0 || !batchReference.Match(op.Body) {
+		return opPath, op.Body, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(op.Body))
+	decoder.UseNumber()
+	var body any
+	if err := decoder.Decode(&body); err != nil {
+		return "", nil, errors.Wrap(err, "decoding body")
+	}
+	body, err := resolveValue(body, responses)
+	if err != nil {
+		return "", nil, err
+	}
+	resolved, err := json.Marshal(body)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "encoding body")
+	}
+	return opPath, resolved, nil
+}
+
+// resolveValue replaces the references in the strings of a decoded JSON value.
+func resolveValue(value any, responses map[string]any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			resolved, err := resolveValue(field, responses)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case []any:
+		for i, item := range v {
+			resolved, err := resolveValue(item, responses)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	case string:
+		if batchReference.FindString(v) == v && v != "" {
+			return lookupReference(v, responses)
+		}
+		var resolveErr error
+		resolved := batchReference.ReplaceAllStringFunc(v, func(reference string) string {
+			referenced, err := lookupReference(reference, responses)
+			if err != nil {
+				resolveErr = err
+				return reference
+			}
+			return referenceString(referenced)
+		})
+		return resolved, resolveErr
+	}
+	return value, nil
+}
+
+// lookupReference returns the value a reference like ${create-did:$.did.id} refers to.
+func lookupReference(reference string, responses map[string]any) (any, error) {
+	match := batchReference.FindStringSubmatch(reference)
+	response, ok := responses[match[1]]
+	if !ok {
+		return nil, errors.Errorf("%s refers to an operation that didn't run before it, or didn't succeed", reference)
+	}
+	value, err := jsonpath.JsonPathLookup(response, match[2])
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving %s", reference)
+	}
+	return value, nil
+}
+
+// referenceString formats a referenced value to be part of a string, encoding anything but strings as JSON.
+func referenceString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// responseBody returns the body of a response as JSON, wrapping bodies that aren't JSON in a string.
+func responseBody(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+// problemBody returns the problem response of an operation that couldn't be run.
+func problemBody(c *gin.Context, instance string, err error) json.RawMessage {
+	problem := framework.Problem(c, framework.ErrorResponse{Status: http.StatusBadRequest, Detail: err.Error(), Instance: instance})
+	encoded, _ := json.Marshal(problem)
+	return encoded
+}
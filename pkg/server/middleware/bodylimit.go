@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+// DefaultMaxBodyBytes is the largest request body accepted by routes when no limit is configured.
+const DefaultMaxBodyBytes int64 = 10 << 20
+
+// BodyLimit rejects requests whose body is larger than the limit of their route with 413 Request Entity Too Large.
+// Requests that declare a larger Content-Length are rejected before their body is read, and reading more than the
+// limit from the body of the others fails, which handlers respond to with the same status.
+func BodyLimit(cfg config.RequestLimitsConfig) gin.HandlerFunc {
+	defaultLimit := cfg.MaxBodyBytes
+	if defaultLimit <= 0 {
+		defaultLimit = DefaultMaxBodyBytes
+	}
+	routeLimits := make(map[string]int64, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routeLimits[routeKey(route.Method, route.Path)] = route.MaxBodyBytes
+	}
+
+	return func(c *gin.Context) {
+		limit := defaultLimit
+		if routeLimit, ok := routeLimits[routeKey(c.Request.Method, c.FullPath())]; ok && routeLimit > 0 {
+			limit = routeLimit
+		}
+		if c.Request.ContentLength > limit {
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: http.StatusRequestEntityTooLarge,
+				Detail: fmt.Sprintf("request body is larger than %d bytes", limit),
+				Code:   framework.CodePayloadTooLarge,
+			})
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
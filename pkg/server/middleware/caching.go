@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// CacheControlHeader tells clients and shared caches how long a response can be reused for.
+	CacheControlHeader = "Cache-Control"
+	// LastModifiedHeader is when the representation in a response was last changed.
+	LastModifiedHeader = "Last-Modified"
+	// IfModifiedSinceHeader makes a GET conditional on the resource having changed since the given time.
+	IfModifiedSinceHeader = "If-Modified-Since"
+
+	// DefaultCacheMaxAge is how long public artifacts can be reused for without revalidating them, when no max age is
+	// configured.
+	DefaultCacheMaxAge = time.Minute
+
+	// maxTrackedRepresentations bounds how many representations Caching remembers the modification time of.
+	maxTrackedRepresentations = 10000
+)
+
+// Caching makes public artifacts, like DID documents, status lists, and schemas, cacheable. Their responses carry an
+// ETag, a Last-Modified time, and a Cache-Control max age, and conditional GETs of an unchanged artifact are answered
+// with 304 Not Modified, without a body. Verifiers that poll for changes then mostly download nothing.
+//
+// Artifacts don't record when they were changed, so Last-Modified is when this instance first served the current
+// representation of an artifact. It is never earlier than the actual change, so If-Modified-Since never hides one.
+type Caching struct {
+	cacheControl string
+
+	mu      sync.Mutex
+	changes map[string]representation
+}
+
+// representation is a version of an artifact, and when it was first served.
+type representation struct {
+	etag     string
+	modified time.Time
+}
+
+// NewCaching creates a Caching from the config. When private is set, which it should be when the API requires
+// authentication, responses may only be cached by the client, not by shared caches.
+func NewCaching(cfg config.CachingConfig, private bool) *Caching {
+	maxAge := cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultCacheMaxAge
+	}
+	visibility := "public"
+	if private {
+		visibility = "private"
+	}
+	return &Caching{
+		cacheControl: visibility + ", max-age=" + strconv.Itoa(int(maxAge.Seconds())),
+		changes:      make(map[string]representation),
+	}
+}
+
+// Cache makes the successful responses of a GET route cacheable. Requests whose If-None-Match header matches the ETag
+// of the response, or, without an If-None-Match header, whose If-Modified-Since header isn't before its Last-Modified
+// time, are answered with 304 Not Modified.
+func (ca *Caching) Cache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.buf.Bytes()
+		if w.Status() == http.StatusOK {
+			etag := entityTag(body)
+			modified := ca.lastModified(c, etag)
+			c.Header(ETagHeader, etag)
+			c.Header(LastModifiedHeader, modified.Format(http.TimeFormat))
+			c.Header(CacheControlHeader, ca.cacheControl)
+			if notModified(c.Request, etag, modified) {
+				c.Writer.WriteHeader(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		if len(body) > 0 {
+			_, _ = c.Writer.Write(body)
+		}
+	}
+}
+
+// lastModified returns when the representation with the given ETag was first served at the URL of the request.
+func (ca *Caching) lastModified(c *gin.Context, etag string) time.Time {
+	// tenants have artifacts of their own at the same URLs
+	key := c.GetString(storage.TenantContextKey) + " " + c.Request.URL.RequestURI()
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if current, ok := ca.changes[key]; ok && current.etag == etag {
+		return current.modified
+	}
+	if len(ca.changes) >= maxTrackedRepresentations {
+		// forgetting a representation only makes it look newer
+		for forgotten := range ca.changes {
+			delete(ca.changes, forgotten)
+			break
+		}
+	}
+	// HTTP dates have a precision of a second
+	modified := time.Now().UTC().Truncate(time.Second)
+	ca.changes[key] = representation{etag: etag, modified: modified}
+	return modified
+}
+
+// notModified reports whether the conditional headers of a GET request are met by the current representation, as
+// defined in RFC 9110. If-None-Match takes precedence over If-Modified-Since.
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if ifNoneMatch := req.Header.Get(IfNoneMatchHeader); ifNoneMatch != "" {
+		return matchesETag(ifNoneMatch, etag, false)
+	}
+	ifModifiedSince := req.Header.Get(IfModifiedSinceHeader)
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	return err == nil && !modified.After(since)
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/challenge"
+)
+
+// RequireChallenge requires the JWT at jwtPath of the request body, e.g. $.applicationJwt, to respond to a challenge
+// bound to its definition or manifest, as target says, with its nonce claim. Requests whose JWT has no challenge, or
+// one that's invalid, are rejected with 400 Bad Request, and those whose challenge was already responded to with 409
+// Conflict. Nonces that exempt returns true for, e.g. those of presentation requests, are left for the handler to
+// check, and exempt may be nil. Challenges of requests that fail are released, so that the requests can be retried.
+// Requests whose body has no JWT there, or one that can't be parsed, are left for the handler to reject. It does
+// nothing when challenges is nil, which is when challenges are disabled.
+func RequireChallenge(challenges *challenge.Service, jwtPath string, target challenge.Target, exempt func(ctx context.Context, nonce string) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if challenges == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload any
+		if err = json.Unmarshal(body, &payload); err != nil {
+			c.Next()
+			return
+		}
+		token, err := jsonpath.JsonPathLookup(payload, jwtPath)
+		tokenStr, ok := token.(string)
+		if err != nil || !ok || tokenStr == "" {
+			c.Next()
+			return
+		}
+
+		if exempt != nil {
+			if _, parsed, err := util.ParseJWT(keyaccess.JWT(tokenStr)); err == nil {
+				nonce, _ := parsed.Get(challenge.NonceClaim)
+				if nonceStr, _ := nonce.(string); nonceStr != "" {
+					isExempt, err := exempt(c, nonceStr)
+					if err != nil {
+						framework.LoggingRespondErrWithMsg(c, err, "could not check challenge", http.StatusInternalServerError)
+						c.Abort()
+						return
+					}
+					if isExempt {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+
+		nonce, err := challenges.ConsumeJWT(c, keyaccess.JWT(tokenStr), target)
+		if errors.Is(err, challenge.ErrMalformedJWT) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			respondChallengeErr(c, err)
+			return
+		}
+		c.Next()
+		if c.Writer.Status() < http.StatusBadRequest {
+			return
+		}
+		if err = challenges.Release(c, nonce); err != nil {
+			logrus.WithError(err).Error("releasing challenge of a failed request")
+		}
+	}
+}
+
+// respondChallengeErr aborts a request whose challenge couldn't be consumed.
+func respondChallengeErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, challenge.ErrChallengeRequired):
+		framework.RespondProblem(c, framework.ErrorResponse{Status: http.StatusBadRequest, Detail: err.Error(), Code: framework.CodeChallengeRequired})
+	case errors.Is(err, challenge.ErrInvalidChallenge):
+		framework.RespondProblem(c, framework.ErrorResponse{Status: http.StatusBadRequest, Detail: err.Error(), Code: framework.CodeInvalidChallenge})
+	case errors.Is(err, challenge.ErrChallengeConsumed):
+		logrus.WithField("path", c.FullPath()).Warn("rejected replayed challenge")
+		framework.RespondProblem(c, framework.ErrorResponse{Status: http.StatusConflict, Detail: err.Error(), Code: framework.CodeReplayed})
+	default:
+		framework.LoggingRespondErrWithMsg(c, err, "could not check challenge", http.StatusInternalServerError)
+	}
+	c.Abort()
+}
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+const (
+	AcceptEncodingHeader  = "Accept-Encoding"
+	ContentEncodingHeader = "Content-Encoding"
+
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+
+	// DefaultCompressionMinSize is the smallest response that is compressed when no size is configured. Smaller
+	// responses barely shrink, and aren't worth the time it takes.
+	DefaultCompressionMinSize = 1024
+
+	// brotli's default level is too slow for responses compressed on the fly
+	brotliLevel = 4
+)
+
+// encoder compresses responses with one content coding. Writers are pooled, since allocating them is expensive.
+type encoder struct {
+	name string
+	pool sync.Pool
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (e *encoder) get(w io.Writer) compressor {
+	c := e.pool.Get().(compressor)
+	c.Reset(w)
+	return c
+}
+
+func (e *encoder) put(c compressor) {
+	e.pool.Put(c)
+}
+
+// encoders are the supported content codings, in order of preference when a client accepts several equally.
+var encoders = []*encoder{
+	{name: EncodingBrotli, pool: sync.Pool{New: func() any { return brotli.NewWriterLevel(nil, brotliLevel) }}},
+	{name: EncodingGzip, pool: sync.Pool{New: func() any { return gzip.NewWriter(nil) }}},
+}
+
+// Compression compresses responses with brotli or gzip, whichever the client prefers in its Accept-Encoding header.
+// Only responses of at least the configured size, with a compressible content type, are compressed, so small
+// responses and ones that are already compressed are sent as they are.
+//
+// It should run before middleware that captures response bodies, such as Idempotency, so that they see the
+// uncompressed body.
+func Compression(cfg config.CompressionConfig) gin.HandlerFunc {
+	minSize := cfg.MinSizeBytes
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		// the response depends on the header even when it isn't compressed, which caches need to know
+		c.Writer.Header().Add("Vary", AcceptEncodingHeader)
+		enc := negotiateEncoding(c.GetHeader(AcceptEncodingHeader))
+		if enc == nil {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoder: enc, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			if err := w.close(); err != nil {
+				logrus.WithContext(c).WithError(err).Error("could not compress response")
+			}
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality in an Accept-Encoding header, returning
+// nil when the client accepts none of them.
+func negotiateEncoding(accept string) *encoder {
+	if accept == "" {
+		return nil
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	var best *encoder
+	var bestQuality float64
+	for _, enc := range encoders {
+		quality, ok := qualities[enc.name]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = enc, quality
+		}
+	}
+	return best
+}
+
+// compressWriter holds back the start of a response until it knows whether it's large enough to compress, and then
+// either compresses it or writes it as it is.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoder *encoder
+	minSize int
+
+	buf        bytes.Buffer
+	decided    bool
+	compressor compressor
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the handler has written anything, including what's held back.
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends what has been written so far, compressing the rest of the response if it's compressible, since
+// flushing handlers stream responses of unknown size.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide compresses the response from here on when large is set and it can be compressed, and writes what was
+// held back.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if large && w.compressible() {
+		header := w.Header()
+		header.Set(ContentEncodingHeader, w.encoder.name)
+		header.Del("Content-Length")
+		w.compressor = w.encoder.get(w.ResponseWriter)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// compressible reports whether the response has a body that compression shrinks.
+func (w *compressWriter) compressible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get(ContentEncodingHeader) != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf.Bytes())
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/jwt", mediaType == "application/javascript",
+		mediaType == "application/yaml", mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// close writes responses that were too small to compress, and finishes compressed ones.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.compressor == nil {
+		return nil
+	}
+	err := w.compressor.Close()
+	w.encoder.put(w.compressor)
+	w.compressor = nil
+	return err
+}
@@ -2,14 +2,40 @@ package middleware
 
 import (
 	"net/http"
+	"sync/atomic"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
-func CORS() gin.HandlerFunc {
-	return cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
+// CORS answers the cross-origin requests of browsers, from any origin or from the allowed ones. Its origins can be
+// updated while the server runs.
+type CORS struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewCORS allows cross-origin requests from any origin when allowAll is set, or else from origins, like
+// https://wallet.example.com. Requests aren't treated as cross-origin ones when neither is set.
+func NewCORS(allowAll bool, origins []string) (*CORS, error) {
+	c := new(CORS)
+	if err := c.Update(allowAll, origins); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update replaces the origins cross-origin requests are allowed from. Invalid origins leave them as they were.
+func (c *CORS) Update(allowAll bool, origins []string) error {
+	if !allowAll && len(origins) == 0 {
+		c.handler.Store(nil)
+		return nil
+	}
+	if allowAll {
+		origins = []string{"*"}
+	}
+	config := cors.Config{
+		AllowOrigins: origins,
 		AllowMethods: []string{
 			http.MethodHead,
 			http.MethodGet,
@@ -20,5 +46,23 @@ func CORS() gin.HandlerFunc {
 		},
 		AllowHeaders:     []string{"*"},
 		AllowCredentials: false,
-	})
+	}
+	if err := config.Validate(); err != nil {
+		return errors.Wrap(err, "invalid cors origins")
+	}
+	handler := cors.New(config)
+	c.handler.Store(&handler)
+	return nil
+}
+
+// Handler returns the middleware answering cross-origin requests with the current origins.
+func (c *CORS) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		handler := c.handler.Load()
+		if handler == nil {
+			ctx.Next()
+			return
+		}
+		(*handler)(ctx)
+	}
 }
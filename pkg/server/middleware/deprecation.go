@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+const (
+	// DeprecationHeader carries when the version of the API serving a response was, or will be, deprecated, as
+	// described in RFC 9745.
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader carries when the version of the API serving a response will stop being served, as described in
+	// RFC 8594.
+	SunsetHeader = "Sunset"
+
+	deprecationDateLayout = "2006-01-02"
+)
+
+// Deprecation adds the headers announcing that a version of the API is deprecated to every response it serves. The
+// version is the prefix of its routes, e.g. /v1. When successor is set, the Link header also points at the same route
+// in the version that replaces it, so that clients can find what to migrate to.
+func Deprecation(cfg config.DeprecationConfig, version, successor string) (gin.HandlerFunc, error) {
+	date, err := parseDeprecationDate(cfg.Date)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing deprecation date of %s", cfg.Version)
+	}
+	var sunset time.Time
+	if cfg.Sunset != "" {
+		if sunset, err = parseDeprecationDate(cfg.Sunset); err != nil {
+			return nil, errors.Wrapf(err, "parsing sunset date of %s", cfg.Version)
+		}
+		if sunset.Before(date) {
+			return nil, errors.Errorf("sunset of %s is before it is deprecated", cfg.Version)
+		}
+	}
+
+	deprecation := fmt.Sprintf("@%d", date.Unix())
+	return func(c *gin.Context) {
+		c.Header(DeprecationHeader, deprecation)
+		if !sunset.IsZero() {
+			c.Header(SunsetHeader, sunset.UTC().Format(http.TimeFormat))
+		}
+		if cfg.Link != "" {
+			c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, cfg.Link))
+		}
+		if successor != "" {
+			path := successor + strings.TrimPrefix(c.Request.URL.Path, version)
+			c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, path))
+		}
+		c.Next()
+	}, nil
+}
+
+func parseDeprecationDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("no date set")
+	}
+	if date, err := time.Parse(deprecationDateLayout, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
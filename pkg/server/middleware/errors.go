@@ -28,14 +28,14 @@ func Errors(shutdown chan os.Signal) gin.HandlerFunc {
 			// check if there's a shutdown-worthy error
 			for _, e := range errors {
 				if framework.IsShutdown(e.Err) {
-					logrus.WithError(e).Errorf("%s : SHUTDOWN ERROR", span.SpanContext().TraceID().String())
+					logrus.WithContext(c).WithError(e).Errorf("%s : SHUTDOWN ERROR", span.SpanContext().TraceID().String())
 					shutdown <- syscall.SIGTERM
 					return
 				}
 			}
 
 			// otherwise just log the errors and return to the caller
-			logrus.Errorf("%s : ERROR : %v", span.SpanContext().TraceID().String(), errors)
+			logrus.WithContext(c).Errorf("%s : ERROR : %v", span.SpanContext().TraceID().String(), errors)
 			c.JSON(-1, errors)
 		}
 	}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+const (
+	// ETagHeader identifies the version of a resource that a response represents.
+	ETagHeader = "ETag"
+	// IfMatchHeader makes a change to a resource conditional on it still being at the given version.
+	IfMatchHeader = "If-Match"
+	// IfNoneMatchHeader makes a GET conditional on the resource having changed from the given version.
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+// Preconditions implements optimistic concurrency control with ETags, as defined in RFC 9110. Responses of GET routes
+// are tagged with the ETag of their body, and changes to a resource are only made when the If-Match header of the
+// request matches the ETag of its current representation. Clients that change a resource someone else changed since
+// they read it get 412 Precondition Failed instead of silently undoing the other change.
+type Preconditions struct {
+	handler        http.Handler
+	requireIfMatch bool
+}
+
+// NewPreconditions creates a Preconditions that reads the current representation of resources through handler, which
+// should be the engine serving them. When requireIfMatch is set, requests that change a resource without an If-Match
+// header are rejected with 428 Precondition Required.
+//
+// The check is best-effort: the current representation is read before the change is made, outside of the storage
+// transaction that makes it, and nothing is locked in between. Two requests with the same If-Match header that arrive
+// at the same time can both pass it, so it catches changes based on a stale read, but doesn't serialize writers.
+func NewPreconditions(handler http.Handler, requireIfMatch bool) *Preconditions {
+	return &Preconditions{handler: handler, requireIfMatch: requireIfMatch}
+}
+
+// ETag tags successful responses of a GET route with the ETag of their body. Requests whose If-None-Match header
+// matches it are answered with 304 Not Modified, without a body.
+func (p *Preconditions) ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.buf.Bytes()
+		if w.Status() == http.StatusOK {
+			etag := entityTag(body)
+			c.Header(ETagHeader, etag)
+			if matchesETag(c.GetHeader(IfNoneMatchHeader), etag, false) {
+				c.Writer.WriteHeader(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		if len(body) > 0 {
+			_, _ = c.Writer.Write(body)
+		}
+	}
+}
+
+// IfMatch makes a route that changes a resource conditional on the request's If-Match header. The resource must be
+// served by a GET route at the same path, which is called to get its current ETag. It should be placed after
+// authentication and authorization, and before the middleware that acts on the outcome of the request, like webhooks.
+// Since the ETag is read before the change is made, and not in its transaction, the check is best-effort, as described
+// in NewPreconditions.
+func (p *Preconditions) IfMatch() gin.HandlerFunc {
+	return p.IfMatchAt(func(c *gin.Context) (string, error) {
+		return c.Request.URL.Path, nil
+	})
+}
+
+// IfMatchAt is IfMatch for routes that change a resource served by a GET route at another path, which path returns
+// for the request, like the ones that review or rotate the resource at their parent path. When path returns nothing,
+// the request creates a resource instead of changing one, so it needs no If-Match header, and one it has matches
+// nothing.
+func (p *Preconditions) IfMatchAt(path func(c *gin.Context) (string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourcePath, err := path(c)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not check If-Match precondition", http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+
+		ifMatch := c.GetHeader(IfMatchHeader)
+		if ifMatch == "" {
+			if p.requireIfMatch && resourcePath != "" {
+				framework.LoggingRespondErrMsg(c, "the If-Match header is required, set it to the ETag of the resource", http.StatusPreconditionRequired)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		var current string
+		if resourcePath != "" {
+			current, err = resourceETag(p.handler, c.Request, resourcePath)
+		}
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not check If-Match precondition", http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		// a resource that doesn't exist matches nothing, not even *
+		if current == "" || !matchesETag(ifMatch, current, true) {
+			framework.LoggingRespondErrMsg(c, "the resource has changed, or doesn't exist, since the ETag in If-Match was read", http.StatusPreconditionFailed)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ParentPath returns a path for IfMatchAt that trims suffix from the path of the request, for routes like /:id/rotate
+// that change the resource at /:id.
+func ParentPath(suffix string) func(c *gin.Context) (string, error) {
+	return func(c *gin.Context) (string, error) {
+		return strings.TrimSuffix(c.Request.URL.Path, suffix), nil
+	}
+}
+
+// resourceETag reads the resource at a path through handler, returning its ETag, or nothing when it doesn't exist. The
+// path defaults to the one of the request r, whose query is kept.
+func resourceETag(handler http.Handler, r *http.Request, path string) (string, error) {
+	// the read is made as the caller, so it sees the same tenant, and is authorized the same way
+	req := r.Clone(r.Context())
+	if path != "" && path != r.URL.Path {
+		u := *req.URL
+		u.Path, u.RawPath, u.RawQuery = path, "", ""
+		req.URL = &u
+		req.RequestURI = u.RequestURI()
+	}
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	for _, header := range []string{IfMatchHeader, IfNoneMatchHeader, IfModifiedSinceHeader, AcceptEncodingHeader, IdempotencyKeyHeader, PreferHeader, "Content-Type"} {
+		req.Header.Del(header)
+	}
+	w := newBufferedResponse()
+	handler.ServeHTTP(w, req)
+	switch w.status {
+	case http.StatusOK:
+		return entityTag(w.body.Bytes()), nil
+	case http.StatusNotFound, http.StatusBadRequest:
+		// some routes report resources that don't exist as bad requests
+		return "", nil
+	default:
+		return "", errors.Errorf("reading the resource failed with status %d: %s", w.status, replayError(w))
+	}
+}
+
+// entityTag returns the strong ETag of a representation.
+func entityTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// matchesETag reports whether a list of ETags, as sent in If-Match and If-None-Match, matches etag. With strong
+// comparison, which If-Match uses, weak ETags never match.
+func matchesETag(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak := strings.TrimPrefix(candidate, "W/"); weak != candidate {
+			if strong {
+				continue
+			}
+			candidate = weak
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter holds back the body of a response until its ETag is known.
+type etagWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// Written reports whether the handler has written anything, including what's held back.
+func (w *etagWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/features"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// FeaturesContextKey is the key under which the feature flags are stored in the gin context.
+const FeaturesContextKey = "ssi-service-features"
+
+// Features makes the feature flags available to every request, for RequireFeature and FeatureEnabled.
+func Features(flags *features.Flags) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(FeaturesContextKey, flags)
+		c.Next()
+	}
+}
+
+// RequireFeature answers requests to a route with 404 Not Found, as if it didn't exist, unless the flag is enabled for
+// the tenant of the request. It should come after Authenticate, which sets the tenant.
+func RequireFeature(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !FeatureEnabled(c, name) {
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: http.StatusNotFound,
+				Detail: "no route matches " + c.Request.Method + " " + c.Request.URL.Path,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// FeatureEnabled returns whether a flag is enabled for the tenant of the request, for handlers that only change what
+// they do when it is.
+func FeatureEnabled(c *gin.Context, name string) bool {
+	flags, ok := c.Value(FeaturesContextKey).(*features.Flags)
+	if !ok {
+		return false
+	}
+	return flags.Enabled(name, c.GetString(storage.TenantContextKey))
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+// FieldsParam is the query parameter that lists the fields a response should be limited to.
+const FieldsParam = "fields"
+
+// fieldSet is the tree of fields kept in a response. A field without children is kept whole.
+type fieldSet map[string]fieldSet
+
+// Fields limits the JSON responses of GET requests to the fields listed in their `fields` query parameter, e.g.
+// `?fields=credentials.id,credentials.revoked`. Fields are dotted paths into the response, and apply to every item of
+// the lists on their way. Requests without the parameter get the full response.
+//
+// Partial responses don't carry the ETag of the full representation.
+func Fields() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param, ok := c.GetQuery(FieldsParam)
+		if !ok || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		fields, err := parseFields(param)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "invalid fields query parameter", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+
+		w := &fieldsWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.buf.Bytes()
+		if util.Is2xxResponse(w.Status()) && len(body) > 0 {
+			// numbers are kept as they are, rather than as floats
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var value any
+			if err = decoder.Decode(&value); err == nil {
+				var shaped bytes.Buffer
+				encoder := json.NewEncoder(&shaped)
+				encoder.SetEscapeHTML(false)
+				if err = encoder.Encode(fields.apply(value)); err == nil {
+					body = bytes.TrimSuffix(shaped.Bytes(), []byte("\n"))
+					c.Writer.Header().Del(ETagHeader)
+				}
+			}
+		}
+		if len(body) > 0 {
+			_, _ = c.Writer.Write(body)
+		}
+	}
+}
+
+// parseFields parses a comma separated list of dotted field paths.
+func parseFields(param string) (fieldSet, error) {
+	fields := make(fieldSet)
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return nil, errors.New("fields must be a comma separated list of field paths")
+		}
+		set := fields
+		for _, name := range strings.Split(path, ".") {
+			if name == "" {
+				return nil, errors.Errorf("field path %q has an empty field", path)
+			}
+			children, ok := set[name]
+			if !ok {
+				children = make(fieldSet)
+				set[name] = children
+			}
+			set = children
+		}
+	}
+	return fields, nil
+}
+
+// apply returns value limited to the fields of the set. Lists are limited item by item, and anything that isn't an
+// object is kept as is.
+func (f fieldSet) apply(value any) any {
+	if len(f) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		limited := make(map[string]any, len(f))
+		for name, children := range f {
+			if field, ok := v[name]; ok {
+				limited[name] = children.apply(field)
+			}
+		}
+		return limited
+	case []any:
+		limited := make([]any, 0, len(v))
+		for _, item := range v {
+			limited = append(limited, f.apply(item))
+		}
+		return limited
+	default:
+		return value
+	}
+}
+
+// fieldsWriter holds back the body of a response until it's limited to the requested fields.
+type fieldsWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *fieldsWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *fieldsWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// Written reports whether the handler has written anything, including what's held back.
+func (w *fieldsWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/idempotency"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients use to make retries of a request safe.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses that are replayed for a retried request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes PUT and POST requests that carry an Idempotency-Key header safe to retry. The first request with
+// a key is handled as usual, and its response is stored. Retries with the same key and request get the stored
+// response, without the request being handled again. Reusing a key for a different request is rejected with
+// 422 Unprocessable Entity, and retrying while the first request is still being handled with 409 Conflict.
+//
+// Keys are scoped to the authenticated client, when there is one. Server errors aren't stored, so requests that
+// failed with one can be retried.
+func Idempotency(store *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || IsAsyncReplay(c) || (c.Request.Method != http.MethodPut && c.Request.Method != http.MethodPost) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			framework.LoggingRespondErrMsg(c, "idempotency key is too long", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if principal := GetPrincipal(c); principal != nil {
+			key = principal.ID + "|" + key
+		}
+		hash := requestHash(c.Request, body)
+		record, err := store.Reserve(c, key, hash)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not check idempotency key", http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		if record != nil {
+			switch {
+			case record.RequestHash != hash:
+				framework.LoggingRespondErrMsg(c, "idempotency key was already used for a different request", http.StatusUnprocessableEntity)
+			case !record.Done:
+				framework.LoggingRespondErrMsg(c, "a request with this idempotency key is still being handled", http.StatusConflict)
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+		c.Writer = &responseWriter{ResponseWriter: c.Writer, buf: buf}
+		c.Next()
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError {
+			err = store.Release(c, key)
+		} else {
+			err = store.Complete(c, key, idempotency.Record{
+				RequestHash: hash,
+				Status:      status,
+				ContentType: c.Writer.Header().Get("Content-Type"),
+				Body:        buf.Bytes(),
+			})
+		}
+		if err != nil {
+			logrus.WithContext(c).WithError(err).Error("could not store response for idempotency key")
+		}
+	}
+}
+
+// requestHash identifies a request by its method, path, query, and body. The query, and JSON bodies, are identified
+// by what they hold rather than how they're encoded, so that retries by clients that encode the same request
+// differently, like with parameters or keys in another order, or other whitespace, aren't mistaken for other requests.
+func requestHash(req *http.Request, body []byte) string {
+	target := req.URL.EscapedPath()
+	if query := req.URL.Query(); len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + target + "\n"))
+	h.Write(canonicalJSON(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON returns body encoded without whitespace, and with the keys of objects sorted, or body as it is when it
+// isn't a single JSON value.
+func canonicalJSON(body []byte) []byte {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
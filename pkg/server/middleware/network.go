@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+// AllowNetworks rejects requests from addresses outside the given networks, which are in CIDR notation, with
+// 403 Forbidden. The address is the one the connection came from, since forwarding headers can be set by anyone.
+// Every address is allowed when no networks are given.
+func AllowNetworks(cidrs []string) (gin.HandlerFunc, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing allowed network: %s", cidr)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *gin.Context) {
+		if len(networks) == 0 {
+			c.Next()
+			return
+		}
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			host = c.Request.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+		logrus.Warnf("rejected request from address outside the allowed networks: %s", c.Request.RemoteAddr)
+		framework.LoggingRespondErrMsg(c, "address is not allowed to call this endpoint", http.StatusForbidden)
+		c.Abort()
+	}, nil
+}
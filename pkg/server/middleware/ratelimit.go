@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/ratelimit"
+)
+
+// Headers describing the rate limit of the client, as in https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimits holds the configured rate limits, which can be updated while the server is running.
+type RateLimits struct {
+	limiter ratelimit.Limiter
+
+	mu           sync.RWMutex
+	defaultLimit ratelimit.Limit
+	routeLimits  map[string]ratelimit.Limit
+}
+
+// NewRateLimits creates rate limits that take tokens from the given limiter.
+func NewRateLimits(limiter ratelimit.Limiter, cfg config.RateLimitConfig) *RateLimits {
+	r := RateLimits{limiter: limiter}
+	r.Update(cfg)
+	return &r
+}
+
+// Update replaces the limits with the configured ones. Buckets are kept, so clients don't get a fresh allowance.
+func (r *RateLimits) Update(cfg config.RateLimitConfig) {
+	routeLimits := make(map[string]ratelimit.Limit, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routeLimits[routeKey(route.Method, route.Path)] = ratelimit.Limit{RequestsPerMinute: route.RequestsPerMinute, Burst: route.Burst}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultLimit = ratelimit.Limit{RequestsPerMinute: cfg.RequestsPerMinute, Burst: cfg.Burst}
+	r.routeLimits = routeLimits
+}
+
+// limit returns the bucket and limit that apply to a route.
+func (r *RateLimits) limit(route string) (string, ratelimit.Limit) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if routeLimit, ok := r.routeLimits[route]; ok {
+		return route, routeLimit
+	}
+	return "default", r.defaultLimit
+}
+
+// RateLimit rejects requests from clients that exceed their limit with 429 Too Many Requests. The route specific
+// limit is used when one is configured, and the default limit otherwise. It should run after Authenticate, so that
+// authenticated clients are limited by who they are rather than where they connect from. When the limiter fails,
+// requests are let through.
+func RateLimit(limiter ratelimit.Limiter, cfg config.RateLimitConfig) gin.HandlerFunc {
+	return NewRateLimits(limiter, cfg).Handler()
+}
+
+// Handler returns the middleware enforcing the limits, as described in RateLimit.
+func (r *RateLimits) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucket, limit := r.limit(routeKey(c.Request.Method, c.FullPath()))
+		// replayed requests were already counted when they were accepted
+		if limit.IsZero() || IsAsyncReplay(c) {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if principal := GetPrincipal(c); principal != nil {
+			client = "principal:" + principal.ID
+		}
+
+		result, err := r.limiter.Allow(c, bucket+"|"+client, limit)
+		if err != nil {
+			logrus.WithContext(c).WithError(err).Error("could not check rate limit, letting request through")
+			metrics.RateLimitDecisions.WithLabelValues(bucket, metrics.RateLimitError).Inc()
+			c.Next()
+			return
+		}
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		c.Header(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			metrics.RateLimitDecisions.WithLabelValues(bucket, metrics.RateLimitLimited).Inc()
+			c.Header(RetryAfterHeader, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			framework.LoggingRespondErrMsg(c, "rate limit exceeded", http.StatusTooManyRequests)
+			c.Abort()
+			return
+		}
+		metrics.RateLimitDecisions.WithLabelValues(bucket, metrics.RateLimitAllowed).Inc()
+		c.Next()
+	}
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/replay"
+)
+
+// RejectReplayedJWT rejects requests whose body has a JWT at jwtPath, e.g. $.applicationJwt, that was accepted before,
+// by this route or any other, with 409 Conflict. Requests whose body has no JWT there, or one that can't be parsed,
+// are left for the handler to reject. The JWT isn't verified until the handler accepts it, so JWTs of requests that
+// fail aren't remembered, lest a forged JWT with the issuer and ID of another keep the real one from being accepted.
+// It does nothing when replays is nil, which is when replay tracking is disabled.
+func RejectReplayedJWT(replays *replay.Service, jwtPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if replays == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload any
+		if err = json.Unmarshal(body, &payload); err != nil {
+			c.Next()
+			return
+		}
+		token, err := jsonpath.JsonPathLookup(payload, jwtPath)
+		tokenStr, ok := token.(string)
+		if err != nil || !ok || tokenStr == "" {
+			c.Next()
+			return
+		}
+
+		id, err := replays.ConsumeJWT(c, keyaccess.JWT(tokenStr))
+		if errors.Is(err, replay.ErrMalformedJWT) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			respondReplayErr(c, err, "jwt")
+			return
+		}
+		c.Next()
+		releaseOnFailure(c, replays, id, http.StatusBadRequest)
+	}
+}
+
+// respondReplayErr aborts a request whose JWT or signature, named by what, couldn't be consumed, with 409 Conflict
+// when it was replayed.
+func respondReplayErr(c *gin.Context, err error, what string) {
+	if errors.Is(err, replay.ErrReplayed) {
+		logrus.WithField("path", c.FullPath()).Warnf("rejected replayed %s", what)
+		framework.RespondProblem(c, framework.ErrorResponse{
+			Status: http.StatusConflict,
+			Detail: what + " " + err.Error(),
+			Code:   framework.CodeReplayed,
+		})
+	} else {
+		framework.LoggingRespondErrWithMsg(c, err, "could not check "+what+" for replay", http.StatusInternalServerError)
+	}
+	c.Abort()
+}
+
+// releaseOnFailure forgets the consumed JWT or signature when the request it came with failed with a status of at least
+// failedFrom.
+func releaseOnFailure(c *gin.Context, replays *replay.Service, id string, failedFrom int) {
+	if c.Writer.Status() < failedFrom {
+		return
+	}
+	if err := replays.Release(c, id); err != nil {
+		logrus.WithError(err).Error("releasing consumed jwt or signature of a failed request")
+	}
+}
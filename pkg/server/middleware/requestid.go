@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+)
+
+// RequestID makes sure every request has an ID, taken from the X-Request-ID header when the caller provides a valid
+// one, and generated otherwise. The ID is returned in the X-Request-ID response header, and carried by the request's
+// context so that logs, error responses, storage calls, and webhooks caused by the request can be correlated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+		c.Set(requestid.ContextKey, id)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/httpsig"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/replay"
+)
+
+const (
+	// SignerContextKey is the gin context key under which the ID of the client key that signed the request is stored.
+	SignerContextKey = "signer"
+
+	// DefaultSignatureMaxAge is how long after it was created a signature is accepted for, when no max age is
+	// configured.
+	DefaultSignatureMaxAge = 5 * time.Minute
+
+	// signatureClockSkew is how far in the future a signature may have been created, for clients whose clock is ahead.
+	signatureClockSkew = 30 * time.Second
+)
+
+// errInvalidRequestSignature is returned when a request isn't signed, or none of its signatures are acceptable.
+var errInvalidRequestSignature = errors.New("invalid request signature")
+
+// RequireSignatures rejects the requests that change something, which are all but GET, HEAD, and OPTIONS requests,
+// unless they carry an HTTP Message Signature (RFC 9421) made with a registered client key that hasn't been revoked.
+// Signatures must cover the @method, the @target-uri or @request-target, and, for requests with a body, the
+// content-digest, whose Content-Digest header must match the body. They're accepted for the max age after they were
+// created, unless they expire sooner. When replays isn't nil, each signature is only accepted once, and a request sent
+// again with the same signature is rejected with 409 Conflict.
+//
+// It must run after Authenticate, since client keys registered for a subject only sign the requests that subject
+// authenticated. The operations of batches and asynchronous replays aren't checked again, since the request they're
+// part of was.
+func RequireSignatures(authService *auth.Service, replays *replay.Service, cfg config.RequestSignaturesConfig) gin.HandlerFunc {
+	maxAge := cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultSignatureMaxAge
+	}
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if IsBatchedRequest(c) || IsAsyncReplay(c) {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, sig, err := verifyRequestSignature(c, authService, maxAge, body)
+		if err != nil {
+			if errors.Is(err, errInvalidRequestSignature) {
+				logrus.WithError(err).Warn("rejected request signature")
+				framework.LoggingRespondErrMsg(c, err.Error(), http.StatusUnauthorized)
+			} else {
+				framework.LoggingRespondErrWithMsg(c, err, "could not verify request signature", http.StatusInternalServerError)
+			}
+			c.Abort()
+			return
+		}
+		c.Set(SignerContextKey, key.ID)
+		if replays == nil {
+			c.Next()
+			return
+		}
+
+		// signatures are remembered until they're too old to be accepted anyway
+		expiresAt := sig.Created.Add(maxAge)
+		if !sig.Expires.IsZero() && sig.Expires.Before(expiresAt) {
+			expiresAt = sig.Expires
+		}
+		id, err := replays.ConsumeSignature(c, key.ID, sig.Nonce, sig.Value, expiresAt)
+		if err != nil {
+			respondReplayErr(c, err, "request signature")
+			return
+		}
+		// the signature was verified before it was consumed, so only requests that can be retried as they are forget it
+		c.Next()
+		releaseOnFailure(c, replays, id, http.StatusInternalServerError)
+	}
+}
+
+// verifyRequestSignature returns the client key that signed the request, and the signature it made, trying each of
+// the request's signatures until one is acceptable.
+func verifyRequestSignature(c *gin.Context, authService *auth.Service, maxAge time.Duration, body []byte) (*auth.ClientKey, *httpsig.Signature, error) {
+	signatures, err := httpsig.ParseSignatures(c.Request)
+	if err != nil {
+		return nil, nil, errors.Wrap(errInvalidRequestSignature, err.Error())
+	}
+	if len(signatures) == 0 {
+		return nil, nil, errors.Wrap(errInvalidRequestSignature, "the request must be signed")
+	}
+	digest := c.GetHeader(httpsig.ContentDigestHeader)
+	if digest != "" || len(body) > 0 {
+		if err = httpsig.VerifyContentDigest(digest, body); err != nil {
+			return nil, nil, errors.Wrap(errInvalidRequestSignature, err.Error())
+		}
+	}
+
+	for i, sig := range signatures {
+		var key *auth.ClientKey
+		if key, err = verifySignature(c, authService, maxAge, sig, len(body) > 0); err == nil {
+			return key, &signatures[i], nil
+		}
+		if !errors.Is(err, errInvalidRequestSignature) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, err
+}
+
+// verifySignature checks that a signature of the request covers enough of it, is recent, and was made with a client
+// key that may sign the request, returning the key.
+func verifySignature(c *gin.Context, authService *auth.Service, maxAge time.Duration, sig httpsig.Signature, hasBody bool) (*auth.ClientKey, error) {
+	invalid := func(format string, args ...any) error {
+		return errors.Wrapf(errInvalidRequestSignature, "signature %q "+format, append([]any{sig.Label}, args...)...)
+	}
+
+	switch {
+	case !sig.Covers("@method"):
+		return nil, invalid("must cover @method")
+	case !sig.Covers("@target-uri") && !sig.Covers("@request-target"):
+		return nil, invalid("must cover @target-uri or @request-target")
+	case hasBody && !sig.Covers("content-digest"):
+		return nil, invalid("must cover content-digest")
+	}
+
+	now := authService.Clock.Now()
+	switch {
+	case sig.Created.IsZero():
+		return nil, invalid("must have a created time")
+	case sig.Created.After(now.Add(signatureClockSkew)):
+		return nil, invalid("was created in the future")
+	case now.Sub(sig.Created) > maxAge:
+		return nil, invalid("was created more than %s ago", maxAge)
+	case !sig.Expires.IsZero() && now.After(sig.Expires):
+		return nil, invalid("has expired")
+	}
+
+	key, publicKey, err := authService.ClientPublicKey(c, sig.KeyID)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidClientKey) {
+			return nil, invalid("names an unknown or revoked key %q", sig.KeyID)
+		}
+		return nil, err
+	}
+	if key.Subject != "" {
+		if principal := GetPrincipal(c); principal == nil || principal.ID != key.Subject {
+			return nil, invalid("was made with key %q, which may not sign the requests of this caller", key.ID)
+		}
+	}
+	if err = sig.Verify(c.Request, key.Algorithm, publicKey); err != nil {
+		return nil, invalid("doesn't verify: %s", err.Error())
+	}
+	return key, nil
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+// timeoutWriteGrace is how long after the timeout of a request its response may still be written, so that requests
+// that time out can be told so.
+const timeoutWriteGrace = time.Second
+
+// Timeouts limits how long requests take to the timeout of their route, replacing the read and write timeouts of the
+// server for the routes that have one. The context of a request is cancelled when its timeout passes, and requests
+// that haven't responded by then are answered with 503 Service Unavailable.
+//
+// The operations of batches and asynchronous replays aren't limited again, since they're part of a request that is, or
+// run in the background.
+func Timeouts(cfg config.TimeoutsConfig) gin.HandlerFunc {
+	routeTimeouts := make(map[string]time.Duration, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routeTimeouts[routeKey(route.Method, route.Path)] = route.Timeout
+	}
+	timeoutOf := func(method, path string) time.Duration {
+		for _, key := range []string{routeKey(method, path), routeKey("", path), routeKey(method, "")} {
+			if timeout, ok := routeTimeouts[key]; ok && timeout > 0 {
+				return timeout
+			}
+		}
+		return cfg.Default
+	}
+
+	return func(c *gin.Context) {
+		if IsBatchedRequest(c) || IsAsyncReplay(c) {
+			c.Next()
+			return
+		}
+		timeout := timeoutOf(c.Request.Method, c.FullPath())
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// writers that don't support deadlines, like the recorders of internal requests, keep the server's
+		deadline, _ := ctx.Deadline()
+		controller := http.NewResponseController(c.Writer)
+		_ = controller.SetReadDeadline(deadline)
+		_ = controller.SetWriteDeadline(deadline.Add(timeoutWriteGrace))
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: http.StatusServiceUnavailable,
+				Detail: fmt.Sprintf("request took longer than %s", timeout),
+				Code:   framework.CodeTimedOut,
+			})
+		}
+	}
+}
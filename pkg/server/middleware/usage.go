@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/usage"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Whose usage a quota caps.
+const (
+	QuotaPerTenant    = "tenant"
+	QuotaPerPrincipal = "principal"
+)
+
+// MeteredRoute describes the usage counted for successful requests to a route.
+type MeteredRoute struct {
+	// Method and Route, relative to the version of the API, of the requests that are metered. E.g. PUT /credentials.
+	Method string
+	Route  string
+
+	Meter usage.Meter
+
+	// Count is how much a request counts. It's 1 when empty, and the number of items of the array at a JSONPath of the
+	// request body or of the response with {request:$.path} and {response:$.path}. Storage counts the bytes of the
+	// request body instead.
+	Count string
+}
+
+// Metering counts the usage of the metered routes, and rejects the requests that would go over a quota. Its quotas can
+// be updated while the server is running.
+type Metering struct {
+	service *usage.Service
+	routes  []MeteredRoute
+
+	mu             sync.RWMutex
+	exceededStatus int
+	quotas         []config.QuotaConfig
+}
+
+// NewMetering creates a Metering that counts usage with service.
+func NewMetering(service *usage.Service, routes []MeteredRoute, cfg config.UsageConfig) (*Metering, error) {
+	m := Metering{service: service, routes: routes}
+	if err := m.Update(cfg); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Update replaces the quotas with the configured ones. Usage that was counted is kept.
+func (m *Metering) Update(cfg config.UsageConfig) error {
+	exceededStatus := cfg.ExceededStatus
+	switch exceededStatus {
+	case 0:
+		exceededStatus = http.StatusTooManyRequests
+	case http.StatusTooManyRequests, http.StatusPaymentRequired:
+	default:
+		return errors.Errorf("exceeded status must be %d or %d, not %d", http.StatusTooManyRequests, http.StatusPaymentRequired, exceededStatus)
+	}
+	quotas := make([]config.QuotaConfig, 0, len(cfg.Quotas))
+	for _, quota := range cfg.Quotas {
+		if !usage.Meter(quota.Meter).IsValid() {
+			return errors.Errorf("unknown meter of quota: %s", quota.Meter)
+		}
+		switch quota.Per {
+		case "":
+			quota.Per = QuotaPerTenant
+		case QuotaPerTenant, QuotaPerPrincipal:
+		default:
+			return errors.Errorf("quota of %s must be per %s or %s, not %s", quota.Meter, QuotaPerTenant, QuotaPerPrincipal, quota.Per)
+		}
+		quotas = append(quotas, quota)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exceededStatus = exceededStatus
+	m.quotas = quotas
+	return nil
+}
+
+// quotasOf returns the status of responses to requests over a quota, and the quotas that apply to a tenant's usage of
+// a meter. Quotas of the tenant replace the quotas of every tenant with the same per.
+func (m *Metering) quotasOf(tenant string, meter usage.Meter) (int, []config.QuotaConfig) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	byPer := make(map[string]config.QuotaConfig)
+	for _, quota := range m.quotas {
+		if usage.Meter(quota.Meter) != meter || (quota.Tenant != "" && quota.Tenant != tenant) {
+			continue
+		}
+		if _, ok := byPer[quota.Per]; ok && quota.Tenant == "" {
+			continue
+		}
+		byPer[quota.Per] = quota
+	}
+	quotas := make([]config.QuotaConfig, 0, len(byPer))
+	for _, per := range []string{QuotaPerTenant, QuotaPerPrincipal} {
+		if quota, ok := byPer[per]; ok {
+			quotas = append(quotas, quota)
+		}
+	}
+	return m.exceededStatus, quotas
+}
+
+// Handler returns the middleware that meters requests. It should come after Authenticate, so that usage is counted for
+// the principal that made the request. Only successful requests are counted, and requests that are accepted to run
+// asynchronously are counted once they ran. Every operation of a batch is metered on its own.
+func (m *Metering) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		metered := m.meteredBy(c.Request.Method, routeVersion(route), route)
+		if len(metered) == 0 {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		tenant := c.GetString(storage.TenantContextKey)
+		var principal string
+		if p := GetPrincipal(c); p != nil {
+			principal = p.ID
+		}
+
+		// replayed requests were checked when they were accepted
+		if !IsAsyncReplay(c) && !m.allow(c, tenant, principal, metered, body) {
+			c.Abort()
+			return
+		}
+
+		w := &responseWriter{ResponseWriter: c.Writer, buf: new(bytes.Buffer)}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		status := c.Writer.Status()
+		if !util.Is2xxResponse(status) || status == http.StatusAccepted {
+			return
+		}
+		for _, route := range metered {
+			count, err := countOf(route, body, w.buf.Bytes())
+			if err != nil {
+				logrus.WithContext(c).WithError(err).Warnf("could not count %s usage of %s %s", route.Meter, c.Request.Method, c.FullPath())
+				continue
+			}
+			if count == 0 {
+				continue
+			}
+			// the request already succeeded, so failing to count it can only be logged
+			if err = m.service.Add(c, tenant, principal, route.Meter, count); err != nil {
+				logrus.WithContext(c).WithError(err).Errorf("could not add %s usage of %s %s", route.Meter, c.Request.Method, c.FullPath())
+			}
+		}
+	}
+}
+
+// allow returns whether a request may be made without going over a quota, responding to it when it may not. Counts
+// that depend on the response are taken to be 1, so that a request can't start once a quota is used up. When usage
+// can't be read, requests are let through.
+func (m *Metering) allow(c *gin.Context, tenant, principal string, metered []MeteredRoute, body []byte) bool {
+	needed := make(map[usage.Meter]int64)
+	for _, route := range metered {
+		count, err := countOf(route, body, nil)
+		if err != nil || count < 1 {
+			count = 1
+		}
+		needed[route.Meter] += count
+	}
+
+	for _, meter := range usage.Meters {
+		count, ok := needed[meter]
+		if !ok {
+			continue
+		}
+		status, quotas := m.quotasOf(tenant, meter)
+		for _, quota := range quotas {
+			var of string
+			if quota.Per == QuotaPerPrincipal {
+				// requests that weren't authenticated only count against the quotas of their tenant
+				if principal == "" {
+					continue
+				}
+				of = principal
+			}
+			used, err := m.service.Current(c, tenant, of, meter)
+			if err != nil {
+				logrus.WithContext(c).WithError(err).Error("could not check quota, letting request through")
+				continue
+			}
+			if used+count <= quota.Limit {
+				continue
+			}
+			now := m.service.Clock.Now()
+			c.Header(RetryAfterHeader, strconv.Itoa(ceilSeconds(usage.PeriodEnd(now).Sub(now))))
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: status,
+				Detail: fmt.Sprintf("%s quota of %d per %s for %s exceeded, %d used", meter, quota.Limit, quota.Per, usage.Period(now), used),
+				Code:   framework.CodeQuotaExceeded,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// meteredBy returns the meters counting requests to a route.
+func (m *Metering) meteredBy(method, version, route string) []MeteredRoute {
+	var metered []MeteredRoute
+	for _, r := range m.routes {
+		if r.Method == method && version+r.Route == route {
+			metered = append(metered, r)
+		}
+	}
+	return metered
+}
+
+// countOf returns how much a request to a metered route counts, given its body and response. Counts that depend on the
+// response are 0 when there is none.
+func countOf(route MeteredRoute, body, response []byte) (int64, error) {
+	if route.Meter == usage.MeterStorage {
+		return int64(len(body)), nil
+	}
+	if route.Count == "" {
+		return 1, nil
+	}
+	match := undoParam.FindStringSubmatch(route.Count)
+	if match == nil {
+		return 0, errors.Errorf("invalid count: %s", route.Count)
+	}
+	source := response
+	if match[1] == "request" {
+		source = body
+	}
+	if source == nil {
+		return 0, nil
+	}
+	var parsed any
+	if err := json.Unmarshal(source, &parsed); err != nil {
+		return 0, errors.Wrapf(err, "reading %s", match[1])
+	}
+	value, err := jsonpath.JsonPathLookup(parsed, match[2])
+	if err != nil {
+		// which includes arrays that are omitted when empty
+		return 0, nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return 0, errors.Errorf("%s in %s is not an array", match[2], match[1])
+	}
+	return int64(len(items)), nil
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/openapi"
+)
+
+// ValidateRequests rejects requests whose JSON body doesn't conform to the schema of their operation in the API spec
+// with 400 Bad Request, before they reach their handler. The problem lists each field that failed, so clients see
+// every mistake in a body at once, rather than the first one the handler trips on. Requests to routes the spec has no
+// body schema for are passed through.
+//
+// versions are the prefixes of the versions of the API, from oldest to newest. Later versions serve the routes of
+// earlier versions until they diverge from them, so routes the spec doesn't document under their own version are
+// validated against the schema of the latest earlier version that documents them.
+func ValidateRequests(validator *openapi.Validator, versions []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path, ok := specPath(validator, c.Request.Method, c.FullPath(), versions)
+		if c.Request.Body == nil || !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "reading request body", http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = validator.ValidateRequest(c.Request.Method, path, body)
+		var validationErr *openapi.ValidationError
+		switch {
+		case err == nil:
+			c.Next()
+			return
+		case errors.As(err, &validationErr):
+			fieldErrors := make([]framework.FieldError, 0, len(validationErr.Violations))
+			for _, v := range validationErr.Violations {
+				fieldErrors = append(fieldErrors, framework.FieldError{Field: v.Field, Error: v.Error})
+			}
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: http.StatusBadRequest,
+				Detail: validationErr.Error(),
+				Code:   framework.CodeValidationFailed,
+				Errors: fieldErrors,
+			})
+		case errors.Is(err, openapi.ErrMalformedBody):
+			framework.RespondProblem(c, framework.ErrorResponse{
+				Status: http.StatusBadRequest,
+				Detail: err.Error(),
+				Code:   framework.CodeMalformedRequest,
+			})
+		default:
+			framework.LoggingRespondErrWithMsg(c, err, "could not validate request body", http.StatusInternalServerError)
+		}
+		c.Abort()
+	}
+}
+
+// specPath returns the path of the operation of the spec that documents requests to route, and whether it takes a JSON
+// body.
+func specPath(validator *openapi.Validator, method, route string, versions []string) (string, bool) {
+	path := openapi.PathTemplate(route)
+	if validator.Documents(method, path) {
+		return path, validator.HasRequestBody(method, path)
+	}
+	for i := len(versions) - 1; i > 0; i-- {
+		if !strings.HasPrefix(path, versions[i]+"/") {
+			continue
+		}
+		rest := strings.TrimPrefix(path, versions[i])
+		for j := i - 1; j >= 0; j-- {
+			if validator.Documents(method, versions[j]+rest) {
+				return versions[j] + rest, validator.HasRequestBody(method, versions[j]+rest)
+			}
+		}
+	}
+	return "", false
+}
@@ -41,6 +41,6 @@ func Webhook(webhookService *webhook.Service, noun webhook.Noun, verb webhook.Ve
 		}
 
 		// publish the webhook
-		go webhookService.PublishWebhook(c, noun, verb, buf)
+		webhookService.PublishWebhookInBackground(c, noun, verb, buf)
 	}
 }
@@ -0,0 +1,298 @@
+// Package openapi converts the Swagger 2.0 specification generated from the router annotations into an OpenAPI 3
+// document, which is what most client generators expect.
+package openapi
+
+import (
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Version is the OpenAPI version of the converted documents.
+	Version = "3.0.3"
+
+	definitionsRef = "#/definitions/"
+	schemasRef     = "#/components/schemas/"
+
+	defaultMediaType = "application/json"
+)
+
+// FromSwagger2 converts a Swagger 2.0 specification, in YAML or JSON, into an OpenAPI 3 document encoded as JSON.
+func FromSwagger2(spec []byte) ([]byte, error) {
+	var swagger map[string]any
+	if err := yaml.Unmarshal(spec, &swagger); err != nil {
+		return nil, errors.Wrap(err, "parsing swagger spec")
+	}
+	if version, _ := swagger["swagger"].(string); version != "2.0" {
+		return nil, errors.Errorf("unsupported swagger version: %v", swagger["swagger"])
+	}
+
+	doc := map[string]any{"openapi": Version}
+	for _, field := range []string{"info", "tags", "externalDocs", "security"} {
+		if value, ok := swagger[field]; ok {
+			doc[field] = value
+		}
+	}
+	if servers := servers(swagger); len(servers) > 0 {
+		doc["servers"] = servers
+	}
+
+	components := map[string]any{}
+	if definitions, ok := swagger["definitions"].(map[string]any); ok {
+		components["schemas"] = rewriteRefs(definitions)
+	}
+	if securityDefinitions, ok := swagger["securityDefinitions"].(map[string]any); ok {
+		components["securitySchemes"] = securitySchemes(securityDefinitions)
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+
+	consumes := stringList(swagger["consumes"])
+	produces := stringList(swagger["produces"])
+	paths := map[string]any{}
+	if swaggerPaths, ok := swagger["paths"].(map[string]any); ok {
+		for path, item := range swaggerPaths {
+			operations, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			converted := map[string]any{}
+			for method, op := range operations {
+				operation, ok := op.(map[string]any)
+				if !ok {
+					// path level parameters
+					converted[method] = op
+					continue
+				}
+				converted[method] = convertOperation(operation, consumes, produces)
+			}
+			paths[path] = rewriteRefs(converted)
+		}
+	}
+	doc["paths"] = paths
+
+	return json.Marshal(doc)
+}
+
+// servers builds the server URL from the host, base path, and schemes of the spec. Without a host, clients use the
+// host the document was fetched from.
+func servers(swagger map[string]any) []any {
+	host, _ := swagger["host"].(string)
+	basePath, _ := swagger["basePath"].(string)
+	if host == "" {
+		if basePath == "" || basePath == "/" {
+			return nil
+		}
+		return []any{map[string]any{"url": basePath}}
+	}
+	schemes := stringList(swagger["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	result := make([]any, 0, len(schemes))
+	for _, scheme := range schemes {
+		result = append(result, map[string]any{"url": scheme + "://" + host + basePath})
+	}
+	return result
+}
+
+func convertOperation(operation map[string]any, consumes, produces []string) map[string]any {
+	converted := map[string]any{}
+	for field, value := range operation {
+		switch field {
+		case "consumes", "produces", "parameters", "responses", "schemes":
+		default:
+			converted[field] = value
+		}
+	}
+	if opConsumes := stringList(operation["consumes"]); len(opConsumes) > 0 {
+		consumes = opConsumes
+	}
+	if opProduces := stringList(operation["produces"]); len(opProduces) > 0 {
+		produces = opProduces
+	}
+
+	var parameters []any
+	for _, p := range asList(operation["parameters"]) {
+		parameter, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch parameter["in"] {
+		case "body":
+			requestBody := map[string]any{"content": content(consumes, parameter["schema"])}
+			if description, ok := parameter["description"]; ok {
+				requestBody["description"] = description
+			}
+			if required, ok := parameter["required"]; ok {
+				requestBody["required"] = required
+			}
+			converted["requestBody"] = requestBody
+		case "formData":
+			// the API only accepts JSON bodies, so form parameters aren't expected
+			continue
+		default:
+			parameters = append(parameters, convertParameter(parameter))
+		}
+	}
+	if len(parameters) > 0 {
+		converted["parameters"] = parameters
+	}
+
+	if swaggerResponses, ok := operation["responses"].(map[string]any); ok {
+		responses := map[string]any{}
+		for code, r := range swaggerResponses {
+			response, ok := r.(map[string]any)
+			if !ok {
+				continue
+			}
+			responses[code] = convertResponse(response, produces)
+		}
+		converted["responses"] = responses
+	}
+	return converted
+}
+
+// convertParameter moves the type information of a non-body parameter into its schema.
+func convertParameter(parameter map[string]any) map[string]any {
+	converted := map[string]any{}
+	schema := map[string]any{}
+	for field, value := range parameter {
+		switch field {
+		case "name", "in", "description", "required", "deprecated", "allowEmptyValue":
+			converted[field] = value
+		case "collectionFormat":
+			if value == "multi" {
+				converted["explode"] = true
+			}
+		default:
+			schema[field] = value
+		}
+	}
+	if len(schema) > 0 {
+		converted["schema"] = schema
+	}
+	return converted
+}
+
+func convertResponse(response map[string]any, produces []string) map[string]any {
+	converted := map[string]any{}
+	if description, ok := response["description"]; ok {
+		converted["description"] = description
+	} else {
+		// descriptions are required by OpenAPI 3
+		converted["description"] = ""
+	}
+	if schema, ok := response["schema"]; ok {
+		converted["content"] = content(produces, schema)
+	}
+	if headers, ok := response["headers"].(map[string]any); ok {
+		convertedHeaders := map[string]any{}
+		for name, h := range headers {
+			header, ok := h.(map[string]any)
+			if !ok {
+				continue
+			}
+			convertedHeaders[name] = convertParameter(header)
+		}
+		converted["headers"] = convertedHeaders
+	}
+	return converted
+}
+
+func content(mediaTypes []string, schema any) map[string]any {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{defaultMediaType}
+	}
+	result := make(map[string]any, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		result[mediaType] = map[string]any{"schema": schema}
+	}
+	return result
+}
+
+func securitySchemes(definitions map[string]any) map[string]any {
+	schemes := make(map[string]any, len(definitions))
+	for name, d := range definitions {
+		definition, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch definition["type"] {
+		case "basic":
+			scheme := map[string]any{"type": "http", "scheme": "basic"}
+			if description, ok := definition["description"]; ok {
+				scheme["description"] = description
+			}
+			schemes[name] = scheme
+		case "oauth2":
+			flow := map[string]any{"scopes": definition["scopes"]}
+			for _, field := range []string{"authorizationUrl", "tokenUrl"} {
+				if value, ok := definition[field]; ok {
+					flow[field] = value
+				}
+			}
+			flowName, _ := definition["flow"].(string)
+			switch flowName {
+			case "accessCode":
+				flowName = "authorizationCode"
+			case "application":
+				flowName = "clientCredentials"
+			}
+			scheme := map[string]any{"type": "oauth2", "flows": map[string]any{flowName: flow}}
+			if description, ok := definition["description"]; ok {
+				scheme["description"] = description
+			}
+			schemes[name] = scheme
+		default:
+			// apiKey schemes are the same in both versions
+			schemes[name] = definition
+		}
+	}
+	return schemes
+}
+
+// rewriteRefs points the references to definitions in value at the component schemas they were moved to.
+func rewriteRefs[T any](value T) T {
+	return rewrite(value).(T)
+}
+
+func rewrite(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" && strings.HasPrefix(ref, definitionsRef) {
+				v[key] = schemasRef + strings.TrimPrefix(ref, definitionsRef)
+				continue
+			}
+			v[key] = rewrite(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = rewrite(child)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func asList(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
+func stringList(value any) []string {
+	var result []string
+	for _, item := range asList(value) {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
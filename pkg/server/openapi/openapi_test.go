@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/doc"
+)
+
+const testSpec = `
+swagger: "2.0"
+info:
+  title: Test API
+basePath: /v1
+definitions:
+  Widget:
+    properties:
+      parts:
+        items:
+          $ref: '#/definitions/Part'
+        type: array
+    type: object
+  Part:
+    type: object
+paths:
+  /widgets/{id}:
+    put:
+      consumes:
+      - application/json
+      produces:
+      - application/json
+      parameters:
+      - description: ID
+        in: path
+        name: id
+        required: true
+        type: string
+      - description: Filter
+        in: query
+        name: filter
+        type: string
+      - description: request body
+        in: body
+        name: request
+        required: true
+        schema:
+          $ref: '#/definitions/Widget'
+      responses:
+        "201":
+          description: Created
+          schema:
+            $ref: '#/definitions/Widget'
+        "204":
+          description: No Content
+      summary: Put Widget
+      tags:
+      - WidgetAPI
+`
+
+func TestFromSwagger2(t *testing.T) {
+	t.Run("converts operations", func(tt *testing.T) {
+		converted, err := FromSwagger2([]byte(testSpec))
+		require.NoError(tt, err)
+
+		var document map[string]any
+		require.NoError(tt, json.Unmarshal(converted, &document))
+		assert.Equal(tt, Version, document["openapi"])
+		assert.Equal(tt, map[string]any{"title": "Test API"}, document["info"])
+		assert.Equal(tt, []any{map[string]any{"url": "/v1"}}, document["servers"])
+
+		schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+		assert.Equal(tt, "#/components/schemas/Part", schemas["Widget"].(map[string]any)["properties"].(map[string]any)["parts"].(map[string]any)["items"].(map[string]any)["$ref"])
+
+		put := document["paths"].(map[string]any)["/widgets/{id}"].(map[string]any)["put"].(map[string]any)
+		assert.Equal(tt, "Put Widget", put["summary"])
+		assert.Equal(tt, []any{"WidgetAPI"}, put["tags"])
+		assert.Equal(tt, []any{
+			map[string]any{"description": "ID", "in": "path", "name": "id", "required": true, "schema": map[string]any{"type": "string"}},
+			map[string]any{"description": "Filter", "in": "query", "name": "filter", "schema": map[string]any{"type": "string"}},
+		}, put["parameters"])
+		assert.Equal(tt, map[string]any{
+			"description": "request body",
+			"required":    true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Widget"}},
+			},
+		}, put["requestBody"])
+		assert.Equal(tt, map[string]any{
+			"201": map[string]any{
+				"description": "Created",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Widget"}},
+				},
+			},
+			"204": map[string]any{"description": "No Content"},
+		}, put["responses"])
+		assert.NotContains(tt, put, "consumes")
+		assert.NotContains(tt, put, "produces")
+	})
+
+	t.Run("converts the API spec", func(tt *testing.T) {
+		converted, err := FromSwagger2(doc.SwaggerYAML)
+		require.NoError(tt, err)
+
+		var document map[string]any
+		require.NoError(tt, json.Unmarshal(converted, &document))
+		schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+		assert.NotEmpty(tt, schemas)
+		assert.NotEmpty(tt, document["paths"])
+
+		// every reference resolves to a component schema
+		assert.NotContains(tt, string(converted), "#/definitions/")
+		for _, match := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(converted), -1) {
+			assert.Contains(tt, schemas, match[1])
+		}
+	})
+
+	t.Run("rejects other versions", func(tt *testing.T) {
+		_, err := FromSwagger2([]byte(`openapi: 3.0.0`))
+		assert.ErrorContains(tt, err, "unsupported swagger version")
+	})
+}
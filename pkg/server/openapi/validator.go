@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const documentURL = "openapi.json"
+
+// Violation is a way in which the body of a request doesn't conform to the schema of its operation.
+type Violation struct {
+	// Field of the body that's invalid, as a dotted path, e.g. options.serviceEndpoint. Empty when the body as a whole
+	// is invalid.
+	Field string
+	Error string
+}
+
+// ValidationError is returned for request bodies that don't conform to the schema of their operation.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.Field == "" {
+			violations = append(violations, v.Error)
+			continue
+		}
+		violations = append(violations, v.Field+": "+v.Error)
+	}
+	return "request body does not conform to the api spec: " + strings.Join(violations, "; ")
+}
+
+// ErrMalformedBody is returned for request bodies that aren't JSON.
+var ErrMalformedBody = errors.New("request body is not valid JSON")
+
+// Validator validates the bodies of requests against the schemas of their operations in an OpenAPI 3 document, as
+// converted by FromSwagger2.
+type Validator struct {
+	// operations by method and path, which are nil for operations without a JSON body
+	operations map[string]*requestBody
+}
+
+type requestBody struct {
+	required bool
+	schema   *jsonschema.Schema
+}
+
+// NewValidator compiles the JSON schemas of the request bodies of the operations of the document. Schemas are
+// compiled as draft 4 JSON schemas, which is what the schemas of OpenAPI 3.0 extend.
+func NewValidator(document []byte) (*Validator, error) {
+	var doc struct {
+		Paths map[string]map[string]struct {
+			RequestBody *struct {
+				Required bool                       `json:"required"`
+				Content  map[string]json.RawMessage `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, errors.Wrap(err, "parsing openapi document")
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft4
+	if err := compiler.AddResource(documentURL, bytes.NewReader(document)); err != nil {
+		return nil, errors.Wrap(err, "loading openapi document")
+	}
+	operations := make(map[string]*requestBody)
+	for path, item := range doc.Paths {
+		for method, operation := range item {
+			operations[operationKey(method, path)] = nil
+			if operation.RequestBody == nil {
+				continue
+			}
+			if _, ok := operation.RequestBody.Content[defaultMediaType]; !ok {
+				continue
+			}
+			pointer := strings.Join([]string{"paths", escapePointer(path), method, "requestBody", "content", escapePointer(defaultMediaType), "schema"}, "/")
+			schema, err := compiler.Compile(documentURL + "#/" + pointer)
+			if err != nil {
+				return nil, errors.Wrapf(err, "compiling request body schema of %s %s", strings.ToUpper(method), path)
+			}
+			operations[operationKey(method, path)] = &requestBody{required: operation.RequestBody.Required, schema: schema}
+		}
+	}
+	return &Validator{operations: operations}, nil
+}
+
+// Documents returns whether the document has an operation of method and path, a path template like /v1/dids/{method}.
+func (v *Validator) Documents(method, path string) bool {
+	_, ok := v.operations[operationKey(method, path)]
+	return ok
+}
+
+// HasRequestBody returns whether the operation of method and path takes a JSON body that's validated.
+func (v *Validator) HasRequestBody(method, path string) bool {
+	return v.operations[operationKey(method, path)] != nil
+}
+
+// ValidateRequest validates the body of a request to the operation of method and path. Bodies of operations that don't
+// take one aren't validated. Members whose value is null are ignored, the same way decoding the body ignores them.
+// Bodies that don't conform fail with a ValidationError, and bodies that aren't JSON with ErrMalformedBody.
+func (v *Validator) ValidateRequest(method, path string, body []byte) error {
+	operation := v.operations[operationKey(method, path)]
+	if operation == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.required {
+			return &ValidationError{Violations: []Violation{{Error: "request body is required"}}}
+		}
+		return nil
+	}
+	var instance any
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return fmt.Errorf("%w: %s", ErrMalformedBody, err.Error())
+	}
+	err := operation.schema.Validate(withoutNulls(instance))
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return errors.Wrapf(err, "validating request body of %s %s", method, path)
+	}
+	return &ValidationError{Violations: violations(validationErr, nil)}
+}
+
+// PathTemplate returns the path template of the spec for a route as registered with gin, e.g. /v1/dids/{method} for
+// /v1/dids/:method.
+func PathTemplate(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = fmt.Sprintf("{%s}", segment[1:])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// violations flattens a validation error into the violations at its leaves, which are the ones that say what's wrong,
+// rather than which of their parents failed because of them.
+func violations(err *jsonschema.ValidationError, result []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		return append(result, Violation{
+			Field: strings.ReplaceAll(strings.TrimPrefix(err.InstanceLocation, "/"), "/", "."),
+			Error: err.Message,
+		})
+	}
+	for _, cause := range err.Causes {
+		result = violations(cause, result)
+	}
+	return result
+}
+
+func withoutNulls(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if child == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = withoutNulls(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = withoutNulls(child)
+		}
+	}
+	return value
+}
+
+func operationKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// escapePointer escapes a token of a JSON pointer, as described in RFC 6901, which is then escaped as the fragment of
+// a URL.
+func escapePointer(token string) string {
+	token = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	return strings.NewReplacer("{", "%7B", "}", "%7D").Replace(token)
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/doc"
+)
+
+const validatorTestSpec = `
+swagger: "2.0"
+definitions:
+  Gadget:
+    properties:
+      name:
+        type: string
+      size:
+        maximum: 10
+        type: integer
+      options:
+        $ref: '#/definitions/Options'
+    required:
+    - name
+    type: object
+  Options:
+    properties:
+      color:
+        enum:
+        - red
+        - blue
+        type: string
+    type: object
+paths:
+  /gadgets/{id}:
+    put:
+      parameters:
+      - in: path
+        name: id
+        required: true
+        type: string
+      - in: body
+        name: request
+        required: true
+        schema:
+          $ref: '#/definitions/Gadget'
+      responses:
+        "201":
+          description: Created
+    get:
+      parameters:
+      - in: path
+        name: id
+        required: true
+        type: string
+      responses:
+        "200":
+          description: OK
+`
+
+func TestValidator(t *testing.T) {
+	document, err := FromSwagger2([]byte(validatorTestSpec))
+	require.NoError(t, err)
+	validator, err := NewValidator(document)
+	require.NoError(t, err)
+
+	t.Run("knows which operations take a body", func(tt *testing.T) {
+		assert.True(tt, validator.Documents("PUT", "/gadgets/{id}"))
+		assert.True(tt, validator.HasRequestBody("PUT", "/gadgets/{id}"))
+		assert.True(tt, validator.Documents("GET", "/gadgets/{id}"))
+		assert.False(tt, validator.HasRequestBody("GET", "/gadgets/{id}"))
+		assert.False(tt, validator.Documents("DELETE", "/gadgets/{id}"))
+	})
+
+	t.Run("accepts bodies that conform", func(tt *testing.T) {
+		assert.NoError(tt, validator.ValidateRequest("PUT", "/gadgets/{id}", []byte(`{"name":"g","size":3,"options":{"color":"red"}}`)))
+		// null members are left out, the same way decoding leaves them out
+		assert.NoError(tt, validator.ValidateRequest("PUT", "/gadgets/{id}", []byte(`{"name":"g","options":null}`)))
+		// as are bodies of operations that don't take one
+		assert.NoError(tt, validator.ValidateRequest("GET", "/gadgets/{id}", []byte(`not json`)))
+	})
+
+	t.Run("lists every field that fails", func(tt *testing.T) {
+		err := validator.ValidateRequest("PUT", "/gadgets/{id}", []byte(`{"size":11,"options":{"color":"green"}}`))
+		var validationErr *ValidationError
+		require.ErrorAs(tt, err, &validationErr)
+		fields := make(map[string]string)
+		for _, v := range validationErr.Violations {
+			fields[v.Field] = v.Error
+		}
+		assert.Len(tt, fields, 3)
+		assert.Contains(tt, fields[""], "name")
+		assert.Contains(tt, fields["size"], "10")
+		assert.Contains(tt, fields, "options.color")
+
+		err = validator.ValidateRequest("PUT", "/gadgets/{id}", nil)
+		require.ErrorAs(tt, err, &validationErr)
+		assert.Equal(tt, "request body is required", validationErr.Violations[0].Error)
+	})
+
+	t.Run("rejects bodies that aren't json", func(tt *testing.T) {
+		err := validator.ValidateRequest("PUT", "/gadgets/{id}", []byte(`{"name":`))
+		assert.ErrorIs(tt, err, ErrMalformedBody)
+	})
+
+	t.Run("compiles the API spec", func(tt *testing.T) {
+		document, err := FromSwagger2(doc.SwaggerYAML)
+		require.NoError(tt, err)
+		validator, err := NewValidator(document)
+		require.NoError(tt, err)
+		assert.True(tt, validator.HasRequestBody("PUT", "/v1/dids/{method}"))
+	})
+}
+
+func TestPathTemplate(t *testing.T) {
+	assert.Equal(t, "/v1/dids/{method}/{id}", PathTemplate("/v1/dids/:method/:id"))
+	assert.Equal(t, "/swagger/{any}", PathTemplate("/swagger/*any"))
+	assert.Equal(t, "/v1/schemas", PathTemplate("/v1/schemas"))
+}
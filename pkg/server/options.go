@@ -0,0 +1,89 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/features"
+	"github.com/tbd54566975/ssi-service/pkg/service"
+)
+
+// Option customizes an SSIServer, for programs that embed the service. Such programs can serve the server's Handler
+// themselves, e.g. mounted under their own mux, instead of calling ListenAndServe.
+type Option func(*options)
+
+type options struct {
+	services      []service.Option
+	middleware    []gin.HandlerFunc
+	apiMiddleware []gin.HandlerFunc
+	routes        []func(engine *gin.Engine) error
+	apiRoutes     []func(api *gin.RouterGroup) error
+	features      []features.Flag
+	loadConfig    func() (*config.SSIServiceConfig, error)
+}
+
+// WithServiceOptions instantiates the services with opts, e.g. to provide their storage or the encryption of their
+// keys.
+func WithServiceOptions(opts ...service.Option) Option {
+	return func(o *options) {
+		o.services = append(o.services, opts...)
+	}
+}
+
+// WithMiddleware runs handlers on every request, after the built-in middleware that assigns request IDs, logs, and
+// handles errors, and before any route. Handlers that respond must abort the request.
+func WithMiddleware(handlers ...gin.HandlerFunc) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, handlers...)
+	}
+}
+
+// WithAPIMiddleware runs handlers on every request to a version of the API, after it was authenticated, rate limited,
+// and checked for idempotency, and before its route. Unlike the handlers of WithMiddleware, they see who made the
+// request. Handlers that respond must abort the request.
+func WithAPIMiddleware(handlers ...gin.HandlerFunc) Option {
+	return func(o *options) {
+		o.apiMiddleware = append(o.apiMiddleware, handlers...)
+	}
+}
+
+// WithRoutes calls register with the engine once the service's own routes are registered, to add routes outside of
+// the versions of the API. They don't share the API's authentication, rate limits, or idempotency.
+func WithRoutes(register func(engine *gin.Engine) error) Option {
+	return func(o *options) {
+		o.routes = append(o.routes, register)
+	}
+}
+
+// WithAPIRoutes calls register with the group of every version of the API, e.g. /v1, to add routes that are
+// authenticated, rate limited, and idempotent like the service's own.
+func WithAPIRoutes(register func(api *gin.RouterGroup) error) Option {
+	return func(o *options) {
+		o.apiRoutes = append(o.apiRoutes, register)
+	}
+}
+
+// WithFeatures adds flags for the experimental capabilities of the program, which are enabled in config like the
+// service's own. Routes require a flag with middleware.RequireFeature, and handlers check one with
+// middleware.FeatureEnabled.
+func WithFeatures(flags ...features.Flag) Option {
+	return func(o *options) {
+		o.features = append(o.features, flags...)
+	}
+}
+
+// WithConfigLoader has the server load its config with load when it's reloaded with ReloadConfig, e.g. through the admin
+// API, usually from the file it was first loaded from.
+func WithConfigLoader(load func() (*config.SSIServiceConfig, error)) Option {
+	return func(o *options) {
+		o.loadConfig = load
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -23,35 +24,44 @@ type PageToken struct {
 const (
 	PageSizeParam  = "pageSize"
 	PageTokenParam = "pageToken"
+
+	// TotalCountHeader is the response header with the number of items in the whole list, across all of its pages.
+	TotalCountHeader = "X-Total-Count"
+
+	// MaxPageSize is the largest page returned. Larger page sizes are lowered to it.
+	MaxPageSize = 1000
 )
 
 // ParsePaginationParams reads the PageSizeParam and PageTokenParam from the URL parameters and populates the passed in
 // pageRequest. The value encoded in PageTokenParam is assumed to be the base64url encoding of a PageToken. It is an
 // error for the query params to be different from the query params encoded in the PageToken. Any error during the
 // execution is responded to using the passed in gin.Context. The return value corresponds to whether there was an
-// error within the function.
+// error within the function. Page sizes larger than MaxPageSize are lowered to it.
 func ParsePaginationParams(c *gin.Context, pageRequest *PageRequest) bool {
 	pageSizeStr := framework.GetQueryValue(c, PageSizeParam)
-	logrus.Debugln("pageSizeStr %s", pageSizeStr)
 	if pageSizeStr != nil {
+		logrus.Debugf("pageSizeStr %s", *pageSizeStr)
 		pageSize, err := strconv.Atoi(*pageSizeStr)
 		if err != nil {
-			errMsg := fmt.Sprintf("list DIDs by method request encountered a problem with the %q query param", PageSizeParam)
+			errMsg := fmt.Sprintf("list request encountered a problem with the %q query param", PageSizeParam)
 			framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
 			return true
 		}
-		logrus.Debugln("pageSize %d", pageSize)
+		logrus.Debugf("pageSize %d", pageSize)
 		if pageSize <= 0 {
 			errMsg := fmt.Sprintf("'%s' must be greater than 0", PageSizeParam)
 			framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
 			return true
 		}
+		if pageSize > MaxPageSize {
+			pageSize = MaxPageSize
+		}
 		pageRequest.PageSize = &pageSize
 	}
 
 	queryPageToken := framework.GetQueryValue(c, PageTokenParam)
-	logrus.Debugln("queryPageToken %s", queryPageToken)
 	if queryPageToken != nil {
+		logrus.Debugf("queryPageToken %s", *queryPageToken)
 		errMsg := "token value cannot be decoded"
 		tokenData, err := base64.RawURLEncoding.DecodeString(*queryPageToken)
 		if err != nil {
@@ -109,6 +119,41 @@ func MaybeSetNextPageToken(c *gin.Context, serviceNextPageToken string, respNext
 	return false
 }
 
+// SetTotalCount sets the TotalCountHeader of the response to the number of items in the whole list.
+func SetTotalCount(c *gin.Context, total int) {
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+}
+
+// Paginate returns the page of items for lists that are read whole from the service. Items are ordered by the value
+// returned by id, which must be unique, and the page token of the next page is the id of the last item of the page.
+// The next page token is assigned to what respNextPageToken is pointing to, and the TotalCountHeader is set to the
+// number of items. Any error during the execution is responded to using the passed in gin.Context. The second return
+// value corresponds to whether there was an error within the function.
+func Paginate[T any](c *gin.Context, pageRequest PageRequest, items []T, id func(T) string, respNextPageToken *string) ([]T, bool) {
+	SetTotalCount(c, len(items))
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return id(sorted[i]) < id(sorted[j])
+	})
+
+	if pageRequest.PageToken != nil {
+		after := *pageRequest.PageToken
+		start := sort.Search(len(sorted), func(i int) bool {
+			return id(sorted[i]) > after
+		})
+		sorted = sorted[start:]
+	}
+	if pageRequest.PageSize == nil || len(sorted) <= *pageRequest.PageSize {
+		return sorted, false
+	}
+	page := sorted[:*pageRequest.PageSize]
+	if MaybeSetNextPageToken(c, id(page[len(page)-1]), respNextPageToken) {
+		return nil, true
+	}
+	return page, false
+}
+
 // PageRequest contains the parameters sent in the request.
 type PageRequest struct {
 	// PageSize is the value associated with PageSizeParam. A nil value means it was not present in the query. When the parameter
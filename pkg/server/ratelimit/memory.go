@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// sweepInterval is how many calls to Allow happen between removals of idle buckets.
+const sweepInterval = 1000
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryLimiter keeps buckets in memory. Limits are only enforced per instance of the service.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	buckets map[string]*bucket
+	calls   int
+}
+
+func NewMemoryLimiter(clock clock.Clock) *MemoryLimiter {
+	return &MemoryLimiter{
+		clock:   clock,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	m.calls++
+	if m.calls%sweepInterval == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.burst()), updated: now}
+		m.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = refill(limit, b.tokens, now.Sub(b.updated))
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(limit, allowed, b.tokens), nil
+}
+
+// sweep removes buckets that have refilled completely, since they're the same as a new bucket.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if refill(b.limit, b.tokens, now.Sub(b.updated)) >= float64(b.limit.burst()) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+var _ Limiter = (*MemoryLimiter)(nil)
@@ -0,0 +1,111 @@
+// Package ratelimit implements token bucket rate limiting, with buckets kept either in memory or in Redis.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	goredislib "github.com/redis/go-redis/v9"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+const (
+	MemoryBackend = "memory"
+	RedisBackend  = "redis"
+)
+
+// Limit describes a token bucket. Buckets hold up to Burst tokens, and are refilled at RequestsPerMinute.
+type Limit struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// IsZero returns whether the limit doesn't limit anything.
+func (l Limit) IsZero() bool {
+	return l.RequestsPerMinute <= 0
+}
+
+// burst returns the capacity of the bucket, defaulting to the per minute rate.
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.RequestsPerMinute
+}
+
+// perSecond returns the number of tokens added to the bucket every second.
+func (l Limit) perSecond() float64 {
+	return float64(l.RequestsPerMinute) / 60
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+
+	// Limit is the capacity of the bucket.
+	Limit int
+
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+
+	// RetryAfter is the time until a token is available. Only set when the request is not allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter takes tokens from buckets identified by a key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// NewLimiter creates the limiter for the configured backend.
+func NewLimiter(cfg config.RateLimitConfig) (Limiter, error) {
+	switch cfg.Backend {
+	case "", MemoryBackend:
+		return NewMemoryLimiter(clock.New()), nil
+	case RedisBackend:
+		if cfg.RedisAddress == "" {
+			return nil, errors.New("redis rate limit backend requires a redis address")
+		}
+		client := goredislib.NewClient(&goredislib.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+		})
+		return NewRedisLimiter(client, clock.New()), nil
+	default:
+		return nil, errors.Errorf("unsupported rate limit backend: %s", cfg.Backend)
+	}
+}
+
+// refill returns the number of tokens in a bucket that had tokens at last, and has been refilling since.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.burst()), tokens+elapsed.Seconds()*limit.perSecond())
+}
+
+// newResult describes a bucket that has tokens left after a request that was allowed or not.
+func newResult(limit Limit, allowed bool, tokens float64) *Result {
+	rate := limit.perSecond()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.burst(),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(limit.burst()) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return &result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/benbjohnson/clock"
+	goredislib "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+func TestLimiters(t *testing.T) {
+	limiters := []struct {
+		name string
+		new  func(t *testing.T, clock clock.Clock) Limiter
+	}{
+		{
+			name: "memory",
+			new: func(_ *testing.T, clock clock.Clock) Limiter {
+				return NewMemoryLimiter(clock)
+			},
+		},
+		{
+			name: "redis",
+			new: func(t *testing.T, clock clock.Clock) Limiter {
+				server := miniredis.RunT(t)
+				return NewRedisLimiter(goredislib.NewClient(&goredislib.Options{Addr: server.Addr()}), clock)
+			},
+		},
+	}
+
+	for _, l := range limiters {
+		t.Run(l.name, func(tt *testing.T) {
+			tt.Run("allows up to the burst then refills", func(ttt *testing.T) {
+				mockClock := clock.NewMock()
+				limiter := l.new(ttt, mockClock)
+				limit := Limit{RequestsPerMinute: 60, Burst: 2}
+
+				result, err := limiter.Allow(context.Background(), "client", limit)
+				require.NoError(ttt, err)
+				assert.True(ttt, result.Allowed)
+				assert.Equal(ttt, 2, result.Limit)
+				assert.Equal(ttt, 1, result.Remaining)
+
+				result, err = limiter.Allow(context.Background(), "client", limit)
+				require.NoError(ttt, err)
+				assert.True(ttt, result.Allowed)
+				assert.Equal(ttt, 0, result.Remaining)
+				assert.Equal(ttt, 2*time.Second, result.Reset)
+
+				result, err = limiter.Allow(context.Background(), "client", limit)
+				require.NoError(ttt, err)
+				assert.False(ttt, result.Allowed)
+				assert.Equal(ttt, time.Second, result.RetryAfter)
+
+				mockClock.Add(time.Second)
+				result, err = limiter.Allow(context.Background(), "client", limit)
+				require.NoError(ttt, err)
+				assert.True(ttt, result.Allowed)
+			})
+
+			tt.Run("keeps separate buckets per key", func(ttt *testing.T) {
+				limiter := l.new(ttt, clock.NewMock())
+				limit := Limit{RequestsPerMinute: 1}
+
+				result, err := limiter.Allow(context.Background(), "a", limit)
+				require.NoError(ttt, err)
+				assert.True(ttt, result.Allowed)
+
+				result, err = limiter.Allow(context.Background(), "a", limit)
+				require.NoError(ttt, err)
+				assert.False(ttt, result.Allowed)
+
+				result, err = limiter.Allow(context.Background(), "b", limit)
+				require.NoError(ttt, err)
+				assert.True(ttt, result.Allowed)
+			})
+		})
+	}
+}
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(config.RateLimitConfig{Backend: RedisBackend})
+	assert.Error(t, err)
+
+	_, err = NewLimiter(config.RateLimitConfig{Backend: "disk"})
+	assert.ErrorContains(t, err, "unsupported rate limit backend")
+
+	limiter, err := NewLimiter(config.RateLimitConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryLimiter{}, limiter)
+}
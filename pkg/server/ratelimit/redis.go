@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	goredislib "github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "ratelimit:"
+
+// takeScript atomically refills a bucket, and takes a token from it when one is available. Buckets expire once
+// they'd be full, since that's the same as a bucket that doesn't exist.
+var takeScript = goredislib.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+local elapsed = math.max(0, now - updated) / 1000
+tokens = math.min(burst, tokens + elapsed * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter keeps buckets in Redis, so that limits are shared by every instance of the service.
+type RedisLimiter struct {
+	client *goredislib.Client
+	clock  clock.Clock
+}
+
+func NewRedisLimiter(client *goredislib.Client, clock clock.Clock) *RedisLimiter {
+	return &RedisLimiter{client: client, clock: clock}
+}
+
+func (r *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	now := r.clock.Now().UnixMilli()
+	res, err := takeScript.Run(ctx, r.client, []string{redisKeyPrefix + key}, limit.perSecond(), limit.burst(), now).Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "running rate limit script")
+	}
+	if len(res) != 2 {
+		return nil, errors.Errorf("unexpected rate limit script result: %v", res)
+	}
+	allowed, ok := res[0].(int64)
+	if !ok {
+		return nil, errors.Errorf("unexpected rate limit script result: %v", res)
+	}
+	tokensStr, ok := res[1].(string)
+	if !ok {
+		return nil, errors.Errorf("unexpected rate limit script result: %v", res)
+	}
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing remaining tokens")
+	}
+	return newResult(limit, allowed == 1, tokens), nil
+}
+
+var _ Limiter = (*RedisLimiter)(nil)
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/faults"
+	"github.com/tbd54566975/ssi-service/internal/logging"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+// Reload applies the reload-safe parts of a new config to the running server, and reloads the TLS certificates from
+// disk. The log level, format, and sampling, the rate limits, the usage quotas, the feature flags, the CORS origins,
+// the webhook timeout, the universal resolver, and the injected faults, are reload-safe. Everything else, including
+// services, storage, and keystore backends, is only read on startup, so changes to it are logged and otherwise ignored
+// until restart.
+func (s *SSIServer) Reload(cfg config.SSIServiceConfig) error {
+	_, err := s.reload(cfg)
+	return err
+}
+
+// ReloadConfig loads the config again with the loader of WithConfigLoader, and reloads it like Reload does. It returns
+// the sections of the config whose changes require a restart, and were ignored. When the config can't be loaded, the
+// TLS certificates are reloaded nonetheless. Servers created without WithConfigLoader have no config to reload.
+func (s *SSIServer) ReloadConfig() ([]string, error) {
+	if s.loadConfig == nil {
+		return nil, router.ErrNoConfigToReload
+	}
+	cfg, err := s.loadConfig()
+	if err != nil {
+		if certsErr := s.ReloadCertificates(); certsErr != nil {
+			logrus.WithError(certsErr).Error("failed to reload tls certificates, continuing with the previous ones")
+		}
+		return nil, errors.Wrap(err, "loading config")
+	}
+	return s.reload(*cfg)
+}
+
+func (s *SSIServer) reload(cfg config.SSIServiceConfig) ([]string, error) {
+	// reloads are serialized, since they're triggered by signals and by the admin API alike
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	restart := restartRequired(s.cfg, cfg)
+	for _, section := range restart {
+		logrus.Warnf("config changes to %s require a restart, ignoring them", section)
+	}
+
+	// faults and the webhook timeout are checked before anything is applied, so that an invalid config leaves the
+	// server as it was. The other sections are checked as they're applied.
+	if s.SSIService.Faults != nil {
+		if err := faults.Validate(cfg.Services.Faults); err != nil {
+			return nil, errors.Wrap(err, "reloading faults")
+		}
+	}
+	if _, err := time.ParseDuration(cfg.Services.WebhookConfig.WebhookTimeout); err != nil {
+		return nil, errors.Wrap(err, "reloading webhook timeout")
+	}
+	if err := s.cors.Update(cfg.Server.EnableAllowAllCORS, cfg.Server.CORSAllowedOrigins); err != nil {
+		return nil, errors.Wrap(err, "reloading cors origins")
+	}
+	s.cfg.Server.EnableAllowAllCORS, s.cfg.Server.CORSAllowedOrigins = cfg.Server.EnableAllowAllCORS, cfg.Server.CORSAllowedOrigins
+	if s.metering != nil {
+		if err := s.metering.Update(cfg.Server.Usage); err != nil {
+			return nil, errors.Wrap(err, "reloading usage quotas")
+		}
+		enabled := s.cfg.Server.Usage.Enabled
+		s.cfg.Server.Usage = cfg.Server.Usage
+		s.cfg.Server.Usage.Enabled = enabled
+	}
+	if s.SSIService.Faults != nil {
+		// enabling or disabling faults requires a restart
+		update := cfg.Services.Faults
+		update.Enabled = true
+		if err := s.SSIService.Faults.Update(update); err != nil {
+			return nil, errors.Wrap(err, "reloading faults")
+		}
+		s.cfg.Services.Faults.Seed, s.cfg.Services.Faults.Faults = cfg.Services.Faults.Seed, cfg.Services.Faults.Faults
+	}
+
+	if err := s.Webhook.Update(cfg.Services.WebhookConfig); err != nil {
+		return nil, errors.Wrap(err, "reloading webhook timeout")
+	}
+	s.cfg.Services.WebhookConfig.WebhookTimeout = cfg.Services.WebhookConfig.WebhookTimeout
+	if err := s.DID.UpdateUniversalResolver(cfg.Services.DIDConfig); err != nil {
+		return nil, errors.Wrap(err, "reloading universal resolver")
+	}
+	did := &s.cfg.Services.DIDConfig
+	did.UniversalResolverURL = cfg.Services.DIDConfig.UniversalResolverURL
+	did.UniversalResolverMethods = cfg.Services.DIDConfig.UniversalResolverMethods
+	did.UniversalResolverCacheTTL = cfg.Services.DIDConfig.UniversalResolverCacheTTL
+
+	logging.Reload(cfg.Server)
+	s.cfg.Server.LogLevel = cfg.Server.LogLevel
+	s.cfg.Server.LogFormat = cfg.Server.LogFormat
+	if s.logSampler != nil {
+		s.logSampler.Update(cfg.Server.LogSampling)
+		s.cfg.Server.LogSampling = cfg.Server.LogSampling
+	}
+
+	if s.rateLimits != nil {
+		s.rateLimits.Update(cfg.Server.RateLimit)
+		enabled := s.cfg.Server.RateLimit.Enabled
+		s.cfg.Server.RateLimit = cfg.Server.RateLimit
+		s.cfg.Server.RateLimit.Enabled = enabled
+	}
+	s.features.Update(cfg.Server.Features)
+	s.cfg.Server.Features = cfg.Server.Features
+	*s.ServerConfig = s.cfg.Server
+
+	if err := s.ReloadCertificates(); err != nil {
+		return nil, err
+	}
+	logrus.Info("reloaded config")
+	return restart, nil
+}
+
+// ReloadCertificates reads the tls certificates of the API listener, and of the admin listener when there is one, from
+// disk again.
+func (s *SSIServer) ReloadCertificates() error {
+	if err := s.Server.ReloadCertificates(); err != nil {
+		return err
+	}
+	if s.Admin != nil {
+		return s.Admin.ReloadCertificates()
+	}
+	return nil
+}
+
+// restartRequired returns the sections of the config that changed, but can't be reloaded.
+func restartRequired(current, updated config.SSIServiceConfig) []string {
+	var sections []string
+	currentServices, updatedServices := current.Services, updated.Services
+	for _, services := range []*config.ServicesConfig{&currentServices, &updatedServices} {
+		services.Faults.Seed, services.Faults.Faults = 0, nil
+		services.WebhookConfig.WebhookTimeout = ""
+		services.DIDConfig.UniversalResolverURL, services.DIDConfig.UniversalResolverMethods = "", nil
+		services.DIDConfig.UniversalResolverCacheTTL = 0
+	}
+	if !reflect.DeepEqual(currentServices, updatedServices) {
+		sections = append(sections, "services")
+	}
+
+	currentServer, updatedServer := current.Server, updated.Server
+	for _, server := range []*config.ServerConfig{&currentServer, &updatedServer} {
+		server.LogLevel, server.LogFormat, server.LogSampling = "", "", config.LogSamplingConfig{}
+		server.RateLimit.RequestsPerMinute, server.RateLimit.Burst, server.RateLimit.Routes = 0, 0, nil
+		server.Usage.ExceededStatus, server.Usage.Quotas = 0, nil
+		server.Features = nil
+		server.EnableAllowAllCORS, server.CORSAllowedOrigins = false, nil
+	}
+	if !reflect.DeepEqual(currentServer, updatedServer) {
+		sections = append(sections, "server")
+	}
+	return sections
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const AssetParam = "asset"
+
+// AdminUIRouter serves the admin web UI, a page that calls the API with the admin credential operators sign in with.
+type AdminUIRouter struct {
+	files  fs.FS
+	apiURL string
+}
+
+// NewAdminUIRouter serves the UI from files. apiURL is where the UI calls the API, which is the origin of the UI
+// itself when empty.
+func NewAdminUIRouter(files fs.FS, apiURL string) *AdminUIRouter {
+	return &AdminUIRouter{files: files, apiURL: strings.TrimSuffix(apiURL, "/")}
+}
+
+// GetAdminUIConfigResponse tells the UI where to call the API.
+type GetAdminUIConfigResponse struct {
+	APIURL string `json:"apiUrl"`
+}
+
+// GetAsset serves the file of the UI named by the asset param, index.html by default, and its config as config.json.
+// The UI holds no data of its own, so it's served without credentials; everything it shows is read from the API with
+// the operator's. It may only be framed, and only call, what it's served with.
+func (ur AdminUIRouter) GetAsset(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param(AssetParam), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	connectSrc := "'self'"
+	if ur.apiURL != "" {
+		connectSrc += " " + ur.apiURL
+	}
+	c.Header("Content-Security-Policy", "default-src 'self'; connect-src "+connectSrc+"; frame-ancestors 'none'; base-uri 'none'; form-action 'none'")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-cache")
+
+	if name == "config.json" {
+		c.JSON(http.StatusOK, GetAdminUIConfigResponse{APIURL: ur.apiURL})
+		return
+	}
+	data, err := fs.ReadFile(ur.files, name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Data(http.StatusOK, mime.TypeByExtension(path.Ext(name)), data)
+}
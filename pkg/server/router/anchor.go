@@ -0,0 +1,156 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/anchor"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type AnchorRouter struct {
+	service *anchor.Service
+}
+
+func NewAnchorRouter(s svcframework.Service) (*AnchorRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	anchorService, ok := s.(*anchor.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create anchor router with service type: %s", s.Type())
+	}
+	return &AnchorRouter{service: anchorService}, nil
+}
+
+type CreateAnchorResponse struct {
+	Anchor anchor.Anchor `json:"anchor"`
+}
+
+// CreateAnchor godoc
+//
+//	@Summary		Create Anchor
+//	@Description	Anchors the state of the anchored namespaces now, rather than waiting for the next scheduled anchor.
+//	@Description	The records of the namespaces of the default tenant and of the configured tenants are hashed, and
+//	@Description	their digest is anchored to the external anchor, whose receipt is kept with the anchor.
+//	@Tags			AnchorAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	CreateAnchorResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/anchors [put]
+func (ar AnchorRouter) CreateAnchor(c *gin.Context) {
+	created, err := ar.service.Anchor(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not anchor state", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, CreateAnchorResponse{Anchor: *created}, http.StatusCreated)
+}
+
+type ListAnchorsResponse struct {
+	// Anchors that are kept, newest first.
+	Anchors []anchor.Anchor `json:"anchors"`
+}
+
+// ListAnchors godoc
+//
+//	@Summary		List Anchors
+//	@Description	Lists the anchors that are kept, newest first.
+//	@Tags			AnchorAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListAnchorsResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/anchors [get]
+func (ar AnchorRouter) ListAnchors(c *gin.Context) {
+	resp, err := ar.service.ListAnchors(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list anchors", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListAnchorsResponse{Anchors: resp.Anchors}, http.StatusOK)
+}
+
+type GetAnchorResponse struct {
+	Anchor anchor.Anchor `json:"anchor"`
+}
+
+// GetAnchor godoc
+//
+//	@Summary		Get Anchor
+//	@Description	Gets an anchor, along with the receipt of the external anchor.
+//	@Tags			AnchorAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetAnchorResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/anchors/{id} [get]
+func (ar AnchorRouter) GetAnchor(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get anchor without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	got, err := ar.service.GetAnchor(c, *id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get anchor with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, anchor.ErrAnchorNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+	framework.Respond(c, GetAnchorResponse{Anchor: *got}, http.StatusOK)
+}
+
+type VerifyAnchorResponse struct {
+	Verification anchor.Verification `json:"verification"`
+}
+
+// VerifyAnchor godoc
+//
+//	@Summary		Verify Anchor
+//	@Description	Verifies that the receipt of an anchor attests to its digest, and that the hashes of the records kept
+//	@Description	with it add up to the digest, then tells which of the anchored records were changed or removed since.
+//	@Description	Receipts of opentimestamps calendars are only checked to cover the digest; their attestation is
+//	@Description	verified against the bitcoin chain with the opentimestamps client.
+//	@Tags			AnchorAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	VerifyAnchorResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/anchors/{id}/verification [get]
+func (ar AnchorRouter) VerifyAnchor(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot verify anchor without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	verification, err := ar.service.Verify(c, *id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not verify anchor with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, anchor.ErrAnchorNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+	framework.Respond(c, VerifyAnchorResponse{Verification: *verification}, http.StatusOK)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/anomaly"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type AnomalyRouter struct {
+	service *anomaly.Service
+}
+
+func NewAnomalyRouter(s svcframework.Service) (*AnomalyRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	anomalyService, ok := s.(*anomaly.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create anomaly router with service type: %s", s.Type())
+	}
+	return &AnomalyRouter{service: anomalyService}, nil
+}
+
+type ListSigningRatesResponse struct {
+	// Signing rates of every key, and every tenant, that signed, ordered by scope, tenant, and key.
+	Rates []anomaly.Rate `json:"rates"`
+}
+
+// ListSigningRates godoc
+//
+//	@Summary		List Signing Rates
+//	@Description	Lists how much every key, and every tenant, signed in the current window, and their baselines, which
+//	@Description	are what spikes are detected against.
+//	@Tags			AnomalyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListSigningRatesResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/signing/rates [get]
+func (ar AnomalyRouter) ListSigningRates(c *gin.Context) {
+	resp, err := ar.service.ListRates(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list signing rates", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListSigningRatesResponse{Rates: resp.Rates}, http.StatusOK)
+}
+
+type ListFreezesResponse struct {
+	// Freezes that haven't ended, oldest first.
+	Freezes []anomaly.Freeze `json:"freezes"`
+}
+
+// ListFreezes godoc
+//
+//	@Summary		List Freezes
+//	@Description	Lists the keys that are frozen because they spiked, and until when. Frozen keys can't sign.
+//	@Tags			AnomalyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListFreezesResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/signing/freezes [get]
+func (ar AnomalyRouter) ListFreezes(c *gin.Context) {
+	resp, err := ar.service.ListFreezes(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list freezes", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListFreezesResponse{Freezes: resp.Freezes}, http.StatusOK)
+}
+
+// LiftFreeze godoc
+//
+//	@Summary		Lift Freeze
+//	@Description	Lifts a freeze before it ends, so that its key can sign again, e.g. once the spike was found to be
+//	@Description	legitimate.
+//	@Tags			AnomalyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/signing/freezes/{id} [delete]
+func (ar AnomalyRouter) LiftFreeze(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot lift freeze without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := ar.service.LiftFreeze(c, *id); err != nil {
+		errMsg := fmt.Sprintf("could not lift freeze with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, anomaly.ErrFreezeNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
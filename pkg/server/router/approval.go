@@ -0,0 +1,181 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/approval"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+const StatusParam = "status"
+
+type ApprovalRouter struct {
+	service *approval.Service
+
+	// principal returns the authenticated caller of a request, who reviews approvals.
+	principal func(c *gin.Context) *auth.Principal
+}
+
+func NewApprovalRouter(s svcframework.Service, principal func(c *gin.Context) *auth.Principal) (*ApprovalRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	approvalService, ok := s.(*approval.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create approval router with service type: %s", s.Type())
+	}
+	if principal == nil {
+		return nil, errors.New("principal cannot be nil")
+	}
+	return &ApprovalRouter{service: approvalService, principal: principal}, nil
+}
+
+type ListApprovalsResponse struct {
+	// Approvals, oldest first.
+	Approvals []approval.Approval `json:"approvals"`
+}
+
+// ListApprovals godoc
+//
+//	@Summary		List Approvals
+//	@Description	Lists the approvals of sensitive operations, oldest first, optionally only those with a status.
+//	@Tags			ApprovalAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			status	query		string	false	"pending, approved, rejected, used, or expired"
+//	@Success		200		{object}	ListApprovalsResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/approvals [get]
+func (ar ApprovalRouter) ListApprovals(c *gin.Context) {
+	var request approval.ListApprovalsRequest
+	if status := framework.GetQueryValue(c, StatusParam); status != nil {
+		request.Status = approval.Status(*status)
+		if !request.Status.IsValid() {
+			errMsg := fmt.Sprintf("unknown approval status: %s", *status)
+			framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := ar.service.ListApprovals(c, request)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list approvals", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListApprovalsResponse{Approvals: resp.Approvals}, http.StatusOK)
+}
+
+type GetApprovalResponse struct {
+	Approval approval.Approval `json:"approval"`
+}
+
+// GetApproval godoc
+//
+//	@Summary		Get Approval
+//	@Description	Gets the approval of a sensitive operation.
+//	@Tags			ApprovalAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetApprovalResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/approvals/{id} [get]
+func (ar ApprovalRouter) GetApproval(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get approval without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	got, err := ar.service.GetApproval(c, *id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get approval with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, approval.ErrApprovalNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+	framework.Respond(c, GetApprovalResponse{Approval: *got}, http.StatusOK)
+}
+
+type ReviewApprovalRequest struct {
+	// Whether the operation is approved, so that its requester can make it, or rejected.
+	Approved bool `json:"approved"`
+
+	// Why the operation is approved or rejected.
+	Reason string `json:"reason"`
+}
+
+// ReviewApproval godoc
+//
+//	@Summary		Review Approval
+//	@Description	Approves, or rejects, a pending approval of a sensitive operation. Approvals can't be reviewed by the
+//	@Description	operator who requested them. Once approved, the requester makes the request again, with the ID of the
+//	@Description	approval in the X-Approval-ID header, and it's handled.
+//	@Tags			ApprovalAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"ID"
+//	@Param			request	body		ReviewApprovalRequest	true	"request body"
+//	@Success		200		{object}	GetApprovalResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		403		{string}	string	"Forbidden"
+//	@Failure		404		{string}	string	"Not found"
+//	@Failure		409		{string}	string	"Conflict"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/approvals/{id}/review [put]
+func (ar ApprovalRouter) ReviewApproval(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot review approval without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	var request ReviewApprovalRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		errMsg := "invalid review approval request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	principal := ar.principal(c)
+	if principal == nil {
+		framework.LoggingRespondErrMsg(c, "missing credentials", http.StatusUnauthorized)
+		return
+	}
+
+	reviewed, err := ar.service.ReviewApproval(c, approval.ReviewApprovalRequest{
+		ID:         *id,
+		ReviewedBy: principal.ID,
+		Approved:   request.Approved,
+		Reason:     request.Reason,
+	})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not review approval with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, approval.ErrApprovalNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, approval.ErrSelfReview):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, approval.ErrNotPending):
+			statusCode = http.StatusConflict
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+	framework.Respond(c, GetApprovalResponse{Approval: *reviewed}, http.StatusOK)
+}
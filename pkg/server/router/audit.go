@@ -0,0 +1,220 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	"github.com/tbd54566975/ssi-service/pkg/service/audit"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+const (
+	ActorParam    = "actor"
+	TenantParam   = "tenant"
+	OutcomeParam  = "outcome"
+	ResourceParam = "resource"
+	SinceParam    = "since"
+	UntilParam    = "until"
+
+	// NDJSONContentType is the content type of exports, which have an event as JSON on each line.
+	NDJSONContentType = "application/x-ndjson"
+)
+
+type AuditRouter struct {
+	service *audit.Service
+}
+
+func NewAuditRouter(s svcframework.Service) (*AuditRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	auditService, ok := s.(*audit.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create audit router with service type: %s", s.Type())
+	}
+	return &AuditRouter{service: auditService}, nil
+}
+
+type ListAuditEventsResponse struct {
+	Events []audit.Event `json:"events"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListAuditEvents godoc
+//
+//	@Summary		List Audit Events
+//	@Description	Lists the events of the audit log, which records every call that changes something, oldest first.
+//	@Tags			AuditAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			actor		query		string	false	"Only the calls of this API key ID or token subject"
+//	@Param			tenant		query		string	false	"Only the calls made in this tenant"
+//	@Param			outcome		query		string	false	"Only the calls with this outcome, one of succeeded, accepted, denied, or failed"
+//	@Param			resource	query		string	false	"Only the calls on this resource, or resources under it, e.g. /v1/credentials"
+//	@Param			since		query		string	false	"Only the calls made at or after this RFC 3339 time"
+//	@Param			until		query		string	false	"Only the calls made before this RFC 3339 time"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListAuditEventsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of audit events across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/audit [get]
+func (ar AuditRouter) ListAuditEvents(c *gin.Context) {
+	request, err := listAuditEventsRequest(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid list audit events request", http.StatusBadRequest)
+		return
+	}
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	gotEvents, err := ar.service.ListEvents(c, *request)
+	if err != nil {
+		errMsg := "could not list audit events"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListAuditEventsResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotEvents.Events, func(e audit.Event) string { return e.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Events = page
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// ExportAuditEvents godoc
+//
+//	@Summary		Export Audit Events
+//	@Description	Exports the events of the audit log as newline delimited JSON, with an event on each line. Events are
+//	@Description	streamed as they're read, so an export may hold every event. They're in the order of their IDs for
+//	@Description	storage that keeps keys sorted, and should be sorted by ID otherwise.
+//	@Tags			AuditAPI
+//	@Accept			json
+//	@Produce		application/x-ndjson
+//	@Param			actor		query		string	false	"Only the calls of this API key ID or token subject"
+//	@Param			tenant		query		string	false	"Only the calls made in this tenant"
+//	@Param			outcome		query		string	false	"Only the calls with this outcome, one of succeeded, accepted, denied, or failed"
+//	@Param			resource	query		string	false	"Only the calls on this resource, or resources under it, e.g. /v1/credentials"
+//	@Param			since		query		string	false	"Only the calls made at or after this RFC 3339 time"
+//	@Param			until		query		string	false	"Only the calls made before this RFC 3339 time"
+//	@Success		200			{string}	string	"Events, one per line"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Router			/admin/audit/export [get]
+func (ar AuditRouter) ExportAuditEvents(c *gin.Context) {
+	request, err := listAuditEventsRequest(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid export audit events request", http.StatusBadRequest)
+		return
+	}
+
+	c.Header("Content-Type", NDJSONContentType)
+	c.Header("Content-Disposition", `attachment; filename="audit.ndjson"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	err = ar.service.ExportEvents(c, *request, func(event audit.Event) error {
+		return encoder.Encode(event)
+	})
+	// the status was sent with the first event, so a failed export can only be cut short
+	if err != nil {
+		logrus.WithContext(c).WithError(err).Error("could not export audit events")
+	}
+}
+
+type VerifyAuditEventsResponse struct {
+	// Whether the hash chain of the log is unbroken, and the signatures of its events are valid.
+	Verified bool `json:"verified"`
+
+	// Why the log failed verification, and the ID of the first event that failed it, when it did.
+	Reason  string `json:"reason,omitempty"`
+	EventID string `json:"eventId,omitempty"`
+
+	// How many chained events were verified, and how many of them aren't signed.
+	Events   int `json:"events"`
+	Unsigned int `json:"unsigned"`
+
+	// The last event of the chain. Auditors keep it, to check that a later verification finds the chain to still extend
+	// it, since events removed from the end of the log along with its head can't be detected otherwise.
+	Head audit.ChainHead `json:"head"`
+
+	// DID of the key the events are signed by.
+	LogID string `json:"logId,omitempty"`
+}
+
+// VerifyAuditEvents godoc
+//
+//	@Summary		Verify Audit Events
+//	@Description	Verifies that the hash chain of the audit log is unbroken, from its first event to its head, and that
+//	@Description	the signatures of its events are valid. A log that fails verification is still a 200 response, whose
+//	@Description	reason tells why it failed.
+//	@Tags			AuditAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	VerifyAuditEventsResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/audit/verification [get]
+func (ar AuditRouter) VerifyAuditEvents(c *gin.Context) {
+	verified, err := ar.service.VerifyEvents(c)
+	if err != nil {
+		errMsg := "could not verify audit events"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	resp := VerifyAuditEventsResponse{
+		Verified: verified.Verified,
+		Reason:   verified.Reason,
+		EventID:  verified.EventID,
+		Events:   verified.Events,
+		Unsigned: verified.Unsigned,
+		Head:     verified.Head,
+		LogID:    verified.LogID,
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// listAuditEventsRequest reads the filters of the events to list from the query.
+func listAuditEventsRequest(c *gin.Context) (*audit.ListEventsRequest, error) {
+	var request audit.ListEventsRequest
+	if actor := framework.GetQueryValue(c, ActorParam); actor != nil {
+		request.Actor = *actor
+	}
+	if tenant := framework.GetQueryValue(c, TenantParam); tenant != nil {
+		request.Tenant = *tenant
+	}
+	if outcome := framework.GetQueryValue(c, OutcomeParam); outcome != nil {
+		request.Outcome = audit.Outcome(*outcome)
+		switch request.Outcome {
+		case audit.OutcomeSucceeded, audit.OutcomeAccepted, audit.OutcomeDenied, audit.OutcomeFailed:
+		default:
+			return nil, errors.Errorf("unknown outcome: %s", *outcome)
+		}
+	}
+	if resource := framework.GetQueryValue(c, ResourceParam); resource != nil {
+		request.Resource = *resource
+	}
+	for param, t := range map[string]*time.Time{SinceParam: &request.Since, UntilParam: &request.Until} {
+		value := framework.GetQueryValue(c, param)
+		if value == nil {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, *value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", param)
+		}
+		*t = parsed
+	}
+	return &request, nil
+}
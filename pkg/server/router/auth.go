@@ -0,0 +1,653 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type AuthRouter struct {
+	service *auth.Service
+}
+
+func NewAuthRouter(s svcframework.Service) (*AuthRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	authService, ok := s.(*auth.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create auth router with service type: %s", s.Type())
+	}
+	return &AuthRouter{service: authService}, nil
+}
+
+type CreateAPIKeyRequest struct {
+	// Human-readable name that describes who or what uses the key.
+	Name string `json:"name" validate:"required"`
+
+	// Whether the key can call admin endpoints, such as the ones used to manage API keys.
+	Admin bool `json:"admin"`
+
+	// Tenant whose data the key can access. When empty, the key accesses the default tenant.
+	TenantID string `json:"tenantId,omitempty"`
+
+	// Scopes the key is restricted to, e.g. credentials:issue and dids:read. When empty, the key isn't restricted by
+	// scopes.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey auth.APIKey `json:"apiKey"`
+
+	// The raw key that must be sent in the X-API-Key header. It is only returned once and cannot be recovered.
+	Key string `json:"key"`
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create API Key
+//	@Description	Creates an API key. The raw key is only included in this response.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateAPIKeyRequest	true	"request body"
+//	@Success		201		{object}	CreateAPIKeyResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		401		{string}	string	"Unauthorized"
+//	@Failure		403		{string}	string	"Forbidden"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/apikeys [put]
+func (ar AuthRouter) CreateAPIKey(c *gin.Context) {
+	var request CreateAPIKeyRequest
+	invalidCreateAPIKeyRequest := "invalid create api key request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateAPIKeyRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateAPIKeyRequest, http.StatusBadRequest)
+		return
+	}
+
+	createAPIKeyResponse, err := ar.service.CreateAPIKey(c, auth.CreateAPIKeyRequest{Name: request.Name, Admin: request.Admin, TenantID: request.TenantID, Scopes: request.Scopes})
+	if err != nil {
+		errMsg := "could not create api key"
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrInvalidTenant) || errors.Is(err, auth.ErrInvalidScope) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	resp := CreateAPIKeyResponse{APIKey: createAPIKeyResponse.APIKey, Key: createAPIKeyResponse.Key}
+	framework.Respond(c, resp, http.StatusCreated)
+}
+
+type GetAPIKeyResponse struct {
+	APIKey auth.APIKey `json:"apiKey"`
+}
+
+// GetAPIKey godoc
+//
+//	@Summary		Get API Key
+//	@Description	Get an API key by its ID. The raw key is never returned.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetAPIKeyResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/admin/apikeys/{id} [get]
+func (ar AuthRouter) GetAPIKey(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get api key without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	gotAPIKey, err := ar.service.GetAPIKey(c, auth.GetAPIKeyRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get api key with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, GetAPIKeyResponse{APIKey: gotAPIKey.APIKey}, http.StatusOK)
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []auth.APIKey `json:"apiKeys"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API Keys
+//	@Description	Lists all API keys, including revoked ones
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListAPIKeysResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of API keys across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/apikeys [get]
+func (ar AuthRouter) ListAPIKeys(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	gotAPIKeys, err := ar.service.ListAPIKeys(c)
+	if err != nil {
+		errMsg := "could not list api keys"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListAPIKeysResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotAPIKeys.APIKeys, func(k auth.APIKey) string { return k.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.APIKeys = page
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		Revoke API Key
+//	@Description	Revokes an API key by its ID. Revoked keys are rejected by the API but remain listed.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/apikeys/{id} [delete]
+func (ar AuthRouter) RevokeAPIKey(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot revoke api key without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := ar.service.RevokeAPIKey(c, auth.RevokeAPIKeyRequest{ID: *id}); err != nil {
+		errMsg := fmt.Sprintf("could not revoke api key with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
+
+type CreateRoleRequest struct {
+	// Name that the role is referenced by in role bindings.
+	Name string `json:"name" validate:"required"`
+
+	// Operations the role may perform, optionally limited to resources the caller created.
+	Permissions []auth.Permission `json:"permissions" validate:"required,dive"`
+}
+
+type RoleResponse struct {
+	Role auth.Role `json:"role"`
+}
+
+// CreateRole godoc
+//
+//	@Summary		Create Role
+//	@Description	Creates a role, or replaces the role with the same name.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateRoleRequest	true	"request body"
+//	@Success		201		{object}	RoleResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/roles [put]
+func (ar AuthRouter) CreateRole(c *gin.Context) {
+	var request CreateRoleRequest
+	invalidCreateRoleRequest := "invalid create role request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateRoleRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateRoleRequest, http.StatusBadRequest)
+		return
+	}
+
+	role, err := ar.service.CreateRole(c, auth.CreateRoleRequest{Role: auth.Role{Name: request.Name, Permissions: request.Permissions}})
+	if err != nil {
+		errMsg := "could not create role"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	framework.Respond(c, RoleResponse{Role: *role}, http.StatusCreated)
+}
+
+// GetRole godoc
+//
+//	@Summary		Get Role
+//	@Description	Get a role by its name
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Name"
+//	@Success		200	{object}	RoleResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/admin/roles/{id} [get]
+func (ar AuthRouter) GetRole(c *gin.Context) {
+	name := framework.GetParam(c, IDParam)
+	if name == nil {
+		errMsg := "cannot get role without name parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	role, err := ar.service.GetRole(c, auth.GetRoleRequest{Name: *name})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get role: %s", *name)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrRoleNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, RoleResponse{Role: *role}, http.StatusOK)
+}
+
+type ListRolesResponse struct {
+	Roles []auth.Role `json:"roles"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListRoles godoc
+//
+//	@Summary		List Roles
+//	@Description	Lists all roles
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListRolesResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of roles across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/roles [get]
+func (ar AuthRouter) ListRoles(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	roles, err := ar.service.ListRoles(c)
+	if err != nil {
+		errMsg := "could not list roles"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListRolesResponse
+	page, failed := pagination.Paginate(c, pageRequest, roles.Roles, func(r auth.Role) string { return r.Name }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Roles = page
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// DeleteRole godoc
+//
+//	@Summary		Delete Role
+//	@Description	Deletes a role by its name. Role bindings that reference the role no longer grant its permissions.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Name"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/roles/{id} [delete]
+func (ar AuthRouter) DeleteRole(c *gin.Context) {
+	name := framework.GetParam(c, IDParam)
+	if name == nil {
+		errMsg := "cannot delete role without name parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := ar.service.DeleteRole(c, auth.DeleteRoleRequest{Name: *name}); err != nil {
+		errMsg := fmt.Sprintf("could not delete role: %s", *name)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
+
+type SetRoleBindingRequest struct {
+	// ID of an API key, or the `sub` claim of OAuth2 access tokens.
+	Subject string `json:"subject" validate:"required"`
+
+	// Names of the roles assigned to the subject. Replaces any previously assigned roles.
+	Roles []string `json:"roles" validate:"required"`
+}
+
+type RoleBindingResponse struct {
+	RoleBinding auth.RoleBinding `json:"roleBinding"`
+}
+
+// SetRoleBinding godoc
+//
+//	@Summary		Set Role Binding
+//	@Description	Assigns roles to an API key or token subject. Subjects with roles bound are limited to what the roles
+//	@Description	and, for tokens, scopes grant.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		SetRoleBindingRequest	true	"request body"
+//	@Success		201		{object}	RoleBindingResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/rolebindings [put]
+func (ar AuthRouter) SetRoleBinding(c *gin.Context) {
+	var request SetRoleBindingRequest
+	invalidRoleBindingRequest := "invalid set role binding request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRoleBindingRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRoleBindingRequest, http.StatusBadRequest)
+		return
+	}
+
+	binding, err := ar.service.SetRoleBinding(c, auth.SetRoleBindingRequest{RoleBinding: auth.RoleBinding{Subject: request.Subject, Roles: request.Roles}})
+	if err != nil {
+		errMsg := "could not set role binding"
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrRoleNotFound) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, RoleBindingResponse{RoleBinding: *binding}, http.StatusCreated)
+}
+
+// GetRoleBinding godoc
+//
+//	@Summary		Get Role Binding
+//	@Description	Get the roles assigned to an API key or token subject
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Subject"
+//	@Success		200	{object}	RoleBindingResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/admin/rolebindings/{id} [get]
+func (ar AuthRouter) GetRoleBinding(c *gin.Context) {
+	subject := framework.GetParam(c, IDParam)
+	if subject == nil {
+		errMsg := "cannot get role binding without subject parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	binding, err := ar.service.GetRoleBinding(c, auth.GetRoleBindingRequest{Subject: *subject})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get role binding: %s", *subject)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrRoleBindingNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, RoleBindingResponse{RoleBinding: *binding}, http.StatusOK)
+}
+
+// DeleteRoleBinding godoc
+//
+//	@Summary		Delete Role Binding
+//	@Description	Removes all roles assigned to an API key or token subject
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Subject"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/rolebindings/{id} [delete]
+func (ar AuthRouter) DeleteRoleBinding(c *gin.Context) {
+	subject := framework.GetParam(c, IDParam)
+	if subject == nil {
+		errMsg := "cannot delete role binding without subject parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := ar.service.DeleteRoleBinding(c, auth.DeleteRoleBindingRequest{Subject: *subject}); err != nil {
+		errMsg := fmt.Sprintf("could not delete role binding: %s", *subject)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
+
+type RegisterClientKeyRequest struct {
+	// Human-readable name that describes who signs with the key.
+	Name string `json:"name" validate:"required"`
+
+	// Subject that must have authenticated the requests signed with the key, which is the ID of an API key or the
+	// `sub` claim of an access token. When empty, the key may sign the requests of any caller.
+	Subject string `json:"subject,omitempty"`
+
+	// Algorithm the key signs with, one of ed25519, ecdsa-p256-sha256, ecdsa-p384-sha384, rsa-pss-sha512, or
+	// rsa-v1_5-sha256. Defaults to the algorithm of the key type, with rsa-pss-sha512 for RSA keys.
+	Algorithm string `json:"alg,omitempty"`
+
+	// Public key that verifies the signatures made with the key.
+	PublicKeyJWK jwx.PublicKeyJWK `json:"publicKeyJwk" validate:"required"`
+}
+
+type ClientKeyResponse struct {
+	ClientKey auth.ClientKey `json:"clientKey"`
+}
+
+// RegisterClientKey godoc
+//
+//	@Summary		Register Client Key
+//	@Description	Registers a public key that a client signs requests with, using HTTP Message Signatures. Signatures
+//	@Description	name the key by the ID in the response.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		RegisterClientKeyRequest	true	"request body"
+//	@Success		201		{object}	ClientKeyResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		401		{string}	string	"Unauthorized"
+//	@Failure		403		{string}	string	"Forbidden"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/clientkeys [put]
+func (ar AuthRouter) RegisterClientKey(c *gin.Context) {
+	var request RegisterClientKeyRequest
+	invalidRegisterClientKeyRequest := "invalid register client key request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRegisterClientKeyRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRegisterClientKeyRequest, http.StatusBadRequest)
+		return
+	}
+
+	key, err := ar.service.RegisterClientKey(c, auth.RegisterClientKeyRequest{
+		Name:         request.Name,
+		Subject:      request.Subject,
+		Algorithm:    request.Algorithm,
+		PublicKeyJWK: request.PublicKeyJWK,
+	})
+	if err != nil {
+		errMsg := "could not register client key"
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrInvalidClientKey) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, ClientKeyResponse{ClientKey: *key}, http.StatusCreated)
+}
+
+// GetClientKey godoc
+//
+//	@Summary		Get Client Key
+//	@Description	Get a client key by its ID
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	ClientKeyResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/admin/clientkeys/{id} [get]
+func (ar AuthRouter) GetClientKey(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get client key without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	key, err := ar.service.GetClientKey(c, auth.GetClientKeyRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get client key with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrClientKeyNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, ClientKeyResponse{ClientKey: *key}, http.StatusOK)
+}
+
+type ListClientKeysResponse struct {
+	ClientKeys []auth.ClientKey `json:"clientKeys"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListClientKeys godoc
+//
+//	@Summary		List Client Keys
+//	@Description	Lists all client keys, including revoked ones
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListClientKeysResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of client keys across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/clientkeys [get]
+func (ar AuthRouter) ListClientKeys(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	gotClientKeys, err := ar.service.ListClientKeys(c)
+	if err != nil {
+		errMsg := "could not list client keys"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListClientKeysResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotClientKeys.ClientKeys, func(k auth.ClientKey) string { return k.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.ClientKeys = page
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// RevokeClientKey godoc
+//
+//	@Summary		Revoke Client Key
+//	@Description	Revokes a client key by its ID. Signatures made with revoked keys are rejected, but the keys remain
+//	@Description	listed.
+//	@Tags			AuthAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/clientkeys/{id} [delete]
+func (ar AuthRouter) RevokeClientKey(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot revoke client key without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := ar.service.RevokeClientKey(c, auth.RevokeClientKeyRequest{ID: *id}); err != nil {
+		errMsg := fmt.Sprintf("could not revoke client key with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrClientKeyNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
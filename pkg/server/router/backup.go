@@ -0,0 +1,179 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/backup"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+const NameParam = "name"
+
+type BackupRouter struct {
+	service *backup.Service
+}
+
+func NewBackupRouter(s svcframework.Service) (*BackupRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	backupService, ok := s.(*backup.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create backup router with service type: %s", s.Type())
+	}
+	return &BackupRouter{service: backupService}, nil
+}
+
+type CreateBackupResponse struct {
+	Backup backup.Record `json:"backup"`
+}
+
+// CreateBackup godoc
+//
+//	@Summary		Create Backup
+//	@Description	Backs up the keys, with their private keys, and the DIDs of the default tenant and of the configured
+//	@Description	tenants now, rather than waiting for the next scheduled backup. The backup is encrypted under the
+//	@Description	configured public keyset and written to the destination.
+//	@Tags			BackupAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	CreateBackupResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/backups [put]
+func (br BackupRouter) CreateBackup(c *gin.Context) {
+	record, err := br.service.CreateBackup(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create backup", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, CreateBackupResponse{Backup: *record}, http.StatusCreated)
+}
+
+type ListBackupsResponse struct {
+	// Names of the backups at the destination, newest first.
+	Names []string `json:"names"`
+}
+
+// ListBackups godoc
+//
+//	@Summary		List Backups
+//	@Description	Lists the names of the backups at the destination, newest first.
+//	@Tags			BackupAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListBackupsResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/backups [get]
+func (br BackupRouter) ListBackups(c *gin.Context) {
+	backups, err := br.service.ListBackups(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list backups", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListBackupsResponse{Names: backups.Names}, http.StatusOK)
+}
+
+// GetBackup godoc
+//
+//	@Summary		Get Backup
+//	@Description	Downloads a backup as it's written to the destination, encrypted. It's decrypted offline with the
+//	@Description	private keyset, e.g. by `ssi admin backup restore`.
+//	@Tags			BackupAPI
+//	@Accept			json
+//	@Produce		application/octet-stream
+//	@Param			name	path		string	true	"Name of the backup"
+//	@Success		200		{string}	string	"Encrypted backup"
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		404		{string}	string	"Not found"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/backups/{name} [get]
+func (br BackupRouter) GetBackup(c *gin.Context) {
+	name := framework.GetParam(c, NameParam)
+	if name == nil {
+		errMsg := "cannot get backup without name parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	encrypted, err := br.service.GetBackup(c, *name)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get backup: %s", *name)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, backup.ErrBackupNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", *name))
+	c.Data(http.StatusOK, "application/octet-stream", encrypted)
+}
+
+// RestoreBackupRequest is a backup, decrypted.
+type RestoreBackupRequest struct {
+	Version   int             `json:"version" validate:"required"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Tenants   []backup.Tenant `json:"tenants" validate:"required"`
+}
+
+type RestoreBackupResponse struct {
+	backup.RestoreResponse
+}
+
+// RestoreBackup godoc
+//
+//	@Summary		Restore Backup
+//	@Description	Restores the keys and DIDs of a backup that was decrypted offline with the private keyset, to the
+//	@Description	tenants they were backed up from. Keys and DIDs that are stored already are kept as they are, so that
+//	@Description	keys revoked since the backup stay revoked, and a backup can be restored more than once. A dry run
+//	@Description	restores nothing, and counts the keys and DIDs that would be restored, and skipped.
+//	@Tags			BackupAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		RestoreBackupRequest	true	"Decrypted backup"
+//	@Param			dryRun	query		bool					false	"Count what would be restored, without restoring anything"
+//	@Success		200		{object}	RestoreBackupResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/backups/restore [put]
+func (br BackupRouter) RestoreBackup(c *gin.Context) {
+	var request RestoreBackupRequest
+	invalidRestoreBackupRequest := "invalid restore backup request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRestoreBackupRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRestoreBackupRequest, http.StatusBadRequest)
+		return
+	}
+	if request.Version != backup.Version {
+		errMsg := fmt.Sprintf("backup version %d is not supported", request.Version)
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidRestoreBackupRequest, http.StatusBadRequest)
+		return
+	}
+
+	restore := br.service.Restore
+	if dryRun {
+		restore = br.service.DryRunRestore
+	}
+	resp, err := restore(c, backup.Backup{Version: request.Version, CreatedAt: request.CreatedAt, Tenants: request.Tenants})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not restore backup", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, RestoreBackupResponse{RestoreResponse: *resp}, http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"github.com/goccy/go-json"
+)
+
+type BatchRequest struct {
+	// The operations to run, in order. Each runs as its own request, authenticated and authorized as the caller. Cannot
+	// be more than 100 operations.
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+
+	// When true, the batch stops at the first operation that fails, and the operations that succeeded before it are
+	// undone, in reverse order. Only operations that can be undone may be part of an atomic batch.
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+type BatchOperation struct {
+	// Names the operation, so that later operations can reference its response. Must be unique within the batch.
+	ID string `json:"id,omitempty"`
+
+	// HTTP method of the request. One of GET, PUT, POST, or DELETE.
+	Method string `json:"method" validate:"required,oneof=GET PUT POST DELETE"`
+
+	// Path of the request, including its query, under the same version of the API as the batch. E.g. `/v1/dids/key`.
+	// References like `${create-did:$.did.id}` are replaced with the value at a JSONPath in the response of an earlier
+	// operation.
+	Path string `json:"path" validate:"required"`
+
+	// Body of the request. References in its strings are replaced the same way as in the path. A string that is a
+	// single reference is replaced with the referenced value, keeping its JSON type.
+	Body json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	// Whether every operation succeeded. When an atomic batch fails, the operations that succeeded have been undone.
+	Succeeded bool `json:"succeeded"`
+
+	// The results of the operations, in the order of the request. Operations of an atomic batch that weren't run
+	// because an earlier one failed have no result.
+	Results []BatchResult `json:"results"`
+}
+
+type BatchResult struct {
+	// ID of the operation, when it has one.
+	ID string `json:"id,omitempty"`
+
+	// HTTP status of the response.
+	Status int `json:"status"`
+
+	// Body of the response.
+	Body json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+
+	// Whether the operation was undone because a later operation of an atomic batch failed.
+	Undone bool `json:"undone,omitempty"`
+
+	// Why undoing the operation failed, leaving what it did in place.
+	UndoError string `json:"undoError,omitempty"`
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/challenge"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type ChallengeRouter struct {
+	service *challenge.Service
+}
+
+func NewChallengeRouter(s svcframework.Service) (*ChallengeRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	challengeService, ok := s.(*challenge.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create challenge router with service type: %s", s.Type())
+	}
+	return &ChallengeRouter{service: challengeService}, nil
+}
+
+type CreateChallengeRequest struct {
+	// What the challenge is bound to, either a presentation definition or a credential manifest.
+	Target challenge.Target `json:"target" validate:"required,oneof=definition manifest"`
+
+	// ID of the presentation definition, or credential manifest, the challenge is bound to.
+	TargetID string `json:"targetId" validate:"required"`
+}
+
+type CreateChallengeResponse struct {
+	Challenge challenge.Challenge `json:"challenge"`
+}
+
+// CreateChallenge godoc
+//
+//	@Summary		Create challenge
+//	@Description	Issues a challenge, a single-use nonce bound to a presentation definition or a credential manifest,
+//	@Description	which the presentation submission or credential application JWT responding to it carries as its
+//	@Description	nonce claim. It can be responded to once, until it expires.
+//	@Tags			ChallengeAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateChallengeRequest	true	"request body"
+//	@Success		201		{object}	CreateChallengeResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/challenges [put]
+func (cr ChallengeRouter) CreateChallenge(c *gin.Context) {
+	var request CreateChallengeRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create challenge request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := cr.service.CreateChallenge(c, challenge.CreateChallengeRequest{
+		Target:   request.Target,
+		TargetID: request.TargetID,
+	})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create challenge", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, CreateChallengeResponse{Challenge: *resp}, http.StatusCreated)
+}
+
+type GetChallengeResponse struct {
+	Challenge challenge.Challenge `json:"challenge"`
+}
+
+// GetChallenge godoc
+//
+//	@Summary		Get challenge
+//	@Description	Gets a challenge by its nonce, which tells whether it was responded to.
+//	@Tags			ChallengeAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Nonce"
+//	@Success		200	{object}	GetChallengeResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/v1/challenges/{id} [get]
+func (cr ChallengeRouter) GetChallenge(c *gin.Context) {
+	nonce := framework.GetParam(c, IDParam)
+	if nonce == nil {
+		framework.LoggingRespondErrMsg(c, "cannot get challenge without ID parameter", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := cr.service.GetChallenge(c, challenge.GetChallengeRequest{Nonce: *nonce})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get challenge", http.StatusNotFound)
+		return
+	}
+	framework.Respond(c, GetChallengeResponse{Challenge: *resp}, http.StatusOK)
+}
@@ -0,0 +1,60 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+// ErrNoConfigToReload is returned by reloaders that have no config to reload, like servers embedded in programs that
+// configure them in code.
+var ErrNoConfigToReload = errors.New("the server has no config to reload")
+
+type ReloadConfigResponse struct {
+	// Sections of the config, like services, whose changes require a restart. Their changes were ignored.
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// ReloadConfig reloads the config of the server with reload, which returns the sections of the config whose changes
+// require a restart.
+func ReloadConfig(reload func() ([]string, error)) gin.HandlerFunc {
+	return configRouter{reload: reload}.ReloadConfig
+}
+
+type configRouter struct {
+	reload func() ([]string, error)
+}
+
+// ReloadConfig godoc
+//
+//	@Summary		Reload Config
+//	@Description	Loads the config file of the service again, and applies the settings that can change while it runs:
+//	@Description	the log level, format, and sampling, the rate limits, the usage quotas, the feature flags, the CORS
+//	@Description	origins, the webhook timeout, the universal resolver, the injected faults, and the TLS certificates.
+//	@Description	Changes to other settings require a restart, and are ignored. Sending SIGHUP to the service does the
+//	@Description	same. When the config is invalid, the previous one stays in use.
+//	@Tags			ConfigAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ReloadConfigResponse
+//	@Failure		409	{string}	string	"The service has no config file to reload"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/config/reload [put]
+func (cr configRouter) ReloadConfig(c *gin.Context) {
+	restartRequired, err := cr.reload()
+	if err != nil {
+		if errors.Is(err, ErrNoConfigToReload) {
+			framework.LoggingRespondErrMsg(c, err.Error(), http.StatusConflict)
+			return
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not reload config", http.StatusInternalServerError)
+		return
+	}
+	if restartRequired == nil {
+		restartRequired = []string{}
+	}
+	framework.Respond(c, ReloadConfigResponse{RestartRequired: restartRequired}, http.StatusOK)
+}
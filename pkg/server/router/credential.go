@@ -3,8 +3,10 @@ package router
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	"github.com/TBD54566975/ssi-sdk/did"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -12,6 +14,7 @@ import (
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	"github.com/tbd54566975/ssi-service/pkg/service/credential"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 )
@@ -20,6 +23,18 @@ const (
 	IssuerParam  string = "issuer"
 	SubjectParam string = "subject"
 	SchemaParam  string = "schema"
+	ViewParam    string = "view"
+)
+
+// CredentialView selects how much of each credential is included in lists of credentials.
+type CredentialView string
+
+const (
+	// CredentialViewFull includes each credential in full, along with its JWT. This is the default.
+	CredentialViewFull CredentialView = "FULL"
+	// CredentialViewBasic leaves out the credential and its JWT, keeping the ID, verification method, and status of
+	// each credential.
+	CredentialViewBasic CredentialView = "BASIC"
 )
 
 type CredentialRouter struct {
@@ -63,7 +78,9 @@ type BatchCreateCredentialsResponse struct {
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		BatchCreateCredentialsRequest	true	"The batch requests"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
 //	@Success		201		{object}	BatchCreateCredentialsResponse
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
 //	@Failure		400		{string}	string	"Bad request"
 //	@Failure		500		{string}	string	"Internal server error"
 //	@Router			/v1/credentials/batch [put]
@@ -96,6 +113,88 @@ func (cr CredentialRouter) BatchCreateCredentials(c *gin.Context) {
 	framework.Respond(c, resp, http.StatusCreated)
 }
 
+type BatchCreateCredentialsIndependentlyRequest struct {
+	// Required. The list of create credential requests. Cannot be more than {{.Services.CredentialConfig.BatchCreateMaxItems}} items.
+	// Requests that are invalid fail on their own.
+	Requests []CreateCredentialRequest `json:"requests" maxItems:"1000" validate:"required"`
+}
+
+type BatchCreateCredentialResultsResponse struct {
+	// The result of each request, in the order of the requests.
+	Results []BatchCreateCredentialResult `json:"results"`
+}
+
+type BatchCreateCredentialResult struct {
+	// The credential created for the request, when it could be created.
+	Credential *credmodel.Container `json:"credential,omitempty"`
+
+	// Why the credential couldn't be created, when it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchCreateCredentialsIndependently godoc
+//
+//	@Summary		Batch Create Credentials Independently
+//	@Description	Create a batch of verifiable credentials concurrently, each independently of the others. Unlike the
+//	@Description	PUT of this path, which creates all the credentials or none, the credentials that can be created are
+//	@Description	created, and the response holds the result of each request, in the order of the requests.
+//	@Tags			CredentialAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		BatchCreateCredentialsIndependentlyRequest	true	"The batch requests"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
+//	@Success		200		{object}	BatchCreateCredentialResultsResponse
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/credentials/batch [post]
+func (cr CredentialRouter) BatchCreateCredentialsIndependently(c *gin.Context) {
+	invalidCreateCredentialRequest := "invalid batch create credential request"
+	var batchRequest BatchCreateCredentialsIndependentlyRequest
+	if err := framework.Decode(c.Request, &batchRequest); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateCredentialRequest, http.StatusBadRequest)
+		return
+	}
+	if len(batchRequest.Requests) == 0 {
+		framework.LoggingRespondErrMsg(c, invalidCreateCredentialRequest+": requests cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	batchCreateMaxItems := cr.service.Config().BatchCreateMaxItems
+	if len(batchRequest.Requests) > batchCreateMaxItems {
+		framework.LoggingRespondErrMsg(c, fmt.Sprintf("max number of requests is %d", batchCreateMaxItems), http.StatusBadRequest)
+		return
+	}
+
+	// invalid requests fail on their own, and the valid ones are created
+	resp := BatchCreateCredentialResultsResponse{Results: make([]BatchCreateCredentialResult, len(batchRequest.Requests))}
+	var req credential.BatchCreateCredentialsRequest
+	var indices []int
+	for i, request := range batchRequest.Requests {
+		if err := framework.ValidateRequest(request); err != nil {
+			resp.Results[i].Error = errors.Wrap(err, "invalid create credential request").Error()
+			continue
+		}
+		req.Requests = append(req.Requests, request.toServiceRequest())
+		indices = append(indices, i)
+	}
+
+	batchCreateCredentialsResponse, err := cr.service.BatchCreateCredentialsIndependently(c, req)
+	if err != nil {
+		errMsg := "could not create credentials"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	for i, result := range batchCreateCredentialsResponse.Results {
+		if result.Error != nil {
+			resp.Results[indices[i]].Error = errors.Wrap(result.Error, "could not create credential").Error()
+			continue
+		}
+		resp.Results[indices[i]].Credential = result.Credential
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
 type CreateCredentialRequest struct {
 	// The issuer id.
 	Issuer string `json:"issuer" validate:"required" example:"did:key:z6MkkZDjunoN4gyPMx5TSy7Mfzw22D2RZQZUcx46bii53Ex3"`
@@ -130,7 +229,16 @@ type CreateCredentialRequest struct {
 
 	// Optional. Corresponds to `evidence` in https://www.w3.org/TR/vc-data-model-2.0/#evidence
 	Evidence []any `json:"evidence" example:"[{\"id\":\"https://example.edu/evidence/f2aeec97-fc0d-42bf-8ca7-0548192d4231\",\"type\":[\"DocumentVerification\"]}]"`
-	// TODO(gabe) support more capabilities like signature type, format, and more.
+
+	// Optional. How the credential is secured: `jwt`, the default, as a VC-JWT, `ldp`, with a Data Integrity proof, or
+	// `vc+sd-jwt`, as an SD-JWT VC whose claims holders disclose one by one. `ldp` requires contexts that define every
+	// claim, and an Ed25519 key, which makes an Ed25519Signature2020 proof, or a BLS12381G2 key, which makes a
+	// BbsBlsSignature2020 proof that holders can derive proofs from.
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=jwt ldp vc+sd-jwt" example:"jwt"`
+
+	// Optional, and only for the `vc+sd-jwt` format. Public key of the holder the credential is bound to, which must
+	// sign a key binding whenever the credential is presented.
+	HolderKey *jwx.PublicKeyJWK `json:"holderKey,omitempty"`
 }
 
 func (c CreateCredentialRequest) toServiceRequest() credential.CreateCredentialRequest {
@@ -146,17 +254,29 @@ func (c CreateCredentialRequest) toServiceRequest() credential.CreateCredentialR
 		Revocable:                          c.Revocable,
 		Suspendable:                        c.Suspendable,
 		Evidence:                           c.Evidence,
+		Format:                             c.Format,
+		HolderKey:                          c.HolderKey,
 	}
 }
 
 type CreateCredentialResponse struct {
 	credmodel.Container
+
+	// How the credential doesn't conform to its schema, when it was issued anyway because schema validation is
+	// advisory.
+	SchemaViolations []credential.SchemaViolation `json:"schemaViolations,omitempty"`
 }
 
 // CreateCredential godoc
 //
 //	@Summary		Create Credential
-//	@Description	Create a verifiable credential
+//	@Description	Create a verifiable credential. When it has a schema, the credential is validated against the JSON Schema
+//	@Description	of the schema before it's signed. With strict schema validation, the default, credentials that don't
+//	@Description	conform are rejected with the fields that fail. With advisory schema validation, they're issued, and
+//	@Description	the response lists how they don't conform. Credentials are issued as VC-JWTs, unless the `ldp` format
+//	@Description	is requested, which secures them with an Ed25519Signature2020 proof, or with a BbsBlsSignature2020
+//	@Description	proof when the issuer's key is a BLS12381G2 key, or the `vc+sd-jwt` format, which issues them as
+//	@Description	SD-JWT VCs, bound to the holder's key when it's given.
 //	@Tags			CredentialAPI
 //	@Accept			json
 //	@Produce		json
@@ -177,16 +297,25 @@ func (cr CredentialRouter) CreateCredential(c *gin.Context) {
 		framework.LoggingRespondErrWithMsg(c, err, invalidCreateCredentialRequest, http.StatusBadRequest)
 		return
 	}
+	if request.HolderKey != nil && request.Format != credential.FormatSDJWT {
+		errMsg := fmt.Sprintf("holderKey can only be set for the %s format", credential.FormatSDJWT)
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
 
 	req := request.toServiceRequest()
 	createCredentialResponse, err := cr.service.CreateCredential(c, req)
 	if err != nil {
 		errMsg := "could not create credential"
+		if errors.Is(err, credential.ErrUnsupportedLDPCredential) {
+			framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+			return
+		}
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
 		return
 	}
 
-	resp := CreateCredentialResponse{Container: createCredentialResponse.Container}
+	resp := CreateCredentialResponse{Container: createCredentialResponse.Container, SchemaViolations: createCredentialResponse.SchemaViolations}
 	framework.Respond(c, resp, http.StatusCreated)
 }
 
@@ -235,6 +364,9 @@ type GetCredentialStatusResponse struct {
 	Revoked bool `json:"revoked"`
 	// Whether the credential has been suspended.
 	Suspended bool `json:"suspended"`
+	// Whether the credential expired, which is recorded once its expiration date passes when expiry checks are
+	// enabled.
+	Expired bool `json:"expired,omitempty"`
 }
 
 // GetCredentialStatus godoc
@@ -267,6 +399,7 @@ func (cr CredentialRouter) GetCredentialStatus(c *gin.Context) {
 	resp := GetCredentialStatusResponse{
 		Revoked:   getCredentialStatusResponse.Revoked,
 		Suspended: getCredentialStatusResponse.Suspended,
+		Expired:   getCredentialStatusResponse.Expired,
 	}
 
 	framework.Respond(c, resp, http.StatusOK)
@@ -290,6 +423,10 @@ type GetCredentialStatusListResponse struct {
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
 //	@Success		200	{object}	GetCredentialStatusListResponse
+//	@Header			200	{string}	ETag	"ETag of the status list, for If-None-Match"
+//	@Header			200	{string}	Last-Modified	"When this representation was first served"
+//	@Header			200	{string}	Cache-Control	"How long the response can be reused for"
+//	@Success		304	{string}	string	"Not modified since the If-None-Match or If-Modified-Since of the request"
 //	@Failure		400	{string}	string	"Bad request"
 //	@Failure		500	{string}	string	"Internal server error"
 //	@Router			/v1/credentials/status/{id} [get]
@@ -320,7 +457,9 @@ func (cr CredentialRouter) GetCredentialStatusList(c *gin.Context) {
 type UpdateCredentialStatusRequest struct {
 	// The new revoked status of this credential. The status will be saved in the encodedList of the StatusList2021
 	// credential associated with this VC.
-	Revoked   bool `json:"revoked,omitempty"`
+	Revoked bool `json:"revoked,omitempty"`
+	// The new suspended status of this credential, which can only be set when it was issued suspendable. Suspension is
+	// temporary: the credential is reinstated by setting it to false.
 	Suspended bool `json:"suspended,omitempty"`
 }
 
@@ -341,11 +480,13 @@ type UpdateCredentialStatusResponse struct {
 // UpdateCredentialStatus godoc
 //
 //	@Summary		Update Credential Status
-//	@Description	Update a credential's status
+//	@Description	Update a credential's status. A dry run doesn't update it, and responds with the status it would have.
+//	@Description	Credentials are revoked in bulk with a batch of requests to this route, which can be dry runs too.
 //	@Tags			CredentialAPI
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		UpdateCredentialStatusRequest	true	"request body"
+//	@Param			dryRun	query		bool							false	"Report the status the credential would have, without updating it"
 //	@Success		201		{object}	UpdateCredentialStatusResponse
 //	@Failure		400		{string}	string	"Bad request"
 //	@Failure		500		{string}	string	"Internal server error"
@@ -372,11 +513,22 @@ func (cr CredentialRouter) UpdateCredentialStatus(c *gin.Context) {
 		return
 	}
 
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateCredentialRequest, http.StatusBadRequest)
+		return
+	}
+
 	req := request.toServiceRequest(*id)
+	req.DryRun = dryRun
 	gotCredential, err := cr.service.UpdateCredentialStatus(c, req)
 
 	if err != nil {
 		errMsg := fmt.Sprintf("could not update credential with id: %s", req.ID)
+		if errors.Is(err, credential.ErrInvalidStatusUpdate) {
+			framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+			return
+		}
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
 		return
 	}
@@ -395,29 +547,63 @@ type VerifyCredentialRequest struct {
 
 	// A JWT that encodes a credential.
 	CredentialJWT *keyaccess.JWT `json:"credentialJwt,omitempty"`
+
+	// An SD-JWT credential, with the disclosures of the claims it reveals, and the key binding JWT it's presented with
+	// when it's bound to the holder's key.
+	CredentialSDJWT *keyaccess.SDJWT `json:"credentialSdJwt,omitempty"`
+
+	// Audience the key binding JWT of an SD-JWT credential must be for, when it's set.
+	Audience string `json:"audience,omitempty"`
+
+	// Nonce the key binding JWT of an SD-JWT credential must be made with, when it's set.
+	Nonce string `json:"nonce,omitempty"`
 }
 
 func (vcr VerifyCredentialRequest) IsValid() bool {
-	return (vcr.DataIntegrityCredential != nil && vcr.CredentialJWT == nil) ||
-		(vcr.DataIntegrityCredential == nil && vcr.CredentialJWT != nil)
+	provided := 0
+	if vcr.DataIntegrityCredential != nil {
+		provided++
+	}
+	if vcr.CredentialJWT != nil {
+		provided++
+	}
+	if vcr.CredentialSDJWT != nil {
+		provided++
+	}
+	return provided == 1
 }
 
 type VerifyCredentialResponse struct {
 	// Whether the credential was verified.
 	Verified bool `json:"verified"`
 
-	// The reason why this credential couldn't be verified.
+	// The reason why this credential couldn't be verified, which is that of the first check it didn't pass.
 	Reason string `json:"reason,omitempty"`
+
+	// How each check of the credential went. Checks that don't apply to the credential, like the schema check of a
+	// credential without a schema, are left out.
+	Checks []credmodel.CheckResult `json:"checks,omitempty"`
+
+	// Whether the credential is revoked in its status list.
+	Revoked bool `json:"revoked,omitempty"`
+
+	// Whether the credential is suspended in its status list. Unlike a revoked credential, it may be reinstated.
+	Suspended bool `json:"suspended,omitempty"`
 }
 
 // VerifyCredential godoc
 //
 //	@Summary		Verify Credential
-//	@Description	Verify a given credential by its id. The system does the following levels of verification:
-//	@Description	1. Makes sure the credential has a valid signature
-//	@Description	2. Makes sure the credential has is not expired
-//	@Description	3. Makes sure the credential complies with the VC Data Model
-//	@Description	4. If the credential has a schema, makes sure its data complies with the schema
+//	@Description	Verify a given credential, which may have been issued by this service or anyone else. The system does
+//	@Description	the following checks, and reports how each went:
+//	@Description	1. signature: makes sure the credential has a valid signature, from a key of its issuer's DID. SD-JWT
+//	@Description	credentials are also checked for keyBinding: when bound to the holder's key, they must be presented
+//	@Description	with a key binding JWT signed by the key, for the audience and nonce when they're set
+//	@Description	2. dataModel: makes sure the credential complies with the VC Data Model
+//	@Description	3. expiry: makes sure the credential is not expired
+//	@Description	4. schema: if the credential has a schema, makes sure its data complies with the schema
+//	@Description	5. status: if the credential has a StatusList2021Entry, fetches its status list credential, verifies it,
+//	@Description	and makes sure the credential isn't revoked or suspended
 //	@Tags			CredentialAPI
 //	@Accept			json
 //	@Produce		json
@@ -435,7 +621,7 @@ func (cr CredentialRouter) VerifyCredential(c *gin.Context) {
 	}
 
 	if !request.IsValid() {
-		errMsg := "request must contain either a Data Integrity Credential or a JWT Credential"
+		errMsg := "request must contain either a Data Integrity Credential, a JWT Credential, or an SD-JWT Credential"
 		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
 		return
 	}
@@ -443,6 +629,8 @@ func (cr CredentialRouter) VerifyCredential(c *gin.Context) {
 	verificationResult, err := cr.service.VerifyCredential(c, credential.VerifyCredentialRequest{
 		DataIntegrityCredential: request.DataIntegrityCredential,
 		CredentialJWT:           request.CredentialJWT,
+		CredentialSDJWT:         request.CredentialSDJWT,
+		KeyBinding:              keyaccess.KeyBinding{Audience: request.Audience, Nonce: request.Nonce},
 	})
 	if err != nil {
 		errMsg := "could not verify credential"
@@ -450,13 +638,99 @@ func (cr CredentialRouter) VerifyCredential(c *gin.Context) {
 		return
 	}
 
-	resp := VerifyCredentialResponse{Verified: verificationResult.Verified, Reason: verificationResult.Reason}
+	resp := VerifyCredentialResponse{
+		Verified:  verificationResult.Verified,
+		Reason:    verificationResult.Reason,
+		Checks:    verificationResult.Checks,
+		Revoked:   verificationResult.Revoked,
+		Suspended: verificationResult.Suspended,
+	}
 	framework.Respond(c, resp, http.StatusOK)
 }
 
+type DeriveCredentialRequest struct {
+	// A credential secured with a BbsBlsSignature2020 proof.
+	Credential *credsdk.VerifiableCredential `json:"credential" validate:"required"`
+
+	// Fields of the credential's subject to reveal. The subject's id, and the rest of the credential, are always
+	// revealed.
+	Reveal []string `json:"reveal" validate:"required,min=1" example:"givenName"`
+
+	// Nonce the derived proof is made with, like a challenge from the verifier it's presented to. A random nonce is
+	// used when it's empty.
+	Nonce string `json:"nonce,omitempty"`
+}
+
+type DeriveCredentialResponse struct {
+	// The credential, which only reveals the requested fields of its subject, secured with a BbsBlsSignatureProof2020
+	// proof.
+	Credential credsdk.VerifiableCredential `json:"credential"`
+}
+
+// DeriveCredential godoc
+//
+//	@Summary		Derive Credential
+//	@Description	Derive, from a credential secured with a BbsBlsSignature2020 proof, a credential which only reveals some
+//	@Description	fields of its subject, secured with a BbsBlsSignatureProof2020 proof. Holders present derived credentials
+//	@Description	to selectively disclose what they were issued. The credential is verified first.
+//	@Tags			CredentialAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		DeriveCredentialRequest	true	"request body"
+//	@Success		200		{object}	DeriveCredentialResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/credentials/derivation [put]
+func (cr CredentialRouter) DeriveCredential(c *gin.Context) {
+	var request DeriveCredentialRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		errMsg := "invalid derive credential request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	derived, err := cr.service.DeriveCredential(c, credential.DeriveCredentialRequest{
+		Credential: *request.Credential,
+		Reveal:     request.Reveal,
+		Nonce:      request.Nonce,
+	})
+	if err != nil {
+		errMsg := "could not derive credential"
+		if errors.Is(err, credential.ErrCannotDeriveCredential) {
+			framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+			return
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	framework.Respond(c, DeriveCredentialResponse{Credential: derived.Credential}, http.StatusOK)
+}
+
 type ListCredentialsResponse struct {
 	// Array of credentials that match the query parameters.
 	Credentials []credmodel.Container `json:"credentials,omitempty"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// respondWithCredentials responds with the requested page of credentials, in the requested view.
+func respondWithCredentials(c *gin.Context, credentials []credmodel.Container, view CredentialView, pageRequest pagination.PageRequest) {
+	var resp ListCredentialsResponse
+	page, failed := pagination.Paginate(c, pageRequest, credentials, func(cred credmodel.Container) string { return cred.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	if view == CredentialViewBasic {
+		for i := range page {
+			page[i].Credential = nil
+			page[i].CredentialJWT = nil
+			page[i].CredentialSDJWT = nil
+		}
+	}
+	resp.Credentials = page
+	framework.Respond(c, resp, http.StatusOK)
 }
 
 // ListCredentials godoc
@@ -469,14 +743,30 @@ type ListCredentialsResponse struct {
 //	@Param			issuer	query		string	false	"The issuer id"	example(did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp)
 //	@Param			schema	query		string	false	"The credentialSchema.id value to filter by"
 //	@Param			subject	query		string	false	"The credentialSubject.id value to filter by"
-//	@Success		200		{object}	ListCredentialsResponse
-//	@Failure		400		{string}	string	"Bad request"
-//	@Failure		500		{string}	string	"Internal server error"
+//	@Param			view		query		string	false	"How much of each credential to include, FULL or BASIC. BASIC leaves out the credential and its JWT"	Enums(FULL, BASIC)
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListCredentialsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of credentials across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/credentials [get]
 func (cr CredentialRouter) ListCredentials(c *gin.Context) {
 	issuer := framework.GetQueryValue(c, IssuerParam)
 	schema := framework.GetQueryValue(c, SchemaParam)
 	subject := framework.GetQueryValue(c, SubjectParam)
+	view := CredentialViewFull
+	if v := framework.GetQueryValue(c, ViewParam); v != nil {
+		view = CredentialView(strings.ToUpper(*v))
+		if view != CredentialViewFull && view != CredentialViewBasic {
+			framework.LoggingRespondErrMsg(c, fmt.Sprintf("view must be one of %s or %s", CredentialViewFull, CredentialViewBasic), http.StatusBadRequest)
+			return
+		}
+	}
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 
 	errMsg := "must use only one of the following optional query parameters: issuer, subject, schema"
 
@@ -487,27 +777,30 @@ func (cr CredentialRouter) ListCredentials(c *gin.Context) {
 	}
 
 	if issuer == nil && schema == nil && subject == nil {
-		cr.listCredentials(c)
+		cr.listCredentials(c, view, pageRequest)
 		return
 	}
 	if issuer != nil {
-		cr.listCredentialsByIssuer(c, *issuer)
+		cr.listCredentialsByIssuer(c, *issuer, view, pageRequest)
 		return
 	}
 	if subject != nil {
-		cr.listCredentialsBySubject(c, *subject)
+		cr.listCredentialsBySubject(c, *subject, view, pageRequest)
 		return
 	}
 	if schema != nil {
-		cr.listCredentialsBySchema(c, *schema)
+		cr.listCredentialsBySchema(c, *schema, view, pageRequest)
 		return
 	}
 
 	framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
 }
 
-func (cr CredentialRouter) listCredentials(c *gin.Context) {
-	gotCredentials, err := cr.service.ListCredentials(c)
+// listCredentials reads only the requested page of credentials, which the filtered lists can't, as credentials are
+// found by their issuer, subject or schema by reading them all.
+func (cr CredentialRouter) listCredentials(c *gin.Context, view CredentialView, pageRequest pagination.PageRequest) {
+	page := pageRequest.ToServicePage()
+	gotCredentials, err := cr.service.ListCredentialsPage(c, credential.ListCredentialsPageRequest{PageToken: page.Token, PageSize: page.Size})
 	if err != nil {
 		errMsg := fmt.Sprintf("could not get credentials")
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
@@ -515,10 +808,21 @@ func (cr CredentialRouter) listCredentials(c *gin.Context) {
 	}
 
 	resp := ListCredentialsResponse{Credentials: gotCredentials.Credentials}
+	if view == CredentialViewBasic {
+		for i := range resp.Credentials {
+			resp.Credentials[i].Credential = nil
+			resp.Credentials[i].CredentialJWT = nil
+			resp.Credentials[i].CredentialSDJWT = nil
+		}
+	}
+	pagination.SetTotalCount(c, gotCredentials.TotalCount)
+	if pagination.MaybeSetNextPageToken(c, gotCredentials.NextPageToken, &resp.NextPageToken) {
+		return
+	}
 	framework.Respond(c, resp, http.StatusOK)
 }
 
-func (cr CredentialRouter) listCredentialsByIssuer(c *gin.Context, issuer string) {
+func (cr CredentialRouter) listCredentialsByIssuer(c *gin.Context, issuer string, view CredentialView, pageRequest pagination.PageRequest) {
 	gotCredentials, err := cr.service.ListCredentialsByIssuer(c, credential.ListCredentialByIssuerRequest{Issuer: issuer})
 	if err != nil {
 		errMsg := fmt.Sprintf("could not get credentials for issuer: %s", util.SanitizeLog(issuer))
@@ -526,12 +830,10 @@ func (cr CredentialRouter) listCredentialsByIssuer(c *gin.Context, issuer string
 		return
 	}
 
-	resp := ListCredentialsResponse{Credentials: gotCredentials.Credentials}
-	framework.Respond(c, resp, http.StatusOK)
-	return
+	respondWithCredentials(c, gotCredentials.Credentials, view, pageRequest)
 }
 
-func (cr CredentialRouter) listCredentialsBySubject(c *gin.Context, subject string) {
+func (cr CredentialRouter) listCredentialsBySubject(c *gin.Context, subject string, view CredentialView, pageRequest pagination.PageRequest) {
 	gotCredentials, err := cr.service.ListCredentialsBySubject(c, credential.ListCredentialBySubjectRequest{Subject: subject})
 	if err != nil {
 		errMsg := fmt.Sprintf("could not get credentials for subject: %s", util.SanitizeLog(subject))
@@ -539,11 +841,10 @@ func (cr CredentialRouter) listCredentialsBySubject(c *gin.Context, subject stri
 		return
 	}
 
-	resp := ListCredentialsResponse{Credentials: gotCredentials.Credentials}
-	framework.Respond(c, resp, http.StatusOK)
+	respondWithCredentials(c, gotCredentials.Credentials, view, pageRequest)
 }
 
-func (cr CredentialRouter) listCredentialsBySchema(c *gin.Context, schema string) {
+func (cr CredentialRouter) listCredentialsBySchema(c *gin.Context, schema string, view CredentialView, pageRequest pagination.PageRequest) {
 	gotCredentials, err := cr.service.ListCredentialsBySchema(c, credential.ListCredentialBySchemaRequest{Schema: schema})
 	if err != nil {
 		errMsg := fmt.Sprintf("could not get credentials for schema: %s", util.SanitizeLog(schema))
@@ -551,8 +852,7 @@ func (cr CredentialRouter) listCredentialsBySchema(c *gin.Context, schema string
 		return
 	}
 
-	resp := ListCredentialsResponse{Credentials: gotCredentials.Credentials}
-	framework.Respond(c, resp, http.StatusOK)
+	respondWithCredentials(c, gotCredentials.Credentials, view, pageRequest)
 }
 
 // DeleteCredential godoc
@@ -771,7 +771,7 @@ func TestCredentialRouter(t *testing.T) {
 				assert.NoError(tt, err)
 				assert.NotEmpty(tt, createdCredSuspendable)
 
-				revocationKey := storage.Join("is", issuerDID.DID.ID, "sc", createdSchema.ID, "sp", string(status.StatusRevocation))
+				revocationKey := storage.Join("is", issuerDID.DID.ID, "sc", schema.VersionedID(createdSchema.ID, createdSchema.Version), "sp", string(status.StatusRevocation))
 
 				slcExists, err := s.Exists(context.Background(), "status-list-credential", revocationKey)
 				assert.NoError(tt, err)
@@ -785,7 +785,7 @@ func TestCredentialRouter(t *testing.T) {
 				assert.NoError(tt, err)
 				assert.True(tt, currentIndexExists)
 
-				suspensionKey := storage.Join("is", issuerDID.DID.ID, "sc", createdSchema.ID, "sp", string(status.StatusSuspension))
+				suspensionKey := storage.Join("is", issuerDID.DID.ID, "sc", schema.VersionedID(createdSchema.ID, createdSchema.Version), "sp", string(status.StatusSuspension))
 
 				slcExists, err = s.Exists(context.Background(), "status-list-credential", suspensionKey)
 				assert.NoError(tt, err)
@@ -1062,6 +1062,7 @@ func TestCredentialRouter(t *testing.T) {
 				assert.Nil(tt, updatedStatus)
 				assert.Error(tt, err)
 				assert.ErrorContains(tt, err, "cannot update both suspended and revoked status")
+				assert.ErrorIs(tt, err, credential.ErrInvalidStatusUpdate)
 			})
 
 			t.Run("Update Suspended On Revoked Credential Should Be Error", func(tt *testing.T) {
@@ -1087,6 +1088,7 @@ func TestCredentialRouter(t *testing.T) {
 				assert.Nil(tt, updatedStatus)
 				assert.Error(tt, err)
 				assert.ErrorContains(tt, err, "has a different status purpose<revocation> value than the status credential<suspension>")
+				assert.ErrorIs(tt, err, credential.ErrInvalidStatusUpdate)
 			})
 
 			t.Run("Create Credential With Invalid Evidence", func(tt *testing.T) {
@@ -1158,6 +1160,64 @@ func TestCredentialRouter(t *testing.T) {
 
 				assert.ElementsMatch(tt, createdCred.Credential.Evidence, getEvidence())
 			})
+
+			t.Run("Create Credential Against Schema", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				keyStoreService := testKeyStoreService(tt, s)
+				didService := testDIDService(tt, s, keyStoreService)
+				schemaService := testSchemaService(tt, s, keyStoreService, didService)
+
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{Method: didsdk.KeyMethod, KeyType: crypto.Ed25519})
+				require.NoError(tt, err)
+				createdSchema, err := schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "email schema", Schema: getEmailSchema()})
+				require.NoError(tt, err)
+				invalidRequest := credential.CreateCredentialRequest{
+					Issuer:                             issuerDID.DID.ID,
+					FullyQualifiedVerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:                            "did:test:345",
+					SchemaID:                           createdSchema.ID,
+					Data: map[string]any{
+						"mail": "Satoshi@Nakamoto.btc",
+					},
+				}
+
+				// credentials that don't conform to their schema aren't issued, by default
+				serviceConfig := config.CredentialServiceConfig{BaseServiceConfig: &config.BaseServiceConfig{Name: "credential", ServiceEndpoint: "http://localhost:1234/v1/credentials"}}
+				credService, err := credential.NewCredentialService(serviceConfig, s, keyStoreService, didService.GetResolver(), schemaService)
+				require.NoError(tt, err)
+				_, err = credService.CreateCredential(context.Background(), invalidRequest)
+				var schemaErr *credential.SchemaValidationError
+				require.ErrorAs(tt, err, &schemaErr)
+				assert.Equal(tt, schema.VersionedID(createdSchema.ID, createdSchema.Version), schemaErr.SchemaID)
+				require.Len(tt, schemaErr.Violations, 1)
+				assert.Equal(tt, "credentialSubject", schemaErr.Violations[0].Field)
+				assert.Equal(tt, "/properties/credentialSubject/required", schemaErr.Violations[0].Keyword)
+				assert.Contains(tt, schemaErr.Violations[0].Error, "email")
+				gotCreds, err := credService.ListCredentialsBySchema(context.Background(), credential.ListCredentialBySchemaRequest{Schema: createdSchema.ID})
+				assert.NoError(tt, err)
+				assert.Empty(tt, gotCreds.Credentials)
+
+				// with advisory schema validation they're issued, reporting how they don't conform
+				serviceConfig.SchemaValidation = config.SchemaValidationAdvisory
+				credService, err = credential.NewCredentialService(serviceConfig, s, keyStoreService, didService.GetResolver(), schemaService)
+				require.NoError(tt, err)
+				createdCred, err := credService.CreateCredential(context.Background(), invalidRequest)
+				require.NoError(tt, err)
+				assert.NotEmpty(tt, createdCred.CredentialJWT)
+				assert.Equal(tt, schemaErr.Violations, createdCred.SchemaViolations)
+
+				// credentials that conform have no violations either way
+				validRequest := invalidRequest
+				validRequest.Data = map[string]any{"email": "Satoshi@Nakamoto.btc"}
+				createdCred, err = credService.CreateCredential(context.Background(), validRequest)
+				require.NoError(tt, err)
+				assert.Empty(tt, createdCred.SchemaViolations)
+
+				// unknown modes are rejected
+				serviceConfig.SchemaValidation = "lenient"
+				_, err = credential.NewCredentialService(serviceConfig, s, keyStoreService, didService.GetResolver(), schemaService)
+				assert.ErrorContains(tt, err, "unknown schema validation<lenient>")
+			})
 		})
 	}
 }
@@ -0,0 +1,168 @@
+package router
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const ProfileParam = "profile"
+
+// DebugRouter serves diagnostics of the running service, for operators.
+type DebugRouter struct {
+	storage storage.ServiceStorage
+	started time.Time
+}
+
+func NewDebugRouter(s storage.ServiceStorage) *DebugRouter {
+	return &DebugRouter{storage: s, started: time.Now()}
+}
+
+type MemoryStats struct {
+	// Bytes of allocated heap objects.
+	AllocBytes uint64 `json:"allocBytes"`
+
+	// Cumulative bytes allocated for heap objects.
+	TotalAllocBytes uint64 `json:"totalAllocBytes"`
+
+	// Bytes of memory obtained from the OS.
+	SysBytes uint64 `json:"sysBytes"`
+
+	// Number of allocated heap objects.
+	HeapObjects uint64 `json:"heapObjects"`
+
+	// Number of completed GC cycles, and the time spent in their stop-the-world pauses.
+	GCCycles     uint32 `json:"gcCycles"`
+	GCPauseNanos uint64 `json:"gcPauseNanos"`
+}
+
+type GetRuntimeStatsResponse struct {
+	GoVersion  string      `json:"goVersion"`
+	Goroutines int         `json:"goroutines"`
+	CPUs       int         `json:"cpus"`
+	MaxProcs   int         `json:"maxProcs"`
+	Memory     MemoryStats `json:"memory"`
+
+	// Seconds since the service started.
+	UptimeSeconds int64 `json:"uptimeSeconds"`
+}
+
+// GetRuntimeStats godoc
+//
+//	@Summary		Get Runtime Stats
+//	@Description	Gets the number of goroutines, the memory use, and the garbage collections of the service.
+//	@Tags			DebugAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	GetRuntimeStatsResponse
+//	@Router			/admin/debug/runtime [get]
+func (dr DebugRouter) GetRuntimeStats(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	framework.Respond(c, GetRuntimeStatsResponse{
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		CPUs:       runtime.NumCPU(),
+		MaxProcs:   runtime.GOMAXPROCS(0),
+		Memory: MemoryStats{
+			AllocBytes:      memStats.Alloc,
+			TotalAllocBytes: memStats.TotalAlloc,
+			SysBytes:        memStats.Sys,
+			HeapObjects:     memStats.HeapObjects,
+			GCCycles:        memStats.NumGC,
+			GCPauseNanos:    memStats.PauseTotalNs,
+		},
+		UptimeSeconds: int64(time.Since(dr.started).Seconds()),
+	}, http.StatusOK)
+}
+
+type GetStorageStatsResponse struct {
+	// Storage provider, e.g. bolt.
+	Type storage.Type `json:"type"`
+
+	// Whether the storage provider is reachable.
+	Open bool `json:"open"`
+
+	// Statistics the storage provider reports, which depend on the provider. Empty when it doesn't report any.
+	Stats map[string]any `json:"stats,omitempty"`
+}
+
+// GetStorageStats godoc
+//
+//	@Summary		Get Storage Stats
+//	@Description	Gets the statistics the storage provider reports, like the size of the database or the use of its
+//	@Description	connection pool.
+//	@Tags			DebugAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	GetStorageStatsResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/debug/storage [get]
+func (dr DebugRouter) GetStorageStats(c *gin.Context) {
+	resp := GetStorageStatsResponse{Type: dr.storage.Type(), Open: dr.storage.IsOpen()}
+	if resp.Open {
+		stats, err := storage.Stats(c, dr.storage)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not get storage stats", http.StatusInternalServerError)
+			return
+		}
+		resp.Stats = stats
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// GetGoroutines godoc
+//
+//	@Summary		Get Goroutines
+//	@Description	Dumps the stacks of every goroutine of the service, as text.
+//	@Tags			DebugAPI
+//	@Produce		plain
+//	@Success		200	{string}	string	"Goroutine stacks"
+//	@Router			/admin/debug/goroutines [get]
+func (dr DebugRouter) GetGoroutines(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := rpprof.Lookup("goroutine").WriteTo(c.Writer, 2); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not dump goroutines", http.StatusInternalServerError)
+	}
+}
+
+// GetProfile godoc
+//
+//	@Summary		Get Profile
+//	@Description	Serves the pprof profiles of the service, for go tool pprof. The index lists the profiles, which
+//	@Description	include heap, allocs, goroutine, block, mutex, and threadcreate. profile is a CPU profile, and trace an
+//	@Description	execution trace, of the number of seconds given by the seconds query parameter, which must be shorter
+//	@Description	than the write timeout of the route.
+//	@Tags			DebugAPI
+//	@Produce		octet-stream
+//	@Param			profile	path		string	true	"Name of the profile, or empty for the index"
+//	@Param			seconds	query		number	false	"Seconds to profile or trace for"
+//	@Param			debug	query		number	false	"When set, profiles are served as text"
+//	@Success		200		{string}	string	"Profile"
+//	@Failure		404		{string}	string	"Unknown profile"
+//	@Router			/admin/debug/pprof/{profile} [get]
+func (dr DebugRouter) GetProfile(c *gin.Context) {
+	switch name := strings.TrimPrefix(c.Param(ProfileParam), "/"); name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
@@ -20,9 +21,10 @@ import (
 )
 
 const (
-	MethodParam  = "method"
-	IDParam      = "id"
-	DeletedParam = "deleted"
+	MethodParam     = "method"
+	IDParam         = "id"
+	DeletedParam    = "deleted"
+	DeactivateParam = "deactivate"
 )
 
 // DIDRouter represents the dependencies required to instantiate a DID-HTTP service
@@ -85,7 +87,9 @@ type CreateDIDByMethodResponse struct {
 //	@Produce		json
 //	@Param			method	path		string														true	"Method"
 //	@Param			request	body		CreateDIDByMethodRequest{options=did.CreateIONDIDOptions}	true	"request body"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
 //	@Success		201		{object}	CreateDIDByMethodResponse
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
 //	@Failure		400		{string}	string	"Bad request"
 //	@Failure		500		{string}	string	"Internal server error"
 //	@Router			/v1/dids/{method} [put]
@@ -192,6 +196,10 @@ type GetDIDByMethodResponse struct {
 //	@Param			method	path		string						true	"Method"
 //	@Param			id		path		string						true	"ID"
 //	@Success		200		{object}	GetDIDByMethodResponse
+//	@Header			200	{string}	ETag	"ETag of the DID, for If-Match"
+//	@Header			200	{string}	Last-Modified	"When this representation was first served"
+//	@Header			200	{string}	Cache-Control	"How long the response can be reused for"
+//	@Success		304	{string}	string	"Not modified since the If-None-Match or If-Modified-Since of the request"
 //	@Failure		400		{string}	string	"Bad request"
 //	@Router			/v1/dids/{method}/{id} [get]
 func (dr DIDRouter) GetDIDByMethod(c *gin.Context) {
@@ -222,6 +230,32 @@ func (dr DIDRouter) GetDIDByMethod(c *gin.Context) {
 	framework.Respond(c, resp, http.StatusOK)
 }
 
+// GetWebDIDDocument serves the documents of the did:web DIDs the service hosts at the URLs they resolve to, so they
+// need no other web server: /.well-known/did.json for a DID of the host the request was made to, and /{path}/did.json
+// for one with a path. Requests for anything else, including DIDs the service doesn't host, are passed on.
+func (dr DIDRouter) GetWebDIDDocument(c *gin.Context) {
+	if c.Request.Method != http.MethodGet || !strings.HasSuffix(c.Request.URL.Path, "/did.json") {
+		c.Next()
+		return
+	}
+	id, err := did.WebDIDForURL(c.Request.Host, c.Request.URL.Path)
+	if err != nil {
+		c.Next()
+		return
+	}
+	doc, err := dr.service.GetHostedWebDID(c, id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get hosted DID: %s", id)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	if doc == nil {
+		c.Next()
+		return
+	}
+	framework.Respond(c, doc, http.StatusOK)
+}
+
 type ListDIDsByMethodResponse struct {
 	DIDs []didsdk.Document `json:"dids,omitempty"`
 
@@ -247,6 +281,7 @@ type GetDIDsRequest struct {
 //	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
 //	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
 //	@Success		200			{object}	ListDIDsByMethodResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of DIDs across all pages"
 //	@Failure		400			{string}	string	"Bad request"
 //	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/dids/{method} [get]
@@ -291,8 +326,18 @@ func (dr DIDRouter) ListDIDsByMethod(c *gin.Context) {
 	resp := ListDIDsByMethodResponse{
 		DIDs: listResp.DIDs,
 	}
-	if pagination.MaybeSetNextPageToken(c, listResp.NextPageToken, &resp.NextPageToken) {
-		return
+	if getIsDeleted {
+		// soft deleted DIDs are read whole
+		dids, failed := pagination.Paginate(c, pageRequest, listResp.DIDs, func(d didsdk.Document) string { return d.ID }, &resp.NextPageToken)
+		if failed {
+			return
+		}
+		resp.DIDs = dids
+	} else {
+		pagination.SetTotalCount(c, listResp.TotalCount)
+		if pagination.MaybeSetNextPageToken(c, listResp.NextPageToken, &resp.NextPageToken) {
+			return
+		}
 	}
 	framework.Respond(c, resp, http.StatusOK)
 }
@@ -315,8 +360,12 @@ type ResolveDIDResponse struct {
 //	@Produce		json
 //	@Param			method	path		string	true	"Method"
 //	@Param			id		path		string	true	"ID"
+//	@Param			If-Match	header		string	false	"ETag of the DID, which must still match for it to be deleted"
+//	@Param			deactivate	query		boolean	false	"When true, the DID is deactivated too, when its method supports it. Deactivation is permanent, even if the DID is restored."
 //	@Success		204		{string}	string	"No Content"
 //	@Failure		400		{string}	string	"Bad request"
+//	@Failure		412	{string}	string	"Precondition failed"
+//	@Failure		428	{string}	string	"Precondition required"
 //	@Failure		500		{string}	string	"Internal server error"
 //	@Router			/v1/dids/{method}/{id} [delete]
 func (dr DIDRouter) SoftDeleteDIDByMethod(c *gin.Context) {
@@ -333,7 +382,17 @@ func (dr DIDRouter) SoftDeleteDIDByMethod(c *gin.Context) {
 		return
 	}
 
-	deleteDIDRequest := did.DeleteDIDRequest{Method: didsdk.Method(*method), ID: *id}
+	deactivate := false
+	if deactivateParam := framework.GetQueryValue(c, DeactivateParam); deactivateParam != nil {
+		var err error
+		if deactivate, err = strconv.ParseBool(*deactivateParam); err != nil {
+			errMsg := "soft delete DID request encountered a problem with the `deactivate` query param"
+			framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	deleteDIDRequest := did.DeleteDIDRequest{Method: didsdk.Method(*method), ID: *id, Deactivate: deactivate}
 	if err := dr.service.SoftDeleteDIDByMethod(c, deleteDIDRequest); err != nil {
 		errMsg := fmt.Sprintf("could not soft delete DID with id: %s", *id)
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
@@ -343,15 +402,64 @@ func (dr DIDRouter) SoftDeleteDIDByMethod(c *gin.Context) {
 	framework.Respond(c, nil, http.StatusNoContent)
 }
 
+type RestoreDIDByMethodResponse struct {
+	DID didsdk.Document `json:"did"`
+}
+
+// RestoreDIDByMethod godoc
+//
+//	@Summary		Restore DID
+//	@Description	Restores a soft-deleted DID, so that it shows up in the ListDIDsByMethod call again. A DID that was
+//	@Description	deactivated when it was deleted stays deactivated.
+//	@Tags			DecentralizedIdentityAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			method	path		string	true	"Method"
+//	@Param			id		path		string	true	"ID"
+//	@Success		200		{object}	RestoreDIDByMethodResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Router			/v1/dids/{method}/{id}/restore [put]
+func (dr DIDRouter) RestoreDIDByMethod(c *gin.Context) {
+	method := framework.GetParam(c, MethodParam)
+	if method == nil {
+		errMsg := "restore DID by method request missing method parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := fmt.Sprintf("restore DID request missing id parameter for method: %s", *method)
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	restoreDIDRequest := did.RestoreDIDRequest{Method: didsdk.Method(*method), ID: *id}
+	restored, err := dr.service.RestoreDIDByMethod(c, restoreDIDRequest)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not restore DID with id: %s", *id)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	framework.Respond(c, RestoreDIDByMethodResponse{DID: restored.DID}, http.StatusOK)
+}
+
 // ResolveDID godoc
 //
 //	@Summary		Resolve a DID
-//	@Description	Resolve a DID that may not be stored in this service
+//	@Description	Resolve a DID that may not be stored in this service. Resolving DIDs of other methods can be slow,
+//	@Description	so it can be run in the background.
 //	@Tags			DecentralizedIdentityAPI
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"ID"
-//	@Success		200	{object}	ResolveDIDResponse
+//	@Param			id		path		string	true	"ID"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
+//	@Success		200		{object}	ResolveDIDResponse
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
+//	@Header			200	{string}	ETag	"ETag of the resolution result, for If-None-Match"
+//	@Header			200	{string}	Last-Modified	"When this representation was first served"
+//	@Header			200	{string}	Cache-Control	"How long the response can be reused for"
+//	@Success		304	{string}	string	"Not modified since the If-None-Match or If-Modified-Since of the request"
 //	@Failure		400	{string}	string	"Bad request"
 //	@Router			/v1/dids/resolver/{id} [get]
 func (dr DIDRouter) ResolveDID(c *gin.Context) {
@@ -414,7 +522,9 @@ func NewBatchDIDRouter(svc *did.BatchService) *BatchDIDRouter {
 //	@Produce		json
 //	@Param			method	path		string					true	"Method. Only `key` is supported."
 //	@Param			request	body		BatchCreateDIDsRequest	true	"The batch requests"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
 //	@Success		201		{object}	BatchCreateDIDsResponse
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
 //	@Failure		400		{string}	string	"Bad request"
 //	@Failure		500		{string}	string	"Internal server error"
 //	@Router			/v1/dids/{method}/batch [put]
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	"github.com/tbd54566975/ssi-service/pkg/service/erasure"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type ErasureRouter struct {
+	service *erasure.Service
+}
+
+func NewErasureRouter(s svcframework.Service) (*ErasureRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	erasureService, ok := s.(*erasure.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create erasure router with service type: %s", s.Type())
+	}
+	return &ErasureRouter{service: erasureService}, nil
+}
+
+type EraseSubjectRequest struct {
+	// DID or other identifier of the data subject whose data is erased, e.g. the DID credentials were issued to.
+	Subject string `json:"subject" validate:"required"`
+}
+
+type ErasureReportResponse struct {
+	Report erasure.Report `json:"report"`
+}
+
+// EraseSubject godoc
+//
+//	@Summary		Erase Subject
+//	@Description	Erases the data held about a data subject: the credentials issued to them, the credential applications
+//	@Description	they made and the responses to them, the presentation submissions they were the holder of, and the
+//	@Description	operations that reviewed those. Erased data is deleted, and the report in the response lists the IDs of
+//	@Description	what was deleted. Erasing a subject again erases what was stored since, or what a failed erasure left.
+//	@Description	A dry run erases nothing, and its report, which isn't stored, lists what would be erased.
+//	@Tags			ErasureAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		EraseSubjectRequest	true	"request body"
+//	@Param			dryRun	query		bool				false	"Report what would be erased, without erasing anything"
+//	@Success		200		{object}	ErasureReportResponse	"Dry run"
+//	@Success		201		{object}	ErasureReportResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/erasures [put]
+func (er ErasureRouter) EraseSubject(c *gin.Context) {
+	var request EraseSubjectRequest
+	invalidEraseSubjectRequest := "invalid erase subject request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidEraseSubjectRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidEraseSubjectRequest, http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidEraseSubjectRequest, http.StatusBadRequest)
+		return
+	}
+
+	report, err := er.service.EraseSubject(c, erasure.EraseSubjectRequest{Subject: request.Subject, DryRun: dryRun})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not erase subject: %s", request.Subject)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if dryRun {
+		framework.Respond(c, ErasureReportResponse{Report: *report}, http.StatusOK)
+		return
+	}
+	framework.Respond(c, ErasureReportResponse{Report: *report}, http.StatusCreated)
+}
+
+// GetErasure godoc
+//
+//	@Summary		Get Erasure
+//	@Description	Get the report of an erasure by its ID
+//	@Tags			ErasureAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	ErasureReportResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/admin/erasures/{id} [get]
+func (er ErasureRouter) GetErasure(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get erasure without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	report, err := er.service.GetReport(c, erasure.GetReportRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get erasure with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, erasure.ErrReportNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, ErasureReportResponse{Report: *report}, http.StatusOK)
+}
+
+type ListErasuresResponse struct {
+	Reports []erasure.Report `json:"reports"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListErasures godoc
+//
+//	@Summary		List Erasures
+//	@Description	Lists the reports of all erasures
+//	@Tags			ErasureAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListErasuresResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of erasures across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/erasures [get]
+func (er ErasureRouter) ListErasures(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	gotReports, err := er.service.ListReports(c)
+	if err != nil {
+		errMsg := "could not list erasures"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListErasuresResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotReports.Reports, func(r erasure.Report) string { return r.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Reports = page
+	framework.Respond(c, resp, http.StatusOK)
+}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/expiry"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+)
+
+type ExpiryRouter struct {
+	service *expiry.Service
+}
+
+func NewExpiryRouter(s svcframework.Service) (*ExpiryRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	expiryService, ok := s.(*expiry.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create expiry router with service type: %s", s.Type())
+	}
+	return &ExpiryRouter{service: expiryService}, nil
+}
+
+type ListExpiriesResponse struct {
+	// Keys, certificates, and credentials that expire within the warning period, soonest first.
+	Expiries []expiry.Expiry `json:"expiries"`
+}
+
+// ListExpiries godoc
+//
+//	@Summary		List Expiries
+//	@Description	Lists the keys, the certificates of the hosts of did:web DIDs, and the issued credentials of the
+//	@Description	default tenant, and of the configured tenants, that expire within the warning period, soonest first,
+//	@Description	whether they were warned of or not.
+//	@Tags			ExpiryAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListExpiriesResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/expiries [get]
+func (er ExpiryRouter) ListExpiries(c *gin.Context) {
+	resp, err := er.service.ListExpiries(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list expiries", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListExpiriesResponse{Expiries: resp.Expiries}, http.StatusOK)
+}
+
+type CreateWarningResponse struct {
+	Warning expiry.Warning `json:"warning"`
+}
+
+// CreateWarning godoc
+//
+//	@Summary		Create Warning
+//	@Description	Checks expiries now, rather than waiting for the next scheduled check, and warns of those within the
+//	@Description	warning period that weren't warned of before. The warning is posted to the alert URLs, and emailed,
+//	@Description	unless it has no expiries.
+//	@Tags			ExpiryAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	CreateWarningResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/expiries/warnings [put]
+func (er ExpiryRouter) CreateWarning(c *gin.Context) {
+	warning, err := er.service.Warn(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not warn of expiries", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, CreateWarningResponse{Warning: *warning}, http.StatusCreated)
+}
+
+type CreateSweepResponse struct {
+	Sweep expiry.Sweep `json:"sweep"`
+}
+
+// CreateSweep godoc
+//
+//	@Summary		Create Sweep
+//	@Description	Records the credentials whose expiration date passed as expired now, rather than waiting for the
+//	@Description	next scheduled check, suspends those whose status list is for suspension, and publishes each to the
+//	@Description	Credential Expire webhooks.
+//	@Tags			ExpiryAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	CreateSweepResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/expiries/sweeps [put]
+func (er ExpiryRouter) CreateSweep(c *gin.Context) {
+	sweep, err := er.service.Expire(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not expire credentials", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, CreateSweepResponse{Sweep: *sweep}, http.StatusCreated)
+}
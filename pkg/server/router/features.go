@@ -0,0 +1,38 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/features"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+type ListFeaturesResponse struct {
+	// Feature flags, sorted by name.
+	Features []features.State `json:"features"`
+}
+
+// Features lists the feature flags of the server.
+func Features(flags *features.Flags) gin.HandlerFunc {
+	return featuresRouter{flags: flags}.ListFeatures
+}
+
+type featuresRouter struct {
+	flags *features.Flags
+}
+
+// ListFeatures godoc
+//
+//	@Summary		List Features
+//	@Description	Lists the feature flags that guard experimental capabilities, and whom each is enabled for. Flags are
+//	@Description	enabled in config, and capabilities whose flag isn't enabled for a tenant act as if they didn't exist.
+//	@Tags			FeaturesAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListFeaturesResponse
+//	@Router			/admin/features [get]
+func (fr featuresRouter) ListFeatures(c *gin.Context) {
+	framework.Respond(c, ListFeaturesResponse{Features: fr.flags.States()}, http.StatusOK)
+}
@@ -20,12 +20,14 @@ const (
 // Health godoc
 //
 //	@Summary		Health Check
-//	@Description	Health is a simple handler that always responds with a 200 OK
+//	@Description	Health is a simple handler that always responds with a 200 OK while the service is running, so
+//	@Description	that it can be used as a liveness probe.
 //	@Tags			HealthCheck
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	GetHealthCheckResponse
 //	@Router			/health [get]
+//	@Router			/liveness [get]
 func Health(c *gin.Context) {
 	status := GetHealthCheckResponse{Status: HealthOK}
 	framework.Respond(c, status, http.StatusOK)
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/issuance"
 )
@@ -121,6 +122,9 @@ func (ir IssuanceRouter) DeleteIssuanceTemplate(c *gin.Context) {
 
 type ListIssuanceTemplatesResponse struct {
 	IssuanceTemplates []issuance.Template `json:"issuanceTemplates,omitempty"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListIssuanceTemplates godoc
@@ -130,11 +134,18 @@ type ListIssuanceTemplatesResponse struct {
 //	@Tags			IssuanceAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListIssuanceTemplatesResponse
-//	@Failure		400	{string}	string	"Bad request"
-//	@Failure		500	{string}	string	"Internal server error"
-//	@Router			/v1/manifests [get]
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListIssuanceTemplatesResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of issuance templates across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/v1/issuancetemplates [get]
 func (ir IssuanceRouter) ListIssuanceTemplates(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	gotManifests, err := ir.service.ListIssuanceTemplates(c, &issuance.ListIssuanceTemplatesRequest{})
 	if err != nil {
 		errMsg := "could not list templates"
@@ -142,6 +153,11 @@ func (ir IssuanceRouter) ListIssuanceTemplates(c *gin.Context) {
 		return
 	}
 
-	resp := ListIssuanceTemplatesResponse{IssuanceTemplates: gotManifests.IssuanceTemplates}
+	var resp ListIssuanceTemplatesResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotManifests.IssuanceTemplates, func(t issuance.Template) string { return t.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.IssuanceTemplates = page
 	framework.Respond(c, resp, http.StatusOK)
 }
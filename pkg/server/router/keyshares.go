@@ -0,0 +1,145 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+)
+
+type KeySharesRouter struct {
+	shares *keystore.ServiceKeyShares
+}
+
+func NewKeySharesRouter(shares *keystore.ServiceKeyShares) (*KeySharesRouter, error) {
+	if shares == nil {
+		return nil, errors.New("key shares cannot be nil")
+	}
+	return &KeySharesRouter{shares: shares}, nil
+}
+
+// GetSealStatus godoc
+//
+//	@Summary		Get Seal Status
+//	@Description	Gets whether the service key of the keystore was split into shares, and whether this instance
+//	@Description	reconstructed it from them.
+//	@Tags			KeyStoreAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	keystore.SealStatus
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/keystore/seal [get]
+func (kr KeySharesRouter) GetSealStatus(c *gin.Context) {
+	status, err := kr.shares.Status(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get seal status", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, status, http.StatusOK)
+}
+
+type CreateKeySharesRequest struct {
+	// Public Tink keysets, as JSON, of the operators that hold the shares, one for each share. Each share is encrypted
+	// to one of them, so whoever creates the shares doesn't see them.
+	PublicKeysets []json.RawMessage `json:"publicKeysets" validate:"required,min=1"`
+}
+
+type CreateKeySharesResponse struct {
+	// Shares of the service key, base64 encoded, each encrypted to the public keyset at the same index of the request.
+	Shares [][]byte            `json:"shares"`
+	Status keystore.SealStatus `json:"status"`
+}
+
+// CreateKeyShares godoc
+//
+//	@Summary		Create Key Shares
+//	@Description	Initializes the service key of the keystore, and splits it into shares, each encrypted to the public
+//	@Description	keyset of the operator who holds it. The service key that was stored before shares were enabled is
+//	@Description	split, and deleted from storage, when there is one. Shares are only created once.
+//	@Tags			KeyStoreAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateKeySharesRequest	true	"request body"
+//	@Success		201		{object}	CreateKeySharesResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		409		{string}	string	"Conflict"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/keystore/shares [put]
+func (kr KeySharesRouter) CreateKeyShares(c *gin.Context) {
+	var request CreateKeySharesRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		errMsg := "invalid create key shares request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		errMsg := "invalid create key shares request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := kr.shares.CreateShares(c, keystore.CreateSharesRequest{PublicKeysets: request.PublicKeysets})
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, keystore.ErrAlreadyInitialized) {
+			statusCode = http.StatusConflict
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not create key shares", statusCode)
+		return
+	}
+	framework.Respond(c, CreateKeySharesResponse{Shares: resp.Shares, Status: resp.Status}, http.StatusCreated)
+}
+
+type UnsealKeyStoreRequest struct {
+	// Share of the service key, base58 encoded, as decrypted with the private keyset of its operator.
+	Share string `json:"share" validate:"required"`
+}
+
+// UnsealKeyStore godoc
+//
+//	@Summary		Unseal Key Store
+//	@Description	Submits a share of the service key of the keystore to this instance. Once as many shares as the
+//	@Description	threshold were submitted, the service key is reconstructed from them, and the keystore is unsealed.
+//	@Description	Shares that aren't one of the service key's, or that were already submitted, are rejected.
+//	@Tags			KeyStoreAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		UnsealKeyStoreRequest	true	"request body"
+//	@Success		200		{object}	keystore.SealStatus
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		409		{string}	string	"Conflict"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/keystore/unseal [put]
+func (kr KeySharesRouter) UnsealKeyStore(c *gin.Context) {
+	var request UnsealKeyStoreRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		errMsg := "invalid unseal key store request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		errMsg := "invalid unseal key store request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	status, err := kr.shares.Unseal(c, request.Share)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, keystore.ErrInvalidShare):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, keystore.ErrNotInitialized):
+			statusCode = http.StatusConflict
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not unseal key store", statusCode)
+		return
+	}
+	framework.Respond(c, status, http.StatusOK)
+}
@@ -17,11 +17,13 @@ import (
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 )
 
@@ -152,6 +154,7 @@ type ListManifestResponse struct {
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
 //	@Success		200	{object}	ListManifestResponse
+//	@Header			200	{string}	ETag	"ETag of the manifest, for If-Match"
 //	@Failure		400	{string}	string	"Bad request"
 //	@Router			/v1/manifests/{id} [get]
 func (mr ManifestRouter) GetManifest(c *gin.Context) {
@@ -176,8 +179,47 @@ func (mr ManifestRouter) GetManifest(c *gin.Context) {
 	framework.Respond(c, resp, http.StatusOK)
 }
 
+type GetManifestRequestObjectResponse struct {
+	// JWT with the manifest in a top level `credential_manifest` claim, signed by the manifest's issuer with the key
+	// the manifest was created with.
+	ManifestJWT keyaccess.JWT `json:"manifestJwt"`
+}
+
+// GetManifestRequestObject godoc
+//
+//	@Summary		Get signed manifest
+//	@Description	Get a credential manifest by its id, signed as a JWT by its issuer, so that wallets can verify that the
+//	@Description	issuer made the manifest before applying to it. The JWT's kid is the manifest's verification method, and its
+//	@Description	`credential_manifest` claim is the manifest.
+//	@Tags			ManifestAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetManifestRequestObjectResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Router			/v1/manifests/{id}/request [get]
+func (mr ManifestRouter) GetManifestRequestObject(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get signed manifest without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	signed, err := mr.service.SignManifest(c, model.SignManifestRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get signed manifest with id: %s", *id)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, GetManifestRequestObjectResponse{ManifestJWT: signed.ManifestJWT}, http.StatusOK)
+}
+
 type ListManifestsResponse struct {
 	Manifests []ListManifestResponse `json:"manifests"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListManifests godoc
@@ -190,12 +232,20 @@ type ListManifestsResponse struct {
 //	@Param			issuer	query		string	false	"string issuer"
 //	@Param			schema	query		string	false	"string schema"
 //	@Param			subject	query		string	false	"string subject"
-//	@Success		200		{object}	ListManifestsResponse
-//	@Failure		400		{string}	string	"Bad request"
-//	@Failure		500		{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListManifestsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of manifests across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/manifests [get]
 func (mr ManifestRouter) ListManifests(c *gin.Context) {
-	gotManifests, err := mr.service.ListManifests(c)
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	page := pageRequest.ToServicePage()
+	gotManifests, err := mr.service.ListManifestsPage(c, model.ListManifestsPageRequest{PageToken: page.Token, PageSize: page.Size})
 	if err != nil {
 		errMsg := "could not list manifests"
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
@@ -211,6 +261,10 @@ func (mr ManifestRouter) ListManifests(c *gin.Context) {
 	}
 
 	resp := ListManifestsResponse{Manifests: manifests}
+	pagination.SetTotalCount(c, gotManifests.TotalCount)
+	if pagination.MaybeSetNextPageToken(c, gotManifests.NextPageToken, &resp.NextPageToken) {
+		return
+	}
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -222,8 +276,11 @@ func (mr ManifestRouter) ListManifests(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
+//	@Param			If-Match	header		string	false	"ETag of the manifest, which must still match for it to be deleted"
 //	@Success		204	{string}	string	"No Content"
 //	@Failure		400	{string}	string	"Bad request"
+//	@Failure		412	{string}	string	"Precondition failed"
+//	@Failure		428	{string}	string	"Precondition required"
 //	@Failure		500	{string}	string	"Internal server error"
 //	@Router			/v1/manifests/{id} [delete]
 func (mr ManifestRouter) DeleteManifest(c *gin.Context) {
@@ -263,7 +320,7 @@ func (sar SubmitApplicationRequest) toServiceRequest() (*model.SubmitApplication
 	}
 	res, _ := json.Marshal(token)
 	logrus.Debugln("ApplicationJWT")
-	logrus.Debugln("%s", string(res))
+	logrus.Debugln(string(res))
 	iss := token.Issuer()
 	if iss == "" {
 		return nil, errors.New("credential application token missing iss")
@@ -316,6 +373,8 @@ type SubmitApplicationResponse struct {
 	// this is an any type to union Data Integrity and JWT style VCs
 	Credentials []any         `json:"verifiableCredentials,omitempty"`
 	ResponseJWT keyaccess.JWT `json:"responseJwt,omitempty"`
+	// Why each input descriptor of a denied application was denied.
+	DeniedInputDescriptors []manifeststg.DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty"`
 }
 
 // SubmitApplication godoc
@@ -329,6 +388,7 @@ type SubmitApplicationResponse struct {
 //	@Param			request	body		SubmitApplicationRequest	true	"request body"
 //	@Success		201		{object}	Operation					"Operation with a SubmitApplicationResponse type in the `result.response` field."
 //	@Failure		400		{string}	string						"Bad request"
+//	@Failure		409		{string}	string						"The JWT was sent before"
 //	@Failure		500		{string}	string						"Internal server error"
 //	@Router			/v1/manifests/applications [put]
 func (mr ManifestRouter) SubmitApplication(c *gin.Context) {
@@ -396,6 +456,9 @@ func (mr ManifestRouter) GetApplication(c *gin.Context) {
 
 type ListApplicationsResponse struct {
 	Applications []manifestsdk.CredentialApplication `json:"applications"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListApplications godoc
@@ -405,10 +468,18 @@ type ListApplicationsResponse struct {
 //	@Tags			ApplicationAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListApplicationsResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListApplicationsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of applications across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/manifests/applications [get]
 func (mr ManifestRouter) ListApplications(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	gotApplications, err := mr.service.ListApplications(c)
 	if err != nil {
 		errMsg := "could not list applications"
@@ -416,7 +487,12 @@ func (mr ManifestRouter) ListApplications(c *gin.Context) {
 		return
 	}
 
-	resp := ListApplicationsResponse{Applications: gotApplications.Applications}
+	var resp ListApplicationsResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotApplications.Applications, func(a manifestsdk.CredentialApplication) string { return a.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Applications = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -454,6 +530,8 @@ type GetResponseResponse struct {
 	// this is an interface type to union Data Integrity and JWT style VCs
 	Credentials any           `json:"verifiableCredentials,omitempty"`
 	ResponseJWT keyaccess.JWT `json:"responseJwt"`
+	// Why each input descriptor of a denied application was denied.
+	DeniedInputDescriptors []manifeststg.DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty"`
 }
 
 // GetResponse godoc
@@ -487,12 +565,61 @@ func (mr ManifestRouter) GetResponse(c *gin.Context) {
 		Response:    gotResponse.Response,
 		Credentials: gotResponse.Credentials,
 		ResponseJWT: gotResponse.ResponseJWT,
+
+		DeniedInputDescriptors: gotResponse.DeniedInputDescriptors,
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// GetApplicationResponse godoc
+//
+//	@Summary		Get application response
+//	@Description	Get the credential response to an application by the application's id. Once an application is approved,
+//	@Description	this is the signed fulfillment along with the credentials issued for the manifest's output descriptors.
+//	@Description	Once it's denied, this is the denial.
+//	@Tags			ApplicationAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetResponseResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Application has not been reviewed"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/v1/manifests/applications/{id}/response [get]
+func (mr ManifestRouter) GetApplicationResponse(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot get application response without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	gotResponse, err := mr.service.GetApplicationResponse(c, model.GetApplicationResponseRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not get response to application with id: %s", *id)
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, manifest.ErrApplicationNotReviewed) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	resp := GetResponseResponse{
+		Response:    gotResponse.Response,
+		Credentials: gotResponse.Credentials,
+		ResponseJWT: gotResponse.ResponseJWT,
+
+		DeniedInputDescriptors: gotResponse.DeniedInputDescriptors,
 	}
 	framework.Respond(c, resp, http.StatusOK)
 }
 
 type ListResponsesResponse struct {
 	Responses []manifestsdk.CredentialResponse `json:"responses"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListResponses godoc
@@ -502,10 +629,18 @@ type ListResponsesResponse struct {
 //	@Tags			ResponseAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListResponsesResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListResponsesResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of responses across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/manifests/responses [get]
 func (mr ManifestRouter) ListResponses(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	gotResponses, err := mr.service.ListResponses(c)
 	if err != nil {
 		errMsg := "could not list responses"
@@ -513,7 +648,12 @@ func (mr ManifestRouter) ListResponses(c *gin.Context) {
 		return
 	}
 
-	resp := ListResponsesResponse{Responses: gotResponses.Responses}
+	var resp ListResponsesResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotResponses.Responses, func(r manifestsdk.CredentialResponse) string { return r.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Responses = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -553,26 +693,36 @@ type ReviewApplicationRequest struct {
 	// Overrides to apply to the credentials that will be created. Keys are the ID that corresponds to an
 	// OutputDescriptor.ID from the manifest.
 	CredentialOverrides map[string]model.CredentialOverride `json:"credentialOverrides,omitempty"`
+
+	// Input descriptors of the manifest's presentation definition that the application is denied for, each with a
+	// code saying why. They're listed in the denial of the credential response, whose reason describes them when no
+	// reason is given. Only used upon denial.
+	DeniedInputDescriptors []manifeststg.DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty" validate:"omitempty,dive"`
 }
 
 func (r ReviewApplicationRequest) toServiceRequest(id string) model.ReviewApplicationRequest {
 	return model.ReviewApplicationRequest{
-		ID:                  id,
-		Approved:            r.Approved,
-		Reason:              r.Reason,
-		CredentialOverrides: r.CredentialOverrides,
+		ID:                     id,
+		Approved:               r.Approved,
+		Reason:                 r.Reason,
+		CredentialOverrides:    r.CredentialOverrides,
+		DeniedInputDescriptors: r.DeniedInputDescriptors,
 	}
 }
 
 // ReviewApplication godoc
 //
 //	@Summary		Reviews an application
-//	@Description	Reviewing an application either fulfills or denies the credential.
+//	@Description	Reviewing an application either fulfills or denies the credential. Denials may list the input
+//	@Description	descriptors the application is denied for, each with a code, and are published to the Application Deny
+//	@Description	webhooks.
 //	@Tags			ApplicationAPI
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		ReviewApplicationRequest	true	"request body"
+//	@Param			Prefer	header		string	false	"Set to `respond-async` to run the request in the background"
 //	@Success		201		{object}	SubmitApplicationResponse	"Credential Response"
+//	@Success		202		{object}	Operation	"Operation running the request, when it prefers respond-async"
 //	@Failure		400		{string}	string						"Bad request"
 //	@Failure		500		{string}	string						"Internal server error"
 //	@Router			/v1/manifests/applications/{id}/review [put]
@@ -594,13 +744,18 @@ func (mr ManifestRouter) ReviewApplication(c *gin.Context) {
 	applicationResponse, err := mr.service.ReviewApplication(c, request.toServiceRequest(*id))
 	if err != nil {
 		errMsg := "failed reviewing application"
-		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, manifest.ErrInvalidDenial) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
 		return
 	}
 	framework.Respond(c, SubmitApplicationResponse{
-		Response:    applicationResponse.Response,
-		Credentials: applicationResponse.Credentials,
-		ResponseJWT: applicationResponse.ResponseJWT,
+		Response:               applicationResponse.Response,
+		Credentials:            applicationResponse.Credentials,
+		ResponseJWT:            applicationResponse.ResponseJWT,
+		DeniedInputDescriptors: applicationResponse.DeniedInputDescriptors,
 	}, http.StatusCreated)
 }
 
@@ -668,6 +823,9 @@ func (mr ManifestRouter) serviceRequestFromRequest(request CreateManifestRequest
 type ListManifestRequestsResponse struct {
 	// The manifest requests matching the query.
 	Requests []model.Request `json:"manifestRequests"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListRequests godoc
@@ -677,10 +835,18 @@ type ListManifestRequestsResponse struct {
 //	@Tags			ManifestAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListManifestRequestsResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListManifestRequestsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of manifest requests across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/manifests/requests [get]
 func (mr ManifestRouter) ListRequests(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	svcResponse, err := mr.service.ListRequests(c)
 
 	if err != nil {
@@ -688,9 +854,12 @@ func (mr ManifestRouter) ListRequests(c *gin.Context) {
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
 		return
 	}
-	resp := ListManifestRequestsResponse{
-		Requests: svcResponse.ManifestRequests,
+	var resp ListManifestRequestsResponse
+	page, failed := pagination.Paginate(c, pageRequest, svcResponse.ManifestRequests, func(r model.Request) string { return r.ID }, &resp.NextPageToken)
+	if failed {
+		return
 	}
+	resp.Requests = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
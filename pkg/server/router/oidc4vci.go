@@ -0,0 +1,228 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vci"
+)
+
+type OIDC4VCIRouter struct {
+	service *oidc4vci.Service
+}
+
+func NewOIDC4VCIRouter(s svcframework.Service) (*OIDC4VCIRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	oidc4vciService, ok := s.(*oidc4vci.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create oidc4vci router with service type: %s", s.Type())
+	}
+	return &OIDC4VCIRouter{service: oidc4vciService}, nil
+}
+
+type CreateOfferRequest struct {
+	// ID of the manifest whose credentials are offered.
+	ManifestID string `json:"manifestId" validate:"required"`
+
+	// IDs of the output descriptors of the manifest whose credentials are offered. All of them when empty.
+	OutputDescriptorIDs []string `json:"outputDescriptorIds,omitempty"`
+
+	// Data of the subject of the offered credentials, which the credentials are issued with.
+	Data map[string]any `json:"data,omitempty"`
+
+	// Whether wallets need a PIN to redeem the offer, which is returned to be given to the holder apart from the offer.
+	UserPinRequired bool `json:"userPinRequired,omitempty"`
+}
+
+func (c CreateOfferRequest) toServiceRequest() oidc4vci.CreateOfferRequest {
+	return oidc4vci.CreateOfferRequest{
+		ManifestID:          c.ManifestID,
+		OutputDescriptorIDs: c.OutputDescriptorIDs,
+		Data:                c.Data,
+		UserPinRequired:     c.UserPinRequired,
+	}
+}
+
+type CreateOfferResponse struct {
+	Offer oidc4vci.Offer `json:"offer"`
+
+	// The credential offer to give the wallet. It holds the pre-authorized code, so it's only returned here.
+	CredentialOffer oidc4vci.CredentialOffer `json:"credentialOffer"`
+
+	// The credential offer as an openid-credential-offer URI, e.g. to show as a QR code.
+	CredentialOfferURI string `json:"credentialOfferUri"`
+
+	// PIN to give the holder apart from the offer, when one is required. It's only returned here.
+	UserPin string `json:"userPin,omitempty"`
+}
+
+// CreateOffer godoc
+//
+//	@Summary		Create credential offer
+//	@Description	Creates an OpenID for Verifiable Credential Issuance offer of the credentials of a manifest, which a
+//	@Description	wallet redeems with the offer's pre-authorized code, in exchange for a proof of possession of a DID,
+//	@Description	that the credentials are issued to. The offer and its PIN are only returned once.
+//	@Tags			OIDC4VCIAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateOfferRequest	true	"request body"
+//	@Success		201		{object}	CreateOfferResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/oidc4vci/offers [put]
+func (or OIDC4VCIRouter) CreateOffer(c *gin.Context) {
+	var request CreateOfferRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create offer request", http.StatusBadRequest)
+		return
+	}
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create offer request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := or.service.CreateOffer(c, request.toServiceRequest())
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create offer", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, CreateOfferResponse{
+		Offer:              resp.Offer,
+		CredentialOffer:    resp.CredentialOffer,
+		CredentialOfferURI: resp.CredentialOfferURI,
+		UserPin:            resp.UserPin,
+	}, http.StatusCreated)
+}
+
+type GetOfferResponse struct {
+	Offer oidc4vci.Offer `json:"offer"`
+}
+
+// GetOffer godoc
+//
+//	@Summary		Get credential offer
+//	@Description	Gets a credential offer, which tells whether its pre-authorized code was redeemed.
+//	@Tags			OIDC4VCIAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetOfferResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Router			/v1/oidc4vci/offers/{id} [get]
+func (or OIDC4VCIRouter) GetOffer(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		framework.LoggingRespondErrMsg(c, "cannot get offer without ID parameter", http.StatusBadRequest)
+		return
+	}
+
+	offer, err := or.service.GetOffer(c, oidc4vci.GetOfferRequest{ID: *id})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, fmt.Sprintf("could not get offer with id: %s", *id), http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, GetOfferResponse{Offer: *offer}, http.StatusOK)
+}
+
+// GetIssuerMetadata responds with the metadata of the credential issuer, which wallets read from
+// /.well-known/openid-credential-issuer.
+func (or OIDC4VCIRouter) GetIssuerMetadata(c *gin.Context) {
+	metadata, err := or.service.Metadata(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get credential issuer metadata", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, metadata, http.StatusOK)
+}
+
+// GetAuthorizationServerMetadata responds with the metadata of the authorization server that redeems pre-authorized
+// codes, which wallets read from /.well-known/oauth-authorization-server.
+func (or OIDC4VCIRouter) GetAuthorizationServerMetadata(c *gin.Context) {
+	framework.Respond(c, or.service.AuthorizationServerMetadata(), http.StatusOK)
+}
+
+// Token redeems the pre-authorized code of an offer for an access token. It's an OAuth 2.0 token endpoint, so the
+// request is form encoded, and errors are OAuth 2.0 errors.
+func (or OIDC4VCIRouter) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	resp, err := or.service.Token(c, oidc4vci.TokenRequest{
+		GrantType:         c.PostForm("grant_type"),
+		PreAuthorizedCode: c.PostForm("pre-authorized_code"),
+		UserPin:           c.PostForm("user_pin"),
+	})
+	if err != nil {
+		respondOIDC4VCIError(c, err, "could not redeem pre-authorized code")
+		return
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+type credentialProof struct {
+	ProofType string `json:"proof_type"`
+	JWT       string `json:"jwt"`
+}
+
+type credentialRequest struct {
+	Format               string           `json:"format"`
+	CredentialIdentifier string           `json:"credential_identifier,omitempty"`
+	Proof                *credentialProof `json:"proof,omitempty"`
+}
+
+// Credential issues an offered credential to the wallet with the access token in the Authorization header, which
+// proves possession of the DID the credential is issued to. Errors are OAuth 2.0 errors.
+func (or OIDC4VCIRouter) Credential(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	authorization := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		respondOIDC4VCIError(c, &oidc4vci.Error{Code: oidc4vci.ErrorInvalidToken, Description: "a bearer access token is required"}, "")
+		return
+	}
+	// wallets send members of later drafts too, which are ignored rather than rejected
+	var request credentialRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+		respondOIDC4VCIError(c, &oidc4vci.Error{Code: oidc4vci.ErrorInvalidRequest, Description: "invalid credential request"}, "")
+		return
+	}
+	serviceRequest := oidc4vci.CredentialRequest{
+		AccessToken:          strings.TrimPrefix(authorization, "Bearer "),
+		Format:               request.Format,
+		CredentialIdentifier: request.CredentialIdentifier,
+	}
+	if request.Proof != nil {
+		serviceRequest.ProofType = request.Proof.ProofType
+		serviceRequest.ProofJWT = keyaccess.JWT(request.Proof.JWT)
+	}
+	resp, err := or.service.IssueCredential(c, serviceRequest)
+	if err != nil {
+		respondOIDC4VCIError(c, err, "could not issue credential")
+		return
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// respondOIDC4VCIError responds with an OAuth 2.0 error when the wallet got the request wrong, and with an internal
+// server error otherwise.
+func respondOIDC4VCIError(c *gin.Context, err error, errMsg string) {
+	var oidcErr *oidc4vci.Error
+	if !errors.As(err, &oidcErr) {
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusBadRequest
+	if oidcErr.Code == oidc4vci.ErrorInvalidToken {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", oidcErr.Code))
+	}
+	// the error is responded as is, rather than as a problem
+	framework.Respond(c, *oidcErr, status)
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vp"
+)
+
+type OIDC4VPRouter struct {
+	service *oidc4vp.Service
+}
+
+func NewOIDC4VPRouter(s svcframework.Service) (*OIDC4VPRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	oidc4vpService, ok := s.(*oidc4vp.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create oidc4vp router with service type: %s", s.Type())
+	}
+	return &OIDC4VPRouter{service: oidc4vpService}, nil
+}
+
+type CreateAuthorizationRequestRequest struct {
+	// ID of the presentation definition the wallet is asked to present credentials for.
+	PresentationDefinitionID string `json:"presentationDefinitionId" validate:"required"`
+
+	// Whether the wallet is asked for a Self-Issued OpenID Provider v2 ID token too, which authenticates the holder.
+	IDTokenRequired bool `json:"idTokenRequired,omitempty"`
+}
+
+type CreateAuthorizationRequestResponse struct {
+	Request oidc4vp.Request `json:"request"`
+
+	// The authorization request to give the wallet.
+	AuthorizationRequest oidc4vp.AuthorizationRequest `json:"authorizationRequest"`
+
+	// The authorization request as an openid4vp URI, e.g. to show as a QR code.
+	AuthorizationRequestURI string `json:"authorizationRequestUri"`
+}
+
+// CreateAuthorizationRequest godoc
+//
+//	@Summary		Create authorization request
+//	@Description	Creates an OpenID for Verifiable Presentations request for a presentation of a presentation
+//	@Description	definition, which a wallet responds to by posting the presentation to the service. The presentation
+//	@Description	is submitted as a presentation submission, which is reviewed like any other.
+//	@Tags			OIDC4VPAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateAuthorizationRequestRequest	true	"request body"
+//	@Success		201		{object}	CreateAuthorizationRequestResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/oidc4vp/requests [put]
+func (or OIDC4VPRouter) CreateAuthorizationRequest(c *gin.Context) {
+	var request CreateAuthorizationRequestRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create authorization request request", http.StatusBadRequest)
+		return
+	}
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create authorization request request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := or.service.CreateAuthorizationRequest(c, oidc4vp.CreateAuthorizationRequestRequest{
+		PresentationDefinitionID: request.PresentationDefinitionID,
+		IDTokenRequired:          request.IDTokenRequired,
+	})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create authorization request", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, CreateAuthorizationRequestResponse{
+		Request:                 resp.Request,
+		AuthorizationRequest:    resp.AuthorizationRequest,
+		AuthorizationRequestURI: resp.AuthorizationRequestURI,
+	}, http.StatusCreated)
+}
+
+type GetAuthorizationRequestResponse struct {
+	Request oidc4vp.Request `json:"request"`
+}
+
+// GetAuthorizationRequest godoc
+//
+//	@Summary		Get authorization request
+//	@Description	Gets an authorization request, which tells whether the wallet responded, and the presentation
+//	@Description	submission its presentation was submitted as.
+//	@Tags			OIDC4VPAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetAuthorizationRequestResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Router			/v1/oidc4vp/requests/{id} [get]
+func (or OIDC4VPRouter) GetAuthorizationRequest(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		framework.LoggingRespondErrMsg(c, "cannot get authorization request without ID parameter", http.StatusBadRequest)
+		return
+	}
+
+	request, err := or.service.GetRequest(c, oidc4vp.GetRequestRequest{ID: *id})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, fmt.Sprintf("could not get authorization request with id: %s", *id), http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, GetAuthorizationRequestResponse{Request: *request}, http.StatusOK)
+}
+
+// Respond accepts the response of a wallet to an authorization request. It's the response URI of direct_post
+// responses, so the request is form encoded, and errors are OAuth 2.0 errors.
+func (or OIDC4VPRouter) Respond(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	_, err := or.service.Respond(c, oidc4vp.ResponseRequest{
+		VPToken:                c.PostForm("vp_token"),
+		PresentationSubmission: c.PostForm("presentation_submission"),
+		State:                  c.PostForm("state"),
+		IDToken:                c.PostForm("id_token"),
+	})
+	if err != nil {
+		var oidcErr *oidc4vp.Error
+		if !errors.As(err, &oidcErr) {
+			framework.LoggingRespondErrWithMsg(c, err, "could not accept response", http.StatusInternalServerError)
+			return
+		}
+		// the error is responded as is, rather than as a problem
+		framework.Respond(c, *oidcErr, http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, map[string]any{}, http.StatusOK)
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI returns a handler that serves the OpenAPI 3 document of the API, which can be used to generate clients.
+func OpenAPI(document []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", document)
+	}
+}
+
+// Swagger returns a handler that serves the Swagger 2.0 specification of the API, as generated by `mage spec`.
+func Swagger(spec []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml", spec)
+	}
+}
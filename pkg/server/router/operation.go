@@ -10,6 +10,7 @@ import (
 	"go.einride.tech/aip/filtering"
 
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 	manifestsvc "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation"
@@ -135,6 +136,9 @@ func (r listOperationsRequest) toServiceRequest() (operation.ListOperationsReque
 
 type ListOperationsResponse struct {
 	Operations []Operation `json:"operations"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListOperations godoc
@@ -146,13 +150,20 @@ type ListOperationsResponse struct {
 //	@Produce		json
 //	@Param			parent	query		string					false	"The name of the parent's resource. For example: `?parent=/presentation/submissions`"
 //	@Param			filter	query		string					false	"A standard filter expression conforming to https://google.aip.dev/160. For example: `?filter=done="true"`"
-//	@Success		200		{object}	ListOperationsResponse	"OK"
-//	@Failure		400		{string}	string					"Bad request"
-//	@Failure		500		{string}	string					"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListOperationsResponse	"OK"
+//	@Header			200			{integer}	X-Total-Count			"Number of operations matching the request across all pages"
+//	@Failure		400			{string}	string					"Bad request"
+//	@Failure		500			{string}	string					"Internal server error"
 //	@Router			/v1/operations [get]
 func (o OperationRouter) ListOperations(c *gin.Context) {
-	parentParam := framework.GetParam(c, ParentParam)
-	filterParam := framework.GetParam(c, FilterParam)
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	parentParam := framework.GetQueryValue(c, ParentParam)
+	filterParam := framework.GetQueryValue(c, FilterParam)
 	var request listOperationsRequest
 	if parentParam != nil {
 		unescaped, err := url.QueryUnescape(*parentParam)
@@ -192,10 +203,16 @@ func (o OperationRouter) ListOperations(c *gin.Context) {
 		return
 	}
 
-	resp := ListOperationsResponse{Operations: make([]Operation, 0, len(ops.Operations))}
+	operations := make([]Operation, 0, len(ops.Operations))
 	for _, op := range ops.Operations {
-		resp.Operations = append(resp.Operations, routerModel(op))
+		operations = append(operations, routerModel(op))
+	}
+	var resp ListOperationsResponse
+	page, failed := pagination.Paginate(c, pageRequest, operations, func(op Operation) string { return op.ID }, &resp.NextPageToken)
+	if failed {
+		return
 	}
+	resp.Operations = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -233,7 +250,7 @@ func routerModel(op operation.Operation) Operation {
 //	@Success		200	{object}	Operation	"OK"
 //	@Failure		400	{string}	string		"Bad request"
 //	@Failure		500	{string}	string		"Internal server error"
-//	@Router			/v1/operations/cancel/{id} [get]
+//	@Router			/v1/operations/cancel/{id} [put]
 func (o OperationRouter) CancelOperation(c *gin.Context) {
 	id := framework.GetParam(c, IDParam)
 	if id == nil {
@@ -7,7 +7,6 @@ import (
 	"net/url"
 
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
-	"github.com/TBD54566975/ssi-sdk/credential/integrity"
 	"github.com/gin-gonic/gin"
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
@@ -15,7 +14,6 @@ import (
 
 	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 
-	credint "github.com/tbd54566975/ssi-service/internal/credential"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
@@ -129,6 +127,76 @@ func definitionFromRequest(request CreatePresentationDefinitionRequest) (*exchan
 	return req, nil
 }
 
+type ValidatePresentationDefinitionRequest struct {
+	// Presentation definition to validate, as Presentation Exchange JSON. Either it or requirements must be set.
+	PresentationDefinition json.RawMessage `json:"presentation_definition,omitempty" swaggertype:"object"`
+
+	// Name and purpose of the definition that requirements are expanded into.
+	Name    string `json:"name,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+
+	// Requirements to expand into a presentation definition, one input descriptor each, and validate.
+	Requirements []model.Requirement `json:"requirements,omitempty" validate:"omitempty,dive"`
+}
+
+type ValidatePresentationDefinitionResponse struct {
+	// Whether the definition has no issues.
+	Valid bool `json:"valid"`
+
+	// Issues of the definition. Each has the JSONPath of the member of the definition that's at fault.
+	Issues []model.DefinitionIssue `json:"issues,omitempty"`
+
+	// The definition that was validated, as expanded from requirements when they were given. Absent when the
+	// definition couldn't be read at all.
+	PresentationDefinition *exchange.PresentationDefinition `json:"presentation_definition,omitempty"`
+}
+
+// ValidateDefinition godoc
+//
+//	@Summary		Validate PresentationDefinition
+//	@Description	Validate a presentation definition, without creating it. Besides checking it against the Presentation
+//	@Description	Exchange schema, fields that are ignored because they're unknown, paths that aren't valid JSONPath,
+//	@Description	filters that no value satisfies, and submission requirements that no submission satisfies are reported
+//	@Description	as issues. Instead of a definition, requirements can be given, which are expanded into one.
+//	@Tags			PresentationDefinitionAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ValidatePresentationDefinitionRequest	true	"request body"
+//	@Success		200		{object}	ValidatePresentationDefinitionResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Router			/v1/presentations/definitions/validation [put]
+func (pr PresentationRouter) ValidateDefinition(c *gin.Context) {
+	var request ValidatePresentationDefinitionRequest
+	errMsg := "Invalid Validate Presentation Definition Request"
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	serviceResp, err := pr.service.ValidatePresentationDefinition(c, model.ValidatePresentationDefinitionRequest{
+		PresentationDefinition: request.PresentationDefinition,
+		Name:                   request.Name,
+		Purpose:                request.Purpose,
+		Requirements:           request.Requirements,
+	})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	resp := ValidatePresentationDefinitionResponse{
+		Valid:                  serviceResp.Valid,
+		Issues:                 serviceResp.Issues,
+		PresentationDefinition: serviceResp.PresentationDefinition,
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
 type GetPresentationDefinitionResponse struct {
 	PresentationDefinition exchange.PresentationDefinition `json:"presentation_definition,omitempty"`
 }
@@ -142,6 +210,7 @@ type GetPresentationDefinitionResponse struct {
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
 //	@Success		200	{object}	GetPresentationDefinitionResponse
+//	@Header			200	{string}	ETag	"ETag of the presentation definition, for If-Match"
 //	@Failure		400	{string}	string	"Bad request"
 //	@Router			/v1/presentations/definitions/{id} [get]
 func (pr PresentationRouter) GetDefinition(c *gin.Context) {
@@ -167,6 +236,9 @@ func (pr PresentationRouter) GetDefinition(c *gin.Context) {
 
 type ListDefinitionsResponse struct {
 	Definitions []*exchange.PresentationDefinition `json:"definitions,omitempty"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListDefinitions godoc
@@ -176,11 +248,18 @@ type ListDefinitionsResponse struct {
 //	@Tags			PresentationDefinitionAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListDefinitionsResponse
-//	@Failure		400	{string}	string	"Bad request"
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListDefinitionsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of presentation definitions across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/presentations/definitions [get]
 func (pr PresentationRouter) ListDefinitions(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	svcResponse, err := pr.service.ListDefinitions(c)
 	if err != nil {
 		errMsg := "could not list definitions"
@@ -188,7 +267,12 @@ func (pr PresentationRouter) ListDefinitions(c *gin.Context) {
 		return
 	}
 
-	resp := ListDefinitionsResponse{Definitions: svcResponse.Definitions}
+	var resp ListDefinitionsResponse
+	page, failed := pagination.Paginate(c, pageRequest, svcResponse.Definitions, func(d *exchange.PresentationDefinition) string { return d.ID }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Definitions = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -200,8 +284,11 @@ func (pr PresentationRouter) ListDefinitions(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
+//	@Param			If-Match	header		string	false	"ETag of the presentation definition, which must still match for it to be deleted"
 //	@Success		204	{string}	string	"No Content"
 //	@Failure		400	{string}	string	"Bad request"
+//	@Failure		412	{string}	string	"Precondition failed"
+//	@Failure		428	{string}	string	"Precondition required"
 //	@Failure		500	{string}	string	"Internal server error"
 //	@Router			/v1/presentations/definitions/{id} [delete]
 func (pr PresentationRouter) DeleteDefinition(c *gin.Context) {
@@ -230,37 +317,7 @@ type CreateSubmissionRequest struct {
 
 func (r CreateSubmissionRequest) toServiceRequest() (*model.CreateSubmissionRequest, error) {
 	logrus.Debugln(r.SubmissionJWT.String())
-	_, _, vp, err := integrity.ParseVerifiablePresentationFromJWT(r.SubmissionJWT.String())
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing presentation from jwt")
-	}
-	if err = vp.IsValid(); err != nil {
-		return nil, errors.Wrap(err, "verifying vp validity")
-	}
-
-	submissionData, err := json.Marshal(vp.PresentationSubmission)
-	if err != nil {
-		return nil, errors.Wrap(err, "marshalling presentation_submission")
-	}
-	var s exchange.PresentationSubmission
-	if err = json.Unmarshal(submissionData, &s); err != nil {
-		return nil, errors.Wrap(err, "unmarshalling presentation submission")
-	}
-	if err = s.IsValid(); err != nil {
-		return nil, errors.Wrap(err, "verifying submission validity")
-	}
-	vp.PresentationSubmission = s
-
-	credContainers, err := credint.NewCredentialContainerFromArray(vp.VerifiableCredential)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing verifiable credential array")
-	}
-
-	return &model.CreateSubmissionRequest{
-		Presentation:  *vp,
-		SubmissionJWT: r.SubmissionJWT,
-		Submission:    s,
-		Credentials:   credContainers}, nil
+	return model.NewCreateSubmissionRequest(r.SubmissionJWT, nil)
 }
 
 // CreateSubmission godoc
@@ -273,6 +330,7 @@ func (r CreateSubmissionRequest) toServiceRequest() (*model.CreateSubmissionRequ
 //	@Param			request	body		CreateSubmissionRequest	true	"request body"
 //	@Success		201		{object}	Operation				"The type of response is Submission once the operation has finished."
 //	@Failure		400		{string}	string					"Bad request"
+//	@Failure		409		{string}	string					"The JWT was sent before"
 //	@Failure		500		{string}	string					"Internal server error"
 //	@Router			/v1/presentations/submissions [put]
 func (pr PresentationRouter) CreateSubmission(c *gin.Context) {
@@ -292,7 +350,11 @@ func (pr PresentationRouter) CreateSubmission(c *gin.Context) {
 	operation, err := pr.service.CreateSubmission(c, *req)
 	if err != nil {
 		errMsg := "cannot create submission"
-		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, presentation.ErrInvalidRequestNonce) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
 		return
 	}
 
@@ -361,6 +423,7 @@ type ListSubmissionResponse struct {
 //	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
 //	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
 //	@Success		200			{object}	ListSubmissionResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of submissions matching the filter across all pages"
 //	@Failure		400			{string}	string	"Bad request"
 //	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/presentations/submissions [get]
@@ -423,6 +486,7 @@ func (pr PresentationRouter) ListSubmissions(c *gin.Context) {
 		return
 	}
 	resp := ListSubmissionResponse{Submissions: listResp.Submissions}
+	pagination.SetTotalCount(c, listResp.TotalCount)
 	if pagination.MaybeSetNextPageToken(c, listResp.NextPageToken, &resp.NextPageToken) {
 		return
 	}
@@ -576,6 +640,9 @@ func (pr PresentationRouter) GetRequest(c *gin.Context) {
 type ListPresentationRequestsResponse struct {
 	// The presentation requests matching the query.
 	Requests []model.Request `json:"presentationRequests"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListRequests godoc
@@ -585,10 +652,18 @@ type ListPresentationRequestsResponse struct {
 //	@Tags			PresentationRequestAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListPresentationRequestsResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListPresentationRequestsResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of presentation requests across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/presentations/requests [get]
 func (pr PresentationRouter) ListRequests(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	svcResponse, err := pr.service.ListRequests(c)
 
 	if err != nil {
@@ -596,9 +671,12 @@ func (pr PresentationRouter) ListRequests(c *gin.Context) {
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
 		return
 	}
-	resp := ListPresentationRequestsResponse{
-		Requests: svcResponse.PresentationRequests,
+	var resp ListPresentationRequestsResponse
+	page, failed := pagination.Paginate(c, pageRequest, svcResponse.PresentationRequests, func(r model.Request) string { return r.ID }, &resp.NextPageToken)
+	if failed {
+		return
 	}
+	resp.Requests = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -194,6 +194,44 @@ func TestPresentationDefinitionService(t *testing.T) {
 				_, err = service.GetRequest(context.Background(), &model.GetRequestRequest{ID: req.ID})
 				assert.Error(t, err)
 				assert.ErrorContains(t, err, "request not found")
+
+				// the nonce of the request is deleted with it
+				isRequestNonce, err := service.IsRequestNonce(context.Background(), req.Nonce)
+				assert.NoError(t, err)
+				assert.False(t, isRequestNonce)
+			})
+
+			t.Run("Purges the nonces of expired requests", func(t *testing.T) {
+				pd := createPresentationDefinition(t)
+				_, err := service.CreatePresentationDefinition(context.Background(), model.CreatePresentationDefinitionRequest{
+					PresentationDefinition: *pd,
+				})
+				assert.NoError(t, err)
+				createRequest := func(expiration *time.Time) *model.Request {
+					req, err := service.CreateRequest(context.Background(), model.CreateRequestRequest{
+						PresentationRequest: model.Request{
+							Request: common.Request{
+								IssuerDID:            authorDID.DID.ID,
+								VerificationMethodID: authorDID.DID.VerificationMethod[0].ID,
+								Expiration:           expiration,
+							},
+							PresentationDefinitionID: pd.ID,
+						},
+					})
+					require.NoError(t, err)
+					return req
+				}
+				past := time.Now().Add(-time.Minute)
+				expired := createRequest(&past)
+				open := createRequest(&In30Seconds)
+
+				_, err = service.Purge(context.Background())
+				assert.NoError(t, err)
+				for nonce, want := range map[string]bool{expired.Nonce: false, open.Nonce: true} {
+					isRequestNonce, err := service.IsRequestNonce(context.Background(), nonce)
+					assert.NoError(t, err)
+					assert.Equal(t, want, isRequestNonce)
+				}
 			})
 
 			t.Run("Error returned when missing required fields", func(t *testing.T) {
@@ -3,6 +3,7 @@ package router
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -10,12 +11,19 @@ import (
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 )
 
-func Readiness(services []svcframework.Service) gin.HandlerFunc {
-	return readiness{getter: servicesToGet{services: services}}.ready
+// statusTimeout is how long services have to report their status, so that storage that doesn't respond doesn't hold up
+// readiness probes. Services that don't report in time are not ready.
+const statusTimeout = 800 * time.Millisecond
+
+// Readiness reports whether services are ready, and whether the server is, which it isn't once shuttingDown returns
+// true, so that load balancers stop sending it requests while it drains. shuttingDown may be nil.
+func Readiness(services []svcframework.Service, shuttingDown func() bool) gin.HandlerFunc {
+	return readiness{getter: servicesToGet{services: services}, shuttingDown: shuttingDown}.ready
 }
 
 type readiness struct {
-	getter serviceGetter
+	getter       serviceGetter
+	shuttingDown func() bool
 }
 
 type GetReadinessResponse struct {
@@ -29,28 +37,34 @@ type GetReadinessResponse struct {
 // Readiness godoc
 //
 //	@Summary		Readiness
-//	@Description	Readiness runs a number of application specific checks to see if all the relied upon services are
-//	@Description	healthy.
+//	@Description	Readiness runs a number of application specific checks to see if all the relied upon services,
+//	@Description	including storage, are healthy. Responds with a 503 when any of them are not, or once the service is
+//	@Description	shutting down, so that it can be used as a readiness probe. Services that don't report their status
+//	@Description	within 800ms, like storage that doesn't respond, are not ready.
 //	@Tags			Readiness
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	GetReadinessResponse
+//	@Failure		503	{object}	GetReadinessResponse
 //	@Router			/readiness [get]
 func (r readiness) ready(c *gin.Context) {
 	services := r.getter.getServices()
 	numServices := len(services)
 	readyServices := 0
-	statuses := make(map[svcframework.Type]svcframework.Status)
-	for _, s := range services {
-		status := s.Status()
-		statuses[s.Type()] = status
-		if status.Status == svcframework.StatusReady {
+	statuses := reportStatuses(services)
+	for _, status := range statuses {
+		if status.IsReady() {
 			readyServices++
 		}
 	}
 
 	var status svcframework.Status
-	if readyServices < numServices {
+	if r.shuttingDown != nil && r.shuttingDown() {
+		status = svcframework.Status{
+			Status:  svcframework.StatusNotReady,
+			Message: "shutting down",
+		}
+	} else if readyServices < numServices {
 		status = svcframework.Status{
 			Status:  svcframework.StatusNotReady,
 			Message: fmt.Sprintf("out of [%d] service, [%d] are ready", numServices, readyServices),
@@ -66,7 +80,47 @@ func (r readiness) ready(c *gin.Context) {
 		ServiceStatuses: statuses,
 	}
 
-	framework.Respond(c, response, http.StatusOK)
+	statusCode := http.StatusOK
+	if !status.IsReady() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	framework.Respond(c, response, statusCode)
+}
+
+// reportStatuses has every service report its status at once, and those that don't within statusTimeout not ready.
+func reportStatuses(services []svcframework.Service) map[svcframework.Type]svcframework.Status {
+	type report struct {
+		service svcframework.Type
+		status  svcframework.Status
+	}
+	// the channel is buffered so that services reporting late don't block once nobody waits for them
+	reports := make(chan report, len(services))
+	for _, s := range services {
+		go func(s svcframework.Service) {
+			reports <- report{service: s.Type(), status: s.Status()}
+		}(s)
+	}
+
+	statuses := make(map[svcframework.Type]svcframework.Status, len(services))
+	timeout := time.NewTimer(statusTimeout)
+	defer timeout.Stop()
+	for range services {
+		select {
+		case r := <-reports:
+			statuses[r.service] = r.status
+		case <-timeout.C:
+			for _, s := range services {
+				if _, reported := statuses[s.Type()]; !reported {
+					statuses[s.Type()] = svcframework.Status{
+						Status:  svcframework.StatusNotReady,
+						Message: fmt.Sprintf("didn't report its status within %s", statusTimeout),
+					}
+				}
+			}
+			return statuses
+		}
+	}
+	return statuses
 }
 
 // serviceGetter is a dependency of this readiness handler to know which service are available in the server
@@ -0,0 +1,102 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/did/resolution"
+)
+
+type ResolutionCacheRouter struct {
+	cache *resolution.CachingResolver
+}
+
+func NewResolutionCacheRouter(cache *resolution.CachingResolver) (*ResolutionCacheRouter, error) {
+	if cache == nil {
+		return nil, errors.New("resolution cache cannot be nil")
+	}
+	return &ResolutionCacheRouter{cache: cache}, nil
+}
+
+// GetResolutionCacheStats godoc
+//
+//	@Summary		Get Resolution Cache Stats
+//	@Description	Gets how many DID resolution results are cached, and how many resolutions were served from the
+//	@Description	cache since the service started.
+//	@Tags			DecentralizedIdentityAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	resolution.CacheStats
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/resolution/cache [get]
+func (rr ResolutionCacheRouter) GetResolutionCacheStats(c *gin.Context) {
+	stats, err := rr.cache.Stats(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get resolution cache stats", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, stats, http.StatusOK)
+}
+
+type PurgeResolutionCacheResponse struct {
+	// How many results were purged.
+	Purged int `json:"purged"`
+}
+
+// PurgeResolutionCache godoc
+//
+//	@Summary		Purge Resolution Cache
+//	@Description	Removes every cached DID resolution result, so that every DID is resolved again.
+//	@Tags			DecentralizedIdentityAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	PurgeResolutionCacheResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/resolution/cache [delete]
+func (rr ResolutionCacheRouter) PurgeResolutionCache(c *gin.Context) {
+	purged, err := rr.cache.Purge(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not purge resolution cache", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, PurgeResolutionCacheResponse{Purged: purged}, http.StatusOK)
+}
+
+// InvalidateResolution godoc
+//
+//	@Summary		Invalidate Resolution
+//	@Description	Removes the cached resolution result of a DID, so that it's resolved again, e.g. after its document
+//	@Description	changed outside the service.
+//	@Tags			DecentralizedIdentityAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"DID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/resolution/cache/{id} [delete]
+func (rr ResolutionCacheRouter) InvalidateResolution(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot invalidate resolution without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	invalidated, err := rr.cache.Invalidate(c, *id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not invalidate resolution of DID: %s", *id)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	if !invalidated {
+		framework.LoggingRespondErrMsg(c, fmt.Sprintf("no resolution of DID<%s> is cached", *id), http.StatusNotFound)
+		return
+	}
+	framework.Respond(c, nil, http.StatusNoContent)
+}
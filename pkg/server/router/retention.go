@@ -0,0 +1,193 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/retention"
+)
+
+type RetentionRouter struct {
+	service *retention.Service
+}
+
+func NewRetentionRouter(s svcframework.Service) (*RetentionRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	retentionService, ok := s.(*retention.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create retention router with service type: %s", s.Type())
+	}
+	return &RetentionRouter{service: retentionService}, nil
+}
+
+type RunRetentionResponse struct {
+	Run retention.Run `json:"run"`
+}
+
+// RunRetention godoc
+//
+//	@Summary		Run Retention
+//	@Description	Runs the retention job now, rather than waiting for the next scheduled run. Applications, responses,
+//	@Description	and submissions of the default tenant and of the configured tenants that were kept as long as their
+//	@Description	rule says are exported to the export destination, if there is one, then deleted, unless a legal hold
+//	@Description	keeps them. A dry run deletes nothing, and lists what would be deleted, and kept.
+//	@Tags			RetentionAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			dryRun	query		bool	false	"Report what would be deleted, without deleting anything"
+//	@Success		200		{object}	RunRetentionResponse	"Dry run"
+//	@Success		201		{object}	RunRetentionResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/retention/runs [put]
+func (rr RetentionRouter) RunRetention(c *gin.Context) {
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid run retention request", http.StatusBadRequest)
+		return
+	}
+	if dryRun {
+		run, err := rr.service.DryRun(c)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, "could not dry run retention", http.StatusInternalServerError)
+			return
+		}
+		framework.Respond(c, RunRetentionResponse{Run: *run}, http.StatusOK)
+		return
+	}
+
+	run, err := rr.service.Run(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not run retention", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, RunRetentionResponse{Run: *run}, http.StatusCreated)
+}
+
+type CreateHoldRequest struct {
+	// Tenant of the objects to hold. Empty for the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Type of the objects to hold: application, response, or submission.
+	Type retention.ObjectType `json:"type" validate:"required"`
+
+	// ID of the object to hold. Every object of the type is held when it's empty.
+	ObjectID string `json:"objectId,omitempty"`
+
+	// Why the objects are held, e.g. the case they're held for.
+	Reason string `json:"reason" validate:"required"`
+}
+
+func (r CreateHoldRequest) toServiceRequest() retention.CreateHoldRequest {
+	return retention.CreateHoldRequest{
+		Tenant:   r.Tenant,
+		Type:     r.Type,
+		ObjectID: r.ObjectID,
+		Reason:   r.Reason,
+	}
+}
+
+type CreateHoldResponse struct {
+	Hold retention.Hold `json:"hold"`
+}
+
+// CreateHold godoc
+//
+//	@Summary		Create Hold
+//	@Description	Places a legal hold on an object, or on every object of a type, of a tenant. Held objects aren't
+//	@Description	deleted, however long they've been kept, until the hold is lifted.
+//	@Tags			RetentionAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateHoldRequest	true	"request body"
+//	@Success		201		{object}	CreateHoldResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/retention/holds [put]
+func (rr RetentionRouter) CreateHold(c *gin.Context) {
+	var request CreateHoldRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		errMsg := "invalid create hold request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := framework.ValidateRequest(request); err != nil {
+		errMsg := "invalid create hold request"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	hold, err := rr.service.CreateHold(c, request.toServiceRequest())
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create hold", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, CreateHoldResponse{Hold: *hold}, http.StatusCreated)
+}
+
+type ListHoldsResponse struct {
+	// Legal holds that weren't lifted, oldest first.
+	Holds []retention.Hold `json:"holds"`
+}
+
+// ListHolds godoc
+//
+//	@Summary		List Holds
+//	@Description	Lists the legal holds that weren't lifted, oldest first.
+//	@Tags			RetentionAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	ListHoldsResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/retention/holds [get]
+func (rr RetentionRouter) ListHolds(c *gin.Context) {
+	resp, err := rr.service.ListHolds(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list holds", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListHoldsResponse{Holds: resp.Holds}, http.StatusOK)
+}
+
+// LiftHold godoc
+//
+//	@Summary		Lift Hold
+//	@Description	Lifts a legal hold, so that the objects it held are deleted by the next run of the retention job once
+//	@Description	they were kept as long as their rule says.
+//	@Tags			RetentionAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/admin/retention/holds/{id} [delete]
+func (rr RetentionRouter) LiftHold(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot lift hold without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := rr.service.LiftHold(c, *id); err != nil {
+		errMsg := fmt.Sprintf("could not lift hold with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, retention.ErrHoldNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	framework.Respond(c, nil, http.StatusNoContent)
+}
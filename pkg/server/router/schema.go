@@ -1,9 +1,14 @@
 package router
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/sirupsen/logrus"
 
 	schemalib "github.com/TBD54566975/ssi-sdk/credential/schema"
 	"github.com/TBD54566975/ssi-sdk/did"
@@ -12,6 +17,7 @@ import (
 
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
 )
@@ -43,6 +49,11 @@ type CreateSchemaRequest struct {
 	// `https://json-schema.org/draft/2019-09/schema`, or `https://json-schema.org/draft-07/schema`.
 	Schema schemalib.JSONSchema `json:"schema" validate:"required"`
 
+	// Version of the schema, as MAJOR.MINOR.PATCH. When a schema with the same name exists, the schema is created as
+	// its next version, which must be greater than its latest version, and defaults to the next minor version.
+	// Otherwise, it defaults to 1.0.0.
+	Version string `json:"version,omitempty" example:"1.1.0"`
+
 	// CredentialSchemaRequest request is an optional additional request to create a credentialized version of a schema.
 	*CredentialSchemaRequest
 }
@@ -75,6 +86,8 @@ type CreateSchemaResponse struct {
 type SchemaResponse struct {
 	// ID is the URL of for resolution of the schema
 	ID string `json:"id"`
+	// Version of the schema. Each version resolves by its version-qualified ID, `<id>@<version>`.
+	Version string `json:"version"`
 	// Type is the type of schema such as `JsonSchema2023` or `CredentialSchema2023`
 	Type schemalib.VCJSONSchemaType `json:"type" validate:"required"`
 
@@ -88,7 +101,8 @@ type SchemaResponse struct {
 // CreateSchema godoc
 //
 //	@Summary		Create Schema
-//	@Description	Create schema
+//	@Description	Create schema. When a schema with the same name exists, the schema is created as its next version,
+//	@Description	with the same ID. Earlier versions are kept as they were, resolvable by their version-qualified IDs.
 //	@Tags			SchemaAPI
 //	@Accept			json
 //	@Produce		json
@@ -114,6 +128,7 @@ func (sr SchemaRouter) CreateSchema(c *gin.Context) {
 		Name:        request.Name,
 		Description: request.Description,
 		Schema:      request.Schema,
+		Version:     request.Version,
 	}
 
 	if request.CredentialSchemaRequest != nil {
@@ -127,6 +142,11 @@ func (sr SchemaRouter) CreateSchema(c *gin.Context) {
 		req.FullyQualifiedVerificationMethodID = did.FullyQualifiedVerificationMethodID(request.Issuer, request.VerificationMethodID)
 	}
 
+	if err := req.IsValid(); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidCreateSchemaRequest, http.StatusBadRequest)
+		return
+	}
+
 	createSchemaResponse, err := sr.service.CreateSchema(c, req)
 	if err != nil {
 		framework.LoggingRespondErrWithMsg(c, err, "could not create schema", http.StatusInternalServerError)
@@ -136,6 +156,7 @@ func (sr SchemaRouter) CreateSchema(c *gin.Context) {
 	resp := CreateSchemaResponse{
 		SchemaResponse: &SchemaResponse{
 			ID:               createSchemaResponse.ID,
+			Version:          createSchemaResponse.Version,
 			Type:             createSchemaResponse.Type,
 			Schema:           createSchemaResponse.Schema,
 			CredentialSchema: createSchemaResponse.CredentialSchema,
@@ -144,16 +165,43 @@ func (sr SchemaRouter) CreateSchema(c *gin.Context) {
 	framework.Respond(c, resp, http.StatusCreated)
 }
 
+// SchemaVersionPath returns the path of the schema that a create schema request makes a new version of, so that the
+// request's If-Match header is checked against it, or nothing when the request creates a new schema. Requests that
+// can't be decoded are left for CreateSchema to reject.
+func (sr SchemaRouter) SchemaVersionPath(c *gin.Context) (string, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "reading request body")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var request CreateSchemaRequest
+	if err = json.Unmarshal(body, &request); err != nil || request.Name == "" {
+		return "", nil
+	}
+	latest, err := sr.service.FindSchemaByName(c, request.Name)
+	if err != nil || latest == nil {
+		return "", err
+	}
+	return strings.TrimSuffix(c.Request.URL.Path, "/") + "/" + latest.ID, nil
+}
+
 // GetSchema godoc
 //
 //	@Summary		Get Schema
-//	@Description	Get a schema by its ID
+//	@Description	Get the latest version of a schema by its ID, or a version of a schema by its version-qualified ID,
+//	@Description	`<id>@<version>`.
 //	@Tags			SchemaAPI
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
 //	@Success		200	{object}	GetSchemaResponse
+//	@Header			200	{string}	ETag	"ETag of the schema, for If-Match"
+//	@Header			200	{string}	Last-Modified	"When this representation was first served"
+//	@Header			200	{string}	Cache-Control	"How long the response can be reused for"
+//	@Success		304	{string}	string	"Not modified since the If-None-Match or If-Modified-Since of the request"
 //	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
 //	@Router			/v1/schemas/{id} [get]
 func (sr SchemaRouter) GetSchema(c *gin.Context) {
 	id := framework.GetParam(c, IDParam)
@@ -163,17 +211,21 @@ func (sr SchemaRouter) GetSchema(c *gin.Context) {
 		return
 	}
 
-	// TODO(gabe) differentiate between internal errors and not found schemas
 	gotSchema, err := sr.service.GetSchema(c, schema.GetSchemaRequest{ID: *id})
 	if err != nil {
 		errMsg := fmt.Sprintf("could not get schema with id: %s", *id)
-		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, schema.ErrSchemaNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
 		return
 	}
 
 	resp := GetSchemaResponse{
 		SchemaResponse: &SchemaResponse{
 			ID:               gotSchema.ID,
+			Version:          gotSchema.Version,
 			Type:             gotSchema.Type,
 			Schema:           gotSchema.Schema,
 			CredentialSchema: gotSchema.CredentialSchema,
@@ -186,6 +238,9 @@ func (sr SchemaRouter) GetSchema(c *gin.Context) {
 type ListSchemasResponse struct {
 	// Schemas is the list of all schemas the service holds
 	Schemas []GetSchemaResponse `json:"schemas,omitempty"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListSchemas godoc
@@ -195,11 +250,20 @@ type ListSchemasResponse struct {
 //	@Tags			SchemaAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListSchemasResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListSchemasResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of schemas across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/schemas [get]
 func (sr SchemaRouter) ListSchemas(c *gin.Context) {
-	gotSchemas, err := sr.service.ListSchemas(c)
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	page := pageRequest.ToServicePage()
+	gotSchemas, err := sr.service.ListSchemasPage(c, schema.ListSchemasPageRequest{PageToken: page.Token, PageSize: page.Size})
 	if err != nil {
 		errMsg := "could not list schemas"
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
@@ -213,6 +277,7 @@ func (sr SchemaRouter) ListSchemas(c *gin.Context) {
 		schemas = append(schemas, GetSchemaResponse{
 			SchemaResponse: &SchemaResponse{
 				ID:               s.ID,
+				Version:          s.Version,
 				Type:             s.Type,
 				Schema:           s.Schema,
 				CredentialSchema: s.CredentialSchema,
@@ -221,6 +286,10 @@ func (sr SchemaRouter) ListSchemas(c *gin.Context) {
 	}
 
 	resp := ListSchemasResponse{Schemas: schemas}
+	pagination.SetTotalCount(c, gotSchemas.TotalCount)
+	if pagination.MaybeSetNextPageToken(c, gotSchemas.NextPageToken, &resp.NextPageToken) {
+		return
+	}
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -228,16 +297,71 @@ type GetSchemaResponse struct {
 	*SchemaResponse
 }
 
+type ListSchemaVersionsResponse struct {
+	// Versions of the schema, oldest first.
+	Versions []GetSchemaResponse `json:"versions"`
+}
+
+// ListSchemaVersions godoc
+//
+//	@Summary		List Schema Versions
+//	@Description	List every version of a schema, oldest first. Versions are immutable.
+//	@Tags			SchemaAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	ListSchemaVersionsResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/v1/schemas/{id}/versions [get]
+func (sr SchemaRouter) ListSchemaVersions(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot list schema versions without ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	gotVersions, err := sr.service.ListSchemaVersions(c, schema.ListSchemaVersionsRequest{ID: *id})
+	if err != nil {
+		errMsg := fmt.Sprintf("could not list versions of schema with id: %s", *id)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, schema.ErrSchemaNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, statusCode)
+		return
+	}
+
+	versions := make([]GetSchemaResponse, 0, len(gotVersions.Versions))
+	for _, v := range gotVersions.Versions {
+		versions = append(versions, GetSchemaResponse{
+			SchemaResponse: &SchemaResponse{
+				ID:               v.ID,
+				Version:          v.Version,
+				Type:             v.Type,
+				Schema:           v.Schema,
+				CredentialSchema: v.CredentialSchema,
+			},
+		})
+	}
+	framework.Respond(c, ListSchemaVersionsResponse{Versions: versions}, http.StatusOK)
+}
+
 // DeleteSchema godoc
 //
 //	@Summary		Delete Schema
-//	@Description	Delete a schema by its ID
+//	@Description	Delete a schema, with every one of its versions, by its ID
 //	@Tags			SchemaAPI
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		string	true	"ID"
+//	@Param			If-Match	header		string	false	"ETag of the schema, which must still match for it to be deleted"
 //	@Success		204	{string}	string	"No Content"
 //	@Failure		400	{string}	string	"Bad request"
+//	@Failure		412	{string}	string	"Precondition failed"
+//	@Failure		428	{string}	string	"Precondition required"
 //	@Failure		500	{string}	string	"Internal server error"
 //	@Router			/v1/schemas/{id} [delete]
 func (sr SchemaRouter) DeleteSchema(c *gin.Context) {
@@ -248,6 +372,12 @@ func (sr SchemaRouter) DeleteSchema(c *gin.Context) {
 		return
 	}
 
+	if _, version := schema.SplitVersionedID(*id); version != "" {
+		errMsg := fmt.Sprintf("cannot delete version<%s> of a schema: only whole schemas can be deleted", version)
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
 	if err := sr.service.DeleteSchema(c, schema.DeleteSchemaRequest{ID: *id}); err != nil {
 		errMsg := fmt.Sprintf("could not delete schema with id: %s", *id)
 		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
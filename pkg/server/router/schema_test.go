@@ -108,6 +108,78 @@ func TestSchemaRouter(t *testing.T) {
 				assert.NotEmpty(tt, gotSchemas.Schemas)
 				assert.Len(tt, gotSchemas.Schemas, 1)
 			})
+
+			t.Run("Schema Versions Test", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				assert.NotEmpty(tt, db)
+
+				serviceConfig := config.SchemaServiceConfig{BaseServiceConfig: &config.BaseServiceConfig{Name: "schema", ServiceEndpoint: "https://ssi-service.com/v1/schemas"}}
+				keyStoreService := testKeyStoreService(tt, db)
+				didService := testDIDService(tt, db, keyStoreService)
+				schemaService, err := schema.NewSchemaService(serviceConfig, db, keyStoreService, didService.GetResolver())
+				assert.NoError(tt, err)
+
+				created, err := schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "simple schema", Schema: getSimpleSchema()})
+				assert.NoError(tt, err)
+				assert.Equal(tt, schema.InitialVersion, created.Version)
+				assert.Equal(tt, "https://ssi-service.com/v1/schemas/"+created.ID+"@1.0.0", created.Schema.ID())
+
+				// a schema with the same name is the next version of the schema
+				nextSchema := getSimpleSchema()
+				nextSchema["description"] = "with an email"
+				next, err := schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "simple schema", Schema: nextSchema})
+				assert.NoError(tt, err)
+				assert.Equal(tt, created.ID, next.ID)
+				assert.Equal(tt, "1.1.0", next.Version)
+
+				// versions must be greater than the latest, and well-formed
+				_, err = schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "simple schema", Schema: getSimpleSchema(), Version: "1.0.1"})
+				assert.ErrorContains(tt, err, "must be greater than the latest version<1.1.0>")
+				_, err = schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "simple schema", Schema: getSimpleSchema(), Version: "2"})
+				assert.ErrorContains(tt, err, "MAJOR.MINOR.PATCH")
+				major, err := schemaService.CreateSchema(context.Background(), schema.CreateSchemaRequest{Name: "simple schema", Schema: getSimpleSchema(), Version: "2.0.0"})
+				assert.NoError(tt, err)
+				assert.Equal(tt, "2.0.0", major.Version)
+
+				// a version that lost a race to a greater one doesn't replace the latest version
+				schemaStorage, err := schema.NewSchemaStorage(db)
+				assert.NoError(tt, err)
+				err = schemaStorage.StoreSchemaVersion(context.Background(), schema.StoredSchema{ID: created.ID, Name: "simple schema", Version: "1.2.0"})
+				assert.ErrorContains(tt, err, "must be greater than the latest version<2.0.0>")
+				err = schemaStorage.StoreSchemaVersion(context.Background(), schema.StoredSchema{ID: created.ID, Name: "simple schema", Version: "2.0.0"})
+				assert.ErrorContains(tt, err, "schema version already exists")
+
+				// the schema resolves to its latest version, and earlier versions by their version-qualified IDs
+				gotSchema, err := schemaService.GetSchema(context.Background(), schema.GetSchemaRequest{ID: created.ID})
+				assert.NoError(tt, err)
+				assert.Equal(tt, "2.0.0", gotSchema.Version)
+				gotSchema, err = schemaService.GetSchema(context.Background(), schema.GetSchemaRequest{ID: schema.VersionedID(created.ID, "1.1.0")})
+				assert.NoError(tt, err)
+				assert.Equal(tt, "with an email", gotSchema.Schema.Description())
+				_, err = schemaService.GetSchema(context.Background(), schema.GetSchemaRequest{ID: schema.VersionedID(created.ID, "1.2.0")})
+				assert.ErrorIs(tt, err, schema.ErrSchemaNotFound)
+
+				versions, err := schemaService.ListSchemaVersions(context.Background(), schema.ListSchemaVersionsRequest{ID: created.ID})
+				assert.NoError(tt, err)
+				gotVersions := make([]string, 0, len(versions.Versions))
+				for _, v := range versions.Versions {
+					gotVersions = append(gotVersions, v.Version)
+				}
+				assert.Equal(tt, []string{"1.0.0", "1.1.0", "2.0.0"}, gotVersions)
+
+				// the schema is listed once
+				gotSchemas, err := schemaService.ListSchemas(context.Background())
+				assert.NoError(tt, err)
+				assert.Len(tt, gotSchemas.Schemas, 1)
+
+				// versions can't be deleted on their own, and are deleted with the schema
+				err = schemaService.DeleteSchema(context.Background(), schema.DeleteSchemaRequest{ID: schema.VersionedID(created.ID, "1.0.0")})
+				assert.ErrorContains(tt, err, "only whole schemas can be deleted")
+				err = schemaService.DeleteSchema(context.Background(), schema.DeleteSchemaRequest{ID: created.ID})
+				assert.NoError(tt, err)
+				_, err = schemaService.GetSchema(context.Background(), schema.GetSchemaRequest{ID: schema.VersionedID(created.ID, "1.0.0")})
+				assert.Error(tt, err)
+			})
 		})
 	}
 }
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/stats"
+)
+
+const DaysParam = "days"
+
+type StatsRouter struct {
+	service *stats.Service
+}
+
+func NewStatsRouter(s svcframework.Service) (*StatsRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	statsService, ok := s.(*stats.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create stats router with service type: %s", s.Type())
+	}
+	return &StatsRouter{service: statsService}, nil
+}
+
+type GetStatsResponse struct {
+	stats.Stats
+}
+
+// GetStats godoc
+//
+//	@Summary		Get Stats
+//	@Description	Gets aggregate statistics for dashboards: how many DIDs there are by method, and applications by
+//	@Description	status, and how many credentials were issued, revoked, and verified or failed verification each UTC
+//	@Description	day of the last `days` days.
+//	@Tags			StatsAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			days	query		number	false	"Days of time series, ending today. Defaults to 30, and at most 366."
+//	@Success		200		{object}	GetStatsResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/stats [get]
+func (sr StatsRouter) GetStats(c *gin.Context) {
+	var request stats.GetStatsRequest
+	if days := framework.GetQueryValue(c, DaysParam); days != nil {
+		parsed, err := strconv.Atoi(*days)
+		if err != nil {
+			framework.LoggingRespondErrWithMsg(c, err, fmt.Sprintf("invalid %s", DaysParam), http.StatusBadRequest)
+			return
+		}
+		request.Days = parsed
+	}
+	if err := request.IsValid(); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid get stats request", http.StatusBadRequest)
+		return
+	}
+
+	gotStats, err := sr.service.GetStats(c, request)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get stats", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, GetStatsResponse{Stats: *gotStats}, http.StatusOK)
+}
@@ -0,0 +1,178 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	NamespaceParam = "namespace"
+	OverwriteParam = "overwrite"
+)
+
+// StorageRouter exports and imports the values of the storage of the service, as they're stored, for backing up and
+// restoring all of it.
+type StorageRouter struct {
+	storage    storage.ServiceStorage
+	encryption *storage.NamespaceEncryptedWrapper
+}
+
+// NewStorageRouter creates a router for the storage the services are built on, before it's encrypted or scoped to
+// tenants, so that values are exported as they're stored. The values of the namespaces encryption encrypts, which is
+// nil when none are, are encrypted by it.
+func NewStorageRouter(s storage.ServiceStorage, encryption *storage.NamespaceEncryptedWrapper) (*StorageRouter, error) {
+	if s == nil {
+		return nil, errors.New("storage cannot be nil")
+	}
+	return &StorageRouter{storage: s, encryption: encryption}, nil
+}
+
+// ExportStorage godoc
+//
+//	@Summary		Export Storage
+//	@Description	Exports every value of every namespace, or of each namespace given, as newline delimited JSON. The
+//	@Description	first line is a header with the format and version of the export, and each line after it is a value
+//	@Description	with its namespace, key, and base64 encoded value. Values are exported as they're stored, so
+//	@Description	encrypted values, like keys, stay encrypted, but the service keys they're encrypted with are exported
+//	@Description	too, unless a key manager holds them. Values are read as of a single point in time, unless the
+//	@Description	header says otherwise, which it does for redis, whose namespaces have to be given.
+//	@Tags			StorageAPI
+//	@Accept			json
+//	@Produce		application/x-ndjson
+//	@Param			namespace	query		[]string	false	"Namespace to export, instead of every namespace"	collectionFormat(multi)
+//	@Success		200			{string}	string		"Header, then values, one per line"
+//	@Failure		500			{string}	string		"Internal server error"
+//	@Router			/admin/storage/export [get]
+func (sr StorageRouter) ExportStorage(c *gin.Context) {
+	now := time.Now()
+	c.Header("Content-Type", NDJSONContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "storage-"+now.UTC().Format("20060102T150405Z")+".ndjson"))
+	c.Status(http.StatusOK)
+	exported, err := storage.Export(c, sr.storage, c.Writer, now, c.QueryArray(NamespaceParam))
+	if err == nil {
+		logrus.WithContext(c).Infof("exported %d values", exported)
+		return
+	}
+	// once the header is sent, a failed export can only be cut short
+	if c.Writer.Written() {
+		logrus.WithContext(c).WithError(err).Error("could not export storage")
+		return
+	}
+	c.Header("Content-Disposition", "")
+	framework.LoggingRespondErrWithMsg(c, err, "could not export storage", http.StatusInternalServerError)
+}
+
+type ImportStorageResponse struct {
+	storage.ImportResult
+
+	// Whether the import is a dry run, which wrote nothing, so that the values counted as imported are those that would
+	// have been.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ImportStorage godoc
+//
+//	@Summary		Import Storage
+//	@Description	Imports an export of storage, as written by the export endpoint, to restore a fresh instance of the
+//	@Description	service. Exports of older versions are upgraded as they're read, and exports of newer versions are
+//	@Description	rejected. Values that are stored already are kept as they are, unless overwrite is set. Encrypted
+//	@Description	values can only be read with the service keys they were encrypted with, so import into the
+//	@Description	storage they were exported from, or into empty storage, and restart the service once it's imported.
+//	@Description	Exports are often larger than the default limit of request bodies, which is raised for this route
+//	@Description	like for any other. A dry run writes nothing, and counts the values that would be written, and
+//	@Description	skipped.
+//	@Tags			StorageAPI
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Param			request		body		string	true	"Export of storage"
+//	@Param			overwrite	query		bool	false	"Replace values that are stored already"
+//	@Param			dryRun		query		bool	false	"Count what would be imported, without importing anything"
+//	@Success		200			{object}	ImportStorageResponse
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		413			{string}	string	"Payload too large"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/storage/import [put]
+func (sr StorageRouter) ImportStorage(c *gin.Context) {
+	invalidImportStorageRequest := "invalid import storage request"
+	overwrite := false
+	if value := framework.GetQueryValue(c, OverwriteParam); value != nil {
+		var err error
+		if overwrite, err = strconv.ParseBool(*value); err != nil {
+			framework.LoggingRespondErrWithMsg(c, errors.Wrapf(err, "invalid %s query parameter", OverwriteParam), invalidImportStorageRequest, http.StatusBadRequest)
+			return
+		}
+	}
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, invalidImportStorageRequest, http.StatusBadRequest)
+		return
+	}
+
+	result, err := storage.Import(c, sr.storage, c.Request.Body, overwrite, dryRun)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrInvalidExport) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not import storage", statusCode)
+		return
+	}
+	framework.Respond(c, ImportStorageResponse{ImportResult: *result, DryRun: dryRun}, http.StatusOK)
+}
+
+type EncryptNamespacesResponse struct {
+	// Base names of the namespaces whose values are encrypted, for every tenant.
+	Namespaces []string `json:"namespaces"`
+
+	// How many values were stored unencrypted, and are now encrypted.
+	Encrypted int `json:"encrypted"`
+
+	// Whether the request is a dry run, which encrypted nothing, so that the values counted as encrypted are those that
+	// would have been.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// EncryptNamespaces godoc
+//
+//	@Summary		Encrypt Namespaces
+//	@Description	Encrypts the values of the encrypted namespaces of every tenant that were stored before their namespaces
+//	@Description	were configured to be encrypted, with the service key of the keystore. Values stored since are
+//	@Description	encrypted as they're written. The service keeps running while values are encrypted, and encrypting
+//	@Description	again only encrypts the values that still aren't. It requires a storage provider that lists its
+//	@Description	namespaces, which redis doesn't. A dry run encrypts nothing, and counts the values that would be
+//	@Description	encrypted.
+//	@Tags			StorageAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			dryRun	query		bool	false	"Count what would be encrypted, without encrypting anything"
+//	@Success		200		{object}	EncryptNamespacesResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/admin/storage/encrypt [put]
+func (sr StorageRouter) EncryptNamespaces(c *gin.Context) {
+	if sr.encryption == nil {
+		framework.LoggingRespondErrMsg(c, "no namespaces are configured to be encrypted", http.StatusBadRequest)
+		return
+	}
+	dryRun, err := framework.IsDryRun(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid encrypt namespaces request", http.StatusBadRequest)
+		return
+	}
+
+	encrypted, err := sr.encryption.EncryptNamespaces(c, dryRun)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not encrypt namespaces", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, EncryptNamespacesResponse{Namespaces: sr.encryption.Namespaces(), Encrypted: encrypted, DryRun: dryRun}, http.StatusOK)
+}
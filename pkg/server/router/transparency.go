@@ -0,0 +1,280 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/transparency"
+)
+
+const (
+	IndexParam    = "index"
+	TreeSizeParam = "treeSize"
+	FirstParam    = "first"
+	SecondParam   = "second"
+)
+
+type TransparencyRouter struct {
+	service *transparency.Service
+}
+
+func NewTransparencyRouter(s svcframework.Service) (*TransparencyRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	transparencyService, ok := s.(*transparency.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create transparency router with service type: %s", s.Type())
+	}
+	return &TransparencyRouter{service: transparencyService}, nil
+}
+
+type GetSignedTreeHeadResponse struct {
+	// Number of entries in the log.
+	TreeSize uint64 `json:"treeSize"`
+
+	// Root hash of the Merkle tree of the entries, in base64.
+	RootHash []byte `json:"rootHash"`
+
+	// When the last entry was appended. Empty when the log has no entries.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+
+	// did:key the tree head is signed by.
+	LogID string `json:"logId"`
+
+	// JWT signed by logId whose claims are treeSize, rootHash, and timestamp.
+	SignedTreeHead keyaccess.JWT `json:"signedTreeHead"`
+}
+
+// GetSignedTreeHead godoc
+//
+//	@Summary		Get Signed Tree Head
+//	@Description	Gets the current tree head of the transparency log, which is signed by the did:key of the log.
+//	@Description	Auditors keep the tree heads they see, and check that every later one is consistent with them.
+//	@Tags			TransparencyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	GetSignedTreeHeadResponse
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/v1/transparency/head [get]
+func (tr TransparencyRouter) GetSignedTreeHead(c *gin.Context) {
+	head, err := tr.service.GetSignedTreeHead(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get signed tree head", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, GetSignedTreeHeadResponse{
+		TreeSize:       head.TreeSize,
+		RootHash:       head.RootHash,
+		Timestamp:      head.Timestamp,
+		LogID:          head.LogID,
+		SignedTreeHead: head.SignedTreeHead,
+	}, http.StatusOK)
+}
+
+type ListTransparencyEntriesResponse struct {
+	// Entries of the log, ordered by their index within the page.
+	Entries []transparency.Entry `json:"entries"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListEntries godoc
+//
+//	@Summary		List Transparency Entries
+//	@Description	Lists the entries of the transparency log. The hash of the leaf of each entry is the SHA-256 hash of
+//	@Description	0x00 followed by the JSON of the entry, canonicalized with RFC 8785.
+//	@Tags			TransparencyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListTransparencyEntriesResponse
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/v1/transparency/entries [get]
+func (tr TransparencyRouter) ListEntries(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	page := pageRequest.ToServicePage()
+
+	entries, err := tr.service.ListEntries(c, transparency.ListEntriesRequest{PageToken: page.Token, PageSize: page.Size})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list transparency entries", http.StatusInternalServerError)
+		return
+	}
+
+	resp := ListTransparencyEntriesResponse{Entries: entries.Entries}
+	if pagination.MaybeSetNextPageToken(c, entries.NextPageToken, &resp.NextPageToken) {
+		return
+	}
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+type ListCredentialTransparencyEntriesResponse struct {
+	// Entries of the log recording operations on the credential, ordered by their index.
+	Entries []transparency.Entry `json:"entries"`
+}
+
+// ListCredentialEntries godoc
+//
+//	@Summary		List Credential Transparency Entries
+//	@Description	Lists the entries of the transparency log recording when a credential was issued, and changes to its
+//	@Description	status, so their inclusion can be proven.
+//	@Tags			TransparencyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID of the credential"
+//	@Success		200	{object}	ListCredentialTransparencyEntriesResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/v1/transparency/credentials/{id} [get]
+func (tr TransparencyRouter) ListCredentialEntries(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		errMsg := "cannot list transparency entries without credential ID parameter"
+		framework.LoggingRespondErrMsg(c, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := tr.service.ListCredentialEntries(c, *id)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not list transparency entries of credential: %s", *id)
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListCredentialTransparencyEntriesResponse{Entries: entries.Entries}, http.StatusOK)
+}
+
+type GetInclusionProofResponse struct {
+	Index    uint64 `json:"index"`
+	TreeSize uint64 `json:"treeSize"`
+
+	// Hash of the leaf of the entry, in base64.
+	LeafHash []byte `json:"leafHash"`
+
+	// Audit path from the leaf to the root hash of the tree, as defined by RFC 6962, in base64.
+	Path [][]byte `json:"path"`
+}
+
+// GetInclusionProof godoc
+//
+//	@Summary		Get Inclusion Proof
+//	@Description	Gets the proof that an entry is included in the tree of the first treeSize entries of the transparency
+//	@Description	log, as defined by RFC 6962. It's verified against the root hash of a signed tree head of that size.
+//	@Tags			TransparencyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			index		query		number	true	"Index of the entry"
+//	@Param			treeSize	query		number	false	"Size of the tree. When not set, the current size of the log"
+//	@Success		200			{object}	GetInclusionProofResponse
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/v1/transparency/proofs/inclusion [get]
+func (tr TransparencyRouter) GetInclusionProof(c *gin.Context) {
+	index, err := uintQueryValue(c, IndexParam, true)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid get inclusion proof request", http.StatusBadRequest)
+		return
+	}
+	treeSize, err := uintQueryValue(c, TreeSizeParam, false)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid get inclusion proof request", http.StatusBadRequest)
+		return
+	}
+
+	proof, err := tr.service.GetInclusionProof(c, transparency.GetInclusionProofRequest{Index: index, TreeSize: treeSize})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, transparency.ErrOutsideOfLog) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not get inclusion proof", statusCode)
+		return
+	}
+	framework.Respond(c, GetInclusionProofResponse{
+		Index:    proof.Index,
+		TreeSize: proof.TreeSize,
+		LeafHash: proof.LeafHash,
+		Path:     append([][]byte{}, proof.Path...),
+	}, http.StatusOK)
+}
+
+type GetConsistencyProofResponse struct {
+	First  uint64 `json:"first"`
+	Second uint64 `json:"second"`
+
+	// Proof that the tree of the first entries is a prefix of the tree of the second, as defined by RFC 6962, in base64.
+	Proof [][]byte `json:"proof"`
+}
+
+// GetConsistencyProof godoc
+//
+//	@Summary		Get Consistency Proof
+//	@Description	Gets the proof that the tree of the first entries of the transparency log is a prefix of the tree of
+//	@Description	the second entries, as defined by RFC 6962, which shows that no entry was changed or removed between
+//	@Description	two signed tree heads.
+//	@Tags			TransparencyAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			first	query		number	true	"Size of the earlier tree"
+//	@Param			second	query		number	false	"Size of the later tree. When not set, the current size of the log"
+//	@Success		200		{object}	GetConsistencyProofResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/transparency/proofs/consistency [get]
+func (tr TransparencyRouter) GetConsistencyProof(c *gin.Context) {
+	first, err := uintQueryValue(c, FirstParam, true)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid get consistency proof request", http.StatusBadRequest)
+		return
+	}
+	second, err := uintQueryValue(c, SecondParam, false)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid get consistency proof request", http.StatusBadRequest)
+		return
+	}
+
+	proof, err := tr.service.GetConsistencyProof(c, transparency.GetConsistencyProofRequest{First: first, Second: second})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, transparency.ErrOutsideOfLog) {
+			statusCode = http.StatusBadRequest
+		}
+		framework.LoggingRespondErrWithMsg(c, err, "could not get consistency proof", statusCode)
+		return
+	}
+	framework.Respond(c, GetConsistencyProofResponse{
+		First:  proof.First,
+		Second: proof.Second,
+		Proof:  append([][]byte{}, proof.Proof...),
+	}, http.StatusOK)
+}
+
+// uintQueryValue parses a query parameter as an unsigned integer, which is 0 when it's optional and missing.
+func uintQueryValue(c *gin.Context, param string, required bool) (uint64, error) {
+	value := framework.GetQueryValue(c, param)
+	if value == nil {
+		if required {
+			return 0, errors.Errorf("missing %s query parameter", param)
+		}
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(*value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing %s", param)
+	}
+	return parsed, nil
+}
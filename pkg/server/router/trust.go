@@ -0,0 +1,248 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/trust"
+)
+
+const (
+	CredentialTypeParam = "credentialType"
+	SchemaIDParam       = "schemaId"
+	SourceParam         = "source"
+)
+
+type TrustRouter struct {
+	service *trust.Service
+}
+
+func NewTrustRouter(s svcframework.Service) (*TrustRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	trustService, ok := s.(*trust.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create trust router with service type: %s", s.Type())
+	}
+	return &TrustRouter{service: trustService}, nil
+}
+
+type CreateTrustedIssuerRequest struct {
+	// DID of the issuer.
+	Issuer string `json:"issuer" validate:"required"`
+
+	// Type of the credentials the issuer is trusted to issue, e.g. UniversityDegreeCredential. Either this or the
+	// schemaId is required.
+	CredentialType string `json:"credentialType,omitempty"`
+
+	// ID of the schema of the credentials the issuer is trusted to issue.
+	SchemaID string `json:"schemaId,omitempty"`
+}
+
+type CreateTrustedIssuerResponse struct {
+	TrustedIssuer trust.TrustedIssuer `json:"trustedIssuer"`
+}
+
+// CreateTrustedIssuer godoc
+//
+//	@Summary		Create trusted issuer
+//	@Description	Trusts an issuer to issue the credentials of a type, or of a schema. Once an issuer is trusted for a
+//	@Description	type or schema, credentials of it are only trusted when one of the issuers trusted for it issued them,
+//	@Description	which credential verification and the evaluation of presentation submissions check.
+//	@Tags			TrustAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateTrustedIssuerRequest	true	"request body"
+//	@Success		201		{object}	CreateTrustedIssuerResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/trust/issuers [put]
+func (tr TrustRouter) CreateTrustedIssuer(c *gin.Context) {
+	var request CreateTrustedIssuerRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid create trusted issuer request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := tr.service.CreateTrustedIssuer(c, trust.CreateTrustedIssuerRequest{
+		Issuer:         request.Issuer,
+		CredentialType: request.CredentialType,
+		SchemaID:       request.SchemaID,
+	})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not create trusted issuer", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, CreateTrustedIssuerResponse{TrustedIssuer: *resp}, http.StatusCreated)
+}
+
+type GetTrustedIssuerResponse struct {
+	TrustedIssuer trust.TrustedIssuer `json:"trustedIssuer"`
+}
+
+// GetTrustedIssuer godoc
+//
+//	@Summary		Get trusted issuer
+//	@Description	Gets a trusted issuer by its ID.
+//	@Tags			TrustAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		200	{object}	GetTrustedIssuerResponse
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		404	{string}	string	"Not found"
+//	@Router			/v1/trust/issuers/{id} [get]
+func (tr TrustRouter) GetTrustedIssuer(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		framework.LoggingRespondErrMsg(c, "cannot get trusted issuer without ID parameter", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := tr.service.GetTrustedIssuer(c, trust.GetTrustedIssuerRequest{ID: *id})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not get trusted issuer", http.StatusNotFound)
+		return
+	}
+	framework.Respond(c, GetTrustedIssuerResponse{TrustedIssuer: *resp}, http.StatusOK)
+}
+
+type ListTrustedIssuersResponse struct {
+	// Trusted issuers, ordered by issuer.
+	TrustedIssuers []trust.TrustedIssuer `json:"trustedIssuers"`
+}
+
+// ListTrustedIssuers godoc
+//
+//	@Summary		List trusted issuers
+//	@Description	Lists the trusted issuers, ordered by issuer, optionally only those of an issuer, a type, a schema, or
+//	@Description	a trust list.
+//	@Tags			TrustAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			issuer			query		string	false	"DID of the issuer"
+//	@Param			credentialType	query		string	false	"Type of credentials"
+//	@Param			schemaId		query		string	false	"ID of a schema"
+//	@Param			source			query		string	false	"Source of the trust list the issuers were imported from"
+//	@Success		200				{object}	ListTrustedIssuersResponse
+//	@Failure		500				{string}	string	"Internal server error"
+//	@Router			/v1/trust/issuers [get]
+func (tr TrustRouter) ListTrustedIssuers(c *gin.Context) {
+	var request trust.ListTrustedIssuersRequest
+	if issuer := framework.GetQueryValue(c, IssuerParam); issuer != nil {
+		request.Issuer = *issuer
+	}
+	if credentialType := framework.GetQueryValue(c, CredentialTypeParam); credentialType != nil {
+		request.CredentialType = *credentialType
+	}
+	if schemaID := framework.GetQueryValue(c, SchemaIDParam); schemaID != nil {
+		request.SchemaID = *schemaID
+	}
+	if source := framework.GetQueryValue(c, SourceParam); source != nil {
+		request.Source = *source
+	}
+
+	resp, err := tr.service.ListTrustedIssuers(c, request)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not list trusted issuers", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, ListTrustedIssuersResponse{TrustedIssuers: resp.TrustedIssuers}, http.StatusOK)
+}
+
+// DeleteTrustedIssuer godoc
+//
+//	@Summary		Delete trusted issuer
+//	@Description	Stops trusting an issuer for a type or schema. Once no issuer is trusted for the type or schema,
+//	@Description	credentials of it are trusted whoever issued them.
+//	@Tags			TrustAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{string}	string	"Bad request"
+//	@Failure		500	{string}	string	"Internal server error"
+//	@Router			/v1/trust/issuers/{id} [delete]
+func (tr TrustRouter) DeleteTrustedIssuer(c *gin.Context) {
+	id := framework.GetParam(c, IDParam)
+	if id == nil {
+		framework.LoggingRespondErrMsg(c, "cannot delete trusted issuer without ID parameter", http.StatusBadRequest)
+		return
+	}
+
+	if err := tr.service.DeleteTrustedIssuer(c, trust.DeleteTrustedIssuerRequest{ID: *id}); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not delete trusted issuer", http.StatusInternalServerError)
+		return
+	}
+	framework.Respond(c, nil, http.StatusNoContent)
+}
+
+type ImportTrustListRequest struct {
+	// Format of the trust list.
+	Format trust.TrustListFormat `json:"format" validate:"required,oneof=ebsi train"`
+
+	// Source of the trust list, e.g. the URL it was fetched from. Issuers imported from the same source before are
+	// replaced.
+	Source string `json:"source" validate:"required"`
+
+	// The trust list: for ebsi, an issuer of the EBSI Trusted Issuers Registry, a list of them, or a page of them,
+	// with their attributes; for train, the JSON form of a TRAIN trust list.
+	TrustList json.RawMessage `json:"trustList" validate:"required" swaggertype:"object"`
+
+	// Type of credentials the issuers the trust list doesn't say the types and schemas of, like those of train trust
+	// lists, are trusted for.
+	CredentialType string `json:"credentialType,omitempty"`
+
+	// ID of the schema the issuers the trust list doesn't say the types and schemas of are trusted for.
+	SchemaID string `json:"schemaId,omitempty"`
+}
+
+type ImportTrustListResponse struct {
+	// The issuers imported from the trust list.
+	TrustedIssuers []trust.TrustedIssuer `json:"trustedIssuers"`
+
+	// How many of the issuers imported from the source before were replaced.
+	Replaced int `json:"replaced"`
+}
+
+// ImportTrustList godoc
+//
+//	@Summary		Import trust list
+//	@Description	Trusts the issuers of an EBSI or TRAIN trust list for the types and schemas it lists them for,
+//	@Description	replacing the issuers imported from the same source before. The trust list isn't verified, so it
+//	@Description	must come from a source that's trusted.
+//	@Tags			TrustAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ImportTrustListRequest	true	"request body"
+//	@Success		201		{object}	ImportTrustListResponse
+//	@Failure		400		{string}	string	"Bad request"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/v1/trust/imports [put]
+func (tr TrustRouter) ImportTrustList(c *gin.Context) {
+	var request ImportTrustListRequest
+	if err := framework.Decode(c.Request, &request); err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid import trust list request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := tr.service.ImportTrustList(c, trust.ImportTrustListRequest{
+		Format:         request.Format,
+		Source:         request.Source,
+		TrustList:      request.TrustList,
+		CredentialType: request.CredentialType,
+		SchemaID:       request.SchemaID,
+	})
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "could not import trust list", http.StatusBadRequest)
+		return
+	}
+	framework.Respond(c, ImportTrustListResponse{TrustedIssuers: resp.TrustedIssuers, Replaced: resp.Replaced}, http.StatusCreated)
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/usage"
+)
+
+const (
+	PeriodParam    = "period"
+	PrincipalParam = "principal"
+	MeterParam     = "meter"
+)
+
+type UsageRouter struct {
+	service *usage.Service
+}
+
+func NewUsageRouter(s svcframework.Service) (*UsageRouter, error) {
+	if s == nil {
+		return nil, errors.New("service cannot be nil")
+	}
+	usageService, ok := s.(*usage.Service)
+	if !ok {
+		return nil, fmt.Errorf("could not create usage router with service type: %s", s.Type())
+	}
+	return &UsageRouter{service: usageService}, nil
+}
+
+type ListUsageResponse struct {
+	// Usage records, ordered by period, then tenant, meter, and principal.
+	Records []usage.Record `json:"records"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListUsage godoc
+//
+//	@Summary		List Usage
+//	@Description	Lists how much each tenant, and each API key or token subject of a tenant, issued, verified, signed,
+//	@Description	and stored, per UTC calendar month. Records without a principal hold the usage of the whole tenant.
+//	@Tags			UsageAPI
+//	@Accept			json
+//	@Produce		json
+//	@Param			period		query		string	false	"Only the usage of this month, e.g. 2023-10"
+//	@Param			tenant		query		string	false	"Only the usage of this tenant"
+//	@Param			principal	query		string	false	"Only the usage of this API key ID or token subject"
+//	@Param			meter		query		string	false	"Only the usage of this meter, one of issuance, verification, signing, or storage"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListUsageResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of usage records across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/admin/usage [get]
+func (ur UsageRouter) ListUsage(c *gin.Context) {
+	request, err := listUsageRequest(c)
+	if err != nil {
+		framework.LoggingRespondErrWithMsg(c, err, "invalid list usage request", http.StatusBadRequest)
+		return
+	}
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
+	gotUsage, err := ur.service.ListUsage(c, *request)
+	if err != nil {
+		errMsg := "could not list usage"
+		framework.LoggingRespondErrWithMsg(c, err, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	var resp ListUsageResponse
+	page, failed := pagination.Paginate(c, pageRequest, gotUsage.Records, usage.Record.Key, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Records = page
+	framework.Respond(c, resp, http.StatusOK)
+}
+
+// listUsageRequest reads the filters of the usage to list from the query.
+func listUsageRequest(c *gin.Context) (*usage.ListUsageRequest, error) {
+	var request usage.ListUsageRequest
+	if period := framework.GetQueryValue(c, PeriodParam); period != nil {
+		if _, err := time.Parse("2006-01", *period); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", PeriodParam)
+		}
+		request.Period = *period
+	}
+	if tenant := framework.GetQueryValue(c, TenantParam); tenant != nil {
+		request.Tenant = *tenant
+	}
+	if principal := framework.GetQueryValue(c, PrincipalParam); principal != nil {
+		request.Principal = *principal
+	}
+	if meter := framework.GetQueryValue(c, MeterParam); meter != nil {
+		request.Meter = usage.Meter(*meter)
+		if !request.Meter.IsValid() {
+			return nil, errors.Errorf("unknown meter: %s", *meter)
+		}
+	}
+	return &request, nil
+}
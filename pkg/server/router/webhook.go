@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
 )
@@ -129,6 +130,9 @@ func (wr WebhookRouter) GetWebhook(c *gin.Context) {
 
 type ListWebhooksResponse struct {
 	Webhooks []ListWebhookResponse `json:"webhooks,omitempty"`
+
+	// Pagination token to retrieve the next page of results. If the value is "", it means no further results for the request.
+	NextPageToken string `json:"nextPageToken"`
 }
 
 // ListWebhooks godoc
@@ -138,10 +142,18 @@ type ListWebhooksResponse struct {
 //	@Tags			WebhookAPI
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	ListWebhooksResponse
-//	@Failure		500	{string}	string	"Internal server error"
+//	@Param			pageSize	query		number	false	"Hint to the server of the maximum elements to return. More may be returned. When not set, the server will return all elements."
+//	@Param			pageToken	query		string	false	"Used to indicate to the server to return a specific page of the list results. Must match a previous requests' `nextPageToken`."
+//	@Success		200			{object}	ListWebhooksResponse
+//	@Header			200			{integer}	X-Total-Count	"Number of webhooks across all pages"
+//	@Failure		400			{string}	string	"Bad request"
+//	@Failure		500			{string}	string	"Internal server error"
 //	@Router			/v1/webhooks [get]
 func (wr WebhookRouter) ListWebhooks(c *gin.Context) {
+	var pageRequest pagination.PageRequest
+	if pagination.ParsePaginationParams(c, &pageRequest) {
+		return
+	}
 	gotWebhooks, err := wr.service.ListWebhooks(c)
 	if err != nil {
 		errMsg := "could not list webhooks"
@@ -154,7 +166,12 @@ func (wr WebhookRouter) ListWebhooks(c *gin.Context) {
 		webhooks = append(webhooks, ListWebhookResponse{Webhook: w})
 	}
 
-	resp := ListWebhooksResponse{Webhooks: webhooks}
+	var resp ListWebhooksResponse
+	page, failed := pagination.Paginate(c, pageRequest, webhooks, func(w ListWebhookResponse) string { return string(w.Webhook.Noun) + ":" + string(w.Webhook.Verb) }, &resp.NextPageToken)
+	if failed {
+		return
+	}
+	resp.Webhooks = page
 	framework.Respond(c, resp, http.StatusOK)
 }
 
@@ -167,15 +184,17 @@ type DeleteWebhookRequest struct {
 // DeleteWebhook godoc
 //
 //	@Summary		Delete Webhook
-//	@Description	Delete a webhook by its ID
+//	@Description	Delete a webhook by its noun, verb, and URL
 //	@Tags			WebhookAPI
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"ID"
-//	@Success		204	{string}	string	"No Content"
-//	@Failure		400	{string}	string	"Bad request"
-//	@Failure		500	{string}	string	"Internal server error"
-//	@Router			/v1/webhooks/{noun}/{verb}/{url} [delete]
+//	@Param			noun	path		string					true	"Noun"
+//	@Param			verb	path		string					true	"Verb"
+//	@Param			request	body		DeleteWebhookRequest	true	"request body"
+//	@Success		204		{string}	string					"No Content"
+//	@Failure		400		{string}	string					"Bad request"
+//	@Failure		500		{string}	string					"Internal server error"
+//	@Router			/v1/webhooks/{noun}/{verb} [delete]
 func (wr WebhookRouter) DeleteWebhook(c *gin.Context) {
 	var request DeleteWebhookRequest
 	invalidCreateWebhookRequest := "invalid delete webhook request"
@@ -3,31 +3,62 @@
 package server
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	swaggerfiles "github.com/swaggo/files"
 	ginswagger "github.com/swaggo/gin-swagger"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/doc"
+	"github.com/tbd54566975/ssi-service/internal/inflight"
+	"github.com/tbd54566975/ssi-service/internal/logging"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/pkg/server/adminui"
+	"github.com/tbd54566975/ssi-service/pkg/server/features"
+	"github.com/tbd54566975/ssi-service/pkg/server/fixtures"
 	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/idempotency"
 	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/openapi"
+	"github.com/tbd54566975/ssi-service/pkg/server/ratelimit"
 	"github.com/tbd54566975/ssi-service/pkg/server/router"
 	"github.com/tbd54566975/ssi-service/pkg/service"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/challenge"
 	didsvc "github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/did/resolution"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	"github.com/tbd54566975/ssi-service/pkg/service/replay"
+	"github.com/tbd54566975/ssi-service/pkg/service/usage"
 	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
 // gin-swagger middleware
 
 const (
 	HealthPrefix            = "/health"
+	LivenessPrefix          = "/liveness"
 	ReadinessPrefix         = "/readiness"
 	SwaggerPrefix           = "/swagger/*any"
+	SwaggerYAMLPath         = "/swagger.yaml"
+	OpenAPIPath             = "/openapi.json"
+	SwaggerJSONPath         = "/swagger.json"
+	MetricsPath             = "/metrics"
 	V1Prefix                = "/v1"
+	V2Prefix                = "/v2"
 	OperationPrefix         = "/operations"
 	DIDsPrefix              = "/dids"
 	ResolverPrefix          = "/resolver"
@@ -44,91 +75,565 @@ const (
 	ResponsesPrefix         = "/responses"
 	KeyStorePrefix          = "/keys"
 	VerificationPath        = "/verification"
+	ValidationPath          = "/validation"
+	DerivationPath          = "/derivation"
 	WebhookPrefix           = "/webhooks"
 	DIDConfigurationsPrefix = "/did-configurations"
+	AdminPrefix             = "/admin"
+	APIKeysPrefix           = "/apikeys"
+	RolesPrefix             = "/roles"
+	RoleBindingsPrefix      = "/rolebindings"
+	ClientKeysPrefix        = "/clientkeys"
+	AuditPrefix             = "/audit"
+	ErasuresPrefix          = "/erasures"
+	UsagePrefix             = "/usage"
+	FeaturesPrefix          = "/features"
+	ConfigReloadPath        = "/config/reload"
+	DebugPrefix             = "/debug"
+	UIPrefix                = "/ui"
+	TransparencyPrefix      = "/transparency"
+	BackupsPrefix           = "/backups"
+	RestorePath             = "/restore"
+	StoragePrefix           = "/storage"
+	ImportPath              = "/import"
+	EncryptPath             = "/encrypt"
+	SigningPrefix           = "/signing"
+	RatesPath               = "/rates"
+	FreezesPrefix           = "/freezes"
+	RetentionPrefix         = "/retention"
+	HoldsPrefix             = "/holds"
+	RunsPath                = "/runs"
+	ApprovalsPrefix         = "/approvals"
+	ReviewPath              = "/review"
+	AdminKeyStorePrefix     = "/keystore"
+	SealPath                = "/seal"
+	SharesPath              = "/shares"
+	UnsealPath              = "/unseal"
+	AnchorsPrefix           = "/anchors"
+	ExpiriesPrefix          = "/expiries"
+	WarningsPath            = "/warnings"
+	SweepsPath              = "/sweeps"
+	StatsPrefix             = "/stats"
+	ExportPath              = "/export"
+	BatchPath               = "/batch"
+	OIDC4VCIPrefix          = "/oidc4vci"
+	OffersPrefix            = "/offers"
+	TokenPath               = "/token"
+	CredentialPath          = "/credential"
+	OIDC4VPPrefix           = "/oidc4vp"
+	ResponsePath            = "/response"
+	RotatePath              = "/rotate"
+	ReencryptPath           = "/reencrypt"
+	ResolutionCachePrefix   = "/resolution/cache"
+	ChallengesPrefix        = "/challenges"
+	TrustPrefix             = "/trust"
+	TrustedIssuersPath      = "/issuers"
+	ImportsPath             = "/imports"
+	JWKPath                 = "/jwk"
+
+	CredentialIssuerMetadataPath    = "/.well-known/openid-credential-issuer"
+	AuthorizationServerMetadataPath = "/.well-known/oauth-authorization-server"
+	JWKSPath                        = "/.well-known/jwks.json"
 )
 
+// APIVersions are the prefixes of the versions of the API that are served, from oldest to newest.
+var APIVersions = []string{V1Prefix, V2Prefix}
+
+// batchUndos are the requests that can be part of an atomic batch, along with the request that undoes each of them.
+var batchUndos = []middleware.BatchUndo{
+	{Method: http.MethodPut, Route: DIDsPrefix + "/:method", Undo: DIDsPrefix + "/:method/{response:$.did.id}"},
+	{Method: http.MethodPut, Route: KeyStorePrefix, Undo: KeyStorePrefix + "/{request:$.id}"},
+	{Method: http.MethodPut, Route: SchemasPrefix, Undo: SchemasPrefix + "/{response:$.id}"},
+	{Method: http.MethodPut, Route: CredentialsPrefix, Undo: CredentialsPrefix + "/{response:$.id}"},
+	{Method: http.MethodPut, Route: PresentationsPrefix + DefinitionsPrefix, Undo: PresentationsPrefix + DefinitionsPrefix + "/{response:$.presentation_definition.id}"},
+	{Method: http.MethodPut, Route: ManifestsPrefix, Undo: ManifestsPrefix + "/{response:$.credential_manifest.id}"},
+	{Method: http.MethodPut, Route: IssuanceTemplatePrefix, Undo: IssuanceTemplatePrefix + "/{response:$.id}"},
+}
+
+// experimentalFeatures are the flags guarding the capabilities that ship disabled, until they graduate and their flag
+// is removed. Their routes require the flag with middleware.RequireFeature.
+var experimentalFeatures []features.Flag
+
+// meteredRoutes are the requests whose usage is metered, and what they count towards.
+var meteredRoutes = []middleware.MeteredRoute{
+	{Method: http.MethodPut, Route: CredentialsPrefix, Meter: usage.MeterIssuance},
+	{Method: http.MethodPut, Route: CredentialsPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: CredentialsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterIssuance, Count: "{request:$.requests}"},
+	{Method: http.MethodPut, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterSigning, Count: "{request:$.requests}"},
+	{Method: http.MethodPut, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterStorage},
+	{Method: http.MethodPost, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterIssuance, Count: "{response:$.results[*].credential}"},
+	{Method: http.MethodPost, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterSigning, Count: "{response:$.results[*].credential}"},
+	{Method: http.MethodPost, Route: CredentialsPrefix + BatchPath, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: CredentialsPrefix + "/:id" + StatusPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: CredentialsPrefix + VerificationPath, Meter: usage.MeterVerification},
+	{Method: http.MethodPut, Route: ManifestsPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: ManifestsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: ManifestsPrefix + RequestsPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: ManifestsPrefix + RequestsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: ManifestsPrefix + ApplicationsPrefix, Meter: usage.MeterVerification},
+	{Method: http.MethodPut, Route: ManifestsPrefix + ApplicationsPrefix, Meter: usage.MeterIssuance, Count: "{response:$.verifiableCredentials}"},
+	{Method: http.MethodPut, Route: ManifestsPrefix + ApplicationsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: ManifestsPrefix + ApplicationsPrefix + "/:id/review", Meter: usage.MeterIssuance, Count: "{response:$.verifiableCredentials}"},
+	{Method: http.MethodPut, Route: PresentationsPrefix + DefinitionsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: PresentationsPrefix + RequestsPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: PresentationsPrefix + RequestsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: PresentationsPrefix + SubmissionsPrefix, Meter: usage.MeterVerification},
+	{Method: http.MethodPut, Route: PresentationsPrefix + SubmissionsPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: DIDConfigurationsPrefix, Meter: usage.MeterSigning},
+	{Method: http.MethodPut, Route: DIDConfigurationsPrefix + VerificationPath, Meter: usage.MeterVerification},
+	{Method: http.MethodPut, Route: SchemasPrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: IssuanceTemplatePrefix, Meter: usage.MeterStorage},
+	{Method: http.MethodPut, Route: KeyStorePrefix, Meter: usage.MeterStorage},
+}
+
+// sensitiveRoutes are the routes that need approval when approval is enabled without configuring them: downloading
+// and restoring backups, and exporting and importing storage, which hold private keys, erasing data subjects, revoking
+// keys, batches, which is how credentials are revoked in bulk, and creating and deleting issuance templates, which
+// decide what applications to their manifests are approved without review.
+func sensitiveRoutes() []config.ApprovalRouteConfig {
+	routes := []config.ApprovalRouteConfig{
+		{Method: http.MethodGet, Path: AdminPrefix + BackupsPrefix + "/:" + router.NameParam},
+		{Method: http.MethodPut, Path: AdminPrefix + BackupsPrefix + RestorePath},
+		{Method: http.MethodGet, Path: AdminPrefix + StoragePrefix + ExportPath},
+		{Method: http.MethodPut, Path: AdminPrefix + StoragePrefix + ImportPath},
+		{Method: http.MethodPut, Path: AdminPrefix + ErasuresPrefix},
+	}
+	for _, version := range APIVersions {
+		routes = append(routes,
+			config.ApprovalRouteConfig{Method: http.MethodDelete, Path: version + KeyStorePrefix + "/:id"},
+			config.ApprovalRouteConfig{Method: http.MethodPost, Path: version + BatchPath},
+			config.ApprovalRouteConfig{Method: http.MethodPut, Path: version + IssuanceTemplatePrefix},
+			config.ApprovalRouteConfig{Method: http.MethodDelete, Path: version + IssuanceTemplatePrefix + "/:id"},
+		)
+	}
+	return routes
+}
+
+// dryRunRoutes are the routes whose handlers honor ?dryRun=true, which approval lets dry runs of through. Dry runs of
+// other routes that need approval are rejected, rather than handled for real without it.
+func dryRunRoutes() []config.ApprovalRouteConfig {
+	routes := []config.ApprovalRouteConfig{
+		{Method: http.MethodPut, Path: AdminPrefix + BackupsPrefix + RestorePath},
+		{Method: http.MethodPut, Path: AdminPrefix + StoragePrefix + ImportPath},
+		{Method: http.MethodPut, Path: AdminPrefix + StoragePrefix + EncryptPath},
+		{Method: http.MethodPut, Path: AdminPrefix + ErasuresPrefix},
+		{Method: http.MethodPut, Path: AdminPrefix + RetentionPrefix + RunsPath},
+	}
+	for _, version := range APIVersions {
+		routes = append(routes, config.ApprovalRouteConfig{Method: http.MethodPut, Path: version + CredentialsPrefix + "/:id" + StatusPrefix})
+	}
+	return routes
+}
+
+// operationRetentionInterval is how often expired async operations, and idempotency records, are deleted.
+const operationRetentionInterval = time.Hour
+
 // SSIServer exposes all dependencies needed to run a http server and all its services
 type SSIServer struct {
 	*config.ServerConfig
 	*service.SSIService
 	*framework.Server
+
+	// Admin serves the admin endpoints when an admin listener is configured, and is nil otherwise. It must be started
+	// and shut down along with Server.
+	Admin *framework.Server
+
+	// the config the server is running with, and the parts of it that can be reloaded
+	cfg        config.SSIServiceConfig
+	rateLimits *middleware.RateLimits
+	metering   *middleware.Metering
+	features   *features.Flags
+	logSampler *logging.Sampler
+	cors       *middleware.CORS
+
+	// loads the config again when it's reloaded, and serializes reloads
+	loadConfig func() (*config.SSIServiceConfig, error)
+	reloadMu   sync.Mutex
+
+	// set once the server starts shutting down, from when readiness probes fail
+	shuttingDown *atomic.Bool
+
+	// the jobs scheduled in the background, which run until stopJobs is called
+	jobs     *inflight.Tracker
+	stopJobs context.CancelFunc
 }
 
-// NewSSIServer does two things: instantiates all service and registers their HTTP bindings
-func NewSSIServer(shutdown chan os.Signal, cfg config.SSIServiceConfig) (*SSIServer, error) {
+// NewSSIServer does two things: instantiates all service and registers their HTTP bindings. Options customize both,
+// for programs that embed the service.
+func NewSSIServer(shutdown chan os.Signal, cfg config.SSIServiceConfig, opts ...Option) (*SSIServer, error) {
+	o := newOptions(opts)
+
 	// creates an HTTP server from the framework, and wrap it to extend it for the SSIS
-	engine := setUpEngine(cfg.Server, shutdown)
+	logSampler := logging.NewSampler(cfg.Server.LogSampling)
+	cors, err := middleware.NewCORS(cfg.Server.EnableAllowAllCORS, cfg.Server.CORSAllowedOrigins)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to set up cors")
+	}
+	engine := setUpEngine(cfg.Server, shutdown, logSampler, cors)
+	flags := features.NewFlags(append(experimentalFeatures, o.features...), cfg.Server.Features)
+	engine.Use(middleware.Features(flags))
+	engine.Use(o.middleware...)
 	httpServer := framework.NewServer(cfg.Server, engine, shutdown)
-	ssi, err := service.InstantiateSSIService(cfg.Services)
+	if cfg.Server.TLS.Enabled {
+		if err := httpServer.EnableTLS(cfg.Server.TLS); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to enable tls")
+		}
+	}
+	ssi, err := service.InstantiateSSIService(cfg.Services, o.services...)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate ssi service")
 	}
 
 	// service-level routers
 	engine.GET(HealthPrefix, router.Health)
-	engine.GET(ReadinessPrefix, router.Readiness(ssi.GetServices()))
-	engine.StaticFile("swagger.yaml", "./doc/swagger.yaml")
-	engine.GET(SwaggerPrefix, ginswagger.WrapHandler(swaggerfiles.Handler, ginswagger.URL("/swagger.yaml")))
+	engine.GET(LivenessPrefix, router.Health)
+	// the server isn't ready once it's shutting down, so that it stops being sent requests while it drains
+	shuttingDown := new(atomic.Bool)
+	httpServer.RegisterPreShutdownHook(func(context.Context) error {
+		shuttingDown.Store(true)
+		return nil
+	})
+	engine.GET(ReadinessPrefix, router.Readiness(ssi.GetServices(), shuttingDown.Load))
+	openAPIDocument, err := openapi.FromSwagger2(doc.SwaggerYAML)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to convert swagger spec to openapi")
+	}
+	engine.GET(OpenAPIPath, router.OpenAPI(openAPIDocument))
+	engine.GET(SwaggerJSONPath, router.OpenAPI(openAPIDocument))
+	engine.GET(SwaggerYAMLPath, router.Swagger(doc.SwaggerYAML))
+	engine.GET(SwaggerPrefix, ginswagger.WrapHandler(swaggerfiles.Handler, ginswagger.URL(SwaggerJSONPath)))
+	var requestValidator *openapi.Validator
+	if cfg.Server.ValidateRequests {
+		if requestValidator, err = openapi.NewValidator(openAPIDocument); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to compile request schemas of openapi spec")
+		}
+	}
+	if err = WebDIDAPI(engine, ssi.DID); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Web DID API")
+	}
+	if err = JWKSAPI(engine, ssi.KeyStore); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate JWKS API")
+	}
+	if ssi.OIDC4VCI != nil {
+		if err = OIDC4VCIWalletAPI(engine, ssi.OIDC4VCI); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate OIDC4VCI wallet API")
+		}
+	}
+	if ssi.OIDC4VP != nil {
+		if err = OIDC4VPWalletAPI(engine, ssi.OIDC4VP); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate OIDC4VP wallet API")
+		}
+	}
+
+	if cfg.Server.EnableBearerTokenAuth && !ssi.Auth.OAuthEnabled() {
+		return nil, sdkutil.LoggingNewError("bearer token auth is enabled, but no oauth issuer is configured")
+	}
 
-	// register all v1 routers
-	v1 := engine.Group(V1Prefix)
-	if err = KeyStoreAPI(v1, ssi.KeyStore); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate KeyStore API")
+	// admin routers always require an admin credential, regardless of whether the rest of the API is protected. When an
+	// admin listener is configured they're only served there, keeping them off the port the API is exposed on.
+	adminEngine := engine
+	var adminServer *framework.Server
+	if cfg.Server.Admin.Host != "" {
+		adminEngine = setUpEngine(cfg.Server, shutdown, logSampler, cors)
+		adminEngine.Use(middleware.Features(flags))
+		adminEngine.Use(o.middleware...)
+		adminEngine.GET(HealthPrefix, router.Health)
+		if adminServer, err = newAdminServer(cfg.Server, adminEngine, shutdown); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate admin listener")
+		}
 	}
-	if err = DecentralizedIdentityAPI(v1, ssi.DID, ssi.BatchDID, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate DID API")
+	allowAdminNetworks, err := middleware.AllowNetworks(cfg.Server.Admin.AllowedNetworks)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to configure admin networks")
+	}
+	// metrics are scraped without a credential, so like the ui they're only served to the admin networks
+	if cfg.Server.Metrics.Enabled {
+		adminEngine.GET(MetricsPath, allowAdminNetworks, gin.WrapH(metrics.Handler()))
+	}
+	// the ui holds no data, so it's only restricted to the admin networks; what it shows is read with the operator's
+	// admin credential
+	if cfg.Server.Admin.EnableUI {
+		apiURL := ""
+		if cfg.Server.Admin.Host != "" {
+			apiURL = cfg.Services.ServiceEndpoint
+		}
+		AdminUIAPI(adminEngine.Group(AdminPrefix, allowAdminNetworks), apiURL)
+	}
+	var approvals *middleware.Approvals
+	if ssi.Approval != nil {
+		routes := cfg.Services.ApprovalConfig.Routes
+		if len(routes) == 0 {
+			routes = sensitiveRoutes()
+		}
+		if approvals, err = middleware.NewApprovals(ssi.Approval, routes, dryRunRoutes()); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to configure approvals")
+		}
+	}
+	admin := adminEngine.Group(AdminPrefix, allowAdminNetworks)
+	if cfg.Server.Audit.Enabled {
+		admin.Use(middleware.NewAudit(ssi.Audit, adminEngine, nil).Handler())
+	}
+	admin.Use(middleware.Authenticate(ssi.Auth, true, ssi.Auth.OAuthEnabled()), middleware.RequireAdmin())
+	if cfg.Server.RequestSignatures.Required {
+		admin.Use(middleware.RequireSignatures(ssi.Auth, ssi.Replay, cfg.Server.RequestSignatures))
+	}
+	if approvals != nil {
+		admin.Use(approvals.Handler())
+	}
+	if err = AuthAPI(admin, ssi.Auth); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Auth API")
+	}
+	if err = AuditAPI(admin, ssi.Audit); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Audit API")
+	}
+	if err = ErasureAPI(admin, ssi.Erasure); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Erasure API")
+	}
+	if err = UsageAPI(admin, ssi.Usage); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Usage API")
+	}
+	if ssi.Backup != nil {
+		if err = BackupAPI(admin, ssi.Backup); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Backup API")
+		}
+	}
+	if ssi.Anomaly != nil {
+		if err = AnomalyAPI(admin, ssi.Anomaly); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Anomaly API")
+		}
+	}
+	if ssi.Retention != nil {
+		if err = RetentionAPI(admin, ssi.Retention); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Retention API")
+		}
+	}
+	if ssi.Approval != nil {
+		if err = ApprovalAPI(admin, ssi.Approval); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Approval API")
+		}
+	}
+	if ssi.Anchor != nil {
+		if err = AnchorAPI(admin, ssi.Anchor); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Anchor API")
+		}
+	}
+	if ssi.Expiry != nil {
+		if err = ExpiryAPI(admin, ssi.Expiry); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Expiry API")
+		}
 	}
-	if err = SchemaAPI(v1, ssi.Schema, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Schema API")
+	if cache := ssi.DID.GetResolutionCache(); cache != nil {
+		if err = ResolutionCacheAPI(admin, cache); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Resolution Cache API")
+		}
 	}
-	if err = CredentialAPI(v1, ssi.Credential, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Credential API")
+	if err = KeyStoreAdminAPI(admin, ssi.KeyStore); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate KeyStore admin API")
 	}
-	if err = OperationAPI(v1, ssi.Operation); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Operation API")
+	if ssi.KeyShares != nil {
+		if err = KeySharesAPI(admin, ssi.KeyShares); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Key Shares API")
+		}
 	}
-	if err = PresentationAPI(v1, ssi.Presentation, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Presentation API")
+	if err = StorageAPI(admin, ssi.GetRawStorage(), ssi.GetNamespaceEncryption()); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Storage API")
 	}
-	if err = ManifestAPI(v1, ssi.Manifest, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Manifest API")
+	admin.GET(FeaturesPrefix, router.Features(flags))
+	// the server is filled in once everything it serves is set up
+	server := new(SSIServer)
+	admin.PUT(ConfigReloadPath, router.ReloadConfig(server.ReloadConfig))
+	if cfg.Server.Admin.EnableDebug {
+		DebugAPI(admin, ssi.GetStorage())
 	}
-	if err = IssuanceAPI(v1, ssi.Issuance); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Issuance API")
+
+	// every version of the API shares its middleware, and the version's deprecation headers, if it is deprecated
+	deprecations, err := deprecationsByVersion(cfg.Server.Deprecations)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to configure api deprecations")
+	}
+	var rateLimits *middleware.RateLimits
+	if cfg.Server.RateLimit.Enabled {
+		limiter, err := ratelimit.NewLimiter(cfg.Server.RateLimit)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate rate limiter")
+		}
+		rateLimits = middleware.NewRateLimits(limiter, cfg.Server.RateLimit)
 	}
-	if err = WebhookAPI(v1, ssi.Webhook); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate Webhook API")
+	var metering *middleware.Metering
+	if cfg.Server.Usage.Enabled {
+		if metering, err = middleware.NewMetering(ssi.Usage, meteredRoutes, cfg.Server.Usage); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to configure usage quotas")
+		}
+	}
+	idempotencyStore, err := idempotency.NewStore(ssi.GetStorage(), idempotency.DefaultTTL)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate idempotency store")
 	}
-	if err = DIDConfigurationAPI(v1, ssi.DIDConfiguration); err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "unable to instantiate DIDConfiguration API")
+	asyncOperations := middleware.NewAsync(ssi.Operation, engine)
+	preconditions := middleware.NewPreconditions(engine, cfg.Server.RequireIfMatch)
+	caching := middleware.NewCaching(cfg.Server.Caching, cfg.Server.EnableAPIKeyAuth || cfg.Server.EnableBearerTokenAuth)
+	batch := middleware.NewBatch(engine, batchUndos)
+	auditing := middleware.NewAudit(ssi.Audit, engine, batchUndos)
+	for _, version := range APIVersions {
+		api := engine.Group(version)
+		if deprecation, ok := deprecations[version]; ok {
+			api.Use(deprecation)
+		}
+		if cfg.Server.Audit.Enabled {
+			api.Use(auditing.Handler())
+		}
+		if cfg.Server.EnableAPIKeyAuth || cfg.Server.EnableBearerTokenAuth {
+			api.Use(middleware.Authenticate(ssi.Auth, cfg.Server.EnableAPIKeyAuth, cfg.Server.EnableBearerTokenAuth))
+		}
+		if rateLimits != nil {
+			api.Use(rateLimits.Handler())
+		}
+		if cfg.Server.RequestSignatures.Required {
+			api.Use(middleware.RequireSignatures(ssi.Auth, ssi.Replay, cfg.Server.RequestSignatures))
+		}
+		// before approvals, so that invalid requests aren't held for approval
+		if requestValidator != nil {
+			api.Use(middleware.ValidateRequests(requestValidator, APIVersions))
+		}
+		// before idempotency, so that the response asking for approval isn't replayed to the request with it
+		if approvals != nil {
+			api.Use(approvals.Handler())
+		}
+		api.Use(middleware.Idempotency(idempotencyStore), middleware.Fields())
+		// after idempotency, so that replaying a stored response isn't counted again
+		if metering != nil {
+			api.Use(metering.Handler())
+		}
+		api.Use(o.apiMiddleware...)
+		if err = registerAPI(api, ssi, asyncOperations, preconditions, caching); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "unable to register %s routers", version)
+		}
+		BatchAPI(api, batch, asyncOperations)
+		for _, register := range o.apiRoutes {
+			if err = register(api); err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "unable to register custom %s routes", version)
+			}
+		}
+	}
+	for _, register := range o.routes {
+		if err = register(engine); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to register custom routes")
+		}
+	}
+
+	if cfg.Server.FixturesFile != "" {
+		if err = loadFixtures(cfg.Server, engine, ssi.GetStorage()); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unable to load fixtures")
+		}
 	}
 
-	return &SSIServer{
+	// expired async operations and idempotency records are deleted, backups are taken, retention is enforced, state is
+	// anchored, expiries are checked, and consumed JWTs, signatures, and request nonces are purged in the background
+	// until the server drains
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := new(inflight.Tracker)
+	runJob(jobsCtx, jobs, func(ctx context.Context) { ssi.Operation.RunRetention(ctx, operationRetentionInterval) })
+	runJob(jobsCtx, jobs, func(ctx context.Context) { idempotencyStore.RunRetention(ctx, operationRetentionInterval) })
+	if ssi.Backup != nil {
+		runJob(jobsCtx, jobs, ssi.Backup.RunSchedule)
+	}
+	if ssi.Retention != nil {
+		runJob(jobsCtx, jobs, ssi.Retention.RunSchedule)
+	}
+	if ssi.Anchor != nil {
+		runJob(jobsCtx, jobs, ssi.Anchor.RunSchedule)
+	}
+	if ssi.Expiry != nil {
+		runJob(jobsCtx, jobs, ssi.Expiry.RunSchedule)
+	}
+	if ssi.Presentation != nil {
+		runJob(jobsCtx, jobs, ssi.Presentation.RunSchedule)
+	}
+	if ssi.Replay != nil {
+		runJob(jobsCtx, jobs, ssi.Replay.RunSchedule)
+	}
+	if ssi.Challenge != nil {
+		runJob(jobsCtx, jobs, ssi.Challenge.RunSchedule)
+	}
+	if ssi.OIDC4VCI != nil {
+		runJob(jobsCtx, jobs, ssi.OIDC4VCI.RunSchedule)
+	}
+	if ssi.OIDC4VP != nil {
+		runJob(jobsCtx, jobs, ssi.OIDC4VP.RunSchedule)
+	}
+
+	*server = SSIServer{
 		Server:       httpServer,
+		Admin:        adminServer,
 		SSIService:   ssi,
 		ServerConfig: &cfg.Server,
-	}, nil
+		cfg:          cfg,
+		rateLimits:   rateLimits,
+		metering:     metering,
+		features:     flags,
+		logSampler:   logSampler,
+		cors:         cors,
+		loadConfig:   o.loadConfig,
+		shuttingDown: shuttingDown,
+		jobs:         jobs,
+		stopJobs:     stopJobs,
+	}
+	return server, nil
 }
 
-// setUpEngine creates the gin engine and sets up the middleware based on config
-func setUpEngine(cfg config.ServerConfig, shutdown chan os.Signal) *gin.Engine {
-	gin.ForceConsoleColor()
-	middlewares := gin.HandlersChain{
-		gin.Recovery(),
-		gin.Logger(),
-		middleware.Errors(shutdown),
+// loadFixtures creates the fixtures of the configured file through the newest version of the API served by engine.
+func loadFixtures(cfg config.ServerConfig, engine *gin.Engine, db storage.ServiceStorage) error {
+	if cfg.Environment == config.EnvironmentProd {
+		return errors.New("fixtures aren't loaded in the prod environment")
+	}
+	file, err := fixtures.ReadFile(cfg.FixturesFile)
+	if err != nil {
+		return err
+	}
+	return fixtures.Load(context.Background(), engine, db, APIVersions[len(APIVersions)-1], *file)
+}
+
+// newAdminServer creates the server of the admin listener, which has an address and TLS config of its own.
+func newAdminServer(cfg config.ServerConfig, engine *gin.Engine, shutdown chan os.Signal) (*framework.Server, error) {
+	adminServer := framework.NewServer(cfg, engine, shutdown)
+	adminServer.Addr = cfg.Admin.Host
+	if cfg.Admin.TLS.Enabled {
+		if err := adminServer.EnableTLS(cfg.Admin.TLS); err != nil {
+			return nil, errors.Wrap(err, "enabling tls")
+		}
 	}
-	if cfg.JagerEnabled {
+	return adminServer, nil
+}
+
+// setUpEngine creates the gin engine and sets up the middleware based on config
+func setUpEngine(cfg config.ServerConfig, shutdown chan os.Signal, logSampler *logging.Sampler, cors *middleware.CORS) *gin.Engine {
+	middlewares := gin.HandlersChain{gin.Recovery()}
+	if cfg.TracingEnabled() {
+		// requests are traced before anything is logged, so that their logs carry the IDs of their traces
 		middlewares = append(middlewares, otelgin.Middleware(config.ServiceName))
 	}
-	if cfg.EnableAllowAllCORS {
-		middlewares = append(middlewares, middleware.CORS())
+	middlewares = append(middlewares,
+		middleware.RequestID(),
+		middleware.AccessLog(logSampler),
+	)
+	if cfg.Metrics.Enabled {
+		middlewares = append(middlewares, middleware.Metrics())
+	}
+	middlewares = append(middlewares,
+		middleware.Errors(shutdown),
+		middleware.BodyLimit(cfg.RequestLimits),
+		middleware.Timeouts(cfg.Timeouts),
+	)
+	if cfg.Compression.Enabled {
+		middlewares = append(middlewares, middleware.Compression(cfg.Compression))
 	}
+	middlewares = append(middlewares, cors.Handler())
 
 	// set up engine and middleware
 	engine := gin.New()
 	engine.Use(middlewares...)
+	engine.NoRoute(noRoute)
 	switch cfg.Environment {
 	case config.EnvironmentDev:
 		gin.SetMode(gin.DebugMode)
@@ -140,8 +645,117 @@ func setUpEngine(cfg config.ServerConfig, shutdown chan os.Signal) *gin.Engine {
 	return engine
 }
 
+// noRoute responds to requests that match no route.
+func noRoute(c *gin.Context) {
+	framework.RespondProblem(c, framework.ErrorResponse{
+		Status: http.StatusNotFound,
+		Detail: "no route matches " + c.Request.Method + " " + c.Request.URL.Path,
+	})
+}
+
+// registerAPI registers the routers of every service on a version of the API. Versions serve the same handlers until
+// one of them changes the shape of its requests or responses, which is when the routers of the new version diverge.
+func registerAPI(api *gin.RouterGroup, ssi *service.SSIService, asyncOperations *middleware.Async, preconditions *middleware.Preconditions, caching *middleware.Caching) error {
+	if err := KeyStoreAPI(api, ssi.KeyStore, ssi.Auth, preconditions); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate KeyStore API")
+	}
+	if err := DecentralizedIdentityAPI(api, ssi.DID, ssi.BatchDID, ssi.Webhook, ssi.Auth, asyncOperations, preconditions, caching); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate DID API")
+	}
+	if err := SchemaAPI(api, ssi.Schema, ssi.Webhook, ssi.Auth, preconditions, caching); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Schema API")
+	}
+	if err := CredentialAPI(api, ssi.Credential, ssi.Webhook, ssi.Auth, asyncOperations, preconditions, caching); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Credential API")
+	}
+	if err := OperationAPI(api, ssi.Operation, ssi.Auth); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Operation API")
+	}
+	if err := PresentationAPI(api, ssi.Presentation, ssi.Webhook, ssi.Auth, ssi.Replay, ssi.Challenge, preconditions); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Presentation API")
+	}
+	if err := ManifestAPI(api, ssi.Manifest, ssi.Webhook, ssi.Auth, ssi.Replay, ssi.Challenge, asyncOperations, preconditions); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Manifest API")
+	}
+	if err := IssuanceAPI(api, ssi.Issuance, ssi.Auth); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Issuance API")
+	}
+	if err := WebhookAPI(api, ssi.Webhook, ssi.Auth); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Webhook API")
+	}
+	if err := DIDConfigurationAPI(api, ssi.DIDConfiguration, ssi.Auth); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate DIDConfiguration API")
+	}
+	if err := StatsAPI(api, ssi.Stats); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "unable to instantiate Stats API")
+	}
+	if ssi.Transparency != nil {
+		if err := TransparencyAPI(api, ssi.Transparency); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "unable to instantiate Transparency API")
+		}
+	}
+	if ssi.Challenge != nil {
+		if err := ChallengeAPI(api, ssi.Challenge, ssi.Auth); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "unable to instantiate Challenge API")
+		}
+	}
+	if ssi.Trust != nil {
+		if err := TrustAPI(api, ssi.Trust, ssi.Auth); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "unable to instantiate Trust API")
+		}
+	}
+	if ssi.OIDC4VCI != nil {
+		if err := OIDC4VCIAPI(api, ssi.OIDC4VCI, ssi.Auth); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "unable to instantiate OIDC4VCI API")
+		}
+	}
+	if ssi.OIDC4VP != nil {
+		if err := OIDC4VPAPI(api, ssi.OIDC4VP, ssi.Auth); err != nil {
+			return sdkutil.LoggingErrorMsg(err, "unable to instantiate OIDC4VP API")
+		}
+	}
+	return nil
+}
+
+// deprecationsByVersion builds the deprecation middleware of each deprecated version of the API, keyed by its prefix.
+// Responses of a deprecated version link to the same route in the version after it.
+func deprecationsByVersion(deprecations []config.DeprecationConfig) (map[string]gin.HandlerFunc, error) {
+	handlers := make(map[string]gin.HandlerFunc, len(deprecations))
+	for _, deprecation := range deprecations {
+		version := "/" + strings.TrimPrefix(deprecation.Version, "/")
+		if _, ok := handlers[version]; ok {
+			return nil, errors.Errorf("api version %s is deprecated more than once", deprecation.Version)
+		}
+		successor, ok := successorVersion(version)
+		if !ok {
+			return nil, errors.Errorf("unknown api version: %s", deprecation.Version)
+		}
+		handler, err := middleware.Deprecation(deprecation, version, successor)
+		if err != nil {
+			return nil, err
+		}
+		handlers[version] = handler
+	}
+	return handlers, nil
+}
+
+// successorVersion returns the version of the API after version, which is empty for the latest version, and whether
+// version is served at all.
+func successorVersion(version string) (string, bool) {
+	for i, v := range APIVersions {
+		if v != version {
+			continue
+		}
+		if i+1 < len(APIVersions) {
+			return APIVersions[i+1], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
 // DecentralizedIdentityAPI registers all HTTP handlers for the DID Service
-func DecentralizedIdentityAPI(rg *gin.RouterGroup, service *didsvc.Service, did *didsvc.BatchService, webhookService *webhook.Service) (err error) {
+func DecentralizedIdentityAPI(rg *gin.RouterGroup, service *didsvc.Service, did *didsvc.BatchService, webhookService *webhook.Service, authService *auth.Service, asyncOperations *middleware.Async, preconditions *middleware.Preconditions, caching *middleware.Caching) (err error) {
 	didRouter, err := router.NewDIDRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating DID router")
@@ -149,33 +763,47 @@ func DecentralizedIdentityAPI(rg *gin.RouterGroup, service *didsvc.Service, did
 	batchDIDRouter := router.NewBatchDIDRouter(did)
 
 	didAPI := rg.Group(DIDsPrefix)
-	didAPI.GET("", didRouter.ListDIDMethods)
-	didAPI.PUT("/:method", middleware.Webhook(webhookService, webhook.DID, webhook.Create), didRouter.CreateDIDByMethod)
-	didAPI.PUT("/:method/batch", middleware.Webhook(webhookService, webhook.DID, webhook.BatchCreate), batchDIDRouter.BatchCreateDIDs)
-	didAPI.GET("/:method", didRouter.ListDIDsByMethod)
-	didAPI.GET("/:method/:id", didRouter.GetDIDByMethod)
-	didAPI.DELETE("/:method/:id", didRouter.SoftDeleteDIDByMethod)
-	didAPI.GET(ResolverPrefix+"/:id", didRouter.ResolveDID)
+	didAPI.GET("", middleware.RequirePermission(authService, auth.ScopeDIDsRead, ""), didRouter.ListDIDMethods)
+	didAPI.PUT("/:method", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), asyncOperations.Handler("dids"), middleware.Webhook(webhookService, webhook.DID, webhook.Create), didRouter.CreateDIDByMethod)
+	didAPI.PUT("/:method/batch", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), asyncOperations.Handler("dids/batch"), middleware.Webhook(webhookService, webhook.DID, webhook.BatchCreate), batchDIDRouter.BatchCreateDIDs)
+	didAPI.GET("/:method", middleware.RequirePermission(authService, auth.ScopeDIDsRead, ""), didRouter.ListDIDsByMethod)
+	didAPI.GET("/:method/:id", middleware.RequirePermission(authService, auth.ScopeDIDsRead, ""), caching.Cache(), didRouter.GetDIDByMethod)
+	didAPI.DELETE("/:method/:id", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), preconditions.IfMatch(), didRouter.SoftDeleteDIDByMethod)
+	didAPI.PUT("/:method/:id/restore", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), didRouter.RestoreDIDByMethod)
+	didAPI.GET(ResolverPrefix+"/:id", middleware.RequirePermission(authService, auth.ScopeDIDsRead, ""), asyncOperations.Handler("dids/resolver"), caching.Cache(), didRouter.ResolveDID)
 	return
 }
 
+// WebDIDAPI serves the documents of the did:web DIDs the service hosts. They're served at whatever paths the DIDs
+// resolve to, which no route can match without conflicting with the others, so they're served for requests that
+// match no route.
+func WebDIDAPI(engine *gin.Engine, service *didsvc.Service) error {
+	didRouter, err := router.NewDIDRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating DID router")
+	}
+	engine.NoRoute(didRouter.GetWebDIDDocument, noRoute)
+	return nil
+}
+
 // SchemaAPI registers all HTTP handlers for the Schema Service
-func SchemaAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service) (err error) {
+func SchemaAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service, authService *auth.Service, preconditions *middleware.Preconditions, caching *middleware.Caching) (err error) {
 	schemaRouter, err := router.NewSchemaRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating schema router")
 	}
 
 	schemaAPI := rg.Group(SchemasPrefix)
-	schemaAPI.PUT("", middleware.Webhook(webhookService, webhook.Schema, webhook.Create), schemaRouter.CreateSchema)
-	schemaAPI.GET("/:id", schemaRouter.GetSchema)
-	schemaAPI.GET("", schemaRouter.ListSchemas)
-	schemaAPI.DELETE("/:id", middleware.Webhook(webhookService, webhook.Schema, webhook.Delete), schemaRouter.DeleteSchema)
+	schemaAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeSchemasWrite, ""), preconditions.IfMatchAt(schemaRouter.SchemaVersionPath), middleware.Webhook(webhookService, webhook.Schema, webhook.Create), schemaRouter.CreateSchema)
+	schemaAPI.GET("/:id", middleware.RequirePermission(authService, auth.ScopeSchemasRead, ""), caching.Cache(), schemaRouter.GetSchema)
+	schemaAPI.GET("/:id/versions", middleware.RequirePermission(authService, auth.ScopeSchemasRead, ""), schemaRouter.ListSchemaVersions)
+	schemaAPI.GET("", middleware.RequirePermission(authService, auth.ScopeSchemasRead, ""), schemaRouter.ListSchemas)
+	schemaAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeSchemasWrite, ""), preconditions.IfMatch(), middleware.Webhook(webhookService, webhook.Schema, webhook.Delete), schemaRouter.DeleteSchema)
 	return
 }
 
 // CredentialAPI registers all HTTP handlers for the Credentials Service
-func CredentialAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service) (err error) {
+func CredentialAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service, authService *auth.Service, asyncOperations *middleware.Async, preconditions *middleware.Preconditions, caching *middleware.Caching) (err error) {
 	credRouter, err := router.NewCredentialRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating credential router")
@@ -183,63 +811,117 @@ func CredentialAPI(rg *gin.RouterGroup, service svcframework.Service, webhookSer
 
 	// Credentials
 	credentialAPI := rg.Group(CredentialsPrefix)
-	credentialAPI.PUT("", middleware.Webhook(webhookService, webhook.Credential, webhook.Create), credRouter.CreateCredential)
-	credentialAPI.PUT("/batch", middleware.Webhook(webhookService, webhook.Credential, webhook.BatchCreate), credRouter.BatchCreateCredentials)
-	credentialAPI.GET("", credRouter.ListCredentials)
-	credentialAPI.GET("/:id", credRouter.GetCredential)
+	credentialAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, auth.ResourceCredential), middleware.RecordOwnership(authService, auth.ResourceCredential, "$.id"), middleware.Webhook(webhookService, webhook.Credential, webhook.Create), credRouter.CreateCredential)
+	credentialAPI.PUT("/batch", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, auth.ResourceCredential), asyncOperations.Handler("credentials/batch"), middleware.Webhook(webhookService, webhook.Credential, webhook.BatchCreate), credRouter.BatchCreateCredentials)
+	credentialAPI.POST("/batch", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, auth.ResourceCredential), asyncOperations.Handler("credentials/batch"), middleware.Webhook(webhookService, webhook.Credential, webhook.BatchCreate), credRouter.BatchCreateCredentialsIndependently)
+	credentialAPI.GET("", middleware.RequirePermission(authService, auth.ScopeCredentialsRead, ""), middleware.RejectOwnedOnly(auth.ScopeCredentialsRead), credRouter.ListCredentials)
+	credentialAPI.GET("/:id", middleware.RequirePermission(authService, auth.ScopeCredentialsRead, auth.ResourceCredential), credRouter.GetCredential)
 	credentialAPI.PUT(VerificationPath, credRouter.VerifyCredential)
-	credentialAPI.DELETE("/:id", middleware.Webhook(webhookService, webhook.Credential, webhook.Delete), credRouter.DeleteCredential)
+	credentialAPI.PUT(DerivationPath, credRouter.DeriveCredential)
+	credentialAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, auth.ResourceCredential), middleware.Webhook(webhookService, webhook.Credential, webhook.Delete), credRouter.DeleteCredential)
 
 	// Credential Status
-	credentialAPI.GET("/:id"+StatusPrefix, credRouter.GetCredentialStatus)
-	credentialAPI.PUT("/:id"+StatusPrefix, credRouter.UpdateCredentialStatus)
-	credentialAPI.GET(StatusPrefix+"/:id", credRouter.GetCredentialStatusList)
+	credentialAPI.GET("/:id"+StatusPrefix, middleware.RequirePermission(authService, auth.ScopeCredentialsRead, auth.ResourceCredential), preconditions.ETag(), credRouter.GetCredentialStatus)
+	credentialAPI.PUT("/:id"+StatusPrefix, middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, auth.ResourceCredential), preconditions.IfMatch(), credRouter.UpdateCredentialStatus)
+	credentialAPI.GET(StatusPrefix+"/:id", caching.Cache(), credRouter.GetCredentialStatusList)
 	return
 }
 
 // PresentationAPI registers all HTTP handlers for the Presentation Service
-func PresentationAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service) (err error) {
+func PresentationAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service, authService *auth.Service, replayService *replay.Service, challengeService *challenge.Service, preconditions *middleware.Preconditions) (err error) {
 	presRouter, err := router.NewPresentationRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating credential router")
 	}
+	// submissions responding to presentation requests carry the nonce of the request, which the service checks
+	var requestNonces func(ctx context.Context, nonce string) (bool, error)
+	if presentationService, ok := service.(*presentation.Service); ok {
+		requestNonces = presentationService.IsRequestNonce
+	}
 
 	presDefAPI := rg.Group(PresentationsPrefix + DefinitionsPrefix)
-	presDefAPI.PUT("", presRouter.CreateDefinition)
-	presDefAPI.GET("/:id", presRouter.GetDefinition)
+	presDefAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeDefinitionsWrite, ""), presRouter.CreateDefinition)
+	presDefAPI.PUT(ValidationPath, presRouter.ValidateDefinition)
+	presDefAPI.GET("/:id", preconditions.ETag(), presRouter.GetDefinition)
 	presDefAPI.GET("", presRouter.ListDefinitions)
-	presDefAPI.DELETE("/:id", presRouter.DeleteDefinition)
+	presDefAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeDefinitionsWrite, ""), preconditions.IfMatch(), presRouter.DeleteDefinition)
 
 	presReqAPI := rg.Group(PresentationsPrefix + RequestsPrefix)
-	presReqAPI.PUT("", presRouter.CreateRequest)
+	presReqAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeRequestsWrite, ""), presRouter.CreateRequest)
 	presReqAPI.GET("/:id", presRouter.GetRequest)
 	presReqAPI.GET("", presRouter.ListRequests)
-	presReqAPI.PUT("/:id", presRouter.DeleteRequest)
+	presReqAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeRequestsWrite, ""), presRouter.DeleteRequest)
 
 	presSubAPI := rg.Group(PresentationsPrefix + SubmissionsPrefix)
-	presSubAPI.PUT("", middleware.Webhook(webhookService, webhook.Submission, webhook.Create), presRouter.CreateSubmission)
-	presSubAPI.GET("/:id", presRouter.GetSubmission)
+	presSubAPI.PUT("", middleware.RejectReplayedJWT(replayService, "$.submissionJwt"), middleware.RequireChallenge(challengeService, "$.submissionJwt", challenge.TargetDefinition, requestNonces), middleware.Webhook(webhookService, webhook.Submission, webhook.Create), presRouter.CreateSubmission)
+	presSubAPI.GET("/:id", preconditions.ETag(), presRouter.GetSubmission)
 	presSubAPI.GET("", presRouter.ListSubmissions)
-	presSubAPI.PUT("/:id/review", presRouter.ReviewSubmission)
+	presSubAPI.PUT("/:id/review", middleware.RequirePermission(authService, auth.ScopeSubmissionsReview, ""), preconditions.IfMatchAt(middleware.ParentPath("/review")), presRouter.ReviewSubmission)
 	return
 }
 
 // KeyStoreAPI registers all HTTP handlers for the Key Store Service
-func KeyStoreAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+func KeyStoreAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service, preconditions *middleware.Preconditions) (err error) {
 	keyStoreRouter, err := router.NewKeyStoreRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating key store router")
 	}
 
 	keyStoreAPI := rg.Group(KeyStorePrefix)
-	keyStoreAPI.PUT("", keyStoreRouter.StoreKey)
-	keyStoreAPI.GET("/:id", keyStoreRouter.GetKeyDetails)
-	keyStoreAPI.DELETE("/:id", keyStoreRouter.RevokeKey)
+	keyStoreAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeKeysWrite, ""), keyStoreRouter.StoreKey)
+	keyStoreAPI.GET("", keyStoreRouter.ListKeys)
+	keyStoreAPI.GET("/:id", preconditions.ETag(), keyStoreRouter.GetKeyDetails)
+	keyStoreAPI.GET("/:id"+JWKPath, keyStoreRouter.GetKeyJWK)
+	keyStoreAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeKeysWrite, ""), keyStoreRouter.RevokeKey)
+	keyStoreAPI.PUT("/:id"+RotatePath, middleware.RequirePermission(authService, auth.ScopeKeysWrite, ""), preconditions.IfMatchAt(middleware.ParentPath(RotatePath)), keyStoreRouter.RotateKey)
 	return
 }
 
+// JWKSAPI registers the HTTP handler serving the public keys of issuer DIDs as JWK Sets, at the well-known path
+// relying parties fetch them from, outside of any version of the API
+func JWKSAPI(engine *gin.Engine, service svcframework.Service) error {
+	keyStoreRouter, err := router.NewKeyStoreRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating key store router")
+	}
+	engine.GET(JWKSPath, keyStoreRouter.GetJWKS)
+	return nil
+}
+
+// ResolutionCacheAPI registers the HTTP handlers that read the DID resolution cache and purge it, which are served
+// under /admin
+func ResolutionCacheAPI(rg *gin.RouterGroup, cache *resolution.CachingResolver) (err error) {
+	resolutionCacheRouter, err := router.NewResolutionCacheRouter(cache)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating resolution cache router")
+	}
+
+	resolutionCacheAPI := rg.Group(ResolutionCachePrefix)
+	resolutionCacheAPI.GET("", resolutionCacheRouter.GetResolutionCacheStats)
+	resolutionCacheAPI.DELETE("", resolutionCacheRouter.PurgeResolutionCache)
+	resolutionCacheAPI.DELETE("/:id", resolutionCacheRouter.InvalidateResolution)
+	return
+}
+
+// KeyStoreAdminAPI registers the HTTP handlers that maintain the keys of the keystore, which are served under /admin
+func KeyStoreAdminAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	keyStoreRouter, err := router.NewKeyStoreRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating key store router")
+	}
+
+	keyStoreAPI := rg.Group(AdminKeyStorePrefix)
+	keyStoreAPI.PUT(ReencryptPath, keyStoreRouter.ReencryptKeys)
+	return
+}
+
+// BatchAPI registers the batch endpoint, which runs requests to the other routes of the same version of the API
+func BatchAPI(rg *gin.RouterGroup, batch *middleware.Batch, asyncOperations *middleware.Async) {
+	rg.POST(BatchPath, asyncOperations.Handler("batch"), batch.Handler())
+}
+
 // OperationAPI registers all HTTP handlers for the Operations Service
-func OperationAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+func OperationAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
 	operationRouter, err := router.NewOperationRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating operation router")
@@ -249,71 +931,73 @@ func OperationAPI(rg *gin.RouterGroup, service svcframework.Service) (err error)
 	operationAPI.GET("", operationRouter.ListOperations)
 	// In this case, it's used so that the operation id matches `presentations/submissions/{submission_id}` for the DIDWebID
 	// path	`/v1/operations/cancel/presentations/submissions/{id}`
-	operationAPI.PUT("/cancel/*id", operationRouter.CancelOperation)
+	operationAPI.PUT("/cancel/*id", middleware.RequirePermission(authService, auth.ScopeOperationsWrite, ""), operationRouter.CancelOperation)
 	operationAPI.GET("/*id", operationRouter.GetOperation)
 	return
 }
 
 // ManifestAPI registers all HTTP handlers for the Manifest Service
-func ManifestAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service) (err error) {
+func ManifestAPI(rg *gin.RouterGroup, service svcframework.Service, webhookService *webhook.Service, authService *auth.Service, replayService *replay.Service, challengeService *challenge.Service, asyncOperations *middleware.Async, preconditions *middleware.Preconditions) (err error) {
 	manifestRouter, err := router.NewManifestRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating manifest router")
 	}
 
 	manifestAPI := rg.Group(ManifestsPrefix)
-	manifestAPI.PUT("", middleware.Webhook(webhookService, webhook.Manifest, webhook.Create), manifestRouter.CreateManifest)
-	manifestAPI.GET("", manifestRouter.ListManifests)
-	manifestAPI.GET("/:id", manifestRouter.GetManifest)
-	manifestAPI.DELETE("/:id", middleware.Webhook(webhookService, webhook.Manifest, webhook.Delete), manifestRouter.DeleteManifest)
+	manifestAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeManifestsWrite, auth.ResourceManifest), middleware.RecordOwnership(authService, auth.ResourceManifest, "$.credential_manifest.id"), middleware.Webhook(webhookService, webhook.Manifest, webhook.Create), manifestRouter.CreateManifest)
+	manifestAPI.GET("", middleware.RejectOwnedOnly(auth.ScopeManifestsWrite), manifestRouter.ListManifests)
+	manifestAPI.GET("/:id", preconditions.ETag(), manifestRouter.GetManifest)
+	manifestAPI.GET("/:id/request", manifestRouter.GetManifestRequestObject)
+	manifestAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeManifestsWrite, auth.ResourceManifest), preconditions.IfMatch(), middleware.Webhook(webhookService, webhook.Manifest, webhook.Delete), manifestRouter.DeleteManifest)
 
 	applicationAPI := manifestAPI.Group(ApplicationsPrefix)
-	applicationAPI.PUT("", middleware.Webhook(webhookService, webhook.Application, webhook.Create), manifestRouter.SubmitApplication)
+	applicationAPI.PUT("", middleware.RejectReplayedJWT(replayService, "$.applicationJwt"), middleware.RequireChallenge(challengeService, "$.applicationJwt", challenge.TargetManifest, nil), middleware.Webhook(webhookService, webhook.Application, webhook.Create), manifestRouter.SubmitApplication)
 	applicationAPI.GET("", manifestRouter.ListApplications)
 	applicationAPI.GET("/:id", manifestRouter.GetApplication)
-	applicationAPI.DELETE("/:id", middleware.Webhook(webhookService, webhook.Application, webhook.Delete), manifestRouter.DeleteApplication)
-	applicationAPI.PUT("/:id/review", manifestRouter.ReviewApplication)
+	applicationAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeApplicationsReview, ""), middleware.Webhook(webhookService, webhook.Application, webhook.Delete), manifestRouter.DeleteApplication)
+	applicationAPI.GET("/:id"+ResponsePath, manifestRouter.GetApplicationResponse)
+	applicationAPI.PUT("/:id/review", middleware.RequirePermission(authService, auth.ScopeApplicationsReview, ""), asyncOperations.Handler("manifests/applications/review"), manifestRouter.ReviewApplication)
 
 	manifestReqAPI := manifestAPI.Group(RequestsPrefix)
-	manifestReqAPI.PUT("", manifestRouter.CreateRequest)
+	manifestReqAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeRequestsWrite, ""), manifestRouter.CreateRequest)
 	manifestReqAPI.GET("", manifestRouter.ListRequests)
 	manifestReqAPI.GET("/:id", manifestRouter.GetRequest)
-	manifestReqAPI.PUT("/:id", manifestRouter.DeleteRequest)
+	manifestReqAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeRequestsWrite, ""), manifestRouter.DeleteRequest)
 
 	responseAPI := manifestAPI.Group(ResponsesPrefix)
 	responseAPI.GET("", manifestRouter.ListResponses)
 	responseAPI.GET("/:id", manifestRouter.GetResponse)
-	responseAPI.DELETE("/:id", manifestRouter.DeleteResponse)
+	responseAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeApplicationsReview, ""), manifestRouter.DeleteResponse)
 	return
 }
 
 // IssuanceAPI registers all HTTP handlers for the Issuance Service
-func IssuanceAPI(rg *gin.RouterGroup, service svcframework.Service) error {
+func IssuanceAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) error {
 	issuanceRouter, err := router.NewIssuanceRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating issuing router")
 	}
 
 	issuanceAPI := rg.Group(IssuanceTemplatePrefix)
-	issuanceAPI.PUT("", issuanceRouter.CreateIssuanceTemplate)
+	issuanceAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeTemplatesWrite, ""), issuanceRouter.CreateIssuanceTemplate)
 	issuanceAPI.GET("", issuanceRouter.ListIssuanceTemplates)
 	issuanceAPI.GET("/:id", issuanceRouter.GetIssuanceTemplate)
-	issuanceAPI.DELETE("/:id", issuanceRouter.DeleteIssuanceTemplate)
+	issuanceAPI.DELETE("/:id", middleware.RequirePermission(authService, auth.ScopeTemplatesWrite, ""), issuanceRouter.DeleteIssuanceTemplate)
 	return nil
 }
 
 // WebhookAPI registers all HTTP handlers for the Webhook Service
-func WebhookAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+func WebhookAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
 	webhookRouter, err := router.NewWebhookRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating webhook router")
 	}
 
 	webhookAPI := rg.Group(WebhookPrefix)
-	webhookAPI.PUT("", webhookRouter.CreateWebhook)
+	webhookAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeWebhooksWrite, ""), webhookRouter.CreateWebhook)
 	webhookAPI.GET("", webhookRouter.ListWebhooks)
 	webhookAPI.GET("/:noun/:verb", webhookRouter.GetWebhook)
-	webhookAPI.DELETE("/:noun/:verb", webhookRouter.DeleteWebhook)
+	webhookAPI.DELETE("/:noun/:verb", middleware.RequirePermission(authService, auth.ScopeWebhooksWrite, ""), webhookRouter.DeleteWebhook)
 
 	// TODO(gabe): consider refactoring this to a single get on /webhooks/info or similar
 	webhookAPI.GET("nouns", webhookRouter.GetSupportedNouns)
@@ -321,15 +1005,338 @@ func WebhookAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
 	return
 }
 
-func DIDConfigurationAPI(rg *gin.RouterGroup, service svcframework.Service) error {
+func DIDConfigurationAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) error {
 	didConfigurationsRouter, err := router.NewDIDConfigurationsRouter(service)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "creating webhook router")
 	}
 
 	webhookAPI := rg.Group(DIDConfigurationsPrefix)
-	webhookAPI.PUT("", didConfigurationsRouter.CreateDIDConfiguration)
+	webhookAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeDIDConfigurationsWrite, ""), didConfigurationsRouter.CreateDIDConfiguration)
 	webhookAPI.PUT(VerificationPath, didConfigurationsRouter.VerifyDIDConfiguration)
 
 	return nil
 }
+
+// StatsAPI registers all HTTP handlers for the Stats Service
+func StatsAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	statsRouter, err := router.NewStatsRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating stats router")
+	}
+
+	rg.GET(StatsPrefix, statsRouter.GetStats)
+	return
+}
+
+// AuthAPI registers all HTTP handlers for managing API keys, roles, role bindings, and client keys
+func AuthAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	authRouter, err := router.NewAuthRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating auth router")
+	}
+
+	apiKeysAPI := rg.Group(APIKeysPrefix)
+	apiKeysAPI.PUT("", authRouter.CreateAPIKey)
+	apiKeysAPI.GET("", authRouter.ListAPIKeys)
+	apiKeysAPI.GET("/:id", authRouter.GetAPIKey)
+	apiKeysAPI.DELETE("/:id", authRouter.RevokeAPIKey)
+
+	rolesAPI := rg.Group(RolesPrefix)
+	rolesAPI.PUT("", authRouter.CreateRole)
+	rolesAPI.GET("", authRouter.ListRoles)
+	rolesAPI.GET("/:id", authRouter.GetRole)
+	rolesAPI.DELETE("/:id", authRouter.DeleteRole)
+
+	roleBindingsAPI := rg.Group(RoleBindingsPrefix)
+	roleBindingsAPI.PUT("", authRouter.SetRoleBinding)
+	roleBindingsAPI.GET("/:id", authRouter.GetRoleBinding)
+	roleBindingsAPI.DELETE("/:id", authRouter.DeleteRoleBinding)
+
+	clientKeysAPI := rg.Group(ClientKeysPrefix)
+	clientKeysAPI.PUT("", authRouter.RegisterClientKey)
+	clientKeysAPI.GET("", authRouter.ListClientKeys)
+	clientKeysAPI.GET("/:id", authRouter.GetClientKey)
+	clientKeysAPI.DELETE("/:id", authRouter.RevokeClientKey)
+	return
+}
+
+// AuditAPI registers all HTTP handlers for the Audit Service, which are served under /admin
+func AuditAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	auditRouter, err := router.NewAuditRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating audit router")
+	}
+
+	auditAPI := rg.Group(AuditPrefix)
+	auditAPI.GET("", auditRouter.ListAuditEvents)
+	auditAPI.GET(ExportPath, auditRouter.ExportAuditEvents)
+	auditAPI.GET(VerificationPath, auditRouter.VerifyAuditEvents)
+	return
+}
+
+// ErasureAPI registers all HTTP handlers for the Erasure Service, which are served under /admin
+func ErasureAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	erasureRouter, err := router.NewErasureRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating erasure router")
+	}
+
+	erasuresAPI := rg.Group(ErasuresPrefix)
+	erasuresAPI.PUT("", erasureRouter.EraseSubject)
+	erasuresAPI.GET("", erasureRouter.ListErasures)
+	erasuresAPI.GET("/:id", erasureRouter.GetErasure)
+	return
+}
+
+// BackupAPI registers all HTTP handlers for the Backup Service, which are served under /admin
+func BackupAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	backupRouter, err := router.NewBackupRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating backup router")
+	}
+
+	backupsAPI := rg.Group(BackupsPrefix)
+	backupsAPI.PUT("", backupRouter.CreateBackup)
+	backupsAPI.GET("", backupRouter.ListBackups)
+	backupsAPI.GET("/:"+router.NameParam, backupRouter.GetBackup)
+	backupsAPI.PUT(RestorePath, backupRouter.RestoreBackup)
+	return
+}
+
+// AnomalyAPI registers all HTTP handlers for the Anomaly Service, which are served under /admin
+func AnomalyAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	anomalyRouter, err := router.NewAnomalyRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating anomaly router")
+	}
+
+	signingAPI := rg.Group(SigningPrefix)
+	signingAPI.GET(RatesPath, anomalyRouter.ListSigningRates)
+	signingAPI.GET(FreezesPrefix, anomalyRouter.ListFreezes)
+	signingAPI.DELETE(FreezesPrefix+"/:id", anomalyRouter.LiftFreeze)
+	return
+}
+
+// RetentionAPI registers all HTTP handlers for the Retention Service, which are served under /admin
+func RetentionAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	retentionRouter, err := router.NewRetentionRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating retention router")
+	}
+
+	retentionAPI := rg.Group(RetentionPrefix)
+	retentionAPI.PUT(RunsPath, retentionRouter.RunRetention)
+	retentionAPI.PUT(HoldsPrefix, retentionRouter.CreateHold)
+	retentionAPI.GET(HoldsPrefix, retentionRouter.ListHolds)
+	retentionAPI.DELETE(HoldsPrefix+"/:id", retentionRouter.LiftHold)
+	return
+}
+
+// ApprovalAPI registers all HTTP handlers for the Approval Service, which are served under /admin
+func ApprovalAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	approvalRouter, err := router.NewApprovalRouter(service, middleware.GetPrincipal)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating approval router")
+	}
+
+	approvalsAPI := rg.Group(ApprovalsPrefix)
+	approvalsAPI.GET("", approvalRouter.ListApprovals)
+	approvalsAPI.GET("/:id", approvalRouter.GetApproval)
+	approvalsAPI.PUT("/:id"+ReviewPath, approvalRouter.ReviewApproval)
+	return
+}
+
+// AnchorAPI registers all HTTP handlers for the Anchor Service, which are served under /admin
+func AnchorAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	anchorRouter, err := router.NewAnchorRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating anchor router")
+	}
+
+	anchorAPI := rg.Group(AnchorsPrefix)
+	anchorAPI.PUT("", anchorRouter.CreateAnchor)
+	anchorAPI.GET("", anchorRouter.ListAnchors)
+	anchorAPI.GET("/:id", anchorRouter.GetAnchor)
+	anchorAPI.GET("/:id"+VerificationPath, anchorRouter.VerifyAnchor)
+	return
+}
+
+// ExpiryAPI registers all HTTP handlers for the Expiry Service, which are served under /admin
+func ExpiryAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	expiryRouter, err := router.NewExpiryRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating expiry router")
+	}
+
+	expiryAPI := rg.Group(ExpiriesPrefix)
+	expiryAPI.GET("", expiryRouter.ListExpiries)
+	expiryAPI.PUT(WarningsPath, expiryRouter.CreateWarning)
+	expiryAPI.PUT(SweepsPath, expiryRouter.CreateSweep)
+	return
+}
+
+// KeySharesAPI registers the HTTP handlers that create the shares of the service key of the keystore, and unseal it,
+// which are served under /admin
+func KeySharesAPI(rg *gin.RouterGroup, shares *keystore.ServiceKeyShares) (err error) {
+	keySharesRouter, err := router.NewKeySharesRouter(shares)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating key shares router")
+	}
+
+	keyStoreAPI := rg.Group(AdminKeyStorePrefix)
+	keyStoreAPI.GET(SealPath, keySharesRouter.GetSealStatus)
+	keyStoreAPI.PUT(SharesPath, keySharesRouter.CreateKeyShares)
+	keyStoreAPI.PUT(UnsealPath, keySharesRouter.UnsealKeyStore)
+	return
+}
+
+// TransparencyAPI registers all HTTP handlers for the Transparency Service, which serves the transparency log to
+// auditors
+func TransparencyAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	transparencyRouter, err := router.NewTransparencyRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating transparency router")
+	}
+
+	transparencyAPI := rg.Group(TransparencyPrefix)
+	transparencyAPI.GET("/head", transparencyRouter.GetSignedTreeHead)
+	transparencyAPI.GET("/entries", transparencyRouter.ListEntries)
+	transparencyAPI.GET(CredentialsPrefix+"/:id", transparencyRouter.ListCredentialEntries)
+	transparencyAPI.GET("/proofs/inclusion", transparencyRouter.GetInclusionProof)
+	transparencyAPI.GET("/proofs/consistency", transparencyRouter.GetConsistencyProof)
+	return
+}
+
+// OIDC4VCIAPI registers the HTTP handlers that offer credentials to wallets with OpenID for Verifiable Credential
+// Issuance
+func OIDC4VCIAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
+	oidc4vciRouter, err := router.NewOIDC4VCIRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating oidc4vci router")
+	}
+
+	offerAPI := rg.Group(OIDC4VCIPrefix + OffersPrefix)
+	offerAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, ""), oidc4vciRouter.CreateOffer)
+	offerAPI.GET("/:id", oidc4vciRouter.GetOffer)
+	return
+}
+
+// OIDC4VCIWalletAPI registers the HTTP handlers that wallets redeem credential offers with, which are served outside of
+// the versioned API, since wallets find them from the credential issuer's URL, and authenticate with the offer's
+// pre-authorized code rather than with the API's credentials
+func OIDC4VCIWalletAPI(engine *gin.Engine, service svcframework.Service) (err error) {
+	oidc4vciRouter, err := router.NewOIDC4VCIRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating oidc4vci router")
+	}
+
+	engine.GET(CredentialIssuerMetadataPath, oidc4vciRouter.GetIssuerMetadata)
+	engine.GET(AuthorizationServerMetadataPath, oidc4vciRouter.GetAuthorizationServerMetadata)
+	walletAPI := engine.Group(OIDC4VCIPrefix)
+	walletAPI.POST(TokenPath, oidc4vciRouter.Token)
+	walletAPI.POST(CredentialPath, oidc4vciRouter.Credential)
+	return
+}
+
+// ChallengeAPI registers the HTTP handlers that issue challenges, which submissions and applications must respond to.
+func ChallengeAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
+	challengeRouter, err := router.NewChallengeRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating challenge router")
+	}
+
+	challengeAPI := rg.Group(ChallengesPrefix)
+	challengeAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeChallengesWrite, ""), challengeRouter.CreateChallenge)
+	challengeAPI.GET("/:id", challengeRouter.GetChallenge)
+	return
+}
+
+// TrustAPI registers the HTTP handlers that manage the trust registry, the issuers trusted to issue the credentials of
+// a type or schema
+func TrustAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
+	trustRouter, err := router.NewTrustRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating trust router")
+	}
+
+	trustAPI := rg.Group(TrustPrefix)
+	trustAPI.PUT(TrustedIssuersPath, middleware.RequirePermission(authService, auth.ScopeTrustWrite, ""), trustRouter.CreateTrustedIssuer)
+	trustAPI.GET(TrustedIssuersPath, trustRouter.ListTrustedIssuers)
+	trustAPI.GET(TrustedIssuersPath+"/:id", trustRouter.GetTrustedIssuer)
+	trustAPI.DELETE(TrustedIssuersPath+"/:id", middleware.RequirePermission(authService, auth.ScopeTrustWrite, ""), trustRouter.DeleteTrustedIssuer)
+	trustAPI.PUT(ImportsPath, middleware.RequirePermission(authService, auth.ScopeTrustWrite, ""), trustRouter.ImportTrustList)
+	return
+}
+
+// OIDC4VPAPI registers the HTTP handlers that request presentations from wallets with OpenID for Verifiable
+// Presentations
+func OIDC4VPAPI(rg *gin.RouterGroup, service svcframework.Service, authService *auth.Service) (err error) {
+	oidc4vpRouter, err := router.NewOIDC4VPRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating oidc4vp router")
+	}
+
+	requestAPI := rg.Group(OIDC4VPPrefix + RequestsPrefix)
+	requestAPI.PUT("", middleware.RequirePermission(authService, auth.ScopeSubmissionsReview, ""), oidc4vpRouter.CreateAuthorizationRequest)
+	requestAPI.GET("/:id", oidc4vpRouter.GetAuthorizationRequest)
+	return
+}
+
+// OIDC4VPWalletAPI registers the HTTP handler that wallets post their responses to authorization requests to, which is
+// served outside of the versioned API, since wallets authenticate with the request's state rather than with the API's
+// credentials
+func OIDC4VPWalletAPI(engine *gin.Engine, service svcframework.Service) (err error) {
+	oidc4vpRouter, err := router.NewOIDC4VPRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating oidc4vp router")
+	}
+
+	engine.Group(OIDC4VPPrefix).POST(ResponsePath, oidc4vpRouter.Respond)
+	return
+}
+
+// UsageAPI registers all HTTP handlers for the Usage Service, which are served under /admin
+func UsageAPI(rg *gin.RouterGroup, service svcframework.Service) (err error) {
+	usageRouter, err := router.NewUsageRouter(service)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating usage router")
+	}
+
+	usageAPI := rg.Group(UsagePrefix)
+	usageAPI.GET("", usageRouter.ListUsage)
+	return
+}
+
+// StorageAPI registers the HTTP handlers that export and import the storage of the service, and encrypt the values of
+// its encrypted namespaces, which are served under /admin
+func StorageAPI(rg *gin.RouterGroup, s storage.ServiceStorage, encryption *storage.NamespaceEncryptedWrapper) (err error) {
+	storageRouter, err := router.NewStorageRouter(s, encryption)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "creating storage router")
+	}
+
+	storageAPI := rg.Group(StoragePrefix)
+	storageAPI.GET(ExportPath, storageRouter.ExportStorage)
+	storageAPI.PUT(ImportPath, storageRouter.ImportStorage)
+	storageAPI.PUT(EncryptPath, storageRouter.EncryptNamespaces)
+	return
+}
+
+// DebugAPI registers the HTTP handlers serving diagnostics of the running service, which are served under /admin
+func DebugAPI(rg *gin.RouterGroup, s storage.ServiceStorage) {
+	debugRouter := router.NewDebugRouter(s)
+
+	debugAPI := rg.Group(DebugPrefix)
+	debugAPI.GET("/runtime", debugRouter.GetRuntimeStats)
+	debugAPI.GET("/storage", debugRouter.GetStorageStats)
+	debugAPI.GET("/goroutines", debugRouter.GetGoroutines)
+	debugAPI.GET("/pprof/*"+router.ProfileParam, debugRouter.GetProfile)
+}
+
+// AdminUIAPI serves the admin web UI, which calls the API at apiURL, or where the UI is served when it's empty.
+func AdminUIAPI(rg *gin.RouterGroup, apiURL string) {
+	uiRouter := router.NewAdminUIRouter(adminui.Files(), apiURL)
+
+	rg.GET(UIPrefix+"/*"+router.AssetParam, uiRouter.GetAsset)
+}
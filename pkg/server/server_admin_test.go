@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestAdminListener(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, admin config.AdminListenerConfig) (*SSIServer, error) {
+		shutdown := make(chan os.Signal, 1)
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: tempBoltFileName(t),
+			},
+		}
+		serviceConfig.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		serviceConfig.Server.Admin = admin
+		return NewSSIServer(shutdown, *serviceConfig)
+	}
+	listAPIKeys := func(handler http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, AdminPrefix+APIKeysPrefix, nil)
+		req.Header.Set(middleware.APIKeyHeader, adminKey)
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("serves admin endpoints with the api by default", func(tt *testing.T) {
+		server, err := newServer(tt, config.AdminListenerConfig{})
+		require.NoError(tt, err)
+		assert.Nil(tt, server.Admin)
+
+		assert.Equal(tt, http.StatusOK, listAPIKeys(server.Handler, "").Code)
+	})
+
+	t.Run("moves admin endpoints to the admin listener", func(tt *testing.T) {
+		server, err := newServer(tt, config.AdminListenerConfig{Host: "127.0.0.1:3001"})
+		require.NoError(tt, err)
+		require.NotNil(tt, server.Admin)
+		assert.Equal(tt, "127.0.0.1:3001", server.Admin.Addr)
+
+		assert.Equal(tt, http.StatusNotFound, listAPIKeys(server.Handler, "").Code)
+		assert.Equal(tt, http.StatusOK, listAPIKeys(server.Admin.Handler, "").Code)
+
+		// the api isn't served by the admin listener
+		w := httptest.NewRecorder()
+		server.Admin.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, V1Prefix+DIDsPrefix, nil))
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+
+		// still requires an admin credential
+		w = httptest.NewRecorder()
+		server.Admin.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, AdminPrefix+APIKeysPrefix, nil))
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("only allows callers from allowed networks", func(tt *testing.T) {
+		server, err := newServer(tt, config.AdminListenerConfig{
+			Host:            "127.0.0.1:3001",
+			AllowedNetworks: []string{"10.0.0.0/8", "::1/128"},
+		})
+		require.NoError(tt, err)
+
+		assert.Equal(tt, http.StatusOK, listAPIKeys(server.Admin.Handler, "10.1.2.3:4567").Code)
+		assert.Equal(tt, http.StatusOK, listAPIKeys(server.Admin.Handler, "[::1]:4567").Code)
+		assert.Equal(tt, http.StatusForbidden, listAPIKeys(server.Admin.Handler, "192.0.2.1:4567").Code)
+	})
+
+	t.Run("rejects invalid networks", func(tt *testing.T) {
+		_, err := newServer(tt, config.AdminListenerConfig{AllowedNetworks: []string{"10.0.0.0"}})
+		assert.ErrorContains(tt, err, "10.0.0.0")
+	})
+
+	t.Run("requires certificates to serve tls", func(tt *testing.T) {
+		_, err := newServer(tt, config.AdminListenerConfig{Host: "127.0.0.1:3001", TLS: config.TLSConfig{Enabled: true}})
+		assert.ErrorContains(tt, err, "cert file")
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestAdminUI(t *testing.T) {
+	uiPrefix := AdminPrefix + UIPrefix
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		w := doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/", nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("serves the page and its files", func(tt *testing.T) {
+		server := newTestServer(tt, func(cfg *config.SSIServiceConfig) {
+			cfg.Server.Admin.EnableUI = true
+		})
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix, nil)
+		assert.Equal(tt, http.StatusMovedPermanently, w.Code)
+		assert.Equal(tt, uiPrefix+"/", w.Header().Get("Location"))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(tt, w.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'")
+		assert.Contains(tt, w.Body.String(), "SSI Service Admin")
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/app.js", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Header().Get("Content-Type"), "javascript")
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/config.json", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var uiConfig router.GetAdminUIConfigResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&uiConfig))
+		assert.Empty(tt, uiConfig.APIURL)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/missing.js", nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("calls the api where it's served when on the admin listener", func(tt *testing.T) {
+		server := newTestServer(tt, func(cfg *config.SSIServiceConfig) {
+			cfg.Server.Admin.EnableUI = true
+			cfg.Server.Admin.Host = "127.0.0.1:0"
+			cfg.Services.ServiceEndpoint = "https://ssi.example.com"
+		})
+		w := doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/", nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+
+		w = doTestRequest(tt, server.Admin.Handler, http.MethodGet, uiPrefix+"/config.json", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var uiConfig router.GetAdminUIConfigResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&uiConfig))
+		assert.Equal(tt, "https://ssi.example.com", uiConfig.APIURL)
+		assert.Contains(tt, w.Header().Get("Content-Security-Policy"), "connect-src 'self' https://ssi.example.com")
+	})
+
+	t.Run("is restricted to the admin networks", func(tt *testing.T) {
+		server := newTestServer(tt, func(cfg *config.SSIServiceConfig) {
+			cfg.Server.Admin.EnableUI = true
+			cfg.Server.Admin.AllowedNetworks = []string{"10.0.0.0/8"}
+		})
+		w := doTestRequest(tt, server.Handler, http.MethodGet, uiPrefix+"/", nil)
+		assert.Equal(tt, http.StatusForbidden, w.Code)
+	})
+}
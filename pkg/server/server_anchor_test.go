@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/anchor"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestAnchorAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("\xf0\x10pending-timestamp\x08\x00"))
+	}))
+	defer calendar.Close()
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.AnchorConfig = config.AnchorServiceConfig{
+				Enabled: enabled,
+				Type:    "opentimestamps",
+				URL:     calendar.URL,
+			}
+		})
+	}
+	verify := func(server *SSIServer, id string) anchor.Verification {
+		w := doTestRequest(t, server.Handler, http.MethodGet, "/admin/anchors/"+id+"/verification", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp router.VerifyAnchorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Verification
+	}
+
+	server := newServer(t, true)
+	ctx := context.Background()
+	db := server.SSIService.GetStorage()
+	require.NoError(t, db.Write(ctx, "credential", "cred-1", []byte(`{"id":"cred-1"}`)))
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/admin/anchors", nil, middleware.APIKeyHeader, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/anchors", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateAnchorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, anchor.TypeOpenTimestamps, created.Anchor.Type)
+	assert.NotEmpty(t, created.Anchor.Receipt)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/anchors", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var anchors router.ListAnchorsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&anchors))
+	require.Len(t, anchors.Anchors, 1)
+	assert.Equal(t, created.Anchor.ID, anchors.Anchors[0].ID)
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/anchors/"+created.Anchor.ID, nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/anchors/unknown", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/anchors/unknown/verification", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	verification := verify(server, created.Anchor.ID)
+	assert.True(t, verification.Intact)
+	assert.True(t, verification.Unchanged)
+	assert.Equal(t, anchor.ReceiptExternal, verification.Receipt)
+
+	// credentials changed after they were anchored are evident
+	require.NoError(t, db.Write(ctx, "credential", "cred-1", []byte(`{"id":"cred-1","revoked":true}`)))
+	verification = verify(server, created.Anchor.ID)
+	assert.False(t, verification.Unchanged)
+	require.NotEmpty(t, verification.Namespaces)
+	assert.Equal(t, "credential", verification.Namespaces[0].Namespace)
+	assert.Equal(t, []string{"cred-1"}, verification.Namespaces[0].Changed)
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/anchors", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/anomaly"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestAnomalyAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	alerts := make(chan anomaly.Alert, 10)
+	alertServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert anomaly.Alert
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &alert); err == nil {
+			alerts <- alert
+		}
+	}))
+	defer alertServer.Close()
+
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.AnomalyConfig = config.AnomalyServiceConfig{
+				Enabled:       enabled,
+				MinSignatures: 2,
+				FreezeFor:     time.Hour,
+				AlertURLs:     []string{alertServer.URL},
+			}
+		})
+	}
+
+	server := newServer(t, true)
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.APIKeyHeader, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	issue := func() *httptest.ResponseRecorder {
+		return doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              "did:example:alice",
+			Data:                 map[string]any{"firstName": "Alice"},
+		}, middleware.APIKeyHeader, "")
+	}
+
+	// the key spikes past the fewest signatures that can spike, and is frozen from the next signature
+	for i := 0; i < 3; i++ {
+		w = issue()
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	// so does the tenant, whose only key it is
+	for _, scope := range []anomaly.Scope{anomaly.ScopeKey, anomaly.ScopeTenant} {
+		select {
+		case alert := <-alerts:
+			assert.Equal(t, scope, alert.Scope)
+			assert.EqualValues(t, 3, alert.Count)
+			if scope == anomaly.ScopeKey {
+				assert.Equal(t, issuer.DID.VerificationMethod[0].ID, alert.KeyID)
+				assert.NotNil(t, alert.FrozenUntil)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s alert was posted", scope)
+		}
+	}
+	w = issue()
+	require.Equal(t, http.StatusTooManyRequests, w.Code, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	var problem framework.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, framework.CodeKeyFrozen, problem.Code)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/signing/freezes", nil, middleware.APIKeyHeader, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/signing/freezes", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var freezes router.ListFreezesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&freezes))
+	require.Len(t, freezes.Freezes, 1)
+	assert.Equal(t, issuer.DID.VerificationMethod[0].ID, freezes.Freezes[0].KeyID)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/signing/rates", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rates router.ListSigningRatesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rates))
+	require.Len(t, rates.Rates, 2)
+	assert.Equal(t, anomaly.ScopeKey, rates.Rates[0].Scope)
+	assert.EqualValues(t, 3, rates.Rates[0].Count)
+	assert.Equal(t, anomaly.ScopeTenant, rates.Rates[1].Scope)
+	assert.EqualValues(t, 3, rates.Rates[1].Count)
+
+	// once the freeze is lifted, the key signs again
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/signing/freezes/"+freezes.Freezes[0].ID, nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = issue()
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/signing/freezes/"+freezes.Freezes[0].ID, nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/signing/freezes", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
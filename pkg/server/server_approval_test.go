@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/approval"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestApprovalAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Server.EnableAPIKeyAuth = true
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.ApprovalConfig.Enabled = enabled
+		})
+	}
+
+	// a second operator has an admin key of their own
+	server := newServer(t, true)
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/admin/apikeys", router.CreateAPIKeyRequest{Name: "second operator", Admin: true}, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var reviewer router.CreateAPIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&reviewer))
+
+	// erasing a data subject isn't handled until it's approved, unless it's a dry run, which changes nothing
+	erasure := router.EraseSubjectRequest{Subject: "did:example:alice"}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures?dryRun=true", erasure, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// routes that would handle a dry run for real don't get to skip approval with one
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/storage/export?dryRun=true", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/v1/keys/some-key?dryRun=true", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", erasure, middleware.APIKeyHeader, adminKey, middleware.ApprovalReasonHeader, "ticket 42")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var pending middleware.PendingApprovalResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&pending))
+	assert.Equal(t, approval.StatusPending, pending.Approval.Status)
+	assert.Equal(t, "/admin/erasures", pending.Approval.Route)
+	assert.Equal(t, "ticket 42", pending.Approval.Reason)
+	assert.Equal(t, "/admin/approvals/"+pending.Approval.ID, w.Header().Get("Location"))
+	withApproval := func(apiKey string) []string {
+		return []string{middleware.APIKeyHeader, apiKey, middleware.ApprovalIDHeader, pending.Approval.ID}
+	}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", erasure, withApproval(adminKey)...)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	review := router.ReviewApprovalRequest{Approved: true, Reason: "confirmed with the data subject"}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/approvals/"+pending.Approval.ID+"/review", review, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/approvals/"+pending.Approval.ID+"/review", review, middleware.APIKeyHeader, reviewer.Key)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reviewed router.GetApprovalResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&reviewed))
+	assert.Equal(t, approval.StatusApproved, reviewed.Approval.Status)
+	assert.Equal(t, reviewer.APIKey.ID, reviewed.Approval.ReviewedBy)
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/approvals/"+pending.Approval.ID+"/review", review, middleware.APIKeyHeader, reviewer.Key)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	// the approval is only good for the request it was requested for, by its requester, once
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", router.EraseSubjectRequest{Subject: "did:example:bob"}, withApproval(adminKey)...)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	var problem framework.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, framework.CodeApprovalRequired, problem.Code)
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", erasure, withApproval(reviewer.Key)...)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", erasure, withApproval(adminKey)...)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/erasures", erasure, withApproval(adminKey)...)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/approvals?status=used", nil, middleware.APIKeyHeader, reviewer.Key)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var approvals router.ListApprovalsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&approvals))
+	require.Len(t, approvals.Approvals, 1)
+	assert.Equal(t, pending.Approval.ID, approvals.Approvals[0].ID)
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/approvals?status=done", nil, middleware.APIKeyHeader, reviewer.Key)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Run("requires approval of sensitive routes of the api", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodDelete, "/v1/keys/key-1", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusAccepted, w.Code, w.Body.String())
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/keys/key-1", nil, middleware.APIKeyHeader, adminKey)
+		assert.NotEqual(tt, http.StatusAccepted, w.Code, w.Body.String())
+
+		// bulk revocation goes through batches, and issuance templates approve applications without review
+		revocation := router.BatchRequest{Operations: []router.BatchOperation{{Method: http.MethodPut, Path: "/v1/credentials/credential-1/status", Body: []byte(`{"revoked":true}`)}}}
+		w = doTestRequest(tt, server.Handler, http.MethodPost, "/v1/batch", revocation, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusAccepted, w.Code, w.Body.String())
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/issuancetemplates", router.CreateIssuanceTemplateRequest{}, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusAccepted, w.Code, w.Body.String())
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, "/v1/issuancetemplates/template-1", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusAccepted, w.Code, w.Body.String())
+	})
+
+	t.Run("isn't required unless enabled", func(tt *testing.T) {
+		server := newServer(tt, false)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/admin/erasures", erasure, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/admin/approvals", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestAsyncOperations(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	waitForOperation := func(t *testing.T, id string) router.Operation {
+		var op router.Operation
+		require.Eventually(t, func() bool {
+			w := doTestRequest(t, server.Handler, http.MethodGet, "/v1/operations/"+id, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&op))
+			return op.Done
+		}, 5*time.Second, 10*time.Millisecond)
+		return op
+	}
+
+	t.Run("runs requests preferring async in the background", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusAccepted, w.Code)
+
+		var accepted router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&accepted))
+		assert.True(tt, strings.HasPrefix(accepted.ID, "async/dids/"))
+		assert.False(tt, accepted.Done)
+
+		op := waitForOperation(tt, accepted.ID)
+		assert.Empty(tt, op.Result.Error)
+		responseBytes, err := json.Marshal(op.Result.Response)
+		require.NoError(tt, err)
+		var resp router.CreateDIDByMethodResponse
+		require.NoError(tt, json.Unmarshal(responseBytes, &resp))
+		assert.True(tt, strings.HasPrefix(resp.DID.ID, "did:key:"))
+
+		// the operation is listed under its parent
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/operations?parent=async/dids", nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		var list router.ListOperationsResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&list))
+		require.Len(tt, list.Operations, 1)
+		assert.Equal(tt, accepted.ID, list.Operations[0].ID)
+
+		// finished operations are returned as they are when cancelled
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/operations/cancel/"+accepted.ID, nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		var cancelled router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&cancelled))
+		assert.True(tt, cancelled.Done)
+		assert.Empty(tt, cancelled.Result.Error)
+	})
+
+	t.Run("records failed requests as errors", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: "bad"}, middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusAccepted, w.Code)
+
+		var accepted router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&accepted))
+		op := waitForOperation(tt, accepted.ID)
+		assert.NotEmpty(tt, op.Result.Error)
+		assert.Nil(tt, op.Result.Response)
+	})
+
+	t.Run("resolves DIDs in the background", func(tt *testing.T) {
+		didKey := "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids/resolver/"+didKey, nil, middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusAccepted, w.Code)
+
+		var accepted router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&accepted))
+		assert.True(tt, strings.HasPrefix(accepted.ID, "async/dids/resolver/"))
+
+		op := waitForOperation(tt, accepted.ID)
+		assert.Empty(tt, op.Result.Error)
+		responseBytes, err := json.Marshal(op.Result.Response)
+		require.NoError(tt, err)
+		var resp router.ResolveDIDResponse
+		require.NoError(tt, json.Unmarshal(responseBytes, &resp))
+		assert.Equal(tt, didKey, resp.DIDDocument.ID)
+	})
+
+	t.Run("runs requests synchronously without the preference", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		assert.Equal(tt, http.StatusCreated, w.Code)
+	})
+}
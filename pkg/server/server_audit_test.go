@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/audit"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestAuditLog(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Server.EnableAPIKeyAuth = true
+		cfg.Server.Audit.Enabled = true
+	})
+
+	listEvents := func(t *testing.T, query string) []audit.Event {
+		w := doTestRequest(t, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix+query, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp router.ListAuditEventsResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Events
+	}
+
+	// an admin creates a key, which creates and deletes a schema
+	w := doTestRequest(t, server.Handler, http.MethodPut, AdminPrefix+APIKeysPrefix, router.CreateAPIKeyRequest{Name: "issuer"}, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var apiKey router.CreateAPIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&apiKey))
+
+	createSchema := router.CreateSchemaRequest{Name: "test schema", Schema: getTestSchema()}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/schemas", createSchema, middleware.APIKeyHeader, apiKey.Key)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateSchemaResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	schemaPath := "/v1/schemas/" + created.ID
+	etag := doTestRequest(t, server.Handler, http.MethodGet, schemaPath, nil, middleware.APIKeyHeader, apiKey.Key).Header().Get(middleware.ETagHeader)
+	require.NotEmpty(t, etag)
+
+	w = doTestRequest(t, server.Handler, http.MethodDelete, schemaPath, nil, middleware.APIKeyHeader, apiKey.Key)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	// and someone without a key tries to create one
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/schemas", createSchema, middleware.APIKeyHeader, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	t.Run("records every call that changes something", func(tt *testing.T) {
+		events := listEvents(tt, "")
+		require.Len(tt, events, 4)
+
+		keyCreated := events[0]
+		assert.Equal(tt, auth.BootstrapAdminKeyID, keyCreated.Actor)
+		assert.Equal(tt, http.MethodPut, keyCreated.Method)
+		assert.Equal(tt, AdminPrefix+APIKeysPrefix, keyCreated.Resource)
+		assert.Equal(tt, http.StatusCreated, keyCreated.Status)
+		assert.Equal(tt, audit.OutcomeSucceeded, keyCreated.Outcome)
+		assert.NotEmpty(tt, keyCreated.RequestID)
+
+		// creations are recorded on the resource they created, along with its hash
+		schemaCreated := events[1]
+		assert.Equal(tt, apiKey.APIKey.ID, schemaCreated.Actor)
+		assert.Equal(tt, "/v1/schemas", schemaCreated.Route)
+		assert.Equal(tt, schemaPath, schemaCreated.Resource)
+		assert.Empty(tt, schemaCreated.BeforeHash)
+		assert.Equal(tt, etag, schemaCreated.AfterHash)
+
+		schemaDeleted := events[2]
+		assert.Equal(tt, http.MethodDelete, schemaDeleted.Method)
+		assert.Equal(tt, "/v1/schemas/:id", schemaDeleted.Route)
+		assert.Equal(tt, schemaPath, schemaDeleted.Resource)
+		assert.Equal(tt, etag, schemaDeleted.BeforeHash)
+		assert.Empty(tt, schemaDeleted.AfterHash)
+
+		denied := events[3]
+		assert.Empty(tt, denied.Actor)
+		assert.Equal(tt, http.StatusUnauthorized, denied.Status)
+		assert.Equal(tt, audit.OutcomeDenied, denied.Outcome)
+
+		// IDs sort in the order events were recorded in
+		for i := 1; i < len(events); i++ {
+			assert.Less(tt, events[i-1].ID, events[i].ID)
+		}
+	})
+
+	t.Run("filters events", func(tt *testing.T) {
+		events := listEvents(tt, "?actor="+apiKey.APIKey.ID)
+		assert.Len(tt, events, 2)
+		events = listEvents(tt, "?outcome=denied")
+		assert.Len(tt, events, 1)
+		events = listEvents(tt, "?resource=/v1/schemas")
+		assert.Len(tt, events, 3)
+		events = listEvents(tt, "?resource="+schemaPath+"&since="+events[0].Time.Format("2006-01-02T15:04:05Z07:00"))
+		assert.Len(tt, events, 2)
+		events = listEvents(tt, "?until=2000-01-01T00:00:00Z")
+		assert.Empty(tt, events)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix+"?outcome=maybe", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix+"?since=yesterday", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("exports events", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix+ExportPath+"?resource=/v1", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, router.NDJSONContentType, w.Header().Get("Content-Type"))
+
+		var exported []audit.Event
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var event audit.Event
+			require.NoError(tt, json.Unmarshal(scanner.Bytes(), &event))
+			exported = append(exported, event)
+		}
+		assert.Equal(tt, listEvents(tt, "?resource=/v1"), exported)
+	})
+
+	t.Run("verifies the chain of events", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix+VerificationPath, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.VerifyAuditEventsResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(tt, resp.Verified, resp.Reason)
+		assert.Equal(tt, 4, resp.Events)
+		assert.Zero(tt, resp.Unsigned)
+		assert.NotEmpty(tt, resp.LogID)
+
+		events := listEvents(tt, "")
+		last := events[len(events)-1]
+		assert.Equal(tt, audit.ChainHead{Sequence: last.Sequence, Hash: last.Hash}, resp.Head)
+		assert.NotEmpty(tt, last.Signature)
+	})
+
+	t.Run("only admins read the audit log", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+AuditPrefix, nil, middleware.APIKeyHeader, apiKey.Key)
+		assert.Equal(tt, http.StatusForbidden, w.Code)
+	})
+}
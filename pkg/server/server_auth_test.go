@@ -0,0 +1,581 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestAuthAPI(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Run("CreateAPIKey returns error when missing name", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				authRouter, _ := testAuthRouter(tt, db)
+
+				badRequestValue := newRequestValue(tt, router.CreateAPIKeyRequest{Admin: true})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/admin/apikeys", badRequestValue)
+				w := httptest.NewRecorder()
+
+				c := newRequestContext(w, req)
+				authRouter.CreateAPIKey(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+				assert.Contains(tt, w.Body.String(), "invalid create api key request")
+			})
+
+			t.Run("Create, Get, List, and Revoke API Keys", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				authRouter, authService := testAuthRouter(tt, db)
+
+				requestValue := newRequestValue(tt, router.CreateAPIKeyRequest{Name: "issuer-backend"})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/admin/apikeys", requestValue)
+				w := httptest.NewRecorder()
+
+				c := newRequestContext(w, req)
+				authRouter.CreateAPIKey(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var createResp router.CreateAPIKeyResponse
+				err := json.NewDecoder(w.Body).Decode(&createResp)
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, createResp.Key)
+				assert.Equal(tt, "issuer-backend", createResp.APIKey.Name)
+				assert.False(tt, createResp.APIKey.Admin)
+
+				// the raw key authenticates
+				authenticated, err := authService.Authenticate(context.Background(), createResp.Key)
+				assert.NoError(tt, err)
+				assert.Equal(tt, createResp.APIKey.ID, authenticated.ID)
+
+				// get it back, without the raw key
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/admin/apikeys/"+createResp.APIKey.ID, nil)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, map[string]string{"id": createResp.APIKey.ID})
+				authRouter.GetAPIKey(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+				assert.NotContains(tt, w.Body.String(), createResp.Key)
+
+				var getResp router.GetAPIKeyResponse
+				err = json.NewDecoder(w.Body).Decode(&getResp)
+				assert.NoError(tt, err)
+				assert.Equal(tt, createResp.APIKey.ID, getResp.APIKey.ID)
+
+				// revoke it
+				req = httptest.NewRequest(http.MethodDelete, "https://ssi-service.com/admin/apikeys/"+createResp.APIKey.ID, nil)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, map[string]string{"id": createResp.APIKey.ID})
+				authRouter.RevokeAPIKey(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				_, err = authService.Authenticate(context.Background(), createResp.Key)
+				assert.ErrorIs(tt, err, auth.ErrInvalidAPIKey)
+
+				// revoked keys are still listed
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/admin/apikeys", nil)
+				w = httptest.NewRecorder()
+				c = newRequestContext(w, req)
+				authRouter.ListAPIKeys(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var listResp router.ListAPIKeysResponse
+				err = json.NewDecoder(w.Body).Decode(&listResp)
+				assert.NoError(tt, err)
+				assert.Len(tt, listResp.APIKeys, 1)
+				assert.True(tt, listResp.APIKeys[0].Revoked)
+				assert.NotNil(tt, listResp.APIKeys[0].RevokedAt)
+			})
+
+			t.Run("GetAPIKey and RevokeAPIKey return not found for unknown keys", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				authRouter, _ := testAuthRouter(tt, db)
+
+				req := httptest.NewRequest(http.MethodGet, "https://ssi-service.com/admin/apikeys/bad", nil)
+				w := httptest.NewRecorder()
+				c := newRequestContextWithParams(w, req, map[string]string{"id": "bad"})
+				authRouter.GetAPIKey(c)
+				assert.Equal(tt, http.StatusNotFound, w.Code)
+
+				req = httptest.NewRequest(http.MethodDelete, "https://ssi-service.com/admin/apikeys/bad", nil)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, map[string]string{"id": "bad"})
+				authRouter.RevokeAPIKey(c)
+				assert.Equal(tt, http.StatusNotFound, w.Code)
+			})
+		})
+	}
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			require.NotEmpty(t, db)
+
+			adminKey := "bootstrap-secret"
+			authService := testAuthService(t, db, auth.HashAPIKey(adminKey))
+
+			engine := gin.New()
+			engine.GET("/v1/things", middleware.APIKeyAuth(authService), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			engine.GET("/admin/things", middleware.APIKeyAuth(authService), middleware.RequireAdmin(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			engine.GET("/v1/dids", middleware.APIKeyAuth(authService), middleware.RequirePermission(authService, auth.ScopeDIDsRead, ""), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			engine.PUT("/v1/dids/key", middleware.APIKeyAuth(authService), middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			userKey, err := authService.CreateAPIKey(context.Background(), auth.CreateAPIKeyRequest{Name: "user"})
+			require.NoError(t, err)
+
+			doRequest := func(path, key string) int {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if key != "" {
+					req.Header.Set(middleware.APIKeyHeader, key)
+				}
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			t.Run("rejects missing and unknown keys", func(tt *testing.T) {
+				assert.Equal(tt, http.StatusUnauthorized, doRequest("/v1/things", ""))
+				assert.Equal(tt, http.StatusUnauthorized, doRequest("/v1/things", "not-a-key"))
+				assert.Equal(tt, http.StatusUnauthorized, doRequest("/v1/things", userKey.APIKey.ID+".wrong"))
+			})
+
+			t.Run("accepts valid keys", func(tt *testing.T) {
+				assert.Equal(tt, http.StatusOK, doRequest("/v1/things", userKey.Key))
+				assert.Equal(tt, http.StatusOK, doRequest("/v1/things", adminKey))
+			})
+
+			t.Run("admin routes require an admin key", func(tt *testing.T) {
+				assert.Equal(tt, http.StatusForbidden, doRequest("/admin/things", userKey.Key))
+				assert.Equal(tt, http.StatusOK, doRequest("/admin/things", adminKey))
+			})
+
+			t.Run("scoped keys are restricted to their scopes", func(tt *testing.T) {
+				doPut := func(path, key string) int {
+					req := httptest.NewRequest(http.MethodPut, path, nil)
+					req.Header.Set(middleware.APIKeyHeader, key)
+					w := httptest.NewRecorder()
+					engine.ServeHTTP(w, req)
+					return w.Code
+				}
+
+				reader, err := authService.CreateAPIKey(context.Background(), auth.CreateAPIKeyRequest{Name: "reader", Scopes: []string{auth.ScopeDIDsRead}})
+				require.NoError(tt, err)
+				assert.Equal(tt, []string{auth.ScopeDIDsRead}, reader.APIKey.Scopes)
+				assert.Equal(tt, http.StatusOK, doRequest("/v1/dids", reader.Key))
+				assert.Equal(tt, http.StatusForbidden, doPut("/v1/dids/key", reader.Key))
+
+				writer, err := authService.CreateAPIKey(context.Background(), auth.CreateAPIKeyRequest{Name: "writer", Scopes: []string{auth.ScopeDIDsWrite}})
+				require.NoError(tt, err)
+				assert.Equal(tt, http.StatusForbidden, doRequest("/v1/dids", writer.Key))
+				assert.Equal(tt, http.StatusOK, doPut("/v1/dids/key", writer.Key))
+
+				// keys without scopes read and write everything, as they always have
+				assert.Equal(tt, http.StatusOK, doRequest("/v1/dids", userKey.Key))
+				assert.Equal(tt, http.StatusOK, doPut("/v1/dids/key", userKey.Key))
+
+				_, err = authService.CreateAPIKey(context.Background(), auth.CreateAPIKeyRequest{Name: "bad", Scopes: []string{"dids:everything"}})
+				assert.ErrorIs(tt, err, auth.ErrInvalidScope)
+			})
+		})
+	}
+}
+
+func TestBearerTokenAuthMiddleware(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signingKey, err := jwk.FromRaw(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, signingKey.Set(jwk.KeyIDKey, "test-key"))
+	require.NoError(t, signingKey.Set(jwk.AlgorithmKey, jwa.ES256))
+	publicKey, err := signingKey.PublicKey()
+	require.NoError(t, err)
+	keySet := jwk.NewSet()
+	require.NoError(t, keySet.AddKey(publicKey))
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(keySet)
+	}))
+	defer jwksServer.Close()
+
+	issuer := "https://idp.example.com"
+	audience := "ssi-service"
+	newToken := func(tt *testing.T, iss string, scopes string) string {
+		token, err := jwt.NewBuilder().
+			Issuer(iss).
+			Subject("user@example.com").
+			Audience([]string{audience}).
+			IssuedAt(time.Now()).
+			Expiration(time.Now().Add(time.Hour)).
+			Claim("scope", scopes).
+			Build()
+		require.NoError(tt, err)
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, signingKey))
+		require.NoError(tt, err)
+		return string(signed)
+	}
+
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			require.NotEmpty(t, db)
+
+			authService, err := auth.NewAuthService(config.AuthServiceConfig{
+				BaseServiceConfig: &config.BaseServiceConfig{Name: "auth"},
+				OAuthIssuer:       issuer,
+				OAuthJWKSURL:      jwksServer.URL,
+				OAuthAudience:     audience,
+			}, db)
+			require.NoError(t, err)
+			require.True(t, authService.OAuthEnabled())
+
+			engine := gin.New()
+			v1 := engine.Group("/v1", middleware.Authenticate(authService, true, true))
+			v1.GET("/dids", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			v1.PUT("/dids/key", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			doRequest := func(method, path, token string) int {
+				req := httptest.NewRequest(method, path, nil)
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			t.Run("rejects invalid tokens", func(tt *testing.T) {
+				assert.Equal(tt, http.StatusUnauthorized, doRequest(http.MethodGet, "/v1/dids", ""))
+				assert.Equal(tt, http.StatusUnauthorized, doRequest(http.MethodGet, "/v1/dids", "not-a-jwt"))
+				assert.Equal(tt, http.StatusUnauthorized, doRequest(http.MethodGet, "/v1/dids", newToken(tt, "https://evil.example.com", "")))
+			})
+
+			t.Run("enforces scopes", func(tt *testing.T) {
+				readOnly := newToken(tt, issuer, "")
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodGet, "/v1/dids", readOnly))
+				assert.Equal(tt, http.StatusForbidden, doRequest(http.MethodPut, "/v1/dids/key", readOnly))
+
+				writer := newToken(tt, issuer, auth.ScopeDIDsWrite+" "+auth.ScopeCredentialsIssue)
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodPut, "/v1/dids/key", writer))
+			})
+
+			t.Run("api keys are not restricted by scopes", func(tt *testing.T) {
+				created, err := authService.CreateAPIKey(context.Background(), auth.CreateAPIKeyRequest{Name: "backend"})
+				require.NoError(tt, err)
+
+				req := httptest.NewRequest(http.MethodPut, "/v1/dids/key", nil)
+				req.Header.Set(middleware.APIKeyHeader, created.Key)
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, req)
+				assert.Equal(tt, http.StatusOK, w.Code)
+			})
+		})
+	}
+}
+
+func TestBearerTokenDiscoveryAndScopeMapping(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signingKey, err := jwk.FromRaw(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, signingKey.Set(jwk.KeyIDKey, "test-key"))
+	require.NoError(t, signingKey.Set(jwk.AlgorithmKey, jwa.ES256))
+	publicKey, err := signingKey.PublicKey()
+	require.NoError(t, err)
+	keySet := jwk.NewSet()
+	require.NoError(t, keySet.AddKey(publicKey))
+
+	// the identity provider only publishes where its keys are in its openid configuration
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": idp.URL, "jwks_uri": idp.URL + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(keySet)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer idp.Close()
+
+	newToken := func(tt *testing.T, claims map[string]any) string {
+		builder := jwt.NewBuilder().
+			Issuer(idp.URL).
+			Subject("user@example.com").
+			IssuedAt(time.Now()).
+			Expiration(time.Now().Add(time.Hour))
+		for name, value := range claims {
+			builder = builder.Claim(name, value)
+		}
+		token, err := builder.Build()
+		require.NoError(tt, err)
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, signingKey))
+		require.NoError(tt, err)
+		return string(signed)
+	}
+
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	authService, err := auth.NewAuthService(config.AuthServiceConfig{
+		BaseServiceConfig: &config.BaseServiceConfig{Name: "auth"},
+		OAuthIssuer:       idp.URL,
+		OAuthScopeMappings: []config.OAuthScopeMappingConfig{
+			{Claim: "groups", Value: "did-admins", Scopes: []string{auth.ScopeDIDsWrite}},
+			{Claim: "realm_access.roles", Value: "issuer", Scopes: []string{auth.ScopeCredentialsIssue}},
+		},
+	}, db)
+	require.NoError(t, err)
+
+	engine := gin.New()
+	v1 := engine.Group("/v1", middleware.Authenticate(authService, false, true))
+	v1.PUT("/dids/key", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	v1.PUT("/credentials", middleware.RequirePermission(authService, auth.ScopeCredentialsIssue, ""), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	doRequest := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodPut, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("claims are mapped to scopes", func(tt *testing.T) {
+		didAdmin := newToken(tt, map[string]any{"groups": []string{"staff", "did-admins"}})
+		assert.Equal(tt, http.StatusOK, doRequest("/v1/dids/key", didAdmin))
+		assert.Equal(tt, http.StatusForbidden, doRequest("/v1/credentials", didAdmin))
+
+		issuer := newToken(tt, map[string]any{"realm_access": map[string]any{"roles": []string{"issuer"}}})
+		assert.Equal(tt, http.StatusForbidden, doRequest("/v1/dids/key", issuer))
+		assert.Equal(tt, http.StatusOK, doRequest("/v1/credentials", issuer))
+
+		// scopes of the scope claim are granted too
+		scoped := newToken(tt, map[string]any{"scope": auth.ScopeDIDsWrite})
+		assert.Equal(tt, http.StatusOK, doRequest("/v1/dids/key", scoped))
+	})
+
+	t.Run("invalid scope mappings are rejected", func(tt *testing.T) {
+		_, err := auth.NewAuthService(config.AuthServiceConfig{
+			BaseServiceConfig:  &config.BaseServiceConfig{Name: "auth"},
+			OAuthIssuer:        idp.URL,
+			OAuthScopeMappings: []config.OAuthScopeMappingConfig{{Claim: "groups", Value: "admins", Scopes: []string{"everything"}}},
+		}, db)
+		assert.ErrorIs(tt, err, auth.ErrInvalidScope)
+	})
+}
+
+func TestRBAC(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Run("SetRoleBinding returns error for unknown roles", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				authRouter, _ := testAuthRouter(tt, db)
+
+				requestValue := newRequestValue(tt, router.SetRoleBindingRequest{Subject: "someone", Roles: []string{"missing"}})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/admin/rolebindings", requestValue)
+				w := httptest.NewRecorder()
+				c := newRequestContext(w, req)
+				authRouter.SetRoleBinding(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+			})
+
+			t.Run("roles limit operations to owned resources", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				authRouter, authService := testAuthRouter(tt, db)
+
+				// create a role that may only manage its own manifests
+				requestValue := newRequestValue(tt, router.CreateRoleRequest{
+					Name:        "manifest-author",
+					Permissions: []auth.Permission{{Operation: auth.ScopeManifestsWrite, OwnedOnly: true}},
+				})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/admin/roles", requestValue)
+				w := httptest.NewRecorder()
+				c := newRequestContext(w, req)
+				authRouter.CreateRole(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				ctx := context.Background()
+				alice, err := authService.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "alice"})
+				require.NoError(tt, err)
+				bob, err := authService.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "bob"})
+				require.NoError(tt, err)
+				unbound, err := authService.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "unbound"})
+				require.NoError(tt, err)
+				for _, key := range []*auth.CreateAPIKeyResponse{alice, bob} {
+					_, err = authService.SetRoleBinding(ctx, auth.SetRoleBindingRequest{
+						RoleBinding: auth.RoleBinding{Subject: key.APIKey.ID, Roles: []string{"manifest-author"}},
+					})
+					require.NoError(tt, err)
+				}
+
+				engine := gin.New()
+				v1 := engine.Group("/v1", middleware.APIKeyAuth(authService))
+				v1.PUT("/manifests",
+					middleware.RequirePermission(authService, auth.ScopeManifestsWrite, auth.ResourceManifest),
+					middleware.RecordOwnership(authService, auth.ResourceManifest, "$.credential_manifest.id"),
+					func(c *gin.Context) {
+						c.JSON(http.StatusCreated, gin.H{"credential_manifest": gin.H{"id": c.Query("id")}})
+					})
+				v1.DELETE("/manifests/:id", middleware.RequirePermission(authService, auth.ScopeManifestsWrite, auth.ResourceManifest), func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+				v1.GET("/manifests", middleware.RejectOwnedOnly(auth.ScopeManifestsWrite), func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+				v1.PUT("/dids/key", middleware.RequirePermission(authService, auth.ScopeDIDsWrite, ""), func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				doRequest := func(method, path, key string) int {
+					req := httptest.NewRequest(method, path, nil)
+					req.Header.Set(middleware.APIKeyHeader, key)
+					w := httptest.NewRecorder()
+					engine.ServeHTTP(w, req)
+					return w.Code
+				}
+
+				assert.Equal(tt, http.StatusCreated, doRequest(http.MethodPut, "/v1/manifests?id=alice-manifest", alice.Key))
+				assert.Equal(tt, http.StatusCreated, doRequest(http.MethodPut, "/v1/manifests?id=unbound-manifest", unbound.Key))
+
+				// only the owner may delete
+				assert.Equal(tt, http.StatusForbidden, doRequest(http.MethodDelete, "/v1/manifests/alice-manifest", bob.Key))
+				assert.Equal(tt, http.StatusForbidden, doRequest(http.MethodDelete, "/v1/manifests/unbound-manifest", alice.Key))
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodDelete, "/v1/manifests/alice-manifest", alice.Key))
+
+				// and may not list every manifest
+				assert.Equal(tt, http.StatusForbidden, doRequest(http.MethodGet, "/v1/manifests", alice.Key))
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodGet, "/v1/manifests", unbound.Key))
+
+				// keys with roles are limited to them, while keys without are not
+				assert.Equal(tt, http.StatusForbidden, doRequest(http.MethodPut, "/v1/dids/key", alice.Key))
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodPut, "/v1/dids/key", unbound.Key))
+				assert.Equal(tt, http.StatusOK, doRequest(http.MethodDelete, "/v1/manifests/alice-manifest", unbound.Key))
+			})
+		})
+	}
+}
+
+func TestMultiTenancy(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.EnableAPIKeyAuth = true
+	})
+
+	ctx := context.Background()
+	acme, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "acme", TenantID: "acme"})
+	require.NoError(t, err)
+	globex, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "globex", TenantID: "globex"})
+	require.NoError(t, err)
+
+	_, err = server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "bad", TenantID: "../globex"})
+	assert.ErrorIs(t, err, auth.ErrInvalidTenant)
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/webhooks", router.CreateWebhookRequest{
+		Noun: "Credential",
+		Verb: "Create",
+		URL:  "https://www.tbd.website/",
+	}, middleware.APIKeyHeader, acme.Key)
+	require.True(t, util.Is2xxResponse(w.Code))
+
+	var acmeWebhooks router.ListWebhooksResponse
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/webhooks", nil, middleware.APIKeyHeader, acme.Key)
+	require.True(t, util.Is2xxResponse(w.Code))
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&acmeWebhooks))
+	assert.Len(t, acmeWebhooks.Webhooks, 1)
+
+	var globexWebhooks router.ListWebhooksResponse
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/webhooks", nil, middleware.APIKeyHeader, globex.Key)
+	require.True(t, util.Is2xxResponse(w.Code))
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&globexWebhooks))
+	assert.Empty(t, globexWebhooks.Webhooks)
+
+	// tenant scoped keys can't manage the deployment, even when they're admin keys
+	tenantAdmin, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "acme-admin", Admin: true, TenantID: "acme"})
+	require.NoError(t, err)
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/apikeys", nil, middleware.APIKeyHeader, tenantAdmin.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestWriteScopes(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.EnableAPIKeyAuth = true
+	})
+
+	ctx := context.Background()
+	reader, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "reader", Scopes: []string{auth.ScopeDIDsRead}})
+	require.NoError(t, err)
+
+	// every route that changes something needs its own write scope, which a key that only reads DIDs doesn't have
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPut, "/v1/keys"},
+		{http.MethodDelete, "/v1/keys/some-key"},
+		{http.MethodPut, "/v1/keys/some-key/rotate"},
+		{http.MethodPut, "/v1/schemas"},
+		{http.MethodDelete, "/v1/schemas/some-schema"},
+		{http.MethodPut, "/v1/webhooks"},
+		{http.MethodDelete, "/v1/webhooks/Credential/Create"},
+		{http.MethodPut, "/v1/issuancetemplates"},
+		{http.MethodPut, "/v1/presentations/definitions"},
+		{http.MethodPut, "/v1/presentations/requests"},
+		{http.MethodPut, "/v1/manifests/requests"},
+		{http.MethodDelete, "/v1/manifests/applications/some-application"},
+		{http.MethodDelete, "/v1/manifests/responses/some-response"},
+		{http.MethodPut, "/v1/did-configurations"},
+	} {
+		w := doTestRequest(t, server.Handler, route.method, route.path, nil, middleware.APIKeyHeader, reader.Key)
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", route.method, route.path)
+	}
+
+	writer, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "writer", Scopes: []string{auth.ScopeWebhooksWrite}})
+	require.NoError(t, err)
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/webhooks", router.CreateWebhookRequest{
+		Noun: "Credential",
+		Verb: "Create",
+		URL:  "https://www.tbd.website/",
+	}, middleware.APIKeyHeader, writer.Key)
+	assert.True(t, util.Is2xxResponse(w.Code))
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestBackupAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	dir := t.TempDir()
+	privateKeyset, publicKeyset, err := encryption.GenerateHybridKeyset()
+	require.NoError(t, err)
+	publicKeysetPath := filepath.Join(dir, "public.json")
+	require.NoError(t, os.WriteFile(publicKeysetPath, publicKeyset, 0600))
+
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.BackupConfig = config.BackupServiceConfig{
+				Enabled:          enabled,
+				Destination:      "file://" + filepath.Join(dir, "backups"),
+				PublicKeysetPath: publicKeysetPath,
+				Tenants:          []string{"acme"},
+			}
+		})
+	}
+
+	// the default tenant, and acme, each have a DID, whose key is in their keystore
+	server := newServer(t, true)
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.APIKeyHeader, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	acmeCtx := storage.WithTenant(context.Background(), "acme")
+	acmeDID, err := server.DID.CreateDIDByMethod(acmeCtx, did.CreateDIDRequest{Method: didsdk.KeyMethod, KeyType: crypto.Ed25519})
+	require.NoError(t, err)
+
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/backups", nil, middleware.APIKeyHeader, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/backups", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateBackupResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, 2, created.Backup.Tenants)
+	assert.Equal(t, 2, created.Backup.Keys)
+	assert.Equal(t, 2, created.Backup.DIDs)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/backups", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var backups router.ListBackupsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&backups))
+	assert.Equal(t, []string{created.Backup.Name}, backups.Names)
+
+	// backups are downloaded encrypted, and can only be decrypted with the private keyset
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/backups/"+created.Backup.Name, nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), issuer.DID.ID)
+	decrypter, err := encryption.NewHybridDecrypter(privateKeyset)
+	require.NoError(t, err)
+	decrypted, err := decrypter.Decrypt(context.Background(), w.Body.Bytes(), nil)
+	require.NoError(t, err)
+	var restoreRequest router.RestoreBackupRequest
+	require.NoError(t, json.Unmarshal(decrypted, &restoreRequest))
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/backups/backup.bin", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Run("restores keys and DIDs to the tenants they were backed up from", func(tt *testing.T) {
+		restored := newServer(tt, true)
+
+		// a dry run counts what would be restored, and restores nothing
+		w := doTestRequest(tt, restored.Handler, http.MethodPut, "/admin/backups/restore?dryRun=true", restoreRequest, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.RestoreBackupResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(tt, resp.DryRun)
+		assert.Equal(tt, 2, resp.RestoredKeys)
+		assert.Equal(tt, 2, resp.RestoredDIDs)
+		_, err := restored.DID.GetDIDByMethod(acmeCtx, did.GetDIDRequest{Method: didsdk.KeyMethod, ID: acmeDID.DID.ID})
+		assert.Error(tt, err)
+
+		w = doTestRequest(tt, restored.Handler, http.MethodPut, "/admin/backups/restore", restoreRequest, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		resp = router.RestoreBackupResponse{}
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.False(tt, resp.DryRun)
+		assert.Equal(tt, 2, resp.RestoredKeys)
+		assert.Equal(tt, 2, resp.RestoredDIDs)
+
+		// the restored key still signs for the DID
+		w = doTestRequest(tt, restored.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              "did:example:alice",
+			Data:                 map[string]any{"firstName": "Alice"},
+		}, middleware.APIKeyHeader, "")
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		gotDID, err := restored.DID.GetDIDByMethod(acmeCtx, did.GetDIDRequest{Method: didsdk.KeyMethod, ID: acmeDID.DID.ID})
+		require.NoError(tt, err)
+		assert.Equal(tt, acmeDID.DID.ID, gotDID.DID.ID)
+
+		// restoring again keeps what's stored
+		w = doTestRequest(tt, restored.Handler, http.MethodPut, "/admin/backups/restore", restoreRequest, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Zero(tt, resp.RestoredKeys)
+		assert.Equal(tt, 2, resp.SkippedKeys)
+		assert.Equal(tt, 2, resp.SkippedDIDs)
+
+		restoreRequest.Version = 2
+		w = doTestRequest(tt, restored.Handler, http.MethodPut, "/admin/backups/restore", restoreRequest, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/backups", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
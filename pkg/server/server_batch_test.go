@@ -0,0 +1,148 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestBatch(t *testing.T) {
+	runBatch := func(t *testing.T, server *SSIServer, request router.BatchRequest) router.BatchResponse {
+		w := doTestRequest(t, server.Handler, http.MethodPost, "/v1/batch", request)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp router.BatchResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+	encode := func(t *testing.T, value any) json.RawMessage {
+		encoded, err := json.Marshal(value)
+		require.NoError(t, err)
+		return encoded
+	}
+	createSchema := func(t *testing.T, name string) router.BatchOperation {
+		return router.BatchOperation{
+			ID:     name,
+			Method: http.MethodPut,
+			Path:   "/v1/schemas",
+			Body:   encode(t, router.CreateSchemaRequest{Name: name, Schema: getTestSchema()}),
+		}
+	}
+
+	t.Run("runs operations in order with references to earlier responses", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		resp := runBatch(tt, server, router.BatchRequest{Operations: []router.BatchOperation{
+			{ID: "did", Method: http.MethodPut, Path: "/v1/dids/key", Body: encode(tt, router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})},
+			{ID: "resolved", Method: http.MethodGet, Path: "/v1/dids/key/${did:$.did.id}"},
+			{Method: http.MethodGet, Path: "/v1/schemas/${missing:$.id}"},
+		}})
+		require.Len(tt, resp.Results, 3)
+		assert.False(tt, resp.Succeeded)
+
+		var created, resolved router.CreateDIDByMethodResponse
+		assert.Equal(tt, http.StatusCreated, resp.Results[0].Status)
+		require.NoError(tt, json.Unmarshal(resp.Results[0].Body, &created))
+		assert.Equal(tt, http.StatusOK, resp.Results[1].Status)
+		require.NoError(tt, json.Unmarshal(resp.Results[1].Body, &resolved))
+		assert.Equal(tt, created.DID.ID, resolved.DID.ID)
+		assert.Equal(tt, "resolved", resp.Results[1].ID)
+
+		// the reference is to an operation that doesn't exist
+		assert.Equal(tt, http.StatusBadRequest, resp.Results[2].Status)
+		assert.Contains(tt, string(resp.Results[2].Body), "${missing:$.id}")
+	})
+
+	t.Run("replaces references with values of the same type", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		resp := runBatch(tt, server, router.BatchRequest{Operations: []router.BatchOperation{
+			createSchema(tt, "first"),
+			{Method: http.MethodPut, Path: "/v1/schemas", Body: json.RawMessage(`{"name": "copy of ${first:$.id}", "schema": "${first:$.schema}"}`)},
+		}})
+		require.True(tt, resp.Succeeded, resp.Results)
+
+		var first, copied router.CreateSchemaResponse
+		require.NoError(tt, json.Unmarshal(resp.Results[0].Body, &first))
+		require.NoError(tt, json.Unmarshal(resp.Results[1].Body, &copied))
+		require.NotNil(tt, copied.Schema)
+		assert.Equal(tt, (*first.Schema)["properties"], (*copied.Schema)["properties"])
+	})
+
+	t.Run("keeps going after failures", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		resp := runBatch(tt, server, router.BatchRequest{Operations: []router.BatchOperation{
+			{Method: http.MethodGet, Path: "/v1/schemas/missing"},
+			createSchema(tt, "schema"),
+		}})
+		assert.False(tt, resp.Succeeded)
+		require.Len(tt, resp.Results, 2)
+		assert.Equal(tt, http.StatusNotFound, resp.Results[0].Status)
+		assert.Equal(tt, http.StatusCreated, resp.Results[1].Status)
+	})
+
+	t.Run("undoes atomic batches that fail", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		resp := runBatch(tt, server, router.BatchRequest{Atomic: true, Operations: []router.BatchOperation{
+			createSchema(tt, "first"),
+			{ID: "did", Method: http.MethodPut, Path: "/v1/dids/key", Body: encode(tt, router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})},
+			{Method: http.MethodPut, Path: "/v1/schemas", Body: json.RawMessage(`{"name": "invalid"}`)},
+			createSchema(tt, "never"),
+		}})
+		assert.False(tt, resp.Succeeded)
+		require.Len(tt, resp.Results, 3)
+		assert.True(tt, resp.Results[0].Undone, resp.Results[0].UndoError)
+		assert.True(tt, resp.Results[1].Undone, resp.Results[1].UndoError)
+		assert.Equal(tt, http.StatusBadRequest, resp.Results[2].Status)
+		assert.False(tt, resp.Results[2].Undone)
+
+		var schema router.CreateSchemaResponse
+		require.NoError(tt, json.Unmarshal(resp.Results[0].Body, &schema))
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas/"+schema.ID, nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil)
+		var schemas router.ListSchemasResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&schemas))
+		assert.Empty(tt, schemas.Schemas)
+	})
+
+	t.Run("rejects atomic batches with operations that can't be undone", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		w := doTestRequest(tt, server.Handler, http.MethodPost, "/v1/batch", router.BatchRequest{Atomic: true, Operations: []router.BatchOperation{
+			createSchema(tt, "first"),
+			{Method: http.MethodDelete, Path: "/v1/schemas/${first:$.id}"},
+		}})
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Contains(tt, w.Body.String(), "can't be undone")
+
+		// nothing ran
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil)
+		var schemas router.ListSchemasResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&schemas))
+		assert.Empty(tt, schemas.Schemas)
+	})
+
+	t.Run("rejects operations outside the version of the batch", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		for _, path := range []string{"/v2/schemas", "/admin/apikeys", "/v1/../admin/apikeys", "https://example.com/v1/schemas"} {
+			w := doTestRequest(tt, server.Handler, http.MethodPost, "/v1/batch", router.BatchRequest{Operations: []router.BatchOperation{
+				{Method: http.MethodGet, Path: path},
+			}})
+			assert.Equal(tt, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
+	t.Run("rejects nested batches", func(tt *testing.T) {
+		server := newTestServer(tt, nil)
+		resp := runBatch(tt, server, router.BatchRequest{Operations: []router.BatchOperation{
+			{Method: http.MethodPost, Path: "/v1/batch", Body: encode(tt, router.BatchRequest{Operations: []router.BatchOperation{createSchema(tt, "nested")}})},
+		}})
+		require.Len(tt, resp.Results, 1)
+		assert.Equal(tt, http.StatusBadRequest, resp.Results[0].Status)
+		assert.Contains(tt, string(resp.Results[0].Body), "be nested")
+	})
+}
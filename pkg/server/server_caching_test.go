@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestCaching(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.Caching.MaxAge = 5 * time.Minute
+	})
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+		Issuer:               issuer.DID.ID,
+		VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+		Subject:              "did:abc:456",
+		Data:                 map[string]any{"firstName": "Jack"},
+		Revocable:            true,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	statusListID := created.Credential.CredentialStatus.(map[string]any)["statusListCredential"].(string)
+	statusListPath := "/v1/credentials/status/" + statusListID[strings.LastIndex(statusListID, "/")+1:]
+
+	t.Run("public artifacts are cacheable", func(tt *testing.T) {
+		for _, path := range []string{"/v1/dids/key/" + issuer.DID.ID, "/v1/dids/resolver/" + issuer.DID.ID, statusListPath} {
+			w := doTestRequest(tt, server.Handler, http.MethodGet, path, nil)
+			require.Equal(tt, http.StatusOK, w.Code, path)
+			assert.NotEmpty(tt, w.Header().Get(middleware.ETagHeader), path)
+			assert.NotEmpty(tt, w.Header().Get(middleware.LastModifiedHeader), path)
+			assert.Equal(tt, "public, max-age=300", w.Header().Get(middleware.CacheControlHeader), path)
+		}
+
+		// lists and private resources aren't
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials/"+created.ID, nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Empty(tt, w.Header().Get(middleware.CacheControlHeader))
+	})
+
+	t.Run("unchanged artifacts are not modified", func(tt *testing.T) {
+		path := "/v1/dids/key/" + issuer.DID.ID
+		w := doTestRequest(tt, server.Handler, http.MethodGet, path, nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		etag := w.Header().Get(middleware.ETagHeader)
+		lastModified := w.Header().Get(middleware.LastModifiedHeader)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfNoneMatchHeader, etag)
+		assert.Equal(tt, http.StatusNotModified, w.Code)
+		assert.Empty(tt, w.Body.String())
+		assert.Equal(tt, etag, w.Header().Get(middleware.ETagHeader))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfModifiedSinceHeader, lastModified)
+		assert.Equal(tt, http.StatusNotModified, w.Code)
+		assert.Equal(tt, lastModified, w.Header().Get(middleware.LastModifiedHeader))
+
+		earlier := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfModifiedSinceHeader, earlier)
+		assert.Equal(tt, http.StatusOK, w.Code)
+
+		// If-None-Match takes precedence over If-Modified-Since
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfNoneMatchHeader, `"other"`, middleware.IfModifiedSinceHeader, lastModified)
+		assert.Equal(tt, http.StatusOK, w.Code)
+	})
+
+	t.Run("changed artifacts are sent again", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, statusListPath, nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		etag := w.Header().Get(middleware.ETagHeader)
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials/"+created.ID+"/status", router.UpdateCredentialStatusRequest{Revoked: true})
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, statusListPath, nil, middleware.IfNoneMatchHeader, etag)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.NotEqual(tt, etag, w.Header().Get(middleware.ETagHeader))
+		assert.NotEmpty(tt, w.Body.String())
+	})
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/challenge"
+)
+
+func TestChallenges(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.ChallengeConfig.Enabled = true
+	})
+
+	createDefinition := func(t *testing.T) string {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/presentations/definitions", router.CreatePresentationDefinitionRequest{
+			Name:    "name",
+			Purpose: "purpose",
+			InputDescriptors: []exchange.InputDescriptor{{
+				ID: "wa_driver_license",
+				Constraints: &exchange.Constraints{
+					Fields: []exchange.Field{{Path: []string{"$.vc.credentialSubject.dateOfBirth"}}},
+				},
+			}},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreatePresentationDefinitionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.PresentationDefinition.ID
+	}
+	createChallenge := func(t *testing.T, definitionID string) challenge.Challenge {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/challenges", router.CreateChallengeRequest{Target: challenge.TargetDefinition, TargetID: definitionID})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreateChallengeResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Challenge
+	}
+	holderSigner, holderDID := getSigner(t)
+	submit := func(t *testing.T, definitionID, nonce string) *httptest.ResponseRecorder {
+		request := createSubmissionRequest(t, definitionID, "did:example:verifier", VerifiableCredential(), holderSigner, holderDID)
+		return doTestRequest(t, server.Handler, http.MethodPut, "/v1/presentations/submissions", withNonce(t, request, holderSigner, nonce))
+	}
+	assertProblem := func(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+		assert.Equal(t, status, w.Code, w.Body.String())
+		var problem framework.ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+		assert.Equal(t, code, problem.Code)
+	}
+
+	definitionID := createDefinition(t)
+
+	t.Run("challenges are required, and bound to their definition", func(tt *testing.T) {
+		assertProblem(tt, submit(tt, definitionID, ""), http.StatusBadRequest, framework.CodeChallengeRequired)
+		assertProblem(tt, submit(tt, definitionID, "unknown"), http.StatusBadRequest, framework.CodeInvalidChallenge)
+
+		otherChallenge := createChallenge(tt, createDefinition(tt))
+		assertProblem(tt, submit(tt, definitionID, otherChallenge.Nonce), http.StatusBadRequest, framework.CodeInvalidChallenge)
+
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/challenges", router.CreateChallengeRequest{Target: challenge.TargetManifest, TargetID: definitionID})
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("a challenge is responded to once", func(tt *testing.T) {
+		issued := createChallenge(tt, definitionID)
+		assert.Equal(tt, definitionID, issued.TargetID)
+		assert.True(tt, issued.ExpiresAt.After(issued.IssuedAt))
+
+		w := submit(tt, definitionID, issued.Nonce)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		assertProblem(tt, submit(tt, definitionID, issued.Nonce), http.StatusConflict, framework.CodeReplayed)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/challenges/"+issued.Nonce, nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.GetChallengeResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.NotNil(tt, resp.Challenge.ConsumedAt)
+	})
+
+	t.Run("the challenge of a request that fails is released", func(tt *testing.T) {
+		issued := createChallenge(tt, definitionID)
+
+		// signed by another key than the holder's, so that the presentation isn't verified
+		otherSigner, _ := getSigner(tt)
+		otherSigner.KID = holderSigner.KID
+		request := createSubmissionRequest(tt, definitionID, "did:example:verifier", VerifiableCredential(), holderSigner, holderDID)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", withNonce(tt, request, otherSigner, issued.Nonce))
+		assert.False(tt, w.Code < http.StatusBadRequest, w.Body.String())
+
+		w = submit(tt, definitionID, issued.Nonce)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("submissions responding to presentation requests carry the request's nonce instead", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var verifier router.CreateDIDByMethodResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&verifier))
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/requests", router.CreateRequestRequest{
+			CommonCreateRequestRequest: &router.CommonCreateRequestRequest{
+				IssuerDID:            verifier.DID.ID,
+				VerificationMethodID: verifier.DID.VerificationMethod[0].ID,
+			},
+			PresentationDefinitionID: definitionID,
+		})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var request router.CreateRequestResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&request))
+
+		w = submit(tt, definitionID, request.Request.Nonce)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
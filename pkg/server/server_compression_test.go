@@ -0,0 +1,90 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+)
+
+func TestCompression(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.Compression = config.CompressionConfig{Enabled: true}
+	})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set(middleware.AcceptEncodingHeader, acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	uncompressed := get(OpenAPIPath, "")
+	require.Equal(t, http.StatusOK, uncompressed.Code)
+	require.Greater(t, uncompressed.Body.Len(), middleware.DefaultCompressionMinSize)
+
+	t.Run("compresses large responses with the preferred encoding", func(tt *testing.T) {
+		tests := []struct {
+			acceptEncoding string
+			encoding       string
+			reader         func(io.Reader) (io.Reader, error)
+		}{
+			{
+				acceptEncoding: "gzip",
+				encoding:       middleware.EncodingGzip,
+				reader:         func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+			},
+			{
+				acceptEncoding: "gzip, deflate, br",
+				encoding:       middleware.EncodingBrotli,
+				reader:         func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+			},
+			{
+				acceptEncoding: "br;q=0.5, gzip;q=0.8",
+				encoding:       middleware.EncodingGzip,
+				reader:         func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+			},
+			{
+				acceptEncoding: "*",
+				encoding:       middleware.EncodingBrotli,
+				reader:         func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+			},
+		}
+		for _, test := range tests {
+			w := get(OpenAPIPath, test.acceptEncoding)
+			assert.Equal(tt, http.StatusOK, w.Code)
+			assert.Equal(tt, test.encoding, w.Header().Get(middleware.ContentEncodingHeader), test.acceptEncoding)
+			assert.Contains(tt, w.Header().Values("Vary"), middleware.AcceptEncodingHeader)
+			assert.Less(tt, w.Body.Len(), uncompressed.Body.Len())
+
+			r, err := test.reader(w.Body)
+			require.NoError(tt, err)
+			body, err := io.ReadAll(r)
+			require.NoError(tt, err)
+			assert.Equal(tt, uncompressed.Body.String(), string(body))
+		}
+	})
+
+	t.Run("sends responses as they are otherwise", func(tt *testing.T) {
+		// not accepted
+		w := get(OpenAPIPath, "identity, gzip;q=0")
+		assert.Empty(tt, w.Header().Get(middleware.ContentEncodingHeader))
+		assert.Equal(tt, uncompressed.Body.String(), w.Body.String())
+
+		// too small
+		w = get(HealthPrefix, "gzip")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Empty(tt, w.Header().Get(middleware.ContentEncodingHeader))
+		assert.Contains(tt, w.Body.String(), "OK")
+	})
+}
@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -15,14 +18,23 @@ import (
 	"github.com/tbd54566975/ssi-service/pkg/testutil"
 
 	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	statussdk "github.com/TBD54566975/ssi-sdk/credential/status"
 	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/TBD54566975/ssi-sdk/cryptosuite"
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
 
+	"github.com/tbd54566975/ssi-service/config"
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
 	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/did"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
 )
@@ -121,6 +133,51 @@ func TestCredentialAPI(t *testing.T) {
 					assert.Contains(ttt, w.Body.String(), "invalid batch create credential request")
 				})
 
+				ttt.Run("Returns A Result Per Request When Created Independently", func(ttt *testing.T) {
+					batchCreateCredentialsRequest := batchCreateCredentialsRequest
+					batchCreateCredentialsRequest.Requests = append([]router.CreateCredentialRequest{
+						// missing the data field
+						{
+							Issuer:               issuerDID.DID.ID,
+							VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+							Subject:              "did:abc:456",
+						},
+						// signed with a key the service doesn't hold
+						{
+							Issuer:               issuerDID.DID.ID,
+							VerificationMethodID: issuerDID.DID.ID + "#missing",
+							Subject:              "did:abc:456",
+							Data:                 map[string]any{"firstName": "Jack"},
+						},
+					}, batchCreateCredentialsRequest.Requests...)
+
+					requestValue := newRequestValue(ttt, batchCreateCredentialsRequest)
+					req := httptest.NewRequest(http.MethodPost, "https://ssi-service.com/v1/credentials/batch", requestValue)
+					w := httptest.NewRecorder()
+					c := newRequestContext(w, req)
+					credRouter.BatchCreateCredentialsIndependently(c)
+					assert.Equal(ttt, http.StatusOK, w.Code)
+
+					var resp router.BatchCreateCredentialResultsResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					require.Len(ttt, resp.Results, 4)
+
+					assert.Nil(ttt, resp.Results[0].Credential)
+					assert.Contains(ttt, resp.Results[0].Error, "invalid create credential request")
+					assert.Nil(ttt, resp.Results[1].Credential)
+					assert.Contains(ttt, resp.Results[1].Error, "could not create credential")
+
+					require.NotNil(ttt, resp.Results[2].Credential)
+					assert.Empty(ttt, resp.Results[2].Error)
+					assert.NotEmpty(ttt, resp.Results[2].Credential.CredentialJWT)
+					assert.Equal(ttt, "did:abc:456", resp.Results[2].Credential.Credential.CredentialSubject.GetID())
+					assert.Empty(ttt, resp.Results[2].Credential.Credential.CredentialStatus)
+
+					require.NotNil(ttt, resp.Results[3].Credential)
+					assert.Equal(ttt, "did:abc:789", resp.Results[3].Credential.Credential.CredentialSubject.GetID())
+					assert.NotEmpty(ttt, resp.Results[3].Credential.Credential.CredentialStatus)
+				})
+
 				ttt.Run("Fails with more than 1000 requests", func(ttt *testing.T) {
 					batchCreateCredentialsRequest := batchCreateCredentialsRequest
 					// missing the data field
@@ -328,6 +385,26 @@ func TestCredentialAPI(t *testing.T) {
 				c = newRequestContext(w, req)
 				credRouter.CreateCredential(c)
 				assert.Contains(ttt, w.Body.String(), "schema not found")
+
+				// reset the http recorder
+				w = httptest.NewRecorder()
+
+				// create cred whose data doesn't conform to the schema
+				invalidCred := createCredRequest
+				invalidCred.Data = map[string]any{"firstName": "Jack", "lastName": 1}
+				requestValue = newRequestValue(ttt, invalidCred)
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue)
+				c = newRequestContext(w, req)
+				credRouter.CreateCredential(c)
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+
+				var problem framework.ErrorResponse
+				assert.NoError(ttt, json.NewDecoder(w.Body).Decode(&problem))
+				assert.Equal(ttt, framework.CodeSchemaValidationFailed, problem.Code)
+				if assert.Len(ttt, problem.Errors, 1) {
+					assert.Equal(ttt, "credentialSubject.lastName", problem.Errors[0].Field)
+					assert.Contains(ttt, problem.Errors[0].Error, "expected string")
+				}
 			})
 
 			tt.Run("Test Get Credential By ID", func(ttt *testing.T) {
@@ -1052,9 +1129,31 @@ func TestCredentialAPI(t *testing.T) {
 				assert.NoError(ttt, err)
 				assert.Equal(ttt, false, credStatusResponse.Revoked)
 
-				// good request number one
+				// a dry run responds with the status the credential would have, without updating it
 				updateCredStatusRequest := router.UpdateCredentialStatusRequest{Revoked: true}
 
+				requestValue = newRequestValue(ttt, updateCredStatusRequest)
+				req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("%s/status?dryRun=true", resp.Credential.ID), requestValue)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(resp.Credential.ID)})
+				credRouter.UpdateCredentialStatus(c)
+				assert.True(ttt, util.Is2xxResponse(w.Code))
+
+				var dryRunResponse = router.UpdateCredentialStatusResponse{}
+				err = json.NewDecoder(w.Body).Decode(&dryRunResponse)
+				assert.NoError(ttt, err)
+				assert.Equal(ttt, true, dryRunResponse.Revoked)
+
+				req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/status", resp.Credential.ID), nil)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(resp.Credential.ID)})
+				credRouter.GetCredentialStatus(c)
+				assert.True(ttt, util.Is2xxResponse(w.Code))
+				credStatusResponse = router.GetCredentialStatusResponse{}
+				err = json.NewDecoder(w.Body).Decode(&credStatusResponse)
+				assert.NoError(ttt, err)
+				assert.Equal(ttt, false, credStatusResponse.Revoked)
+
+				// good request number one
+
 				requestValue = newRequestValue(ttt, updateCredStatusRequest)
 				req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("%s/status", resp.Credential.ID), requestValue)
 				c = newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(resp.Credential.ID)})
@@ -1068,6 +1167,572 @@ func TestCredentialAPI(t *testing.T) {
 
 			})
 
+			tt.Run("Test Reinstating A Credential Keeps Others Revoked", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil)
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+				credRouter := testCredentialRouter(ttt, db, keyStoreService, didService, schemaService)
+
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(ttt, err)
+
+				var creds []credsdk.VerifiableCredential
+				for i := 0; i < 2; i++ {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+						Issuer:               issuerDID.DID.ID,
+						VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+						Subject:              "did:abc:456",
+						Data:                 map[string]any{"index": i},
+						Revocable:            true,
+					})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+					credRouter.CreateCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.CreateCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					creds = append(creds, *resp.Credential)
+				}
+
+				updateStatus := func(cred credsdk.VerifiableCredential, revoked bool) {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.UpdateCredentialStatusRequest{Revoked: revoked})
+					req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("%s/status", cred.ID), requestValue)
+					c := newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(cred.ID)})
+					credRouter.UpdateCredentialStatus(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+				}
+				statusList := func() credsdk.VerifiableCredential {
+					statusListURI := creds[0].CredentialStatus.(map[string]any)["statusListCredential"].(string)
+					w := httptest.NewRecorder()
+					req := httptest.NewRequest(http.MethodGet, statusListURI, nil)
+					c := newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(statusListURI)})
+					credRouter.GetCredentialStatusList(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.GetCredentialStatusListResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return *resp.Credential
+				}
+
+				updateStatus(creds[0], true)
+				updateStatus(creds[1], true)
+				updateStatus(creds[0], false)
+
+				list := statusList()
+				revoked, err := statussdk.ValidateCredentialInStatusList(creds[0], list)
+				require.NoError(ttt, err)
+				assert.False(ttt, revoked)
+				revoked, err = statussdk.ValidateCredentialInStatusList(creds[1], list)
+				require.NoError(ttt, err)
+				assert.True(ttt, revoked)
+			})
+
+			tt.Run("Test Expiring Credentials", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil)
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+				credService := testCredentialService(ttt, db, keyStoreService, didService, schemaService)
+				credRouter, err := router.NewCredentialRouter(credService)
+				require.NoError(ttt, err)
+
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(ttt, err)
+
+				createCredential := func(suspendable bool) credsdk.VerifiableCredential {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+						Issuer:               issuerDID.DID.ID,
+						VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+						Subject:              "did:abc:456",
+						Data:                 map[string]any{"suspendable": suspendable},
+						Expiry:               time.Now().Add(time.Hour).Format(time.RFC3339),
+						Suspendable:          suspendable,
+					})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+					credRouter.CreateCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.CreateCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return *resp.Credential
+				}
+				suspendable := createCredential(true)
+				plain := createCredential(false)
+
+				// a credential whose status list is for suspension is suspended
+				resp, err := credService.ExpireCredential(context.Background(), credential.ExpireCredentialRequest{ID: idFromURI(suspendable.ID)})
+				require.NoError(ttt, err)
+				assert.True(ttt, resp.Changed)
+				assert.True(ttt, resp.Expired)
+				assert.True(ttt, resp.Suspended)
+
+				statusListURI := suspendable.CredentialStatus.(map[string]any)["statusListCredential"].(string)
+				statusList, err := credService.GetCredentialStatusList(context.Background(), credential.GetCredentialStatusListRequest{ID: idFromURI(statusListURI)})
+				require.NoError(ttt, err)
+				suspended, err := statussdk.ValidateCredentialInStatusList(suspendable, *statusList.Credential)
+				require.NoError(ttt, err)
+				assert.True(ttt, suspended)
+
+				// a credential without a status is only recorded as expired
+				resp, err = credService.ExpireCredential(context.Background(), credential.ExpireCredentialRequest{ID: idFromURI(plain.ID)})
+				require.NoError(ttt, err)
+				assert.True(ttt, resp.Changed)
+				assert.False(ttt, resp.Suspended)
+				status, err := credService.GetCredentialStatus(context.Background(), credential.GetCredentialStatusRequest{ID: idFromURI(plain.ID)})
+				require.NoError(ttt, err)
+				assert.True(ttt, status.Expired)
+				assert.False(ttt, status.Suspended)
+
+				// and credentials are only expired once
+				resp, err = credService.ExpireCredential(context.Background(), credential.ExpireCredentialRequest{ID: idFromURI(suspendable.ID)})
+				require.NoError(ttt, err)
+				assert.False(ttt, resp.Changed)
+				assert.True(ttt, resp.Expired)
+			})
+
+			tt.Run("Test Verifying Reports The Status Of Credentials", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil)
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+				credRouter := testCredentialRouter(ttt, db, keyStoreService, didService, schemaService)
+
+				verify := func(credentialJWT keyaccess.JWT) router.VerifyCredentialResponse {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.VerifyCredentialRequest{CredentialJWT: &credentialJWT})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials/verification", requestValue))
+					credRouter.VerifyCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.VerifyCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return resp
+				}
+				checkOf := func(resp router.VerifyCredentialResponse, name string) credint.CheckResult {
+					for _, check := range resp.Checks {
+						if check.Check == name {
+							return check
+						}
+					}
+					ttt.Fatalf("no %s check in %+v", name, resp.Checks)
+					return credint.CheckResult{}
+				}
+
+				// a credential issued by the service, whose status list is read from storage
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(ttt, err)
+				w := httptest.NewRecorder()
+				requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+					Issuer:               issuerDID.DID.ID,
+					VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:              "did:abc:456",
+					Data:                 map[string]any{"firstName": "Jack"},
+					Revocable:            true,
+				})
+				c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+				credRouter.CreateCredential(c)
+				require.True(ttt, util.Is2xxResponse(w.Code))
+				var created router.CreateCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&created))
+
+				resp := verify(*created.CredentialJWT)
+				assert.True(ttt, resp.Verified, resp.Reason)
+				assert.True(ttt, checkOf(resp, credint.CheckStatus).Passed)
+
+				w = httptest.NewRecorder()
+				requestValue = newRequestValue(ttt, router.UpdateCredentialStatusRequest{Revoked: true})
+				req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("%s/status", created.Credential.ID), requestValue)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(created.Credential.ID)})
+				credRouter.UpdateCredentialStatus(c)
+				require.True(ttt, util.Is2xxResponse(w.Code))
+
+				resp = verify(*created.CredentialJWT)
+				assert.False(ttt, resp.Verified)
+				assert.Equal(ttt, "credential is revoked", resp.Reason)
+				assert.True(ttt, resp.Revoked)
+				assert.False(ttt, resp.Suspended)
+				assert.True(ttt, checkOf(resp, credint.CheckSignature).Passed)
+				assert.True(ttt, checkOf(resp, credint.CheckExpiry).Passed)
+				assert.False(ttt, checkOf(resp, credint.CheckStatus).Passed)
+
+				// a suspendable credential is told apart from a revoked one, and verifies again once reinstated
+				w = httptest.NewRecorder()
+				requestValue = newRequestValue(ttt, router.CreateCredentialRequest{
+					Issuer:               issuerDID.DID.ID,
+					VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:              "did:abc:456",
+					Data:                 map[string]any{"firstName": "Jill"},
+					Suspendable:          true,
+				})
+				c = newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+				credRouter.CreateCredential(c)
+				require.True(ttt, util.Is2xxResponse(w.Code))
+				var suspendable router.CreateCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&suspendable))
+				updateStatus := func(request router.UpdateCredentialStatusRequest) int {
+					w := httptest.NewRecorder()
+					req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("%s/status", suspendable.Credential.ID), newRequestValue(ttt, request))
+					c := newRequestContextWithParams(w, req, map[string]string{"id": idFromURI(suspendable.Credential.ID)})
+					credRouter.UpdateCredentialStatus(c)
+					return w.Code
+				}
+
+				assert.Equal(ttt, http.StatusBadRequest, updateStatus(router.UpdateCredentialStatusRequest{Revoked: true}))
+				assert.Equal(ttt, http.StatusBadRequest, updateStatus(router.UpdateCredentialStatusRequest{Revoked: true, Suspended: true}))
+				assert.Equal(ttt, http.StatusOK, updateStatus(router.UpdateCredentialStatusRequest{Suspended: true}))
+				resp = verify(*suspendable.CredentialJWT)
+				assert.False(ttt, resp.Verified)
+				assert.Equal(ttt, "credential is suspended", resp.Reason)
+				assert.False(ttt, resp.Revoked)
+				assert.True(ttt, resp.Suspended)
+
+				assert.Equal(ttt, http.StatusOK, updateStatus(router.UpdateCredentialStatusRequest{Suspended: false}))
+				resp = verify(*suspendable.CredentialJWT)
+				assert.True(ttt, resp.Verified, resp.Reason)
+				assert.False(ttt, resp.Suspended)
+
+				// credentials issued elsewhere, whose status list is fetched from their issuer
+				issuerSigner, externalIssuer := getSigner(ttt)
+				var statusListJWT []byte
+				issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/jwt")
+					_, _ = w.Write(statusListJWT)
+				}))
+				ttt.Cleanup(issuer.Close)
+				statusListURL := issuer.URL + "/status/1"
+
+				issue := func(index string) (credsdk.VerifiableCredential, keyaccess.JWT) {
+					vc := VerifiableCredential()
+					vc.Issuer = externalIssuer.String()
+					vc.CredentialStatus = statussdk.StatusList2021Entry{
+						ID:                   vc.ID + "/status",
+						Type:                 statussdk.StatusList2021EntryType,
+						StatusPurpose:        statussdk.StatusRevocation,
+						StatusListIndex:      index,
+						StatusListCredential: statusListURL,
+					}
+					vcJWT, err := integrity.SignVerifiableCredentialJWT(issuerSigner, vc)
+					require.NoError(ttt, err)
+					return vc, keyaccess.JWT(vcJWT)
+				}
+				revokedVC, revokedJWT := issue("7")
+				_, validJWT := issue("8")
+
+				statusList, err := statussdk.GenerateStatusList2021Credential(statusListURL, externalIssuer.String(), statussdk.StatusRevocation, []credsdk.VerifiableCredential{revokedVC})
+				require.NoError(ttt, err)
+				statusListJWT, err = integrity.SignVerifiableCredentialJWT(issuerSigner, *statusList)
+				require.NoError(ttt, err)
+
+				resp = verify(validJWT)
+				assert.True(ttt, resp.Verified, resp.Reason)
+				assert.True(ttt, checkOf(resp, credint.CheckStatus).Passed)
+
+				resp = verify(revokedJWT)
+				assert.False(ttt, resp.Verified)
+				assert.Equal(ttt, "credential is revoked", checkOf(resp, credint.CheckStatus).Reason)
+
+				// a status list that isn't signed by a key of its issuer fails the check
+				otherSigner, _ := getSigner(ttt)
+				statusListJWT, err = integrity.SignVerifiableCredentialJWT(otherSigner, *statusList)
+				require.NoError(ttt, err)
+				resp = verify(validJWT)
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, checkOf(resp, credint.CheckStatus).Reason, "verifying status list credential")
+			})
+
+			tt.Run("Test Issuing And Verifying Ed25519Signature2020 Credentials", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil)
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+
+				// offline, with the context that defines the claims loaded from a file
+				contextPath := filepath.Join(ttt.TempDir(), "name.jsonld")
+				require.NoError(ttt, os.WriteFile(contextPath, []byte(`{"@context": {"firstName": "https://schema.org/givenName"}}`), 0600))
+				serviceConfig := config.CredentialServiceConfig{
+					BaseServiceConfig: &config.BaseServiceConfig{Name: "credential", ServiceEndpoint: "https://ssi-service.com/v1/credentials"},
+					JSONLDContexts:    []config.JSONLDContextConfig{{URL: "https://example.com/contexts/name/v1", Path: contextPath}},
+					JSONLDOffline:     true,
+				}
+				credentialService, err := credential.NewCredentialService(serviceConfig, db, keyStoreService, didService.GetResolver(), schemaService)
+				require.NoError(ttt, err)
+				credRouter, err := router.NewCredentialRouter(credentialService)
+				require.NoError(ttt, err)
+
+				create := func(issuerDID *did.CreateDIDResponse, data map[string]any) *httptest.ResponseRecorder {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+						Issuer:               issuerDID.DID.ID,
+						VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+						Subject:              "did:abc:456",
+						Context:              "https://example.com/contexts/name/v1",
+						Data:                 data,
+						Format:               credential.FormatLDP,
+					})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+					credRouter.CreateCredential(c)
+					return w
+				}
+
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(ttt, err)
+				w := create(issuerDID, map[string]any{"firstName": "Jack"})
+				require.True(ttt, util.Is2xxResponse(w.Code), w.Body.String())
+				var created router.CreateCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&created))
+				assert.Nil(ttt, created.CredentialJWT)
+				require.NotNil(ttt, created.Credential.Proof)
+				assert.True(ttt, keyaccess.IsEd25519Signature2020(created.Credential.Proof))
+
+				verify := func(cred credsdk.VerifiableCredential) router.VerifyCredentialResponse {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.VerifyCredentialRequest{DataIntegrityCredential: &cred})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials/verification", requestValue))
+					credRouter.VerifyCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.VerifyCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return resp
+				}
+				resp := verify(*created.Credential)
+				assert.True(ttt, resp.Verified, resp.Reason)
+
+				tampered := *created.Credential
+				tampered.CredentialSubject = credsdk.CredentialSubject{"id": "did:abc:456", "firstName": "Jill"}
+				resp = verify(tampered)
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, resp.Reason, "invalid signature")
+
+				// claims that no context defines aren't signed
+				w = create(issuerDID, map[string]any{"lastName": "Doe"})
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+
+				// nor are credentials of issuers without Ed25519 keys
+				secp256k1DID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.SECP256k1,
+				})
+				require.NoError(ttt, err)
+				w = create(secp256k1DID, map[string]any{"firstName": "Jack"})
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+				assert.Contains(ttt, w.Body.String(), "require an Ed25519 key")
+			})
+
+			tt.Run("Test Issuing, Deriving And Verifying BbsBlsSignature2020 Credentials", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil, "web")
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+
+				contextPath := filepath.Join(ttt.TempDir(), "name.jsonld")
+				require.NoError(ttt, os.WriteFile(contextPath, []byte(`{"@context": {"firstName": "https://schema.org/givenName", "lastName": "https://schema.org/familyName"}}`), 0600))
+				serviceConfig := config.CredentialServiceConfig{
+					BaseServiceConfig: &config.BaseServiceConfig{Name: "credential", ServiceEndpoint: "https://ssi-service.com/v1/credentials"},
+					JSONLDContexts:    []config.JSONLDContextConfig{{URL: "https://example.com/contexts/name/v1", Path: contextPath}},
+					JSONLDOffline:     true,
+				}
+				credentialService, err := credential.NewCredentialService(serviceConfig, db, keyStoreService, didService.GetResolver(), schemaService)
+				require.NoError(ttt, err)
+				credRouter, err := router.NewCredentialRouter(credentialService)
+				require.NoError(ttt, err)
+
+				// BLS12381G2 keys are only supported by did:web, which doesn't resolve anywhere else before it's created
+				defer gock.Off()
+				gock.New("https://example.com").
+					Get("/.well-known/did.json").
+					Reply(http.StatusNotFound)
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.WebMethod,
+					KeyType: crypto.BLS12381G2,
+					Options: did.CreateWebDIDOptions{DIDWebID: "did:web:example.com"},
+				})
+				require.NoError(ttt, err)
+				assert.Equal(ttt, cryptosuite.BLS12381G2Key2020, issuerDID.DID.VerificationMethod[0].Type)
+
+				w := httptest.NewRecorder()
+				requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+					Issuer:               issuerDID.DID.ID,
+					VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:              "did:abc:456",
+					Context:              "https://example.com/contexts/name/v1",
+					Data:                 map[string]any{"firstName": "Jack", "lastName": "Dorsey"},
+					Format:               credential.FormatLDP,
+				})
+				c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+				credRouter.CreateCredential(c)
+				require.True(ttt, util.Is2xxResponse(w.Code), w.Body.String())
+				var created router.CreateCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&created))
+				require.NotNil(ttt, created.Credential)
+				assert.True(ttt, keyaccess.IsBbsBlsSignature2020(created.Credential.Proof))
+
+				verify := func(cred credsdk.VerifiableCredential) router.VerifyCredentialResponse {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, router.VerifyCredentialRequest{DataIntegrityCredential: &cred})
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials/verification", requestValue))
+					credRouter.VerifyCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var resp router.VerifyCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return resp
+				}
+				resp := verify(*created.Credential)
+				assert.True(ttt, resp.Verified, resp.Reason)
+
+				derive := func(request router.DeriveCredentialRequest) *httptest.ResponseRecorder {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, request)
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials/derivation", requestValue))
+					credRouter.DeriveCredential(c)
+					return w
+				}
+				w = derive(router.DeriveCredentialRequest{Credential: created.Credential, Reveal: []string{"firstName"}, Nonce: "challenge"})
+				require.Equal(ttt, http.StatusOK, w.Code, w.Body.String())
+				var derived router.DeriveCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&derived))
+				assert.True(ttt, keyaccess.IsBbsBlsSignatureProof2020(derived.Credential.Proof))
+				assert.Equal(ttt, "Jack", derived.Credential.CredentialSubject["firstName"])
+				assert.NotContains(ttt, derived.Credential.CredentialSubject, "lastName")
+				resp = verify(derived.Credential)
+				assert.True(ttt, resp.Verified, resp.Reason)
+
+				// a derived credential can't be changed
+				tampered := derived.Credential
+				tampered.CredentialSubject = credsdk.CredentialSubject{"id": "did:abc:456", "firstName": "Jill"}
+				resp = verify(tampered)
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, resp.Reason, "invalid proof")
+
+				// nor can fields the subject doesn't have be revealed, or credentials without BBS+ signatures be derived
+				w = derive(router.DeriveCredentialRequest{Credential: created.Credential, Reveal: []string{"birthDate"}})
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+				assert.Contains(ttt, w.Body.String(), "credential subject has no field: birthDate")
+				w = derive(router.DeriveCredentialRequest{Credential: &derived.Credential, Reveal: []string{"firstName"}})
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+				assert.Contains(ttt, w.Body.String(), "not secured with a BbsBlsSignature2020 proof")
+			})
+
+			tt.Run("Test Issuing And Verifying SD-JWT Credentials", func(ttt *testing.T) {
+				db := test.ServiceStorage(ttt)
+				require.NotEmpty(ttt, db)
+
+				keyStoreService, _ := testKeyStoreService(ttt, db)
+				didService, _ := testDIDService(ttt, db, keyStoreService, nil)
+				schemaService := testSchemaService(ttt, db, keyStoreService, didService)
+				credRouter := testCredentialRouter(ttt, db, keyStoreService, didService, schemaService)
+
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(ttt, err)
+				_, holderKey, err := crypto.GenerateP256Key()
+				require.NoError(ttt, err)
+				holderJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK("", holderKey)
+				require.NoError(ttt, err)
+				holder, err := keyaccess.NewJWKKeyAccess("did:abc:456", "did:abc:456#key-1", holderKey)
+				require.NoError(ttt, err)
+
+				w := httptest.NewRecorder()
+				requestValue := newRequestValue(ttt, router.CreateCredentialRequest{
+					Issuer:               issuerDID.DID.ID,
+					VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:              "did:abc:456",
+					Data:                 map[string]any{"firstName": "Jack", "lastName": "Dorsey"},
+					Format:               credential.FormatSDJWT,
+					HolderKey:            holderJWK,
+				})
+				c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+				credRouter.CreateCredential(c)
+				require.True(ttt, util.Is2xxResponse(w.Code), w.Body.String())
+				var created router.CreateCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&created))
+				assert.Nil(ttt, created.CredentialJWT)
+				require.NotNil(ttt, created.CredentialSDJWT)
+				assert.Equal(ttt, "Dorsey", created.Credential.CredentialSubject["lastName"])
+
+				// the SD-JWT is stored with the credential
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/credentials/"+created.ID, nil), map[string]string{"id": created.ID})
+				credRouter.GetCredential(c)
+				require.True(ttt, util.Is2xxResponse(w.Code), w.Body.String())
+				var got router.GetCredentialResponse
+				require.NoError(ttt, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(ttt, created.CredentialSDJWT, got.CredentialSDJWT)
+
+				verify := func(request router.VerifyCredentialRequest) router.VerifyCredentialResponse {
+					w := httptest.NewRecorder()
+					requestValue := newRequestValue(ttt, request)
+					c := newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials/verification", requestValue))
+					credRouter.VerifyCredential(c)
+					require.True(ttt, util.Is2xxResponse(w.Code), w.Body.String())
+					var resp router.VerifyCredentialResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					return resp
+				}
+
+				// the holder presents only the first name, bound to the verifier's nonce
+				presented, err := created.CredentialSDJWT.Present([]string{"firstName"}, holder, keyaccess.KeyBinding{Audience: "did:abc:verifier", Nonce: "challenge"})
+				require.NoError(ttt, err)
+				resp := verify(router.VerifyCredentialRequest{CredentialSDJWT: presented, Audience: "did:abc:verifier", Nonce: "challenge"})
+				assert.True(ttt, resp.Verified, resp.Reason)
+				assert.Contains(ttt, resp.Checks, credint.CheckResult{Check: credint.CheckKeyBinding, Passed: true})
+
+				resp = verify(router.VerifyCredentialRequest{CredentialSDJWT: presented, Nonce: "another challenge"})
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, resp.Reason, "isn't made with the nonce")
+
+				// a bound credential can't be presented without a key binding
+				unbound, err := created.CredentialSDJWT.Present([]string{"firstName"}, nil, keyaccess.KeyBinding{})
+				require.NoError(ttt, err)
+				resp = verify(router.VerifyCredentialRequest{CredentialSDJWT: unbound})
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, resp.Reason, "has no key binding JWT")
+
+				// nor with a disclosure its issuer didn't sign
+				parts := strings.Split(presented.String(), "~")
+				disclosure := base64.RawURLEncoding.EncodeToString([]byte(`["salt", "lastName", "Doe"]`))
+				tampered := keyaccess.SDJWTPtr(strings.Join(parts[:len(parts)-1], "~") + "~" + disclosure + "~" + parts[len(parts)-1])
+				resp = verify(router.VerifyCredentialRequest{CredentialSDJWT: tampered, Nonce: "challenge"})
+				assert.False(ttt, resp.Verified)
+				assert.Contains(ttt, resp.Reason, "doesn't have")
+
+				// holder keys are only for SD-JWT credentials
+				w = httptest.NewRecorder()
+				requestValue = newRequestValue(ttt, router.CreateCredentialRequest{
+					Issuer:               issuerDID.DID.ID,
+					VerificationMethodID: issuerDID.DID.VerificationMethod[0].ID,
+					Subject:              "did:abc:456",
+					Data:                 map[string]any{"firstName": "Jack"},
+					HolderKey:            holderJWK,
+				})
+				c = newRequestContext(w, httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/credentials", requestValue))
+				credRouter.CreateCredential(c)
+				assert.Equal(ttt, http.StatusBadRequest, w.Code)
+			})
+
 			tt.Run("Test Get Status List Credential", func(ttt *testing.T) {
 				db := test.ServiceStorage(ttt)
 				require.NotEmpty(ttt, db)
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestDebugAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, enableDebug bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Server.Admin.EnableDebug = enableDebug
+		})
+	}
+	debugPrefix := AdminPrefix + DebugPrefix
+
+	server := newServer(t, true)
+
+	t.Run("requires an admin credential", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/runtime", nil, middleware.APIKeyHeader, "")
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/pprof/heap", nil, middleware.APIKeyHeader, "")
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("reports runtime stats", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/runtime", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.GetRuntimeStatsResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.NotEmpty(tt, resp.GoVersion)
+		assert.Positive(tt, resp.Goroutines)
+		assert.Positive(tt, resp.Memory.SysBytes)
+	})
+
+	t.Run("reports storage stats", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/storage", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.GetStorageStatsResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(tt, storage.Bolt, resp.Type)
+		assert.True(tt, resp.Open)
+		assert.Positive(tt, resp.Stats["sizeBytes"])
+	})
+
+	t.Run("dumps goroutines", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/goroutines", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(tt, w.Body.String(), "goroutine ")
+	})
+
+	t.Run("serves pprof profiles", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/pprof/", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Body.String(), "heap")
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/pprof/heap", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.NotEmpty(tt, w.Body.Bytes())
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/pprof/goroutine?debug=1", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Body.String(), "goroutine profile")
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, debugPrefix+"/pprof/nonsense", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, debugPrefix+"/runtime", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
@@ -504,6 +504,37 @@ func TestDIDAPI(t *testing.T) {
 				err = json.NewDecoder(w.Body).Decode(&gotDeletedDIDsResponseAfterDelete)
 				assert.NoError(tt, err)
 				assert.Len(tt, gotDeletedDIDsResponseAfterDelete.DIDs, 1)
+
+				// restore it
+				restoreDIDPath := fmt.Sprintf("https://ssi-service.com/v1/dids/key/%s/restore", createdID)
+				req = httptest.NewRequest(http.MethodPut, restoreDIDPath, nil)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, goodParams)
+				didService.RestoreDIDByMethod(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var restoredResp router.RestoreDIDByMethodResponse
+				err = json.NewDecoder(w.Body).Decode(&restoredResp)
+				assert.NoError(tt, err)
+				assert.Equal(tt, createdID, restoredResp.DID.ID)
+
+				// it's listed again, and can't be restored twice
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/dids/key", requestReader)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, params)
+				didService.ListDIDsByMethod(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var gotDIDsResponseAfterRestore router.ListDIDsByMethodResponse
+				err = json.NewDecoder(w.Body).Decode(&gotDIDsResponseAfterRestore)
+				assert.NoError(tt, err)
+				assert.Len(tt, gotDIDsResponseAfterRestore.DIDs, 1)
+
+				req = httptest.NewRequest(http.MethodPut, restoreDIDPath, nil)
+				w = httptest.NewRecorder()
+				c = newRequestContextWithParams(w, req, goodParams)
+				didService.RestoreDIDByMethod(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
 			})
 
 			t.Run("List DIDs made up token fails", func(tt *testing.T) {
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
+)
+
+func TestDrain(t *testing.T) {
+	newServer := func(t *testing.T, configure ...func(*config.SSIServiceConfig)) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			for _, c := range configure {
+				c(cfg)
+			}
+		})
+	}
+
+	t.Run("waits for async operations", func(tt *testing.T) {
+		server := newServer(tt)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.PreferHeader, middleware.RespondAsync)
+		require.Equal(tt, http.StatusAccepted, w.Code)
+		var accepted router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&accepted))
+
+		require.NoError(tt, server.Drain(context.Background()))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/operations/"+accepted.ID, nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var op router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&op))
+		assert.True(tt, op.Done)
+		assert.Empty(tt, op.Result.Error)
+
+		// no more operations are started
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("waits for webhook deliveries", func(tt *testing.T) {
+		var delivered atomic.Bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			delivered.Store(true)
+		}))
+		defer receiver.Close()
+
+		server := newServer(tt)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/webhooks", router.CreateWebhookRequest{Noun: webhook.DID, Verb: webhook.Create, URL: receiver.URL})
+		require.Equal(tt, http.StatusCreated, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code)
+
+		require.NoError(tt, server.Drain(context.Background()))
+		assert.True(tt, delivered.Load())
+	})
+
+	t.Run("drains services before the services their work is handed to", func(tt *testing.T) {
+		server := newServer(tt, func(cfg *config.SSIServiceConfig) { cfg.Services.AnomalyConfig.Enabled = true })
+		assert.Equal(tt, []svcframework.Type{svcframework.Operation, svcframework.Anomaly, svcframework.Webhook}, server.ShutdownOrder())
+
+		server = newServer(tt)
+		assert.Equal(tt, []svcframework.Type{svcframework.Operation, svcframework.Webhook}, server.ShutdownOrder())
+	})
+
+	t.Run("runs the shutdown hooks of components in order", func(tt *testing.T) {
+		server := newServer(tt)
+		var ran []svcframework.Type
+		server.RegisterShutdownHook(svcframework.Backup, func(ctx context.Context) error {
+			ran = append(ran, svcframework.Backup)
+			return errors.New("upload failed")
+		})
+		server.RegisterShutdownHook(svcframework.Anchor, func(ctx context.Context) error {
+			ran = append(ran, svcframework.Anchor)
+			return nil
+		})
+
+		err := server.Drain(context.Background())
+		assert.ErrorContains(tt, err, "shutting down backup: upload failed")
+		assert.Equal(tt, []svcframework.Type{svcframework.Backup, svcframework.Anchor}, ran)
+
+		// storage stays open until it's closed
+		assert.True(tt, server.GetStorage().IsOpen())
+		require.NoError(tt, server.CloseStorage())
+		assert.False(tt, server.GetStorage().IsOpen())
+	})
+}
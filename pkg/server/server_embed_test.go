@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// countingEncrypter encrypts with a fixed key, counting how many times it's used.
+type countingEncrypter struct {
+	*encryption.XChaCha20Poly1305Encrypter
+	uses atomic.Int32
+}
+
+func (e *countingEncrypter) Encrypt(ctx context.Context, plaintext, contextData []byte) ([]byte, error) {
+	e.uses.Add(1)
+	return e.XChaCha20Poly1305Encrypter.Encrypt(ctx, plaintext, contextData)
+}
+
+func TestEmbeddedServer(t *testing.T) {
+	newServer := func(t *testing.T, opts ...Option) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			// the storage is provided by the embedding program
+			cfg.Services.StorageProvider = "provided"
+		}, opts...)
+	}
+	newStorage := func(t *testing.T) storage.ServiceStorage {
+		s, err := storage.NewStorage(storage.Bolt, storage.Option{ID: storage.BoltDBFilePathOption, Option: tempBoltFileName(t)})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("requires storage when the configured provider isn't available", func(tt *testing.T) {
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(tt, err)
+		serviceConfig.Services.StorageProvider = "provided"
+		_, err = NewSSIServer(make(chan os.Signal, 1), *serviceConfig)
+		assert.Error(tt, err)
+	})
+
+	t.Run("uses the provided storage and key encryption", func(tt *testing.T) {
+		s := newStorage(tt)
+		encrypter := &countingEncrypter{XChaCha20Poly1305Encrypter: encryption.NewXChaCha20Poly1305EncrypterWithKey(make([]byte, 32))}
+		server := newServer(tt, WithServiceOptions(service.WithStorage(s), service.WithKeyEncryption(encrypter, encrypter.XChaCha20Poly1305Encrypter)))
+
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		assert.Positive(tt, encrypter.uses.Load())
+		var created router.CreateDIDByMethodResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&created))
+
+		// another server with the same storage sees the DID
+		other := newServer(tt, WithServiceOptions(service.WithStorage(s)))
+		w = doTestRequest(tt, other.Handler, http.MethodGet, "/v1/dids/key/"+created.DID.ID, nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+	})
+
+	t.Run("runs custom middleware and routes", func(tt *testing.T) {
+		server := newServer(tt,
+			WithServiceOptions(service.WithStorage(newStorage(tt))),
+			WithMiddleware(func(c *gin.Context) {
+				c.Header("X-Embedded", "true")
+				c.Next()
+			}),
+			WithRoutes(func(engine *gin.Engine) error {
+				engine.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+				return nil
+			}),
+			WithAPIRoutes(func(api *gin.RouterGroup) error {
+				api.GET("/greetings", func(c *gin.Context) { c.String(http.StatusOK, "greetings from "+c.FullPath()) })
+				return nil
+			}),
+		)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/hello", nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "hello", w.Body.String())
+		assert.Equal(tt, "true", w.Header().Get("X-Embedded"))
+
+		for _, version := range APIVersions {
+			w = doTestRequest(tt, server.Handler, http.MethodGet, version+"/greetings", nil)
+			assert.Equal(tt, http.StatusOK, w.Code)
+			assert.Equal(tt, "greetings from "+version+"/greetings", w.Body.String())
+		}
+
+		// the service's own routes run the middleware too
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "true", w.Header().Get("X-Embedded"))
+	})
+
+	t.Run("runs custom API middleware after authentication", func(tt *testing.T) {
+		server := newTestServer(tt, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.StorageProvider = "provided"
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey("secret")
+			cfg.Server.EnableAPIKeyAuth = true
+		},
+			WithServiceOptions(service.WithStorage(newStorage(tt))),
+			WithAPIMiddleware(func(c *gin.Context) {
+				c.Header("X-Caller", middleware.GetPrincipal(c).ID)
+				c.Next()
+			}),
+		)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil, middleware.APIKeyHeader, "secret")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, auth.BootstrapAdminKeyID, w.Header().Get("X-Caller"))
+
+		// unauthenticated requests don't reach it
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Empty(tt, w.Header().Get("X-Caller"))
+	})
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	manifestsdk "github.com/TBD54566975/ssi-sdk/credential/manifest"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	prestorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
+)
+
+func TestErasureAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+	})
+
+	subject, otherSubject := "did:example:alice", "did:example:bob"
+
+	// credentials are issued to the subject and someone else
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.APIKeyHeader, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	issue := func(subject string) string {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              subject,
+			Data:                 map[string]any{"firstName": "Jack"},
+			Revocable:            true,
+		}, middleware.APIKeyHeader, "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateCredentialResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		return created.ID
+	}
+	subjectCredentialID := issue(subject)
+	otherCredentialID := issue(otherSubject)
+
+	// and both apply for credentials, and submit presentations
+	ctx := context.Background()
+	db := server.SSIService.GetStorage()
+	manifestStorage, err := manifeststg.NewManifestStorage(db)
+	require.NoError(t, err)
+	presentationStorage, err := presentation.NewPresentationStorage(db)
+	require.NoError(t, err)
+	opsStorage, err := operation.NewOperationStorage(db)
+	require.NoError(t, err)
+	apply := func(applicant string) (applicationID, responseID string) {
+		applicationID, responseID = uuid.NewString(), uuid.NewString()
+		require.NoError(t, manifestStorage.StoreApplication(ctx, manifeststg.StoredApplication{
+			ID:           applicationID,
+			ApplicantDID: applicant,
+			Application:  manifestsdk.CredentialApplication{ID: applicationID},
+		}))
+		require.NoError(t, manifestStorage.StoreResponse(ctx, manifeststg.StoredResponse{
+			ID:           responseID,
+			ApplicantDID: applicant,
+			Response:     manifestsdk.CredentialResponse{ID: responseID, ApplicationID: applicationID},
+		}))
+		require.NoError(t, opsStorage.StoreOperation(ctx, opstorage.StoredOperation{ID: opcredential.IDFromResponseID(applicationID), Done: true}))
+		return applicationID, responseID
+	}
+	submit := func(holder string) string {
+		submissionID := uuid.NewString()
+		require.NoError(t, presentationStorage.StoreSubmission(ctx, prestorage.StoredSubmission{
+			Status: submission.StatusPending,
+			VerifiablePresentation: credsdk.VerifiablePresentation{
+				Holder:                 holder,
+				PresentationSubmission: exchange.PresentationSubmission{ID: submissionID},
+			},
+		}))
+		require.NoError(t, opsStorage.StoreOperation(ctx, opstorage.StoredOperation{ID: submission.IDFromSubmissionID(submissionID)}))
+		return submissionID
+	}
+	applicationID, responseID := apply(subject)
+	otherApplicationID, _ := apply(otherSubject)
+	submissionID := submit(subject)
+	otherSubmissionID := submit(otherSubject)
+
+	t.Run("a dry run reports what would be erased, and erases nothing", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix+"?dryRun=maybe", router.EraseSubjectRequest{Subject: subject}, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix+"?dryRun=true", router.EraseSubjectRequest{Subject: subject}, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var dryRun router.ErasureReportResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&dryRun))
+		assert.True(tt, dryRun.Report.DryRun)
+		assert.Equal(tt, []string{subjectCredentialID}, dryRun.Report.Credentials)
+		assert.Equal(tt, []string{applicationID}, dryRun.Report.Applications)
+		assert.Equal(tt, []string{responseID}, dryRun.Report.Responses)
+		assert.Equal(tt, []string{submissionID}, dryRun.Report.Submissions)
+		assert.ElementsMatch(tt, []string{opcredential.IDFromResponseID(applicationID), submission.IDFromSubmissionID(submissionID)}, dryRun.Report.Operations)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials/"+subjectCredentialID, nil, middleware.APIKeyHeader, "")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		_, err := manifestStorage.GetApplication(ctx, applicationID)
+		assert.NoError(tt, err)
+		_, err = presentationStorage.GetSubmission(ctx, submissionID)
+		assert.NoError(tt, err)
+		_, err = opsStorage.GetOperation(ctx, submission.IDFromSubmissionID(submissionID))
+		assert.NoError(tt, err)
+
+		// and its report isn't stored
+		w = doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+ErasuresPrefix+"/"+dryRun.Report.ID, nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	var report router.ErasureReportResponse
+	t.Run("erases the data of the subject", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix, router.EraseSubjectRequest{Subject: subject}, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&report))
+
+		assert.NotEmpty(tt, report.Report.ID)
+		assert.Equal(tt, subject, report.Report.Subject)
+		assert.Equal(tt, []string{subjectCredentialID}, report.Report.Credentials)
+		assert.Equal(tt, []string{applicationID}, report.Report.Applications)
+		assert.Equal(tt, []string{responseID}, report.Report.Responses)
+		assert.Equal(tt, []string{submissionID}, report.Report.Submissions)
+		assert.ElementsMatch(tt, []string{opcredential.IDFromResponseID(applicationID), submission.IDFromSubmissionID(submissionID)}, report.Report.Operations)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials/"+subjectCredentialID, nil, middleware.APIKeyHeader, "")
+		assert.NotEqual(tt, http.StatusOK, w.Code)
+		_, err := manifestStorage.GetApplication(ctx, applicationID)
+		assert.Error(tt, err)
+		_, err = manifestStorage.GetResponse(ctx, responseID)
+		assert.Error(tt, err)
+		_, err = presentationStorage.GetSubmission(ctx, submissionID)
+		assert.Error(tt, err)
+		_, err = opsStorage.GetOperation(ctx, submission.IDFromSubmissionID(submissionID))
+		assert.Error(tt, err)
+	})
+
+	t.Run("keeps the data of others", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials/"+otherCredentialID, nil, middleware.APIKeyHeader, "")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		_, err := manifestStorage.GetApplication(ctx, otherApplicationID)
+		assert.NoError(tt, err)
+		_, err = presentationStorage.GetSubmission(ctx, otherSubmissionID)
+		assert.NoError(tt, err)
+		_, err = opsStorage.GetOperation(ctx, opcredential.IDFromResponseID(otherApplicationID))
+		assert.NoError(tt, err)
+	})
+
+	t.Run("erasing again finds nothing left", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix, router.EraseSubjectRequest{Subject: subject}, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var again router.ErasureReportResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&again))
+		assert.NotEqual(tt, report.Report.ID, again.Report.ID)
+		assert.Empty(tt, again.Report.Credentials)
+		assert.Empty(tt, again.Report.Applications)
+		assert.Empty(tt, again.Report.Submissions)
+	})
+
+	t.Run("keeps reports of erasures", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+ErasuresPrefix+"/"+report.Report.ID, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var got router.ErasureReportResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(tt, report.Report.Credentials, got.Report.Credentials)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+ErasuresPrefix, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var list router.ListErasuresResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&list))
+		assert.Len(tt, list.Reports, 2)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+ErasuresPrefix+"/missing", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("requires a subject and an admin", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix, router.EraseSubjectRequest{}, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodPut, AdminPrefix+ErasuresPrefix, router.EraseSubjectRequest{Subject: subject}, middleware.APIKeyHeader, "")
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestPreconditions(t *testing.T) {
+	newServer := func(t *testing.T, requireIfMatch bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Server.RequireIfMatch = requireIfMatch
+		})
+	}
+	createSchema := func(t *testing.T, server *SSIServer) string {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/schemas", router.CreateSchemaRequest{Name: "test schema", Schema: getTestSchema()})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var resp router.CreateSchemaResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return "/v1/schemas/" + resp.ID
+	}
+
+	t.Run("tags responses with etags", func(tt *testing.T) {
+		server := newServer(tt, false)
+		path := createSchema(tt, server)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, path, nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		etag := w.Header().Get(middleware.ETagHeader)
+		assert.Regexp(tt, `^"[A-Za-z0-9_-]+"$`, etag)
+		assert.NotEmpty(tt, w.Body.String())
+
+		// the same representation has the same etag
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil)
+		assert.Equal(tt, etag, w.Header().Get(middleware.ETagHeader))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfNoneMatchHeader, etag)
+		assert.Equal(tt, http.StatusNotModified, w.Code)
+		assert.Empty(tt, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, path, nil, middleware.IfNoneMatchHeader, `"other"`)
+		assert.Equal(tt, http.StatusOK, w.Code)
+
+		// errors aren't tagged
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas/missing", nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+		assert.Empty(tt, w.Header().Get(middleware.ETagHeader))
+	})
+
+	t.Run("only changes resources that match If-Match", func(tt *testing.T) {
+		server := newServer(tt, false)
+		path := createSchema(tt, server)
+		etag := doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader)
+
+		w := doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, `"stale"`)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(tt, http.StatusOK, doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Code)
+
+		// weak etags never match
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, "W/"+etag)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, `"stale", `+etag)
+		assert.Equal(tt, http.StatusNoContent, w.Code)
+
+		// the resource is gone, so nothing matches
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, "*")
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("guards new versions of schemas", func(tt *testing.T) {
+		server := newServer(tt, false)
+		path := createSchema(tt, server)
+		etag := doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader)
+		require.NotEmpty(tt, etag)
+		newVersion := router.CreateSchemaRequest{Name: "test schema", Schema: getTestSchema()}
+
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", newVersion, middleware.IfMatchHeader, `"stale"`)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", newVersion, middleware.IfMatchHeader, etag)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+		// the new version changed the schema, so the etag it was made with is stale
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", newVersion, middleware.IfMatchHeader, etag)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code, w.Body.String())
+
+		// a schema that doesn't exist yet matches nothing
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", router.CreateSchemaRequest{Name: "other schema", Schema: getTestSchema()}, middleware.IfMatchHeader, "*")
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code, w.Body.String())
+	})
+
+	t.Run("guards key rotation", func(tt *testing.T) {
+		server := newServer(tt, false)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code)
+		var resp router.CreateDIDByMethodResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		path := "/v1/keys/" + url.PathEscape(resp.DID.VerificationMethod[0].ID)
+		etag := doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader)
+		require.NotEmpty(tt, etag)
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, path+"/rotate", nil, middleware.IfMatchHeader, `"stale"`)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code, w.Body.String())
+		assert.Equal(tt, etag, doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader))
+	})
+
+	t.Run("guards dids", func(tt *testing.T) {
+		server := newServer(tt, false)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code)
+		var resp router.CreateDIDByMethodResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		path := "/v1/dids/key/" + resp.DID.ID
+		etag := doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader)
+		require.NotEmpty(tt, etag)
+
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, `"stale"`)
+		assert.Equal(tt, http.StatusPreconditionFailed, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, etag)
+		assert.Equal(tt, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("requires If-Match when configured", func(tt *testing.T) {
+		server := newServer(tt, true)
+		path := createSchema(tt, server)
+
+		// of new versions of a schema too, but not of new schemas
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", router.CreateSchemaRequest{Name: "test schema", Schema: getTestSchema()})
+		assert.Equal(tt, http.StatusPreconditionRequired, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil)
+		assert.Equal(tt, http.StatusPreconditionRequired, w.Code)
+
+		etag := doTestRequest(tt, server.Handler, http.MethodGet, path, nil).Header().Get(middleware.ETagHeader)
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, path, nil, middleware.IfMatchHeader, etag)
+		assert.Equal(tt, http.StatusNoContent, w.Code)
+	})
+}
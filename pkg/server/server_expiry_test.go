@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/expiry"
+)
+
+func TestExpiryAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	var warnings []expiry.Warning
+	alerts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var warning expiry.Warning
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&warning))
+		warnings = append(warnings, warning)
+	}))
+	defer alerts.Close()
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.ExpiryConfig = config.ExpiryServiceConfig{
+				Enabled:   enabled,
+				AlertURLs: []string{alerts.URL},
+			}
+		})
+	}
+	warn := func(server *SSIServer) expiry.Warning {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/admin/expiries/warnings", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreateWarningResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Warning
+	}
+
+	server := newServer(t, true)
+	expirationDate := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	stored := []byte(`{"LocalCredentialId":"cred-1","credential":{"expirationDate":"` + expirationDate + `"}}`)
+	require.NoError(t, server.SSIService.GetStorage().Write(context.Background(), "credential", "cred-1", stored))
+
+	w := doTestRequest(t, server.Handler, http.MethodGet, "/admin/expiries", nil, middleware.APIKeyHeader, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/expiries", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var expiries router.ListExpiriesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&expiries))
+	require.Len(t, expiries.Expiries, 1)
+	assert.Equal(t, expiry.KindCredential, expiries.Expiries[0].Kind)
+	assert.Equal(t, "cred-1", expiries.Expiries[0].ID)
+
+	warning := warn(server)
+	assert.Equal(t, expiries.Expiries, warning.Expiries)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warning.ID, warnings[0].ID)
+
+	// expiries that were warned of aren't warned of again
+	warning = warn(server)
+	assert.Empty(t, warning.Expiries)
+	assert.Len(t, warnings, 1)
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/expiries", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/faults"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestFaultInjection(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.Faults = config.FaultsConfig{
+			Enabled: true,
+			Faults: []config.FaultConfig{
+				{Target: faults.TargetStorage, Operations: []string{faults.OperationWrite}, Match: []string{"credential"}, Error: "disk full"},
+				{Target: faults.TargetResolver, Match: []string{"web"}, Error: "unreachable"},
+			},
+		}
+	})
+	// reloads start out from the config the server was created with
+	serviceConfig := server.cfg
+
+	// writes to other namespaces succeed
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	issue := func() *httptest.ResponseRecorder {
+		return doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              "did:example:alice",
+			Data:                 map[string]any{"firstName": "Jack"},
+		})
+	}
+
+	t.Run("injects faults into storage", func(tt *testing.T) {
+		w := issue()
+		assert.Equal(tt, http.StatusInternalServerError, w.Code)
+		assert.Contains(tt, w.Body.String(), "disk full")
+	})
+
+	t.Run("injects faults into did resolution", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids/resolver/did:web:example.com", nil)
+		assert.NotEqual(tt, http.StatusOK, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids/resolver/"+issuer.DID.ID, nil)
+		assert.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("faults reload", func(tt *testing.T) {
+		invalid := serviceConfig
+		invalid.Services.Faults.Faults = []config.FaultConfig{{Target: "network"}}
+		assert.Error(tt, server.Reload(invalid))
+		assert.Equal(tt, http.StatusInternalServerError, issue().Code)
+
+		recovered := serviceConfig
+		recovered.Services.Faults.Faults = nil
+		require.NoError(tt, server.Reload(recovered))
+		w := issue()
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
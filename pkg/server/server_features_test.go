@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/features"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	// the embedding program ships an experimental route, and changes an existing one behind another flag
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Server.EnableAPIKeyAuth = true
+		cfg.Server.Features = []config.FeatureConfig{
+			{Name: "greetings", Tenants: []string{"acme"}},
+			{Name: "graduated", Enabled: true},
+		}
+	},
+		WithFeatures(
+			features.Flag{Name: "greetings", Description: "Greets callers"},
+			features.Flag{Name: "loud", Description: "Greets callers loudly"},
+		),
+		WithAPIRoutes(func(api *gin.RouterGroup) error {
+			api.GET("/greeting", middleware.RequireFeature("greetings"), func(c *gin.Context) {
+				greeting := "hello"
+				if middleware.FeatureEnabled(c, "loud") {
+					greeting = "HELLO"
+				}
+				c.String(http.StatusOK, greeting)
+			})
+			return nil
+		}),
+	)
+	// reloads start out from the config the server was created with
+	serviceConfig := server.cfg
+
+	ctx := context.Background()
+	acme, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "acme", TenantID: "acme"})
+	require.NoError(t, err)
+	globex, err := server.Auth.CreateAPIKey(ctx, auth.CreateAPIKeyRequest{Name: "globex", TenantID: "globex"})
+	require.NoError(t, err)
+
+	t.Run("enables features for the configured tenants", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/greeting", nil, middleware.APIKeyHeader, acme.Key)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "hello", w.Body.String())
+
+		// as if the route didn't exist
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/greeting", nil, middleware.APIKeyHeader, globex.Key)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+		assert.Contains(tt, w.Body.String(), "no route matches GET /v1/greeting")
+	})
+
+	t.Run("lists features and whom they are enabled for", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+FeaturesPrefix, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.ListFeaturesResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		// unknown flags, like those of capabilities that graduated, are ignored
+		require.Len(tt, resp.Features, 2)
+		assert.Equal(tt, "greetings", resp.Features[0].Name)
+		assert.False(tt, resp.Features[0].Enabled)
+		assert.Equal(tt, []string{"acme"}, resp.Features[0].Tenants)
+		assert.Equal(tt, "loud", resp.Features[1].Name)
+	})
+
+	t.Run("features reload", func(tt *testing.T) {
+		updated := serviceConfig
+		updated.Server.Features = []config.FeatureConfig{{Name: "greetings", Enabled: true}, {Name: "loud", Enabled: true}}
+		require.NoError(tt, server.Reload(updated))
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/greeting", nil, middleware.APIKeyHeader, globex.Key)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "HELLO", w.Body.String())
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestResponseShaping(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var value map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&value))
+		return value
+	}
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+		Issuer:               issuer.DID.ID,
+		VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+		Subject:              "did:abc:456",
+		Data:                 map[string]any{"firstName": "Jack"},
+		Revocable:            true,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+
+	t.Run("limits responses to the requested fields", func(tt *testing.T) {
+		resp := decode(tt, doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?fields=credentials.id,credentials.credential.issuer", nil))
+		assert.Equal(tt, map[string]any{
+			"credentials": []any{
+				map[string]any{"id": created.ID, "credential": map[string]any{"issuer": issuer.DID.ID}},
+			},
+		}, resp)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids/key/"+issuer.DID.ID+"?fields=did.id", nil)
+		assert.Empty(tt, w.Header().Get(middleware.ETagHeader))
+		assert.Equal(tt, map[string]any{"did": map[string]any{"id": issuer.DID.ID}}, decode(tt, w))
+	})
+
+	t.Run("ignores fields that don't exist", func(tt *testing.T) {
+		resp := decode(tt, doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials/"+created.ID+"?fields=id,missing.field", nil))
+		assert.Equal(tt, map[string]any{"id": created.ID}, resp)
+	})
+
+	t.Run("rejects malformed fields", func(tt *testing.T) {
+		for _, fields := range []string{"", "id,,revoked", "credentials..id"} {
+			w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?fields="+fields, nil)
+			assert.Equal(tt, http.StatusBadRequest, w.Code, fields)
+		}
+	})
+
+	t.Run("lists credentials without their payloads", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?view=BASIC", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var resp router.ListCredentialsResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(tt, resp.Credentials, 1)
+		assert.Equal(tt, created.ID, resp.Credentials[0].ID)
+		assert.Nil(tt, resp.Credentials[0].Credential)
+		assert.Nil(tt, resp.Credentials[0].CredentialJWT)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?issuer="+issuer.DID.ID+"&view=full", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(tt, resp.Credentials, 1)
+		assert.NotNil(tt, resp.Credentials[0].CredentialJWT)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?view=tiny", nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestFixtures(t *testing.T) {
+	newConfig := func(t *testing.T, boltFile, fixturesFile string) config.SSIServiceConfig {
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: boltFile,
+			},
+		}
+		serviceConfig.Server.FixturesFile = fixturesFile
+		return *serviceConfig
+	}
+	writeFixtures := func(t *testing.T, contents string) string {
+		path := filepath.Join(t.TempDir(), "fixtures.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+	list := func(t *testing.T, server *SSIServer, path string, into any) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.NewDecoder(w.Body).Decode(into))
+	}
+
+	t.Run("creates the sample fixtures once", func(tt *testing.T) {
+		serviceConfig := newConfig(tt, tempBoltFileName(tt), filepath.Join("..", "..", "config", "fixtures.yaml"))
+		server, err := NewSSIServer(make(chan os.Signal, 1), serviceConfig)
+		require.NoError(tt, err)
+
+		var manifests router.ListManifestsResponse
+		list(tt, server, "/v1/manifests", &manifests)
+		require.Len(tt, manifests.Manifests, 1)
+		var credentials router.ListCredentialsResponse
+		list(tt, server, "/v1/credentials?subject=did:example:alice", &credentials)
+		require.Len(tt, credentials.Credentials, 1)
+		assert.Equal(tt, manifests.Manifests[0].Manifest.Issuer.ID, credentials.Credentials[0].Credential.IssuerID())
+		require.NoError(tt, server.SSIService.GetStorage().Close())
+
+		// starting again on the same storage creates nothing new
+		server, err = NewSSIServer(make(chan os.Signal, 1), serviceConfig)
+		require.NoError(tt, err)
+		list(tt, server, "/v1/manifests", &manifests)
+		assert.Len(tt, manifests.Manifests, 1)
+		list(tt, server, "/v1/credentials?subject=did:example:alice", &credentials)
+		assert.Len(tt, credentials.Credentials, 1)
+	})
+
+	t.Run("rejects invalid fixtures", func(tt *testing.T) {
+		for name, contents := range map[string]string{
+			"duplicate names":      "dids:\n  - {name: a, method: key, body: {keyType: Ed25519}}\n  - {name: a, method: key, body: {keyType: Ed25519}}\n",
+			"missing name":         "dids:\n  - {method: key, body: {keyType: Ed25519}}\n",
+			"did without a method": "dids:\n  - {name: a, body: {keyType: Ed25519}}\n",
+			"unknown reference":    "credentials:\n  - {name: a, body: {issuer: \"${issuer:$.did.id}\"}}\n",
+			"failing request":      "dids:\n  - {name: a, method: key, body: {keyType: rot13}}\n",
+		} {
+			_, err := NewSSIServer(make(chan os.Signal, 1), newConfig(tt, tempBoltFileName(tt), writeFixtures(tt, contents)))
+			assert.ErrorContains(tt, err, "unable to load fixtures", name)
+		}
+	})
+
+	t.Run("refuses to load fixtures in prod", func(tt *testing.T) {
+		serviceConfig := newConfig(tt, tempBoltFileName(tt), writeFixtures(tt, "dids: []\n"))
+		serviceConfig.Server.Environment = config.EnvironmentProd
+		_, err := NewSSIServer(make(chan os.Signal, 1), serviceConfig)
+		assert.ErrorContains(tt, err, "prod environment")
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestIdempotency(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	createDID := router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}
+
+	t.Run("replays the response to retries", func(tt *testing.T) {
+		first := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "create-did")
+		assert.Equal(tt, http.StatusCreated, first.Code)
+		assert.Empty(tt, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+		retry := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "create-did")
+		assert.Equal(tt, http.StatusCreated, retry.Code)
+		assert.Equal(tt, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+		assert.Equal(tt, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+		assert.Equal(tt, first.Body.String(), retry.Body.String())
+	})
+
+	t.Run("rejects keys reused for other requests", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "reused")
+		assert.Equal(tt, http.StatusCreated, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.SECP256k1}, middleware.IdempotencyKeyHeader, "reused")
+		assert.Equal(tt, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("runs requests without a key every time", func(tt *testing.T) {
+		first := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "")
+		second := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "")
+		assert.Equal(tt, http.StatusCreated, first.Code)
+		assert.Equal(tt, http.StatusCreated, second.Code)
+		assert.NotEqual(tt, first.Body.String(), second.Body.String())
+	})
+
+	t.Run("lets requests that failed with a server error be retried", func(tt *testing.T) {
+		bad := router.CreateDIDByMethodRequest{KeyType: "bad"}
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", bad, middleware.IdempotencyKeyHeader, "failing")
+		assert.Equal(tt, http.StatusInternalServerError, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", bad, middleware.IdempotencyKeyHeader, "failing")
+		assert.Equal(tt, http.StatusInternalServerError, w.Code)
+		assert.Empty(tt, w.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("replays the operation of async requests", func(tt *testing.T) {
+		first := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "async", middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusAccepted, first.Code)
+
+		retry := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, "async", middleware.PreferHeader, middleware.RespondAsync)
+		assert.Equal(tt, http.StatusAccepted, retry.Code)
+		assert.Equal(tt, first.Body.String(), retry.Body.String())
+	})
+
+	t.Run("replays the response to retries that encode the request differently", func(tt *testing.T) {
+		doRawRequest := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/v1/dids/key", strings.NewReader(body))
+			req.Header.Set(middleware.IdempotencyKeyHeader, "encoded")
+			w := httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, req)
+			return w
+		}
+		first := doRawRequest(`{"keyType":"Ed25519","options":null}`)
+		assert.Equal(tt, http.StatusCreated, first.Code)
+
+		retry := doRawRequest("{\n  \"options\": null,\n  \"keyType\": \"Ed25519\"\n}\n")
+		assert.Equal(tt, http.StatusCreated, retry.Code)
+		assert.Equal(tt, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+		assert.Equal(tt, first.Body.String(), retry.Body.String())
+
+		w := doRawRequest(`{"keyType":"Ed25519","options":{}}`)
+		assert.Equal(tt, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("rejects keys that are too long", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", createDID, middleware.IdempotencyKeyHeader, strings.Repeat("a", 256))
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+}
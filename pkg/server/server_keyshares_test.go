@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+)
+
+func TestKeySharesAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Services.KeyStoreConfig.ServiceKeyShares = 3
+		cfg.Services.KeyStoreConfig.ServiceKeyThreshold = 2
+	})
+	getStatus := func() keystore.SealStatus {
+		w := doTestRequest(t, server.Handler, http.MethodGet, "/admin/keystore/seal", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var status keystore.SealStatus
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+		return status
+	}
+	storeKey := func(id string) *httptest.ResponseRecorder {
+		_, privKey, err := crypto.GenerateEd25519Key()
+		require.NoError(t, err)
+		return doTestRequest(t, server.Handler, http.MethodPut, "/v1/keys", router.StoreKeyRequest{ID: id, Type: crypto.Ed25519, Controller: "did:example:a", PrivateKeyBase58: base58.Encode(privKey)}, middleware.APIKeyHeader, adminKey)
+	}
+
+	// keys can't be stored until the shares of the service key are created
+	assert.Equal(t, keystore.SealStatus{Sealed: true, Shares: 3, Threshold: 2}, getStatus())
+	w := storeKey("key-1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+	var problem framework.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, framework.CodeKeyStoreSealed, problem.Code)
+
+	var publicKeysets []json.RawMessage
+	var decrypters []encryption.Decrypter
+	for i := 0; i < 3; i++ {
+		privateKeyset, publicKeyset, err := encryption.GenerateHybridKeyset()
+		require.NoError(t, err)
+		publicKeysets = append(publicKeysets, publicKeyset)
+		decrypter, err := encryption.NewHybridDecrypter(privateKeyset)
+		require.NoError(t, err)
+		decrypters = append(decrypters, decrypter)
+	}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/keystore/shares", router.CreateKeySharesRequest{PublicKeysets: publicKeysets[:1]}, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/keystore/shares", router.CreateKeySharesRequest{PublicKeysets: publicKeysets}, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateKeySharesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	require.Len(t, created.Shares, 3)
+	assert.False(t, created.Status.Sealed)
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/keystore/shares", router.CreateKeySharesRequest{PublicKeysets: publicKeysets}, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	w = storeKey("key-1")
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// once sealed, e.g. by a restart, operators unseal the keystore with their shares
+	server.KeyShares.Seal()
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/keys/key-1", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/keystore/unseal", router.UnsealKeyStoreRequest{Share: "not a share"}, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	for _, i := range []int{2, 0} {
+		share, err := decrypters[i].Decrypt(context.Background(), created.Shares[i], nil)
+		require.NoError(t, err)
+		w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/keystore/unseal", router.UnsealKeyStoreRequest{Share: string(share)}, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assert.Equal(t, keystore.SealStatus{Initialized: true, Shares: 3, Threshold: 2}, getStatus())
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/keys/key-1", nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
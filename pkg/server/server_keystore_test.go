@@ -1,12 +1,16 @@
 package server
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	"github.com/goccy/go-json"
 	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +18,7 @@ import (
 
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 	"github.com/tbd54566975/ssi-service/pkg/testutil"
 )
 
@@ -65,6 +70,56 @@ func TestKeyStoreAPI(t *testing.T) {
 				assert.True(tt, util.Is2xxResponse(w.Code))
 			})
 
+			t.Run("Test Store Imported Key", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				keyStoreRouter, _, _ := testKeyStore(tt, db)
+				storeKey := func(request router.StoreKeyRequest) *httptest.ResponseRecorder {
+					req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/keys", newRequestValue(tt, request))
+					w := httptest.NewRecorder()
+					keyStoreRouter.StoreKey(newRequestContext(w, req))
+					return w
+				}
+
+				// a JWK, whose type is read from the key
+				pubKey, privKey, err := crypto.GenerateKeyByKeyType(crypto.P256)
+				require.NoError(tt, err)
+				_, privKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK("", privKey)
+				require.NoError(tt, err)
+				w := storeKey(router.StoreKeyRequest{ID: "did:test:me#key-jwk", Controller: "did:test:me", PrivateKeyJWK: privKeyJWK})
+				require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+				w = httptest.NewRecorder()
+				getReq := httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/keys/did:test:me#key-jwk", nil)
+				keyStoreRouter.GetKeyDetails(newRequestContextWithParams(w, getReq, map[string]string{"id": "did:test:me#key-jwk"}))
+				require.True(tt, util.Is2xxResponse(w.Code))
+				var resp router.GetKeyDetailsResponse
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(tt, crypto.P256, resp.Type)
+				wantPubKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("", pubKey)
+				require.NoError(tt, err)
+				assert.Equal(tt, wantPubKeyJWK.X, resp.PublicKeyJWK.X)
+				assert.Equal(tt, wantPubKeyJWK.Y, resp.PublicKeyJWK.Y)
+
+				// PEM, which must be of the type given
+				_, edKey, err := crypto.GenerateKeyByKeyType(crypto.Ed25519)
+				require.NoError(tt, err)
+				der, err := x509.MarshalPKCS8PrivateKey(edKey)
+				require.NoError(tt, err)
+				privKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+				w = storeKey(router.StoreKeyRequest{ID: "did:test:me#key-pem", Type: crypto.P384, Controller: "did:test:me", PrivateKeyPEM: privKeyPEM})
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+				assert.Contains(tt, w.Body.String(), "key is of type Ed25519, not P-384")
+				w = storeKey(router.StoreKeyRequest{ID: "did:test:me#key-pem", Type: crypto.Ed25519, Controller: "did:test:me", PrivateKeyPEM: privKeyPEM})
+				assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+				// a single form of the key
+				w = storeKey(router.StoreKeyRequest{ID: "did:test:me#key-both", Controller: "did:test:me", PrivateKeyJWK: privKeyJWK, PrivateKeyPEM: privKeyPEM})
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+				assert.Contains(tt, w.Body.String(), "exactly one of")
+			})
+
 			t.Run("Test Get Key Details", func(tt *testing.T) {
 				db := test.ServiceStorage(tt)
 				require.NotEmpty(tt, db)
@@ -115,6 +170,77 @@ func TestKeyStoreAPI(t *testing.T) {
 				assert.NoError(tt, err)
 				assert.NotEmpty(tt, wantPubKey, gotPubKey)
 			})
+
+			t.Run("Test Get Key JWKs", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				keyStoreRouter, keyStoreService, _ := testKeyStore(tt, db)
+				ctx := context.Background()
+				storeKey := func(id, controller string, keyType crypto.KeyType) {
+					_, privKey, err := crypto.GenerateKeyByKeyType(keyType)
+					require.NoError(tt, err)
+					privKeyBytes, err := crypto.PrivKeyToBytes(privKey)
+					require.NoError(tt, err)
+					require.NoError(tt, keyStoreService.StoreKey(ctx, keystore.StoreKeyRequest{
+						ID:               id,
+						Type:             keyType,
+						Controller:       controller,
+						PrivateKeyBase58: base58.Encode(privKeyBytes),
+					}))
+				}
+				storeKey("did:web:example.com#key-1", "did:web:example.com", crypto.P256)
+				storeKey("did:web:example.com#key-2", "did:web:example.com", crypto.Ed25519)
+				storeKey("did:web:example.com#key-3", "did:web:example.com", crypto.Ed25519)
+				storeKey("did:test:other#key-1", "did:test:other", crypto.Ed25519)
+				_, err := keyStoreService.RotateKey(ctx, keystore.RotateKeyRequest{ID: "did:web:example.com#key-2"})
+				require.NoError(tt, err)
+				require.NoError(tt, keyStoreService.RevokeKey(ctx, keystore.RevokeKeyRequest{ID: "did:web:example.com#key-3"}))
+
+				// a single key, with the algorithm it signs with
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/keys/did:web:example.com#key-1/jwk", nil)
+				keyStoreRouter.GetKeyJWK(newRequestContextWithParams(w, req, map[string]string{"id": "did:web:example.com#key-1"}))
+				require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+				var publicJWK jwx.PublicKeyJWK
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&publicJWK))
+				assert.Equal(tt, "did:web:example.com#key-1", publicJWK.KID)
+				assert.Equal(tt, "ES256", publicJWK.ALG)
+
+				// revoked keys aren't served
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/keys/did:web:example.com#key-3/jwk", nil)
+				keyStoreRouter.GetKeyJWK(newRequestContextWithParams(w, req, map[string]string{"id": "did:web:example.com#key-3"}))
+				assert.Equal(tt, http.StatusNotFound, w.Code)
+
+				getJWKS := func(target string) []jwx.PublicKeyJWK {
+					w := httptest.NewRecorder()
+					keyStoreRouter.GetJWKS(newRequestContext(w, httptest.NewRequest(http.MethodGet, target, nil)))
+					require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+					var resp router.GetJWKSResponse
+					require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+					return resp.Keys
+				}
+				kids := func(keys []jwx.PublicKeyJWK) []string {
+					var ids []string
+					for _, key := range keys {
+						ids = append(ids, key.KID)
+					}
+					return ids
+				}
+
+				// the keys of the did:web of the host, including rotated keys, but not revoked ones
+				keys := getJWKS("https://example.com/.well-known/jwks.json")
+				assert.ElementsMatch(tt, []string{"did:web:example.com#key-1", "did:web:example.com#key-2", "did:web:example.com#key-2-v2"}, kids(keys))
+				for _, key := range keys {
+					assert.NotEmpty(tt, key.ALG)
+				}
+
+				keys = getJWKS("https://ssi-service.com/.well-known/jwks.json?did=did:test:other")
+				assert.Equal(tt, []string{"did:test:other#key-1"}, kids(keys))
+				assert.Equal(tt, "EdDSA", keys[0].ALG)
+				assert.Empty(tt, getJWKS("https://ssi-service.com/.well-known/jwks.json?did=did:test:unknown"))
+			})
 		})
 	}
 }
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/logging"
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	engine := gin.New()
+	engine.Use(middleware.RequestID(), middleware.AccessLog(nil))
+	engine.GET("/v1/things/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, "thing")
+	})
+
+	doRequest := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(requestid.Header, "request-1")
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	doRequest("/v1/things/1")
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, logrus.InfoLevel, entry.Level)
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+	assert.Equal(t, "/v1/things/1", entry.Data["path"])
+	assert.Equal(t, "/v1/things/:id", entry.Data["route"])
+	assert.Contains(t, entry.Data, "latency_ms")
+	assert.Equal(t, "request-1", requestid.FromContext(entry.Context))
+
+	doRequest("/v1/things/missing")
+	entry = hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, logrus.WarnLevel, entry.Level)
+	assert.Equal(t, http.StatusNotFound, entry.Data["status"])
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	sampler := logging.NewSampler(config.LogSamplingConfig{
+		First:      1,
+		Thereafter: 10,
+		Routes:     []config.RouteLogSamplingConfig{{Method: http.MethodGet, Path: "/v1/things/:id"}},
+	})
+	engine := gin.New()
+	engine.Use(middleware.RequestID(), middleware.AccessLog(sampler))
+	engine.GET("/v1/things/:id", func(c *gin.Context) {
+		logrus.WithContext(c).Info("getting thing")
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, "thing")
+	})
+
+	accessLogs := func() int {
+		count := 0
+		for _, entry := range hook.AllEntries() {
+			if entry.Message == "request handled" {
+				count++
+			}
+		}
+		return count
+	}
+
+	for i := 0; i < 5; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/things/1", nil))
+	}
+	assert.Equal(t, 1, accessLogs())
+
+	// what's logged while handling requests carries their method and route
+	entry := hook.AllEntries()[0]
+	assert.Equal(t, "getting thing", entry.Message)
+	fields := logging.FieldsFromContext(entry.Context)
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, "/v1/things/:id", fields["route"])
+
+	// failed requests are always logged
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/things/missing", nil))
+	assert.Equal(t, 2, accessLogs())
+}
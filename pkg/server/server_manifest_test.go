@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -28,8 +29,10 @@ import (
 	"github.com/tbd54566975/ssi-service/pkg/service/did"
 	"github.com/tbd54566975/ssi-service/pkg/service/issuance"
 	manifestsvc "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
+	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
 	"github.com/tbd54566975/ssi-service/pkg/testutil"
 )
 
@@ -117,7 +120,7 @@ func TestManifestAPI(t *testing.T) {
 				didService, _ := testDIDService(tt, db, keyStoreService, nil)
 				schemaService := testSchemaService(tt, db, keyStoreService, didService)
 				credentialService := testCredentialService(tt, db, keyStoreService, didService, schemaService)
-				manifestRouter, _ := testManifest(tt, db, keyStoreService, didService, credentialService)
+				manifestRouter, manifestService := testManifest(tt, db, keyStoreService, didService, credentialService)
 
 				w := httptest.NewRecorder()
 
@@ -177,6 +180,29 @@ func TestManifestAPI(t *testing.T) {
 				assert.NoError(tt, err)
 				assert.NotEmpty(tt, getManifestResp)
 				assert.Equal(tt, resp.Manifest.ID, getManifestResp.ID)
+
+				// get the manifest signed by its issuer
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("https://ssi-service.com/v1/manifests/%s/request", resp.Manifest.ID), nil)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": resp.Manifest.ID})
+				manifestRouter.GetManifestRequestObject(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var signedResp router.GetManifestRequestObjectResponse
+				err = json.NewDecoder(w.Body).Decode(&signedResp)
+				assert.NoError(tt, err)
+				require.NotEmpty(tt, signedResp.ManifestJWT)
+
+				verified, err := manifestService.VerifyManifest(context.Background(), manifestsvc.VerifyManifestRequest{ManifestJWT: signedResp.ManifestJWT})
+				assert.NoError(tt, err)
+				assert.True(tt, verified.Verified, verified.Reason)
+
+				// a manifest that doesn't exist can't be signed
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/manifests/bad/request", nil)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": "bad"})
+				manifestRouter.GetManifestRequestObject(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
 			})
 
 			t.Run("Test Get Manifests", func(tt *testing.T) {
@@ -462,7 +488,7 @@ func TestManifestAPI(t *testing.T) {
 				}
 				assert.Equal(tt, expectedSubject, vc.CredentialSubject)
 				assert.Equal(tt, time.Date(2022, 10, 31, 0, 0, 0, 0, time.UTC).Format(time.RFC3339), vc.ExpirationDate)
-				assert.Equal(tt, licenseSchema.ID, vc.CredentialSchema.ID)
+				assert.Equal(tt, schema.VersionedID(licenseSchema.ID, licenseSchema.Version), vc.CredentialSchema.ID)
 				assert.Empty(tt, vc.CredentialStatus)
 
 				_, _, vc2, err := parsing.ToCredential(appResp.Credentials[1])
@@ -485,7 +511,7 @@ func TestManifestAPI(t *testing.T) {
 					time.Date(2022, 10, 31, 0, 0, 5, 0, time.UTC).Format(time.RFC3339),
 					vc2.ExpirationDate,
 				)
-				assert.Equal(tt, licenseSchema.ID, vc2.CredentialSchema.ID)
+				assert.Equal(tt, schema.VersionedID(licenseSchema.ID, licenseSchema.Version), vc2.CredentialSchema.ID)
 				assert.NotEmpty(tt, vc2.CredentialStatus)
 			})
 
@@ -595,6 +621,14 @@ func TestManifestAPI(t *testing.T) {
 				assert.False(tt, op.Done)
 				assert.Contains(tt, op.ID, "credentials/responses/")
 
+				// there's no response to the application until it's reviewed
+				applicationID := storage.StatusObjectID(op.ID)
+				pendingW := httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/response", nil)
+				c = newRequestContextWithParams(pendingW, req, map[string]string{"id": applicationID})
+				manifestRouter.GetApplicationResponse(c)
+				assert.Equal(tt, http.StatusNotFound, pendingW.Code)
+
 				// review application
 				expireAt := time.Date(2025, 10, 32, 0, 0, 0, 0, time.UTC)
 				reviewApplicationRequestValue := newRequestValue(tt, router.ReviewApplicationRequest{
@@ -623,7 +657,6 @@ func TestManifestAPI(t *testing.T) {
 						},
 					},
 				})
-				applicationID := storage.StatusObjectID(op.ID)
 				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/review", reviewApplicationRequestValue)
 				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
 				manifestRouter.ReviewApplication(c)
@@ -640,6 +673,22 @@ func TestManifestAPI(t *testing.T) {
 				assert.Len(tt, appResp.Credentials, 2)
 				assert.Empty(tt, appResp.Response.Denial)
 
+				// the signed fulfillment and its credentials are retrievable by the application's id
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/response", nil)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
+				manifestRouter.GetApplicationResponse(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var gotResp router.GetResponseResponse
+				err = json.NewDecoder(w.Body).Decode(&gotResp)
+				assert.NoError(tt, err)
+				assert.Equal(tt, appResp.Response.ID, gotResp.Response.ID)
+				assert.Equal(tt, applicationID, gotResp.Response.ApplicationID)
+				assert.Len(tt, gotResp.Response.Fulfillment.DescriptorMap, 2)
+				assert.Len(tt, gotResp.Credentials, 2)
+				assert.NotEmpty(tt, gotResp.ResponseJWT)
+
 				_, _, vc, err := parsing.ToCredential(appResp.Credentials[0])
 				assert.NoError(tt, err)
 				assert.Equal(tt, credsdk.CredentialSubject{
@@ -651,7 +700,7 @@ func TestManifestAPI(t *testing.T) {
 				}, vc.CredentialSubject)
 				assert.Equal(tt, expireAt.Format(time.RFC3339), vc.ExpirationDate)
 				assert.NotEmpty(tt, vc.CredentialStatus)
-				assert.Equal(tt, licenseSchema.ID, vc.CredentialSchema.ID)
+				assert.Equal(tt, schema.VersionedID(licenseSchema.ID, licenseSchema.Version), vc.CredentialSchema.ID)
 			})
 
 			t.Run("Test Denied Application", func(tt *testing.T) {
@@ -803,6 +852,187 @@ func TestManifestAPI(t *testing.T) {
 				assert.Equal(tt, appResp.Response.Denial.InputDescriptors[0], "license-type")
 			})
 
+			t.Run("Test Deny Application With Input Descriptor Reasons", func(tt *testing.T) {
+				db := test.ServiceStorage(tt)
+				require.NotEmpty(tt, db)
+
+				keyStoreService, _ := testKeyStoreService(tt, db)
+				didService, _ := testDIDService(tt, db, keyStoreService, nil)
+				schemaService := testSchemaService(tt, db, keyStoreService, didService)
+				credentialService := testCredentialService(tt, db, keyStoreService, didService, schemaService)
+				manifestRouter, manifestService := testManifest(tt, db, keyStoreService, didService, credentialService)
+
+				// denials are published to the Application Deny webhook
+				denials := make(chan []byte, 1)
+				webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					received, err := io.ReadAll(r.Body)
+					assert.NoError(tt, err)
+					denials <- received
+				}))
+				defer webhookServer.Close()
+				webhookService := testWebhookService(tt, db)
+				_, err := webhookService.CreateWebhook(context.Background(), webhook.CreateWebhookRequest{Noun: webhook.Application, Verb: webhook.Deny, URL: webhookServer.URL})
+				require.NoError(tt, err)
+				manifestService.SetWebhooks(webhookService)
+
+				// create an issuer
+				issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+					Method:  didsdk.KeyMethod,
+					KeyType: crypto.Ed25519,
+				})
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, issuerDID)
+
+				// create an applicant
+				applicantPrivKey, applicantDIDKey, err := key.GenerateDIDKey(crypto.Ed25519)
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, applicantPrivKey)
+				assert.NotEmpty(tt, applicantDIDKey)
+
+				applicantDID, err := applicantDIDKey.Expand()
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, applicantDID)
+
+				// create a schema for the creds to be issued against, needed for the application
+				kid := issuerDID.DID.VerificationMethod[0].ID
+				licenseApplicationSchema, err := schemaService.CreateSchema(
+					context.Background(),
+					schema.CreateSchemaRequest{Issuer: issuerDID.DID.ID, FullyQualifiedVerificationMethodID: kid, Name: "license application schema", Schema: getLicenseApplicationSchema()})
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, licenseApplicationSchema)
+
+				// create a second schema for the creds to be issued after the application is approved
+				licenseSchema, err := schemaService.CreateSchema(
+					context.Background(),
+					schema.CreateSchemaRequest{Issuer: issuerDID.DID.ID, FullyQualifiedVerificationMethodID: kid, Name: "license schema", Schema: getLicenseSchema()})
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, licenseSchema)
+
+				// issue a credential against the schema to the subject, from the issuer
+				createdCred, err := credentialService.CreateCredential(context.Background(), credential.CreateCredentialRequest{
+					Issuer:                             issuerDID.DID.ID,
+					FullyQualifiedVerificationMethodID: kid,
+					Subject:                            applicantDID.ID,
+					SchemaID:                           licenseApplicationSchema.ID,
+					Data:                               map[string]any{"licenseType": "Class D"},
+				})
+				assert.NoError(tt, err)
+				assert.NotEmpty(tt, createdCred)
+
+				// good request to create a manifest
+				createManifestRequest := getValidCreateManifestRequest(issuerDID.DID.ID, issuerDID.DID.VerificationMethod[0].ID, licenseSchema.ID)
+				w := httptest.NewRecorder()
+				requestValue := newRequestValue(tt, createManifestRequest)
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests", requestValue)
+				c := newRequestContext(w, req)
+				manifestRouter.CreateManifest(c)
+				assert.True(tt, util.Is2xxResponse(w.Code))
+
+				var resp router.CreateManifestResponse
+				err = json.NewDecoder(w.Body).Decode(&resp)
+				assert.NoError(tt, err)
+
+				m := resp.Manifest
+				assert.NotEmpty(tt, m)
+				assert.Equal(tt, m.Issuer.ID, issuerDID.DID.ID)
+
+				// good application request
+				container := []credmodel.Container{{CredentialJWT: createdCred.CredentialJWT}}
+				applicationRequest := getValidApplicationRequest(m.ID, m.PresentationDefinition.ID, m.PresentationDefinition.InputDescriptors[0].ID, container)
+
+				// sign application
+				signer, err := keyaccess.NewJWKKeyAccess(applicantDID.ID, applicantDID.VerificationMethod[0].ID, applicantPrivKey)
+				assert.NoError(tt, err)
+				signed, err := signer.SignJSON(applicationRequest)
+				assert.NoError(tt, err)
+
+				w = httptest.NewRecorder()
+
+				applicationRequestValue := newRequestValue(tt, router.SubmitApplicationRequest{ApplicationJWT: *signed})
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications", applicationRequestValue)
+				c = newRequestContext(w, req)
+				manifestRouter.SubmitApplication(c)
+				assert.NoError(tt, err)
+
+				var op router.Operation
+				err = json.NewDecoder(w.Body).Decode(&op)
+				assert.NoError(tt, err)
+
+				assert.False(tt, op.Done)
+				assert.Contains(tt, op.ID, "credentials/responses/")
+
+				// input descriptors that aren't in the manifest's presentation definition can't be denied
+				applicationID := storage.StatusObjectID(op.ID)
+				badReviewValue := newRequestValue(tt, router.ReviewApplicationRequest{
+					DeniedInputDescriptors: []manifeststg.DeniedInputDescriptor{{ID: "unknown", Code: manifeststg.DenialCodeExpired}},
+				})
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/review", badReviewValue)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
+				manifestRouter.ReviewApplication(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+				assert.Contains(tt, w.Body.String(), "has no input descriptor with id: unknown")
+
+				// nor denied along with an approval
+				badReviewValue = newRequestValue(tt, router.ReviewApplicationRequest{
+					Approved:               true,
+					DeniedInputDescriptors: []manifeststg.DeniedInputDescriptor{{ID: "license-type", Code: manifeststg.DenialCodeExpired}},
+				})
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/review", badReviewValue)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
+				manifestRouter.ReviewApplication(c)
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+
+				// deny the application for its license type, without a reason of its own
+				reviewValue := newRequestValue(tt, router.ReviewApplicationRequest{
+					DeniedInputDescriptors: []manifeststg.DeniedInputDescriptor{
+						{ID: "license-type", Code: manifeststg.DenialCodeExpired, Reason: "the license expired in 2020"},
+					},
+				})
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/review", reviewValue)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
+				manifestRouter.ReviewApplication(c)
+				require.True(tt, util.Is2xxResponse(w.Code), w.Body.String())
+
+				var reviewResp router.SubmitApplicationResponse
+				err = json.NewDecoder(w.Body).Decode(&reviewResp)
+				assert.NoError(tt, err)
+				require.NotEmpty(tt, reviewResp.Response.Denial)
+				assert.Empty(tt, reviewResp.Response.Fulfillment)
+				assert.Equal(tt, "denied input descriptors license-type: expired (the license expired in 2020)", reviewResp.Response.Denial.Reason)
+				assert.Equal(tt, []string{"license-type"}, reviewResp.Response.Denial.InputDescriptors)
+				assert.Equal(tt, []manifeststg.DeniedInputDescriptor{
+					{ID: "license-type", Code: manifeststg.DenialCodeExpired, Reason: "the license expired in 2020"},
+				}, reviewResp.DeniedInputDescriptors)
+
+				// the applicant fetches the denial by the application's id
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodGet, "https://ssi-service.com/v1/manifests/applications/"+applicationID+"/response", nil)
+				c = newRequestContextWithParams(w, req, map[string]string{"id": applicationID})
+				manifestRouter.GetApplicationResponse(c)
+				require.True(tt, util.Is2xxResponse(w.Code))
+
+				var gotResp router.GetResponseResponse
+				err = json.NewDecoder(w.Body).Decode(&gotResp)
+				assert.NoError(tt, err)
+				assert.Equal(tt, reviewResp.Response.Denial, gotResp.Response.Denial)
+				assert.Equal(tt, reviewResp.DeniedInputDescriptors, gotResp.DeniedInputDescriptors)
+				assert.NotEmpty(tt, gotResp.ResponseJWT)
+
+				select {
+				case received := <-denials:
+					var payload webhook.Payload
+					require.NoError(tt, json.Unmarshal(received, &payload))
+					assert.Equal(tt, webhook.Application, payload.Noun)
+					assert.Equal(tt, webhook.Deny, payload.Verb)
+					assert.Contains(tt, string(payload.Data), "the license expired in 2020")
+				case <-time.After(10 * time.Second):
+					tt.Fatal("denial was not published")
+				}
+			})
+
 			t.Run("Test Get Application By ID and Get Applications", func(tt *testing.T) {
 				db := test.ServiceStorage(tt)
 				require.NotEmpty(tt, db)
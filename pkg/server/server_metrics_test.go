@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+)
+
+func TestMetrics(t *testing.T) {
+	engine := gin.New()
+	engine.Use(middleware.Metrics())
+	engine.GET("/v1/widgets/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, "widget")
+	})
+	engine.GET(MetricsPath, gin.WrapH(metrics.Handler()))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	get("/v1/widgets/1")
+	get("/v1/widgets/2")
+	get("/v1/widgets/missing")
+	get("/v1/nothing/here")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/v1/widgets/:id", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/v1/widgets/:id", "404")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")))
+
+	w := get(MetricsPath)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `ssi_http_requests_total{code="200",method="GET",route="/v1/widgets/:id"} 2`)
+	assert.Contains(t, w.Body.String(), `ssi_http_request_duration_seconds_count{method="GET",route="/v1/widgets/:id"} 3`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
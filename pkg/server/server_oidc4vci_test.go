@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/TBD54566975/ssi-sdk/oidc/issuance"
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vci"
+	"github.com/tbd54566975/ssi-service/pkg/service/schema"
+)
+
+func TestOIDC4VCI(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.OIDC4VCIConfig.Enabled = true
+		cfg.Services.OIDC4VCIConfig.CredentialIssuer = "https://issuer.example.com"
+	})
+	ssi := server.SSIService
+
+	// a manifest whose credentials need no data
+	issuerDID, err := ssi.DID.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{Method: didsdk.KeyMethod, KeyType: crypto.Ed25519})
+	require.NoError(t, err)
+	kid := issuerDID.DID.VerificationMethod[0].ID
+	membershipSchema, err := ssi.Schema.CreateSchema(context.Background(), schema.CreateSchemaRequest{
+		Issuer:                             issuerDID.DID.ID,
+		FullyQualifiedVerificationMethodID: kid,
+		Name:                               "membership schema",
+		Schema: map[string]any{
+			"$schema":    "https://json-schema.org/draft-07/schema",
+			"type":       "object",
+			"properties": map[string]any{"credentialSubject": map[string]any{"type": "object"}},
+		},
+	})
+	require.NoError(t, err)
+	createdManifest, err := ssi.Manifest.CreateManifest(context.Background(), getValidCreateManifestRequest(issuerDID.DID.ID, kid, membershipSchema.ID).ToServiceRequest())
+	require.NoError(t, err)
+	m := createdManifest.Manifest
+	require.NotEmpty(t, m.OutputDescriptors)
+
+	holderKey, holderDIDKey, err := key.GenerateDIDKey(crypto.Ed25519)
+	require.NoError(t, err)
+	holderDID, err := holderDIDKey.Expand()
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	createOffer := func(tt *testing.T, request router.CreateOfferRequest) router.CreateOfferResponse {
+		w := serve(httptest.NewRequest(http.MethodPut, "/v1/oidc4vci/offers", newRequestValue(tt, request)))
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreateOfferResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+	redeem := func(code, pin string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {oidc4vci.PreAuthorizedCodeGrantType}, "pre-authorized_code": {code}}
+		if pin != "" {
+			form.Set("user_pin", pin)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oidc4vci/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+	proof := func(tt *testing.T, nonce string) string {
+		token, err := jwt.NewBuilder().Audience([]string{"https://issuer.example.com"}).IssuedAt(time.Now()).Claim("nonce", nonce).Build()
+		require.NoError(tt, err)
+		headers := jws.NewHeaders()
+		require.NoError(tt, headers.Set(jws.TypeKey, oidc4vci.ProofJWTType))
+		require.NoError(tt, headers.Set(jws.KeyIDKey, holderDID.VerificationMethod[0].ID))
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.EdDSA, holderKey, jws.WithProtectedHeaders(headers)))
+		require.NoError(tt, err)
+		return string(signed)
+	}
+	requestCredential := func(tt *testing.T, accessToken, proofJWT string) *httptest.ResponseRecorder {
+		body := map[string]any{
+			"format": oidc4vci.FormatJWTVCJSON,
+			"proof":  map[string]any{"proof_type": oidc4vci.ProofType, "jwt": proofJWT},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oidc4vci/credential", newRequestValue(tt, body))
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		return serve(req)
+	}
+
+	t.Run("serves the credentials of manifests in its metadata", func(tt *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/.well-known/openid-credential-issuer", nil))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var metadata issuance.IssuerMetadata
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&metadata))
+		assert.Equal(tt, "https://issuer.example.com", metadata.CredentialIssuer.String())
+		assert.Equal(tt, "https://issuer.example.com/oidc4vci/credential", metadata.CredentialEndpoint.String())
+		assert.Contains(tt, metadata.CredentialsSupported, oidc4vci.CredentialID(m.ID, m.OutputDescriptors[0].ID))
+
+		w = serve(httptest.NewRequest(http.MethodGet, "/.well-known/oauth-authorization-server", nil))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var serverMetadata oidc4vci.AuthorizationServerMetadata
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&serverMetadata))
+		assert.Equal(tt, "https://issuer.example.com/oidc4vci/token", serverMetadata.TokenEndpoint)
+	})
+
+	t.Run("issues the offered credential to the holder that proves possession", func(tt *testing.T) {
+		offer := createOffer(tt, router.CreateOfferRequest{ManifestID: m.ID, OutputDescriptorIDs: []string{m.OutputDescriptors[0].ID}})
+		assert.Equal(tt, []string{oidc4vci.CredentialID(m.ID, m.OutputDescriptors[0].ID)}, offer.CredentialOffer.Credentials)
+		assert.True(tt, strings.HasPrefix(offer.CredentialOfferURI, "openid-credential-offer://?credential_offer="))
+		code := offer.CredentialOffer.Grants[oidc4vci.PreAuthorizedCodeGrantType].PreAuthorizedCode
+		require.NotEmpty(tt, code)
+
+		w := redeem(code, "")
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var token oidc4vci.TokenResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&token))
+
+		// codes are only redeemed once
+		w = redeem(code, "")
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Contains(tt, w.Body.String(), oidc4vci.ErrorInvalidGrant)
+
+		// a proof over another nonce is rejected with the nonce to use instead
+		w = requestCredential(tt, token.AccessToken, proof(tt, "stale"))
+		require.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+		var proofErr oidc4vci.Error
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&proofErr))
+		assert.Equal(tt, oidc4vci.ErrorInvalidOrMissingProof, proofErr.Code)
+		require.NotEmpty(tt, proofErr.CNonce)
+
+		w = requestCredential(tt, token.AccessToken, proof(tt, proofErr.CNonce))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var credential oidc4vci.CredentialResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&credential))
+		assert.Equal(tt, oidc4vci.FormatJWTVCJSON, credential.Format)
+		credentialJWT, ok := credential.Credential.(string)
+		require.True(tt, ok)
+		parsed, err := jwt.Parse([]byte(credentialJWT), jwt.WithVerify(false), jwt.WithValidate(false))
+		require.NoError(tt, err)
+		assert.Equal(tt, holderDID.ID, parsed.Subject())
+		assert.Equal(tt, issuerDID.DID.ID, parsed.Issuer())
+
+		// every offered credential was issued
+		w = requestCredential(tt, token.AccessToken, proof(tt, credential.CNonce))
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = serve(httptest.NewRequest(http.MethodGet, "/v1/oidc4vci/offers/"+offer.Offer.ID, nil))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var gotOffer router.GetOfferResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&gotOffer))
+		assert.NotNil(tt, gotOffer.Offer.RedeemedAt)
+	})
+
+	t.Run("requires the user pin of offers that have one", func(tt *testing.T) {
+		offer := createOffer(tt, router.CreateOfferRequest{ManifestID: m.ID, UserPinRequired: true})
+		require.Len(tt, offer.UserPin, 6)
+		code := offer.CredentialOffer.Grants[oidc4vci.PreAuthorizedCodeGrantType].PreAuthorizedCode
+
+		wrongPin := "000000"
+		if offer.UserPin == wrongPin {
+			wrongPin = "111111"
+		}
+		w := redeem(code, wrongPin)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+		w = redeem(code, offer.UserPin)
+		assert.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("rejects unknown access tokens", func(tt *testing.T) {
+		w := requestCredential(tt, "unknown", proof(tt, "nonce"))
+		assert.Equal(tt, http.StatusUnauthorized, w.Code, w.Body.String())
+		assert.Contains(tt, w.Header().Get("WWW-Authenticate"), oidc4vci.ErrorInvalidToken)
+	})
+
+	t.Run("rejects offers of unknown manifests", func(tt *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodPut, "/v1/oidc4vci/offers", newRequestValue(tt, router.CreateOfferRequest{ManifestID: "unknown"})))
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
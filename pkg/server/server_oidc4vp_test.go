@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vp"
+)
+
+func TestOIDC4VP(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.OIDC4VPConfig.Enabled = true
+		cfg.Services.OIDC4VPConfig.BaseURL = "https://verifier.example.com"
+	})
+	clientID := "https://verifier.example.com/oidc4vp/response"
+
+	pRouter, err := router.NewPresentationRouter(server.SSIService.Presentation)
+	require.NoError(t, err)
+	definition := createPresentationDefinition(t, pRouter)
+
+	holderKey, holderDIDKey, err := key.GenerateDIDKey(crypto.Ed25519)
+	require.NoError(t, err)
+	holderDID, err := holderDIDKey.Expand()
+	require.NoError(t, err)
+	holderKID := holderDID.VerificationMethod[0].ID
+	issuerSigner, issuerDID := getSigner(t)
+	vc := VerifiableCredential()
+	vc.Issuer = issuerDID.String()
+	vcJWT, err := integrity.SignVerifiableCredentialJWT(issuerSigner, vc)
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	createRequest := func(tt *testing.T, idTokenRequired bool) router.CreateAuthorizationRequestResponse {
+		body := router.CreateAuthorizationRequestRequest{PresentationDefinitionID: definition.PresentationDefinition.ID, IDTokenRequired: idTokenRequired}
+		w := serve(httptest.NewRequest(http.MethodPut, "/v1/oidc4vp/requests", newRequestValue(tt, body)))
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreateAuthorizationRequestResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+	sign := func(tt *testing.T, claims map[string]any) string {
+		token := jwt.New()
+		for k, v := range claims {
+			require.NoError(tt, token.Set(k, v))
+		}
+		headers := jws.NewHeaders()
+		require.NoError(tt, headers.Set(jws.KeyIDKey, holderKID))
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.EdDSA, holderKey, jws.WithProtectedHeaders(headers)))
+		require.NoError(tt, err)
+		return string(signed)
+	}
+	// vpToken presents the credential, with a presentation submission that's posted apart from it
+	vpToken := func(tt *testing.T, nonce string) (string, string) {
+		submission := exchange.PresentationSubmission{
+			ID:            uuid.NewString(),
+			DefinitionID:  definition.PresentationDefinition.ID,
+			DescriptorMap: []exchange.SubmissionDescriptor{{ID: "wa_driver_license", Format: string(exchange.JWTVPTarget), Path: "$.verifiableCredential[0]"}},
+		}
+		submissionBytes, err := json.Marshal(submission)
+		require.NoError(tt, err)
+		vp := map[string]any{
+			"@context":             []string{credential.VerifiableCredentialsLinkedDataContext},
+			"type":                 []string{credential.VerifiablePresentationType},
+			"verifiableCredential": []string{string(vcJWT)},
+		}
+		return sign(tt, map[string]any{"iss": holderDID.ID, "aud": clientID, "nonce": nonce, "iat": time.Now().Unix(), "vp": vp}), string(submissionBytes)
+	}
+	respond := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/oidc4vp/response", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	t.Run("submits the presentation the wallet responds with for review", func(tt *testing.T) {
+		created := createRequest(tt, false)
+		authorizationRequest := created.AuthorizationRequest
+		assert.Equal(tt, oidc4vp.ResponseTypeVPToken, authorizationRequest.ResponseType)
+		assert.Equal(tt, clientID, authorizationRequest.ClientID)
+		assert.Equal(tt, clientID, authorizationRequest.ResponseURI)
+		assert.Equal(tt, definition.PresentationDefinition.ID, authorizationRequest.PresentationDefinition.ID)
+		requestURI, err := url.Parse(created.AuthorizationRequestURI)
+		require.NoError(tt, err)
+		assert.Equal(tt, "openid4vp", requestURI.Scheme)
+		assert.Equal(tt, authorizationRequest.State, requestURI.Query().Get("state"))
+
+		// a presentation over another nonce is rejected, and the request can still be responded to
+		token, submission := vpToken(tt, "stale")
+		w := respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {authorizationRequest.State}})
+		require.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(tt, w.Body.String(), oidc4vp.ErrorInvalidRequest)
+
+		token, submission = vpToken(tt, authorizationRequest.Nonce)
+		w = respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {authorizationRequest.State}})
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+
+		// requests are only responded to once
+		w = respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {authorizationRequest.State}})
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = serve(httptest.NewRequest(http.MethodGet, "/v1/oidc4vp/requests/"+created.Request.ID, nil))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var got router.GetAuthorizationRequestResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(tt, oidc4vp.StatusSubmitted, got.Request.Status)
+		assert.Equal(tt, holderDID.ID, got.Request.Holder)
+		require.NotEmpty(tt, got.Request.SubmissionID)
+
+		review := router.ReviewSubmissionRequest{Approved: true, Reason: "checked"}
+		w = serve(httptest.NewRequest(http.MethodPut, "/v1/presentations/submissions/"+got.Request.SubmissionID+"/review", newRequestValue(tt, review)))
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(tt, w.Body.String(), "approved")
+	})
+
+	t.Run("requires an id token of the holder when asked for one", func(tt *testing.T) {
+		created := createRequest(tt, true)
+		authorizationRequest := created.AuthorizationRequest
+		assert.Equal(tt, oidc4vp.ResponseTypeVPAndIDToken, authorizationRequest.ResponseType)
+		assert.Equal(tt, "openid", authorizationRequest.Scope)
+
+		token, submission := vpToken(tt, authorizationRequest.Nonce)
+		w := respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {authorizationRequest.State}})
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		idToken := sign(tt, map[string]any{
+			"iss":   holderDID.ID,
+			"sub":   holderDID.ID,
+			"aud":   clientID,
+			"nonce": authorizationRequest.Nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})
+		w = respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {authorizationRequest.State}, "id_token": {idToken}})
+		assert.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("rejects unknown states", func(tt *testing.T) {
+		token, submission := vpToken(tt, "nonce")
+		w := respond(url.Values{"vp_token": {token}, "presentation_submission": {submission}, "state": {"unknown"}})
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("rejects requests of unknown presentation definitions", func(tt *testing.T) {
+		body := router.CreateAuthorizationRequestRequest{PresentationDefinitionID: "unknown"}
+		w := serve(httptest.NewRequest(http.MethodPut, "/v1/oidc4vp/requests", newRequestValue(tt, body)))
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/openapi"
+)
+
+func TestOpenAPI(t *testing.T) {
+	_, publicKeyset, err := encryption.GenerateHybridKeyset()
+	require.NoError(t, err)
+	publicKeysetPath := filepath.Join(t.TempDir(), "public.json")
+	require.NoError(t, os.WriteFile(publicKeysetPath, publicKeyset, 0600))
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.Admin.EnableDebug = true
+		cfg.Services.TransparencyConfig.Enabled = true
+		cfg.Services.BackupConfig = config.BackupServiceConfig{
+			Enabled:          true,
+			Destination:      "file://" + filepath.Join(t.TempDir(), "backups"),
+			PublicKeysetPath: publicKeysetPath,
+		}
+		cfg.Services.AnomalyConfig.Enabled = true
+		cfg.Services.RetentionConfig.Enabled = true
+		cfg.Services.ApprovalConfig.Enabled = true
+		cfg.Services.AnchorConfig = config.AnchorServiceConfig{Enabled: true, Type: "rfc3161", URL: "https://tsa.example.com"}
+		cfg.Services.ExpiryConfig = config.ExpiryServiceConfig{Enabled: true}
+		cfg.Services.KeyStoreConfig.ServiceKeyShares = 3
+		cfg.Services.KeyStoreConfig.ServiceKeyThreshold = 2
+		cfg.Server.ValidateRequests = true
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("serves the specs and swagger ui", func(tt *testing.T) {
+		w := get(OpenAPIPath)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Header().Get("Content-Type"), "application/json")
+		assert.Equal(tt, w.Body.String(), get(SwaggerJSONPath).Body.String())
+
+		w = get(SwaggerYAMLPath)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Contains(tt, w.Body.String(), `swagger: "2.0"`)
+
+		w = get("/swagger/index.html")
+		assert.Equal(tt, http.StatusOK, w.Code)
+	})
+
+	t.Run("documents every route", func(tt *testing.T) {
+		w := get(OpenAPIPath)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var document struct {
+			OpenAPI string                    `json:"openapi"`
+			Paths   map[string]map[string]any `json:"paths"`
+		}
+		require.NoError(tt, json.Unmarshal(w.Body.Bytes(), &document))
+		assert.Equal(tt, openapi.Version, document.OpenAPI)
+
+		undocumented := map[string]bool{
+			OpenAPIPath:     true,
+			SwaggerJSONPath: true,
+			SwaggerYAMLPath: true,
+			SwaggerPrefix:   true,
+			MetricsPath:     true,
+		}
+		wildcard := regexp.MustCompile(`[:*]([^/]+)`)
+		for _, route := range server.Handler.(*gin.Engine).Routes() {
+			if undocumented[route.Path] {
+				continue
+			}
+			// later versions of the API serve the routes of v1 until they diverge from them
+			path := wildcard.ReplaceAllString(route.Path, "{$1}")
+			if strings.HasPrefix(path, V2Prefix+"/") {
+				if _, ok := document.Paths[path]; !ok {
+					path = V1Prefix + strings.TrimPrefix(path, V2Prefix)
+				}
+			}
+			operations, ok := document.Paths[path]
+			if assert.True(tt, ok, "route %s is not documented", path) {
+				assert.Contains(tt, operations, strings.ToLower(route.Method), "route %s %s is not documented", route.Method, path)
+			}
+		}
+	})
+
+	t.Run("validates requests against the spec", func(tt *testing.T) {
+		put := func(path, body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+			return w
+		}
+
+		for _, version := range APIVersions {
+			w := put(version+DIDsPrefix+"/key", `{"keyType":5}`)
+			require.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.Equal(tt, framework.ProblemContentType, w.Header().Get("Content-Type"))
+			var problem framework.ErrorResponse
+			require.NoError(tt, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(tt, framework.CodeValidationFailed, problem.Code)
+			require.Len(tt, problem.Errors, 1)
+			assert.Equal(tt, "keyType", problem.Errors[0].Field)
+		}
+
+		w := put(V1Prefix+DIDsPrefix+"/key", `{"keyType":`)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Contains(tt, w.Body.String(), framework.CodeMalformedRequest)
+
+		// valid bodies reach the handler, which can't store keys in this keystore until its shares are created
+		w = put(V1Prefix+DIDsPrefix+"/key", `{"keyType":"Ed25519"}`)
+		assert.Contains(tt, w.Body.String(), framework.CodeKeyStoreSealed)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
+	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/server/router"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation"
@@ -300,7 +301,7 @@ func reviewSubmission(t *testing.T, pRouter *router.PresentationRouter, submissi
 }
 
 func setupOperationsRouter(t *testing.T, s storage.ServiceStorage) *router.OperationRouter {
-	svc, err := operation.NewOperationService(s)
+	svc, err := operation.NewOperationService(config.OperationServiceConfig{}, s)
 	assert.NoError(t, err)
 	opRouter, err := router.NewOperationRouter(svc)
 	assert.NoError(t, err)
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestPagination(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	// listAll follows the page tokens of a list, returning the ids of every page and the total count of the first one.
+	listAll := func(t *testing.T, path, items string, pageSize int) ([][]string, int) {
+		var pages [][]string
+		total := -1
+		token := ""
+		for {
+			query := url.Values{pagination.PageSizeParam: []string{strconv.Itoa(pageSize)}}
+			if token != "" {
+				query.Set(pagination.PageTokenParam, token)
+			}
+			w := doTestRequest(t, server.Handler, http.MethodGet, path+"?"+query.Encode(), nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			if total < 0 {
+				var err error
+				total, err = strconv.Atoi(w.Header().Get(pagination.TotalCountHeader))
+				require.NoError(t, err)
+			}
+			var resp map[string]any
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			var ids []string
+			list, _ := resp[items].([]any)
+			for _, item := range list {
+				ids = append(ids, item.(map[string]any)["id"].(string))
+			}
+			pages = append(pages, ids)
+			token, _ = resp["nextPageToken"].(string)
+			if token == "" {
+				return pages, total
+			}
+		}
+	}
+
+	var schemaIDs []string
+	for i := 0; i < 5; i++ {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/schemas", router.CreateSchemaRequest{Name: fmt.Sprintf("schema %d", i), Schema: getTestSchema()})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateSchemaResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		schemaIDs = append(schemaIDs, created.ID)
+	}
+	var dids []router.CreateDIDByMethodResponse
+	for i := 0; i < 3; i++ {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateDIDByMethodResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		dids = append(dids, created)
+	}
+	issuer := dids[0].DID
+	for i := 0; i < 3; i++ {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.ID,
+			VerificationMethodID: issuer.VerificationMethod[0].ID,
+			Subject:              dids[i].DID.ID,
+			Data:                 map[string]any{"index": i},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/manifests", getValidCreateManifestRequest(issuer.ID, issuer.VerificationMethod[0].ID, schemaIDs[i]))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	t.Run("lists are paged in order of their ids", func(tt *testing.T) {
+		pages, total := listAll(tt, "/v1/schemas", "schemas", 2)
+		assert.Equal(tt, 5, total)
+		require.Len(tt, pages, 3)
+		assert.Len(tt, pages[0], 2)
+		assert.Len(tt, pages[1], 2)
+		assert.Len(tt, pages[2], 1)
+
+		var ids []string
+		for _, page := range pages {
+			ids = append(ids, page...)
+		}
+		assert.ElementsMatch(tt, schemaIDs, ids)
+		assert.IsIncreasing(tt, ids)
+	})
+
+	t.Run("lists read from storage are paged", func(tt *testing.T) {
+		for _, list := range []struct {
+			path  string
+			items string
+		}{
+			{path: "/v1/keys", items: "keys"},
+			{path: "/v1/credentials", items: "credentials"},
+			{path: "/v1/manifests", items: "manifests"},
+		} {
+			pages, total := listAll(tt, list.path, list.items, 2)
+			assert.Equal(tt, 3, total, list.path)
+			var ids []string
+			for _, page := range pages {
+				assert.LessOrEqual(tt, len(page), 2, list.path)
+				ids = append(ids, page...)
+			}
+			assert.Len(tt, ids, 3, list.path)
+		}
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids/key", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "3", w.Header().Get(pagination.TotalCountHeader))
+	})
+
+	t.Run("returns everything without a page size", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var resp router.ListSchemasResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(tt, resp.Schemas, 5)
+		assert.Empty(tt, resp.NextPageToken)
+		assert.Equal(tt, "5", w.Header().Get(pagination.TotalCountHeader))
+
+		// page sizes over the limit are lowered to it
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas?pageSize="+strconv.Itoa(pagination.MaxPageSize+1), nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(tt, resp.Schemas, 5)
+	})
+
+	t.Run("rejects bad page parameters", func(tt *testing.T) {
+		for _, query := range []string{"pageSize=0", "pageSize=two", "pageToken=nope"} {
+			w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?"+query, nil)
+			assert.Equal(tt, http.StatusBadRequest, w.Code, query)
+		}
+
+		// page tokens only work for the query they were made for
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/schemas?pageSize=1", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var resp router.ListSchemasResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		require.NotEmpty(tt, resp.NextPageToken)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/credentials?issuer=did:abc:123&pageToken="+resp.NextPageToken, nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+}
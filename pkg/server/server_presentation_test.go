@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential"
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
@@ -20,6 +21,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -33,6 +35,7 @@ import (
 	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
 	"github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	presentationstorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
@@ -217,6 +220,117 @@ func TestPresentationAPI(t *testing.T) {
 				})
 			})
 
+			t.Run("Validate reports the issues of a definition", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				pRouter, _ := setupPresentationRouter(tt, s)
+
+				definition := `{
+					"id": "definition-1",
+					"input_descriptors": [
+						{
+							"id": "license",
+							"group": ["A"],
+							"constraints": {
+								"fields": [
+									{"path": ["$.credentialSubject.age"], "filter": {"type": "number", "minimum": 21, "maximum": 18}},
+									{"path": ["credentialSubject[.name"], "filter": {"type": "string", "const": "Alice", "pattern": "^B"}},
+									{"path": ["$.credentialSubject.class"], "filter": {"type": "string", "enum": [1, 2]}, "optinal": true}
+								]
+							}
+						},
+						{"id": "license", "group": ["A"], "constraints": {}}
+					],
+					"submission_requirements": [
+						{"rule": "pick", "count": 3, "from": "A"},
+						{"rule": "all", "from": "B"}
+					]
+				}`
+				value := newRequestValue(tt, router.ValidatePresentationDefinitionRequest{PresentationDefinition: json.RawMessage(definition)})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/definitions/validation", value)
+				w := httptest.NewRecorder()
+				pRouter.ValidateDefinition(newRequestContext(w, req))
+				require.Equal(tt, http.StatusOK, w.Code)
+
+				var resp router.ValidatePresentationDefinitionResponse
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+				assert.False(tt, resp.Valid)
+				assert.Equal(tt, "definition-1", resp.PresentationDefinition.ID)
+				paths := make([]string, 0, len(resp.Issues))
+				for _, issue := range resp.Issues {
+					paths = append(paths, issue.Path)
+				}
+				assert.ElementsMatch(tt, []string{
+					"$.input_descriptors[0].constraints.fields[2].optinal",
+					"$.input_descriptors[0].constraints.fields[0].filter",
+					"$.input_descriptors[0].constraints.fields[1].path[0]",
+					"$.input_descriptors[0].constraints.fields[1].filter.const",
+					"$.input_descriptors[0].constraints.fields[2].filter.enum",
+					"$.input_descriptors[1].id",
+					"$.submission_requirements[0].count",
+					"$.submission_requirements[1].from",
+				}, paths)
+			})
+
+			t.Run("Validate and create expand requirements into a definition", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				pRouter, _ := setupPresentationRouter(tt, s)
+
+				requirements := []model.Requirement{{
+					ID:              "license",
+					Name:            "Driver's license",
+					Claims:          []model.ClaimRequirement{{Claim: "age", Type: "number", Minimum: 21}, {Claim: "address.country", Optional: true}},
+					LimitDisclosure: true,
+				}}
+				value := newRequestValue(tt, router.ValidatePresentationDefinitionRequest{Name: "bar", Requirements: requirements})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/definitions/validation", value)
+				w := httptest.NewRecorder()
+				pRouter.ValidateDefinition(newRequestContext(w, req))
+				require.Equal(tt, http.StatusOK, w.Code)
+
+				var resp router.ValidatePresentationDefinitionResponse
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+				assert.True(tt, resp.Valid)
+				assert.Empty(tt, resp.Issues)
+				assert.Equal(tt, "bar", resp.PresentationDefinition.Name)
+				require.Len(tt, resp.PresentationDefinition.InputDescriptors, 1)
+				descriptor := resp.PresentationDefinition.InputDescriptors[0]
+				assert.Equal(tt, "license", descriptor.ID)
+				assert.Equal(tt, exchange.Required, *descriptor.Constraints.LimitDisclosure)
+				require.Len(tt, descriptor.Constraints.Fields, 2)
+				assert.Equal(tt, []string{"$.credentialSubject.age", "$.vc.credentialSubject.age"}, descriptor.Constraints.Fields[0].Path)
+				assert.Equal(tt, "number", descriptor.Constraints.Fields[0].Filter.Type)
+				assert.Nil(tt, descriptor.Constraints.Fields[1].Filter)
+				assert.True(tt, descriptor.Constraints.Fields[1].Optional)
+
+				// the expanded definition can be created as is
+				created := createPresentationDefinition(tt, pRouter, WithInputDescriptors(resp.PresentationDefinition.InputDescriptors))
+				assert.Equal(tt, resp.PresentationDefinition.InputDescriptors, created.PresentationDefinition.InputDescriptors)
+
+				// unsatisfiable requirements are reported too
+				requirements[0].Claims[0].Maximum = 18
+				value = newRequestValue(tt, router.ValidatePresentationDefinitionRequest{Requirements: requirements})
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/definitions/validation", value)
+				w = httptest.NewRecorder()
+				pRouter.ValidateDefinition(newRequestContext(w, req))
+				require.Equal(tt, http.StatusOK, w.Code)
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+				assert.False(tt, resp.Valid)
+				require.Len(tt, resp.Issues, 1)
+				assert.Equal(tt, "$.input_descriptors[0].constraints.fields[0].filter", resp.Issues[0].Path)
+			})
+
+			t.Run("Validate requires either a definition or requirements", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				pRouter, _ := setupPresentationRouter(tt, s)
+
+				value := newRequestValue(tt, router.ValidatePresentationDefinitionRequest{})
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/definitions/validation", value)
+				w := httptest.NewRecorder()
+				pRouter.ValidateDefinition(newRequestContext(w, req))
+				assert.Equal(tt, http.StatusBadRequest, w.Code)
+				assert.Contains(tt, w.Body.String(), "either a presentation definition or requirements must be given")
+			})
+
 			t.Run("Create returns error without input descriptors", func(tt *testing.T) {
 				s := test.ServiceStorage(tt)
 				pRouter, _ := setupPresentationRouter(tt, s)
@@ -292,6 +406,65 @@ func TestPresentationAPI(t *testing.T) {
 					assert.Equal(ttt, opstorage.StatusObjectID(op.ID), resp.GetSubmission().ID)
 					assert.Equal(ttt, definition.PresentationDefinition.ID, resp.GetSubmission().DefinitionID)
 					assert.Equal(ttt, "pending", resp.Submission.Status)
+					require.NotNil(ttt, resp.Evaluation)
+					assert.True(ttt, resp.Evaluation.Satisfied)
+				})
+
+				tt.Run("Submissions are evaluated against the constraints of their definition", func(ttt *testing.T) {
+					s := test.ServiceStorage(ttt)
+					pRouter, didService := setupPresentationRouter(ttt, s)
+					authorDID := createDID(ttt, didService)
+
+					holderSigner, holderDID := getSigner(ttt)
+					definition := createPresentationDefinition(ttt, pRouter, WithInputDescriptors([]exchange.InputDescriptor{{
+						ID: "wa_driver_license",
+						Constraints: &exchange.Constraints{
+							Fields: []exchange.Field{
+								{ID: "date_of_birth", Path: []string{"$.credentialSubject.dateOfBirth", "$.vc.credentialSubject.dateOfBirth"}},
+								{ID: "family_name", Path: []string{"$.vc.credentialSubject.familyName"}, Filter: &exchange.Filter{Type: "string", Const: "Smith"}},
+								{ID: "over_21", Path: []string{"$.vc.credentialSubject.over21"}, Filter: &exchange.Filter{Type: "number", Minimum: 21}, Predicate: exchange.Required.Ptr()},
+								{ID: "middle_name", Path: []string{"$.vc.credentialSubject.middleName"}, Optional: true},
+							},
+							LimitDisclosure: exchange.Required.Ptr(),
+						},
+					}}))
+					op := createSubmission(ttt, pRouter, definition.PresentationDefinition.ID, authorDID.DID.ID, VerifiableCredential(
+						WithCredentialSubject(credential.CredentialSubject{
+							"dateOfBirth": "1987-01-02",
+							"familyName":  "Andres",
+							"givenName":   "Uribe",
+							"over21":      true,
+							"id":          "did:web:andresuribe.com",
+						})), holderDID, holderSigner)
+
+					req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("https://ssi-service.com/v1/presentations/submissions/%s", opstorage.StatusObjectID(op.ID)), nil)
+					w := httptest.NewRecorder()
+					pRouter.GetSubmission(newRequestContextWithParams(w, req, map[string]string{"id": opstorage.StatusObjectID(op.ID)}))
+					require.True(ttt, util.Is2xxResponse(w.Code))
+
+					var resp router.GetSubmissionResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&resp))
+					assert.Equal(ttt, "pending", resp.Submission.Status)
+					evaluation := resp.Evaluation
+					require.NotNil(ttt, evaluation)
+					assert.False(ttt, evaluation.Satisfied)
+					require.Len(ttt, evaluation.InputDescriptors, 1)
+					descriptor := evaluation.InputDescriptors[0]
+					assert.Equal(ttt, "wa_driver_license", descriptor.ID)
+					assert.False(ttt, descriptor.Satisfied)
+					require.Len(ttt, descriptor.Fields, 4)
+
+					assert.True(ttt, descriptor.Fields[0].Satisfied)
+					assert.Equal(ttt, "$.vc.credentialSubject.dateOfBirth", descriptor.Fields[0].Path)
+					assert.False(ttt, descriptor.Fields[1].Satisfied)
+					assert.Contains(ttt, descriptor.Fields[1].Reason, "the value does not satisfy its filter")
+					assert.True(ttt, descriptor.Fields[2].Satisfied, "the result of the predicate satisfies it")
+					assert.False(ttt, descriptor.Fields[3].Satisfied)
+					assert.True(ttt, descriptor.Fields[3].Optional)
+
+					require.NotNil(ttt, descriptor.LimitDisclosure)
+					assert.False(ttt, descriptor.LimitDisclosure.Satisfied)
+					assert.Equal(ttt, "discloses claims<givenName> that no field asks for", descriptor.LimitDisclosure.Reason)
 				})
 
 				tt.Run("Create well formed submission returns operation", func(ttt *testing.T) {
@@ -325,6 +498,72 @@ func TestPresentationAPI(t *testing.T) {
 					assert.Zero(ttt, resp.Result)
 				})
 
+				tt.Run("Submissions responding to a presentation request consume its nonce", func(ttt *testing.T) {
+					s := test.ServiceStorage(ttt)
+					pRouter, didService := setupPresentationRouter(ttt, s)
+					authorDID := createDID(ttt, didService)
+
+					holderSigner, holderDID := getSigner(ttt)
+					definition := createPresentationDefinition(ttt, pRouter)
+					presentationRequest := createPresentationRequest(ttt, pRouter, definition.PresentationDefinition.ID, authorDID.DID)
+					nonce := presentationRequest.Request.Nonce
+					require.NotEmpty(ttt, nonce)
+					_, token, err := util.ParseJWT(presentationRequest.Request.PresentationDefinitionJWT)
+					require.NoError(ttt, err)
+					claim, _ := token.Get("nonce")
+					assert.Equal(ttt, nonce, claim)
+
+					submit := func(definitionID, nonce string) *httptest.ResponseRecorder {
+						request := createSubmissionRequest(ttt, definitionID, authorDID.DID.ID, VerifiableCredential(), holderSigner, holderDID)
+						request = withNonce(ttt, request, holderSigner, nonce)
+						req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/submissions", newRequestValue(ttt, request))
+						w := httptest.NewRecorder()
+						pRouter.CreateSubmission(newRequestContext(w, req))
+						return w
+					}
+
+					w := submit(definition.PresentationDefinition.ID, nonce)
+					assert.Equal(ttt, http.StatusCreated, w.Code)
+
+					w = submit(definition.PresentationDefinition.ID, nonce)
+					assert.Equal(ttt, http.StatusBadRequest, w.Code)
+					assert.Contains(ttt, w.Body.String(), "was already responded to")
+
+					otherDefinition := createPresentationDefinition(ttt, pRouter)
+					w = submit(otherDefinition.PresentationDefinition.ID, createPresentationRequest(ttt, pRouter, definition.PresentationDefinition.ID, authorDID.DID).Request.Nonce)
+					assert.Equal(ttt, http.StatusBadRequest, w.Code)
+					assert.Contains(ttt, w.Body.String(), "is for presentation definition")
+
+					expiredRequest := router.CreateRequestRequest{
+						CommonCreateRequestRequest: &router.CommonCreateRequestRequest{
+							IssuerDID:            authorDID.DID.ID,
+							VerificationMethodID: authorDID.DID.VerificationMethod[0].ID,
+							Expiration:           time.Now().Add(-time.Minute).Format(time.RFC3339),
+						},
+						PresentationDefinitionID: definition.PresentationDefinition.ID,
+					}
+					req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/presentations/requests", newRequestValue(ttt, expiredRequest))
+					w = httptest.NewRecorder()
+					pRouter.CreateRequest(newRequestContext(w, req))
+					require.True(ttt, util.Is2xxResponse(w.Code))
+					var expired router.CreateRequestResponse
+					require.NoError(ttt, json.NewDecoder(w.Body).Decode(&expired))
+					w = submit(definition.PresentationDefinition.ID, expired.Request.Nonce)
+					assert.Equal(ttt, http.StatusBadRequest, w.Code)
+					assert.Contains(ttt, w.Body.String(), "expired")
+
+					// submissions to a definition that was requested must respond to a request
+					w = submit(definition.PresentationDefinition.ID, uuid.NewString())
+					assert.Equal(ttt, http.StatusBadRequest, w.Code)
+					assert.Contains(ttt, w.Body.String(), "doesn't carry the nonce of a request")
+					w = submit(definition.PresentationDefinition.ID, "")
+					assert.Equal(ttt, http.StatusBadRequest, w.Code)
+
+					// while nonces of submissions to other definitions are the holder's own
+					w = submit(otherDefinition.PresentationDefinition.ID, uuid.NewString())
+					assert.Equal(ttt, http.StatusCreated, w.Code)
+				})
+
 				tt.Run("Review submission returns approved submission", func(ttt *testing.T) {
 					s := test.ServiceStorage(ttt)
 					pRouter, didService := setupPresentationRouter(ttt, s)
@@ -592,6 +831,7 @@ func TestPresentationAPI(t *testing.T) {
 								Holder:  holderDID.String(),
 								Type:    []any{"VerifiablePresentation"},
 							},
+							Evaluation: submissionEvaluation,
 						},
 						{
 							Status: "pending",
@@ -600,6 +840,7 @@ func TestPresentationAPI(t *testing.T) {
 								Holder:  mrTeeDID.String(),
 								Type:    []any{"VerifiablePresentation"},
 							},
+							Evaluation: submissionEvaluation,
 						},
 					}
 					diff := cmp.Diff(expectedSubmissions, resp.Submissions,
@@ -681,6 +922,7 @@ func TestPresentationAPI(t *testing.T) {
 								Holder:  holderDID.String(),
 								Type:    []any{"VerifiablePresentation"},
 							},
+							Evaluation: submissionEvaluation,
 						},
 					}
 					diff := cmp.Diff(expectedSubmissions, resp.Submissions,
@@ -774,6 +1016,17 @@ func createDID(t *testing.T, didService *did.Service) *did.CreateDIDResponse {
 	return creatorDID
 }
 
+// submissionEvaluation is the evaluation of the submissions made by createSubmission to the definitions made by
+// createPresentationDefinition.
+var submissionEvaluation = &presentationstorage.Evaluation{
+	Satisfied: true,
+	InputDescriptors: []presentationstorage.DescriptorEvaluation{{
+		ID:        "wa_driver_license",
+		Satisfied: true,
+		Fields:    []presentationstorage.FieldEvaluation{{ID: "date_of_birth", Path: "$.vc.credentialSubject.dateOfBirth", Satisfied: true}},
+	}},
+}
+
 func createSubmission(t *testing.T, pRouter *router.PresentationRouter, definitionID string, requesterDID string,
 	vc credential.VerifiableCredential, holderDID key.DIDKey, holderSigner jwx.Signer) router.Operation {
 	request := createSubmissionRequest(t, definitionID, requesterDID, vc, holderSigner, holderDID)
@@ -824,6 +1077,18 @@ func createSubmissionRequest(t *testing.T, definitionID, requesterDID string, vc
 	return request
 }
 
+// withNonce signs the presentation of the request again with nonce as its nonce claim.
+func withNonce(t *testing.T, request router.CreateSubmissionRequest, holderSigner jwx.Signer, nonce string) router.CreateSubmissionRequest {
+	token, err := jwt.ParseInsecure([]byte(request.SubmissionJWT))
+	require.NoError(t, err)
+	claims, err := token.AsMap(context.Background())
+	require.NoError(t, err)
+	claims["nonce"] = nonce
+	signed, err := holderSigner.SignWithDefaults(claims)
+	require.NoError(t, err)
+	return router.CreateSubmissionRequest{SubmissionJWT: keyaccess.JWT(signed)}
+}
+
 func VerifiableCredential(options ...VCOption) credential.VerifiableCredential {
 	vc := credential.VerifiableCredential{
 		Context:        []string{credential.VerifiableCredentialsLinkedDataContext},
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+func TestProblemResponses(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.RequestLimits = config.RequestLimitsConfig{
+			MaxBodyBytes: 1024,
+			Routes: []config.RouteRequestLimitConfig{
+				{Method: http.MethodPut, Path: "/v1/schemas", MaxBodyBytes: 16},
+			},
+		}
+	})
+
+	doRequest := func(t *testing.T, method, path string, body io.Reader) framework.ErrorResponse {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(method, path, body))
+		assert.Equal(t, framework.ProblemContentType, w.Header().Get("Content-Type"))
+
+		var problem framework.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, w.Code, problem.Status)
+		assert.Equal(t, http.StatusText(w.Code), problem.Title)
+		assert.Equal(t, framework.ProblemTypeBase+problem.Code, problem.Type)
+		assert.Equal(t, path, problem.Instance)
+		assert.Equal(t, problem.Detail, problem.Error)
+		assert.NotEmpty(t, problem.RequestID)
+		return problem
+	}
+
+	t.Run("validation errors list the fields that failed", func(tt *testing.T) {
+		problem := doRequest(tt, http.MethodPut, "/v1/dids/key", strings.NewReader(`{"options":{}}`))
+		assert.Equal(tt, http.StatusBadRequest, problem.Status)
+		assert.Equal(tt, framework.CodeValidationFailed, problem.Code)
+		assert.Contains(tt, problem.Detail, "invalid create DID request")
+		assert.Equal(tt, []framework.FieldError{{Field: "keyType", Error: "keyType is a required field"}}, problem.Errors)
+	})
+
+	t.Run("malformed requests", func(tt *testing.T) {
+		problem := doRequest(tt, http.MethodPut, "/v1/dids/key", strings.NewReader(`{"keyType":`))
+		assert.Equal(tt, http.StatusBadRequest, problem.Status)
+		assert.Equal(tt, framework.CodeMalformedRequest, problem.Code)
+
+		problem = doRequest(tt, http.MethodPut, "/v1/dids/key", strings.NewReader(`{"keyType":1}`))
+		assert.Equal(tt, http.StatusBadRequest, problem.Status)
+		assert.Equal(tt, framework.CodeMalformedRequest, problem.Code)
+		if assert.Len(tt, problem.Errors, 1) {
+			assert.Equal(tt, "keyType", problem.Errors[0].Field)
+		}
+	})
+
+	t.Run("errors without a specific code use their status", func(tt *testing.T) {
+		problem := doRequest(tt, http.MethodGet, "/admin/apikeys", nil)
+		assert.Equal(tt, http.StatusUnauthorized, problem.Status)
+		assert.Equal(tt, "unauthorized", problem.Code)
+
+		problem = doRequest(tt, http.MethodGet, "/v1/unknown", nil)
+		assert.Equal(tt, http.StatusNotFound, problem.Status)
+		assert.Equal(tt, "not_found", problem.Code)
+	})
+
+	t.Run("bodies over the limit are rejected", func(tt *testing.T) {
+		large := `{"keyType":"Ed25519","options":"` + strings.Repeat("a", 1024) + `"}`
+		problem := doRequest(tt, http.MethodPut, "/v1/dids/key", strings.NewReader(large))
+		assert.Equal(tt, http.StatusRequestEntityTooLarge, problem.Status)
+		assert.Equal(tt, framework.CodePayloadTooLarge, problem.Code)
+
+		// without a content length, the body is cut off while it's read
+		req := httptest.NewRequest(http.MethodPut, "/v1/dids/key", io.MultiReader(strings.NewReader(large)))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		assert.Equal(tt, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(tt, w.Body.String(), framework.CodePayloadTooLarge)
+
+		// routes can have their own limits
+		problem = doRequest(tt, http.MethodPut, "/v1/schemas", strings.NewReader(`{"name":"a schema"}`))
+		assert.Equal(tt, http.StatusRequestEntityTooLarge, problem.Status)
+	})
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/ratelimit"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	mockClock := clock.NewMock()
+	limiter := ratelimit.NewMemoryLimiter(mockClock)
+	engine := gin.New()
+	v1 := engine.Group("/v1", middleware.RateLimit(limiter, config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 60,
+		Burst:             3,
+		Routes: []config.RouteRateLimitConfig{
+			{Method: http.MethodPut, Path: "/v1/credentials", RequestsPerMinute: 1},
+		},
+	}))
+	v1.GET("/credentials", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	v1.PUT("/credentials", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	doRequest := func(method, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/credentials", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("applies the default limit", func(tt *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := doRequest(http.MethodGet, "10.0.0.1")
+			assert.Equal(tt, http.StatusOK, w.Code)
+			assert.Equal(tt, "3", w.Header().Get(middleware.RateLimitLimitHeader))
+		}
+		w := doRequest(http.MethodGet, "10.0.0.1")
+		assert.Equal(tt, http.StatusTooManyRequests, w.Code)
+		assert.Equal(tt, "0", w.Header().Get(middleware.RateLimitRemainingHeader))
+		assert.Equal(tt, "1", w.Header().Get(middleware.RetryAfterHeader))
+
+		assert.Equal(tt, http.StatusOK, doRequest(http.MethodGet, "10.0.0.2").Code)
+
+		mockClock.Add(time.Second)
+		assert.Equal(tt, http.StatusOK, doRequest(http.MethodGet, "10.0.0.1").Code)
+	})
+
+	t.Run("applies route limits separately", func(tt *testing.T) {
+		w := doRequest(http.MethodPut, "10.0.0.3")
+		assert.Equal(tt, http.StatusCreated, w.Code)
+		assert.Equal(tt, "1", w.Header().Get(middleware.RateLimitLimitHeader))
+		assert.Equal(tt, "60", w.Header().Get(middleware.RateLimitResetHeader))
+
+		w = doRequest(http.MethodPut, "10.0.0.3")
+		assert.Equal(tt, http.StatusTooManyRequests, w.Code)
+		assert.Equal(tt, "60", w.Header().Get(middleware.RetryAfterHeader))
+
+		assert.Equal(tt, http.StatusOK, doRequest(http.MethodGet, "10.0.0.3").Code)
+	})
+
+	t.Run("counts decisions per limit", func(tt *testing.T) {
+		putLimit := "PUT /v1/credentials"
+		limited := testutil.ToFloat64(metrics.RateLimitDecisions.WithLabelValues(putLimit, metrics.RateLimitLimited))
+		allowed := testutil.ToFloat64(metrics.RateLimitDecisions.WithLabelValues(putLimit, metrics.RateLimitAllowed))
+
+		assert.Equal(tt, http.StatusCreated, doRequest(http.MethodPut, "10.0.0.4").Code)
+		assert.Equal(tt, http.StatusTooManyRequests, doRequest(http.MethodPut, "10.0.0.4").Code)
+
+		assert.Equal(tt, allowed+1, testutil.ToFloat64(metrics.RateLimitDecisions.WithLabelValues(putLimit, metrics.RateLimitAllowed)))
+		assert.Equal(tt, limited+1, testutil.ToFloat64(metrics.RateLimitDecisions.WithLabelValues(putLimit, metrics.RateLimitLimited)))
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestReload(t *testing.T) {
+	logger := logrus.StandardLogger()
+	level, formatter := logger.GetLevel(), logger.Formatter
+	t.Cleanup(func() {
+		logger.SetLevel(level)
+		logger.SetFormatter(formatter)
+	})
+
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.LogLevel = "info"
+		cfg.Server.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerMinute: 1}
+	})
+	assert.Equal(t, http.StatusOK, doTestRequest(t, server.Handler, http.MethodGet, "/v1/dids", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doTestRequest(t, server.Handler, http.MethodGet, "/v1/dids", nil).Code)
+
+	reloaded := server.cfg
+	reloaded.Server.LogLevel = "error"
+	reloaded.Server.RateLimit = config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 1,
+		Routes: []config.RouteRateLimitConfig{
+			{Method: http.MethodGet, Path: "/v1/dids", RequestsPerMinute: 60},
+		},
+	}
+	require.NoError(t, server.Reload(reloaded))
+
+	assert.Equal(t, logrus.ErrorLevel, logger.GetLevel())
+	assert.Equal(t, "error", server.LogLevel)
+	assert.Equal(t, http.StatusOK, doTestRequest(t, server.Handler, http.MethodGet, "/v1/dids", nil).Code)
+}
+
+func TestRestartRequired(t *testing.T) {
+	current, err := config.LoadConfig("", nil)
+	require.NoError(t, err)
+
+	updated := *current
+	updated.Server.LogLevel = "error"
+	updated.Server.RateLimit.RequestsPerMinute = 100
+	assert.Empty(t, restartRequired(*current, updated))
+
+	updated.Server.APIHost = "0.0.0.0:1234"
+	assert.Equal(t, []string{"server"}, restartRequired(*current, updated))
+
+	updated.Services.StorageProvider = "redis"
+	assert.Equal(t, []string{"services", "server"}, restartRequired(*current, updated))
+}
+
+func TestReloadConfig(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newConfig := func(t *testing.T) config.SSIServiceConfig {
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: tempBoltFileName(t),
+			},
+		}
+		serviceConfig.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		return *serviceConfig
+	}
+	origin := []string{"Origin", "https://wallet.example.com"}
+	admin := []string{"X-Api-Key", adminKey}
+
+	t.Run("reloads the config through the admin API", func(tt *testing.T) {
+		serviceConfig := newConfig(tt)
+		reloaded := serviceConfig
+		var loadErr error
+		server, err := NewSSIServer(make(chan os.Signal, 1), serviceConfig, WithConfigLoader(func() (*config.SSIServiceConfig, error) {
+			return &reloaded, loadErr
+		}))
+		require.NoError(tt, err)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids", nil, origin...)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Empty(tt, w.Header().Get("Access-Control-Allow-Origin"))
+
+		reloaded.Server.CORSAllowedOrigins = []string{"https://wallet.example.com"}
+		reloaded.Services.WebhookConfig.WebhookTimeout = "30s"
+		reloaded.Server.APIHost = "0.0.0.0:1234"
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/admin/config/reload", nil, admin...)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var response router.ReloadConfigResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(tt, []string{"server"}, response.RestartRequired)
+		assert.Equal(tt, "30s", server.cfg.Services.WebhookConfig.WebhookTimeout)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids", nil, origin...)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "https://wallet.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+		// an invalid config leaves the server as it was
+		reloaded.Server.CORSAllowedOrigins = []string{"wallet"}
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/admin/config/reload", nil, admin...)
+		assert.Equal(tt, http.StatusInternalServerError, w.Code)
+		loadErr = errors.New("invalid config")
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/admin/config/reload", nil, admin...)
+		assert.Equal(tt, http.StatusInternalServerError, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/dids", nil, origin...)
+		assert.Equal(tt, "https://wallet.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("has no config to reload without a loader", func(tt *testing.T) {
+		server, err := NewSSIServer(make(chan os.Signal, 1), newConfig(tt))
+		require.NoError(tt, err)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/admin/config/reload", nil, admin...)
+		assert.Equal(tt, http.StatusConflict, w.Code)
+	})
+}
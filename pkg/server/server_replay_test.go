@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestReplays(t *testing.T) {
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.ReplayConfig.Enabled = enabled
+		})
+	}
+	createDefinition := func(t *testing.T, server *SSIServer) string {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/presentations/definitions", router.CreatePresentationDefinitionRequest{
+			Name:    "name",
+			Purpose: "purpose",
+			InputDescriptors: []exchange.InputDescriptor{{
+				ID: "wa_driver_license",
+				Constraints: &exchange.Constraints{
+					Fields: []exchange.Field{{Path: []string{"$.vc.credentialSubject.dateOfBirth"}}},
+				},
+			}},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreatePresentationDefinitionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.PresentationDefinition.ID
+	}
+	holderSigner, holderDID := getSigner(t)
+
+	// a signed jwt, which isn't a valid application or submission, so that it's rejected by the handlers
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sign := func(t *testing.T, issuer, jti string) keyaccess.JWT {
+		token, err := jwt.NewBuilder().Issuer(issuer).JwtID(jti).Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.EdDSA, key))
+		require.NoError(t, err)
+		return keyaccess.JWT(signed)
+	}
+	invalid := router.CreateSubmissionRequest{SubmissionJWT: sign(t, "did:example:alice", "jti-1")}
+
+	t.Run("a jwt is only accepted once, by any route", func(tt *testing.T) {
+		server := newServer(tt, true)
+		submission := createSubmissionRequest(tt, createDefinition(tt, server), "did:example:verifier", VerifiableCredential(), holderSigner, holderDID)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", submission)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", submission)
+		assert.Equal(tt, http.StatusConflict, w.Code, w.Body.String())
+		var problem framework.ErrorResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&problem))
+		assert.Equal(tt, framework.CodeReplayed, problem.Code)
+
+		application := router.SubmitApplicationRequest{ApplicationJWT: submission.SubmissionJWT}
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/manifests/applications", application)
+		assert.Equal(tt, http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	t.Run("a rejected jwt isn't remembered", func(tt *testing.T) {
+		server := newServer(tt, true)
+		for i := 0; i < 2; i++ {
+			w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", invalid)
+			assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+
+		// so a forged jwt with the issuer and id of another doesn't keep the real one from being accepted
+		submission := createSubmissionRequest(tt, createDefinition(tt, server), "did:example:verifier", VerifiableCredential(), holderSigner, holderDID)
+		token, err := jwt.ParseInsecure([]byte(submission.SubmissionJWT))
+		require.NoError(tt, err)
+		require.NotEmpty(tt, token.JwtID())
+		forged := router.CreateSubmissionRequest{SubmissionJWT: sign(tt, token.Issuer(), token.JwtID())}
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", forged)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", submission)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("isn't tracked unless enabled", func(tt *testing.T) {
+		server := newServer(tt, false)
+		submission := createSubmissionRequest(tt, createDefinition(tt, server), "did:example:verifier", VerifiableCredential(), holderSigner, holderDID)
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", submission)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+		application := router.SubmitApplicationRequest{ApplicationJWT: submission.SubmissionJWT}
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/manifests/applications", application)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+)
+
+func TestRequestID(t *testing.T) {
+	received := make(chan string, 1)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(requestid.Header)
+	}))
+	defer webhookServer.Close()
+
+	server := newTestServer(t, nil)
+
+	t.Run("generates IDs", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, HealthPrefix, nil, requestid.Header, "")
+		assert.True(tt, requestid.IsValid(w.Header().Get(requestid.Header)))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, HealthPrefix, nil, requestid.Header, "not valid!")
+		assert.NotEqual(tt, "not valid!", w.Header().Get(requestid.Header))
+		assert.True(tt, requestid.IsValid(w.Header().Get(requestid.Header)))
+	})
+
+	t.Run("propagates IDs to error responses", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/schemas", router.CreateSchemaRequest{}, requestid.Header, "request-1")
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		assert.Equal(tt, "request-1", w.Header().Get(requestid.Header))
+
+		var errResp framework.ErrorResponse
+		require.NoError(tt, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(tt, "request-1", errResp.RequestID)
+	})
+
+	t.Run("propagates IDs to webhooks", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/webhooks", router.CreateWebhookRequest{
+			Noun: "DID",
+			Verb: "Create",
+			URL:  webhookServer.URL,
+		}, requestid.Header, "")
+		require.True(tt, util.Is2xxResponse(w.Code))
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: "Ed25519"}, requestid.Header, "request-2")
+		require.True(tt, util.Is2xxResponse(w.Code))
+
+		select {
+		case id := <-received:
+			assert.Equal(tt, "request-2", id)
+		case <-time.After(2 * time.Second):
+			assert.Fail(tt, "webhook was not received")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/did/resolution"
+)
+
+func TestResolutionCacheAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.DIDConfig.ResolutionCache = config.DIDResolutionCacheConfig{Enabled: enabled}
+		})
+	}
+	getStats := func(t *testing.T, server *SSIServer) resolution.CacheStats {
+		w := doTestRequest(t, server.Handler, http.MethodGet, "/admin/resolution/cache", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats resolution.CacheStats
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+		return stats
+	}
+
+	server := newServer(t, true)
+	_, didKey, err := key.GenerateDIDKey(crypto.Ed25519)
+	require.NoError(t, err)
+	did := didKey.String()
+
+	// the second resolution is served from the cache
+	for i := 0; i < 2; i++ {
+		w := doTestRequest(t, server.Handler, http.MethodGet, "/v1/dids/resolver/"+did, nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assert.Equal(t, resolution.CacheStats{Backend: resolution.MemoryCacheBackend, Entries: 1, Hits: 1, Misses: 1}, getStats(t, server))
+
+	w := doTestRequest(t, server.Handler, http.MethodDelete, "/admin/resolution/cache/"+did, nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/resolution/cache/"+did, nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/dids/resolver/"+did, nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/resolution/cache", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var purged router.PurgeResolutionCacheResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&purged))
+	assert.Equal(t, 1, purged.Purged)
+	assert.Zero(t, getStats(t, server).Entries)
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/resolution/cache", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
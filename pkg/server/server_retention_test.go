@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	manifestsdk "github.com/TBD54566975/ssi-sdk/credential/manifest"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/retention"
+)
+
+func TestRetentionAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+			cfg.Services.RetentionConfig = config.RetentionServiceConfig{
+				Enabled: enabled,
+				Rules:   []config.RetentionRuleConfig{{Type: "application", Status: "rejected", KeepDays: 90}},
+			}
+		})
+	}
+	runRetention := func(server *SSIServer) retention.Run {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/admin/retention/runs", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.RunRetentionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.Run
+	}
+
+	server := newServer(t, true)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+	server.SSIService.Retention.Clock = mockClock
+
+	// two applications are rejected, and one of them is held
+	ctx := context.Background()
+	manifestStorage, err := manifeststg.NewManifestStorage(server.SSIService.GetStorage())
+	require.NoError(t, err)
+	for _, id := range []string{"app-1", "app-2"} {
+		require.NoError(t, manifestStorage.StoreApplication(ctx, manifeststg.StoredApplication{
+			ID:          id,
+			Status:      opcredential.StatusRejected,
+			Application: manifestsdk.CredentialApplication{ID: id},
+		}))
+	}
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/admin/retention/holds", router.CreateHoldRequest{Type: retention.TypeApplication, ObjectID: "app-2"}, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/retention/holds", router.CreateHoldRequest{Type: retention.TypeApplication, ObjectID: "app-2", Reason: "case 42"}, middleware.APIKeyHeader, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/admin/retention/holds", router.CreateHoldRequest{Type: retention.TypeApplication, ObjectID: "app-2", Reason: "case 42"}, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateHoldResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/admin/retention/holds", nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var holds router.ListHoldsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&holds))
+	assert.Equal(t, []retention.Hold{created.Hold}, holds.Holds)
+
+	// retention starts when the job first sees them, and only the one that isn't held is deleted once it's over
+	run := runRetention(server)
+	assert.Empty(t, run.Deleted)
+	mockClock.Add(91 * 24 * time.Hour)
+	run = runRetention(server)
+	require.Len(t, run.Deleted, 1)
+	assert.Equal(t, "app-1", run.Deleted[0].ID)
+	require.Len(t, run.Held, 1)
+	assert.Equal(t, "app-2", run.Held[0].ID)
+	applications, err := manifestStorage.ListApplications(ctx)
+	require.NoError(t, err)
+	require.Len(t, applications, 1)
+	assert.Equal(t, "app-2", applications[0].ID)
+
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/retention/holds/"+created.Hold.ID, nil, middleware.APIKeyHeader, adminKey)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodDelete, "/admin/retention/holds/"+created.Hold.ID, nil, middleware.APIKeyHeader, adminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	run = runRetention(server)
+	require.Len(t, run.Deleted, 1)
+	assert.Equal(t, "app-2", run.Deleted[0].ID)
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/admin/retention/holds", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	credmodel "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	"github.com/tbd54566975/ssi-service/pkg/service/schema"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestManifestReviewPolicies(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			keyStoreService, _ := testKeyStoreService(t, db)
+			didService, _ := testDIDService(t, db, keyStoreService, nil)
+			schemaService := testSchemaService(t, db, keyStoreService, didService)
+			credentialService := testCredentialService(t, db, keyStoreService, didService, schemaService)
+
+			issuerDID, err := didService.CreateDIDByMethod(context.Background(), did.CreateDIDRequest{
+				Method:  didsdk.KeyMethod,
+				KeyType: crypto.Ed25519,
+			})
+			require.NoError(t, err)
+			kid := issuerDID.DID.VerificationMethod[0].ID
+			applicantPrivKey, applicantDIDKey, err := key.GenerateDIDKey(crypto.Ed25519)
+			require.NoError(t, err)
+			applicantDID, err := applicantDIDKey.Expand()
+			require.NoError(t, err)
+
+			licenseApplicationSchema, err := schemaService.CreateSchema(context.Background(),
+				schema.CreateSchemaRequest{Issuer: issuerDID.DID.ID, FullyQualifiedVerificationMethodID: kid, Name: "license application schema", Schema: getLicenseApplicationSchema()})
+			require.NoError(t, err)
+			// approvals without an issuance template issue credentials without data, so the output needs none
+			membershipSchema, err := schemaService.CreateSchema(context.Background(),
+				schema.CreateSchemaRequest{Issuer: issuerDID.DID.ID, FullyQualifiedVerificationMethodID: kid, Name: "membership schema", Schema: map[string]any{
+					"$schema":    "https://json-schema.org/draft-07/schema",
+					"type":       "object",
+					"properties": map[string]any{"credentialSubject": map[string]any{"type": "object"}},
+				}})
+			require.NoError(t, err)
+			createdCred, err := credentialService.CreateCredential(context.Background(), credential.CreateCredentialRequest{
+				Issuer:                             issuerDID.DID.ID,
+				FullyQualifiedVerificationMethodID: kid,
+				Subject:                            applicantDID.ID,
+				SchemaID:                           licenseApplicationSchema.ID,
+				Data:                               map[string]any{"licenseType": "Class D"},
+			})
+			require.NoError(t, err)
+
+			// submit submits an application for a new manifest to a service with the policies
+			submit := func(tt *testing.T, policies ...config.ReviewPolicyConfig) router.SubmitApplicationResponse {
+				serviceConfig := config.ManifestServiceConfig{
+					BaseServiceConfig: &config.BaseServiceConfig{Name: "manifest"},
+					ReviewPolicies:    policies,
+				}
+				manifestService, err := manifest.NewManifestService(serviceConfig, db, keyStoreService, didService.GetResolver(), credentialService, nil)
+				require.NoError(tt, err)
+				manifestRouter, err := router.NewManifestRouter(manifestService)
+				require.NoError(tt, err)
+
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests",
+					newRequestValue(tt, getValidCreateManifestRequest(issuerDID.DID.ID, kid, membershipSchema.ID)))
+				manifestRouter.CreateManifest(newRequestContext(w, req))
+				require.True(tt, util.Is2xxResponse(w.Code))
+				var resp router.CreateManifestResponse
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+				m := resp.Manifest
+
+				container := []credmodel.Container{{CredentialJWT: createdCred.CredentialJWT}}
+				applicationRequest := getValidApplicationRequest(m.ID, m.PresentationDefinition.ID, m.PresentationDefinition.InputDescriptors[0].ID, container)
+				signer, err := keyaccess.NewJWKKeyAccess(applicantDID.ID, applicantDID.VerificationMethod[0].ID, applicantPrivKey)
+				require.NoError(tt, err)
+				signed, err := signer.SignJSON(applicationRequest)
+				require.NoError(tt, err)
+
+				w = httptest.NewRecorder()
+				req = httptest.NewRequest(http.MethodPut, "https://ssi-service.com/v1/manifests/applications",
+					newRequestValue(tt, router.SubmitApplicationRequest{ApplicationJWT: *signed}))
+				manifestRouter.SubmitApplication(newRequestContext(w, req))
+				require.True(tt, util.Is2xxResponse(w.Code))
+				var op router.Operation
+				require.NoError(tt, json.NewDecoder(w.Body).Decode(&op))
+				if !op.Done {
+					return router.SubmitApplicationResponse{}
+				}
+				var appResp router.SubmitApplicationResponse
+				respData, err := json.Marshal(op.Result.Response)
+				require.NoError(tt, err)
+				require.NoError(tt, json.Unmarshal(respData, &appResp))
+				return appResp
+			}
+
+			t.Run("applications that no policy matches stay pending", func(tt *testing.T) {
+				appResp := submit(tt, config.ReviewPolicyConfig{
+					Name:       "class a",
+					Action:     manifest.ReviewActionDeny,
+					Conditions: []config.ReviewConditionConfig{{Path: "$.credentialSubject.licenseType", Equals: "Class A"}},
+				})
+				assert.Empty(tt, appResp.Response.ID)
+			})
+
+			t.Run("the first matching policy denies", func(tt *testing.T) {
+				appResp := submit(tt, config.ReviewPolicyConfig{
+					Name:       "unlicensed classes",
+					Action:     manifest.ReviewActionDeny,
+					Reason:     "class is not licensed here",
+					Conditions: []config.ReviewConditionConfig{{Path: "$.credentialSubject.licenseType", Pattern: "^Class [CD]$"}},
+				}, config.ReviewPolicyConfig{
+					Name:   "trusted issuers",
+					Action: manifest.ReviewActionApprove,
+				})
+				require.NotNil(tt, appResp.Response.Denial)
+				assert.Equal(tt, "class is not licensed here", appResp.Response.Denial.Reason)
+			})
+
+			t.Run("approves applications with credentials from trusted issuers", func(tt *testing.T) {
+				appResp := submit(tt, config.ReviewPolicyConfig{
+					Name:    "untrusted issuers",
+					Action:  manifest.ReviewActionApprove,
+					Issuers: []string{"did:example:untrusted"},
+				}, config.ReviewPolicyConfig{
+					Name:    "trusted issuers",
+					Action:  manifest.ReviewActionApprove,
+					Issuers: []string{issuerDID.DID.ID},
+				})
+				assert.Nil(tt, appResp.Response.Denial)
+				assert.NotNil(tt, appResp.Response.Fulfillment)
+				assert.NotEmpty(tt, appResp.Credentials)
+			})
+
+			t.Run("rejects invalid policies", func(tt *testing.T) {
+				for _, policy := range []config.ReviewPolicyConfig{
+					{Action: "escalate"},
+					{Action: manifest.ReviewActionDeny, Conditions: []config.ReviewConditionConfig{{Equals: "Class A"}}},
+					{Action: manifest.ReviewActionDeny, Conditions: []config.ReviewConditionConfig{{Path: "$.issuer", Pattern: "("}}},
+				} {
+					serviceConfig := config.ManifestServiceConfig{
+						BaseServiceConfig: &config.BaseServiceConfig{Name: "manifest"},
+						ReviewPolicies:    []config.ReviewPolicyConfig{policy},
+					}
+					_, err := manifest.NewManifestService(serviceConfig, db, keyStoreService, didService.GetResolver(), credentialService, nil)
+					assert.Error(tt, err)
+				}
+			})
+		})
+	}
+}
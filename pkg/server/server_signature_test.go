@@ -0,0 +1,208 @@
+package server
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/httpsig"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+)
+
+func TestRequestSignatures(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	_, bootstrapKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	bootstrapJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK(auth.BootstrapClientKeyID, bootstrapKey)
+	require.NoError(t, err)
+	bootstrapJWKBytes, err := json.Marshal(bootstrapJWK)
+	require.NoError(t, err)
+
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Services.AuthConfig.BootstrapClientKeyJWK = string(bootstrapJWKBytes)
+		cfg.Server.EnableAPIKeyAuth = true
+		cfg.Server.RequestSignatures.Required = true
+		cfg.Services.ReplayConfig.Enabled = true
+	})
+
+	// doRequest makes a request with the API key, signed with the client key when there is one
+	doRequest := func(t *testing.T, method, path string, body any, apiKey, keyID string, key gocrypto.Signer) *httptest.ResponseRecorder {
+		var bodyBytes []byte
+		if body != nil {
+			bodyBytes, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(bodyBytes))
+		req.Header.Set(middleware.APIKeyHeader, apiKey)
+		if key != nil {
+			components := []string{"@method", "@target-uri"}
+			if len(bodyBytes) > 0 {
+				req.Header.Set(httpsig.ContentDigestHeader, httpsig.ContentDigest(bodyBytes))
+				components = append(components, "content-digest")
+			}
+			alg, err := httpsig.KeyAlgorithm(key.Public())
+			require.NoError(t, err)
+			require.NoError(t, httpsig.Sign(req, "sig1", components, keyID, alg, key))
+		}
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// the bootstrap client key registers the first keys, one of which is only for the requests of an api key
+	w := doRequest(t, http.MethodPut, AdminPrefix+APIKeysPrefix, router.CreateAPIKeyRequest{Name: "issuer"}, adminKey, auth.BootstrapClientKeyID, bootstrapKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var apiKey router.CreateAPIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&apiKey))
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	clientJWK, err := jwx.PublicKeyToPublicKeyJWK("", clientKey.Public())
+	require.NoError(t, err)
+	w = doRequest(t, http.MethodPut, AdminPrefix+ClientKeysPrefix, router.RegisterClientKeyRequest{
+		Name:         "issuer backend",
+		Subject:      apiKey.APIKey.ID,
+		PublicKeyJWK: *clientJWK,
+	}, adminKey, auth.BootstrapClientKeyID, bootstrapKey)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered router.ClientKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&registered))
+	assert.Equal(t, httpsig.AlgorithmECDSAP256SHA256, registered.ClientKey.Algorithm)
+	createDID := router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}
+
+	t.Run("changes must be signed, reads needn't be", func(tt *testing.T) {
+		w := doRequest(tt, http.MethodPut, "/v1/dids/key", createDID, apiKey.Key, "", nil)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "the request must be signed")
+
+		w = doRequest(tt, http.MethodPut, AdminPrefix+ClientKeysPrefix, router.RegisterClientKeyRequest{Name: "other", PublicKeyJWK: *clientJWK}, adminKey, "", nil)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+
+		w = doRequest(tt, http.MethodGet, "/v1/dids/key", nil, apiKey.Key, "", nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+		w = doRequest(tt, http.MethodGet, AdminPrefix+ClientKeysPrefix+"/"+registered.ClientKey.ID, nil, adminKey, "", nil)
+		assert.Equal(tt, http.StatusOK, w.Code)
+	})
+
+	t.Run("accepts requests signed by registered keys", func(tt *testing.T) {
+		w := doRequest(tt, http.MethodPut, "/v1/dids/key", createDID, apiKey.Key, registered.ClientKey.ID, clientKey)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+
+		// the operations of a signed batch are covered by its signature
+		didBody, err := json.Marshal(createDID)
+		require.NoError(tt, err)
+		batch := router.BatchRequest{Operations: []router.BatchOperation{
+			{Method: http.MethodPut, Path: "/v1/dids/key", Body: didBody},
+		}}
+		w = doRequest(tt, http.MethodPost, "/v1/batch", batch, apiKey.Key, registered.ClientKey.ID, clientKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var batchResp router.BatchResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&batchResp))
+		assert.True(tt, batchResp.Succeeded)
+	})
+
+	t.Run("rejects signatures that don't match the request", func(tt *testing.T) {
+		body, err := json.Marshal(createDID)
+		require.NoError(tt, err)
+		req := httptest.NewRequest(http.MethodPut, "/v1/dids/key", bytes.NewReader([]byte(`{"keyType":"secp256k1"}`)))
+		req.Header.Set(middleware.APIKeyHeader, apiKey.Key)
+		req.Header.Set(httpsig.ContentDigestHeader, httpsig.ContentDigest(body))
+		require.NoError(tt, httpsig.Sign(req, "sig1", []string{"@method", "@target-uri", "content-digest"}, registered.ClientKey.ID, httpsig.AlgorithmECDSAP256SHA256, clientKey))
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "digest doesn't match the body")
+
+		// signed for another path
+		req = httptest.NewRequest(http.MethodPut, "/v1/dids/key", http.NoBody)
+		req.Header.Set(middleware.APIKeyHeader, apiKey.Key)
+		require.NoError(tt, httpsig.Sign(req, "sig1", []string{"@method", "@target-uri"}, registered.ClientKey.ID, httpsig.AlgorithmECDSAP256SHA256, clientKey))
+		req.URL.Path = "/v1/dids/web"
+		w = httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "doesn't verify")
+
+		// not covering enough of the request
+		req = httptest.NewRequest(http.MethodPut, "/v1/dids/key", bytes.NewReader(body))
+		req.Header.Set(middleware.APIKeyHeader, apiKey.Key)
+		req.Header.Set(httpsig.ContentDigestHeader, httpsig.ContentDigest(body))
+		require.NoError(tt, httpsig.Sign(req, "sig1", []string{"@method", "@target-uri"}, registered.ClientKey.ID, httpsig.AlgorithmECDSAP256SHA256, clientKey))
+		w = httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "must cover content-digest")
+	})
+
+	t.Run("rejects replayed signatures", func(tt *testing.T) {
+		body, err := json.Marshal(createDID)
+		require.NoError(tt, err)
+		req := httptest.NewRequest(http.MethodPut, "/v1/dids/key", bytes.NewReader(body))
+		req.Header.Set(middleware.APIKeyHeader, apiKey.Key)
+		req.Header.Set(httpsig.ContentDigestHeader, httpsig.ContentDigest(body))
+		require.NoError(tt, httpsig.Sign(req, "sig1", []string{"@method", "@target-uri", "content-digest"}, registered.ClientKey.ID, httpsig.AlgorithmECDSAP256SHA256, clientKey))
+		replayed := req.Clone(req.Context())
+		replayed.Body = io.NopCloser(bytes.NewReader(body))
+
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		w = httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, replayed)
+		assert.Equal(tt, http.StatusConflict, w.Code)
+		assert.Contains(tt, w.Body.String(), "request signature was already accepted")
+	})
+
+	t.Run("keys only sign for their subject", func(tt *testing.T) {
+		w := doRequest(tt, http.MethodPut, AdminPrefix+APIKeysPrefix, router.CreateAPIKeyRequest{Name: "other"}, adminKey, registered.ClientKey.ID, clientKey)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "may not sign the requests of this caller")
+	})
+
+	t.Run("rejects old signatures", func(tt *testing.T) {
+		mock := clock.NewMock()
+		mock.Set(time.Now().Add(middleware.DefaultSignatureMaxAge + time.Minute))
+		server.SSIService.Auth.Clock = mock
+		defer func() { server.SSIService.Auth.Clock = clock.New() }()
+
+		w := doRequest(tt, http.MethodPut, "/v1/dids/key", createDID, apiKey.Key, registered.ClientKey.ID, clientKey)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "was created more than 5m0s ago")
+	})
+
+	t.Run("rejects revoked keys", func(tt *testing.T) {
+		w := doRequest(tt, http.MethodDelete, AdminPrefix+ClientKeysPrefix+"/"+registered.ClientKey.ID, nil, adminKey, auth.BootstrapClientKeyID, bootstrapKey)
+		require.Equal(tt, http.StatusNoContent, w.Code, w.Body.String())
+
+		w = doRequest(tt, http.MethodPut, "/v1/dids/key", createDID, apiKey.Key, registered.ClientKey.ID, clientKey)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		assert.Contains(tt, w.Body.String(), "unknown or revoked key")
+
+		// and keeps them listed
+		w = doRequest(tt, http.MethodGet, AdminPrefix+ClientKeysPrefix, nil, adminKey, "", nil)
+		require.Equal(tt, http.StatusOK, w.Code)
+		var list router.ListClientKeysResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&list))
+		require.Len(tt, list.ClientKeys, 1)
+		assert.True(tt, list.ClientKeys[0].Revoked)
+	})
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/stats"
+)
+
+func TestStatsAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+
+	// two credentials are issued, one is revoked, and each is verified, one after it was tampered with
+	var credentials []router.CreateCredentialResponse
+	for i := 0; i < 2; i++ {
+		w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              "did:example:alice",
+			Data:                 map[string]any{"firstName": "Alice"},
+			Revocable:            true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateCredentialResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		credentials = append(credentials, created)
+	}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials/"+credentials[0].ID+"/status", router.UpdateCredentialStatusRequest{Revoked: true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials/verification", router.VerifyCredentialRequest{CredentialJWT: credentials[1].CredentialJWT})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	jwt := string(*credentials[1].CredentialJWT)
+	tampered := keyaccess.JWT(jwt[:len(jwt)-4] + "AAAA")
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials/verification", router.VerifyCredentialRequest{CredentialJWT: &tampered})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/stats?days=7", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp router.GetStatsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 1, resp.DIDsByMethod["key"])
+	assert.Equal(t, 0, resp.ApplicationsByStatus["pending"])
+	require.Len(t, resp.CredentialsIssued, 7)
+	today := len(resp.CredentialsIssued) - 1
+	assert.Equal(t, resp.To, resp.CredentialsIssued[today].Day)
+	assert.EqualValues(t, 2, resp.CredentialsIssued[today].Count)
+	assert.EqualValues(t, 1, resp.CredentialsRevoked[today].Count)
+	assert.EqualValues(t, 1, resp.VerificationsSucceeded[today].Count)
+	assert.EqualValues(t, 1, resp.VerificationsFailed[today].Count)
+
+	t.Run("rejects days out of range", func(tt *testing.T) {
+		for _, days := range []string{"-1", strconv.Itoa(stats.MaxDays + 1), "week"} {
+			w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/stats?days="+days, nil)
+			assert.Equal(tt, http.StatusBadRequest, w.Code, days)
+		}
+	})
+}
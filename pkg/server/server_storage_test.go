@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestStorageAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	newServer := func(t *testing.T, boltFile string) *SSIServer {
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: boltFile,
+			},
+		}
+		serviceConfig.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		server, err := NewSSIServer(make(chan os.Signal, 1), *serviceConfig)
+		require.NoError(t, err)
+		return server
+	}
+	doRequest := func(server *SSIServer, method, path string, body []byte, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set(middleware.APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// the default tenant, and acme, each have a DID, whose key is in their keystore
+	server := newServer(t, tempBoltFileName(t))
+	w := doRequest(server, http.MethodPut, "/v1/dids/key", []byte(`{"keyType":"Ed25519"}`), "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	acmeCtx := storage.WithTenant(context.Background(), "acme")
+	acmeDID, err := server.DID.CreateDIDByMethod(acmeCtx, did.CreateDIDRequest{Method: didsdk.KeyMethod, KeyType: crypto.Ed25519})
+	require.NoError(t, err)
+
+	w = doRequest(server, http.MethodGet, "/admin/storage/export", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(server, http.MethodGet, "/admin/storage/export", nil, adminKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, router.NDJSONContentType, w.Header().Get("Content-Type"))
+	export := w.Body.Bytes()
+	var header storage.ExportHeader
+	require.NoError(t, json.NewDecoder(bytes.NewReader(export)).Decode(&header))
+	assert.Equal(t, storage.ExportVersion, header.Version)
+	assert.True(t, header.Consistent)
+
+	t.Run("restores into a fresh instance", func(tt *testing.T) {
+		boltFile := tempBoltFileName(tt)
+		fresh := newServer(tt, boltFile)
+
+		// a dry run counts what would be imported, and imports nothing
+		w := doRequest(fresh, http.MethodPut, "/admin/storage/import?dryRun=true&overwrite=true", export, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.ImportStorageResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(tt, resp.DryRun)
+		assert.Equal(tt, strings.Count(string(export), "\n")-1, resp.Imported)
+		_, err := fresh.DID.GetDIDByMethod(acmeCtx, did.GetDIDRequest{Method: didsdk.KeyMethod, ID: acmeDID.DID.ID})
+		assert.Error(tt, err)
+
+		// the fresh instance created a service key of its own, which is replaced by the one its keys are encrypted with
+		w = doRequest(fresh, http.MethodPut, "/admin/storage/import?overwrite=true", export, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		resp = router.ImportStorageResponse{}
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.False(tt, resp.DryRun)
+		assert.Equal(tt, strings.Count(string(export), "\n")-1, resp.Imported)
+		require.NoError(tt, fresh.CloseStorage())
+
+		// once restarted, the restored keys still sign for their DIDs
+		restored := newServer(tt, boltFile)
+		w = doRequest(restored, http.MethodPut, "/v1/credentials", []byte(`{"issuer":"`+issuer.DID.ID+`","verificationMethodId":"`+issuer.DID.VerificationMethod[0].ID+`","subject":"did:example:alice","data":{"firstName":"Alice"}}`), "")
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		gotDID, err := restored.DID.GetDIDByMethod(acmeCtx, did.GetDIDRequest{Method: didsdk.KeyMethod, ID: acmeDID.DID.ID})
+		require.NoError(tt, err)
+		assert.Equal(tt, acmeDID.DID.ID, gotDID.DID.ID)
+
+		// importing again keeps what's stored
+		w = doRequest(restored, http.MethodPut, "/admin/storage/import", export, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		resp = router.ImportStorageResponse{}
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Zero(tt, resp.Imported)
+		assert.Equal(tt, strings.Count(string(export), "\n")-1, resp.Skipped)
+	})
+
+	t.Run("rejects exports of versions it can't import", func(tt *testing.T) {
+		w := doRequest(server, http.MethodPut, "/admin/storage/import", []byte(`{"format":"ssi-service-storage-export","version":99}`), adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doRequest(server, http.MethodPut, "/admin/storage/import?overwrite=maybe", export, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
+
+func TestStorageEncryptionAPI(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	boltFile := tempBoltFileName(t)
+	newServer := func(t *testing.T, encryptedNamespaces []string) *SSIServer {
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: boltFile,
+			},
+		}
+		serviceConfig.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		serviceConfig.Services.EncryptedNamespaces = encryptedNamespaces
+		server, err := NewSSIServer(make(chan os.Signal, 1), *serviceConfig)
+		require.NoError(t, err)
+		return server
+	}
+	doRequest := func(server *SSIServer, method, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set(middleware.APIKeyHeader, adminKey)
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// a credential is stored before credentials are encrypted
+	server := newServer(t, nil)
+	w := doRequest(server, http.MethodPut, "/v1/dids/key", []byte(`{"keyType":"Ed25519"}`))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	createCredential := []byte(`{"issuer":"` + issuer.DID.ID + `","verificationMethodId":"` + issuer.DID.VerificationMethod[0].ID + `","subject":"did:example:alice","data":{"firstName":"Alice"}}`)
+	w = doRequest(server, http.MethodPut, "/v1/credentials", createCredential)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var before router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&before))
+
+	w = doRequest(server, http.MethodPut, "/admin/storage/encrypt", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.NoError(t, server.CloseStorage())
+
+	server = newServer(t, []string{"credential"})
+	defer func() { _ = server.CloseStorage() }()
+	w = doRequest(server, http.MethodPut, "/v1/credentials", createCredential)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var after router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&after))
+	for _, id := range []string{before.ID, after.ID} {
+		w = doRequest(server, http.MethodGet, "/v1/credentials/"+id, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// a dry run counts the credential stored before, and encrypts nothing
+	w = doRequest(server, http.MethodPut, "/admin/storage/encrypt?dryRun=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp router.EncryptNamespacesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []string{"credential"}, resp.Namespaces)
+	assert.Equal(t, 1, resp.Encrypted)
+	assert.True(t, resp.DryRun)
+
+	w = doRequest(server, http.MethodPut, "/admin/storage/encrypt", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = router.EncryptNamespacesResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 1, resp.Encrypted)
+	assert.False(t, resp.DryRun)
+
+	w = doRequest(server, http.MethodGet, "/v1/credentials/"+before.ID, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(server, http.MethodPut, "/admin/storage/encrypt", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = router.EncryptNamespacesResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Zero(t, resp.Encrypted)
+}
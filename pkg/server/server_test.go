@@ -2,12 +2,14 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
 	"github.com/tbd54566975/ssi-service/pkg/service/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/did"
 	svcframework "github.com/tbd54566975/ssi-service/pkg/service/framework"
@@ -92,7 +95,7 @@ func TestReadinessAPI(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "https://ssi-service.com/readiness", nil)
 	w := httptest.NewRecorder()
 
-	handler := router.Readiness(nil)
+	handler := router.Readiness(nil, nil)
 	c := newRequestContext(w, req)
 	handler(c)
 	assert.True(t, util.Is2xxResponse(w.Code))
@@ -103,6 +106,78 @@ func TestReadinessAPI(t *testing.T) {
 
 	assert.Equal(t, svcframework.StatusReady, resp.Status.Status)
 	assert.Len(t, resp.ServiceStatuses, 0)
+
+	t.Run("aggregates every service and storage", func(tt *testing.T) {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPrefix, nil))
+		assert.Equal(tt, http.StatusOK, w.Code)
+
+		var resp router.GetReadinessResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(tt, svcframework.StatusReady, resp.Status.Status)
+		assert.Len(tt, resp.ServiceStatuses, len(server.GetServices()))
+		assert.Equal(tt, svcframework.StatusReady, resp.ServiceStatuses[svcframework.Storage].Status)
+	})
+
+	t.Run("not ready when a service doesn't report its status in time", func(tt *testing.T) {
+		w := httptest.NewRecorder()
+		handler := router.Readiness([]svcframework.Service{slowService{delay: 5 * time.Second}}, nil)
+		start := time.Now()
+		handler(newRequestContext(w, httptest.NewRequest(http.MethodGet, ReadinessPrefix, nil)))
+		assert.Less(tt, time.Since(start), 5*time.Second)
+		assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+
+		var resp router.GetReadinessResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(tt, svcframework.StatusNotReady, resp.ServiceStatuses["slow"].Status)
+		assert.Contains(tt, resp.ServiceStatuses["slow"].Message, "didn't report its status")
+	})
+
+	t.Run("not ready once shutting down", func(tt *testing.T) {
+		require.NoError(tt, server.PreShutdownHooks(context.Background()))
+
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPrefix, nil))
+		assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+
+		var resp router.GetReadinessResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(tt, svcframework.StatusNotReady, resp.Status.Status)
+		assert.Equal(tt, "shutting down", resp.Status.Message)
+		assert.Equal(tt, svcframework.StatusReady, resp.ServiceStatuses[svcframework.Storage].Status)
+	})
+
+	t.Run("not ready when storage is unreachable", func(tt *testing.T) {
+		require.NoError(tt, server.GetStorage().Close())
+
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPrefix, nil))
+		assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+
+		var resp router.GetReadinessResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(tt, svcframework.StatusNotReady, resp.Status.Status)
+		assert.Equal(tt, svcframework.StatusNotReady, resp.ServiceStatuses[svcframework.Storage].Status)
+
+		// liveness doesn't depend on storage
+		w = httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessPrefix, nil))
+		assert.Equal(tt, http.StatusOK, w.Code)
+	})
+}
+
+// slowService takes delay to report its status.
+type slowService struct {
+	delay time.Duration
+}
+
+func (s slowService) Type() svcframework.Type {
+	return "slow"
+}
+
+func (s slowService) Status() svcframework.Status {
+	time.Sleep(s.delay)
+	return svcframework.Status{Status: svcframework.StatusReady}
 }
 
 func newRequestValue(t *testing.T, data any) io.Reader {
@@ -360,6 +435,30 @@ func testWebhookRouter(t *testing.T, bolt storage.ServiceStorage) *router.Webhoo
 	return webhookRouter
 }
 
+func testAuthService(t *testing.T, db storage.ServiceStorage, adminKeyHash string) *auth.Service {
+	serviceConfig := config.AuthServiceConfig{
+		BaseServiceConfig: &config.BaseServiceConfig{Name: "auth"},
+		AdminAPIKeyHash:   adminKeyHash,
+	}
+
+	// create an auth service
+	authService, err := auth.NewAuthService(serviceConfig, db)
+	require.NoError(t, err)
+	require.NotEmpty(t, authService)
+	return authService
+}
+
+func testAuthRouter(t *testing.T, db storage.ServiceStorage) (*router.AuthRouter, *auth.Service) {
+	authService := testAuthService(t, db, "")
+
+	// create router for service
+	authRouter, err := router.NewAuthRouter(authService)
+	require.NoError(t, err)
+	require.NotEmpty(t, authRouter)
+
+	return authRouter, authService
+}
+
 func idFromURI(id string) string {
 	return id[len(id)-36:]
 }
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+func TestTimeouts(t *testing.T) {
+	// the routes answer with how long their requests have left, or wait until they time out
+	remaining := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, time.Until(deadline).Round(time.Minute).String())
+	}
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Server.Timeouts = config.TimeoutsConfig{
+			Default: time.Minute,
+			Routes: []config.RouteTimeoutConfig{
+				{Method: http.MethodGet, Timeout: 10 * time.Second},
+				{Path: "/v1/slow", Timeout: 20 * time.Millisecond},
+				{Path: "/v1/sleep", Timeout: time.Second},
+				{Method: http.MethodPut, Path: "/v1/remaining", Timeout: 5 * time.Minute},
+			},
+		}
+	}, WithAPIRoutes(func(api *gin.RouterGroup) error {
+		api.GET("/remaining", remaining)
+		api.POST("/remaining", remaining)
+		api.PUT("/remaining", remaining)
+		api.GET("/slow", func(c *gin.Context) {
+			select {
+			case <-c.Request.Context().Done():
+			case <-time.After(time.Second):
+				c.String(http.StatusOK, "done")
+			}
+		})
+		api.GET("/sleep", func(c *gin.Context) {
+			time.Sleep(200 * time.Millisecond)
+			c.String(http.StatusOK, "done")
+		})
+		return nil
+	}))
+
+	t.Run("routes get the timeout configured for them", func(tt *testing.T) {
+		// by method and path
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/remaining", nil)
+		assert.Equal(tt, "5m0s", w.Body.String())
+		// by method, which rounds to no time left
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/remaining", nil)
+		assert.Equal(tt, "0s", w.Body.String())
+		// by default
+		w = doTestRequest(tt, server.Handler, http.MethodPost, "/v1/remaining", nil)
+		assert.Equal(tt, "1m0s", w.Body.String())
+	})
+
+	t.Run("requests that take longer time out", func(tt *testing.T) {
+		// the timeout of the path outranks the one of the method
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/slow", nil)
+		assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+		var problem framework.ErrorResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&problem))
+		assert.Equal(tt, framework.CodeTimedOut, problem.Code)
+		assert.Equal(tt, "request took longer than 20ms", problem.Detail)
+	})
+
+	t.Run("routes outlast the write timeout of the server", func(tt *testing.T) {
+		ts := httptest.NewUnstartedServer(server.Handler)
+		ts.Config.WriteTimeout = 50 * time.Millisecond
+		ts.Start()
+		defer ts.Close()
+
+		resp, err := ts.Client().Get(ts.URL + "/v1/sleep")
+		require.NoError(tt, err)
+		defer resp.Body.Close()
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+	})
+}
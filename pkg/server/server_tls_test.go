@@ -0,0 +1,145 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+)
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, "test-ca", nil, nil)
+	writeTestPEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	writeTestServerCertificate(t, dir, "server-1", ca, caKey)
+	clientCert, clientKey := newTestCertificate(t, "client", ca, caKey)
+
+	tlsConfig := config.TLSConfig{
+		Enabled:           true,
+		CertFile:          filepath.Join(dir, "server.pem"),
+		KeyFile:           filepath.Join(dir, "server-key.pem"),
+		ClientCAFile:      filepath.Join(dir, "ca.pem"),
+		RequireClientCert: true,
+	}
+
+	t.Run("rejects incomplete config", func(tt *testing.T) {
+		s := framework.NewServer(config.ServerConfig{}, gin.New(), nil)
+		assert.Error(tt, s.EnableTLS(config.TLSConfig{Enabled: true, CertFile: tlsConfig.CertFile}))
+		assert.Error(tt, s.EnableTLS(config.TLSConfig{Enabled: true, CertFile: tlsConfig.CertFile, KeyFile: tlsConfig.KeyFile, RequireClientCert: true}))
+	})
+
+	engine := gin.New()
+	engine.GET(HealthPrefix, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	s := framework.NewServer(config.ServerConfig{}, engine, nil)
+	require.NoError(t, s.EnableTLS(tlsConfig))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = s.Server.ServeTLS(listener, "", "")
+	}()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	get := func(tt *testing.T, withClientCert bool) (*http.Response, error) {
+		clientTLS := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+		if withClientCert {
+			clientTLS.Certificates = []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}}
+		}
+		client := http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS, DisableKeepAlives: true}}
+		resp, err := client.Get("https://" + listener.Addr().String() + HealthPrefix)
+		if err == nil {
+			tt.Cleanup(func() { _ = resp.Body.Close() })
+		}
+		return resp, err
+	}
+
+	t.Run("requires a client certificate", func(tt *testing.T) {
+		_, err := get(tt, false)
+		assert.Error(tt, err)
+
+		resp, err := get(tt, true)
+		require.NoError(tt, err)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, "server-1", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	})
+
+	t.Run("reloads certificates", func(tt *testing.T) {
+		writeTestServerCertificate(tt, dir, "server-2", ca, caKey)
+		require.NoError(tt, s.ReloadCertificates())
+
+		resp, err := get(tt, true)
+		require.NoError(tt, err)
+		assert.Equal(tt, "server-2", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	})
+
+	t.Run("keeps certificates when reloading fails", func(tt *testing.T) {
+		require.NoError(tt, os.WriteFile(tlsConfig.CertFile, []byte("not a certificate"), 0600))
+		assert.Error(tt, s.ReloadCertificates())
+
+		resp, err := get(tt, true)
+		require.NoError(tt, err)
+		assert.Equal(tt, "server-2", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	})
+}
+
+// newTestCertificate creates a certificate signed by the given parent, or a self-signed CA when parent is nil.
+func newTestCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeTestServerCertificate(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) {
+	cert, key := newTestCertificate(t, name, ca, caKey)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	writeTestPEM(t, filepath.Join(dir, "server.pem"), "CERTIFICATE", cert.Raw)
+	writeTestPEM(t, filepath.Join(dir, "server-key.pem"), "EC PRIVATE KEY", keyBytes)
+}
+
+func writeTestPEM(t *testing.T, path, blockType string, data []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600))
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	didint "github.com/tbd54566975/ssi-service/internal/did"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/transparency"
+)
+
+func TestTransparencyAPI(t *testing.T) {
+	newServer := func(t *testing.T, enabled bool) *SSIServer {
+		return newTestServer(t, func(cfg *config.SSIServiceConfig) {
+			cfg.Services.CredentialConfig.BatchCreateMaxItems = 10
+			cfg.Services.TransparencyConfig.Enabled = enabled
+		})
+	}
+	getSignedTreeHead := func(t *testing.T, server *SSIServer) router.GetSignedTreeHeadResponse {
+		w := doTestRequest(t, server.Handler, http.MethodGet, "/v1/transparency/head", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var head router.GetSignedTreeHeadResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&head))
+		return head
+	}
+
+	server := newServer(t, true)
+
+	// the log starts empty, and signs its tree heads with a did:key that auditors can resolve
+	emptyHead := getSignedTreeHead(t, server)
+	assert.Zero(t, emptyHead.TreeSize)
+	assert.Nil(t, emptyHead.Timestamp)
+	keyResolver, err := resolution.NewResolver(key.Resolver{})
+	require.NoError(t, err)
+	resolved, err := keyResolver.Resolve(context.Background(), emptyHead.LogID)
+	require.NoError(t, err)
+	verifyTreeHead := func(t *testing.T, head router.GetSignedTreeHeadResponse) {
+		require.NoError(t, didint.VerifyTokenFromDID(context.Background(), keyResolver, head.LogID, resolved.Document.VerificationMethod[0].ID, head.SignedTreeHead))
+		token, err := jwt.ParseInsecure([]byte(head.SignedTreeHead))
+		require.NoError(t, err)
+		treeSize, _ := token.Get("treeSize")
+		assert.EqualValues(t, head.TreeSize, treeSize)
+		rootHash, _ := token.Get("rootHash")
+		assert.Equal(t, base64.StdEncoding.EncodeToString(head.RootHash), rootHash)
+	}
+	verifyTreeHead(t, emptyHead)
+
+	// credentials are logged when they're issued, and when their status changes
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	createRequest := router.CreateCredentialRequest{
+		Issuer:               issuer.DID.ID,
+		VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+		Subject:              "did:example:alice",
+		Data:                 map[string]any{"firstName": "Alice"},
+		Revocable:            true,
+	}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", createRequest)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials/batch", router.BatchCreateCredentialsRequest{
+		Requests: []router.CreateCredentialRequest{createRequest, createRequest},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doTestRequest(t, server.Handler, http.MethodPut, fmt.Sprintf("/v1/credentials/%s/status", created.ID), router.UpdateCredentialStatusRequest{Revoked: true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	head := getSignedTreeHead(t, server)
+	assert.Equal(t, uint64(4), head.TreeSize)
+	assert.NotNil(t, head.Timestamp)
+	assert.Equal(t, emptyHead.LogID, head.LogID)
+	verifyTreeHead(t, head)
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/transparency/credentials/"+created.ID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var credentialEntries router.ListCredentialTransparencyEntriesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&credentialEntries))
+	require.Len(t, credentialEntries.Entries, 2)
+	credentialHash := sha256.Sum256([]byte(created.CredentialJWT.String()))
+	for i, operation := range []transparency.Operation{transparency.OperationIssued, transparency.OperationRevoked} {
+		entry := credentialEntries.Entries[i]
+		assert.Equal(t, operation, entry.Operation)
+		assert.Equal(t, issuer.DID.ID, entry.Issuer)
+		assert.Equal(t, credentialHash[:], entry.CredentialHash)
+	}
+
+	w = doTestRequest(t, server.Handler, http.MethodGet, "/v1/transparency/entries", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var entries router.ListTransparencyEntriesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&entries))
+	require.Len(t, entries.Entries, 4)
+	assert.Equal(t, credentialEntries.Entries[1], entries.Entries[3])
+
+	t.Run("proves that entries are included in a signed tree head", func(tt *testing.T) {
+		for _, entry := range entries.Entries {
+			w := doTestRequest(tt, server.Handler, http.MethodGet, fmt.Sprintf("/v1/transparency/proofs/inclusion?index=%d&treeSize=%d", entry.Index, head.TreeSize), nil)
+			require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+			var proof router.GetInclusionProofResponse
+			require.NoError(tt, json.NewDecoder(w.Body).Decode(&proof))
+
+			// auditors compute the hash of the leaf from the entry, rather than trusting the one in the proof
+			leafHash, err := entry.LeafHash()
+			require.NoError(tt, err)
+			assert.Equal(tt, leafHash, proof.LeafHash)
+			assert.NoError(tt, transparency.VerifyInclusion(leafHash, entry.Index, head.TreeSize, proof.Path, head.RootHash))
+		}
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/transparency/proofs/inclusion?index=4", nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/transparency/proofs/inclusion?index=0&treeSize=5", nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/transparency/proofs/inclusion", nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("proves that later tree heads extend earlier ones", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials", createRequest)
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		laterHead := getSignedTreeHead(tt, server)
+		require.Equal(tt, uint64(5), laterHead.TreeSize)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, fmt.Sprintf("/v1/transparency/proofs/consistency?first=%d&second=%d", head.TreeSize, laterHead.TreeSize), nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var proof router.GetConsistencyProofResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&proof))
+		assert.NoError(tt, transparency.VerifyConsistency(head.TreeSize, laterHead.TreeSize, proof.Proof, head.RootHash, laterHead.RootHash))
+		assert.Error(tt, transparency.VerifyConsistency(head.TreeSize, laterHead.TreeSize, proof.Proof, emptyHead.RootHash, laterHead.RootHash))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/transparency/proofs/consistency?first=6", nil)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("isn't served unless enabled", func(tt *testing.T) {
+		w := doTestRequest(tt, newServer(tt, false).Handler, http.MethodGet, "/v1/transparency/head", nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+}
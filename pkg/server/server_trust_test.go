@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/trust"
+)
+
+func TestTrustAPI(t *testing.T) {
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.TrustConfig.Enabled = true
+	})
+
+	trustIssuer := func(t *testing.T, request router.CreateTrustedIssuerRequest) trust.TrustedIssuer {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/trust/issuers", request)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp router.CreateTrustedIssuerResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp.TrustedIssuer
+	}
+
+	t.Run("trusted issuers can be registered, listed, and deleted", func(tt *testing.T) {
+		registered := trustIssuer(tt, router.CreateTrustedIssuerRequest{Issuer: "did:example:dmv", CredentialType: "DriversLicense"})
+		assert.Equal(tt, "did:example:dmv", registered.Issuer)
+		assert.Equal(tt, "DriversLicense", registered.CredentialType)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/trust/issuers/"+registered.ID, nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var got router.GetTrustedIssuerResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(tt, registered.ID, got.TrustedIssuer.ID)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/trust/issuers?credentialType=DriversLicense", nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var listed router.ListTrustedIssuersResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&listed))
+		require.Len(tt, listed.TrustedIssuers, 1)
+		assert.Equal(tt, registered.ID, listed.TrustedIssuers[0].ID)
+
+		// an issuer is trusted for a type or a schema, not both, and must be a DID
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/trust/issuers", router.CreateTrustedIssuerRequest{Issuer: "did:example:dmv", CredentialType: "DriversLicense", SchemaID: "schema"})
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/trust/issuers", router.CreateTrustedIssuerRequest{Issuer: "dmv", CredentialType: "DriversLicense"})
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+
+		w = doTestRequest(tt, server.Handler, http.MethodDelete, "/v1/trust/issuers/"+registered.ID, nil)
+		assert.Equal(tt, http.StatusNoContent, w.Code)
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/trust/issuers/"+registered.ID, nil)
+		assert.Equal(tt, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("trust lists are imported in place of their previous versions", func(tt *testing.T) {
+		train := func(dids ...string) json.RawMessage {
+			var services []any
+			for _, did := range dids {
+				services = append(services, map[string]any{"ServiceInformation": map[string]any{
+					"ServiceStatus":          "http://uri.etsi.org/TrstSvc/TrustedList/Svcstatus/granted",
+					"ServiceDigitalIdentity": map[string]any{"DigitalId": map[string]any{"DID": did}},
+				}})
+			}
+			list, err := json.Marshal(map[string]any{"TrustServiceStatusList": map[string]any{"TrustServiceProviderList": map[string]any{
+				"TrustServiceProvider": map[string]any{"TSPServices": map[string]any{"TSPService": services}},
+			}}})
+			require.NoError(tt, err)
+			return list
+		}
+		importList := func(list json.RawMessage) router.ImportTrustListResponse {
+			w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/trust/imports", router.ImportTrustListRequest{
+				Format:    trust.TrustListFormatTRAIN,
+				Source:    "https://train.example.com/scheme",
+				TrustList: list,
+				SchemaID:  "https://example.com/schemas/membership",
+			})
+			require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+			var resp router.ImportTrustListResponse
+			require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+			return resp
+		}
+
+		imported := importList(train("did:web:first.example.com", "did:web:second.example.com"))
+		assert.Len(tt, imported.TrustedIssuers, 2)
+		assert.Zero(tt, imported.Replaced)
+		imported = importList(train("did:web:second.example.com"))
+		require.Len(tt, imported.TrustedIssuers, 1)
+		assert.Equal(tt, "https://example.com/schemas/membership", imported.TrustedIssuers[0].SchemaID)
+		assert.Equal(tt, 2, imported.Replaced)
+
+		w := doTestRequest(tt, server.Handler, http.MethodGet, "/v1/trust/issuers?source=https://train.example.com/scheme", nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var listed router.ListTrustedIssuersResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&listed))
+		require.Len(tt, listed.TrustedIssuers, 1)
+		assert.Equal(tt, "did:web:second.example.com", listed.TrustedIssuers[0].Issuer)
+
+		// train trust lists don't say what their issuers are trusted for
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/trust/imports", router.ImportTrustListRequest{
+			Format:    trust.TrustListFormatTRAIN,
+			Source:    "https://train.example.com/scheme",
+			TrustList: train("did:web:first.example.com"),
+		})
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("credentials are verified against the issuers trusted for their type", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var issuer router.CreateDIDByMethodResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&issuer))
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials", router.CreateCredentialRequest{
+			Issuer:               issuer.DID.ID,
+			VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+			Subject:              "did:example:alice",
+			Data:                 map[string]any{"firstName": "Alice"},
+		})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateCredentialResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&created))
+		verify := func() router.VerifyCredentialResponse {
+			w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials/verification", router.VerifyCredentialRequest{CredentialJWT: created.CredentialJWT})
+			require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+			var resp router.VerifyCredentialResponse
+			require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+			return resp
+		}
+		trustCheck := func(resp router.VerifyCredentialResponse) *credint.CheckResult {
+			for _, check := range resp.Checks {
+				if check.Check == credint.CheckTrust {
+					return &check
+				}
+			}
+			return nil
+		}
+
+		other := trustIssuer(tt, router.CreateTrustedIssuerRequest{Issuer: "did:example:other", CredentialType: credsdk.VerifiableCredentialType})
+		resp := verify()
+		assert.False(tt, resp.Verified)
+		check := trustCheck(resp)
+		require.NotNil(tt, check)
+		assert.False(tt, check.Passed)
+		assert.Contains(tt, check.Reason, fmt.Sprintf("issuer<%s> is not trusted for credential type<VerifiableCredential>", issuer.DID.ID))
+
+		trusted := trustIssuer(tt, router.CreateTrustedIssuerRequest{Issuer: issuer.DID.ID, CredentialType: credsdk.VerifiableCredentialType})
+		resp = verify()
+		assert.True(tt, resp.Verified, resp.Reason)
+		check = trustCheck(resp)
+		require.NotNil(tt, check)
+		assert.True(tt, check.Passed)
+
+		for _, id := range []string{other.ID, trusted.ID} {
+			w = doTestRequest(tt, server.Handler, http.MethodDelete, "/v1/trust/issuers/"+id, nil)
+			require.Equal(tt, http.StatusNoContent, w.Code)
+		}
+	})
+
+	t.Run("submissions are evaluated against the issuers trusted for their credentials", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/definitions", router.CreatePresentationDefinitionRequest{
+			Name:    "name",
+			Purpose: "purpose",
+			InputDescriptors: []exchange.InputDescriptor{{
+				ID: "wa_driver_license",
+				Constraints: &exchange.Constraints{
+					Fields: []exchange.Field{{Path: []string{"$.vc.credentialSubject.dateOfBirth"}}},
+				},
+			}},
+		})
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var definition router.CreatePresentationDefinitionResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&definition))
+
+		// the issuer of the submitted credential is made up, so it can't be one the license is trusted from
+		trustIssuer(tt, router.CreateTrustedIssuerRequest{Issuer: "did:example:dmv", CredentialType: "DriversLicense"})
+		holderSigner, holderDID := getSigner(tt)
+		license := VerifiableCredential(func(vc *credsdk.VerifiableCredential) {
+			vc.Type = []string{credsdk.VerifiableCredentialType, "DriversLicense"}
+		})
+		request := createSubmissionRequest(tt, definition.PresentationDefinition.ID, "did:example:verifier", license, holderSigner, holderDID)
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/presentations/submissions", request)
+		require.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+		var op router.Operation
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&op))
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, "/v1/presentations/submissions/"+opstorage.StatusObjectID(op.ID), nil)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.GetSubmissionResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		require.NotNil(tt, resp.Evaluation)
+		assert.False(tt, resp.Evaluation.Satisfied)
+		require.Len(tt, resp.Evaluation.InputDescriptors, 1)
+		descriptor := resp.Evaluation.InputDescriptors[0]
+		assert.False(tt, descriptor.Satisfied)
+		assert.True(tt, descriptor.Fields[0].Satisfied)
+		require.NotNil(tt, descriptor.TrustedIssuer)
+		assert.False(tt, descriptor.TrustedIssuer.Satisfied)
+		assert.Contains(tt, descriptor.TrustedIssuer.Reason, "is not trusted for credential type<DriversLicense>")
+	})
+}
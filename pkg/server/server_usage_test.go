@@ -0,0 +1,135 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/framework"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/usage"
+)
+
+func TestUsageMetering(t *testing.T) {
+	adminKey := "bootstrap-secret"
+	server := newTestServer(t, func(cfg *config.SSIServiceConfig) {
+		cfg.Services.AuthConfig.AdminAPIKeyHash = auth.HashAPIKey(adminKey)
+		cfg.Services.CredentialConfig.BatchCreateMaxItems = 10
+		cfg.Server.EnableAPIKeyAuth = true
+		cfg.Server.Usage = config.UsageConfig{
+			Enabled: true,
+			Quotas: []config.QuotaConfig{
+				{Meter: string(usage.MeterIssuance), Limit: 3, Per: middleware.QuotaPerPrincipal},
+				{Meter: string(usage.MeterVerification), Limit: 1},
+			},
+		}
+	})
+	// reloads start out from the config the server was created with
+	serviceConfig := server.cfg
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, time.October, 31, 23, 0, 0, 0, time.UTC))
+	server.SSIService.Usage.Clock = mockClock
+
+	createKey := func(name string) router.CreateAPIKeyResponse {
+		w := doTestRequest(t, server.Handler, http.MethodPut, AdminPrefix+APIKeysPrefix, router.CreateAPIKeyRequest{Name: name}, middleware.APIKeyHeader, adminKey)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created router.CreateAPIKeyResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		return created
+	}
+	alice, bob := createKey("alice"), createKey("bob")
+
+	w := doTestRequest(t, server.Handler, http.MethodPut, "/v1/dids/key", router.CreateDIDByMethodRequest{KeyType: crypto.Ed25519}, middleware.APIKeyHeader, alice.Key)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issuer router.CreateDIDByMethodResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&issuer))
+	createCredential := router.CreateCredentialRequest{
+		Issuer:               issuer.DID.ID,
+		VerificationMethodID: issuer.DID.VerificationMethod[0].ID,
+		Subject:              "did:example:alice",
+		Data:                 map[string]any{"firstName": "Jack"},
+	}
+	issue := func(apiKey string) *httptest.ResponseRecorder {
+		return doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials", createCredential, middleware.APIKeyHeader, apiKey)
+	}
+
+	// alice issues a credential, then a batch of two
+	w = issue(alice.Key)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var credential router.CreateCredentialResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&credential))
+	batch := router.BatchCreateCredentialsRequest{Requests: []router.CreateCredentialRequest{createCredential, createCredential}}
+	w = doTestRequest(t, server.Handler, http.MethodPut, "/v1/credentials/batch", batch, middleware.APIKeyHeader, alice.Key)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("rejects calls over a quota until the month ends", func(tt *testing.T) {
+		w := issue(alice.Key)
+		require.Equal(tt, http.StatusTooManyRequests, w.Code, w.Body.String())
+		assert.Equal(tt, "3600", w.Header().Get(middleware.RetryAfterHeader))
+		var problem framework.ErrorResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&problem))
+		assert.Equal(tt, framework.CodeQuotaExceeded, problem.Code)
+
+		// the batch would go over the quota as a whole
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials/batch", batch, middleware.APIKeyHeader, alice.Key)
+		assert.Equal(tt, http.StatusTooManyRequests, w.Code, w.Body.String())
+	})
+
+	t.Run("quotas per principal are kept for each api key", func(tt *testing.T) {
+		w := issue(bob.Key)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("quotas per tenant are shared by its api keys", func(tt *testing.T) {
+		verify := router.VerifyCredentialRequest{CredentialJWT: credential.CredentialJWT}
+		w := doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials/verification", verify, middleware.APIKeyHeader, alice.Key)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+
+		w = doTestRequest(tt, server.Handler, http.MethodPut, "/v1/credentials/verification", verify, middleware.APIKeyHeader, bob.Key)
+		assert.Equal(tt, http.StatusTooManyRequests, w.Code, w.Body.String())
+	})
+
+	t.Run("reports usage per tenant and principal", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+UsagePrefix+"?period=2023-10&meter=issuance", nil, middleware.APIKeyHeader, adminKey)
+		require.Equal(tt, http.StatusOK, w.Code, w.Body.String())
+		var resp router.ListUsageResponse
+		require.NoError(tt, json.NewDecoder(w.Body).Decode(&resp))
+		counts := make(map[string]int64)
+		for _, record := range resp.Records {
+			assert.Equal(tt, "2023-10", record.Period)
+			assert.Equal(tt, usage.MeterIssuance, record.Meter)
+			counts[record.Principal] = record.Count
+		}
+		assert.Equal(tt, map[string]int64{"": 4, alice.APIKey.ID: 3, bob.APIKey.ID: 1}, counts)
+
+		w = doTestRequest(tt, server.Handler, http.MethodGet, AdminPrefix+UsagePrefix+"?meter=bandwidth", nil, middleware.APIKeyHeader, adminKey)
+		assert.Equal(tt, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("quotas reload", func(tt *testing.T) {
+		invalid := serviceConfig
+		invalid.Server.Usage.ExceededStatus = http.StatusInternalServerError
+		assert.Error(tt, server.Reload(invalid))
+
+		updated := serviceConfig
+		updated.Server.Usage.ExceededStatus = http.StatusPaymentRequired
+		require.NoError(tt, server.Reload(updated))
+		assert.Equal(tt, http.StatusPaymentRequired, issue(alice.Key).Code)
+	})
+
+	t.Run("usage starts over each month", func(tt *testing.T) {
+		mockClock.Add(time.Hour)
+		w := issue(alice.Key)
+		assert.Equal(tt, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/server/middleware"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+func TestAPIVersions(t *testing.T) {
+	newServer := func(t *testing.T, deprecations ...config.DeprecationConfig) (*SSIServer, error) {
+		shutdown := make(chan os.Signal, 1)
+		serviceConfig, err := config.LoadConfig("", nil)
+		require.NoError(t, err)
+		serviceConfig.Services.StorageOptions = []storage.Option{
+			{
+				ID:     storage.BoltDBFilePathOption,
+				Option: tempBoltFileName(t),
+			},
+		}
+		serviceConfig.Server.Deprecations = deprecations
+		return NewSSIServer(shutdown, *serviceConfig)
+	}
+	get := func(server *SSIServer, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("serves every version without deprecation headers", func(tt *testing.T) {
+		server, err := newServer(tt)
+		require.NoError(tt, err)
+
+		for _, version := range APIVersions {
+			w := get(server, version+"/dids")
+			assert.Equal(tt, http.StatusOK, w.Code)
+			assert.Empty(tt, w.Header().Get(middleware.DeprecationHeader))
+			assert.Empty(tt, w.Header().Get(middleware.SunsetHeader))
+		}
+	})
+
+	t.Run("announces deprecated versions", func(tt *testing.T) {
+		server, err := newServer(tt, config.DeprecationConfig{
+			Version: "v1",
+			Date:    "2024-01-31",
+			Sunset:  "2024-07-31T12:00:00Z",
+			Link:    "https://example.com/migrating-to-v2",
+		})
+		require.NoError(tt, err)
+
+		w := get(server, "/v1/dids")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Equal(tt, "@1706659200", w.Header().Get(middleware.DeprecationHeader))
+		assert.Equal(tt, "Wed, 31 Jul 2024 12:00:00 GMT", w.Header().Get(middleware.SunsetHeader))
+		assert.Equal(tt, []string{
+			`<https://example.com/migrating-to-v2>; rel="deprecation"; type="text/html"`,
+			`</v2/dids>; rel="successor-version"`,
+		}, w.Header().Values("Link"))
+
+		// errors of deprecated versions are announced too
+		w = get(server, "/v1/dids/key/did:key:unknown")
+		assert.NotEqual(tt, http.StatusOK, w.Code)
+		assert.NotEmpty(tt, w.Header().Get(middleware.DeprecationHeader))
+
+		w = get(server, "/v2/dids")
+		assert.Equal(tt, http.StatusOK, w.Code)
+		assert.Empty(tt, w.Header().Get(middleware.DeprecationHeader))
+		assert.Empty(tt, w.Header().Values("Link"))
+	})
+
+	t.Run("rejects invalid deprecations", func(tt *testing.T) {
+		_, err := newServer(tt, config.DeprecationConfig{Version: "v0", Date: "2024-01-31"})
+		assert.ErrorContains(tt, err, "unknown api version")
+
+		_, err = newServer(tt, config.DeprecationConfig{Version: "v1", Date: "January"})
+		assert.ErrorContains(tt, err, "parsing deprecation date")
+
+		_, err = newServer(tt, config.DeprecationConfig{Version: "v1", Date: "2024-01-31", Sunset: "2023-01-31"})
+		assert.ErrorContains(tt, err, "sunset of v1 is before it is deprecated")
+	})
+}
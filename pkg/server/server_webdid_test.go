@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/tbd54566975/ssi-service/pkg/server/router"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+)
+
+func TestWebDIDHosting(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	// the DIDs don't resolve anywhere else before they're created
+	defer gock.Off()
+	gock.New("https://example.com").
+		Get("/.well-known/did.json").
+		Reply(http.StatusNotFound)
+	gock.New("https://example.com").
+		Get("/users/alice/did.json").
+		Reply(http.StatusNotFound)
+	for _, id := range []string{"did:web:example.com", "did:web:example.com:users:alice"} {
+		w := doTestRequest(t, server.Handler, http.MethodPut, "https://ssi-service.com/v1/dids/web", router.CreateDIDByMethodRequest{
+			KeyType: crypto.Ed25519,
+			Options: did.CreateWebDIDOptions{DIDWebID: id},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	getDocument := func(url string) (int, didsdk.Document) {
+		w := doTestRequest(t, server.Handler, http.MethodGet, url, nil)
+		var doc didsdk.Document
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+		}
+		return w.Code, doc
+	}
+
+	t.Run("serves the document of a DID of a domain", func(tt *testing.T) {
+		code, doc := getDocument("https://example.com/.well-known/did.json")
+		assert.Equal(tt, http.StatusOK, code)
+		assert.Equal(tt, "did:web:example.com", doc.ID)
+		assert.NotEmpty(tt, doc.VerificationMethod)
+	})
+
+	t.Run("serves the document of a DID with a path", func(tt *testing.T) {
+		code, doc := getDocument("https://example.com/users/alice/did.json")
+		assert.Equal(tt, http.StatusOK, code)
+		assert.Equal(tt, "did:web:example.com:users:alice", doc.ID)
+	})
+
+	t.Run("doesn't serve the DIDs of other hosts, or DIDs it doesn't host", func(tt *testing.T) {
+		code, _ := getDocument("https://other.example.com/.well-known/did.json")
+		assert.Equal(tt, http.StatusNotFound, code)
+
+		code, _ = getDocument("https://example.com/users/bob/did.json")
+		assert.Equal(tt, http.StatusNotFound, code)
+
+		code, _ = getDocument("https://example.com/users/alice/other.json")
+		assert.Equal(tt, http.StatusNotFound, code)
+	})
+
+	t.Run("stops serving the document of a deleted DID", func(tt *testing.T) {
+		w := doTestRequest(tt, server.Handler, http.MethodDelete, "https://ssi-service.com/v1/dids/web/did:web:example.com:users:alice", nil)
+		require.Equal(tt, http.StatusNoContent, w.Code, w.Body.String())
+
+		code, _ := getDocument("https://example.com/users/alice/did.json")
+		assert.Equal(tt, http.StatusNotFound, code)
+	})
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// newTestServer creates an SSI server that keeps its data in a bolt file of the test's own, with the default config
+// changed by configure, which may be nil.
+func newTestServer(t *testing.T, configure func(cfg *config.SSIServiceConfig), opts ...Option) *SSIServer {
+	t.Helper()
+	serviceConfig, err := config.LoadConfig("", nil)
+	require.NoError(t, err)
+	serviceConfig.Services.StorageOptions = []storage.Option{
+		{
+			ID:     storage.BoltDBFilePathOption,
+			Option: tempBoltFileName(t),
+		},
+	}
+	if configure != nil {
+		configure(serviceConfig)
+	}
+	server, err := NewSSIServer(make(chan os.Signal, 1), *serviceConfig, opts...)
+	require.NoError(t, err)
+	return server
+}
+
+// doTestRequest serves a request to handler, with body as JSON unless it's nil, and with headers given as pairs of a
+// name and a value. Headers with an empty value are left out.
+func doTestRequest(t *testing.T, handler http.Handler, method, path string, body any, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, path, newRequestValue(t, body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
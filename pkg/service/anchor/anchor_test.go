@@ -0,0 +1,156 @@
+package anchor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+// newCalendar returns an opentimestamps calendar that answers each digest with a pending timestamp.
+func newCalendar(t *testing.T) *httptest.Server {
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/digest", r.URL.Path)
+		assert.Equal(t, otsAccept, r.Header.Get("Accept"))
+		digest, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Len(t, digest, 32)
+		_, _ = w.Write([]byte("\xf0\x10pending-timestamp\x08\x00"))
+	}))
+	t.Cleanup(calendar.Close)
+	return calendar
+}
+
+func TestNewAnchorService(t *testing.T) {
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	for name, cfg := range map[string]config.AnchorServiceConfig{
+		"no url":           {Type: "rfc3161"},
+		"unknown type":     {Type: "ion", URL: "https://ion.example.com"},
+		"empty namespace":  {Type: "rfc3161", URL: "https://tsa.example.com", Namespaces: []string{""}},
+		"invalid tenant":   {Type: "rfc3161", URL: "https://tsa.example.com", Tenants: []string{"a b"}},
+		"missing roots":    {Type: "rfc3161", URL: "https://tsa.example.com", CertificatesPath: "does-not-exist.pem"},
+		"negative to keep": {Type: "opentimestamps", URL: "https://calendar.example.com", Keep: -1},
+	} {
+		_, err := NewAnchorService(cfg, db, storage.NewTenantWrapper(db))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAnchorAndVerify(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			globalStorage := test.ServiceStorage(t)
+			tenantStorage := storage.NewTenantWrapper(globalStorage)
+			s, err := NewAnchorService(config.AnchorServiceConfig{
+				Namespaces: []string{"credential", "audit"},
+				Tenants:    []string{"acme"},
+				Type:       "opentimestamps",
+				URL:        newCalendar(t).URL,
+				Keep:       2,
+			}, globalStorage, tenantStorage)
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+			s.Clock = mockClock
+
+			acme := storage.WithTenant(ctx, "acme")
+			require.NoError(t, tenantStorage.Write(ctx, "credential", "cred-1", []byte(`{"id":"cred-1"}`)))
+			require.NoError(t, tenantStorage.Write(ctx, "credential", "cred-2", []byte(`{"id":"cred-2"}`)))
+			require.NoError(t, tenantStorage.Write(ctx, "audit", "event-1", []byte(`{"id":"event-1"}`)))
+			require.NoError(t, tenantStorage.Write(acme, "credential", "cred-3", []byte(`{"id":"cred-3"}`)))
+
+			anchor, err := s.Anchor(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, TypeOpenTimestamps, anchor.Type)
+			assert.Equal(t, []NamespaceDigest{
+				{Namespace: "credential", Records: 2, Digest: anchor.Namespaces[0].Digest},
+				{Namespace: "audit", Records: 1, Digest: anchor.Namespaces[1].Digest},
+				{Tenant: "acme", Namespace: "credential", Records: 1, Digest: anchor.Namespaces[2].Digest},
+				{Tenant: "acme", Namespace: "audit", Records: 0, Digest: anchor.Namespaces[3].Digest},
+			}, anchor.Namespaces)
+			assert.NotEqual(t, anchor.Namespaces[0].Digest, anchor.Namespaces[2].Digest)
+
+			verification, err := s.Verify(ctx, anchor.ID)
+			require.NoError(t, err)
+			assert.True(t, verification.Intact)
+			assert.True(t, verification.Unchanged)
+			assert.Equal(t, ReceiptExternal, verification.Receipt)
+			assert.Len(t, verification.Namespaces, 4)
+
+			// added records are expected, while changed and removed ones are evident
+			require.NoError(t, tenantStorage.Write(ctx, "credential", "cred-1", []byte(`{"id":"cred-1","tampered":true}`)))
+			require.NoError(t, tenantStorage.Write(ctx, "credential", "cred-4", []byte(`{"id":"cred-4"}`)))
+			require.NoError(t, tenantStorage.Delete(acme, "credential", "cred-3"))
+			verification, err = s.Verify(ctx, anchor.ID)
+			require.NoError(t, err)
+			assert.True(t, verification.Intact)
+			assert.False(t, verification.Unchanged)
+			assert.Equal(t, NamespaceChanges{Namespace: "credential", Added: 1, Changed: []string{"cred-1"}}, verification.Namespaces[0])
+			assert.Equal(t, NamespaceChanges{Namespace: "audit"}, verification.Namespaces[1])
+			assert.Equal(t, NamespaceChanges{Tenant: "acme", Namespace: "credential", Removed: []string{"cred-3"}}, verification.Namespaces[2])
+
+			// hashes of records changed to hide changes no longer add up to the anchored digest
+			records, err := s.storage.GetRecords(ctx, anchor.ID, anchor.Namespaces[0])
+			require.NoError(t, err)
+			records["cred-1"] = recordHash("cred-1", []byte(`{"id":"cred-1","tampered":true}`))
+			recordBytes, err := json.Marshal(records)
+			require.NoError(t, err)
+			require.NoError(t, globalStorage.Write(ctx, recordNamespace, recordKey(anchor.ID, anchor.Namespaces[0]), recordBytes))
+			verification, err = s.Verify(ctx, anchor.ID)
+			require.NoError(t, err)
+			assert.False(t, verification.Intact)
+
+			// receipts must be of the anchored digest
+			anchor.Receipt = anchor.Receipt[:len(anchor.Receipt)-20]
+			anchor.Digest = anchor.Namespaces[0].Digest
+			anchorBytes, err := json.Marshal(anchor)
+			require.NoError(t, err)
+			require.NoError(t, globalStorage.Write(ctx, anchorNamespace, anchor.ID, anchorBytes))
+			verification, err = s.Verify(ctx, anchor.ID)
+			require.NoError(t, err)
+			assert.Equal(t, ReceiptInvalid, verification.Receipt)
+			assert.NotEmpty(t, verification.ReceiptError)
+
+			_, err = s.Verify(ctx, "unknown")
+			assert.ErrorIs(t, err, ErrAnchorNotFound)
+		})
+	}
+}
+
+func TestKeep(t *testing.T) {
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	s, err := NewAnchorService(config.AnchorServiceConfig{Type: "opentimestamps", URL: newCalendar(t).URL, Keep: 2}, db, storage.NewTenantWrapper(db))
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	s.Clock = mockClock
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		mockClock.Add(time.Hour)
+		anchor, err := s.Anchor(context.Background())
+		require.NoError(t, err)
+		ids = append(ids, anchor.ID)
+	}
+	resp, err := s.ListAnchors(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resp.Anchors, 2)
+	assert.Equal(t, ids[2], resp.Anchors[0].ID)
+	assert.Equal(t, ids[1], resp.Anchors[1].ID)
+	_, err = s.GetAnchor(context.Background(), ids[0])
+	assert.ErrorIs(t, err, ErrAnchorNotFound)
+	records, err := s.storage.GetRecords(context.Background(), ids[0], NamespaceDigest{Namespace: "audit"})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
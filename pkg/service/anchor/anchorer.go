@@ -0,0 +1,108 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/timestamp"
+)
+
+// errVerifyExternally is returned when a receipt covers a digest, but its attestation can only be verified with the
+// tooling of its anchor.
+var errVerifyExternally = errors.New("receipt covers the digest; verify its attestation with the tooling of its anchor")
+
+// anchorer anchors SHA-256 digests to an external anchor, and verifies the receipts it returns.
+type anchorer interface {
+	// Anchor anchors the digest, and returns the receipt of the anchor.
+	Anchor(ctx context.Context, digest []byte) ([]byte, error)
+
+	// Verify checks that the receipt attests to the digest, and returns the time it attests the digest existed at, if
+	// any. It returns errVerifyExternally when the receipt covers the digest but can't be verified locally.
+	Verify(receipt, digest []byte) (*time.Time, error)
+}
+
+// timestampingAuthority anchors digests to a timestamping authority, as defined in RFC 3161.
+type timestampingAuthority struct {
+	client *http.Client
+	url    string
+
+	// roots timestamps are verified with. The system roots when nil.
+	roots *x509.CertPool
+}
+
+func (a timestampingAuthority) Anchor(ctx context.Context, digest []byte) ([]byte, error) {
+	return timestamp.Stamp(ctx, a.client, a.url, digest)
+}
+
+func (a timestampingAuthority) Verify(receipt, digest []byte) (*time.Time, error) {
+	ts, err := timestamp.Verify(receipt, digest, a.roots)
+	if err != nil {
+		return nil, err
+	}
+	return &ts.Time, nil
+}
+
+const (
+	// otsAccept is the media type opentimestamps calendars answer with.
+	otsAccept = "application/vnd.opentimestamps.v1"
+	// otsSHA256 is the tag of the SHA-256 operation, which .ots files start with when they timestamp a SHA-256 digest.
+	otsSHA256 = 0x08
+	otsMajor  = 0x01
+)
+
+// otsMagic is the header of .ots files.
+var otsMagic = []byte("\x00OpenTimestamps\x00\x00Proof\x00\xbf\x89\xe2\xe8\x84\xe8\x92\x94")
+
+// calendar anchors digests to an opentimestamps calendar. The calendar answers with a pending timestamp, which it
+// commits to the bitcoin chain within hours; receipts are .ots files of the digest, which the opentimestamps client
+// upgrades, and verifies against the chain, once it has.
+type calendar struct {
+	client *http.Client
+	url    string
+}
+
+func (c calendar) Anchor(ctx context.Context, digest []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.url, "/")+"/digest", bytes.NewReader(digest))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating calendar request")
+	}
+	req.Header.Set("Accept", otsAccept)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "submitting digest to calendar")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("calendar answered %d", resp.StatusCode)
+	}
+	pending, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading calendar response")
+	}
+	if len(pending) == 0 {
+		return nil, errors.New("calendar answered without a timestamp")
+	}
+	receipt := make([]byte, 0, len(otsMagic)+2+len(digest)+len(pending))
+	receipt = append(receipt, otsMagic...)
+	receipt = append(receipt, otsMajor, otsSHA256)
+	receipt = append(receipt, digest...)
+	return append(receipt, pending...), nil
+}
+
+func (c calendar) Verify(receipt, digest []byte) (*time.Time, error) {
+	header := make([]byte, 0, len(otsMagic)+2+len(digest))
+	header = append(header, otsMagic...)
+	header = append(header, otsMajor, otsSHA256)
+	header = append(header, digest...)
+	if !bytes.HasPrefix(receipt, header) || len(receipt) == len(header) {
+		return nil, errors.New("receipt isn't an opentimestamps file of the digest")
+	}
+	return nil, errVerifyExternally
+}
@@ -0,0 +1,106 @@
+package anchor
+
+import (
+	"time"
+)
+
+// Type is a type of external anchor digests of state are anchored to.
+type Type string
+
+const (
+	// TypeRFC3161 anchors digests to a timestamping authority, as defined in RFC 3161. Its receipts are timestamp
+	// tokens, which are verified with the root certificates of the authority.
+	TypeRFC3161 Type = "rfc3161"
+	// TypeOpenTimestamps anchors digests to an opentimestamps calendar, which commits to them in the bitcoin chain.
+	// Its receipts are .ots files, which are upgraded and verified against the chain with the opentimestamps client.
+	TypeOpenTimestamps Type = "opentimestamps"
+)
+
+// IsValid returns whether the type is one that's supported.
+func (t Type) IsValid() bool {
+	return t == TypeRFC3161 || t == TypeOpenTimestamps
+}
+
+// DefaultNamespaces are the namespaces whose records are hashed when none are configured: credentials, status list
+// credentials, and the audit log.
+var DefaultNamespaces = []string{"credential", "status-list-credential", "audit"}
+
+// NamespaceDigest is the digest of the records of a namespace of a tenant, as they were when they were anchored.
+type NamespaceDigest struct {
+	// Tenant of the namespace. Empty for the default tenant.
+	Tenant    string `json:"tenant,omitempty"`
+	Namespace string `json:"namespace"`
+	Records   int    `json:"records"`
+
+	// Hex encoded SHA-256 digest of the hashes of the records, ordered by key.
+	Digest string `json:"digest"`
+}
+
+// Anchor is a digest of the state of the anchored namespaces, and the receipt of the external anchor it was anchored
+// to.
+type Anchor struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Hex encoded SHA-256 digest of the digests of the namespaces, which is what was anchored.
+	Digest     string            `json:"digest"`
+	Namespaces []NamespaceDigest `json:"namespaces"`
+
+	Type Type   `json:"type"`
+	URL  string `json:"url"`
+
+	// Receipt of the anchor, base64 encoded: an RFC 3161 timestamp token, or an .ots file.
+	Receipt []byte `json:"receipt"`
+}
+
+type ListAnchorsResponse struct {
+	Anchors []Anchor `json:"anchors"`
+}
+
+// ReceiptStatus is what verifying the receipt of an anchor found.
+type ReceiptStatus string
+
+const (
+	// ReceiptVerified is the status of receipts that were verified to attest to the digest.
+	ReceiptVerified ReceiptStatus = "verified"
+	// ReceiptExternal is the status of receipts that cover the digest, but whose attestation can only be verified
+	// with the tooling of the anchor, e.g. opentimestamps receipts, which are verified against the bitcoin chain.
+	ReceiptExternal ReceiptStatus = "external"
+	// ReceiptInvalid is the status of receipts that don't attest to the digest.
+	ReceiptInvalid ReceiptStatus = "invalid"
+)
+
+// NamespaceChanges are the changes to the records of a namespace of a tenant since they were anchored.
+type NamespaceChanges struct {
+	Tenant    string `json:"tenant,omitempty"`
+	Namespace string `json:"namespace"`
+
+	// Number of records that were added since.
+	Added int `json:"added"`
+
+	// Keys of the records that were changed since.
+	Changed []string `json:"changed,omitempty"`
+
+	// Keys of the records that were removed since.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Verification is what verifying an anchor against the current state of its namespaces found.
+type Verification struct {
+	AnchorID   string    `json:"anchorId"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+
+	// Whether the hashes of the records kept with the anchor still add up to its digest, so that the changes are
+	// relative to the state that was anchored.
+	Intact bool `json:"intact"`
+
+	Receipt      ReceiptStatus `json:"receipt"`
+	ReceiptError string        `json:"receiptError,omitempty"`
+
+	// Time the timestamping authority attests the digest existed at, for verified RFC 3161 receipts.
+	AnchoredAt *time.Time `json:"anchoredAt,omitempty"`
+
+	// Whether none of the anchored records were changed or removed since. Records added since don't count.
+	Unchanged  bool               `json:"unchanged"`
+	Namespaces []NamespaceChanges `json:"namespaces"`
+}
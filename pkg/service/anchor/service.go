@@ -0,0 +1,418 @@
+package anchor
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultInterval is how often state is anchored when no interval is configured.
+	DefaultInterval = 24 * time.Hour
+
+	// anchorTimeout is how long the external anchor has to answer.
+	anchorTimeout = 30 * time.Second
+)
+
+// ErrAnchorNotFound is returned when no anchor exists with the requested ID.
+var ErrAnchorNotFound = errors.New("anchor not found")
+
+// Service anchors digests of the state of critical namespaces to an external anchor, so that records changed or
+// removed after they were anchored are evident. Each record is hashed, the hashes of the records of each namespace are
+// digested, and the digests of the namespaces are digested into the digest that is anchored. The hashes of the records
+// are kept with each anchor, so that verifying it tells which records changed since.
+type Service struct {
+	storage    *Storage
+	anchorer   anchorer
+	anchorType Type
+	url        string
+	roots      *x509.CertPool
+	namespaces []string
+	tenants    []string
+	interval   time.Duration
+	keep       int
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Anchor
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.anchorer == nil {
+		ae.AppendString("no anchor configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("anchor service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewAnchorService creates the anchor service. Anchors, and the hashes of the records they cover, are kept in
+// globalStorage, while records are read from the tenants of tenantStorage.
+func NewAnchorService(cfg config.AnchorServiceConfig, globalStorage, tenantStorage storage.ServiceStorage) (*Service, error) {
+	anchorStorage, err := NewAnchorStorage(globalStorage, tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the anchor service")
+	}
+	if cfg.URL == "" {
+		return nil, sdkutil.LoggingNewError("anchor url must be set")
+	}
+	var roots *x509.CertPool
+	if cfg.CertificatesPath != "" {
+		pemBytes, err := os.ReadFile(cfg.CertificatesPath)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not read anchor certificates: %s", cfg.CertificatesPath)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pemBytes) {
+			return nil, sdkutil.LoggingNewErrorf("no certificates in %s", cfg.CertificatesPath)
+		}
+	}
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	var a anchorer
+	switch Type(cfg.Type) {
+	case TypeRFC3161:
+		a = timestampingAuthority{client: client, url: cfg.URL, roots: roots}
+	case TypeOpenTimestamps:
+		a = calendar{client: client, url: cfg.URL}
+	default:
+		return nil, sdkutil.LoggingNewErrorf("unknown type of anchor: %q", cfg.Type)
+	}
+	namespaces := cfg.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = DefaultNamespaces
+	}
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			return nil, sdkutil.LoggingNewError("anchored namespaces can't be empty")
+		}
+	}
+	for _, tenant := range cfg.Tenants {
+		if !storage.IsValidTenantID(tenant) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant)
+		}
+	}
+	if cfg.Keep < 0 {
+		return nil, sdkutil.LoggingNewErrorf("number of anchors kept can't be negative: %d", cfg.Keep)
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	service := Service{
+		storage:    anchorStorage,
+		anchorer:   a,
+		anchorType: Type(cfg.Type),
+		url:        cfg.URL,
+		roots:      roots,
+		namespaces: namespaces,
+		tenants:    cfg.Tenants,
+		interval:   interval,
+		keep:       cfg.Keep,
+		Clock:      clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// verifierFor returns what verifies the receipts of anchors of a type, which may not be the type anchors are made with
+// now.
+func (s Service) verifierFor(anchorType Type) (anchorer, error) {
+	switch anchorType {
+	case TypeRFC3161:
+		return timestampingAuthority{roots: s.roots}, nil
+	case TypeOpenTimestamps:
+		return calendar{}, nil
+	}
+	return nil, errors.Errorf("unknown type of anchor: %q", anchorType)
+}
+
+// writeField writes a length prefixed field to h, so that digests of consecutive fields are unambiguous.
+func writeField(h hash.Hash, field []byte) {
+	var length [binary.MaxVarintLen64]byte
+	h.Write(length[:binary.PutUvarint(length[:], uint64(len(field)))])
+	h.Write(field)
+}
+
+// recordHash returns the hex encoded hash of a record.
+func recordHash(key string, value []byte) string {
+	h := sha256.New()
+	writeField(h, []byte(key))
+	h.Write(value)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// namespaceDigest returns the hex encoded digest of the hashes of the records of a namespace, ordered by key.
+func namespaceDigest(records map[string]string) (string, error) {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		record, err := hex.DecodeString(records[key])
+		if err != nil {
+			return "", errors.Wrapf(err, "decoding hash of record: %s", key)
+		}
+		writeField(h, []byte(key))
+		writeField(h, record)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stateDigest returns the digest of the digests of the namespaces, in their order, which is what's anchored.
+func stateDigest(namespaces []NamespaceDigest) ([]byte, error) {
+	h := sha256.New()
+	for _, namespace := range namespaces {
+		digest, err := hex.DecodeString(namespace.Digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding digest of namespace: %s", namespace.Namespace)
+		}
+		writeField(h, []byte(namespace.Tenant))
+		writeField(h, []byte(namespace.Namespace))
+		writeField(h, digest)
+	}
+	return h.Sum(nil), nil
+}
+
+// hashRecords returns the hashes of the records of a namespace in the tenant of ctx, keyed by the keys of the records.
+func (s Service) hashRecords(ctx context.Context, namespace string) (map[string]string, error) {
+	records := make(map[string]string)
+	err := s.storage.IterateRecords(ctx, namespace, func(key string, value []byte) (bool, error) {
+		records[key] = recordHash(key, value)
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "hashing records of namespace %s", namespace)
+	}
+	return records, nil
+}
+
+// Anchor hashes the records of the anchored namespaces of the default tenant, and of the configured tenants, and
+// anchors their digest to the external anchor. Anchors beyond the number that are kept are deleted, oldest first.
+func (s Service) Anchor(ctx context.Context) (*Anchor, error) {
+	anchor := Anchor{
+		ID:        uuid.NewString(),
+		CreatedAt: s.Clock.Now().UTC(),
+		Type:      s.anchorType,
+		URL:       s.url,
+	}
+	records := make(map[string]map[string]string)
+	for _, tenantID := range append([]string{""}, s.tenants...) {
+		tenantCtx := storage.WithTenant(ctx, tenantID)
+		for _, namespace := range s.namespaces {
+			namespaceRecords, err := s.hashRecords(tenantCtx, namespace)
+			if err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "could not hash records of tenant %q", tenantID)
+			}
+			digest, err := namespaceDigest(namespaceRecords)
+			if err != nil {
+				return nil, err
+			}
+			anchored := NamespaceDigest{Tenant: tenantID, Namespace: namespace, Records: len(namespaceRecords), Digest: digest}
+			anchor.Namespaces = append(anchor.Namespaces, anchored)
+			records[recordKey(anchor.ID, anchored)] = namespaceRecords
+		}
+	}
+	digest, err := stateDigest(anchor.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	anchor.Digest = hex.EncodeToString(digest)
+
+	anchorCtx, cancel := context.WithTimeout(ctx, anchorTimeout)
+	defer cancel()
+	if anchor.Receipt, err = s.anchorer.Anchor(anchorCtx, digest); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not anchor digest to %s", s.url)
+	}
+	if err = s.storage.StoreAnchor(ctx, anchor, records); err != nil {
+		return nil, err
+	}
+	if err = s.prune(ctx); err != nil {
+		logrus.WithError(err).Warn("deleting anchors beyond the number that are kept")
+	}
+	return &anchor, nil
+}
+
+// prune deletes the oldest anchors beyond the number that are kept.
+func (s Service) prune(ctx context.Context) error {
+	if s.keep == 0 {
+		return nil
+	}
+	anchors, err := s.storage.ListAnchors(ctx)
+	if err != nil {
+		return err
+	}
+	if len(anchors) <= s.keep {
+		return nil
+	}
+	sortNewestFirst(anchors)
+	for _, anchor := range anchors[s.keep:] {
+		if err = s.storage.DeleteAnchor(ctx, anchor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortNewestFirst(anchors []Anchor) {
+	sort.SliceStable(anchors, func(i, j int) bool { return anchors[i].CreatedAt.After(anchors[j].CreatedAt) })
+}
+
+// ListAnchors returns the anchors that are kept, newest first.
+func (s Service) ListAnchors(ctx context.Context) (*ListAnchorsResponse, error) {
+	anchors, err := s.storage.ListAnchors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sortNewestFirst(anchors)
+	return &ListAnchorsResponse{Anchors: anchors}, nil
+}
+
+func (s Service) GetAnchor(ctx context.Context, id string) (*Anchor, error) {
+	anchor, err := s.storage.GetAnchor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if anchor == nil {
+		return nil, ErrAnchorNotFound
+	}
+	return anchor, nil
+}
+
+// Verify checks that the receipt of an anchor attests to its digest, that the hashes of the records kept with it add
+// up to the digest, and compares them with the records of its namespaces now.
+func (s Service) Verify(ctx context.Context, id string) (*Verification, error) {
+	anchor, err := s.GetAnchor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	verification := Verification{
+		AnchorID:   anchor.ID,
+		VerifiedAt: s.Clock.Now().UTC(),
+		Intact:     true,
+		Unchanged:  true,
+		Namespaces: make([]NamespaceChanges, 0, len(anchor.Namespaces)),
+	}
+	for _, namespace := range anchor.Namespaces {
+		anchored, err := s.storage.GetRecords(ctx, anchor.ID, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if digest, err := namespaceDigest(anchored); err != nil || digest != namespace.Digest || len(anchored) != namespace.Records {
+			verification.Intact = false
+		}
+		current, err := s.hashRecords(storage.WithTenant(ctx, namespace.Tenant), namespace.Namespace)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not hash records of tenant %q", namespace.Tenant)
+		}
+		changes := compare(anchored, current)
+		changes.Tenant = namespace.Tenant
+		changes.Namespace = namespace.Namespace
+		if len(changes.Changed) > 0 || len(changes.Removed) > 0 {
+			verification.Unchanged = false
+		}
+		verification.Namespaces = append(verification.Namespaces, changes)
+	}
+
+	digest, err := stateDigest(anchor.Namespaces)
+	if err != nil || hex.EncodeToString(digest) != anchor.Digest {
+		verification.Intact = false
+	}
+	verifier, err := s.verifierFor(anchor.Type)
+	if err == nil {
+		// the receipt is verified for the digest that was anchored, whether or not the namespaces still add up to it
+		if digest, err = hex.DecodeString(anchor.Digest); err == nil {
+			verification.AnchoredAt, err = verifier.Verify(anchor.Receipt, digest)
+		}
+	}
+	switch {
+	case err == nil:
+		verification.Receipt = ReceiptVerified
+	case errors.Is(err, errVerifyExternally):
+		verification.Receipt = ReceiptExternal
+	default:
+		verification.Receipt = ReceiptInvalid
+		verification.ReceiptError = err.Error()
+	}
+	return &verification, nil
+}
+
+// compare returns the changes from the anchored hashes of the records of a namespace to their current hashes.
+func compare(anchored, current map[string]string) NamespaceChanges {
+	var changes NamespaceChanges
+	for key, currentHash := range current {
+		anchoredHash, ok := anchored[key]
+		switch {
+		case !ok:
+			changes.Added++
+		case anchoredHash != currentHash:
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for key := range anchored {
+		if _, ok := current[key]; !ok {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+// RunSchedule anchors state every interval, until ctx is done. Instances sharing the storage take turns, so that each
+// scheduled anchor is made once. An anchor in progress when ctx is done is completed before returning.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.interval, s.anchorScheduled)
+}
+
+func (s Service) anchorScheduled() {
+	ctx := context.Background()
+	claimed, err := schedule.Claim(ctx, s.storage.global, scheduleNamespace, scheduledKey, s.Clock.Now(), s.interval)
+	if err != nil {
+		logrus.WithError(err).Error("claiming scheduled anchor")
+		return
+	}
+	if !claimed {
+		return
+	}
+	anchor, err := s.Anchor(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("anchoring state")
+		return
+	}
+	logrus.WithField("anchor", anchor.ID).Infof("anchored digest %s of %d namespaces to %s", anchor.Digest, len(anchor.Namespaces), anchor.URL)
+}
@@ -0,0 +1,127 @@
+package anchor
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// anchorNamespace holds the anchors, keyed by ID, in the storage of the deployment.
+	anchorNamespace = "anchor"
+	// recordNamespace holds the hashes of the records each anchor covers, keyed by the ID of the anchor, the tenant, and
+	// the namespace, in the storage of the deployment.
+	recordNamespace = "anchor-record"
+	// scheduleNamespace holds when the last scheduled anchor was claimed, in the storage of the deployment.
+	scheduleNamespace = "anchor-schedule"
+	scheduledKey      = "scheduled"
+)
+
+type Storage struct {
+	global storage.ServiceStorage
+	tenant storage.ServiceStorage
+}
+
+func NewAnchorStorage(global, tenant storage.ServiceStorage) (*Storage, error) {
+	if global == nil || tenant == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{global: global, tenant: tenant}, nil
+}
+
+func recordKey(anchorID string, digest NamespaceDigest) string {
+	return storage.Join(anchorID, digest.Tenant, digest.Namespace)
+}
+
+// StoreAnchor stores an anchor, along with the hashes of the records of each of its namespaces, keyed by
+// recordKey. The anchor is written last, so that it's only listed once the hashes of its records are stored.
+func (s *Storage) StoreAnchor(ctx context.Context, anchor Anchor, records map[string]map[string]string) error {
+	for _, digest := range anchor.Namespaces {
+		key := recordKey(anchor.ID, digest)
+		recordBytes, err := json.Marshal(records[key])
+		if err != nil {
+			return sdkutil.LoggingErrorMsgf(err, "could not marshal records of anchor: %s", key)
+		}
+		if err = s.global.Write(ctx, recordNamespace, key, recordBytes); err != nil {
+			return sdkutil.LoggingErrorMsgf(err, "could not store records of anchor: %s", key)
+		}
+	}
+	anchorBytes, err := json.Marshal(anchor)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not marshal anchor: %s", anchor.ID)
+	}
+	return s.global.Write(ctx, anchorNamespace, anchor.ID, anchorBytes)
+}
+
+// GetAnchor returns the anchor with the ID, or nil when there is none.
+func (s *Storage) GetAnchor(ctx context.Context, id string) (*Anchor, error) {
+	anchorBytes, err := s.global.Read(ctx, anchorNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get anchor: %s", id)
+	}
+	if len(anchorBytes) == 0 {
+		return nil, nil
+	}
+	var anchor Anchor
+	if err = json.Unmarshal(anchorBytes, &anchor); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling anchor: %s", id)
+	}
+	return &anchor, nil
+}
+
+func (s *Storage) ListAnchors(ctx context.Context) ([]Anchor, error) {
+	var anchors []Anchor
+	err := s.global.Iterate(ctx, anchorNamespace, func(key string, anchorBytes []byte) (bool, error) {
+		var anchor Anchor
+		if err := json.Unmarshal(anchorBytes, &anchor); err != nil {
+			logrus.WithError(err).Warnf("unmarshal anchor: %s", key)
+			return true, nil
+		}
+		anchors = append(anchors, anchor)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list anchors")
+	}
+	return anchors, nil
+}
+
+// GetRecords returns the hashes of the records of a namespace of an anchor, keyed by the keys of the records.
+func (s *Storage) GetRecords(ctx context.Context, anchorID string, digest NamespaceDigest) (map[string]string, error) {
+	key := recordKey(anchorID, digest)
+	recordBytes, err := s.global.Read(ctx, recordNamespace, key)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get records of anchor: %s", key)
+	}
+	records := make(map[string]string)
+	if len(recordBytes) == 0 {
+		return records, nil
+	}
+	if err = json.Unmarshal(recordBytes, &records); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling records of anchor: %s", key)
+	}
+	return records, nil
+}
+
+// DeleteAnchor deletes an anchor, and the hashes of its records.
+func (s *Storage) DeleteAnchor(ctx context.Context, anchor Anchor) error {
+	if err := s.global.Delete(ctx, anchorNamespace, anchor.ID); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete anchor: %s", anchor.ID)
+	}
+	for _, digest := range anchor.Namespaces {
+		if err := s.global.Delete(ctx, recordNamespace, recordKey(anchor.ID, digest)); err != nil {
+			return sdkutil.LoggingErrorMsgf(err, "could not delete records of anchor: %s", anchor.ID)
+		}
+	}
+	return nil
+}
+
+// IterateRecords calls fn with every record of a namespace in the tenant of ctx.
+func (s *Storage) IterateRecords(ctx context.Context, namespace string, fn storage.IterateFunc) error {
+	return s.tenant.Iterate(ctx, namespace, fn)
+}
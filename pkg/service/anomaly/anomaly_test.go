@@ -0,0 +1,125 @@
+package anomaly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestObserveSigning(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			s, err := NewAnomalyService(config.AnomalyServiceConfig{
+				Enabled:         true,
+				BaselineWindows: 1,
+				Threshold:       2,
+				MinSignatures:   3,
+				FreezeFor:       time.Hour,
+			}, test.ServiceStorage(t))
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+			s.Clock = mockClock
+			ctx := storage.WithTenant(context.Background(), "acme")
+			sign := func(tenant, keyID string, times int) {
+				for i := 0; i < times; i++ {
+					s.countSignature(ctx, tenant, keyID, mockClock.Now())
+				}
+			}
+			checkFrozen := func(tenant, keyID string) error {
+				return s.checkFrozen(ctx, tenant, keyID, mockClock.Now())
+			}
+
+			// the first window spikes past the fewest signatures that can spike
+			sign("acme", "key-1", 3)
+			require.NoError(t, checkFrozen("acme", "key-1"))
+			mockClock.Add(time.Minute)
+
+			// with a baseline of 3, the next window spikes past 6, while other keys and tenants are counted on their own
+			sign("acme", "key-1", 6)
+			sign("", "key-1", 1)
+			require.NoError(t, checkFrozen("acme", "key-1"))
+			sign("acme", "key-1", 1)
+			err = checkFrozen("acme", "key-1")
+			var frozenErr *keystore.FrozenKeyError
+			require.ErrorAs(t, err, &frozenErr)
+			assert.Equal(t, mockClock.Now().Add(time.Hour), frozenErr.Until)
+			require.NoError(t, checkFrozen("", "key-1"))
+			require.NoError(t, checkFrozen("acme", "key-2"))
+			sign("acme", "key-2", 1)
+
+			rates, err := s.ListRates(ctx)
+			require.NoError(t, err)
+			require.Len(t, rates.Rates, 5)
+			assert.Equal(t, Rate{
+				Scope:       ScopeKey,
+				Tenant:      "acme",
+				KeyID:       "key-1",
+				WindowStart: mockClock.Now(),
+				Count:       7,
+				Baseline:    3,
+				Spiked:      true,
+			}, rates.Rates[0])
+
+			freezes, err := s.ListFreezes(ctx)
+			require.NoError(t, err)
+			require.Len(t, freezes.Freezes, 1)
+			assert.Equal(t, "acme", freezes.Freezes[0].Tenant)
+			assert.Equal(t, "key-1", freezes.Freezes[0].KeyID)
+
+			// lifting the freeze lets the key sign again, without spiking again in the same window
+			require.NoError(t, s.LiftFreeze(ctx, freezes.Freezes[0].ID))
+			sign("acme", "key-1", 1)
+			require.NoError(t, checkFrozen("acme", "key-1"))
+			assert.ErrorIs(t, s.LiftFreeze(ctx, freezes.Freezes[0].ID), ErrFreezeNotFound)
+
+			// freezes end on their own
+			sign("acme", "key-2", 3)
+			require.ErrorAs(t, checkFrozen("acme", "key-2"), &frozenErr)
+			mockClock.Add(time.Hour)
+			require.NoError(t, checkFrozen("acme", "key-2"))
+			freezes, err = s.ListFreezes(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, freezes.Freezes)
+
+			// signatures observed are counted in the background
+			require.NoError(t, s.ObserveSigning(ctx, "key-2"))
+			require.NoError(t, s.Drain(ctx))
+			rates, err = s.ListRates(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, rates.Rates[1].Count)
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := Service{config: config.AnomalyServiceConfig{Window: time.Minute, BaselineWindows: 3, Threshold: 2, MinSignatures: 1}}
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	var rate Rate
+	for i := 0; i < 8; i++ {
+		s.count(&rate, start)
+	}
+
+	// windows are folded into the baseline with a weight of 2/(3+1), and windows without signatures count as 0
+	s.count(&rate, start.Add(2*time.Minute))
+	assert.Equal(t, start.Add(2*time.Minute), rate.WindowStart)
+	assert.EqualValues(t, 1, rate.Count)
+	assert.InDelta(t, 2, rate.Baseline, 0.001)
+	assert.False(t, rate.Spiked)
+
+	// the window spikes past twice the baseline, once
+	for i := 0; i < 3; i++ {
+		assert.False(t, s.count(&rate, start.Add(2*time.Minute)))
+	}
+	assert.True(t, s.count(&rate, start.Add(2*time.Minute)))
+	assert.False(t, s.count(&rate, start.Add(2*time.Minute)))
+}
@@ -0,0 +1,74 @@
+package anomaly
+
+import (
+	"time"
+)
+
+// Scope is what a signing rate counts the signatures of.
+type Scope string
+
+const (
+	// ScopeKey counts the signatures of a key.
+	ScopeKey Scope = "key"
+	// ScopeTenant counts the signatures of every key of a tenant.
+	ScopeTenant Scope = "tenant"
+)
+
+// Rate counts the signatures of a key, or of a tenant, in the current window, and averages the counts of the windows
+// before it into a baseline.
+type Rate struct {
+	Scope  Scope  `json:"scope"`
+	Tenant string `json:"tenant,omitempty"`
+	// ID of the key, for rates of keys.
+	KeyID string `json:"keyId,omitempty"`
+
+	// Start of the current window, and the number of signatures in it.
+	WindowStart time.Time `json:"windowStart"`
+	Count       int64     `json:"count"`
+
+	// Exponentially weighted moving average of the counts of the windows before the current one.
+	Baseline float64 `json:"baseline"`
+
+	// Whether the current window spiked, and was alerted.
+	Spiked bool `json:"spiked"`
+}
+
+// Alert is posted to the alert URLs when a key, or a tenant, spikes.
+type Alert struct {
+	Scope  Scope  `json:"scope"`
+	Tenant string `json:"tenant,omitempty"`
+	KeyID  string `json:"keyId,omitempty"`
+
+	// Window that spiked, how many signatures were made in it when it spiked, and how many it takes to spike.
+	WindowStart time.Time `json:"windowStart"`
+	Count       int64     `json:"count"`
+	Baseline    float64   `json:"baseline"`
+	Limit       float64   `json:"limit"`
+
+	// When the key is frozen until, when keys that spike are frozen.
+	FrozenUntil *time.Time `json:"frozenUntil,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Freeze keeps a key that spiked from signing until it ends, or until it's lifted.
+type Freeze struct {
+	ID     string `json:"id"`
+	Tenant string `json:"tenant,omitempty"`
+	KeyID  string `json:"keyId"`
+
+	FrozenAt time.Time `json:"frozenAt"`
+	Until    time.Time `json:"until"`
+
+	// Signatures in the window that spiked, and the baseline of the key at the time.
+	Count    int64   `json:"count"`
+	Baseline float64 `json:"baseline"`
+}
+
+type ListRatesResponse struct {
+	Rates []Rate
+}
+
+type ListFreezesResponse struct {
+	Freezes []Freeze
+}
@@ -0,0 +1,332 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/inflight"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// DefaultWindow is the length of the windows signatures are counted in when none is configured.
+	DefaultWindow = time.Minute
+	// DefaultBaselineWindows is the number of windows baselines are averaged over when none is configured.
+	DefaultBaselineWindows = 60
+	// DefaultThreshold is how many times its baseline a key, or tenant, signs to spike when none is configured.
+	DefaultThreshold = 10
+	// DefaultMinSignatures is the fewest signatures in a window that spike when none is configured.
+	DefaultMinSignatures = 100
+
+	alertTimeout = 10 * time.Second
+)
+
+// ErrFreezeNotFound is returned when lifting a freeze that doesn't exist, or ended.
+var ErrFreezeNotFound = errors.New("freeze not found")
+
+// Service detects spikes in how much each key, and each tenant, signs, compared to their baselines. Spikes are alerted,
+// and keys that spike are frozen for a while when configured so. It's the signing monitor of the keystore, and keeps
+// what it counts in the storage of the deployment, so that instances sharing it count together.
+type Service struct {
+	storage    *Storage
+	config     config.AnomalyServiceConfig
+	httpClient *http.Client
+
+	// background tracks the signatures being counted, and the alerts being posted, so shutdown can wait for them
+	background *inflight.Tracker
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Anomaly
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("anomaly service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewAnomalyService(cfg config.AnomalyServiceConfig, s storage.ServiceStorage) (*Service, error) {
+	anomalyStorage, err := NewAnomalyStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the anomaly service")
+	}
+	if cfg.Window < 0 || cfg.BaselineWindows < 0 || cfg.Threshold < 0 || cfg.MinSignatures < 0 || cfg.FreezeFor < 0 {
+		return nil, sdkutil.LoggingNewError("window, baseline_windows, threshold, min_signatures, and freeze_for cannot be negative")
+	}
+	for _, alertURL := range cfg.AlertURLs {
+		if u, err := url.ParseRequestURI(alertURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, sdkutil.LoggingNewErrorf("invalid alert url: %s", alertURL)
+		}
+	}
+	if cfg.Window == 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.BaselineWindows == 0 {
+		cfg.BaselineWindows = DefaultBaselineWindows
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	if cfg.MinSignatures == 0 {
+		cfg.MinSignatures = DefaultMinSignatures
+	}
+
+	service := Service{
+		storage:    anomalyStorage,
+		config:     cfg,
+		httpClient: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		background: new(inflight.Tracker),
+		Clock:      clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// ObserveSigning returns a keystore.FrozenKeyError when the key is frozen in the tenant of ctx, and otherwise counts the
+// signature in the background, since signatures are often made in a transaction of the storage, which some storage
+// can't be written to until the transaction ends. A key that spikes is frozen from the signature after the one that
+// spiked. Drain waits for the signatures being counted.
+func (s Service) ObserveSigning(ctx context.Context, keyID string) error {
+	now := s.Clock.Now().UTC()
+	tenant := storage.TenantFromContext(ctx)
+	if err := s.checkFrozen(ctx, tenant, keyID, now); err != nil {
+		return err
+	}
+
+	if !s.background.Start() {
+		logrus.WithContext(ctx).Warnf("not counting signature of key<%s> while shutting down", keyID)
+		return nil
+	}
+	go func() {
+		defer s.background.Done()
+		s.countSignature(context.Background(), tenant, keyID, now)
+	}()
+	return nil
+}
+
+// checkFrozen returns a keystore.FrozenKeyError when the key of the tenant is frozen at now.
+func (s Service) checkFrozen(ctx context.Context, tenant, keyID string, now time.Time) error {
+	freeze, err := s.storage.GetFreeze(ctx, tenant, keyID)
+	if err != nil {
+		return errors.Wrap(err, "checking whether key is frozen")
+	}
+	if freeze != nil && now.Before(freeze.Until) {
+		return &keystore.FrozenKeyError{KeyID: keyID, Until: freeze.Until}
+	}
+	return nil
+}
+
+// countSignature counts a signature of the key of the tenant made at now, alerts when the key or the tenant spike, and
+// freezes the key when it spikes and keys that spike are frozen. Failures are logged, since the signature was made.
+func (s Service) countSignature(ctx context.Context, tenant, keyID string, now time.Time) {
+	if rate, spiked := s.observe(ctx, ScopeKey, tenant, keyID, now); spiked {
+		alert := s.newAlert(*rate, now)
+		if s.config.FreezeFor > 0 {
+			freeze := Freeze{
+				ID:       uuid.NewString(),
+				Tenant:   tenant,
+				KeyID:    keyID,
+				FrozenAt: now,
+				Until:    now.Add(s.config.FreezeFor),
+				Count:    rate.Count,
+				Baseline: rate.Baseline,
+			}
+			if err := s.storage.StoreFreeze(ctx, freeze); err != nil {
+				logrus.WithError(err).Errorf("could not freeze key<%s> that spiked", keyID)
+			} else {
+				alert.FrozenUntil = &freeze.Until
+			}
+		}
+		s.sendAlert(ctx, alert)
+	}
+	if rate, spiked := s.observe(ctx, ScopeTenant, tenant, "", now); spiked {
+		s.sendAlert(ctx, s.newAlert(*rate, now))
+	}
+}
+
+// observe counts a signature made at now in the rate, and returns the rate, and whether it spiked with this signature.
+func (s Service) observe(ctx context.Context, scope Scope, tenant, keyID string, now time.Time) (*Rate, bool) {
+	rate, spiked, err := s.storage.UpdateRate(ctx, scope, tenant, keyID, func(rate *Rate) bool {
+		return s.count(rate, now)
+	})
+	if err != nil {
+		logrus.WithError(err).Errorf("could not count signature of %s in tenant %q", scope, tenant)
+		return nil, false
+	}
+	return rate, spiked
+}
+
+// count adds a signature made at now to the rate, first folding the windows before the window of now into its
+// baseline. It returns whether the window spiked with this signature, which is the case once per window.
+func (s Service) count(rate *Rate, now time.Time) bool {
+	window := now.Truncate(s.config.Window)
+	if rate.WindowStart.IsZero() {
+		rate.WindowStart = window
+	}
+	if rate.WindowStart.Before(window) {
+		alpha := 2 / float64(s.config.BaselineWindows+1)
+		rate.Baseline += alpha * (float64(rate.Count) - rate.Baseline)
+		// each window without signatures since pulls the baseline towards 0
+		if empty := int64(window.Sub(rate.WindowStart)/s.config.Window) - 1; empty > 0 {
+			rate.Baseline *= math.Pow(1-alpha, float64(empty))
+		}
+		rate.WindowStart = window
+		rate.Count = 0
+		rate.Spiked = false
+	}
+	rate.Count++
+	if rate.Spiked || float64(rate.Count) <= s.limit(*rate) {
+		return false
+	}
+	rate.Spiked = true
+	return true
+}
+
+// limit is the most signatures the window of the rate can have without spiking.
+func (s Service) limit(rate Rate) float64 {
+	return math.Max(float64(s.config.MinSignatures), s.config.Threshold*rate.Baseline)
+}
+
+func (s Service) newAlert(rate Rate, now time.Time) Alert {
+	return Alert{
+		Scope:       rate.Scope,
+		Tenant:      rate.Tenant,
+		KeyID:       rate.KeyID,
+		WindowStart: rate.WindowStart,
+		Count:       rate.Count,
+		Baseline:    rate.Baseline,
+		Limit:       s.limit(rate),
+		Timestamp:   now,
+	}
+}
+
+// sendAlert logs the alert, and posts it to the alert URLs.
+func (s Service) sendAlert(ctx context.Context, alert Alert) {
+	logrus.WithFields(logrus.Fields{
+		"scope":  alert.Scope,
+		"tenant": alert.Tenant,
+		"keyId":  alert.KeyID,
+		"count":  alert.Count,
+		"limit":  alert.Limit,
+	}).Warn("signing spiked")
+	if len(s.config.AlertURLs) == 0 {
+		return
+	}
+	alertBytes, err := json.Marshal(alert)
+	if err != nil {
+		logrus.WithError(err).Error("marshalling alert")
+		return
+	}
+	postCtx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+	for _, alertURL := range s.config.AlertURLs {
+		if err = s.post(postCtx, alertURL, alertBytes); err != nil {
+			logrus.WithError(err).Errorf("posting signing alert to %s", alertURL)
+		}
+	}
+}
+
+func (s Service) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "building http req")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "posting alert")
+	}
+	defer resp.Body.Close()
+	if !util.Is2xxResponse(resp.StatusCode) {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status code %v not in the 200s. body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Drain stops signatures from being counted, and waits for the signatures being counted, and the alerts being posted,
+// to finish, or for ctx to be done.
+func (s Service) Drain(ctx context.Context) error {
+	return errors.Wrap(s.background.Drain(ctx), "waiting for signatures to be counted")
+}
+
+// ListRates returns the signing rate of every key, and every tenant, that signed, ordered by scope, tenant, and key.
+func (s Service) ListRates(ctx context.Context) (*ListRatesResponse, error) {
+	rates, err := s.storage.ListRates(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list signing rates")
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		return rateKey(rates[i].Scope, rates[i].Tenant, rates[i].KeyID) < rateKey(rates[j].Scope, rates[j].Tenant, rates[j].KeyID)
+	})
+	return &ListRatesResponse{Rates: rates}, nil
+}
+
+// ListFreezes returns the freezes that haven't ended, oldest first.
+func (s Service) ListFreezes(ctx context.Context) (*ListFreezesResponse, error) {
+	freezes, err := s.storage.ListFreezes(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list freezes")
+	}
+	now := s.Clock.Now()
+	active := make([]Freeze, 0, len(freezes))
+	for _, freeze := range freezes {
+		if now.Before(freeze.Until) {
+			active = append(active, freeze)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].FrozenAt.Equal(active[j].FrozenAt) {
+			return active[i].FrozenAt.Before(active[j].FrozenAt)
+		}
+		return active[i].ID < active[j].ID
+	})
+	return &ListFreezesResponse{Freezes: active}, nil
+}
+
+// LiftFreeze ends the freeze with the ID, so that its key can sign again.
+func (s Service) LiftFreeze(ctx context.Context, id string) error {
+	freezes, err := s.ListFreezes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, freeze := range freezes.Freezes {
+		if freeze.ID == id {
+			return s.storage.DeleteFreeze(ctx, freeze.Tenant, freeze.KeyID)
+		}
+	}
+	return ErrFreezeNotFound
+}
@@ -0,0 +1,147 @@
+package anomaly
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// rateNamespace holds the signing rates, keyed by rateKey.
+	rateNamespace = "signing-rate"
+	// freezeNamespace holds the freezes of keys, keyed by freezeKey.
+	freezeNamespace = "signing-freeze"
+)
+
+func rateKey(scope Scope, tenant, keyID string) string {
+	return string(scope) + "|" + tenant + "|" + keyID
+}
+
+func freezeKey(tenant, keyID string) string {
+	return tenant + "|" + keyID
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewAnomalyStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+type updatedRate struct {
+	rate   Rate
+	result bool
+}
+
+// UpdateRate calls update with the stored rate, or a new one when there is none, and stores what update made of it.
+// The rate is read and written in a transaction that watches it, so that instances sharing the storage count every
+// signature. It returns the updated rate, and what update returned.
+func (s *Storage) UpdateRate(ctx context.Context, scope Scope, tenant, keyID string, update func(rate *Rate) bool) (*Rate, bool, error) {
+	key := rateKey(scope, tenant, keyID)
+	watchKeys := []storage.WatchKey{{Namespace: rateNamespace, Key: key}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		rate := Rate{Scope: scope, Tenant: tenant, KeyID: keyID}
+		rateBytes, err := s.db.Read(ctx, rateNamespace, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(rateBytes) > 0 {
+			if err = json.Unmarshal(rateBytes, &rate); err != nil {
+				return nil, errors.Wrap(err, "unmarshalling signing rate")
+			}
+		}
+		result := update(&rate)
+		if rateBytes, err = json.Marshal(rate); err != nil {
+			return nil, errors.Wrap(err, "marshalling signing rate")
+		}
+		return updatedRate{rate: rate, result: result}, tx.Write(ctx, rateNamespace, key, rateBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, false, sdkutil.LoggingErrorMsgf(err, "could not update signing rate: %s", key)
+	}
+	u := updated.(updatedRate)
+	return &u.rate, u.result, nil
+}
+
+// ListRates returns every signing rate.
+func (s *Storage) ListRates(ctx context.Context) ([]Rate, error) {
+	ratesBytes, err := s.db.ReadAll(ctx, rateNamespace)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list signing rates")
+	}
+	rates := make([]Rate, 0, len(ratesBytes))
+	for key, rateBytes := range ratesBytes {
+		var rate Rate
+		if err = json.Unmarshal(rateBytes, &rate); err != nil {
+			logrus.WithError(err).Warnf("unmarshal signing rate: %s", key)
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (s *Storage) StoreFreeze(ctx context.Context, freeze Freeze) error {
+	freezeBytes, err := json.Marshal(freeze)
+	if err != nil {
+		return errors.Wrap(err, "marshalling freeze")
+	}
+	key := freezeKey(freeze.Tenant, freeze.KeyID)
+	if err = s.db.Write(ctx, freezeNamespace, key, freezeBytes); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store freeze: %s", key)
+	}
+	return nil
+}
+
+// GetFreeze returns the freeze of the key of the tenant, or nil when the key was never frozen, or its freeze was
+// lifted. The freeze may have ended.
+func (s *Storage) GetFreeze(ctx context.Context, tenant, keyID string) (*Freeze, error) {
+	key := freezeKey(tenant, keyID)
+	freezeBytes, err := s.db.Read(ctx, freezeNamespace, key)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get freeze: %s", key)
+	}
+	if len(freezeBytes) == 0 {
+		return nil, nil
+	}
+	var freeze Freeze
+	if err = json.Unmarshal(freezeBytes, &freeze); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling freeze: %s", key)
+	}
+	return &freeze, nil
+}
+
+// ListFreezes returns every freeze, including those that ended.
+func (s *Storage) ListFreezes(ctx context.Context) ([]Freeze, error) {
+	freezesBytes, err := s.db.ReadAll(ctx, freezeNamespace)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list freezes")
+	}
+	freezes := make([]Freeze, 0, len(freezesBytes))
+	for key, freezeBytes := range freezesBytes {
+		var freeze Freeze
+		if err = json.Unmarshal(freezeBytes, &freeze); err != nil {
+			logrus.WithError(err).Warnf("unmarshal freeze: %s", key)
+			continue
+		}
+		freezes = append(freezes, freeze)
+	}
+	return freezes, nil
+}
+
+func (s *Storage) DeleteFreeze(ctx context.Context, tenant, keyID string) error {
+	key := freezeKey(tenant, keyID)
+	if err := s.db.Delete(ctx, freezeNamespace, key); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete freeze: %s", key)
+	}
+	return nil
+}
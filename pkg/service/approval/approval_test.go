@@ -0,0 +1,96 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestApprovals(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := NewApprovalService(config.ApprovalServiceConfig{ExpiresAfter: time.Hour}, test.ServiceStorage(t))
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+			s.Clock = mockClock
+
+			requestApproval := func() *Approval {
+				approval, err := s.RequestApproval(ctx, RequestApprovalRequest{
+					Method:      "DELETE",
+					URI:         "/v1/keys/key-1",
+					Route:       "/v1/keys/:id",
+					RequestHash: "hash",
+					RequestedBy: "alice",
+					Reason:      "key leaked",
+				})
+				require.NoError(t, err)
+				assert.Equal(t, StatusPending, approval.Status)
+				return approval
+			}
+			approval := requestApproval()
+
+			// approvals can't be used before they're approved, nor reviewed by their requester
+			_, err = s.UseApproval(ctx, UseApprovalRequest{ID: approval.ID, RequestHash: "hash", RequestedBy: "alice"})
+			assert.ErrorIs(t, err, ErrNotGranted)
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: approval.ID, ReviewedBy: "alice", Approved: true})
+			assert.ErrorIs(t, err, ErrSelfReview)
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: "unknown", ReviewedBy: "bob", Approved: true})
+			assert.ErrorIs(t, err, ErrApprovalNotFound)
+
+			reviewed, err := s.ReviewApproval(ctx, ReviewApprovalRequest{ID: approval.ID, ReviewedBy: "bob", Approved: true, Reason: "confirmed"})
+			require.NoError(t, err)
+			assert.Equal(t, StatusApproved, reviewed.Status)
+			assert.Equal(t, "bob", reviewed.ReviewedBy)
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: approval.ID, ReviewedBy: "carol", Approved: false})
+			assert.ErrorIs(t, err, ErrNotPending)
+
+			// approvals are only used by their requester, for their request, and once
+			_, err = s.UseApproval(ctx, UseApprovalRequest{ID: approval.ID, RequestHash: "hash", RequestedBy: "bob"})
+			assert.ErrorIs(t, err, ErrNotGranted)
+			_, err = s.UseApproval(ctx, UseApprovalRequest{ID: approval.ID, RequestHash: "other", RequestedBy: "alice"})
+			assert.ErrorIs(t, err, ErrNotGranted)
+			used, err := s.UseApproval(ctx, UseApprovalRequest{ID: approval.ID, RequestHash: "hash", RequestedBy: "alice"})
+			require.NoError(t, err)
+			assert.Equal(t, StatusUsed, used.Status)
+			_, err = s.UseApproval(ctx, UseApprovalRequest{ID: approval.ID, RequestHash: "hash", RequestedBy: "alice"})
+			assert.ErrorIs(t, err, ErrNotGranted)
+
+			// approvals that aren't reviewed, or used, in time expire
+			mockClock.Add(time.Minute)
+			rejected := requestApproval()
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: rejected.ID, ReviewedBy: "bob", Approved: false})
+			require.NoError(t, err)
+			unreviewed := requestApproval()
+			unused := requestApproval()
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: unused.ID, ReviewedBy: "bob", Approved: true})
+			require.NoError(t, err)
+			mockClock.Add(time.Hour)
+			_, err = s.ReviewApproval(ctx, ReviewApprovalRequest{ID: unreviewed.ID, ReviewedBy: "bob", Approved: true})
+			assert.ErrorIs(t, err, ErrNotPending)
+			_, err = s.UseApproval(ctx, UseApprovalRequest{ID: unused.ID, RequestHash: "hash", RequestedBy: "alice"})
+			assert.ErrorIs(t, err, ErrNotGranted)
+			got, err := s.GetApproval(ctx, unused.ID)
+			require.NoError(t, err)
+			assert.Equal(t, StatusExpired, got.Status)
+
+			all, err := s.ListApprovals(ctx, ListApprovalsRequest{})
+			require.NoError(t, err)
+			require.Len(t, all.Approvals, 4)
+			assert.Equal(t, approval.ID, all.Approvals[0].ID)
+			expired, err := s.ListApprovals(ctx, ListApprovalsRequest{Status: StatusExpired})
+			require.NoError(t, err)
+			assert.Len(t, expired.Approvals, 2)
+			_, err = s.ListApprovals(ctx, ListApprovalsRequest{Status: "done"})
+			assert.Error(t, err)
+		})
+	}
+}
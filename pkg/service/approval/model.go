@@ -0,0 +1,109 @@
+package approval
+
+import (
+	"time"
+)
+
+// Status is where an approval is in its review.
+type Status string
+
+const (
+	// StatusPending approvals are waiting for an operator to review them.
+	StatusPending Status = "pending"
+	// StatusApproved approvals can be used once, by their requester, to make the request they approve.
+	StatusApproved Status = "approved"
+	// StatusRejected approvals can't be used.
+	StatusRejected Status = "rejected"
+	// StatusUsed approvals were used to make the request they approve.
+	StatusUsed Status = "used"
+	// StatusExpired approvals weren't reviewed, or used, before they expired.
+	StatusExpired Status = "expired"
+)
+
+// Statuses are all the statuses approvals can be in.
+var Statuses = []Status{StatusPending, StatusApproved, StatusRejected, StatusUsed, StatusExpired}
+
+// IsValid returns whether the status is one of Statuses.
+func (s Status) IsValid() bool {
+	for _, status := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Approval is a request for a sensitive operation, which only runs once an operator other than its requester approves
+// it.
+type Approval struct {
+	ID string `json:"id"`
+
+	// Method and URI of the request, and the route it's for, e.g. DELETE /v1/keys/:id.
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+	Route  string `json:"route"`
+
+	// SHA-256 hash of the method, URI, and body of the request, in hex. Bodies aren't stored, since sensitive
+	// operations often carry secrets, so requesters share the request with reviewers, who check it against the hash.
+	RequestHash string `json:"requestHash"`
+
+	// Tenant of the requester. Empty for the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+	// ID of the principal that requested the operation, and the reason they gave.
+	RequestedBy string `json:"requestedBy"`
+	Reason      string `json:"reason,omitempty"`
+
+	Status Status `json:"status"`
+
+	// ID of the principal that approved or rejected the request, and the reason they gave.
+	ReviewedBy   string `json:"reviewedBy,omitempty"`
+	ReviewReason string `json:"reviewReason,omitempty"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+	// When the approval can't be reviewed, or used, anymore.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// statusAt returns the status of the approval at now, which is expired once it wasn't reviewed, or used, in time.
+func (a Approval) statusAt(now time.Time) Status {
+	if (a.Status == StatusPending || a.Status == StatusApproved) && !now.Before(a.ExpiresAt) {
+		return StatusExpired
+	}
+	return a.Status
+}
+
+// RequestApprovalRequest is a request for a sensitive operation that needs approval.
+type RequestApprovalRequest struct {
+	Method      string `validate:"required"`
+	URI         string `validate:"required"`
+	Route       string `validate:"required"`
+	RequestHash string `validate:"required"`
+	Tenant      string
+	RequestedBy string `validate:"required"`
+	Reason      string
+}
+
+type ReviewApprovalRequest struct {
+	ID         string `validate:"required"`
+	ReviewedBy string `validate:"required"`
+	Approved   bool
+	Reason     string
+}
+
+// UseApprovalRequest is the request an approval is used to make, by its requester.
+type UseApprovalRequest struct {
+	ID          string `validate:"required"`
+	RequestHash string `validate:"required"`
+	RequestedBy string `validate:"required"`
+}
+
+type ListApprovalsRequest struct {
+	// Only lists approvals with the status, when set.
+	Status Status
+}
+
+type ListApprovalsResponse struct {
+	Approvals []Approval `json:"approvals"`
+}
@@ -0,0 +1,185 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// DefaultExpiresAfter is how long approvals can be reviewed, and used, when no expiry is configured.
+const DefaultExpiresAfter = 24 * time.Hour
+
+var (
+	// ErrApprovalNotFound is returned when no approval exists with the requested ID.
+	ErrApprovalNotFound = errors.New("approval not found")
+	// ErrSelfReview is returned when the requester of an approval reviews it, which takes a second operator.
+	ErrSelfReview = errors.New("approvals can't be reviewed by their requester")
+	// ErrNotPending is returned when reviewing an approval that was already reviewed, or expired.
+	ErrNotPending = errors.New("approval is not pending")
+	// ErrNotGranted is returned when using an approval that isn't approved, or is for another request or requester.
+	ErrNotGranted = errors.New("approval is not granted")
+)
+
+// Service keeps the approvals of sensitive operations, which only run once an operator other than the one requesting
+// them approves. Approvals are kept in the storage of the deployment, since they're reviewed by its admins.
+type Service struct {
+	storage      *Storage
+	expiresAfter time.Duration
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Approval
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("approval service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewApprovalService(cfg config.ApprovalServiceConfig, s storage.ServiceStorage) (*Service, error) {
+	approvalStorage, err := NewApprovalStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the approval service")
+	}
+	if cfg.ExpiresAfter < 0 {
+		return nil, sdkutil.LoggingNewError("expires_after cannot be negative")
+	}
+	expiresAfter := cfg.ExpiresAfter
+	if expiresAfter == 0 {
+		expiresAfter = DefaultExpiresAfter
+	}
+
+	service := Service{
+		storage:      approvalStorage,
+		expiresAfter: expiresAfter,
+		Clock:        clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// RequestApproval creates a pending approval of a request.
+func (s Service) RequestApproval(ctx context.Context, request RequestApprovalRequest) (*Approval, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	now := s.Clock.Now().UTC()
+	approval := Approval{
+		ID:          uuid.NewString(),
+		Method:      request.Method,
+		URI:         request.URI,
+		Route:       request.Route,
+		RequestHash: request.RequestHash,
+		Tenant:      request.Tenant,
+		RequestedBy: request.RequestedBy,
+		Reason:      request.Reason,
+		Status:      StatusPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.expiresAfter),
+	}
+	if err := s.storage.StoreApproval(ctx, approval); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// GetApproval returns the approval with the ID, or ErrApprovalNotFound.
+func (s Service) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	approval, err := s.storage.GetApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, ErrApprovalNotFound
+	}
+	approval.Status = approval.statusAt(s.Clock.Now())
+	return approval, nil
+}
+
+// ListApprovals returns the approvals, oldest first.
+func (s Service) ListApprovals(ctx context.Context, request ListApprovalsRequest) (*ListApprovalsResponse, error) {
+	if request.Status != "" && !request.Status.IsValid() {
+		return nil, errors.Errorf("unknown approval status: %s", request.Status)
+	}
+	stored, err := s.storage.ListApprovals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := s.Clock.Now()
+	approvals := make([]Approval, 0, len(stored))
+	for _, approval := range stored {
+		approval.Status = approval.statusAt(now)
+		if request.Status == "" || approval.Status == request.Status {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.SliceStable(approvals, func(i, j int) bool { return approvals[i].CreatedAt.Before(approvals[j].CreatedAt) })
+	return &ListApprovalsResponse{Approvals: approvals}, nil
+}
+
+// ReviewApproval approves, or rejects, a pending approval. Its requester can't review it.
+func (s Service) ReviewApproval(ctx context.Context, request ReviewApprovalRequest) (*Approval, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	return s.storage.UpdateApproval(ctx, request.ID, func(approval *Approval) error {
+		now := s.Clock.Now().UTC()
+		if approval.RequestedBy == request.ReviewedBy {
+			return ErrSelfReview
+		}
+		if status := approval.statusAt(now); status != StatusPending {
+			return errors.Wrapf(ErrNotPending, "approval is %s", status)
+		}
+		approval.Status = StatusRejected
+		if request.Approved {
+			approval.Status = StatusApproved
+		}
+		approval.ReviewedBy = request.ReviewedBy
+		approval.ReviewReason = request.Reason
+		approval.ReviewedAt = &now
+		return nil
+	})
+}
+
+// UseApproval marks an approval as used by the request it approves, which can then be made. Approvals can only be used
+// once, by their requester, for the request they were requested for, and only once they're approved.
+func (s Service) UseApproval(ctx context.Context, request UseApprovalRequest) (*Approval, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	return s.storage.UpdateApproval(ctx, request.ID, func(approval *Approval) error {
+		now := s.Clock.Now().UTC()
+		if approval.RequestedBy != request.RequestedBy || approval.RequestHash != request.RequestHash {
+			return errors.Wrap(ErrNotGranted, "approval is for another request")
+		}
+		if status := approval.statusAt(now); status != StatusApproved {
+			return errors.Wrapf(ErrNotGranted, "approval is %s", status)
+		}
+		approval.Status = StatusUsed
+		approval.UsedAt = &now
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package approval
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// namespace holds the approvals, keyed by ID, in the storage of the deployment.
+const namespace = "approval"
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewApprovalStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) StoreApproval(ctx context.Context, approval Approval) error {
+	approvalBytes, err := json.Marshal(approval)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not marshal approval: %s", approval.ID)
+	}
+	return s.db.Write(ctx, namespace, approval.ID, approvalBytes)
+}
+
+// GetApproval returns the approval with the ID, or nil when there is none.
+func (s *Storage) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	approvalBytes, err := s.db.Read(ctx, namespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get approval: %s", id)
+	}
+	if len(approvalBytes) == 0 {
+		return nil, nil
+	}
+	var approval Approval
+	if err = json.Unmarshal(approvalBytes, &approval); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling approval: %s", id)
+	}
+	return &approval, nil
+}
+
+func (s *Storage) ListApprovals(ctx context.Context) ([]Approval, error) {
+	var approvals []Approval
+	err := s.db.Iterate(ctx, namespace, func(key string, approvalBytes []byte) (bool, error) {
+		var approval Approval
+		if err := json.Unmarshal(approvalBytes, &approval); err != nil {
+			logrus.WithError(err).Warnf("unmarshal approval: %s", key)
+			return true, nil
+		}
+		approvals = append(approvals, approval)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list approvals")
+	}
+	return approvals, nil
+}
+
+// UpdateApproval applies update to the approval with the ID in a transaction that watches it, so that concurrent
+// reviews, and uses, of the same approval can't both succeed. update returns an error to leave the approval as it is.
+func (s *Storage) UpdateApproval(ctx context.Context, id string, update func(approval *Approval) error) (*Approval, error) {
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: id}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		approvalBytes, err := s.db.Read(ctx, namespace, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(approvalBytes) == 0 {
+			return nil, ErrApprovalNotFound
+		}
+		var approval Approval
+		if err = json.Unmarshal(approvalBytes, &approval); err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling approval: %s", id)
+		}
+		if err = update(&approval); err != nil {
+			return nil, err
+		}
+		if approvalBytes, err = json.Marshal(approval); err != nil {
+			return nil, errors.Wrapf(err, "marshalling approval: %s", id)
+		}
+		return &approval, tx.Write(ctx, namespace, id, approvalBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(*Approval), nil
+}
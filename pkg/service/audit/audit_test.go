@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestAuditChain(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			keyStore, err := keystore.NewKeyStoreService(config.KeyStoreServiceConfig{}, db)
+			require.NoError(t, err)
+			service, err := NewAuditService(db, keyStore)
+			require.NoError(t, err)
+
+			verify := func(t *testing.T) *VerifyEventsResponse {
+				verified, err := service.VerifyEvents(ctx)
+				require.NoError(t, err)
+				return verified
+			}
+			verified := verify(t)
+			assert.True(t, verified.Verified)
+			assert.Zero(t, verified.Events)
+
+			var events []Event
+			for _, resource := range []string{"/v1/dids/key", "/v1/credentials", "/v1/keys/did:example:1#key-1/revocation"} {
+				event, err := service.Record(ctx, Event{Actor: "issuer", Method: http.MethodPut, Resource: resource, Status: http.StatusOK, Outcome: OutcomeSucceeded})
+				require.NoError(t, err)
+				events = append(events, *event)
+			}
+
+			// each event is chained to the one before it, and signed
+			assert.Equal(t, uint64(1), events[0].Sequence)
+			assert.Empty(t, events[0].PreviousHash)
+			for i := 1; i < len(events); i++ {
+				assert.Equal(t, uint64(i+1), events[i].Sequence)
+				assert.Equal(t, events[i-1].Hash, events[i].PreviousHash)
+			}
+			verified = verify(t)
+			assert.True(t, verified.Verified, verified.Reason)
+			assert.Equal(t, 3, verified.Events)
+			assert.Zero(t, verified.Unsigned)
+			assert.Equal(t, ChainHead{Sequence: 3, Hash: events[2].Hash}, verified.Head)
+			assert.Contains(t, verified.LogID, "did:key:")
+
+			write := func(event Event) {
+				eventBytes, err := json.Marshal(event)
+				require.NoError(t, err)
+				require.NoError(t, db.Write(ctx, auditNamespace, event.ID, eventBytes))
+			}
+
+			// a changed event
+			changed := events[1]
+			changed.Actor = "someone else"
+			write(changed)
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Equal(t, changed.ID, verified.EventID)
+			assert.Contains(t, verified.Reason, "changed")
+
+			// a changed event whose hash was recomputed, which breaks its signature
+			changed.Hash, err = changed.ComputeHash()
+			require.NoError(t, err)
+			write(changed)
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Equal(t, changed.ID, verified.EventID)
+			assert.Contains(t, verified.Reason, "signature")
+
+			// and whose signature was removed, which an event after the first signed one can't be
+			changed.Signature = ""
+			write(changed)
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Equal(t, changed.ID, verified.EventID)
+			assert.Contains(t, verified.Reason, "event 2 isn't signed, though event 1 before it is")
+
+			// a removed event
+			write(events[1])
+			require.NoError(t, db.Delete(ctx, auditNamespace, events[1].ID))
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Contains(t, verified.Reason, "missing")
+
+			// a removed last event, which the head still names
+			write(events[1])
+			require.NoError(t, db.Delete(ctx, auditNamespace, events[2].ID))
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Contains(t, verified.Reason, "its head is event 3")
+
+			// a forged signature
+			write(events[2])
+			forged := events[2]
+			forged.Signature = events[1].Signature
+			write(forged)
+			verified = verify(t)
+			assert.False(t, verified.Verified)
+			assert.Contains(t, verified.Reason, "signature")
+
+			write(events[2])
+			assert.True(t, verify(t).Verified)
+		})
+	}
+}
+
+func TestAuditChainUnsigned(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			service, err := NewAuditService(test.ServiceStorage(t), nil)
+			require.NoError(t, err)
+
+			_, err = service.Record(ctx, Event{Method: http.MethodPut, Resource: "/v1/schemas", Outcome: OutcomeSucceeded})
+			require.NoError(t, err)
+			verified, err := service.VerifyEvents(ctx)
+			require.NoError(t, err)
+			assert.True(t, verified.Verified, verified.Reason)
+			assert.Equal(t, 1, verified.Unsigned)
+			assert.Empty(t, verified.LogID)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gowebpki/jcs"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+// Outcome summarizes how a call ended.
+type Outcome string
+
+const (
+	// OutcomeSucceeded is the outcome of calls that responded with a 2xx or 3xx status, except 202.
+	OutcomeSucceeded Outcome = "succeeded"
+	// OutcomeAccepted is the outcome of calls that were accepted to run asynchronously. The call that runs them is
+	// recorded as well.
+	OutcomeAccepted Outcome = "accepted"
+	// OutcomeDenied is the outcome of calls that weren't authenticated or authorized.
+	OutcomeDenied Outcome = "denied"
+	// OutcomeFailed is the outcome of every other call.
+	OutcomeFailed Outcome = "failed"
+)
+
+// OutcomeOf returns the outcome of a call that responded with status.
+func OutcomeOf(status int) Outcome {
+	switch {
+	case status == http.StatusAccepted:
+		return OutcomeAccepted
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	case status >= 200 && status < 400:
+		return OutcomeSucceeded
+	default:
+		return OutcomeFailed
+	}
+}
+
+// Event records a call to the API that changes something.
+type Event struct {
+	// ID of the event. IDs sort in the order events were recorded in.
+	ID string `json:"id"`
+
+	Time time.Time `json:"time"`
+
+	// ID of the request, as logged and sent in the X-Request-ID header.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Who made the call, which is the ID of their API key or the subject of their access token. Empty when the call
+	// wasn't authenticated.
+	Actor string `json:"actor,omitempty"`
+
+	// Tenant the call was made in. Empty for the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+
+	// ID of the client key that signed the call, when request signatures are required.
+	Signer string `json:"signer,omitempty"`
+
+	Method string `json:"method"`
+
+	// Route of the call as registered, e.g. /v1/dids/:method/:id.
+	Route string `json:"route,omitempty"`
+
+	// Path of the resource the call acted on, which is the path of the resource created by calls that create one.
+	Resource string `json:"resource"`
+
+	// ETags of the resource before and after the call, which are hashes of its representation. Empty when the resource
+	// didn't exist, or the call isn't on a single resource.
+	BeforeHash string `json:"beforeHash,omitempty"`
+	AfterHash  string `json:"afterHash,omitempty"`
+
+	// Status of the response.
+	Status  int     `json:"status"`
+	Outcome Outcome `json:"outcome"`
+
+	// Position of the event in the hash chain of the log, counted from 1. Events recorded before the log was chained
+	// have none.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	// Hash of the event before it in the chain, which chains the event to every event recorded before it. Empty for the
+	// first event.
+	PreviousHash []byte `json:"previousHash,omitempty"`
+
+	// SHA-256 hash of the canonical JSON of the event, without its hash and signature.
+	Hash []byte `json:"hash,omitempty"`
+
+	// JWT of the sequence and hash of the event, signed by the key of the log when the event was recorded. Empty when
+	// the event couldn't be signed.
+	Signature keyaccess.JWT `json:"signature,omitempty"`
+}
+
+// ComputeHash returns the hash of the event, which is the SHA-256 hash of its JSON, canonicalized according to RFC 8785,
+// without its hash and signature.
+func (e Event) ComputeHash() ([]byte, error) {
+	e.Hash, e.Signature = nil, ""
+	eventBytes, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling event")
+	}
+	canonical, err := jcs.Transform(eventBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing event")
+	}
+	hash := sha256.Sum256(canonical)
+	return hash[:], nil
+}
+
+// SignedEvent is what the signature of an event signs.
+type SignedEvent struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     []byte `json:"hash"`
+}
+
+// ChainHead is the last event of the hash chain of the log.
+type ChainHead struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     []byte `json:"hash,omitempty"`
+}
+
+// ListEventsRequest filters the events that are listed. Empty fields match every event.
+type ListEventsRequest struct {
+	Actor   string
+	Tenant  string
+	Outcome Outcome
+
+	// Prefix of the resources of the events, e.g. /v1/credentials.
+	Resource string
+
+	// Events recorded at or after Since, and before Until.
+	Since time.Time
+	Until time.Time
+}
+
+// Matches returns whether the event passes the filters of the request.
+func (r ListEventsRequest) Matches(event Event) bool {
+	switch {
+	case r.Actor != "" && event.Actor != r.Actor:
+		return false
+	case r.Tenant != "" && event.Tenant != r.Tenant:
+		return false
+	case r.Outcome != "" && event.Outcome != r.Outcome:
+		return false
+	case r.Resource != "" && !hasPathPrefix(event.Resource, r.Resource):
+		return false
+	case !r.Since.IsZero() && event.Time.Before(r.Since):
+		return false
+	case !r.Until.IsZero() && !event.Time.Before(r.Until):
+		return false
+	}
+	return true
+}
+
+type ListEventsResponse struct {
+	Events []Event
+}
+
+type VerifyEventsResponse struct {
+	// Whether the hash chain of the log is unbroken, and the signatures of its events are valid.
+	Verified bool
+
+	// Why the log failed verification, and the ID of the first event that failed it, when it did.
+	Reason  string
+	EventID string
+
+	// How many chained events were verified, and how many of them aren't signed, which only events recorded before the
+	// first signed one may be.
+	Events   int
+	Unsigned int
+
+	// The last event of the chain, which a later verification should find the chain to still extend.
+	Head ChainHead
+
+	// DID the events are signed by.
+	LogID string
+}
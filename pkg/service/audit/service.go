@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Service keeps the audit log, which records every call to the API that changes something. Events are kept in the
+// storage of the deployment rather than of a tenant, so the log of every tenant can be reviewed in one place. Each
+// event is chained to the events before it by their hashes, and signed by the key of the log, so that changing,
+// removing, or reordering recorded events is detected when the log is verified.
+type Service struct {
+	storage  *Storage
+	keyStore *keystore.Service
+	Clock    clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Audit
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("audit service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewAuditService creates the audit service. Events are signed with a key kept in keyStore, and aren't signed when it's
+// nil.
+func NewAuditService(s storage.ServiceStorage, keyStore *keystore.Service) (*Service, error) {
+	auditStorage, err := NewAuditStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate audit storage for the audit service")
+	}
+	return &Service{storage: auditStorage, keyStore: keyStore, Clock: clock.New()}, nil
+}
+
+// Record appends an event to the audit log, assigning its ID and time, and chaining it to the event before it. Events
+// that can't be signed are recorded unsigned, since the calls they record already happened.
+func (s Service) Record(ctx context.Context, event Event) (*Event, error) {
+	signer, err := s.getSigner(ctx)
+	if err != nil {
+		logrus.WithContext(ctx).WithError(err).Error("could not get the key of the audit log, recording the event unsigned")
+	}
+	recorded, err := s.storage.AppendEvent(ctx, func(head ChainHead) (*Event, error) {
+		sealed := event
+		now := s.Clock.Now().UTC()
+		// the time comes first so that IDs sort in the order events were recorded in, and the random suffix keeps events
+		// recorded at the same time apart
+		sealed.ID = fmt.Sprintf("%016x-%s", now.UnixNano(), uuid.NewString()[:8])
+		sealed.Time = now
+		sealed.Sequence = head.Sequence + 1
+		sealed.PreviousHash = head.Hash
+		hash, err := sealed.ComputeHash()
+		if err != nil {
+			return nil, err
+		}
+		sealed.Hash = hash
+		if signer != nil {
+			signature, err := signer.SignJSON(SignedEvent{Sequence: sealed.Sequence, Hash: hash})
+			if err != nil {
+				logrus.WithContext(ctx).WithError(err).Errorf("could not sign audit event %d, recording it unsigned", sealed.Sequence)
+			} else {
+				sealed.Signature = *signature
+			}
+		}
+		return &sealed, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "recording audit event")
+	}
+	return recorded, nil
+}
+
+// getSigner returns what signs the events of the log, or nil without a keystore. The key of the log is kept in the
+// keystore of the default tenant, since the log is the deployment's. It's read without the signing monitor, which
+// would otherwise count every call that changes something as a signature.
+func (s Service) getSigner(ctx context.Context) (*keyaccess.JWKKeyAccess, error) {
+	if s.keyStore == nil {
+		return nil, nil
+	}
+	ctx = storage.WithTenant(ctx, "")
+	logKey, err := s.getLogKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gotKey, err := s.keyStore.GetKey(ctx, keystore.GetKeyRequest{ID: logKey.KeyID})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "getting log key")
+	}
+	if gotKey.Revoked {
+		return nil, sdkutil.LoggingNewErrorf("log key<%s> was revoked", gotKey.ID)
+	}
+	return keyaccess.NewJWKKeyAccess(gotKey.Controller, gotKey.ID, gotKey.Key)
+}
+
+// getLogKey returns the key of the log, creating a did:key for it the first time the log signs an event.
+func (s Service) getLogKey(ctx context.Context) (*keystore.DIDKey, error) {
+	return s.keyStore.GetOrCreateDIDKey(ctx, s.storage.db, chainNamespace, logKeyKey)
+}
+
+// VerifyEvents verifies the hash chain of the audit log, from its first chained event to its head, and the signatures
+// of its events. Events recorded before the log was chained aren't verified. A log that fails verification isn't an
+// error: the response tells why it failed.
+func (s Service) VerifyEvents(ctx context.Context) (*VerifyEventsResponse, error) {
+	var events []Event
+	err := s.storage.IterateEvents(ctx, func(event Event) (bool, error) {
+		if event.Sequence > 0 {
+			events = append(events, event)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "reading audit events")
+	}
+	// storage that doesn't keep keys sorted iterates events in any order
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+
+	head, err := s.storage.GetChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := VerifyEventsResponse{Head: *head}
+	var verifier *keyaccess.JWKKeyAccess
+	logKey, err := s.storage.GetLogKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if logKey != nil {
+		resp.LogID = logKey.DID
+		if verifier, err = logKeyVerifier(*logKey); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "reading log key")
+		}
+	}
+
+	fail := func(event Event, reason string, args ...any) *VerifyEventsResponse {
+		resp.Reason = fmt.Sprintf(reason, args...)
+		resp.EventID = event.ID
+		return &resp
+	}
+	// once the log signs events, an unsigned one is a signed one whose signature was removed
+	var previous, firstSigned Event
+	for i, event := range events {
+		switch {
+		case i == 0 && event.Sequence != 1:
+			return fail(event, "events before event %d are missing", event.Sequence), nil
+		case i == 0 && len(event.PreviousHash) != 0:
+			return fail(event, "the first event is chained to an event before it"), nil
+		case i > 0 && event.Sequence == previous.Sequence:
+			return fail(event, "events %s and %s are both event %d", previous.ID, event.ID, event.Sequence), nil
+		case i > 0 && event.Sequence != previous.Sequence+1:
+			return fail(event, "events %d to %d are missing", previous.Sequence+1, event.Sequence-1), nil
+		case i > 0 && !bytes.Equal(event.PreviousHash, previous.Hash):
+			return fail(event, "event %d isn't chained to event %d", event.Sequence, previous.Sequence), nil
+		}
+		hash, err := event.ComputeHash()
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "hashing audit event: %s", event.ID)
+		}
+		if !bytes.Equal(hash, event.Hash) {
+			return fail(event, "event %d was changed after it was recorded", event.Sequence), nil
+		}
+		switch {
+		case event.Signature == "" && firstSigned.Sequence != 0:
+			return fail(event, "event %d isn't signed, though event %d before it is", event.Sequence, firstSigned.Sequence), nil
+		case event.Signature == "":
+			resp.Unsigned++
+		default:
+			if err = verifySignature(verifier, event); err != nil {
+				return fail(event, "the signature of event %d is invalid: %s", event.Sequence, err.Error()), nil
+			}
+			if firstSigned.Sequence == 0 {
+				firstSigned = event
+			}
+		}
+		resp.Events++
+		previous = event
+	}
+	// the head is written along with the last event, so a chain that ends before its head had events removed from its end
+	if previous.Sequence != head.Sequence || !bytes.Equal(previous.Hash, head.Hash) {
+		return fail(previous, "the chain ends at event %d, but its head is event %d", previous.Sequence, head.Sequence), nil
+	}
+	resp.Verified = true
+	return &resp, nil
+}
+
+// logKeyVerifier returns what verifies the signatures of the log key, whose public key is read from its did:key.
+func logKeyVerifier(logKey keystore.DIDKey) (*keyaccess.JWKKeyAccess, error) {
+	pubKeyBytes, keyType, err := key.DIDKey(logKey.DID).Decode()
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := crypto.BytesToPubKey(pubKeyBytes, keyType)
+	if err != nil {
+		return nil, err
+	}
+	return keyaccess.NewJWKKeyAccessVerifier(logKey.DID, logKey.KeyID, pubKey)
+}
+
+// verifySignature checks that the signature of an event was made by the log key, over the event's sequence and hash.
+func verifySignature(verifier *keyaccess.JWKKeyAccess, event Event) error {
+	if verifier == nil {
+		return fmt.Errorf("the log has no key")
+	}
+	_, token, err := verifier.VerifyAndParse(event.Signature.String())
+	if err != nil {
+		return err
+	}
+	claims := token.PrivateClaims()
+	if sequence, _ := claims["sequence"].(float64); uint64(sequence) != event.Sequence {
+		return fmt.Errorf("it signs another event")
+	}
+	if hash, _ := claims["hash"].(string); hash != base64.StdEncoding.EncodeToString(event.Hash) {
+		return fmt.Errorf("it signs another hash")
+	}
+	return nil
+}
+
+// ListEvents returns the events of the audit log that match the request.
+func (s Service) ListEvents(ctx context.Context, request ListEventsRequest) (*ListEventsResponse, error) {
+	events := make([]Event, 0)
+	err := s.ExportEvents(ctx, request, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListEventsResponse{Events: events}, nil
+}
+
+// ExportEvents calls fn with each event of the audit log that matches the request, without reading them all into
+// memory, stopping at the first error fn returns.
+func (s Service) ExportEvents(ctx context.Context, request ListEventsRequest, fn func(event Event) error) error {
+	err := s.storage.IterateEvents(ctx, func(event Event) (bool, error) {
+		if !request.Matches(event) {
+			return true, nil
+		}
+		if err := fn(event); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "reading audit events")
+	}
+	return nil
+}
+
+// hasPathPrefix returns whether path is prefix, or a path under it.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
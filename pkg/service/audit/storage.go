@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// auditNamespace holds the events of the audit log. It's append-only: events are written once, under IDs that are never
+// reused, and nothing updates or deletes them.
+const auditNamespace = "audit"
+
+// chainNamespace holds the head of the hash chain of the audit log, and the key its events are signed with.
+const (
+	chainNamespace = "audit-chain"
+	headKey        = "head"
+	logKeyKey      = "log-key"
+)
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewAuditStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// AppendEvent adds an event to the end of the hash chain of the audit log. seal is called with the head of the chain,
+// and returns the event chained to it, in a transaction that watches the head, so that events are chained one at a
+// time, by every instance sharing the storage. Events are never overwritten, so appending an event whose ID is already
+// taken fails.
+func (s *Storage) AppendEvent(ctx context.Context, seal func(head ChainHead) (*Event, error)) (*Event, error) {
+	watchKeys := []storage.WatchKey{{Namespace: chainNamespace, Key: headKey}}
+	result, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		head, err := s.GetChainHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		event, err := seal(*head)
+		if err != nil {
+			return nil, err
+		}
+		if event.ID == "" {
+			return nil, errors.New("could not append audit event without an ID")
+		}
+		exists, err := s.db.Exists(ctx, auditNamespace, event.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not append audit event: %s", event.ID)
+		}
+		if exists {
+			return nil, errors.Errorf("could not append audit event: %s already exists", event.ID)
+		}
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not append audit event: %s", event.ID)
+		}
+		if err = tx.Write(ctx, auditNamespace, event.ID, eventBytes); err != nil {
+			return nil, err
+		}
+		headBytes, err := json.Marshal(ChainHead{Sequence: event.Sequence, Hash: event.Hash})
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling chain head")
+		}
+		return event, tx.Write(ctx, chainNamespace, headKey, headBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not append audit event")
+	}
+	return result.(*Event), nil
+}
+
+// GetChainHead returns the last event of the hash chain of the audit log, which is empty before an event is chained.
+func (s *Storage) GetChainHead(ctx context.Context) (*ChainHead, error) {
+	headBytes, err := s.db.Read(ctx, chainNamespace, headKey)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get chain head")
+	}
+	var head ChainHead
+	if len(headBytes) == 0 {
+		return &head, nil
+	}
+	if err = json.Unmarshal(headBytes, &head); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unmarshalling chain head")
+	}
+	return &head, nil
+}
+
+// GetLogKey returns the key the log signs its events with, or nil before one is created.
+func (s *Storage) GetLogKey(ctx context.Context) (*keystore.DIDKey, error) {
+	return keystore.GetDIDKey(ctx, s.db, chainNamespace, logKeyKey)
+}
+
+// IterateEvents calls fn with every event of the audit log, without reading them all into memory, until fn returns
+// false.
+func (s *Storage) IterateEvents(ctx context.Context, fn func(event Event) (bool, error)) error {
+	return s.db.Iterate(ctx, auditNamespace, func(key string, eventBytes []byte) (bool, error) {
+		var event Event
+		if err := json.Unmarshal(eventBytes, &event); err != nil {
+			logrus.WithError(err).Warnf("unmarshal audit event: %s", key)
+			return true, nil
+		}
+		return fn(event)
+	})
+}
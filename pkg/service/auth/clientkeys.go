@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	gocrypto "crypto"
+
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/httpsig"
+)
+
+// BootstrapClientKeyID is the key ID that signatures made with the client key configured via config.AuthServiceConfig
+// name.
+const BootstrapClientKeyID = "bootstrap-client"
+
+var (
+	// ErrInvalidClientKey is returned when a client key can't be registered, or can't verify signatures because it's
+	// unknown or revoked.
+	ErrInvalidClientKey = errors.New("invalid client key")
+
+	// ErrClientKeyNotFound is returned when no client key exists with the requested ID.
+	ErrClientKeyNotFound = errors.New("client key not found")
+)
+
+// clientPublicKey is a client key along with its parsed public key.
+type clientPublicKey struct {
+	ClientKey
+	publicKey gocrypto.PublicKey
+}
+
+// newBootstrapClientKey parses the client key configured as a JWK, which can sign requests before any key is registered.
+func newBootstrapClientKey(rawJWK string) (*clientPublicKey, error) {
+	var publicKeyJWK jwx.PublicKeyJWK
+	if err := json.Unmarshal([]byte(rawJWK), &publicKeyJWK); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling bootstrap client key")
+	}
+	key, err := parseClientKey(ClientKey{ID: BootstrapClientKeyID, Name: BootstrapClientKeyID, PublicKeyJWK: publicKeyJWK})
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing bootstrap client key")
+	}
+	return key, nil
+}
+
+// parseClientKey parses the public key of a client key, defaulting its algorithm to the one of the key type.
+func parseClientKey(key ClientKey) (*clientPublicKey, error) {
+	publicKey, err := key.PublicKeyJWK.ToPublicKey()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidClientKey, err.Error())
+	}
+	if key.Algorithm == "" {
+		if key.Algorithm, err = httpsig.KeyAlgorithm(publicKey); err != nil {
+			return nil, errors.Wrap(ErrInvalidClientKey, err.Error())
+		}
+	} else if err = httpsig.CheckAlgorithm(key.Algorithm, publicKey); err != nil {
+		return nil, errors.Wrap(ErrInvalidClientKey, err.Error())
+	}
+	return &clientPublicKey{ClientKey: key, publicKey: publicKey}, nil
+}
+
+// RegisterClientKey registers a public key that a client signs requests with. When no algorithm is given, the one of
+// the key type is used.
+func (s Service) RegisterClientKey(ctx context.Context, request RegisterClientKeyRequest) (*ClientKey, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid register client key request")
+	}
+	parsed, err := parseClientKey(ClientKey{
+		ID:           uuid.NewString(),
+		Name:         request.Name,
+		Subject:      request.Subject,
+		Algorithm:    request.Algorithm,
+		PublicKeyJWK: request.PublicKeyJWK,
+		CreatedAt:    s.Clock.Now().UTC(),
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "registering client key")
+	}
+	if err = s.storage.StoreClientKey(ctx, parsed.ClientKey); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "storing client key")
+	}
+	return &parsed.ClientKey, nil
+}
+
+func (s Service) GetClientKey(ctx context.Context, request GetClientKeyRequest) (*ClientKey, error) {
+	key, err := s.storage.GetClientKey(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting client key: %s", request.ID)
+	}
+	if key == nil {
+		return nil, sdkutil.LoggingErrorMsgf(ErrClientKeyNotFound, "getting client key: %s", request.ID)
+	}
+	return key, nil
+}
+
+func (s Service) ListClientKeys(ctx context.Context) (*ListClientKeysResponse, error) {
+	keys, err := s.storage.ListClientKeys(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing client keys")
+	}
+	if keys == nil {
+		keys = make([]ClientKey, 0)
+	}
+	return &ListClientKeysResponse{ClientKeys: keys}, nil
+}
+
+// RevokeClientKey marks a client key as revoked. Signatures made with revoked keys are rejected.
+func (s Service) RevokeClientKey(ctx context.Context, request RevokeClientKeyRequest) error {
+	key, err := s.storage.GetClientKey(ctx, request.ID)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "getting client key: %s", request.ID)
+	}
+	if key == nil {
+		return sdkutil.LoggingErrorMsgf(ErrClientKeyNotFound, "revoking client key: %s", request.ID)
+	}
+	if key.Revoked {
+		return nil
+	}
+	now := s.Clock.Now().UTC()
+	key.Revoked = true
+	key.RevokedAt = &now
+	if err = s.storage.StoreClientKey(ctx, *key); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "revoking client key: %s", request.ID)
+	}
+	return nil
+}
+
+// ClientPublicKey returns the client key that signatures naming keyID were made with, along with its public key.
+// ErrInvalidClientKey is returned when the key is unknown or has been revoked.
+func (s Service) ClientPublicKey(ctx context.Context, keyID string) (*ClientKey, gocrypto.PublicKey, error) {
+	if s.bootstrapClientKey != nil && keyID == BootstrapClientKeyID {
+		return &s.bootstrapClientKey.ClientKey, s.bootstrapClientKey.publicKey, nil
+	}
+	if keyID == "" {
+		return nil, nil, ErrInvalidClientKey
+	}
+	key, err := s.storage.GetClientKey(ctx, keyID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "getting client key")
+	}
+	if key == nil {
+		return nil, nil, ErrInvalidClientKey
+	}
+	if key.Revoked {
+		logrus.Warnf("revoked client key<%s> signed a request", keyID)
+		return nil, nil, ErrInvalidClientKey
+	}
+	parsed, err := parseClientKey(*key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &parsed.ClientKey, parsed.publicKey, nil
+}
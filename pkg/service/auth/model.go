@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+)
+
+// APIKey is the public view of an API key. The secret portion of a key is only ever returned once, upon creation,
+// and is never persisted in plain text.
+type APIKey struct {
+	// ID of the key. This is also the first segment of the raw key value.
+	ID string `json:"id"`
+
+	// Human-readable name to help operators identify what the key is used for.
+	Name string `json:"name"`
+
+	// Whether the key is allowed to call administrative endpoints, such as minting and revoking other keys.
+	Admin bool `json:"admin"`
+
+	// Tenant whose data the key can access. Empty for the default tenant.
+	TenantID string `json:"tenantId,omitempty"`
+
+	// Scopes the key is restricted to, e.g. credentials:issue and dids:read. Keys without scopes are not restricted.
+	Scopes []string `json:"scopes,omitempty"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	Revoked   bool       `json:"revoked"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name     string `validate:"required"`
+	Admin    bool
+	TenantID string
+	Scopes   []string
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey APIKey
+
+	// Key is the raw value that clients must present. It is not recoverable after this response.
+	Key string
+}
+
+type GetAPIKeyRequest struct {
+	ID string `validate:"required"`
+}
+
+type GetAPIKeyResponse struct {
+	APIKey APIKey
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKey
+}
+
+type RevokeAPIKeyRequest struct {
+	ID string `validate:"required"`
+}
+
+type CreateRoleRequest struct {
+	Role Role `validate:"required"`
+}
+
+type GetRoleRequest struct {
+	Name string `validate:"required"`
+}
+
+type ListRolesResponse struct {
+	Roles []Role
+}
+
+type DeleteRoleRequest struct {
+	Name string `validate:"required"`
+}
+
+type SetRoleBindingRequest struct {
+	RoleBinding RoleBinding `validate:"required"`
+}
+
+type GetRoleBindingRequest struct {
+	Subject string `validate:"required"`
+}
+
+type DeleteRoleBindingRequest struct {
+	Subject string `validate:"required"`
+}
+
+// ClientKey is a public key registered for a client to sign its requests with, using HTTP Message Signatures. Like API
+// keys, revoked client keys are kept, so that the signatures they made can still be attributed.
+type ClientKey struct {
+	// ID of the key, which signatures name in their keyid parameter.
+	ID string `json:"id"`
+
+	// Human-readable name to help operators identify who signs with the key.
+	Name string `json:"name"`
+
+	// Subject that must have authenticated the requests signed with the key, which is the ID of an API key or the
+	// `sub` claim of an access token. When empty, the key may sign the requests of any caller.
+	Subject string `json:"subject,omitempty"`
+
+	// Algorithm the key signs with, from the HTTP Signature Algorithms registry, e.g. ed25519 or ecdsa-p256-sha256.
+	Algorithm string `json:"alg"`
+
+	PublicKeyJWK jwx.PublicKeyJWK `json:"publicKeyJwk"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	Revoked   bool       `json:"revoked"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type RegisterClientKeyRequest struct {
+	Name         string `validate:"required"`
+	Subject      string
+	Algorithm    string
+	PublicKeyJWK jwx.PublicKeyJWK `validate:"required"`
+}
+
+type GetClientKeyRequest struct {
+	ID string `validate:"required"`
+}
+
+type ListClientKeysResponse struct {
+	ClientKeys []ClientKey
+}
+
+type RevokeClientKeyRequest struct {
+	ID string `validate:"required"`
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Scopes that can be granted through OAuth2 access tokens and API keys. Each guards a group of endpoints.
+const (
+	ScopeAdmin                  = "admin"
+	ScopeKeysWrite              = "keys:write"
+	ScopeDIDsRead               = "dids:read"
+	ScopeDIDsWrite              = "dids:write"
+	ScopeDIDConfigurationsWrite = "didconfigurations:write"
+	ScopeSchemasRead            = "schemas:read"
+	ScopeSchemasWrite           = "schemas:write"
+	ScopeCredentialsRead        = "credentials:read"
+	ScopeCredentialsIssue       = "credentials:issue"
+	ScopeTemplatesWrite         = "templates:write"
+	ScopeDefinitionsWrite       = "definitions:write"
+	ScopeRequestsWrite          = "requests:write"
+	ScopeChallengesWrite        = "challenges:write"
+	ScopeSubmissionsReview      = "submissions:review"
+	ScopeManifestsWrite         = "manifests:write"
+	ScopeApplicationsReview     = "applications:review"
+	ScopeWebhooksWrite          = "webhooks:write"
+	ScopeOperationsWrite        = "operations:write"
+	ScopeTrustWrite             = "trust:write"
+
+	// jwksMinRefreshInterval bounds how often the JWKS is fetched from the issuer, even when tokens reference key
+	// IDs that are not in the cached set.
+	jwksMinRefreshInterval = 5 * time.Minute
+
+	// tokenClockSkew is how much clock drift is tolerated when validating time based claims.
+	tokenClockSkew = 30 * time.Second
+
+	// discoveryTimeout bounds requests for the OpenID configuration and the JWKS of the issuer.
+	discoveryTimeout = 10 * time.Second
+
+	openIDConfigurationPath = "/.well-known/openid-configuration"
+)
+
+// readScopes guard the endpoints that only read. They're only required of callers with ScopedReads, so that access
+// tokens and API keys that predate them keep reading what they could.
+var readScopes = map[string]bool{
+	ScopeDIDsRead:        true,
+	ScopeSchemasRead:     true,
+	ScopeCredentialsRead: true,
+}
+
+// IsValidScope returns whether the scope guards any endpoints.
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeAdmin, ScopeKeysWrite, ScopeDIDsWrite, ScopeDIDConfigurationsWrite, ScopeSchemasWrite, ScopeCredentialsIssue,
+		ScopeTemplatesWrite, ScopeDefinitionsWrite, ScopeRequestsWrite, ScopeChallengesWrite, ScopeSubmissionsReview,
+		ScopeManifestsWrite, ScopeApplicationsReview, ScopeWebhooksWrite, ScopeOperationsWrite, ScopeTrustWrite:
+		return true
+	}
+	return readScopes[scope]
+}
+
+// ErrInvalidAccessToken is returned when a bearer token fails signature or claim validation.
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// Principal is the authenticated caller of a request, regardless of how they authenticated.
+type Principal struct {
+	// ID of the API key, or the subject of the access token.
+	ID string
+
+	// Whether the caller may call administrative endpoints.
+	Admin bool
+
+	// Scopes granted to the caller. Ignored when AllScopes is true.
+	Scopes []string
+
+	// AllScopes is set for callers that are not subject to scope checks.
+	AllScopes bool
+
+	// ScopedReads is set for callers that need a read scope, like dids:read, to call the endpoints that only read.
+	// Other callers may call them without one.
+	ScopedReads bool
+
+	// Permissions granted through the roles bound to the caller.
+	Permissions []Permission
+
+	// Tenant whose data the caller can access. Empty for the default tenant.
+	TenantID string
+}
+
+// HasScope returns whether the principal was granted the given scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.AllScopes {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal returns the principal that authenticates with this key. API keys created with scopes are restricted to
+// them, reads included, and other API keys are not restricted by scopes.
+func (k APIKey) Principal() *Principal {
+	if len(k.Scopes) > 0 {
+		return &Principal{ID: k.ID, Admin: k.Admin, Scopes: k.Scopes, ScopedReads: true, TenantID: k.TenantID}
+	}
+	return &Principal{ID: k.ID, Admin: k.Admin, AllScopes: true, TenantID: k.TenantID}
+}
+
+// tokenVerifier validates JWT access tokens minted by an external OAuth2 / OIDC issuer.
+type tokenVerifier struct {
+	issuer        string
+	audience      string
+	tenantClaim   string
+	scopeMappings []config.OAuthScopeMappingConfig
+	client        *http.Client
+
+	// keys of the issuer, which are discovered on first use when no JWKS URL is configured
+	mu   sync.Mutex
+	keys jwk.Set
+}
+
+func newTokenVerifier(cfg config.AuthServiceConfig) (*tokenVerifier, error) {
+	if cfg.OAuthIssuer == "" {
+		return nil, errors.New("oauth issuer cannot be empty")
+	}
+	for _, mapping := range cfg.OAuthScopeMappings {
+		if mapping.Claim == "" || mapping.Value == "" {
+			return nil, errors.New("oauth scope mappings must have a claim and a value")
+		}
+		for _, scope := range mapping.Scopes {
+			if !IsValidScope(scope) {
+				return nil, errors.Wrapf(ErrInvalidScope, "mapping claim %s to scope: %s", mapping.Claim, scope)
+			}
+		}
+	}
+	v := tokenVerifier{
+		issuer:        cfg.OAuthIssuer,
+		audience:      cfg.OAuthAudience,
+		tenantClaim:   cfg.OAuthTenantClaim,
+		scopeMappings: cfg.OAuthScopeMappings,
+		client:        &http.Client{Timeout: discoveryTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+	if cfg.OAuthJWKSURL != "" {
+		if err := v.registerJWKS(cfg.OAuthJWKSURL); err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+func (v *tokenVerifier) registerJWKS(jwksURL string) error {
+	cache := jwk.NewCache(context.Background())
+	if err := cache.Register(jwksURL, jwk.WithMinRefreshInterval(jwksMinRefreshInterval), jwk.WithHTTPClient(v.client)); err != nil {
+		return errors.Wrapf(err, "registering jwks url: %s", jwksURL)
+	}
+	v.keys = jwk.NewCachedSet(cache, jwksURL)
+	return nil
+}
+
+// keySet returns the keys of the issuer, discovering where they're published from its OpenID configuration when no
+// JWKS URL is configured. Discovery is retried by later calls when it fails, e.g. while the issuer is unavailable.
+func (v *tokenVerifier) keySet(ctx context.Context) (jwk.Set, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys != nil {
+		return v.keys, nil
+	}
+
+	configURL := strings.TrimSuffix(v.issuer, "/") + openIDConfigurationPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating openid configuration request")
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting openid configuration: %s", configURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("getting openid configuration: %s responded with %d", configURL, resp.StatusCode)
+	}
+	var discovered struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&discovered); err != nil {
+		return nil, errors.Wrap(err, "decoding openid configuration")
+	}
+	// the configuration must be the issuer's own, so that tokens can't be verified with keys another issuer published
+	if strings.TrimSuffix(discovered.Issuer, "/") != strings.TrimSuffix(v.issuer, "/") {
+		return nil, errors.Errorf("openid configuration is of issuer<%s>, not <%s>", discovered.Issuer, v.issuer)
+	}
+	if discovered.JWKSURI == "" {
+		return nil, errors.New("openid configuration has no jwks_uri")
+	}
+	if err = v.registerJWKS(discovered.JWKSURI); err != nil {
+		return nil, err
+	}
+	return v.keys, nil
+}
+
+func (v *tokenVerifier) verify(ctx context.Context, rawToken string, now func() time.Time) (*Principal, error) {
+	keys, err := v.keySet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting keys of the oauth issuer")
+	}
+	options := []jwt.ParseOption{
+		jwt.WithContext(ctx),
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAcceptableSkew(tokenClockSkew),
+		jwt.WithClock(jwt.ClockFunc(now)),
+	}
+	if v.audience != "" {
+		options = append(options, jwt.WithAudience(v.audience))
+	}
+	token, err := jwt.ParseString(rawToken, options...)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidAccessToken, err.Error())
+	}
+	if token.Subject() == "" {
+		return nil, errors.Wrap(ErrInvalidAccessToken, "missing sub claim")
+	}
+
+	scopes := append(scopesFromToken(token), v.mappedScopes(token)...)
+	principal := Principal{ID: token.Subject(), Scopes: scopes}
+	principal.Admin = principal.HasScope(ScopeAdmin)
+	if v.tenantClaim != "" {
+		if tenant, ok := token.Get(v.tenantClaim); ok {
+			tenantID, ok := tenant.(string)
+			if !ok || !storage.IsValidTenantID(tenantID) {
+				return nil, errors.Wrapf(ErrInvalidAccessToken, "invalid %s claim", v.tenantClaim)
+			}
+			principal.TenantID = tenantID
+		}
+	}
+	return &principal, nil
+}
+
+// mappedScopes returns the scopes that the scope mappings grant the token.
+func (v *tokenVerifier) mappedScopes(token jwt.Token) []string {
+	var scopes []string
+	for _, mapping := range v.scopeMappings {
+		if claimHasValue(token, mapping.Claim, mapping.Value) {
+			scopes = append(scopes, mapping.Scopes...)
+		}
+	}
+	return scopes
+}
+
+// claimHasValue returns whether the claim, named by its dotted path, is the value, or is an array that includes it.
+func claimHasValue(token jwt.Token, claim, value string) bool {
+	path := strings.Split(claim, ".")
+	current, ok := token.Get(path[0])
+	for _, name := range path[1:] {
+		if !ok {
+			return false
+		}
+		object, isObject := current.(map[string]any)
+		if !isObject {
+			return false
+		}
+		current, ok = object[name]
+	}
+	if !ok {
+		return false
+	}
+	switch c := current.(type) {
+	case string:
+		return c == value
+	case []any:
+		for _, v := range c {
+			if s, isString := v.(string); isString && s == value {
+				return true
+			}
+		}
+	case []string:
+		for _, s := range c {
+			if s == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopesFromToken reads granted scopes from either the space delimited `scope` claim (RFC 9068) or the `scp` array
+// claim used by several identity providers.
+func scopesFromToken(token jwt.Token) []string {
+	var scopes []string
+	if scope, ok := token.Get("scope"); ok {
+		if s, ok := scope.(string); ok {
+			scopes = append(scopes, strings.Fields(s)...)
+		}
+	}
+	if scp, ok := token.Get("scp"); ok {
+		switch s := scp.(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(s)...)
+		case []any:
+			for _, v := range s {
+				if str, ok := v.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+		}
+	}
+	return scopes
+}
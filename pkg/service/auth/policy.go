@@ -0,0 +1,58 @@
+package auth
+
+// Resource types whose ownership is tracked, so that permissions can be constrained to owned resources.
+const (
+	ResourceManifest   = "manifest"
+	ResourceCredential = "credential"
+)
+
+// Permission grants an operation, optionally constrained to resources that the caller owns. Operations are named
+// after the scopes that guard the same endpoints, e.g. ScopeManifestsWrite.
+type Permission struct {
+	Operation string `json:"operation" validate:"required"`
+
+	// OwnedOnly restricts the operation to resources that were created by the caller. It applies to endpoints that
+	// address a single resource; creating new resources is always allowed, and listing them never is.
+	OwnedOnly bool `json:"ownedOnly,omitempty"`
+}
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string       `json:"name" validate:"required"`
+	Permissions []Permission `json:"permissions" validate:"required,dive"`
+}
+
+// RoleBinding assigns roles to a subject. The subject is either the ID of an API key, or the `sub` claim of an
+// access token.
+type RoleBinding struct {
+	Subject string   `json:"subject" validate:"required"`
+	Roles   []string `json:"roles" validate:"required"`
+}
+
+// Decision is the outcome of checking a principal's permission to perform an operation.
+type Decision struct {
+	Allowed bool
+
+	// OwnedOnly is set when the operation is only allowed on resources the principal owns.
+	OwnedOnly bool
+}
+
+// Authorize decides whether the principal may perform the operation. Unconstrained grants, from scopes or roles, take
+// precedence over grants limited to owned resources. Operations that only read are allowed without a grant, unless the
+// principal has ScopedReads.
+func (p Principal) Authorize(operation string) Decision {
+	if p.HasScope(operation) || (readScopes[operation] && !p.ScopedReads) {
+		return Decision{Allowed: true}
+	}
+	decision := Decision{}
+	for _, permission := range p.Permissions {
+		if permission.Operation != operation {
+			continue
+		}
+		if !permission.OwnedOnly {
+			return Decision{Allowed: true}
+		}
+		decision = Decision{Allowed: true, OwnedOnly: true}
+	}
+	return decision
+}
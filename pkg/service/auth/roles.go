@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrRoleNotFound is returned when no role exists with the requested name.
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleBindingNotFound is returned when the requested subject has no roles bound.
+	ErrRoleBindingNotFound = errors.New("role binding not found")
+)
+
+// CreateRole creates a role, replacing any existing role with the same name.
+func (s Service) CreateRole(ctx context.Context, request CreateRoleRequest) (*Role, error) {
+	if err := sdkutil.IsValidStruct(request.Role); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create role request")
+	}
+	if err := s.storage.StoreRole(ctx, request.Role); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "storing role: %s", request.Role.Name)
+	}
+	return &request.Role, nil
+}
+
+func (s Service) GetRole(ctx context.Context, request GetRoleRequest) (*Role, error) {
+	role, err := s.storage.GetRole(ctx, request.Name)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting role: %s", request.Name)
+	}
+	if role == nil {
+		return nil, sdkutil.LoggingErrorMsgf(ErrRoleNotFound, "getting role: %s", request.Name)
+	}
+	return role, nil
+}
+
+func (s Service) ListRoles(ctx context.Context) (*ListRolesResponse, error) {
+	roles, err := s.storage.ListRoles(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing roles")
+	}
+	if roles == nil {
+		roles = make([]Role, 0)
+	}
+	return &ListRolesResponse{Roles: roles}, nil
+}
+
+// DeleteRole deletes a role. Bindings that reference the role are left in place, and the role is ignored when
+// resolving them.
+func (s Service) DeleteRole(ctx context.Context, request DeleteRoleRequest) error {
+	if err := s.storage.DeleteRole(ctx, request.Name); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "deleting role: %s", request.Name)
+	}
+	return nil
+}
+
+// SetRoleBinding assigns roles to a subject, replacing any roles previously assigned. All roles must exist.
+func (s Service) SetRoleBinding(ctx context.Context, request SetRoleBindingRequest) (*RoleBinding, error) {
+	binding := request.RoleBinding
+	if err := sdkutil.IsValidStruct(binding); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid role binding request")
+	}
+	for _, name := range binding.Roles {
+		role, err := s.storage.GetRole(ctx, name)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "getting role: %s", name)
+		}
+		if role == nil {
+			return nil, sdkutil.LoggingErrorMsgf(ErrRoleNotFound, "binding role: %s", name)
+		}
+	}
+	if err := s.storage.StoreRoleBinding(ctx, binding); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "storing role binding: %s", binding.Subject)
+	}
+	return &binding, nil
+}
+
+func (s Service) GetRoleBinding(ctx context.Context, request GetRoleBindingRequest) (*RoleBinding, error) {
+	binding, err := s.storage.GetRoleBinding(ctx, request.Subject)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting role binding: %s", request.Subject)
+	}
+	if binding == nil {
+		return nil, sdkutil.LoggingErrorMsgf(ErrRoleBindingNotFound, "getting role binding: %s", request.Subject)
+	}
+	return binding, nil
+}
+
+func (s Service) DeleteRoleBinding(ctx context.Context, request DeleteRoleBindingRequest) error {
+	if err := s.storage.DeleteRoleBinding(ctx, request.Subject); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "deleting role binding: %s", request.Subject)
+	}
+	return nil
+}
+
+// ApplyRoleBindings adds the permissions of the roles bound to the principal. A principal that has roles bound is
+// restricted to what its roles and scopes grant, even when it would otherwise be unrestricted, as with API keys.
+func (s Service) ApplyRoleBindings(ctx context.Context, principal *Principal) error {
+	binding, err := s.storage.GetRoleBinding(ctx, principal.ID)
+	if err != nil {
+		return errors.Wrap(err, "getting role binding")
+	}
+	if binding == nil {
+		return nil
+	}
+	principal.AllScopes = false
+	for _, name := range binding.Roles {
+		role, err := s.storage.GetRole(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "getting role: %s", name)
+		}
+		if role == nil {
+			continue
+		}
+		principal.Permissions = append(principal.Permissions, role.Permissions...)
+	}
+	return nil
+}
+
+// RecordOwner records the principal that created a resource.
+func (s Service) RecordOwner(ctx context.Context, resourceType, id, owner string) error {
+	if err := s.storage.StoreResourceOwner(ctx, resourceType, id, owner); err != nil {
+		return errors.Wrapf(err, "recording owner of %s: %s", resourceType, id)
+	}
+	return nil
+}
+
+// IsOwner returns whether the principal created the resource. Resources without a recorded owner are not owned by
+// anyone.
+func (s Service) IsOwner(ctx context.Context, resourceType, id, principalID string) (bool, error) {
+	owner, err := s.storage.GetResourceOwner(ctx, resourceType, id)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting owner of %s: %s", resourceType, id)
+	}
+	return owner != "" && owner == principalID, nil
+}
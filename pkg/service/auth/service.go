@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// keySeparator separates the ID of a key from its secret in the raw key value.
+	keySeparator = "."
+
+	// secretLength is the number of random bytes used for the secret portion of a key.
+	secretLength = 32
+
+	// BootstrapAdminKeyID is the ID reported for the admin key that's configured via config.AuthServiceConfig.
+	BootstrapAdminKeyID = "bootstrap-admin"
+)
+
+var (
+	// ErrInvalidAPIKey is returned when a presented API key is malformed, unknown, or revoked.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	// ErrAPIKeyNotFound is returned when no API key exists with the requested ID.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrInvalidTenant is returned for tenant IDs that can't be used to scope storage.
+	ErrInvalidTenant = errors.New("invalid tenant")
+
+	// ErrInvalidScope is returned when creating an API key with a scope that guards no endpoints.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+type Service struct {
+	storage            *Storage
+	config             config.AuthServiceConfig
+	verifier           *tokenVerifier
+	bootstrapClientKey *clientPublicKey
+	Clock              clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Auth
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("auth service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func (s Service) Config() config.AuthServiceConfig {
+	return s.config
+}
+
+func NewAuthService(config config.AuthServiceConfig, s storage.ServiceStorage) (*Service, error) {
+	authStorage, err := NewAuthStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the auth service")
+	}
+	service := Service{
+		storage: authStorage,
+		config:  config,
+		Clock:   clock.New(),
+	}
+	if config.OAuthIssuer != "" {
+		verifier, err := newTokenVerifier(config)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate oauth token verifier")
+		}
+		service.verifier = verifier
+	}
+	if config.BootstrapClientKeyJWK != "" {
+		bootstrapClientKey, err := newBootstrapClientKey(config.BootstrapClientKeyJWK)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate bootstrap client key")
+		}
+		service.bootstrapClientKey = bootstrapClientKey
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// CreateAPIKey mints a new API key. The raw key is only returned in the response, and is stored hashed.
+func (s Service) CreateAPIKey(ctx context.Context, request CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create api key request")
+	}
+	if request.TenantID != "" && !storage.IsValidTenantID(request.TenantID) {
+		return nil, sdkutil.LoggingErrorMsgf(ErrInvalidTenant, "creating api key for tenant: %s", request.TenantID)
+	}
+	for _, scope := range request.Scopes {
+		if !IsValidScope(scope) {
+			return nil, sdkutil.LoggingErrorMsgf(ErrInvalidScope, "creating api key with scope: %s", scope)
+		}
+	}
+
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating api key secret")
+	}
+	id := uuid.NewString()
+	rawKey := id + keySeparator + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := APIKey{
+		ID:        id,
+		Name:      request.Name,
+		Admin:     request.Admin,
+		TenantID:  request.TenantID,
+		Scopes:    request.Scopes,
+		CreatedAt: s.Clock.Now().UTC(),
+	}
+	if err := s.storage.StoreAPIKey(ctx, StoredAPIKey{APIKey: apiKey, Hash: HashAPIKey(rawKey)}); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "storing api key")
+	}
+	return &CreateAPIKeyResponse{APIKey: apiKey, Key: rawKey}, nil
+}
+
+func (s Service) GetAPIKey(ctx context.Context, request GetAPIKeyRequest) (*GetAPIKeyResponse, error) {
+	stored, err := s.storage.GetAPIKey(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting api key: %s", request.ID)
+	}
+	if stored == nil {
+		return nil, sdkutil.LoggingErrorMsgf(ErrAPIKeyNotFound, "getting api key: %s", request.ID)
+	}
+	return &GetAPIKeyResponse{APIKey: stored.APIKey}, nil
+}
+
+func (s Service) ListAPIKeys(ctx context.Context) (*ListAPIKeysResponse, error) {
+	stored, err := s.storage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing api keys")
+	}
+	keys := make([]APIKey, 0, len(stored))
+	for _, k := range stored {
+		keys = append(keys, k.APIKey)
+	}
+	return &ListAPIKeysResponse{APIKeys: keys}, nil
+}
+
+// RevokeAPIKey marks a key as revoked. Revoked keys are kept around so that they can still be listed.
+func (s Service) RevokeAPIKey(ctx context.Context, request RevokeAPIKeyRequest) error {
+	stored, err := s.storage.GetAPIKey(ctx, request.ID)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "getting api key: %s", request.ID)
+	}
+	if stored == nil {
+		return sdkutil.LoggingErrorMsgf(ErrAPIKeyNotFound, "revoking api key: %s", request.ID)
+	}
+	if stored.Revoked {
+		return nil
+	}
+	now := s.Clock.Now().UTC()
+	stored.Revoked = true
+	stored.RevokedAt = &now
+	if err = s.storage.StoreAPIKey(ctx, *stored); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "revoking api key: %s", request.ID)
+	}
+	return nil
+}
+
+// Authenticate checks the raw key presented by a client and returns the key it corresponds to. ErrInvalidAPIKey
+// is returned when the key is not recognized or has been revoked.
+func (s Service) Authenticate(ctx context.Context, rawKey string) (*APIKey, error) {
+	if rawKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	hash := HashAPIKey(rawKey)
+	if s.config.AdminAPIKeyHash != "" && hashesEqual(hash, strings.ToLower(s.config.AdminAPIKeyHash)) {
+		return &APIKey{ID: BootstrapAdminKeyID, Name: BootstrapAdminKeyID, Admin: true}, nil
+	}
+
+	id, _, found := strings.Cut(rawKey, keySeparator)
+	if !found || id == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	stored, err := s.storage.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting api key")
+	}
+	if stored == nil || !hashesEqual(hash, stored.Hash) {
+		return nil, ErrInvalidAPIKey
+	}
+	if stored.Revoked {
+		logrus.Warnf("revoked api key<%s> was presented", id)
+		return nil, ErrInvalidAPIKey
+	}
+	return &stored.APIKey, nil
+}
+
+// OAuthEnabled returns whether the service is configured to validate OAuth2 access tokens.
+func (s Service) OAuthEnabled() bool {
+	return s.verifier != nil
+}
+
+// VerifyAccessToken validates a JWT access token against the configured issuer, audience, and JWKS, and returns
+// the principal it was issued to. ErrInvalidAccessToken is returned for tokens that don't pass validation.
+func (s Service) VerifyAccessToken(ctx context.Context, rawToken string) (*Principal, error) {
+	if s.verifier == nil {
+		return nil, errors.New("oauth is not configured")
+	}
+	if rawToken == "" {
+		return nil, ErrInvalidAccessToken
+	}
+	return s.verifier.verify(ctx, rawToken, s.Clock.Now)
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of a raw api key. API keys are high entropy random values, so a
+// fast hash is sufficient. This is also the format expected for config.AuthServiceConfig's AdminAPIKeyHash.
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashesEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
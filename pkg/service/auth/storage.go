@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	apiKeyNamespace        = "apikey"
+	roleNamespace          = "role"
+	roleBindingNamespace   = "role_binding"
+	resourceOwnerNamespace = "resource_owner"
+	clientKeyNamespace     = "client_key"
+)
+
+// StoredAPIKey is what gets persisted for every API key. Only a SHA-256 hash of the raw key is stored.
+type StoredAPIKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewAuthStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) StoreAPIKey(ctx context.Context, key StoredAPIKey) error {
+	id := key.ID
+	if id == "" {
+		return sdkutil.LoggingNewError("could not store api key without an ID")
+	}
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store api key: %s", id)
+	}
+	return s.db.Write(ctx, apiKeyNamespace, id, keyBytes)
+}
+
+// GetAPIKey returns the stored key with the given id, or nil if none exists.
+func (s *Storage) GetAPIKey(ctx context.Context, id string) (*StoredAPIKey, error) {
+	keyBytes, err := s.db.Read(ctx, apiKeyNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get api key: %s", id)
+	}
+	if len(keyBytes) == 0 {
+		return nil, nil
+	}
+	var stored StoredAPIKey
+	if err = json.Unmarshal(keyBytes, &stored); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling stored api key: %s", id)
+	}
+	return &stored, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]StoredAPIKey, error) {
+	var stored []StoredAPIKey
+	err := s.db.Iterate(ctx, apiKeyNamespace, func(key string, keyBytes []byte) (bool, error) {
+		var next StoredAPIKey
+		if err := json.Unmarshal(keyBytes, &next); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored api key: %s", key)
+			return true, nil
+		}
+		stored = append(stored, next)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing api keys")
+	}
+	return stored, nil
+}
+
+func (s *Storage) StoreClientKey(ctx context.Context, key ClientKey) error {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store client key: %s", key.ID)
+	}
+	return s.db.Write(ctx, clientKeyNamespace, key.ID, keyBytes)
+}
+
+// GetClientKey returns the client key with the given id, or nil if none exists.
+func (s *Storage) GetClientKey(ctx context.Context, id string) (*ClientKey, error) {
+	keyBytes, err := s.db.Read(ctx, clientKeyNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get client key: %s", id)
+	}
+	if len(keyBytes) == 0 {
+		return nil, nil
+	}
+	var key ClientKey
+	if err = json.Unmarshal(keyBytes, &key); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling stored client key: %s", id)
+	}
+	return &key, nil
+}
+
+func (s *Storage) ListClientKeys(ctx context.Context) ([]ClientKey, error) {
+	var keys []ClientKey
+	err := s.db.Iterate(ctx, clientKeyNamespace, func(id string, keyBytes []byte) (bool, error) {
+		var key ClientKey
+		if err := json.Unmarshal(keyBytes, &key); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored client key: %s", id)
+			return true, nil
+		}
+		keys = append(keys, key)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing client keys")
+	}
+	return keys, nil
+}
+
+func (s *Storage) StoreRole(ctx context.Context, role Role) error {
+	roleBytes, err := json.Marshal(role)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store role: %s", role.Name)
+	}
+	return s.db.Write(ctx, roleNamespace, role.Name, roleBytes)
+}
+
+// GetRole returns the role with the given name, or nil if none exists.
+func (s *Storage) GetRole(ctx context.Context, name string) (*Role, error) {
+	roleBytes, err := s.db.Read(ctx, roleNamespace, name)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get role: %s", name)
+	}
+	if len(roleBytes) == 0 {
+		return nil, nil
+	}
+	var role Role
+	if err = json.Unmarshal(roleBytes, &role); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling stored role: %s", name)
+	}
+	return &role, nil
+}
+
+func (s *Storage) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	err := s.db.Iterate(ctx, roleNamespace, func(key string, roleBytes []byte) (bool, error) {
+		var role Role
+		if err := json.Unmarshal(roleBytes, &role); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored role: %s", key)
+			return true, nil
+		}
+		roles = append(roles, role)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing roles")
+	}
+	return roles, nil
+}
+
+func (s *Storage) DeleteRole(ctx context.Context, name string) error {
+	return s.db.Delete(ctx, roleNamespace, name)
+}
+
+func (s *Storage) StoreRoleBinding(ctx context.Context, binding RoleBinding) error {
+	bindingBytes, err := json.Marshal(binding)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store role binding: %s", binding.Subject)
+	}
+	return s.db.Write(ctx, roleBindingNamespace, binding.Subject, bindingBytes)
+}
+
+// GetRoleBinding returns the role binding for the given subject, or nil if none exists.
+func (s *Storage) GetRoleBinding(ctx context.Context, subject string) (*RoleBinding, error) {
+	bindingBytes, err := s.db.Read(ctx, roleBindingNamespace, subject)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get role binding: %s", subject)
+	}
+	if len(bindingBytes) == 0 {
+		return nil, nil
+	}
+	var binding RoleBinding
+	if err = json.Unmarshal(bindingBytes, &binding); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling stored role binding: %s", subject)
+	}
+	return &binding, nil
+}
+
+func (s *Storage) DeleteRoleBinding(ctx context.Context, subject string) error {
+	return s.db.Delete(ctx, roleBindingNamespace, subject)
+}
+
+func (s *Storage) StoreResourceOwner(ctx context.Context, resourceType, id, owner string) error {
+	return s.db.Write(ctx, resourceOwnerNamespace, storage.Join(resourceType, id), []byte(owner))
+}
+
+// GetResourceOwner returns the ID of the principal that created the resource, or an empty string if unknown.
+func (s *Storage) GetResourceOwner(ctx context.Context, resourceType, id string) (string, error) {
+	owner, err := s.db.Read(ctx, resourceOwnerNamespace, storage.Join(resourceType, id))
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsgf(err, "could not get owner of %s: %s", resourceType, id)
+	}
+	return string(owner), nil
+}
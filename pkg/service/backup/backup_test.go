@@ -0,0 +1,17 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedBackups(t *testing.T) {
+	names := []string{
+		"ssi-service-backup-20231001T000000Z.bin",
+		"notes.txt",
+		".ssi-service-backup-20231003T000000Z.bin-123",
+		"ssi-service-backup-20231002T000000Z.bin",
+	}
+	assert.Equal(t, []string{"ssi-service-backup-20231002T000000Z.bin", "ssi-service-backup-20231001T000000Z.bin"}, sortedBackups(names))
+}
@@ -0,0 +1,238 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+const (
+	fileScheme = "file"
+	s3Scheme   = "s3"
+	gcsScheme  = "gs"
+)
+
+// Destination is the cold storage backups are written to. Backups are objects whose names don't contain slashes.
+type Destination interface {
+	// Put writes an object, replacing the object with the same name, if there is one.
+	Put(ctx context.Context, name string, data []byte) error
+
+	// Get reads an object, returning ErrBackupNotFound when there isn't one with the name.
+	Get(ctx context.Context, name string) ([]byte, error)
+
+	// List returns the names of the objects.
+	List(ctx context.Context) ([]string, error)
+}
+
+// NewDestination creates the destination of a URL: s3://bucket/prefix, gs://bucket/prefix, or file:///path for local
+// development. The region of an s3 bucket is set with the region query parameter, e.g. s3://bucket?region=us-east-1.
+// credentialsPath is an AWS shared credentials file for s3, and a service account JSON file for gs. When it's empty,
+// the credentials of the environment are used.
+func NewDestination(ctx context.Context, destination, credentialsPath string) (Destination, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing destination")
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case fileScheme:
+		if u.Path == "" {
+			return nil, errors.New("file destination has no path")
+		}
+		if err = os.MkdirAll(u.Path, 0700); err != nil {
+			return nil, errors.Wrap(err, "creating destination directory")
+		}
+		return fileDestination{dir: u.Path}, nil
+	case s3Scheme:
+		if u.Host == "" {
+			return nil, errors.New("s3 destination has no bucket")
+		}
+		cfg := aws.Config{}
+		if region := u.Query().Get("region"); region != "" {
+			cfg.Region = aws.String(region)
+		}
+		if credentialsPath != "" {
+			cfg.Credentials = credentials.NewSharedCredentials(credentialsPath, "default")
+		}
+		sess, err := session.NewSession(&cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating aws session")
+		}
+		return s3Destination{client: s3.New(sess), bucket: u.Host, prefix: prefix}, nil
+	case gcsScheme:
+		if u.Host == "" {
+			return nil, errors.New("gs destination has no bucket")
+		}
+		var opts []option.ClientOption
+		if credentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(credentialsPath))
+		}
+		client, err := gcs.NewService(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating gcs client")
+		}
+		return gcsDestination{client: client, bucket: u.Host, prefix: prefix}, nil
+	default:
+		return nil, errors.Errorf("destination scheme %q is not supported, use s3, gs, or file", u.Scheme)
+	}
+}
+
+type fileDestination struct {
+	dir string
+}
+
+func (d fileDestination) Put(_ context.Context, name string, data []byte) error {
+	// the object is written to a temporary file first, so that a failed write doesn't leave a partial backup behind
+	tmp, err := os.CreateTemp(d.dir, "."+name+"-*")
+	if err != nil {
+		return errors.Wrap(err, "creating backup file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "writing backup file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "closing backup file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), filepath.Join(d.dir, name)), "renaming backup file")
+}
+
+func (d fileDestination) Get(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	return data, errors.Wrap(err, "reading backup file")
+}
+
+func (d fileDestination) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading destination directory")
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+type s3Destination struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+func (d s3Destination) Put(ctx context.Context, name string, data []byte) error {
+	_, err := d.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(path.Join(d.prefix, name)),
+		Body:   bytes.NewReader(data),
+	})
+	return errors.Wrap(err, "putting s3 object")
+}
+
+func (d s3Destination) Get(ctx context.Context, name string) ([]byte, error) {
+	out, err := d.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(path.Join(d.prefix, name)),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrBackupNotFound
+		}
+		return nil, errors.Wrap(err, "getting s3 object")
+	}
+	defer out.Body.Close()
+	data, err := io.ReadAll(out.Body)
+	return data, errors.Wrap(err, "reading s3 object")
+}
+
+func (d s3Destination) List(ctx context.Context) ([]string, error) {
+	var names []string
+	input := s3.ListObjectsV2Input{Bucket: aws.String(d.bucket)}
+	if d.prefix != "" {
+		input.Prefix = aws.String(d.prefix + "/")
+	}
+	err := d.client.ListObjectsV2PagesWithContext(ctx, &input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			names = append(names, path.Base(aws.StringValue(object.Key)))
+		}
+		return true
+	})
+	return names, errors.Wrap(err, "listing s3 objects")
+}
+
+type gcsDestination struct {
+	client *gcs.Service
+	bucket string
+	prefix string
+}
+
+func (d gcsDestination) Put(ctx context.Context, name string, data []byte) error {
+	object := gcs.Object{Name: path.Join(d.prefix, name)}
+	_, err := d.client.Objects.Insert(d.bucket, &object).Media(bytes.NewReader(data)).Context(ctx).Do()
+	return errors.Wrap(err, "inserting gcs object")
+}
+
+func (d gcsDestination) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := d.client.Objects.Get(d.bucket, path.Join(d.prefix, name)).Context(ctx).Download()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, ErrBackupNotFound
+		}
+		return nil, errors.Wrap(err, "downloading gcs object")
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, errors.Wrap(err, "reading gcs object")
+}
+
+func (d gcsDestination) List(ctx context.Context) ([]string, error) {
+	var names []string
+	call := d.client.Objects.List(d.bucket)
+	if d.prefix != "" {
+		call = call.Prefix(d.prefix + "/")
+	}
+	err := call.Pages(ctx, func(objects *gcs.Objects) error {
+		for _, object := range objects.Items {
+			names = append(names, path.Base(object.Name))
+		}
+		return nil
+	})
+	return names, errors.Wrap(err, "listing gcs objects")
+}
+
+// sortedBackups returns the names of the backups among names, newest first.
+func sortedBackups(names []string) []string {
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if validName.MatchString(name) {
+			backups = append(backups, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups
+}
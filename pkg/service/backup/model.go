@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+)
+
+// Version of the format of backups.
+const Version = 1
+
+// Backup is what's encrypted and written to the destination: the keys and DIDs of each tenant that's backed up.
+type Backup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Tenants   []Tenant  `json:"tenants"`
+}
+
+// Tenant holds the keys, with their private keys, and the DIDs of a tenant.
+type Tenant struct {
+	// ID of the tenant, empty for the default tenant.
+	ID string `json:"id,omitempty"`
+
+	Keys []keystore.StoredKey `json:"keys"`
+
+	// DIDs as they're stored, keyed by their IDs.
+	DIDs map[string]json.RawMessage `json:"dids"`
+}
+
+// Record describes a backup that was written to the destination.
+type Record struct {
+	// Name of the backup at the destination.
+	Name string `json:"name"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Number of tenants, keys, and DIDs backed up.
+	Tenants int `json:"tenants"`
+	Keys    int `json:"keys"`
+	DIDs    int `json:"dids"`
+}
+
+type ListBackupsResponse struct {
+	// Names of the backups at the destination, newest first.
+	Names []string
+}
+
+type RestoreResponse struct {
+	// Whether the restore is a dry run, which restored nothing, so that the keys and DIDs counted as restored are those
+	// that would have been.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Number of keys and DIDs restored. Those already stored aren't restored, and are counted as skipped.
+	RestoredKeys int `json:"restoredKeys"`
+	SkippedKeys  int `json:"skippedKeys"`
+	RestoredDIDs int `json:"restoredDids"`
+	SkippedDIDs  int `json:"skippedDids"`
+}
@@ -0,0 +1,294 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultInterval is how often backups are taken when no interval is configured.
+	DefaultInterval = 24 * time.Hour
+
+	namePrefix = "ssi-service-backup-"
+	nameLayout = "20060102T150405Z"
+	nameSuffix = ".bin"
+)
+
+var (
+	// ErrBackupNotFound is returned when the destination has no backup with the requested name.
+	ErrBackupNotFound = errors.New("backup not found")
+
+	validName = regexp.MustCompile(`^` + namePrefix + `\d{8}T\d{6}Z\` + nameSuffix + `$`)
+)
+
+// Service backs up the keys, with their private keys, and the DIDs of the service to cold storage, encrypted under a
+// public key whose private key is kept offline, and restores them from a backup that was decrypted offline.
+type Service struct {
+	storage     *Storage
+	keyStore    *keystore.Service
+	didStorage  *did.Storage
+	destination Destination
+	encrypter   encryption.Encrypter
+	tenants     []string
+	interval    time.Duration
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Backup
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.keyStore == nil {
+		ae.AppendString("no key store service configured")
+	}
+	if s.didStorage == nil {
+		ae.AppendString("no did storage configured")
+	}
+	if s.destination == nil {
+		ae.AppendString("no destination configured")
+	}
+	if s.encrypter == nil {
+		ae.AppendString("no public keyset configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("backup service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewBackupService creates the backup service. Which scheduled backups were taken is kept in globalStorage, while keys
+// and DIDs are read from, and restored to, the tenants of tenantStorage.
+func NewBackupService(cfg config.BackupServiceConfig, globalStorage, tenantStorage storage.ServiceStorage, keyStore *keystore.Service) (*Service, error) {
+	backupStorage, err := NewBackupStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the backup service")
+	}
+	didStorage, err := did.NewDIDStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate did storage for the backup service")
+	}
+	for _, tenant := range cfg.Tenants {
+		if !storage.IsValidTenantID(tenant) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant)
+		}
+	}
+	if cfg.PublicKeysetPath == "" {
+		return nil, sdkutil.LoggingNewError("public_keyset_path is required to encrypt backups")
+	}
+	publicKeyset, err := os.ReadFile(cfg.PublicKeysetPath)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not read public keyset")
+	}
+	encrypter, err := encryption.NewHybridEncrypter(publicKeyset)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not create backup encrypter")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	destination, err := NewDestination(ctx, cfg.Destination, cfg.CredentialsPath)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not create backup destination")
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	service := Service{
+		storage:     backupStorage,
+		keyStore:    keyStore,
+		didStorage:  didStorage,
+		destination: destination,
+		encrypter:   encrypter,
+		tenants:     cfg.Tenants,
+		interval:    interval,
+		Clock:       clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// CreateBackup exports the keys and DIDs of the default tenant and of the configured tenants, encrypts them, and
+// writes them to the destination.
+func (s Service) CreateBackup(ctx context.Context) (*Record, error) {
+	now := s.Clock.Now().UTC()
+	backup := Backup{Version: Version, CreatedAt: now}
+	record := Record{Name: namePrefix + now.Format(nameLayout) + nameSuffix, CreatedAt: now}
+	for _, tenantID := range append([]string{""}, s.tenants...) {
+		tenantCtx := storage.WithTenant(ctx, tenantID)
+		keys, err := s.keyStore.ExportKeys(tenantCtx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "exporting keys of tenant %q", tenantID)
+		}
+		dids, err := s.didStorage.ExportDIDs(tenantCtx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "exporting dids of tenant %q", tenantID)
+		}
+		backup.Tenants = append(backup.Tenants, Tenant{ID: tenantID, Keys: keys, DIDs: dids})
+		record.Tenants++
+		record.Keys += len(keys)
+		record.DIDs += len(dids)
+	}
+
+	backupBytes, err := json.Marshal(backup)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not marshal backup")
+	}
+	encrypted, err := s.encrypter.Encrypt(ctx, backupBytes, nil)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not encrypt backup")
+	}
+	if err = s.destination.Put(ctx, record.Name, encrypted); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not write backup: %s", record.Name)
+	}
+	return &record, nil
+}
+
+// ListBackups returns the names of the backups at the destination.
+func (s Service) ListBackups(ctx context.Context) (*ListBackupsResponse, error) {
+	names, err := s.destination.List(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list backups")
+	}
+	return &ListBackupsResponse{Names: sortedBackups(names)}, nil
+}
+
+// GetBackup returns a backup as it's written to the destination, encrypted.
+func (s Service) GetBackup(ctx context.Context, name string) ([]byte, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrBackupNotFound
+	}
+	backup, err := s.destination.Get(ctx, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting backup: %s", name)
+	}
+	return backup, nil
+}
+
+// Restore stores the keys and DIDs of a decrypted backup in the tenants they were backed up from. Keys and DIDs that
+// are stored already are kept as they are, so a backup can be restored more than once.
+func (s Service) Restore(ctx context.Context, backup Backup) (*RestoreResponse, error) {
+	return s.restore(ctx, backup, false)
+}
+
+// DryRunRestore returns how many keys and DIDs of a decrypted backup Restore would restore, and skip, without storing
+// any.
+func (s Service) DryRunRestore(ctx context.Context, backup Backup) (*RestoreResponse, error) {
+	return s.restore(ctx, backup, true)
+}
+
+func (s Service) restore(ctx context.Context, backup Backup, dryRun bool) (*RestoreResponse, error) {
+	if backup.Version != Version {
+		return nil, sdkutil.LoggingNewErrorf("backup version %d is not supported", backup.Version)
+	}
+	resp := RestoreResponse{DryRun: dryRun}
+	for _, tenant := range backup.Tenants {
+		if tenant.ID != "" && !storage.IsValidTenantID(tenant.ID) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant.ID)
+		}
+		tenantCtx := storage.WithTenant(ctx, tenant.ID)
+		for _, key := range tenant.Keys {
+			restored, err := s.restoreKey(tenantCtx, key, dryRun)
+			if err != nil {
+				return nil, errors.Wrapf(err, "restoring keys of tenant %q", tenant.ID)
+			}
+			if restored {
+				resp.RestoredKeys++
+			} else {
+				resp.SkippedKeys++
+			}
+		}
+		for id, stored := range tenant.DIDs {
+			restored, err := s.restoreDID(tenantCtx, id, stored, dryRun)
+			if err != nil {
+				return nil, errors.Wrapf(err, "restoring dids of tenant %q", tenant.ID)
+			}
+			if restored {
+				resp.RestoredDIDs++
+			} else {
+				resp.SkippedDIDs++
+			}
+		}
+	}
+	return &resp, nil
+}
+
+// restoreKey imports a key, unless it's a dry run, returning whether it was, or would be, imported.
+func (s Service) restoreKey(ctx context.Context, key keystore.StoredKey, dryRun bool) (bool, error) {
+	if !dryRun {
+		return s.keyStore.ImportKey(ctx, key)
+	}
+	if !keyaccess.IsSupportedKeyType(key.KeyType) {
+		return false, sdkutil.LoggingNewErrorf("unsupported key type: %s", key.KeyType)
+	}
+	exists, err := s.keyStore.KeyExists(ctx, key.ID)
+	return !exists, err
+}
+
+// restoreDID imports a DID, unless it's a dry run, returning whether it was, or would be, imported.
+func (s Service) restoreDID(ctx context.Context, id string, stored json.RawMessage, dryRun bool) (bool, error) {
+	if !dryRun {
+		return s.didStorage.ImportDID(ctx, id, stored)
+	}
+	exists, err := s.didStorage.DIDExists(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !exists && !json.Valid(stored) {
+		return false, sdkutil.LoggingNewErrorf("could not import DID: %s, it isn't valid JSON", id)
+	}
+	return !exists, nil
+}
+
+// RunSchedule takes a backup every interval, until ctx is done. Instances sharing the storage take turns, so that each
+// scheduled backup is taken once. A backup in progress when ctx is done is completed before returning.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.interval, s.runScheduledBackup)
+}
+
+func (s Service) runScheduledBackup() {
+	ctx := context.Background()
+	claimed, err := schedule.Claim(ctx, s.storage.db, namespace, scheduledKey, s.Clock.Now(), s.interval)
+	if err != nil {
+		logrus.WithError(err).Error("claiming scheduled backup")
+		return
+	}
+	if !claimed {
+		return
+	}
+	record, err := s.CreateBackup(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("taking scheduled backup")
+		return
+	}
+	logrus.WithField("backup", record.Name).Infof("backed up %d keys and %d dids", record.Keys, record.DIDs)
+}
@@ -0,0 +1,23 @@
+package backup
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	namespace    = "backup"
+	scheduledKey = "scheduled"
+)
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewBackupStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
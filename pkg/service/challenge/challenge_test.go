@@ -0,0 +1,70 @@
+package challenge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestNewChallengeService(t *testing.T) {
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	_, err := NewChallengeService(config.ChallengeServiceConfig{TTL: -time.Minute}, db, nil, nil)
+	assert.Error(t, err)
+	_, err = NewChallengeService(config.ChallengeServiceConfig{}, db, nil, nil)
+	assert.ErrorContains(t, err, "no presentation service configured")
+}
+
+func TestConsume(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			challengeStorage, err := NewChallengeStorage(test.ServiceStorage(t))
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Now())
+			s := Service{storage: challengeStorage, config: config.ChallengeServiceConfig{TTL: time.Minute}, Clock: mockClock}
+
+			issue := func(nonce, tenant string) {
+				now := mockClock.Now()
+				require.NoError(t, challengeStorage.StoreChallenge(ctx, StoredChallenge{
+					Challenge: Challenge{Nonce: nonce, Target: TargetDefinition, TargetID: "definition", IssuedAt: now, ExpiresAt: now.Add(time.Minute)},
+					Tenant:    tenant,
+				}))
+			}
+
+			// a challenge is consumed once, for what it's bound to, by the tenant that issued it
+			issue("first", "")
+			err = s.Consume(ctx, "first", TargetManifest, "definition")
+			assert.True(t, errors.Is(err, ErrInvalidChallenge))
+			err = s.Consume(storage.WithTenant(ctx, "other"), "first", TargetDefinition, "definition")
+			assert.True(t, errors.Is(err, ErrInvalidChallenge))
+			require.NoError(t, s.Consume(ctx, "first", TargetDefinition, "definition"))
+			err = s.Consume(ctx, "first", TargetDefinition, "definition")
+			assert.True(t, errors.Is(err, ErrChallengeConsumed))
+
+			// released challenges can be consumed again
+			require.NoError(t, s.Release(ctx, "first"))
+			require.NoError(t, s.Consume(ctx, "first", TargetDefinition, "definition"))
+
+			// challenges can't be consumed once they expire, and are purged, consumed or not
+			issue("second", "")
+			mockClock.Add(time.Minute)
+			err = s.Consume(ctx, "second", TargetDefinition, "definition")
+			assert.True(t, errors.Is(err, ErrInvalidChallenge))
+			purged, err := s.Purge(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 2, purged)
+			err = s.Consume(ctx, "first", TargetDefinition, "definition")
+			assert.True(t, errors.Is(err, ErrInvalidChallenge))
+		})
+	}
+}
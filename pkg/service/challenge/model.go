@@ -0,0 +1,45 @@
+package challenge
+
+import (
+	"time"
+)
+
+// Target is what a challenge is bound to.
+type Target string
+
+const (
+	// TargetDefinition challenges are carried by the presentation submissions of a presentation definition.
+	TargetDefinition Target = "definition"
+	// TargetManifest challenges are carried by the credential applications of a credential manifest.
+	TargetManifest Target = "manifest"
+)
+
+// NonceClaim is the claim of submission and application JWTs that carries the challenge.
+const NonceClaim = "nonce"
+
+type CreateChallengeRequest struct {
+	// What the challenge is bound to.
+	Target Target `json:"target" validate:"required,oneof=definition manifest"`
+
+	// ID of the presentation definition, or credential manifest, the challenge is bound to.
+	TargetID string `json:"targetId" validate:"required"`
+}
+
+// Challenge is a single-use nonce bound to a presentation definition or a credential manifest.
+type Challenge struct {
+	// The nonce, which the JWT responding to the challenge carries as its nonce claim.
+	Nonce    string    `json:"nonce"`
+	Target   Target    `json:"target"`
+	TargetID string    `json:"targetId"`
+	IssuedAt time.Time `json:"issuedAt"`
+
+	// When the challenge can no longer be responded to. It's deleted some time after.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// When the challenge was responded to, which is only once.
+	ConsumedAt *time.Time `json:"consumedAt,omitempty"`
+}
+
+type GetChallengeRequest struct {
+	Nonce string `json:"nonce" validate:"required"`
+}
@@ -0,0 +1,256 @@
+package challenge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	manifestmodel "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	presmodel "github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultTTL is how long challenges can be responded to when no TTL is configured.
+	DefaultTTL = 5 * time.Minute
+	// DefaultPurgeInterval is how often expired challenges are deleted when no interval is configured.
+	DefaultPurgeInterval = time.Hour
+)
+
+var (
+	// ErrChallengeRequired is returned for JWTs without a nonce claim.
+	ErrChallengeRequired = errors.New("challenge required")
+	// ErrInvalidChallenge is returned for nonces that aren't of a challenge of the tenant, or of a challenge that expired,
+	// or that's bound to another definition or manifest.
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	// ErrChallengeConsumed is returned for challenges that were already responded to.
+	ErrChallengeConsumed = errors.New("challenge was already responded to")
+	// ErrMalformedJWT is returned when a JWT can't be parsed, so its challenge can't be read.
+	ErrMalformedJWT = errors.New("malformed jwt")
+)
+
+// Service issues challenges, single-use nonces bound to a presentation definition or a credential manifest, and
+// consumes them when a presentation submission, or credential application, responds to them. Challenges are kept until
+// they expire, consumed or not, so that they're rejected when they're responded to again.
+type Service struct {
+	storage      *Storage
+	config       config.ChallengeServiceConfig
+	presentation *presentation.Service
+	manifest     *manifest.Service
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Challenge
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.presentation == nil {
+		ae.AppendString("no presentation service configured")
+	}
+	if s.manifest == nil {
+		ae.AppendString("no manifest service configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("challenge service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewChallengeService creates the challenge service, which keeps challenges in globalStorage, and binds them to the
+// definitions of presentationService and the manifests of manifestService.
+func NewChallengeService(cfg config.ChallengeServiceConfig, globalStorage storage.ServiceStorage, presentationService *presentation.Service, manifestService *manifest.Service) (*Service, error) {
+	challengeStorage, err := NewChallengeStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the challenge service")
+	}
+	if cfg.TTL < 0 || cfg.PurgeInterval < 0 {
+		return nil, sdkutil.LoggingNewError("ttl and purge_interval cannot be negative")
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.PurgeInterval == 0 {
+		cfg.PurgeInterval = DefaultPurgeInterval
+	}
+	service := Service{
+		storage:      challengeStorage,
+		config:       cfg,
+		presentation: presentationService,
+		manifest:     manifestService,
+		Clock:        clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// CreateChallenge issues a challenge bound to a presentation definition or credential manifest of the tenant of ctx.
+func (s Service) CreateChallenge(ctx context.Context, request CreateChallengeRequest) (*Challenge, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create challenge request")
+	}
+	switch request.Target {
+	case TargetDefinition:
+		if _, err := s.presentation.GetPresentationDefinition(ctx, presmodel.GetPresentationDefinitionRequest{ID: request.TargetID}); err != nil {
+			return nil, err
+		}
+	case TargetManifest:
+		if _, err := s.manifest.GetManifest(ctx, manifestmodel.GetManifestRequest{ID: request.TargetID}); err != nil {
+			return nil, err
+		}
+	}
+	nonce, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating nonce")
+	}
+	now := s.Clock.Now()
+	challenge := StoredChallenge{
+		Challenge: Challenge{
+			Nonce:     nonce,
+			Target:    request.Target,
+			TargetID:  request.TargetID,
+			IssuedAt:  now,
+			ExpiresAt: now.Add(s.config.TTL),
+		},
+		Tenant: storage.TenantFromContext(ctx),
+	}
+	if err = s.storage.StoreChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &challenge.Challenge, nil
+}
+
+// GetChallenge returns a challenge issued by the tenant of ctx, which tells whether it was responded to.
+func (s Service) GetChallenge(ctx context.Context, request GetChallengeRequest) (*Challenge, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid get challenge request")
+	}
+	stored, err := s.storage.GetChallenge(ctx, request.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Tenant != storage.TenantFromContext(ctx) {
+		return nil, sdkutil.LoggingNewError("challenge not found")
+	}
+	return &stored.Challenge, nil
+}
+
+// ConsumeJWT consumes the challenge of a presentation submission JWT, when target is TargetDefinition, or of a
+// credential application JWT, when it's TargetManifest. The challenge is the JWT's nonce claim, and must be bound to
+// the definition of the submission, or the manifest of the application. It returns the nonce, for Release.
+func (s Service) ConsumeJWT(ctx context.Context, token keyaccess.JWT, target Target) (string, error) {
+	_, parsed, err := util.ParseJWT(token)
+	if err != nil {
+		return "", errors.Wrap(ErrMalformedJWT, err.Error())
+	}
+	claims, err := parsed.AsMap(ctx)
+	if err != nil {
+		return "", errors.Wrap(ErrMalformedJWT, err.Error())
+	}
+	nonce, _ := claims[NonceClaim].(string)
+	if nonce == "" {
+		return "", ErrChallengeRequired
+	}
+	return nonce, s.Consume(ctx, nonce, target, targetIDFromClaims(claims, target))
+}
+
+// targetIDFromClaims returns the ID of the definition of a presentation submission JWT, or of the manifest of a
+// credential application JWT, from its claims, or empty when there's none.
+func targetIDFromClaims(claims map[string]any, target Target) string {
+	var path []string
+	switch target {
+	case TargetDefinition:
+		path = []string{"vp", "presentation_submission", "definition_id"}
+	case TargetManifest:
+		path = []string{"credential_application", "manifest_id"}
+	}
+	var value any = claims
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	id, _ := value.(string)
+	return id
+}
+
+// Consume records that the challenge with the nonce was responded to by a JWT of the tenant of ctx, for the definition
+// or manifest with targetID. Challenges are only consumed once, before they expire.
+func (s Service) Consume(ctx context.Context, nonce string, target Target, targetID string) error {
+	tenant := storage.TenantFromContext(ctx)
+	now := s.Clock.Now()
+	_, err := s.storage.UpdateChallenge(ctx, nonce, func(challenge *StoredChallenge) error {
+		if challenge == nil || challenge.Tenant != tenant {
+			return errors.Wrap(ErrInvalidChallenge, "unknown nonce")
+		}
+		if challenge.Target != target || challenge.TargetID != targetID {
+			return errors.Wrapf(ErrInvalidChallenge, "bound to %s<%s>", challenge.Target, challenge.TargetID)
+		}
+		if challenge.ConsumedAt != nil {
+			return ErrChallengeConsumed
+		}
+		if !now.Before(challenge.ExpiresAt) {
+			return errors.Wrap(ErrInvalidChallenge, "expired")
+		}
+		challenge.ConsumedAt = &now
+		return nil
+	})
+	return err
+}
+
+// Release makes a challenge that was consumed by a request that failed respondable again, until it expires.
+func (s Service) Release(ctx context.Context, nonce string) error {
+	_, err := s.storage.UpdateChallenge(ctx, nonce, func(challenge *StoredChallenge) error {
+		if challenge == nil {
+			return errors.Errorf("challenge not found")
+		}
+		challenge.ConsumedAt = nil
+		return nil
+	})
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not release challenge")
+	}
+	return nil
+}
+
+// Purge deletes the challenges that expired, returning how many were deleted.
+func (s Service) Purge(ctx context.Context) (int, error) {
+	return s.storage.DeleteExpired(ctx, s.Clock.Now())
+}
+
+// RunSchedule purges every purge interval, until ctx is done. Instances sharing the storage may purge at the same
+// time, which is harmless.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.config.PurgeInterval, func() {
+		purged, err := s.Purge(context.Background())
+		if err != nil {
+			logrus.WithError(err).Error("purging expired challenges")
+			return
+		}
+		logrus.Debugf("purged %d expired challenges", purged)
+	})
+}
@@ -0,0 +1,111 @@
+package challenge
+
+import (
+	"context"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// challengeNamespace holds the challenges, keyed by their nonce, in the storage of the deployment, so that expired
+// challenges of every tenant are purged together. Each challenge records the tenant that issued it, whose definitions
+// and manifests it's bound to.
+const challengeNamespace = "challenge"
+
+type StoredChallenge struct {
+	Challenge
+	Tenant string `json:"tenant,omitempty"`
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewChallengeStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) StoreChallenge(ctx context.Context, challenge StoredChallenge) error {
+	challengeBytes, err := json.Marshal(challenge)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not marshal challenge")
+	}
+	if err = s.db.Write(ctx, challengeNamespace, challenge.Nonce, challengeBytes); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not store challenge")
+	}
+	return nil
+}
+
+// GetChallenge returns the challenge with the nonce, or nil when there's none.
+func (s *Storage) GetChallenge(ctx context.Context, nonce string) (*StoredChallenge, error) {
+	challengeBytes, err := s.db.Read(ctx, challengeNamespace, nonce)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get challenge")
+	}
+	if len(challengeBytes) == 0 {
+		return nil, nil
+	}
+	var challenge StoredChallenge
+	if err = json.Unmarshal(challengeBytes, &challenge); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not unmarshal challenge")
+	}
+	return &challenge, nil
+}
+
+// UpdateChallenge reads the challenge with the nonce and writes it back as update changes it, in a transaction that
+// watches it, so that a challenge responded to twice at once is only consumed once. update is given nil when there's
+// no challenge with the nonce, and returns an error to leave the challenge unchanged, which is returned as is.
+func (s *Storage) UpdateChallenge(ctx context.Context, nonce string, update func(challenge *StoredChallenge) error) (*StoredChallenge, error) {
+	watchKeys := []storage.WatchKey{{Namespace: challengeNamespace, Key: nonce}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		challenge, err := s.GetChallenge(ctx, nonce)
+		if err != nil {
+			return nil, err
+		}
+		if err = update(challenge); err != nil {
+			return nil, err
+		}
+		challengeBytes, err := json.Marshal(challenge)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling challenge")
+		}
+		return challenge, tx.Write(ctx, challengeNamespace, nonce, challengeBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(*StoredChallenge), nil
+}
+
+// DeleteExpired deletes the challenges that expired at now, consumed or not, returning how many were deleted.
+func (s *Storage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	var expired []string
+	err := s.db.Iterate(ctx, challengeNamespace, func(key string, challengeBytes []byte) (bool, error) {
+		var challenge StoredChallenge
+		if err := json.Unmarshal(challengeBytes, &challenge); err != nil {
+			logrus.WithError(err).Warnf("unmarshal challenge: %s", key)
+			return true, nil
+		}
+		if !now.Before(challenge.ExpiresAt) {
+			expired = append(expired, key)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list challenges")
+	}
+	for _, key := range expired {
+		if err = s.db.Delete(ctx, challengeNamespace, key); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not delete expired challenge: %s", key)
+		}
+	}
+	return len(expired), nil
+}
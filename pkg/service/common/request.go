@@ -11,6 +11,9 @@ import (
 	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 )
 
+// NonceClaim is the claim of request JWTs that holds their nonce.
+const NonceClaim = "nonce"
+
 type Request struct {
 	// ID for this request. It matches the "jti" claim in the JWT.
 	// This is an output only field.
@@ -33,6 +36,10 @@ type Request struct {
 	// The URL that the presenter should be submitting the presentation submission to.
 	// Optional.
 	CallbackURL string `json:"callbackUrl,omitempty" example:"https://example.com"`
+
+	// Nonce of this request, for requests whose responses are checked to carry it. It matches the "nonce" claim in the
+	// JWT. This is an output only field.
+	Nonce string `json:"nonce,omitempty"`
 }
 
 // ToServiceModel converts a storage model to a service model.
@@ -43,6 +50,7 @@ func ToServiceModel(stored *StoredRequest) (*Request, error) {
 		IssuerDID:            stored.IssuerDID,
 		VerificationMethodID: stored.VerificationMethodID,
 		CallbackURL:          stored.CallbackURL,
+		Nonce:                stored.Nonce,
 	}
 	if stored.Expiration != "" {
 		expiration, err := time.Parse(time.RFC3339, stored.Expiration)
@@ -55,8 +63,9 @@ func ToServiceModel(stored *StoredRequest) (*Request, error) {
 }
 
 // CreateStoredRequest creates a StoredRequest with the associated signed JWT populated. In addition to the fields
-// present in request, the JWT will also include a claim with claimName and claimValue.
-func CreateStoredRequest(ctx context.Context, keyStore *keystore.Service, claimName string, claimValue any, request Request, id string) (*StoredRequest, error) {
+// present in request, the JWT will also include a claim with claimName and claimValue, and the nonce claim when nonce
+// isn't empty. Callers that set a nonce track it, and check it in the responses to the request.
+func CreateStoredRequest(ctx context.Context, keyStore *keystore.Service, claimName string, claimValue any, request Request, id, nonce string) (*StoredRequest, error) {
 	requestID := uuid.NewString()
 	builder := jwt.NewBuilder().
 		Claim(claimName, claimValue).
@@ -72,6 +81,9 @@ func CreateStoredRequest(ctx context.Context, keyStore *keystore.Service, claimN
 	if request.CallbackURL != "" {
 		builder.Claim("callbackUrl", request.CallbackURL)
 	}
+	if nonce != "" {
+		builder.Claim(NonceClaim, nonce)
+	}
 	token, err := builder.Build()
 	if err != nil {
 		return nil, errors.Wrap(err, "building jwt")
@@ -92,6 +104,7 @@ func CreateStoredRequest(ctx context.Context, keyStore *keystore.Service, claimN
 		ReferenceID:          id,
 		JWT:                  signedToken.String(),
 		CallbackURL:          request.CallbackURL,
+		Nonce:                nonce,
 	}
 	return stored, nil
 }
@@ -18,6 +18,7 @@ type StoredRequest struct {
 	ReferenceID          string   `json:"referenceId"`
 	JWT                  string   `json:"jwt"`
 	CallbackURL          string   `json:"callbackUrl"`
+	Nonce                string   `json:"nonce,omitempty"`
 }
 
 type RequestStorage interface {
@@ -71,17 +72,17 @@ func (s *requestStorage) DeleteRequest(ctx context.Context, id string) error {
 }
 
 func (s *requestStorage) ListRequests(ctx context.Context) ([]StoredRequest, error) {
-	m, err := s.db.ReadAll(ctx, s.namespace)
+	ts := make([]StoredRequest, 0)
+	err := s.db.Iterate(ctx, s.namespace, func(k string, v []byte) (bool, error) {
+		var request StoredRequest
+		if err := json.Unmarshal(v, &request); err != nil {
+			return false, errors.Wrapf(err, "unmarshalling request with key <%s>", k)
+		}
+		ts = append(ts, request)
+		return true, nil
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "reading all")
 	}
-	ts := make([]StoredRequest, len(m))
-	i := 0
-	for k, v := range m {
-		if err = json.Unmarshal(v, &ts[i]); err != nil {
-			return nil, errors.Wrapf(err, "unmarshalling request with key <%s>", k)
-		}
-		i++
-	}
 	return ts, nil
 }
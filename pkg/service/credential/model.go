@@ -3,6 +3,7 @@ package credential
 import (
 	"fmt"
 
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	"github.com/TBD54566975/ssi-sdk/util"
 	"github.com/tbd54566975/ssi-service/internal/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/common"
@@ -16,6 +17,18 @@ type BatchCreateCredentialsResponse struct {
 	Credentials []credential.Container
 }
 
+// BatchCreateCredentialResultsResponse holds the result of each request of a batch whose credentials are created
+// independently, in the order of the requests.
+type BatchCreateCredentialResultsResponse struct {
+	Results []BatchCreateCredentialResult
+}
+
+// BatchCreateCredentialResult is either the credential created for a request of a batch, or why it couldn't be.
+type BatchCreateCredentialResult struct {
+	Credential *credential.Container
+	Error      error
+}
+
 type CreateCredentialRequest struct {
 	Issuer string `json:"issuer" validate:"required"`
 	// Fully qualified verification method ID to determine the private key used for signing this credential. For example
@@ -31,13 +44,33 @@ type CreateCredentialRequest struct {
 	Revocable   bool           `json:"revocable,omitempty"`
 	Suspendable bool           `json:"suspendable,omitempty"`
 	Evidence    []any          `json:"evidence,omitempty"`
-	// TODO(gabe) support more capabilities like signature type, format, evidence, and more.
-}
+	// Format the credential is secured in: FormatJWT, the default, FormatLDP, or FormatSDJWT.
+	Format string `json:"format,omitempty"`
+	// Key of the holder an SD-JWT credential is bound to, so that it can only be presented with a key binding signed
+	// by it. Only used with FormatSDJWT.
+	HolderKey *jwx.PublicKeyJWK `json:"holderKey,omitempty"`
+}
+
+// Formats credentials are issued in.
+const (
+	// FormatJWT secures credentials as VC-JWTs.
+	FormatJWT = "jwt"
+	// FormatLDP secures credentials with a Data Integrity proof, which requires contexts that define every claim of
+	// the credential. Issuers with an Ed25519 key make Ed25519Signature2020 proofs, and issuers with a BLS12381G2 key
+	// make BbsBlsSignature2020 proofs, from which holders derive proofs that only reveal some of the claims.
+	FormatLDP = "ldp"
+	// FormatSDJWT secures credentials as SD-JWT VCs, whose subject's claims holders disclose one by one.
+	FormatSDJWT = "vc+sd-jwt"
+)
 
 // CreateCredentialResponse holds a resulting credential from credential creation, which is an XOR type:
 // containing either a Data Integrity Proofed credential or a VC-JWT representation.
 type CreateCredentialResponse struct {
 	credential.Container `json:"credential,omitempty"`
+
+	// SchemaViolations are how the credential doesn't conform to its schema, when it was issued anyway because schema
+	// validation is advisory.
+	SchemaViolations []SchemaViolation `json:"schemaViolations,omitempty"`
 }
 
 type GetCredentialRequest struct {
@@ -60,8 +93,19 @@ type ListCredentialBySchemaRequest struct {
 	Schema string `json:"schema" validate:"required"`
 }
 
+type ListCredentialsPageRequest struct {
+	// A storage dependent token of the page to list. Empty means the first page.
+	PageToken string
+	// A value of -1 means all credentials.
+	PageSize int
+}
+
 type ListCredentialsResponse struct {
-	Credentials []credential.Container `json:"credentials,omitempty"`
+	Credentials   []credential.Container `json:"credentials,omitempty"`
+	NextPageToken string
+
+	// TotalCount is the number of credentials across all pages. It's only set for pages.
+	TotalCount int
 }
 
 type DeleteCredentialRequest struct {
@@ -75,12 +119,16 @@ type GetCredentialStatusRequest struct {
 type GetCredentialStatusResponse struct {
 	Revoked   bool `json:"revoked" validate:"required"`
 	Suspended bool `json:"suspended" validate:"required"`
+	Expired   bool `json:"expired"`
 }
 
 type UpdateCredentialStatusRequest struct {
 	ID        string `json:"id" validate:"required"`
 	Revoked   bool   `json:"revoked" validate:"required"`
 	Suspended bool   `json:"suspended" validate:"required"`
+
+	// When set, the status isn't updated, and the response is the status the credential would have.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 type UpdateCredentialStatusResponse struct {
@@ -88,6 +136,18 @@ type UpdateCredentialStatusResponse struct {
 	Suspended bool `json:"suspended" validate:"required"`
 }
 
+type ExpireCredentialRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type ExpireCredentialResponse struct {
+	credential.Container `json:"credential,omitempty"`
+
+	// Whether the credential was recorded as expired by this request. It isn't when it was recorded as expired before,
+	// or when it's revoked.
+	Changed bool `json:"changed"`
+}
+
 type GetCredentialStatusListRequest struct {
 	ID string `json:"id" validate:"required"`
 }
@@ -133,5 +193,8 @@ func (csr CreateCredentialRequest) IsValid() error {
 	if err := util.IsValidStruct(csr); err != nil {
 		return err
 	}
+	if csr.HolderKey != nil && csr.Format != FormatSDJWT {
+		return fmt.Errorf("a holder key can only be set for the %s format", FormatSDJWT)
+	}
 	return common.ValidateVerificationMethodID(csr.FullyQualifiedVerificationMethodID, csr.Issuer)
 }
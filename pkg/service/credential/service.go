@@ -2,12 +2,17 @@ package credential
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential"
 	schemalib "github.com/TBD54566975/ssi-sdk/credential/schema"
 	statussdk "github.com/TBD54566975/ssi-sdk/credential/status"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	"github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
@@ -16,12 +21,17 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/tbd54566975/ssi-service/config"
 	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/internal/jsonld"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
+	"github.com/tbd54566975/ssi-service/pkg/service/stats"
+	"github.com/tbd54566975/ssi-service/pkg/service/transparency"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
@@ -29,9 +39,21 @@ type Service struct {
 	config   config.CredentialServiceConfig
 	verifier *credint.Validator
 
+	// contexts loads the JSON-LD contexts of credentials secured with Data Integrity proofs.
+	contexts *jsonld.ContextLoader
+
+	// statusLists fetches the status lists of credentials being verified, including those issued elsewhere.
+	statusLists credint.StatusListResolution
+
 	// external dependencies
 	keyStore *keystore.Service
 	schema   *schema.Service
+
+	// transparency records the credentials that are issued, and changes to their status, when it's set.
+	transparency *transparency.Service
+
+	// stats counts the credentials that are issued, revoked, and verified, when it's set.
+	stats *stats.Service
 }
 
 func (s Service) Type() framework.Type {
@@ -52,6 +74,9 @@ func (s Service) Status() framework.Status {
 	if s.schema == nil {
 		ae.AppendString("no schema service configured")
 	}
+	if !isValidSchemaValidation(s.config.SchemaValidation) {
+		ae.AppendString(fmt.Sprintf("unknown schema validation<%s>", s.config.SchemaValidation))
+	}
 	if !ae.IsEmpty() {
 		return framework.Status{
 			Status:  framework.StatusNotReady,
@@ -65,6 +90,50 @@ func (s Service) Config() config.CredentialServiceConfig {
 	return s.config
 }
 
+// SetTransparencyLog makes the service record the credentials it issues, and changes to their status, in a
+// transparency log. It must be called before the service is handed to other services.
+func (s *Service) SetTransparencyLog(log *transparency.Service) {
+	s.transparency = log
+}
+
+// SetIssuerTrust makes credential verification check that the issuers of credentials are trusted to issue them. It must
+// be called before the service is handed to other services.
+func (s *Service) SetIssuerTrust(trust credint.IssuerTrust) {
+	s.verifier.SetIssuerTrust(trust)
+}
+
+// logToTransparency appends entries for the credentials to the transparency log, when there is one. The credentials
+// are stored by the time they're logged, so failures are logged rather than failing the request.
+func (s Service) logToTransparency(ctx context.Context, operation transparency.Operation, containers ...credint.Container) {
+	if s.transparency == nil {
+		return
+	}
+	if _, err := s.transparency.Append(ctx, operation, containers...); err != nil {
+		logrus.WithContext(ctx).WithError(err).Errorf("could not append %d %s credential(s) to the transparency log", len(containers), operation)
+	}
+}
+
+// SetStats makes the service count the credentials it issues, revokes, and verifies for dashboards. It must be called
+// before the service is handed to other services.
+func (s *Service) SetStats(statsService *stats.Service) {
+	s.stats = statsService
+}
+
+// countStats counts a metric, when stats are kept. Like logging to the transparency log, it happens once the request
+// succeeded, so failures are logged rather than failing the request.
+func (s Service) countStats(ctx context.Context, metric stats.Metric, count int) {
+	if s.stats == nil {
+		return
+	}
+	if err := s.stats.Add(ctx, metric, int64(count)); err != nil {
+		logrus.WithContext(ctx).WithError(err).Errorf("could not count %d %s", count, metric)
+	}
+}
+
+// ErrUnsupportedLDPCredential is returned when a credential can't be issued with a Data Integrity proof, like when its
+// issuer's key is neither an Ed25519 nor a BLS12381G2 key, or its contexts don't define all of its claims.
+var ErrUnsupportedLDPCredential = errors.New("credential cannot be secured with a Data Integrity proof")
+
 func NewCredentialService(config config.CredentialServiceConfig, s storage.ServiceStorage, keyStore *keystore.Service, didResolver resolution.Resolver, schema *schema.Service) (*Service, error) {
 	credentialStorage, err := NewCredentialStorage(s)
 	if err != nil {
@@ -74,12 +143,19 @@ func NewCredentialService(config config.CredentialServiceConfig, s storage.Servi
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate verifier for the credential service")
 	}
+	contexts, err := newContextLoader(config)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate JSON-LD context loader for the credential service")
+	}
+	verifier.SetContextLoader(contexts)
 	service := Service{
-		storage:  credentialStorage,
-		config:   config,
-		verifier: verifier,
-		keyStore: keyStore,
-		schema:   schema,
+		storage:     credentialStorage,
+		config:      config,
+		verifier:    verifier,
+		contexts:    contexts,
+		statusLists: newStatusListResolver(credentialStorage, config.ServiceEndpoint),
+		keyStore:    keyStore,
+		schema:      schema,
 	}
 	if !service.Status().IsReady() {
 		return nil, errors.New(service.Status().Message)
@@ -87,10 +163,30 @@ func NewCredentialService(config config.CredentialServiceConfig, s storage.Servi
 	return &service, nil
 }
 
-func (s Service) CreateCredential(ctx context.Context, request CreateCredentialRequest) (*CreateCredentialResponse, error) {
-	if err := request.IsValid(); err != nil {
+// newContextLoader creates the loader of the JSON-LD contexts of credentials, with the contexts the service is configured
+// with read from their files.
+func newContextLoader(config config.CredentialServiceConfig) (*jsonld.ContextLoader, error) {
+	documents := make([]jsonld.Document, 0, len(config.JSONLDContexts))
+	for _, context := range config.JSONLDContexts {
+		content, err := os.ReadFile(context.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading JSON-LD context<%s>", context.URL)
+		}
+		documents = append(documents, jsonld.Document{URL: context.URL, Content: content})
+	}
+	return jsonld.NewContextLoader(documents, config.JSONLDOffline)
+}
+
+func (s Service) CreateCredential(ctx context.Context, request CreateCredentialRequest) (_ *CreateCredentialResponse, err error) {
+	ctx, span := tracing.Start(ctx, "credential.CreateCredential", attribute.String("credential.issuer", request.Issuer), attribute.String("credential.schema", request.SchemaID))
+	defer func() { tracing.End(span, err) }()
+
+	if err = request.IsValid(); err != nil {
 		return nil, errors.Wrap(err, "validating request")
 	}
+	if request.SchemaID, err = s.versionedSchemaID(ctx, request.SchemaID); err != nil {
+		return nil, err
+	}
 
 	watchKeys := make([]storage.WatchKey, 0)
 
@@ -124,9 +220,25 @@ func (s Service) CreateCredential(ctx context.Context, request CreateCredentialR
 		return nil, errors.New("problem casting to CreateCredentialResponse")
 	}
 
+	s.logToTransparency(ctx, transparency.OperationIssued, credResponse.Container)
+	s.countStats(ctx, stats.MetricCredentialsIssued, 1)
 	return credResponse, nil
 }
 
+// versionedSchemaID returns the version-qualified ID of the version of a schema that credentials are issued against:
+// the latest version, unless schemaID is version-qualified already. Credentials record it, so that they're validated
+// against the version they were issued against, however the schema changes.
+func (s Service) versionedSchemaID(ctx context.Context, schemaID string) (string, error) {
+	if schemaID == "" {
+		return "", nil
+	}
+	gotSchema, err := s.schema.GetSchema(ctx, schema.GetSchemaRequest{ID: schemaID})
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsgf(err, "failed to create credential; could not get schema: %s", schemaID)
+	}
+	return gotSchema.VersionedID(), nil
+}
+
 func (s Service) createCredentialFunc(request CreateCredentialRequest, slcMetadata StatusListCredentialMetadata) storage.BusinessLogicFunc {
 	return func(ctx context.Context, tx storage.Tx) (any, error) {
 		return s.createCredential(ctx, request, tx, slcMetadata)
@@ -224,31 +336,46 @@ func (s Service) createCredential(ctx context.Context, request CreateCredentialR
 		return nil, sdkutil.LoggingErrorMsg(err, "could not build credential")
 	}
 
-	// verify the built schema complies with the schema we've set
+	// verify the built credential complies with the schema we've set, before it's signed
+	var violations []SchemaViolation
 	if knownSchema != nil {
-		if err = schemalib.IsCredentialValidForJSONSchema(*cred, *knownSchema); err != nil {
-			return nil, sdkutil.LoggingErrorMsgf(err, "credential data does not comply with the provided schema: %s", request.SchemaID)
+		if violations, err = s.validateAgainstSchema(ctx, *cred, *knownSchema, request.SchemaID); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "validating credential against its schema")
 		}
 	}
 
-	// TODO(gabe) support Data Integrity creds too https://github.com/TBD54566975/ssi-service/issues/105
 	credCopy, err := credint.CopyCredential(*cred)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not copy credential")
 	}
-	credJWT, err := s.signCredentialJWT(ctx, request.FullyQualifiedVerificationMethodID, *credCopy)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "signing credential")
-	}
-
 	container := credint.Container{
 		ID:                                 credentialID,
 		FullyQualifiedVerificationMethodID: request.FullyQualifiedVerificationMethodID,
-		Credential:                         cred,
-		CredentialJWT:                      credJWT,
 		Revoked:                            false,
 		Suspended:                          false,
 	}
+	switch request.Format {
+	case FormatLDP:
+		// the signed credential is the credential, since its proof is embedded in it
+		if err = s.signCredentialLDP(ctx, request.FullyQualifiedVerificationMethodID, credCopy); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "signing credential")
+		}
+		container.Credential = credCopy
+	case FormatSDJWT:
+		credSDJWT, err := s.signCredentialSDJWT(ctx, request.FullyQualifiedVerificationMethodID, *credCopy, request.HolderKey)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "signing credential")
+		}
+		container.Credential = cred
+		container.CredentialSDJWT = credSDJWT
+	default:
+		credJWT, err := s.signCredentialJWT(ctx, request.FullyQualifiedVerificationMethodID, *credCopy)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "signing credential")
+		}
+		container.Credential = cred
+		container.CredentialJWT = credJWT
+	}
 
 	credentialStorageRequest := StoreCredentialRequest{
 		Container: container,
@@ -257,22 +384,19 @@ func (s Service) createCredential(ctx context.Context, request CreateCredentialR
 		return nil, sdkutil.LoggingErrorMsg(err, "saving credential")
 	}
 
-	return &CreateCredentialResponse{Container: container}, nil
+	return &CreateCredentialResponse{Container: container, SchemaViolations: violations}, nil
 }
 
 // signCredentialJWT signs a credential and returns it as a vc-jwt
 func (s Service) signCredentialJWT(ctx context.Context, verificationMethodID string, cred credential.VerifiableCredential) (*keyaccess.JWT, error) {
 	keyStoreID := did.FullyQualifiedVerificationMethodID(cred.IssuerID(), verificationMethodID)
-	gotKey, err := s.keyStore.GetKey(ctx, keystore.GetKeyRequest{ID: keyStoreID})
+	gotKey, err := s.keyStore.GetSigningKey(ctx, keyStoreID)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "getting key for signing credential<%s>", verificationMethodID)
 	}
 	if gotKey.Controller != cred.Issuer.(string) {
 		return nil, sdkutil.LoggingNewErrorf("key controller<%s> does not match credential issuer<%s> for key<%s>", gotKey.Controller, cred.Issuer, verificationMethodID)
 	}
-	if gotKey.Revoked {
-		return nil, sdkutil.LoggingNewErrorf("cannot use revoked key<%s>", gotKey.ID)
-	}
 	keyAccess, err := keyaccess.NewJWKKeyAccess(verificationMethodID, gotKey.ID, gotKey.Key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "creating key access for signing credential with key<%s>", gotKey.ID)
@@ -284,58 +408,186 @@ func (s Service) signCredentialJWT(ctx context.Context, verificationMethodID str
 	return credToken, nil
 }
 
+// signCredentialSDJWT secures a credential as an SD-JWT VC, bound to the holder's key when it's given.
+func (s Service) signCredentialSDJWT(ctx context.Context, verificationMethodID string, cred credential.VerifiableCredential, holderKey *jwx.PublicKeyJWK) (*keyaccess.SDJWT, error) {
+	keyStoreID := did.FullyQualifiedVerificationMethodID(cred.IssuerID(), verificationMethodID)
+	gotKey, err := s.keyStore.GetSigningKey(ctx, keyStoreID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting key for signing credential<%s>", verificationMethodID)
+	}
+	if gotKey.Controller != cred.Issuer.(string) {
+		return nil, sdkutil.LoggingNewErrorf("key controller<%s> does not match credential issuer<%s> for key<%s>", gotKey.Controller, cred.Issuer, verificationMethodID)
+	}
+	keyAccess, err := keyaccess.NewJWKKeyAccess(verificationMethodID, gotKey.ID, gotKey.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating key access for signing credential with key<%s>", gotKey.ID)
+	}
+	credSDJWT, err := keyAccess.SignSDJWTCredential(cred, holderKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not sign credential with key<%s>", gotKey.ID)
+	}
+	return credSDJWT, nil
+}
+
+// signCredentialLDP secures a credential with a BbsBlsSignature2020 proof when the issuer's key is a BLS12381G2 key,
+// and with an Ed25519Signature2020 proof otherwise. Since the proof signs the credential as JSON-LD, contexts that the
+// credential needs are added to it.
+func (s Service) signCredentialLDP(ctx context.Context, verificationMethodID string, cred *credential.VerifiableCredential) error {
+	keyStoreID := did.FullyQualifiedVerificationMethodID(cred.IssuerID(), verificationMethodID)
+	gotKey, err := s.keyStore.GetSigningKey(ctx, keyStoreID)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "getting key for signing credential<%s>", verificationMethodID)
+	}
+	if gotKey.Controller != cred.Issuer.(string) {
+		return sdkutil.LoggingNewErrorf("key controller<%s> does not match credential issuer<%s> for key<%s>", gotKey.Controller, cred.Issuer, verificationMethodID)
+	}
+	if cred.CredentialStatus != nil {
+		if err = addContext(cred, statussdk.StatusList2021Context); err != nil {
+			return err
+		}
+	}
+	if gotKey.Type == crypto.BLS12381G2 {
+		err = keyaccess.SignBbsBlsSignature2020(cred, keyStoreID, gotKey.Key, s.contexts)
+	} else {
+		err = keyaccess.SignEd25519Signature2020(cred, keyStoreID, gotKey.Key, s.contexts)
+	}
+	if err != nil {
+		return errors.Wrap(ErrUnsupportedLDPCredential, err.Error())
+	}
+	return nil
+}
+
+// addContext adds a context to a credential, unless it already has it.
+func addContext(cred *credential.VerifiableCredential, context string) error {
+	contexts, err := sdkutil.InterfaceToStrings(cred.Context)
+	if err != nil {
+		return errors.Wrap(err, "reading credential context")
+	}
+	for _, c := range contexts {
+		if c == context {
+			return nil
+		}
+	}
+	cred.Context = append(contexts, context)
+	return nil
+}
+
 type VerifyCredentialRequest struct {
 	DataIntegrityCredential *credential.VerifiableCredential `json:"credential,omitempty"`
 	CredentialJWT           *keyaccess.JWT                   `json:"credentialJwt,omitempty"`
+	CredentialSDJWT         *keyaccess.SDJWT                 `json:"credentialSdJwt,omitempty"`
+
+	// What the key binding of an SD-JWT credential must have been made for.
+	KeyBinding keyaccess.KeyBinding `json:"keyBinding,omitempty"`
 }
 
-// IsValid checks if the request is valid, meaning there is at least one data integrity (with proof)
-// OR jwt credential, but not both
+// IsValid checks if the request is valid, meaning there is at least one data integrity (with proof),
+// jwt, or sd-jwt credential, but not more than one
 func (vcr VerifyCredentialRequest) IsValid() error {
-	if vcr.DataIntegrityCredential == nil && vcr.CredentialJWT == nil {
-		return errors.New("either a credential or a credential JWT must be provided")
+	if vcr.DataIntegrityCredential == nil && vcr.CredentialJWT == nil && vcr.CredentialSDJWT == nil {
+		return errors.New("either a credential, a credential JWT, or a credential SD-JWT must be provided")
 	}
 	if (vcr.DataIntegrityCredential != nil && vcr.DataIntegrityCredential.Proof != nil) && vcr.CredentialJWT != nil {
 		return errors.New("only one of credential or credential JWT can be provided")
 	}
+	if vcr.CredentialSDJWT != nil && (vcr.DataIntegrityCredential != nil || vcr.CredentialJWT != nil) {
+		return errors.New("a credential SD-JWT can't be provided with a credential or credential JWT")
+	}
 	return nil
 }
 
 type VerifyCredentialResponse struct {
 	Verified bool   `json:"verified"`
 	Reason   string `json:"reason,omitempty"`
+
+	// How each check of the credential went.
+	Checks []credint.CheckResult `json:"checks,omitempty"`
+
+	// Whether the credential is revoked, or suspended, in its status list.
+	Revoked   bool `json:"revoked,omitempty"`
+	Suspended bool `json:"suspended,omitempty"`
 }
 
-// VerifyCredential does three levels of verification on a credential:
-// 1. Makes sure the credential has a valid signature
-// 2. Makes sure the credential has is not expired
-// 3. Makes sure the credential complies with the VC Data Model
+// VerifyCredential verifies a credential, whether it was issued by the service or anyone else:
+// 1. Makes sure the credential has a valid signature, from a key of its issuer's DID
+// 2. Makes sure the credential complies with the VC Data Model
+// 3. Makes sure the credential is not expired
 // 4. If the credential has a schema, makes sure its data complies with the schema
-// LATER: Makes sure the credential has not been revoked, other checks.
-// Note: https://github.com/TBD54566975/ssi-sdk/issues/213
+// 5. If the credential has a StatusList2021Entry, makes sure it isn't revoked or suspended in its status list
+// 6. If issuers are checked against a trust registry, makes sure its issuer is trusted for its types and schema
+// Every check is run, and reported, even when an earlier one fails. An SD-JWT credential that's bound to a holder's key
+// must also be presented with a key binding signed by the key, for the request's key binding.
 func (s Service) VerifyCredential(ctx context.Context, request VerifyCredentialRequest) (*VerifyCredentialResponse, error) {
+	ctx, span := tracing.Start(ctx, "credential.VerifyCredential")
+	defer span.End()
 	logrus.Debugf("verifying credential: %+v", request)
 
 	if err := request.IsValid(); err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "invalid verify credential request")
 	}
 
-	logrus.Debugf("request is vauild")
-
-	if request.CredentialJWT != nil {
-		logrus.Debugf("start VerifyJWTCredential")
-		err := s.verifier.VerifyJWTCredential(ctx, *request.CredentialJWT)
-		if err != nil {
-			return &VerifyCredentialResponse{Verified: false, Reason: err.Error()}, nil
+	var report credint.Report
+	if request.CredentialSDJWT != nil {
+		report = s.verifier.ReportSDJWT(ctx, *request.CredentialSDJWT, request.KeyBinding, s.statusLists)
+	} else {
+		container := credint.Container{CredentialJWT: request.CredentialJWT}
+		if request.CredentialJWT == nil {
+			container.Credential = request.DataIntegrityCredential
 		}
+		report = s.verifier.Report(ctx, container, s.statusLists)
+	}
+	span.SetAttributes(attribute.Bool("credential.verified", report.Verified))
+	if !report.Verified {
+		s.countStats(ctx, stats.MetricVerificationsFailed, 1)
 	} else {
+		s.countStats(ctx, stats.MetricVerificationsSucceeded, 1)
+	}
+	return &VerifyCredentialResponse{
+		Verified:  report.Verified,
+		Reason:    report.Reason,
+		Checks:    report.Checks,
+		Revoked:   report.Revoked,
+		Suspended: report.Suspended,
+	}, nil
+}
+
+// ErrCannotDeriveCredential is returned when a credential can't be derived from, like when it isn't secured with a
+// BbsBlsSignature2020 proof, or its subject doesn't have the fields to reveal.
+var ErrCannotDeriveCredential = errors.New("cannot derive credential")
+
+type DeriveCredentialRequest struct {
+	Credential credential.VerifiableCredential
+	// Fields of the credential's subject to reveal.
+	Reveal []string
+	// Nonce the derived proof is made with, like a challenge from the verifier it's presented to. A random nonce is
+	// used when it's empty.
+	Nonce string
+}
+
+type DeriveCredentialResponse struct {
+	Credential credential.VerifiableCredential
+}
 
-		if err := s.verifier.VerifyDataIntegrityCredential(ctx, *request.DataIntegrityCredential); err != nil {
-			return &VerifyCredentialResponse{Verified: false, Reason: err.Error()}, nil
+// DeriveCredential derives, from a credential secured with a BbsBlsSignature2020 proof, a credential which only
+// reveals some fields of its subject, secured with a BbsBlsSignatureProof2020 proof. This is what holders do to
+// selectively disclose what they were issued, which doesn't require any key.
+func (s Service) DeriveCredential(ctx context.Context, request DeriveCredentialRequest) (_ *DeriveCredentialResponse, err error) {
+	ctx, span := tracing.Start(ctx, "credential.DeriveCredential")
+	defer func() { tracing.End(span, err) }()
+	logrus.Debugf("deriving credential: %+v", request)
+
+	nonce := []byte(request.Nonce)
+	if len(nonce) == 0 {
+		nonce = make([]byte, 32)
+		if _, err = rand.Read(nonce); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "generating nonce")
 		}
 	}
-
-	return &VerifyCredentialResponse{Verified: true}, nil
+	derived, err := s.verifier.DeriveBBSCredential(ctx, request.Credential, request.Reveal, nonce)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(errors.Wrap(ErrCannotDeriveCredential, err.Error()), "deriving credential")
+	}
+	return &DeriveCredentialResponse{Credential: *derived}, nil
 }
 
 func (s Service) GetCredential(ctx context.Context, request GetCredentialRequest) (*GetCredentialResponse, error) {
@@ -350,11 +602,13 @@ func (s Service) GetCredential(ctx context.Context, request GetCredentialRequest
 	}
 	response := GetCredentialResponse{
 		credint.Container{
-			ID:            gotCred.LocalCredentialID,
-			Credential:    gotCred.Credential,
-			CredentialJWT: gotCred.CredentialJWT,
-			Revoked:       gotCred.Revoked,
-			Suspended:     gotCred.Suspended,
+			ID:              gotCred.LocalCredentialID,
+			Credential:      gotCred.Credential,
+			CredentialJWT:   gotCred.CredentialJWT,
+			CredentialSDJWT: gotCred.CredentialSDJWT,
+			Revoked:         gotCred.Revoked,
+			Suspended:       gotCred.Suspended,
+			Expired:         gotCred.Expired,
 		},
 	}
 	return &response, nil
@@ -371,11 +625,13 @@ func (s Service) ListCredentials(ctx context.Context) (*ListCredentialsResponse,
 	creds := make([]credint.Container, 0, len(gotCreds))
 	for _, cred := range gotCreds {
 		container := credint.Container{
-			ID:            cred.LocalCredentialID,
-			Credential:    cred.Credential,
-			CredentialJWT: cred.CredentialJWT,
-			Revoked:       cred.Revoked,
-			Suspended:     cred.Suspended,
+			ID:              cred.LocalCredentialID,
+			Credential:      cred.Credential,
+			CredentialJWT:   cred.CredentialJWT,
+			CredentialSDJWT: cred.CredentialSDJWT,
+			Revoked:         cred.Revoked,
+			Suspended:       cred.Suspended,
+			Expired:         cred.Expired,
 		}
 		creds = append(creds, container)
 	}
@@ -384,6 +640,38 @@ func (s Service) ListCredentials(ctx context.Context) (*ListCredentialsResponse,
 	return &response, nil
 }
 
+// ListCredentialsPage lists the credentials in a page, ordered by their ids, reading only that page from storage.
+func (s Service) ListCredentialsPage(ctx context.Context, request ListCredentialsPageRequest) (*ListCredentialsResponse, error) {
+	logrus.Debugf("listing a page of credential(s)")
+
+	page, err := s.storage.ListCredentialsPage(ctx, request.PageToken, request.PageSize)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not list credential(s)")
+	}
+	total, err := s.storage.CountCredentials(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not count credential(s)")
+	}
+
+	response := ListCredentialsResponse{
+		Credentials:   make([]credint.Container, 0, len(page.Credentials)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    total,
+	}
+	for _, cred := range page.Credentials {
+		response.Credentials = append(response.Credentials, credint.Container{
+			ID:              cred.LocalCredentialID,
+			Credential:      cred.Credential,
+			CredentialJWT:   cred.CredentialJWT,
+			CredentialSDJWT: cred.CredentialSDJWT,
+			Revoked:         cred.Revoked,
+			Suspended:       cred.Suspended,
+			Expired:         cred.Expired,
+		})
+	}
+	return &response, nil
+}
+
 func (s Service) ListCredentialsByIssuer(ctx context.Context, request ListCredentialByIssuerRequest) (*ListCredentialsResponse, error) {
 	logrus.Debugf("listing credential(s) for issuer: %s", util.SanitizeLog(request.Issuer))
 
@@ -395,11 +683,13 @@ func (s Service) ListCredentialsByIssuer(ctx context.Context, request ListCreden
 	creds := make([]credint.Container, 0, len(gotCreds))
 	for _, cred := range gotCreds {
 		container := credint.Container{
-			ID:            cred.LocalCredentialID,
-			Credential:    cred.Credential,
-			CredentialJWT: cred.CredentialJWT,
-			Revoked:       cred.Revoked,
-			Suspended:     cred.Suspended,
+			ID:              cred.LocalCredentialID,
+			Credential:      cred.Credential,
+			CredentialJWT:   cred.CredentialJWT,
+			CredentialSDJWT: cred.CredentialSDJWT,
+			Revoked:         cred.Revoked,
+			Suspended:       cred.Suspended,
+			Expired:         cred.Expired,
 		}
 		creds = append(creds, container)
 	}
@@ -419,11 +709,13 @@ func (s Service) ListCredentialsBySubject(ctx context.Context, request ListCrede
 	creds := make([]credint.Container, 0, len(gotCreds))
 	for _, cred := range gotCreds {
 		container := credint.Container{
-			ID:            cred.LocalCredentialID,
-			Credential:    cred.Credential,
-			CredentialJWT: cred.CredentialJWT,
-			Revoked:       cred.Revoked,
-			Suspended:     cred.Suspended,
+			ID:              cred.LocalCredentialID,
+			Credential:      cred.Credential,
+			CredentialJWT:   cred.CredentialJWT,
+			CredentialSDJWT: cred.CredentialSDJWT,
+			Revoked:         cred.Revoked,
+			Suspended:       cred.Suspended,
+			Expired:         cred.Expired,
 		}
 		creds = append(creds, container)
 	}
@@ -442,11 +734,13 @@ func (s Service) ListCredentialsBySchema(ctx context.Context, request ListCreden
 	creds := make([]credint.Container, 0, len(gotCreds))
 	for _, cred := range gotCreds {
 		container := credint.Container{
-			ID:            cred.LocalCredentialID,
-			Credential:    cred.Credential,
-			CredentialJWT: cred.CredentialJWT,
-			Revoked:       cred.Revoked,
-			Suspended:     cred.Suspended,
+			ID:              cred.LocalCredentialID,
+			Credential:      cred.Credential,
+			CredentialJWT:   cred.CredentialJWT,
+			CredentialSDJWT: cred.CredentialSDJWT,
+			Revoked:         cred.Revoked,
+			Suspended:       cred.Suspended,
+			Expired:         cred.Expired,
 		}
 		creds = append(creds, container)
 	}
@@ -467,6 +761,7 @@ func (s Service) GetCredentialStatus(ctx context.Context, request GetCredentialS
 	response := GetCredentialStatusResponse{
 		Revoked:   gotCred.Revoked,
 		Suspended: gotCred.Suspended,
+		Expired:   gotCred.Expired,
 	}
 	return &response, nil
 }
@@ -483,41 +778,66 @@ func (s Service) GetCredentialStatusList(ctx context.Context, request GetCredent
 	}
 	response := GetCredentialStatusListResponse{
 		credint.Container{
-			ID:            gotCred.LocalCredentialID,
-			Credential:    gotCred.Credential,
-			CredentialJWT: gotCred.CredentialJWT,
-			Revoked:       false, // Credential Status List cannot be revoked
-			Suspended:     false, // Credential Status List cannot be suspended
+			ID:              gotCred.LocalCredentialID,
+			Credential:      gotCred.Credential,
+			CredentialJWT:   gotCred.CredentialJWT,
+			CredentialSDJWT: gotCred.CredentialSDJWT,
+			Revoked:         false, // Credential Status List cannot be revoked
+			Suspended:       false, // Credential Status List cannot be suspended
 		},
 	}
 	return &response, nil
 }
 
+// ErrInvalidStatusUpdate is returned when a credential's status can't be updated as requested, like when revoking a
+// credential whose status list is for suspension, or a credential without a status.
+var ErrInvalidStatusUpdate = errors.New("invalid status update")
+
+// UpdateCredentialStatus revokes, suspends, or reinstates a credential, setting or clearing its bit in its status list.
+// Credentials are issued either revocable or suspendable, with a status list for revocation or for suspension, and
+// only their status for that purpose can be updated.
 func (s Service) UpdateCredentialStatus(ctx context.Context, request UpdateCredentialStatusRequest) (*UpdateCredentialStatusResponse, error) {
 	gotCred, err := s.storage.GetCredential(ctx, request.ID)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not get credential: %s", request.ID)
 	}
 
+	if request.Suspended && request.Revoked {
+		return nil, sdkutil.LoggingErrorMsg(ErrInvalidStatusUpdate, "cannot update both suspended and revoked status")
+	}
 	if gotCred.Credential.CredentialStatus == nil {
-		return nil, sdkutil.LoggingNewErrorf("credential %q has no credentialStatus field", gotCred.LocalCredentialID)
+		return nil, sdkutil.LoggingErrorMsgf(ErrInvalidStatusUpdate, "credential %q has no credentialStatus field", gotCred.LocalCredentialID)
 	}
 
-	statusPurpose := gotCred.Credential.CredentialStatus.(map[string]any)["statusPurpose"].(string)
+	// the purpose is checked here rather than in the transaction, so that dry runs are checked too
+	statusPurpose := statusPurposeOf(gotCred)
 	if len(statusPurpose) == 0 {
-		return nil, sdkutil.LoggingNewErrorf("status purpose could not be derived from credential status")
+		return nil, sdkutil.LoggingErrorMsgf(ErrInvalidStatusUpdate, "status purpose could not be derived from the credential status of credential %q", gotCred.LocalCredentialID)
+	}
+	requestedPurpose := statusPurpose
+	if request.Revoked {
+		requestedPurpose = statussdk.StatusRevocation
+	} else if request.Suspended {
+		requestedPurpose = statussdk.StatusSuspension
+	}
+	if requestedPurpose != statusPurpose {
+		return nil, sdkutil.LoggingErrorMsgf(ErrInvalidStatusUpdate, "credential<%s> has a different status purpose<%s> value than the status credential<%s>", gotCred.Credential.ID, statusPurpose, requestedPurpose)
 	}
 
-	statusListCredential, err := s.storage.GetStatusListCredentialKeyData(ctx, gotCred.Issuer, gotCred.Schema, statussdk.StatusPurpose(statusPurpose))
+	statusListCredential, err := s.storage.GetStatusListCredentialKeyData(ctx, gotCred.Issuer, gotCred.Schema, statusPurpose)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting status list watch key uuid data")
 	}
 
 	if statusListCredential == nil {
-		return nil, errors.Wrap(err, "status list credential should exist in order to update")
+		return nil, errors.New("status list credential should exist in order to update")
 	}
 
-	statusListCredentialWatchKey := s.storage.GetStatusListCredentialWatchKey(gotCred.Issuer, gotCred.Schema, statusPurpose)
+	if request.DryRun {
+		return &UpdateCredentialStatusResponse{Revoked: request.Revoked, Suspended: request.Suspended}, nil
+	}
+
+	statusListCredentialWatchKey := s.storage.GetStatusListCredentialWatchKey(gotCred.Issuer, gotCred.Schema, string(statusPurpose))
 
 	slcMetadata := StatusListCredentialMetadata{statusListCredentialWatchKey: statusListCredentialWatchKey}
 
@@ -534,6 +854,25 @@ func (s Service) UpdateCredentialStatus(ctx context.Context, request UpdateCrede
 		return nil, errors.New("casting to UpdateCredentialStatusResponse")
 	}
 
+	if credResponse.Revoked != gotCred.Revoked || credResponse.Suspended != gotCred.Suspended {
+		operation := transparency.OperationReinstated
+		switch {
+		case credResponse.Revoked:
+			operation = transparency.OperationRevoked
+		case credResponse.Suspended:
+			operation = transparency.OperationSuspended
+		}
+		s.logToTransparency(ctx, operation, credint.Container{
+			ID:              gotCred.LocalCredentialID,
+			Credential:      gotCred.Credential,
+			CredentialJWT:   gotCred.CredentialJWT,
+			CredentialSDJWT: gotCred.CredentialSDJWT,
+		})
+	}
+	if credResponse.Revoked && !gotCred.Revoked {
+		s.countStats(ctx, stats.MetricCredentialsRevoked, 1)
+	}
+
 	return credResponse, nil
 }
 
@@ -575,14 +914,19 @@ func (s Service) updateCredentialStatusBusinessLogic(ctx context.Context, tx sto
 }
 
 func updateCredentialStatus(ctx context.Context, tx storage.Tx, s Service, gotCred *StoredCredential, request UpdateCredentialStatusRequest, slcMetadata StatusListCredentialMetadata) (*credint.Container, error) {
+	// callers check the purpose of the status against the request
+	statusPurpose := statusPurposeOf(gotCred)
+
 	// store the credential with updated status
 	container := credint.Container{
 		ID:                                 gotCred.LocalCredentialID,
 		FullyQualifiedVerificationMethodID: gotCred.FullyQualifiedVerificationMethodID,
 		Credential:                         gotCred.Credential,
 		CredentialJWT:                      gotCred.CredentialJWT,
+		CredentialSDJWT:                    gotCred.CredentialSDJWT,
 		Revoked:                            request.Revoked,
 		Suspended:                          request.Suspended,
+		Expired:                            gotCred.Expired,
 	}
 
 	storageRequest := StoreCredentialRequest{
@@ -593,8 +937,8 @@ func updateCredentialStatus(ctx context.Context, tx storage.Tx, s Service, gotCr
 		return nil, sdkutil.LoggingErrorMsg(err, "could not store credential")
 	}
 
-	statusListCredentialURI := gotCred.Credential.CredentialStatus.(map[string]any)["statusListCredential"].(string)
-
+	credStatus, _ := gotCred.Credential.CredentialStatus.(map[string]any)
+	statusListCredentialURI, _ := credStatus["statusListCredential"].(string)
 	if len(statusListCredentialURI) == 0 {
 		return nil, sdkutil.LoggingNewErrorf("problem with getting status list credential id")
 	}
@@ -609,31 +953,28 @@ func updateCredentialStatus(ctx context.Context, tx storage.Tx, s Service, gotCr
 		return nil, sdkutil.LoggingNewErrorf("problem with getting status list credential for issuer: %s schema: %s", gotCred.Issuer, gotCred.Schema)
 	}
 
+	// the status list holds the bits of one purpose, so it is regenerated from every credential on it whose status for
+	// that purpose is set, whichever credential's status the request changes
 	var revokedOrSuspendedStatusCreds []credential.VerifiableCredential
 	for _, cred := range creds {
 		// we add the current cred to the creds list based on request, not on what could be in stale database that the tx has not updated yet
-		if cred.Credential.ID == gotCred.Credential.ID {
+		if cred.Credential.ID == gotCred.Credential.ID || cred.Credential.CredentialStatus == nil {
+			continue
+		}
+		if credStatus, ok := cred.Credential.CredentialStatus.(map[string]any); !ok || credStatus["statusListCredential"] != statusListCredentialURI {
 			continue
 		}
 
-		if request.Revoked && cred.Credential.CredentialStatus != nil && cred.Revoked {
-			revokedOrSuspendedStatusCreds = append(revokedOrSuspendedStatusCreds, *cred.Credential)
-		} else if request.Suspended && cred.Credential.CredentialStatus != nil && cred.Suspended {
+		if (statusPurpose == statussdk.StatusRevocation && cred.Revoked) || (statusPurpose == statussdk.StatusSuspension && cred.Suspended) {
 			revokedOrSuspendedStatusCreds = append(revokedOrSuspendedStatusCreds, *cred.Credential)
 		}
 	}
 
 	// add current one since it has not been saved yet and won't be available in the creds array
-	if request.Revoked == true || request.Suspended == true {
+	if request.Revoked || request.Suspended {
 		revokedOrSuspendedStatusCreds = append(revokedOrSuspendedStatusCreds, *gotCred.Credential)
 	}
 
-	statusPurpose := statussdk.StatusRevocation
-
-	if request.Suspended {
-		statusPurpose = statussdk.StatusSuspension
-	}
-
 	generatedStatusListCredential, err := statussdk.GenerateStatusList2021Credential(statusListCredentialURI, gotCred.Issuer, statusPurpose, revokedOrSuspendedStatusCreds)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not generate status list")
@@ -665,6 +1006,98 @@ func updateCredentialStatus(ctx context.Context, tx storage.Tx, s Service, gotCr
 	return &container, nil
 }
 
+// ExpireCredential records that a credential expired, and suspends it when its status list is for suspension, so that
+// verifiers checking its status find it isn't valid anymore. Credentials that are revoked, or were recorded as expired
+// before, aren't changed.
+func (s Service) ExpireCredential(ctx context.Context, request ExpireCredentialRequest) (*ExpireCredentialResponse, error) {
+	logrus.Debugf("expiring credential: %s", request.ID)
+
+	gotCred, err := s.storage.GetCredential(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get credential: %s", request.ID)
+	}
+	if !gotCred.IsValid() {
+		return nil, sdkutil.LoggingNewErrorf("credential returned is not valid: %s", request.ID)
+	}
+
+	var watchKeys []storage.WatchKey
+	var slcMetadata StatusListCredentialMetadata
+	if statusPurposeOf(gotCred) == statussdk.StatusSuspension {
+		slcMetadata.statusListCredentialWatchKey = s.storage.GetStatusListCredentialWatchKey(gotCred.Issuer, gotCred.Schema, string(statussdk.StatusSuspension))
+		watchKeys = append(watchKeys, slcMetadata.statusListCredentialWatchKey)
+	}
+	returnValue, err := s.storage.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		return s.expireCredential(ctx, tx, request, slcMetadata)
+	}, watchKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute")
+	}
+	response, ok := returnValue.(*ExpireCredentialResponse)
+	if !ok {
+		return nil, errors.New("casting to ExpireCredentialResponse")
+	}
+
+	if response.Changed && response.Suspended && !gotCred.Suspended {
+		s.logToTransparency(ctx, transparency.OperationSuspended, credint.Container{
+			ID:              gotCred.LocalCredentialID,
+			Credential:      gotCred.Credential,
+			CredentialJWT:   gotCred.CredentialJWT,
+			CredentialSDJWT: gotCred.CredentialSDJWT,
+		})
+	}
+	return response, nil
+}
+
+func (s Service) expireCredential(ctx context.Context, tx storage.Tx, request ExpireCredentialRequest, slcMetadata StatusListCredentialMetadata) (*ExpireCredentialResponse, error) {
+	// read the credential again, since its status may have changed since it was read outside the transaction
+	gotCred, err := s.storage.GetCredential(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get credential: %s", request.ID)
+	}
+	container := credint.Container{
+		ID:                                 gotCred.LocalCredentialID,
+		FullyQualifiedVerificationMethodID: gotCred.FullyQualifiedVerificationMethodID,
+		Credential:                         gotCred.Credential,
+		CredentialJWT:                      gotCred.CredentialJWT,
+		CredentialSDJWT:                    gotCred.CredentialSDJWT,
+		Revoked:                            gotCred.Revoked,
+		Suspended:                          gotCred.Suspended,
+		Expired:                            gotCred.Expired,
+	}
+	if gotCred.Expired || gotCred.Revoked {
+		return &ExpireCredentialResponse{Container: container}, nil
+	}
+
+	gotCred.Expired = true
+	if statusPurposeOf(gotCred) == statussdk.StatusSuspension && !gotCred.Suspended {
+		suspended, err := updateCredentialStatus(ctx, tx, s, gotCred, UpdateCredentialStatusRequest{ID: request.ID, Suspended: true}, slcMetadata)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "suspending expired credential")
+		}
+		return &ExpireCredentialResponse{Container: *suspended, Changed: true}, nil
+	}
+
+	container.Expired = true
+	if err = s.storage.StoreCredentialTx(ctx, tx, StoreCredentialRequest{Container: container}); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not store credential")
+	}
+	return &ExpireCredentialResponse{Container: container, Changed: true}, nil
+}
+
+// statusPurposeOf returns the status purpose of the credential status of a stored credential, which is empty when it
+// has none.
+func statusPurposeOf(stored *StoredCredential) statussdk.StatusPurpose {
+	if stored.Credential == nil {
+		return ""
+	}
+	credStatus, ok := stored.Credential.CredentialStatus.(map[string]any)
+	if !ok {
+		return ""
+	}
+	statusPurpose, _ := credStatus["statusPurpose"].(string)
+	return statussdk.StatusPurpose(statusPurpose)
+}
+
 func parseIDFromURI(uri string) (string, error) {
 	const uuidStandardFormLen = 36
 	if len(uri) < uuidStandardFormLen {
@@ -705,6 +1138,10 @@ func (s Service) BatchCreateCredentials(ctx context.Context, batchRequest BatchC
 
 	funcs := make([]storage.BusinessLogicFunc, 0, len(batchRequest.Requests))
 	for _, request := range batchRequest.Requests {
+		var err error
+		if request.SchemaID, err = s.versionedSchemaID(ctx, request.SchemaID); err != nil {
+			return nil, err
+		}
 		var statusMetadata StatusListCredentialMetadata
 		if request.hasStatus() && request.isStatusValid() {
 			statusPurpose := statussdk.StatusRevocation
@@ -758,5 +1195,44 @@ func (s Service) BatchCreateCredentials(ctx context.Context, batchRequest BatchC
 		return nil, errors.New("problem casting to BatchCreateCredentialsResponse")
 	}
 
+	s.logToTransparency(ctx, transparency.OperationIssued, credResponse.Credentials...)
+	s.countStats(ctx, stats.MetricCredentialsIssued, len(credResponse.Credentials))
 	return credResponse, nil
 }
+
+// defaultBatchCreateConcurrency is how many credentials of a batch are created at once, unless configured otherwise.
+const defaultBatchCreateConcurrency = 10
+
+// BatchCreateCredentialsIndependently creates the credentials of a batch concurrently, each in its own transaction, so
+// that a request that fails doesn't stop the others. Unlike BatchCreateCredentials, it succeeds when some of the
+// credentials couldn't be created, and reports the result of each request.
+func (s Service) BatchCreateCredentialsIndependently(ctx context.Context, batchRequest BatchCreateCredentialsRequest) (*BatchCreateCredentialResultsResponse, error) {
+	concurrency := s.config.BatchCreateConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchCreateConcurrency
+	}
+
+	results := make([]BatchCreateCredentialResult, len(batchRequest.Requests))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(batchRequest.Requests); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				created, err := s.CreateCredential(ctx, batchRequest.Requests[index])
+				if err != nil {
+					results[index].Error = err
+					continue
+				}
+				results[index].Credential = &created.Container
+			}
+		}()
+	}
+	for i := range batchRequest.Requests {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return &BatchCreateCredentialResultsResponse{Results: results}, nil
+}
@@ -1,17 +1,26 @@
 package credential
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential"
 	statussdk "github.com/TBD54566975/ssi-sdk/credential/status"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
@@ -97,3 +106,90 @@ func (s Service) createStatusListCredential(ctx context.Context, tx storage.Tx,
 
 	return randomIndex, generatedStatusListCredential, nil
 }
+
+const (
+	// statusListTimeout bounds how long fetching a status list credential from another service may take.
+	statusListTimeout = 10 * time.Second
+
+	// maxStatusListBytes is the largest status list credential that's read from another service.
+	maxStatusListBytes = 1 << 20
+)
+
+// statusListResolver fetches the status list credentials that credentials being verified point to. Those of the
+// service are read from its storage, and those of other issuers are fetched from their URLs.
+type statusListResolver struct {
+	storage *Storage
+	// prefix of the URLs of the service's own status list credentials
+	prefix string
+	client *http.Client
+}
+
+func newStatusListResolver(storage *Storage, serviceEndpoint string) statusListResolver {
+	return statusListResolver{
+		storage: storage,
+		prefix:  serviceEndpoint + "/status/",
+		client:  &http.Client{Timeout: statusListTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (r statusListResolver) ResolveStatusList(ctx context.Context, id string) (*credint.Container, error) {
+	if localID, ok := strings.CutPrefix(id, r.prefix); ok && localID != "" && !strings.Contains(localID, "/") {
+		stored, err := r.storage.GetStatusListCredential(ctx, localID)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting status list credential")
+		}
+		return &credint.Container{ID: stored.LocalCredentialID, Credential: stored.Credential, CredentialJWT: stored.CredentialJWT}, nil
+	}
+
+	statusListURL, err := url.Parse(id)
+	if err != nil || (statusListURL.Scheme != "https" && statusListURL.Scheme != "http") {
+		return nil, errors.Errorf("status list credential is not an HTTP URL: %s", id)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusListURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Accept", "application/json, application/jwt")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching status list credential")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetching status list credential: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusListBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading status list credential")
+	}
+	return parseStatusListCredential(body)
+}
+
+// parseStatusListCredential reads a status list credential as served by an issuer: a VC-JWT, a data integrity
+// credential, or the response of this service's status list endpoint, which wraps either.
+func parseStatusListCredential(body []byte) (*credint.Container, error) {
+	body = bytes.TrimSpace(body)
+	if !bytes.HasPrefix(body, []byte("{")) {
+		container, err := credint.NewCredentialContainerFromJWT(string(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing status list credential JWT")
+		}
+		return container, nil
+	}
+
+	var wrapped struct {
+		Credential    *credential.VerifiableCredential `json:"credential"`
+		CredentialJWT *keyaccess.JWT                   `json:"credentialJwt"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, errors.Wrap(err, "parsing status list credential")
+	}
+	if wrapped.CredentialJWT != nil || wrapped.Credential != nil {
+		return &credint.Container{Credential: wrapped.Credential, CredentialJWT: wrapped.CredentialJWT}, nil
+	}
+	var cred credential.VerifiableCredential
+	if err := json.Unmarshal(body, &cred); err != nil {
+		return nil, errors.Wrap(err, "parsing status list credential")
+	}
+	return &credint.Container{Credential: &cred}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/credential"
@@ -16,6 +17,7 @@ import (
 	credint "github.com/tbd54566975/ssi-service/internal/credential"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/schema"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
@@ -33,6 +35,8 @@ type StoredCredential struct {
 	// only one of these fields should be present
 	Credential    *credential.VerifiableCredential `json:"credential,omitempty"`
 	CredentialJWT *keyaccess.JWT                   `json:"token,omitempty"`
+	// set along with the credential, which has every claim the SD-JWT discloses
+	CredentialSDJWT *keyaccess.SDJWT `json:"sdJwt,omitempty"`
 
 	Issuer                             string `json:"issuer"`
 	FullyQualifiedVerificationMethodID string `json:"fullyQualifiedVerificationMethodId"`
@@ -41,6 +45,7 @@ type StoredCredential struct {
 	IssuanceDate                       string `json:"issuanceDate"`
 	Revoked                            bool   `json:"revoked"`
 	Suspended                          bool   `json:"suspended"`
+	Expired                            bool   `json:"expired,omitempty"`
 }
 
 type WriteContext struct {
@@ -56,7 +61,7 @@ type StatusListCredentialMetadata struct {
 }
 
 func (sc StoredCredential) IsValid() bool {
-	return sc.Key != "" && (sc.HasDataIntegrityCredential() || sc.HasJWTCredential())
+	return sc.Key != "" && (sc.HasDataIntegrityCredential() || sc.HasJWTCredential() || sc.HasSDJWTCredential())
 }
 
 func (sc StoredCredential) HasDataIntegrityCredential() bool {
@@ -67,6 +72,10 @@ func (sc StoredCredential) HasJWTCredential() bool {
 	return sc.CredentialJWT != nil
 }
 
+func (sc StoredCredential) HasSDJWTCredential() bool {
+	return sc.CredentialSDJWT != nil
+}
+
 const (
 	credentialNamespace                    = "credential"
 	statusListCredentialNamespace          = "status-list-credential"
@@ -183,7 +192,7 @@ func (cs *Storage) CreateStatusListCredentialTx(ctx context.Context, tx storage.
 		return -1, sdkutil.LoggingErrorMsg(err, "could not marshal random unique numbers")
 	}
 
-	if err := tx.Write(context.Background(), slcMetadata.statusListIndexPoolWatchKey.Namespace, slcMetadata.statusListIndexPoolWatchKey.Key, uniqueNumBytes); err != nil {
+	if err := tx.Write(ctx, slcMetadata.statusListIndexPoolWatchKey.Namespace, slcMetadata.statusListIndexPoolWatchKey.Key, uniqueNumBytes); err != nil {
 		return -1, sdkutil.LoggingErrorMsg(err, "problem writing status list indexes to db")
 	}
 
@@ -193,7 +202,7 @@ func (cs *Storage) CreateStatusListCredentialTx(ctx context.Context, tx storage.
 		return -1, sdkutil.LoggingErrorMsg(err, "could not marshal status list index bytes")
 	}
 
-	if err := tx.Write(context.Background(), slcMetadata.statusListCurrentIndexWatchKey.Namespace, slcMetadata.statusListCurrentIndexWatchKey.Key, statusListIndexBytes); err != nil {
+	if err := tx.Write(ctx, slcMetadata.statusListCurrentIndexWatchKey.Namespace, slcMetadata.statusListCurrentIndexWatchKey.Key, statusListIndexBytes); err != nil {
 		return -1, sdkutil.LoggingErrorMsg(err, "problem writing current list index to db")
 	}
 
@@ -220,25 +229,20 @@ func (cs *Storage) StoreStatusListCredentialTx(ctx context.Context, tx storage.T
 }
 
 func (cs *Storage) GetStatusListCredential(ctx context.Context, id string) (*StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, statusListCredentialNamespace)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for cred with id: %s", id)
-	}
-
 	var storedCreds []StoredCredential
-	for _, key := range keys {
-		credBytes, err := cs.db.Read(ctx, statusListCredentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-			continue
-		}
+	err := cs.db.Iterate(ctx, statusListCredentialNamespace, func(key string, credBytes []byte) (bool, error) {
 		var cred StoredCredential
-		if err = json.Unmarshal(credBytes, &cred); err != nil {
+		if err := json.Unmarshal(credBytes, &cred); err != nil {
 			logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
+			return true, nil
 		}
 		if cred.LocalCredentialID == id {
 			storedCreds = append(storedCreds, cred)
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for cred with id: %s", id)
 	}
 
 	if len(storedCreds) == 0 {
@@ -307,6 +311,7 @@ func buildStoredCredential(request StoreCredentialRequest) (*StoredCredential, e
 		LocalCredentialID:                  credID,
 		Credential:                         cred,
 		CredentialJWT:                      request.CredentialJWT,
+		CredentialSDJWT:                    request.CredentialSDJWT,
 		Issuer:                             issuer,
 		FullyQualifiedVerificationMethodID: request.FullyQualifiedVerificationMethodID,
 		Subject:                            subject,
@@ -314,6 +319,7 @@ func buildStoredCredential(request StoreCredentialRequest) (*StoredCredential, e
 		IssuanceDate:                       cred.IssuanceDate,
 		Revoked:                            request.Revoked,
 		Suspended:                          request.Suspended,
+		Expired:                            request.Expired,
 	}, nil
 }
 
@@ -351,25 +357,11 @@ func (cs *Storage) getCredential(ctx context.Context, id string, namespace strin
 // The method is greedy, meaning if multiple values are found...and some fail during processing, we will
 // return only the successful values and log an error for the failures.
 func (cs *Storage) ListCredentials(ctx context.Context) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, credentialNamespace)
+	storedCreds, err := cs.listCredentialsWithKey(ctx, credentialNamespace, func(string) bool { return true })
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage")
 	}
 
-	var storedCreds []StoredCredential
-	for _, key := range keys {
-		credBytes, err := cs.db.Read(ctx, credentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-		} else {
-			var cred StoredCredential
-			if err = json.Unmarshal(credBytes, &cred); err != nil {
-				logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-			}
-			storedCreds = append(storedCreds, cred)
-		}
-	}
-
 	if len(storedCreds) == 0 {
 		logrus.Info("no credentials able to be retrieved")
 	}
@@ -377,6 +369,47 @@ func (cs *Storage) ListCredentials(ctx context.Context) ([]StoredCredential, err
 	return storedCreds, nil
 }
 
+// CredentialsPage is a page of credentials, ordered by their ids.
+type CredentialsPage struct {
+	Credentials   []StoredCredential
+	NextPageToken string
+}
+
+// ListCredentialsPage returns the credentials in the page with the given token and size. A size of -1 means all
+// credentials. Credentials are keyed by their ids first, so that pages are ordered by id. It will return those it can
+// even if it has trouble with some.
+func (cs *Storage) ListCredentialsPage(ctx context.Context, token string, size int) (*CredentialsPage, error) {
+	creds, nextPageToken, err := cs.db.ReadPage(ctx, credentialNamespace, token, size)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not read page of credentials")
+	}
+	keys := make([]string, 0, len(creds))
+	for key := range creds {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	storedCreds := make([]StoredCredential, 0, len(keys))
+	for _, key := range keys {
+		var cred StoredCredential
+		if err = json.Unmarshal(creds[key], &cred); err != nil {
+			logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
+			continue
+		}
+		storedCreds = append(storedCreds, cred)
+	}
+	return &CredentialsPage{Credentials: storedCreds, NextPageToken: nextPageToken}, nil
+}
+
+// CountCredentials returns how many credentials are stored.
+func (cs *Storage) CountCredentials(ctx context.Context) (int, error) {
+	keys, err := cs.db.ReadAllKeys(ctx, credentialNamespace)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not read credential keys")
+	}
+	return len(keys), nil
+}
+
 // Note: this is a lazy  implementation. Optimizations are to be had by adjusting prefix
 // queries, and nested buckets. It is not intended that bolt is run in production, or at any scale,
 // so this is not much of a concern.
@@ -385,39 +418,16 @@ func (cs *Storage) ListCredentials(ctx context.Context) ([]StoredCredential, err
 // The method is greedy, meaning if multiple values are found and some fail during processing, we will
 // return only the successful values and log an error for the failures.
 func (cs *Storage) ListCredentialsByIssuer(ctx context.Context, issuer string) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, credentialNamespace)
+	storedCreds, err := cs.listCredentialsWithKey(ctx, credentialNamespace, func(k string) bool {
+		return strings.Contains(k, issuer)
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for issuer: %s", issuer)
 	}
-	// see if the prefix keys contains the issuer value
-	var issuerKeys []string
-	for _, k := range keys {
-		if strings.Contains(k, issuer) {
-			issuerKeys = append(issuerKeys, k)
-		}
-	}
-	if len(issuerKeys) == 0 {
-		logrus.Warnf("no credentials found for issuer: %s", util.SanitizeLog(issuer))
-		return nil, nil
-	}
-
-	// now get each credential by key
-	var storedCreds []StoredCredential
-	for _, key := range issuerKeys {
-		credBytes, err := cs.db.Read(ctx, credentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-		} else {
-			var cred StoredCredential
-			if err = json.Unmarshal(credBytes, &cred); err != nil {
-				logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-			}
-			storedCreds = append(storedCreds, cred)
-		}
-	}
 
 	if len(storedCreds) == 0 {
-		logrus.Infof("no credentials able to be retrieved for issuer: %s", issuerKeys)
+		logrus.Warnf("no credentials found for issuer: %s", util.SanitizeLog(issuer))
+		return nil, nil
 	}
 
 	return storedCreds, nil
@@ -427,84 +437,43 @@ func (cs *Storage) ListCredentialsByIssuer(ctx context.Context, issuer string) (
 // The method is greedy, meaning if multiple values are found...and some fail during processing, we will
 // return only the successful values and log an error for the failures.
 func (cs *Storage) ListCredentialsBySubject(ctx context.Context, subject string) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, credentialNamespace)
+	storedCreds, err := cs.listCredentialsWithKey(ctx, credentialNamespace, func(k string) bool {
+		return strings.Contains(k, subject)
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for subject: %s", subject)
 	}
 
-	// see if the prefix keys contains the subject value
-	var subjectKeys []string
-	for _, k := range keys {
-		if strings.Contains(k, subject) {
-			subjectKeys = append(subjectKeys, k)
-		}
-	}
-	if len(subjectKeys) == 0 {
+	if len(storedCreds) == 0 {
 		logrus.Warnf("no credentials found for subject: %s", util.SanitizeLog(subject))
 		return nil, nil
 	}
 
-	// now get each credential by key
-	var storedCreds []StoredCredential
-	for _, key := range subjectKeys {
-		credBytes, err := cs.db.Read(ctx, credentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-		} else {
-			var cred StoredCredential
-			if err := json.Unmarshal(credBytes, &cred); err != nil {
-				logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-			}
-			storedCreds = append(storedCreds, cred)
-		}
-	}
-
-	if len(storedCreds) == 0 {
-		logrus.Infof("no credentials able to be retrieved for subject: %s", subjectKeys)
-	}
-
 	return storedCreds, nil
 }
 
-// GetCredentialsBySchema gets all credentials stored with a prefix key containing the schema value
+// GetCredentialsBySchema gets all credentials stored with a prefix key containing the schema value, including those
+// issued against any version of the schema when the schema value isn't version-qualified.
 // The method is greedy, meaning if multiple values are found...and some fail during processing, we will
 // return only the successful values and log an error for the failures.
-func (cs *Storage) GetCredentialsBySchema(ctx context.Context, schema string) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, credentialNamespace)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for schema: %s", schema)
-	}
-
-	// see if the prefix keys contains the schema value
-	query := storage.Join("sc", schema)
-	var schemaKeys []string
-	for _, k := range keys {
-		if strings.HasSuffix(k, query) {
-			schemaKeys = append(schemaKeys, k)
-		}
-	}
-	if len(schemaKeys) == 0 {
-		logrus.Warnf("no credentials found for schema: %s", util.SanitizeLog(schema))
-		return nil, nil
-	}
-
-	// now get each credential by key
-	var storedCreds []StoredCredential
-	for _, key := range schemaKeys {
-		credBytes, err := cs.db.Read(ctx, credentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-		} else {
-			var cred StoredCredential
-			if err := json.Unmarshal(credBytes, &cred); err != nil {
-				logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-			}
-			storedCreds = append(storedCreds, cred)
+func (cs *Storage) GetCredentialsBySchema(ctx context.Context, schemaID string) ([]StoredCredential, error) {
+	query := storage.Join("", "sc", "")
+	storedCreds, err := cs.listCredentialsWithKey(ctx, credentialNamespace, func(k string) bool {
+		i := strings.LastIndex(k, query)
+		if i < 0 {
+			return false
 		}
+		keySchema := k[i+len(query):]
+		keySchemaID, _ := schema.SplitVersionedID(keySchema)
+		return keySchema == schemaID || keySchemaID == schemaID
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for schema: %s", schemaID)
 	}
 
 	if len(storedCreds) == 0 {
-		logrus.Infof("no credentials able to be retrieved for schema: %s", schemaKeys)
+		logrus.Warnf("no credentials found for schema: %s", util.SanitizeLog(schemaID))
+		return nil, nil
 	}
 
 	return storedCreds, nil
@@ -518,86 +487,58 @@ func (cs *Storage) GetCredentialsByIssuerAndSchema(ctx context.Context, issuer s
 }
 
 func (cs *Storage) GetStatusListCredentialsByIssuerSchemaPurpose(ctx context.Context, issuer string, schema string, statusPurpose statussdk.StatusPurpose) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, statusListCredentialNamespace)
+	query := storage.Join("sc", schema, "sp", string(statusPurpose))
+	storedCreds, err := cs.listCredentialsWithKey(ctx, statusListCredentialNamespace, func(k string) bool {
+		return strings.Contains(k, issuer) && strings.HasSuffix(k, query)
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for issuer: %s", issuer)
 	}
 
-	query := storage.Join("sc", schema, "sp", string(statusPurpose))
-	var issuerSchemaKeys []string
-	for _, k := range keys {
-		if strings.Contains(k, issuer) && strings.HasSuffix(k, query) {
-			issuerSchemaKeys = append(issuerSchemaKeys, k)
-		}
-	}
-
-	if len(issuerSchemaKeys) == 0 {
+	if len(storedCreds) == 0 {
 		logrus.Warnf("no status list credentials found for issuer: %s schema %s and status purpose %s", util.SanitizeLog(issuer), util.SanitizeLog(schema), util.SanitizeLog(string(statusPurpose)))
 		return nil, nil
 	}
 
-	// now get each credential by key
-	storedCreds := make([]StoredCredential, 0, len(issuerSchemaKeys))
-	for _, key := range issuerSchemaKeys {
-		credBytes, err := cs.db.Read(ctx, statusListCredentialNamespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-			return nil, err
-		}
-
-		var cred StoredCredential
-		if err = json.Unmarshal(credBytes, &cred); err != nil {
-			logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-		}
-
-		storedCreds = append(storedCreds, cred)
-	}
-
-	if len(storedCreds) == 0 {
-		logrus.Infof("no credentials able to be retrieved for issuer: %s", issuerSchemaKeys)
-	}
-
 	return storedCreds, nil
 }
 
 func (cs *Storage) getCredentialsByIssuerAndSchema(ctx context.Context, issuer string, schema string, namespace string) ([]StoredCredential, error) {
-	keys, err := cs.db.ReadAllKeys(ctx, namespace)
+	query := storage.Join("sc", schema)
+	storedCreds, err := cs.listCredentialsWithKey(ctx, namespace, func(k string) bool {
+		return strings.Contains(k, issuer) && strings.HasSuffix(k, query)
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not read credential storage while searching for creds for issuer: %s", issuer)
 	}
 
-	query := storage.Join("sc", schema)
-	var issuerSchemaKeys []string
-	for _, k := range keys {
-		if strings.Contains(k, issuer) && strings.HasSuffix(k, query) {
-			issuerSchemaKeys = append(issuerSchemaKeys, k)
-		}
-	}
-
-	if len(issuerSchemaKeys) == 0 {
+	if len(storedCreds) == 0 {
 		logrus.Warnf("no credentials found for issuer: %s and schema %s", util.SanitizeLog(issuer), util.SanitizeLog(schema))
 		return nil, nil
 	}
 
-	// now get each credential by key
+	return storedCreds, nil
+}
+
+// listCredentialsWithKey streams over every credential in the namespace and collects the ones whose storage key
+// satisfies keyMatches. Values that cannot be unmarshalled are logged and skipped.
+func (cs *Storage) listCredentialsWithKey(ctx context.Context, namespace string, keyMatches func(key string) bool) ([]StoredCredential, error) {
 	var storedCreds []StoredCredential
-	for _, key := range issuerSchemaKeys {
-		credBytes, err := cs.db.Read(ctx, namespace, key)
-		if err != nil {
-			logrus.WithError(err).Errorf("could not read credential with key: %s", key)
-		} else {
-			var cred StoredCredential
-			if err = json.Unmarshal(credBytes, &cred); err != nil {
-				logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
-			}
-			storedCreds = append(storedCreds, cred)
+	err := cs.db.Iterate(ctx, namespace, func(key string, credBytes []byte) (bool, error) {
+		if !keyMatches(key) {
+			return true, nil
 		}
+		var cred StoredCredential
+		if err := json.Unmarshal(credBytes, &cred); err != nil {
+			logrus.WithError(err).Errorf("unmarshalling credential with key: %s", key)
+			return true, nil
+		}
+		storedCreds = append(storedCreds, cred)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	if len(storedCreds) == 0 {
-		logrus.Infof("no credentials able to be retrieved for issuer: %s", issuerSchemaKeys)
-	}
-
 	return storedCreds, nil
 }
 
@@ -0,0 +1,87 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	schemalib "github.com/TBD54566975/ssi-sdk/credential/schema"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+// SchemaViolation is a way in which a credential doesn't conform to the JSON Schema of its schema.
+type SchemaViolation struct {
+	// Field of the credential that's invalid, as a dotted path, e.g. credentialSubject.emailAddress. Empty when the
+	// credential as a whole is invalid.
+	Field string `json:"field"`
+	// Location of the keyword of the schema that the field fails, as a JSON pointer, e.g.
+	// /properties/credentialSubject/required.
+	Keyword string `json:"keyword"`
+	// Error describes how the field fails the keyword.
+	Error string `json:"error"`
+}
+
+// SchemaValidationError is returned when issuing a credential whose data doesn't conform to the JSON Schema of its
+// schema, with strict schema validation.
+type SchemaValidationError struct {
+	SchemaID   string
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.Field == "" {
+			violations = append(violations, v.Error)
+			continue
+		}
+		violations = append(violations, v.Field+": "+v.Error)
+	}
+	return fmt.Sprintf("credential data does not comply with the provided schema<%s>: %s", e.SchemaID, strings.Join(violations, "; "))
+}
+
+// validateAgainstSchema checks that the credential conforms to the JSON Schema of its schema. With strict schema
+// validation, credentials that don't conform fail with a SchemaValidationError. Otherwise, how they don't conform is
+// logged and returned, and they're issued anyway. Schemas that can't be validated against fail in either case.
+func (s Service) validateAgainstSchema(ctx context.Context, cred credential.VerifiableCredential, knownSchema schemalib.JSONSchema, schemaID string) ([]SchemaViolation, error) {
+	err := schemalib.IsCredentialValidForJSONSchema(cred, knownSchema)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, errors.Wrapf(err, "validating credential against schema<%s>", schemaID)
+	}
+	violations := schemaViolations(validationErr, nil)
+	if s.config.SchemaValidation == config.SchemaValidationAdvisory {
+		logrus.WithContext(ctx).WithField("violations", violations).Warnf("issuing credential that does not comply with schema<%s>", schemaID)
+		return violations, nil
+	}
+	return nil, &SchemaValidationError{SchemaID: schemaID, Violations: violations}
+}
+
+// schemaViolations flattens a validation error into the violations at its leaves, which are the ones that say what's
+// wrong, rather than which of their parents failed because of them.
+func schemaViolations(err *jsonschema.ValidationError, violations []SchemaViolation) []SchemaViolation {
+	if len(err.Causes) == 0 {
+		return append(violations, SchemaViolation{
+			Field:   strings.ReplaceAll(strings.TrimPrefix(err.InstanceLocation, "/"), "/", "."),
+			Keyword: err.KeywordLocation,
+			Error:   err.Message,
+		})
+	}
+	for _, cause := range err.Causes {
+		violations = schemaViolations(cause, violations)
+	}
+	return violations
+}
+
+// isValidSchemaValidation returns whether mode is a schema validation mode, which is strict when empty.
+func isValidSchemaValidation(mode string) bool {
+	return mode == "" || mode == config.SchemaValidationStrict || mode == config.SchemaValidationAdvisory
+}
@@ -32,6 +32,24 @@ type MethodHandler interface {
 
 	// SoftDeleteDID marks the given DID as deleted. It is not removed from storage.
 	SoftDeleteDID(ctx context.Context, request DeleteDIDRequest) error
+
+	// RestoreDID unmarks the given soft-deleted DID as deleted.
+	RestoreDID(ctx context.Context, request RestoreDIDRequest) (*RestoreDIDResponse, error)
+}
+
+// UpdatableMethodHandler is implemented by the handlers of DID methods whose documents can change once created, by
+// operations anchored to the network of the method.
+type UpdatableMethodHandler interface {
+	MethodHandler
+
+	// UpdateDID adds and removes keys and services of a DID.
+	UpdateDID(ctx context.Context, request UpdateDIDRequest) (*UpdateDIDResponse, error)
+
+	// RecoverDID replaces the keys and services of a DID, and the keys that control it.
+	RecoverDID(ctx context.Context, request RecoverDIDRequest) (*RecoverDIDResponse, error)
+
+	// DeactivateDID permanently deactivates a DID.
+	DeactivateDID(ctx context.Context, request DeactivateDIDRequest) (*DeactivateDIDResponse, error)
 }
 
 // NewHandlerResolver creates a new HandlerResolver from a map of MethodHandlers which are used to resolve DIDs
@@ -1,9 +1,12 @@
 package did
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
 	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
@@ -24,6 +27,10 @@ import (
 const (
 	updateKeySuffix  string = "update"
 	recoverKeySuffix string = "recover"
+
+	// pendingKeySuffix is appended to the suffix of the next update or recovery key of a DID, which is stored under it
+	// while the operation committing to it is anchored.
+	pendingKeySuffix string = "-pending"
 )
 
 func NewIONHandler(baseURL string, s *Storage, ks *keystore.Service) (MethodHandler, error) {
@@ -40,18 +47,27 @@ func NewIONHandler(baseURL string, s *Storage, ks *keystore.Service) (MethodHand
 	if err != nil {
 		return nil, errors.Wrap(err, "creating ion resolver")
 	}
-	return &ionHandler{method: did.IONMethod, resolver: r, storage: s, keyStore: ks}, nil
+	return &ionHandler{
+		method:   did.IONMethod,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		client:   http.DefaultClient,
+		resolver: r,
+		storage:  s,
+		keyStore: ks,
+	}, nil
 }
 
 type ionHandler struct {
 	method   did.Method
+	baseURL  string
+	client   *http.Client
 	resolver *ion.Resolver
 	storage  *Storage
 	keyStore *keystore.Service
 }
 
 // Verify interface compliance https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
-var _ MethodHandler = (*ionHandler)(nil)
+var _ UpdatableMethodHandler = (*ionHandler)(nil)
 
 type CreateIONDIDOptions struct {
 	// Services to add to the DID document that will be created.
@@ -74,12 +90,16 @@ func (h *ionHandler) GetMethod() did.Method {
 	return h.method
 }
 
+// ionStoredDID is stored under the short form of the DID. Until its create operation is anchored, only the long form
+// resolves, so DID is the document of the long form until the DID is Published.
 type ionStoredDID struct {
 	ID          string       `json:"id"`
 	DID         did.Document `json:"did"`
 	SoftDeleted bool         `json:"softDeleted"`
 	LongFormDID string       `json:"longFormDID"`
 	Operations  []any        `json:"operations"`
+	Published   bool         `json:"published"`
+	Deactivated bool         `json:"deactivated"`
 }
 
 func (i ionStoredDID) GetID() string {
@@ -175,13 +195,18 @@ func (h *ionHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 		return nil, errors.Wrap(err, "anchoring create operation")
 	}
 
-	// store the did document
+	// store the did document under its short form, which is what it's known by once anchored
+	id, err := shortFormDID(resolutionResult.Document.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting short form of anchored DID")
+	}
 	storedDID := ionStoredDID{
-		ID:          resolutionResult.Document.ID,
+		ID:          id,
 		DID:         resolutionResult.Document,
 		SoftDeleted: false,
 		LongFormDID: ionDID.LongForm(),
 		Operations:  ionDID.Operations(),
+		Published:   isPublished(resolutionResult),
 	}
 	if err = h.storage.StoreDID(ctx, storedDID); err != nil {
 		return nil, errors.Wrap(err, "storing ion did document")
@@ -191,7 +216,7 @@ func (h *ionHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 	// 1. update key
 	// 2. recovery key
 	// 3. key(s) in the did docs
-	updateStoreRequest, err := keyToStoreRequest(resolutionResult.Document.ID+"#"+updateKeySuffix, ionDID.GetUpdatePrivateKey(), resolutionResult.Document.ID)
+	updateStoreRequest, err := keyToStoreRequest(id+"#"+updateKeySuffix, ionDID.GetUpdatePrivateKey(), id)
 	if err != nil {
 		return nil, errors.Wrap(err, "converting update private key to store request")
 	}
@@ -199,7 +224,7 @@ func (h *ionHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 		return nil, errors.Wrap(err, "could not store did:ion update private key")
 	}
 
-	recoveryStoreRequest, err := keyToStoreRequest(resolutionResult.Document.ID+"#"+recoverKeySuffix, ionDID.GetRecoveryPrivateKey(), resolutionResult.Document.ID)
+	recoveryStoreRequest, err := keyToStoreRequest(id+"#"+recoverKeySuffix, ionDID.GetRecoveryPrivateKey(), id)
 	if err != nil {
 		return nil, errors.Wrap(err, "converting recovery private key to store request")
 	}
@@ -207,8 +232,8 @@ func (h *ionHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 		return nil, errors.Wrap(err, "could not store did:ion recovery private key")
 	}
 
-	keyStoreID := did.FullyQualifiedVerificationMethodID(resolutionResult.Document.ID, resolutionResult.Document.VerificationMethod[0].ID)
-	keyStoreRequest, err := keyToStoreRequest(keyStoreID, *privKeyJWK, resolutionResult.Document.ID)
+	keyStoreID := did.FullyQualifiedVerificationMethodID(id, resolutionResult.Document.VerificationMethod[0].ID)
+	keyStoreRequest, err := keyToStoreRequest(keyStoreID, *privKeyJWK, id)
 	if err != nil {
 		return nil, errors.Wrap(err, "converting private key to store request")
 	}
@@ -242,22 +267,39 @@ func keyToStoreRequest(kid string, privateKeyJWK jwx.PrivateKeyJWK, controller s
 	}, nil
 }
 
+// isPublished reports whether the DID of a resolution result from the ION node is anchored.
+func isPublished(result *resolution.Result) bool {
+	return result.DocumentMetadata != nil && result.DocumentMetadata.Method.Published
+}
+
+// shortFormDID returns the short form of a did:ion DID given in either form.
+func shortFormDID(id string) (string, error) {
+	if !ion.IsLongFormDID(id) {
+		return id, nil
+	}
+	return ion.LongToShortFormDID(id)
+}
+
 func (h *ionHandler) GetDID(ctx context.Context, request GetDIDRequest) (*GetDIDResponse, error) {
-	id := request.ID
+	id, err := shortFormDID(request.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting short form of DID")
+	}
 
 	// TODO(gabe) as we are fully custodying ION DIDs this is fine; as we move to a more decentralized model we will
 	//  need to either remove local storage or treat it as a cache with a TTL
 
 	// first check if the DID is in the storage
 	gotDID := new(ionStoredDID)
-	err := h.storage.GetDID(ctx, id, gotDID)
+	err = h.storage.GetDID(ctx, id, gotDID)
 	if err == nil {
+		h.refreshPublished(ctx, gotDID)
 		return &GetDIDResponse{DID: gotDID.DID}, nil
 	}
 	logrus.WithError(err).Warnf("error getting DID from storage: %s", id)
 
 	// if not, resolve it from the network
-	resolved, err := h.resolver.Resolve(ctx, id, nil)
+	resolved, err := h.resolver.Resolve(ctx, request.ID, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "resolving DID from network")
 	}
@@ -316,3 +358,415 @@ func (h *ionHandler) SoftDeleteDID(ctx context.Context, request DeleteDIDRequest
 
 	return h.storage.StoreDID(ctx, *gotDID)
 }
+
+// RestoreDID unmarks a soft-deleted DID as deleted. A DID that was deactivated stays deactivated.
+func (h *ionHandler) RestoreDID(ctx context.Context, request RestoreDIDRequest) (*RestoreDIDResponse, error) {
+	logrus.Debugf("restoring DID: %+v", request)
+
+	id := request.ID
+	gotDID := new(ionStoredDID)
+	if err := h.storage.GetDID(ctx, id, gotDID); err != nil {
+		return nil, errors.Wrapf(err, "getting DID: %s", id)
+	}
+	if !gotDID.SoftDeleted {
+		return nil, fmt.Errorf("did<%s> has not been deleted", id)
+	}
+
+	gotDID.SoftDeleted = false
+
+	if err := h.storage.StoreDID(ctx, *gotDID); err != nil {
+		return nil, errors.Wrapf(err, "storing DID: %s", id)
+	}
+	return &RestoreDIDResponse{DID: gotDID.DID}, nil
+}
+
+// refreshPublished checks whether the create operation of a stored DID that isn't published yet has been anchored,
+// and if so, stores the document the network now resolves for its short form. The DID stays as it is when the
+// network can't tell yet.
+func (h *ionHandler) refreshPublished(ctx context.Context, storedDID *ionStoredDID) {
+	if storedDID.Published {
+		return
+	}
+	resolved, err := h.resolver.Resolve(ctx, storedDID.ID, nil)
+	if err != nil {
+		logrus.WithError(err).Debugf("DID<%s> is not anchored yet", storedDID.ID)
+		return
+	}
+	if !isPublished(resolved) {
+		return
+	}
+	storedDID.DID = resolved.Document
+	storedDID.Published = true
+	if err = h.storage.StoreDID(ctx, *storedDID); err != nil {
+		logrus.WithError(err).Warnf("could not store published DID<%s>", storedDID.ID)
+	}
+}
+
+// getOperableDID returns the stored DID that an update, recover, or deactivate operation is for. Operations can only
+// follow the anchored create operation of a DID, and none can follow its deactivation.
+func (h *ionHandler) getOperableDID(ctx context.Context, id string) (*ionStoredDID, error) {
+	id, err := shortFormDID(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting short form of DID")
+	}
+	gotDID := new(ionStoredDID)
+	if err = h.storage.GetDID(ctx, id, gotDID); err != nil {
+		return nil, errors.Wrapf(err, "getting DID: %s", id)
+	}
+	if gotDID.SoftDeleted {
+		return nil, fmt.Errorf("did<%s> has been deleted", id)
+	}
+	if gotDID.Deactivated {
+		return nil, fmt.Errorf("did<%s> has been deactivated", id)
+	}
+	h.refreshPublished(ctx, gotDID)
+	if !gotDID.Published {
+		return nil, fmt.Errorf("did<%s> has not been anchored yet", id)
+	}
+	return gotDID, nil
+}
+
+// getOperationKey returns the update or recovery key of a DID, by its suffix. A pending key that wasn't revoked was
+// committed to by an anchored operation that failed to activate it, so it's activated, and returned, instead.
+func (h *ionHandler) getOperationKey(ctx context.Context, id, suffix string) (*jwx.PrivateKeyJWK, error) {
+	pendingID := id + "#" + suffix + pendingKeySuffix
+	pending, err := h.keyStore.KeyExists(ctx, pendingID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking for pending %s key", suffix)
+	}
+	if pending {
+		gotKey, err := h.keyStore.GetKey(ctx, keystore.GetKeyRequest{ID: pendingID})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting pending %s key", suffix)
+		}
+		if !gotKey.Revoked {
+			_, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(uuid.NewString(), gotKey.Key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "converting pending %s key to JWK", suffix)
+			}
+			if err = h.activateOperationKey(ctx, id, suffix, *privateKeyJWK); err != nil {
+				return nil, err
+			}
+			return privateKeyJWK, nil
+		}
+	}
+
+	gotKey, err := h.keyStore.GetKey(ctx, keystore.GetKeyRequest{ID: id + "#" + suffix})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s key", suffix)
+	}
+	_, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(uuid.NewString(), gotKey.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting %s key to JWK", suffix)
+	}
+	return privateKeyJWK, nil
+}
+
+// newOperationKey generates the next update or recovery key of a DID, which Sidetree requires to be secp256k1.
+func newOperationKey() (*jwx.PrivateKeyJWK, error) {
+	_, privateKey, err := crypto.GenerateSECP256k1Key()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating key")
+	}
+	_, privateKeyJWK, err := jwx.PrivateKeyToPrivateKeyJWK(uuid.NewString(), privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting key to JWK")
+	}
+	return privateKeyJWK, nil
+}
+
+// storeOperationKey replaces the update or recovery key of a DID, by its suffix.
+func (h *ionHandler) storeOperationKey(ctx context.Context, id, suffix string, key jwx.PrivateKeyJWK) error {
+	storeRequest, err := keyToStoreRequest(id+"#"+suffix, key, id)
+	if err != nil {
+		return errors.Wrapf(err, "converting %s key to store request", suffix)
+	}
+	if err = h.keyStore.StoreKey(ctx, *storeRequest); err != nil {
+		return errors.Wrapf(err, "storing %s key", suffix)
+	}
+	return nil
+}
+
+// storePendingOperationKey stores the next update or recovery key of a DID as pending, before the operation that
+// commits to it is anchored, so that the key is never lost once the ION network expects it.
+func (h *ionHandler) storePendingOperationKey(ctx context.Context, id, suffix string, key jwx.PrivateKeyJWK) error {
+	return h.storeOperationKey(ctx, id, suffix+pendingKeySuffix, key)
+}
+
+// activateOperationKey replaces the update or recovery key of a DID with its pending key, once the operation that
+// commits to it was anchored. The pending key is revoked, rather than deleted, so that it's kept.
+func (h *ionHandler) activateOperationKey(ctx context.Context, id, suffix string, key jwx.PrivateKeyJWK) error {
+	if err := h.storeOperationKey(ctx, id, suffix, key); err != nil {
+		return errors.Wrapf(err, "activating pending %s key", suffix)
+	}
+	if err := h.keyStore.RevokeKey(ctx, keystore.RevokeKeyRequest{ID: id + "#" + suffix + pendingKeySuffix}); err != nil {
+		return errors.Wrapf(err, "revoking pending %s key", suffix)
+	}
+	return nil
+}
+
+// discardPendingOperationKey revokes the pending update or recovery key of a DID when the operation that commits to it
+// wasn't anchored, so that it's not taken for the key of the DID.
+func (h *ionHandler) discardPendingOperationKey(ctx context.Context, id, suffix string) {
+	if err := h.keyStore.RevokeKey(ctx, keystore.RevokeKeyRequest{ID: id + "#" + suffix + pendingKeySuffix}); err != nil {
+		logrus.WithError(err).Errorf("revoking pending %s key of DID<%s>", suffix, id)
+	}
+}
+
+// anchorOperation submits an update, recover, or deactivate operation to the ION node. Unlike for a create, the node
+// answers these without a resolution result.
+func (h *ionHandler) anchorOperation(ctx context.Context, op ion.AnchorOperation) error {
+	opBytes, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling %s operation", op.GetType())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+"/operations", bytes.NewReader(opBytes))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "posting %s operation", op.GetType())
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s operation failed: %s", op.GetType(), string(body))
+	}
+	return nil
+}
+
+// UpdateDID anchors an update of the keys and services of a DID, signed with its update key, which is then replaced
+// by the next one.
+func (h *ionHandler) UpdateDID(ctx context.Context, request UpdateDIDRequest) (*UpdateDIDResponse, error) {
+	storedDID, err := h.getOperableDID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := ion.ION(storedDID.ID).Suffix()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting DID suffix")
+	}
+	updateKey, err := h.getOperationKey(ctx, storedDID.ID, updateKeySuffix)
+	if err != nil {
+		return nil, err
+	}
+	nextUpdateKey, err := newOperationKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating next update key")
+	}
+	signer, err := ion.NewBTCSignerVerifier(*updateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	updateOp, err := ion.NewUpdateRequest(suffix, updateKey.ToPublicKeyJWK(), nextUpdateKey.ToPublicKeyJWK(), *signer, request.StateChange)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating update operation")
+	}
+	doc, err := documentForState(storedDID.ID, applyStateChange(documentState(storedDID.DID), request.StateChange))
+	if err != nil {
+		return nil, errors.Wrap(err, "applying update to document")
+	}
+
+	if err = h.storePendingOperationKey(ctx, storedDID.ID, updateKeySuffix, *nextUpdateKey); err != nil {
+		return nil, err
+	}
+	if err = h.anchorOperation(ctx, updateOp); err != nil {
+		h.discardPendingOperationKey(ctx, storedDID.ID, updateKeySuffix)
+		return nil, errors.Wrap(err, "anchoring update operation")
+	}
+	if err = h.activateOperationKey(ctx, storedDID.ID, updateKeySuffix, *nextUpdateKey); err != nil {
+		return nil, err
+	}
+	storedDID.DID = *doc
+	storedDID.Operations = append(storedDID.Operations, updateOp)
+	if err = h.storage.StoreDID(ctx, *storedDID); err != nil {
+		return nil, errors.Wrap(err, "storing updated ion did document")
+	}
+	return &UpdateDIDResponse{DID: storedDID.DID}, nil
+}
+
+// RecoverDID anchors the replacement of the keys and services of a DID with those of a new document, signed with its
+// recovery key. Both the update and recovery keys are then replaced by new ones, so it also regains control of a DID
+// whose update key was compromised.
+func (h *ionHandler) RecoverDID(ctx context.Context, request RecoverDIDRequest) (*RecoverDIDResponse, error) {
+	if request.Document.IsEmpty() {
+		return nil, errors.New("document cannot be empty")
+	}
+	storedDID, err := h.getOperableDID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := ion.ION(storedDID.ID).Suffix()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting DID suffix")
+	}
+	recoveryKey, err := h.getOperationKey(ctx, storedDID.ID, recoverKeySuffix)
+	if err != nil {
+		return nil, err
+	}
+	nextRecoveryKey, err := newOperationKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating next recovery key")
+	}
+	nextUpdateKey, err := newOperationKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating next update key")
+	}
+	signer, err := ion.NewBTCSignerVerifier(*recoveryKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	recoverOp, err := ion.NewRecoverRequest(suffix, recoveryKey.ToPublicKeyJWK(), nextRecoveryKey.ToPublicKeyJWK(), nextUpdateKey.ToPublicKeyJWK(), request.Document, *signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating recover operation")
+	}
+	doc, err := documentForState(storedDID.ID, request.Document)
+	if err != nil {
+		return nil, errors.Wrap(err, "applying recovery to document")
+	}
+
+	if err = h.storePendingOperationKey(ctx, storedDID.ID, recoverKeySuffix, *nextRecoveryKey); err != nil {
+		return nil, err
+	}
+	if err = h.storePendingOperationKey(ctx, storedDID.ID, updateKeySuffix, *nextUpdateKey); err != nil {
+		h.discardPendingOperationKey(ctx, storedDID.ID, recoverKeySuffix)
+		return nil, err
+	}
+	if err = h.anchorOperation(ctx, recoverOp); err != nil {
+		h.discardPendingOperationKey(ctx, storedDID.ID, recoverKeySuffix)
+		h.discardPendingOperationKey(ctx, storedDID.ID, updateKeySuffix)
+		return nil, errors.Wrap(err, "anchoring recover operation")
+	}
+	if err = h.activateOperationKey(ctx, storedDID.ID, recoverKeySuffix, *nextRecoveryKey); err != nil {
+		return nil, err
+	}
+	if err = h.activateOperationKey(ctx, storedDID.ID, updateKeySuffix, *nextUpdateKey); err != nil {
+		return nil, err
+	}
+	storedDID.DID = *doc
+	storedDID.Operations = append(storedDID.Operations, recoverOp)
+	if err = h.storage.StoreDID(ctx, *storedDID); err != nil {
+		return nil, errors.Wrap(err, "storing recovered ion did document")
+	}
+	return &RecoverDIDResponse{DID: storedDID.DID}, nil
+}
+
+// DeactivateDID anchors the deactivation of a DID, signed with its recovery key. Deactivation is permanent: the DID
+// then resolves to a document without keys or services, and no other operation can follow it.
+func (h *ionHandler) DeactivateDID(ctx context.Context, request DeactivateDIDRequest) (*DeactivateDIDResponse, error) {
+	storedDID, err := h.getOperableDID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := ion.ION(storedDID.ID).Suffix()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting DID suffix")
+	}
+	recoveryKey, err := h.getOperationKey(ctx, storedDID.ID, recoverKeySuffix)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ion.NewBTCSignerVerifier(*recoveryKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating signer")
+	}
+	deactivateOp, err := ion.NewDeactivateRequest(suffix, recoveryKey.ToPublicKeyJWK(), *signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating deactivate operation")
+	}
+
+	if err = h.anchorOperation(ctx, deactivateOp); err != nil {
+		return nil, errors.Wrap(err, "anchoring deactivate operation")
+	}
+	storedDID.DID = did.Document{Context: storedDID.DID.Context, ID: storedDID.ID}
+	storedDID.Deactivated = true
+	storedDID.Operations = append(storedDID.Operations, deactivateOp)
+	if err = h.storage.StoreDID(ctx, *storedDID); err != nil {
+		return nil, errors.Wrap(err, "storing deactivated ion did document")
+	}
+	return &DeactivateDIDResponse{DID: storedDID.DID}, nil
+}
+
+// documentState returns the keys and services of a DID document as the Sidetree document state they were made from.
+func documentState(doc did.Document) ion.Document {
+	purposes := make(map[string][]ion.PublicKeyPurpose)
+	relationships := []struct {
+		purpose ion.PublicKeyPurpose
+		refs    []did.VerificationMethodSet
+	}{
+		{ion.Authentication, doc.Authentication},
+		{ion.AssertionMethod, doc.AssertionMethod},
+		{ion.KeyAgreement, doc.KeyAgreement},
+		{ion.CapabilityInvocation, doc.CapabilityInvocation},
+		{ion.CapabilityDelegation, doc.CapabilityDelegation},
+	}
+	for _, r := range relationships {
+		for _, ref := range r.refs {
+			if id, ok := ref.(string); ok {
+				purposes[fragment(id)] = append(purposes[fragment(id)], r.purpose)
+			}
+		}
+	}
+
+	state := ion.Document{}
+	for _, vm := range doc.VerificationMethod {
+		if vm.PublicKeyJWK == nil {
+			continue
+		}
+		state.PublicKeys = append(state.PublicKeys, ion.PublicKey{
+			ID:           fragment(vm.ID),
+			Type:         string(vm.Type),
+			PublicKeyJWK: *vm.PublicKeyJWK,
+			Purposes:     purposes[fragment(vm.ID)],
+		})
+	}
+	for _, service := range doc.Services {
+		service.ID = fragment(service.ID)
+		state.Services = append(state.Services, service)
+	}
+	return state
+}
+
+// applyStateChange returns the document state that the patches of an update make of state.
+func applyStateChange(state ion.Document, change ion.StateChange) ion.Document {
+	removedKeys := make(map[string]bool, len(change.PublicKeyIDsToRemove))
+	for _, id := range change.PublicKeyIDsToRemove {
+		removedKeys[fragment(id)] = true
+	}
+	removedServices := make(map[string]bool, len(change.ServiceIDsToRemove))
+	for _, id := range change.ServiceIDsToRemove {
+		removedServices[fragment(id)] = true
+	}
+
+	changed := ion.Document{}
+	for _, key := range state.PublicKeys {
+		if !removedKeys[key.ID] {
+			changed.PublicKeys = append(changed.PublicKeys, key)
+		}
+	}
+	changed.PublicKeys = append(changed.PublicKeys, change.PublicKeysToAdd...)
+	for _, service := range state.Services {
+		if !removedServices[service.ID] {
+			changed.Services = append(changed.Services, service)
+		}
+	}
+	changed.Services = append(changed.Services, change.ServicesToAdd...)
+	return changed
+}
+
+// documentForState returns the DID document of id that a Sidetree document state resolves to.
+func documentForState(id string, state ion.Document) (*did.Document, error) {
+	return ion.PatchesToDIDDocument(id, id, []ion.Patch{ion.ReplaceAction{Action: ion.Replace, Document: state}})
+}
+
+// fragment returns the ID of a key or service without the DID or '#' it may be prefixed with.
+func fragment(id string) string {
+	if i := strings.LastIndex(id, "#"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
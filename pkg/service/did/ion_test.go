@@ -11,6 +11,7 @@ import (
 	"github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/ion"
 	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tbd54566975/ssi-service/config"
@@ -252,6 +253,236 @@ func TestIONHandler(t *testing.T) {
 				assert.NoError(tt, err)
 				assert.NotEmpty(tt, gotDIDs)
 				assert.Len(tt, gotDeletedDIDs.DIDs, 1)
+
+				// restore it
+				restored, err := handler.RestoreDID(context.Background(), RestoreDIDRequest{
+					Method: did.IONMethod,
+					ID:     created.DID.ID,
+				})
+				assert.NoError(tt, err)
+				assert.Equal(tt, created.DID.ID, restored.DID.ID)
+
+				gotDIDsAfterRestore, err := handler.ListDIDs(context.Background(), nil)
+				assert.NoError(tt, err)
+				assert.Len(tt, gotDIDsAfterRestore.DIDs, 1)
+
+				_, err = handler.RestoreDID(context.Background(), RestoreDIDRequest{Method: did.IONMethod, ID: created.DID.ID})
+				assert.ErrorContains(tt, err, "has not been deleted")
+			})
+
+			t.Run("Deactivate a DID when deleting it", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				keystoreService := testKeyStoreService(tt, s)
+				didStorage, err := NewDIDStorage(s)
+				assert.NoError(tt, err)
+				handler, err := NewIONHandler("https://test-ion-resolver.com", didStorage, keystoreService)
+				assert.NoError(tt, err)
+				didService := &Service{
+					storage:  didStorage,
+					handlers: map[did.Method]MethodHandler{did.IONMethod: handler},
+					keyStore: keystoreService,
+				}
+				defer gock.Off()
+
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Reply(200).
+					BodyString(string(BasicDIDResolution))
+				created, err := handler.CreateDID(context.Background(), CreateDIDRequest{
+					Method:  did.IONMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(tt, err)
+				id := created.DID.ID
+
+				createdDIDData, err := json.Marshal(created.DID)
+				require.NoError(tt, err)
+				gock.New("https://test-ion-resolver.com").
+					Get("/identifiers/" + id).
+					Reply(200).
+					BodyString(fmt.Sprintf(`{"didDocument": %s, "didDocumentMetadata": {"method": {"published": true}}}`, createdDIDData))
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Reply(200)
+
+				err = didService.SoftDeleteDIDByMethod(context.Background(), DeleteDIDRequest{Method: did.IONMethod, ID: id, Deactivate: true})
+				require.NoError(tt, err)
+				assert.True(tt, gock.IsDone())
+
+				deleted, err := handler.ListDeletedDIDs(context.Background())
+				require.NoError(tt, err)
+				assert.Len(tt, deleted.DIDs, 1)
+
+				// the restored DID stays deactivated
+				_, err = didService.RestoreDIDByMethod(context.Background(), RestoreDIDRequest{Method: did.IONMethod, ID: id})
+				require.NoError(tt, err)
+				_, err = handler.(UpdatableMethodHandler).UpdateDID(context.Background(), UpdateDIDRequest{Method: did.IONMethod, ID: id})
+				assert.ErrorContains(tt, err, "has been deactivated")
+			})
+
+			t.Run("Update, recover, and deactivate a DID", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				keystoreService := testKeyStoreService(tt, s)
+				didStorage, err := NewDIDStorage(s)
+				assert.NoError(tt, err)
+				handler, err := NewIONHandler("https://test-ion-resolver.com", didStorage, keystoreService)
+				assert.NoError(tt, err)
+				ionHandler := handler.(UpdatableMethodHandler)
+				defer gock.Off()
+
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Reply(200).
+					BodyString(string(BasicDIDResolution))
+				created, err := handler.CreateDID(context.Background(), CreateDIDRequest{
+					Method:  did.IONMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(tt, err)
+				id := created.DID.ID
+
+				// nothing can follow a create operation until it's anchored
+				addService := ion.StateChange{
+					ServicesToAdd: []did.Service{{ID: "linked-domain", Type: "LinkedDomains", ServiceEndpoint: "https://example.com"}},
+				}
+				_, err = ionHandler.UpdateDID(context.Background(), UpdateDIDRequest{Method: did.IONMethod, ID: id, StateChange: addService})
+				assert.ErrorContains(tt, err, "has not been anchored yet")
+
+				createdDIDData, err := json.Marshal(created.DID)
+				require.NoError(tt, err)
+				gock.New("https://test-ion-resolver.com").
+					Get("/identifiers/" + id).
+					Reply(200).
+					BodyString(fmt.Sprintf(`{"didDocument": %s, "didDocumentMetadata": {"method": {"published": true}}}`, createdDIDData))
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Times(3).
+					Reply(200)
+
+				updateKey, err := keystoreService.GetKey(context.Background(), keystore.GetKeyRequest{ID: id + "#update"})
+				require.NoError(tt, err)
+				addService.PublicKeyIDsToRemove = []string{"externalPublicKey1"}
+				updated, err := ionHandler.UpdateDID(context.Background(), UpdateDIDRequest{Method: did.IONMethod, ID: id, StateChange: addService})
+				require.NoError(tt, err)
+				assert.Equal(tt, id, updated.DID.ID)
+				require.Len(tt, updated.DID.Services, 1)
+				assert.Equal(tt, "#linked-domain", updated.DID.Services[0].ID)
+				require.Len(tt, updated.DID.VerificationMethod, 1)
+				assert.Equal(tt, created.DID.VerificationMethod[0].ID, updated.DID.VerificationMethod[0].ID)
+				assert.Equal(tt, []did.VerificationMethodSet{created.DID.VerificationMethod[0].ID}, updated.DID.AssertionMethod)
+				nextUpdateKey, err := keystoreService.GetKey(context.Background(), keystore.GetKeyRequest{ID: id + "#update"})
+				require.NoError(tt, err)
+				assert.NotEqual(tt, updateKey.Key, nextUpdateKey.Key)
+
+				// the next update key was stored as pending before it was anchored, and is kept once it's active
+				pendingUpdateKey, err := keystoreService.GetKey(context.Background(), keystore.GetKeyRequest{ID: id + "#update-pending"})
+				require.NoError(tt, err)
+				assert.Equal(tt, nextUpdateKey.Key, pendingUpdateKey.Key)
+				assert.True(tt, pendingUpdateKey.Revoked)
+
+				gotDID, err := handler.GetDID(context.Background(), GetDIDRequest{Method: did.IONMethod, ID: id})
+				require.NoError(tt, err)
+				assert.Equal(tt, updated.DID, gotDID.DID)
+
+				recoveryKey, err := keystoreService.GetKey(context.Background(), keystore.GetKeyRequest{ID: id + "#recover"})
+				require.NoError(tt, err)
+				publicKey, _, err := crypto.GenerateEd25519Key()
+				require.NoError(tt, err)
+				publicKeyJWK, err := jwx.PublicKeyToPublicKeyJWK("recovered", publicKey)
+				require.NoError(tt, err)
+				recovered, err := ionHandler.RecoverDID(context.Background(), RecoverDIDRequest{
+					Method: did.IONMethod,
+					ID:     id,
+					Document: ion.Document{PublicKeys: []ion.PublicKey{{
+						ID:           "recovered",
+						Type:         "JsonWebKey2020",
+						PublicKeyJWK: *publicKeyJWK,
+						Purposes:     []ion.PublicKeyPurpose{ion.Authentication},
+					}}},
+				})
+				require.NoError(tt, err)
+				require.Len(tt, recovered.DID.VerificationMethod, 1)
+				assert.Equal(tt, "#recovered", recovered.DID.VerificationMethod[0].ID)
+				assert.Empty(tt, recovered.DID.Services)
+				nextRecoveryKey, err := keystoreService.GetKey(context.Background(), keystore.GetKeyRequest{ID: id + "#recover"})
+				require.NoError(tt, err)
+				assert.NotEqual(tt, recoveryKey.Key, nextRecoveryKey.Key)
+
+				deactivated, err := ionHandler.DeactivateDID(context.Background(), DeactivateDIDRequest{Method: did.IONMethod, ID: id})
+				require.NoError(tt, err)
+				assert.Equal(tt, id, deactivated.DID.ID)
+				assert.Empty(tt, deactivated.DID.VerificationMethod)
+				assert.True(tt, gock.IsDone())
+
+				_, err = ionHandler.UpdateDID(context.Background(), UpdateDIDRequest{Method: did.IONMethod, ID: id, StateChange: addService})
+				assert.ErrorContains(tt, err, "has been deactivated")
+			})
+
+			t.Run("Rotate a key of a DID", func(tt *testing.T) {
+				s := test.ServiceStorage(tt)
+				keystoreService := testKeyStoreService(tt, s)
+				didStorage, err := NewDIDStorage(s)
+				assert.NoError(tt, err)
+				handler, err := NewIONHandler("https://test-ion-resolver.com", didStorage, keystoreService)
+				assert.NoError(tt, err)
+				didService := &Service{
+					storage:  didStorage,
+					handlers: map[did.Method]MethodHandler{did.IONMethod: handler},
+					keyStore: keystoreService,
+				}
+				keystoreService.SetRotationPublisher(didService)
+				defer gock.Off()
+
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Reply(200).
+					BodyString(string(BasicDIDResolution))
+				created, err := handler.CreateDID(context.Background(), CreateDIDRequest{
+					Method:  did.IONMethod,
+					KeyType: crypto.Ed25519,
+				})
+				require.NoError(tt, err)
+				id := created.DID.ID
+				createdDIDData, err := json.Marshal(created.DID)
+				require.NoError(tt, err)
+				gock.New("https://test-ion-resolver.com").
+					Get("/identifiers/" + id).
+					Reply(200).
+					BodyString(fmt.Sprintf(`{"didDocument": %s, "didDocumentMetadata": {"method": {"published": true}}}`, createdDIDData))
+				gock.New("https://test-ion-resolver.com").
+					Post("/operations").
+					Reply(200)
+
+				keyID := did.FullyQualifiedVerificationMethodID(id, created.DID.VerificationMethod[0].ID)
+				rotated, err := keystoreService.RotateKey(context.Background(), keystore.RotateKeyRequest{ID: keyID})
+				require.NoError(tt, err)
+				assert.True(tt, rotated.DIDUpdated)
+				assert.True(tt, gock.IsDone())
+
+				// the next version is published next to the key, with the same relationships
+				gotDID, err := handler.GetDID(context.Background(), GetDIDRequest{Method: did.IONMethod, ID: id})
+				require.NoError(tt, err)
+				require.Len(tt, gotDID.DID.VerificationMethod, len(created.DID.VerificationMethod)+1)
+				assert.Equal(tt, created.DID.VerificationMethod[0].ID, gotDID.DID.VerificationMethod[0].ID)
+				next := gotDID.DID.VerificationMethod[len(gotDID.DID.VerificationMethod)-1]
+				assert.Equal(tt, rotated.Key.ID, did.FullyQualifiedVerificationMethodID(id, next.ID))
+				assert.Equal(tt, rotated.Key.PublicKeyJWK.X, next.PublicKeyJWK.X)
+				assert.Contains(tt, gotDID.DID.AssertionMethod, did.VerificationMethodSet(next.ID))
+				assert.Contains(tt, gotDID.DID.Authentication, did.VerificationMethodSet(next.ID))
+
+				// keys that aren't in the document are rotated without updating it
+				_, privateKey, err := crypto.GenerateEd25519Key()
+				require.NoError(tt, err)
+				err = keystoreService.StoreKey(context.Background(), keystore.StoreKeyRequest{
+					ID:               id + "#unpublished",
+					Type:             crypto.Ed25519,
+					Controller:       id,
+					PrivateKeyBase58: base58.Encode(privateKey),
+				})
+				require.NoError(tt, err)
+				rotated, err = keystoreService.RotateKey(context.Background(), keystore.RotateKeyRequest{ID: id + "#unpublished"})
+				require.NoError(tt, err)
+				assert.False(tt, rotated.DIDUpdated)
 			})
 
 			t.Run("Get DID from resolver", func(tt *testing.T) {
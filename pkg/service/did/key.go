@@ -147,3 +147,23 @@ func (h *keyHandler) SoftDeleteDID(ctx context.Context, request DeleteDIDRequest
 
 	return h.storage.StoreDID(ctx, *gotStoredDID)
 }
+
+func (h *keyHandler) RestoreDID(ctx context.Context, request RestoreDIDRequest) (*RestoreDIDResponse, error) {
+	logrus.Debugf("restoring DID: %+v", request)
+
+	id := request.ID
+	gotStoredDID, err := h.storage.GetDIDDefault(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting DID: %s", id)
+	}
+	if !gotStoredDID.SoftDeleted {
+		return nil, fmt.Errorf("did<%s> has not been deleted", id)
+	}
+
+	gotStoredDID.SoftDeleted = false
+
+	if err = h.storage.StoreDID(ctx, *gotStoredDID); err != nil {
+		return nil, errors.Wrapf(err, "storing DID: %s", id)
+	}
+	return &RestoreDIDResponse{DID: gotStoredDID.GetDocument()}, nil
+}
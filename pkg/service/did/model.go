@@ -5,6 +5,7 @@ import (
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/ion"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
 	"github.com/tbd54566975/ssi-service/pkg/service/common"
 )
@@ -78,9 +79,60 @@ type ListDIDsRequest struct {
 type ListDIDsResponse struct {
 	DIDs          []didsdk.Document `json:"dids"`
 	NextPageToken string
+
+	// TotalCount is the number of DIDs across all pages.
+	TotalCount int
 }
 
 type DeleteDIDRequest struct {
 	Method didsdk.Method `json:"method" validate:"required"`
 	ID     string        `json:"id" validate:"required"`
+
+	// Deactivate publishes the deactivation of the DID too, when its method supports it. Deactivation is permanent, so
+	// the DID stays deactivated when it's restored.
+	Deactivate bool `json:"deactivate,omitempty"`
+}
+
+type RestoreDIDRequest struct {
+	Method didsdk.Method `json:"method" validate:"required"`
+	ID     string        `json:"id" validate:"required"`
+}
+
+// RestoreDIDResponse is the JSON-serializable response for restoring a soft-deleted DID
+type RestoreDIDResponse struct {
+	DID didsdk.Document `json:"did"`
+}
+
+// UpdateDIDRequest adds and removes the keys and services of a DID as StateChange describes.
+type UpdateDIDRequest struct {
+	Method      didsdk.Method   `json:"method" validate:"required"`
+	ID          string          `json:"id" validate:"required"`
+	StateChange ion.StateChange `json:"stateChange"`
+}
+
+// UpdateDIDResponse is the JSON-serializable response for updating a DID
+type UpdateDIDResponse struct {
+	DID didsdk.Document `json:"did"`
+}
+
+// RecoverDIDRequest replaces the keys and services of a DID with those of Document.
+type RecoverDIDRequest struct {
+	Method   didsdk.Method `json:"method" validate:"required"`
+	ID       string        `json:"id" validate:"required"`
+	Document ion.Document  `json:"document"`
+}
+
+// RecoverDIDResponse is the JSON-serializable response for recovering a DID
+type RecoverDIDResponse struct {
+	DID didsdk.Document `json:"did"`
+}
+
+type DeactivateDIDRequest struct {
+	Method didsdk.Method `json:"method" validate:"required"`
+	ID     string        `json:"id" validate:"required"`
+}
+
+// DeactivateDIDResponse is the JSON-serializable response for deactivating a DID
+type DeactivateDIDResponse struct {
+	DID didsdk.Document `json:"did"`
 }
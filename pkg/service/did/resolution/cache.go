@@ -0,0 +1,348 @@
+package resolution
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	goredislib "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+)
+
+const (
+	MemoryCacheBackend = "memory"
+	RedisCacheBackend  = "redis"
+
+	// DefaultCacheTTL is how long results are cached when no TTL is configured.
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultCacheMaxEntries is how many results the memory backend keeps when no maximum is configured.
+	DefaultCacheMaxEntries = 10000
+
+	redisCacheKeyPrefix = "didresolution:"
+)
+
+// ResultCache keeps resolution results by DID until they expire.
+type ResultCache interface {
+	// Get returns the cached result of the DID, or nil when there is none.
+	Get(ctx context.Context, did string) (*resolution.Result, error)
+	Set(ctx context.Context, did string, result resolution.Result) error
+	// Delete removes the result of the DID, returning whether one was cached.
+	Delete(ctx context.Context, did string) (bool, error)
+	// Purge removes every result, returning how many were cached.
+	Purge(ctx context.Context) (int, error)
+	// Len returns how many results are cached.
+	Len(ctx context.Context) (int, error)
+	// Evictions returns how many results were evicted to make room for others.
+	Evictions() int64
+}
+
+// CacheStats describes how well the resolution cache serves resolutions.
+type CacheStats struct {
+	Backend string `json:"backend"`
+
+	// How many results are cached.
+	Entries int `json:"entries"`
+
+	// How many resolutions were served from the cache, and how many weren't, since the service started.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+
+	// How many results were evicted to make room for others since the service started. Always 0 with Redis, which
+	// evicts on its own.
+	Evictions int64 `json:"evictions"`
+}
+
+// CachingResolver is a resolver that caches the results of another resolver by DID. Only successful resolutions are
+// cached, and errors of the cache are logged rather than failing resolution.
+type CachingResolver struct {
+	resolver resolution.Resolver
+	cache    ResultCache
+	backend  string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+var _ resolution.Resolver = (*CachingResolver)(nil)
+
+// NewCachingResolver creates a CachingResolver of resolver with the configured backend.
+func NewCachingResolver(resolver resolution.Resolver, cfg config.DIDResolutionCacheConfig) (*CachingResolver, error) {
+	if resolver == nil {
+		return nil, errors.New("resolver cannot be nil")
+	}
+	if cfg.TTL < 0 || cfg.MaxEntries < 0 {
+		return nil, errors.New("resolution cache ttl and max_entries cannot be negative")
+	}
+	ttl := cfg.TTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	var cache ResultCache
+	backend := cfg.Backend
+	switch backend {
+	case "", MemoryCacheBackend:
+		backend = MemoryCacheBackend
+		maxEntries := cfg.MaxEntries
+		if maxEntries == 0 {
+			maxEntries = DefaultCacheMaxEntries
+		}
+		cache = NewMemoryResultCache(ttl, maxEntries, clock.New())
+	case RedisCacheBackend:
+		if cfg.RedisAddress == "" {
+			return nil, errors.New("redis resolution cache backend requires a redis address")
+		}
+		client := goredislib.NewClient(&goredislib.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+		})
+		cache = NewRedisResultCache(client, ttl)
+	default:
+		return nil, errors.Errorf("unsupported resolution cache backend: %s", cfg.Backend)
+	}
+	return &CachingResolver{resolver: resolver, cache: cache, backend: backend}, nil
+}
+
+func (cr *CachingResolver) Resolve(ctx context.Context, did string, opts ...resolution.Option) (*resolution.Result, error) {
+	cached, err := cr.cache.Get(ctx, did)
+	if err != nil {
+		logrus.WithError(err).Warn("getting cached resolution result")
+	}
+	if cached != nil {
+		cr.hits.Add(1)
+		metrics.DIDResolutionCacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+		return cached, nil
+	}
+	cr.misses.Add(1)
+	metrics.DIDResolutionCacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+
+	resolved, err := cr.resolver.Resolve(ctx, did, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = cr.cache.Set(ctx, did, *resolved); err != nil {
+		logrus.WithError(err).Warn("caching resolution result")
+	}
+	return resolved, nil
+}
+
+func (cr *CachingResolver) Methods() []didsdk.Method {
+	return cr.resolver.Methods()
+}
+
+// Invalidate removes the cached result of the DID, so that it's resolved again, returning whether one was cached.
+func (cr *CachingResolver) Invalidate(ctx context.Context, did string) (bool, error) {
+	return cr.cache.Delete(ctx, did)
+}
+
+// Purge removes every cached result, returning how many were cached.
+func (cr *CachingResolver) Purge(ctx context.Context) (int, error) {
+	return cr.cache.Purge(ctx)
+}
+
+func (cr *CachingResolver) Stats(ctx context.Context) (*CacheStats, error) {
+	entries, err := cr.cache.Len(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting cached results")
+	}
+	return &CacheStats{
+		Backend:   cr.backend,
+		Entries:   entries,
+		Hits:      cr.hits.Load(),
+		Misses:    cr.misses.Load(),
+		Evictions: cr.cache.Evictions(),
+	}, nil
+}
+
+type cacheEntry struct {
+	did       string
+	result    resolution.Result
+	expiresAt time.Time
+}
+
+// MemoryResultCache keeps results in memory, evicting the least recently used ones when it's full. Results are only
+// cached per instance of the service.
+type MemoryResultCache struct {
+	mu         sync.Mutex
+	clock      clock.Clock
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	// most recently used first
+	order     *list.List
+	evictions int64
+}
+
+func NewMemoryResultCache(ttl time.Duration, maxEntries int, clock clock.Clock) *MemoryResultCache {
+	return &MemoryResultCache{
+		clock:      clock,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (m *MemoryResultCache) Get(_ context.Context, did string) (*resolution.Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[did]
+	if !ok {
+		return nil, nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if !m.clock.Now().Before(entry.expiresAt) {
+		m.remove(element)
+		return nil, nil
+	}
+	m.order.MoveToFront(element)
+	result := entry.result
+	return &result, nil
+}
+
+func (m *MemoryResultCache) Set(_ context.Context, did string, result resolution.Result) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiresAt := m.clock.Now().Add(m.ttl)
+	if element, ok := m.entries[did]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[did] = m.order.PushFront(&cacheEntry{did: did, result: result, expiresAt: expiresAt})
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+		m.evictions++
+	}
+	return nil
+}
+
+func (m *MemoryResultCache) Delete(_ context.Context, did string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[did]
+	if ok {
+		m.remove(element)
+	}
+	return ok, nil
+}
+
+func (m *MemoryResultCache) Purge(_ context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	purged := m.order.Len()
+	m.entries = make(map[string]*list.Element)
+	m.order.Init()
+	return purged, nil
+}
+
+func (m *MemoryResultCache) Len(_ context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len(), nil
+}
+
+func (m *MemoryResultCache) Evictions() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.evictions
+}
+
+func (m *MemoryResultCache) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*cacheEntry).did)
+}
+
+var _ ResultCache = (*MemoryResultCache)(nil)
+
+// RedisResultCache keeps results in Redis, so that they're shared by every instance of the service. Results expire
+// with Redis' own TTLs.
+type RedisResultCache struct {
+	client *goredislib.Client
+	ttl    time.Duration
+}
+
+func NewRedisResultCache(client *goredislib.Client, ttl time.Duration) *RedisResultCache {
+	return &RedisResultCache{client: client, ttl: ttl}
+}
+
+func (r *RedisResultCache) Get(ctx context.Context, did string) (*resolution.Result, error) {
+	resultBytes, err := r.client.Get(ctx, redisCacheKeyPrefix+did).Bytes()
+	if errors.Is(err, goredislib.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting cached result")
+	}
+	var result resolution.Result
+	if err = json.Unmarshal(resultBytes, &result); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling cached result")
+	}
+	return &result, nil
+}
+
+func (r *RedisResultCache) Set(ctx context.Context, did string, result resolution.Result) error {
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "marshalling result")
+	}
+	return errors.Wrap(r.client.Set(ctx, redisCacheKeyPrefix+did, resultBytes, r.ttl).Err(), "caching result")
+}
+
+func (r *RedisResultCache) Delete(ctx context.Context, did string) (bool, error) {
+	deleted, err := r.client.Del(ctx, redisCacheKeyPrefix+did).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "deleting cached result")
+	}
+	return deleted > 0, nil
+}
+
+func (r *RedisResultCache) Purge(ctx context.Context) (int, error) {
+	keys, err := r.keys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	deleted, err := r.client.Del(ctx, keys...).Result()
+	if err != nil {
+		return 0, errors.Wrap(err, "deleting cached results")
+	}
+	return int(deleted), nil
+}
+
+func (r *RedisResultCache) Len(ctx context.Context) (int, error) {
+	keys, err := r.keys(ctx)
+	return len(keys), err
+}
+
+func (r *RedisResultCache) Evictions() int64 {
+	return 0
+}
+
+func (r *RedisResultCache) keys(ctx context.Context) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, redisCacheKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "scanning cached results")
+	}
+	return keys, nil
+}
+
+var _ ResultCache = (*RedisResultCache)(nil)
@@ -0,0 +1,150 @@
+package resolution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	goredislib "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+)
+
+type countingResolver struct {
+	resolutions map[string]int
+}
+
+func (r *countingResolver) Resolve(_ context.Context, did string, _ ...resolution.Option) (*resolution.Result, error) {
+	r.resolutions[did]++
+	if did == "did:example:unknown" {
+		return nil, errors.New("not found")
+	}
+	return &resolution.Result{Document: didsdk.Document{ID: did}}, nil
+}
+
+func (r *countingResolver) Methods() []didsdk.Method {
+	return []didsdk.Method{"example"}
+}
+
+func TestResultCaches(t *testing.T) {
+	ctx := context.Background()
+	mockClock := clock.NewMock()
+	server := miniredis.RunT(t)
+	caches := map[string]ResultCache{
+		MemoryCacheBackend: NewMemoryResultCache(time.Minute, 2, mockClock),
+		RedisCacheBackend:  NewRedisResultCache(goredislib.NewClient(&goredislib.Options{Addr: server.Addr()}), time.Minute),
+	}
+	expire := func(backend string) {
+		if backend == RedisCacheBackend {
+			server.FastForward(time.Minute)
+			return
+		}
+		mockClock.Add(time.Minute)
+	}
+
+	for backend, cache := range caches {
+		t.Run(backend, func(tt *testing.T) {
+			got, err := cache.Get(ctx, "did:example:a")
+			require.NoError(tt, err)
+			assert.Nil(tt, got)
+
+			require.NoError(tt, cache.Set(ctx, "did:example:a", resolution.Result{Document: didsdk.Document{ID: "did:example:a"}}))
+			got, err = cache.Get(ctx, "did:example:a")
+			require.NoError(tt, err)
+			require.NotNil(tt, got)
+			assert.Equal(tt, "did:example:a", got.Document.ID)
+
+			// results expire after the TTL
+			expire(backend)
+			got, err = cache.Get(ctx, "did:example:a")
+			require.NoError(tt, err)
+			assert.Nil(tt, got)
+
+			require.NoError(tt, cache.Set(ctx, "did:example:a", resolution.Result{}))
+			require.NoError(tt, cache.Set(ctx, "did:example:b", resolution.Result{}))
+			deleted, err := cache.Delete(ctx, "did:example:a")
+			require.NoError(tt, err)
+			assert.True(tt, deleted)
+			deleted, err = cache.Delete(ctx, "did:example:a")
+			require.NoError(tt, err)
+			assert.False(tt, deleted)
+			entries, err := cache.Len(ctx)
+			require.NoError(tt, err)
+			assert.Equal(tt, 1, entries)
+			purged, err := cache.Purge(ctx)
+			require.NoError(tt, err)
+			assert.Equal(tt, 1, purged)
+			entries, err = cache.Len(ctx)
+			require.NoError(tt, err)
+			assert.Zero(tt, entries)
+		})
+	}
+
+	t.Run("memory evicts the least recently used results", func(tt *testing.T) {
+		cache := NewMemoryResultCache(time.Minute, 2, clock.NewMock())
+		require.NoError(tt, cache.Set(ctx, "did:example:a", resolution.Result{}))
+		require.NoError(tt, cache.Set(ctx, "did:example:b", resolution.Result{}))
+		got, err := cache.Get(ctx, "did:example:a")
+		require.NoError(tt, err)
+		assert.NotNil(tt, got)
+		require.NoError(tt, cache.Set(ctx, "did:example:c", resolution.Result{}))
+
+		got, err = cache.Get(ctx, "did:example:b")
+		require.NoError(tt, err)
+		assert.Nil(tt, got)
+		got, err = cache.Get(ctx, "did:example:a")
+		require.NoError(tt, err)
+		assert.NotNil(tt, got)
+		assert.EqualValues(tt, 1, cache.Evictions())
+	})
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	resolver := &countingResolver{resolutions: make(map[string]int)}
+	cachingResolver, err := NewCachingResolver(resolver, config.DIDResolutionCacheConfig{Enabled: true})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		resolved, err := cachingResolver.Resolve(ctx, "did:example:a")
+		require.NoError(t, err)
+		assert.Equal(t, "did:example:a", resolved.Document.ID)
+	}
+	assert.Equal(t, 1, resolver.resolutions["did:example:a"])
+
+	// failed resolutions aren't cached
+	for i := 0; i < 2; i++ {
+		_, err = cachingResolver.Resolve(ctx, "did:example:unknown")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, resolver.resolutions["did:example:unknown"])
+
+	invalidated, err := cachingResolver.Invalidate(ctx, "did:example:a")
+	require.NoError(t, err)
+	assert.True(t, invalidated)
+	_, err = cachingResolver.Resolve(ctx, "did:example:a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolver.resolutions["did:example:a"])
+
+	stats, err := cachingResolver.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Backend: MemoryCacheBackend, Entries: 1, Hits: 2, Misses: 4}, *stats)
+
+	t.Run("validates the config", func(tt *testing.T) {
+		for _, cfg := range []config.DIDResolutionCacheConfig{
+			{Backend: "memcached"},
+			{Backend: RedisCacheBackend},
+			{TTL: -time.Minute},
+		} {
+			_, err := NewCachingResolver(resolver, cfg)
+			assert.Error(tt, err)
+		}
+	})
+}
@@ -3,13 +3,17 @@ package resolution
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
 	didint "github.com/tbd54566975/ssi-service/internal/did"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	utilint "github.com/tbd54566975/ssi-service/internal/util"
 )
 
@@ -18,14 +22,19 @@ type ServiceResolver struct {
 	resolutionMethods []string
 	hr                resolution.Resolver
 	lr                resolution.Resolver
-	ur                *universalResolver
+
+	// the universal resolver, which UpdateUniversalResolver replaces while DIDs are resolved
+	mu sync.RWMutex
+	ur *universalResolver
 }
 
 var _ resolution.Resolver = (*ServiceResolver)(nil)
 
 // NewServiceResolver creates a new ServiceResolver instance which can resolve DIDs using a combination of local and
-// universal resolvers.
-func NewServiceResolver(handlerResolver resolution.Resolver, localResolutionMethods []string, universalResolverURL string) (*ServiceResolver, error) {
+// universal resolvers. Only DIDs of universalResolverMethods are resolved with the universal resolver, or of every
+// method when it's empty, and they're cached for universalResolverCacheTTL, or DefaultUniversalResolverCacheTTL when
+// it's zero. A negative TTL disables caching.
+func NewServiceResolver(handlerResolver resolution.Resolver, localResolutionMethods []string, universalResolverURL string, universalResolverMethods []string, universalResolverCacheTTL time.Duration) (*ServiceResolver, error) {
 	var lr resolution.Resolver
 	var err error
 	if len(localResolutionMethods) > 0 {
@@ -36,38 +45,58 @@ func NewServiceResolver(handlerResolver resolution.Resolver, localResolutionMeth
 		}
 	}
 
-	// instantiate universal resolver
-	var ur *universalResolver
-	if universalResolverURL != "" {
-		ur, err = newUniversalResolver(universalResolverURL)
-		if err != nil {
-			return nil, errors.Wrap(err, "instantiating universal resolver")
-		}
-	}
-
-	return &ServiceResolver{
+	sr := ServiceResolver{
 		resolutionMethods: localResolutionMethods,
 		hr:                handlerResolver,
 		lr:                lr,
-		ur:                ur,
-	}, nil
+	}
+	if err = sr.UpdateUniversalResolver(universalResolverURL, universalResolverMethods, universalResolverCacheTTL); err != nil {
+		return nil, err
+	}
+	return &sr, nil
+}
+
+// UpdateUniversalResolver replaces the universal resolver with the one at url, resolving DIDs of methods and caching
+// them for cacheTTL, as NewServiceResolver does, so that it can change while the service runs. DIDs aren't resolved
+// with a universal resolver once url is empty.
+func (sr *ServiceResolver) UpdateUniversalResolver(url string, methods []string, cacheTTL time.Duration) error {
+	var ur *universalResolver
+	if url != "" {
+		if cacheTTL == 0 {
+			cacheTTL = DefaultUniversalResolverCacheTTL
+		}
+		var err error
+		if ur, err = newUniversalResolver(url, methods, cacheTTL); err != nil {
+			return errors.Wrap(err, "instantiating universal resolver")
+		}
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.ur = ur
+	return nil
 }
 
 // Resolve resolves a DID using a combination of local and universal resolvers. The ordering is as follows:
 // 1. Try to resolve with the handlers we have, wrapping the resulting DID in resolution result
 // 2. Try to resolve with the local resolver
-// 3. Try to resolve with the universal resolver
+// 3. Try to resolve with the universal resolver, when it resolves DIDs of the method
 // TODO(gabe) avoid caching DIDs that should be externally resolved https://github.com/TBD54566975/ssi-service/issues/361
-func (sr *ServiceResolver) Resolve(ctx context.Context, did string, opts ...resolution.Option) (*resolution.Result, error) {
+func (sr *ServiceResolver) Resolve(ctx context.Context, did string, opts ...resolution.Option) (_ *resolution.Result, err error) {
+	ctx, span := tracing.Start(ctx, "did.Resolve")
+	defer func() { tracing.End(span, err) }()
+
 	// check the did is valid
-	if _, err := utilint.GetMethodForDID(did); err != nil {
+	method, err := utilint.GetMethodForDID(did)
+	if err != nil {
 		return nil, errors.Wrap(err, "getting method DID")
 	}
+	span.SetAttributes(attribute.String("did.method", string(method)))
 
 	// first, try to resolve with the handlers we have
 	if sr.hr != nil {
 		handlersResolvedDID, err := sr.hr.Resolve(ctx, did, opts...)
 		if err == nil {
+			span.SetAttributes(attribute.String("did.resolver", "handler"))
 			return handlersResolvedDID, nil
 		}
 		logrus.WithError(err).Error("error resolving DID with handler resolver")
@@ -77,17 +106,21 @@ func (sr *ServiceResolver) Resolve(ctx context.Context, did string, opts ...reso
 	if sr.lr != nil {
 		locallyResolvedDID, err := sr.lr.Resolve(ctx, did, opts...)
 		if err == nil {
+			span.SetAttributes(attribute.String("did.resolver", "local"))
 			return locallyResolvedDID, nil
 		}
 		logrus.WithError(err).Error("error resolving DID with local resolver")
 	}
 
 	// finally, resolution with the universal resolver
-	if sr.ur != nil {
-		universallyResolvedDID, err := sr.ur.Resolve(ctx, did, opts...)
+	sr.mu.RLock()
+	ur := sr.ur
+	sr.mu.RUnlock()
+	if ur != nil {
+		universallyResolvedDID, err := ur.Resolve(ctx, did, opts...)
 		if err == nil {
+			span.SetAttributes(attribute.String("did.resolver", "universal"))
 			return universallyResolvedDID, nil
-
 		}
 		logrus.WithError(err).Error("error resolving DID with universal resolver")
 	}
@@ -3,15 +3,31 @@ package resolution
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/benbjohnson/clock"
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	utilint "github.com/tbd54566975/ssi-service/internal/util"
+)
+
+const (
+	// DefaultUniversalResolverCacheTTL is how long resolved DIDs are cached when no TTL is configured.
+	DefaultUniversalResolverCacheTTL = 5 * time.Minute
+
+	// maxCachedDIDs bounds how many resolved DIDs are cached at once.
+	maxCachedDIDs = 1000
+	// maxResolutionResultBytes bounds how much of a response is read.
+	maxResolutionResultBytes = 1 << 20
 )
 
 // universalResolver is a struct that implements the Resolver interface. It calls the universal resolver endpoint
@@ -20,22 +36,61 @@ type universalResolver struct {
 	client           *http.Client
 	url              string
 	supportedMethods []didsdk.Method
+
+	// methods whose DIDs are resolved, every method when empty
+	allowedMethods map[didsdk.Method]bool
+
+	// how long results are cached, not at all when it's not positive
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[string]cachedResult
+	clock    clock.Clock
+}
+
+type cachedResult struct {
+	result    resolution.Result
+	expiresAt time.Time
 }
 
 var _ resolution.Resolver = (*universalResolver)(nil)
 
-func newUniversalResolver(url string) (*universalResolver, error) {
+func newUniversalResolver(url string, methods []string, cacheTTL time.Duration) (*universalResolver, error) {
 	if url == "" {
 		return nil, errors.New("universal resolver url cannot be empty")
 	}
+	allowedMethods := make(map[didsdk.Method]bool, len(methods))
+	for _, method := range methods {
+		allowedMethods[didsdk.Method(method)] = true
+	}
 	return &universalResolver{
-		client: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
-		url:    url,
+		client:         &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		url:            url,
+		allowedMethods: allowedMethods,
+		cacheTTL:       cacheTTL,
+		cache:          make(map[string]cachedResult),
+		clock:          clock.New(),
 	}, nil
 }
 
-// Resolve results resolution results by doing a GET on <url>/1.0.identifiers/<did>.
+// resolves returns whether DIDs of the method are resolved with the universal resolver.
+func (ur *universalResolver) resolves(method didsdk.Method) bool {
+	return len(ur.allowedMethods) == 0 || ur.allowedMethods[method]
+}
+
+// Resolve results resolution results by doing a GET on <url>/1.0.identifiers/<did>. Results are cached for the cache
+// TTL, and DIDs of methods that aren't allowed aren't resolved.
 func (ur *universalResolver) Resolve(ctx context.Context, did string, _ ...resolution.Option) (*resolution.Result, error) {
+	method, err := utilint.GetMethodForDID(did)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting method DID")
+	}
+	if !ur.resolves(method) {
+		return nil, fmt.Errorf("method %s is not resolved with the universal resolver", method)
+	}
+	if cached := ur.getCached(did); cached != nil {
+		return cached, nil
+	}
+
 	url := ur.url + "/1.0/identifiers/" + did
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -46,18 +101,72 @@ func (ur *universalResolver) Resolve(ctx context.Context, did string, _ ...resol
 	if err != nil {
 		return nil, errors.Wrap(err, "performing http get")
 	}
+	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(bufio.NewReader(resp.Body))
+	respBody, err := io.ReadAll(io.LimitReader(bufio.NewReader(resp.Body), maxResolutionResultBytes))
 	if err != nil {
 		return nil, err
 	}
 	var result resolution.Result
 	if err = json.Unmarshal(respBody, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("universal resolver responded with status %d", resp.StatusCode)
+		}
 		return nil, errors.Wrap(err, "unmarshalling JSON")
 	}
+	if resp.StatusCode != http.StatusOK || result.Document.IsEmpty() {
+		if result.Metadata.Error != nil {
+			return nil, fmt.Errorf("universal resolver could not resolve DID: %s", result.Metadata.Error.Code)
+		}
+		return nil, fmt.Errorf("universal resolver responded with status %d, and no document", resp.StatusCode)
+	}
+
+	ur.putCached(did, result)
 	return &result, nil
 }
 
+func (ur *universalResolver) getCached(did string) *resolution.Result {
+	if ur.cacheTTL <= 0 {
+		return nil
+	}
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+	cached, ok := ur.cache[did]
+	if !ok {
+		return nil
+	}
+	if !ur.clock.Now().Before(cached.expiresAt) {
+		delete(ur.cache, did)
+		return nil
+	}
+	result := cached.result
+	return &result
+}
+
+func (ur *universalResolver) putCached(did string, result resolution.Result) {
+	if ur.cacheTTL <= 0 {
+		return
+	}
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+	now := ur.clock.Now()
+	if len(ur.cache) >= maxCachedDIDs {
+		for cachedDID, cached := range ur.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(ur.cache, cachedDID)
+			}
+		}
+	}
+	// when every result is still fresh, an arbitrary one makes room
+	for cachedDID := range ur.cache {
+		if len(ur.cache) < maxCachedDIDs {
+			break
+		}
+		delete(ur.cache, cachedDID)
+	}
+	ur.cache[did] = cachedResult{result: result, expiresAt: now.Add(ur.cacheTTL)}
+}
+
 // Methods returns the methods that this resolver supports
 // as per https://github.com/decentralized-identity/universal-resolver/blob/main/swagger/api.yml#L121
 func (ur *universalResolver) Methods() []didsdk.Method {
@@ -77,6 +186,7 @@ func (ur *universalResolver) Methods() []didsdk.Method {
 		logrus.WithError(err).Error("Failed to perform http get for universal resolver methods")
 		return nil
 	}
+	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(bufio.NewReader(resp.Body))
 	if err != nil {
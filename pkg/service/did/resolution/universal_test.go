@@ -2,17 +2,23 @@ package resolution
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestUniversalResolver tests the universal resolver's dev instance. It is intentionally skipped to not run in CI.
 func TestUniversalResolver(t *testing.T) {
 	t.Skip("skipping integration test")
 	t.Run("test get methods", func(tt *testing.T) {
-		resolver, err := newUniversalResolver("https://dev.uniresolver.io")
+		resolver, err := newUniversalResolver("https://dev.uniresolver.io", nil, 0)
 		assert.NoError(tt, err)
 		assert.NotEmpty(tt, resolver)
 
@@ -22,7 +28,7 @@ func TestUniversalResolver(t *testing.T) {
 	})
 
 	t.Run("test get ion resolution", func(tt *testing.T) {
-		resolver, err := newUniversalResolver("https://dev.uniresolver.io")
+		resolver, err := newUniversalResolver("https://dev.uniresolver.io", nil, 0)
 		assert.NoError(tt, err)
 		assert.NotEmpty(tt, resolver)
 
@@ -33,3 +39,69 @@ func TestUniversalResolver(t *testing.T) {
 		assert.Equal(tt, "did:ion:EiClkZMDxPKqC9c-umQfTkR8vvZ9JPhl_xLDI9Nfk38w5w", resolution.Document.ID)
 	})
 }
+
+func TestUniversalResolverFallback(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		did := strings.TrimPrefix(r.URL.Path, "/1.0/identifiers/")
+		if did == "did:ebsi:unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"didResolutionMetadata":{"error":"notFound"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"didDocument":{"id":"` + did + `"},"didResolutionMetadata":{"contentType":"application/did+ld+json"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	resolver, err := NewServiceResolver(nil, []string{"key"}, server.URL, []string{"ebsi", "indy"}, time.Minute)
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	resolver.ur.clock = mockClock
+	ctx := context.Background()
+
+	t.Run("resolves DIDs of allowed methods that aren't resolved locally", func(tt *testing.T) {
+		requests = 0
+		resolved, err := resolver.Resolve(ctx, "did:ebsi:zvHWX359A3CvfJnCYaAiAde")
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:ebsi:zvHWX359A3CvfJnCYaAiAde", resolved.Document.ID)
+
+		// results are cached until the TTL passes
+		_, err = resolver.Resolve(ctx, "did:ebsi:zvHWX359A3CvfJnCYaAiAde")
+		require.NoError(tt, err)
+		assert.Equal(tt, 1, requests)
+		mockClock.Add(time.Minute)
+		_, err = resolver.Resolve(ctx, "did:ebsi:zvHWX359A3CvfJnCYaAiAde")
+		require.NoError(tt, err)
+		assert.Equal(tt, 2, requests)
+	})
+
+	t.Run("doesn't resolve DIDs of other methods", func(tt *testing.T) {
+		requests = 0
+		_, err := resolver.Resolve(ctx, "did:example:123")
+		assert.Error(tt, err)
+		assert.Zero(tt, requests)
+	})
+
+	t.Run("doesn't cache DIDs that aren't found", func(tt *testing.T) {
+		requests = 0
+		for i := 0; i < 2; i++ {
+			_, err := resolver.ur.Resolve(ctx, "did:ebsi:unknown")
+			assert.ErrorContains(tt, err, "404")
+		}
+		assert.Equal(tt, 2, requests)
+	})
+	t.Run("resolves with the universal resolver it's updated to", func(tt *testing.T) {
+		requests = 0
+		require.NoError(tt, resolver.UpdateUniversalResolver(server.URL, []string{"example"}, -1))
+		resolved, err := resolver.Resolve(ctx, "did:example:123")
+		require.NoError(tt, err)
+		assert.Equal(tt, "did:example:123", resolved.Document.ID)
+		assert.Equal(tt, 1, requests)
+
+		require.NoError(tt, resolver.UpdateUniversalResolver("", nil, 0))
+		_, err = resolver.Resolve(ctx, "did:example:123")
+		assert.Error(tt, err)
+		assert.Equal(tt, 1, requests)
+	})
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 
 	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/ion"
 	didresolution "github.com/TBD54566975/ssi-sdk/did/resolution"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/pkg/service/did/resolution"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
@@ -24,7 +26,13 @@ type Service struct {
 	handlers map[didsdk.Method]MethodHandler
 
 	// resolver for DID methods
-	resolver *resolution.ServiceResolver
+	resolver didresolution.Resolver
+
+	// the resolver of the service before it's wrapped, whose universal resolver can be updated
+	serviceResolver *resolution.ServiceResolver
+
+	// caches the results of resolver, when the resolution cache is enabled
+	resolutionCache *resolution.CachingResolver
 
 	// external dependencies
 	keyStore *keystore.Service
@@ -66,6 +74,23 @@ func (s *Service) GetResolver() didresolution.Resolver {
 	return s.resolver
 }
 
+// GetResolutionCache returns the cache of resolution results, or nil when the resolution cache isn't enabled.
+func (s *Service) GetResolutionCache() *resolution.CachingResolver {
+	return s.resolutionCache
+}
+
+// UpdateUniversalResolver resolves DIDs with the universal resolver of config from now on, so that its URL, methods,
+// and cache TTL can change while the service runs.
+func (s *Service) UpdateUniversalResolver(config config.DIDServiceConfig) error {
+	return s.serviceResolver.UpdateUniversalResolver(config.UniversalResolverURL, config.UniversalResolverMethods, config.UniversalResolverCacheTTL)
+}
+
+// WrapResolver replaces the resolver of the service with the one wrap returns for it, e.g. to instrument resolution.
+// It must be called before the resolver is handed to other services.
+func (s *Service) WrapResolver(wrap func(didresolution.Resolver) didresolution.Resolver) {
+	s.resolver = wrap(s.resolver)
+}
+
 func NewDIDService(config config.DIDServiceConfig, s storage.ServiceStorage, keyStore *keystore.Service) (*Service, error) {
 	didStorage, err := NewDIDStorage(s)
 	if err != nil {
@@ -93,11 +118,18 @@ func NewDIDService(config config.DIDServiceConfig, s storage.ServiceStorage, key
 	}
 
 	// instantiate DID resolver
-	resolver, err := resolution.NewServiceResolver(hr, config.LocalResolutionMethods, config.UniversalResolverURL)
+	resolver, err := resolution.NewServiceResolver(hr, config.LocalResolutionMethods, config.UniversalResolverURL, config.UniversalResolverMethods, config.UniversalResolverCacheTTL)
 	if err != nil {
 		return nil, errors.Wrap(err, "instantiating DID resolver")
 	}
 	service.resolver = resolver
+	service.serviceResolver = resolver
+	if config.ResolutionCache.Enabled {
+		if service.resolutionCache, err = resolution.NewCachingResolver(resolver, config.ResolutionCache); err != nil {
+			return nil, errors.Wrap(err, "instantiating DID resolution cache")
+		}
+		service.resolver = service.resolutionCache
+	}
 
 	if !service.Status().IsReady() {
 		return nil, errors.New(service.Status().Message)
@@ -199,18 +231,166 @@ func (s *Service) ListDIDsByMethod(ctx context.Context, request ListDIDsRequest)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not get handler for method<%s>", request.Method)
 	}
+	var resp *ListDIDsResponse
 	if request.Deleted {
-		return handler.ListDeletedDIDs(ctx)
+		resp, err = handler.ListDeletedDIDs(ctx)
+	} else {
+		resp, err = handler.ListDIDs(ctx, request.PageRequest)
 	}
-	return handler.ListDIDs(ctx, request.PageRequest)
+	if err != nil {
+		return nil, err
+	}
+	if resp.TotalCount, err = s.storage.CountDIDs(ctx, request.Method.String(), request.Deleted); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not count DIDs for method<%s>", request.Method)
+	}
+	return resp, nil
 }
 
+// SoftDeleteDIDByMethod marks a DID as deleted, so that it's no longer listed, without orphaning what was issued with
+// it. When the request asks to deactivate the DID too, and its method's documents can be updated, the DID is
+// deactivated first.
 func (s *Service) SoftDeleteDIDByMethod(ctx context.Context, request DeleteDIDRequest) error {
 	handler, err := s.getHandler(request.Method)
 	if err != nil {
 		return sdkutil.LoggingErrorMsgf(err, "could not get handler for method<%s>", request.Method)
 	}
-	return handler.SoftDeleteDID(ctx, request)
+	if _, ok := handler.(UpdatableMethodHandler); ok && request.Deactivate {
+		deactivateRequest := DeactivateDIDRequest{Method: request.Method, ID: request.ID}
+		if _, err = s.DeactivateDIDByMethod(ctx, deactivateRequest); err != nil {
+			return errors.Wrap(err, "deactivating DID")
+		}
+	}
+	if err = handler.SoftDeleteDID(ctx, request); err != nil {
+		return err
+	}
+	s.invalidateResolution(ctx, request.ID)
+	return nil
+}
+
+// RestoreDIDByMethod unmarks a soft-deleted DID as deleted, so that it's listed again.
+func (s *Service) RestoreDIDByMethod(ctx context.Context, request RestoreDIDRequest) (*RestoreDIDResponse, error) {
+	handler, err := s.getHandler(request.Method)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get handler for method<%s>", request.Method)
+	}
+	defer s.invalidateResolution(ctx, request.ID)
+	return handler.RestoreDID(ctx, request)
+}
+
+// GetHostedWebDID returns the document of a did:web DID the service created, and hasn't deleted, to be served at the
+// URL the DID resolves to. It returns nil when the service doesn't host the DID.
+func (s *Service) GetHostedWebDID(ctx context.Context, id string) (*didsdk.Document, error) {
+	if _, ok := s.handlers[didsdk.WebMethod]; !ok {
+		return nil, nil
+	}
+	exists, err := s.storage.DIDExists(ctx, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "checking whether DID<%s> exists", id)
+	}
+	if !exists {
+		return nil, nil
+	}
+	gotDID, err := s.storage.GetDIDDefault(ctx, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting DID<%s>", id)
+	}
+	if gotDID.IsSoftDeleted() {
+		return nil, nil
+	}
+	doc := gotDID.GetDocument()
+	return &doc, nil
+}
+
+func (s *Service) UpdateDIDByMethod(ctx context.Context, request UpdateDIDRequest) (*UpdateDIDResponse, error) {
+	handler, err := s.getUpdatableHandler(request.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer s.invalidateResolution(ctx, request.ID)
+	return handler.UpdateDID(ctx, request)
+}
+
+func (s *Service) RecoverDIDByMethod(ctx context.Context, request RecoverDIDRequest) (*RecoverDIDResponse, error) {
+	handler, err := s.getUpdatableHandler(request.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer s.invalidateResolution(ctx, request.ID)
+	return handler.RecoverDID(ctx, request)
+}
+
+func (s *Service) DeactivateDIDByMethod(ctx context.Context, request DeactivateDIDRequest) (*DeactivateDIDResponse, error) {
+	handler, err := s.getUpdatableHandler(request.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer s.invalidateResolution(ctx, request.ID)
+	return handler.DeactivateDID(ctx, request)
+}
+
+// PublishRotation adds the next version of a rotated key to the document of the DID the key backs, next to the key,
+// with the same type and relationships. It returns false, without publishing anything, when the key doesn't back a DID
+// the service created, or when the DID's method doesn't let its documents be updated.
+func (s *Service) PublishRotation(ctx context.Context, rotation keystore.KeyRotation) (bool, error) {
+	method, err := didresolution.GetMethodForDID(rotation.Controller)
+	if err != nil {
+		return false, nil
+	}
+	handler, ok := s.handlers[method].(UpdatableMethodHandler)
+	if !ok {
+		return false, nil
+	}
+	exists, err := s.storage.DIDExists(ctx, rotation.Controller)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "checking whether DID<%s> exists", rotation.Controller)
+	}
+	if !exists {
+		return false, nil
+	}
+	gotDID, err := handler.GetDID(ctx, GetDIDRequest{Method: method, ID: rotation.Controller})
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "getting DID<%s>", rotation.Controller)
+	}
+
+	// keys are stored by the verification method IDs of the DID, which may differ from the ID of its document until
+	// the DID is published
+	var published *ion.PublicKey
+	keys := documentState(gotDID.DID).PublicKeys
+	for i := range keys {
+		if didsdk.FullyQualifiedVerificationMethodID(rotation.Controller, keys[i].ID) == rotation.SupersededID {
+			published = &keys[i]
+			break
+		}
+	}
+	if published == nil {
+		return false, nil
+	}
+	next := ion.PublicKey{
+		ID:           fragment(rotation.ID),
+		Type:         published.Type,
+		PublicKeyJWK: rotation.PublicKeyJWK,
+		Purposes:     published.Purposes,
+	}
+	_, err = s.UpdateDIDByMethod(ctx, UpdateDIDRequest{
+		Method:      method,
+		ID:          rotation.Controller,
+		StateChange: ion.StateChange{PublicKeysToAdd: []ion.PublicKey{next}},
+	})
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "updating DID<%s>", rotation.Controller)
+	}
+	return true, nil
+}
+
+// invalidateResolution removes the cached resolution result of a DID the service changed, so that the change is seen
+// by the next resolution rather than once the result expires.
+func (s *Service) invalidateResolution(ctx context.Context, did string) {
+	if s.resolutionCache == nil {
+		return
+	}
+	if _, err := s.resolutionCache.Invalidate(ctx, did); err != nil {
+		logrus.WithError(err).Warnf("invalidating cached resolution of DID<%s>", did)
+	}
 }
 
 func (s *Service) getHandler(method didsdk.Method) (MethodHandler, error) {
@@ -221,6 +401,19 @@ func (s *Service) getHandler(method didsdk.Method) (MethodHandler, error) {
 	return handler, nil
 }
 
+// getUpdatableHandler returns the handler for the given DID method when its DIDs can be updated after they're created.
+func (s *Service) getUpdatableHandler(method didsdk.Method) (UpdatableMethodHandler, error) {
+	handler, err := s.getHandler(method)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get handler for method<%s>", method)
+	}
+	updatable, ok := handler.(UpdatableMethodHandler)
+	if !ok {
+		return nil, sdkutil.LoggingNewErrorf("DIDs of method<%s> cannot be updated", method)
+	}
+	return updatable, nil
+}
+
 type BatchService struct {
 	config  config.DIDServiceConfig
 	storage *Storage
@@ -153,30 +153,42 @@ func (ds *Storage) ListDIDs(ctx context.Context, method string, outType StoredDI
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, couldNotGetDIDsErr)
 	}
-	gotDIDs, err := ds.db.ReadAll(ctx, ns)
+	var out []StoredDID
+	err = ds.db.Iterate(ctx, ns, func(_ string, didBytes []byte) (bool, error) {
+		if nextDID, ok := unmarshalStoredDID(didBytes, outType); ok {
+			out = append(out, nextDID)
+		}
+		return true, nil
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, couldNotGetDIDsErr)
 	}
-	if len(gotDIDs) == 0 {
+	if len(out) == 0 {
 		logrus.Infof("no DIDs found for method: %s", method)
 		return nil, nil
 	}
-
-	out := ds.storedDIDs(gotDIDs, outType)
 	return out, nil
 }
 
 func (ds *Storage) storedDIDs(gotDIDs map[string][]byte, outType StoredDID) []StoredDID {
 	out := make([]StoredDID, 0, len(gotDIDs))
 	for _, didBytes := range gotDIDs {
-		nextDID := reflect.New(reflect.TypeOf(outType).Elem()).Interface()
-		if err := json.Unmarshal(didBytes, &nextDID); err == nil {
-			out = append(out, nextDID.(StoredDID))
+		if nextDID, ok := unmarshalStoredDID(didBytes, outType); ok {
+			out = append(out, nextDID)
 		}
 	}
 	return out
 }
 
+// unmarshalStoredDID creates a new value of the same type as outType and unmarshals didBytes into it.
+func unmarshalStoredDID(didBytes []byte, outType StoredDID) (StoredDID, bool) {
+	nextDID := reflect.New(reflect.TypeOf(outType).Elem()).Interface()
+	if err := json.Unmarshal(didBytes, &nextDID); err != nil {
+		return nil, false
+	}
+	return nextDID.(StoredDID), true
+}
+
 type StoredDIDs struct {
 	DIDs          []StoredDID
 	NextPageToken string
@@ -201,6 +213,28 @@ func (ds *Storage) ListDIDsPage(ctx context.Context, method string, page *common
 	}, nil
 }
 
+// CountDIDs returns how many DIDs are stored for a given method, counting either the soft deleted DIDs or the others.
+func (ds *Storage) CountDIDs(ctx context.Context, method string, deleted bool) (int, error) {
+	ns, err := getNamespaceForMethod(method)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting namespace")
+	}
+	count := 0
+	err = ds.db.Iterate(ctx, ns, func(_ string, didBytes []byte) (bool, error) {
+		var stored struct {
+			SoftDeleted bool `json:"softDeleted"`
+		}
+		if err := json.Unmarshal(didBytes, &stored); err == nil && stored.SoftDeleted == deleted {
+			count++
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "counting DIDs for method: %s", method)
+	}
+	return count, nil
+}
+
 func (ds *Storage) ListDIDsDefault(ctx context.Context, method string) ([]DefaultStoredDID, error) {
 	gotDIDs, err := ds.ListDIDs(ctx, method, new(DefaultStoredDID))
 	if err != nil {
@@ -243,6 +277,45 @@ func validateOut(out StoredDID) error {
 	return nil
 }
 
+// ExportDIDs returns the DIDs of every method as they're stored, keyed by their IDs, so that what's stored besides the
+// document, like the operations of ion DIDs, is kept when they're backed up.
+func (ds *Storage) ExportDIDs(ctx context.Context) (map[string]json.RawMessage, error) {
+	dids := make(map[string]json.RawMessage)
+	for method, ns := range didMethodToNamespace {
+		gotDIDs, err := ds.db.ReadAll(ctx, ns)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not export DIDs for method: %s", method)
+		}
+		for id, didBytes := range gotDIDs {
+			dids[id] = didBytes
+		}
+	}
+	return dids, nil
+}
+
+// ImportDID stores a DID returned by ExportDIDs as it was exported, unless it's stored already. It returns whether
+// the DID was stored.
+func (ds *Storage) ImportDID(ctx context.Context, id string, stored json.RawMessage) (bool, error) {
+	ns, err := getNamespaceForDID(id)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "could not import DID: %s", id)
+	}
+	exists, err := ds.db.Exists(ctx, ns, id)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "could not import DID: %s", id)
+	}
+	if exists {
+		return false, nil
+	}
+	if !json.Valid(stored) {
+		return false, sdkutil.LoggingNewErrorf("could not import DID: %s, it isn't valid JSON", id)
+	}
+	if err = ds.tx.Write(ctx, ns, id, stored); err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "could not import DID: %s", id)
+	}
+	return true, nil
+}
+
 func getNamespaceForDID(id string) (string, error) {
 	method, err := util.GetMethodForDID(id)
 	if err != nil {
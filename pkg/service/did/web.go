@@ -3,14 +3,18 @@ package did
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/cryptosuite"
 	"github.com/TBD54566975/ssi-sdk/did"
 	"github.com/TBD54566975/ssi-sdk/did/web"
 	"github.com/TBD54566975/ssi-sdk/util"
 	"github.com/mr-tron/base58"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
 	"github.com/tbd54566975/ssi-service/pkg/service/common"
 	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 )
@@ -25,6 +29,29 @@ func NewWebHandler(s *Storage, ks *keystore.Service) (MethodHandler, error) {
 	return &webHandler{method: did.WebMethod, storage: s, keyStore: ks}, nil
 }
 
+// WebDIDForURL returns the did:web DID whose document is served at path of host, reversing the resolution of the
+// DID https://w3c-ccg.github.io/did-method-web/#read-resolve. The document of a DID of a domain is at
+// /.well-known/did.json, and that of a DID with a path, e.g. did:web:example.com:users:alice, at /users/alice/did.json.
+func WebDIDForURL(host, path string) (string, error) {
+	if host == "" {
+		return "", errors.New("host cannot be empty")
+	}
+	id := web.Prefix + ":" + strings.ReplaceAll(host, ":", "%3A")
+	if path == "/"+web.WellKnownURLPath+web.DIDDocFilename {
+		return id, nil
+	}
+	if !strings.HasSuffix(path, "/"+web.DIDDocFilename) {
+		return "", fmt.Errorf("path<%s> is not that of a did:web document", path)
+	}
+	segments := strings.Split(strings.TrimPrefix(strings.TrimSuffix(path, "/"+web.DIDDocFilename), "/"), "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return "", fmt.Errorf("path<%s> is not that of a did:web document", path)
+		}
+	}
+	return id + ":" + strings.Join(segments, ":"), nil
+}
+
 type webHandler struct {
 	method   did.Method
 	storage  *Storage
@@ -49,7 +76,7 @@ func (h *webHandler) GetMethod() did.Method {
 func (h *webHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*CreateDIDResponse, error) {
 	logrus.Debugf("creating DID: %+v", request)
 
-	if !crypto.IsSupportedKeyType(request.KeyType) {
+	if !keyaccess.IsSupportedKeyType(request.KeyType) {
 		return nil, errors.Errorf("key type <%s> not supported", request.KeyType)
 	}
 	// process options
@@ -80,17 +107,17 @@ func (h *webHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 		return nil, fmt.Errorf("did with id<%s> already exists", opts.DIDWebID)
 	}
 
-	pubKey, privKey, err := crypto.GenerateKeyByKeyType(request.KeyType)
+	pubKey, privKey, err := keyaccess.GenerateKeyByKeyType(request.KeyType)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not generate key for did:web")
 	}
 
-	pubKeyBytes, err := crypto.PubKeyToBytes(pubKey)
+	pubKeyBytes, err := keyaccess.PubKeyToBytes(pubKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not convert public key to byte")
 	}
 
-	doc, err := didWeb.CreateDoc(request.KeyType, pubKeyBytes)
+	doc, err := createWebDoc(didWeb, request.KeyType, pubKeyBytes)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create did:web docs")
 	}
@@ -107,7 +134,7 @@ func (h *webHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 	}
 
 	// convert to a serialized format for return to the client
-	privKeyBytes, err := crypto.PrivKeyToBytes(privKey)
+	privKeyBytes, err := keyaccess.PrivKeyToBytes(privKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not encode private key as base58")
 	}
@@ -127,6 +154,28 @@ func (h *webHandler) CreateDID(ctx context.Context, request CreateDIDRequest) (*
 	return &CreateDIDResponse{DID: storedDID.DID}, nil
 }
 
+// createWebDoc creates the document of a did:web DID with a key. BLS12381G2 keys, which have no JWK representation
+// the SDK supports, are Bls12381G2Key2020 verification methods, so that BbsBlsSignature2020 proofs can be verified
+// with them.
+func createWebDoc(didWeb web.DIDWeb, kt crypto.KeyType, pubKeyBytes []byte) (*did.Document, error) {
+	if kt != crypto.BLS12381G2 {
+		return didWeb.CreateDoc(kt, pubKeyBytes)
+	}
+	id := didWeb.String()
+	keyReference := id + "#owner"
+	verificationMethod, err := did.ConstructMultibaseVerificationMethod(keyReference, id, pubKeyBytes, cryptosuite.BLS12381G2Key2020)
+	if err != nil {
+		return nil, err
+	}
+	verificationMethodSet := []did.VerificationMethodSet{keyReference}
+	return &did.Document{
+		Context:            []any{did.KnownDIDContext, cryptosuite.BLS12381G2Key2020Context},
+		ID:                 id,
+		VerificationMethod: []did.VerificationMethod{*verificationMethod},
+		AssertionMethod:    verificationMethodSet,
+	}, nil
+}
+
 func (h *webHandler) GetDID(ctx context.Context, request GetDIDRequest) (*GetDIDResponse, error) {
 	logrus.Debugf("getting DID: %+v", request)
 
@@ -190,3 +239,23 @@ func (h *webHandler) SoftDeleteDID(ctx context.Context, request DeleteDIDRequest
 
 	return h.storage.StoreDID(ctx, *gotStoredDID)
 }
+
+func (h *webHandler) RestoreDID(ctx context.Context, request RestoreDIDRequest) (*RestoreDIDResponse, error) {
+	logrus.Debugf("restoring DID: %+v", request)
+
+	id := request.ID
+	gotStoredDID, err := h.storage.GetDIDDefault(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting DID: %s", id)
+	}
+	if !gotStoredDID.SoftDeleted {
+		return nil, fmt.Errorf("did<%s> has not been deleted", id)
+	}
+
+	gotStoredDID.SoftDeleted = false
+
+	if err = h.storage.StoreDID(ctx, *gotStoredDID); err != nil {
+		return nil, errors.Wrapf(err, "storing DID: %s", id)
+	}
+	return &RestoreDIDResponse{DID: gotStoredDID.GetDocument()}, nil
+}
@@ -0,0 +1,32 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebDIDForURL(t *testing.T) {
+	tests := []struct {
+		host string
+		path string
+		id   string
+	}{
+		{host: "example.com", path: "/.well-known/did.json", id: "did:web:example.com"},
+		{host: "localhost:3000", path: "/.well-known/did.json", id: "did:web:localhost%3A3000"},
+		{host: "example.com", path: "/users/alice/did.json", id: "did:web:example.com:users:alice"},
+		{host: "example.com", path: "/did.json"},
+		{host: "example.com", path: "/users//did.json"},
+		{host: "example.com", path: "/users/alice/doc.json"},
+		{host: "", path: "/.well-known/did.json"},
+	}
+	for _, test := range tests {
+		id, err := WebDIDForURL(test.host, test.path)
+		if test.id == "" {
+			assert.Error(t, err, "%s%s", test.host, test.path)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.id, id)
+	}
+}
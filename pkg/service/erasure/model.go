@@ -0,0 +1,48 @@
+package erasure
+
+import (
+	"time"
+)
+
+type EraseSubjectRequest struct {
+	// DID or other identifier of the data subject whose data is erased.
+	Subject string `json:"subject" validate:"required"`
+
+	// When set, nothing is erased, and the report, which isn't stored, lists what would be.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// Report records what was erased for a data subject. Erased resources are deleted outright, so their IDs in the
+// report are what's left of them.
+type Report struct {
+	ID string `json:"id"`
+
+	// DID or other identifier of the data subject whose data was erased.
+	Subject string `json:"subject"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Whether the report lists what would be erased, by a dry run that erased nothing.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// IDs of the credentials issued to the subject.
+	Credentials []string `json:"credentials"`
+
+	// IDs of the credential applications the subject made, and of the responses sent to them.
+	Applications []string `json:"applications"`
+	Responses    []string `json:"responses"`
+
+	// IDs of the presentation submissions the subject was the holder of.
+	Submissions []string `json:"submissions"`
+
+	// IDs of the operations that reviewed the subject's applications and submissions.
+	Operations []string `json:"operations"`
+}
+
+type GetReportRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type ListReportsResponse struct {
+	Reports []Report `json:"reports"`
+}
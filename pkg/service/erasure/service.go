@@ -0,0 +1,164 @@
+package erasure
+
+import (
+	"context"
+	"fmt"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	manifestmodel "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	presmodel "github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// ErrReportNotFound is returned when no erasure report exists with the requested ID.
+var ErrReportNotFound = errors.New("erasure report not found")
+
+// Service erases the data held about a data subject across the services that hold it, as asked for by e.g. a GDPR
+// request for erasure, and keeps a report of each erasure.
+type Service struct {
+	storage *Storage
+
+	// external dependencies
+	credential   *credential.Service
+	manifest     *manifest.Service
+	presentation *presentation.Service
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Erasure
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.credential == nil {
+		ae.AppendString("no credential service configured")
+	}
+	if s.manifest == nil {
+		ae.AppendString("no manifest service configured")
+	}
+	if s.presentation == nil {
+		ae.AppendString("no presentation service configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("erasure service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewErasureService(s storage.ServiceStorage, credential *credential.Service, manifest *manifest.Service, presentation *presentation.Service) (*Service, error) {
+	erasureStorage, err := NewErasureStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate erasure storage for the erasure service")
+	}
+	service := Service{
+		storage:      erasureStorage,
+		credential:   credential,
+		manifest:     manifest,
+		presentation: presentation,
+		Clock:        clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// EraseSubject deletes the credentials issued to a subject, the credential applications they made and the responses
+// to them, the presentation submissions they were the holder of, and the operations that reviewed those, then stores
+// and returns a report of what was deleted. When it fails part of the way, what was deleted stays deleted, and erasing
+// the subject again deletes the rest. A dry run deletes nothing, and returns a report of what would be deleted, which
+// isn't stored.
+func (s Service) EraseSubject(ctx context.Context, request EraseSubjectRequest) (*Report, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid erase subject request")
+	}
+	if request.DryRun {
+		logrus.Infof("dry run of erasing data of subject: %s", request.Subject)
+	} else {
+		logrus.Infof("erasing data of subject: %s", request.Subject)
+	}
+
+	report := Report{
+		ID:          uuid.NewString(),
+		Subject:     request.Subject,
+		CreatedAt:   s.Clock.Now().UTC(),
+		DryRun:      request.DryRun,
+		Credentials: make([]string, 0),
+	}
+
+	gotCreds, err := s.credential.ListCredentialsBySubject(ctx, credential.ListCredentialBySubjectRequest{Subject: request.Subject})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "listing credentials of subject: %s", request.Subject)
+	}
+	for _, cred := range gotCreds.Credentials {
+		if request.DryRun {
+			report.Credentials = append(report.Credentials, cred.ID)
+			continue
+		}
+		if err = s.credential.DeleteCredential(ctx, credential.DeleteCredentialRequest{ID: cred.ID}); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "erasing credential: %s", cred.ID)
+		}
+		report.Credentials = append(report.Credentials, cred.ID)
+	}
+
+	erasedApplicant, err := s.manifest.EraseApplicant(ctx, manifestmodel.EraseApplicantRequest{ApplicantDID: request.Subject, DryRun: request.DryRun})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "erasing applications of subject: %s", request.Subject)
+	}
+	report.Applications = erasedApplicant.ApplicationIDs
+	report.Responses = erasedApplicant.ResponseIDs
+
+	erasedHolder, err := s.presentation.EraseHolder(ctx, presmodel.EraseHolderRequest{Holder: request.Subject, DryRun: request.DryRun})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "erasing submissions of subject: %s", request.Subject)
+	}
+	report.Submissions = erasedHolder.SubmissionIDs
+	report.Operations = append(erasedApplicant.OperationIDs, erasedHolder.OperationIDs...)
+	if request.DryRun {
+		return &report, nil
+	}
+
+	if err = s.storage.StoreReport(ctx, report); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "storing erasure report of subject: %s", request.Subject)
+	}
+	return &report, nil
+}
+
+func (s Service) GetReport(ctx context.Context, request GetReportRequest) (*Report, error) {
+	report, err := s.storage.GetReport(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting erasure report: %s", request.ID)
+	}
+	if report == nil {
+		return nil, sdkutil.LoggingErrorMsgf(ErrReportNotFound, "getting erasure report: %s", request.ID)
+	}
+	return report, nil
+}
+
+func (s Service) ListReports(ctx context.Context) (*ListReportsResponse, error) {
+	reports, err := s.storage.ListReports(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing erasure reports")
+	}
+	if reports == nil {
+		reports = make([]Report, 0)
+	}
+	return &ListReportsResponse{Reports: reports}, nil
+}
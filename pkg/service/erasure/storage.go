@@ -0,0 +1,69 @@
+package erasure
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const reportNamespace = "erasure_report"
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewErasureStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) StoreReport(ctx context.Context, report Report) error {
+	if report.ID == "" {
+		return sdkutil.LoggingNewError("could not store erasure report without an ID")
+	}
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store erasure report: %s", report.ID)
+	}
+	return s.db.Write(ctx, reportNamespace, report.ID, reportBytes)
+}
+
+// GetReport returns the report with the given id, or nil if none exists.
+func (s *Storage) GetReport(ctx context.Context, id string) (*Report, error) {
+	reportBytes, err := s.db.Read(ctx, reportNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get erasure report: %s", id)
+	}
+	if len(reportBytes) == 0 {
+		return nil, nil
+	}
+	var report Report
+	if err = json.Unmarshal(reportBytes, &report); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling stored erasure report: %s", id)
+	}
+	return &report, nil
+}
+
+func (s *Storage) ListReports(ctx context.Context) ([]Report, error) {
+	var reports []Report
+	err := s.db.Iterate(ctx, reportNamespace, func(id string, reportBytes []byte) (bool, error) {
+		var report Report
+		if err := json.Unmarshal(reportBytes, &report); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored erasure report: %s", id)
+			return true, nil
+		}
+		reports = append(reports, report)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing erasure reports")
+	}
+	return reports, nil
+}
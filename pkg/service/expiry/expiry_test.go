@@ -0,0 +1,258 @@
+package expiry
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	credstorage "github.com/tbd54566975/ssi-service/pkg/service/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func newKeyStore(t *testing.T, db storage.ServiceStorage) *keystore.Service {
+	keyStore, err := keystore.NewKeyStoreService(config.KeyStoreServiceConfig{}, db)
+	require.NoError(t, err)
+	return keyStore
+}
+
+func TestNewExpiryService(t *testing.T) {
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	tenantStorage := storage.NewTenantWrapper(db)
+	keyStore := newKeyStore(t, tenantStorage)
+	for name, cfg := range map[string]config.ExpiryServiceConfig{
+		"negative warn before": {WarnBefore: -time.Hour},
+		"invalid tenant":       {Tenants: []string{"a b"}},
+		"invalid alert url":    {AlertURLs: []string{"ftp://example.com"}},
+		"smtp without port":    {Email: config.ExpiryEmailConfig{SMTPAddress: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}}},
+		"email without to":     {Email: config.ExpiryEmailConfig{SMTPAddress: "smtp.example.com:587", From: "a@example.com"}},
+	} {
+		_, err := NewExpiryService(cfg, db, tenantStorage, keyStore, nil, nil)
+		assert.Error(t, err, name)
+	}
+	_, err := NewExpiryService(config.ExpiryServiceConfig{}, db, tenantStorage, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestWarn(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			acme := storage.WithTenant(ctx, "acme")
+			globalStorage := test.ServiceStorage(t)
+			tenantStorage := storage.NewTenantWrapper(globalStorage)
+			keyStore := newKeyStore(t, tenantStorage)
+
+			var warnings []Warning
+			alerts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var warning Warning
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&warning))
+				warnings = append(warnings, warning)
+			}))
+			defer alerts.Close()
+
+			s, err := NewExpiryService(config.ExpiryServiceConfig{
+				KeyMaxAge: 10 * 24 * time.Hour,
+				Tenants:   []string{"acme"},
+				AlertURLs: []string{alerts.URL},
+				Email: config.ExpiryEmailConfig{
+					SMTPAddress: "smtp.example.com:587",
+					Username:    "ssi",
+					Password:    "secret",
+					From:        "ssi@example.com",
+					To:          []string{"ops@example.com"},
+				},
+			}, globalStorage, tenantStorage, keyStore, nil, nil)
+			require.NoError(t, err)
+			now := time.Now().UTC().Truncate(time.Second)
+			mockClock := clock.NewMock()
+			mockClock.Set(now)
+			s.Clock = mockClock
+			notAfter := map[string]time.Time{
+				"example.com:443":       now.Add(7 * 24 * time.Hour),
+				"acme.example.com:8443": now.Add(60 * 24 * time.Hour),
+			}
+			s.certificate = func(_ context.Context, host string) (*x509.Certificate, error) {
+				if _, ok := notAfter[host]; !ok {
+					return nil, errors.Errorf("no such host: %s", host)
+				}
+				return &x509.Certificate{NotAfter: notAfter[host]}, nil
+			}
+			var emails []string
+			s.sendMail = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+				assert.Equal(t, "smtp.example.com:587", addr)
+				assert.Equal(t, "ssi@example.com", from)
+				assert.Equal(t, []string{"ops@example.com"}, to)
+				emails = append(emails, string(msg))
+				return nil
+			}
+
+			// keys, unless revoked
+			_, privKey, err := crypto.GenerateEd25519Key()
+			require.NoError(t, err)
+			for _, id := range []string{"key-1", "key-2"} {
+				require.NoError(t, keyStore.StoreKey(ctx, keystore.StoreKeyRequest{ID: id, Type: crypto.Ed25519, Controller: "did:example:a", PrivateKeyBase58: base58.Encode(privKey)}))
+			}
+			require.NoError(t, keyStore.RevokeKey(ctx, keystore.RevokeKeyRequest{ID: "key-2"}))
+
+			// certificates of the hosts of did:web DIDs, which are skipped when they can't be reached
+			didStorage, err := did.NewDIDStorage(tenantStorage)
+			require.NoError(t, err)
+			for tenantCtx, id := range map[context.Context]string{ctx: "did:web:example.com", acme: "did:web:acme.example.com%3A8443:user"} {
+				require.NoError(t, didStorage.StoreDID(tenantCtx, did.DefaultStoredDID{ID: id, DID: didsdk.Document{ID: id}}))
+			}
+			require.NoError(t, didStorage.StoreDID(ctx, did.DefaultStoredDID{ID: "did:web:down.example.com", DID: didsdk.Document{ID: "did:web:down.example.com"}}))
+
+			// credentials with an expiration date, unless revoked
+			for id, stored := range map[string]credstorage.StoredCredential{
+				"cred-1": {LocalCredentialID: "cred-1", Credential: &credential.VerifiableCredential{ExpirationDate: now.Add(20 * 24 * time.Hour).Format(time.RFC3339)}},
+				"cred-2": {LocalCredentialID: "cred-2", Credential: &credential.VerifiableCredential{ExpirationDate: now.Add(90 * 24 * time.Hour).Format(time.RFC3339)}},
+				"cred-3": {LocalCredentialID: "cred-3", Credential: &credential.VerifiableCredential{ExpirationDate: now.Add(5 * 24 * time.Hour).Format(time.RFC3339)}, Revoked: true},
+				"cred-4": {LocalCredentialID: "cred-4", Credential: &credential.VerifiableCredential{}},
+			} {
+				storedBytes, err := json.Marshal(stored)
+				require.NoError(t, err)
+				require.NoError(t, tenantStorage.Write(acme, "credential", id, storedBytes))
+			}
+
+			resp, err := s.ListExpiries(ctx)
+			require.NoError(t, err)
+			require.Len(t, resp.Expiries, 3)
+			assert.Equal(t, Expiry{Kind: KindCertificate, ID: "did:web:example.com", Host: "example.com:443", ExpiresAt: now.Add(7 * 24 * time.Hour)}, resp.Expiries[0])
+			assert.Equal(t, KindKey, resp.Expiries[1].Kind)
+			assert.Equal(t, "key-1", resp.Expiries[1].ID)
+			assert.Equal(t, Expiry{Kind: KindCredential, Tenant: "acme", ID: "cred-1", ExpiresAt: now.Add(20 * 24 * time.Hour)}, resp.Expiries[2])
+
+			warning, err := s.Warn(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, resp.Expiries, warning.Expiries)
+			require.Len(t, warnings, 1)
+			assert.Equal(t, warning.ID, warnings[0].ID)
+			require.Len(t, emails, 1)
+			assert.Contains(t, emails[0], "Subject: 3 keys, certificates, or credentials expire soon")
+			assert.Contains(t, emails[0], "certificate of example.com:443, the host of did:web:example.com")
+			assert.Contains(t, emails[0], "credential cred-1 expires at")
+
+			// each expiry is warned of once
+			warning, err = s.Warn(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, warning.Expiries)
+			assert.Len(t, warnings, 1)
+			assert.Len(t, emails, 1)
+
+			// until it changes
+			notAfter["example.com:443"] = now.Add(8 * 24 * time.Hour)
+			warning, err = s.Warn(ctx)
+			require.NoError(t, err)
+			require.Len(t, warning.Expiries, 1)
+			assert.Equal(t, KindCertificate, warning.Expiries[0].Kind)
+			assert.Len(t, warnings, 2)
+
+			// what expired is forgotten
+			mockClock.Add(9 * 24 * time.Hour)
+			_, err = s.Warn(ctx)
+			require.NoError(t, err)
+			warned, err := s.storage.ListWarned(ctx)
+			require.NoError(t, err)
+			assert.Len(t, warned, 2)
+			assert.NotContains(t, warned, warnedKey(Expiry{Kind: KindCertificate, ID: "did:web:example.com"}))
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			acme := storage.WithTenant(ctx, "acme")
+			globalStorage := test.ServiceStorage(t)
+			tenantStorage := storage.NewTenantWrapper(globalStorage)
+			keyStore := newKeyStore(t, tenantStorage)
+
+			s, err := NewExpiryService(config.ExpiryServiceConfig{Tenants: []string{"acme"}}, globalStorage, tenantStorage, keyStore, nil, nil)
+			require.NoError(t, err)
+			_, err = s.Expire(ctx)
+			assert.ErrorContains(t, err, "no credential service")
+
+			now := time.Now().UTC().Truncate(time.Second)
+			mockClock := clock.NewMock()
+			mockClock.Set(now)
+			s.Clock = mockClock
+			var expired []string
+			s.expireCredential = func(ctx context.Context, request credstorage.ExpireCredentialRequest) (*credstorage.ExpireCredentialResponse, error) {
+				if request.ID == "cred-broken" {
+					return nil, errors.New("status list is gone")
+				}
+				expired = append(expired, storage.TenantFromContext(ctx)+"/"+request.ID)
+				return &credstorage.ExpireCredentialResponse{Container: credint.Container{ID: request.ID, Expired: true}, Changed: request.ID != "cred-raced"}, nil
+			}
+			published := make(map[string]credint.Container)
+			s.publish = func(ctx context.Context, noun webhook.Noun, verb webhook.Verb, data []byte) {
+				assert.Equal(t, webhook.Credential, noun)
+				assert.Equal(t, webhook.Expire, verb)
+				var container credint.Container
+				assert.NoError(t, json.Unmarshal(data, &container))
+				published[storage.TenantFromContext(ctx)+"/"+container.ID] = container
+			}
+
+			// credentials whose expiration date passed, unless they're revoked, or were recorded as expired before
+			expiredAt := func(d time.Duration) *credential.VerifiableCredential {
+				return &credential.VerifiableCredential{ExpirationDate: now.Add(d).Format(time.RFC3339)}
+			}
+			for tenantCtx, credentials := range map[context.Context][]credstorage.StoredCredential{
+				ctx: {
+					{LocalCredentialID: "cred-1", Credential: expiredAt(-time.Hour)},
+					{LocalCredentialID: "cred-2", Credential: expiredAt(time.Hour)},
+					{LocalCredentialID: "cred-3", Credential: expiredAt(-time.Hour), Revoked: true},
+					{LocalCredentialID: "cred-4", Credential: expiredAt(-time.Hour), Expired: true},
+					{LocalCredentialID: "cred-broken", Credential: expiredAt(-time.Hour)},
+				},
+				acme: {
+					{LocalCredentialID: "cred-5", Credential: expiredAt(-2 * time.Hour)},
+					{LocalCredentialID: "cred-raced", Credential: expiredAt(-time.Hour)},
+					{LocalCredentialID: "cred-6", Credential: &credential.VerifiableCredential{}},
+				},
+			} {
+				for _, stored := range credentials {
+					storedBytes, err := json.Marshal(stored)
+					require.NoError(t, err)
+					require.NoError(t, tenantStorage.Write(tenantCtx, "credential", stored.LocalCredentialID, storedBytes))
+				}
+			}
+
+			sweep, err := s.Expire(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, now, sweep.SweptAt)
+			assert.Equal(t, []Expiry{
+				{Kind: KindCredential, Tenant: "acme", ID: "cred-5", ExpiresAt: now.Add(-2 * time.Hour)},
+				{Kind: KindCredential, ID: "cred-1", ExpiresAt: now.Add(-time.Hour)},
+			}, sweep.Expired)
+			assert.ElementsMatch(t, []string{"/cred-1", "acme/cred-5", "acme/cred-raced"}, expired)
+
+			// each expired credential is published to the webhooks of its tenant
+			assert.Equal(t, map[string]credint.Container{
+				"/cred-1":     {ID: "cred-1", Expired: true},
+				"acme/cred-5": {ID: "cred-5", Expired: true},
+			}, published)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package expiry
+
+import (
+	"time"
+)
+
+// Kind is a kind of thing that expires.
+type Kind string
+
+const (
+	// KindKey is a key of the keystore, which expires the configured maximum age after it was created.
+	KindKey Kind = "key"
+	// KindCertificate is the TLS certificate of the host a did:web DID is resolved from.
+	KindCertificate Kind = "certificate"
+	// KindCredential is a credential issued by the service, which expires at its expiration date.
+	KindCredential Kind = "credential"
+)
+
+// Expiry is a key, certificate, or credential of a tenant that expires.
+type Expiry struct {
+	Kind Kind `json:"kind"`
+
+	// Tenant of the key, DID, or credential. Empty for the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+
+	// ID of the key, of the did:web DID, or of the credential.
+	ID string `json:"id"`
+
+	// Host the did:web DID is resolved from, whose certificate expires.
+	Host string `json:"host,omitempty"`
+
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type ListExpiriesResponse struct {
+	// Expiries within the warning period, soonest first.
+	Expiries []Expiry
+}
+
+// Warning is posted to the alert URLs, and emailed, when keys, certificates, or credentials come within the warning
+// period of expiring. Each expiry is only warned of once.
+type Warning struct {
+	ID       string    `json:"id"`
+	WarnedAt time.Time `json:"warnedAt"`
+
+	// Expiries that weren't warned of before, soonest first.
+	Expiries []Expiry `json:"expiries"`
+}
+
+// Sweep lists the credentials a check recorded as expired.
+type Sweep struct {
+	SweptAt time.Time `json:"sweptAt"`
+
+	// Credentials recorded as expired, soonest expired first.
+	Expired []Expiry `json:"expired"`
+}
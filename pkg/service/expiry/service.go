@@ -0,0 +1,528 @@
+package expiry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	didsdk "github.com/TBD54566975/ssi-sdk/did"
+	"github.com/TBD54566975/ssi-sdk/did/web"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/tbd54566975/ssi-service/config"
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultInterval is how often expiries are checked when no interval is configured.
+	DefaultInterval = 24 * time.Hour
+	// DefaultWarnBefore is how long before they expire operators are warned when no period is configured.
+	DefaultWarnBefore = 30 * 24 * time.Hour
+
+	// warnTimeout is how long alert URLs have to take a warning.
+	warnTimeout = 10 * time.Second
+	// dialTimeout is how long hosts of did:web DIDs have to complete a TLS handshake.
+	dialTimeout = 10 * time.Second
+)
+
+// Service warns operators a while before keys, the certificates of the hosts of did:web DIDs, and issued credentials
+// expire, so that keys are rotated, certificates renewed, and credentials reissued in time. Warnings are posted to the
+// alert URLs, and emailed, and each expiry is only warned of once. Once they expire, credentials are recorded as
+// expired, and published to the webhooks of expired credentials.
+type Service struct {
+	storage     *Storage
+	keyStore    *keystore.Service
+	dids        *did.Storage
+	credentials *credential.Storage
+	config      config.ExpiryServiceConfig
+	httpClient  *http.Client
+
+	// certificate returns the leaf certificate host serves, and sendMail sends an email. They're replaced in tests.
+	certificate func(ctx context.Context, host string) (*x509.Certificate, error)
+	sendMail    func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	// expireCredential records that a credential expired, and publish publishes a webhook, which the credential and
+	// webhook services do. They're replaced in tests.
+	expireCredential func(ctx context.Context, request credential.ExpireCredentialRequest) (*credential.ExpireCredentialResponse, error)
+	publish          func(ctx context.Context, noun webhook.Noun, verb webhook.Verb, data []byte)
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Expiry
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.keyStore == nil {
+		ae.AppendString("no keystore service configured")
+	}
+	if s.config.ExpireCredentials && s.expireCredential == nil {
+		ae.AppendString("no credential service configured to expire credentials")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("expiry service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewExpiryService creates the expiry service. What was warned of is kept in globalStorage, while keys, DIDs, and
+// credentials are read from the tenants of tenantStorage, and of keyStore. Credentials that expired are recorded as
+// expired by credentialService, and published to the webhooks of webhookService, which may be nil when credentials
+// aren't expired.
+func NewExpiryService(cfg config.ExpiryServiceConfig, globalStorage, tenantStorage storage.ServiceStorage, keyStore *keystore.Service, credentialService *credential.Service, webhookService *webhook.Service) (*Service, error) {
+	expiryStorage, err := NewExpiryStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the expiry service")
+	}
+	didStorage, err := did.NewDIDStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate DID storage for the expiry service")
+	}
+	credentialStorage, err := credential.NewCredentialStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate credential storage for the expiry service")
+	}
+	if cfg.Interval < 0 || cfg.WarnBefore < 0 || cfg.KeyMaxAge < 0 {
+		return nil, sdkutil.LoggingNewError("interval, warn_before, and key_max_age cannot be negative")
+	}
+	for _, tenant := range cfg.Tenants {
+		if !storage.IsValidTenantID(tenant) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant)
+		}
+	}
+	for _, alertURL := range cfg.AlertURLs {
+		if u, err := url.ParseRequestURI(alertURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, sdkutil.LoggingNewErrorf("invalid alert url: %s", alertURL)
+		}
+	}
+	if cfg.Email.SMTPAddress != "" {
+		if _, _, err := net.SplitHostPort(cfg.Email.SMTPAddress); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "invalid smtp address: %s", cfg.Email.SMTPAddress)
+		}
+		if cfg.Email.From == "" || len(cfg.Email.To) == 0 {
+			return nil, sdkutil.LoggingNewError("emailed warnings need a from and a to address")
+		}
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.WarnBefore == 0 {
+		cfg.WarnBefore = DefaultWarnBefore
+	}
+
+	service := Service{
+		storage:     expiryStorage,
+		keyStore:    keyStore,
+		dids:        didStorage,
+		credentials: credentialStorage,
+		config:      cfg,
+		httpClient:  &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		certificate: dialCertificate,
+		sendMail:    smtp.SendMail,
+		Clock:       clock.New(),
+	}
+	if credentialService != nil {
+		service.expireCredential = credentialService.ExpireCredential
+	}
+	if webhookService != nil {
+		service.publish = webhookService.PublishEvent
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// dialCertificate completes a TLS handshake with host, and returns the leaf certificate it serves. The certificate is
+// verified, so a host whose certificate already expired fails.
+func dialCertificate(ctx context.Context, host string) (*x509.Certificate, error) {
+	dialer := tls.Dialer{NetDialer: &net.Dialer{Timeout: dialTimeout}}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dialing %s", host)
+	}
+	defer conn.Close()
+	certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("%s served no certificate", host)
+	}
+	return certificates[0], nil
+}
+
+// ListExpiries returns the keys, certificates, and credentials of the default tenant, and of the configured tenants,
+// that expire within the warning period, soonest first. Whatever already expired isn't listed.
+func (s Service) ListExpiries(ctx context.Context) (*ListExpiriesResponse, error) {
+	now := s.Clock.Now()
+	until := now.Add(s.config.WarnBefore)
+	expiries := make([]Expiry, 0)
+	for _, tenant := range append([]string{""}, s.config.Tenants...) {
+		tenantCtx := storage.WithTenant(ctx, tenant)
+		tenantExpiries, err := s.listKeys(tenantCtx, tenant)
+		if err != nil {
+			return nil, err
+		}
+		certificates, err := s.listCertificates(tenantCtx, tenant)
+		if err != nil {
+			return nil, err
+		}
+		credentials, err := s.listCredentials(tenantCtx, tenant)
+		if err != nil {
+			return nil, err
+		}
+		tenantExpiries = append(tenantExpiries, certificates...)
+		tenantExpiries = append(tenantExpiries, credentials...)
+		for _, expiry := range tenantExpiries {
+			if expiry.ExpiresAt.After(now) && !expiry.ExpiresAt.After(until) {
+				expiries = append(expiries, expiry)
+			}
+		}
+	}
+	sort.SliceStable(expiries, func(i, j int) bool {
+		return expiries[i].ExpiresAt.Before(expiries[j].ExpiresAt)
+	})
+	return &ListExpiriesResponse{Expiries: expiries}, nil
+}
+
+// listKeys returns when the keys of the tenant of ctx that aren't revoked are due to be rotated, when keys have a
+// maximum age.
+func (s Service) listKeys(ctx context.Context, tenant string) ([]Expiry, error) {
+	if s.config.KeyMaxAge == 0 {
+		return nil, nil
+	}
+	keys, err := s.keyStore.ListKeyDetails(ctx, keystore.ListKeyDetailsRequest{PageSize: -1})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list keys")
+	}
+	var expiries []Expiry
+	for _, key := range keys.Keys {
+		if key.Revoked {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, key.CreatedAt)
+		if err != nil {
+			logrus.WithError(err).Warnf("parsing when key %s was created", key.ID)
+			continue
+		}
+		expiries = append(expiries, Expiry{Kind: KindKey, Tenant: tenant, ID: key.ID, ExpiresAt: createdAt.Add(s.config.KeyMaxAge)})
+	}
+	return expiries, nil
+}
+
+// listCertificates returns when the certificates of the hosts the did:web DIDs of the tenant of ctx are resolved from
+// expire. Hosts that can't be reached are logged, and skipped.
+func (s Service) listCertificates(ctx context.Context, tenant string) ([]Expiry, error) {
+	dids, err := s.dids.ListDIDsDefault(ctx, didsdk.WebMethod.String())
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list did:web DIDs")
+	}
+	notAfter := make(map[string]*time.Time)
+	var expiries []Expiry
+	for _, stored := range dids {
+		if stored.SoftDeleted {
+			continue
+		}
+		docURL, err := web.DIDWeb(stored.ID).GetDocURL()
+		if err != nil {
+			logrus.WithError(err).Warnf("getting the document url of %s", stored.ID)
+			continue
+		}
+		u, err := url.Parse(docURL)
+		if err != nil {
+			logrus.WithError(err).Warnf("parsing the document url of %s", stored.ID)
+			continue
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		if _, ok := notAfter[host]; !ok {
+			notAfter[host] = nil
+			certificate, err := s.certificate(ctx, host)
+			if err != nil {
+				logrus.WithError(err).Warnf("getting the certificate of %s, the host of %s", host, stored.ID)
+			} else {
+				notAfter[host] = &certificate.NotAfter
+			}
+		}
+		if notAfter[host] == nil {
+			continue
+		}
+		expiries = append(expiries, Expiry{Kind: KindCertificate, Tenant: tenant, ID: stored.ID, Host: host, ExpiresAt: *notAfter[host]})
+	}
+	return expiries, nil
+}
+
+// listCredentials returns when the credentials issued in the tenant of ctx that aren't revoked expire.
+func (s Service) listCredentials(ctx context.Context, tenant string) ([]Expiry, error) {
+	credentials, err := s.credentials.ListCredentials(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list credentials")
+	}
+	var expiries []Expiry
+	for _, stored := range credentials {
+		if stored.Revoked {
+			continue
+		}
+		if expiresAt, ok := credentialExpiresAt(stored); ok {
+			expiries = append(expiries, Expiry{Kind: KindCredential, Tenant: tenant, ID: stored.LocalCredentialID, ExpiresAt: expiresAt})
+		}
+	}
+	return expiries, nil
+}
+
+// credentialExpiresAt returns when a stored credential expires, unless it has no expiration date. Expiration dates
+// that can't be parsed are logged.
+func credentialExpiresAt(stored credential.StoredCredential) (time.Time, bool) {
+	cred := stored.Credential
+	if cred == nil && stored.CredentialJWT != nil {
+		var err error
+		if _, _, cred, err = integrity.ParseVerifiableCredentialFromJWT(stored.CredentialJWT.String()); err != nil {
+			logrus.WithError(err).Warnf("parsing credential %s", stored.LocalCredentialID)
+			return time.Time{}, false
+		}
+	}
+	if cred == nil || cred.ExpirationDate == "" {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, cred.ExpirationDate)
+	if err != nil {
+		logrus.WithError(err).Warnf("parsing the expiration date of credential %s", stored.LocalCredentialID)
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// Expire records the credentials of the default tenant, and of the configured tenants, whose expiration date passed as
+// expired, suspending those whose status list is for suspension, and publishes each to the Credential Expire webhooks
+// of its tenant. Credentials that are revoked, or were recorded as expired before, are skipped, and those that can't be
+// expired are logged, so that one doesn't hold up the others.
+func (s Service) Expire(ctx context.Context) (*Sweep, error) {
+	if s.expireCredential == nil {
+		return nil, sdkutil.LoggingNewError("no credential service configured to expire credentials")
+	}
+	sweep := Sweep{SweptAt: s.Clock.Now(), Expired: make([]Expiry, 0)}
+	for _, tenant := range append([]string{""}, s.config.Tenants...) {
+		tenantCtx := storage.WithTenant(ctx, tenant)
+		credentials, err := s.credentials.ListCredentials(tenantCtx)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not list credentials")
+		}
+		for _, stored := range credentials {
+			if stored.Revoked || stored.Expired {
+				continue
+			}
+			expiresAt, ok := credentialExpiresAt(stored)
+			if !ok || expiresAt.After(sweep.SweptAt) {
+				continue
+			}
+			resp, err := s.expireCredential(tenantCtx, credential.ExpireCredentialRequest{ID: stored.LocalCredentialID})
+			if err != nil {
+				logrus.WithError(err).Errorf("expiring credential %s", stored.LocalCredentialID)
+				continue
+			}
+			if !resp.Changed {
+				continue
+			}
+			sweep.Expired = append(sweep.Expired, Expiry{Kind: KindCredential, Tenant: tenant, ID: stored.LocalCredentialID, ExpiresAt: expiresAt})
+			s.publishExpired(tenantCtx, resp.Container)
+		}
+	}
+	sort.SliceStable(sweep.Expired, func(i, j int) bool {
+		return sweep.Expired[i].ExpiresAt.Before(sweep.Expired[j].ExpiresAt)
+	})
+	return &sweep, nil
+}
+
+// publishExpired publishes an expired credential to the Credential Expire webhooks of the tenant of ctx. Failures are
+// logged.
+func (s Service) publishExpired(ctx context.Context, container credint.Container) {
+	if s.publish == nil {
+		return
+	}
+	data, err := json.Marshal(container)
+	if err != nil {
+		logrus.WithError(err).Errorf("marshalling expired credential %s", container.ID)
+		return
+	}
+	s.publish(ctx, webhook.Credential, webhook.Expire, data)
+}
+
+// Warn warns of the expiries within the warning period that weren't warned of before, and returns the warning, whose
+// expiries are empty when there was nothing new to warn of. A key, certificate, or credential whose expiry changed,
+// e.g. a renewed certificate, is warned of again when its new expiry comes within the warning period.
+func (s Service) Warn(ctx context.Context) (*Warning, error) {
+	resp, err := s.ListExpiries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	warned, err := s.storage.ListWarned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	warning := Warning{ID: uuid.NewString(), WarnedAt: s.Clock.Now(), Expiries: make([]Expiry, 0)}
+	for _, expiry := range resp.Expiries {
+		if previous, ok := warned[warnedKey(expiry)]; ok && previous.ExpiresAt.Equal(expiry.ExpiresAt) {
+			continue
+		}
+		warning.Expiries = append(warning.Expiries, expiry)
+	}
+	if len(warning.Expiries) > 0 {
+		s.send(ctx, warning)
+		for _, expiry := range warning.Expiries {
+			if err = s.storage.StoreWarned(ctx, expiry); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// forget what expired, so that what's warned of is kept from growing
+	for key, expiry := range warned {
+		if expiry.ExpiresAt.Before(warning.WarnedAt) {
+			if err = s.storage.DeleteWarned(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &warning, nil
+}
+
+// send logs the warning, posts it to the alert URLs, and emails it. Failures are logged.
+func (s Service) send(ctx context.Context, warning Warning) {
+	for _, expiry := range warning.Expiries {
+		logrus.WithFields(logrus.Fields{
+			"kind":      expiry.Kind,
+			"tenant":    expiry.Tenant,
+			"id":        expiry.ID,
+			"expiresAt": expiry.ExpiresAt,
+		}).Warn("expiring soon")
+	}
+	if len(s.config.AlertURLs) > 0 {
+		warningBytes, err := json.Marshal(warning)
+		if err != nil {
+			logrus.WithError(err).Error("marshalling expiry warning")
+			return
+		}
+		postCtx, cancel := context.WithTimeout(ctx, warnTimeout)
+		defer cancel()
+		for _, alertURL := range s.config.AlertURLs {
+			if err = s.post(postCtx, alertURL, warningBytes); err != nil {
+				logrus.WithError(err).Errorf("posting expiry warning to %s", alertURL)
+			}
+		}
+	}
+	if s.config.Email.SMTPAddress != "" {
+		if err := s.email(warning); err != nil {
+			logrus.WithError(err).Errorf("emailing expiry warning through %s", s.config.Email.SMTPAddress)
+		}
+	}
+}
+
+func (s Service) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "building http req")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "posting warning")
+	}
+	defer resp.Body.Close()
+	if !util.Is2xxResponse(resp.StatusCode) {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status code %v not in the 200s. body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// email sends the warning as a plain text email, listing each expiry on a line.
+func (s Service) email(warning Warning) error {
+	emailConfig := s.config.Email
+	var auth smtp.Auth
+	if emailConfig.Username != "" {
+		host, _, _ := net.SplitHostPort(emailConfig.SMTPAddress)
+		auth = smtp.PlainAuth("", emailConfig.Username, emailConfig.Password, host)
+	}
+	var msg strings.Builder
+	msg.WriteString("From: " + emailConfig.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(emailConfig.To, ", ") + "\r\n")
+	msg.WriteString(fmt.Sprintf("Subject: %d keys, certificates, or credentials expire soon\r\n", len(warning.Expiries)))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	for _, expiry := range warning.Expiries {
+		line := fmt.Sprintf("%s %s expires at %s", expiry.Kind, expiry.ID, expiry.ExpiresAt.UTC().Format(time.RFC3339))
+		if expiry.Host != "" {
+			line = fmt.Sprintf("certificate of %s, the host of %s, expires at %s", expiry.Host, expiry.ID, expiry.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		if expiry.Tenant != "" {
+			line += fmt.Sprintf(" (tenant %s)", expiry.Tenant)
+		}
+		msg.WriteString(line + "\r\n")
+	}
+	return s.sendMail(emailConfig.SMTPAddress, auth, emailConfig.From, emailConfig.To, []byte(msg.String()))
+}
+
+// RunSchedule checks expiries every interval, and warns of those that come within the warning period, until ctx is
+// done. Credentials that expired are recorded as expired first, when configured. Instances sharing the storage take
+// turns, so that each scheduled check is made once.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.config.Interval, s.checkScheduled)
+}
+
+func (s Service) checkScheduled() {
+	ctx := context.Background()
+	claimed, err := schedule.Claim(ctx, s.storage.db, scheduleNamespace, scheduledKey, s.Clock.Now(), s.config.Interval)
+	if err != nil {
+		logrus.WithError(err).Error("claiming scheduled expiry check")
+		return
+	}
+	if !claimed {
+		return
+	}
+	if s.config.ExpireCredentials {
+		sweep, err := s.Expire(ctx)
+		if err != nil {
+			logrus.WithError(err).Error("expiring credentials")
+		} else {
+			logrus.Infof("expired %d credentials", len(sweep.Expired))
+		}
+	}
+	warning, err := s.Warn(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("checking expiries")
+		return
+	}
+	logrus.WithField("warning", warning.ID).Infof("warned of %d expiries", len(warning.Expiries))
+}
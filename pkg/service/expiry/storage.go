@@ -0,0 +1,69 @@
+package expiry
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// warnedNamespace holds the expiries that were warned of, keyed by warnedKey, in the storage of the deployment.
+	warnedNamespace = "expiry-warned"
+	// scheduleNamespace holds when the last scheduled check was claimed, in the storage of the deployment.
+	scheduleNamespace = "expiry-schedule"
+	scheduledKey      = "scheduled"
+)
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewExpiryStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func warnedKey(expiry Expiry) string {
+	return storage.Join(expiry.Tenant, string(expiry.Kind), expiry.ID)
+}
+
+// ListWarned returns the expiries that were warned of, keyed by warnedKey.
+func (s *Storage) ListWarned(ctx context.Context) (map[string]Expiry, error) {
+	warned := make(map[string]Expiry)
+	err := s.db.Iterate(ctx, warnedNamespace, func(key string, expiryBytes []byte) (bool, error) {
+		var expiry Expiry
+		if err := json.Unmarshal(expiryBytes, &expiry); err != nil {
+			logrus.WithError(err).Warnf("unmarshal warned expiry: %s", key)
+			return true, nil
+		}
+		warned[key] = expiry
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list warned expiries")
+	}
+	return warned, nil
+}
+
+// StoreWarned stores that an expiry was warned of, so that it isn't warned of again.
+func (s *Storage) StoreWarned(ctx context.Context, expiry Expiry) error {
+	expiryBytes, err := json.Marshal(expiry)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not marshal warned expiry: %s", expiry.ID)
+	}
+	return s.db.Write(ctx, warnedNamespace, warnedKey(expiry), expiryBytes)
+}
+
+func (s *Storage) DeleteWarned(ctx context.Context, key string) error {
+	if err := s.db.Delete(ctx, warnedNamespace, key); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete warned expiry: %s", key)
+	}
+	return nil
+}
@@ -18,6 +18,26 @@ const (
 	Operation        Type = "operation"
 	Webhook          Type = "webhook"
 	DIDConfiguration Type = "did_configuration"
+	Auth             Type = "auth"
+	Audit            Type = "audit"
+	Erasure          Type = "erasure"
+	Usage            Type = "usage"
+	Transparency     Type = "transparency"
+	Backup           Type = "backup"
+	Anomaly          Type = "anomaly"
+	Stats            Type = "stats"
+	Retention        Type = "retention"
+	Approval         Type = "approval"
+	Anchor           Type = "anchor"
+	Expiry           Type = "expiry"
+	Replay           Type = "replay"
+	Challenge        Type = "challenge"
+	Trust            Type = "trust"
+	OIDC4VCI         Type = "oidc4vci"
+	OIDC4VP          Type = "oidc4vp"
+
+	// Storage is not a service, but reports on the connectivity of the storage provider all services depend on.
+	Storage Type = "storage"
 
 	StatusReady    StatusState = "ready"
 	StatusNotReady StatusState = "not_ready"
@@ -0,0 +1,71 @@
+package framework
+
+import (
+	"context"
+	"sync"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Drainer is implemented by services with work running in the background, like webhook deliveries or asynchronous
+// operations, which they finish, or give up on, when the service shuts down.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// ShutdownHook finishes the work of a component when the service shuts down, returning when it's done or when ctx is.
+type ShutdownHook func(ctx context.Context) error
+
+// ShutdownHooks are run when the service shuts down, once it stopped accepting requests. Each hook is registered for
+// a component, which names it in logs and errors.
+type ShutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+type shutdownHook struct {
+	component Type
+	hook      ShutdownHook
+}
+
+// Register adds hook to the hooks run for component. Hooks run in the order they're registered, so a component whose
+// work produces work for another, like operations publishing webhooks, is registered before it.
+func (h *ShutdownHooks) Register(component Type, hook ShutdownHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, shutdownHook{component: component, hook: hook})
+}
+
+// Components returns the components hooks are registered for, in the order their hooks run.
+func (h *ShutdownHooks) Components() []Type {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	components := make([]Type, 0, len(h.hooks))
+	for _, registered := range h.hooks {
+		components = append(components, registered.component)
+	}
+	return components
+}
+
+// Run runs every hook in turn, within ctx. A hook that fails doesn't keep the following ones from running, and the
+// errors of all of them are returned together.
+func (h *ShutdownHooks) Run(ctx context.Context) error {
+	h.mu.Lock()
+	hooks := append([]shutdownHook(nil), h.hooks...)
+	h.mu.Unlock()
+
+	errs := sdkutil.NewAppendError()
+	for _, registered := range hooks {
+		if err := registered.hook(ctx); err != nil {
+			errs.Append(errors.Wrapf(err, "shutting down %s", registered.component))
+			continue
+		}
+		logrus.WithField("component", registered.component).Debug("shut down")
+	}
+	if !errs.IsEmpty() {
+		return errs.Error()
+	}
+	return nil
+}
@@ -66,18 +66,18 @@ func (s Storage) DeleteIssuanceTemplate(ctx context.Context, id string) error {
 }
 
 func (s Storage) ListIssuanceTemplates(ctx context.Context) ([]Template, error) {
-	m, err := s.db.ReadAll(ctx, namespace)
+	ts := make([]Template, 0)
+	err := s.db.Iterate(ctx, namespace, func(k string, v []byte) (bool, error) {
+		var t Template
+		if err := json.Unmarshal(v, &t); err != nil {
+			return false, errors.Wrapf(err, "unmarshalling template with key <%s>", k)
+		}
+		ts = append(ts, t)
+		return true, nil
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "reading all")
 	}
-	ts := make([]Template, len(m))
-	i := 0
-	for k, v := range m {
-		if err = json.Unmarshal(v, &ts[i]); err != nil {
-			return nil, errors.Wrapf(err, "unmarshalling template with key <%s>", k)
-		}
-		i++
-	}
 	return ts, nil
 }
 
@@ -85,19 +85,19 @@ func (s Storage) GetIssuanceTemplatesByManifestID(ctx context.Context, manifestI
 	if manifestID == "" {
 		return nil, errors.New("cannot find issuance template without a manifest ID")
 	}
-	ms, err := s.db.ReadAll(ctx, namespace)
-	if err != nil {
-		return nil, errors.Wrap(err, "reading all values")
-	}
 	var ts []StoredIssuanceTemplate
-	for key, data := range ms {
+	err := s.db.Iterate(ctx, namespace, func(key string, data []byte) (bool, error) {
 		var sit StoredIssuanceTemplate
 		if err := json.Unmarshal(data, &sit); err != nil {
-			return nil, errors.Wrapf(err, "unmarshalling <%s>", key)
+			return false, errors.Wrapf(err, "unmarshalling <%s>", key)
 		}
 		if sit.IssuanceTemplate.CredentialManifest == manifestID {
 			ts = append(ts, sit)
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "reading all values")
 	}
 	return ts, nil
 }
@@ -0,0 +1,87 @@
+package keystore
+
+import (
+	"context"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/key"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// DIDKey is a key of the keystore that's identified by a did:key, so that what it signs can be verified without asking
+// the service for it. Logs, like the audit and transparency logs, sign with one.
+type DIDKey struct {
+	DID string `json:"did"`
+
+	// ID of the verification method of the key, which is the kid header of what it signs.
+	KeyID string `json:"keyId"`
+}
+
+// GetDIDKey returns the did:key stored under name in namespace, or nil before one is created.
+func GetDIDKey(ctx context.Context, db storage.ServiceStorage, namespace, name string) (*DIDKey, error) {
+	keyBytes, err := db.Read(ctx, namespace, name)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get did:key<%s>", name)
+	}
+	if len(keyBytes) == 0 {
+		return nil, nil
+	}
+	var didKey DIDKey
+	if err = json.Unmarshal(keyBytes, &didKey); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling did:key<%s>", name)
+	}
+	return &didKey, nil
+}
+
+// GetOrCreateDIDKey returns the did:key stored under name in namespace, creating an Ed25519 one the first time, whose
+// private key is stored in the keystore. It's stored in a transaction that watches it, so that every instance sharing
+// the storage uses the same key. When instances create one at the same time, the keys of all but one of them go unused.
+func (s Service) GetOrCreateDIDKey(ctx context.Context, db storage.ServiceStorage, namespace, name string) (*DIDKey, error) {
+	didKey, err := GetDIDKey(ctx, db, namespace, name)
+	if err != nil || didKey != nil {
+		return didKey, err
+	}
+	privKey, generated, err := key.GenerateDIDKey(crypto.Ed25519)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating did:key")
+	}
+	expanded, err := generated.Expand()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "expanding did:key")
+	}
+	privKeyBytes, err := crypto.PrivKeyToBytes(privKey)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "encoding did:key")
+	}
+	didKey = &DIDKey{DID: generated.String(), KeyID: expanded.VerificationMethod[0].ID}
+	if err = s.StoreKey(ctx, StoreKeyRequest{
+		ID:               didKey.KeyID,
+		Type:             crypto.Ed25519,
+		Controller:       didKey.DID,
+		PrivateKeyBase58: base58.Encode(privKeyBytes),
+	}); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "storing did:key")
+	}
+
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: name}}
+	result, err := db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		stored, err := GetDIDKey(ctx, db, namespace, name)
+		if err != nil || stored != nil {
+			return stored, err
+		}
+		keyBytes, err := json.Marshal(didKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling did:key")
+		}
+		return didKey, tx.Write(ctx, namespace, name, keyBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not store did:key<%s>", name)
+	}
+	return result.(*DIDKey), nil
+}
@@ -0,0 +1,217 @@
+package keystore
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+)
+
+// importableKeyTypes are the types of the keys that can be imported as JWKs or PEM.
+var importableKeyTypes = map[crypto.KeyType]bool{
+	crypto.SECP256k1: true,
+	crypto.P256:      true,
+	crypto.P384:      true,
+	crypto.RSA:       true,
+	crypto.Ed25519:   true,
+}
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSECP256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ImportPrivateKeyJWK reads a private key generated outside the service, given as a JWK, and returns its type and its
+// base58 encoding, as keys are stored. The key must be of keyType, when it's set, and the public key the JWK has must
+// be that of its private key.
+func ImportPrivateKeyJWK(jwk jwx.PrivateKeyJWK, keyType crypto.KeyType) (crypto.KeyType, string, error) {
+	if jwk.D == "" {
+		return "", "", errors.New("jwk has no private key")
+	}
+	var key gocrypto.PrivateKey
+	if jwk.KTY == "EC" && jwk.CRV == string(crypto.SECP256k1) {
+		// parsed by hand, since the JWK library only reads secp256k1 keys when built with the jwx_es256k tag
+		d, err := base64.RawURLEncoding.DecodeString(jwk.D)
+		if err != nil {
+			return "", "", errors.Wrap(err, "decoding secp256k1 private key")
+		}
+		if key, err = secpPrivateKey(d); err != nil {
+			return "", "", err
+		}
+	} else {
+		parsed, err := jwk.ToPrivateKey()
+		if err != nil {
+			return "", "", errors.Wrap(err, "reading jwk")
+		}
+		key = parsed
+	}
+	if err := checkPublicJWK(jwk, key); err != nil {
+		return "", "", err
+	}
+	return importPrivateKey(key, keyType)
+}
+
+// ImportPrivateKeyPEM reads a private key generated outside the service, given as PEM, and returns its type and its
+// base58 encoding, as keys are stored. Keys are read from PKCS #8 blocks, SEC 1 EC PRIVATE KEY blocks, and PKCS #1 RSA
+// PRIVATE KEY blocks, which must not be encrypted, and must be of keyType, when it's set.
+func ImportPrivateKeyPEM(pemKey string, keyType crypto.KeyType) (crypto.KeyType, string, error) {
+	rest := []byte(pemKey)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return "", "", errors.New("no private key in pem")
+		}
+
+		var key gocrypto.PrivateKey
+		var err error
+		switch block.Type {
+		case "EC PARAMETERS":
+			// openssl writes the curve before the key
+			continue
+		case "PRIVATE KEY":
+			if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+				key, err = parsePKCS8SECP256k1(block.Bytes)
+			}
+		case "EC PRIVATE KEY":
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				key, err = parseSEC1SECP256k1(block.Bytes)
+			}
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "ENCRYPTED PRIVATE KEY":
+			return "", "", errors.New("encrypted private keys can't be imported, decrypt the key first")
+		default:
+			return "", "", errors.Errorf("unsupported pem block: %s", block.Type)
+		}
+		if err != nil {
+			return "", "", errors.Wrapf(err, "reading %s", block.Type)
+		}
+		return importPrivateKey(key, keyType)
+	}
+}
+
+// importPrivateKey checks that a private key is of a type that can be imported, and of keyType when it's set, and
+// encodes it as keys are stored.
+func importPrivateKey(key gocrypto.PrivateKey, keyType crypto.KeyType) (crypto.KeyType, string, error) {
+	actualType, err := crypto.GetKeyTypeFromPrivateKey(key)
+	if err != nil {
+		return "", "", errors.Wrap(err, "getting key type")
+	}
+	if actualType == crypto.SECP256k1ECDSA {
+		// stored like the secp256k1 keys the service generates, which the bytes of both are read as
+		actualType = crypto.SECP256k1
+	}
+	if !importableKeyTypes[actualType] {
+		return "", "", errors.Errorf("keys of type %s can't be imported", actualType)
+	}
+	if keyType != "" && keyType != actualType {
+		return "", "", errors.Errorf("key is of type %s, not %s", actualType, keyType)
+	}
+	if rsaKey, ok := key.(rsa.PrivateKey); ok {
+		if err = rsaKey.Validate(); err != nil {
+			return "", "", errors.Wrap(err, "invalid rsa key")
+		}
+	}
+	keyBytes, err := crypto.PrivKeyToBytes(key)
+	if err != nil {
+		return "", "", errors.Wrap(err, "encoding private key")
+	}
+	return actualType, base58.Encode(keyBytes), nil
+}
+
+// checkPublicJWK checks that the public key a JWK has, when it has one, is that of its private key, which the JWK
+// library doesn't. The public key of RSA keys is checked when the key is validated.
+func checkPublicJWK(jwk jwx.PrivateKeyJWK, key gocrypto.PrivateKey) error {
+	var x, y []byte
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		x = k.Public().(ed25519.PublicKey)
+	case ecdsa.PrivateKey:
+		// the JWK library takes the public key of EC keys from the JWK, rather than from their private key
+		curves := map[elliptic.Curve]ecdh.Curve{elliptic.P256(): ecdh.P256(), elliptic.P384(): ecdh.P384(), elliptic.P521(): ecdh.P521()}
+		curve, ok := curves[k.Curve]
+		if !ok {
+			return errors.Errorf("unsupported curve: %s", k.Curve.Params().Name)
+		}
+		ecdhKey, err := curve.NewPrivateKey(k.D.FillBytes(make([]byte, (k.Curve.Params().BitSize+7)/8)))
+		if err != nil {
+			return errors.Wrap(err, "reading ec private key")
+		}
+		point := ecdhKey.PublicKey().Bytes()[1:]
+		x, y = point[:len(point)/2], point[len(point)/2:]
+	case secp.PrivateKey:
+		public := k.PubKey()
+		x, y = public.X().FillBytes(make([]byte, 32)), public.Y().FillBytes(make([]byte, 32))
+	default:
+		return nil
+	}
+	if jwk.X != "" && jwk.X != base64.RawURLEncoding.EncodeToString(x) {
+		return errors.New("jwk's public key doesn't match its private key")
+	}
+	if y != nil && jwk.Y != "" && jwk.Y != base64.RawURLEncoding.EncodeToString(y) {
+		return errors.New("jwk's public key doesn't match its private key")
+	}
+	return nil
+}
+
+// sec1PrivateKey is an EC PRIVATE KEY, as RFC 5915 defines it.
+type sec1PrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// parseSEC1SECP256k1 reads a secp256k1 key from an EC PRIVATE KEY, since the x509 package only reads NIST curves.
+func parseSEC1SECP256k1(der []byte) (gocrypto.PrivateKey, error) {
+	var key sec1PrivateKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, errors.Wrap(err, "parsing ec private key")
+	}
+	if key.NamedCurveOID != nil && !key.NamedCurveOID.Equal(oidSECP256k1) {
+		return nil, errors.Errorf("unsupported curve: %s", key.NamedCurveOID)
+	}
+	return secpPrivateKey(key.PrivateKey)
+}
+
+// parsePKCS8SECP256k1 reads a secp256k1 key from a PKCS #8 private key.
+func parsePKCS8SECP256k1(der []byte) (gocrypto.PrivateKey, error) {
+	var key struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, errors.Wrap(err, "parsing pkcs8 private key")
+	}
+	var curve asn1.ObjectIdentifier
+	if !key.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.Errorf("unsupported algorithm: %s", key.Algo.Algorithm)
+	}
+	if _, err := asn1.Unmarshal(key.Algo.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidSECP256k1) {
+		return nil, errors.New("unsupported curve")
+	}
+	return parseSEC1SECP256k1(key.PrivateKey)
+}
+
+func secpPrivateKey(d []byte) (gocrypto.PrivateKey, error) {
+	if len(d) == 0 || len(d) > 32 || new(big.Int).SetBytes(d).Sign() == 0 {
+		return nil, errors.New("invalid secp256k1 private key")
+	}
+	return *secp.PrivKeyFromBytes(d), nil
+}
@@ -0,0 +1,125 @@
+package keystore
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+func TestImportPrivateKey(t *testing.T) {
+	for _, keyType := range []crypto.KeyType{crypto.SECP256k1, crypto.P256, crypto.P384, crypto.RSA, crypto.Ed25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			_, privKey, err := crypto.GenerateKeyByKeyType(keyType)
+			require.NoError(t, err)
+			jwk := privateKeyJWK(t, privKey)
+			pemKey := privateKeyPEM(t, privKey)
+
+			for name, imported := range map[string]func(crypto.KeyType) (crypto.KeyType, string, error){
+				"jwk": func(kt crypto.KeyType) (crypto.KeyType, string, error) { return ImportPrivateKeyJWK(jwk, kt) },
+				"pem": func(kt crypto.KeyType) (crypto.KeyType, string, error) { return ImportPrivateKeyPEM(pemKey, kt) },
+			} {
+				// the type is read from the key, and stored keys are read back as the same key
+				gotType, gotBase58, err := imported("")
+				require.NoError(t, err, name)
+				assert.Equal(t, keyType, gotType, name)
+				keyBytes, err := base58.Decode(gotBase58)
+				require.NoError(t, err)
+				stored, err := keyaccess.BytesToPrivKey(keyBytes, gotType)
+				require.NoError(t, err, name)
+				storedBytes, err := crypto.PrivKeyToBytes(stored)
+				require.NoError(t, err)
+				wantBytes, err := crypto.PrivKeyToBytes(privKey)
+				require.NoError(t, err)
+				assert.Equal(t, wantBytes, storedBytes, name)
+
+				_, _, err = imported(keyType)
+				assert.NoError(t, err, name)
+				_, _, err = imported(crypto.X25519)
+				assert.ErrorContains(t, err, "key is of type "+string(keyType)+", not X25519", name)
+			}
+		})
+	}
+
+	t.Run("public key of the jwk must be that of its private key", func(t *testing.T) {
+		_, privKey, err := crypto.GenerateKeyByKeyType(crypto.P256)
+		require.NoError(t, err)
+		_, otherKey, err := crypto.GenerateKeyByKeyType(crypto.P256)
+		require.NoError(t, err)
+		jwk := privateKeyJWK(t, privKey)
+		jwk.X = privateKeyJWK(t, otherKey).X
+		_, _, err = ImportPrivateKeyJWK(jwk, "")
+		assert.ErrorContains(t, err, "doesn't match")
+
+		jwk.D = ""
+		_, _, err = ImportPrivateKeyJWK(jwk, "")
+		assert.ErrorContains(t, err, "no private key")
+	})
+
+	t.Run("keys of other types can't be imported", func(t *testing.T) {
+		_, privKey, err := crypto.GenerateKeyByKeyType(crypto.P521)
+		require.NoError(t, err)
+		_, _, err = ImportPrivateKeyPEM(privateKeyPEM(t, privKey), "")
+		assert.ErrorContains(t, err, "keys of type P-521 can't be imported")
+	})
+
+	t.Run("pem must have an unencrypted private key", func(t *testing.T) {
+		_, _, err := ImportPrivateKeyPEM("not pem", "")
+		assert.ErrorContains(t, err, "no private key")
+		encrypted := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{0}})
+		_, _, err = ImportPrivateKeyPEM(string(encrypted), "")
+		assert.ErrorContains(t, err, "encrypted")
+		public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{0}})
+		_, _, err = ImportPrivateKeyPEM(string(public), "")
+		assert.ErrorContains(t, err, "unsupported pem block")
+	})
+}
+
+// privateKeyJWK exports a private key as a JWK. secp256k1 keys are exported by hand, since the JWK library only exports
+// them when built with the jwx_es256k tag.
+func privateKeyJWK(t *testing.T, privKey gocrypto.PrivateKey) jwx.PrivateKeyJWK {
+	if secpKey, ok := privKey.(secp.PrivateKey); ok {
+		public := secpKey.PubKey()
+		return jwx.PrivateKeyJWK{
+			KTY: "EC",
+			CRV: string(crypto.SECP256k1),
+			X:   base64.RawURLEncoding.EncodeToString(public.X().FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(public.Y().FillBytes(make([]byte, 32))),
+			D:   base64.RawURLEncoding.EncodeToString(secpKey.Serialize()),
+		}
+	}
+	_, jwk, err := jwx.PrivateKeyToPrivateKeyJWK("", privKey)
+	require.NoError(t, err)
+	return *jwk
+}
+
+// privateKeyPEM exports a private key as PEM, as openssl does: PKCS #8, except for secp256k1 keys, which are SEC 1.
+func privateKeyPEM(t *testing.T, privKey gocrypto.PrivateKey) string {
+	if secpKey, ok := privKey.(secp.PrivateKey); ok {
+		der, err := asn1.Marshal(sec1PrivateKey{Version: 1, PrivateKey: secpKey.Serialize(), NamedCurveOID: oidSECP256k1})
+		require.NoError(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	}
+	switch k := privKey.(type) {
+	case ecdsa.PrivateKey:
+		privKey = &k
+	case rsa.PrivateKey:
+		privKey = &k
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
@@ -0,0 +1,394 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+const (
+	awsKMSPrefix  = "aws-kms://"
+	gcpKMSPrefix  = "gcp-kms://"
+	hcVaultPrefix = "hcvault://"
+)
+
+// KeyManager holds private keys outside the service, in a KMS or an HSM, and signs with them there, so that their
+// private key material never reaches the service or its storage.
+type KeyManager interface {
+	// PublicKey reads the public key of the key with the name.
+	PublicKey(ctx context.Context, name string) (gocrypto.PublicKey, error)
+
+	// Sign signs with the key with the name, like a crypto.Signer: digest is the message hashed with opts.HashFunc(),
+	// or the message itself for Ed25519 keys, and ECDSA signatures are ASN.1 DER encoded.
+	Sign(ctx context.Context, name string, digest []byte, opts gocrypto.SignerOpts) ([]byte, error)
+}
+
+// NewKeyManager creates the key manager holding the key of a URI, and returns it with the name of the key in it. Keys
+// are named like tink names master keys: aws-kms://arn:aws:kms:<region>:<account>:key/<id> for AWS KMS,
+// gcp-kms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v> for GCP Cloud KMS, and
+// hcvault://<host>:<port>/<mount>/keys/<name> for HashiCorp Vault Transit. credentialsPath is an AWS shared
+// credentials file for aws-kms, a service account JSON file for gcp-kms, and a file holding a Vault token for
+// hcvault. When it's empty, the credentials of the environment are used.
+func NewKeyManager(ctx context.Context, keyURI, credentialsPath string) (KeyManager, string, error) {
+	switch {
+	case strings.HasPrefix(keyURI, awsKMSPrefix):
+		arn := strings.TrimPrefix(keyURI, awsKMSPrefix)
+		parts := strings.Split(arn, ":")
+		if len(parts) < 6 || parts[0] != "arn" {
+			return nil, "", errors.Errorf("aws kms key %q is not a key ARN", arn)
+		}
+		cfg := aws.Config{Region: aws.String(parts[3])}
+		if credentialsPath != "" {
+			cfg.Credentials = credentials.NewSharedCredentials(credentialsPath, "default")
+		}
+		sess, err := session.NewSession(&cfg)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "creating aws session")
+		}
+		return newAWSKeyManager(kms.New(sess)), arn, nil
+	case strings.HasPrefix(keyURI, gcpKMSPrefix):
+		name := strings.TrimPrefix(keyURI, gcpKMSPrefix)
+		if !strings.Contains(name, "/cryptoKeyVersions/") {
+			return nil, "", errors.Errorf("gcp kms key %q is not a key version", name)
+		}
+		var opts []option.ClientOption
+		if credentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(credentialsPath))
+		}
+		client, err := cloudkms.NewService(ctx, opts...)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "creating gcp kms client")
+		}
+		return gcpKeyManager{client: client}, name, nil
+	case strings.HasPrefix(keyURI, hcVaultPrefix):
+		u, err := url.Parse(keyURI)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "parsing vault key")
+		}
+		name := strings.Trim(u.Path, "/")
+		if u.Host == "" || !strings.Contains(name, "/keys/") {
+			return nil, "", errors.Errorf("vault key %q is not a transit key", keyURI)
+		}
+		token := os.Getenv("VAULT_TOKEN")
+		if credentialsPath != "" {
+			tokenBytes, err := os.ReadFile(credentialsPath)
+			if err != nil {
+				return nil, "", errors.Wrap(err, "reading vault token")
+			}
+			token = strings.TrimSpace(string(tokenBytes))
+		}
+		return vaultKeyManager{address: "https://" + u.Host, token: token, client: http.DefaultClient}, name, nil
+	default:
+		return nil, "", errors.Errorf("key %q isn't held by a supported key manager, use aws-kms, gcp-kms, or hcvault", keyURI)
+	}
+}
+
+// externalKey is a key held by a key manager, which signs there.
+type externalKey struct {
+	ctx     context.Context
+	manager KeyManager
+	name    string
+	uri     string
+	public  gocrypto.PublicKey
+}
+
+var _ keyaccess.ExternalKey = (*externalKey)(nil)
+
+func (k externalKey) Public() gocrypto.PublicKey {
+	return k.public
+}
+
+func (k externalKey) Sign(_ io.Reader, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	signature, err := k.manager.Sign(k.ctx, k.name, digest, opts)
+	return signature, errors.Wrapf(err, "signing with key<%s>", k.uri)
+}
+
+func (k externalKey) KeyManagerURI() string {
+	return k.uri
+}
+
+// keyTypeOf returns the type of the public key of a key held by a key manager.
+func keyTypeOf(public gocrypto.PublicKey) (crypto.KeyType, error) {
+	switch k := public.(type) {
+	case ed25519.PublicKey:
+		return crypto.Ed25519, nil
+	case *rsa.PublicKey:
+		return crypto.RSA, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return crypto.P256, nil
+		case elliptic.P384():
+			return crypto.P384, nil
+		case elliptic.P521():
+			return crypto.P521, nil
+		}
+		return "", errors.Errorf("unsupported curve: %s", k.Curve.Params().Name)
+	default:
+		return "", errors.Errorf("unsupported public key type: %T", public)
+	}
+}
+
+// awsKeyManager signs with the keys of AWS KMS. The algorithm a key signs with depends on its type, which is read
+// with its public key, so the public keys it reads are kept for signing.
+type awsKeyManager struct {
+	client *kms.KMS
+
+	mu   sync.Mutex
+	keys map[string]gocrypto.PublicKey
+}
+
+func newAWSKeyManager(client *kms.KMS) *awsKeyManager {
+	return &awsKeyManager{client: client, keys: make(map[string]gocrypto.PublicKey)}
+}
+
+func (m *awsKeyManager) PublicKey(ctx context.Context, name string) (gocrypto.PublicKey, error) {
+	out, err := m.client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(name)})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting aws kms public key")
+	}
+	public, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing aws kms public key")
+	}
+	m.mu.Lock()
+	m.keys[name] = public
+	m.mu.Unlock()
+	return public, nil
+}
+
+func (m *awsKeyManager) Sign(ctx context.Context, name string, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	m.mu.Lock()
+	public, ok := m.keys[name]
+	m.mu.Unlock()
+	if !ok {
+		var err error
+		if public, err = m.PublicKey(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	algorithm, err := awsSigningAlgorithm(public, opts)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.client.SignWithContext(ctx, &kms.SignInput{
+		KeyId:            aws.String(name),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "signing with aws kms")
+	}
+	return out.Signature, nil
+}
+
+// awsSigningAlgorithm returns the algorithm of AWS KMS that signs digests hashed with opts.HashFunc() with a key. RSA
+// keys sign with PSS when opts are PSS options, and with PKCS #1 v1.5 otherwise, like rsa.PrivateKey does.
+func awsSigningAlgorithm(public gocrypto.PublicKey, opts gocrypto.SignerOpts) (string, error) {
+	var algorithms map[gocrypto.Hash]string
+	switch public.(type) {
+	case *ecdsa.PublicKey:
+		algorithms = map[gocrypto.Hash]string{
+			gocrypto.SHA256: kms.SigningAlgorithmSpecEcdsaSha256,
+			gocrypto.SHA384: kms.SigningAlgorithmSpecEcdsaSha384,
+			gocrypto.SHA512: kms.SigningAlgorithmSpecEcdsaSha512,
+		}
+	case *rsa.PublicKey:
+		algorithms = map[gocrypto.Hash]string{
+			gocrypto.SHA256: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+			gocrypto.SHA384: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+			gocrypto.SHA512: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512,
+		}
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			algorithms = map[gocrypto.Hash]string{
+				gocrypto.SHA256: kms.SigningAlgorithmSpecRsassaPssSha256,
+				gocrypto.SHA384: kms.SigningAlgorithmSpecRsassaPssSha384,
+				gocrypto.SHA512: kms.SigningAlgorithmSpecRsassaPssSha512,
+			}
+		}
+	default:
+		return "", errors.Errorf("aws kms can't sign with %T keys", public)
+	}
+	algorithm, ok := algorithms[opts.HashFunc()]
+	if !ok {
+		return "", errors.Errorf("aws kms can't sign with hash %s", opts.HashFunc())
+	}
+	return algorithm, nil
+}
+
+type gcpKeyManager struct {
+	client *cloudkms.Service
+}
+
+func (m gcpKeyManager) PublicKey(ctx context.Context, name string) (gocrypto.PublicKey, error) {
+	resp, err := m.client.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.GetPublicKey(name).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting gcp kms public key")
+	}
+	return parsePEMPublicKey(resp.Pem)
+}
+
+func (m gcpKeyManager) Sign(ctx context.Context, name string, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	// the algorithm is that of the key version, so only the digest is sent
+	var request cloudkms.AsymmetricSignRequest
+	encoded := base64.StdEncoding.EncodeToString(digest)
+	switch opts.HashFunc() {
+	case 0:
+		request.Data = encoded
+	case gocrypto.SHA256:
+		request.Digest = &cloudkms.Digest{Sha256: encoded}
+	case gocrypto.SHA384:
+		request.Digest = &cloudkms.Digest{Sha384: encoded}
+	case gocrypto.SHA512:
+		request.Digest = &cloudkms.Digest{Sha512: encoded}
+	default:
+		return nil, errors.Errorf("gcp kms can't sign with hash %s", opts.HashFunc())
+	}
+	resp, err := m.client.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.AsymmetricSign(name, &request).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "signing with gcp kms")
+	}
+	signature, err := base64.StdEncoding.DecodeString(resp.Signature)
+	return signature, errors.Wrap(err, "decoding gcp kms signature")
+}
+
+// vaultKeyManager signs with the keys of the transit secrets engine of a Vault, whose names are
+// <mount>/keys/<name>.
+type vaultKeyManager struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+func (m vaultKeyManager) PublicKey(ctx context.Context, name string) (gocrypto.PublicKey, error) {
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := m.do(ctx, http.MethodGet, name, nil, &resp); err != nil {
+		return nil, errors.Wrap(err, "reading vault key")
+	}
+	latest, ok := resp.Data.Keys[strconv.Itoa(resp.Data.LatestVersion)]
+	if !ok || latest.PublicKey == "" {
+		return nil, errors.Errorf("vault key %q has no public key", name)
+	}
+	if resp.Data.Type == "ed25519" {
+		public, err := base64.StdEncoding.DecodeString(latest.PublicKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding vault public key")
+		}
+		return ed25519.PublicKey(public), nil
+	}
+	return parsePEMPublicKey(latest.PublicKey)
+}
+
+func (m vaultKeyManager) Sign(ctx context.Context, name string, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	request := map[string]any{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"marshaling_algorithm": "asn1",
+	}
+	if opts.HashFunc() != 0 {
+		hashes := map[gocrypto.Hash]string{
+			gocrypto.SHA256: "sha2-256",
+			gocrypto.SHA384: "sha2-384",
+			gocrypto.SHA512: "sha2-512",
+		}
+		hash, ok := hashes[opts.HashFunc()]
+		if !ok {
+			return nil, errors.Errorf("vault can't sign with hash %s", opts.HashFunc())
+		}
+		request["prehashed"] = true
+		request["hash_algorithm"] = hash
+	}
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		request["signature_algorithm"] = "pss"
+		request["salt_length"] = "hash"
+	}
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := m.do(ctx, http.MethodPost, strings.Replace(name, "/keys/", "/sign/", 1), request, &resp); err != nil {
+		return nil, errors.Wrap(err, "signing with vault")
+	}
+
+	// signatures are formatted as vault:<version>:<base64 signature>
+	parts := strings.Split(resp.Data.Signature, ":")
+	if len(parts) != 3 {
+		return nil, errors.Errorf("vault signature %q is malformed", resp.Data.Signature)
+	}
+	signature, err := base64.StdEncoding.DecodeString(parts[2])
+	return signature, errors.Wrap(err, "decoding vault signature")
+}
+
+func (m vaultKeyManager) do(ctx context.Context, method, path string, request, response any) error {
+	var body io.Reader
+	if request != nil {
+		requestBytes, err := json.Marshal(request)
+		if err != nil {
+			return errors.Wrap(err, "marshalling request")
+		}
+		body = bytes.NewReader(requestBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.address+"/v1/"+path, body)
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("X-Vault-Token", m.token)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "calling vault")
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault responded %d: %s", resp.StatusCode, respBytes)
+	}
+	return errors.Wrap(json.Unmarshal(respBytes, response), "unmarshalling response")
+}
+
+func parsePEMPublicKey(encoded string) (gocrypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	return public, errors.Wrap(err, "parsing public key")
+}
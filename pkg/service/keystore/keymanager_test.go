@@ -0,0 +1,402 @@
+package keystore
+
+import (
+	"context"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+func TestNewKeyManager(t *testing.T) {
+	t.Run("names keys by their URIs", func(tt *testing.T) {
+		manager, name, err := NewKeyManager(context.Background(), "aws-kms://arn:aws:kms:us-east-1:123456789012:key/abcd", "")
+		require.NoError(tt, err)
+		assert.IsType(tt, &awsKeyManager{}, manager)
+		assert.Equal(tt, "arn:aws:kms:us-east-1:123456789012:key/abcd", name)
+
+		manager, name, err = NewKeyManager(context.Background(), "hcvault://vault.example.com:8200/transit/keys/issuer", "")
+		require.NoError(tt, err)
+		assert.Equal(tt, "https://vault.example.com:8200", manager.(vaultKeyManager).address)
+		assert.Equal(tt, "transit/keys/issuer", name)
+	})
+
+	t.Run("rejects keys it can't name", func(tt *testing.T) {
+		for _, uri := range []string{
+			"aws-kms://alias/issuer",
+			"gcp-kms://projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			"hcvault://vault.example.com:8200/transit/issuer",
+			"azure-kv://issuer",
+		} {
+			_, _, err := NewKeyManager(context.Background(), uri, "")
+			assert.Error(tt, err, uri)
+		}
+	})
+}
+
+func TestExternalKeys(t *testing.T) {
+	vault := newFakeVault(t)
+	keyStore, err := createKeyStoreService(t)
+	require.NoError(t, err)
+	keyStore.newKeyManager = func(ctx context.Context, keyURI, _ string) (KeyManager, string, error) {
+		_, name, err := NewKeyManager(ctx, keyURI, "")
+		if err != nil {
+			return nil, "", err
+		}
+		return vaultKeyManager{address: vault.URL, token: fakeVaultToken, client: vault.Client()}, name, nil
+	}
+	host := strings.TrimPrefix(vault.URL, "https://")
+
+	for _, keyType := range []crypto.KeyType{crypto.P256, crypto.Ed25519} {
+		keyType := keyType
+		t.Run(string(keyType), func(tt *testing.T) {
+			ctx := context.Background()
+			id := "did:example:issuer#" + string(keyType)
+			uri := "hcvault://" + host + "/transit/keys/" + string(keyType)
+			require.NoError(tt, keyStore.StoreKey(ctx, StoreKeyRequest{
+				ID:            id,
+				Controller:    "did:example:issuer",
+				KeyManagerURI: uri,
+			}))
+
+			details, err := keyStore.GetKeyDetails(ctx, GetKeyDetailsRequest{ID: id})
+			require.NoError(tt, err)
+			assert.Equal(tt, keyType, details.Type)
+			public, err := details.PublicKeyJWK.ToPublicKey()
+			require.NoError(tt, err)
+
+			// the signature is made by the key manager, and verifies with the public key it holds
+			token, err := keyStore.Sign(ctx, id, map[string]any{"hello": "world"})
+			require.NoError(tt, err)
+			verifier, err := keyaccess.NewJWKKeyAccessVerifier("did:example:issuer", id, public)
+			require.NoError(tt, err)
+			assert.NoError(tt, verifier.Verify(*token))
+
+			// only the URI of the key is stored
+			keys, err := keyStore.ExportKeys(ctx)
+			require.NoError(tt, err)
+			for _, key := range keys {
+				if key.ID == id {
+					assert.Empty(tt, key.Base58Key)
+					assert.Equal(tt, uri, key.KeyManagerURI)
+				}
+			}
+
+			require.NoError(tt, keyStore.RevokeKey(ctx, RevokeKeyRequest{ID: id}))
+			_, err = keyStore.Sign(ctx, id, map[string]any{"hello": "world"})
+			assert.ErrorContains(tt, err, "revoked")
+		})
+	}
+
+	t.Run("rejects keys of other types than requested", func(tt *testing.T) {
+		err := keyStore.StoreKey(context.Background(), StoreKeyRequest{
+			ID:            "did:example:issuer#mistyped",
+			Type:          crypto.SECP256k1,
+			Controller:    "did:example:issuer",
+			KeyManagerURI: "hcvault://" + host + "/transit/keys/" + string(crypto.P256),
+		})
+		assert.ErrorContains(tt, err, "is of type P-256")
+	})
+
+	t.Run("fails to store keys the key manager doesn't hold", func(tt *testing.T) {
+		err := keyStore.StoreKey(context.Background(), StoreKeyRequest{
+			ID:            "did:example:issuer#missing",
+			Controller:    "did:example:issuer",
+			KeyManagerURI: "hcvault://" + host + "/transit/keys/missing",
+		})
+		assert.ErrorContains(tt, err, "404")
+	})
+}
+
+func TestKeyManagers(t *testing.T) {
+	ctx := context.Background()
+
+	// signs digests with a key, and verifies the signatures with the public key the manager holds for it
+	testSign := func(t *testing.T, manager KeyManager, name string, opts gocrypto.SignerOpts) {
+		public, err := manager.PublicKey(ctx, name)
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte("hello world"))
+		signature, err := manager.Sign(ctx, name, digest[:], opts)
+		require.NoError(t, err)
+		switch public := public.(type) {
+		case *ecdsa.PublicKey:
+			assert.True(t, ecdsa.VerifyASN1(public, digest[:], signature))
+		case *rsa.PublicKey:
+			if pss, ok := opts.(*rsa.PSSOptions); ok {
+				assert.NoError(t, rsa.VerifyPSS(public, gocrypto.SHA256, digest[:], signature, pss))
+				return
+			}
+			assert.NoError(t, rsa.VerifyPKCS1v15(public, gocrypto.SHA256, digest[:], signature))
+		default:
+			t.Fatalf("unexpected public key %T", public)
+		}
+	}
+
+	t.Run("aws", func(tt *testing.T) {
+		kmsServer := newFakeAWSKMS(tt)
+		sess, err := session.NewSession(&aws.Config{
+			Endpoint:    aws.String(kmsServer.URL),
+			Region:      aws.String("us-east-1"),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		})
+		require.NoError(tt, err)
+		manager := newAWSKeyManager(kms.New(sess))
+
+		testSign(tt, manager, "ecdsa", gocrypto.SHA256)
+		// rsa keys sign with PKCS #1 v1.5, unless PSS is asked for
+		testSign(tt, manager, "rsa", gocrypto.SHA256)
+		testSign(tt, manager, "rsa", &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: gocrypto.SHA256})
+
+		// keys whose public key wasn't read yet have it read to pick their algorithm
+		digest := sha256.Sum256([]byte("hello world"))
+		_, err = newAWSKeyManager(kms.New(sess)).Sign(ctx, "rsa", digest[:], gocrypto.SHA256)
+		assert.NoError(tt, err)
+
+		_, err = manager.PublicKey(ctx, "missing")
+		assert.ErrorContains(tt, err, "NotFoundException")
+	})
+
+	t.Run("gcp", func(tt *testing.T) {
+		kmsServer := newFakeGCPKMS(tt)
+		client, err := cloudkms.NewService(ctx, option.WithEndpoint(kmsServer.URL+"/"), option.WithoutAuthentication())
+		require.NoError(tt, err)
+		manager := gcpKeyManager{client: client}
+
+		testSign(tt, manager, "projects/p/locations/global/keyRings/r/cryptoKeys/ecdsa/cryptoKeyVersions/1", gocrypto.SHA256)
+		testSign(tt, manager, "projects/p/locations/global/keyRings/r/cryptoKeys/rsa/cryptoKeyVersions/1", gocrypto.SHA256)
+
+		_, err = manager.PublicKey(ctx, "projects/p/locations/global/keyRings/r/cryptoKeys/missing/cryptoKeyVersions/1")
+		assert.ErrorContains(tt, err, "404")
+	})
+
+	t.Run("vault", func(tt *testing.T) {
+		vault := newFakeVault(tt)
+		manager := vaultKeyManager{address: vault.URL, token: fakeVaultToken, client: vault.Client()}
+
+		testSign(tt, manager, "transit/keys/"+string(crypto.P256), gocrypto.SHA256)
+
+		_, err := manager.PublicKey(ctx, "transit/keys/missing")
+		assert.ErrorContains(tt, err, "404")
+		_, err = vaultKeyManager{address: vault.URL, token: "wrong", client: vault.Client()}.PublicKey(ctx, "transit/keys/"+string(crypto.P256))
+		assert.ErrorContains(tt, err, "403")
+	})
+}
+
+// newFakeAWSKMS serves the GetPublicKey and Sign actions of AWS KMS, with a P-256 and an RSA key, named ecdsa and rsa.
+// Keys only sign with the algorithms AWS KMS allows for their type.
+func newFakeAWSKMS(t *testing.T) *httptest.Server {
+	keys := newFakeKMSKeys(t)
+	algorithms := map[string]map[string]bool{
+		"ecdsa": {kms.SigningAlgorithmSpecEcdsaSha256: true},
+		"rsa":   {kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256: true, kms.SigningAlgorithmSpecRsassaPssSha256: true},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fail := func(errType, message string) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"__type": errType, "message": message})
+		}
+		var request struct {
+			KeyID            string `json:"KeyId"`
+			Message          []byte `json:"Message"`
+			SigningAlgorithm string `json:"SigningAlgorithm"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			fail("ValidationException", err.Error())
+			return
+		}
+		key, ok := keys[request.KeyID]
+		if !ok {
+			fail("NotFoundException", "key not found")
+			return
+		}
+		var response any
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			public, err := x509.MarshalPKIXPublicKey(key.Public())
+			require.NoError(t, err)
+			response = map[string]any{"KeyId": request.KeyID, "PublicKey": public}
+		case "TrentService.Sign":
+			if !algorithms[request.KeyID][request.SigningAlgorithm] {
+				fail("InvalidKeyUsageException", request.SigningAlgorithm+" is not valid for this key")
+				return
+			}
+			var opts gocrypto.SignerOpts = gocrypto.SHA256
+			if request.SigningAlgorithm == kms.SigningAlgorithmSpecRsassaPssSha256 {
+				opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: gocrypto.SHA256}
+			}
+			signature, err := key.Sign(rand.Reader, request.Message, opts)
+			require.NoError(t, err)
+			response = map[string]any{"KeyId": request.KeyID, "Signature": signature}
+		default:
+			fail("UnknownOperationException", r.Header.Get("X-Amz-Target"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newFakeGCPKMS serves the endpoints of GCP KMS that read public keys and sign, with a P-256 and an RSA PKCS #1 v1.5 key,
+// whose key versions are in the crypto keys ecdsa and rsa.
+func newFakeGCPKMS(t *testing.T) *httptest.Server {
+	keys := newFakeKMSKeys(t)
+	keyOf := func(path, suffix string) (gocrypto.Signer, bool) {
+		parts := strings.Split(strings.TrimSuffix(path, suffix), "/")
+		if len(parts) < 3 || parts[len(parts)-2] != "cryptoKeyVersions" {
+			return nil, false
+		}
+		key, ok := keys[parts[len(parts)-3]]
+		return key, ok
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response any
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/publicKey"):
+			key, ok := keyOf(r.URL.Path, "/publicKey")
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			public, err := x509.MarshalPKIXPublicKey(key.Public())
+			require.NoError(t, err)
+			response = map[string]any{"pem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))}
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":asymmetricSign"):
+			key, ok := keyOf(r.URL.Path, ":asymmetricSign")
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var request cloudkms.AsymmetricSignRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Digest == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			digest, err := base64.StdEncoding.DecodeString(request.Digest.Sha256)
+			require.NoError(t, err)
+			signature, err := key.Sign(rand.Reader, digest, gocrypto.SHA256)
+			require.NoError(t, err)
+			response = map[string]any{"signature": base64.StdEncoding.EncodeToString(signature)}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newFakeKMSKeys(t *testing.T) map[string]gocrypto.Signer {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return map[string]gocrypto.Signer{"ecdsa": ecdsaKey, "rsa": rsaKey}
+}
+
+const fakeVaultToken = "test-token"
+
+// newFakeVault serves the endpoints of the transit secrets engine that read keys and sign, with a P-256 and an Ed25519
+// key, named by their types.
+func newFakeVault(t *testing.T) *httptest.Server {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecdsaPublic, err := x509.MarshalPKIXPublicKey(&ecdsaKey.PublicKey)
+	require.NoError(t, err)
+
+	keys := map[string]map[string]any{
+		string(crypto.P256): {
+			"type":           "ecdsa-p256",
+			"latest_version": 1,
+			"keys": map[string]any{"1": map[string]any{
+				"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecdsaPublic})),
+			}},
+		},
+		string(crypto.Ed25519): {
+			"type":           "ed25519",
+			"latest_version": 1,
+			"keys": map[string]any{"1": map[string]any{
+				"public_key": base64.StdEncoding.EncodeToString(edPublic),
+			}},
+		},
+	}
+	signers := map[string]gocrypto.Signer{string(crypto.P256): ecdsaKey, string(crypto.Ed25519): edPrivate}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != fakeVaultToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var response any
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/transit/keys/"):
+			key, ok := keys[strings.TrimPrefix(r.URL.Path, "/v1/transit/keys/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			response = map[string]any{"data": key}
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/transit/sign/"):
+			signer, ok := signers[strings.TrimPrefix(r.URL.Path, "/v1/transit/sign/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var request struct {
+				Input     string `json:"input"`
+				Prehashed bool   `json:"prehashed"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			input, _ := base64.StdEncoding.DecodeString(request.Input)
+			opts := gocrypto.Hash(0)
+			if request.Prehashed {
+				opts = gocrypto.SHA256
+			}
+			signature, err := signer.Sign(rand.Reader, input, opts)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			response = map[string]any{"data": map[string]any{
+				"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(signature),
+			}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
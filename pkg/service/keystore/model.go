@@ -12,6 +12,10 @@ type StoreKeyRequest struct {
 	Type             crypto.KeyType
 	Controller       string
 	PrivateKeyBase58 string
+
+	// The URI of a key held by a key manager, which is stored instead of PrivateKeyBase58. The type of the key is
+	// read from the key manager when Type is empty.
+	KeyManagerURI string
 }
 
 type GetKeyRequest struct {
@@ -26,6 +30,9 @@ type GetKeyResponse struct {
 	Revoked    bool
 	RevokedAt  string
 	Key        gocrypto.PrivateKey
+
+	// ID of the key's next version, when the key was rotated.
+	SupersededBy string
 }
 
 type GetKeyDetailsRequest struct {
@@ -40,8 +47,82 @@ type GetKeyDetailsResponse struct {
 	Revoked      bool
 	RevokedAt    string
 	PublicKeyJWK jwx.PublicKeyJWK
+
+	// Version of the key, counted from 1, which rotating the key increments.
+	Version int
+
+	// ID of the key's next version, and when it was rotated, when the key was rotated.
+	SupersededBy string
+	SupersededAt string
+}
+
+type GetPublicKeyJWKRequest struct {
+	ID string
+}
+
+type GetJWKSRequest struct {
+	// The controller of the keys, such as the DID of an issuer.
+	Controller string
+}
+
+type GetJWKSResponse struct {
+	Keys []jwx.PublicKeyJWK
+}
+
+type ListKeyDetailsRequest struct {
+	// A storage dependent token of the page to list. Empty means the first page.
+	PageToken string
+	// A value of -1 means all keys.
+	PageSize int
+}
+
+type ListKeyDetailsResponse struct {
+	Keys          []GetKeyDetailsResponse
+	NextPageToken string
+
+	// TotalCount is the number of keys across all pages.
+	TotalCount int
 }
 
 type RevokeKeyRequest struct {
 	ID string
 }
+
+type RotateKeyRequest struct {
+	ID string
+}
+
+type RotateKeyResponse struct {
+	// The key's next version, which signs in place of the rotated key from now on.
+	Key GetKeyDetailsResponse
+
+	// Whether the next version was published to the document of the DID the key backs.
+	DIDUpdated bool
+}
+
+type ReencryptKeysRequest struct {
+	// Tenants whose keys are re-encrypted, besides the default tenant.
+	Tenants []string
+
+	// Whether keys that were stored unencrypted, before encryption was enabled, are encrypted too.
+	AllowPlaintext bool
+}
+
+type ReencryptKeysResponse struct {
+	// How many keys were re-encrypted with data keys.
+	Reencrypted int
+
+	// How many keys already were encrypted with data keys.
+	Skipped int
+}
+
+// KeyRotation is what a RotationPublisher publishes of a rotated key.
+type KeyRotation struct {
+	// ID and controller of the rotated key.
+	SupersededID string
+	Controller   string
+
+	// ID and public key of its next version.
+	ID           string
+	PublicKeyJWK jwx.PublicKeyJWK
+}
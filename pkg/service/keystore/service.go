@@ -5,25 +5,60 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/mr-tron/base58"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/encryption"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
 type ServiceFactory func(storage.Tx) (*Service, error)
 
 type Service struct {
-	storage *Storage
-	config  config.KeyStoreServiceConfig
+	storage   *Storage
+	config    config.KeyStoreServiceConfig
+	monitor   SigningMonitor
+	publisher RotationPublisher
+
+	// newKeyManager creates the key managers holding the keys that are stored by their URI.
+	newKeyManager func(ctx context.Context, keyURI, credentialsPath string) (KeyManager, string, error)
+}
+
+// SigningMonitor observes each signature made with the keys of the keystore, and may refuse them.
+type SigningMonitor interface {
+	// ObserveSigning is called before signing with the key, and returns an error when the key mustn't sign.
+	ObserveSigning(ctx context.Context, keyID string) error
+}
+
+// RotationPublisher publishes the next versions of rotated keys where the keys were published, such as the documents
+// of the DIDs they back.
+type RotationPublisher interface {
+	// PublishRotation publishes the next version of a key next to the key, returning whether the key was published
+	// anywhere to publish it next to.
+	PublishRotation(ctx context.Context, rotation KeyRotation) (bool, error)
+}
+
+// ErrKeyRevoked is returned for the public keys of revoked keys, which aren't served.
+var ErrKeyRevoked = errors.New("key was revoked")
+
+// FrozenKeyError is returned when signing with a key that's frozen, until the freeze ends.
+type FrozenKeyError struct {
+	KeyID string
+	Until time.Time
+}
+
+func (e *FrozenKeyError) Error() string {
+	return fmt.Sprintf("key<%s> is frozen until %s", e.KeyID, e.Until.Format(time.RFC3339))
 }
 
 func (s Service) Type() framework.Type {
@@ -48,8 +83,20 @@ func (s Service) Config() config.KeyStoreServiceConfig {
 	return s.config
 }
 
+// SetSigningMonitor makes the service consult the monitor before each signature made with its keys. It must be called
+// before the service is handed to other services.
+func (s *Service) SetSigningMonitor(monitor SigningMonitor) {
+	s.monitor = monitor
+}
+
+// SetRotationPublisher makes the service publish the next versions of the keys it rotates. It must be called before
+// keys are rotated.
+func (s *Service) SetRotationPublisher(publisher RotationPublisher) {
+	s.publisher = publisher
+}
+
 func NewKeyStoreService(config config.KeyStoreServiceConfig, s storage.ServiceStorage) (*Service, error) {
-	encrypter, decrypter, err := NewServiceEncryption(s, config.EncryptionConfig, ServiceKeyEncryptionKey)
+	encrypter, decrypter, err := NewKeyEncryption(s, config.EncryptionConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating new encryption")
 	}
@@ -67,8 +114,9 @@ func NewKeyStoreServiceFactory(config config.KeyStoreServiceConfig, s storage.Se
 		}
 
 		service := Service{
-			storage: keyStoreStorage,
-			config:  config,
+			storage:       keyStoreStorage,
+			config:        config,
+			newKeyManager: NewKeyManager,
 		}
 		if !service.Status().IsReady() {
 			return nil, errors.New(service.Status().Message)
@@ -80,9 +128,13 @@ func NewKeyStoreServiceFactory(config config.KeyStoreServiceConfig, s storage.Se
 func (s Service) StoreKey(ctx context.Context, request StoreKeyRequest) error {
 	logrus.Debugf("storing key: %+v", request)
 
+	if request.KeyManagerURI != "" {
+		return s.storeExternalKey(ctx, request)
+	}
+
 	// check if the provided key type is supported. support entails being able to serialize/deserialize, in addition
 	// to facilitating signing/verification and encryption/decryption support.
-	if !crypto.IsSupportedKeyType(request.Type) {
+	if !keyaccess.IsSupportedKeyType(request.Type) {
 		return sdkutil.LoggingNewErrorf("unsupported key type: %s", request.Type)
 	}
 
@@ -99,6 +151,58 @@ func (s Service) StoreKey(ctx context.Context, request StoreKeyRequest) error {
 	return nil
 }
 
+// storeExternalKey stores a key held by a key manager by its URI, with the public key read from the key manager.
+func (s Service) storeExternalKey(ctx context.Context, request StoreKeyRequest) error {
+	if request.PrivateKeyBase58 != "" {
+		return sdkutil.LoggingNewErrorf("key<%s> can't be both held by a key manager and stored", request.ID)
+	}
+	key, err := s.getExternalKey(ctx, request.KeyManagerURI)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "getting key: %s", request.KeyManagerURI)
+	}
+	keyType, err := keyTypeOf(key.Public())
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "key<%s> can't be used", request.KeyManagerURI)
+	}
+	if request.Type != "" && request.Type != keyType {
+		return sdkutil.LoggingNewErrorf("key<%s> is of type %s, not %s", request.KeyManagerURI, keyType, request.Type)
+	}
+
+	stored := StoredKey{
+		ID:            request.ID,
+		Controller:    request.Controller,
+		KeyType:       keyType,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		KeyManagerURI: request.KeyManagerURI,
+	}
+	if err = s.writeExternalKey(ctx, stored, key); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "storing key: %s", request.ID)
+	}
+	return nil
+}
+
+// writeExternalKey stores a key held by a key manager with its public key.
+func (s Service) writeExternalKey(ctx context.Context, stored StoredKey, key *externalKey) error {
+	publicJWK, err := jwx.PublicKeyToPublicKeyJWK(stored.ID, key.Public())
+	if err != nil {
+		return errors.Wrap(err, "converting public key to JWK")
+	}
+	return s.storage.StoreExternalKey(ctx, stored, *publicJWK)
+}
+
+// getExternalKey gets a key held by a key manager, which signs there.
+func (s Service) getExternalKey(ctx context.Context, keyURI string) (*externalKey, error) {
+	manager, name, err := s.newKeyManager(ctx, keyURI, s.config.KeyManagerCredentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	public, err := manager.PublicKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &externalKey{ctx: ctx, manager: manager, name: name, uri: keyURI, public: public}, nil
+}
+
 func (s Service) GetKey(ctx context.Context, request GetKeyRequest) (*GetKeyResponse, error) {
 	logrus.Debugf("getting key: %+v", request)
 
@@ -111,24 +215,42 @@ func (s Service) GetKey(ctx context.Context, request GetKeyRequest) (*GetKeyResp
 		return nil, sdkutil.LoggingErrorMsgf(err, "key with id<%s> could not be found", id)
 	}
 
+	if gotKey.KeyManagerURI != "" {
+		key, err := s.getExternalKey(ctx, gotKey.KeyManagerURI)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "getting key<%s> from its key manager", id)
+		}
+		return &GetKeyResponse{
+			ID:           gotKey.ID,
+			Type:         gotKey.KeyType,
+			Controller:   gotKey.Controller,
+			Key:          key,
+			CreatedAt:    gotKey.CreatedAt,
+			Revoked:      gotKey.Revoked,
+			RevokedAt:    gotKey.RevokedAt,
+			SupersededBy: gotKey.SupersededBy,
+		}, nil
+	}
+
 	// deserialize the key before returning
 	keyBytes, err := base58.Decode(gotKey.Base58Key)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not deserialize key from base58")
 	}
-	privKey, err := crypto.BytesToPrivKey(keyBytes, gotKey.KeyType)
+	privKey, err := keyaccess.BytesToPrivKey(keyBytes, gotKey.KeyType)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not reconstruct private key from storage")
 	}
 
 	return &GetKeyResponse{
-		ID:         gotKey.ID,
-		Type:       gotKey.KeyType,
-		Controller: gotKey.Controller,
-		Key:        privKey,
-		CreatedAt:  gotKey.CreatedAt,
-		Revoked:    gotKey.Revoked,
-		RevokedAt:  gotKey.RevokedAt,
+		ID:           gotKey.ID,
+		Type:         gotKey.KeyType,
+		Controller:   gotKey.Controller,
+		Key:          privKey,
+		CreatedAt:    gotKey.CreatedAt,
+		Revoked:      gotKey.Revoked,
+		RevokedAt:    gotKey.RevokedAt,
+		SupersededBy: gotKey.SupersededBy,
 	}, nil
 }
 
@@ -161,9 +283,229 @@ func (s Service) GetKeyDetails(ctx context.Context, request GetKeyDetailsRequest
 		Revoked:      gotKeyDetails.Revoked,
 		RevokedAt:    gotKeyDetails.RevokedAt,
 		PublicKeyJWK: gotKeyDetails.PublicKeyJWK,
+		Version:      gotKeyDetails.Version,
+		SupersededBy: gotKeyDetails.SupersededBy,
+		SupersededAt: gotKeyDetails.SupersededAt,
 	}, nil
 }
 
+// GetPublicKeyJWK returns the public key of a key as a JWK, with the algorithm it signs with, so that what it signed
+// can be verified without resolving the DID it backs. Public keys of revoked keys aren't returned.
+func (s Service) GetPublicKeyJWK(ctx context.Context, request GetPublicKeyJWKRequest) (*jwx.PublicKeyJWK, error) {
+	logrus.Debugf("getting public key jwk: %+v", request)
+
+	details, err := s.GetKeyDetails(ctx, GetKeyDetailsRequest{ID: request.ID})
+	if err != nil {
+		return nil, err
+	}
+	if details.Revoked {
+		return nil, errors.Wrapf(ErrKeyRevoked, "key<%s>", request.ID)
+	}
+	publicJWK := signingJWK(details.PublicKeyJWK)
+	return &publicJWK, nil
+}
+
+// GetJWKS returns the public keys of the keys of a controller, such as the DID of an issuer, as a JWK Set. Revoked keys
+// are left out, but rotated keys are kept, since what they signed is still verified.
+func (s Service) GetJWKS(ctx context.Context, request GetJWKSRequest) (*GetJWKSResponse, error) {
+	logrus.Debugf("getting jwks: %+v", request)
+
+	page, err := s.storage.ListKeyDetails(ctx, "", -1)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not list keys of controller: %s", request.Controller)
+	}
+	resp := GetJWKSResponse{Keys: make([]jwx.PublicKeyJWK, 0)}
+	for _, details := range page.Keys {
+		if details.Controller != request.Controller || details.Revoked {
+			continue
+		}
+		resp.Keys = append(resp.Keys, signingJWK(details.PublicKeyJWK))
+	}
+	return &resp, nil
+}
+
+// signingJWK sets the algorithm a public key signs with, when its JWK doesn't have it, so that verifiers don't guess it.
+func signingJWK(publicJWK jwx.PublicKeyJWK) jwx.PublicKeyJWK {
+	if publicJWK.ALG == "" {
+		// keys with no JWA algorithm, like BBS+ keys, are left without one
+		publicJWK.ALG, _ = jwx.AlgFromKeyAndCurve(publicJWK.KTY, publicJWK.CRV)
+	}
+	return publicJWK
+}
+
+// RotateKey generates the next version of a key, of the same type and controller, which signs in place of the key from
+// then on. The key is kept, superseded by its next version, so that what it signed can still be verified. When a
+// RotationPublisher is set, the next version is published once it's stored, so that nothing is published that the
+// service doesn't hold, and the rotation is undone when it can't be published.
+func (s Service) RotateKey(ctx context.Context, request RotateKeyRequest) (*RotateKeyResponse, error) {
+	logrus.Debugf("rotating key: %+v", request)
+
+	id := request.ID
+	key, err := s.storage.GetKey(ctx, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting key with id: %s", id)
+	}
+	switch {
+	case key.Revoked:
+		return nil, sdkutil.LoggingNewErrorf("cannot rotate revoked key<%s>", id)
+	case key.SupersededBy != "":
+		return nil, sdkutil.LoggingNewErrorf("cannot rotate key<%s>, which was superseded by key<%s>", id, key.SupersededBy)
+	case key.KeyManagerURI != "":
+		return nil, sdkutil.LoggingNewErrorf("cannot rotate key<%s>, which is rotated by its key manager", id)
+	}
+
+	_, privKey, err := keyaccess.GenerateKeyByKeyType(key.KeyType)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "generating next version of key<%s>", id)
+	}
+	privKeyBytes, err := keyaccess.PrivKeyToBytes(privKey)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "serializing next version of key")
+	}
+	version := key.GetVersion() + 1
+	next := StoredKey{
+		ID:             fmt.Sprintf("%s-v%d", key.GetFirstVersionID(), version),
+		Controller:     key.Controller,
+		KeyType:        key.KeyType,
+		Base58Key:      base58.Encode(privKeyBytes),
+		Version:        version,
+		FirstVersionID: key.GetFirstVersionID(),
+	}
+	publicJWK, err := keyaccess.PrivateKeyToPublicKeyJWK(next.ID, privKey)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "converting next version of key to JWK")
+	}
+
+	// the next version is stored before it's published, so that the DID never lists a key the service doesn't hold
+	if err = s.storage.RotateKey(ctx, *key, next); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "rotating key: %s", id)
+	}
+	var didUpdated bool
+	if s.publisher != nil {
+		didUpdated, err = s.publisher.PublishRotation(ctx, KeyRotation{
+			SupersededID: key.ID,
+			Controller:   key.Controller,
+			ID:           next.ID,
+			PublicKeyJWK: *publicJWK,
+		})
+		if err != nil {
+			if undoErr := s.storage.UndoRotation(ctx, *key, next); undoErr != nil {
+				logrus.WithError(undoErr).Errorf("undoing rotation of key<%s>, which wasn't published", id)
+			}
+			return nil, sdkutil.LoggingErrorMsgf(err, "publishing next version of key<%s>", id)
+		}
+	}
+
+	details, err := s.GetKeyDetails(ctx, GetKeyDetailsRequest{ID: next.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &RotateKeyResponse{Key: *details, DIDUpdated: didUpdated}, nil
+}
+
+// ReencryptKeys re-encrypts the keys of the default tenant, and of the requested tenants, that were encrypted before
+// each key was encrypted with a data key of its own. It's safe to run again when it fails midway.
+func (s Service) ReencryptKeys(ctx context.Context, request ReencryptKeysRequest) (*ReencryptKeysResponse, error) {
+	logrus.Debugf("re-encrypting keys: %+v", request)
+
+	for _, tenant := range request.Tenants {
+		if !storage.IsValidTenantID(tenant) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant)
+		}
+	}
+	var resp ReencryptKeysResponse
+	for _, tenant := range append([]string{""}, request.Tenants...) {
+		reencrypted, skipped, err := s.storage.ReencryptKeys(storage.WithTenant(ctx, tenant), request.AllowPlaintext)
+		resp.Reencrypted += reencrypted
+		resp.Skipped += skipped
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "re-encrypting keys of tenant %q", tenant)
+		}
+	}
+	return &resp, nil
+}
+
+func (s Service) ListKeyDetails(ctx context.Context, request ListKeyDetailsRequest) (*ListKeyDetailsResponse, error) {
+	logrus.Debug("listing keys")
+
+	page, err := s.storage.ListKeyDetails(ctx, request.PageToken, request.PageSize)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list key details")
+	}
+	total, err := s.storage.CountKeys(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not count keys")
+	}
+	resp := ListKeyDetailsResponse{
+		Keys:          make([]GetKeyDetailsResponse, 0, len(page.Keys)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    total,
+	}
+	for _, details := range page.Keys {
+		resp.Keys = append(resp.Keys, GetKeyDetailsResponse{
+			ID:           details.ID,
+			Type:         details.KeyType,
+			Controller:   details.Controller,
+			CreatedAt:    details.CreatedAt,
+			Revoked:      details.Revoked,
+			RevokedAt:    details.RevokedAt,
+			PublicKeyJWK: details.PublicKeyJWK,
+			Version:      details.Version,
+			SupersededBy: details.SupersededBy,
+			SupersededAt: details.SupersededAt,
+		})
+	}
+	return &resp, nil
+}
+
+// ExportKeys returns every key of the keystore with its private key, or the URI of the key in the key manager holding
+// it, so that the keys can be backed up.
+func (s Service) ExportKeys(ctx context.Context) ([]StoredKey, error) {
+	keys, err := s.storage.ListKeys(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not export keys")
+	}
+	return keys, nil
+}
+
+// KeyExists returns whether a key with the ID is stored, revoked or not.
+func (s Service) KeyExists(ctx context.Context, id string) (bool, error) {
+	exists, err := s.storage.KeyExists(ctx, id)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "checking whether key exists: %s", id)
+	}
+	return exists, nil
+}
+
+// ImportKey stores a key returned by ExportKeys as it was exported, unless a key with its ID is stored already, so
+// that keys revoked since they were exported stay revoked. It returns whether the key was stored.
+func (s Service) ImportKey(ctx context.Context, key StoredKey) (bool, error) {
+	if !keyaccess.IsSupportedKeyType(key.KeyType) {
+		return false, sdkutil.LoggingNewErrorf("unsupported key type: %s", key.KeyType)
+	}
+	exists, err := s.storage.KeyExists(ctx, key.ID)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "checking whether key exists: %s", key.ID)
+	}
+	if exists {
+		return false, nil
+	}
+	if key.KeyManagerURI != "" {
+		externalKey, err := s.getExternalKey(ctx, key.KeyManagerURI)
+		if err != nil {
+			return false, sdkutil.LoggingErrorMsgf(err, "getting key<%s> from its key manager", key.ID)
+		}
+		if err = s.writeExternalKey(ctx, key, externalKey); err != nil {
+			return false, sdkutil.LoggingErrorMsgf(err, "importing key: %s", key.ID)
+		}
+		return true, nil
+	}
+	if err = s.storage.StoreKey(ctx, key); err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "importing key: %s", key.ID)
+	}
+	return true, nil
+}
+
 // GenerateServiceKey creates a random key that's 32 bytes encoded using base58.
 func GenerateServiceKey() (key string, err error) {
 	keyBytes, err := util.GenerateSalt(chacha20poly1305.KeySize)
@@ -176,15 +518,44 @@ func GenerateServiceKey() (key string, err error) {
 	return
 }
 
-// Sign fetches the key in the store, and uses it to sign data. Data should be json or json-serializable.
-func (s Service) Sign(ctx context.Context, keyID string, data any) (*keyaccess.JWT, error) {
+// GetSigningKey gets a key to sign with, failing when the key is revoked, or when the signing monitor refuses it. Keys
+// that were rotated sign with their latest version, whose ID the signatures are made with.
+func (s Service) GetSigningKey(ctx context.Context, keyID string) (_ *GetKeyResponse, err error) {
+	ctx, span := tracing.Start(ctx, "keystore.GetSigningKey", attribute.String("key.id", keyID))
+	defer func() {
+		metrics.KeystoreSignOperations.WithLabelValues(metrics.Outcome(err)).Inc()
+		tracing.End(span, err)
+	}()
+
 	gotKey, err := s.GetKey(ctx, GetKeyRequest{ID: keyID})
 	if err != nil {
-		return nil, sdkutil.LoggingErrorMsgf(err, "getting key with keyID<%s>", keyID)
+		return nil, err
+	}
+	for gotKey.SupersededBy != "" {
+		if gotKey, err = s.GetKey(ctx, GetKeyRequest{ID: gotKey.SupersededBy}); err != nil {
+			return nil, err
+		}
 	}
 	if gotKey.Revoked {
 		return nil, sdkutil.LoggingNewErrorf("cannot use revoked key<%s>", gotKey.ID)
 	}
+	if s.monitor != nil {
+		if err = s.monitor.ObserveSigning(ctx, gotKey.ID); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "cannot sign with key<%s>", gotKey.ID)
+		}
+	}
+	return gotKey, nil
+}
+
+// Sign fetches the key in the store, and uses it to sign data. Data should be json or json-serializable.
+func (s Service) Sign(ctx context.Context, keyID string, data any) (_ *keyaccess.JWT, err error) {
+	ctx, span := tracing.Start(ctx, "keystore.Sign", attribute.String("key.id", keyID))
+	defer func() { tracing.End(span, err) }()
+
+	gotKey, err := s.GetSigningKey(ctx, keyID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "getting key with keyID<%s>", keyID)
+	}
 	keyAccess, err := keyaccess.NewJWKKeyAccess(gotKey.Controller, gotKey.ID, gotKey.Key)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "creating key access for keyID<%s>", keyID)
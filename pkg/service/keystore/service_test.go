@@ -2,6 +2,7 @@ package keystore
 
 import (
 	"context"
+	gocrypto "crypto"
 	"os"
 	"testing"
 	"time"
@@ -9,11 +10,15 @@ import (
 	"github.com/TBD54566975/ssi-sdk/crypto"
 	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
 	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
 	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
 )
 
 func TestGenerateServiceKey(t *testing.T) {
@@ -50,6 +55,38 @@ func TestStoreAndGetKey(t *testing.T) {
 	assert.NotEmpty(t, signer)
 }
 
+func TestStoreGetAndRotateBBSKey(t *testing.T) {
+	keyStore, err := createKeyStoreService(t)
+	require.NoError(t, err)
+
+	_, privKey, err := crypto.GenerateBBSKeyPair()
+	require.NoError(t, err)
+	privKeyBytes, err := privKey.Marshal()
+	require.NoError(t, err)
+	keyID := "did:example:123#key-1"
+	err = keyStore.StoreKey(context.Background(), StoreKeyRequest{
+		ID:               keyID,
+		Type:             crypto.BLS12381G2,
+		Controller:       "did:example:123",
+		PrivateKeyBase58: base58.Encode(privKeyBytes),
+	})
+	require.NoError(t, err)
+
+	keyResponse, err := keyStore.GetKey(context.Background(), GetKeyRequest{ID: keyID})
+	require.NoError(t, err)
+	assert.Equal(t, privKey, keyResponse.Key)
+
+	details, err := keyStore.GetKeyDetails(context.Background(), GetKeyDetailsRequest{ID: keyID})
+	require.NoError(t, err)
+	assert.Equal(t, "OKP", details.PublicKeyJWK.KTY)
+	assert.Equal(t, "Bls12381G2", details.PublicKeyJWK.CRV)
+
+	rotated, err := keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: keyID})
+	require.NoError(t, err)
+	assert.Equal(t, crypto.BLS12381G2, rotated.Key.Type)
+	assert.NotEqual(t, details.PublicKeyJWK.X, rotated.Key.PublicKeyJWK.X)
+}
+
 func TestRevokeKey(t *testing.T) {
 	keyStore, err := createKeyStoreService(t)
 	assert.NoError(t, err)
@@ -93,6 +130,395 @@ func TestRevokeKey(t *testing.T) {
 	assert.ErrorContains(t, err, "cannot use revoked key")
 }
 
+type testRotationPublisher struct {
+	rotations []KeyRotation
+	err       error
+}
+
+func (p *testRotationPublisher) PublishRotation(_ context.Context, rotation KeyRotation) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	p.rotations = append(p.rotations, rotation)
+	return true, nil
+}
+
+func TestRotateKey(t *testing.T) {
+	keyStore, err := createKeyStoreService(t)
+	require.NoError(t, err)
+	publisher := new(testRotationPublisher)
+	keyStore.SetRotationPublisher(publisher)
+
+	_, privKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	keyID := "did:example:123#key-1"
+	err = keyStore.StoreKey(context.Background(), StoreKeyRequest{
+		ID:               keyID,
+		Type:             crypto.Ed25519,
+		Controller:       "did:example:123",
+		PrivateKeyBase58: base58.Encode(privKey),
+	})
+	require.NoError(t, err)
+	signed, err := keyStore.Sign(context.Background(), keyID, map[string]any{"data": "before"})
+	require.NoError(t, err)
+
+	rotated, err := keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: keyID})
+	require.NoError(t, err)
+	assert.True(t, rotated.DIDUpdated)
+	assert.Equal(t, keyID+"-v2", rotated.Key.ID)
+	assert.Equal(t, 2, rotated.Key.Version)
+	assert.Equal(t, crypto.Ed25519, rotated.Key.Type)
+	assert.Equal(t, "did:example:123", rotated.Key.Controller)
+	require.Len(t, publisher.rotations, 1)
+	assert.Equal(t, KeyRotation{
+		SupersededID: keyID,
+		Controller:   "did:example:123",
+		ID:           rotated.Key.ID,
+		PublicKeyJWK: rotated.Key.PublicKeyJWK,
+	}, publisher.rotations[0])
+
+	// the rotated key is kept, and still verifies what it signed
+	details, err := keyStore.GetKeyDetails(context.Background(), GetKeyDetailsRequest{ID: keyID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, details.Version)
+	assert.Equal(t, rotated.Key.ID, details.SupersededBy)
+	assert.Equal(t, "2023-06-23T00:00:00Z", details.SupersededAt)
+	publicKey, err := details.PublicKeyJWK.ToPublicKey()
+	require.NoError(t, err)
+	verifier, err := jwx.NewJWXVerifier("did:example:123", keyID, publicKey)
+	require.NoError(t, err)
+	assert.NoError(t, verifier.Verify(signed.String()))
+
+	// signing with the rotated key signs with its next version
+	signed, err = keyStore.Sign(context.Background(), keyID, map[string]any{"data": "after"})
+	require.NoError(t, err)
+	assert.Error(t, verifier.Verify(signed.String()))
+	nextPublicKey, err := rotated.Key.PublicKeyJWK.ToPublicKey()
+	require.NoError(t, err)
+	nextVerifier, err := jwx.NewJWXVerifier("did:example:123", rotated.Key.ID, nextPublicKey)
+	require.NoError(t, err)
+	assert.NoError(t, nextVerifier.Verify(signed.String()))
+
+	// only the latest version is rotated, and versions are counted from the first one
+	_, err = keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: keyID})
+	assert.ErrorContains(t, err, "which was superseded by key<"+rotated.Key.ID+">")
+	rotated, err = keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: rotated.Key.ID})
+	require.NoError(t, err)
+	assert.Equal(t, keyID+"-v3", rotated.Key.ID)
+	assert.Equal(t, 3, rotated.Key.Version)
+
+	// of rotations of the same version, only the first stores its next version
+	stale, err := keyStore.storage.GetKey(context.Background(), rotated.Key.ID)
+	require.NoError(t, err)
+	next := StoredKey{ID: keyID + "-v4", Controller: stale.Controller, KeyType: stale.KeyType, Base58Key: base58.Encode(privKey), Version: 4, FirstVersionID: keyID}
+	require.NoError(t, keyStore.storage.RotateKey(context.Background(), *stale, next))
+	err = keyStore.storage.RotateKey(context.Background(), *stale, next)
+	assert.ErrorContains(t, err, "was superseded by key<"+next.ID+">")
+
+	// versions that can't be published are undone, so the key can be rotated again
+	publisher.err = errors.New("ledger unavailable")
+	_, err = keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: next.ID})
+	assert.ErrorContains(t, err, "ledger unavailable")
+	details, err = keyStore.GetKeyDetails(context.Background(), GetKeyDetailsRequest{ID: next.ID})
+	require.NoError(t, err)
+	assert.Empty(t, details.SupersededBy)
+	exists, err := keyStore.storage.KeyExists(context.Background(), keyID+"-v5")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	publisher.err = nil
+	rotated, err = keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: next.ID})
+	require.NoError(t, err)
+	assert.Equal(t, keyID+"-v5", rotated.Key.ID)
+
+	require.NoError(t, keyStore.RevokeKey(context.Background(), RevokeKeyRequest{ID: rotated.Key.ID}))
+	_, err = keyStore.RotateKey(context.Background(), RotateKeyRequest{ID: rotated.Key.ID})
+	assert.ErrorContains(t, err, "cannot rotate revoked key")
+}
+
+func TestServiceKeyShares(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			db := test.ServiceStorage(t)
+			cfg := config.KeyStoreServiceConfig{
+				BaseServiceConfig:   &config.BaseServiceConfig{Name: "test-keyStore"},
+				ServiceKeyShares:    3,
+				ServiceKeyThreshold: 2,
+			}
+
+			// a deployment that enables shares after it started keeps using its stored service key until they're created
+			legacy, err := NewKeyStoreService(config.KeyStoreServiceConfig{BaseServiceConfig: cfg.BaseServiceConfig}, db)
+			require.NoError(t, err)
+			_, privKey, err := crypto.GenerateEd25519Key()
+			require.NoError(t, err)
+			require.NoError(t, legacy.StoreKey(ctx, StoreKeyRequest{ID: "key-1", Type: crypto.Ed25519, Controller: "did:example:a", PrivateKeyBase58: base58.Encode(privKey)}))
+
+			newKeyStore := func() (*ServiceKeyShares, *Service) {
+				shares, err := NewServiceKeyShares(db, cfg)
+				require.NoError(t, err)
+				encrypter, decrypter := shares.Encryption()
+				keyStore, err := NewKeyStoreServiceFactory(cfg, db, encrypter, decrypter)(db)
+				require.NoError(t, err)
+				return shares, keyStore
+			}
+			shares, keyStore := newKeyStore()
+			status, err := shares.Status(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, SealStatus{Shares: 3, Threshold: 2}, *status)
+			_, err = keyStore.GetKey(ctx, GetKeyRequest{ID: "key-1"})
+			require.NoError(t, err)
+
+			// each share is encrypted to an operator, and the stored service key is split and deleted
+			var publicKeysets []json.RawMessage
+			var decrypters []encryption.Decrypter
+			for i := 0; i < 3; i++ {
+				privateKeyset, publicKeyset, err := encryption.GenerateHybridKeyset()
+				require.NoError(t, err)
+				publicKeysets = append(publicKeysets, publicKeyset)
+				decrypter, err := encryption.NewHybridDecrypter(privateKeyset)
+				require.NoError(t, err)
+				decrypters = append(decrypters, decrypter)
+			}
+			_, err = shares.CreateShares(ctx, CreateSharesRequest{PublicKeysets: publicKeysets[:2]})
+			assert.Error(t, err)
+			created, err := shares.CreateShares(ctx, CreateSharesRequest{PublicKeysets: publicKeysets})
+			require.NoError(t, err)
+			require.Len(t, created.Shares, 3)
+			assert.Equal(t, SealStatus{Initialized: true, Shares: 3, Threshold: 2}, created.Status)
+			stored, err := db.Read(ctx, serviceInternalNamespace, ServiceKeyEncryptionKey)
+			require.NoError(t, err)
+			assert.Empty(t, stored)
+			_, err = shares.CreateShares(ctx, CreateSharesRequest{PublicKeysets: publicKeysets})
+			assert.ErrorIs(t, err, ErrAlreadyInitialized)
+			_, err = keyStore.GetKey(ctx, GetKeyRequest{ID: "key-1"})
+			require.NoError(t, err)
+			var plaintextShares []string
+			for i, share := range created.Shares {
+				plaintext, err := decrypters[i].Decrypt(ctx, share, nil)
+				require.NoError(t, err)
+				plaintextShares = append(plaintextShares, string(plaintext))
+			}
+
+			// other instances are sealed until threshold shares are submitted
+			shares, keyStore = newKeyStore()
+			_, err = keyStore.GetKey(ctx, GetKeyRequest{ID: "key-1"})
+			assert.ErrorContains(t, err, ErrSealed.Error())
+			status, err = shares.Unseal(ctx, plaintextShares[2])
+			require.NoError(t, err)
+			assert.Equal(t, SealStatus{Initialized: true, Sealed: true, Shares: 3, Threshold: 2, Progress: 1}, *status)
+			_, err = shares.Unseal(ctx, "not a share")
+			assert.ErrorIs(t, err, ErrInvalidShare)
+			status, err = shares.Unseal(ctx, plaintextShares[0])
+			require.NoError(t, err)
+			assert.False(t, status.Sealed)
+			gotKey, err := keyStore.GetKey(ctx, GetKeyRequest{ID: "key-1"})
+			require.NoError(t, err)
+			assert.Equal(t, privKey, gotKey.Key)
+
+			// wrong shares, and shares submitted before, are rejected when they're submitted
+			shares.Seal()
+			forgedShare, err := base58.Decode(plaintextShares[1])
+			require.NoError(t, err)
+			forgedShare[0] ^= 1
+			_, err = shares.Unseal(ctx, plaintextShares[0])
+			require.NoError(t, err)
+			_, err = shares.Unseal(ctx, base58.Encode(forgedShare))
+			assert.ErrorIs(t, err, ErrInvalidShare)
+			_, err = shares.Unseal(ctx, plaintextShares[0])
+			assert.ErrorIs(t, err, ErrInvalidShare)
+			status, err = shares.Status(ctx)
+			require.NoError(t, err)
+			assert.True(t, status.Sealed)
+			assert.Equal(t, 1, status.Progress)
+			status, err = shares.Unseal(ctx, plaintextShares[1])
+			require.NoError(t, err)
+			assert.False(t, status.Sealed)
+			assert.Zero(t, status.Progress)
+
+			// shares created before they were hashed are checked once the service key is reconstructed from them
+			description, err := shares.getDescription(ctx)
+			require.NoError(t, err)
+			description.ShareDigests = nil
+			descriptionBytes, err := json.Marshal(description)
+			require.NoError(t, err)
+			require.NoError(t, db.Write(ctx, serviceInternalNamespace, serviceKeySharesKey, descriptionBytes))
+			shares.Seal()
+			_, err = shares.Unseal(ctx, base58.Encode(forgedShare))
+			require.NoError(t, err)
+			_, err = shares.Unseal(ctx, plaintextShares[0])
+			assert.ErrorIs(t, err, ErrInvalidShare)
+			for _, share := range plaintextShares[:2] {
+				status, err = shares.Unseal(ctx, share)
+				require.NoError(t, err)
+			}
+			assert.False(t, status.Sealed)
+		})
+	}
+
+	t.Run("validates the config", func(t *testing.T) {
+		db := testutil.TestDatabases[0].ServiceStorage(t)
+		for _, cfg := range []config.KeyStoreServiceConfig{
+			{ServiceKeyShares: 3, ServiceKeyThreshold: 1},
+			{ServiceKeyShares: 2, ServiceKeyThreshold: 3},
+			{ServiceKeyShares: 3, ServiceKeyThreshold: 2, EncryptionConfig: config.EncryptionConfig{MasterKeyURI: "gcp-kms://key"}},
+			{ServiceKeyShares: 3, ServiceKeyThreshold: 2, EncryptionConfig: config.EncryptionConfig{DisableEncryption: true}},
+		} {
+			_, err := NewServiceKeyShares(db, cfg)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestReencryptKeys(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			tenantCtx := storage.WithTenant(ctx, "acme")
+			db := test.ServiceStorage(t)
+			tenantStorage := storage.NewTenantWrapper(db)
+			cfg := config.KeyStoreServiceConfig{BaseServiceConfig: &config.BaseServiceConfig{Name: "test-keyStore"}}
+			storeKey := func(ctx context.Context, keyStore *Service, id string) gocrypto.PrivateKey {
+				_, privKey, err := crypto.GenerateEd25519Key()
+				require.NoError(t, err)
+				require.NoError(t, keyStore.StoreKey(ctx, StoreKeyRequest{ID: id, Type: crypto.Ed25519, Controller: "did:example:a", PrivateKeyBase58: base58.Encode(privKey)}))
+				return privKey
+			}
+
+			// keys used to be encrypted with the service key directly, or not at all when encryption was disabled
+			serviceEncrypter, serviceDecrypter, err := NewServiceEncryption(db, cfg.EncryptionConfig, ServiceKeyEncryptionKey)
+			require.NoError(t, err)
+			legacy, err := NewKeyStoreServiceFactory(cfg, tenantStorage, serviceEncrypter, serviceDecrypter)(tenantStorage)
+			require.NoError(t, err)
+			encryptedKey := storeKey(ctx, legacy, "key-1")
+			unencrypted, err := NewKeyStoreServiceFactory(cfg, tenantStorage, nil, nil)(tenantStorage)
+			require.NoError(t, err)
+			plaintextKey := storeKey(tenantCtx, unencrypted, "key-2")
+
+			keyEncrypter, keyDecrypter, err := NewKeyEncryption(db, cfg.EncryptionConfig)
+			require.NoError(t, err)
+			keyStore, err := NewKeyStoreServiceFactory(cfg, tenantStorage, keyEncrypter, keyDecrypter)(tenantStorage)
+			require.NoError(t, err)
+			newKey := storeKey(ctx, keyStore, "key-3")
+			got, err := keyStore.GetKey(ctx, GetKeyRequest{ID: "key-1"})
+			require.NoError(t, err)
+			assert.Equal(t, encryptedKey, got.Key)
+			_, err = keyStore.GetKey(tenantCtx, GetKeyRequest{ID: "key-2"})
+			assert.Error(t, err)
+
+			_, err = keyStore.ReencryptKeys(ctx, ReencryptKeysRequest{Tenants: []string{"acme"}})
+			assert.Error(t, err)
+			_, err = keyStore.ReencryptKeys(ctx, ReencryptKeysRequest{Tenants: []string{"not a tenant"}, AllowPlaintext: true})
+			assert.Error(t, err)
+			reencrypted, err := keyStore.ReencryptKeys(ctx, ReencryptKeysRequest{Tenants: []string{"acme"}, AllowPlaintext: true})
+			require.NoError(t, err)
+			assert.Equal(t, ReencryptKeysResponse{Reencrypted: 1, Skipped: 2}, *reencrypted)
+			reencrypted, err = keyStore.ReencryptKeys(ctx, ReencryptKeysRequest{Tenants: []string{"acme"}})
+			require.NoError(t, err)
+			assert.Equal(t, ReencryptKeysResponse{Skipped: 3}, *reencrypted)
+
+			for _, key := range []struct {
+				ctx     context.Context
+				id      string
+				privKey gocrypto.PrivateKey
+			}{{ctx, "key-1", encryptedKey}, {tenantCtx, "key-2", plaintextKey}, {ctx, "key-3", newKey}} {
+				stored, err := tenantStorage.Read(key.ctx, namespace, key.id)
+				require.NoError(t, err)
+				assert.True(t, encryption.IsEnvelope(stored))
+				got, err = keyStore.GetKey(key.ctx, GetKeyRequest{ID: key.id})
+				require.NoError(t, err)
+				assert.Equal(t, key.privKey, got.Key)
+			}
+		})
+	}
+
+	t.Run("keeps the service key encrypted with the master key", func(t *testing.T) {
+		ctx := context.Background()
+		db := testutil.TestDatabases[0].ServiceStorage(t)
+		masterKey, err := GenerateServiceKey()
+		require.NoError(t, err)
+		master := encryption.NewXChaCha20Poly1305EncrypterWithKeyResolver(func(ctx context.Context) ([]byte, error) {
+			return base58.Decode(masterKey)
+		})
+
+		resolver, err := newWrappedServiceKeyResolver(ctx, db, master, master)
+		require.NoError(t, err)
+		serviceKey, err := resolver(ctx)
+		require.NoError(t, err)
+		stored, err := getServiceKey(ctx, db, serviceInternalNamespace, WrappedServiceKeyEncryptionKey)
+		require.NoError(t, err)
+		assert.NotEqual(t, serviceKey, stored)
+
+		// other instances use the same service key
+		resolver, err = newWrappedServiceKeyResolver(ctx, db, master, master)
+		require.NoError(t, err)
+		otherServiceKey, err := resolver(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, serviceKey, otherServiceKey)
+	})
+}
+
+func TestRotateServiceKey(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			tenantCtx := storage.WithTenant(ctx, "acme")
+			db := test.ServiceStorage(t)
+			cfg := config.ServicesConfig{
+				KeyStoreConfig:      config.KeyStoreServiceConfig{BaseServiceConfig: &config.BaseServiceConfig{Name: "test-keyStore"}},
+				EncryptedNamespaces: []string{"credential"},
+			}
+
+			// keys are encrypted with the app level encryption too, and credentials with the service key, like the
+			// service does
+			storageEncrypter, storageDecrypter, err := NewServiceEncryption(db, cfg.AppLevelEncryptionConfiguration, ServiceDataEncryptionKey)
+			require.NoError(t, err)
+			keyEncrypter, keyDecrypter, err := NewKeyEncryption(db, cfg.KeyStoreConfig.EncryptionConfig)
+			require.NoError(t, err)
+			namespaceStorage := storage.NewNamespaceEncryptedWrapper(storage.NewEncryptedWrapper(db, storageEncrypter, storageDecrypter), keyEncrypter.(*encryption.EnvelopeEncrypter), cfg.EncryptedNamespaces)
+			tenantStorage := storage.NewTenantWrapper(namespaceStorage)
+			require.NoError(t, tenantStorage.Write(tenantCtx, "credential", "credential-1", []byte(`{"id":"credential-1"}`)))
+			keyStore, err := NewKeyStoreServiceFactory(cfg.KeyStoreConfig, tenantStorage, keyEncrypter, keyDecrypter)(tenantStorage)
+			require.NoError(t, err)
+			privKeys := make(map[context.Context]gocrypto.PrivateKey)
+			for _, keyCtx := range []context.Context{ctx, tenantCtx} {
+				_, privKey, err := crypto.GenerateEd25519Key()
+				require.NoError(t, err)
+				require.NoError(t, keyStore.StoreKey(keyCtx, StoreKeyRequest{ID: "key-1", Type: crypto.Ed25519, Controller: "did:example:a", PrivateKeyBase58: base58.Encode(privKey)}))
+				privKeys[keyCtx] = privKey
+			}
+			serviceKey, err := getServiceKey(ctx, db, serviceInternalNamespace, ServiceKeyEncryptionKey)
+			require.NoError(t, err)
+
+			rotated, err := RotateServiceKey(ctx, db, cfg)
+			if _, ok := db.(storage.NamespaceLister); !ok {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 3, rotated)
+			rotatedKey, err := getServiceKey(ctx, db, serviceInternalNamespace, ServiceKeyEncryptionKey)
+			require.NoError(t, err)
+			assert.NotEqual(t, serviceKey, rotatedKey)
+
+			for keyCtx, privKey := range privKeys {
+				got, err := keyStore.GetKey(keyCtx, GetKeyRequest{ID: "key-1"})
+				require.NoError(t, err)
+				assert.Equal(t, privKey, got.Key)
+			}
+			credential, err := tenantStorage.Read(tenantCtx, "credential", "credential-1")
+			require.NoError(t, err)
+			assert.Equal(t, []byte(`{"id":"credential-1"}`), credential)
+		})
+	}
+
+	t.Run("doesn't rotate service keys split into shares", func(t *testing.T) {
+		db := testutil.TestDatabases[0].ServiceStorage(t)
+		cfg := config.ServicesConfig{KeyStoreConfig: config.KeyStoreServiceConfig{ServiceKeyShares: 3, ServiceKeyThreshold: 2}}
+		_, err := RotateServiceKey(context.Background(), db, cfg)
+		assert.ErrorContains(t, err, "shares")
+	})
+}
+
 func createKeyStoreService(t *testing.T) (*Service, error) {
 	file, err := os.CreateTemp("", "bolt")
 	require.NoError(t, err)
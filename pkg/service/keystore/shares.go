@@ -0,0 +1,377 @@
+package keystore
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/shamir"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// serviceKeySharesKey is where the shares of the service key are described. The shares themselves aren't stored.
+const serviceKeySharesKey = "ssi-service-key-shares"
+
+var (
+	// ErrNotInitialized is returned when the service key is used before its shares are created.
+	ErrNotInitialized = errors.New("the service key isn't initialized, create its shares first")
+	// ErrAlreadyInitialized is returned when creating the shares of a service key that was already split.
+	ErrAlreadyInitialized = errors.New("the shares of the service key were already created")
+	// ErrSealed is returned when the service key is used before enough of its shares were submitted to reconstruct it.
+	ErrSealed = errors.New("the keystore is sealed, submit the shares of the service key to unseal it")
+	// ErrInvalidShare is returned when a share isn't one of the service key's, or the shares submitted don't
+	// reconstruct it.
+	ErrInvalidShare = errors.New("invalid share of the service key")
+)
+
+// sharesDescription is what's stored about the shares of the service key: enough to check the shares submitted, and
+// the key reconstructed from them, and nothing that helps reconstruct it.
+type sharesDescription struct {
+	Shares    int `json:"shares"`
+	Threshold int `json:"threshold"`
+	// SHA-256 hash of the service key, in hex.
+	KeyDigest string `json:"keyDigest"`
+	// SHA-256 hashes of the shares, in hex, so that a wrong share is rejected when it's submitted. Shares created
+	// before they were hashed are only checked once the service key is reconstructed from them.
+	ShareDigests []string  `json:"shareDigests,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// isShare returns whether share is one of the shares described, when they were hashed.
+func (d sharesDescription) isShare(share []byte) bool {
+	if len(d.ShareDigests) == 0 {
+		return true
+	}
+	digest := sha256.Sum256(share)
+	match := 0
+	for _, shareDigest := range d.ShareDigests {
+		match |= subtle.ConstantTimeCompare([]byte(hex.EncodeToString(digest[:])), []byte(shareDigest))
+	}
+	return match == 1
+}
+
+// SealStatus tells whether the service key was split into shares, and whether it was reconstructed from them.
+type SealStatus struct {
+	// Whether the shares of the service key were created. The service key of deployments that enable shares after
+	// they started is used from storage until they are.
+	Initialized bool `json:"initialized"`
+	// Whether the keystore can't encrypt or decrypt keys, since the service key wasn't reconstructed yet.
+	Sealed    bool `json:"sealed"`
+	Shares    int  `json:"shares"`
+	Threshold int  `json:"threshold"`
+	// Shares submitted to this instance towards reconstructing the service key.
+	Progress int `json:"progress"`
+}
+
+type CreateSharesRequest struct {
+	// Public Tink keysets, as JSON, of the operators that hold the shares. Each share is encrypted to one of them, so
+	// whoever creates the shares doesn't see them.
+	PublicKeysets []json.RawMessage `validate:"required,min=1"`
+}
+
+type CreateSharesResponse struct {
+	// Shares of the service key, each encrypted to the public keyset at the same index of the request.
+	Shares [][]byte   `json:"shares"`
+	Status SealStatus `json:"status"`
+}
+
+// ServiceKeyShares keeps the service key that encrypts the private keys of the keystore out of storage. The key is
+// split into shares when it's initialized, each encrypted to the operator who holds it, and is only held in memory
+// once enough operators submitted their shares. Each instance of the service is unsealed on its own.
+type ServiceKeyShares struct {
+	db        storage.ServiceStorage
+	shares    int
+	threshold int
+
+	mu        sync.Mutex
+	key       []byte
+	submitted map[byte][]byte
+
+	Clock clock.Clock
+}
+
+func NewServiceKeyShares(db storage.ServiceStorage, cfg config.KeyStoreServiceConfig) (*ServiceKeyShares, error) {
+	if db == nil {
+		return nil, sdkutil.LoggingNewError("db reference is nil")
+	}
+	switch {
+	case !cfg.EncryptionEnabled():
+		return nil, sdkutil.LoggingNewError("service_key_shares requires encryption of the keystore")
+	case cfg.GetMasterKeyURI() != "":
+		return nil, sdkutil.LoggingNewError("service_key_shares cannot be combined with master_key_uri")
+	case cfg.ServiceKeyThreshold < 2:
+		return nil, sdkutil.LoggingNewError("service_key_threshold must be at least 2")
+	case cfg.ServiceKeyShares < cfg.ServiceKeyThreshold || cfg.ServiceKeyShares > shamir.MaxShares:
+		return nil, sdkutil.LoggingNewErrorf("service_key_shares must be between service_key_threshold and %d", shamir.MaxShares)
+	}
+	return &ServiceKeyShares{
+		db:        db,
+		shares:    cfg.ServiceKeyShares,
+		threshold: cfg.ServiceKeyThreshold,
+		submitted: make(map[byte][]byte),
+		Clock:     clock.New(),
+	}, nil
+}
+
+// Encryption returns the Encrypter and Decrypter of the keystore, which fail with ErrSealed until it's unsealed. Keys
+// are encrypted with data keys, which are encrypted with the service key, like NewKeyEncryption does.
+func (s *ServiceKeyShares) Encryption() (encryption.Encrypter, encryption.Decrypter) {
+	encSuite := encryption.NewXChaCha20Poly1305EncrypterWithKeyResolver(s.resolveKey)
+	envelope := encryption.NewEnvelopeEncrypter(encSuite, encSuite, encSuite)
+	return envelope, envelope
+}
+
+func (s *ServiceKeyShares) resolveKey(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	key := s.key
+	s.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+
+	description, err := s.getDescription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if description != nil {
+		return nil, ErrSealed
+	}
+	// until the shares are created, the key that was stored before shares were enabled is used
+	stored, err := s.db.Read(ctx, serviceInternalNamespace, ServiceKeyEncryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading service key")
+	}
+	if len(stored) == 0 {
+		return nil, ErrNotInitialized
+	}
+	return getServiceKey(ctx, s.db, serviceInternalNamespace, ServiceKeyEncryptionKey)
+}
+
+// CreateShares initializes the service key, and splits it into shares, each encrypted to the public keyset of the
+// operator who holds it. The service key that was stored before shares were enabled is split, and deleted from storage,
+// when there is one. The instance that creates the shares is unsealed.
+func (s *ServiceKeyShares) CreateShares(ctx context.Context, request CreateSharesRequest) (*CreateSharesResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	if len(request.PublicKeysets) != s.shares {
+		return nil, errors.Errorf("%d public keysets are needed, one for each share", s.shares)
+	}
+	encrypters := make([]encryption.Encrypter, 0, len(request.PublicKeysets))
+	for i, publicKeyset := range request.PublicKeysets {
+		encrypter, err := encryption.NewHybridEncrypter(publicKeyset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "public keyset %d", i)
+		}
+		encrypters = append(encrypters, encrypter)
+	}
+
+	watchKeys := []storage.WatchKey{
+		{Namespace: serviceInternalNamespace, Key: serviceKeySharesKey},
+		{Namespace: serviceInternalNamespace, Key: ServiceKeyEncryptionKey},
+	}
+	var key []byte
+	var encrypted [][]byte
+	var stored bool
+	description := sharesDescription{Shares: s.shares, Threshold: s.threshold, CreatedAt: s.Clock.Now().UTC()}
+	_, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		existing, err := s.getDescription(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, ErrAlreadyInitialized
+		}
+		storedKey, err := s.db.Read(ctx, serviceInternalNamespace, ServiceKeyEncryptionKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading service key")
+		}
+		if stored = len(storedKey) != 0; stored {
+			if key, err = getServiceKey(ctx, s.db, serviceInternalNamespace, ServiceKeyEncryptionKey); err != nil {
+				return nil, err
+			}
+		} else {
+			generated, err := GenerateServiceKey()
+			if err != nil {
+				return nil, err
+			}
+			if key, err = base58.Decode(generated); err != nil {
+				return nil, errors.Wrap(err, "decoding service key")
+			}
+		}
+		// the shares are encrypted before the description is written, so that shares are never described that nobody
+		// received
+		shares, err := shamir.Split(key, s.shares, s.threshold)
+		if err != nil {
+			return nil, errors.Wrap(err, "splitting service key")
+		}
+		encrypted = make([][]byte, 0, len(shares))
+		description.ShareDigests = make([]string, 0, len(shares))
+		for i, share := range shares {
+			ciphertext, err := encrypters[i].Encrypt(ctx, []byte(base58.Encode(share)), nil)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encrypting share %d", i)
+			}
+			encrypted = append(encrypted, ciphertext)
+			shareDigest := sha256.Sum256(share)
+			description.ShareDigests = append(description.ShareDigests, hex.EncodeToString(shareDigest[:]))
+		}
+		digest := sha256.Sum256(key)
+		description.KeyDigest = hex.EncodeToString(digest[:])
+		descriptionBytes, err := json.Marshal(description)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling shares description")
+		}
+		return nil, tx.Write(ctx, serviceInternalNamespace, serviceKeySharesKey, descriptionBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	if stored {
+		// the shares were created, so they're returned even when the stored key couldn't be deleted
+		if err = s.db.Delete(ctx, serviceInternalNamespace, ServiceKeyEncryptionKey); err != nil {
+			logrus.WithError(err).Error("could not delete the stored service key after splitting it, delete it manually")
+		}
+	}
+
+	s.mu.Lock()
+	s.key = key
+	s.submitted = make(map[byte][]byte)
+	s.mu.Unlock()
+	return &CreateSharesResponse{Shares: encrypted, Status: s.status(&description)}, nil
+}
+
+// Unseal submits a share of the service key, base58 encoded. Shares that aren't one of the service key's, or that were
+// submitted before, are rejected. Once threshold shares were submitted, the service key is reconstructed from them, and
+// the keystore is unsealed. No more shares are kept than the service key was split into.
+func (s *ServiceKeyShares) Unseal(ctx context.Context, share string) (*SealStatus, error) {
+	description, err := s.getDescription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if description == nil {
+		return nil, ErrNotInitialized
+	}
+	shareBytes, err := base58.Decode(share)
+	if err != nil || len(shareBytes) != chacha20poly1305.KeySize+1 {
+		return nil, errors.Wrap(ErrInvalidShare, "share isn't a base58 encoded share of a service key")
+	}
+	if !description.isShare(shareBytes) {
+		return nil, errors.Wrap(ErrInvalidShare, "share isn't one of the service key's")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		x := shareBytes[len(shareBytes)-1]
+		if _, ok := s.submitted[x]; ok {
+			return nil, errors.Wrap(ErrInvalidShare, "share was already submitted")
+		}
+		if len(s.submitted) >= description.Shares {
+			return nil, errors.Wrap(ErrInvalidShare, "more shares were submitted than the service key was split into, seal the keystore to start over")
+		}
+		s.submitted[x] = shareBytes
+		if len(s.submitted) >= description.Threshold {
+			key, err := s.reconstructLocked(description)
+			// shares that don't reconstruct the service key are only submitted when they weren't hashed, and which of
+			// them is wrong isn't known, so they're all submitted again
+			s.submitted = make(map[byte][]byte)
+			if err != nil {
+				return nil, err
+			}
+			s.key = key
+		}
+	}
+	status := s.statusLocked(description)
+	return &status, nil
+}
+
+// reconstructLocked reconstructs the service key from the shares submitted, and checks it against its hash.
+func (s *ServiceKeyShares) reconstructLocked(description *sharesDescription) ([]byte, error) {
+	submitted := make([][]byte, 0, len(s.submitted))
+	for _, submittedShare := range s.submitted {
+		submitted = append(submitted, submittedShare)
+	}
+	key, err := shamir.Combine(submitted)
+	if err == nil {
+		digest := sha256.Sum256(key)
+		if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(digest[:])), []byte(description.KeyDigest)) == 1 {
+			return key, nil
+		}
+	}
+	return nil, errors.Wrap(ErrInvalidShare, "the shares submitted don't reconstruct the service key, submit them again")
+}
+
+// Seal forgets the service key, and the shares submitted, so that the keystore can't be used until it's unsealed
+// again.
+func (s *ServiceKeyShares) Seal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = nil
+	s.submitted = make(map[byte][]byte)
+}
+
+// Status returns whether the service key was split into shares, and whether this instance is unsealed.
+func (s *ServiceKeyShares) Status(ctx context.Context) (*SealStatus, error) {
+	description, err := s.getDescription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := s.status(description)
+	if description == nil {
+		key, err := s.db.Read(ctx, serviceInternalNamespace, ServiceKeyEncryptionKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading service key")
+		}
+		status.Sealed = len(key) == 0
+	}
+	return &status, nil
+}
+
+func (s *ServiceKeyShares) status(description *sharesDescription) SealStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusLocked(description)
+}
+
+func (s *ServiceKeyShares) statusLocked(description *sharesDescription) SealStatus {
+	status := SealStatus{
+		Initialized: description != nil,
+		Sealed:      s.key == nil,
+		Shares:      s.shares,
+		Threshold:   s.threshold,
+		Progress:    len(s.submitted),
+	}
+	if description != nil {
+		status.Shares, status.Threshold = description.Shares, description.Threshold
+	}
+	return status
+}
+
+func (s *ServiceKeyShares) getDescription(ctx context.Context) (*sharesDescription, error) {
+	descriptionBytes, err := s.db.Read(ctx, serviceInternalNamespace, serviceKeySharesKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading shares description")
+	}
+	if len(descriptionBytes) == 0 {
+		return nil, nil
+	}
+	var description sharesDescription
+	if err = json.Unmarshal(descriptionBytes, &description); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling shares description")
+	}
+	return &description, nil
+}
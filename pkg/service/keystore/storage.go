@@ -2,6 +2,9 @@ package keystore
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/TBD54566975/ssi-sdk/crypto"
@@ -11,8 +14,12 @@ import (
 	"github.com/goccy/go-json"
 	"github.com/mr-tron/base58"
 	"github.com/pkg/errors"
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/encryption"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 // StoredKey represents a common data model to store data on all key types
@@ -24,6 +31,36 @@ type StoredKey struct {
 	Revoked    bool           `json:"revoked"`
 	RevokedAt  string         `json:"revokedAt"`
 	CreatedAt  string         `json:"createdAt"`
+
+	// The URI of the key in the key manager holding it, when the private key is held outside the service. Base58Key
+	// is empty then.
+	KeyManagerURI string `json:"keyManagerUri,omitempty"`
+
+	// Version of the key, counted from 1, and the ID of its first version. Keys stored before they could be rotated
+	// have neither, and are their own first version.
+	Version        int    `json:"version,omitempty"`
+	FirstVersionID string `json:"firstVersionId,omitempty"`
+
+	// ID of the key's next version, and when it was rotated. The key is kept to verify what it signed, but signing
+	// with it signs with its latest version.
+	SupersededBy string `json:"supersededBy,omitempty"`
+	SupersededAt string `json:"supersededAt,omitempty"`
+}
+
+// GetVersion returns the version of the key, counted from 1.
+func (k StoredKey) GetVersion() int {
+	if k.Version == 0 {
+		return 1
+	}
+	return k.Version
+}
+
+// GetFirstVersionID returns the ID of the key's first version.
+func (k StoredKey) GetFirstVersionID() string {
+	if k.FirstVersionID == "" {
+		return k.ID
+	}
+	return k.FirstVersionID
 }
 
 // KeyDetails represents a common data model to get information about a key, without revealing the key itself
@@ -35,6 +72,9 @@ type KeyDetails struct {
 	RevokedAt    string           `json:"revokedAt"`
 	CreatedAt    string           `json:"createdAt"`
 	PublicKeyJWK jwx.PublicKeyJWK `json:"publicKeyJwk"`
+	Version      int              `json:"version"`
+	SupersededBy string           `json:"supersededBy,omitempty"`
+	SupersededAt string           `json:"supersededAt,omitempty"`
 }
 
 type ServiceKey struct {
@@ -50,6 +90,10 @@ const (
 
 	ServiceKeyEncryptionKey  = "ssi-service-key-encryption-key"
 	ServiceDataEncryptionKey = "ssi-service-data-key"
+
+	// WrappedServiceKeyEncryptionKey is the service key of the keystore when a master key is configured, which it's
+	// stored encrypted with.
+	WrappedServiceKeyEncryptionKey = "ssi-service-wrapped-key-encryption-key"
 )
 
 var (
@@ -147,7 +191,226 @@ func NewServiceEncryption(db storage.ServiceStorage, cfg encryption.ExternalEncr
 	return encSuite, encSuite, nil
 }
 
-// TODO(gabe): support more robust service key operations, including rotation, and caching
+// NewKeyEncryption creates the Encrypter and Decrypter of the private keys of the keystore with the given
+// configuration. Each key is encrypted with a data key of its own, which is encrypted with the service key. When a
+// master key is configured, the service key is stored encrypted with it, so the master key is only used to decrypt the
+// service key. Keys that were encrypted before data keys were used keep being decrypted, until ReencryptKeys
+// re-encrypts them.
+func NewKeyEncryption(db storage.ServiceStorage, cfg encryption.ExternalEncryptionConfig) (encryption.Encrypter, encryption.Decrypter, error) {
+	if !cfg.EncryptionEnabled() {
+		return nil, nil, nil
+	}
+
+	if len(cfg.GetMasterKeyURI()) == 0 {
+		serviceEncrypter, serviceDecrypter, err := NewServiceEncryption(db, cfg, ServiceKeyEncryptionKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		envelope := encryption.NewEnvelopeEncrypter(serviceEncrypter, serviceDecrypter, serviceDecrypter)
+		return envelope, envelope, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	masterEncrypter, masterDecrypter, err := encryption.NewExternalEncrypter(ctx, cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating master key encrypter")
+	}
+	resolver, err := newWrappedServiceKeyResolver(ctx, db, masterEncrypter, masterDecrypter)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "ensuring that the wrapped service key exists")
+	}
+	serviceSuite := encryption.NewXChaCha20Poly1305EncrypterWithKeyResolver(resolver)
+	// keys used to be encrypted with the master key directly
+	envelope := encryption.NewEnvelopeEncrypter(serviceSuite, serviceSuite, masterDecrypter)
+	return envelope, envelope, nil
+}
+
+// newWrappedServiceKeyResolver makes sure that a service key exists, encrypted with the master key, and returns a
+// resolver of it. The service key is decrypted the first time it's resolved, and kept after.
+func newWrappedServiceKeyResolver(ctx context.Context, db storage.ServiceStorage, masterEncrypter encryption.Encrypter, masterDecrypter encryption.Decrypter) (encryption.KeyResolver, error) {
+	contextData := []byte(WrappedServiceKeyEncryptionKey)
+	watchKeys := []storage.WatchKey{{
+		Namespace: serviceInternalNamespace,
+		Key:       WrappedServiceKeyEncryptionKey,
+	}}
+	_, err := db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		// Create the key only if it doesn't already exist.
+		exists, err := db.Exists(ctx, serviceInternalNamespace, WrappedServiceKeyEncryptionKey)
+		if err != nil || exists {
+			return nil, err
+		}
+		keyBytes, err := util.GenerateSalt(chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, errors.Wrap(err, "generating service key")
+		}
+		wrapped, err := masterEncrypter.Encrypt(ctx, keyBytes, contextData)
+		if err != nil {
+			return nil, errors.Wrap(err, "encrypting service key")
+		}
+		return nil, storeServiceKey(ctx, tx, ServiceKey{Base58Key: base58.Encode(wrapped)}, serviceInternalNamespace, WrappedServiceKeyEncryptionKey)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var key []byte
+	return func(ctx context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if key != nil {
+			return key, nil
+		}
+		wrapped, err := getServiceKey(ctx, db, serviceInternalNamespace, WrappedServiceKeyEncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		unwrapped, err := masterDecrypter.Decrypt(ctx, wrapped, contextData)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypting service key")
+		}
+		key = unwrapped
+		return key, nil
+	}, nil
+}
+
+// RotateServiceKey replaces the service key, which encrypts the data keys of the private keys of every tenant, and of
+// the values of encrypted namespaces, with a new one, returning how many data keys were encrypted with it. The private
+// keys and values themselves aren't encrypted again.
+// It's meant to be run on db while the service is stopped, with the config the service runs with, and requires a
+// storage provider that lists its namespaces. Keys that weren't encrypted with a data key yet have to be re-encrypted
+// first. Service keys that are encrypted with a master key, or split into shares, aren't rotated this way.
+func RotateServiceKey(ctx context.Context, db storage.ServiceStorage, cfg config.ServicesConfig) (int, error) {
+	switch {
+	case !cfg.KeyStoreConfig.EncryptionEnabled():
+		return 0, errors.New("keys aren't encrypted")
+	case cfg.KeyStoreConfig.GetMasterKeyURI() != "":
+		return 0, errors.New("the service key is encrypted with a master key, which is rotated in its key manager instead")
+	case cfg.KeyStoreConfig.ServiceKeyShares > 0:
+		return 0, errors.New("the service key is split into shares, which are created again to replace it instead")
+	}
+	currentKey, err := getServiceKey(ctx, db, serviceInternalNamespace, ServiceKeyEncryptionKey)
+	if err != nil {
+		return 0, err
+	}
+	nextKey, err := util.GenerateSalt(chacha20poly1305.KeySize)
+	if err != nil {
+		return 0, errors.Wrap(err, "generating service key")
+	}
+	current := encryption.NewXChaCha20Poly1305EncrypterWithKey(currentKey)
+	envelope := encryption.NewEnvelopeEncrypter(current, current, nil)
+	next := encryption.NewXChaCha20Poly1305EncrypterWithKey(nextKey)
+
+	// the keys of every namespace are encrypted with the app level encryption too, when it's enabled
+	storageEncrypter, storageDecrypter, err := NewServiceEncryption(db, cfg.AppLevelEncryptionConfiguration, ServiceDataEncryptionKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "creating app level encrypter")
+	}
+
+	namespaces, err := storage.ListNamespaces(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	var keyNamespaces, ids []string
+	var rewrapped [][]byte
+	for _, ns := range namespaces {
+		if storage.BaseNamespace(ns) != publicKeyNamespace {
+			continue
+		}
+		// keys are listed by their public keys, like ReencryptKeys does, since the namespace of keys prefixes that of
+		// public keys
+		keyNamespace := strings.TrimSuffix(ns, publicKeyNamespace) + namespace
+		keyIDs, err := db.ReadAllKeys(ctx, ns)
+		if err != nil {
+			return 0, errors.Wrapf(err, "reading public key ids of namespace<%s>", ns)
+		}
+		for _, id := range keyIDs {
+			value, err := db.Read(ctx, keyNamespace, id)
+			if err != nil {
+				return 0, errors.Wrapf(err, "reading key<%s>", id)
+			}
+			if len(value) == 0 {
+				continue
+			}
+			if storageDecrypter != nil {
+				if value, err = storageDecrypter.Decrypt(ctx, value, nil); err != nil {
+					return 0, errors.Wrapf(err, "decrypting key<%s>", id)
+				}
+			}
+			if value, err = envelope.Rewrap(ctx, value, nil, next); err != nil {
+				return 0, errors.Wrapf(err, "encrypting the data key of key<%s>, have keys been re-encrypted?", id)
+			}
+			if storageEncrypter != nil {
+				if value, err = storageEncrypter.Encrypt(ctx, value, nil); err != nil {
+					return 0, errors.Wrapf(err, "encrypting key<%s>", id)
+				}
+			}
+			keyNamespaces = append(keyNamespaces, keyNamespace)
+			ids = append(ids, id)
+			rewrapped = append(rewrapped, value)
+		}
+	}
+
+	// values of encrypted namespaces have data keys encrypted with the service key too, unless they were stored before
+	// their namespace was encrypted
+	encryptedNamespaces := make(map[string]bool, len(cfg.EncryptedNamespaces))
+	for _, ns := range cfg.EncryptedNamespaces {
+		encryptedNamespaces[ns] = true
+	}
+	for _, ns := range namespaces {
+		if !encryptedNamespaces[storage.BaseNamespace(ns)] {
+			continue
+		}
+		err = db.Iterate(ctx, ns, func(key string, value []byte) (bool, error) {
+			if len(value) == 0 {
+				return true, nil
+			}
+			var err error
+			if storageDecrypter != nil {
+				if value, err = storageDecrypter.Decrypt(ctx, value, nil); err != nil {
+					return false, errors.Wrapf(err, "decrypting value<%s>", key)
+				}
+			}
+			if !encryption.IsEnvelope(value) {
+				return true, nil
+			}
+			if value, err = envelope.Rewrap(ctx, value, nil, next); err != nil {
+				return false, errors.Wrapf(err, "encrypting the data key of value<%s>", key)
+			}
+			if storageEncrypter != nil {
+				if value, err = storageEncrypter.Encrypt(ctx, value, nil); err != nil {
+					return false, errors.Wrapf(err, "encrypting value<%s>", key)
+				}
+			}
+			keyNamespaces = append(keyNamespaces, ns)
+			ids = append(ids, key)
+			rewrapped = append(rewrapped, value)
+			return true, nil
+		})
+		if err != nil {
+			return 0, errors.Wrapf(err, "reading values of namespace<%s>", ns)
+		}
+	}
+
+	// the keys and values are written along with the service key, so that they're never encrypted with a service key
+	// that's gone
+	watchKeys := []storage.WatchKey{{Namespace: serviceInternalNamespace, Key: ServiceKeyEncryptionKey}}
+	_, err = db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		for i := range ids {
+			if err := tx.Write(ctx, keyNamespaces[i], ids[i], rewrapped[i]); err != nil {
+				return nil, errors.Wrapf(err, "writing value<%s> of namespace<%s>", ids[i], keyNamespaces[i])
+			}
+		}
+		return nil, storeServiceKey(ctx, tx, ServiceKey{Base58Key: base58.Encode(nextKey)}, serviceInternalNamespace, ServiceKeyEncryptionKey)
+	}, watchKeys)
+	if err != nil {
+		return 0, errors.Wrap(err, "storing the rotated service key")
+	}
+	return len(ids), nil
+}
+
+// TODO(gabe): support more robust service key operations, including caching
 func storeServiceKey(ctx context.Context, tx storage.Tx, key ServiceKey, namespace string, skKey string) error {
 	keyBytes, err := json.Marshal(key)
 	if err != nil {
@@ -183,14 +446,11 @@ func getServiceKey(ctx context.Context, db storage.ServiceStorage, namespace, sk
 
 func (kss *Storage) StoreKey(ctx context.Context, key StoredKey) error {
 	// TODO(gabe): conflict checking on key id
-	id := key.ID
-	if id == "" {
+	if key.ID == "" {
 		return sdkutil.LoggingNewError("could not store key without an ID")
 	}
-
-	keyBytes, err := json.Marshal(key)
-	if err != nil {
-		return sdkutil.LoggingErrorMsg(err, "deserializing key from base58")
+	if key.KeyManagerURI != "" {
+		return sdkutil.LoggingNewErrorf("could not store key<%s> held by a key manager without its public key", key.ID)
 	}
 
 	skBytes, err := base58.Decode(key.Base58Key)
@@ -198,24 +458,47 @@ func (kss *Storage) StoreKey(ctx context.Context, key StoredKey) error {
 		return sdkutil.LoggingErrorMsg(err, "deserializing key from base58")
 	}
 
-	secretKey, err := crypto.BytesToPrivKey(skBytes, key.KeyType)
+	secretKey, err := keyaccess.BytesToPrivKey(skBytes, key.KeyType)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "reconstructing private key from input")
 	}
 
-	publicJWK, _, err := jwx.PrivateKeyToPrivateKeyJWK(key.ID, secretKey)
+	publicJWK, err := keyaccess.PrivateKeyToPublicKeyJWK(key.ID, secretKey)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "reconstructing JWK")
 	}
+	return kss.storeKey(ctx, key, *publicJWK)
+}
 
+// StoreExternalKey stores a key held by a key manager, which is only referenced by its URI, with its public key.
+func (kss *Storage) StoreExternalKey(ctx context.Context, key StoredKey, publicJWK jwx.PublicKeyJWK) error {
+	if key.ID == "" {
+		return sdkutil.LoggingNewError("could not store key without an ID")
+	}
+	if key.KeyManagerURI == "" || key.Base58Key != "" {
+		return sdkutil.LoggingNewErrorf("key<%s> must only be referenced by the URI of its key manager", key.ID)
+	}
+	return kss.storeKey(ctx, key, publicJWK)
+}
+
+func (kss *Storage) storeKey(ctx context.Context, key StoredKey, publicJWK jwx.PublicKeyJWK) error {
 	publicBytes, err := json.Marshal(publicJWK)
 	if err != nil {
 		return sdkutil.LoggingErrorMsg(err, "marshalling JWK")
 	}
 
-	if err := kss.tx.Write(ctx, publicKeyNamespace, id, publicBytes); err != nil {
+	if err := kss.tx.Write(ctx, publicKeyNamespace, key.ID, publicBytes); err != nil {
 		return sdkutil.LoggingErrorMsgf(err, "writing public key")
 	}
+	return kss.writeKey(ctx, key)
+}
+
+// writeKey writes the key, encrypted, without its public key.
+func (kss *Storage) writeKey(ctx context.Context, key StoredKey) error {
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "marshalling key")
+	}
 
 	// encrypt key before storing
 	encryptedKey, err := kss.encrypter.Encrypt(ctx, keyBytes, nil)
@@ -223,7 +506,7 @@ func (kss *Storage) StoreKey(ctx context.Context, key StoredKey) error {
 		return sdkutil.LoggingErrorMsgf(err, "could not encrypt key: %s", key.ID)
 	}
 
-	return kss.tx.Write(ctx, namespace, id, encryptedKey)
+	return kss.tx.Write(ctx, namespace, key.ID, encryptedKey)
 }
 
 // RevokeKey revokes a key by setting the revoked flag to true.
@@ -238,7 +521,99 @@ func (kss *Storage) RevokeKey(ctx context.Context, id string) error {
 
 	key.Revoked = true
 	key.RevokedAt = kss.Clock.Now().Format(time.RFC3339)
-	return kss.StoreKey(ctx, *key)
+	return kss.writeKey(ctx, *key)
+}
+
+// RotateKey stores next as the next version of key, and marks key as superseded by it, in one transaction. The key is
+// read again in the transaction, so that of concurrent rotations of the same key, only one stores its next version.
+func (kss *Storage) RotateKey(ctx context.Context, key StoredKey, next StoredKey) error {
+	now := kss.Clock.Now().Format(time.RFC3339)
+	next.CreatedAt = now
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: key.ID}, {Namespace: namespace, Key: next.ID}}
+	_, err := kss.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		current, err := kss.GetKey(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case current.Revoked:
+			return nil, errors.Errorf("key<%s> was revoked", key.ID)
+		case current.SupersededBy != "":
+			return nil, errors.Errorf("key<%s> was superseded by key<%s>", key.ID, current.SupersededBy)
+		}
+		exists, err := kss.KeyExists(ctx, next.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking whether key<%s> exists", next.ID)
+		}
+		if exists {
+			return nil, errors.Errorf("key<%s> already exists", next.ID)
+		}
+
+		txStorage := *kss
+		txStorage.tx = tx
+		if err = txStorage.StoreKey(ctx, next); err != nil {
+			return nil, errors.Wrapf(err, "storing key<%s>", next.ID)
+		}
+		current.SupersededBy = next.ID
+		current.SupersededAt = now
+		return nil, txStorage.writeKey(ctx, *current)
+	}, watchKeys)
+	return err
+}
+
+// UndoRotation undoes RotateKey, when the next version of key couldn't be published, so that key can be rotated again.
+func (kss *Storage) UndoRotation(ctx context.Context, key StoredKey, next StoredKey) error {
+	key.SupersededBy = ""
+	key.SupersededAt = ""
+	if err := kss.writeKey(ctx, key); err != nil {
+		return errors.Wrapf(err, "restoring key<%s>", key.ID)
+	}
+	if err := kss.db.Delete(ctx, namespace, next.ID); err != nil {
+		return errors.Wrapf(err, "deleting key<%s>", next.ID)
+	}
+	if err := kss.db.Delete(ctx, publicKeyNamespace, next.ID); err != nil {
+		return errors.Wrapf(err, "deleting public key<%s>", next.ID)
+	}
+	return nil
+}
+
+// ReencryptKeys re-encrypts the keys that weren't encrypted with data keys, returning how many were re-encrypted, and
+// how many already were. Keys that were stored unencrypted are only re-encrypted when allowPlaintext is true.
+func (kss *Storage) ReencryptKeys(ctx context.Context, allowPlaintext bool) (reencrypted int, skipped int, err error) {
+	if _, ok := kss.encrypter.(*encryption.EnvelopeEncrypter); !ok {
+		return 0, 0, errors.New("keys aren't encrypted with data keys")
+	}
+	ids, err := kss.db.ReadAllKeys(ctx, publicKeyNamespace)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "reading public key ids")
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		storedKeyBytes, err := kss.db.Read(ctx, namespace, id)
+		if err != nil {
+			return reencrypted, skipped, errors.Wrapf(err, "reading key<%s>", id)
+		}
+		if len(storedKeyBytes) == 0 || encryption.IsEnvelope(storedKeyBytes) {
+			skipped++
+			continue
+		}
+
+		var key StoredKey
+		decryptedKey, err := kss.decrypter.Decrypt(ctx, storedKeyBytes, nil)
+		if err != nil {
+			if !allowPlaintext || json.Unmarshal(storedKeyBytes, &key) != nil {
+				return reencrypted, skipped, errors.Wrapf(err, "decrypting key<%s>", id)
+			}
+		} else if err = json.Unmarshal(decryptedKey, &key); err != nil {
+			return reencrypted, skipped, errors.Wrapf(err, "unmarshalling key<%s>", id)
+		}
+		if err = kss.writeKey(ctx, key); err != nil {
+			return reencrypted, skipped, err
+		}
+		reencrypted++
+	}
+	return reencrypted, skipped, nil
 }
 
 func (kss *Storage) GetKey(ctx context.Context, id string) (*StoredKey, error) {
@@ -285,5 +660,74 @@ func (kss *Storage) GetKeyDetails(ctx context.Context, id string) (*KeyDetails,
 		CreatedAt:    stored.CreatedAt,
 		Revoked:      stored.Revoked,
 		PublicKeyJWK: storedPublicKey,
+		Version:      stored.GetVersion(),
+		SupersededBy: stored.SupersededBy,
+		SupersededAt: stored.SupersededAt,
+	}, nil
+}
+
+// KeyDetailsPage is a page of key details, ordered by their ids.
+type KeyDetailsPage struct {
+	Keys          []KeyDetails
+	NextPageToken string
+}
+
+// ListKeyDetails returns the details of the keys in the page with the given token and size. A size of -1 means all
+// keys.
+func (kss *Storage) ListKeyDetails(ctx context.Context, token string, size int) (*KeyDetailsPage, error) {
+	publicKeys, nextPageToken, err := kss.db.ReadPage(ctx, publicKeyNamespace, token, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading page of public keys")
+	}
+	ids := make([]string, 0, len(publicKeys))
+	for id := range publicKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]KeyDetails, 0, len(ids))
+	for _, id := range ids {
+		details, err := kss.GetKeyDetails(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *details)
+	}
+	return &KeyDetailsPage{
+		Keys:          keys,
+		NextPageToken: nextPageToken,
 	}, nil
 }
+
+// CountKeys returns how many keys are stored.
+func (kss *Storage) CountKeys(ctx context.Context) (int, error) {
+	ids, err := kss.db.ReadAllKeys(ctx, publicKeyNamespace)
+	if err != nil {
+		return 0, errors.Wrap(err, "reading public key ids")
+	}
+	return len(ids), nil
+}
+
+// ListKeys returns every key with its private key, ordered by their ids.
+func (kss *Storage) ListKeys(ctx context.Context) ([]StoredKey, error) {
+	ids, err := kss.db.ReadAllKeys(ctx, publicKeyNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading public key ids")
+	}
+	sort.Strings(ids)
+
+	keys := make([]StoredKey, 0, len(ids))
+	for _, id := range ids {
+		key, err := kss.GetKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+// KeyExists returns whether a key with the id is stored.
+func (kss *Storage) KeyExists(ctx context.Context, id string) (bool, error) {
+	return kss.db.Exists(ctx, namespace, id)
+}
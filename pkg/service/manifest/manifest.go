@@ -4,15 +4,38 @@ import (
 	"context"
 
 	"github.com/TBD54566975/ssi-sdk/credential/manifest"
+	"github.com/TBD54566975/ssi-sdk/did"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/goccy/go-json"
 	"github.com/lestrrat-go/jwx/jws"
 	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/sirupsen/logrus"
 
 	didint "github.com/tbd54566975/ssi-service/internal/did"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
 )
 
+// SignManifest signs a manifest as a JWT with the key of its issuer that it was created with, so that wallets can
+// check that the issuer made the manifest before applying. The JWT is verified by VerifyManifest.
+func (s Service) SignManifest(ctx context.Context, request model.SignManifestRequest) (*model.SignManifestResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid sign manifest request")
+	}
+	logrus.Debugf("signing manifest: %s", request.ID)
+
+	gotManifest, err := s.storage.GetManifest(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get manifest: %s", request.ID)
+	}
+	keyStoreID := did.FullyQualifiedVerificationMethodID(gotManifest.IssuerDID, gotManifest.FullyQualifiedVerificationMethodID)
+	manifestJWT, err := s.keyStore.Sign(ctx, keyStoreID, CredentialManifestContainer{Manifest: gotManifest.Manifest})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not sign manifest: %s", request.ID)
+	}
+	return &model.SignManifestResponse{ManifestJWT: *manifestJWT}, nil
+}
+
 func (s Service) verifyManifestJWT(ctx context.Context, token keyaccess.JWT) (*manifest.CredentialManifest, error) {
 	// parse headers
 	headers, err := keyaccess.GetJWTHeaders([]byte(token))
@@ -55,8 +55,29 @@ type GetManifestResponse struct {
 	Manifest manifestsdk.CredentialManifest `json:"manifest"`
 }
 
+// SignManifestRequest signs a manifest as a JWT, by the manifest's ID.
+type SignManifestRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type SignManifestResponse struct {
+	// ManifestJWT contains the manifest in a top level `credential_manifest` claim, signed by the manifest's issuer.
+	ManifestJWT keyaccess.JWT `json:"manifestJwt"`
+}
+
+type ListManifestsPageRequest struct {
+	// A storage dependent token of the page to list. Empty means the first page.
+	PageToken string
+	// A value of -1 means all manifests.
+	PageSize int
+}
+
 type ListManifestsResponse struct {
-	Manifests []GetManifestResponse `json:"manifests,omitempty"`
+	Manifests     []GetManifestResponse `json:"manifests,omitempty"`
+	NextPageToken string
+
+	// TotalCount is the number of manifests across all pages.
+	TotalCount int
 }
 
 type DeleteManifestRequest struct {
@@ -77,6 +98,8 @@ type SubmitApplicationResponse struct {
 	Response    manifestsdk.CredentialResponse `json:"response" validate:"required"`
 	Credentials []any                          `json:"credentials,omitempty"`
 	ResponseJWT keyaccess.JWT                  `json:"responseJwt,omitempty" validate:"required"`
+	// DeniedInputDescriptors say why each input descriptor of a denied application was denied.
+	DeniedInputDescriptors []storage.DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty"`
 }
 
 type GetApplicationRequest struct {
@@ -105,11 +128,22 @@ type ReviewApplicationRequest struct {
 	Approved bool   `json:"approved" validate:"required"`
 	// Reason is only used upon denial
 	Reason string `json:"reason"`
-	// TODO(gabe) add a way to specify which input descriptors resulted in the failure
+	// DeniedInputDescriptors are the input descriptors of the manifest's presentation definition that the application
+	// is denied for, with a code for each. Only used upon denial.
+	DeniedInputDescriptors []storage.DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty" validate:"omitempty,dive"`
 
 	CredentialOverrides map[string]CredentialOverride `json:"credentialOverrides,omitempty"`
 }
 
+// IssueOutputCredentialRequest issues the credential an output descriptor of a manifest describes, outside of an
+// application, e.g. to a wallet redeeming a credential offer.
+type IssueOutputCredentialRequest struct {
+	ManifestID         string `validate:"required"`
+	OutputDescriptorID string `validate:"required"`
+	Subject            string `validate:"required"`
+	Data               map[string]any
+}
+
 // Response
 
 type GetResponseRequest struct {
@@ -117,9 +151,15 @@ type GetResponseRequest struct {
 }
 
 type GetResponseResponse struct {
-	Response    manifestsdk.CredentialResponse `json:"response"`
-	Credentials any
-	ResponseJWT keyaccess.JWT
+	Response               manifestsdk.CredentialResponse `json:"response"`
+	Credentials            any
+	ResponseJWT            keyaccess.JWT
+	DeniedInputDescriptors []storage.DeniedInputDescriptor
+}
+
+// GetApplicationResponseRequest gets the credential response to an application, by the application's ID.
+type GetApplicationResponseRequest struct {
+	ID string `json:"id" validate:"required"`
 }
 
 type ListResponsesResponse struct {
@@ -130,12 +170,29 @@ type DeleteResponseRequest struct {
 	ID string `json:"id" validate:"required"`
 }
 
+type EraseApplicantRequest struct {
+	ApplicantDID string `json:"applicantDid" validate:"required"`
+
+	// When set, nothing is deleted, and the response lists what would be.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type EraseApplicantResponse struct {
+	// IDs of the applications of the applicant that were deleted.
+	ApplicationIDs []string `json:"applicationIds"`
+	// IDs of the responses to the applicant that were deleted.
+	ResponseIDs []string `json:"responseIds"`
+	// IDs of the operations that reviewed the applications, which were deleted along with them.
+	OperationIDs []string `json:"operationIds"`
+}
+
 // ServiceModel creates a SubmitApplicationResponse from a given StoredResponse.
 func ServiceModel(storedResponse *storage.StoredResponse) SubmitApplicationResponse {
 	return SubmitApplicationResponse{
-		Response:    storedResponse.Response,
-		Credentials: cred.ContainersToInterface(storedResponse.Credentials),
-		ResponseJWT: storedResponse.ResponseJWT,
+		Response:               storedResponse.Response,
+		Credentials:            cred.ContainersToInterface(storedResponse.Credentials),
+		ResponseJWT:            storedResponse.ResponseJWT,
+		DeniedInputDescriptors: storedResponse.DeniedInputDescriptors,
 	}
 }
 
@@ -0,0 +1,199 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/config"
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
+)
+
+const (
+	ReviewActionApprove = "approve"
+	ReviewActionDeny    = "deny"
+)
+
+// reviewPolicy is a config.ReviewPolicyConfig that is ready to match applications.
+type reviewPolicy struct {
+	name       string
+	approve    bool
+	reason     string
+	manifests  map[string]bool
+	issuers    map[string]bool
+	conditions []reviewCondition
+}
+
+type reviewCondition struct {
+	path    string
+	equals  string
+	pattern *regexp.Regexp
+}
+
+// newReviewPolicies validates the configured policies, in order.
+func newReviewPolicies(configs []config.ReviewPolicyConfig) ([]reviewPolicy, error) {
+	policies := make([]reviewPolicy, 0, len(configs))
+	for i, c := range configs {
+		policy := reviewPolicy{name: c.Name, reason: c.Reason}
+		if policy.name == "" {
+			policy.name = fmt.Sprintf("review policy %d", i+1)
+		}
+		if policy.reason == "" {
+			policy.reason = fmt.Sprintf("automatic from %s", policy.name)
+		}
+		switch c.Action {
+		case ReviewActionApprove:
+			policy.approve = true
+		case ReviewActionDeny:
+		default:
+			return nil, errors.Errorf("%s has action<%s>, which is neither %s nor %s", policy.name, c.Action, ReviewActionApprove, ReviewActionDeny)
+		}
+		if len(c.ManifestIDs) > 0 {
+			policy.manifests = make(map[string]bool, len(c.ManifestIDs))
+			for _, id := range c.ManifestIDs {
+				policy.manifests[id] = true
+			}
+		}
+		if len(c.Issuers) > 0 {
+			policy.issuers = make(map[string]bool, len(c.Issuers))
+			for _, issuer := range c.Issuers {
+				policy.issuers[issuer] = true
+			}
+		}
+		for _, condition := range c.Conditions {
+			if condition.Path == "" {
+				return nil, errors.Errorf("%s has a condition without a path", policy.name)
+			}
+			rc := reviewCondition{path: condition.Path, equals: condition.Equals}
+			if condition.Pattern != "" {
+				pattern, err := regexp.Compile(condition.Pattern)
+				if err != nil {
+					return nil, errors.Wrapf(err, "compiling pattern of %s", policy.name)
+				}
+				rc.pattern = pattern
+			}
+			policy.conditions = append(policy.conditions, rc)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// matchReviewPolicy returns the first policy that matches an application to the manifest with the submitted
+// credentials, or nil when none does.
+func matchReviewPolicy(policies []reviewPolicy, manifestID string, credentials []credint.Container) (*reviewPolicy, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	credentialsJSON := make([]map[string]any, 0, len(credentials))
+	for _, container := range credentials {
+		var c any = container.Credential
+		if container.Credential == nil && container.CredentialJWT != nil {
+			c = container.CredentialJWT.String()
+		}
+		credJSON, err := toCredentialJSON(c)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading submitted credential")
+		}
+		credentialsJSON = append(credentialsJSON, credJSON)
+	}
+	for i := range policies {
+		if policies[i].matches(manifestID, credentialsJSON) {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (p reviewPolicy) matches(manifestID string, credentials []map[string]any) bool {
+	if p.manifests != nil && !p.manifests[manifestID] {
+		return false
+	}
+	if p.issuers != nil {
+		// a policy that trusts issuers can't vouch for an application without credentials
+		if len(credentials) == 0 {
+			return false
+		}
+		for _, c := range credentials {
+			if !p.issuers[issuerOf(c)] {
+				return false
+			}
+		}
+	}
+	for _, condition := range p.conditions {
+		held := false
+		for _, c := range credentials {
+			if condition.holds(c) {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return false
+		}
+	}
+	return true
+}
+
+func (c reviewCondition) holds(credential map[string]any) bool {
+	value, err := jsonpath.JsonPathLookup(credential, c.path)
+	if err != nil || value == nil {
+		return false
+	}
+	values := stringValues(value)
+	if len(values) == 0 {
+		return false
+	}
+	if c.equals == "" && c.pattern == nil {
+		return true
+	}
+	for _, v := range values {
+		if (c.equals == "" || v == c.equals) && (c.pattern == nil || c.pattern.MatchString(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValues returns the values found at a JSONPath as strings, one for each item of the arrays that wildcards and
+// filters find.
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	default:
+		valueBytes, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return []string{string(valueBytes)}
+	}
+}
+
+// issuerOf returns the DID of the issuer of a credential, whose issuer is either the DID or an object with it as id.
+func issuerOf(credential map[string]any) string {
+	switch issuer := credential["issuer"].(type) {
+	case string:
+		return issuer
+	case map[string]any:
+		id, _ := issuer["id"].(string)
+		return id
+	}
+	return ""
+}
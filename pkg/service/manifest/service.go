@@ -3,6 +3,7 @@ package manifest
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
 	"github.com/TBD54566975/ssi-sdk/credential/manifest"
@@ -17,6 +18,7 @@ import (
 	"github.com/tbd54566975/ssi-service/config"
 	credint "github.com/tbd54566975/ssi-service/internal/credential"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	"github.com/tbd54566975/ssi-service/pkg/service/common"
 	"github.com/tbd54566975/ssi-service/pkg/service/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
@@ -29,16 +31,26 @@ import (
 	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
 	presmodel "github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const requestNamespace = "manifest_request"
 
+// ErrApplicationNotReviewed is returned when the response to an application is asked for before the application has
+// been reviewed, and so before there is a response.
+var ErrApplicationNotReviewed = errors.New("application has not been reviewed")
+
+// ErrInvalidDenial is returned when a review denies input descriptors that the application can't be denied for.
+var ErrInvalidDenial = errors.New("invalid denial")
+
 type Service struct {
 	storage                 *manifeststg.Storage
 	opsStorage              *operation.Storage
 	issuanceTemplateStorage *issuance.Storage
 	config                  config.ManifestServiceConfig
+	reviewPolicies          []reviewPolicy
 
 	// external dependencies
 	keyStore        *keystore.Service
@@ -46,6 +58,9 @@ type Service struct {
 	didResolver     resolution.Resolver
 	credential      *credential.Service
 
+	// publish publishes a webhook, which the webhook service does, when there is one.
+	publish func(ctx context.Context, noun webhook.Noun, verb webhook.Verb, data []byte)
+
 	Clock      clock.Clock
 	reqStorage common.RequestStorage
 }
@@ -94,12 +109,17 @@ func NewManifestService(config config.ManifestServiceConfig, s storage.ServiceSt
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for issuance templates")
 	}
+	reviewPolicies, err := newReviewPolicies(config.ReviewPolicies)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate review policies for the manifest service")
+	}
 	requestStorage := common.NewRequestStorage(s, requestNamespace)
 	return &Service{
 		storage:                 manifestStorage,
 		opsStorage:              opsStorage,
 		issuanceTemplateStorage: issuanceStorage,
 		config:                  config,
+		reviewPolicies:          reviewPolicies,
 		keyStore:                keyStore,
 		didResolver:             didResolver,
 		credential:              credential,
@@ -109,6 +129,12 @@ func NewManifestService(config config.ManifestServiceConfig, s storage.ServiceSt
 	}, nil
 }
 
+// SetWebhooks makes the service publish the responses to denied applications to the Application Deny webhooks of
+// webhookService. It must be called before the service is handed to other services.
+func (s *Service) SetWebhooks(webhookService *webhook.Service) {
+	s.publish = webhookService.PublishEvent
+}
+
 // CredentialManifestContainer represents what is signed over and return for a credential manifest
 type CredentialManifestContainer struct {
 	Manifest manifest.CredentialManifest `json:"credential_manifest"`
@@ -255,6 +281,28 @@ func (s Service) ListManifests(ctx context.Context) (*model.ListManifestsRespons
 	return &response, nil
 }
 
+// ListManifestsPage lists the manifests in a page, ordered by their ids, reading only that page from storage.
+func (s Service) ListManifestsPage(ctx context.Context, request model.ListManifestsPageRequest) (*model.ListManifestsResponse, error) {
+	page, err := s.storage.ListManifestsPage(ctx, request.PageToken, request.PageSize)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list manifests(s)")
+	}
+	total, err := s.storage.CountManifests(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not count manifests(s)")
+	}
+
+	response := model.ListManifestsResponse{
+		Manifests:     make([]model.GetManifestResponse, 0, len(page.Manifests)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    total,
+	}
+	for _, m := range page.Manifests {
+		response.Manifests = append(response.Manifests, model.GetManifestResponse{Manifest: m.Manifest})
+	}
+	return &response, nil
+}
+
 func (s Service) DeleteManifest(ctx context.Context, request model.DeleteManifestRequest) error {
 	logrus.Debugf("deleting manifest: %s", request.ID)
 
@@ -272,12 +320,16 @@ type CredentialResponseContainer struct {
 }
 
 // ProcessApplicationSubmission stores the application in a pending state, along with an operation.
+// When a configured review policy matches the application, the application is approved or denied as the policy says.
 // When there is an issuance template related to this manifest, the operation is done immediately.
 // Once the operation is done, the Operation.Response field will be of type model.SubmitApplicationResponse.
 // Invalid applications return an operation marked as done, with Response that represents denial.
 // The state of the application can be updated by calling CancelOperation, or by calling ReviewApplicationSubmission.
 // When the state is updated, the operation is marked as done.
-func (s Service) ProcessApplicationSubmission(ctx context.Context, request model.SubmitApplicationRequest) (*operation.Operation, error) {
+func (s Service) ProcessApplicationSubmission(ctx context.Context, request model.SubmitApplicationRequest) (_ *operation.Operation, err error) {
+	ctx, span := tracing.Start(ctx, "manifest.ProcessApplicationSubmission", attribute.String("manifest.id", request.Application.ManifestID))
+	defer func() { tracing.End(span, err) }()
+
 	// get the manifest associated with the application
 	manifestID := request.Application.ManifestID
 	gotManifest, err := s.storage.GetManifest(ctx, manifestID)
@@ -338,10 +390,29 @@ func (s Service) ProcessApplicationSubmission(ctx context.Context, request model
 		return nil, errors.Wrap(err, "storing operation")
 	}
 
+	policy, err := matchReviewPolicy(s.reviewPolicies, manifestID, request.Credentials)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "matching review policies")
+	}
+	if policy != nil && !policy.approve {
+		_, deniedOp, err := s.reviewApplication(ctx, model.ReviewApplicationRequest{ID: applicationID, Reason: policy.reason})
+		if err != nil {
+			return nil, errors.Wrapf(err, "denying application from %s", policy.name)
+		}
+		return operation.ServiceModel(*deniedOp)
+	}
+
 	autoStoredOp, err := s.attemptAutomaticIssuance(ctx, request, manifestID, applicantDID, applicationID, *gotManifest)
 	if err != nil {
 		return nil, err
 	}
+	if autoStoredOp == nil && policy != nil {
+		_, approvedOp, err := s.reviewApplication(ctx, model.ReviewApplicationRequest{ID: applicationID, Approved: true, Reason: policy.reason})
+		if err != nil {
+			return nil, errors.Wrapf(err, "approving application from %s", policy.name)
+		}
+		autoStoredOp = approvedOp
+	}
 
 	if autoStoredOp != nil {
 		storedOp = autoStoredOp
@@ -401,23 +472,39 @@ func (s Service) attemptAutomaticIssuance(ctx context.Context, request model.Sub
 // ReviewApplication moves an application state and marks the operation associated with it as done. A credential
 // response is stored.
 func (s Service) ReviewApplication(ctx context.Context, request model.ReviewApplicationRequest) (*model.SubmitApplicationResponse, error) {
+	storedResponse, _, err := s.reviewApplication(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	m := model.ServiceModel(storedResponse)
+	return &m, nil
+}
+
+// reviewApplication reviews an application as ReviewApplication does, returning the stored response and the operation
+// it marked as done.
+func (s Service) reviewApplication(ctx context.Context, request model.ReviewApplicationRequest) (*manifeststg.StoredResponse, *opstorage.StoredOperation, error) {
 	application, err := s.storage.GetApplication(ctx, request.ID)
 	if err != nil {
-		return nil, errors.Wrap(err, "fetching application")
+		return nil, nil, errors.Wrap(err, "fetching application")
 	}
 
 	manifestID := application.ManifestID
 	gotManifest, err := s.storage.GetManifest(ctx, manifestID)
 	if err != nil {
-		return nil, errors.Wrap(err, "fetching manifest")
+		return nil, nil, errors.Wrap(err, "fetching manifest")
 	}
 	applicationID := application.ID
 	if gotManifest == nil {
-		return nil, sdkutil.LoggingNewErrorf("application<%s> is not valid; a manifest does not exist with id: %s", applicationID, manifestID)
+		return nil, nil, sdkutil.LoggingNewErrorf("application<%s> is not valid; a manifest does not exist with id: %s", applicationID, manifestID)
 	}
 	credManifest := gotManifest.Manifest
 	applicantDID := application.ApplicantDID
 
+	if err = validateDenial(request, credManifest); err != nil {
+		return nil, nil, err
+	}
+
+	reason := request.Reason
 	var responseContainer CredentialResponseContainer
 	var credentials []credint.Container
 	if request.Approved {
@@ -426,7 +513,7 @@ func (s Service) ReviewApplication(ctx context.Context, request model.ReviewAppl
 		approvalResponse, creds, err := s.buildFulfillmentCredentialResponse(ctx, applicantDID, applicationID, manifestID, gotManifest.FullyQualifiedVerificationMethodID, credManifest, request.CredentialOverrides)
 		if err != nil {
 			logrus.Debugln("start Approved build failed")
-			return nil, sdkutil.LoggingErrorMsg(err, "building credential response")
+			return nil, nil, sdkutil.LoggingErrorMsg(err, "building credential response")
 		}
 		credentials = creds
 
@@ -437,9 +524,16 @@ func (s Service) ReviewApplication(ctx context.Context, request model.ReviewAppl
 			Credentials: genericCredentials,
 		}
 	} else {
-		denialResponse, err := buildDenialCredentialResponse(manifestID, applicantDID, applicationID, request.Reason)
+		deniedIDs := make([]string, 0, len(request.DeniedInputDescriptors))
+		for _, denied := range request.DeniedInputDescriptors {
+			deniedIDs = append(deniedIDs, denied.ID)
+		}
+		if reason == "" && len(deniedIDs) > 0 {
+			reason = denialReason(request.DeniedInputDescriptors)
+		}
+		denialResponse, err := buildDenialCredentialResponse(manifestID, applicantDID, applicationID, reason, deniedIDs...)
 		if err != nil {
-			return nil, sdkutil.LoggingErrorMsg(err, "building denial credential response")
+			return nil, nil, sdkutil.LoggingErrorMsg(err, "building denial credential response")
 		}
 		responseContainer = CredentialResponseContainer{Response: *denialResponse}
 	}
@@ -448,7 +542,7 @@ func (s Service) ReviewApplication(ctx context.Context, request model.ReviewAppl
 	keyStoreID := did.FullyQualifiedVerificationMethodID(gotManifest.IssuerDID, gotManifest.FullyQualifiedVerificationMethodID)
 	responseJWT, err := s.signCredentialResponse(ctx, keyStoreID, responseContainer)
 	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "could not sign credential response")
+		return nil, nil, sdkutil.LoggingErrorMsg(err, "could not sign credential response")
 	}
 
 	// store the response we've generated
@@ -459,15 +553,111 @@ func (s Service) ReviewApplication(ctx context.Context, request model.ReviewAppl
 		Response:     responseContainer.Response,
 		Credentials:  credentials,
 		ResponseJWT:  *responseJWT,
+
+		DeniedInputDescriptors: request.DeniedInputDescriptors,
 	}
-	storedResponse, _, err := s.storage.StoreReviewApplication(ctx, request.ID, request.Approved, request.Reason,
+	storedResponse, storedOp, err := s.storage.StoreReviewApplication(ctx, request.ID, request.Approved, reason,
 		opcredential.IDFromResponseID(request.ID), storeResponseRequest)
 	if err != nil {
-		return nil, errors.Wrap(err, "updating submission")
+		return nil, nil, errors.Wrap(err, "updating submission")
+	}
+	if !request.Approved {
+		s.publishDenial(ctx, storedResponse)
 	}
+	return storedResponse, storedOp, nil
+}
 
-	m := model.ServiceModel(storedResponse)
-	return &m, nil
+// validateDenial checks that the input descriptors a review denies are those of the manifest's presentation
+// definition, each denied once with a known code, and that they're only denied along with the application.
+func validateDenial(request model.ReviewApplicationRequest, credManifest manifest.CredentialManifest) error {
+	if len(request.DeniedInputDescriptors) == 0 {
+		return nil
+	}
+	if request.Approved {
+		return sdkutil.LoggingErrorMsg(ErrInvalidDenial, "input descriptors can only be denied along with the application")
+	}
+	inputDescriptors := make(map[string]bool)
+	if credManifest.PresentationDefinition != nil {
+		for _, id := range credManifest.PresentationDefinition.InputDescriptors {
+			inputDescriptors[id.ID] = true
+		}
+	}
+	denied := make(map[string]bool, len(request.DeniedInputDescriptors))
+	for _, d := range request.DeniedInputDescriptors {
+		switch {
+		case !inputDescriptors[d.ID]:
+			return sdkutil.LoggingErrorMsgf(ErrInvalidDenial, "manifest<%s> has no input descriptor with id: %s", credManifest.ID, d.ID)
+		case denied[d.ID]:
+			return sdkutil.LoggingErrorMsgf(ErrInvalidDenial, "input descriptor<%s> is denied more than once", d.ID)
+		case !d.Code.IsValid():
+			return sdkutil.LoggingErrorMsgf(ErrInvalidDenial, "input descriptor<%s> has unknown denial code: %s", d.ID, d.Code)
+		}
+		denied[d.ID] = true
+	}
+	return nil
+}
+
+// denialReason describes denied input descriptors, for denials that are given no reason of their own.
+func denialReason(denied []manifeststg.DeniedInputDescriptor) string {
+	reasons := make([]string, 0, len(denied))
+	for _, d := range denied {
+		reason := fmt.Sprintf("%s: %s", d.ID, d.Code)
+		if d.Reason != "" {
+			reason += fmt.Sprintf(" (%s)", d.Reason)
+		}
+		reasons = append(reasons, reason)
+	}
+	return "denied input descriptors " + strings.Join(reasons, "; ")
+}
+
+// publishDenial publishes the response to a denied application to the Application Deny webhooks, when there are
+// webhooks. The denial is stored by then, so failures are logged rather than failing the review.
+func (s Service) publishDenial(ctx context.Context, response *manifeststg.StoredResponse) {
+	if s.publish == nil {
+		return
+	}
+	data, err := json.Marshal(model.ServiceModel(response))
+	if err != nil {
+		logrus.WithError(err).Errorf("marshalling denial of application: %s", response.Response.ApplicationID)
+		return
+	}
+	s.publish(ctx, webhook.Application, webhook.Deny, data)
+}
+
+// IssueOutputCredential issues the credential an output descriptor of a manifest describes to the subject, signed by
+// the manifest's issuer, with the data of its subject.
+func (s Service) IssueOutputCredential(ctx context.Context, request model.IssueOutputCredentialRequest) (*credint.Container, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid issue output credential request")
+	}
+	gotManifest, err := s.storage.GetManifest(ctx, request.ManifestID)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching manifest")
+	}
+	if gotManifest == nil {
+		return nil, sdkutil.LoggingNewErrorf("manifest not found with id: %s", request.ManifestID)
+	}
+	for _, od := range gotManifest.Manifest.OutputDescriptors {
+		if od.ID != request.OutputDescriptorID {
+			continue
+		}
+		data := request.Data
+		if data == nil {
+			data = make(map[string]any)
+		}
+		created, err := s.credential.CreateCredential(ctx, credential.CreateCredentialRequest{
+			Issuer:                             gotManifest.Manifest.Issuer.ID,
+			FullyQualifiedVerificationMethodID: gotManifest.FullyQualifiedVerificationMethodID,
+			Subject:                            request.Subject,
+			SchemaID:                           od.Schema,
+			Data:                               data,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating credential")
+		}
+		return &created.Container, nil
+	}
+	return nil, sdkutil.LoggingNewErrorf("manifest<%s> has no output descriptor with id: %s", request.ManifestID, request.OutputDescriptorID)
 }
 
 func (s Service) GetApplication(ctx context.Context, request model.GetApplicationRequest) (*model.GetApplicationResponse, error) {
@@ -521,10 +711,41 @@ func (s Service) GetResponse(ctx context.Context, request model.GetResponseReque
 		Response:    gotResponse.Response,
 		Credentials: credint.ContainersToInterface(gotResponse.Credentials),
 		ResponseJWT: gotResponse.ResponseJWT,
+
+		DeniedInputDescriptors: gotResponse.DeniedInputDescriptors,
 	}
 	return &response, nil
 }
 
+// GetApplicationResponse gets the credential response that reviewing an application produced, which is the signed
+// fulfillment with its credentials when the application was approved, and the denial when it was not.
+func (s Service) GetApplicationResponse(ctx context.Context, request model.GetApplicationResponseRequest) (*model.GetResponseResponse, error) {
+	logrus.Debugf("getting response to application: %s", request.ID)
+
+	gotApp, err := s.storage.GetApplication(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get application: %s", request.ID)
+	}
+	responseID := gotApp.ResponseID
+	if responseID == "" {
+		// applications reviewed before their response was recorded with them are matched by the response's application
+		gotResponses, err := s.storage.ListResponses(ctx)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not list responses")
+		}
+		for _, res := range gotResponses {
+			if res.Response.ApplicationID == request.ID {
+				responseID = res.Response.ID
+				break
+			}
+		}
+	}
+	if responseID == "" {
+		return nil, sdkutil.LoggingErrorMsgf(ErrApplicationNotReviewed, "could not get response to application: %s", request.ID)
+	}
+	return s.GetResponse(ctx, model.GetResponseRequest{ID: responseID})
+}
+
 func (s Service) ListResponses(ctx context.Context) (*model.ListResponsesResponse, error) {
 	logrus.Debugf("listing responses")
 
@@ -552,6 +773,68 @@ func (s Service) DeleteResponse(ctx context.Context, request model.DeleteRespons
 	return nil
 }
 
+// EraseApplicant deletes every application made by an applicant and every response sent to them, along with the
+// operations reviewing the applications.
+func (s Service) EraseApplicant(ctx context.Context, request model.EraseApplicantRequest) (*model.EraseApplicantResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	logrus.Debugf("erasing applications of applicant: %s", request.ApplicantDID)
+
+	resp := model.EraseApplicantResponse{
+		ApplicationIDs: make([]string, 0),
+		ResponseIDs:    make([]string, 0),
+		OperationIDs:   make([]string, 0),
+	}
+	gotApps, err := s.storage.ListApplications(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list application(s)")
+	}
+	for _, app := range gotApps {
+		if app.ApplicantDID != request.ApplicantDID {
+			continue
+		}
+		// applications are stored under the ID of the credential application they hold
+		applicationID := app.Application.ID
+		opID := opcredential.IDFromResponseID(applicationID)
+		var deleted bool
+		if request.DryRun {
+			if deleted, err = s.opsStorage.OperationExists(ctx, opID); err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "could not check operation with id: %s", opID)
+			}
+		} else {
+			if err = s.storage.DeleteApplication(ctx, applicationID); err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "could not erase application with id: %s", applicationID)
+			}
+			if deleted, err = s.opsStorage.DeleteOperationIfExists(ctx, opID); err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "could not erase operation with id: %s", opID)
+			}
+		}
+		resp.ApplicationIDs = append(resp.ApplicationIDs, applicationID)
+		if deleted {
+			resp.OperationIDs = append(resp.OperationIDs, opID)
+		}
+	}
+
+	gotResponses, err := s.storage.ListResponses(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list responses")
+	}
+	for _, res := range gotResponses {
+		if res.ApplicantDID != request.ApplicantDID {
+			continue
+		}
+		responseID := res.Response.ID
+		if !request.DryRun {
+			if err = s.storage.DeleteResponse(ctx, responseID); err != nil {
+				return nil, sdkutil.LoggingErrorMsgf(err, "could not erase response with id: %s", responseID)
+			}
+		}
+		resp.ResponseIDs = append(resp.ResponseIDs, responseID)
+	}
+	return &resp, nil
+}
+
 func (s Service) CreateRequest(ctx context.Context, req model.CreateRequestRequest) (*model.Request, error) {
 	if err := sdkutil.IsValidStruct(req); err != nil {
 		return nil, err
@@ -569,7 +852,7 @@ func (s Service) CreateRequest(ctx context.Context, req model.CreateRequestReque
 	claimName := "credential_manifest"
 	claimValue := storedManifest.Manifest
 
-	stored, err := common.CreateStoredRequest(ctx, s.keyStore, claimName, claimValue, request.Request, request.ManifestID)
+	stored, err := common.CreateStoredRequest(ctx, s.keyStore, claimName, claimValue, request.Request, request.ManifestID, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "creating stored request")
 	}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"sort"
 
 	"github.com/TBD54566975/ssi-sdk/credential/manifest"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
@@ -40,6 +41,8 @@ type StoredApplication struct {
 	Application    manifest.CredentialApplication `json:"application"`
 	Credentials    []cred.Container               `json:"credentials"`
 	ApplicationJWT keyaccess.JWT                  `json:"applicationJwt"`
+	// ResponseID is the ID of the credential response that the review of the application produced.
+	ResponseID string `json:"responseId,omitempty"`
 }
 
 type StoredResponse struct {
@@ -49,6 +52,44 @@ type StoredResponse struct {
 	Response     manifest.CredentialResponse `json:"response"`
 	Credentials  []cred.Container            `json:"credentials"`
 	ResponseJWT  keyaccess.JWT               `json:"responseJwt"`
+	// DeniedInputDescriptors say why each input descriptor of a denied application was denied.
+	DeniedInputDescriptors []DeniedInputDescriptor `json:"deniedInputDescriptors,omitempty"`
+}
+
+// DenialCode says why an input descriptor of an application was denied.
+type DenialCode string
+
+const (
+	// DenialCodeMissing is for input descriptors the application submits no credential for.
+	DenialCodeMissing DenialCode = "missing"
+	// DenialCodeInvalid is for credentials that aren't valid, e.g. because their signature doesn't verify.
+	DenialCodeInvalid DenialCode = "invalid"
+	DenialCodeExpired DenialCode = "expired"
+	DenialCodeRevoked DenialCode = "revoked"
+	// DenialCodeUntrustedIssuer is for credentials whose issuer isn't trusted for the input descriptor.
+	DenialCodeUntrustedIssuer DenialCode = "untrusted_issuer"
+	// DenialCodeUnsatisfied is for credentials that don't satisfy the constraints of the input descriptor.
+	DenialCodeUnsatisfied DenialCode = "unsatisfied"
+	DenialCodeOther       DenialCode = "other"
+)
+
+func (c DenialCode) IsValid() bool {
+	switch c {
+	case DenialCodeMissing, DenialCodeInvalid, DenialCodeExpired, DenialCodeRevoked, DenialCodeUntrustedIssuer,
+		DenialCodeUnsatisfied, DenialCodeOther:
+		return true
+	}
+	return false
+}
+
+// DeniedInputDescriptor is an input descriptor of the presentation definition of a manifest that an application was
+// denied for, with the reason it was.
+type DeniedInputDescriptor struct {
+	// ID of the input descriptor.
+	ID   string     `json:"id" validate:"required"`
+	Code DenialCode `json:"code" validate:"required"`
+	// Reason describes the denial to the applicant in more detail than its code.
+	Reason string `json:"reason,omitempty"`
 }
 
 type Storage struct {
@@ -91,24 +132,64 @@ func (ms *Storage) GetManifest(ctx context.Context, id string) (*StoredManifest,
 
 // ListManifests attempts to get all stored manifests. It will return those it can even if it has trouble with some.
 func (ms *Storage) ListManifests(ctx context.Context) ([]StoredManifest, error) {
-	gotManifests, err := ms.db.ReadAll(ctx, manifestNamespace)
+	var stored []StoredManifest
+	err := ms.db.Iterate(ctx, manifestNamespace, func(_ string, manifestBytes []byte) (bool, error) {
+		var nextManifest StoredManifest
+		if err := json.Unmarshal(manifestBytes, &nextManifest); err == nil {
+			stored = append(stored, nextManifest)
+		} else {
+			logrus.Errorf("could not unmarshal manifest while getting all manifests: %s", err.Error())
+		}
+		return true, nil
+	})
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "getting all manifests")
 	}
-	if len(gotManifests) == 0 {
+	if len(stored) == 0 {
 		logrus.Info("no manifests to get")
 		return nil, nil
 	}
-	var stored []StoredManifest
-	for _, manifestBytes := range gotManifests {
+	return stored, nil
+}
+
+// ManifestsPage is a page of manifests, ordered by their ids.
+type ManifestsPage struct {
+	Manifests     []StoredManifest
+	NextPageToken string
+}
+
+// ListManifestsPage returns the manifests in the page with the given token and size. A size of -1 means all manifests.
+// It will return those it can even if it has trouble with some.
+func (ms *Storage) ListManifestsPage(ctx context.Context, token string, size int) (*ManifestsPage, error) {
+	manifests, nextPageToken, err := ms.db.ReadPage(ctx, manifestNamespace, token, size)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "reading page of manifests")
+	}
+	ids := make([]string, 0, len(manifests))
+	for id := range manifests {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	stored := make([]StoredManifest, 0, len(ids))
+	for _, id := range ids {
 		var nextManifest StoredManifest
-		if err = json.Unmarshal(manifestBytes, &nextManifest); err == nil {
-			stored = append(stored, nextManifest)
-		} else {
-			logrus.Errorf("could not unmarshal manifest while getting all manifests: %s", err.Error())
+		if err = json.Unmarshal(manifests[id], &nextManifest); err != nil {
+			logrus.Errorf("could not unmarshal manifest<%s> while listing manifests: %s", id, err.Error())
+			continue
 		}
+		stored = append(stored, nextManifest)
 	}
-	return stored, nil
+	return &ManifestsPage{Manifests: stored, NextPageToken: nextPageToken}, nil
+}
+
+// CountManifests returns how many manifests are stored.
+func (ms *Storage) CountManifests(ctx context.Context) (int, error) {
+	ids, err := ms.db.ReadAllKeys(ctx, manifestNamespace)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "reading manifest ids")
+	}
+	return len(ids), nil
 }
 
 func (ms *Storage) DeleteManifest(ctx context.Context, id string) error {
@@ -147,22 +228,22 @@ func (ms *Storage) GetApplication(ctx context.Context, id string) (*StoredApplic
 
 // ListApplications attempts to get all stored applications. It will return those it can even if it has trouble with some.
 func (ms *Storage) ListApplications(ctx context.Context) ([]StoredApplication, error) {
-	gotApplications, err := ms.db.ReadAll(ctx, credential.ApplicationNamespace)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "listing all applications")
-	}
-	if len(gotApplications) == 0 {
-		logrus.Info("no applications to list")
-		return nil, nil
-	}
 	var stored []StoredApplication
-	for appKey, applicationBytes := range gotApplications {
+	err := ms.db.Iterate(ctx, credential.ApplicationNamespace, func(appKey string, applicationBytes []byte) (bool, error) {
 		var nextApplication StoredApplication
-		if err = json.Unmarshal(applicationBytes, &nextApplication); err == nil {
+		if err := json.Unmarshal(applicationBytes, &nextApplication); err == nil {
 			stored = append(stored, nextApplication)
 		} else {
 			logrus.WithError(err).Errorf("could not unmarshal stored application while listing all applications: %s", appKey)
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing all applications")
+	}
+	if len(stored) == 0 {
+		logrus.Info("no applications to list")
+		return nil, nil
 	}
 	return stored, nil
 }
@@ -203,22 +284,22 @@ func (ms *Storage) GetResponse(ctx context.Context, id string) (*StoredResponse,
 
 // ListResponses attempts to get all stored responses. It will return those it can even if it has trouble with some.
 func (ms *Storage) ListResponses(ctx context.Context) ([]StoredResponse, error) {
-	gotResponses, err := ms.db.ReadAll(ctx, responseNamespace)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "listing all responses")
-	}
-	if len(gotResponses) == 0 {
-		logrus.Info("no responses to list")
-		return nil, nil
-	}
 	var stored []StoredResponse
-	for responseKey, responseBytes := range gotResponses {
+	err := ms.db.Iterate(ctx, responseNamespace, func(responseKey string, responseBytes []byte) (bool, error) {
 		var nextResponse StoredResponse
-		if err = json.Unmarshal(responseBytes, &nextResponse); err == nil {
+		if err := json.Unmarshal(responseBytes, &nextResponse); err == nil {
 			stored = append(stored, nextResponse)
 		} else {
 			logrus.WithError(err).Errorf("could not unmarshal stored response while listing all responses: %s", responseKey)
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "listing all responses")
+	}
+	if len(stored) == 0 {
+		logrus.Info("no responses to list")
+		return nil, nil
 	}
 	return stored, nil
 }
@@ -231,7 +312,7 @@ func (ms *Storage) DeleteResponse(ctx context.Context, id string) error {
 }
 
 // StoreReviewApplication does the following:
-//  1. Updates the application status according to the approved parameter.
+//  1. Updates the application status according to the approved parameter, and records the ID of the response.
 //  2. Creates a Credential Response corresponding to the approved parameter and with the given reason.
 //  3. Marks the operation with id == opID as done, and sets operation.Response to the StoredResponse from the object
 //     creates in step 2.
@@ -246,7 +327,11 @@ func (ms *Storage) StoreReviewApplication(ctx context.Context, applicationID str
 	if approved {
 		m["status"] = opsubmission.StatusApproved
 	}
-	if _, err := storage.Update(ctx, ms.db, credential.ApplicationNamespace, applicationID, m); err != nil {
+	applicationUpdate := map[string]any{"responseId": response.ID}
+	for k, v := range m {
+		applicationUpdate[k] = v
+	}
+	if _, err := storage.Update(ctx, ms.db, credential.ApplicationNamespace, applicationID, applicationUpdate); err != nil {
 		return nil, nil, errors.Wrap(err, "updating application")
 	}
 
@@ -0,0 +1,154 @@
+package oidc4vci
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+const (
+	// PreAuthorizedCodeGrantType is the grant type wallets redeem the pre-authorized code of an offer with.
+	PreAuthorizedCodeGrantType = "urn:ietf:params:oauth:grant-type:pre-authorized_code"
+
+	// ProofType is the only type of proof of possession accepted, a JWT signed by a key of the holder's DID.
+	ProofType = "jwt"
+	// ProofJWTType is the typ header of proof of possession JWTs.
+	ProofJWTType = "openid4vci-proof+jwt"
+	// FormatJWTVCJSON is the only format credentials are issued in.
+	FormatJWTVCJSON = "jwt_vc_json"
+)
+
+// Error codes of the token and credential endpoints.
+const (
+	ErrorInvalidRequest              = "invalid_request"
+	ErrorInvalidGrant                = "invalid_grant"
+	ErrorUnsupportedGrantType        = "unsupported_grant_type"
+	ErrorInvalidToken                = "invalid_token"
+	ErrorInvalidOrMissingProof       = "invalid_or_missing_proof"
+	ErrorUnsupportedCredentialFormat = "unsupported_credential_format"
+	ErrorUnsupportedCredentialType   = "unsupported_credential_type"
+)
+
+// Error is an error of the token or credential endpoint, which wallets are answered with as OAuth 2.0 errors. Errors of
+// proofs carry a fresh nonce to sign the next proof over.
+type Error struct {
+	Code            string `json:"error"`
+	Description     string `json:"error_description,omitempty"`
+	CNonce          string `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int    `json:"c_nonce_expires_in,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+// CredentialID is the ID of the credential an output descriptor of a manifest describes, in offers and the issuer's
+// metadata.
+func CredentialID(manifestID, outputDescriptorID string) string {
+	return manifestID + "/" + outputDescriptorID
+}
+
+type CreateOfferRequest struct {
+	// ID of the manifest whose credentials are offered.
+	ManifestID string `json:"manifestId" validate:"required"`
+
+	// IDs of the output descriptors of the manifest whose credentials are offered. All of them when empty.
+	OutputDescriptorIDs []string `json:"outputDescriptorIds,omitempty"`
+
+	// Data of the subject of the offered credentials.
+	Data map[string]any `json:"data,omitempty"`
+
+	// Whether wallets need a PIN, given to the holder apart from the offer, to redeem it.
+	UserPinRequired bool `json:"userPinRequired,omitempty"`
+}
+
+// CredentialOffer is the credential offer wallets are given, as OpenID for Verifiable Credential Issuance defines it.
+type CredentialOffer struct {
+	CredentialIssuer string                            `json:"credential_issuer"`
+	Credentials      []string                          `json:"credentials"`
+	Grants           map[string]PreAuthorizedCodeGrant `json:"grants"`
+}
+
+type PreAuthorizedCodeGrant struct {
+	PreAuthorizedCode string `json:"pre-authorized_code"`
+	UserPinRequired   bool   `json:"user_pin_required"`
+}
+
+type CreateOfferResponse struct {
+	Offer
+
+	// The offer to give the wallet, which holds the pre-authorized code, so it's only returned once.
+	CredentialOffer CredentialOffer `json:"credentialOffer"`
+
+	// The offer as an openid-credential-offer URI, e.g. for a QR code.
+	CredentialOfferURI string `json:"credentialOfferUri"`
+
+	// PIN to give the holder apart from the offer, when one is required. It's only returned once.
+	UserPin string `json:"userPin,omitempty"`
+}
+
+// Offer is what's stored about an offer, without its secrets.
+type Offer struct {
+	ID                  string         `json:"id"`
+	ManifestID          string         `json:"manifestId"`
+	OutputDescriptorIDs []string       `json:"outputDescriptorIds"`
+	Data                map[string]any `json:"data,omitempty"`
+	UserPinRequired     bool           `json:"userPinRequired,omitempty"`
+	CreatedAt           time.Time      `json:"createdAt"`
+
+	// When the pre-authorized code can no longer be redeemed.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// When the pre-authorized code was redeemed, which is only once.
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+}
+
+type GetOfferRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type TokenRequest struct {
+	GrantType         string
+	PreAuthorizedCode string
+	UserPin           string
+}
+
+type TokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	CNonce          string `json:"c_nonce"`
+	CNonceExpiresIn int    `json:"c_nonce_expires_in"`
+}
+
+type CredentialRequest struct {
+	AccessToken string
+
+	Format string
+
+	// ID of the offered credential to issue. The first offered credential that wasn't issued yet when empty.
+	CredentialIdentifier string
+
+	ProofType string
+	ProofJWT  keyaccess.JWT
+}
+
+type CredentialResponse struct {
+	Format          string `json:"format"`
+	Credential      any    `json:"credential"`
+	CNonce          string `json:"c_nonce"`
+	CNonceExpiresIn int    `json:"c_nonce_expires_in"`
+}
+
+// AuthorizationServerMetadata tells wallets where to redeem pre-authorized codes, as RFC 8414 defines it.
+type AuthorizationServerMetadata struct {
+	Issuer                                     string   `json:"issuer"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	PreAuthorizedGrantAnonymousAccessSupported bool     `json:"pre-authorized_grant_anonymous_access_supported"`
+}
@@ -0,0 +1,530 @@
+package oidc4vci
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	"github.com/TBD54566975/ssi-sdk/oidc/issuance"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	didint "github.com/tbd54566975/ssi-service/internal/did"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	manifestmodel "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultOfferTTL is how long pre-authorized codes can be redeemed when no TTL is configured.
+	DefaultOfferTTL = 24 * time.Hour
+	// DefaultAccessTokenTTL is how long access tokens are valid when no TTL is configured.
+	DefaultAccessTokenTTL = 10 * time.Minute
+	// DefaultNonceTTL is how long nonces are valid when no TTL is configured.
+	DefaultNonceTTL = 5 * time.Minute
+	// PurgeInterval is how often expired codes and tokens are deleted.
+	PurgeInterval = time.Hour
+
+	// TokenPath and CredentialPath are the paths of the token and credential endpoints, under the credential issuer.
+	TokenPath      = "/oidc4vci/token"
+	CredentialPath = "/oidc4vci/credential"
+
+	// offerURIScheme is the scheme of the URIs that pass credential offers to wallets.
+	offerURIScheme = "openid-credential-offer://"
+
+	userPinDigits  = 6
+	maxPinAttempts = 5
+)
+
+// Service issues the credentials of manifests to wallets with OpenID for Verifiable Credential Issuance. An operator
+// creates an offer of the credentials of a manifest, which the wallet redeems for an access token with the offer's
+// pre-authorized code, and then for the credentials, issued to the DID it proves possession of.
+type Service struct {
+	storage     *Storage
+	config      config.OIDC4VCIServiceConfig
+	manifest    *manifest.Service
+	didResolver resolution.Resolver
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.OIDC4VCI
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.manifest == nil {
+		ae.AppendString("no manifest service configured")
+	}
+	if s.didResolver == nil {
+		ae.AppendString("no did resolver configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("oidc4vci service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewOIDC4VCIService creates the service, which keeps offers and tokens in globalStorage, and issues the credentials of
+// the manifests of manifestService.
+func NewOIDC4VCIService(cfg config.OIDC4VCIServiceConfig, globalStorage storage.ServiceStorage, manifestService *manifest.Service, didResolver resolution.Resolver) (*Service, error) {
+	oidcStorage, err := NewOIDC4VCIStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the oidc4vci service")
+	}
+	issuer, err := url.Parse(cfg.CredentialIssuer)
+	if err != nil || issuer.Scheme == "" || issuer.Host == "" {
+		return nil, sdkutil.LoggingNewErrorf("credential_issuer<%s> must be an absolute url", cfg.CredentialIssuer)
+	}
+	cfg.CredentialIssuer = strings.TrimSuffix(cfg.CredentialIssuer, "/")
+	if cfg.OfferTTL < 0 || cfg.AccessTokenTTL < 0 || cfg.NonceTTL < 0 {
+		return nil, sdkutil.LoggingNewError("offer_ttl, access_token_ttl, and nonce_ttl cannot be negative")
+	}
+	if cfg.OfferTTL == 0 {
+		cfg.OfferTTL = DefaultOfferTTL
+	}
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if cfg.NonceTTL == 0 {
+		cfg.NonceTTL = DefaultNonceTTL
+	}
+	service := Service{
+		storage:     oidcStorage,
+		config:      cfg,
+		manifest:    manifestService,
+		didResolver: didResolver,
+		Clock:       clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// CredentialIssuer returns the URL wallets know the issuer by.
+func (s Service) CredentialIssuer() string {
+	return s.config.CredentialIssuer
+}
+
+// CreateOffer creates an offer of the credentials of a manifest, to be issued with the offer's data, and returns it
+// along with its pre-authorized code and PIN, which aren't returned again.
+func (s Service) CreateOffer(ctx context.Context, request CreateOfferRequest) (*CreateOfferResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create offer request")
+	}
+	gotManifest, err := s.manifest.GetManifest(ctx, manifestmodel.GetManifestRequest{ID: request.ManifestID})
+	if err != nil {
+		return nil, err
+	}
+	outputDescriptorIDs := request.OutputDescriptorIDs
+	if len(outputDescriptorIDs) == 0 {
+		for _, od := range gotManifest.Manifest.OutputDescriptors {
+			outputDescriptorIDs = append(outputDescriptorIDs, od.ID)
+		}
+	}
+	credentialIDs := make([]string, 0, len(outputDescriptorIDs))
+	for _, id := range outputDescriptorIDs {
+		found := false
+		for _, od := range gotManifest.Manifest.OutputDescriptors {
+			found = found || od.ID == id
+		}
+		if !found {
+			return nil, sdkutil.LoggingNewErrorf("manifest<%s> has no output descriptor with id: %s", request.ManifestID, id)
+		}
+		credentialIDs = append(credentialIDs, CredentialID(request.ManifestID, id))
+	}
+
+	code, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating pre-authorized code")
+	}
+	var userPin, userPinHash string
+	if request.UserPinRequired {
+		n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "generating user pin")
+		}
+		userPin = fmt.Sprintf("%0*d", userPinDigits, n.Int64())
+		userPinHash = hashSecret(userPin)
+	}
+	now := s.Clock.Now().UTC()
+	offer := Offer{
+		ID:                  uuid.NewString(),
+		ManifestID:          request.ManifestID,
+		OutputDescriptorIDs: outputDescriptorIDs,
+		Data:                request.Data,
+		UserPinRequired:     request.UserPinRequired,
+		CreatedAt:           now,
+		ExpiresAt:           now.Add(s.config.OfferTTL),
+	}
+	stored := StoredOffer{Offer: offer, Tenant: storage.TenantFromContext(ctx), UserPinHash: userPinHash}
+	if err = s.storage.StoreOffer(ctx, stored, code); err != nil {
+		return nil, err
+	}
+
+	credentialOffer := CredentialOffer{
+		CredentialIssuer: s.config.CredentialIssuer,
+		Credentials:      credentialIDs,
+		Grants: map[string]PreAuthorizedCodeGrant{
+			PreAuthorizedCodeGrantType: {PreAuthorizedCode: code, UserPinRequired: request.UserPinRequired},
+		},
+	}
+	offerBytes, err := json.Marshal(credentialOffer)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "marshalling credential offer")
+	}
+	return &CreateOfferResponse{
+		Offer:              offer,
+		CredentialOffer:    credentialOffer,
+		CredentialOfferURI: offerURIScheme + "?credential_offer=" + url.QueryEscape(string(offerBytes)),
+		UserPin:            userPin,
+	}, nil
+}
+
+// GetOffer returns an offer created by the tenant of ctx.
+func (s Service) GetOffer(ctx context.Context, request GetOfferRequest) (*Offer, error) {
+	stored, err := s.storage.GetOffer(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Tenant != storage.TenantFromContext(ctx) {
+		return nil, sdkutil.LoggingNewErrorf("offer not found with id: %s", request.ID)
+	}
+	return &stored.Offer, nil
+}
+
+// Token redeems the pre-authorized code of an offer, once, for an access token and the nonce to sign the first proof of
+// possession over. Requests wallets got wrong return an *Error.
+func (s Service) Token(ctx context.Context, request TokenRequest) (*TokenResponse, error) {
+	if request.GrantType != PreAuthorizedCodeGrantType {
+		return nil, newError(ErrorUnsupportedGrantType, "grant type<%s> is not supported", request.GrantType)
+	}
+	if request.PreAuthorizedCode == "" {
+		return nil, newError(ErrorInvalidRequest, "pre-authorized_code is required")
+	}
+	offerID, err := s.storage.GetOfferIDByCode(ctx, request.PreAuthorizedCode)
+	if err != nil {
+		return nil, err
+	}
+	if offerID == "" {
+		return nil, newError(ErrorInvalidGrant, "pre-authorized code is unknown")
+	}
+
+	// wrong pins are counted, so the offer is written back with the error
+	now := s.Clock.Now().UTC()
+	var grantErr error
+	_, err = s.storage.UpdateOffer(ctx, offerID, func(offer *StoredOffer) error {
+		switch {
+		case offer.RedeemedAt != nil:
+			return newError(ErrorInvalidGrant, "pre-authorized code was already redeemed")
+		case !now.Before(offer.ExpiresAt):
+			return newError(ErrorInvalidGrant, "pre-authorized code expired")
+		case offer.UserPinRequired && offer.FailedPinAttempts >= maxPinAttempts:
+			return newError(ErrorInvalidGrant, "user pin was wrong too many times")
+		case offer.UserPinRequired && subtle.ConstantTimeCompare([]byte(hashSecret(request.UserPin)), []byte(offer.UserPinHash)) != 1:
+			offer.FailedPinAttempts++
+			grantErr = newError(ErrorInvalidGrant, "user pin is wrong")
+			return nil
+		}
+		offer.RedeemedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if grantErr != nil {
+		return nil, grantErr
+	}
+
+	accessToken, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating access token")
+	}
+	nonce, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating nonce")
+	}
+	token := StoredToken{
+		OfferID:         offerID,
+		ExpiresAt:       now.Add(s.config.AccessTokenTTL),
+		CNonce:          nonce,
+		CNonceExpiresAt: now.Add(s.config.NonceTTL),
+	}
+	if err = s.storage.StoreToken(ctx, accessToken, token); err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:     accessToken,
+		TokenType:       "bearer",
+		ExpiresIn:       int(s.config.AccessTokenTTL.Seconds()),
+		CNonce:          nonce,
+		CNonceExpiresIn: int(s.config.NonceTTL.Seconds()),
+	}, nil
+}
+
+// IssueCredential issues an offered credential to the DID the wallet proves possession of, with a JWT signed over the
+// access token's current nonce. Each credential of the offer is issued once, and each nonce is used once; responses,
+// and errors of proofs, carry the nonce to sign the next proof over. Requests wallets got wrong return an *Error.
+func (s Service) IssueCredential(ctx context.Context, request CredentialRequest) (*CredentialResponse, error) {
+	now := s.Clock.Now().UTC()
+	token, err := s.storage.GetToken(ctx, request.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || !now.Before(token.ExpiresAt) {
+		return nil, newError(ErrorInvalidToken, "access token is unknown or expired")
+	}
+	if request.Format != FormatJWTVCJSON {
+		return nil, newError(ErrorUnsupportedCredentialFormat, "format<%s> is not supported, only %s is", request.Format, FormatJWTVCJSON)
+	}
+	offer, err := s.storage.GetOffer(ctx, token.OfferID)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil {
+		return nil, errors.Errorf("offer not found: %s", token.OfferID)
+	}
+
+	holderDID, proofNonce, proofErr := s.verifyProof(ctx, request)
+	nonce, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating nonce")
+	}
+
+	// the nonce is replaced whether the proof holds or not, so the token is written back with the error
+	var requestErr *Error
+	var outputDescriptorID string
+	_, err = s.storage.UpdateToken(ctx, request.AccessToken, func(token *StoredToken) error {
+		if !now.Before(token.ExpiresAt) {
+			return newError(ErrorInvalidToken, "access token is unknown or expired")
+		}
+		nonceHeld := proofNonce == token.CNonce && now.Before(token.CNonceExpiresAt)
+		token.CNonce = nonce
+		token.CNonceExpiresAt = now.Add(s.config.NonceTTL)
+		switch {
+		case proofErr != nil:
+			requestErr = newError(ErrorInvalidOrMissingProof, "%s", proofErr.Error())
+			return nil
+		case !nonceHeld:
+			requestErr = newError(ErrorInvalidOrMissingProof, "proof is not signed over the current nonce")
+			return nil
+		}
+		if outputDescriptorID, requestErr = nextCredential(offer.Offer, token.Issued, request.CredentialIdentifier); requestErr != nil {
+			return nil
+		}
+		token.Issued = append(token.Issued, CredentialID(offer.ManifestID, outputDescriptorID))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if requestErr != nil {
+		requestErr.CNonce = nonce
+		requestErr.CNonceExpiresIn = int(s.config.NonceTTL.Seconds())
+		return nil, requestErr
+	}
+
+	// the credential is issued in the tenant that offered it
+	issueCtx := ctx
+	if offer.Tenant != "" {
+		issueCtx = storage.WithTenant(ctx, offer.Tenant)
+	}
+	container, err := s.manifest.IssueOutputCredential(issueCtx, manifestmodel.IssueOutputCredentialRequest{
+		ManifestID:         offer.ManifestID,
+		OutputDescriptorID: outputDescriptorID,
+		Subject:            holderDID,
+		Data:               offer.Data,
+	})
+	if err == nil && !container.HasJWTCredential() {
+		err = errors.New("credential was not issued as a jwt")
+	}
+	if err != nil {
+		// the credential can be requested again
+		credentialID := CredentialID(offer.ManifestID, outputDescriptorID)
+		if _, releaseErr := s.storage.UpdateToken(ctx, request.AccessToken, func(token *StoredToken) error {
+			token.Issued = removeString(token.Issued, credentialID)
+			return nil
+		}); releaseErr != nil {
+			logrus.WithError(releaseErr).Warnf("releasing credential<%s> of offer<%s>", credentialID, offer.ID)
+		}
+		return nil, errors.Wrap(err, "issuing credential")
+	}
+	return &CredentialResponse{
+		Format:          FormatJWTVCJSON,
+		Credential:      container.CredentialJWT.String(),
+		CNonce:          nonce,
+		CNonceExpiresIn: int(s.config.NonceTTL.Seconds()),
+	}, nil
+}
+
+// verifyProof verifies the proof of possession of a credential request, which is a JWT signed by a key of the holder's
+// DID, for the credential issuer, returning the holder's DID and the nonce the proof is signed over.
+func (s Service) verifyProof(ctx context.Context, request CredentialRequest) (holderDID, nonce string, err error) {
+	if request.ProofType != ProofType || request.ProofJWT == "" {
+		return "", "", errors.Errorf("a proof of type %s is required", ProofType)
+	}
+	signature, parsed, err := util.ParseJWT(request.ProofJWT)
+	if err != nil {
+		return "", "", errors.Wrap(err, "parsing proof")
+	}
+	headers := signature.ProtectedHeaders()
+	if headers.Type() != ProofJWTType {
+		return "", "", errors.Errorf("proof must be of typ %s", ProofJWTType)
+	}
+	kid := headers.KeyID()
+	holderDID, _, found := strings.Cut(kid, "#")
+	if !found || !strings.HasPrefix(holderDID, "did:") {
+		return "", "", errors.Errorf("kid<%s> of proof must be a DID URL", kid)
+	}
+	audienced := false
+	for _, aud := range parsed.Audience() {
+		audienced = audienced || aud == s.config.CredentialIssuer
+	}
+	if !audienced {
+		return "", "", errors.Errorf("proof must be for audience %s", s.config.CredentialIssuer)
+	}
+	if parsed.IssuedAt().IsZero() {
+		return "", "", errors.New("proof must have an iat")
+	}
+	if value, ok := parsed.Get("nonce"); ok {
+		nonce, _ = value.(string)
+	}
+	if err = didint.VerifyTokenFromDID(ctx, s.didResolver, holderDID, kid, request.ProofJWT); err != nil {
+		return "", "", errors.Wrap(err, "verifying proof")
+	}
+	return holderDID, nonce, nil
+}
+
+// nextCredential returns the output descriptor of the offered credential to issue, which is the one identified, or the
+// first one that wasn't issued.
+func nextCredential(offer Offer, issued []string, credentialIdentifier string) (string, *Error) {
+	for _, id := range offer.OutputDescriptorIDs {
+		credentialID := CredentialID(offer.ManifestID, id)
+		if credentialIdentifier != "" && credentialIdentifier != credentialID {
+			continue
+		}
+		if containsString(issued, credentialID) {
+			if credentialIdentifier != "" {
+				return "", newError(ErrorInvalidRequest, "credential<%s> was already issued", credentialID)
+			}
+			continue
+		}
+		return id, nil
+	}
+	if credentialIdentifier != "" {
+		return "", newError(ErrorUnsupportedCredentialType, "credential<%s> was not offered", credentialIdentifier)
+	}
+	return "", newError(ErrorInvalidRequest, "every offered credential was already issued")
+}
+
+// Metadata returns the metadata of the credential issuer, which supports the credentials of the manifests of the
+// default tenant.
+func (s Service) Metadata(ctx context.Context) (*issuance.IssuerMetadata, error) {
+	issuer, err := url.Parse(s.config.CredentialIssuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing credential issuer")
+	}
+	endpoint, err := url.Parse(s.config.CredentialIssuer + CredentialPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing credential endpoint")
+	}
+	manifests, err := s.manifest.ListManifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := issuance.IssuerMetadata{
+		CredentialIssuer:     sdkutil.URL{URL: *issuer},
+		CredentialEndpoint:   sdkutil.URL{URL: *endpoint},
+		CredentialsSupported: make(map[string]issuance.CredentialSupported),
+	}
+	for _, m := range manifests.Manifests {
+		for _, od := range m.Manifest.OutputDescriptors {
+			id := CredentialID(m.Manifest.ID, od.ID)
+			supported := issuance.CredentialSupported{
+				Format:                               issuance.JWTVCJSON,
+				ID:                                   &id,
+				CryptographicBindingMethodsSupported: []issuance.CryptographicBindingMethodSupported{issuance.AllDIDMethods},
+				JWTVCJSONCredentialMetadata:          &issuance.JWTVCJSONCredentialMetadata{Types: []string{"VerifiableCredential"}},
+			}
+			if od.Name != "" {
+				name := od.Name
+				display := issuance.CredentialDisplay{Display: issuance.Display{Name: &name}}
+				if od.Description != "" {
+					description := od.Description
+					display.Description = &description
+				}
+				supported.Display = []issuance.CredentialDisplay{display}
+			}
+			metadata.CredentialsSupported[id] = supported
+		}
+	}
+	return &metadata, nil
+}
+
+// AuthorizationServerMetadata returns the metadata of the issuer as the authorization server its pre-authorized codes
+// are redeemed with.
+func (s Service) AuthorizationServerMetadata() AuthorizationServerMetadata {
+	return AuthorizationServerMetadata{
+		Issuer:              s.config.CredentialIssuer,
+		TokenEndpoint:       s.config.CredentialIssuer + TokenPath,
+		GrantTypesSupported: []string{PreAuthorizedCodeGrantType},
+		PreAuthorizedGrantAnonymousAccessSupported: true,
+	}
+}
+
+// Purge deletes the expired access tokens, and the codes of expired offers, returning how many were deleted.
+func (s Service) Purge(ctx context.Context) (int, error) {
+	return s.storage.DeleteExpired(ctx, s.Clock.Now())
+}
+
+// RunSchedule purges every purge interval, until ctx is done.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, PurgeInterval, func() {
+		if purged, err := s.Purge(context.Background()); err != nil {
+			logrus.WithError(err).Error("purging expired oidc4vci codes and tokens")
+		} else if purged > 0 {
+			logrus.Infof("purged %d expired oidc4vci codes and tokens", purged)
+		}
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
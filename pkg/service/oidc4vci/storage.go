@@ -0,0 +1,230 @@
+package oidc4vci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Offers and tokens are kept in the storage of the deployment, since wallets redeem them without a tenant. Each offer
+// records the tenant it was created by, whose manifests and credentials it's redeemed against. Codes and tokens are
+// keyed by their hash, so that they can't be read back from storage.
+const (
+	offerNamespace = "oidc4vci-offer"
+	codeNamespace  = "oidc4vci-code"
+	tokenNamespace = "oidc4vci-token"
+)
+
+type StoredOffer struct {
+	Offer
+	Tenant            string `json:"tenant,omitempty"`
+	UserPinHash       string `json:"userPinHash,omitempty"`
+	FailedPinAttempts int    `json:"failedPinAttempts,omitempty"`
+}
+
+// StoredToken is an access token, which issues each of the credentials of its offer once.
+type StoredToken struct {
+	OfferID         string    `json:"offerId"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	CNonce          string    `json:"cNonce"`
+	CNonceExpiresAt time.Time `json:"cNonceExpiresAt"`
+
+	// IDs of the offered credentials that were issued.
+	Issued []string `json:"issued,omitempty"`
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewOIDC4VCIStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// StoreOffer stores an offer, along with its pre-authorized code.
+func (s *Storage) StoreOffer(ctx context.Context, offer StoredOffer, code string) error {
+	offerBytes, err := json.Marshal(offer)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not marshal offer")
+	}
+	err = s.db.WriteMany(ctx,
+		[]string{offerNamespace, codeNamespace},
+		[]string{offer.ID, hashSecret(code)},
+		[][]byte{offerBytes, []byte(offer.ID)})
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not store offer")
+	}
+	return nil
+}
+
+func (s *Storage) GetOffer(ctx context.Context, id string) (*StoredOffer, error) {
+	offerBytes, err := s.db.Read(ctx, offerNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get offer: %s", id)
+	}
+	if len(offerBytes) == 0 {
+		return nil, nil
+	}
+	var offer StoredOffer
+	if err = json.Unmarshal(offerBytes, &offer); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not unmarshal offer: %s", id)
+	}
+	return &offer, nil
+}
+
+// GetOfferIDByCode returns the ID of the offer with the pre-authorized code, or empty when there's none.
+func (s *Storage) GetOfferIDByCode(ctx context.Context, code string) (string, error) {
+	idBytes, err := s.db.Read(ctx, codeNamespace, hashSecret(code))
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsg(err, "could not get offer by code")
+	}
+	return string(idBytes), nil
+}
+
+// UpdateOffer reads the offer with id and writes it back as update changes it, in a transaction that watches it, so
+// that an offer redeemed by two requests at once is only redeemed by one of them. update returns an error to leave the
+// offer unchanged, which is returned as is.
+func (s *Storage) UpdateOffer(ctx context.Context, id string, update func(offer *StoredOffer) error) (*StoredOffer, error) {
+	watchKeys := []storage.WatchKey{{Namespace: offerNamespace, Key: id}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		offer, err := s.GetOffer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if offer == nil {
+			return nil, errors.Errorf("offer not found: %s", id)
+		}
+		if err = update(offer); err != nil {
+			return nil, err
+		}
+		offerBytes, err := json.Marshal(offer)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling offer")
+		}
+		return offer, tx.Write(ctx, offerNamespace, id, offerBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(*StoredOffer), nil
+}
+
+func (s *Storage) StoreToken(ctx context.Context, accessToken string, token StoredToken) error {
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not marshal token")
+	}
+	if err = s.db.Write(ctx, tokenNamespace, hashSecret(accessToken), tokenBytes); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not store token")
+	}
+	return nil
+}
+
+// GetToken returns the access token, or nil when there's no such token.
+func (s *Storage) GetToken(ctx context.Context, accessToken string) (*StoredToken, error) {
+	tokenBytes, err := s.db.Read(ctx, tokenNamespace, hashSecret(accessToken))
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get token")
+	}
+	if len(tokenBytes) == 0 {
+		return nil, nil
+	}
+	var token StoredToken
+	if err = json.Unmarshal(tokenBytes, &token); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not unmarshal token")
+	}
+	return &token, nil
+}
+
+// UpdateToken reads the access token and writes it back as update changes it, in a transaction that watches it, so
+// that a nonce is only used once. It returns nil when there's no such token. update returns an error to leave the token
+// unchanged, which is returned as is.
+func (s *Storage) UpdateToken(ctx context.Context, accessToken string, update func(token *StoredToken) error) (*StoredToken, error) {
+	key := hashSecret(accessToken)
+	watchKeys := []storage.WatchKey{{Namespace: tokenNamespace, Key: key}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		tokenBytes, err := s.db.Read(ctx, tokenNamespace, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokenBytes) == 0 {
+			return (*StoredToken)(nil), nil
+		}
+		var token StoredToken
+		if err = json.Unmarshal(tokenBytes, &token); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling token")
+		}
+		if err = update(&token); err != nil {
+			return nil, err
+		}
+		if tokenBytes, err = json.Marshal(token); err != nil {
+			return nil, errors.Wrap(err, "marshalling token")
+		}
+		return &token, tx.Write(ctx, tokenNamespace, key, tokenBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(*StoredToken), nil
+}
+
+// DeleteExpired deletes the tokens that expired at now, and the codes of offers that expired, returning how many were
+// deleted. Offers are kept, as a record of what was offered.
+func (s *Storage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	var expiredTokens []string
+	err := s.db.Iterate(ctx, tokenNamespace, func(key string, tokenBytes []byte) (bool, error) {
+		var token StoredToken
+		if err := json.Unmarshal(tokenBytes, &token); err != nil {
+			logrus.WithError(err).Warnf("unmarshal token: %s", key)
+			return true, nil
+		}
+		if !now.Before(token.ExpiresAt) {
+			expiredTokens = append(expiredTokens, key)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list tokens")
+	}
+	codes, err := s.db.ReadAll(ctx, codeNamespace)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list codes")
+	}
+	var expiredCodes []string
+	for key, idBytes := range codes {
+		offer, err := s.GetOffer(ctx, string(idBytes))
+		if err != nil {
+			return 0, err
+		}
+		if offer == nil || !now.Before(offer.ExpiresAt) {
+			expiredCodes = append(expiredCodes, key)
+		}
+	}
+	for _, key := range expiredTokens {
+		if err = s.db.Delete(ctx, tokenNamespace, key); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not delete expired token: %s", key)
+		}
+	}
+	for _, key := range expiredCodes {
+		if err = s.db.Delete(ctx, codeNamespace, key); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not delete expired code: %s", key)
+		}
+	}
+	return len(expiredTokens) + len(expiredCodes), nil
+}
@@ -0,0 +1,130 @@
+package oidc4vp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+)
+
+const (
+	// ResponseTypeVPToken asks wallets for a presentation, and ResponseTypeVPAndIDToken for a Self-Issued OpenID
+	// Provider v2 ID token along with it.
+	ResponseTypeVPToken      = "vp_token"
+	ResponseTypeVPAndIDToken = "vp_token id_token"
+
+	// ResponseModeDirectPost is the only response mode, in which wallets post their responses to the response URI.
+	ResponseModeDirectPost = "direct_post"
+	// ClientIDSchemeRedirectURI identifies the service to wallets by its response URI.
+	ClientIDSchemeRedirectURI = "redirect_uri"
+
+	// FormatJWTVPJSON and FormatJWTVCJSON are the formats presentations, and their credentials, are accepted in.
+	FormatJWTVPJSON = "jwt_vp_json"
+	FormatJWTVCJSON = "jwt_vc_json"
+)
+
+// Error codes of the response endpoint.
+const (
+	ErrorInvalidRequest = "invalid_request"
+	ErrorAccessDenied   = "access_denied"
+)
+
+// Error is an error of the response endpoint, which wallets are answered with as OAuth 2.0 errors.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+// Status of an authorization request.
+type Status string
+
+const (
+	// StatusPending requests wait for the wallet's response.
+	StatusPending Status = "pending"
+	// StatusSubmitted requests were responded to, with a presentation that's submitted for review.
+	StatusSubmitted Status = "submitted"
+	// StatusExpired requests weren't responded to in time.
+	StatusExpired Status = "expired"
+)
+
+type CreateAuthorizationRequestRequest struct {
+	// ID of the presentation definition the wallet is asked to present credentials for.
+	PresentationDefinitionID string `json:"presentationDefinitionId" validate:"required"`
+
+	// Whether the wallet is asked for a Self-Issued OpenID Provider v2 ID token, which authenticates the holder.
+	IDTokenRequired bool `json:"idTokenRequired,omitempty"`
+}
+
+// AuthorizationRequest is the authorization request wallets are given, as OpenID for Verifiable Presentations defines
+// it.
+type AuthorizationRequest struct {
+	ResponseType           string                          `json:"response_type"`
+	ClientID               string                          `json:"client_id"`
+	ClientIDScheme         string                          `json:"client_id_scheme"`
+	ResponseMode           string                          `json:"response_mode"`
+	ResponseURI            string                          `json:"response_uri"`
+	Scope                  string                          `json:"scope,omitempty"`
+	Nonce                  string                          `json:"nonce"`
+	State                  string                          `json:"state"`
+	PresentationDefinition exchange.PresentationDefinition `json:"presentation_definition"`
+	ClientMetadata         ClientMetadata                  `json:"client_metadata"`
+}
+
+// ClientMetadata tells wallets the formats presentations are accepted in.
+type ClientMetadata struct {
+	VPFormats map[string]map[string][]string `json:"vp_formats"`
+}
+
+type CreateAuthorizationRequestResponse struct {
+	Request Request `json:"request"`
+
+	// The request to give the wallet.
+	AuthorizationRequest AuthorizationRequest `json:"authorizationRequest"`
+
+	// The request as an openid4vp URI, e.g. for a QR code.
+	AuthorizationRequestURI string `json:"authorizationRequestUri"`
+}
+
+// Request is what's stored about an authorization request.
+type Request struct {
+	ID                       string    `json:"id"`
+	PresentationDefinitionID string    `json:"presentationDefinitionId"`
+	IDTokenRequired          bool      `json:"idTokenRequired,omitempty"`
+	Status                   Status    `json:"status"`
+	CreatedAt                time.Time `json:"createdAt"`
+
+	// When the wallet can no longer respond.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// When the wallet responded, which is only once.
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+
+	// DID of the holder that responded.
+	Holder string `json:"holder,omitempty"`
+
+	// ID of the presentation submission the response was submitted as, which is reviewed like any other submission.
+	SubmissionID string `json:"submissionId,omitempty"`
+}
+
+type GetRequestRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// ResponseRequest is the response of a wallet to an authorization request, posted to the response URI.
+type ResponseRequest struct {
+	VPToken string
+
+	// The presentation submission of the VP token, when the presentation doesn't embed it.
+	PresentationSubmission string
+
+	State   string
+	IDToken string
+}
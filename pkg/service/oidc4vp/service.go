@@ -0,0 +1,407 @@
+package oidc4vp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/did/resolution"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	didint "github.com/tbd54566975/ssi-service/internal/did"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	presmodel "github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultRequestTTL is how long wallets can respond to authorization requests when no TTL is configured.
+	DefaultRequestTTL = 10 * time.Minute
+	// PurgeInterval is how often the states of requests that can no longer be responded to are deleted.
+	PurgeInterval = time.Hour
+
+	// ResponsePath is the path of the response URI, under the base URL.
+	ResponsePath = "/oidc4vp/response"
+
+	// requestURIScheme is the scheme of the URIs that pass authorization requests to wallets.
+	requestURIScheme = "openid4vp://"
+)
+
+// algorithms are the algorithms presentations, and their credentials, can be signed with.
+var algorithms = []string{string(crypto.EdDSA), string(crypto.ES256K), string(crypto.ES256), string(crypto.ES384), string(crypto.PS256)}
+
+// Service asks wallets for presentations of presentation definitions with OpenID for Verifiable Presentations, and,
+// when the holder should be authenticated too, for Self-Issued OpenID Provider v2 ID tokens. Wallets post their
+// responses to the service, which submits the presentations to the presentation service, where they're verified, and
+// reviewed, like any other submission.
+type Service struct {
+	storage      *Storage
+	config       config.OIDC4VPServiceConfig
+	presentation *presentation.Service
+	didResolver  resolution.Resolver
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.OIDC4VP
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.presentation == nil {
+		ae.AppendString("no presentation service configured")
+	}
+	if s.didResolver == nil {
+		ae.AppendString("no did resolver configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("oidc4vp service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewOIDC4VPService creates the service, which keeps authorization requests in globalStorage, and submits the
+// presentations wallets respond with to presentationService.
+func NewOIDC4VPService(cfg config.OIDC4VPServiceConfig, globalStorage storage.ServiceStorage, presentationService *presentation.Service, didResolver resolution.Resolver) (*Service, error) {
+	oidcStorage, err := NewOIDC4VPStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the oidc4vp service")
+	}
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, sdkutil.LoggingNewErrorf("base_url<%s> must be an absolute url", cfg.BaseURL)
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.RequestTTL < 0 {
+		return nil, sdkutil.LoggingNewError("request_ttl cannot be negative")
+	}
+	if cfg.RequestTTL == 0 {
+		cfg.RequestTTL = DefaultRequestTTL
+	}
+	service := Service{
+		storage:      oidcStorage,
+		config:       cfg,
+		presentation: presentationService,
+		didResolver:  didResolver,
+		Clock:        clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// ClientID is what the service is known by to wallets, which is its response URI.
+func (s Service) ClientID() string {
+	return s.config.BaseURL + ResponsePath
+}
+
+// CreateAuthorizationRequest creates a request for a presentation of a presentation definition, and returns it along
+// with the request to give the wallet.
+func (s Service) CreateAuthorizationRequest(ctx context.Context, request CreateAuthorizationRequestRequest) (*CreateAuthorizationRequestResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create authorization request request")
+	}
+	definition, err := s.presentation.GetPresentationDefinition(ctx, presmodel.GetPresentationDefinitionRequest{ID: request.PresentationDefinitionID})
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating nonce")
+	}
+	state, err := util.RandomSecret()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "generating state")
+	}
+	now := s.Clock.Now().UTC()
+	stored := StoredRequest{
+		Request: Request{
+			ID:                       uuid.NewString(),
+			PresentationDefinitionID: request.PresentationDefinitionID,
+			IDTokenRequired:          request.IDTokenRequired,
+			Status:                   StatusPending,
+			CreatedAt:                now,
+			ExpiresAt:                now.Add(s.config.RequestTTL),
+		},
+		Tenant: storage.TenantFromContext(ctx),
+		Nonce:  nonce,
+	}
+	if err = s.storage.StoreRequest(ctx, stored, state); err != nil {
+		return nil, err
+	}
+
+	authorizationRequest := AuthorizationRequest{
+		ResponseType:           ResponseTypeVPToken,
+		ClientID:               s.ClientID(),
+		ClientIDScheme:         ClientIDSchemeRedirectURI,
+		ResponseMode:           ResponseModeDirectPost,
+		ResponseURI:            s.ClientID(),
+		Nonce:                  nonce,
+		State:                  state,
+		PresentationDefinition: definition.PresentationDefinition,
+		ClientMetadata: ClientMetadata{VPFormats: map[string]map[string][]string{
+			FormatJWTVPJSON: {"alg": algorithms},
+			FormatJWTVCJSON: {"alg": algorithms},
+		}},
+	}
+	if request.IDTokenRequired {
+		authorizationRequest.ResponseType = ResponseTypeVPAndIDToken
+		authorizationRequest.Scope = "openid"
+	}
+	requestURI, err := toURI(authorizationRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateAuthorizationRequestResponse{
+		Request:                 stored.Request,
+		AuthorizationRequest:    authorizationRequest,
+		AuthorizationRequestURI: requestURI,
+	}, nil
+}
+
+// toURI encodes an authorization request as the query of an openid4vp URI, with its objects as JSON.
+func toURI(request AuthorizationRequest) (string, error) {
+	definitionBytes, err := json.Marshal(request.PresentationDefinition)
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsg(err, "marshalling presentation definition")
+	}
+	metadataBytes, err := json.Marshal(request.ClientMetadata)
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsg(err, "marshalling client metadata")
+	}
+	query := url.Values{
+		"response_type":           {request.ResponseType},
+		"client_id":               {request.ClientID},
+		"client_id_scheme":        {request.ClientIDScheme},
+		"response_mode":           {request.ResponseMode},
+		"response_uri":            {request.ResponseURI},
+		"nonce":                   {request.Nonce},
+		"state":                   {request.State},
+		"presentation_definition": {string(definitionBytes)},
+		"client_metadata":         {string(metadataBytes)},
+	}
+	if request.Scope != "" {
+		query.Set("scope", request.Scope)
+	}
+	return requestURIScheme + "?" + query.Encode(), nil
+}
+
+// GetRequest returns an authorization request created by the tenant of ctx.
+func (s Service) GetRequest(ctx context.Context, request GetRequestRequest) (*Request, error) {
+	stored, err := s.storage.GetRequest(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Tenant != storage.TenantFromContext(ctx) {
+		return nil, sdkutil.LoggingNewErrorf("authorization request not found with id: %s", request.ID)
+	}
+	if stored.Status == StatusPending && stored.RespondedAt == nil && !s.Clock.Now().Before(stored.ExpiresAt) {
+		stored.Status = StatusExpired
+	}
+	return &stored.Request, nil
+}
+
+// Respond verifies the response of a wallet to an authorization request, and submits its presentation, which is then
+// reviewed like any other submission. Each request is responded to once. Responses wallets got wrong return an *Error.
+func (s Service) Respond(ctx context.Context, response ResponseRequest) (*Request, error) {
+	if response.State == "" || response.VPToken == "" {
+		return nil, newError(ErrorInvalidRequest, "state and vp_token are required")
+	}
+	requestID, err := s.storage.GetRequestIDByState(ctx, response.State)
+	if err != nil {
+		return nil, err
+	}
+	if requestID == "" {
+		return nil, newError(ErrorInvalidRequest, "state is unknown")
+	}
+	stored, err := s.storage.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, errors.Errorf("request not found: %s", requestID)
+	}
+	now := s.Clock.Now().UTC()
+	if err = respondable(stored, now); err != nil {
+		return nil, err
+	}
+
+	submissionRequest, respErr := s.readResponse(ctx, stored, response)
+	if respErr != nil {
+		return nil, respErr
+	}
+	holder := submissionRequest.Presentation.Holder
+
+	// the request is claimed before the presentation is submitted, so that it's only submitted once
+	_, err = s.storage.UpdateRequest(ctx, requestID, func(request *StoredRequest) error {
+		if err := respondable(request, now); err != nil {
+			return err
+		}
+		request.RespondedAt = &now
+		request.Holder = holder
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the presentation is submitted to the tenant that asked for it
+	submitCtx := ctx
+	if stored.Tenant != "" {
+		submitCtx = storage.WithTenant(ctx, stored.Tenant)
+	}
+	if _, err = s.presentation.CreateSubmission(submitCtx, *submissionRequest); err != nil {
+		logrus.WithError(err).Warnf("submitting the presentation responded to request<%s>", requestID)
+		// the wallet can respond again
+		if _, releaseErr := s.storage.UpdateRequest(ctx, requestID, func(request *StoredRequest) error {
+			request.RespondedAt = nil
+			request.Holder = ""
+			return nil
+		}); releaseErr != nil {
+			logrus.WithError(releaseErr).Warnf("releasing request<%s>", requestID)
+		}
+		return nil, newError(ErrorAccessDenied, "presentation could not be verified")
+	}
+	updated, err := s.storage.UpdateRequest(ctx, requestID, func(request *StoredRequest) error {
+		request.Status = StatusSubmitted
+		request.SubmissionID = submissionRequest.Submission.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated.Request, nil
+}
+
+// respondable returns an *Error when the request can no longer be responded to.
+func respondable(request *StoredRequest, now time.Time) error {
+	switch {
+	case request.RespondedAt != nil:
+		return newError(ErrorInvalidRequest, "request was already responded to")
+	case !now.Before(request.ExpiresAt):
+		return newError(ErrorInvalidRequest, "request expired")
+	}
+	return nil
+}
+
+// readResponse reads the submission of the VP token of a response, which must be for the request's presentation
+// definition, for the service, and over the request's nonce, and checks the ID token of the holder, when the request
+// asks for one. The signatures of the presentation and its credentials are verified when it's submitted.
+func (s Service) readResponse(ctx context.Context, request *StoredRequest, response ResponseRequest) (*presmodel.CreateSubmissionRequest, *Error) {
+	var submission *exchange.PresentationSubmission
+	if response.PresentationSubmission != "" {
+		submission = new(exchange.PresentationSubmission)
+		if err := json.Unmarshal([]byte(response.PresentationSubmission), submission); err != nil {
+			return nil, newError(ErrorInvalidRequest, "presentation_submission is not a presentation submission")
+		}
+	}
+	submissionRequest, err := presmodel.NewCreateSubmissionRequest(keyaccess.JWT(response.VPToken), submission)
+	if err != nil {
+		return nil, newError(ErrorInvalidRequest, "vp_token is not a presentation: %s", err.Error())
+	}
+	if submissionRequest.Submission.DefinitionID != request.PresentationDefinitionID {
+		return nil, newError(ErrorInvalidRequest, "presentation is not for presentation definition %s", request.PresentationDefinitionID)
+	}
+	if err = s.checkAudienceAndNonce(keyaccess.JWT(response.VPToken), request.Nonce); err != nil {
+		return nil, newError(ErrorInvalidRequest, "vp_token %s", err.Error())
+	}
+	if request.IDTokenRequired {
+		subject, err := s.verifyIDToken(ctx, keyaccess.JWT(response.IDToken), request.Nonce)
+		if err != nil {
+			return nil, newError(ErrorInvalidRequest, "id_token %s", err.Error())
+		}
+		if subject != submissionRequest.Presentation.Holder {
+			return nil, newError(ErrorInvalidRequest, "id_token is not of the holder of the presentation")
+		}
+	}
+	return submissionRequest, nil
+}
+
+func (s Service) checkAudienceAndNonce(token keyaccess.JWT, nonce string) error {
+	_, parsed, err := util.ParseJWT(token)
+	if err != nil {
+		return errors.Wrap(err, "is not a jwt")
+	}
+	audienced := false
+	for _, aud := range parsed.Audience() {
+		audienced = audienced || aud == s.ClientID()
+	}
+	if !audienced {
+		return errors.Errorf("must be for audience %s", s.ClientID())
+	}
+	if value, ok := parsed.Get("nonce"); !ok || value != nonce {
+		return errors.New("is not signed over the nonce of the request")
+	}
+	return nil
+}
+
+// verifyIDToken verifies a self-issued ID token, which is signed by a key of the DID that's its subject, and issuer,
+// returning the DID.
+func (s Service) verifyIDToken(ctx context.Context, token keyaccess.JWT, nonce string) (string, error) {
+	if token == "" {
+		return "", errors.New("is required")
+	}
+	if err := s.checkAudienceAndNonce(token, nonce); err != nil {
+		return "", err
+	}
+	signature, parsed, err := util.ParseJWT(token)
+	if err != nil {
+		return "", errors.Wrap(err, "is not a jwt")
+	}
+	subject := parsed.Subject()
+	if !strings.HasPrefix(subject, "did:") || parsed.Issuer() != subject {
+		return "", errors.New("must be self-issued, by the DID that's its subject")
+	}
+	if parsed.Expiration().IsZero() {
+		return "", errors.New("must have an exp")
+	}
+	kid := signature.ProtectedHeaders().KeyID()
+	if strings.HasPrefix(kid, "#") {
+		kid = subject + kid
+	}
+	if !strings.HasPrefix(kid, subject+"#") {
+		return "", errors.Errorf("kid<%s> must be a verification method of its subject", kid)
+	}
+	if err = didint.VerifyTokenFromDID(ctx, s.didResolver, subject, kid, token); err != nil {
+		return "", errors.Wrap(err, "could not be verified")
+	}
+	return subject, nil
+}
+
+// Purge deletes the states of requests that can no longer be responded to, returning how many were deleted.
+func (s Service) Purge(ctx context.Context) (int, error) {
+	return s.storage.DeleteExpired(ctx, s.Clock.Now())
+}
+
+// RunSchedule purges every purge interval, until ctx is done.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, PurgeInterval, func() {
+		if purged, err := s.Purge(context.Background()); err != nil {
+			logrus.WithError(err).Error("purging oidc4vp states")
+		} else if purged > 0 {
+			logrus.Infof("purged %d oidc4vp states", purged)
+		}
+	})
+}
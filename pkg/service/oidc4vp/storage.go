@@ -0,0 +1,137 @@
+package oidc4vp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Authorization requests are kept in the storage of the deployment, since wallets respond to them without a tenant.
+// Each request records the tenant it was created by, whose presentation definitions it asks for, and whose submissions
+// the responses become. States are keyed by their hash, so that they can't be read back from storage.
+const (
+	requestNamespace = "oidc4vp-request"
+	stateNamespace   = "oidc4vp-state"
+)
+
+type StoredRequest struct {
+	Request
+	Tenant string `json:"tenant,omitempty"`
+	Nonce  string `json:"nonce"`
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewOIDC4VPStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// StoreRequest stores an authorization request, along with its state.
+func (s *Storage) StoreRequest(ctx context.Context, request StoredRequest, state string) error {
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not marshal request")
+	}
+	err = s.db.WriteMany(ctx,
+		[]string{requestNamespace, stateNamespace},
+		[]string{request.ID, hashState(state)},
+		[][]byte{requestBytes, []byte(request.ID)})
+	if err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not store request")
+	}
+	return nil
+}
+
+func (s *Storage) GetRequest(ctx context.Context, id string) (*StoredRequest, error) {
+	requestBytes, err := s.db.Read(ctx, requestNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get request: %s", id)
+	}
+	if len(requestBytes) == 0 {
+		return nil, nil
+	}
+	var request StoredRequest
+	if err = json.Unmarshal(requestBytes, &request); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not unmarshal request: %s", id)
+	}
+	return &request, nil
+}
+
+// GetRequestIDByState returns the ID of the request with the state, or empty when there's none.
+func (s *Storage) GetRequestIDByState(ctx context.Context, state string) (string, error) {
+	idBytes, err := s.db.Read(ctx, stateNamespace, hashState(state))
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsg(err, "could not get request by state")
+	}
+	return string(idBytes), nil
+}
+
+// UpdateRequest reads the request with id and writes it back as update changes it, in a transaction that watches it,
+// so that a request responded to by two wallets at once is only responded to by one of them. update returns an error
+// to leave the request unchanged, which is returned as is.
+func (s *Storage) UpdateRequest(ctx context.Context, id string, update func(request *StoredRequest) error) (*StoredRequest, error) {
+	watchKeys := []storage.WatchKey{{Namespace: requestNamespace, Key: id}}
+	updated, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		request, err := s.GetRequest(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if request == nil {
+			return nil, errors.Errorf("request not found: %s", id)
+		}
+		if err = update(request); err != nil {
+			return nil, err
+		}
+		requestBytes, err := json.Marshal(request)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling request")
+		}
+		return request, tx.Write(ctx, requestNamespace, id, requestBytes)
+	}, watchKeys)
+	if err != nil {
+		return nil, err
+	}
+	return updated.(*StoredRequest), nil
+}
+
+// DeleteExpired deletes the states of requests that expired at now, or were responded to, returning how many were
+// deleted. Requests are kept, as a record of what was asked for.
+func (s *Storage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	states, err := s.db.ReadAll(ctx, stateNamespace)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list states")
+	}
+	var expired []string
+	for key, idBytes := range states {
+		request, err := s.GetRequest(ctx, string(idBytes))
+		if err != nil {
+			return 0, err
+		}
+		if request == nil || request.SubmissionID != "" || !now.Before(request.ExpiresAt) {
+			expired = append(expired, key)
+		}
+	}
+	for _, key := range expired {
+		if err = s.db.Delete(ctx, stateNamespace, key); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not delete expired state: %s", key)
+		}
+	}
+	return len(expired), nil
+}
@@ -0,0 +1,259 @@
+package operation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.einride.tech/aip/filtering"
+
+	"github.com/tbd54566975/ssi-service/internal/inflight"
+	"github.com/tbd54566975/ssi-service/internal/requestid"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
+	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// AsyncFunc does the work of an asynchronous operation. The JSON it returns becomes the response of the operation, and
+// an error becomes its error. ctx is cancelled when the operation is cancelled.
+type AsyncFunc func(ctx context.Context) ([]byte, error)
+
+// interruptedReason is the error of asynchronous operations that were still running when the service shut down.
+const interruptedReason = "operation interrupted by a shutdown of the service, retry the request"
+
+// ErrShuttingDown is returned when an asynchronous operation is started after the service started shutting down.
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// runningOperations holds the asynchronous operations running in this process.
+type runningOperations struct {
+	work inflight.Tracker
+
+	mu      sync.Mutex
+	running map[string]runningOperation
+}
+
+type runningOperation struct {
+	// ctx is the detached context the operation runs in, without its cancellation, for recording the operation's
+	// outcome in the right tenant
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newRunningOperations() *runningOperations {
+	return &runningOperations{running: make(map[string]runningOperation)}
+}
+
+func (r *runningOperations) add(id string, op runningOperation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[id] = op
+}
+
+// cancel cancels the operation with the given id, if it's running, and stops tracking it.
+func (r *runningOperations) cancel(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if op, ok := r.running[id]; ok {
+		op.cancel()
+		delete(r.running, id)
+	}
+}
+
+// cancelAll cancels every running operation, and returns them by id.
+func (r *runningOperations) cancelAll() map[string]runningOperation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancelled := r.running
+	for _, op := range cancelled {
+		op.cancel()
+	}
+	r.running = make(map[string]runningOperation)
+	return cancelled
+}
+
+// RunAsync starts fn in the background, and returns the operation tracking it under the given parent resource, which
+// is created with async.Parent. The operation is done once fn returns, or when it's cancelled.
+//
+// fn runs with a context that's detached from ctx, so it keeps running after the request that started it is done, but
+// keeps the tenant and request ID of ctx.
+func (s Service) RunAsync(ctx context.Context, parent string, fn AsyncFunc) (*Operation, error) {
+	if !async.IsAsync(parent) {
+		return nil, sdkutil.LoggingNewErrorf("invalid parent for async operation: %s", parent)
+	}
+	if !s.running.work.Start() {
+		return nil, ErrShuttingDown
+	}
+
+	op := opstorage.StoredOperation{
+		ID:        parent + "/" + uuid.NewString(),
+		CreatedAt: time.Now(),
+	}
+	if err := s.storage.StoreOperation(ctx, op); err != nil {
+		s.running.work.Done()
+		return nil, errors.Wrap(err, "storing operation")
+	}
+
+	// ctx may be reused once the request is done, so anything needed from it is copied before fn starts
+	detached := detach(ctx)
+	runCtx, cancel := context.WithCancel(detached)
+	s.running.add(op.ID, runningOperation{ctx: detached, cancel: cancel})
+	go func() {
+		defer s.running.work.Done()
+		defer s.running.cancel(op.ID)
+
+		response, err := fn(runCtx)
+		if runCtx.Err() != nil {
+			// cancelled, which is recorded by whoever cancelled it
+			return
+		}
+		s.finish(detached, op, response, err)
+	}()
+
+	return ServiceModel(op)
+}
+
+// finish records the outcome of an asynchronous operation, unless it's already done.
+func (s Service) finish(ctx context.Context, op opstorage.StoredOperation, response []byte, fnErr error) {
+	logger := logrus.WithContext(ctx).WithField("operation_id", op.ID)
+	stored, err := s.storage.GetOperation(ctx, op.ID)
+	if err != nil {
+		logger.WithError(err).Error("reading finished async operation")
+		return
+	}
+	if stored.Done {
+		return
+	}
+
+	stored.Done = true
+	expiresAt := time.Now().Add(s.retention)
+	stored.ExpiresAt = &expiresAt
+	if fnErr != nil {
+		stored.Error = fnErr.Error()
+	} else {
+		stored.Response = response
+	}
+	if err = s.storage.StoreOperation(ctx, stored); err != nil {
+		logger.WithError(err).Error("storing finished async operation")
+	}
+}
+
+// cancelAsync stops the asynchronous operation with the given id, and marks it as done with a cancellation error.
+// Operations that are already done are returned as they are.
+func (s Service) cancelAsync(ctx context.Context, id string) (*Operation, error) {
+	s.running.cancel(id)
+	return s.stopAsync(ctx, id, cancelledReason)
+}
+
+// stopAsync marks the asynchronous operation with the given id as done, with reason as its error, unless it's already
+// done. The operation must no longer be running.
+func (s Service) stopAsync(ctx context.Context, id, reason string) (*Operation, error) {
+	stored, err := s.storage.GetOperation(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching from storage")
+	}
+	if stored.Done {
+		return ServiceModel(stored)
+	}
+
+	stored.Done = true
+	stored.Error = reason
+	expiresAt := time.Now().Add(s.retention)
+	stored.ExpiresAt = &expiresAt
+	if err = s.storage.StoreOperation(ctx, stored); err != nil {
+		return nil, errors.Wrap(err, "marking as done")
+	}
+	return ServiceModel(stored)
+}
+
+// Drain stops new asynchronous operations from starting, and waits for the running ones to finish. When ctx is done
+// first, the operations still running are cancelled and recorded as interrupted, so that clients polling them know to
+// retry instead of waiting for a result that won't come.
+func (s Service) Drain(ctx context.Context) error {
+	err := s.running.work.Drain(ctx)
+	if err == nil {
+		return nil
+	}
+	for id, op := range s.running.cancelAll() {
+		logrus.WithContext(op.ctx).WithField("operation_id", id).Warn("interrupting async operation to shut down")
+		if _, stopErr := s.stopAsync(op.ctx, id, interruptedReason); stopErr != nil {
+			logrus.WithContext(op.ctx).WithError(stopErr).WithField("operation_id", id).Error("recording interrupted async operation")
+		}
+	}
+	return errors.Wrap(err, "waiting for async operations")
+}
+
+// DeleteExpiredOperations deletes the asynchronous operations of the tenant of ctx whose retention period has passed,
+// returning how many were deleted.
+func (s Service) DeleteExpiredOperations(ctx context.Context) (int, error) {
+	ops, err := s.storage.ListOperations(ctx, async.ParentResource, filtering.Filter{})
+	if err != nil {
+		return 0, errors.Wrap(err, "listing async operations")
+	}
+	deleted := 0
+	for _, op := range ops {
+		if s.isExpired(op) {
+			if err = s.storage.DeleteOperation(ctx, op.ID); err != nil {
+				return deleted, errors.Wrapf(err, "deleting expired operation: %s", op.ID)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// RunRetention deletes expired asynchronous operations of the default tenant every interval, until ctx is done. A
+// deletion in progress when ctx is done is completed before returning. Operations of other tenants are deleted when
+// they're read after expiring.
+func (s Service) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteExpiredOperations(detach(ctx)); err != nil {
+				logrus.WithError(err).Error("deleting expired operations")
+			}
+		}
+	}
+}
+
+func (s Service) isExpired(op opstorage.StoredOperation) bool {
+	return op.ExpiresAt != nil && time.Now().After(*op.ExpiresAt)
+}
+
+func (s Service) deleteExpired(ctx context.Context, id string) {
+	if err := s.storage.DeleteOperation(ctx, id); err != nil {
+		logrus.WithContext(ctx).WithError(err).WithField("operation_id", id).Warn("deleting expired operation")
+	}
+}
+
+// withoutExpired returns the operations in ops that haven't expired, and deletes the ones that have.
+func (s Service) withoutExpired(ctx context.Context, ops []opstorage.StoredOperation) []opstorage.StoredOperation {
+	current := make([]opstorage.StoredOperation, 0, len(ops))
+	for _, op := range ops {
+		if s.isExpired(op) {
+			s.deleteExpired(ctx, op.ID)
+			continue
+		}
+		current = append(current, op)
+	}
+	return current
+}
+
+// detach returns a context that isn't cancelled along with ctx, but is scoped to the same tenant and request.
+func detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if id := requestid.FromContext(ctx); id != "" {
+		detached = requestid.With(detached, id)
+	}
+	if tenantID := storage.TenantFromContext(ctx); tenantID != "" {
+		detached = storage.WithTenant(detached, tenantID)
+	}
+	return detached
+}
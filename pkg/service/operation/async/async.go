@@ -0,0 +1,25 @@
+package async
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ParentResource is the prefix of the parent resource of asynchronous operations. Each kind of work run
+	// asynchronously has its own parent under it, like "async/credentials/batch".
+	ParentResource = "async"
+
+	// Namespace is where asynchronous operations are stored.
+	Namespace = "operation_async"
+)
+
+// Parent returns the parent resource of asynchronous operations running the given kind of work.
+func Parent(kind string) string {
+	return fmt.Sprintf("%s/%s", ParentResource, kind)
+}
+
+// IsAsync returns whether the operation or parent resource with the given name is asynchronous.
+func IsAsync(name string) bool {
+	return name == ParentResource || strings.HasPrefix(name, ParentResource+"/")
+}
@@ -0,0 +1,208 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestService_RunAsync(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			newService := func(t *testing.T, retention string) *Service {
+				s, err := NewOperationService(config.OperationServiceConfig{RetentionPeriod: retention}, test.ServiceStorage(t))
+				require.NoError(t, err)
+				return s
+			}
+			waitForDone := func(t *testing.T, s *Service, id string) *Operation {
+				var op *Operation
+				require.Eventually(t, func() bool {
+					var err error
+					op, err = s.GetOperation(context.Background(), GetOperationRequest{ID: id})
+					require.NoError(t, err)
+					return op.Done
+				}, 5*time.Second, 10*time.Millisecond)
+				return op
+			}
+
+			t.Run("stores the response", func(tt *testing.T) {
+				s := newService(tt, "")
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+					return []byte(`{"hello":"world"}`), nil
+				})
+				require.NoError(tt, err)
+				assert.Contains(tt, op.ID, "async/tests/")
+				assert.False(tt, op.Done)
+
+				op = waitForDone(tt, s, op.ID)
+				assert.Empty(tt, op.Result.Error)
+				assert.Equal(tt, json.RawMessage(`{"hello":"world"}`), op.Result.Response)
+			})
+
+			t.Run("stores the error", func(tt *testing.T) {
+				s := newService(tt, "")
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+					return nil, errors.New("oops")
+				})
+				require.NoError(tt, err)
+
+				op = waitForDone(tt, s, op.ID)
+				assert.Equal(tt, "oops", op.Result.Error)
+				assert.Nil(tt, op.Result.Response)
+			})
+
+			t.Run("can be cancelled", func(tt *testing.T) {
+				s := newService(tt, "")
+				stopped := make(chan struct{})
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(ctx context.Context) ([]byte, error) {
+					<-ctx.Done()
+					close(stopped)
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+
+				cancelled, err := s.CancelOperation(context.Background(), CancelOperationRequest{ID: op.ID})
+				require.NoError(tt, err)
+				assert.True(tt, cancelled.Done)
+				assert.Equal(tt, cancelledReason, cancelled.Result.Error)
+				<-stopped
+
+				op, err = s.GetOperation(context.Background(), GetOperationRequest{ID: op.ID})
+				require.NoError(tt, err)
+				assert.Equal(tt, cancelledReason, op.Result.Error)
+				assert.Nil(tt, op.Result.Response)
+			})
+
+			t.Run("lists operations by parent", func(tt *testing.T) {
+				s := newService(tt, "")
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+				waitForDone(tt, s, op.ID)
+
+				ops, err := s.ListOperations(context.Background(), ListOperationsRequest{Parent: async.Parent("tests")})
+				require.NoError(tt, err)
+				require.Len(tt, ops.Operations, 1)
+				assert.Equal(tt, op.ID, ops.Operations[0].ID)
+
+				ops, err = s.ListOperations(context.Background(), ListOperationsRequest{Parent: async.Parent("others")})
+				require.NoError(tt, err)
+				assert.Empty(tt, ops.Operations)
+			})
+
+			t.Run("keeps the tenant of the request", func(tt *testing.T) {
+				s := newService(tt, "")
+				ctx := storage.WithTenant(context.Background(), "tenant")
+				tenants := make(chan string, 1)
+				op, err := s.RunAsync(ctx, async.Parent("tests"), func(ctx context.Context) ([]byte, error) {
+					tenants <- storage.TenantFromContext(ctx)
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+				assert.Equal(tt, "tenant", <-tenants)
+
+				require.Eventually(tt, func() bool {
+					op, err = s.GetOperation(ctx, GetOperationRequest{ID: op.ID})
+					require.NoError(tt, err)
+					return op.Done
+				}, 5*time.Second, 10*time.Millisecond)
+			})
+
+			t.Run("drains running operations", func(tt *testing.T) {
+				s := newService(tt, "")
+				finish := make(chan struct{})
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+					<-finish
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+
+				drained := make(chan error)
+				go func() { drained <- s.Drain(context.Background()) }()
+				require.Eventually(tt, func() bool {
+					_, err = s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+						return []byte(`{}`), nil
+					})
+					return errors.Is(err, ErrShuttingDown)
+				}, 5*time.Second, 10*time.Millisecond)
+
+				close(finish)
+				require.NoError(tt, <-drained)
+				op, err = s.GetOperation(context.Background(), GetOperationRequest{ID: op.ID})
+				require.NoError(tt, err)
+				assert.True(tt, op.Done)
+				assert.Empty(tt, op.Result.Error)
+			})
+
+			t.Run("interrupts operations still running when draining times out", func(tt *testing.T) {
+				s := newService(tt, "")
+				ctx := storage.WithTenant(context.Background(), "tenant")
+				stopped := make(chan struct{})
+				op, err := s.RunAsync(ctx, async.Parent("tests"), func(ctx context.Context) ([]byte, error) {
+					<-ctx.Done()
+					close(stopped)
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+
+				drainCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				assert.ErrorIs(tt, s.Drain(drainCtx), context.DeadlineExceeded)
+				<-stopped
+
+				op, err = s.GetOperation(ctx, GetOperationRequest{ID: op.ID})
+				require.NoError(tt, err)
+				assert.True(tt, op.Done)
+				assert.Equal(tt, interruptedReason, op.Result.Error)
+				assert.Nil(tt, op.Result.Response)
+			})
+
+			t.Run("deletes operations after the retention period", func(tt *testing.T) {
+				s := newService(tt, "1ms")
+				done := make(chan struct{})
+				op, err := s.RunAsync(context.Background(), async.Parent("tests"), func(_ context.Context) ([]byte, error) {
+					<-done
+					return []byte(`{}`), nil
+				})
+				require.NoError(tt, err)
+
+				// running operations are kept
+				deleted, err := s.DeleteExpiredOperations(context.Background())
+				require.NoError(tt, err)
+				assert.Zero(tt, deleted)
+				_, err = s.GetOperation(context.Background(), GetOperationRequest{ID: op.ID})
+				require.NoError(tt, err)
+
+				close(done)
+				require.Eventually(tt, func() bool {
+					stored, err := s.storage.GetOperation(context.Background(), op.ID)
+					require.NoError(tt, err)
+					return stored.Done
+				}, 5*time.Second, 10*time.Millisecond)
+				time.Sleep(2 * time.Millisecond)
+
+				deleted, err = s.DeleteExpiredOperations(context.Background())
+				require.NoError(tt, err)
+				assert.Equal(tt, 1, deleted)
+				_, err = s.storage.GetOperation(context.Background(), op.ID)
+				assert.ErrorContains(tt, err, "operation not found")
+			})
+		})
+	}
+}
+
+func TestNewOperationService(t *testing.T) {
+	_, err := NewOperationService(config.OperationServiceConfig{RetentionPeriod: "forever"}, testutil.TestDatabases[0].ServiceStorage(t))
+	assert.ErrorContains(t, err, "parsing operation retention period")
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	manifestmodel "github.com/tbd54566975/ssi-service/pkg/service/manifest/model"
 	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
 	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
@@ -21,8 +24,17 @@ import (
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
+// defaultRetentionPeriod is how long the results of finished asynchronous operations are kept when no retention period
+// is configured.
+const defaultRetentionPeriod = 7 * 24 * time.Hour
+
 type Service struct {
 	storage *Storage
+
+	// retention is how long finished asynchronous operations are kept before they are deleted
+	retention time.Duration
+	// running tracks the asynchronous operations that are running in this process, so they can be cancelled
+	running *runningOperations
 }
 
 func (s Service) Type() framework.Type {
@@ -52,6 +64,7 @@ func (s Service) ListOperations(ctx context.Context, request ListOperationsReque
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching ops from storage")
 	}
+	ops = s.withoutExpired(ctx, ops)
 
 	resp := ListOperationsResponse{Operations: make([]Operation, len(ops))}
 	for i, op := range ops {
@@ -93,6 +106,8 @@ func ServiceModel(op opstorage.StoredOperation) (*Operation, error) {
 				return nil, errors.Wrap(err, "unmarshalling cred response")
 			}
 			newOp.Result.Response = manifestmodel.ServiceModel(&s)
+		case async.IsAsync(op.ID):
+			newOp.Result.Response = json.RawMessage(op.Response)
 		default:
 			return nil, errors.New("unknown response type")
 		}
@@ -110,6 +125,10 @@ func (s Service) GetOperation(ctx context.Context, request GetOperationRequest)
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching from storage")
 	}
+	if s.isExpired(storedOp) {
+		s.deleteExpired(ctx, storedOp.ID)
+		return nil, sdkutil.LoggingNewErrorf("operation not found with id: %s", request.ID)
+	}
 	return ServiceModel(storedOp)
 }
 
@@ -118,6 +137,10 @@ func (s Service) CancelOperation(ctx context.Context, request CancelOperationReq
 		return nil, errors.Wrap(err, "invalid request")
 	}
 
+	if async.IsAsync(request.ID) {
+		return s.cancelAsync(ctx, request.ID)
+	}
+
 	storedOp, err := s.storage.CancelOperation(ctx, request.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "marking as done")
@@ -125,12 +148,22 @@ func (s Service) CancelOperation(ctx context.Context, request CancelOperationReq
 	return ServiceModel(*storedOp)
 }
 
-func NewOperationService(s storage.ServiceStorage) (*Service, error) {
+func NewOperationService(config config.OperationServiceConfig, s storage.ServiceStorage) (*Service, error) {
 	opStorage, err := NewOperationStorage(s)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "creating operation storage")
 	}
-	service := &Service{storage: opStorage}
+	retention := defaultRetentionPeriod
+	if config.RetentionPeriod != "" {
+		if retention, err = time.ParseDuration(config.RetentionPeriod); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "parsing operation retention period")
+		}
+	}
+	service := &Service{
+		storage:   opStorage,
+		retention: retention,
+		running:   newRunningOperations(),
+	}
 	if !service.Status().IsReady() {
 		return nil, errors.New(service.Status().Message)
 	}
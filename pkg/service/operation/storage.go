@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.einride.tech/aip/filtering"
 
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
 	opstorage "github.com/tbd54566975/ssi-service/pkg/service/operation/storage"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/storage/namespace"
@@ -102,26 +103,30 @@ func (s Storage) GetOperation(ctx context.Context, id string) (opstorage.StoredO
 }
 
 func (s Storage) ListOperations(ctx context.Context, parent string, filter filtering.Filter) ([]opstorage.StoredOperation, error) {
-	operations, err := s.db.ReadAll(ctx, namespace.FromParent(parent))
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsgf(err, "could not get all operations")
-	}
-
 	shouldInclude, err := storage.NewIncludeFunc(filter)
 	if err != nil {
 		return nil, err
 	}
-	stored := make([]opstorage.StoredOperation, 0, len(operations))
-	for i, manifestBytes := range operations {
+	stored := make([]opstorage.StoredOperation, 0)
+	err = s.db.Iterate(ctx, namespace.FromParent(parent), func(key string, opBytes []byte) (bool, error) {
+		// asynchronous operations of every kind share a namespace
+		if async.IsAsync(parent) && !strings.HasPrefix(key, parent+"/") {
+			return true, nil
+		}
 		var nextOp opstorage.StoredOperation
-		if err = json.Unmarshal(manifestBytes, &nextOp); err != nil {
-			logrus.WithError(err).WithField("idx", i).Warnf("Skipping operation")
+		if err := json.Unmarshal(opBytes, &nextOp); err != nil {
+			logrus.WithError(err).WithField("key", key).Warnf("Skipping operation")
+			return true, nil
 		}
 		include, err := shouldInclude(nextOp)
 		// We explicitly ignore evaluation errors and simply include them in the result.
 		if err != nil || include {
 			stored = append(stored, nextOp)
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get all operations")
 	}
 	return stored, nil
 }
@@ -133,6 +138,30 @@ func (s Storage) DeleteOperation(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteOperationIfExists deletes an operation, returning whether there was one to delete.
+func (s Storage) DeleteOperationIfExists(ctx context.Context, id string) (bool, error) {
+	exists, err := s.OperationExists(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+	if err = s.db.Delete(ctx, namespace.FromID(id), id); err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "deleting operation: %s", id)
+	}
+	return true, nil
+}
+
+// OperationExists returns whether an operation with the ID is stored.
+func (s Storage) OperationExists(ctx context.Context, id string) (bool, error) {
+	exists, err := s.db.Exists(ctx, namespace.FromID(id), id)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "checking operation: %s", id)
+	}
+	return exists, nil
+}
+
 func NewOperationStorage(db storage.ServiceStorage) (*Storage, error) {
 	if db == nil {
 		return nil, errors.New("db reference is nil")
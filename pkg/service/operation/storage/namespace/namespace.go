@@ -3,6 +3,7 @@ package namespace
 import (
 	"strings"
 
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/async"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
 )
@@ -25,11 +26,13 @@ func FromID(id string) string {
 // FromParent returns a namespace from a given parent resource name like "presentations/submissions". Empty is returned
 // when the parent resource cannot be resolved.
 func FromParent(parent string) string {
-	switch parent {
-	case submission.ParentResource:
+	switch {
+	case parent == submission.ParentResource:
 		return namespace
-	case credential.ParentResource:
+	case parent == credential.ParentResource:
 		return credentialResponseNamespace
+	case async.IsAsync(parent):
+		return async.Namespace
 	default:
 		return ""
 	}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"strings"
+	"time"
 
 	"go.einride.tech/aip/filtering"
 )
@@ -17,6 +18,12 @@ type StoredOperation struct {
 
 	// Populated only when Done == true and Error == ""
 	Response []byte `json:"response,omitempty"`
+
+	// When the operation was started. Only set for asynchronous operations.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// When the operation is deleted. Only set for asynchronous operations once they're done.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 func (s StoredOperation) FilterVariablesMap() map[string]any {
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"github.com/benbjohnson/clock"
+
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Option customizes how an SSIService is instantiated, for programs that embed the service and provide some of its
+// dependencies themselves.
+type Option func(*dependencies)
+
+// dependencies are the implementations provided in place of the ones the config would create.
+type dependencies struct {
+	storage      storage.ServiceStorage
+	keyEncrypter encryption.Encrypter
+	keyDecrypter encryption.Decrypter
+	clock        clock.Clock
+}
+
+// WithStorage stores the data of every service in s, instead of in the storage provider of the config. App level
+// encryption and the scoping of data to tenants are still applied on top of it, as configured.
+func WithStorage(s storage.ServiceStorage) Option {
+	return func(d *dependencies) {
+		d.storage = s
+	}
+}
+
+// WithKeyEncryption encrypts and decrypts the private keys of the key store with encrypter and decrypter, e.g. ones
+// backed by an HSM, instead of with the encryption of the key store config.
+func WithKeyEncryption(encrypter encryption.Encrypter, decrypter encryption.Decrypter) Option {
+	return func(d *dependencies) {
+		d.keyEncrypter = encrypter
+		d.keyDecrypter = decrypter
+	}
+}
+
+// WithClock keeps time for the services with c, e.g. a mock clock in tests, instead of the system's. The services
+// schedule their jobs, and tell what expired, by it.
+func WithClock(c clock.Clock) Option {
+	return func(d *dependencies) {
+		d.clock = c
+	}
+}
+
+func newDependencies(opts []Option) dependencies {
+	var d dependencies
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return d
+}
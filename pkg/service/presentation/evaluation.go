@@ -0,0 +1,207 @@
+package presentation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/credential/parsing"
+	"github.com/TBD54566975/ssi-sdk/schema"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/oliveagle/jsonpath"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/credential"
+	presentationstorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
+)
+
+// evaluateSubmission evaluates the constraints of each input descriptor of a definition against the credential that a
+// presentation submits for it. Presentations that don't submit a credential of the right format for each input
+// descriptor fail, while credentials that don't satisfy the constraints are reported in the evaluation. The issuers of
+// the credentials are checked against trust too, when it's set.
+func evaluateSubmission(ctx context.Context, definition exchange.PresentationDefinition, sub exchange.PresentationSubmission, vp credsdk.VerifiablePresentation, trust credential.IssuerTrust) (*presentationstorage.Evaluation, error) {
+	// the sdk checks that the submission maps each input descriptor to a credential, but applies the filters of fields
+	// with predicates to their results, and leaves out limit_disclosure, so constraints are evaluated here instead
+	unconstrained := definition
+	unconstrained.InputDescriptors = make([]exchange.InputDescriptor, 0, len(definition.InputDescriptors))
+	for _, descriptor := range definition.InputDescriptors {
+		descriptor.Constraints = nil
+		unconstrained.InputDescriptors = append(unconstrained.InputDescriptors, descriptor)
+	}
+	if _, err := exchange.VerifyPresentationSubmissionVP(unconstrained, vp); err != nil {
+		return nil, errors.Wrap(err, "verifying presentation submission vp")
+	}
+
+	paths := make(map[string]string, len(sub.DescriptorMap))
+	for _, d := range sub.DescriptorMap {
+		paths[d.ID] = d.Path
+	}
+	vpJSON, err := sdkutil.ToJSONMap(vp)
+	if err != nil {
+		return nil, errors.Wrap(err, "turning vp into json")
+	}
+
+	evaluation := presentationstorage.Evaluation{
+		Satisfied:        true,
+		InputDescriptors: make([]presentationstorage.DescriptorEvaluation, 0, len(definition.InputDescriptors)),
+	}
+	for _, descriptor := range definition.InputDescriptors {
+		claim, err := jsonpath.JsonPathLookup(vpJSON, paths[descriptor.ID])
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving credential of input descriptor<%s>", descriptor.ID)
+		}
+		result, err := evaluateDescriptor(ctx, descriptor, claim, trust)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating input descriptor<%s>", descriptor.ID)
+		}
+		evaluation.Satisfied = evaluation.Satisfied && result.Satisfied
+		evaluation.InputDescriptors = append(evaluation.InputDescriptors, *result)
+	}
+	return &evaluation, nil
+}
+
+func evaluateDescriptor(ctx context.Context, descriptor exchange.InputDescriptor, claim any, trust credential.IssuerTrust) (*presentationstorage.DescriptorEvaluation, error) {
+	result := presentationstorage.DescriptorEvaluation{ID: descriptor.ID, Satisfied: true}
+	constraints := descriptor.Constraints
+	if constraints == nil && trust == nil {
+		return &result, nil
+	}
+	credJSON, err := parsing.ToCredentialJSONMap(claim)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting credential as json")
+	}
+	_, _, cred, err := parsing.ToCredential(claim)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting credential")
+	}
+
+	if trust != nil {
+		// issuers are trusted whatever the definition asks for, so the check is always required
+		result.TrustedIssuer = &presentationstorage.ConstraintEvaluation{Preference: exchange.Required, Satisfied: true}
+		if err = trust.CheckIssuer(ctx, *cred); err != nil {
+			result.TrustedIssuer.Satisfied = false
+			result.TrustedIssuer.Reason = err.Error()
+		}
+	}
+	if constraints != nil {
+		for _, field := range constraints.Fields {
+			fieldResult := evaluateField(field, credJSON)
+			result.Satisfied = result.Satisfied && (fieldResult.Satisfied || field.Optional)
+			result.Fields = append(result.Fields, fieldResult)
+		}
+
+		if constraints.LimitDisclosure != nil {
+			result.LimitDisclosure = &presentationstorage.ConstraintEvaluation{Preference: *constraints.LimitDisclosure, Satisfied: true}
+			if undisclosed := excessClaims(constraints.Fields, cred.CredentialSubject); len(undisclosed) > 0 {
+				result.LimitDisclosure.Satisfied = false
+				result.LimitDisclosure.Reason = fmt.Sprintf("discloses claims<%s> that no field asks for", strings.Join(undisclosed, ", "))
+			}
+		}
+		if constraints.SubjectIsIssuer != nil {
+			result.SubjectIsIssuer = &presentationstorage.ConstraintEvaluation{Preference: *constraints.SubjectIsIssuer, Satisfied: true}
+			issuer := issuerID(cred.Issuer)
+			if subject := cred.CredentialSubject.GetID(); issuer == "" || issuer != subject {
+				result.SubjectIsIssuer.Satisfied = false
+				result.SubjectIsIssuer.Reason = fmt.Sprintf("subject<%s> is not the issuer<%s>", subject, issuer)
+			}
+		}
+	}
+	for _, c := range []*presentationstorage.ConstraintEvaluation{result.LimitDisclosure, result.SubjectIsIssuer, result.TrustedIssuer} {
+		if c != nil && c.Preference == exchange.Required && !c.Satisfied {
+			result.Satisfied = false
+		}
+	}
+	return &result, nil
+}
+
+// evaluateField finds the value of a field at the first of its paths that the credential has, and applies the field's
+// filter to it. Fields with a predicate are satisfied by true, which is the result of the filter that the holder
+// submits in place of the value.
+func evaluateField(field exchange.Field, credJSON map[string]any) presentationstorage.FieldEvaluation {
+	result := presentationstorage.FieldEvaluation{ID: field.ID, Optional: field.Optional}
+	if field.Predicate != nil {
+		result.Predicate = *field.Predicate
+	}
+	var value any
+	for _, path := range field.Path {
+		if v, err := jsonpath.JsonPathLookup(credJSON, path); err == nil {
+			value, result.Path = v, path
+			break
+		}
+	}
+	if result.Path == "" {
+		result.Reason = "the credential has no value at any of its paths"
+		return result
+	}
+	if field.Filter == nil {
+		result.Satisfied = true
+		return result
+	}
+
+	if field.Predicate != nil {
+		if predicate, ok := value.(bool); ok {
+			result.Satisfied = predicate
+			if !predicate {
+				result.Reason = "the result of its predicate is false"
+			}
+			return result
+		}
+		if *field.Predicate == exchange.Required {
+			result.Reason = "the value is disclosed instead of the result of its predicate"
+			return result
+		}
+	}
+	filterJSON, err := field.Filter.ToJSON()
+	if err == nil {
+		err = schema.IsAnyValidAgainstJSONSchema(value, filterJSON)
+	}
+	if err != nil {
+		result.Reason = fmt.Sprintf("the value does not satisfy its filter: %s", err)
+		return result
+	}
+	result.Satisfied = true
+	return result
+}
+
+// excessClaims lists the claims of a credential's subject, other than its id, that none of the paths of the fields
+// are into.
+func excessClaims(fields []exchange.Field, subject credsdk.CredentialSubject) []string {
+	asked := make(map[string]bool)
+	for _, field := range fields {
+		for _, path := range field.Path {
+			for _, prefix := range claimPaths {
+				if !strings.HasPrefix(path, prefix) {
+					continue
+				}
+				claim := strings.TrimPrefix(path, prefix)
+				if i := strings.IndexAny(claim, ".["); i >= 0 {
+					claim = claim[:i]
+				}
+				asked[claim] = true
+			}
+		}
+	}
+	var excess []string
+	for claim := range subject {
+		if claim != credsdk.VerifiableCredentialIDProperty && !asked[claim] {
+			excess = append(excess, claim)
+		}
+	}
+	sort.Strings(excess)
+	return excess
+}
+
+// issuerID is the ID of an issuer, which is either a string, or an object with an id.
+func issuerID(issuer any) string {
+	switch i := issuer.(type) {
+	case string:
+		return i
+	case map[string]any:
+		id, _ := i[credsdk.VerifiableCredentialIDProperty].(string)
+		return id
+	}
+	return ""
+}
@@ -3,8 +3,10 @@ package model
 import (
 	credsdk "github.com/TBD54566975/ssi-sdk/credential"
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
 	"github.com/TBD54566975/ssi-sdk/util"
 	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
 	"github.com/tbd54566975/ssi-service/pkg/server/pagination"
 	"github.com/tbd54566975/ssi-service/pkg/service/common"
 	"go.einride.tech/aip/filtering"
@@ -38,6 +40,67 @@ type DeletePresentationDefinitionRequest struct {
 	ID string `json:"id" validate:"required"`
 }
 
+// Requirement is a credential that a presentation must include, described more simply than by an input descriptor.
+// Requirements are expanded into input descriptors, one each.
+type Requirement struct {
+	// ID of the input descriptor. A random one is generated when empty.
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+
+	// Claims of the credential's subject that the credential must have.
+	Claims []ClaimRequirement `json:"claims,omitempty" validate:"omitempty,dive"`
+
+	// LimitDisclosure requires that the credential disclose its claims and nothing more.
+	LimitDisclosure bool `json:"limitDisclosure,omitempty"`
+}
+
+// ClaimRequirement is a claim of a credential's subject, and the values it may have.
+type ClaimRequirement struct {
+	// Claim is the name of the claim, as a dotted path into the credential's subject, e.g. address.country.
+	Claim string `json:"claim" validate:"required"`
+
+	// Type is the JSON type that the claim's value must have, e.g. string or number.
+	Type    string `json:"type,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Const   any    `json:"const,omitempty"`
+	Enum    []any  `json:"enum,omitempty"`
+	Minimum any    `json:"minimum,omitempty"`
+	Maximum any    `json:"maximum,omitempty"`
+
+	// Optional claims are asked for, but not required.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ValidatePresentationDefinitionRequest holds a presentation definition to validate, either as Presentation Exchange
+// JSON, or as requirements to expand into one.
+type ValidatePresentationDefinitionRequest struct {
+	PresentationDefinition []byte `json:"presentationDefinition,omitempty"`
+
+	Name         string        `json:"name,omitempty"`
+	Purpose      string        `json:"purpose,omitempty"`
+	Requirements []Requirement `json:"requirements,omitempty" validate:"omitempty,dive"`
+}
+
+// DefinitionIssue is a way in which a presentation definition is wrong, or can't be satisfied.
+type DefinitionIssue struct {
+	// Path of the member of the definition that's at fault, as a JSONPath, e.g.
+	// $.input_descriptors[0].constraints.fields[1].path[0]. It's $ when the definition as a whole is at fault.
+	Path string `json:"path"`
+	// Message describes what's wrong with the member.
+	Message string `json:"message"`
+}
+
+type ValidatePresentationDefinitionResponse struct {
+	// Valid is true when the definition has no issues.
+	Valid  bool              `json:"valid"`
+	Issues []DefinitionIssue `json:"issues,omitempty"`
+
+	// PresentationDefinition that was validated, as expanded from requirements when they were given. Empty when the
+	// definition couldn't be read at all.
+	PresentationDefinition *exchange.PresentationDefinition `json:"presentationDefinition,omitempty"`
+}
+
 type CreateSubmissionRequest struct {
 	Presentation  credsdk.VerifiablePresentation  `json:"presentation" validate:"required"`
 	SubmissionJWT keyaccess.JWT                   `json:"submissionJwt,omitempty" validate:"required"`
@@ -49,6 +112,45 @@ func (csr CreateSubmissionRequest) IsValid() bool {
 	return util.IsValidStruct(csr) == nil
 }
 
+// NewCreateSubmissionRequest reads the presentation of a JWT VP, along with its presentation submission and credentials.
+// The submission is the one given when the presentation doesn't embed one.
+func NewCreateSubmissionRequest(submissionJWT keyaccess.JWT, submission *exchange.PresentationSubmission) (*CreateSubmissionRequest, error) {
+	_, _, vp, err := integrity.ParseVerifiablePresentationFromJWT(submissionJWT.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing presentation from jwt")
+	}
+	if err = vp.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "verifying vp validity")
+	}
+	if vp.PresentationSubmission == nil && submission != nil {
+		vp.PresentationSubmission = *submission
+	}
+
+	submissionData, err := json.Marshal(vp.PresentationSubmission)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling presentation_submission")
+	}
+	var s exchange.PresentationSubmission
+	if err = json.Unmarshal(submissionData, &s); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling presentation submission")
+	}
+	if err = s.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "verifying submission validity")
+	}
+	vp.PresentationSubmission = s
+
+	credContainers, err := credential.NewCredentialContainerFromArray(vp.VerifiableCredential)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing verifiable credential array")
+	}
+
+	return &CreateSubmissionRequest{
+		Presentation:  *vp,
+		SubmissionJWT: submissionJWT,
+		Submission:    s,
+		Credentials:   credContainers}, nil
+}
+
 type CreateSubmissionResponse struct {
 	Submission exchange.PresentationSubmission `json:"submission"`
 }
@@ -65,6 +167,20 @@ type DeleteSubmissionRequest struct {
 	ID string `json:"id" validate:"required"`
 }
 
+type EraseHolderRequest struct {
+	Holder string `json:"holder" validate:"required"`
+
+	// When set, nothing is deleted, and the response lists what would be.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type EraseHolderResponse struct {
+	// IDs of the submissions of the holder that were deleted.
+	SubmissionIDs []string `json:"submissionIds"`
+	// IDs of the operations that reviewed them, which were deleted along with them.
+	OperationIDs []string `json:"operationIds"`
+}
+
 type ListSubmissionRequest struct {
 	Filter      filtering.Filter
 	PageRequest *pagination.PageRequest
@@ -77,6 +193,8 @@ type Submission struct {
 	Reason string `json:"reason,omitempty"`
 	// The verifiable presentation containing the presentation_submission along with the credentials presented.
 	VerifiablePresentation *credsdk.VerifiablePresentation `json:"verifiablePresentation,omitempty"`
+	// How the credentials of the presentation fare against the constraints of the presentation definition.
+	Evaluation *storage.Evaluation `json:"evaluation,omitempty"`
 }
 
 func (r Submission) GetSubmission() *exchange.PresentationSubmission {
@@ -98,6 +216,9 @@ func (r Submission) GetSubmission() *exchange.PresentationSubmission {
 type ListSubmissionResponse struct {
 	Submissions   []Submission `json:"submissions"`
 	NextPageToken string
+
+	// TotalCount is the number of submissions across all pages.
+	TotalCount int
 }
 
 type ListDefinitionsResponse struct {
@@ -121,6 +242,7 @@ func ServiceModel(storedSubmission *storage.StoredSubmission) Submission {
 		Status:                 storedSubmission.Status.String(),
 		Reason:                 storedSubmission.Reason,
 		VerifiablePresentation: &storedSubmission.VerifiablePresentation,
+		Evaluation:             storedSubmission.Evaluation,
 	}
 }
 
@@ -0,0 +1,56 @@
+package presentation
+
+import (
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/google/uuid"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+)
+
+// claimPaths are where the subject of a credential is, in its data model and in the claims of a JWT.
+var claimPaths = []string{"$.credentialSubject.", "$.vc.credentialSubject."}
+
+// InputDescriptors expands requirements into the input descriptors of a presentation definition, one each. Each claim
+// becomes a field, which has a filter when the claim's values are constrained.
+func InputDescriptors(requirements []model.Requirement) []exchange.InputDescriptor {
+	descriptors := make([]exchange.InputDescriptor, 0, len(requirements))
+	for _, requirement := range requirements {
+		id := requirement.ID
+		if id == "" {
+			id = uuid.NewString()
+		}
+		constraints := exchange.Constraints{}
+		for _, claim := range requirement.Claims {
+			constraints.Fields = append(constraints.Fields, claimField(claim))
+		}
+		if requirement.LimitDisclosure {
+			constraints.LimitDisclosure = exchange.Required.Ptr()
+		}
+		descriptors = append(descriptors, exchange.InputDescriptor{
+			ID:          id,
+			Name:        requirement.Name,
+			Purpose:     requirement.Purpose,
+			Constraints: &constraints,
+		})
+	}
+	return descriptors
+}
+
+func claimField(claim model.ClaimRequirement) exchange.Field {
+	field := exchange.Field{Optional: claim.Optional}
+	for _, path := range claimPaths {
+		field.Path = append(field.Path, path+claim.Claim)
+	}
+	filter := exchange.Filter{
+		Type:    claim.Type,
+		Pattern: claim.Pattern,
+		Const:   claim.Const,
+		Enum:    claim.Enum,
+		Minimum: claim.Minimum,
+		Maximum: claim.Maximum,
+	}
+	if filter.Type != "" || filter.Pattern != "" || filter.Const != nil || len(filter.Enum) > 0 || filter.Minimum != nil || filter.Maximum != nil {
+		field.Filter = &filter
+	}
+	return field
+}
@@ -3,14 +3,18 @@ package presentation
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
 	"github.com/TBD54566975/ssi-sdk/credential/integrity"
 	"github.com/TBD54566975/ssi-sdk/did/resolution"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/jws"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.einride.tech/aip/filtering"
 
 	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/internal/credential"
@@ -24,11 +28,22 @@ import (
 	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
 	"github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
 	presentationstorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
 )
 
-const presentationRequestNamespace = "presentation_request"
+const (
+	presentationRequestNamespace = "presentation_request"
+
+	// PurgeInterval is how often the nonces of requests that can no longer be responded to are deleted.
+	PurgeInterval = time.Hour
+)
+
+// ErrInvalidRequestNonce is returned for submissions that respond to a presentation request that expired, that's for
+// another presentation definition, or that was already responded to, and for submissions to a presentation definition
+// that requests were created for that don't carry the nonce of one.
+var ErrInvalidRequestNonce = errors.New("invalid presentation request nonce")
 
 type Service struct {
 	storage    presentationstorage.Storage
@@ -39,6 +54,11 @@ type Service struct {
 	schema     *schema.Service
 	verifier   *credential.Validator
 	reqStorage common.RequestStorage
+
+	// trust checks the issuers of submitted credentials, when it's set.
+	trust credential.IssuerTrust
+
+	Clock clock.Clock
 }
 
 func (s Service) Type() framework.Type {
@@ -87,6 +107,7 @@ func NewPresentationService(config config.PresentationServiceConfig, s storage.S
 		schema:     schema,
 		verifier:   verifier,
 		reqStorage: requestStorage,
+		Clock:      clock.New(),
 	}
 	if !service.Status().IsReady() {
 		return nil, errors.New(service.Status().Message)
@@ -94,6 +115,12 @@ func NewPresentationService(config config.PresentationServiceConfig, s storage.S
 	return &service, nil
 }
 
+// SetIssuerTrust makes the evaluation of submissions check that the issuers of the submitted credentials are trusted
+// to issue them. It must be called before the service is handed to other services.
+func (s *Service) SetIssuerTrust(trust credential.IssuerTrust) {
+	s.trust = trust
+}
+
 // CreatePresentationDefinition houses the main service logic for presentation definition creation. It validates the input, and
 // produces a presentation definition value that conforms with the PresentationDefinition specification.
 func (s Service) CreatePresentationDefinition(ctx context.Context,
@@ -176,7 +203,7 @@ func (s Service) CreateSubmission(ctx context.Context, request model.CreateSubmi
 		return nil, errors.Wrap(err, "provided value is not a valid presentation submission")
 	}
 
-	headers, _, vp, err := integrity.ParseVerifiablePresentationFromJWT(request.SubmissionJWT.String())
+	headers, token, vp, err := integrity.ParseVerifiablePresentationFromJWT(request.SubmissionJWT.String())
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing vp from jwt")
 	}
@@ -221,14 +248,22 @@ func (s Service) CreateSubmission(ctx context.Context, request model.CreateSubmi
 		}
 	}
 
-	// TODO(gabe) plug in additional credential verification logic here
-	if _, err = exchange.VerifyPresentationSubmissionVP(storedDefinition.PresentationDefinition, request.Presentation); err != nil {
-		return nil, errors.Wrap(err, "verifying presentation submission vp")
+	evaluation, err := evaluateSubmission(ctx, storedDefinition.PresentationDefinition, request.Submission, request.Presentation, s.trust)
+	if err != nil {
+		return nil, errors.Wrap(err, "evaluating presentation submission")
+	}
+
+	// holders set a nonce of their own when they don't respond to a request, so only the nonces of requests are checked
+	nonce, _ := token.Get(common.NonceClaim)
+	nonceValue, _ := nonce.(string)
+	if err = s.consumeRequestNonce(ctx, nonceValue, request.Submission); err != nil {
+		return nil, err
 	}
 
 	storedSubmission := presentationstorage.StoredSubmission{
 		Status:                 submission.StatusPending,
 		VerifiablePresentation: request.Presentation,
+		Evaluation:             evaluation,
 	}
 
 	// TODO(andres): IO requests should be done in parallel, once we have context wired up.
@@ -275,10 +310,15 @@ func (s Service) ListSubmissions(ctx context.Context, request model.ListSubmissi
 		return nil, errors.Wrap(err, "fetching submissions from storage")
 	}
 	logrus.Debug(len(subs.Submissions))
+	total, err := s.storage.CountSubmissions(ctx, request.Filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting submissions in storage")
+	}
 
 	resp := &model.ListSubmissionResponse{
 		Submissions:   make([]model.Submission, 0, len(subs.Submissions)),
 		NextPageToken: subs.NextPageToken,
+		TotalCount:    total,
 	}
 	for _, sub := range subs.Submissions {
 		sub := sub // What's this?? see https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
@@ -288,6 +328,50 @@ func (s Service) ListSubmissions(ctx context.Context, request model.ListSubmissi
 	return resp, nil
 }
 
+// EraseHolder deletes every submission whose presentation was made by a holder, along with the operations reviewing
+// them.
+func (s Service) EraseHolder(ctx context.Context, request model.EraseHolderRequest) (*model.EraseHolderResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	logrus.Debugf("erasing presentation submissions of holder: %s", request.Holder)
+
+	subs, err := s.storage.ListSubmissions(ctx, filtering.Filter{}, common.Page{Size: -1})
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching submissions from storage")
+	}
+	resp := model.EraseHolderResponse{SubmissionIDs: make([]string, 0), OperationIDs: make([]string, 0)}
+	for _, sub := range subs.Submissions {
+		sub := sub
+		if sub.VerifiablePresentation.Holder != request.Holder {
+			continue
+		}
+		ps := model.ServiceModel(&sub).GetSubmission()
+		if ps == nil || ps.ID == "" {
+			continue
+		}
+		opID := submission.IDFromSubmissionID(ps.ID)
+		var deleted bool
+		if request.DryRun {
+			if deleted, err = s.opsStorage.OperationExists(ctx, opID); err != nil {
+				return nil, errors.Wrapf(err, "checking operation: %s", opID)
+			}
+		} else {
+			if err = s.storage.DeleteSubmission(ctx, ps.ID); err != nil {
+				return nil, errors.Wrapf(err, "erasing submission: %s", ps.ID)
+			}
+			if deleted, err = s.opsStorage.DeleteOperationIfExists(ctx, opID); err != nil {
+				return nil, errors.Wrapf(err, "erasing operation: %s", opID)
+			}
+		}
+		resp.SubmissionIDs = append(resp.SubmissionIDs, ps.ID)
+		if deleted {
+			resp.OperationIDs = append(resp.OperationIDs, opID)
+		}
+	}
+	return &resp, nil
+}
+
 func (s Service) ReviewSubmission(ctx context.Context, request model.ReviewSubmissionRequest) (*model.Submission, error) {
 	if err := request.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid request")
@@ -327,6 +411,10 @@ func (s Service) CreateRequest(ctx context.Context, req model.CreateRequestReque
 	}
 
 	request := req.PresentationRequest
+	if request.Expiration == nil && s.config.RequestTTL > 0 {
+		expiration := s.Clock.Now().Add(s.config.RequestTTL)
+		request.Expiration = &expiration
+	}
 	pd, err := s.storage.GetDefinition(ctx, request.PresentationDefinitionID)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting presentation definition")
@@ -342,6 +430,7 @@ func (s Service) CreateRequest(ctx context.Context, req model.CreateRequestReque
 		pd.PresentationDefinition,
 		request.Request,
 		request.PresentationDefinitionID,
+		uuid.NewString(),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating stored request")
@@ -349,9 +438,100 @@ func (s Service) CreateRequest(ctx context.Context, req model.CreateRequestReque
 	if err := s.reqStorage.StoreRequest(ctx, *stored); err != nil {
 		return nil, errors.Wrap(err, "storing signed document")
 	}
+	storedNonce := presentationstorage.StoredRequestNonce{
+		Nonce:        stored.Nonce,
+		RequestID:    stored.ID,
+		DefinitionID: request.PresentationDefinitionID,
+		ExpiresAt:    request.Expiration,
+	}
+	if err := s.storage.StoreRequestNonce(ctx, storedNonce); err != nil {
+		return nil, errors.Wrap(err, "storing request nonce")
+	}
 	return serviceModel(stored)
 }
 
+// IsRequestNonce returns whether the nonce is of a presentation request, which submissions responding to the request
+// carry.
+func (s Service) IsRequestNonce(ctx context.Context, nonce string) (bool, error) {
+	storedNonce, err := s.storage.GetRequestNonce(ctx, nonce)
+	if err != nil {
+		return false, errors.Wrap(err, "getting request nonce")
+	}
+	return storedNonce != nil, nil
+}
+
+// consumeRequestNonce checks that a submission carrying the nonce of a presentation request responds to the request
+// before it expires, and records that the request was responded to, so that its nonce isn't accepted again.
+// Submissions to a presentation definition that requests were created for must carry the nonce of one of them, while
+// those to other definitions carry a nonce of the holder's own, which isn't checked.
+func (s Service) consumeRequestNonce(ctx context.Context, nonce string, sub exchange.PresentationSubmission) error {
+	var storedNonce *presentationstorage.StoredRequestNonce
+	if nonce != "" {
+		var err error
+		if storedNonce, err = s.storage.GetRequestNonce(ctx, nonce); err != nil {
+			return errors.Wrap(err, "getting request nonce")
+		}
+	}
+	if storedNonce == nil {
+		requested, err := s.isRequested(ctx, sub.DefinitionID)
+		if err != nil {
+			return err
+		}
+		if requested {
+			return sdkutil.LoggingErrorMsgf(ErrInvalidRequestNonce, "presentation definition<%s> was requested, and the submission doesn't carry the nonce of a request that can be responded to",
+				sub.DefinitionID)
+		}
+		return nil
+	}
+	if storedNonce.DefinitionID != sub.DefinitionID {
+		return sdkutil.LoggingErrorMsgf(ErrInvalidRequestNonce, "presentation request<%s> is for presentation definition<%s>",
+			storedNonce.RequestID, storedNonce.DefinitionID)
+	}
+	if storedNonce.ExpiresAt != nil && !s.Clock.Now().Before(*storedNonce.ExpiresAt) {
+		return sdkutil.LoggingErrorMsgf(ErrInvalidRequestNonce, "presentation request<%s> expired", storedNonce.RequestID)
+	}
+	consumed, err := s.storage.ConsumeRequestNonce(ctx, nonce, sub.ID)
+	if err != nil {
+		return errors.Wrap(err, "consuming request nonce")
+	}
+	if !consumed {
+		return sdkutil.LoggingErrorMsgf(ErrInvalidRequestNonce, "presentation request<%s> was already responded to",
+			storedNonce.RequestID)
+	}
+	return nil
+}
+
+// isRequested returns whether requests were created for the presentation definition, which haven't been deleted.
+func (s Service) isRequested(ctx context.Context, definitionID string) (bool, error) {
+	requests, err := s.reqStorage.ListRequests(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "listing presentation requests")
+	}
+	for _, request := range requests {
+		if request.ReferenceID == definitionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Purge deletes the nonces of requests that expired or were responded to, returning how many were deleted. Requests
+// are kept, so submissions carrying a deleted nonce are still rejected.
+func (s Service) Purge(ctx context.Context) (int, error) {
+	return s.storage.DeleteExpiredRequestNonces(ctx, s.Clock.Now())
+}
+
+// RunSchedule purges every purge interval, until ctx is done.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, PurgeInterval, func() {
+		if purged, err := s.Purge(context.Background()); err != nil {
+			logrus.WithError(err).Error("purging presentation request nonces")
+		} else if purged > 0 {
+			logrus.Infof("purged %d presentation request nonces", purged)
+		}
+	})
+}
+
 func (s Service) GetRequest(ctx context.Context, request *model.GetRequestRequest) (*model.Request, error) {
 	logrus.Debugf("getting presentation request: %s", request.ID)
 
@@ -369,7 +549,16 @@ func (s Service) GetRequest(ctx context.Context, request *model.GetRequestReques
 func (s Service) DeleteRequest(ctx context.Context, request model.DeleteRequestRequest) error {
 	logrus.Debugf("deleting presentation request: %s", request.ID)
 
-	if err := s.reqStorage.DeleteRequest(ctx, request.ID); err != nil {
+	storedRequest, err := s.reqStorage.GetRequest(ctx, request.ID)
+	if err != nil {
+		return errors.Wrapf(err, "getting presentation request with id: %s", request.ID)
+	}
+	if storedRequest != nil && storedRequest.Nonce != "" {
+		if err = s.storage.DeleteRequestNonce(ctx, storedRequest.Nonce); err != nil {
+			return errors.Wrap(err, "deleting request nonce")
+		}
+	}
+	if err = s.reqStorage.DeleteRequest(ctx, request.ID); err != nil {
 		return sdkutil.LoggingNewErrorf("could not delete presentation request with id: %s", request.ID)
 	}
 
@@ -3,6 +3,7 @@ package presentation
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
@@ -21,6 +22,7 @@ import (
 
 const (
 	presentationDefinitionNamespace = "presentation_definition"
+	requestNonceNamespace           = "presentation_request_nonce"
 )
 
 type Storage struct {
@@ -96,6 +98,30 @@ func (ps *Storage) ListSubmissions(ctx context.Context, filter filtering.Filter,
 	}, nil
 }
 
+// CountSubmissions returns how many submissions across all pages are included by the filter.
+func (ps *Storage) CountSubmissions(ctx context.Context, filter filtering.Filter) (int, error) {
+	shouldInclude, err := storage.NewIncludeFunc(filter)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = ps.db.Iterate(ctx, opsubmission.Namespace, func(key string, data []byte) (bool, error) {
+		var ss prestorage.StoredSubmission
+		if err := json.Unmarshal(data, &ss); err != nil {
+			logrus.WithError(err).WithField("key", key).Error("unmarshalling submission")
+		}
+		// evaluation errors are counted, the same way they're included when listing
+		if include, err := shouldInclude(ss); err != nil || include {
+			count++
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "counting submissions")
+	}
+	return count, nil
+}
+
 func NewPresentationStorage(db storage.ServiceStorage) (prestorage.Storage, error) {
 	if db == nil {
 		return nil, errors.New("db reference is nil")
@@ -174,18 +200,106 @@ func (ps *Storage) GetSubmission(ctx context.Context, id string) (*prestorage.St
 	return &stored, nil
 }
 
+func (ps *Storage) DeleteSubmission(ctx context.Context, id string) error {
+	if err := ps.db.Delete(ctx, opsubmission.Namespace, id); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "deleting submission: %s", id)
+	}
+	return nil
+}
+
 func (ps *Storage) ListDefinitions(ctx context.Context) ([]prestorage.StoredDefinition, error) {
-	m, err := ps.db.ReadAll(ctx, presentationDefinitionNamespace)
+	ts := make([]prestorage.StoredDefinition, 0)
+	err := ps.db.Iterate(ctx, presentationDefinitionNamespace, func(k string, v []byte) (bool, error) {
+		var definition prestorage.StoredDefinition
+		if err := json.Unmarshal(v, &definition); err != nil {
+			return false, errors.Wrapf(err, "unmarshalling template with key <%s>", k)
+		}
+		ts = append(ts, definition)
+		return true, nil
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "reading all")
 	}
-	ts := make([]prestorage.StoredDefinition, len(m))
-	i := 0
-	for k, v := range m {
-		if err = json.Unmarshal(v, &ts[i]); err != nil {
-			return nil, errors.Wrapf(err, "unmarshalling template with key <%s>", k)
+	return ts, nil
+}
+
+func (ps *Storage) StoreRequestNonce(ctx context.Context, nonce prestorage.StoredRequestNonce) error {
+	if nonce.Nonce == "" {
+		return sdkutil.LoggingNewError("could not store presentation request nonce without a value")
+	}
+	jsonBytes, err := json.Marshal(nonce)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not store nonce of presentation request: %s", nonce.RequestID)
+	}
+	return ps.db.Write(ctx, requestNonceNamespace, nonce.Nonce, jsonBytes)
+}
+
+func (ps *Storage) GetRequestNonce(ctx context.Context, nonce string) (*prestorage.StoredRequestNonce, error) {
+	jsonBytes, err := ps.db.Read(ctx, requestNonceNamespace, nonce)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get presentation request nonce")
+	}
+	if len(jsonBytes) == 0 {
+		return nil, nil
+	}
+	var stored prestorage.StoredRequestNonce
+	if err = json.Unmarshal(jsonBytes, &stored); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not unmarshal stored presentation request nonce")
+	}
+	return &stored, nil
+}
+
+// ConsumeRequestNonce checks and records the submission in a transaction that watches the nonce, so that two
+// submissions carrying the same nonce at once can't both consume it.
+func (ps *Storage) ConsumeRequestNonce(ctx context.Context, nonce, submissionID string) (bool, error) {
+	watchKeys := []storage.WatchKey{{Namespace: requestNonceNamespace, Key: nonce}}
+	consumed, err := ps.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		stored, err := ps.GetRequestNonce(ctx, nonce)
+		if err != nil {
+			return false, err
 		}
-		i++
+		if stored == nil || stored.SubmissionID != "" {
+			return false, nil
+		}
+		stored.SubmissionID = submissionID
+		jsonBytes, err := json.Marshal(stored)
+		if err != nil {
+			return false, errors.Wrap(err, "marshalling presentation request nonce")
+		}
+		return true, tx.Write(ctx, requestNonceNamespace, nonce, jsonBytes)
+	}, watchKeys)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsg(err, "could not consume presentation request nonce")
 	}
-	return ts, nil
+	return consumed.(bool), nil
+}
+
+func (ps *Storage) DeleteRequestNonce(ctx context.Context, nonce string) error {
+	if err := ps.db.Delete(ctx, requestNonceNamespace, nonce); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete presentation request nonce: %s", nonce)
+	}
+	return nil
+}
+
+func (ps *Storage) DeleteExpiredRequestNonces(ctx context.Context, now time.Time) (int, error) {
+	nonces, err := ps.db.ReadAll(ctx, requestNonceNamespace)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list presentation request nonces")
+	}
+	var expired []string
+	for key, nonceBytes := range nonces {
+		var stored prestorage.StoredRequestNonce
+		if err = json.Unmarshal(nonceBytes, &stored); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not unmarshal stored presentation request nonce: %s", key)
+		}
+		if stored.SubmissionID != "" || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+			expired = append(expired, key)
+		}
+	}
+	for _, key := range expired {
+		if err = ps.DeleteRequestNonce(ctx, key); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
 }
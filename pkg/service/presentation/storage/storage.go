@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/TBD54566975/ssi-sdk/credential"
 	"github.com/TBD54566975/ssi-sdk/credential/exchange"
@@ -20,6 +21,7 @@ type StoredDefinition struct {
 type Storage interface {
 	DefinitionStorage
 	SubmissionStorage
+	RequestNonceStorage
 }
 
 type DefinitionStorage interface {
@@ -33,6 +35,55 @@ type StoredSubmission struct {
 	Status                 submission.Status                 `json:"status"`
 	Reason                 string                            `json:"reason"`
 	VerifiablePresentation credential.VerifiablePresentation `json:"vp"`
+
+	// Evaluation of the constraints of the presentation definition against the submitted credentials. Empty for
+	// submissions stored before submissions were evaluated.
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
+}
+
+// Evaluation is how the credentials of a submission fare against the constraints of its presentation definition.
+type Evaluation struct {
+	// Satisfied is true when the credential of each input descriptor satisfies its required constraints.
+	Satisfied        bool                   `json:"satisfied"`
+	InputDescriptors []DescriptorEvaluation `json:"inputDescriptors"`
+}
+
+// DescriptorEvaluation is how the credential submitted for an input descriptor fares against its constraints.
+type DescriptorEvaluation struct {
+	// ID of the input descriptor.
+	ID string `json:"id"`
+	// Satisfied is true when the credential satisfies every field that isn't optional, and the constraints that are
+	// required.
+	Satisfied bool              `json:"satisfied"`
+	Fields    []FieldEvaluation `json:"fields,omitempty"`
+
+	LimitDisclosure *ConstraintEvaluation `json:"limitDisclosure,omitempty"`
+	SubjectIsIssuer *ConstraintEvaluation `json:"subjectIsIssuer,omitempty"`
+
+	// Whether the issuer of the credential is trusted to issue it, which is only evaluated when issuers are checked
+	// against a trust registry, and is always required.
+	TrustedIssuer *ConstraintEvaluation `json:"trustedIssuer,omitempty"`
+}
+
+// FieldEvaluation is how the credential fares against a field of the constraints.
+type FieldEvaluation struct {
+	// ID of the field, when it has one.
+	ID string `json:"id,omitempty"`
+	// Path of the field that the credential has a value at, if any.
+	Path      string              `json:"path,omitempty"`
+	Optional  bool                `json:"optional,omitempty"`
+	Predicate exchange.Preference `json:"predicate,omitempty"`
+	Satisfied bool                `json:"satisfied"`
+	// Reason why the field isn't satisfied.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ConstraintEvaluation is how the credential fares against a constraint that's either required or preferred.
+type ConstraintEvaluation struct {
+	Preference exchange.Preference `json:"preference"`
+	Satisfied  bool                `json:"satisfied"`
+	// Reason why the constraint isn't satisfied.
+	Reason string `json:"reason,omitempty"`
 }
 
 type StoredSubmissions struct {
@@ -50,7 +101,33 @@ type SubmissionStorage interface {
 	StoreSubmission(ctx context.Context, schema StoredSubmission) error
 	GetSubmission(ctx context.Context, id string) (*StoredSubmission, error)
 	ListSubmissions(ctx context.Context, filter filtering.Filter, page common.Page) (*StoredSubmissions, error)
+	CountSubmissions(ctx context.Context, filter filtering.Filter) (int, error)
 	UpdateSubmission(ctx context.Context, id string, approved bool, reason string, submissionID string) (StoredSubmission, opstorage.StoredOperation, error)
+	DeleteSubmission(ctx context.Context, id string) error
 }
 
 var ErrSubmissionNotFound = errors.New("submission not found")
+
+// StoredRequestNonce is the nonce of a presentation request, which the submission responding to the request carries.
+type StoredRequestNonce struct {
+	Nonce        string `json:"nonce"`
+	RequestID    string `json:"requestId"`
+	DefinitionID string `json:"definitionId"`
+	// When the request expires, if it does.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// ID of the submission that responded to the request, once one did.
+	SubmissionID string `json:"submissionId,omitempty"`
+}
+
+type RequestNonceStorage interface {
+	StoreRequestNonce(ctx context.Context, nonce StoredRequestNonce) error
+	// GetRequestNonce returns nil when no request has the nonce.
+	GetRequestNonce(ctx context.Context, nonce string) (*StoredRequestNonce, error)
+	// ConsumeRequestNonce records the submission that responded with the nonce, unless one already did, in which case
+	// it returns false.
+	ConsumeRequestNonce(ctx context.Context, nonce, submissionID string) (bool, error)
+	DeleteRequestNonce(ctx context.Context, nonce string) error
+	// DeleteExpiredRequestNonces deletes the nonces of requests that expired at now, or were responded to, returning how
+	// many were deleted.
+	DeleteExpiredRequestNonces(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,293 @@
+package presentation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/oliveagle/jsonpath"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+)
+
+// ValidatePresentationDefinition lints a presentation definition, without storing it. Besides checking it against the
+// Presentation Exchange schema, it finds the fields that are ignored because they're unknown, paths that aren't valid
+// JSONPath, filters that no value satisfies, and submission requirements that no submission satisfies. Definitions
+// given as requirements are expanded first, and the expanded definition is returned with its issues.
+func (s Service) ValidatePresentationDefinition(_ context.Context, request model.ValidatePresentationDefinitionRequest) (*model.ValidatePresentationDefinitionResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid validate presentation definition request")
+	}
+	if (len(request.PresentationDefinition) > 0) == (len(request.Requirements) > 0) {
+		return nil, sdkutil.LoggingNewError("either a presentation definition or requirements must be given, but not both")
+	}
+
+	var issues []model.DefinitionIssue
+	var definition exchange.PresentationDefinition
+	if len(request.Requirements) > 0 {
+		definition = exchange.PresentationDefinition{
+			ID:               uuid.NewString(),
+			Name:             request.Name,
+			Purpose:          request.Purpose,
+			InputDescriptors: InputDescriptors(request.Requirements),
+		}
+	} else {
+		var value any
+		if err := json.Unmarshal(request.PresentationDefinition, &value); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "unmarshalling presentation definition")
+		}
+		issues = unknownFields("$", value, reflect.TypeOf(definition), issues)
+		if err := json.Unmarshal(request.PresentationDefinition, &definition); err != nil {
+			issues = append(issues, model.DefinitionIssue{Path: "$", Message: fmt.Sprintf("not a presentation definition: %s", err)})
+			return &model.ValidatePresentationDefinitionResponse{Issues: issues}, nil
+		}
+	}
+
+	issues = lintDefinition(definition, issues)
+	return &model.ValidatePresentationDefinitionResponse{
+		Valid:                  len(issues) == 0,
+		Issues:                 issues,
+		PresentationDefinition: &definition,
+	}, nil
+}
+
+// unknownFields finds the members of objects in value that the type they're decoded into doesn't have, and so are
+// dropped when they're decoded.
+func unknownFields(path string, value any, t reflect.Type, issues []model.DefinitionIssue) []model.DefinitionIssue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return issues
+		}
+		fields := jsonFields(t, make(map[string]reflect.Type))
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldType, ok := fields[name]
+			if !ok {
+				issues = append(issues, model.DefinitionIssue{Path: path + "." + name, Message: "unknown field, which is ignored"})
+				continue
+			}
+			issues = unknownFields(path+"."+name, object[name], fieldType, issues)
+		}
+	case reflect.Slice:
+		array, ok := value.([]any)
+		if !ok {
+			return issues
+		}
+		for i, element := range array {
+			issues = unknownFields(fmt.Sprintf("%s[%d]", path, i), element, t.Elem(), issues)
+		}
+	}
+	return issues
+}
+
+// jsonFields maps the JSON names of the fields of a struct to their types, including the fields of embedded structs.
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) map[string]reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			jsonFields(field.Type, fields)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func lintDefinition(definition exchange.PresentationDefinition, issues []model.DefinitionIssue) []model.DefinitionIssue {
+	if err := exchange.IsValidPresentationDefinition(definition); err != nil {
+		issues = append(issues, model.DefinitionIssue{Path: "$", Message: err.Error()})
+	}
+
+	ids := make(map[string]int, len(definition.InputDescriptors))
+	groups := make(map[string]int)
+	for i, descriptor := range definition.InputDescriptors {
+		path := fmt.Sprintf("$.input_descriptors[%d]", i)
+		if first, ok := ids[descriptor.ID]; ok {
+			issues = append(issues, model.DefinitionIssue{
+				Path:    path + ".id",
+				Message: fmt.Sprintf("id<%s> is also the id of $.input_descriptors[%d]", descriptor.ID, first),
+			})
+		} else {
+			ids[descriptor.ID] = i
+		}
+		for _, group := range descriptor.Group {
+			groups[group]++
+		}
+		if descriptor.Constraints != nil {
+			issues = lintConstraints(path+".constraints", *descriptor.Constraints, issues)
+		}
+	}
+
+	for i, requirement := range definition.SubmissionRequirements {
+		issues = lintSubmissionRequirement(fmt.Sprintf("$.submission_requirements[%d]", i), requirement, groups, issues)
+	}
+	return issues
+}
+
+func lintConstraints(path string, constraints exchange.Constraints, issues []model.DefinitionIssue) []model.DefinitionIssue {
+	fieldIDs := make(map[string]bool, len(constraints.Fields))
+	for i, field := range constraints.Fields {
+		fieldPath := fmt.Sprintf("%s.fields[%d]", path, i)
+		if field.ID != "" {
+			fieldIDs[field.ID] = true
+		}
+		for j, p := range field.Path {
+			if _, err := jsonpath.Compile(p); err != nil {
+				issues = append(issues, model.DefinitionIssue{
+					Path:    fmt.Sprintf("%s.path[%d]", fieldPath, j),
+					Message: fmt.Sprintf("path<%s> is not a valid JSONPath: %s", p, err),
+				})
+			}
+		}
+		if field.Filter != nil {
+			issues = lintFilter(fieldPath+".filter", *field.Filter, issues)
+		}
+	}
+
+	relations := map[string][]exchange.RelationalConstraint{"is_holder": constraints.IsHolder, "same_subject": constraints.SameSubject}
+	for _, name := range []string{"is_holder", "same_subject"} {
+		for i, relation := range relations[name] {
+			for j, id := range relation.FieldID {
+				if !fieldIDs[id] {
+					issues = append(issues, model.DefinitionIssue{
+						Path:    fmt.Sprintf("%s.%s[%d].field_id[%d]", path, name, i, j),
+						Message: fmt.Sprintf("no field of the input descriptor has id<%s>", id),
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// lintFilter checks that a filter is a JSON Schema, and that some value satisfies it.
+func lintFilter(path string, filter exchange.Filter, issues []model.DefinitionIssue) []model.DefinitionIssue {
+	filterJSON, err := filter.ToJSON()
+	if err != nil {
+		return append(issues, model.DefinitionIssue{Path: path, Message: fmt.Sprintf("filter can't be marshalled: %s", err)})
+	}
+	schema, err := jsonschema.CompileString("filter.json", filterJSON)
+	if err != nil {
+		return append(issues, model.DefinitionIssue{Path: path, Message: fmt.Sprintf("filter is not a valid JSON Schema: %s", err)})
+	}
+
+	lower, lowerOK := number(filter.Minimum)
+	if exclusive, ok := number(filter.ExclusiveMinimum); ok && (!lowerOK || exclusive >= lower) {
+		lower, lowerOK = exclusive, true
+	}
+	upper, upperOK := number(filter.Maximum)
+	if exclusive, ok := number(filter.ExclusiveMaximum); ok && (!upperOK || exclusive <= upper) {
+		upper, upperOK = exclusive, true
+	}
+	exclusiveBound := filter.ExclusiveMinimum != nil || filter.ExclusiveMaximum != nil
+	if lowerOK && upperOK && (lower > upper || (lower == upper && exclusiveBound)) {
+		issues = append(issues, model.DefinitionIssue{Path: path, Message: "no number is within the filter's minimum and maximum"})
+	}
+	if filter.MaxLength > 0 && filter.MinLength > filter.MaxLength {
+		issues = append(issues, model.DefinitionIssue{
+			Path:    path,
+			Message: fmt.Sprintf("minLength<%d> is greater than maxLength<%d>", filter.MinLength, filter.MaxLength),
+		})
+	}
+
+	// const and enum list the only values the filter is satisfied by, so one of them must satisfy the rest of it
+	if filter.Const != nil {
+		if err = schema.Validate(filter.Const); err != nil {
+			issues = append(issues, model.DefinitionIssue{
+				Path:    path + ".const",
+				Message: fmt.Sprintf("const<%v> doesn't satisfy the rest of the filter: %s", filter.Const, err),
+			})
+		}
+	} else if len(filter.Enum) > 0 {
+		satisfiable := false
+		for _, value := range filter.Enum {
+			if schema.Validate(value) == nil {
+				satisfiable = true
+				break
+			}
+		}
+		if !satisfiable {
+			issues = append(issues, model.DefinitionIssue{Path: path + ".enum", Message: "no value of enum satisfies the rest of the filter"})
+		}
+	}
+	return issues
+}
+
+func number(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// lintSubmissionRequirement checks that the groups a submission requirement picks from have input descriptors, and
+// enough of them to pick.
+func lintSubmissionRequirement(path string, requirement exchange.SubmissionRequirement, groups map[string]int, issues []model.DefinitionIssue) []model.DefinitionIssue {
+	available := len(requirement.FromNested)
+	if requirement.From != "" {
+		available = groups[requirement.From]
+		if available == 0 {
+			issues = append(issues, model.DefinitionIssue{
+				Path:    path + ".from",
+				Message: fmt.Sprintf("no input descriptor is in group<%s>", requirement.From),
+			})
+		}
+	}
+	for i, nested := range requirement.FromNested {
+		issues = lintSubmissionRequirement(fmt.Sprintf("%s.from_nested[%d]", path, i), nested, groups, issues)
+	}
+	if requirement.Rule != exchange.Pick || available == 0 {
+		return issues
+	}
+
+	if requirement.Count > available {
+		issues = append(issues, model.DefinitionIssue{
+			Path:    path + ".count",
+			Message: fmt.Sprintf("count<%d> is more than the %d there are to pick from", requirement.Count, available),
+		})
+	}
+	if requirement.Minimum > available {
+		issues = append(issues, model.DefinitionIssue{
+			Path:    path + ".min",
+			Message: fmt.Sprintf("min<%d> is more than the %d there are to pick from", requirement.Minimum, available),
+		})
+	}
+	if requirement.Maximum > 0 && requirement.Minimum > requirement.Maximum {
+		issues = append(issues, model.DefinitionIssue{
+			Path:    path + ".min",
+			Message: fmt.Sprintf("min<%d> is greater than max<%d>", requirement.Minimum, requirement.Maximum),
+		})
+	}
+	return issues
+}
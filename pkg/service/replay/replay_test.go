@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func signJWT(t *testing.T, key ed25519.PrivateKey, jti string, exp time.Time) keyaccess.JWT {
+	builder := jwt.NewBuilder().Issuer("did:example:alice")
+	if jti != "" {
+		builder = builder.JwtID(jti)
+	}
+	if !exp.IsZero() {
+		builder = builder.Expiration(exp)
+	}
+	token, err := builder.Build()
+	require.NoError(t, err)
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.EdDSA, key))
+	require.NoError(t, err)
+	return keyaccess.JWT(signed)
+}
+
+func TestNewReplayService(t *testing.T) {
+	db := testutil.TestDatabases[0].ServiceStorage(t)
+	_, err := NewReplayService(config.ReplayServiceConfig{TTL: -time.Hour}, db)
+	assert.Error(t, err)
+	_, err = NewReplayService(config.ReplayServiceConfig{}, nil)
+	assert.Error(t, err)
+}
+
+func TestConsume(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			db := test.ServiceStorage(t)
+			s, err := NewReplayService(config.ReplayServiceConfig{TTL: time.Hour}, db)
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Now())
+			s.Clock = mockClock
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			require.NoError(t, err)
+
+			// a jwt is accepted once, by any tenant
+			token := signJWT(t, key, "", time.Time{})
+			id, err := s.ConsumeJWT(ctx, token)
+			require.NoError(t, err)
+			_, err = s.ConsumeJWT(storage.WithTenant(ctx, "acme"), token)
+			assert.ErrorIs(t, err, ErrReplayed)
+
+			// unless it's released
+			require.NoError(t, s.Release(ctx, id))
+			_, err = s.ConsumeJWT(ctx, token)
+			assert.NoError(t, err)
+
+			// jwts with an id are remembered by it, and until they expire, when that's after the ttl
+			_, err = s.ConsumeJWT(ctx, signJWT(t, key, "jti-1", mockClock.Now().Add(2*time.Hour)))
+			require.NoError(t, err)
+			_, err = s.ConsumeJWT(ctx, signJWT(t, key, "jti-1", time.Time{}))
+			assert.ErrorIs(t, err, ErrReplayed)
+			_, err = s.ConsumeJWT(ctx, "not a jwt")
+			assert.ErrorIs(t, err, ErrMalformedJWT)
+
+			// signatures are remembered by their nonce, or value
+			signatureExpiry := mockClock.Now().Add(5 * time.Minute)
+			_, err = s.ConsumeSignature(ctx, "client-key", "nonce-1", []byte("a"), signatureExpiry)
+			require.NoError(t, err)
+			_, err = s.ConsumeSignature(ctx, "client-key", "nonce-1", []byte("b"), signatureExpiry)
+			assert.ErrorIs(t, err, ErrReplayed)
+			_, err = s.ConsumeSignature(ctx, "other-key", "nonce-1", []byte("b"), signatureExpiry)
+			assert.NoError(t, err)
+			_, err = s.ConsumeSignature(ctx, "client-key", "", []byte("c"), signatureExpiry)
+			require.NoError(t, err)
+			_, err = s.ConsumeSignature(ctx, "client-key", "", []byte("c"), signatureExpiry)
+			assert.ErrorIs(t, err, ErrReplayed)
+
+			// what expired is forgotten, and purged
+			mockClock.Add(90 * time.Minute)
+			_, err = s.ConsumeJWT(ctx, token)
+			assert.NoError(t, err)
+			_, err = s.ConsumeJWT(ctx, signJWT(t, key, "jti-1", time.Time{}))
+			assert.ErrorIs(t, err, ErrReplayed)
+			purged, err := s.Purge(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 3, purged)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultTTL is how long accepted JWTs are remembered, unless they expire later, when no TTL is configured.
+	DefaultTTL = 30 * 24 * time.Hour
+	// DefaultPurgeInterval is how often what's no longer remembered is deleted when no interval is configured.
+	DefaultPurgeInterval = time.Hour
+)
+
+var (
+	// ErrReplayed is returned when a JWT or request signature was accepted before.
+	ErrReplayed = errors.New("was already accepted")
+	// ErrMalformedJWT is returned when a JWT can't be parsed, so it can't be remembered.
+	ErrMalformedJWT = errors.New("malformed jwt")
+)
+
+// Service remembers the JWTs and request signatures that were accepted, so that the same signed artifact isn't
+// accepted twice, whichever route, tenant, or instance it's sent to. JWTs are remembered for the TTL, or until they
+// expire, when that's later, and signatures until they're too old to be accepted.
+type Service struct {
+	storage *Storage
+	config  config.ReplayServiceConfig
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Replay
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("replay service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewReplayService creates the replay service, which keeps what was accepted in globalStorage.
+func NewReplayService(cfg config.ReplayServiceConfig, globalStorage storage.ServiceStorage) (*Service, error) {
+	replayStorage, err := NewReplayStorage(globalStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the replay service")
+	}
+	if cfg.TTL < 0 || cfg.PurgeInterval < 0 {
+		return nil, sdkutil.LoggingNewError("ttl and purge_interval cannot be negative")
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.PurgeInterval == 0 {
+		cfg.PurgeInterval = DefaultPurgeInterval
+	}
+	return &Service{storage: replayStorage, config: cfg, Clock: clock.New()}, nil
+}
+
+// ConsumeJWT accepts a JWT, unless it was accepted before, in which case it returns ErrReplayed. JWTs with an ID, the
+// jti claim, are remembered by their issuer and ID, so that the same ID isn't accepted twice even when the rest of the
+// JWT changed, and others by their hash. It returns the ID the JWT is remembered by, for Release.
+func (s Service) ConsumeJWT(ctx context.Context, token keyaccess.JWT) (string, error) {
+	_, parsed, err := util.ParseJWT(token)
+	if err != nil {
+		return "", errors.Wrap(ErrMalformedJWT, err.Error())
+	}
+	var id string
+	if jti := parsed.JwtID(); jti != "" {
+		id = "jwt:" + parsed.Issuer() + " " + jti
+	} else {
+		sum := sha256.Sum256([]byte(token))
+		id = "jwt:" + hex.EncodeToString(sum[:])
+	}
+	now := s.Clock.Now()
+	expiresAt := now.Add(s.config.TTL)
+	if exp := parsed.Expiration(); exp.After(expiresAt) {
+		expiresAt = exp
+	}
+	return id, s.consume(ctx, id, now, expiresAt)
+}
+
+// ConsumeSignature accepts a request signature made with the client key, unless it was accepted before, in which case
+// it returns ErrReplayed. Signatures with a nonce are remembered by their key and nonce, so that a nonce isn't accepted
+// twice, and others by their value. They're remembered until expiresAt, after which they're no longer accepted anyway.
+// It returns the ID the signature is remembered by, for Release.
+func (s Service) ConsumeSignature(ctx context.Context, keyID, nonce string, value []byte, expiresAt time.Time) (string, error) {
+	id := "signature:" + keyID + " nonce " + nonce
+	if nonce == "" {
+		id = "signature:" + keyID + " " + hex.EncodeToString(value)
+	}
+	return id, s.consume(ctx, id, s.Clock.Now(), expiresAt)
+}
+
+func (s Service) consume(ctx context.Context, id string, now, expiresAt time.Time) error {
+	stored, err := s.storage.StoreConsumed(ctx, id, Consumed{ConsumedAt: now, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// Release forgets a JWT or signature that was consumed, by the ID it's remembered by, so that it can be accepted again.
+// It's meant for requests that failed before what they carried was accepted.
+func (s Service) Release(ctx context.Context, id string) error {
+	return s.storage.DeleteConsumed(ctx, id)
+}
+
+// Purge deletes the JWTs and signatures that are no longer remembered, returning how many were deleted.
+func (s Service) Purge(ctx context.Context) (int, error) {
+	return s.storage.DeleteExpired(ctx, s.Clock.Now())
+}
+
+// RunSchedule purges every purge interval, until ctx is done. Instances sharing the storage may purge at the same
+// time, which is harmless.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.config.PurgeInterval, func() {
+		purged, err := s.Purge(context.Background())
+		if err != nil {
+			logrus.WithError(err).Error("purging consumed jwts and signatures")
+			return
+		}
+		logrus.Debugf("purged %d consumed jwts and signatures", purged)
+	})
+}
@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// consumedNamespace holds the IDs of the JWTs and request signatures that were accepted, keyed by the hash of the ID,
+// in the storage of the deployment, so that they're shared by all tenants and instances.
+const consumedNamespace = "replay-consumed"
+
+// Consumed records that a JWT or request signature was accepted.
+type Consumed struct {
+	ConsumedAt time.Time `json:"consumedAt"`
+
+	// When the ID is forgotten, and could be accepted again. JWTs and signatures are expired by then.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewReplayStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// consumedKey hashes id, so that IDs of any length and content can be stored.
+func consumedKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// StoreConsumed stores that the ID was consumed, unless it was consumed before and hasn't expired at now, in which case
+// it returns false. The check and the write are made in a transaction that watches the ID, so that an ID consumed by
+// two instances at once is only consumed by one of them.
+func (s *Storage) StoreConsumed(ctx context.Context, id string, consumed Consumed) (bool, error) {
+	key := consumedKey(id)
+	watchKeys := []storage.WatchKey{{Namespace: consumedNamespace, Key: key}}
+	stored, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		previousBytes, err := s.db.Read(ctx, consumedNamespace, key)
+		if err != nil {
+			return false, err
+		}
+		if len(previousBytes) > 0 {
+			var previous Consumed
+			if err = json.Unmarshal(previousBytes, &previous); err != nil {
+				return false, errors.Wrap(err, "unmarshalling consumed id")
+			}
+			if consumed.ConsumedAt.Before(previous.ExpiresAt) {
+				return false, nil
+			}
+		}
+		consumedBytes, err := json.Marshal(consumed)
+		if err != nil {
+			return false, errors.Wrap(err, "marshalling consumed id")
+		}
+		return true, tx.Write(ctx, consumedNamespace, key, consumedBytes)
+	}, watchKeys)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsg(err, "could not store consumed id")
+	}
+	return stored.(bool), nil
+}
+
+func (s *Storage) DeleteConsumed(ctx context.Context, id string) error {
+	if err := s.db.Delete(ctx, consumedNamespace, consumedKey(id)); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not delete consumed id")
+	}
+	return nil
+}
+
+// DeleteExpired deletes the IDs that expired at now, returning how many were deleted.
+func (s *Storage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	var expired []string
+	err := s.db.Iterate(ctx, consumedNamespace, func(key string, consumedBytes []byte) (bool, error) {
+		var consumed Consumed
+		if err := json.Unmarshal(consumedBytes, &consumed); err != nil {
+			logrus.WithError(err).Warnf("unmarshal consumed id: %s", key)
+			return true, nil
+		}
+		if !now.Before(consumed.ExpiresAt) {
+			expired = append(expired, key)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "could not list consumed ids")
+	}
+	for _, key := range expired {
+		if err = s.db.Delete(ctx, consumedNamespace, key); err != nil {
+			return 0, sdkutil.LoggingErrorMsgf(err, "could not delete expired consumed id: %s", key)
+		}
+	}
+	return len(expired), nil
+}
@@ -0,0 +1,126 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// ObjectType is a type of object retention rules apply to.
+type ObjectType string
+
+const (
+	TypeApplication ObjectType = "application"
+	TypeResponse    ObjectType = "response"
+	TypeSubmission  ObjectType = "submission"
+)
+
+// ObjectTypes are all the types of objects retention rules apply to.
+var ObjectTypes = []ObjectType{TypeApplication, TypeResponse, TypeSubmission}
+
+// statuses are the statuses objects of each type can be in. Responses have none.
+var statuses = map[ObjectType][]string{
+	TypeApplication: {"pending", "fulfilled", "rejected", "cancelled"},
+	TypeResponse:    nil,
+	TypeSubmission:  {"pending", "approved", "denied", "cancelled"},
+}
+
+// IsValid returns whether the type is one of ObjectTypes.
+func (t ObjectType) IsValid() bool {
+	_, ok := statuses[t]
+	return ok
+}
+
+// hasStatus returns whether objects of the type can be in the status.
+func (t ObjectType) hasStatus(status string) bool {
+	for _, s := range statuses[t] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Object is an object retention rules apply to.
+type Object struct {
+	// Tenant the object belongs to. Empty for the default tenant.
+	Tenant string     `json:"tenant,omitempty"`
+	Type   ObjectType `json:"type"`
+	ID     string     `json:"id"`
+	Status string     `json:"status,omitempty"`
+
+	// When the retention job first saw the object in its status, which is when its retention started.
+	Since time.Time `json:"since"`
+}
+
+// key identifies the object within its tenant.
+func (o Object) key() string {
+	return storage.Join(string(o.Type), o.ID)
+}
+
+// Hold is a legal hold, which keeps objects from being deleted until it's lifted, however long they've been kept.
+type Hold struct {
+	ID string `json:"id"`
+
+	// Tenant of the objects that are held. Empty for the default tenant.
+	Tenant string     `json:"tenant,omitempty"`
+	Type   ObjectType `json:"type"`
+
+	// ID of the object that is held. Every object of the type is held when it's empty.
+	ObjectID string `json:"objectId,omitempty"`
+
+	// Why the objects are held, e.g. the case they're held for.
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// holds returns whether the hold applies to an object.
+func (h Hold) holds(object Object) bool {
+	return h.Tenant == object.Tenant && h.Type == object.Type && (h.ObjectID == "" || h.ObjectID == object.ID)
+}
+
+type CreateHoldRequest struct {
+	Tenant   string     `json:"tenant,omitempty"`
+	Type     ObjectType `json:"type" validate:"required"`
+	ObjectID string     `json:"objectId,omitempty"`
+	Reason   string     `json:"reason" validate:"required"`
+}
+
+type ListHoldsResponse struct {
+	Holds []Hold `json:"holds"`
+}
+
+// Run is what a run of the retention job deleted, and kept.
+type Run struct {
+	StartedAt time.Time `json:"startedAt"`
+
+	// Whether the run is a dry run, which deleted nothing, so that Deleted lists what would have been deleted.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Objects that were kept as long as their rule says, and were deleted.
+	Deleted []Object `json:"deleted"`
+
+	// Objects that were kept as long as their rule says, but are held, so weren't deleted.
+	Held []Object `json:"held"`
+
+	// Name of the export of the deleted objects at the export destination. Empty when nothing was deleted, or there's
+	// no export destination.
+	Export string `json:"export,omitempty"`
+}
+
+// ExportVersion is the version of the format of exports.
+const ExportVersion = 1
+
+// Export holds the objects a run of the retention job deleted, as they were stored.
+type Export struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Objects    []ExportedObject `json:"objects"`
+}
+
+type ExportedObject struct {
+	Object
+	Data json.RawMessage `json:"data"`
+}
@@ -0,0 +1,206 @@
+package retention
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/exchange"
+	"github.com/TBD54566975/ssi-sdk/credential/manifest"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	prestorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestKeepFor(t *testing.T) {
+	t.Run("rejects invalid rules", func(tt *testing.T) {
+		for name, rules := range map[string][]config.RetentionRuleConfig{
+			"unknown type":        {{Type: "credential", KeepDays: 30}},
+			"unknown status":      {{Type: "submission", Status: "rejected", KeepDays: 30}},
+			"status of responses": {{Type: "response", Status: "pending", KeepDays: 30}},
+			"no days":             {{Type: "application", KeepDays: 0}},
+			"duplicate":           {{Type: "application", Status: "rejected", KeepDays: 30}, {Type: "application", Status: "rejected", KeepDays: 60}},
+		} {
+			_, err := keepFor(rules)
+			assert.Error(tt, err, name)
+		}
+	})
+
+	t.Run("rules for a status come first", func(tt *testing.T) {
+		rules, err := keepFor([]config.RetentionRuleConfig{
+			{Type: "application", KeepDays: 365},
+			{Type: "application", Status: "rejected", KeepDays: 90},
+		})
+		require.NoError(tt, err)
+		s := Service{rules: rules}
+
+		keep, ok := s.keepFor(TypeApplication, "rejected")
+		assert.True(tt, ok)
+		assert.Equal(tt, 90*24*time.Hour, keep)
+		keep, ok = s.keepFor(TypeApplication, "fulfilled")
+		assert.True(tt, ok)
+		assert.Equal(tt, 365*24*time.Hour, keep)
+		_, ok = s.keepFor(TypeSubmission, "denied")
+		assert.False(tt, ok)
+		assert.True(tt, s.hasRules(TypeApplication))
+		assert.False(tt, s.hasRules(TypeResponse))
+	})
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			globalStorage := test.ServiceStorage(t)
+			tenantStorage := storage.NewTenantWrapper(globalStorage)
+			exportDir := t.TempDir()
+			s, err := NewRetentionService(config.RetentionServiceConfig{
+				Rules: []config.RetentionRuleConfig{
+					{Type: "application", Status: "rejected", KeepDays: 90},
+					{Type: "submission", KeepDays: 365},
+				},
+				ExportDestination: "file://" + exportDir,
+				Tenants:           []string{"acme"},
+			}, globalStorage, tenantStorage)
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+			s.Clock = mockClock
+
+			manifestStorage, err := manifeststg.NewManifestStorage(tenantStorage)
+			require.NoError(t, err)
+			storeApplication := func(id string, status opcredential.Status) {
+				require.NoError(t, manifestStorage.StoreApplication(ctx, manifeststg.StoredApplication{
+					ID:          id,
+					Status:      status,
+					Application: manifest.CredentialApplication{ID: id},
+				}))
+			}
+			storeApplication("app-1", opcredential.StatusRejected)
+			storeApplication("app-2", opcredential.StatusPending)
+			storeApplication("app-3", opcredential.StatusRejected)
+			submissionStorage, err := presentation.NewPresentationStorage(tenantStorage)
+			require.NoError(t, err)
+			acmeCtx := storage.WithTenant(ctx, "acme")
+			require.NoError(t, submissionStorage.StoreSubmission(acmeCtx, prestorage.StoredSubmission{
+				Status: submission.StatusDenied,
+				VerifiablePresentation: credential.VerifiablePresentation{
+					PresentationSubmission: exchange.PresentationSubmission{ID: "sub-1"},
+				},
+			}))
+
+			// retention starts when objects are first seen, so nothing is deleted yet
+			run, err := s.Run(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, run.Deleted)
+			assert.Empty(t, run.Held)
+
+			// app-2 is rejected later, so its retention starts over then
+			mockClock.Add(30 * 24 * time.Hour)
+			storeApplication("app-2", opcredential.StatusRejected)
+			_, err = s.Run(ctx)
+			require.NoError(t, err)
+
+			hold, err := s.CreateHold(ctx, CreateHoldRequest{Type: TypeApplication, ObjectID: "app-3", Reason: "case 42"})
+			require.NoError(t, err)
+			mockClock.Add(60 * 24 * time.Hour)
+
+			// a dry run lists what would be deleted, but deletes and exports nothing
+			run, err = s.DryRun(ctx)
+			require.NoError(t, err)
+			assert.True(t, run.DryRun)
+			require.Len(t, run.Deleted, 1)
+			assert.Equal(t, "app-1", run.Deleted[0].ID)
+			require.Len(t, run.Held, 1)
+			assert.Empty(t, run.Export)
+			_, err = manifestStorage.GetApplication(ctx, "app-1")
+			assert.NoError(t, err)
+
+			run, err = s.Run(ctx)
+			require.NoError(t, err)
+			require.Len(t, run.Deleted, 1)
+			assert.Equal(t, "app-1", run.Deleted[0].ID)
+			assert.Equal(t, "rejected", run.Deleted[0].Status)
+			require.Len(t, run.Held, 1)
+			assert.Equal(t, "app-3", run.Held[0].ID)
+			application, err := manifestStorage.GetApplication(ctx, "app-1")
+			assert.Error(t, err)
+			assert.Nil(t, application)
+			_, err = manifestStorage.GetApplication(ctx, "app-2")
+			assert.NoError(t, err)
+
+			// deleted objects are exported as they were stored
+			require.NotEmpty(t, run.Export)
+			exportBytes, err := os.ReadFile(filepath.Join(exportDir, run.Export))
+			require.NoError(t, err)
+			var export Export
+			require.NoError(t, json.Unmarshal(exportBytes, &export))
+			assert.Equal(t, ExportVersion, export.Version)
+			require.Len(t, export.Objects, 1)
+			var exported manifeststg.StoredApplication
+			require.NoError(t, json.Unmarshal(export.Objects[0].Data, &exported))
+			assert.Equal(t, "app-1", exported.Application.ID)
+
+			// once the hold is lifted, and a year went by, every tenant's objects past their retention are deleted
+			require.NoError(t, s.LiftHold(ctx, hold.ID))
+			assert.ErrorIs(t, s.LiftHold(ctx, hold.ID), ErrHoldNotFound)
+			mockClock.Add(275 * 24 * time.Hour)
+			run, err = s.Run(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, run.Held)
+			deleted := make(map[string]string)
+			for _, object := range run.Deleted {
+				deleted[object.ID] = object.Tenant
+			}
+			assert.Equal(t, map[string]string{"app-2": "", "app-3": "", "sub-1": "acme"}, deleted)
+			stored, err := submissionStorage.GetSubmission(acmeCtx, "sub-1")
+			assert.Error(t, err)
+			assert.Nil(t, stored)
+
+			seen, err := s.storage.ListObjects(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, seen)
+		})
+	}
+}
+
+func TestListHolds(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			globalStorage := test.ServiceStorage(t)
+			s, err := NewRetentionService(config.RetentionServiceConfig{}, globalStorage, storage.NewTenantWrapper(globalStorage))
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			s.Clock = mockClock
+
+			_, err = s.CreateHold(ctx, CreateHoldRequest{Type: "credential", Reason: "case 42"})
+			assert.Error(t, err)
+			_, err = s.CreateHold(ctx, CreateHoldRequest{Type: TypeSubmission})
+			assert.Error(t, err)
+
+			first, err := s.CreateHold(ctx, CreateHoldRequest{Tenant: "acme", Type: TypeSubmission, Reason: "case 42"})
+			require.NoError(t, err)
+			mockClock.Add(time.Minute)
+			second, err := s.CreateHold(ctx, CreateHoldRequest{Type: TypeApplication, ObjectID: "app-1", Reason: "case 43"})
+			require.NoError(t, err)
+
+			holds, err := s.ListHolds(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []Hold{*first, *second}, holds.Holds)
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.einride.tech/aip/filtering"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/backup"
+	"github.com/tbd54566975/ssi-service/pkg/service/common"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/operation/submission"
+	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	presmodel "github.com/tbd54566975/ssi-service/pkg/service/presentation/model"
+	prestorage "github.com/tbd54566975/ssi-service/pkg/service/presentation/storage"
+	"github.com/tbd54566975/ssi-service/pkg/service/schedule"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// DefaultInterval is how often the retention job runs when no interval is configured.
+	DefaultInterval = 24 * time.Hour
+
+	exportPrefix = "ssi-service-retention-"
+	exportLayout = "20060102T150405Z"
+	exportSuffix = ".json"
+)
+
+// ErrHoldNotFound is returned when no legal hold exists with the requested ID.
+var ErrHoldNotFound = errors.New("hold not found")
+
+// Service deletes credential applications, credential responses, and presentation submissions once they've been kept
+// as long as a retention rule says, unless a legal hold keeps them. None of these record when they reached their
+// status, so retention starts when the retention job first sees an object in its status, and starts over when the
+// status changes. Deleted objects are exported first, when there's an export destination.
+type Service struct {
+	storage     *Storage
+	manifests   *manifeststg.Storage
+	submissions prestorage.Storage
+	operations  *operation.Storage
+	destination backup.Destination
+	rules       map[string]time.Duration
+	tenants     []string
+	interval    time.Duration
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Retention
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.manifests == nil {
+		ae.AppendString("no manifest storage configured")
+	}
+	if s.submissions == nil {
+		ae.AppendString("no submission storage configured")
+	}
+	if s.operations == nil {
+		ae.AppendString("no operation storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("retention service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewRetentionService creates the retention service. Legal holds, and which scheduled runs were made, are kept in
+// globalStorage, while objects are deleted from the tenants of tenantStorage.
+func NewRetentionService(cfg config.RetentionServiceConfig, globalStorage, tenantStorage storage.ServiceStorage) (*Service, error) {
+	retentionStorage, err := NewRetentionStorage(globalStorage, tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the retention service")
+	}
+	manifestStorage, err := manifeststg.NewManifestStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate manifest storage for the retention service")
+	}
+	submissionStorage, err := presentation.NewPresentationStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate submission storage for the retention service")
+	}
+	operationStorage, err := operation.NewOperationStorage(tenantStorage)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate operation storage for the retention service")
+	}
+	rules, err := keepFor(cfg.Rules)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid retention rules")
+	}
+	for _, tenant := range cfg.Tenants {
+		if !storage.IsValidTenantID(tenant) {
+			return nil, sdkutil.LoggingNewErrorf("invalid tenant: %s", tenant)
+		}
+	}
+	var destination backup.Destination
+	if cfg.ExportDestination != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if destination, err = backup.NewDestination(ctx, cfg.ExportDestination, cfg.CredentialsPath); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not create export destination")
+		}
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	service := Service{
+		storage:     retentionStorage,
+		manifests:   manifestStorage,
+		submissions: submissionStorage,
+		operations:  operationStorage,
+		destination: destination,
+		rules:       rules,
+		tenants:     cfg.Tenants,
+		interval:    interval,
+		Clock:       clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// keepFor validates the rules, and returns how long objects are kept, keyed by type and status. Rules for every status
+// of a type have an empty status.
+func keepFor(rules []config.RetentionRuleConfig) (map[string]time.Duration, error) {
+	keep := make(map[string]time.Duration, len(rules))
+	for _, rule := range rules {
+		objectType := ObjectType(rule.Type)
+		if !objectType.IsValid() {
+			return nil, errors.Errorf("unknown type of objects: %s", rule.Type)
+		}
+		if rule.Status != "" && !objectType.hasStatus(rule.Status) {
+			return nil, errors.Errorf("%s objects can't be %s", rule.Type, rule.Status)
+		}
+		if rule.KeepDays < 1 {
+			return nil, errors.Errorf("%s objects must be kept at least a day, not %d", rule.Type, rule.KeepDays)
+		}
+		key := storage.Join(rule.Type, rule.Status)
+		if _, ok := keep[key]; ok {
+			return nil, errors.Errorf("more than one rule for %s objects with status %q", rule.Type, rule.Status)
+		}
+		keep[key] = time.Duration(rule.KeepDays) * 24 * time.Hour
+	}
+	return keep, nil
+}
+
+// keepFor returns how long objects of a type in a status are kept, and whether any rule says so. A rule for the status
+// comes before a rule for every status.
+func (s Service) keepFor(objectType ObjectType, status string) (time.Duration, bool) {
+	if keep, ok := s.rules[storage.Join(string(objectType), status)]; ok && status != "" {
+		return keep, true
+	}
+	keep, ok := s.rules[storage.Join(string(objectType), "")]
+	return keep, ok
+}
+
+// hasRules returns whether any rule applies to objects of the type.
+func (s Service) hasRules(objectType ObjectType) bool {
+	if _, ok := s.keepFor(objectType, ""); ok {
+		return true
+	}
+	for _, status := range statuses[objectType] {
+		if _, ok := s.keepFor(objectType, status); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// storedObject is an object as it's stored, with the ID of the operation that created or reviewed it, if any.
+type storedObject struct {
+	ExportedObject
+	operationID string
+}
+
+// listObjects returns the objects of a type in the tenant of ctx.
+func (s Service) listObjects(ctx context.Context, objectType ObjectType) ([]storedObject, error) {
+	var objects []storedObject
+	add := func(id, status, operationID string, data any) error {
+		dataBytes, err := json.Marshal(data)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling %s: %s", objectType, id)
+		}
+		objects = append(objects, storedObject{
+			ExportedObject: ExportedObject{
+				Object: Object{Tenant: storage.TenantFromContext(ctx), Type: objectType, ID: id, Status: status},
+				Data:   dataBytes,
+			},
+			operationID: operationID,
+		})
+		return nil
+	}
+	switch objectType {
+	case TypeApplication:
+		applications, err := s.manifests.ListApplications(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, application := range applications {
+			// applications are stored under the ID of the credential application they hold
+			id := application.Application.ID
+			if err = add(id, application.Status.String(), opcredential.IDFromResponseID(id), application); err != nil {
+				return nil, err
+			}
+		}
+	case TypeResponse:
+		responses, err := s.manifests.ListResponses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, response := range responses {
+			if err = add(response.Response.ID, "", "", response); err != nil {
+				return nil, err
+			}
+		}
+	case TypeSubmission:
+		submissions, err := s.submissions.ListSubmissions(ctx, filtering.Filter{}, common.Page{Size: -1})
+		if err != nil {
+			return nil, err
+		}
+		for _, stored := range submissions.Submissions {
+			stored := stored
+			ps := presmodel.ServiceModel(&stored).GetSubmission()
+			if ps == nil || ps.ID == "" {
+				continue
+			}
+			if err = add(ps.ID, stored.Status.String(), submission.IDFromSubmissionID(ps.ID), stored); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return objects, nil
+}
+
+// deleteObject deletes an object from the tenant of ctx, along with its operation.
+func (s Service) deleteObject(ctx context.Context, object storedObject) error {
+	var err error
+	switch object.Type {
+	case TypeApplication:
+		err = s.manifests.DeleteApplication(ctx, object.ID)
+	case TypeResponse:
+		err = s.manifests.DeleteResponse(ctx, object.ID)
+	case TypeSubmission:
+		err = s.submissions.DeleteSubmission(ctx, object.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "deleting %s: %s", object.Type, object.ID)
+	}
+	if object.operationID != "" {
+		if _, err = s.operations.DeleteOperationIfExists(ctx, object.operationID); err != nil {
+			return errors.Wrapf(err, "deleting operation: %s", object.operationID)
+		}
+	}
+	return s.storage.DeleteObject(ctx, object.key())
+}
+
+// Run deletes the objects of the default tenant, and of the configured tenants, that were kept as long as their rule
+// says and aren't held. They're exported first, when there's an export destination, and nothing is deleted when they
+// can't be.
+func (s Service) Run(ctx context.Context) (*Run, error) {
+	return s.run(ctx, false)
+}
+
+// DryRun returns what Run would delete, and keep, now, without deleting or exporting anything. Objects it sees for the
+// first time, or in a new status, don't start their retention, which only a run does.
+func (s Service) DryRun(ctx context.Context) (*Run, error) {
+	return s.run(ctx, true)
+}
+
+func (s Service) run(ctx context.Context, dryRun bool) (*Run, error) {
+	now := s.Clock.Now().UTC()
+	holds, err := s.storage.ListHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run := Run{StartedAt: now, DryRun: dryRun, Deleted: make([]Object, 0), Held: make([]Object, 0)}
+	var due []storedObject
+	for _, tenantID := range append([]string{""}, s.tenants...) {
+		tenantCtx := storage.WithTenant(ctx, tenantID)
+		seen, err := s.storage.ListObjects(tenantCtx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing objects seen in tenant %q", tenantID)
+		}
+		current := make(map[string]bool, len(seen))
+		for _, objectType := range ObjectTypes {
+			if !s.hasRules(objectType) {
+				continue
+			}
+			objects, err := s.listObjects(tenantCtx, objectType)
+			if err != nil {
+				return nil, errors.Wrapf(err, "listing %s objects of tenant %q", objectType, tenantID)
+			}
+			for _, object := range objects {
+				key := object.key()
+				current[key] = true
+				if previous, ok := seen[key]; ok && previous.Status == object.Status {
+					object.Since = previous.Since
+				} else {
+					object.Since = now
+					if !dryRun {
+						if err = s.storage.StoreObject(tenantCtx, object.Object); err != nil {
+							return nil, err
+						}
+					}
+				}
+				keep, ok := s.keepFor(object.Type, object.Status)
+				if !ok || now.Before(object.Since.Add(keep)) {
+					continue
+				}
+				if isHeld(holds, object.Object) {
+					run.Held = append(run.Held, object.Object)
+					continue
+				}
+				due = append(due, object)
+			}
+		}
+		// objects that are gone, or that no rule applies to anymore, start over if they're seen again
+		if dryRun {
+			continue
+		}
+		for key := range seen {
+			if !current[key] {
+				if err = s.storage.DeleteObject(tenantCtx, key); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if dryRun {
+		for _, object := range due {
+			run.Deleted = append(run.Deleted, object.Object)
+		}
+		return &run, nil
+	}
+	if len(due) == 0 {
+		return &run, nil
+	}
+
+	if s.destination != nil {
+		if run.Export, err = s.export(ctx, now, due); err != nil {
+			return nil, err
+		}
+	}
+	for _, object := range due {
+		if err = s.deleteObject(storage.WithTenant(ctx, object.Tenant), object); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not delete %d objects past their retention", len(due)-len(run.Deleted))
+		}
+		run.Deleted = append(run.Deleted, object.Object)
+	}
+	return &run, nil
+}
+
+func isHeld(holds []Hold, object Object) bool {
+	for _, hold := range holds {
+		if hold.holds(object) {
+			return true
+		}
+	}
+	return false
+}
+
+// export writes the objects to the export destination, returning the name of the export.
+func (s Service) export(ctx context.Context, now time.Time, objects []storedObject) (string, error) {
+	export := Export{Version: ExportVersion, ExportedAt: now, Objects: make([]ExportedObject, 0, len(objects))}
+	for _, object := range objects {
+		export.Objects = append(export.Objects, object.ExportedObject)
+	}
+	exportBytes, err := json.Marshal(export)
+	if err != nil {
+		return "", sdkutil.LoggingErrorMsg(err, "could not marshal export")
+	}
+	name := exportPrefix + now.Format(exportLayout) + exportSuffix
+	if err = s.destination.Put(ctx, name, exportBytes); err != nil {
+		return "", sdkutil.LoggingErrorMsgf(err, "could not write export: %s", name)
+	}
+	return name, nil
+}
+
+// RunSchedule runs the retention job every interval, until ctx is done. Instances sharing the storage take turns, so
+// that each scheduled run is made once. A run in progress when ctx is done is completed before returning.
+func (s Service) RunSchedule(ctx context.Context) {
+	schedule.Every(ctx, s.Clock, s.interval, s.runScheduled)
+}
+
+func (s Service) runScheduled() {
+	ctx := context.Background()
+	claimed, err := schedule.Claim(ctx, s.storage.global, scheduleNamespace, scheduledKey, s.Clock.Now(), s.interval)
+	if err != nil {
+		logrus.WithError(err).Error("claiming scheduled retention run")
+		return
+	}
+	if !claimed {
+		return
+	}
+	run, err := s.Run(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("running scheduled retention")
+		return
+	}
+	logrus.WithField("export", run.Export).Infof("deleted %d objects past their retention, and kept %d held ones", len(run.Deleted), len(run.Held))
+}
+
+// CreateHold places a legal hold on an object, or on every object of a type, of a tenant.
+func (s Service) CreateHold(ctx context.Context, request CreateHoldRequest) (*Hold, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+	if !request.Type.IsValid() {
+		return nil, errors.Errorf("unknown type of objects: %s", request.Type)
+	}
+	if request.Tenant != "" && !storage.IsValidTenantID(request.Tenant) {
+		return nil, errors.Errorf("invalid tenant: %s", request.Tenant)
+	}
+	hold := Hold{
+		ID:        uuid.NewString(),
+		Tenant:    request.Tenant,
+		Type:      request.Type,
+		ObjectID:  request.ObjectID,
+		Reason:    request.Reason,
+		CreatedAt: s.Clock.Now().UTC(),
+	}
+	if err := s.storage.StoreHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// ListHolds returns the legal holds, oldest first.
+func (s Service) ListHolds(ctx context.Context) (*ListHoldsResponse, error) {
+	holds, err := s.storage.ListHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(holds, func(i, j int) bool { return holds[i].CreatedAt.Before(holds[j].CreatedAt) })
+	return &ListHoldsResponse{Holds: holds}, nil
+}
+
+// LiftHold lifts a legal hold, so that the objects it held are deleted by the next run once they're past their
+// retention.
+func (s Service) LiftHold(ctx context.Context, id string) error {
+	hold, err := s.storage.GetHold(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hold == nil {
+		return ErrHoldNotFound
+	}
+	return s.storage.DeleteHold(ctx, id)
+}
@@ -0,0 +1,115 @@
+package retention
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// holdNamespace holds the legal holds, keyed by ID, in the storage of the deployment.
+	holdNamespace = "retention-hold"
+	// scheduleNamespace holds when the last scheduled run was claimed, in the storage of the deployment.
+	scheduleNamespace = "retention"
+	scheduledKey      = "scheduled"
+	// objectNamespace holds the objects the retention job saw, keyed by Object.key, in the storage of each tenant.
+	objectNamespace = "retention-object"
+)
+
+type Storage struct {
+	global storage.ServiceStorage
+	tenant storage.ServiceStorage
+}
+
+func NewRetentionStorage(global, tenant storage.ServiceStorage) (*Storage, error) {
+	if global == nil || tenant == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{global: global, tenant: tenant}, nil
+}
+
+func (s *Storage) StoreHold(ctx context.Context, hold Hold) error {
+	holdBytes, err := json.Marshal(hold)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not marshal hold: %s", hold.ID)
+	}
+	return s.global.Write(ctx, holdNamespace, hold.ID, holdBytes)
+}
+
+// GetHold returns the hold with the ID, or nil when there is none.
+func (s *Storage) GetHold(ctx context.Context, id string) (*Hold, error) {
+	holdBytes, err := s.global.Read(ctx, holdNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get hold: %s", id)
+	}
+	if len(holdBytes) == 0 {
+		return nil, nil
+	}
+	var hold Hold
+	if err = json.Unmarshal(holdBytes, &hold); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling hold: %s", id)
+	}
+	return &hold, nil
+}
+
+func (s *Storage) ListHolds(ctx context.Context) ([]Hold, error) {
+	var holds []Hold
+	err := s.global.Iterate(ctx, holdNamespace, func(key string, holdBytes []byte) (bool, error) {
+		var hold Hold
+		if err := json.Unmarshal(holdBytes, &hold); err != nil {
+			logrus.WithError(err).Warnf("unmarshal hold: %s", key)
+			return true, nil
+		}
+		holds = append(holds, hold)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list holds")
+	}
+	return holds, nil
+}
+
+func (s *Storage) DeleteHold(ctx context.Context, id string) error {
+	if err := s.global.Delete(ctx, holdNamespace, id); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete hold: %s", id)
+	}
+	return nil
+}
+
+// ListObjects returns the objects the retention job saw in the tenant of ctx, keyed by Object.key.
+func (s *Storage) ListObjects(ctx context.Context) (map[string]Object, error) {
+	objects := make(map[string]Object)
+	err := s.tenant.Iterate(ctx, objectNamespace, func(key string, objectBytes []byte) (bool, error) {
+		var object Object
+		if err := json.Unmarshal(objectBytes, &object); err != nil {
+			logrus.WithError(err).Warnf("unmarshal retention object: %s", key)
+			return true, nil
+		}
+		objects[key] = object
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list retention objects")
+	}
+	return objects, nil
+}
+
+func (s *Storage) StoreObject(ctx context.Context, object Object) error {
+	objectBytes, err := json.Marshal(object)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not marshal retention object: %s", object.key())
+	}
+	return s.tenant.Write(ctx, objectNamespace, object.key(), objectBytes)
+}
+
+func (s *Storage) DeleteObject(ctx context.Context, key string) error {
+	if err := s.tenant.Delete(ctx, objectNamespace, key); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete retention object: %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Package schedule is shared by the services that run jobs in the background, every interval.
+package schedule
+
+import (
+	"context"
+	"time"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Every calls run every interval of c, until ctx is done. Runs aren't passed ctx, so that a run in progress when ctx is
+// done is completed before Every returns.
+func Every(ctx context.Context, c clock.Clock, interval time.Duration, run func()) {
+	ticker := c.Ticker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// Claim returns whether the scheduled run of a job at now should be made by the caller, which is the case unless
+// another instance sharing the storage claimed one less than half an interval ago. The time of the last claim is kept
+// at key of namespace, and the claim is made in a transaction that watches it, so that only one instance makes each
+// scheduled run.
+func Claim(ctx context.Context, db storage.ServiceStorage, namespace, key string, now time.Time, interval time.Duration) (bool, error) {
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: key}}
+	claimed, err := db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		lastBytes, err := db.Read(ctx, namespace, key)
+		if err != nil {
+			return false, err
+		}
+		if len(lastBytes) > 0 {
+			var last time.Time
+			if err = last.UnmarshalText(lastBytes); err != nil {
+				return false, errors.Wrap(err, "unmarshalling time of the last scheduled run")
+			}
+			if now.Sub(last) < interval/2 {
+				return false, nil
+			}
+		}
+		nowBytes, err := now.MarshalText()
+		if err != nil {
+			return false, errors.Wrap(err, "marshalling time of the scheduled run")
+		}
+		return true, tx.Write(ctx, namespace, key, nowBytes)
+	}, watchKeys)
+	if err != nil {
+		return false, sdkutil.LoggingErrorMsgf(err, "could not claim scheduled run: %s", namespace)
+	}
+	return claimed.(bool), nil
+}
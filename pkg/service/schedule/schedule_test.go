@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestEvery(t *testing.T) {
+	mock := clock.NewMock()
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		Every(ctx, mock, time.Hour, func() { runs <- struct{}{} })
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		// the ticker may not be set up yet, so time is moved on until it ticks
+		assert.Eventually(t, func() bool {
+			mock.Add(time.Hour)
+			select {
+			case <-runs:
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			claim := func(now time.Time) bool {
+				claimed, err := Claim(ctx, db, "job", "scheduled", now, 24*time.Hour)
+				require.NoError(t, err)
+				return claimed
+			}
+
+			// instances ticking at about the same time make the scheduled run once
+			now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+			assert.True(t, claim(now))
+			assert.False(t, claim(now.Add(time.Minute)))
+
+			assert.True(t, claim(now.Add(23*time.Hour)))
+
+			// jobs are claimed separately
+			claimed, err := Claim(ctx, db, "other-job", "scheduled", now.Add(23*time.Hour), 24*time.Hour)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+		})
+	}
+}
@@ -13,6 +13,11 @@ type CreateSchemaRequest struct {
 	Description string            `json:"description,omitempty"`
 	Schema      schema.JSONSchema `json:"schema" validate:"required"`
 
+	// Version of the schema, as MAJOR.MINOR.PATCH. When a schema with the name exists, the schema is its next
+	// version, which must be greater than its latest, and defaults to the next minor version. Otherwise, it defaults
+	// to InitialVersion.
+	Version string `json:"version,omitempty"`
+
 	// If both are present the schema will be signed by the issuer's private key with the specified KID
 	Issuer                             string `json:"issuer,omitempty"`
 	FullyQualifiedVerificationMethodID string `json:"fullyQualifiedVerificationMethodId,omitempty"`
@@ -27,6 +32,11 @@ func (csr CreateSchemaRequest) IsValid() error {
 	if err := util.IsValidStruct(csr); err != nil {
 		return err
 	}
+	if csr.Version != "" {
+		if _, err := parseVersion(csr.Version); err != nil {
+			return err
+		}
+	}
 	if csr.FullyQualifiedVerificationMethodID != "" && csr.Issuer != "" {
 		return common.ValidateVerificationMethodID(csr.FullyQualifiedVerificationMethodID, csr.Issuer)
 	}
@@ -35,26 +45,54 @@ func (csr CreateSchemaRequest) IsValid() error {
 
 type CreateSchemaResponse struct {
 	ID               string                  `json:"id"`
+	Version          string                  `json:"version"`
 	Type             schema.VCJSONSchemaType `json:"type"`
 	Schema           *schema.JSONSchema      `json:"schema,omitempty"`
 	CredentialSchema *keyaccess.JWT          `json:"credentialSchema,omitempty"`
 }
 
+type ListSchemasPageRequest struct {
+	// A storage dependent token of the page to list. Empty means the first page.
+	PageToken string
+	// A value of -1 means all schemas.
+	PageSize int
+}
+
 type ListSchemasResponse struct {
-	Schemas []GetSchemaResponse `json:"schemas,omitempty"`
+	Schemas       []GetSchemaResponse `json:"schemas,omitempty"`
+	NextPageToken string
+
+	// TotalCount is the number of schemas across all pages.
+	TotalCount int
 }
 
 type GetSchemaRequest struct {
+	// ID of the schema, for its latest version, or a version-qualified ID, for that version.
 	ID string `json:"id" validate:"required"`
 }
 
 type GetSchemaResponse struct {
 	ID               string                  `json:"id"`
+	Version          string                  `json:"version"`
 	Type             schema.VCJSONSchemaType `json:"type"`
 	Schema           *schema.JSONSchema      `json:"schema,omitempty"`
 	CredentialSchema *keyaccess.JWT          `json:"credentialSchema,omitempty"`
 }
 
+// VersionedID returns the version-qualified ID of the schema's version.
+func (gsr GetSchemaResponse) VersionedID() string {
+	return VersionedID(gsr.ID, gsr.Version)
+}
+
+type ListSchemaVersionsRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type ListSchemaVersionsResponse struct {
+	// Versions of the schema, oldest first.
+	Versions []GetSchemaResponse `json:"versions"`
+}
+
 type DeleteSchemaRequest struct {
 	ID string `json:"id" validate:"required"`
 }
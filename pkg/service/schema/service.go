@@ -20,10 +20,12 @@ import (
 
 	"github.com/tbd54566975/ssi-service/config"
 	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+	"github.com/tbd54566975/ssi-service/internal/tracing"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 
 	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
@@ -119,15 +121,33 @@ func (s Service) CreateSchema(ctx context.Context, request CreateSchemaRequest)
 		jsonSchema[schema.JSONSchemaDescriptionProperty] = request.Description
 	}
 
+	// a schema with the name of an existing schema is the next version of that schema
+	schemaID := uuid.NewString()
+	version := InitialVersion
+	if request.Version != "" {
+		version = request.Version
+	}
+	latest, err := s.storage.FindSchemaByName(ctx, request.Name)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not find schema named: %s", request.Name)
+	}
+	if latest != nil {
+		schemaID = latest.ID
+		if version, err = nextVersion(latest.GetVersion(), request.Version); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not version schema: %s", schemaID)
+		}
+	}
+
+	// every version has an id of its own, qualified by its version
 	// if the schema is a credential schema, the credential's id is a fully qualified URI
 	// if the schema is a JSON schema, the schema's id is a fully qualified URI
-	schemaID := uuid.NewString()
-	schemaURI := strings.Join([]string{s.Config().ServiceEndpoint, schemaID}, "/")
+	versionedID := VersionedID(schemaID, version)
+	schemaURI := strings.Join([]string{s.Config().ServiceEndpoint, versionedID}, "/")
 
 	// create schema for storage
-	storedSchema := StoredSchema{ID: schemaID}
+	storedSchema := StoredSchema{ID: schemaID, Name: request.Name, Version: version}
 	if request.IsCredentialSchemaRequest() {
-		jsonSchema[schema.JSONSchemaIDProperty] = schemaID
+		jsonSchema[schema.JSONSchemaIDProperty] = versionedID
 		credSchema, err := s.createCredentialSchema(ctx, jsonSchema, schemaURI, request.Issuer, request.FullyQualifiedVerificationMethodID)
 		if err != nil {
 			return nil, sdkutil.LoggingErrorMsg(err, "could not create credential schema")
@@ -140,12 +160,13 @@ func (s Service) CreateSchema(ctx context.Context, request CreateSchemaRequest)
 		storedSchema.Schema = &jsonSchema
 	}
 	// store schema
-	if err = s.storage.StoreSchema(ctx, storedSchema); err != nil {
+	if err = s.storage.StoreSchemaVersion(ctx, storedSchema); err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not store schema")
 	}
 
 	return &CreateSchemaResponse{
 		ID:               schemaID,
+		Version:          version,
 		Type:             storedSchema.Type,
 		Schema:           storedSchema.Schema,
 		CredentialSchema: storedSchema.CredentialSchema,
@@ -180,16 +201,13 @@ func (s Service) createCredentialSchema(ctx context.Context, jsonSchema schema.J
 // signCredentialSchema signs a credential schema with the issuer's key and kid as a  VC JWT
 func (s Service) signCredentialSchema(ctx context.Context, cred credential.VerifiableCredential, issuer, fullyQualifiedVerificationMethodID string) (*keyaccess.JWT, error) {
 	keyStoreID := did.FullyQualifiedVerificationMethodID(cred.IssuerID(), fullyQualifiedVerificationMethodID)
-	gotKey, err := s.keyStore.GetKey(ctx, keystore.GetKeyRequest{ID: keyStoreID})
+	gotKey, err := s.keyStore.GetSigningKey(ctx, keyStoreID)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "getting key for signing credential schema<%s>", fullyQualifiedVerificationMethodID)
 	}
 	if gotKey.Controller != issuer {
 		return nil, sdkutil.LoggingNewErrorf("key controller<%s> does not match credential issuer<%s> for key<%s>", gotKey.Controller, issuer, fullyQualifiedVerificationMethodID)
 	}
-	if gotKey.Revoked {
-		return nil, sdkutil.LoggingNewErrorf("cannot use revoked key<%s>", gotKey.ID)
-	}
 	keyAccess, err := keyaccess.NewJWKKeyAccess(fullyQualifiedVerificationMethodID, gotKey.ID, gotKey.Key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "creating key access for signing credential schema with key<%s>", gotKey.ID)
@@ -210,17 +228,35 @@ func (s Service) ListSchemas(ctx context.Context) (*ListSchemasResponse, error)
 	}
 	schemas := make([]GetSchemaResponse, 0, len(storedSchemas))
 	for _, stored := range storedSchemas {
-		schemas = append(schemas, GetSchemaResponse{
-			ID:               stored.ID,
-			Type:             stored.Type,
-			Schema:           stored.Schema,
-			CredentialSchema: stored.CredentialSchema,
-		})
+		schemas = append(schemas, toGetSchemaResponse(stored))
 	}
 
 	return &ListSchemasResponse{Schemas: schemas}, nil
 }
 
+// ListSchemasPage lists the schemas in a page, ordered by their ids, reading only that page from storage.
+func (s Service) ListSchemasPage(ctx context.Context, request ListSchemasPageRequest) (*ListSchemasResponse, error) {
+	logrus.Debug("listing a page of schemas")
+
+	page, err := s.storage.ListSchemasPage(ctx, request.PageToken, request.PageSize)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "error getting schemas")
+	}
+	total, err := s.storage.CountSchemas(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "error counting schemas")
+	}
+	resp := ListSchemasResponse{
+		Schemas:       make([]GetSchemaResponse, 0, len(page.Schemas)),
+		NextPageToken: page.NextPageToken,
+		TotalCount:    total,
+	}
+	for _, stored := range page.Schemas {
+		resp.Schemas = append(resp.Schemas, toGetSchemaResponse(stored))
+	}
+	return &resp, nil
+}
+
 func (s Service) GetSchema(ctx context.Context, request GetSchemaRequest) (*GetSchemaResponse, error) {
 	logrus.Debugf("getting schema: %s", request.ID)
 
@@ -232,17 +268,54 @@ func (s Service) GetSchema(ctx context.Context, request GetSchemaRequest) (*GetS
 	if gotSchema == nil {
 		return nil, sdkutil.LoggingNewErrorf("schema with id<%s> could not be found", request.ID)
 	}
-	return &GetSchemaResponse{
-		ID:               gotSchema.ID,
-		Type:             gotSchema.Type,
-		Schema:           gotSchema.Schema,
-		CredentialSchema: gotSchema.CredentialSchema,
-	}, nil
+	resp := toGetSchemaResponse(*gotSchema)
+	return &resp, nil
+}
+
+// FindSchemaByName returns the latest version of the schema with the name, or nil when there's none.
+func (s Service) FindSchemaByName(ctx context.Context, name string) (*GetSchemaResponse, error) {
+	found, err := s.storage.FindSchemaByName(ctx, name)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	resp := toGetSchemaResponse(*found)
+	return &resp, nil
+}
+
+// ListSchemaVersions lists every version of a schema, oldest first. Versions are immutable, so each keeps resolving
+// by its version-qualified ID as the schema gets new versions.
+func (s Service) ListSchemaVersions(ctx context.Context, request ListSchemaVersionsRequest) (*ListSchemaVersionsResponse, error) {
+	logrus.Debugf("listing versions of schema: %s", request.ID)
+
+	versions, err := s.storage.ListSchemaVersions(ctx, request.ID)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "error listing versions of schema: %s", request.ID)
+	}
+	resp := ListSchemaVersionsResponse{Versions: make([]GetSchemaResponse, 0, len(versions))}
+	for _, version := range versions {
+		resp.Versions = append(resp.Versions, toGetSchemaResponse(version))
+	}
+	return &resp, nil
+}
+
+func toGetSchemaResponse(stored StoredSchema) GetSchemaResponse {
+	return GetSchemaResponse{
+		ID:               stored.ID,
+		Version:          stored.GetVersion(),
+		Type:             stored.Type,
+		Schema:           stored.Schema,
+		CredentialSchema: stored.CredentialSchema,
+	}
 }
 
+// DeleteSchema deletes a schema with every one of its versions. Single versions can't be deleted, so that the history
+// of a schema stays as it was.
 func (s Service) DeleteSchema(ctx context.Context, request DeleteSchemaRequest) error {
 	logrus.Debugf("deleting schema: %s", request.ID)
 
+	if _, version := SplitVersionedID(request.ID); version != "" {
+		return sdkutil.LoggingNewErrorf("could not delete version<%s> of schema; only whole schemas can be deleted", version)
+	}
 	if err := s.storage.DeleteSchema(ctx, request.ID); err != nil {
 		return sdkutil.LoggingErrorMsgf(err, "could not delete schema with id: %s", request.ID)
 	}
@@ -251,7 +324,10 @@ func (s Service) DeleteSchema(ctx context.Context, request DeleteSchemaRequest)
 }
 
 // Resolve wraps our get schema method for exposing schema access to other services
-func (s Service) Resolve(ctx context.Context, id string) (*schema.JSONSchema, schema.VCJSONSchemaType, error) {
+func (s Service) Resolve(ctx context.Context, id string) (_ *schema.JSONSchema, _ schema.VCJSONSchemaType, err error) {
+	ctx, span := tracing.Start(ctx, "schema.Resolve", attribute.String("schema.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	gotSchemaResponse, err := s.GetSchema(ctx, GetSchemaRequest{ID: id})
 	if err != nil {
 		return nil, "", sdkutil.LoggingErrorMsg(err, "resolving schema")
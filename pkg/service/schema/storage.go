@@ -2,6 +2,8 @@ package schema
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/TBD54566975/ssi-sdk/util"
 	"github.com/goccy/go-json"
@@ -15,14 +17,33 @@ import (
 )
 
 const (
+	// namespace holds the latest version of each schema, by its ID
 	namespace = "schema"
+	// versionNamespace holds every version of each schema, by its version-qualified ID
+	versionNamespace = "versioned_schema"
 )
 
+// ErrSchemaNotFound is returned when no schema exists with the requested ID.
+var ErrSchemaNotFound = errors.New("schema not found")
+
 type StoredSchema struct {
 	ID               string                  `json:"id"`
 	Type             schema.VCJSONSchemaType `json:"type"`
 	Schema           *schema.JSONSchema      `json:"schema,omitempty"`
 	CredentialSchema *keyaccess.JWT          `json:"credentialSchema,omitempty"`
+
+	// Name of the schema, which its versions share. Empty for schemas stored before schemas had versions.
+	Name string `json:"name,omitempty"`
+	// Version of the schema, empty for schemas stored before schemas had versions, which are at InitialVersion.
+	Version string `json:"version,omitempty"`
+}
+
+// GetVersion returns the version of the schema.
+func (s StoredSchema) GetVersion() string {
+	if s.Version == "" {
+		return InitialVersion
+	}
+	return s.Version
 }
 
 type Storage struct {
@@ -48,13 +69,71 @@ func (s *Storage) StoreSchema(ctx context.Context, schema StoredSchema) error {
 	return s.db.Write(ctx, namespace, id, schemaBytes)
 }
 
+// StoreSchemaVersion stores a new version of a schema, which becomes its latest version. Versions are never
+// overwritten, and the latest version is only ever replaced by a greater one, so storing a version that's already
+// stored, or that isn't greater than the latest version, fails. Both are read again in the transaction that writes.
+func (s *Storage) StoreSchemaVersion(ctx context.Context, schema StoredSchema) error {
+	id := schema.ID
+	if id == "" || schema.Version == "" {
+		return util.LoggingNewError("could not store schema version without an ID and a version")
+	}
+	version, err := parseVersion(schema.Version)
+	if err != nil {
+		return util.LoggingErrorMsgf(err, "could not store schema: %s", id)
+	}
+	versionedID := VersionedID(id, schema.Version)
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return util.LoggingErrorMsgf(err, "could not store schema: %s", versionedID)
+	}
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: id}, {Namespace: versionNamespace, Key: versionedID}}
+	_, err = s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		versionBytes, err := s.db.Read(ctx, versionNamespace, versionedID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading schema version: %s", versionedID)
+		}
+		if len(versionBytes) > 0 {
+			return nil, fmt.Errorf("schema version already exists: %s", versionedID)
+		}
+		latestBytes, err := s.db.Read(ctx, namespace, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading latest schema version: %s", id)
+		}
+		if len(latestBytes) > 0 {
+			var latest StoredSchema
+			if err = json.Unmarshal(latestBytes, &latest); err != nil {
+				return nil, errors.Wrapf(err, "unmarshalling latest schema version: %s", id)
+			}
+			latestVersion, err := parseVersion(latest.GetVersion())
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing latest schema version: %s", id)
+			}
+			if !latestVersion.less(*version) {
+				return nil, fmt.Errorf("version<%s> must be greater than the latest version<%s>", schema.Version, latest.GetVersion())
+			}
+		}
+		if err = tx.Write(ctx, versionNamespace, versionedID, schemaBytes); err != nil {
+			return nil, err
+		}
+		return nil, tx.Write(ctx, namespace, id, schemaBytes)
+	}, watchKeys)
+	return err
+}
+
+// GetSchema gets the latest version of a schema by its ID, or the version of a version-qualified ID.
 func (s *Storage) GetSchema(ctx context.Context, id string) (*StoredSchema, error) {
+	schemaID, version := SplitVersionedID(id)
+	if version != "" {
+		return s.getSchemaVersion(ctx, schemaID, version)
+	}
 	schemaBytes, err := s.db.Read(ctx, namespace, id)
 	if err != nil {
 		return nil, util.LoggingErrorMsgf(err, "could not get schema: %s", id)
 	}
 	if len(schemaBytes) == 0 {
-		return nil, util.LoggingNewErrorf("schema not found with id: %s", id)
+		err = fmt.Errorf("%w with id: %s", ErrSchemaNotFound, id)
+		logrus.WithError(err).Error()
+		return nil, err
 	}
 	var stored StoredSchema
 	if err = json.Unmarshal(schemaBytes, &stored); err != nil {
@@ -63,30 +142,162 @@ func (s *Storage) GetSchema(ctx context.Context, id string) (*StoredSchema, erro
 	return &stored, nil
 }
 
+func (s *Storage) getSchemaVersion(ctx context.Context, id, version string) (*StoredSchema, error) {
+	versionedID := VersionedID(id, version)
+	schemaBytes, err := s.db.Read(ctx, versionNamespace, versionedID)
+	if err != nil {
+		return nil, util.LoggingErrorMsgf(err, "could not get schema: %s", versionedID)
+	}
+	if len(schemaBytes) == 0 {
+		// schemas stored before schemas had versions only have their latest, initial, version
+		latest, err := s.GetSchema(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if latest.Version != "" || version != InitialVersion {
+			err = fmt.Errorf("%w with id: %s", ErrSchemaNotFound, versionedID)
+			logrus.WithError(err).Error()
+			return nil, err
+		}
+		return latest, nil
+	}
+	var stored StoredSchema
+	if err = json.Unmarshal(schemaBytes, &stored); err != nil {
+		return nil, util.LoggingErrorMsgf(err, "could not unmarshal stored schema: %s", versionedID)
+	}
+	return &stored, nil
+}
+
+// ListSchemaVersions returns every version of a schema, oldest first.
+func (s *Storage) ListSchemaVersions(ctx context.Context, id string) ([]StoredSchema, error) {
+	latest, err := s.GetSchema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if latest.Version == "" {
+		return []StoredSchema{*latest}, nil
+	}
+	versions, err := s.db.ReadPrefix(ctx, versionNamespace, VersionedID(id, ""))
+	if err != nil {
+		return nil, util.LoggingErrorMsgf(err, "could not read versions of schema: %s", id)
+	}
+	stored := make([]StoredSchema, 0, len(versions))
+	parsed := make(map[string]semanticVersion, len(versions))
+	for versionedID, schemaBytes := range versions {
+		var nextSchema StoredSchema
+		if err = json.Unmarshal(schemaBytes, &nextSchema); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored schema: %s", versionedID)
+			continue
+		}
+		version, err := parseVersion(nextSchema.Version)
+		if err != nil {
+			logrus.WithError(err).Errorf("could not parse version of stored schema: %s", versionedID)
+			continue
+		}
+		parsed[nextSchema.Version] = *version
+		stored = append(stored, nextSchema)
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return parsed[stored[i].Version].less(parsed[stored[j].Version])
+	})
+	return stored, nil
+}
+
+// FindSchemaByName returns the latest version of the schema with the name, or nil when there's none.
+func (s *Storage) FindSchemaByName(ctx context.Context, name string) (*StoredSchema, error) {
+	var found *StoredSchema
+	err := s.db.Iterate(ctx, namespace, func(_ string, schemaBytes []byte) (bool, error) {
+		var nextSchema StoredSchema
+		if err := json.Unmarshal(schemaBytes, &nextSchema); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored schema: %s", string(schemaBytes))
+			return true, nil
+		}
+		if nextSchema.Name == name {
+			found = &nextSchema
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, util.LoggingErrorMsgf(err, "could not find schema with name: %s", name)
+	}
+	return found, nil
+}
+
 // ListSchemas attempts to get all stored schemas. It will return those it can even if it has trouble with some.
 func (s *Storage) ListSchemas(ctx context.Context) ([]StoredSchema, error) {
-	gotSchemas, err := s.db.ReadAll(ctx, namespace)
+	var stored []StoredSchema
+	err := s.db.Iterate(ctx, namespace, func(_ string, schemaBytes []byte) (bool, error) {
+		var nextSchema StoredSchema
+		if err := json.Unmarshal(schemaBytes, &nextSchema); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored schema: %s", string(schemaBytes))
+			return true, nil
+		}
+		stored = append(stored, nextSchema)
+		return true, nil
+	})
 	if err != nil {
 		return nil, util.LoggingErrorMsg(err, "could not list schemas")
 	}
-	if len(gotSchemas) == 0 {
+	if len(stored) == 0 {
 		logrus.Info("no schemas to list")
 		return nil, nil
 	}
-	stored := make([]StoredSchema, 0, len(gotSchemas))
-	for _, schemaBytes := range gotSchemas {
+	return stored, nil
+}
+
+// SchemasPage is a page of schemas, ordered by their ids.
+type SchemasPage struct {
+	Schemas       []StoredSchema
+	NextPageToken string
+}
+
+// ListSchemasPage returns the schemas in the page with the given token and size. A size of -1 means all schemas. It
+// will return those it can even if it has trouble with some.
+func (s *Storage) ListSchemasPage(ctx context.Context, token string, size int) (*SchemasPage, error) {
+	schemas, nextPageToken, err := s.db.ReadPage(ctx, namespace, token, size)
+	if err != nil {
+		return nil, util.LoggingErrorMsg(err, "could not read page of schemas")
+	}
+	ids := make([]string, 0, len(schemas))
+	for id := range schemas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	stored := make([]StoredSchema, 0, len(ids))
+	for _, id := range ids {
 		var nextSchema StoredSchema
-		if err = json.Unmarshal(schemaBytes, &nextSchema); err != nil {
-			logrus.WithError(err).Errorf("could not unmarshal stored schema: %s", string(schemaBytes))
+		if err = json.Unmarshal(schemas[id], &nextSchema); err != nil {
+			logrus.WithError(err).Errorf("could not unmarshal stored schema: %s", id)
 			continue
 		}
 		stored = append(stored, nextSchema)
 	}
-	return stored, nil
+	return &SchemasPage{Schemas: stored, NextPageToken: nextPageToken}, nil
+}
+
+// CountSchemas returns how many schemas are stored.
+func (s *Storage) CountSchemas(ctx context.Context) (int, error) {
+	ids, err := s.db.ReadAllKeys(ctx, namespace)
+	if err != nil {
+		return 0, util.LoggingErrorMsg(err, "could not read schema ids")
+	}
+	return len(ids), nil
 }
 
+// DeleteSchema deletes a schema, along with every one of its versions.
 func (s *Storage) DeleteSchema(ctx context.Context, id string) error {
-	if err := s.db.Delete(ctx, namespace, id); err != nil {
+	versions, err := s.db.ReadPrefix(ctx, versionNamespace, VersionedID(id, ""))
+	if err != nil {
+		return util.LoggingErrorMsgf(err, "could not read versions of schema: %s", id)
+	}
+	for versionedID := range versions {
+		if err = s.db.Delete(ctx, versionNamespace, versionedID); err != nil {
+			return util.LoggingErrorMsgf(err, "could not delete schema version: %s", versionedID)
+		}
+	}
+	if err = s.db.Delete(ctx, namespace, id); err != nil {
 		return util.LoggingErrorMsgf(err, "could not delete schema: %s", id)
 	}
 	return nil
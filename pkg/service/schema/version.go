@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// VersionSeparator separates the ID of a schema from one of its versions in a version-qualified ID, such as
+	// <id>@1.2.0.
+	VersionSeparator = "@"
+
+	// InitialVersion is the version of a schema that's created with a new name, when no version is requested. Schemas
+	// that were created before schemas had versions are at this version too.
+	InitialVersion = "1.0.0"
+)
+
+// VersionedID returns the version-qualified ID of a version of a schema.
+func VersionedID(id, version string) string {
+	return id + VersionSeparator + version
+}
+
+// SplitVersionedID splits a version-qualified ID into the ID of the schema and its version. The version is empty when
+// the ID isn't version-qualified.
+func SplitVersionedID(id string) (string, string) {
+	schemaID, version, _ := strings.Cut(id, VersionSeparator)
+	return schemaID, version
+}
+
+// semanticVersion is a MAJOR.MINOR.PATCH version, as in https://semver.org, without pre-release or build metadata.
+type semanticVersion struct {
+	major, minor, patch int
+}
+
+func parseVersion(version string) (*semanticVersion, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("version<%s> is not of the form MAJOR.MINOR.PATCH", version)
+	}
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 || (len(part) > 1 && part[0] == '0') {
+			return nil, fmt.Errorf("version<%s> is not of the form MAJOR.MINOR.PATCH", version)
+		}
+		numbers = append(numbers, number)
+	}
+	return &semanticVersion{major: numbers[0], minor: numbers[1], patch: numbers[2]}, nil
+}
+
+func (v semanticVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func (v semanticVersion) less(other semanticVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	return v.patch < other.patch
+}
+
+// nextVersion returns the version that follows latest: requested, which must be greater than latest, or the next
+// minor version of latest when nothing is requested.
+func nextVersion(latest, requested string) (string, error) {
+	latestVersion, err := parseVersion(latest)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing latest version")
+	}
+	if requested == "" {
+		return semanticVersion{major: latestVersion.major, minor: latestVersion.minor + 1}.String(), nil
+	}
+	requestedVersion, err := parseVersion(requested)
+	if err != nil {
+		return "", err
+	}
+	if !latestVersion.less(*requestedVersion) {
+		return "", fmt.Errorf("version<%s> must be greater than the latest version<%s>", requested, latest)
+	}
+	return requestedVersion.String(), nil
+}
@@ -1,20 +1,42 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
+	didresolution "github.com/TBD54566975/ssi-sdk/did/resolution"
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
 	"github.com/pkg/errors"
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/faults"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+	"github.com/tbd54566975/ssi-service/pkg/service/anchor"
+	"github.com/tbd54566975/ssi-service/pkg/service/anomaly"
+	"github.com/tbd54566975/ssi-service/pkg/service/approval"
+	"github.com/tbd54566975/ssi-service/pkg/service/audit"
+	"github.com/tbd54566975/ssi-service/pkg/service/auth"
+	"github.com/tbd54566975/ssi-service/pkg/service/backup"
+	"github.com/tbd54566975/ssi-service/pkg/service/challenge"
 	"github.com/tbd54566975/ssi-service/pkg/service/credential"
 	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/erasure"
+	"github.com/tbd54566975/ssi-service/pkg/service/expiry"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/service/issuance"
 	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
 	"github.com/tbd54566975/ssi-service/pkg/service/manifest"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vci"
+	"github.com/tbd54566975/ssi-service/pkg/service/oidc4vp"
 	"github.com/tbd54566975/ssi-service/pkg/service/operation"
 	"github.com/tbd54566975/ssi-service/pkg/service/presentation"
+	"github.com/tbd54566975/ssi-service/pkg/service/replay"
+	"github.com/tbd54566975/ssi-service/pkg/service/retention"
 	"github.com/tbd54566975/ssi-service/pkg/service/schema"
+	"github.com/tbd54566975/ssi-service/pkg/service/stats"
+	"github.com/tbd54566975/ssi-service/pkg/service/transparency"
+	"github.com/tbd54566975/ssi-service/pkg/service/trust"
+	"github.com/tbd54566975/ssi-service/pkg/service/usage"
 	"github.com/tbd54566975/ssi-service/pkg/service/webhook"
 	wellknown "github.com/tbd54566975/ssi-service/pkg/service/well-known"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
@@ -22,35 +44,66 @@ import (
 
 // SSIService represents all services and their dependencies independent of transport
 type SSIService struct {
-	KeyStore         *keystore.Service
-	DID              *did.Service
-	Schema           *schema.Service
-	Issuance         *issuance.Service
-	Credential       *credential.Service
-	Manifest         *manifest.Service
-	Presentation     *presentation.Service
-	Operation        *operation.Service
-	Webhook          *webhook.Service
-	storage          storage.ServiceStorage
-	BatchDID         *did.BatchService
-	DIDConfiguration *wellknown.DIDConfigurationService
+	KeyStore            *keystore.Service
+	DID                 *did.Service
+	Schema              *schema.Service
+	Issuance            *issuance.Service
+	Credential          *credential.Service
+	Manifest            *manifest.Service
+	Presentation        *presentation.Service
+	Operation           *operation.Service
+	Webhook             *webhook.Service
+	Auth                *auth.Service
+	Audit               *audit.Service
+	Erasure             *erasure.Service
+	Usage               *usage.Service
+	Stats               *stats.Service
+	Transparency        *transparency.Service
+	Backup              *backup.Service
+	Anomaly             *anomaly.Service
+	Retention           *retention.Service
+	Approval            *approval.Service
+	Anchor              *anchor.Service
+	Expiry              *expiry.Service
+	Replay              *replay.Service
+	Challenge           *challenge.Service
+	Trust               *trust.Service
+	OIDC4VCI            *oidc4vci.Service
+	OIDC4VP             *oidc4vp.Service
+	storage             storage.ServiceStorage
+	rawStorage          storage.ServiceStorage
+	namespaceEncryption *storage.NamespaceEncryptedWrapper
+	BatchDID            *did.BatchService
+	DIDConfiguration    *wellknown.DIDConfigurationService
+
+	// Faults injected into storage and DID resolution, when they're enabled.
+	Faults *faults.Injector
+
+	// KeyShares reconstructs the service key of the keystore from its shares, when it's split into shares.
+	KeyShares *keystore.ServiceKeyShares
+
+	// the hooks run by Drain, and whether storage was created from the config, and is closed by CloseStorage
+	shutdownHooks framework.ShutdownHooks
+	ownsStorage   bool
 }
 
 // InstantiateSSIService creates a new instance of the SSIS which instantiates all services and their
-// dependencies independent of transport.
-func InstantiateSSIService(config config.ServicesConfig) (*SSIService, error) {
-	if err := validateServiceConfig(config); err != nil {
+// dependencies independent of transport. Dependencies provided as options are used instead of the ones the config
+// describes.
+func InstantiateSSIService(config config.ServicesConfig, opts ...Option) (*SSIService, error) {
+	deps := newDependencies(opts)
+	if err := validateServiceConfig(config, deps); err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate SSI Service, invalid config")
 	}
-	service, err := instantiateServices(config)
+	service, err := instantiateServices(config, deps)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsgf(err, "could not instantiate the ssi service")
 	}
 	return service, nil
 }
 
-func validateServiceConfig(config config.ServicesConfig) error {
-	if !storage.IsStorageAvailable(storage.Type(config.StorageProvider)) {
+func validateServiceConfig(config config.ServicesConfig, deps dependencies) error {
+	if deps.storage == nil && !storage.IsStorageAvailable(storage.Type(config.StorageProvider)) {
 		return fmt.Errorf("%s storage provider configured, but not available", config.StorageProvider)
 	}
 	if config.KeyStoreConfig.IsEmpty() {
@@ -81,30 +134,71 @@ func validateServiceConfig(config config.ServicesConfig) error {
 }
 
 // instantiateServices begins all instantiates and their dependencies
-func instantiateServices(config config.ServicesConfig) (*SSIService, error) {
-	unencryptedStorageProvider, err := storage.NewStorage(storage.Type(config.StorageProvider), config.StorageOptions...)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsgf(err, "could not instantiate storage provider: %s", config.StorageProvider)
+func instantiateServices(config config.ServicesConfig, deps dependencies) (*SSIService, error) {
+	unencryptedStorageProvider := deps.storage
+	if unencryptedStorageProvider == nil {
+		var err error
+		unencryptedStorageProvider, err = storage.NewStorage(storage.Type(config.StorageProvider), config.StorageOptions...)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not instantiate storage provider: %s", config.StorageProvider)
+		}
 	}
+	// storage operations are measured, and traced as part of the requests they're made for when tracing is enabled
+	unencryptedStorageProvider = storage.NewInstrumentedWrapper(unencryptedStorageProvider)
 
 	storageEncrypter, storageDecrypter, err := keystore.NewServiceEncryption(unencryptedStorageProvider, config.AppLevelEncryptionConfiguration, keystore.ServiceDataEncryptionKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating app level encrypter")
 	}
-	storageProvider := unencryptedStorageProvider
+	var globalStorageProvider storage.ServiceStorage = unencryptedStorageProvider
 	if storageEncrypter != nil && storageDecrypter != nil {
-		storageProvider = storage.NewEncryptedWrapper(unencryptedStorageProvider, storageEncrypter, storageDecrypter)
+		globalStorageProvider = storage.NewEncryptedWrapper(unencryptedStorageProvider, storageEncrypter, storageDecrypter)
+	}
+
+	keyEncrypter, keyDecrypter := deps.keyEncrypter, deps.keyDecrypter
+	var keyShares *keystore.ServiceKeyShares
+	if keyEncrypter == nil || keyDecrypter == nil {
+		if config.KeyStoreConfig.ServiceKeyShares > 0 {
+			if keyShares, err = keystore.NewServiceKeyShares(unencryptedStorageProvider, config.KeyStoreConfig); err != nil {
+				return nil, errors.Wrap(err, "creating keystore key shares")
+			}
+			keyEncrypter, keyDecrypter = keyShares.Encryption()
+		} else {
+			keyEncrypter, keyDecrypter, err = keystore.NewKeyEncryption(unencryptedStorageProvider, config.KeyStoreConfig.EncryptionConfig)
+			if err != nil {
+				return nil, errors.Wrap(err, "creating keystore encrypter")
+			}
+		}
+	}
+
+	// namespaces holding personal data may be encrypted with the service key too, like the private keys are
+	var namespaceEncryption *storage.NamespaceEncryptedWrapper
+	if len(config.EncryptedNamespaces) > 0 {
+		envelope, ok := keyEncrypter.(*encryption.EnvelopeEncrypter)
+		if !ok {
+			return nil, errors.New("encrypted namespaces require the keystore to encrypt keys with data keys")
+		}
+		namespaceEncryption = storage.NewNamespaceEncryptedWrapper(globalStorageProvider, envelope, config.EncryptedNamespaces)
+		globalStorageProvider = namespaceEncryption
+	}
+
+	var faultInjector *faults.Injector
+	if config.Faults.Enabled {
+		if faultInjector, err = faults.NewInjector(config.Faults); err != nil {
+			return nil, errors.Wrap(err, "creating fault injector")
+		}
+		globalStorageProvider = faults.NewStorage(globalStorageProvider, faultInjector)
 	}
 
+	// all tenant data is scoped to the tenant of each request, while data about the deployment itself, like api keys,
+	// is stored in the global storage provider
+	storageProvider := storage.NewTenantWrapper(globalStorageProvider)
+
 	webhookService, err := webhook.NewWebhookService(config.WebhookConfig, storageProvider)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the webhook service")
 	}
 
-	keyEncrypter, keyDecrypter, err := keystore.NewServiceEncryption(unencryptedStorageProvider, config.KeyStoreConfig.EncryptionConfig, keystore.ServiceKeyEncryptionKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "creating keystore encrypter")
-	}
 	keyStoreServiceFactory := keystore.NewKeyStoreServiceFactory(config.KeyStoreConfig, storageProvider, keyEncrypter, keyDecrypter)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the keystore service factory")
@@ -115,6 +209,14 @@ func instantiateServices(config config.ServicesConfig) (*SSIService, error) {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate KeyStore service")
 	}
 
+	var anomalyService *anomaly.Service
+	if config.AnomalyConfig.Enabled {
+		if anomalyService, err = anomaly.NewAnomalyService(config.AnomalyConfig, globalStorageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the anomaly service")
+		}
+		keyStoreService.SetSigningMonitor(anomalyService)
+	}
+
 	batchDIDService, err := did.NewBatchDIDService(config.DIDConfig, storageProvider, keyStoreServiceFactory)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate batch DID service")
@@ -124,6 +226,12 @@ func instantiateServices(config config.ServicesConfig) (*SSIService, error) {
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the DID service")
 	}
+	keyStoreService.SetRotationPublisher(didService)
+	if faultInjector != nil {
+		didService.WrapResolver(func(r didresolution.Resolver) didresolution.Resolver {
+			return faults.NewResolver(r, faultInjector)
+		})
+	}
 	didResolver := didService.GetResolver()
 
 	schemaService, err := schema.NewSchemaService(config.SchemaConfig, storageProvider, keyStoreService, didResolver)
@@ -141,41 +249,238 @@ func instantiateServices(config config.ServicesConfig) (*SSIService, error) {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the credential service")
 	}
 
+	statsService, err := stats.NewStatsService(storageProvider, didService)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the stats service")
+	}
+	credentialService.SetStats(statsService)
+
+	var transparencyService *transparency.Service
+	if config.TransparencyConfig.Enabled {
+		if transparencyService, err = transparency.NewTransparencyService(storageProvider, keyStoreService); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the transparency service")
+		}
+		credentialService.SetTransparencyLog(transparencyService)
+	}
+
 	presentationService, err := presentation.NewPresentationService(config.PresentationConfig, storageProvider, didResolver, schemaService, keyStoreService)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the presentation service")
 	}
 
+	var trustService *trust.Service
+	if config.TrustConfig.Enabled {
+		if trustService, err = trust.NewTrustService(config.TrustConfig, storageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the trust service")
+		}
+		credentialService.SetIssuerTrust(trustService)
+		presentationService.SetIssuerTrust(trustService)
+	}
+
 	manifestService, err := manifest.NewManifestService(config.ManifestConfig, storageProvider, keyStoreService, didResolver, credentialService, presentationService)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the manifest service")
 	}
+	manifestService.SetWebhooks(webhookService)
+
+	erasureService, err := erasure.NewErasureService(storageProvider, credentialService, manifestService, presentationService)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the erasure service")
+	}
 
-	operationService, err := operation.NewOperationService(storageProvider)
+	operationService, err := operation.NewOperationService(config.OperationConfig, storageProvider)
 	if err != nil {
 		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the operation service")
 	}
 
+	authService, err := auth.NewAuthService(config.AuthConfig, globalStorageProvider)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the auth service")
+	}
+
+	auditService, err := audit.NewAuditService(globalStorageProvider, keyStoreService)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the audit service")
+	}
+
+	usageService, err := usage.NewUsageService(globalStorageProvider)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the usage service")
+	}
+
+	var backupService *backup.Service
+	if config.BackupConfig.Enabled {
+		if backupService, err = backup.NewBackupService(config.BackupConfig, globalStorageProvider, storageProvider, keyStoreService); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the backup service")
+		}
+	}
+
+	var retentionService *retention.Service
+	if config.RetentionConfig.Enabled {
+		if retentionService, err = retention.NewRetentionService(config.RetentionConfig, globalStorageProvider, storageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the retention service")
+		}
+	}
+
+	var approvalService *approval.Service
+	if config.ApprovalConfig.Enabled {
+		if approvalService, err = approval.NewApprovalService(config.ApprovalConfig, globalStorageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the approval service")
+		}
+	}
+
+	var anchorService *anchor.Service
+	if config.AnchorConfig.Enabled {
+		if anchorService, err = anchor.NewAnchorService(config.AnchorConfig, globalStorageProvider, storageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the anchor service")
+		}
+	}
+
+	var expiryService *expiry.Service
+	if config.ExpiryConfig.Enabled {
+		if expiryService, err = expiry.NewExpiryService(config.ExpiryConfig, globalStorageProvider, storageProvider, keyStoreService, credentialService, webhookService); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the expiry service")
+		}
+	}
+
+	var replayService *replay.Service
+	if config.ReplayConfig.Enabled {
+		if replayService, err = replay.NewReplayService(config.ReplayConfig, globalStorageProvider); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the replay service")
+		}
+	}
+
+	var challengeService *challenge.Service
+	if config.ChallengeConfig.Enabled {
+		if challengeService, err = challenge.NewChallengeService(config.ChallengeConfig, globalStorageProvider, presentationService, manifestService); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the challenge service")
+		}
+	}
+
+	var oidc4vciService *oidc4vci.Service
+	if config.OIDC4VCIConfig.Enabled {
+		oidc4vciConfig := config.OIDC4VCIConfig
+		if oidc4vciConfig.CredentialIssuer == "" {
+			oidc4vciConfig.CredentialIssuer = config.ServiceEndpoint
+		}
+		if oidc4vciService, err = oidc4vci.NewOIDC4VCIService(oidc4vciConfig, globalStorageProvider, manifestService, didResolver); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the oidc4vci service")
+		}
+	}
+
+	var oidc4vpService *oidc4vp.Service
+	if config.OIDC4VPConfig.Enabled {
+		oidc4vpConfig := config.OIDC4VPConfig
+		if oidc4vpConfig.BaseURL == "" {
+			oidc4vpConfig.BaseURL = config.ServiceEndpoint
+		}
+		if oidc4vpService, err = oidc4vp.NewOIDC4VPService(oidc4vpConfig, globalStorageProvider, presentationService, didResolver); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate the oidc4vp service")
+		}
+	}
+
 	didConfigurationService, _ := wellknown.NewDIDConfigurationService(keyStoreService, didResolver, schemaService)
-	return &SSIService{
-		KeyStore:         keyStoreService,
-		DID:              didService,
-		BatchDID:         batchDIDService,
-		Schema:           schemaService,
-		Issuance:         issuanceService,
-		Credential:       credentialService,
-		Manifest:         manifestService,
-		Presentation:     presentationService,
-		Operation:        operationService,
-		Webhook:          webhookService,
-		DIDConfiguration: didConfigurationService,
-		storage:          storageProvider,
-	}, nil
+	ssi := SSIService{
+		KeyStore:            keyStoreService,
+		DID:                 didService,
+		BatchDID:            batchDIDService,
+		Schema:              schemaService,
+		Issuance:            issuanceService,
+		Credential:          credentialService,
+		Manifest:            manifestService,
+		Presentation:        presentationService,
+		Operation:           operationService,
+		Webhook:             webhookService,
+		Auth:                authService,
+		Audit:               auditService,
+		Erasure:             erasureService,
+		Usage:               usageService,
+		Stats:               statsService,
+		Transparency:        transparencyService,
+		Backup:              backupService,
+		Anomaly:             anomalyService,
+		Retention:           retentionService,
+		Approval:            approvalService,
+		Anchor:              anchorService,
+		Expiry:              expiryService,
+		Replay:              replayService,
+		Challenge:           challengeService,
+		Trust:               trustService,
+		OIDC4VCI:            oidc4vciService,
+		OIDC4VP:             oidc4vpService,
+		DIDConfiguration:    didConfigurationService,
+		Faults:              faultInjector,
+		KeyShares:           keyShares,
+		storage:             storageProvider,
+		rawStorage:          unencryptedStorageProvider,
+		namespaceEncryption: namespaceEncryption,
+		ownsStorage:         deps.storage == nil,
+	}
+	if deps.clock != nil {
+		ssi.setClock(deps.clock)
+	}
+	// services are drained before the services their work hands work to: asynchronous operations sign, which the
+	// anomaly service counts, and publish webhooks as they finish
+	ssi.shutdownHooks.Register(framework.Operation, ssi.Operation.Drain)
+	if ssi.Anomaly != nil {
+		ssi.shutdownHooks.Register(framework.Anomaly, ssi.Anomaly.Drain)
+	}
+	ssi.shutdownHooks.Register(framework.Webhook, ssi.Webhook.Drain)
+	return &ssi, nil
+}
+
+// setClock keeps time for every service that keeps time with c.
+func (s *SSIService) setClock(c clock.Clock) {
+	s.Manifest.Clock = c
+	s.Erasure.Clock = c
+	s.Auth.Clock = c
+	s.Audit.Clock = c
+	s.Usage.Clock = c
+	s.Stats.Clock = c
+	if s.KeyShares != nil {
+		s.KeyShares.Clock = c
+	}
+	if s.Transparency != nil {
+		s.Transparency.Clock = c
+	}
+	if s.Backup != nil {
+		s.Backup.Clock = c
+	}
+	if s.Anomaly != nil {
+		s.Anomaly.Clock = c
+	}
+	if s.Retention != nil {
+		s.Retention.Clock = c
+	}
+	if s.Approval != nil {
+		s.Approval.Clock = c
+	}
+	if s.Anchor != nil {
+		s.Anchor.Clock = c
+	}
+	if s.Expiry != nil {
+		s.Expiry.Clock = c
+	}
+	if s.Replay != nil {
+		s.Replay.Clock = c
+	}
+	if s.Challenge != nil {
+		s.Challenge.Clock = c
+	}
+	if s.Trust != nil {
+		s.Trust.Clock = c
+	}
+	if s.OIDC4VCI != nil {
+		s.OIDC4VCI.Clock = c
+	}
+	if s.OIDC4VP != nil {
+		s.OIDC4VP.Clock = c
+	}
 }
 
 // GetServices returns all services
 func (s *SSIService) GetServices() []framework.Service {
-	return []framework.Service{
+	services := []framework.Service{
 		s.KeyStore,
 		s.DID,
 		s.Schema,
@@ -185,9 +490,112 @@ func (s *SSIService) GetServices() []framework.Service {
 		s.Presentation,
 		s.Operation,
 		s.Webhook,
+		s.Auth,
+		s.Audit,
+		s.Erasure,
+		s.Usage,
+		s.Stats,
+		storageStatus{db: s.storage},
+	}
+	if s.Transparency != nil {
+		services = append(services, s.Transparency)
+	}
+	if s.Backup != nil {
+		services = append(services, s.Backup)
+	}
+	if s.Anomaly != nil {
+		services = append(services, s.Anomaly)
+	}
+	if s.Retention != nil {
+		services = append(services, s.Retention)
+	}
+	if s.Approval != nil {
+		services = append(services, s.Approval)
+	}
+	if s.Anchor != nil {
+		services = append(services, s.Anchor)
+	}
+	if s.Expiry != nil {
+		services = append(services, s.Expiry)
+	}
+	if s.Replay != nil {
+		services = append(services, s.Replay)
+	}
+	if s.Challenge != nil {
+		services = append(services, s.Challenge)
 	}
+	if s.Trust != nil {
+		services = append(services, s.Trust)
+	}
+	if s.OIDC4VCI != nil {
+		services = append(services, s.OIDC4VCI)
+	}
+	if s.OIDC4VP != nil {
+		services = append(services, s.OIDC4VP)
+	}
+	return services
+}
+
+// storageStatus reports whether the storage provider is reachable, so that readiness checks account for it.
+type storageStatus struct {
+	db storage.ServiceStorage
+}
+
+func (s storageStatus) Type() framework.Type {
+	return framework.Storage
+}
+
+func (s storageStatus) Status() framework.Status {
+	if s.db == nil {
+		return framework.Status{Status: framework.StatusNotReady, Message: "storage not loaded"}
+	}
+	if !s.db.IsOpen() {
+		return framework.Status{Status: framework.StatusNotReady, Message: fmt.Sprintf("%s storage is not reachable", s.db.Type())}
+	}
+	return framework.Status{Status: framework.StatusReady}
 }
 
 func (s *SSIService) GetStorage() storage.ServiceStorage {
 	return s.storage
 }
+
+// GetRawStorage returns the storage the services are built on, which holds the values of every tenant as they're
+// stored, encrypted when app level encryption is enabled.
+func (s *SSIService) GetRawStorage() storage.ServiceStorage {
+	return s.rawStorage
+}
+
+// GetNamespaceEncryption returns the storage encrypting the configured namespaces, which encrypts the values stored
+// before they were configured, or nil when no namespace is encrypted.
+func (s *SSIService) GetNamespaceEncryption() *storage.NamespaceEncryptedWrapper {
+	return s.namespaceEncryption
+}
+
+// RegisterShutdownHook has Drain run hook for component, after the hooks registered before it. Services with work
+// running in the background are registered when the SSIService is instantiated.
+func (s *SSIService) RegisterShutdownHook(component framework.Type, hook framework.ShutdownHook) {
+	s.shutdownHooks.Register(component, hook)
+}
+
+// ShutdownOrder returns the components whose shutdown hooks Drain runs, in the order it runs them.
+func (s *SSIService) ShutdownOrder() []framework.Type {
+	return s.shutdownHooks.Components()
+}
+
+// Drain runs the shutdown hooks of every component, within ctx, so that webhook deliveries, asynchronous operations,
+// and the work of other components are finished or given up on. Storage is left open, for CloseStorage to close.
+func (s *SSIService) Drain(ctx context.Context) error {
+	return s.shutdownHooks.Run(ctx)
+}
+
+// CloseStorage closes the storage provider, flushing the writes it still buffers, and should be called last, once
+// nothing writes to it anymore. Storage provided with WithStorage is left open for whoever provided it to close.
+func (s *SSIService) CloseStorage() error {
+	if !s.ownsStorage || s.storage == nil {
+		return nil
+	}
+	if err := s.storage.Close(); err != nil {
+		return errors.Wrapf(err, "closing %s storage", s.storage.Type())
+	}
+	return nil
+}
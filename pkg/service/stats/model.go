@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Metric is a kind of event that is counted per day.
+type Metric string
+
+const (
+	// MetricCredentialsIssued counts the credentials issued, one by one, in batches, and in response to applications.
+	MetricCredentialsIssued Metric = "credentials_issued"
+	// MetricCredentialsRevoked counts the credentials revoked.
+	MetricCredentialsRevoked Metric = "credentials_revoked"
+	// MetricVerificationsSucceeded counts the credentials that were verified, on their own or submitted with applications.
+	MetricVerificationsSucceeded Metric = "verifications_succeeded"
+	// MetricVerificationsFailed counts the credentials that failed verification.
+	MetricVerificationsFailed Metric = "verifications_failed"
+)
+
+// Metrics are all the kinds of events that are counted.
+var Metrics = []Metric{MetricCredentialsIssued, MetricCredentialsRevoked, MetricVerificationsSucceeded, MetricVerificationsFailed}
+
+// IsValid returns whether the metric is one of Metrics.
+func (m Metric) IsValid() bool {
+	for _, metric := range Metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// dayLayout is the layout of days, which are UTC calendar days.
+const dayLayout = "2006-01-02"
+
+// Day returns the day t is in, e.g. 2023-10-02.
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// Record is how many times a metric was counted on a day.
+type Record struct {
+	Day    string `json:"day"`
+	Metric Metric `json:"metric"`
+	Count  int64  `json:"count"`
+}
+
+// Key identifies the record. Keys sort records by day, then metric.
+func (r Record) Key() string {
+	return storage.Join(r.Day, string(r.Metric))
+}
+
+// DailyCount is how many times something was counted on a UTC calendar day.
+type DailyCount struct {
+	// Day, e.g. 2023-10-02.
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+// DefaultDays is how many days of time series are returned when none are requested, and MaxDays the most that are.
+const (
+	DefaultDays = 30
+	MaxDays     = 366
+)
+
+type GetStatsRequest struct {
+	// Days of time series to return, ending with the current day. DefaultDays when 0.
+	Days int
+}
+
+// IsValid returns an error when more than MaxDays days are requested, or fewer than one.
+func (r GetStatsRequest) IsValid() error {
+	if r.Days != 0 && (r.Days < 1 || r.Days > MaxDays) {
+		return errors.Errorf("days must be between 1 and %d, not %d", MaxDays, r.Days)
+	}
+	return nil
+}
+
+// Stats are aggregate counts of what a tenant holds, and time series of what it did, for dashboards.
+type Stats struct {
+	// First and last day of the time series, e.g. 2023-10-02.
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// DIDs that aren't soft deleted, by method. Every supported method is listed.
+	DIDsByMethod map[string]int `json:"didsByMethod"`
+
+	// Credential applications, by status: pending, fulfilled, rejected, or cancelled. Every status is listed.
+	ApplicationsByStatus map[string]int `json:"applicationsByStatus"`
+
+	// Time series with a count for every day from From to To, oldest first. Days without events count 0.
+	CredentialsIssued      []DailyCount `json:"credentialsIssued"`
+	CredentialsRevoked     []DailyCount `json:"credentialsRevoked"`
+	VerificationsSucceeded []DailyCount `json:"verificationsSucceeded"`
+	VerificationsFailed    []DailyCount `json:"verificationsFailed"`
+}
@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// applicationStatuses are the statuses applications are counted by.
+var applicationStatuses = []opcredential.Status{
+	opcredential.StatusPending,
+	opcredential.StatusFulfilled,
+	opcredential.StatusRejected,
+	opcredential.StatusCancelled,
+}
+
+// Service aggregates statistics of each tenant for operator dashboards: counts of the DIDs and applications it holds,
+// and daily counts of the credentials it issued, revoked, and verified. Events can't be told apart from what's stored,
+// so the services that issue, revoke, and verify count them as they happen, per UTC calendar day.
+type Service struct {
+	storage      *Storage
+	dids         *did.Storage
+	applications *manifeststg.Storage
+	methods      []string
+	Clock        clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Stats
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.dids == nil {
+		ae.AppendString("no did storage configured")
+	}
+	if s.applications == nil {
+		ae.AppendString("no application storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("stats service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewStatsService(s storage.ServiceStorage, didService *did.Service) (*Service, error) {
+	if didService == nil {
+		return nil, errors.New("did service cannot be nil")
+	}
+	statsStorage, err := NewStatsStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the stats service")
+	}
+	didStorage, err := did.NewDIDStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate did storage for the stats service")
+	}
+	applicationStorage, err := manifeststg.NewManifestStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate application storage for the stats service")
+	}
+	supported := didService.GetSupportedMethods().Methods
+	methods := make([]string, 0, len(supported))
+	for _, method := range supported {
+		methods = append(methods, method.String())
+	}
+	service := Service{
+		storage:      statsStorage,
+		dids:         didStorage,
+		applications: applicationStorage,
+		methods:      methods,
+		Clock:        clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// Add counts a metric on the current day, for the tenant of the context.
+func (s Service) Add(ctx context.Context, metric Metric, count int64) error {
+	if !metric.IsValid() {
+		return sdkutil.LoggingNewErrorf("unknown metric: %s", metric)
+	}
+	if count == 0 {
+		return nil
+	}
+	return s.storage.AddToRecord(ctx, Record{Day: Day(s.Clock.Now()), Metric: metric, Count: count})
+}
+
+// GetStats returns the statistics of the tenant of the context, with time series of the last request.Days days.
+func (s Service) GetStats(ctx context.Context, request GetStatsRequest) (*Stats, error) {
+	if err := request.IsValid(); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid get stats request")
+	}
+	days := request.Days
+	if days == 0 {
+		days = DefaultDays
+	}
+	now := s.Clock.Now().UTC()
+	stats := Stats{
+		From:                 Day(now.AddDate(0, 0, 1-days)),
+		To:                   Day(now),
+		DIDsByMethod:         make(map[string]int, len(s.methods)),
+		ApplicationsByStatus: make(map[string]int, len(applicationStatuses)),
+	}
+
+	for _, method := range s.methods {
+		count, err := s.dids.CountDIDs(ctx, method, false)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "could not count DIDs for method<%s>", method)
+		}
+		stats.DIDsByMethod[method] = count
+	}
+
+	for _, status := range applicationStatuses {
+		stats.ApplicationsByStatus[status.String()] = 0
+	}
+	applications, err := s.applications.ListApplications(ctx)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list applications")
+	}
+	for _, application := range applications {
+		stats.ApplicationsByStatus[application.Status.String()]++
+	}
+
+	records, err := s.storage.ListRecords(ctx, stats.From, stats.To)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(records))
+	for _, record := range records {
+		counts[record.Key()] += record.Count
+	}
+	series := func(metric Metric) []DailyCount {
+		dailyCounts := make([]DailyCount, 0, days)
+		for i := days - 1; i >= 0; i-- {
+			day := Day(now.AddDate(0, 0, -i))
+			dailyCounts = append(dailyCounts, DailyCount{Day: day, Count: counts[Record{Day: day, Metric: metric}.Key()]})
+		}
+		return dailyCounts
+	}
+	stats.CredentialsIssued = series(MetricCredentialsIssued)
+	stats.CredentialsRevoked = series(MetricCredentialsRevoked)
+	stats.VerificationsSucceeded = series(MetricVerificationsSucceeded)
+	stats.VerificationsFailed = series(MetricVerificationsFailed)
+	return &stats, nil
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TBD54566975/ssi-sdk/credential/manifest"
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/did"
+	manifeststg "github.com/tbd54566975/ssi-service/pkg/service/manifest/storage"
+	opcredential "github.com/tbd54566975/ssi-service/pkg/service/operation/credential"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestGetStats(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			db := test.ServiceStorage(t)
+			statsStorage, err := NewStatsStorage(db)
+			require.NoError(t, err)
+			didStorage, err := did.NewDIDStorage(db)
+			require.NoError(t, err)
+			applicationStorage, err := manifeststg.NewManifestStorage(db)
+			require.NoError(t, err)
+			mockClock := clock.NewMock()
+			mockClock.Set(time.Date(2023, 10, 2, 23, 0, 0, 0, time.UTC))
+			s := Service{
+				storage:      statsStorage,
+				dids:         didStorage,
+				applications: applicationStorage,
+				methods:      []string{"key", "web"},
+				Clock:        mockClock,
+			}
+
+			for _, stored := range []did.DefaultStoredDID{
+				{ID: "did:key:z6Mk1"},
+				{ID: "did:key:z6Mk2"},
+				{ID: "did:key:z6Mk3", SoftDeleted: true},
+			} {
+				require.NoError(t, didStorage.StoreDID(ctx, stored))
+			}
+			for id, status := range map[string]opcredential.Status{
+				"app-1": opcredential.StatusPending,
+				"app-2": opcredential.StatusPending,
+				"app-3": opcredential.StatusFulfilled,
+			} {
+				require.NoError(t, applicationStorage.StoreApplication(ctx, manifeststg.StoredApplication{
+					ID:          id,
+					Status:      status,
+					Application: manifest.CredentialApplication{ID: id},
+				}))
+			}
+
+			// events are counted on the UTC day they happen
+			require.NoError(t, s.Add(ctx, MetricCredentialsIssued, 2))
+			mockClock.Add(2 * time.Hour)
+			require.NoError(t, s.Add(ctx, MetricCredentialsIssued, 1))
+			require.NoError(t, s.Add(ctx, MetricCredentialsIssued, 2))
+			require.NoError(t, s.Add(ctx, MetricCredentialsRevoked, 1))
+			require.NoError(t, s.Add(ctx, MetricVerificationsSucceeded, 1))
+			require.NoError(t, s.Add(ctx, MetricVerificationsFailed, 2))
+			assert.Error(t, s.Add(ctx, "signatures", 1))
+
+			got, err := s.GetStats(ctx, GetStatsRequest{Days: 3})
+			require.NoError(t, err)
+			assert.Equal(t, "2023-10-01", got.From)
+			assert.Equal(t, "2023-10-03", got.To)
+			assert.Equal(t, map[string]int{"key": 2, "web": 0}, got.DIDsByMethod)
+			assert.Equal(t, map[string]int{"pending": 2, "fulfilled": 1, "rejected": 0, "cancelled": 0}, got.ApplicationsByStatus)
+			assert.Equal(t, []DailyCount{{Day: "2023-10-01"}, {Day: "2023-10-02", Count: 2}, {Day: "2023-10-03", Count: 3}}, got.CredentialsIssued)
+			assert.Equal(t, []DailyCount{{Day: "2023-10-01"}, {Day: "2023-10-02"}, {Day: "2023-10-03", Count: 1}}, got.CredentialsRevoked)
+			assert.EqualValues(t, 1, got.VerificationsSucceeded[2].Count)
+			assert.EqualValues(t, 2, got.VerificationsFailed[2].Count)
+
+			// days before the time series aren't counted, and it defaults to 30 days
+			got, err = s.GetStats(ctx, GetStatsRequest{Days: 1})
+			require.NoError(t, err)
+			assert.Equal(t, []DailyCount{{Day: "2023-10-03", Count: 3}}, got.CredentialsIssued)
+			got, err = s.GetStats(ctx, GetStatsRequest{})
+			require.NoError(t, err)
+			assert.Len(t, got.CredentialsIssued, DefaultDays)
+			assert.Equal(t, "2023-09-04", got.From)
+
+			_, err = s.GetStats(ctx, GetStatsRequest{Days: MaxDays + 1})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// statsNamespace holds the daily counts of the metrics, keyed by Record.Key.
+const statsNamespace = "stats"
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewStatsStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// AddToRecord adds count to the record, creating it when it doesn't exist yet. The record is read and written in a
+// transaction that watches it, so that instances sharing the storage don't lose each other's counts.
+func (s *Storage) AddToRecord(ctx context.Context, record Record) error {
+	key := record.Key()
+	watchKeys := []storage.WatchKey{{Namespace: statsNamespace, Key: key}}
+	_, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		recordBytes, err := s.db.Read(ctx, statsNamespace, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading stats record")
+		}
+		if len(recordBytes) > 0 {
+			var stored Record
+			if err = json.Unmarshal(recordBytes, &stored); err != nil {
+				return nil, errors.Wrap(err, "unmarshalling stats record")
+			}
+			record.Count += stored.Count
+		}
+		if recordBytes, err = json.Marshal(record); err != nil {
+			return nil, errors.Wrap(err, "marshalling stats record")
+		}
+		return nil, tx.Write(ctx, statsNamespace, key, recordBytes)
+	}, watchKeys)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not add to stats record: %s", key)
+	}
+	return nil
+}
+
+// ListRecords returns the records of the days from from to to, inclusive.
+func (s *Storage) ListRecords(ctx context.Context, from, to string) ([]Record, error) {
+	var records []Record
+	err := s.db.Iterate(ctx, statsNamespace, func(key string, recordBytes []byte) (bool, error) {
+		var record Record
+		if err := json.Unmarshal(recordBytes, &record); err != nil {
+			logrus.WithError(err).Warnf("unmarshal stats record: %s", key)
+			return true, nil
+		}
+		if record.Day >= from && record.Day <= to {
+			records = append(records, record)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list stats records")
+	}
+	return records, nil
+}
@@ -0,0 +1,248 @@
+package transparency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+// The tree is the Merkle tree of RFC 6962 (https://www.rfc-editor.org/rfc/rfc6962#section-2.1), so that auditors can
+// verify its proofs with any Certificate Transparency library. Leaves and nodes are hashed with different prefixes, so
+// that a node can't be passed off as a leaf.
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// LeafHash returns the hash of a leaf of the tree holding data.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash returns the hash of a node of the tree with the given children.
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// emptyRootHash is the root hash of a tree without leaves.
+func emptyRootHash() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+// nodeReader reads the hash of the perfect subtree at a level of the tree, which covers the leaves from index<<level
+// up to, but not including, (index+1)<<level. Level 0 holds the hashes of the leaves.
+type nodeReader func(ctx context.Context, level uint8, index uint64) ([]byte, error)
+
+// splitPoint returns the largest power of two smaller than n, which is where RFC 6962 splits a tree of n > 1 leaves.
+func splitPoint(n uint64) uint64 {
+	k := uint64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// perfectLevel returns the level of the perfect subtree covering the leaves from lo up to hi, and whether they make one.
+func perfectLevel(lo, hi uint64) (uint8, bool) {
+	n := hi - lo
+	if n == 0 || n&(n-1) != 0 || lo%n != 0 {
+		return 0, false
+	}
+	var level uint8
+	for n > 1 {
+		n >>= 1
+		level++
+	}
+	return level, true
+}
+
+// subtreeHash returns the hash of the tree of the leaves from lo up to, but not including, hi. Only the hashes of the
+// perfect subtrees are stored, so it reads O(log(hi-lo)) of them.
+func subtreeHash(ctx context.Context, read nodeReader, lo, hi uint64) ([]byte, error) {
+	if level, ok := perfectLevel(lo, hi); ok {
+		return read(ctx, level, lo>>level)
+	}
+	k := splitPoint(hi - lo)
+	left, err := subtreeHash(ctx, read, lo, lo+k)
+	if err != nil {
+		return nil, err
+	}
+	right, err := subtreeHash(ctx, read, lo+k, hi)
+	if err != nil {
+		return nil, err
+	}
+	return NodeHash(left, right), nil
+}
+
+// rootHash returns the root hash of the tree of the first size leaves.
+func rootHash(ctx context.Context, read nodeReader, size uint64) ([]byte, error) {
+	if size == 0 {
+		return emptyRootHash(), nil
+	}
+	return subtreeHash(ctx, read, 0, size)
+}
+
+// inclusionProof returns the audit path of the leaf at index m in the tree of the leaves from lo up to hi, which is
+// PATH(m, D[lo:hi]) of RFC 6962.
+func inclusionProof(ctx context.Context, read nodeReader, m, lo, hi uint64) ([][]byte, error) {
+	if hi-lo == 1 {
+		return nil, nil
+	}
+	k := splitPoint(hi - lo)
+	var path [][]byte
+	var sibling []byte
+	var err error
+	if m < lo+k {
+		if path, err = inclusionProof(ctx, read, m, lo, lo+k); err != nil {
+			return nil, err
+		}
+		sibling, err = subtreeHash(ctx, read, lo+k, hi)
+	} else {
+		if path, err = inclusionProof(ctx, read, m, lo+k, hi); err != nil {
+			return nil, err
+		}
+		sibling, err = subtreeHash(ctx, read, lo, lo+k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(path, sibling), nil
+}
+
+// consistencyProof returns the proof that the tree of the first m leaves from lo is a prefix of the tree of the leaves
+// from lo up to hi, which is SUBPROOF(m, D[lo:hi], complete) of RFC 6962.
+func consistencyProof(ctx context.Context, read nodeReader, m, lo, hi uint64, complete bool) ([][]byte, error) {
+	if m == hi-lo {
+		if complete {
+			return nil, nil
+		}
+		hash, err := subtreeHash(ctx, read, lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{hash}, nil
+	}
+	k := splitPoint(hi - lo)
+	var proof [][]byte
+	var sibling []byte
+	var err error
+	if m <= k {
+		if proof, err = consistencyProof(ctx, read, m, lo, lo+k, complete); err != nil {
+			return nil, err
+		}
+		sibling, err = subtreeHash(ctx, read, lo+k, hi)
+	} else {
+		if proof, err = consistencyProof(ctx, read, m-k, lo+k, hi, false); err != nil {
+			return nil, err
+		}
+		sibling, err = subtreeHash(ctx, read, lo, lo+k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, sibling), nil
+}
+
+// VerifyInclusion checks that the leaf with the given hash is at index in the tree of size leaves with the given root
+// hash, following https://www.rfc-editor.org/rfc/rfc9162#section-2.1.3.2.
+func VerifyInclusion(leafHash []byte, index, size uint64, proof [][]byte, root []byte) error {
+	if index >= size {
+		return errors.Errorf("index %d is outside of a tree of size %d", index, size)
+	}
+	fn, sn := index, size-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return errors.New("inclusion proof is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("inclusion proof is too short")
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("inclusion proof doesn't lead to the root hash")
+	}
+	return nil
+}
+
+// VerifyConsistency checks that the tree of first leaves with the first root hash is a prefix of the tree of second
+// leaves with the second root hash, following https://www.rfc-editor.org/rfc/rfc9162#section-2.1.4.2.
+func VerifyConsistency(first, second uint64, proof [][]byte, firstRoot, secondRoot []byte) error {
+	switch {
+	case first > second:
+		return errors.Errorf("tree of size %d can't be a prefix of a tree of size %d", first, second)
+	case first == second:
+		if len(proof) > 0 {
+			return errors.New("consistency proof of a tree with itself must be empty")
+		}
+		if !bytes.Equal(firstRoot, secondRoot) {
+			return errors.New("root hashes of trees of the same size differ")
+		}
+		return nil
+	case first == 0:
+		if len(proof) > 0 {
+			return errors.New("consistency proof of an empty tree must be empty")
+		}
+		return nil
+	case len(proof) == 0:
+		return errors.New("consistency proof is empty")
+	}
+
+	if first&(first-1) == 0 {
+		proof = append([][]byte{firstRoot}, proof...)
+	}
+	fn, sn := first-1, second-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return errors.New("consistency proof is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("consistency proof is too short")
+	}
+	if !bytes.Equal(fr, firstRoot) {
+		return errors.New("consistency proof doesn't lead to the first root hash")
+	}
+	if !bytes.Equal(sr, secondRoot) {
+		return errors.New("consistency proof doesn't lead to the second root hash")
+	}
+	return nil
+}
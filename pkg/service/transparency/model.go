@@ -0,0 +1,113 @@
+package transparency
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gowebpki/jcs"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/internal/keyaccess"
+)
+
+// Operation is what was done to the credential an entry of the log records.
+type Operation string
+
+const (
+	OperationIssued     Operation = "issued"
+	OperationRevoked    Operation = "revoked"
+	OperationSuspended  Operation = "suspended"
+	OperationReinstated Operation = "reinstated"
+)
+
+// Entry is a leaf of the log. The hash of the leaf is the LeafHash of the JSON of the entry, canonicalized with
+// https://www.rfc-editor.org/rfc/rfc8785, so auditors can compute it from the entries the log serves.
+type Entry struct {
+	// Position of the entry in the log, starting at 0.
+	Index uint64 `json:"index"`
+
+	Operation Operation `json:"operation"`
+
+	// ID of the credential, as stored by the service.
+	CredentialID string `json:"credentialId"`
+
+	// SHA-256 hash of the credential, which is the hash of its JWT.
+	CredentialHash []byte `json:"credentialHash"`
+
+	// DID of the issuer of the credential.
+	Issuer string `json:"issuer"`
+
+	// When the entry was appended.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LeafHash returns the hash of the leaf of the entry.
+func (e Entry) LeafHash() ([]byte, error) {
+	entryBytes, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling entry")
+	}
+	canonical, err := jcs.Transform(entryBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "canonicalizing entry")
+	}
+	return LeafHash(canonical), nil
+}
+
+// TreeHead describes the log when it had TreeSize entries.
+type TreeHead struct {
+	TreeSize uint64 `json:"treeSize"`
+
+	// Root hash of the Merkle tree of the entries.
+	RootHash []byte `json:"rootHash"`
+
+	// When the last entry was appended. Empty when the log has no entries.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+type GetSignedTreeHeadResponse struct {
+	TreeHead
+
+	// DID the tree head is signed by.
+	LogID string `json:"logId"`
+
+	// JWT signed by LogID whose claims are the fields of the tree head.
+	SignedTreeHead keyaccess.JWT `json:"signedTreeHead"`
+}
+
+type ListEntriesRequest struct {
+	PageToken string
+	PageSize  int
+}
+
+type ListEntriesResponse struct {
+	Entries       []Entry
+	NextPageToken string
+}
+
+type ListCredentialEntriesResponse struct {
+	Entries []Entry
+}
+
+type GetInclusionProofRequest struct {
+	Index    uint64
+	TreeSize uint64
+}
+
+type GetInclusionProofResponse struct {
+	Index    uint64
+	TreeSize uint64
+	LeafHash []byte
+	Path     [][]byte
+}
+
+type GetConsistencyProofRequest struct {
+	First  uint64
+	Second uint64
+}
+
+type GetConsistencyProofResponse struct {
+	First  uint64
+	Second uint64
+	Proof  [][]byte
+}
@@ -0,0 +1,209 @@
+package transparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	credint "github.com/tbd54566975/ssi-service/internal/credential"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// ErrOutsideOfLog is returned for entries, and trees, the log hasn't grown to.
+var ErrOutsideOfLog = errors.New("outside of the log")
+
+// Service keeps the transparency log of each tenant, an append-only Merkle tree of the credentials it issued, revoked,
+// suspended, and reinstated. The log serves signed tree heads, and proofs that an entry is included in a tree head, and
+// that a tree head extends an earlier one, so auditors can check that the issuer doesn't back-date or delete entries.
+type Service struct {
+	storage  *Storage
+	keyStore *keystore.Service
+	Clock    clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Transparency
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if s.keyStore == nil {
+		ae.AppendString("no key store service configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("transparency service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewTransparencyService(s storage.ServiceStorage, keyStore *keystore.Service) (*Service, error) {
+	transparencyStorage, err := NewTransparencyStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the transparency service")
+	}
+	service := Service{storage: transparencyStorage, keyStore: keyStore, Clock: clock.New()}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// Append appends an entry recording the operation for each of the credentials to the log, in one transaction.
+func (s Service) Append(ctx context.Context, operation Operation, containers ...credint.Container) ([]Entry, error) {
+	if len(containers) == 0 {
+		return nil, nil
+	}
+	now := s.Clock.Now().UTC()
+	entries := make([]Entry, 0, len(containers))
+	for _, container := range containers {
+		credentialHash, err := hashCredential(container)
+		if err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "hashing credential: %s", container.ID)
+		}
+		entries = append(entries, Entry{
+			Operation:      operation,
+			CredentialID:   container.ID,
+			CredentialHash: credentialHash,
+			Issuer:         container.Credential.IssuerID(),
+			Timestamp:      now,
+		})
+	}
+	if _, err := s.storage.AppendEntries(ctx, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// hashCredential returns the SHA-256 hash of the JWT or SD-JWT of a credential, or of its JSON when it's neither.
+func hashCredential(container credint.Container) ([]byte, error) {
+	var credentialBytes []byte
+	if container.CredentialJWT != nil {
+		credentialBytes = []byte(container.CredentialJWT.String())
+	} else if container.CredentialSDJWT != nil {
+		credentialBytes = []byte(container.CredentialSDJWT.String())
+	} else {
+		var err error
+		if credentialBytes, err = json.Marshal(container.Credential); err != nil {
+			return nil, err
+		}
+	}
+	hash := sha256.Sum256(credentialBytes)
+	return hash[:], nil
+}
+
+// GetSignedTreeHead returns the current tree head of the log, signed by the key of the log.
+func (s Service) GetSignedTreeHead(ctx context.Context) (*GetSignedTreeHeadResponse, error) {
+	head, err := s.storage.GetTreeHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logKey, err := s.getLogKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := s.keyStore.Sign(ctx, logKey.KeyID, head)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "signing tree head")
+	}
+	return &GetSignedTreeHeadResponse{TreeHead: *head, LogID: logKey.DID, SignedTreeHead: *signed}, nil
+}
+
+// getLogKey returns the key of the log, creating a did:key for it the first time the log signs a tree head. Each tenant
+// has its own.
+func (s Service) getLogKey(ctx context.Context) (*keystore.DIDKey, error) {
+	return s.keyStore.GetOrCreateDIDKey(ctx, s.storage.db, namespace, logKeyKey)
+}
+
+// ListEntries returns a page of the entries of the log, ordered by their index within the page.
+func (s Service) ListEntries(ctx context.Context, request ListEntriesRequest) (*ListEntriesResponse, error) {
+	entries, nextPageToken, err := s.storage.ListEntries(ctx, request.PageToken, request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ListEntriesResponse{Entries: entries, NextPageToken: nextPageToken}, nil
+}
+
+// ListCredentialEntries returns the entries of the log recording operations on a credential, ordered by their index.
+func (s Service) ListCredentialEntries(ctx context.Context, credentialID string) (*ListCredentialEntriesResponse, error) {
+	entries, err := s.storage.ListCredentialEntries(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	return &ListCredentialEntriesResponse{Entries: entries}, nil
+}
+
+// GetInclusionProof returns the proof that the entry at an index is included in the tree of the first TreeSize
+// entries, or of all entries when TreeSize is 0.
+func (s Service) GetInclusionProof(ctx context.Context, request GetInclusionProofRequest) (*GetInclusionProofResponse, error) {
+	treeSize, err := s.treeSize(ctx, request.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	if request.Index >= treeSize {
+		return nil, errors.Wrapf(ErrOutsideOfLog, "index %d is outside of a tree of size %d", request.Index, treeSize)
+	}
+	entry, err := s.storage.GetEntry(ctx, request.Index)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, sdkutil.LoggingNewErrorf("entry %d could not be found", request.Index)
+	}
+	leafHash, err := entry.LeafHash()
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "hashing entry: %d", request.Index)
+	}
+	path, err := inclusionProof(ctx, s.storage.readNode, request.Index, 0, treeSize)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not compute inclusion proof")
+	}
+	return &GetInclusionProofResponse{Index: request.Index, TreeSize: treeSize, LeafHash: leafHash, Path: path}, nil
+}
+
+// GetConsistencyProof returns the proof that the tree of the first First entries is a prefix of the tree of the first
+// Second entries, or of all entries when Second is 0.
+func (s Service) GetConsistencyProof(ctx context.Context, request GetConsistencyProofRequest) (*GetConsistencyProofResponse, error) {
+	second, err := s.treeSize(ctx, request.Second)
+	if err != nil {
+		return nil, err
+	}
+	if request.First > second {
+		return nil, errors.Wrapf(ErrOutsideOfLog, "tree of size %d can't be a prefix of a tree of size %d", request.First, second)
+	}
+	var proof [][]byte
+	if request.First > 0 {
+		if proof, err = consistencyProof(ctx, s.storage.readNode, request.First, 0, second, true); err != nil {
+			return nil, sdkutil.LoggingErrorMsg(err, "could not compute consistency proof")
+		}
+	}
+	return &GetConsistencyProofResponse{First: request.First, Second: second, Proof: proof}, nil
+}
+
+// treeSize returns the requested tree size, or the size of the tree when none is requested, checking that the log has
+// grown to it.
+func (s Service) treeSize(ctx context.Context, requested uint64) (uint64, error) {
+	head, err := s.storage.GetTreeHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if requested == 0 {
+		return head.TreeSize, nil
+	}
+	if requested > head.TreeSize {
+		return 0, errors.Wrapf(ErrOutsideOfLog, "tree size %d is larger than the log, which has %d entries", requested, head.TreeSize)
+	}
+	return requested, nil
+}
@@ -0,0 +1,220 @@
+package transparency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/keystore"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+const (
+	// namespace holds the tree head of the log, and its key.
+	namespace = "transparency"
+	headKey   = "head"
+	logKeyKey = "key"
+
+	// entryNamespace holds the entries of the log, keyed by their zero-padded index so they're read in order.
+	entryNamespace = "transparency-entry"
+
+	// credentialNamespace indexes the entries of each credential, keyed by the credential ID and the entry index.
+	credentialNamespace = "transparency-credential"
+
+	// nodeNamespace holds the hashes of the perfect subtrees of the tree, keyed by their level and index.
+	nodeNamespace = "transparency-node"
+)
+
+func entryKey(index uint64) string {
+	return fmt.Sprintf("%020d", index)
+}
+
+func nodeKey(level uint8, index uint64) string {
+	return storage.Join(fmt.Sprintf("%02d", level), fmt.Sprintf("%020d", index))
+}
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewTransparencyStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// AppendEntries appends the entries to the log, setting their indexes, and returns the new tree head. Entries are
+// appended in a transaction that watches the tree head, so that instances sharing the storage don't append entries at
+// the same index.
+func (s *Storage) AppendEntries(ctx context.Context, entries []Entry) (*TreeHead, error) {
+	watchKeys := []storage.WatchKey{{Namespace: namespace, Key: headKey}}
+	result, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		head, err := s.GetTreeHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// nodes written by the transaction aren't visible to reads until it commits, so they're read from here first
+		written := make(map[string][]byte)
+		read := func(ctx context.Context, level uint8, index uint64) ([]byte, error) {
+			if hash, ok := written[nodeKey(level, index)]; ok {
+				return hash, nil
+			}
+			return s.readNode(ctx, level, index)
+		}
+		write := func(ctx context.Context, level uint8, index uint64, hash []byte) error {
+			written[nodeKey(level, index)] = hash
+			return tx.Write(ctx, nodeNamespace, nodeKey(level, index), hash)
+		}
+
+		size := head.TreeSize
+		for i := range entries {
+			entries[i].Index = size
+			if err = s.writeEntry(ctx, tx, entries[i]); err != nil {
+				return nil, err
+			}
+			hash, err := entries[i].LeafHash()
+			if err != nil {
+				return nil, err
+			}
+
+			// every perfect subtree the leaf completes is stored, so that hashes of the tree are read in O(log n)
+			level, index := uint8(0), size
+			if err = write(ctx, level, index, hash); err != nil {
+				return nil, err
+			}
+			for index&1 == 1 {
+				left, err := read(ctx, level, index-1)
+				if err != nil {
+					return nil, err
+				}
+				hash = NodeHash(left, hash)
+				level, index = level+1, index>>1
+				if err = write(ctx, level, index, hash); err != nil {
+					return nil, err
+				}
+			}
+			size++
+		}
+
+		root, err := rootHash(ctx, read, size)
+		if err != nil {
+			return nil, errors.Wrap(err, "computing root hash")
+		}
+		timestamp := entries[len(entries)-1].Timestamp
+		newHead := TreeHead{TreeSize: size, RootHash: root, Timestamp: &timestamp}
+		headBytes, err := json.Marshal(newHead)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling tree head")
+		}
+		if err = tx.Write(ctx, namespace, headKey, headBytes); err != nil {
+			return nil, err
+		}
+		return &newHead, nil
+	}, watchKeys)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not append entries to the transparency log")
+	}
+	return result.(*TreeHead), nil
+}
+
+func (s *Storage) writeEntry(ctx context.Context, tx storage.Tx, entry Entry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshalling entry")
+	}
+	if err = tx.Write(ctx, entryNamespace, entryKey(entry.Index), entryBytes); err != nil {
+		return err
+	}
+	return tx.Write(ctx, credentialNamespace, storage.Join(entry.CredentialID, entryKey(entry.Index)), entryBytes)
+}
+
+// GetTreeHead returns the current tree head of the log, which is the head of an empty tree before the first entry is
+// appended.
+func (s *Storage) GetTreeHead(ctx context.Context) (*TreeHead, error) {
+	headBytes, err := s.db.Read(ctx, namespace, headKey)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get tree head")
+	}
+	if len(headBytes) == 0 {
+		return &TreeHead{RootHash: emptyRootHash()}, nil
+	}
+	var head TreeHead
+	if err = json.Unmarshal(headBytes, &head); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "unmarshalling tree head")
+	}
+	return &head, nil
+}
+
+func (s *Storage) readNode(ctx context.Context, level uint8, index uint64) ([]byte, error) {
+	hash, err := s.db.Read(ctx, nodeNamespace, nodeKey(level, index))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading node %d at level %d", index, level)
+	}
+	if len(hash) == 0 {
+		return nil, errors.Errorf("node %d at level %d doesn't exist", index, level)
+	}
+	return hash, nil
+}
+
+// GetEntry returns the entry at index, or nil when there isn't one.
+func (s *Storage) GetEntry(ctx context.Context, index uint64) (*Entry, error) {
+	entryBytes, err := s.db.Read(ctx, entryNamespace, entryKey(index))
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get entry: %d", index)
+	}
+	if len(entryBytes) == 0 {
+		return nil, nil
+	}
+	var entry Entry
+	if err = json.Unmarshal(entryBytes, &entry); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling entry: %d", index)
+	}
+	return &entry, nil
+}
+
+// ListEntries returns the entries in the page with the given token and size, ordered by their index within the page. A
+// size of -1 means all entries.
+func (s *Storage) ListEntries(ctx context.Context, token string, size int) ([]Entry, string, error) {
+	entriesBytes, nextPageToken, err := s.db.ReadPage(ctx, entryNamespace, token, size)
+	if err != nil {
+		return nil, "", sdkutil.LoggingErrorMsg(err, "could not read page of entries")
+	}
+	entries, err := unmarshalEntries(entriesBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, nextPageToken, nil
+}
+
+// ListCredentialEntries returns the entries of a credential, ordered by their index.
+func (s *Storage) ListCredentialEntries(ctx context.Context, credentialID string) ([]Entry, error) {
+	entriesBytes, err := s.db.ReadPrefix(ctx, credentialNamespace, storage.Join(credentialID, ""))
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not read entries of credential: %s", credentialID)
+	}
+	return unmarshalEntries(entriesBytes)
+}
+
+func unmarshalEntries(entriesBytes map[string][]byte) ([]Entry, error) {
+	entries := make([]Entry, 0, len(entriesBytes))
+	for key, entryBytes := range entriesBytes {
+		var entry Entry
+		if err := json.Unmarshal(entryBytes, &entry); err != nil {
+			return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling entry: %s", key)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Index < entries[j].Index })
+	return entries, nil
+}
+
+// GetLogKey returns the key the log signs its tree heads with, or nil before one is created.
+func (s *Storage) GetLogKey(ctx context.Context) (*keystore.DIDKey, error) {
+	return keystore.GetDIDKey(ctx, s.db, namespace, logKeyKey)
+}
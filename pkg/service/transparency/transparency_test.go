@@ -0,0 +1,111 @@
+package transparency
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+// referenceRootHash computes MTH(D[n]) of RFC 6962 from all the leaf hashes.
+func referenceRootHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return emptyRootHash()
+	case 1:
+		return leaves[0]
+	}
+	k := splitPoint(uint64(len(leaves)))
+	return NodeHash(referenceRootHash(leaves[:k]), referenceRootHash(leaves[k:]))
+}
+
+func TestTransparencyStorage(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			s, err := NewTransparencyStorage(test.ServiceStorage(t))
+			require.NoError(t, err)
+
+			head, err := s.GetTreeHead(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, head.TreeSize)
+			assert.Equal(t, emptyRootHash(), head.RootHash)
+
+			// entries are appended one at a time, then in batches, which must build the same tree
+			const size = 21
+			var leaves [][]byte
+			var roots [][]byte
+			for appended := 0; appended < size; {
+				batch := 1
+				if appended >= 8 && size-appended >= 3 {
+					batch = 3
+				}
+				entries := make([]Entry, 0, batch)
+				for i := 0; i < batch; i++ {
+					entries = append(entries, Entry{
+						Operation:      OperationIssued,
+						CredentialID:   fmt.Sprintf("credential-%d", (appended+i)%5),
+						CredentialHash: []byte{byte(appended + i)},
+						Issuer:         "did:example:issuer",
+						Timestamp:      time.Unix(int64(appended+i), 0).UTC(),
+					})
+				}
+				head, err = s.AppendEntries(ctx, entries)
+				require.NoError(t, err)
+				for _, entry := range entries {
+					leaf, err := entry.LeafHash()
+					require.NoError(t, err)
+					leaves = append(leaves, leaf)
+					roots = append(roots, referenceRootHash(leaves))
+				}
+				appended += batch
+				require.Equal(t, uint64(appended), head.TreeSize)
+				require.Equal(t, roots[appended-1], head.RootHash)
+			}
+
+			for treeSize := uint64(1); treeSize <= size; treeSize++ {
+				root := roots[treeSize-1]
+				for index := uint64(0); index < treeSize; index++ {
+					path, err := inclusionProof(ctx, s.readNode, index, 0, treeSize)
+					require.NoError(t, err)
+					assert.NoError(t, VerifyInclusion(leaves[index], index, treeSize, path, root), "index %d of %d", index, treeSize)
+					assert.Error(t, VerifyInclusion(leaves[index], index, treeSize, path, emptyRootHash()))
+					if index > 0 {
+						assert.Error(t, VerifyInclusion(leaves[index-1], index, treeSize, path, root))
+					}
+				}
+				for first := uint64(1); first <= treeSize; first++ {
+					proof, err := consistencyProof(ctx, s.readNode, first, 0, treeSize, true)
+					require.NoError(t, err)
+					assert.NoError(t, VerifyConsistency(first, treeSize, proof, roots[first-1], root), "%d of %d", first, treeSize)
+					if first < treeSize {
+						assert.Error(t, VerifyConsistency(first, treeSize, proof, roots[first], root))
+					}
+				}
+			}
+
+			entries, nextPageToken, err := s.ListEntries(ctx, "", -1)
+			require.NoError(t, err)
+			require.Len(t, entries, size)
+			assert.Empty(t, nextPageToken)
+			for i, entry := range entries {
+				assert.Equal(t, uint64(i), entry.Index)
+			}
+
+			credentialEntries, err := s.ListCredentialEntries(ctx, "credential-1")
+			require.NoError(t, err)
+			require.Len(t, credentialEntries, 4)
+			for i, entry := range credentialEntries {
+				assert.Equal(t, uint64(1+5*i), entry.Index)
+				leaf, err := entry.LeafHash()
+				require.NoError(t, err)
+				assert.Equal(t, leaves[entry.Index], leaf)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package trust
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// TrustListFormat is the format of a trust list that trusted issuers are imported from.
+type TrustListFormat string
+
+const (
+	// TrustListFormatEBSI is the issuers of the EBSI Trusted Issuers Registry, with their accreditations.
+	TrustListFormatEBSI TrustListFormat = "ebsi"
+	// TrustListFormatTRAIN is a TRAIN trust list, the JSON form of an ETSI TS 119 612 trust status list whose
+	// services are identified by DIDs.
+	TrustListFormatTRAIN TrustListFormat = "train"
+)
+
+// TrustedIssuer is an issuer trusted to issue the credentials of a type, or of a schema. Once an issuer is trusted for
+// a type or schema, credentials of the type or schema are only trusted when one of the issuers trusted for it issued
+// them.
+type TrustedIssuer struct {
+	ID string `json:"id"`
+
+	// DID of the issuer.
+	Issuer string `json:"issuer"`
+
+	// Type of the credentials the issuer is trusted to issue, e.g. UniversityDegreeCredential.
+	CredentialType string `json:"credentialType,omitempty"`
+
+	// ID of the schema of the credentials the issuer is trusted to issue.
+	SchemaID string `json:"schemaId,omitempty"`
+
+	// Source of the trust list the issuer was imported from, and its format. Empty for issuers that were registered
+	// one by one.
+	Source string          `json:"source,omitempty"`
+	Format TrustListFormat `json:"format,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateTrustedIssuerRequest struct {
+	Issuer         string `json:"issuer" validate:"required,startswith=did:"`
+	CredentialType string `json:"credentialType" validate:"required_without=SchemaID,excluded_with=SchemaID"`
+	SchemaID       string `json:"schemaId"`
+}
+
+type GetTrustedIssuerRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// ListTrustedIssuersRequest lists the trusted issuers, limited to those with the issuer, type, schema, and source
+// that are set.
+type ListTrustedIssuersRequest struct {
+	Issuer         string
+	CredentialType string
+	SchemaID       string
+	Source         string
+}
+
+type ListTrustedIssuersResponse struct {
+	TrustedIssuers []TrustedIssuer `json:"trustedIssuers"`
+}
+
+type DeleteTrustedIssuerRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// ImportTrustListRequest imports the issuers of a trust list, replacing those imported from the same source before.
+type ImportTrustListRequest struct {
+	Format TrustListFormat `json:"format" validate:"required,oneof=ebsi train"`
+
+	// Source of the trust list, e.g. the URL it was fetched from, which the issuers are imported from.
+	Source string `json:"source" validate:"required"`
+
+	// The trust list.
+	TrustList json.RawMessage `json:"trustList" validate:"required"`
+
+	// Type, or schema, that the issuers the trust list doesn't say the types and schemas of are trusted for.
+	CredentialType string `json:"credentialType" validate:"excluded_with=SchemaID"`
+	SchemaID       string `json:"schemaId"`
+}
+
+type ImportTrustListResponse struct {
+	// The issuers imported from the trust list.
+	TrustedIssuers []TrustedIssuer `json:"trustedIssuers"`
+
+	// How many of the issuers imported from the source before were replaced.
+	Replaced int `json:"replaced"`
+}
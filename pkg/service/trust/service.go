@@ -0,0 +1,239 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+var (
+	// ErrInvalidTrustList is returned for trust lists that can't be read in their format.
+	ErrInvalidTrustList = errors.New("invalid trust list")
+	// ErrUntrustedIssuer is returned for credentials of a type or schema that their issuer isn't trusted for.
+	ErrUntrustedIssuer = errors.New("untrusted issuer")
+)
+
+// Service is the trust registry, the issuers that operators trust to issue the credentials of a type or schema. It's
+// consulted when credentials are verified, and when presentation submissions are evaluated.
+type Service struct {
+	storage *Storage
+	config  config.TrustServiceConfig
+
+	Clock clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Trust
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("trust service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+// NewTrustService creates the trust service, which keeps the trusted issuers of each tenant in its storage.
+func NewTrustService(cfg config.TrustServiceConfig, s storage.ServiceStorage) (*Service, error) {
+	trustStorage, err := NewTrustStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate storage for the trust service")
+	}
+	service := Service{
+		storage: trustStorage,
+		config:  cfg,
+		Clock:   clock.New(),
+	}
+	if !service.Status().IsReady() {
+		return nil, errors.New(service.Status().Message)
+	}
+	return &service, nil
+}
+
+// CreateTrustedIssuer trusts an issuer to issue the credentials of a type, or of a schema.
+func (s Service) CreateTrustedIssuer(ctx context.Context, request CreateTrustedIssuerRequest) (*TrustedIssuer, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid create trusted issuer request")
+	}
+	issuer := TrustedIssuer{
+		ID:             uuid.NewString(),
+		Issuer:         request.Issuer,
+		CredentialType: request.CredentialType,
+		SchemaID:       request.SchemaID,
+		CreatedAt:      s.Clock.Now(),
+	}
+	if err := s.storage.StoreTrustedIssuers(ctx, issuer); err != nil {
+		return nil, err
+	}
+	return &issuer, nil
+}
+
+func (s Service) GetTrustedIssuer(ctx context.Context, request GetTrustedIssuerRequest) (*TrustedIssuer, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid get trusted issuer request")
+	}
+	issuer, err := s.storage.GetTrustedIssuer(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if issuer == nil {
+		return nil, sdkutil.LoggingNewErrorf("trusted issuer not found: %s", request.ID)
+	}
+	return issuer, nil
+}
+
+// ListTrustedIssuers lists the trusted issuers, by issuer, type, and schema.
+func (s Service) ListTrustedIssuers(ctx context.Context, request ListTrustedIssuersRequest) (*ListTrustedIssuersResponse, error) {
+	issuers, err := s.storage.ListTrustedIssuers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]TrustedIssuer, 0, len(issuers))
+	for _, issuer := range issuers {
+		if (request.Issuer == "" || issuer.Issuer == request.Issuer) &&
+			(request.CredentialType == "" || issuer.CredentialType == request.CredentialType) &&
+			(request.SchemaID == "" || issuer.SchemaID == request.SchemaID) &&
+			(request.Source == "" || issuer.Source == request.Source) {
+			matching = append(matching, issuer)
+		}
+	}
+	sortTrustedIssuers(matching)
+	return &ListTrustedIssuersResponse{TrustedIssuers: matching}, nil
+}
+
+// DeleteTrustedIssuer stops trusting an issuer for a type or schema. Once no issuer is trusted for the type or
+// schema, credentials of it are trusted whoever issued them.
+func (s Service) DeleteTrustedIssuer(ctx context.Context, request DeleteTrustedIssuerRequest) error {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "invalid delete trusted issuer request")
+	}
+	return s.storage.DeleteTrustedIssuer(ctx, request.ID)
+}
+
+// ImportTrustList trusts the issuers of a trust list for the types and schemas it lists them for, and stops trusting
+// those imported from the same source before, so that importing the latest version of a list keeps up with it. The
+// new issuers are stored before the old ones are deleted, so that issuers on both versions stay trusted throughout.
+func (s Service) ImportTrustList(ctx context.Context, request ImportTrustListRequest) (*ImportTrustListResponse, error) {
+	if err := sdkutil.IsValidStruct(request); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "invalid import trust list request")
+	}
+	fallback := listedIssuer{CredentialType: request.CredentialType, SchemaID: request.SchemaID}
+	listed, err := parseTrustList(request.Format, request.TrustList, fallback)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidTrustList, err.Error())
+	}
+
+	previous, err := s.ListTrustedIssuers(ctx, ListTrustedIssuersRequest{Source: request.Source})
+	if err != nil {
+		return nil, err
+	}
+	now := s.Clock.Now()
+	imported := make([]TrustedIssuer, 0, len(listed))
+	for _, issuer := range listed {
+		imported = append(imported, TrustedIssuer{
+			ID:             uuid.NewString(),
+			Issuer:         issuer.Issuer,
+			CredentialType: issuer.CredentialType,
+			SchemaID:       issuer.SchemaID,
+			Source:         request.Source,
+			Format:         request.Format,
+			CreatedAt:      now,
+		})
+	}
+	if err = s.storage.StoreTrustedIssuers(ctx, imported...); err != nil {
+		return nil, err
+	}
+	for _, issuer := range previous.TrustedIssuers {
+		if err = s.storage.DeleteTrustedIssuer(ctx, issuer.ID); err != nil {
+			return nil, err
+		}
+	}
+	logrus.Infof("imported %d trusted issuers from %s trust list<%s>, replacing %d", len(imported), request.Format, request.Source, len(previous.TrustedIssuers))
+	sortTrustedIssuers(imported)
+	return &ImportTrustListResponse{TrustedIssuers: imported, Replaced: len(previous.TrustedIssuers)}, nil
+}
+
+// CheckIssuer returns why the issuer of a credential isn't trusted to issue it, or nil when it is. Each type of the
+// credential, and its schema, that issuers are trusted for must have its issuer among them, while the types and
+// schemas no issuer is trusted for are trusted whoever issued them.
+func (s Service) CheckIssuer(ctx context.Context, credential credsdk.VerifiableCredential) error {
+	issuers, err := s.storage.ListTrustedIssuers(ctx)
+	if err != nil {
+		return errors.Wrap(err, "reading trusted issuers")
+	}
+	if len(issuers) == 0 {
+		return nil
+	}
+	types, err := sdkutil.InterfaceToStrings(credential.Type)
+	if err != nil {
+		return errors.Wrap(err, "reading credential types")
+	}
+	isType := make(map[string]bool, len(types))
+	for _, credentialType := range types {
+		isType[credentialType] = true
+	}
+	var schemaID string
+	if credential.CredentialSchema != nil {
+		schemaID = credential.CredentialSchema.ID
+	}
+
+	// what the credential is that issuers are trusted for, and whether its issuer is one of them
+	issuerID := credential.IssuerID()
+	trusted := make(map[string]bool)
+	for _, issuer := range issuers {
+		var what string
+		switch {
+		case issuer.CredentialType != "" && isType[issuer.CredentialType]:
+			what = fmt.Sprintf("credential type<%s>", issuer.CredentialType)
+		case issuer.SchemaID != "" && issuer.SchemaID == schemaID:
+			what = fmt.Sprintf("schema<%s>", issuer.SchemaID)
+		default:
+			continue
+		}
+		trusted[what] = trusted[what] || issuer.Issuer == issuerID
+	}
+	var untrusted []string
+	for what, isTrusted := range trusted {
+		if !isTrusted {
+			untrusted = append(untrusted, what)
+		}
+	}
+	if len(untrusted) == 0 {
+		return nil
+	}
+	sort.Strings(untrusted)
+	return errors.Wrapf(ErrUntrustedIssuer, "issuer<%s> is not trusted for %s", issuerID, strings.Join(untrusted, ", "))
+}
+
+// sortTrustedIssuers orders trusted issuers by issuer, then by what they're trusted for.
+func sortTrustedIssuers(issuers []TrustedIssuer) {
+	sort.Slice(issuers, func(i, j int) bool {
+		a, b := issuers[i], issuers[j]
+		if a.Issuer != b.Issuer {
+			return a.Issuer < b.Issuer
+		}
+		if a.CredentialType != b.CredentialType {
+			return a.CredentialType < b.CredentialType
+		}
+		return a.SchemaID < b.SchemaID
+	})
+}
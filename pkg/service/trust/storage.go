@@ -0,0 +1,88 @@
+package trust
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// trustedIssuerNamespace holds the trusted issuers of each tenant, keyed by their ID.
+const trustedIssuerNamespace = "trusted_issuer"
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewTrustStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// StoreTrustedIssuers writes the trusted issuers at once.
+func (s *Storage) StoreTrustedIssuers(ctx context.Context, issuers ...TrustedIssuer) error {
+	if len(issuers) == 0 {
+		return nil
+	}
+	namespaces := make([]string, 0, len(issuers))
+	keys := make([]string, 0, len(issuers))
+	values := make([][]byte, 0, len(issuers))
+	for _, issuer := range issuers {
+		issuerBytes, err := json.Marshal(issuer)
+		if err != nil {
+			return sdkutil.LoggingErrorMsg(err, "could not marshal trusted issuer")
+		}
+		namespaces = append(namespaces, trustedIssuerNamespace)
+		keys = append(keys, issuer.ID)
+		values = append(values, issuerBytes)
+	}
+	if err := s.db.WriteMany(ctx, namespaces, keys, values); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "could not store trusted issuers")
+	}
+	return nil
+}
+
+// GetTrustedIssuer returns the trusted issuer with the ID, or nil when there's none.
+func (s *Storage) GetTrustedIssuer(ctx context.Context, id string) (*TrustedIssuer, error) {
+	issuerBytes, err := s.db.Read(ctx, trustedIssuerNamespace, id)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get trusted issuer: %s", id)
+	}
+	if len(issuerBytes) == 0 {
+		return nil, nil
+	}
+	var issuer TrustedIssuer
+	if err = json.Unmarshal(issuerBytes, &issuer); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not unmarshal trusted issuer: %s", id)
+	}
+	return &issuer, nil
+}
+
+// ListTrustedIssuers returns every trusted issuer of the tenant.
+func (s *Storage) ListTrustedIssuers(ctx context.Context) ([]TrustedIssuer, error) {
+	issuers := make([]TrustedIssuer, 0)
+	err := s.db.Iterate(ctx, trustedIssuerNamespace, func(key string, issuerBytes []byte) (bool, error) {
+		var issuer TrustedIssuer
+		if err := json.Unmarshal(issuerBytes, &issuer); err != nil {
+			return false, errors.Wrapf(err, "unmarshalling trusted issuer<%s>", key)
+		}
+		issuers = append(issuers, issuer)
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not list trusted issuers")
+	}
+	return issuers, nil
+}
+
+func (s *Storage) DeleteTrustedIssuer(ctx context.Context, id string) error {
+	if err := s.db.Delete(ctx, trustedIssuerNamespace, id); err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not delete trusted issuer: %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package trust
+
+import (
+	"context"
+	"testing"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	"github.com/TBD54566975/ssi-sdk/crypto"
+	"github.com/TBD54566975/ssi-sdk/crypto/jwx"
+	"github.com/benbjohnson/clock"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+	"github.com/tbd54566975/ssi-service/pkg/testutil"
+)
+
+func TestParseEBSI(t *testing.T) {
+	// an accreditation as the registry has it, a JWT signed by the accrediting issuer
+	_, privKey, err := crypto.GenerateEd25519Key()
+	require.NoError(t, err)
+	signer, err := jwx.NewJWXSigner("did:ebsi:accreditor", "did:ebsi:accreditor#key-1", privKey)
+	require.NoError(t, err)
+	accreditation := credsdk.VerifiableCredential{
+		Context:      []string{credsdk.VerifiableCredentialsLinkedDataContext},
+		Type:         []string{credsdk.VerifiableCredentialType, "VerifiableAttestation", "VerifiableAccreditationToAttest"},
+		Issuer:       "did:ebsi:accreditor",
+		IssuanceDate: "2023-01-01T00:00:00Z",
+		CredentialSubject: credsdk.CredentialSubject{
+			"id": "did:ebsi:university",
+			"accreditedFor": []any{
+				map[string]any{
+					"schemaId": "https://api-pilot.ebsi.eu/trusted-schemas-registry/v2/schemas/diploma",
+					"types":    []string{credsdk.VerifiableCredentialType, "VerifiableAttestation", "VerifiableDiploma"},
+				},
+			},
+		},
+	}
+	accreditationJWT, err := integrity.SignVerifiableCredentialJWT(*signer, accreditation)
+	require.NoError(t, err)
+
+	list, err := json.Marshal(map[string]any{
+		"items": []any{
+			map[string]any{
+				"did": "did:ebsi:university",
+				"attributes": []any{
+					map[string]any{"body": string(accreditationJWT), "issuerType": "TI"},
+					// the same accreditation again, as JSON
+					map[string]any{"body": `{"credentialSubject":{"accreditedFor":[{"types":["VerifiableDiploma"]}]}}`, "issuerType": "TI"},
+				},
+			},
+			map[string]any{
+				"did": "did:ebsi:revoked",
+				"attributes": []any{
+					map[string]any{"body": string(accreditationJWT), "issuerType": "Revoked"},
+				},
+			},
+			map[string]any{"did": "did:ebsi:unaccredited"},
+		},
+	})
+	require.NoError(t, err)
+
+	listed, err := parseTrustList(TrustListFormatEBSI, list, listedIssuer{CredentialType: "Fallback"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []listedIssuer{
+		{Issuer: "did:ebsi:university", SchemaID: "https://api-pilot.ebsi.eu/trusted-schemas-registry/v2/schemas/diploma"},
+		{Issuer: "did:ebsi:university", CredentialType: "VerifiableDiploma"},
+		{Issuer: "did:ebsi:unaccredited", CredentialType: "Fallback"},
+	}, listed)
+
+	// a single issuer, without a fallback for the ones without accreditations
+	listed, err = parseTrustList(TrustListFormatEBSI, []byte(`{"did":"did:ebsi:unaccredited","attributes":[]}`), listedIssuer{})
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	_, err = parseTrustList(TrustListFormatEBSI, []byte(`[{"did":"not-a-did"}]`), listedIssuer{})
+	assert.ErrorContains(t, err, "is not a DID")
+	_, err = parseTrustList(TrustListFormatEBSI, []byte(`"issuers"`), listedIssuer{})
+	assert.Error(t, err)
+}
+
+func TestParseTRAIN(t *testing.T) {
+	list := []byte(`{
+		"TrustServiceStatusList": {
+			"TrustServiceProviderList": {
+				"TrustServiceProvider": [
+					{
+						"TSPServices": {
+							"TSPService": {
+								"ServiceInformation": {
+									"ServiceStatus": "http://uri.etsi.org/TrstSvc/TrustedList/Svcstatus/granted",
+									"ServiceDigitalIdentity": {"DigitalId": [{"DID": "did:web:granted.example.com"}, {"X509Certificate": "MII..."}]}
+								}
+							}
+						}
+					},
+					{
+						"TSPServices": {
+							"TSPService": [
+								{
+									"ServiceInformation": {
+										"ServiceStatus": "http://uri.etsi.org/TrstSvc/TrustedList/Svcstatus/withdrawn",
+										"ServiceDigitalIdentity": {"DigitalId": {"DID": "did:web:withdrawn.example.com"}}
+									}
+								}
+							]
+						}
+					}
+				]
+			}
+		}
+	}`)
+
+	listed, err := parseTrustList(TrustListFormatTRAIN, list, listedIssuer{SchemaID: "schema"})
+	require.NoError(t, err)
+	assert.Equal(t, []listedIssuer{{Issuer: "did:web:granted.example.com", SchemaID: "schema"}}, listed)
+
+	// the list doesn't say what its issuers are trusted for
+	_, err = parseTrustList(TrustListFormatTRAIN, list, listedIssuer{})
+	assert.ErrorContains(t, err, "type or schema")
+	_, err = parseTrustList(TrustListFormatTRAIN, []byte(`{}`), listedIssuer{SchemaID: "schema"})
+	assert.ErrorContains(t, err, "no services")
+}
+
+func TestCheckIssuer(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := NewTrustService(config.TrustServiceConfig{Enabled: true}, storage.NewTenantWrapper(test.ServiceStorage(t)))
+			require.NoError(t, err)
+
+			degree := credsdk.VerifiableCredential{
+				Type:             []string{credsdk.VerifiableCredentialType, "UniversityDegreeCredential"},
+				Issuer:           "did:example:university",
+				CredentialSchema: &credsdk.CredentialSchema{ID: "degree-schema"},
+			}
+
+			// every issuer is trusted until issuers are registered
+			require.NoError(t, s.CheckIssuer(ctx, degree))
+
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:other", CredentialType: "UniversityDegreeCredential"})
+			require.NoError(t, err)
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:other", SchemaID: "degree-schema"})
+			require.NoError(t, err)
+			err = s.CheckIssuer(ctx, degree)
+			assert.True(t, errors.Is(err, ErrUntrustedIssuer))
+			assert.ErrorContains(t, err, "issuer<did:example:university> is not trusted for credential type<UniversityDegreeCredential>, schema<degree-schema>")
+
+			// trusted for the type, but not yet for the schema
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:university", CredentialType: "UniversityDegreeCredential"})
+			require.NoError(t, err)
+			err = s.CheckIssuer(ctx, degree)
+			assert.ErrorContains(t, err, "is not trusted for schema<degree-schema>")
+			schemaIssuer, err := s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:university", SchemaID: "degree-schema"})
+			require.NoError(t, err)
+			require.NoError(t, s.CheckIssuer(ctx, degree))
+
+			// the registry is per tenant
+			otherTenant := storage.WithTenant(ctx, "other")
+			require.NoError(t, s.CheckIssuer(otherTenant, credsdk.VerifiableCredential{Type: degree.Type, Issuer: "did:example:anyone"}))
+
+			require.NoError(t, s.DeleteTrustedIssuer(ctx, DeleteTrustedIssuerRequest{ID: schemaIssuer.ID}))
+			_, err = s.GetTrustedIssuer(ctx, GetTrustedIssuerRequest{ID: schemaIssuer.ID})
+			assert.ErrorContains(t, err, "not found")
+			assert.Error(t, s.CheckIssuer(ctx, degree))
+
+			// a trusted issuer is for a type or a schema
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:university"})
+			assert.Error(t, err)
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:university", CredentialType: "UniversityDegreeCredential", SchemaID: "degree-schema"})
+			assert.Error(t, err)
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "university", CredentialType: "UniversityDegreeCredential"})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImportTrustList(t *testing.T) {
+	for _, test := range testutil.TestDatabases {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+			trustStorage, err := NewTrustStorage(test.ServiceStorage(t))
+			require.NoError(t, err)
+			s := Service{storage: trustStorage, Clock: clock.NewMock()}
+
+			_, err = s.CreateTrustedIssuer(ctx, CreateTrustedIssuerRequest{Issuer: "did:example:registered", CredentialType: "Diploma"})
+			require.NoError(t, err)
+
+			imported, err := s.ImportTrustList(ctx, ImportTrustListRequest{
+				Format:         TrustListFormatEBSI,
+				Source:         "https://registry.example.com/issuers",
+				TrustList:      []byte(`[{"did":"did:ebsi:first"},{"did":"did:ebsi:second"}]`),
+				CredentialType: "Diploma",
+			})
+			require.NoError(t, err)
+			assert.Zero(t, imported.Replaced)
+			require.Len(t, imported.TrustedIssuers, 2)
+			assert.Equal(t, "did:ebsi:first", imported.TrustedIssuers[0].Issuer)
+			assert.Equal(t, TrustListFormatEBSI, imported.TrustedIssuers[0].Format)
+
+			// importing the source again replaces what was imported from it, and nothing else
+			imported, err = s.ImportTrustList(ctx, ImportTrustListRequest{
+				Format:         TrustListFormatEBSI,
+				Source:         "https://registry.example.com/issuers",
+				TrustList:      []byte(`{"did":"did:ebsi:second"}`),
+				CredentialType: "Diploma",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, imported.Replaced)
+			listed, err := s.ListTrustedIssuers(ctx, ListTrustedIssuersRequest{CredentialType: "Diploma"})
+			require.NoError(t, err)
+			require.Len(t, listed.TrustedIssuers, 2)
+			assert.Equal(t, "did:ebsi:second", listed.TrustedIssuers[0].Issuer)
+			assert.Equal(t, "did:example:registered", listed.TrustedIssuers[1].Issuer)
+
+			// a list that can't be read leaves the issuers imported before
+			_, err = s.ImportTrustList(ctx, ImportTrustListRequest{
+				Format:    TrustListFormatTRAIN,
+				Source:    "https://registry.example.com/issuers",
+				TrustList: []byte(`{}`),
+				SchemaID:  "schema",
+			})
+			assert.True(t, errors.Is(err, ErrInvalidTrustList))
+			listed, err = s.ListTrustedIssuers(ctx, ListTrustedIssuersRequest{Source: "https://registry.example.com/issuers"})
+			require.NoError(t, err)
+			assert.Len(t, listed.TrustedIssuers, 1)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package trust
+
+import (
+	"strings"
+
+	credsdk "github.com/TBD54566975/ssi-sdk/credential"
+	"github.com/TBD54566975/ssi-sdk/credential/integrity"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+// listedIssuer is an issuer of a trust list, and the type or schema it's trusted for.
+type listedIssuer struct {
+	Issuer         string
+	CredentialType string
+	SchemaID       string
+}
+
+// genericTypes are the types of every credential, or of every EBSI attestation, which accreditations list besides the
+// types they're for. Trusting issuers for them would untrust every other issuer.
+var genericTypes = map[string]bool{
+	credsdk.VerifiableCredentialType: true,
+	"VerifiableAttestation":          true,
+}
+
+// parseTrustList lists the issuers of a trust list, and what they're trusted for. Issuers the list doesn't say the
+// types and schemas of are trusted for fallback, when it has a type or schema.
+func parseTrustList(format TrustListFormat, trustList []byte, fallback listedIssuer) ([]listedIssuer, error) {
+	var listed []listedIssuer
+	var err error
+	switch format {
+	case TrustListFormatEBSI:
+		listed, err = parseEBSI(trustList, fallback)
+	case TrustListFormatTRAIN:
+		listed, err = parseTRAIN(trustList, fallback)
+	default:
+		return nil, errors.Errorf("unsupported trust list format<%s>", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// accreditations of the same issuer often list the same types
+	seen := make(map[listedIssuer]bool, len(listed))
+	unique := make([]listedIssuer, 0, len(listed))
+	for _, issuer := range listed {
+		if !seen[issuer] {
+			seen[issuer] = true
+			unique = append(unique, issuer)
+		}
+	}
+	return unique, nil
+}
+
+// ebsiIssuer is an issuer of the EBSI Trusted Issuers Registry, as GET /issuers/{did} returns it.
+type ebsiIssuer struct {
+	DID        string `json:"did"`
+	Attributes []struct {
+		// The accreditation of the issuer, a verifiable credential, usually as a JWT.
+		Body       string `json:"body"`
+		IssuerType string `json:"issuerType"`
+	} `json:"attributes"`
+}
+
+// ebsiAccreditation is what an accreditation's subject is accredited for.
+type ebsiAccreditation struct {
+	SchemaID string   `json:"schemaId"`
+	Types    []string `json:"types"`
+}
+
+// parseEBSI lists the issuers of the EBSI Trusted Issuers Registry, given as an issuer, a list of issuers, or a page of
+// issuers with their attributes. Each issuer is trusted for the types and schemas its attributes' accreditations are
+// for, except when its attributes are revoked.
+func parseEBSI(trustList []byte, fallback listedIssuer) ([]listedIssuer, error) {
+	var page struct {
+		Items []ebsiIssuer `json:"items"`
+	}
+	var single ebsiIssuer
+	var issuers []ebsiIssuer
+	switch {
+	case json.Unmarshal(trustList, &issuers) == nil:
+	case json.Unmarshal(trustList, &page) == nil && page.Items != nil:
+		issuers = page.Items
+	case json.Unmarshal(trustList, &single) == nil && single.DID != "":
+		issuers = []ebsiIssuer{single}
+	default:
+		return nil, errors.New("expected an issuer, a list of issuers, or a page of issuers")
+	}
+
+	var listed []listedIssuer
+	for _, issuer := range issuers {
+		if !strings.HasPrefix(issuer.DID, "did:") {
+			return nil, errors.Errorf("issuer<%s> is not a DID", issuer.DID)
+		}
+		var accredited []listedIssuer
+		revoked := false
+		for i, attribute := range issuer.Attributes {
+			if strings.EqualFold(attribute.IssuerType, "revoked") {
+				revoked = true
+				continue
+			}
+			accreditations, err := ebsiAccreditations(attribute.Body)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading attribute<%d> of issuer<%s>", i, issuer.DID)
+			}
+			for _, accreditation := range accreditations {
+				if accreditation.SchemaID != "" {
+					accredited = append(accredited, listedIssuer{Issuer: issuer.DID, SchemaID: accreditation.SchemaID})
+				}
+				for _, credentialType := range accreditation.Types {
+					if !genericTypes[credentialType] {
+						accredited = append(accredited, listedIssuer{Issuer: issuer.DID, CredentialType: credentialType})
+					}
+				}
+			}
+		}
+		// issuers whose only attributes are revoked aren't trusted for anything, not even fallback
+		if len(accredited) == 0 && !revoked && (fallback.CredentialType != "" || fallback.SchemaID != "") {
+			accredited = append(accredited, listedIssuer{Issuer: issuer.DID, CredentialType: fallback.CredentialType, SchemaID: fallback.SchemaID})
+		}
+		listed = append(listed, accredited...)
+	}
+	return listed, nil
+}
+
+// ebsiAccreditations reads what the subject of an accreditation, given as a JWT or as JSON, is accredited for. The
+// accreditation's signature isn't verified, since the trust list is only imported from a source that's trusted.
+func ebsiAccreditations(body string) ([]ebsiAccreditation, error) {
+	if body == "" {
+		return nil, nil
+	}
+	var cred *credsdk.VerifiableCredential
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		if err := json.Unmarshal([]byte(body), &cred); err != nil {
+			return nil, errors.Wrap(err, "parsing accreditation")
+		}
+	} else {
+		_, _, parsed, err := integrity.ParseVerifiableCredentialFromJWT(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing accreditation jwt")
+		}
+		cred = parsed
+	}
+	accreditedFor, ok := cred.CredentialSubject["accreditedFor"]
+	if !ok {
+		return nil, nil
+	}
+	accreditedForBytes, err := json.Marshal(accreditedFor)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling accreditedFor")
+	}
+	var accreditations []ebsiAccreditation
+	if err = json.Unmarshal(accreditedForBytes, &accreditations); err != nil {
+		return nil, errors.Wrap(err, "parsing accreditedFor")
+	}
+	return accreditations, nil
+}
+
+// oneOrMany is a JSON value that's either an object or a list of objects, as lists converted from XML have a single
+// element as an object.
+type oneOrMany[T any] []T
+
+func (o *oneOrMany[T]) UnmarshalJSON(data []byte) error {
+	var many []T
+	if err := json.Unmarshal(data, &many); err == nil {
+		*o = many
+		return nil
+	}
+	var one T
+	if err := json.Unmarshal(data, &one); err != nil {
+		return err
+	}
+	*o = []T{one}
+	return nil
+}
+
+// trainTrustList is a TRAIN trust list, the JSON form of an ETSI TS 119 612 trust status list, whose services have the
+// DIDs of their issuers as digital identities.
+type trainTrustList struct {
+	TrustServiceStatusList struct {
+		TrustServiceProviderList struct {
+			TrustServiceProvider oneOrMany[struct {
+				TSPServices struct {
+					TSPService oneOrMany[struct {
+						ServiceInformation struct {
+							ServiceStatus          string `json:"ServiceStatus"`
+							ServiceDigitalIdentity struct {
+								DigitalID oneOrMany[struct {
+									DID string `json:"DID"`
+								}] `json:"DigitalId"`
+							} `json:"ServiceDigitalIdentity"`
+						} `json:"ServiceInformation"`
+					}] `json:"TSPService"`
+				} `json:"TSPServices"`
+			}] `json:"TrustServiceProvider"`
+		} `json:"TrustServiceProviderList"`
+	} `json:"TrustServiceStatusList"`
+}
+
+// parseTRAIN lists the DIDs of the services of a TRAIN trust list, except the services whose status is withdrawn. A
+// TRAIN trust list is for a trust scheme rather than for types or schemas, so its issuers are trusted for fallback.
+func parseTRAIN(trustList []byte, fallback listedIssuer) ([]listedIssuer, error) {
+	if fallback.CredentialType == "" && fallback.SchemaID == "" {
+		return nil, errors.New("the type or schema the issuers of a TRAIN trust list are trusted for is required")
+	}
+	var list trainTrustList
+	if err := json.Unmarshal(trustList, &list); err != nil {
+		return nil, errors.Wrap(err, "parsing TRAIN trust list")
+	}
+	var listed []listedIssuer
+	for _, provider := range list.TrustServiceStatusList.TrustServiceProviderList.TrustServiceProvider {
+		for _, service := range provider.TSPServices.TSPService {
+			status := strings.ToLower(service.ServiceInformation.ServiceStatus)
+			if strings.HasSuffix(status, "withdrawn") || strings.HasSuffix(status, "revoked") {
+				continue
+			}
+			for _, id := range service.ServiceInformation.ServiceDigitalIdentity.DigitalID {
+				if !strings.HasPrefix(id.DID, "did:") {
+					continue
+				}
+				listed = append(listed, listedIssuer{Issuer: id.DID, CredentialType: fallback.CredentialType, SchemaID: fallback.SchemaID})
+			}
+		}
+	}
+	if len(listed) == 0 {
+		return nil, errors.New("TRAIN trust list has no services identified by a DID")
+	}
+	return listed, nil
+}
@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Meter is a kind of usage that is counted.
+type Meter string
+
+const (
+	// MeterIssuance counts the credentials issued.
+	MeterIssuance Meter = "issuance"
+	// MeterVerification counts the credentials, presentations, applications, and DID configurations verified.
+	MeterVerification Meter = "verification"
+	// MeterSigning counts the documents signed with the keys of the service.
+	MeterSigning Meter = "signing"
+	// MeterStorage counts the bytes of the request bodies of the calls that store something.
+	MeterStorage Meter = "storage"
+)
+
+// Meters are all the kinds of usage that are counted.
+var Meters = []Meter{MeterIssuance, MeterVerification, MeterSigning, MeterStorage}
+
+// IsValid returns whether the meter is one of Meters.
+func (m Meter) IsValid() bool {
+	for _, meter := range Meters {
+		if m == meter {
+			return true
+		}
+	}
+	return false
+}
+
+// periodLayout is the layout of periods, which are UTC calendar months.
+const periodLayout = "2006-01"
+
+// Period returns the period t is in, e.g. 2023-10.
+func Period(t time.Time) string {
+	return t.UTC().Format(periodLayout)
+}
+
+// PeriodEnd returns when the period t is in ends, which is the start of the next month.
+func PeriodEnd(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Record is how much a meter counted in a period, for a tenant, or for one of the principals of a tenant.
+type Record struct {
+	// Period the usage was counted in, which is a UTC calendar month, e.g. 2023-10.
+	Period string `json:"period"`
+
+	// Tenant the usage was counted for. Empty for the default tenant.
+	Tenant string `json:"tenant,omitempty"`
+
+	// ID of the API key, or subject of the access token, the usage was counted for. Empty for the usage of the whole
+	// tenant, which adds up that of its principals and of the calls that weren't authenticated.
+	Principal string `json:"principal,omitempty"`
+
+	Meter Meter `json:"meter"`
+
+	// How much was counted, which is bytes for storage and calls, or items of batches, for the other meters.
+	Count int64 `json:"count"`
+}
+
+// Key identifies the record. Keys sort records by period, then tenant, meter, and principal.
+func (r Record) Key() string {
+	return storage.Join(r.Period, r.Tenant, string(r.Meter), r.Principal)
+}
+
+// ListUsageRequest filters the records that are listed. Empty fields match every record.
+type ListUsageRequest struct {
+	Period    string
+	Tenant    string
+	Principal string
+	Meter     Meter
+}
+
+// Matches returns whether the record passes the filters of the request.
+func (r ListUsageRequest) Matches(record Record) bool {
+	switch {
+	case r.Period != "" && record.Period != r.Period:
+		return false
+	case r.Tenant != "" && record.Tenant != r.Tenant:
+		return false
+	case r.Principal != "" && record.Principal != r.Principal:
+		return false
+	case r.Meter != "" && record.Meter != r.Meter:
+		return false
+	}
+	return true
+}
+
+type ListUsageResponse struct {
+	Records []Record
+}
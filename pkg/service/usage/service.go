@@ -0,0 +1,94 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/benbjohnson/clock"
+
+	"github.com/tbd54566975/ssi-service/pkg/service/framework"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Service meters how much each tenant, and each principal of a tenant, issues, verifies, signs, and stores, per UTC
+// calendar month. Usage is kept in the storage of the deployment rather than of a tenant, so the usage of every tenant
+// can be reported in one place.
+type Service struct {
+	storage *Storage
+	Clock   clock.Clock
+}
+
+func (s Service) Type() framework.Type {
+	return framework.Usage
+}
+
+func (s Service) Status() framework.Status {
+	ae := sdkutil.NewAppendError()
+	if s.storage == nil {
+		ae.AppendString("no storage configured")
+	}
+	if !ae.IsEmpty() {
+		return framework.Status{
+			Status:  framework.StatusNotReady,
+			Message: fmt.Sprintf("usage service is not ready: %s", ae.Error().Error()),
+		}
+	}
+	return framework.Status{Status: framework.StatusReady}
+}
+
+func NewUsageService(s storage.ServiceStorage) (*Service, error) {
+	usageStorage, err := NewUsageStorage(s)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not instantiate usage storage for the usage service")
+	}
+	return &Service{storage: usageStorage, Clock: clock.New()}, nil
+}
+
+// Add counts usage of a meter in the current period, for the tenant, and for the principal when there is one.
+func (s Service) Add(ctx context.Context, tenant, principal string, meter Meter, count int64) error {
+	if !meter.IsValid() {
+		return sdkutil.LoggingNewErrorf("unknown meter: %s", meter)
+	}
+	record := Record{Period: Period(s.Clock.Now()), Tenant: tenant, Meter: meter, Count: count}
+	if err := s.storage.AddToRecord(ctx, record); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "adding tenant usage")
+	}
+	if principal == "" {
+		return nil
+	}
+	record.Principal = principal
+	if err := s.storage.AddToRecord(ctx, record); err != nil {
+		return sdkutil.LoggingErrorMsg(err, "adding principal usage")
+	}
+	return nil
+}
+
+// Current returns how much a meter counted in the current period, for the tenant, or for one of its principals when
+// principal isn't empty.
+func (s Service) Current(ctx context.Context, tenant, principal string, meter Meter) (int64, error) {
+	key := Record{Period: Period(s.Clock.Now()), Tenant: tenant, Principal: principal, Meter: meter}.Key()
+	record, err := s.storage.GetRecord(ctx, key)
+	if err != nil {
+		return 0, sdkutil.LoggingErrorMsg(err, "getting current usage")
+	}
+	if record == nil {
+		return 0, nil
+	}
+	return record.Count, nil
+}
+
+// ListUsage returns the usage records that match the request.
+func (s Service) ListUsage(ctx context.Context, request ListUsageRequest) (*ListUsageResponse, error) {
+	records := make([]Record, 0)
+	err := s.storage.IterateRecords(ctx, func(record Record) (bool, error) {
+		if request.Matches(record) {
+			records = append(records, record)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "reading usage records")
+	}
+	return &ListUsageResponse{Records: records}, nil
+}
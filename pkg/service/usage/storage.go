@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"context"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// usageNamespace holds the usage records, keyed by Record.Key.
+const usageNamespace = "usage"
+
+type Storage struct {
+	db storage.ServiceStorage
+}
+
+func NewUsageStorage(db storage.ServiceStorage) (*Storage, error) {
+	if db == nil {
+		return nil, errors.New("db reference is nil")
+	}
+	return &Storage{db: db}, nil
+}
+
+// AddToRecord adds count to the record, creating it when it doesn't exist yet. The record is read and written in a
+// transaction that watches it, so that instances sharing the storage don't lose each other's counts.
+func (s *Storage) AddToRecord(ctx context.Context, record Record) error {
+	key := record.Key()
+	watchKeys := []storage.WatchKey{{Namespace: usageNamespace, Key: key}}
+	_, err := s.db.Execute(ctx, func(ctx context.Context, tx storage.Tx) (any, error) {
+		stored, err := s.GetRecord(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			record.Count += stored.Count
+		}
+		recordBytes, err := json.Marshal(record)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling usage record")
+		}
+		return nil, tx.Write(ctx, usageNamespace, key, recordBytes)
+	}, watchKeys)
+	if err != nil {
+		return sdkutil.LoggingErrorMsgf(err, "could not add to usage record: %s", key)
+	}
+	return nil
+}
+
+// GetRecord returns the record with the key, or nil when nothing was counted for it.
+func (s *Storage) GetRecord(ctx context.Context, key string) (*Record, error) {
+	recordBytes, err := s.db.Read(ctx, usageNamespace, key)
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "could not get usage record: %s", key)
+	}
+	if len(recordBytes) == 0 {
+		return nil, nil
+	}
+	var record Record
+	if err = json.Unmarshal(recordBytes, &record); err != nil {
+		return nil, sdkutil.LoggingErrorMsgf(err, "unmarshalling usage record: %s", key)
+	}
+	return &record, nil
+}
+
+// IterateRecords calls fn with every usage record until fn returns false.
+func (s *Storage) IterateRecords(ctx context.Context, fn func(record Record) (bool, error)) error {
+	return s.db.Iterate(ctx, usageNamespace, func(key string, recordBytes []byte) (bool, error) {
+		var record Record
+		if err := json.Unmarshal(recordBytes, &record); err != nil {
+			logrus.WithError(err).Warnf("unmarshal usage record: %s", key)
+			return true, nil
+		}
+		return fn(record)
+	})
+}
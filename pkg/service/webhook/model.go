@@ -28,6 +28,10 @@ const (
 	BatchCreate = Verb("BatchCreate")
 	Create      = Verb("Create")
 	Delete      = Verb("Delete")
+	// Expire is published by the expiry service when credentials expire, rather than in response to a request.
+	Expire = Verb("Expire")
+	// Deny is published by the manifest service when applications are denied, whether by a reviewer or a review policy.
+	Deny = Verb("Deny")
 )
 
 type Webhook struct {
@@ -111,7 +115,7 @@ func (n Noun) IsValid() bool {
 
 func (v Verb) isValid() bool {
 	switch v {
-	case Create, Delete:
+	case Create, Delete, Expire, Deny:
 		return true
 	default:
 		return false
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sdkutil "github.com/TBD54566975/ssi-sdk/util"
@@ -16,6 +17,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/internal/inflight"
+	"github.com/tbd54566975/ssi-service/internal/requestid"
 	"github.com/tbd54566975/ssi-service/internal/util"
 	"github.com/tbd54566975/ssi-service/pkg/service/framework"
 	"github.com/tbd54566975/ssi-service/pkg/storage"
@@ -27,7 +30,10 @@ type Service struct {
 	storage         *Storage
 	config          config.WebhookServiceConfig
 	httpClient      *http.Client
-	timeoutDuration time.Duration
+	timeoutDuration *atomic.Int64
+
+	// deliveries tracks the webhooks being published in the background, so shutdown can wait for them
+	deliveries *inflight.Tracker
 }
 
 func (s Service) Type() framework.Type {
@@ -61,16 +67,15 @@ func NewWebhookService(config config.WebhookServiceConfig, s storage.ServiceStor
 
 	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
 
-	duration, err := time.ParseDuration(config.WebhookTimeout)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "parsing webhook timeout")
-	}
-
 	service := Service{
 		storage:         webhookStorage,
 		config:          config,
 		httpClient:      client,
-		timeoutDuration: duration,
+		timeoutDuration: new(atomic.Int64),
+		deliveries:      new(inflight.Tracker),
+	}
+	if err = service.Update(config); err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "parsing webhook timeout")
 	}
 
 	if !service.Status().IsReady() {
@@ -174,12 +179,59 @@ func (s Service) GetSupportedNouns() GetSupportedNounsResponse {
 }
 
 func (s Service) GetSupportedVerbs() GetSupportedVerbsResponse {
-	return GetSupportedVerbsResponse{Verbs: []Verb{Create, Delete}}
+	return GetSupportedVerbsResponse{Verbs: []Verb{Create, Delete, Expire, Deny}}
+}
+
+// PublishWebhookInBackground publishes a webhook like PublishWebhook, without waiting for it to be delivered. Drain
+// waits for the deliveries in progress, and webhooks aren't published once it's called.
+func (s Service) PublishWebhookInBackground(c *gin.Context, noun Noun, verb Verb, payloadReader io.Reader) {
+	if !s.deliveries.Start() {
+		logrus.Warnf("not publishing webhook while shutting down: %s:%s", noun, verb)
+		return
+	}
+	// c is reused once the request is done, so the delivery uses a copy of it
+	cCopy := c.Copy()
+	go func() {
+		defer s.deliveries.Done()
+		s.PublishWebhook(cCopy, noun, verb, payloadReader)
+	}()
+}
+
+// Update replaces how long publishing a webhook to its URLs takes at most with the timeout of config, so that it can
+// change while the service runs.
+func (s Service) Update(config config.WebhookServiceConfig) error {
+	duration, err := time.ParseDuration(config.WebhookTimeout)
+	if err != nil {
+		return errors.Wrap(err, "parsing webhook timeout")
+	}
+	s.timeoutDuration.Store(int64(duration))
+	return nil
+}
+
+// Drain stops webhooks from being published, and waits for the deliveries in progress to finish, or for ctx to be
+// done.
+func (s Service) Drain(ctx context.Context) error {
+	return errors.Wrap(s.deliveries.Drain(ctx), "waiting for webhook deliveries")
 }
 
 // TODO: consider returning an error to be handled by the gin middleware
 func (s Service) PublishWebhook(c *gin.Context, noun Noun, verb Verb, payloadReader io.Reader) {
-	timeoutCtx, cancel := context.WithTimeout(c.Copy(), s.timeoutDuration)
+	s.publish(c.Copy(), noun, verb, payloadReader)
+}
+
+// PublishEvent publishes a webhook for something that happened outside a request, like a credential expiring, to the
+// webhooks of the tenant of ctx, waiting for it to be delivered. Events aren't published once Drain is called.
+func (s Service) PublishEvent(ctx context.Context, noun Noun, verb Verb, data []byte) {
+	if !s.deliveries.Start() {
+		logrus.Warnf("not publishing webhook while shutting down: %s:%s", noun, verb)
+		return
+	}
+	defer s.deliveries.Done()
+	s.publish(ctx, noun, verb, bytes.NewReader(data))
+}
+
+func (s Service) publish(ctx context.Context, noun Noun, verb Verb, payloadReader io.Reader) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(s.timeoutDuration.Load()))
 	defer cancel()
 
 	nounString := string(noun)
@@ -215,7 +267,7 @@ func (s Service) PublishWebhook(c *gin.Context, noun Noun, verb Verb, payloadRea
 		go func(url, data string) {
 			defer wg.Done()
 			if err = s.post(timeoutCtx, url, data); err != nil {
-				logrus.WithError(err).Errorf("posting payload to %s", url)
+				logrus.WithContext(timeoutCtx).WithError(err).Errorf("posting payload to %s", url)
 			}
 		}(url, string(postJSONData))
 	}
@@ -228,6 +280,9 @@ func (s Service) post(ctx context.Context, url string, json string) error {
 		return errors.Wrap(err, "building http req")
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
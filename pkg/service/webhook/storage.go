@@ -50,19 +50,18 @@ func (whs *Storage) GetWebhook(ctx context.Context, noun, verb string) (*Webhook
 }
 
 func (whs *Storage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
-	gotWebhooks, err := whs.db.ReadAll(ctx, webhookNamespace)
-	if err != nil {
-		return nil, sdkutil.LoggingErrorMsg(err, "could not get all webhooks")
-	}
-
 	var webhooks []Webhook
-	for _, webhookBytes := range gotWebhooks {
+	err := whs.db.Iterate(ctx, webhookNamespace, func(_ string, webhookBytes []byte) (bool, error) {
 		var webhook Webhook
-		if err = json.Unmarshal(webhookBytes, &webhook); err == nil {
+		if err := json.Unmarshal(webhookBytes, &webhook); err == nil {
 			webhooks = append(webhooks, webhook)
 		} else {
 			logrus.WithError(err).Warn("unmarshal webhook")
 		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, sdkutil.LoggingErrorMsg(err, "could not get all webhooks")
 	}
 
 	return webhooks, nil
@@ -29,14 +29,14 @@ type BoltDB struct {
 	db *bolt.DB
 }
 
-func (b *BoltDB) ReadPage(_ context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+func (b *BoltDB) ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
 	result := make(map[string][]byte)
 	var nextCursorToReturn []byte
 
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			logrus.Warnf("namespace<%s> does not exist", namespace)
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
 			return nil
 		}
 		cursor := bucket.Cursor()
@@ -124,6 +124,29 @@ func (b *BoltDB) Close() error {
 	return b.db.Close()
 }
 
+// Stats reports the size of the database file, and the use of its transactions and pages.
+func (b *BoltDB) Stats(_ context.Context) (map[string]any, error) {
+	var size int64
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "reading database size")
+	}
+	stats := b.db.Stats()
+	return map[string]any{
+		"sizeBytes":        size,
+		"freePages":        stats.FreePageN,
+		"pendingPages":     stats.PendingPageN,
+		"freeAllocBytes":   stats.FreeAlloc,
+		"freelistBytes":    stats.FreelistInuse,
+		"readTransactions": stats.TxN,
+		"openTransactions": stats.OpenTxN,
+		"writes":           stats.TxStats.Write,
+		"writeTimeNanos":   stats.TxStats.WriteTime.Nanoseconds(),
+	}, nil
+}
+
 type boltTx struct {
 	tx *bolt.Tx
 }
@@ -169,7 +192,7 @@ func (b *BoltDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFun
 		if t.DB() != nil {
 			err = t.Rollback()
 			if err != nil {
-				logrus.Error("unable to roll back")
+				logrus.WithContext(ctx).Error("unable to roll back")
 			}
 		}
 	}()
@@ -178,7 +201,7 @@ func (b *BoltDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFun
 	result, err := businessLogicFunc(ctx, &bTx)
 	if err != nil {
 		if rollbackErr := t.Rollback(); rollbackErr != nil {
-			logrus.Errorf("problem rolling back %s", rollbackErr)
+			logrus.WithContext(ctx).Errorf("problem rolling back %s", rollbackErr)
 			return nil, errors.Wrap(rollbackErr, "rolling back transaction")
 		}
 		return nil, errors.Wrap(err, "executing business logic func")
@@ -223,12 +246,12 @@ func (b *BoltDB) WriteMany(_ context.Context, namespaces, keys []string, values
 	})
 }
 
-func (b *BoltDB) Read(_ context.Context, namespace, key string) ([]byte, error) {
+func (b *BoltDB) Read(ctx context.Context, namespace, key string) ([]byte, error) {
 	var result []byte
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			logrus.Warnf("namespace<%s> does not exist", namespace)
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
 			return nil
 		}
 		result = bucket.Get([]byte(key))
@@ -238,12 +261,12 @@ func (b *BoltDB) Read(_ context.Context, namespace, key string) ([]byte, error)
 }
 
 // ReadPrefix does a prefix query within a namespace.
-func (b *BoltDB) ReadPrefix(_ context.Context, namespace, prefix string) (map[string][]byte, error) {
+func (b *BoltDB) ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error) {
 	result := make(map[string][]byte)
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			logrus.Warnf("namespace<%s> does not exist", namespace)
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
 			return nil
 		}
 		cursor := bucket.Cursor()
@@ -256,12 +279,12 @@ func (b *BoltDB) ReadPrefix(_ context.Context, namespace, prefix string) (map[st
 	return result, err
 }
 
-func (b *BoltDB) ReadAll(_ context.Context, namespace string) (map[string][]byte, error) {
+func (b *BoltDB) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
 	result := make(map[string][]byte)
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			logrus.Warnf("namespace<%s> does not exist", namespace)
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
 			return nil
 		}
 		cursor := bucket.Cursor()
@@ -273,12 +296,34 @@ func (b *BoltDB) ReadAll(_ context.Context, namespace string) (map[string][]byte
 	return result, err
 }
 
-func (b *BoltDB) ReadAllKeys(_ context.Context, namespace string) ([]string, error) {
+// Iterate walks the namespace with a bolt cursor inside a single read transaction.
+func (b *BoltDB) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(namespace))
+		if bucket == nil {
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			keepGoing, err := fn(string(k), v)
+			if err != nil {
+				return err
+			}
+			if !keepGoing {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltDB) ReadAllKeys(ctx context.Context, namespace string) ([]string, error) {
 	var result []string
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			logrus.Warnf("namespace<%s> does not exist", namespace)
+			logrus.WithContext(ctx).Warnf("namespace<%s> does not exist", namespace)
 			return nil
 		}
 		cursor := bucket.Cursor()
@@ -290,6 +335,30 @@ func (b *BoltDB) ReadAllKeys(_ context.Context, namespace string) ([]string, err
 	return result, err
 }
 
+// ListNamespaces returns the namespaces of the database, which are its buckets.
+func (b *BoltDB) ListNamespaces(_ context.Context) ([]string, error) {
+	var namespaces []string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			namespaces = append(namespaces, string(name))
+			return nil
+		})
+	})
+	return namespaces, err
+}
+
+// Snapshot reads every value of every bucket in a single read transaction.
+func (b *BoltDB) Snapshot(_ context.Context, fn SnapshotFunc) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			namespace := string(name)
+			return bucket.ForEach(func(k, v []byte) error {
+				return fn(namespace, string(k), v)
+			})
+		})
+	})
+}
+
 func (b *BoltDB) Delete(_ context.Context, namespace, key string) error {
 	return b.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,8 +14,14 @@ import (
 	"github.com/alicebob/miniredis/v2"
 	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
 	"github.com/goccy/go-json"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/tbd54566975/ssi-service/internal/metrics"
 	"github.com/tbd54566975/ssi-service/pkg/encryption"
 )
 
@@ -30,6 +37,10 @@ func getDBImplementations(t *testing.T) []ServiceStorage {
 	postgresDB := setupPostgresDB(t)
 	dbImpls = append(dbImpls, postgresDB)
 
+	memoryDB, err := NewStorage(Memory)
+	require.NoError(t, err)
+	dbImpls = append(dbImpls, memoryDB)
+
 	key := make([]byte, 32)
 	dbImpls = append(dbImpls, NewEncryptedWrapper(
 		boltDB,
@@ -269,6 +280,160 @@ func TestDBReadPage(t *testing.T) {
 	}
 }
 
+func TestDBIterate(t *testing.T) {
+	for _, dbImpl := range getDBImplementations(t) {
+		db := dbImpl
+
+		namespace := "constructors"
+		otherNamespace := "constructors-history"
+		dummyData := []byte("dummy")
+		for i := 0; i < 10; i++ {
+			err := db.Write(context.Background(), namespace, fmt.Sprintf("team-%d", i), dummyData)
+			assert.NoError(t, err)
+		}
+		err := db.Write(context.Background(), otherNamespace, "team-old", dummyData)
+		assert.NoError(t, err)
+
+		t.Run(string(db.Type())+" visits every element in the namespace", func(t *testing.T) {
+			visited := make(map[string][]byte)
+			err := db.Iterate(context.Background(), namespace, func(key string, value []byte) (bool, error) {
+				visited[key] = append([]byte(nil), value...)
+				return true, nil
+			})
+			assert.NoError(t, err)
+			assert.Len(t, visited, 10)
+			assert.Equal(t, dummyData, visited["team-0"])
+			assert.NotContains(t, visited, "team-old")
+		})
+
+		t.Run(string(db.Type())+" stops when the callback returns false", func(t *testing.T) {
+			count := 0
+			err := db.Iterate(context.Background(), namespace, func(string, []byte) (bool, error) {
+				count++
+				return count < 3, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, count)
+		})
+
+		t.Run(string(db.Type())+" propagates callback errors", func(t *testing.T) {
+			err := db.Iterate(context.Background(), namespace, func(string, []byte) (bool, error) {
+				return false, errors.New("boom")
+			})
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "boom")
+		})
+
+		t.Run(string(db.Type())+" missing namespace is empty", func(t *testing.T) {
+			count := 0
+			err := db.Iterate(context.Background(), "does-not-exist", func(string, []byte) (bool, error) {
+				count++
+				return true, nil
+			})
+			assert.NoError(t, err)
+			assert.Zero(t, count)
+		})
+
+		t.Run(string(db.Type())+" lists namespaces", func(t *testing.T) {
+			namespaces, err := ListNamespaces(context.Background(), db)
+			if _, ok := db.(NamespaceLister); !ok {
+				assert.ErrorContains(t, err, "doesn't list its namespaces")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Subset(t, namespaces, []string{namespace, otherNamespace})
+			assert.NotContains(t, namespaces, "does-not-exist")
+		})
+	}
+}
+
+func TestTenantWrapper(t *testing.T) {
+	for _, dbImpl := range getDBImplementations(t) {
+		db := NewTenantWrapper(dbImpl)
+
+		namespace := "tenant-isolation"
+		acme := WithTenant(context.Background(), "acme")
+		globex := WithTenant(context.Background(), "globex")
+
+		require.NoError(t, db.Write(acme, namespace, "shared-key", []byte("acme")))
+		require.NoError(t, db.Write(globex, namespace, "shared-key", []byte("globex")))
+		require.NoError(t, db.Write(context.Background(), namespace, "default-key", []byte("default")))
+
+		t.Run(string(db.Type())+" tenants only read their own data", func(t *testing.T) {
+			value, err := db.Read(acme, namespace, "shared-key")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("acme"), value)
+
+			value, err = db.Read(globex, namespace, "shared-key")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("globex"), value)
+
+			value, err = db.Read(acme, namespace, "default-key")
+			assert.NoError(t, err)
+			assert.Empty(t, value)
+
+			all, err := db.ReadAll(context.Background(), namespace)
+			assert.NoError(t, err)
+			assert.Len(t, all, 1)
+			assert.Contains(t, all, "default-key")
+
+			count := 0
+			err = db.Iterate(globex, namespace, func(string, []byte) (bool, error) {
+				count++
+				return true, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+
+		t.Run(string(db.Type())+" transactions are scoped to the tenant", func(t *testing.T) {
+			_, err := db.Execute(acme, func(ctx context.Context, tx Tx) (any, error) {
+				return nil, tx.Write(ctx, namespace, "tx-key", []byte("acme"))
+			}, []WatchKey{{Namespace: namespace, Key: "tx-key"}})
+			assert.NoError(t, err)
+
+			exists, err := db.Exists(acme, namespace, "tx-key")
+			assert.NoError(t, err)
+			assert.True(t, exists)
+
+			exists, err = db.Exists(globex, namespace, "tx-key")
+			assert.NoError(t, err)
+			assert.False(t, exists)
+		})
+
+		t.Run(string(db.Type())+" invalid tenants are rejected", func(t *testing.T) {
+			_, err := db.Read(WithTenant(context.Background(), "../acme"), namespace, "shared-key")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInstrumentedWrapper(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	db := InstrumentedWrapper{s: setupBoltDB(t), tracer: tp.Tracer(tracerName)}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, db.Write(ctx, "traced", "key", []byte("value")))
+	value, err := db.Read(ctx, "traced", "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+	require.NoError(t, db.Delete(ctx, "traced", "key"))
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	for i, name := range []string{"storage.Write", "storage.Read", "storage.Delete"} {
+		assert.Equal(t, name, spans[i].Name)
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent.SpanID())
+		assert.Contains(t, spans[i].Attributes, attribute.String("db.system", string(Bolt)))
+		assert.Contains(t, spans[i].Attributes, attribute.String("db.namespace", "traced"))
+	}
+
+	// each operation's duration is observed, by storage, operation, and outcome
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(metrics.StorageOperationDuration), 3)
+}
+
 func TestDBPrefixAndKeys(t *testing.T) {
 	for _, dbImpl := range getDBImplementations(t) {
 		db := dbImpl
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/tbd54566975/ssi-service/pkg/encryption"
@@ -41,6 +42,14 @@ func (e EncryptedWrapper) Close() error {
 	return e.s.Close()
 }
 
+func (e EncryptedWrapper) Stats(ctx context.Context) (map[string]any, error) {
+	return Stats(ctx, e.s)
+}
+
+func (e EncryptedWrapper) ListNamespaces(ctx context.Context) ([]string, error) {
+	return ListNamespaces(ctx, e.s)
+}
+
 func (e EncryptedWrapper) Write(ctx context.Context, namespace, key string, value []byte) error {
 	encryptedData, err := e.encrypter.Encrypt(ctx, value, nil)
 	if err != nil {
@@ -49,6 +58,14 @@ func (e EncryptedWrapper) Write(ctx context.Context, namespace, key string, valu
 	return e.s.Write(ctx, namespace, key, encryptedData)
 }
 
+func (e EncryptedWrapper) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	encryptedData, err := e.encrypter.Encrypt(ctx, value, nil)
+	if err != nil {
+		return errors.Wrap(err, "encrypting data")
+	}
+	return WriteWithTTL(ctx, e.s, namespace, key, encryptedData, ttl)
+}
+
 func (e EncryptedWrapper) WriteMany(ctx context.Context, namespace, keys []string, values [][]byte) error {
 	encryptedValues := make([][]byte, 0, len(values))
 	for _, value := range values {
@@ -121,6 +138,16 @@ func (e EncryptedWrapper) ReadAllKeys(ctx context.Context, namespace string) ([]
 	return e.s.ReadAllKeys(ctx, namespace)
 }
 
+func (e EncryptedWrapper) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	return e.s.Iterate(ctx, namespace, func(key string, encryptedBytes []byte) (bool, error) {
+		decryptedData, err := e.decrypter.Decrypt(ctx, encryptedBytes, nil)
+		if err != nil {
+			return false, errors.Wrap(err, "decrypting data")
+		}
+		return fn(key, decryptedData)
+	})
+}
+
 func (e EncryptedWrapper) Delete(ctx context.Context, namespace, key string) error {
 	return e.s.Delete(ctx, namespace, key)
 }
@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+)
+
+// NamespaceEncryptedWrapper encrypts the values of some namespaces, like those holding credentials and applications,
+// which may contain personal data, while the values of other namespaces are stored as they are. Namespaces are
+// configured by their base name, so they're encrypted for every tenant. Each value is encrypted with a data key of its
+// own, which is how encrypted values are told apart from those stored before their namespace was encrypted. Those are
+// read as they are, until EncryptNamespaces encrypts them.
+type NamespaceEncryptedWrapper struct {
+	s          ServiceStorage
+	envelope   *encryption.EnvelopeEncrypter
+	namespaces map[string]bool
+}
+
+// NewNamespaceEncryptedWrapper creates a wrapper encrypting the values of namespaces, which are base names of
+// namespaces, with envelope.
+func NewNamespaceEncryptedWrapper(s ServiceStorage, envelope *encryption.EnvelopeEncrypter, namespaces []string) *NamespaceEncryptedWrapper {
+	encrypted := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		encrypted[namespace] = true
+	}
+	return &NamespaceEncryptedWrapper{
+		s:          s,
+		envelope:   envelope,
+		namespaces: encrypted,
+	}
+}
+
+// Namespaces returns the base names of the namespaces whose values are encrypted, sorted.
+func (e NamespaceEncryptedWrapper) Namespaces() []string {
+	namespaces := make([]string, 0, len(e.namespaces))
+	for namespace := range e.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func (e NamespaceEncryptedWrapper) encrypts(namespace string) bool {
+	return e.namespaces[BaseNamespace(namespace)]
+}
+
+func (e NamespaceEncryptedWrapper) encrypt(ctx context.Context, namespace string, value []byte) ([]byte, error) {
+	if !e.encrypts(namespace) {
+		return value, nil
+	}
+	encryptedData, err := e.envelope.Encrypt(ctx, value, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "encrypting value of namespace<%s>", namespace)
+	}
+	return encryptedData, nil
+}
+
+func (e NamespaceEncryptedWrapper) decrypt(ctx context.Context, namespace string, value []byte) ([]byte, error) {
+	if !e.encrypts(namespace) || !encryption.IsEnvelope(value) {
+		return value, nil
+	}
+	decryptedData, err := e.envelope.Decrypt(ctx, value, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting value of namespace<%s>", namespace)
+	}
+	return decryptedData, nil
+}
+
+func (e NamespaceEncryptedWrapper) decryptMap(ctx context.Context, namespace string, values map[string][]byte) (map[string][]byte, error) {
+	if !e.encrypts(namespace) {
+		return values, nil
+	}
+	decryptedValues := make(map[string][]byte, len(values))
+	for key, value := range values {
+		decryptedData, err := e.decrypt(ctx, namespace, value)
+		if err != nil {
+			return nil, err
+		}
+		decryptedValues[key] = decryptedData
+	}
+	return decryptedValues, nil
+}
+
+func (e NamespaceEncryptedWrapper) Init(opts ...Option) error {
+	return e.s.Init(opts...)
+}
+
+func (e NamespaceEncryptedWrapper) Type() Type {
+	return e.s.Type()
+}
+
+func (e NamespaceEncryptedWrapper) URI() string {
+	return e.s.URI()
+}
+
+func (e NamespaceEncryptedWrapper) IsOpen() bool {
+	return e.s.IsOpen()
+}
+
+func (e NamespaceEncryptedWrapper) Close() error {
+	return e.s.Close()
+}
+
+func (e NamespaceEncryptedWrapper) Stats(ctx context.Context) (map[string]any, error) {
+	return Stats(ctx, e.s)
+}
+
+func (e NamespaceEncryptedWrapper) ListNamespaces(ctx context.Context) ([]string, error) {
+	return ListNamespaces(ctx, e.s)
+}
+
+func (e NamespaceEncryptedWrapper) Write(ctx context.Context, namespace, key string, value []byte) error {
+	encryptedData, err := e.encrypt(ctx, namespace, value)
+	if err != nil {
+		return err
+	}
+	return e.s.Write(ctx, namespace, key, encryptedData)
+}
+
+func (e NamespaceEncryptedWrapper) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	encryptedData, err := e.encrypt(ctx, namespace, value)
+	if err != nil {
+		return err
+	}
+	return WriteWithTTL(ctx, e.s, namespace, key, encryptedData, ttl)
+}
+
+func (e NamespaceEncryptedWrapper) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
+	encryptedValues := make([][]byte, 0, len(values))
+	for i, value := range values {
+		if i >= len(namespaces) {
+			encryptedValues = append(encryptedValues, value)
+			continue
+		}
+		encryptedData, err := e.encrypt(ctx, namespaces[i], value)
+		if err != nil {
+			return err
+		}
+		encryptedValues = append(encryptedValues, encryptedData)
+	}
+	return e.s.WriteMany(ctx, namespaces, keys, encryptedValues)
+}
+
+func (e NamespaceEncryptedWrapper) Read(ctx context.Context, namespace, key string) ([]byte, error) {
+	storedBytes, err := e.s.Read(ctx, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(ctx, namespace, storedBytes)
+}
+
+func (e NamespaceEncryptedWrapper) Exists(ctx context.Context, namespace, key string) (bool, error) {
+	return e.s.Exists(ctx, namespace, key)
+}
+
+func (e NamespaceEncryptedWrapper) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
+	storedValues, err := e.s.ReadAll(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return e.decryptMap(ctx, namespace, storedValues)
+}
+
+func (e NamespaceEncryptedWrapper) ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+	storedValues, nextPageToken, err := e.s.ReadPage(ctx, namespace, pageToken, pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	decryptedValues, err := e.decryptMap(ctx, namespace, storedValues)
+	if err != nil {
+		return nil, "", err
+	}
+	return decryptedValues, nextPageToken, nil
+}
+
+func (e NamespaceEncryptedWrapper) ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error) {
+	storedValues, err := e.s.ReadPrefix(ctx, namespace, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return e.decryptMap(ctx, namespace, storedValues)
+}
+
+func (e NamespaceEncryptedWrapper) ReadAllKeys(ctx context.Context, namespace string) ([]string, error) {
+	return e.s.ReadAllKeys(ctx, namespace)
+}
+
+func (e NamespaceEncryptedWrapper) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	if !e.encrypts(namespace) {
+		return e.s.Iterate(ctx, namespace, fn)
+	}
+	return e.s.Iterate(ctx, namespace, func(key string, storedBytes []byte) (bool, error) {
+		decryptedData, err := e.decrypt(ctx, namespace, storedBytes)
+		if err != nil {
+			return false, err
+		}
+		return fn(key, decryptedData)
+	})
+}
+
+func (e NamespaceEncryptedWrapper) Delete(ctx context.Context, namespace, key string) error {
+	return e.s.Delete(ctx, namespace, key)
+}
+
+func (e NamespaceEncryptedWrapper) DeleteNamespace(ctx context.Context, namespace string) error {
+	return e.s.DeleteNamespace(ctx, namespace)
+}
+
+type namespaceEncryptedTx struct {
+	tx      Tx
+	wrapper NamespaceEncryptedWrapper
+}
+
+func (m namespaceEncryptedTx) Write(ctx context.Context, namespace, key string, value []byte) error {
+	encryptedData, err := m.wrapper.encrypt(ctx, namespace, value)
+	if err != nil {
+		return err
+	}
+	return m.tx.Write(ctx, namespace, key, encryptedData)
+}
+
+func (e NamespaceEncryptedWrapper) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error) {
+	return e.s.Execute(ctx, func(ctx context.Context, tx Tx) (any, error) {
+		return businessLogicFunc(ctx, namespaceEncryptedTx{tx: tx, wrapper: e})
+	}, watchKeys)
+}
+
+// EncryptNamespaces encrypts the values of the encrypted namespaces of every tenant that were stored before their
+// namespaces were encrypted, returning how many values it encrypted, or would encrypt on a dry run. Each value is read
+// again as it's encrypted, in the same transaction, so values written meanwhile aren't overwritten, and the service
+// can keep running. It requires a storage provider that lists its namespaces.
+func (e NamespaceEncryptedWrapper) EncryptNamespaces(ctx context.Context, dryRun bool) (int, error) {
+	namespaces, err := ListNamespaces(ctx, e.s)
+	if err != nil {
+		return 0, err
+	}
+	encrypted := 0
+	for _, namespace := range namespaces {
+		if !e.encrypts(namespace) {
+			continue
+		}
+		var keys []string
+		err = e.s.Iterate(ctx, namespace, func(key string, value []byte) (bool, error) {
+			if len(value) > 0 && !encryption.IsEnvelope(value) {
+				keys = append(keys, key)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return encrypted, errors.Wrapf(err, "reading values of namespace<%s>", namespace)
+		}
+		if dryRun {
+			encrypted += len(keys)
+			continue
+		}
+		for _, key := range keys {
+			wrote, err := e.encryptValue(ctx, namespace, key)
+			if err != nil {
+				return encrypted, err
+			}
+			if wrote {
+				encrypted++
+			}
+		}
+	}
+	return encrypted, nil
+}
+
+// encryptValue encrypts a value that's stored unencrypted, returning whether it did, which it doesn't when the value
+// was encrypted, or deleted, since it was listed.
+func (e NamespaceEncryptedWrapper) encryptValue(ctx context.Context, namespace, key string) (bool, error) {
+	watchKeys := []WatchKey{{Namespace: namespace, Key: key}}
+	result, err := e.s.Execute(ctx, func(ctx context.Context, tx Tx) (any, error) {
+		value, err := e.s.Read(ctx, namespace, key)
+		if err != nil {
+			return false, err
+		}
+		if len(value) == 0 || encryption.IsEnvelope(value) {
+			return false, nil
+		}
+		encryptedData, err := e.envelope.Encrypt(ctx, value, nil)
+		if err != nil {
+			return false, errors.Wrapf(err, "encrypting value of namespace<%s>", namespace)
+		}
+		return true, tx.Write(ctx, namespace, key, encryptedData)
+	}, watchKeys)
+	if err != nil {
+		return false, errors.Wrapf(err, "encrypting value<%s> of namespace<%s>", key, namespace)
+	}
+	wrote, _ := result.(bool)
+	return wrote, nil
+}
+
+var _ ServiceStorage = (*NamespaceEncryptedWrapper)(nil)
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/internal/util"
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+)
+
+func TestNamespaceEncryptedWrapper(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewStorage(Memory)
+	require.NoError(t, err)
+	key, err := util.GenerateSalt(32)
+	require.NoError(t, err)
+	serviceKey := encryption.NewXChaCha20Poly1305EncrypterWithKey(key)
+	envelope := encryption.NewEnvelopeEncrypter(serviceKey, serviceKey, nil)
+
+	// values stored before their namespace is encrypted
+	require.NoError(t, db.Write(ctx, "credential", "before", []byte(`{"name":"before"}`)))
+	require.NoError(t, db.Write(ctx, "tenants/acme/credential", "before", []byte(`{"name":"acme"}`)))
+
+	encrypted := NewNamespaceEncryptedWrapper(db, envelope, []string{"credential"})
+	assert.Equal(t, []string{"credential"}, encrypted.Namespaces())
+	require.NoError(t, encrypted.Write(ctx, "credential", "after", []byte(`{"name":"after"}`)))
+	require.NoError(t, encrypted.Write(ctx, "schema", "after", []byte(`{"name":"schema"}`)))
+	_, err = encrypted.Execute(ctx, func(ctx context.Context, tx Tx) (any, error) {
+		return nil, tx.Write(ctx, "tenants/acme/credential", "after", []byte(`{"name":"acme after"}`))
+	}, nil)
+	require.NoError(t, err)
+
+	t.Run("encrypts the values of encrypted namespaces only", func(t *testing.T) {
+		stored, err := db.Read(ctx, "credential", "after")
+		require.NoError(t, err)
+		assert.True(t, encryption.IsEnvelope(stored))
+		stored, err = db.Read(ctx, "tenants/acme/credential", "after")
+		require.NoError(t, err)
+		assert.True(t, encryption.IsEnvelope(stored))
+		stored, err = db.Read(ctx, "schema", "after")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"name":"schema"}`), stored)
+	})
+
+	t.Run("reads values whether they're encrypted or not", func(t *testing.T) {
+		values, err := encrypted.ReadAll(ctx, "credential")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"before": []byte(`{"name":"before"}`),
+			"after":  []byte(`{"name":"after"}`),
+		}, values)
+		value, err := encrypted.Read(ctx, "tenants/acme/credential", "after")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"name":"acme after"}`), value)
+	})
+
+	t.Run("encrypts the values stored before", func(t *testing.T) {
+		count, err := encrypted.EncryptNamespaces(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		stored, err := db.Read(ctx, "credential", "before")
+		require.NoError(t, err)
+		assert.False(t, encryption.IsEnvelope(stored))
+
+		count, err = encrypted.EncryptNamespaces(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		err = db.Iterate(ctx, "tenants/acme/credential", func(_ string, value []byte) (bool, error) {
+			assert.True(t, encryption.IsEnvelope(value))
+			return true, nil
+		})
+		require.NoError(t, err)
+		value, err := encrypted.Read(ctx, "tenants/acme/credential", "before")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"name":"acme"}`), value)
+
+		count, err = encrypted.EncryptNamespaces(ctx, false)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ExportFormat names the format of exports of storage, in their headers.
+	ExportFormat = "ssi-service-storage-export"
+
+	// ExportVersion is the version of the format of the exports that are written. It's increased when the format, or
+	// how the service stores its values, changes in a way that older exports have to be upgraded for, so that exports
+	// keep being imported once they're upgraded by Import. Exports of versions that are newer are rejected.
+	ExportVersion = 1
+)
+
+// ExportHeader is the first line of an export.
+type ExportHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Storage provider the values were exported from.
+	Provider Type `json:"provider"`
+
+	// Whether the values were read as of a single point in time. Exports of storage providers that don't read
+	// snapshots are read namespace by namespace, so values written during the export may or may not be in it.
+	Consistent bool `json:"consistent"`
+
+	// Namespaces that were exported, when not every namespace was.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ExportedValue is a line of an export after its header. Values are exported as they're stored, so encrypted values
+// stay encrypted.
+type ExportedValue struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+}
+
+// ErrInvalidExport is returned by Import when what it reads isn't an export it can import.
+var ErrInvalidExport = errors.New("invalid export")
+
+// ErrSnapshotsNotSupported is returned by Snapshotter.Snapshot, before reading anything, by wrappers of storage
+// providers that don't read snapshots.
+var ErrSnapshotsNotSupported = errors.New("storage provider doesn't read snapshots")
+
+// SnapshotFunc is called for every value read by Snapshotter.Snapshot. The value is only valid for the duration of the
+// call. Any error returned stops the snapshot.
+type SnapshotFunc func(namespace, key string, value []byte) error
+
+// Snapshotter is implemented by storage providers that read all of their values as of a single point in time, so that
+// exports of them are consistent.
+type Snapshotter interface {
+	Snapshot(ctx context.Context, fn SnapshotFunc) error
+}
+
+// ImportResult counts the values of an import.
+type ImportResult struct {
+	// Header of the export.
+	Header ExportHeader `json:"header"`
+
+	// Number of values written, and of values that weren't because they were stored already.
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Export writes every value of s to w, after a header, or only those of namespaces when it isn't empty. Values are read
+// as of a single point in time when s is a Snapshotter, and namespace by namespace otherwise, which requires s to list
+// its namespaces unless they're given. Returns how many values were exported.
+func Export(ctx context.Context, s ServiceStorage, w io.Writer, createdAt time.Time, namespaces []string) (int, error) {
+	encoder := json.NewEncoder(w)
+	header := ExportHeader{Format: ExportFormat, Version: ExportVersion, CreatedAt: createdAt.UTC(), Provider: s.Type(), Namespaces: namespaces}
+	exportedNamespaces := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		exportedNamespaces[namespace] = true
+	}
+	writeHeader := func(consistent bool) error {
+		header.Consistent = consistent
+		return errors.Wrap(encoder.Encode(header), "writing export header")
+	}
+	exported := 0
+	write := func(namespace, key string, value []byte) error {
+		exported++
+		return encoder.Encode(ExportedValue{Namespace: namespace, Key: key, Value: value})
+	}
+
+	if snapshotter, ok := s.(Snapshotter); ok {
+		// the header is written along with the first value, or once the snapshot is read when there are none, as
+		// wrappers only tell whether they read snapshots by trying to
+		headerWritten := false
+		err := snapshotter.Snapshot(ctx, func(namespace, key string, value []byte) error {
+			if len(exportedNamespaces) > 0 && !exportedNamespaces[namespace] {
+				return nil
+			}
+			if !headerWritten {
+				headerWritten = true
+				if err := writeHeader(true); err != nil {
+					return err
+				}
+			}
+			return write(namespace, key, value)
+		})
+		if !errors.Is(err, ErrSnapshotsNotSupported) {
+			if err == nil && !headerWritten {
+				err = writeHeader(true)
+			}
+			return exported, errors.Wrap(err, "exporting values")
+		}
+	}
+
+	if len(namespaces) == 0 {
+		var err error
+		if namespaces, err = ListNamespaces(ctx, s); err != nil {
+			return 0, err
+		}
+	}
+	if err := writeHeader(false); err != nil {
+		return 0, err
+	}
+	for _, namespace := range namespaces {
+		err := s.Iterate(ctx, namespace, func(key string, value []byte) (bool, error) {
+			return true, write(namespace, key, value)
+		})
+		if err != nil {
+			return exported, errors.Wrapf(err, "exporting namespace<%s>", namespace)
+		}
+	}
+	return exported, nil
+}
+
+// Import writes the values of an export read from r into s. Values that are stored already are replaced when overwrite
+// is set, and kept as they are otherwise. Nothing is written on a dry run, whose result counts the values that would
+// be. Returns an error wrapping ErrInvalidExport when r isn't an export of a version it can import, or is malformed, in
+// which case the values before the malformed one were imported.
+func Import(ctx context.Context, s ServiceStorage, r io.Reader, overwrite, dryRun bool) (*ImportResult, error) {
+	decoder := json.NewDecoder(r)
+	var result ImportResult
+	if err := decoder.Decode(&result.Header); err != nil {
+		return nil, fmt.Errorf("reading export header: %w: %w", ErrInvalidExport, err)
+	}
+	if result.Header.Format != ExportFormat {
+		return nil, errors.Wrapf(ErrInvalidExport, "not an export of storage, its format is %q", result.Header.Format)
+	}
+	// exports of older versions are upgraded here once the format changes
+	if result.Header.Version < 1 || result.Header.Version > ExportVersion {
+		return nil, errors.Wrapf(ErrInvalidExport, "export version %d is not supported", result.Header.Version)
+	}
+
+	for decoder.More() {
+		var value ExportedValue
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("reading value %d: %w: %w", result.Imported+result.Skipped+1, ErrInvalidExport, err)
+		}
+		if value.Namespace == "" || value.Key == "" {
+			return nil, errors.Wrapf(ErrInvalidExport, "value %d needs a namespace and a key", result.Imported+result.Skipped+1)
+		}
+		if !overwrite {
+			exists, err := s.Exists(ctx, value.Namespace, value.Key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "checking whether %s in namespace<%s> exists", value.Key, value.Namespace)
+			}
+			if exists {
+				result.Skipped++
+				continue
+			}
+		}
+		if !dryRun {
+			if err := s.Write(ctx, value.Namespace, value.Key, value.Value); err != nil {
+				return nil, errors.Wrapf(err, "writing %s in namespace<%s>", value.Key, value.Namespace)
+			}
+		}
+		result.Imported++
+	}
+	return &result, nil
+}
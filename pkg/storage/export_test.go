@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for _, dbImpl := range getDBImplementations(t) {
+		db := NewInstrumentedWrapper(dbImpl)
+		ctx := context.Background()
+		require.NoError(t, db.Write(ctx, "export-a", "one", []byte("1")))
+		require.NoError(t, db.Write(ctx, "export-a", "two", []byte("2")))
+		require.NoError(t, db.Write(ctx, "export:b", "three", []byte("3")))
+
+		t.Run(string(dbImpl.Type())+" exports and imports into a fresh instance", func(t *testing.T) {
+			var namespaces []string
+			if _, ok := dbImpl.(NamespaceLister); !ok {
+				namespaces = []string{"export-a", "export:b"}
+			}
+			var export bytes.Buffer
+			exported, err := Export(ctx, db, &export, createdAt, namespaces)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, exported, 3)
+
+			var header ExportHeader
+			require.NoError(t, json.NewDecoder(&export).Decode(&header))
+			assert.Equal(t, ExportFormat, header.Format)
+			assert.Equal(t, ExportVersion, header.Version)
+			assert.Equal(t, createdAt, header.CreatedAt)
+			_, consistent := dbImpl.(Snapshotter)
+			assert.Equal(t, consistent, header.Consistent)
+
+			export.Reset()
+			_, err = Export(ctx, db, &export, createdAt, []string{"export-a", "export:b"})
+			require.NoError(t, err)
+			fresh, err := NewStorage(Memory)
+			require.NoError(t, err)
+			result, err := Import(ctx, fresh, bytes.NewReader(export.Bytes()), false, false)
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.Imported)
+			assert.Zero(t, result.Skipped)
+
+			value, err := fresh.Read(ctx, "export:b", "three")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("3"), value)
+
+			// values stored already are skipped, and a dry run writes nothing
+			result, err = Import(ctx, fresh, bytes.NewReader(export.Bytes()), false, true)
+			require.NoError(t, err)
+			assert.Zero(t, result.Imported)
+			assert.Equal(t, 3, result.Skipped)
+		})
+	}
+}
+
+func TestImportRejectsInvalidExports(t *testing.T) {
+	db, err := NewStorage(Memory)
+	require.NoError(t, err)
+	for name, export := range map[string]string{
+		"not json":          "backup",
+		"other format":      `{"format":"something-else","version":1}`,
+		"newer version":     `{"format":"ssi-service-storage-export","version":99}`,
+		"value without key": `{"format":"ssi-service-storage-export","version":1}` + "\n" + `{"namespace":"a"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Import(context.Background(), db, strings.NewReader(export), false, false)
+			assert.ErrorIs(t, err, ErrInvalidExport)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tbd54566975/ssi-service/internal/metrics"
+)
+
+const tracerName = "github.com/tbd54566975/ssi-service/pkg/storage"
+
+// InstrumentedWrapper records a span, and the duration, of every operation on the storage it wraps. Spans are children
+// of the span the context of the operation carries, e.g. the one of the request being handled, and are no-ops unless
+// tracing is enabled.
+type InstrumentedWrapper struct {
+	s      ServiceStorage
+	tracer trace.Tracer
+}
+
+func NewInstrumentedWrapper(s ServiceStorage) *InstrumentedWrapper {
+	return &InstrumentedWrapper{s: s, tracer: otel.Tracer(tracerName)}
+}
+
+// instrumentedOp is an operation on the storage that's being instrumented.
+type instrumentedOp struct {
+	storage string
+	name    string
+	span    trace.Span
+	start   time.Time
+}
+
+func (t InstrumentedWrapper) start(ctx context.Context, name, namespace string) (context.Context, *instrumentedOp) {
+	storage := string(t.s.Type())
+	attrs := []attribute.KeyValue{attribute.String("db.system", storage), attribute.String("db.operation", name)}
+	if namespace != "" {
+		attrs = append(attrs, attribute.String("db.namespace", namespace))
+	}
+	ctx, span := t.tracer.Start(ctx, "storage."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx, &instrumentedOp{storage: storage, name: name, span: span, start: time.Now()}
+}
+
+func (o *instrumentedOp) end(err error) {
+	metrics.StorageOperationDuration.WithLabelValues(o.storage, o.name, metrics.Outcome(err)).Observe(time.Since(o.start).Seconds())
+	if err != nil {
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+	o.span.End()
+}
+
+func (t InstrumentedWrapper) Init(opts ...Option) error {
+	return t.s.Init(opts...)
+}
+
+func (t InstrumentedWrapper) Type() Type {
+	return t.s.Type()
+}
+
+func (t InstrumentedWrapper) URI() string {
+	return t.s.URI()
+}
+
+func (t InstrumentedWrapper) IsOpen() bool {
+	return t.s.IsOpen()
+}
+
+func (t InstrumentedWrapper) Close() error {
+	return t.s.Close()
+}
+
+func (t InstrumentedWrapper) Stats(ctx context.Context) (map[string]any, error) {
+	return Stats(ctx, t.s)
+}
+
+func (t InstrumentedWrapper) ListNamespaces(ctx context.Context) (namespaces []string, err error) {
+	ctx, op := t.start(ctx, "list_namespaces", "")
+	defer func() { op.end(err) }()
+	return ListNamespaces(ctx, t.s)
+}
+
+// Snapshot reads a snapshot of the wrapped storage, returning ErrSnapshotsNotSupported when it doesn't read snapshots.
+func (t InstrumentedWrapper) Snapshot(ctx context.Context, fn SnapshotFunc) error {
+	snapshotter, ok := t.s.(Snapshotter)
+	if !ok {
+		return ErrSnapshotsNotSupported
+	}
+	ctx, op := t.start(ctx, "snapshot", "")
+	err := snapshotter.Snapshot(ctx, fn)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) Write(ctx context.Context, namespace, key string, value []byte) error {
+	ctx, op := t.start(ctx, "Write", namespace)
+	err := t.s.Write(ctx, namespace, key, value)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	ctx, op := t.start(ctx, "WriteWithTTL", namespace)
+	err := WriteWithTTL(ctx, t.s, namespace, key, value, ttl)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
+	ctx, op := t.start(ctx, "WriteMany", "")
+	op.span.SetAttributes(attribute.Int("db.keys", len(keys)))
+	err := t.s.WriteMany(ctx, namespaces, keys, values)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) Read(ctx context.Context, namespace, key string) ([]byte, error) {
+	ctx, op := t.start(ctx, "Read", namespace)
+	value, err := t.s.Read(ctx, namespace, key)
+	op.end(err)
+	return value, err
+}
+
+func (t InstrumentedWrapper) Exists(ctx context.Context, namespace, key string) (bool, error) {
+	ctx, op := t.start(ctx, "Exists", namespace)
+	exists, err := t.s.Exists(ctx, namespace, key)
+	op.end(err)
+	return exists, err
+}
+
+func (t InstrumentedWrapper) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
+	ctx, op := t.start(ctx, "ReadAll", namespace)
+	values, err := t.s.ReadAll(ctx, namespace)
+	op.end(err)
+	return values, err
+}
+
+func (t InstrumentedWrapper) ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+	ctx, op := t.start(ctx, "ReadPage", namespace)
+	values, nextPageToken, err := t.s.ReadPage(ctx, namespace, pageToken, pageSize)
+	op.end(err)
+	return values, nextPageToken, err
+}
+
+func (t InstrumentedWrapper) ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error) {
+	ctx, op := t.start(ctx, "ReadPrefix", namespace)
+	values, err := t.s.ReadPrefix(ctx, namespace, prefix)
+	op.end(err)
+	return values, err
+}
+
+func (t InstrumentedWrapper) ReadAllKeys(ctx context.Context, namespace string) ([]string, error) {
+	ctx, op := t.start(ctx, "ReadAllKeys", namespace)
+	keys, err := t.s.ReadAllKeys(ctx, namespace)
+	op.end(err)
+	return keys, err
+}
+
+func (t InstrumentedWrapper) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	ctx, op := t.start(ctx, "Iterate", namespace)
+	err := t.s.Iterate(ctx, namespace, fn)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) Delete(ctx context.Context, namespace, key string) error {
+	ctx, op := t.start(ctx, "Delete", namespace)
+	err := t.s.Delete(ctx, namespace, key)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) DeleteNamespace(ctx context.Context, namespace string) error {
+	ctx, op := t.start(ctx, "DeleteNamespace", namespace)
+	err := t.s.DeleteNamespace(ctx, namespace)
+	op.end(err)
+	return err
+}
+
+func (t InstrumentedWrapper) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error) {
+	ctx, op := t.start(ctx, "Execute", "")
+	result, err := t.s.Execute(ctx, businessLogicFunc, watchKeys)
+	op.end(err)
+	return result, err
+}
+
+var _ ServiceStorage = (*InstrumentedWrapper)(nil)
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"sync"
+
+	sdkutil "github.com/TBD54566975/ssi-sdk/util"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	if err := RegisterStorage(new(MemoryDB)); err != nil {
+		panic(err)
+	}
+}
+
+// MemoryDB keeps everything in memory, and forgets it when it's closed. It's meant for tests, and for trying the
+// service out, and orders keys like BoltDB, so that pages are read in the same order.
+type MemoryDB struct {
+	// writeMu serializes writes, including transactions, like bolt does.
+	writeMu sync.Mutex
+	mu      sync.RWMutex
+	data    map[string]map[string][]byte
+}
+
+var _ ServiceStorage = (*MemoryDB)(nil)
+
+// Init instantiates an empty in-memory storage instance. It takes no options.
+func (m *MemoryDB) Init(opts ...Option) error {
+	if len(opts) > 0 {
+		return errors.New("memory storage takes no options")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data != nil {
+		return errors.New("memory db already opened")
+	}
+	m.data = make(map[string]map[string][]byte)
+	return nil
+}
+
+func (m *MemoryDB) Type() Type {
+	return Memory
+}
+
+func (m *MemoryDB) URI() string {
+	return string(Memory)
+}
+
+func (m *MemoryDB) IsOpen() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data != nil
+}
+
+// Close forgets everything that was stored.
+func (m *MemoryDB) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = nil
+	return nil
+}
+
+func (m *MemoryDB) Write(_ context.Context, namespace, key string, value []byte) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	return m.put([]memoryWrite{{namespace: namespace, key: key, value: value}})
+}
+
+func (m *MemoryDB) WriteMany(_ context.Context, namespaces, keys []string, values [][]byte) error {
+	if len(namespaces) != len(keys) || len(namespaces) != len(values) {
+		return errors.New("namespaces, keys, and values, are not of equal length")
+	}
+	writes := make([]memoryWrite, 0, len(namespaces))
+	for i := range namespaces {
+		writes = append(writes, memoryWrite{namespace: namespaces[i], key: keys[i], value: values[i]})
+	}
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	return m.put(writes)
+}
+
+type memoryWrite struct {
+	namespace string
+	key       string
+	value     []byte
+}
+
+// put applies writes at once, copying their values so that callers can reuse them.
+func (m *MemoryDB) put(writes []memoryWrite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		return errors.New("memory db is closed")
+	}
+	for _, w := range writes {
+		bucket, ok := m.data[w.namespace]
+		if !ok {
+			bucket = make(map[string][]byte)
+			m.data[w.namespace] = bucket
+		}
+		bucket[w.key] = append([]byte{}, w.value...)
+	}
+	return nil
+}
+
+type memoryTx struct {
+	writes []memoryWrite
+}
+
+func (tx *memoryTx) Write(_ context.Context, namespace, key string, value []byte) error {
+	tx.writes = append(tx.writes, memoryWrite{namespace: namespace, key: key, value: append([]byte{}, value...)})
+	return nil
+}
+
+// Execute runs the provided function within a transaction, whose writes are applied once it returns without an error.
+// Like BoltDB, transactions run one at a time, and what businessLogicFunc reads doesn't include its own writes.
+func (m *MemoryDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, _ []WatchKey) (any, error) {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	var tx memoryTx
+	result, err := businessLogicFunc(ctx, &tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing business logic func")
+	}
+	if err = m.put(tx.writes); err != nil {
+		return nil, errors.Wrap(err, "committing transaction")
+	}
+	return result, nil
+}
+
+func (m *MemoryDB) Read(_ context.Context, namespace, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.data[namespace][key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (m *MemoryDB) Exists(_ context.Context, namespace, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.data[namespace][key]
+	return ok, nil
+}
+
+// sortedKeys returns the keys of namespace with prefix, in order. The caller must hold mu.
+func (m *MemoryDB) sortedKeys(namespace, prefix string) []string {
+	var keys []string
+	for k := range m.data[namespace] {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *MemoryDB) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
+	return m.ReadPrefix(ctx, namespace, "")
+}
+
+// ReadPage returns pages of keys in order, with the next key as the page token, like BoltDB.
+func (m *MemoryDB) ReadPage(_ context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+	var start string
+	if pageToken != "" {
+		tokenKey, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "base64 decoding page token")
+		}
+		start = string(tokenKey)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := m.sortedKeys(namespace, "")
+	i := sort.SearchStrings(keys, start)
+	result := make(map[string][]byte)
+	for ; i < len(keys) && (pageSize == -1 || len(result) < pageSize); i++ {
+		result[keys[i]] = append([]byte{}, m.data[namespace][keys[i]]...)
+	}
+	var next string
+	if i < len(keys) {
+		next = base64.RawURLEncoding.EncodeToString([]byte(keys[i]))
+	}
+	return result, next, nil
+}
+
+func (m *MemoryDB) ReadPrefix(_ context.Context, namespace, prefix string) (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[string][]byte)
+	for k, v := range m.data[namespace] {
+		if strings.HasPrefix(k, prefix) {
+			result[k] = append([]byte{}, v...)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryDB) ReadAllKeys(_ context.Context, namespace string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedKeys(namespace, ""), nil
+}
+
+// ListNamespaces returns the namespaces that hold values.
+func (m *MemoryDB) ListNamespaces(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	namespaces := make([]string, 0, len(m.data))
+	for namespace, values := range m.data {
+		if len(values) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces, nil
+}
+
+// Snapshot visits every value of every namespace, in order, as they were when it was called. It doesn't hold any lock
+// while fn runs.
+func (m *MemoryDB) Snapshot(_ context.Context, fn SnapshotFunc) error {
+	m.mu.RLock()
+	namespaces := make([]string, 0, len(m.data))
+	snapshot := make(map[string]map[string][]byte, len(m.data))
+	for namespace, values := range m.data {
+		namespaces = append(namespaces, namespace)
+		// values are replaced rather than changed when they're written, so they're kept as they are
+		snapshot[namespace] = make(map[string][]byte, len(values))
+		for k, v := range values {
+			snapshot[namespace][k] = v
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		keys := make([]string, 0, len(snapshot[namespace]))
+		for k := range snapshot[namespace] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := fn(namespace, k, snapshot[namespace][k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Iterate visits the keys of the namespace in order. It doesn't hold any lock while fn runs, so values written during
+// the iteration may or may not be visited.
+func (m *MemoryDB) Iterate(_ context.Context, namespace string, fn IterateFunc) error {
+	m.mu.RLock()
+	keys := m.sortedKeys(namespace, "")
+	m.mu.RUnlock()
+	for _, k := range keys {
+		m.mu.RLock()
+		value, ok := m.data[namespace][k]
+		m.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		keepGoing, err := fn(k, value)
+		if err != nil {
+			return err
+		}
+		if !keepGoing {
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MemoryDB) Delete(_ context.Context, namespace, key string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bucket, ok := m.data[namespace]
+	if !ok {
+		return sdkutil.LoggingNewErrorf("namespace<%s> does not exist", namespace)
+	}
+	delete(bucket, key)
+	return nil
+}
+
+func (m *MemoryDB) DeleteNamespace(_ context.Context, namespace string) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.data[namespace]; !ok {
+		return sdkutil.LoggingNewErrorf("could not delete namespace<%s>: it does not exist", namespace)
+	}
+	delete(m.data, namespace)
+	return nil
+}
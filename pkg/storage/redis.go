@@ -20,10 +20,11 @@ func init() {
 }
 
 const (
-	Pong                         = "PONG"
-	RedisScanBatchSize           = 1000
-	MaxElapsedTime               = 6 * time.Second
-	RedisAddressOption OptionKey = "redis-address-option"
+	Pong                          = "PONG"
+	RedisScanBatchSize            = 1000
+	RedisWriteBatchSize           = 1000
+	MaxElapsedTime                = 6 * time.Second
+	RedisAddressOption  OptionKey = "redis-address-option"
 )
 
 type RedisDB struct {
@@ -51,7 +52,10 @@ func (b *RedisDB) ReadPage(ctx context.Context, namespace string, pageToken stri
 	return results, nextCursor, nil
 }
 
-var _ ServiceStorage = (*RedisDB)(nil)
+var (
+	_ ServiceStorage = (*RedisDB)(nil)
+	_ TTLWriter      = (*RedisDB)(nil)
+)
 
 type redisTx struct {
 	pipe goredislib.Pipeliner
@@ -144,6 +148,24 @@ func (b *RedisDB) Close() error {
 	return b.db.Close()
 }
 
+// Stats reports the number of keys, and the use of the connection pool.
+func (b *RedisDB) Stats(ctx context.Context) (map[string]any, error) {
+	keys, err := b.db.DBSize(ctx).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "counting keys")
+	}
+	pool := b.db.PoolStats()
+	return map[string]any{
+		"keys":             keys,
+		"poolHits":         pool.Hits,
+		"poolMisses":       pool.Misses,
+		"poolTimeouts":     pool.Timeouts,
+		"totalConnections": pool.TotalConns,
+		"idleConnections":  pool.IdleConns,
+		"staleConnections": pool.StaleConns,
+	}, nil
+}
+
 func (b *RedisDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error) {
 	var finalOutput any
 	// Transactional function.
@@ -174,14 +196,14 @@ func (b *RedisDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFu
 	err := backoff.Retry(func() error {
 		err := b.db.Watch(ctx, txf, watchKeysStr...)
 		if err != nil && errors.Is(err, goredislib.TxFailedErr) {
-			logrus.Warn("Optimistic lock lost. Retrying..")
+			logrus.WithContext(ctx).Warn("Optimistic lock lost. Retrying..")
 			return err
 		}
 		return backoff.Permanent(err)
 	}, expBackoff)
 
 	if err != nil {
-		logrus.Errorf("error after retrying: %v", err)
+		logrus.WithContext(ctx).Errorf("error after retrying: %v", err)
 		return nil, errors.Wrap(err, "failed to execute after retrying")
 	}
 
@@ -204,18 +226,32 @@ func (b *RedisDB) Write(ctx context.Context, namespace, key string, value []byte
 	return b.db.Set(ctx, nameSpaceKey, value, 0).Err()
 }
 
+// WriteWithTTL writes the value with SET EX, so that redis deletes it once ttl elapsed.
+func (b *RedisDB) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	nameSpaceKey := getRedisKey(namespace, key)
+	return b.db.Set(ctx, nameSpaceKey, value, ttl).Err()
+}
+
+// WriteMany writes the values in pipelines of RedisWriteBatchSize SETs, so that a batch takes a round trip to redis,
+// rather than one per value, without holding up redis with a single huge command.
 func (b *RedisDB) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
-	if len(namespaces) != len(keys) && len(namespaces) != len(values) {
+	if len(namespaces) != len(keys) || len(namespaces) != len(values) {
 		return errors.New("namespaces, keys, and values, are not of equal length")
 	}
 
-	valuesToSet := make([]string, 0, 2*len(values))
-	for i := range namespaces {
-		valuesToSet = append(valuesToSet, getRedisKey(namespaces[i], keys[i]))
-		valuesToSet = append(valuesToSet, string(values[i]))
+	for start := 0; start < len(keys); start += RedisWriteBatchSize {
+		end := min(start+RedisWriteBatchSize, len(keys))
+		_, err := b.db.Pipelined(ctx, func(pipe goredislib.Pipeliner) error {
+			for i := start; i < end; i++ {
+				pipe.Set(ctx, getRedisKey(namespaces[i], keys[i]), values[i], 0)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "writing batch")
+		}
 	}
-
-	return b.db.MSet(ctx, valuesToSet).Err()
+	return nil
 }
 
 func (b *RedisDB) Read(ctx context.Context, namespace, key string) ([]byte, error) {
@@ -298,6 +334,42 @@ func (b *RedisDB) ReadAllKeys(ctx context.Context, namespace string) ([]string,
 	return keys, nil
 }
 
+// Iterate scans the namespace in batches of RedisScanBatchSize keys, fetching the values of each batch with a single
+// MGET. At most one batch is held in memory at a time.
+func (b *RedisDB) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	keyStart := len(namespace) + 1
+	cursor := uint64(0)
+	for {
+		keys, nextCursor, err := b.db.Scan(ctx, cursor, getRedisKey(namespace, "*"), RedisScanBatchSize).Result()
+		if err != nil {
+			return errors.Wrap(err, "scan error")
+		}
+		if len(keys) > 0 {
+			values, err := b.db.MGet(ctx, keys...).Result()
+			if err != nil {
+				return errors.Wrap(err, "getting multiple keys")
+			}
+			for i, val := range values {
+				// the key may have been deleted between the SCAN and the MGET
+				if val == nil {
+					continue
+				}
+				keepGoing, err := fn(keys[i][keyStart:], []byte(fmt.Sprintf("%v", val)))
+				if err != nil {
+					return err
+				}
+				if !keepGoing {
+					return nil
+				}
+			}
+		}
+		if nextCursor == 0 {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
 // NOTE: When passing pageSize == -1, **all** items are returns. Exercise caution regarding memory limits. Always
 // prefer to set the pageSize.
 // TODO: Remove all calls that set pageSize to -1. https://github.com/TBD54566975/ssi-service/issues/525
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/pkg/encryption"
+)
+
+func TestRedisWriteWithTTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	db, err := NewStorage(Redis, Option{ID: RedisAddressOption, Option: server.Addr()}, Option{ID: PasswordOption, Option: "test-password"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	key := make([]byte, 32)
+	encrypted := NewEncryptedWrapper(db, encryption.NewXChaCha20Poly1305EncrypterWithKey(key), encryption.NewXChaCha20Poly1305EncrypterWithKey(key))
+	tenants := NewTenantWrapper(encrypted)
+
+	ctx := WithTenant(context.Background(), "acme")
+	require.NoError(t, WriteWithTTL(ctx, tenants, "cache", "short", []byte("value"), time.Minute))
+	require.NoError(t, WriteWithTTL(ctx, tenants, "cache", "long", []byte("value"), time.Hour))
+	require.NoError(t, tenants.Write(ctx, "cache", "forever", []byte("value")))
+
+	read, err := tenants.Read(ctx, "cache", "short")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), read)
+
+	server.FastForward(2 * time.Minute)
+	keys, err := tenants.ReadAllKeys(ctx, "cache")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"long", "forever"}, keys)
+
+	assert.Error(t, WriteWithTTL(ctx, tenants, "cache", "never", []byte("value"), 0))
+}
+
+func TestWriteWithTTLUnsupported(t *testing.T) {
+	db := setupBoltDB(t)
+	err := WriteWithTTL(context.Background(), db, "cache", "key", []byte("value"), time.Minute)
+	assert.ErrorContains(t, err, "doesn't expire values")
+}
+
+func TestRedisWriteManyInBatches(t *testing.T) {
+	db := setupRedisDB(t)
+
+	count := 2*RedisWriteBatchSize + 1
+	namespaces := make([]string, 0, count)
+	keys := make([]string, 0, count)
+	values := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		namespaces = append(namespaces, "batch")
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+		values = append(values, []byte(fmt.Sprintf("value-%d", i)))
+	}
+	require.NoError(t, db.WriteMany(context.Background(), namespaces, keys, values))
+
+	gotKeys, err := db.ReadAllKeys(context.Background(), "batch")
+	require.NoError(t, err)
+	assert.Len(t, gotKeys, count)
+	read, err := db.Read(context.Background(), "batch", keys[count-1])
+	require.NoError(t, err)
+	assert.Equal(t, values[count-1], read)
+
+	err = db.WriteMany(context.Background(), namespaces, keys, values[:1])
+	assert.ErrorContains(t, err, "not of equal length")
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
 
 	// We include the postresql driver in our implementation, so users can pick "postgres" via configuration.
 	_ "github.com/lib/pq"
@@ -20,6 +23,8 @@ func init() {
 const (
 	SQLConnectionString OptionKey = "sql-connection-string-option"
 	SQLDriverName       OptionKey = "sql-driver-name-option"
+
+	sqlMigrationTimeout = time.Minute
 )
 
 type SQLDB struct {
@@ -39,29 +44,11 @@ func (s *SQLDB) Init(opts ...Option) error {
 		return err
 	}
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS key_values (
-    key varchar,
-    value varchar
-);`)
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(`CREATE INDEX idx_key_values ON key_values USING hash (key);`)
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS namespaces (
-    namespace varchar
-);`)
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(`CREATE INDEX idx_namespaces ON namespaces USING hash (namespace);`)
-	if err != nil {
-		return err
+	ctx, cancel := context.WithTimeout(context.Background(), sqlMigrationTimeout)
+	defer cancel()
+	if err = migrateSQL(ctx, db); err != nil {
+		_ = db.Close()
+		return errors.Wrap(err, "migrating schema")
 	}
 
 	s.db = db
@@ -126,6 +113,20 @@ func (s *SQLDB) Close() error {
 	return s.db.Close()
 }
 
+// Stats reports the use of the connection pool.
+func (s *SQLDB) Stats(_ context.Context) (map[string]any, error) {
+	stats := s.db.Stats()
+	return map[string]any{
+		"openConnections":   stats.OpenConnections,
+		"inUseConnections":  stats.InUse,
+		"idleConnections":   stats.Idle,
+		"waits":             stats.WaitCount,
+		"waitTimeNanos":     stats.WaitDuration.Nanoseconds(),
+		"maxIdleClosed":     stats.MaxIdleClosed,
+		"maxLifetimeClosed": stats.MaxLifetimeClosed,
+	}, nil
+}
+
 func (s *SQLDB) Write(ctx context.Context, namespace, key string, value []byte) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -134,7 +135,7 @@ func (s *SQLDB) Write(ctx context.Context, namespace, key string, value []byte)
 	defer func(tx *sql.Tx) {
 		err := tx.Rollback()
 		if err != nil {
-			logrus.WithError(err).Error("unable to rollback")
+			logrus.WithContext(ctx).WithError(err).Error("unable to rollback")
 		}
 	}(tx)
 
@@ -153,29 +154,27 @@ type ExecContext interface {
 }
 
 func write(ctx context.Context, db ExecContext, namespace, key string, value []byte) error {
-	_, err := db.ExecContext(ctx, "INSERT INTO namespaces (namespace) VALUES ($1) EXCEPT SELECT namespace FROM namespaces WHERE namespace = $2", namespace, namespace)
+	_, err := db.ExecContext(ctx, "INSERT INTO namespaces (namespace) VALUES ($1) ON CONFLICT DO NOTHING", namespace)
 	if err != nil {
 		return err
 	}
-	_, err = db.ExecContext(ctx, "INSERT INTO key_values (key, value) VALUES ($1, $2)", Join(namespace, key), base64.RawStdEncoding.EncodeToString(value))
+	_, err = db.ExecContext(ctx, "INSERT INTO key_values (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value", Join(namespace, key), base64.RawStdEncoding.EncodeToString(value))
 	return err
 }
 
+// WriteMany writes all the values in a single transaction, so that either all or none of them are written.
 func (s *SQLDB) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
-	stmt, err := s.db.Prepare("INSERT INTO key_values (key, value) VALUES ($1, $2)")
-	if err != nil {
-		return err
+	if len(namespaces) != len(keys) || len(namespaces) != len(values) {
+		return errors.New("namespaces, keys, and values, are not of equal length")
 	}
-	defer func(stmt *sql.Stmt) {
-		_ = stmt.Close()
-	}(stmt)
-
-	for i, k := range keys {
-		_, err = stmt.ExecContext(ctx, Join(namespaces[i], k), base64.RawStdEncoding.EncodeToString(values[i]))
-		if err != nil {
-			return err
+	_, err := s.Execute(ctx, func(ctx context.Context, tx Tx) (any, error) {
+		for i := range keys {
+			if err := tx.Write(ctx, namespaces[i], keys[i], values[i]); err != nil {
+				return nil, err
+			}
 		}
-	}
+		return nil, nil
+	}, nil)
 	return err
 }
 
@@ -232,7 +231,7 @@ func (s *SQLDB) ReadAll(ctx context.Context, namespace string) (map[string][]byt
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			logrus.WithError(err).Error("closing rows")
+			logrus.WithContext(ctx).WithError(err).Error("closing rows")
 		}
 	}(rows)
 
@@ -301,7 +300,7 @@ func (s *SQLDB) ReadPrefix(ctx context.Context, namespace, prefix string) (map[s
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			logrus.WithError(err).Error("closing rows")
+			logrus.WithContext(ctx).WithError(err).Error("closing rows")
 		}
 	}(rows)
 
@@ -335,6 +334,134 @@ func (s *SQLDB) ReadAllKeys(ctx context.Context, namespace string) ([]string, er
 	return keys, err
 }
 
+// ListNamespaces returns the namespaces that were written to.
+func (s *SQLDB) ListNamespaces(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT namespace FROM namespaces")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			logrus.WithError(err).Error("closing rows")
+		}
+	}(rows)
+
+	var namespaces []string
+	for rows.Next() {
+		var namespace string
+		if err := rows.Scan(&namespace); err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces, rows.Err()
+}
+
+// Snapshot reads every value in a single read only transaction, whose reads are repeatable, so that they're read as of
+// when it started. Keys are stored joined with their namespaces, which may hold the separator themselves, so each key is
+// split off the longest namespace it starts with.
+func (s *SQLDB) Snapshot(ctx context.Context, fn SnapshotFunc) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var namespaces []string
+	namespaceRows, err := tx.QueryContext(ctx, "SELECT DISTINCT namespace FROM namespaces")
+	if err != nil {
+		return err
+	}
+	for namespaceRows.Next() {
+		var namespace string
+		if err = namespaceRows.Scan(&namespace); err != nil {
+			_ = namespaceRows.Close()
+			return err
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	if err = namespaceRows.Close(); err != nil {
+		return err
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return len(namespaces[i]) > len(namespaces[j])
+	})
+
+	rows, err := tx.QueryContext(ctx, "SELECT key, value FROM key_values ORDER BY key")
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			logrus.WithError(err).Error("closing rows")
+		}
+	}(rows)
+	for rows.Next() {
+		var key string
+		var value string
+		if err = rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		decoded, err := base64.RawStdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		namespace, found := "", false
+		for _, n := range namespaces {
+			if strings.HasPrefix(key, n+":") {
+				namespace, found = n, true
+				break
+			}
+		}
+		if !found {
+			logrus.WithContext(ctx).Warnf("key<%s> isn't in any namespace", key)
+			continue
+		}
+		if err = fn(namespace, key[len(namespace)+1:], decoded); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Iterate streams the rows of the namespace, decoding one value at a time.
+func (s *SQLDB) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT key, value FROM key_values WHERE key LIKE $1 ORDER BY key", Join(namespace, "%"))
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			logrus.WithError(err).Error("closing rows")
+		}
+	}(rows)
+
+	for rows.Next() {
+		var key string
+		var value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		decoded, err := base64.RawStdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		keepGoing, err := fn(key[len(namespace)+1:], decoded)
+		if err != nil {
+			return err
+		}
+		if !keepGoing {
+			break
+		}
+	}
+	return rows.Err()
+}
+
 func (s *SQLDB) Delete(ctx context.Context, namespace, key string) error {
 	row := s.db.QueryRowContext(ctx, "SELECT * FROM namespaces WHERE namespace = $1", namespace)
 	var gotNamespace string
@@ -408,11 +535,14 @@ func (s *SQLDB) UpdateValueAndOperation(ctx context.Context, namespace, key stri
 	opUpdater.SetUpdatedResponse(updatedValue)
 
 	updatedOpValue, err := updateValue(ctx, opNamespace, opKey, opUpdater, tx)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, nil, err
 	}
-	return updatedValue, updatedOpValue, err
+	return updatedValue, updatedOpValue, nil
 }
 
 func updateValue(ctx context.Context, namespace string, key string, updater Updater, tx *sql.Tx) ([]byte, error) {
@@ -443,18 +573,32 @@ func (s *sqlTx) Write(ctx context.Context, namespace, key string, value []byte)
 	return write(ctx, s.tx, namespace, key, value)
 }
 
-func (s *SQLDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, _ []WatchKey) (any, error) {
+// Execute runs the business logic in a transaction. The watched keys are locked until it ends, whether they exist or
+// not, so that transactions watching the same keys run one after the other.
+func (s *SQLDB) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer func(tx *sql.Tx) {
 		err := tx.Rollback()
-		if err != nil {
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
 			logrus.Errorf("problem rolling back %s", err)
 		}
 	}(tx)
 
+	// keys are locked in order, so that transactions locking the same keys can't deadlock
+	lockKeys := make([]string, 0, len(watchKeys))
+	for _, wk := range watchKeys {
+		lockKeys = append(lockKeys, Join(wk.Namespace, wk.Key))
+	}
+	sort.Strings(lockKeys)
+	for _, key := range lockKeys {
+		if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+			return nil, errors.Wrapf(err, "locking key<%s>", key)
+		}
+	}
+
 	bTx := sqlTx{tx: tx}
 
 	result, err := businessLogicFunc(ctx, &bTx)
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// sqlMigrationLockID identifies the advisory lock that instances hold while they migrate the schema, so that instances
+// starting together wait for each other, rather than applying the same migrations at once.
+const sqlMigrationLockID = 5_439_501_022
+
+// sqlMigrations bring the schema of a database up to date. Each is applied once, in order, and recorded by its version,
+// which is its position in the list, starting at 1. Released migrations are never edited; the schema is changed by
+// appending migrations.
+var sqlMigrations = []string{
+	// 1: the schema that was created before migrations were recorded
+	`CREATE TABLE IF NOT EXISTS key_values (
+    key varchar,
+    value varchar
+);
+CREATE INDEX IF NOT EXISTS idx_key_values ON key_values USING hash (key);
+CREATE TABLE IF NOT EXISTS namespaces (
+    namespace varchar
+);
+CREATE INDEX IF NOT EXISTS idx_namespaces ON namespaces USING hash (namespace);`,
+
+	// 2: keys and namespaces are unique, so that writes replace values instead of adding rows. Of the rows that were
+	// written for the same key, the one stored last is kept, which is usually the one written last.
+	`DELETE FROM key_values a USING key_values b WHERE a.key = b.key AND a.ctid < b.ctid;
+DROP INDEX IF EXISTS idx_key_values;
+ALTER TABLE key_values ADD PRIMARY KEY (key);
+DELETE FROM namespaces a USING namespaces b WHERE a.namespace = b.namespace AND a.ctid < b.ctid;
+DROP INDEX IF EXISTS idx_namespaces;
+ALTER TABLE namespaces ADD PRIMARY KEY (namespace);`,
+}
+
+// migrateSQL applies the migrations the database hasn't had yet, in a single transaction, failing when the database
+// was migrated by a newer version of the service.
+func migrateSQL(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	defer func(tx *sql.Tx) {
+		_ = tx.Rollback()
+	}(tx)
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", sqlMigrationLockID); err != nil {
+		return errors.Wrap(err, "locking schema")
+	}
+	if _, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version integer PRIMARY KEY,
+    applied_at timestamptz NOT NULL DEFAULT now()
+);`); err != nil {
+		return errors.Wrap(err, "creating migrations table")
+	}
+
+	var version int
+	if err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return errors.Wrap(err, "reading schema version")
+	}
+	if version > len(sqlMigrations) {
+		return errors.Errorf("schema version<%d> is newer than the latest this service knows<%d>", version, len(sqlMigrations))
+	}
+	for i := version; i < len(sqlMigrations); i++ {
+		logrus.Infof("applying sql migration %d", i+1)
+		if _, err = tx.ExecContext(ctx, sqlMigrations[i]); err != nil {
+			return errors.Wrapf(err, "applying migration %d", i+1)
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", i+1); err != nil {
+			return errors.Wrapf(err, "recording migration %d", i+1)
+		}
+	}
+	return errors.Wrap(tx.Commit(), "committing migrations")
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLMigrations(t *testing.T) {
+	db := setupPostgresDB(t)
+	ctx := context.Background()
+	countRows := func(key string) int {
+		var count int
+		require.NoError(t, db.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM key_values WHERE key = $1", key).Scan(&count))
+		return count
+	}
+
+	t.Run("are applied once", func(tt *testing.T) {
+		var version int
+		require.NoError(tt, db.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version))
+		assert.Equal(tt, len(sqlMigrations), version)
+
+		// a second instance starts on the migrated schema
+		again, err := NewStorage(DatabaseSQL, Option{ID: SQLConnectionString, Option: db.URI()}, Option{ID: SQLDriverName, Option: "postgres"})
+		require.NoError(tt, err)
+		require.NoError(tt, again.Close())
+	})
+
+	t.Run("make writes replace values", func(tt *testing.T) {
+		require.NoError(tt, db.Write(ctx, "migrations", "key", []byte("first")))
+		require.NoError(tt, db.Write(ctx, "migrations", "key", []byte("second")))
+
+		value, err := db.Read(ctx, "migrations", "key")
+		require.NoError(tt, err)
+		assert.Equal(tt, []byte("second"), value)
+		assert.Equal(tt, 1, countRows(Join("migrations", "key")))
+	})
+
+	t.Run("migrate the schema that was created before migrations", func(tt *testing.T) {
+		_, err := db.db.ExecContext(ctx, `DROP TABLE schema_migrations, key_values, namespaces;
+CREATE TABLE key_values (key varchar, value varchar);
+CREATE INDEX idx_key_values ON key_values USING hash (key);
+CREATE TABLE namespaces (namespace varchar);
+CREATE INDEX idx_namespaces ON namespaces USING hash (namespace);
+INSERT INTO namespaces (namespace) VALUES ('legacy'), ('legacy');
+INSERT INTO key_values (key, value) VALUES ('legacy:key', 'Zmlyc3Q'), ('legacy:key', 'c2Vjb25k');`)
+		require.NoError(tt, err)
+
+		require.NoError(tt, migrateSQL(ctx, db.db))
+		assert.Equal(tt, 1, countRows("legacy:key"))
+		value, err := db.Read(ctx, "legacy", "key")
+		require.NoError(tt, err)
+		assert.Equal(tt, []byte("second"), value)
+
+		require.NoError(tt, db.Delete(ctx, "legacy", "key"))
+	})
+
+	t.Run("write many values in one transaction", func(tt *testing.T) {
+		err := db.WriteMany(ctx, []string{"many", "many"}, []string{"a", "b"}, [][]byte{[]byte("a")})
+		assert.Error(tt, err)
+
+		require.NoError(tt, db.WriteMany(ctx, []string{"many", "many"}, []string{"a", "b"}, [][]byte{[]byte("a"), []byte("b")}))
+		values, err := db.ReadAll(ctx, "many")
+		require.NoError(tt, err)
+		assert.Len(tt, values, 2)
+
+		// namespaces are recorded, so the values can be deleted
+		assert.NoError(tt, db.Delete(ctx, "many", "a"))
+	})
+}
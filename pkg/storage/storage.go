@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -23,10 +25,17 @@ type Tx interface {
 	Write(ctx context.Context, namespace, key string, value []byte) error
 }
 
+// IterateFunc is called for every key/value pair visited by ServiceStorage.Iterate. Returning false stops the
+// iteration early. Any error returned stops the iteration and is propagated to the caller of Iterate.
+// The value is only guaranteed to be valid for the duration of the call; implementations must copy it if they need to
+// retain it. IterateFunc must not write to the storage that is being iterated over.
+type IterateFunc func(key string, value []byte) (bool, error)
+
 const (
 	Bolt        Type = "bolt"
 	DatabaseSQL Type = "database_sql"
 	Redis       Type = "redis"
+	Memory      Type = "memory"
 
 	// Common options
 
@@ -70,11 +79,70 @@ type ServiceStorage interface {
 	ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (results map[string][]byte, nextPageToken string, err error)
 	ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error)
 	ReadAllKeys(ctx context.Context, namespace string) ([]string, error)
+
+	// Iterate calls fn for each key/value pair in the namespace. Unlike ReadAll, values are streamed to fn and never
+	// collected in memory all at once, so it is safe to use with namespaces that hold a very large number of items.
+	// Iterating over a namespace that doesn't exist is not an error.
+	Iterate(ctx context.Context, namespace string, fn IterateFunc) error
 	Delete(ctx context.Context, namespace, key string) error
 	DeleteNamespace(ctx context.Context, namespace string) error
 	Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error)
 }
 
+// StatsReporter is implemented by storage providers that report statistics about themselves, like the size of the
+// database or the use of its connection pool, for diagnosing them.
+type StatsReporter interface {
+	Stats(ctx context.Context) (map[string]any, error)
+}
+
+// Stats returns the statistics of a storage provider, or nil when it doesn't report any.
+func Stats(ctx context.Context, s ServiceStorage) (map[string]any, error) {
+	reporter, ok := s.(StatsReporter)
+	if !ok {
+		return nil, nil
+	}
+	return reporter.Stats(ctx)
+}
+
+// NamespaceLister is implemented by storage providers that list the namespaces they hold, for tools that work on all
+// of their data, like exports.
+type NamespaceLister interface {
+	ListNamespaces(ctx context.Context) ([]string, error)
+}
+
+// ListNamespaces returns the namespaces of a storage provider, sorted, failing when it doesn't list them.
+func ListNamespaces(ctx context.Context, s ServiceStorage) ([]string, error) {
+	lister, ok := s.(NamespaceLister)
+	if !ok {
+		return nil, errors.Errorf("storage provider<%s> doesn't list its namespaces", s.Type())
+	}
+	namespaces, err := lister.ListNamespaces(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing namespaces")
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// TTLWriter is implemented by storage providers that expire values, so that values which only matter for a while
+// don't have to be deleted by the service.
+type TTLWriter interface {
+	// WriteWithTTL writes the value, which is deleted once ttl elapsed.
+	WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error
+}
+
+// WriteWithTTL writes a value that's deleted once ttl elapsed, failing when the storage provider doesn't expire values.
+func WriteWithTTL(ctx context.Context, s ServiceStorage, namespace, key string, value []byte, ttl time.Duration) error {
+	writer, ok := s.(TTLWriter)
+	if !ok {
+		return errors.Errorf("storage provider<%s> doesn't expire values", s.Type())
+	}
+	if ttl <= 0 {
+		return errors.Errorf("ttl<%s> must be positive", ttl)
+	}
+	return writer.WriteWithTTL(ctx, namespace, key, value, ttl)
+}
+
 // NewStorage returns the instance of the given storageProvider. If it doesn't exist, then a default implementation
 // is created with the given option parameter.
 func NewStorage(storageProvider Type, opts ...Option) (ServiceStorage, error) {
@@ -98,7 +166,7 @@ func RegisterStorage(storage ServiceStorage) error {
 
 // AvailableStorage returns the supported storage providers.
 func AvailableStorage() []Type {
-	return []Type{Bolt, Redis}
+	return []Type{Bolt, Redis, DatabaseSQL, Memory}
 }
 
 // IsStorageAvailable determines whether a given storage provider is available for instantiation.
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TenantContextKey is the key under which HTTP handlers store the tenant of a request in the gin context. gin only
+	// looks up string keys in its own key store, so this can't be an unexported type like tenantKey.
+	TenantContextKey = "ssi-service-tenant"
+
+	tenantNamespacePrefix = "tenants/"
+)
+
+var validTenantID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose storage operations are scoped to the given tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant that storage operations made with ctx are scoped to. An empty string is the
+// default tenant, which is what's used when multi-tenancy isn't in use.
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok {
+		return tenantID
+	}
+	if tenantID, ok := ctx.Value(TenantContextKey).(string); ok {
+		return tenantID
+	}
+	return ""
+}
+
+// IsValidTenantID returns whether the id can be used as a tenant. Tenant IDs end up in namespace names, so they are
+// restricted to letters, digits, dashes, and underscores.
+func IsValidTenantID(tenantID string) bool {
+	return validTenantID.MatchString(tenantID)
+}
+
+// BaseNamespace returns a namespace without the prefix that scopes it to a tenant, if it has one.
+func BaseNamespace(namespace string) string {
+	_, base := splitNamespace(namespace)
+	return base
+}
+
+// NamespaceTenant returns the tenant a namespace is scoped to, which is empty for the default tenant.
+func NamespaceTenant(namespace string) string {
+	tenantID, _ := splitNamespace(namespace)
+	return tenantID
+}
+
+func splitNamespace(namespace string) (tenantID, base string) {
+	if !strings.HasPrefix(namespace, tenantNamespacePrefix) {
+		return "", namespace
+	}
+	tenantID, base, found := strings.Cut(strings.TrimPrefix(namespace, tenantNamespacePrefix), "/")
+	if !found {
+		return "", namespace
+	}
+	return tenantID, base
+}
+
+// TenantWrapper isolates the data of each tenant by scoping every namespace to the tenant found in the context of
+// the operation. Data of the default tenant lives in the unscoped namespaces, so wrapping an existing database is
+// backwards compatible.
+type TenantWrapper struct {
+	s ServiceStorage
+}
+
+func NewTenantWrapper(s ServiceStorage) *TenantWrapper {
+	return &TenantWrapper{s: s}
+}
+
+func (t TenantWrapper) namespace(ctx context.Context, namespace string) (string, error) {
+	tenantID := TenantFromContext(ctx)
+	if tenantID == "" {
+		return namespace, nil
+	}
+	if !IsValidTenantID(tenantID) {
+		return "", errors.Errorf("invalid tenant: %s", tenantID)
+	}
+	return tenantNamespacePrefix + tenantID + "/" + namespace, nil
+}
+
+func (t TenantWrapper) Init(opts ...Option) error {
+	return t.s.Init(opts...)
+}
+
+func (t TenantWrapper) Type() Type {
+	return t.s.Type()
+}
+
+func (t TenantWrapper) URI() string {
+	return t.s.URI()
+}
+
+func (t TenantWrapper) IsOpen() bool {
+	return t.s.IsOpen()
+}
+
+func (t TenantWrapper) Close() error {
+	return t.s.Close()
+}
+
+func (t TenantWrapper) Stats(ctx context.Context) (map[string]any, error) {
+	return Stats(ctx, t.s)
+}
+
+func (t TenantWrapper) Write(ctx context.Context, namespace, key string, value []byte) error {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return t.s.Write(ctx, ns, key, value)
+}
+
+func (t TenantWrapper) WriteWithTTL(ctx context.Context, namespace, key string, value []byte, ttl time.Duration) error {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return WriteWithTTL(ctx, t.s, ns, key, value, ttl)
+}
+
+func (t TenantWrapper) WriteMany(ctx context.Context, namespaces, keys []string, values [][]byte) error {
+	scoped := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		ns, err := t.namespace(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		scoped = append(scoped, ns)
+	}
+	return t.s.WriteMany(ctx, scoped, keys, values)
+}
+
+func (t TenantWrapper) Read(ctx context.Context, namespace, key string) ([]byte, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.Read(ctx, ns, key)
+}
+
+func (t TenantWrapper) Exists(ctx context.Context, namespace, key string) (bool, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	return t.s.Exists(ctx, ns, key)
+}
+
+func (t TenantWrapper) ReadAll(ctx context.Context, namespace string) (map[string][]byte, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.ReadAll(ctx, ns)
+}
+
+func (t TenantWrapper) ReadPage(ctx context.Context, namespace string, pageToken string, pageSize int) (map[string][]byte, string, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	return t.s.ReadPage(ctx, ns, pageToken, pageSize)
+}
+
+func (t TenantWrapper) ReadPrefix(ctx context.Context, namespace, prefix string) (map[string][]byte, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.ReadPrefix(ctx, ns, prefix)
+}
+
+func (t TenantWrapper) ReadAllKeys(ctx context.Context, namespace string) ([]string, error) {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.ReadAllKeys(ctx, ns)
+}
+
+func (t TenantWrapper) Iterate(ctx context.Context, namespace string, fn IterateFunc) error {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return t.s.Iterate(ctx, ns, fn)
+}
+
+func (t TenantWrapper) Delete(ctx context.Context, namespace, key string) error {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return t.s.Delete(ctx, ns, key)
+}
+
+func (t TenantWrapper) DeleteNamespace(ctx context.Context, namespace string) error {
+	ns, err := t.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return t.s.DeleteNamespace(ctx, ns)
+}
+
+type tenantTx struct {
+	tx      Tx
+	wrapper TenantWrapper
+}
+
+func (m tenantTx) Write(ctx context.Context, namespace, key string, value []byte) error {
+	ns, err := m.wrapper.namespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return m.tx.Write(ctx, ns, key, value)
+}
+
+func (t TenantWrapper) Execute(ctx context.Context, businessLogicFunc BusinessLogicFunc, watchKeys []WatchKey) (any, error) {
+	scopedKeys := make([]WatchKey, 0, len(watchKeys))
+	for _, watchKey := range watchKeys {
+		ns, err := t.namespace(ctx, watchKey.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		scopedKeys = append(scopedKeys, WatchKey{Namespace: ns, Key: watchKey.Key})
+	}
+	return t.s.Execute(ctx, func(ctx context.Context, tx Tx) (any, error) {
+		return businessLogicFunc(ctx, tenantTx{tx: tx, wrapper: t})
+	}, scopedKeys)
+}
+
+var _ ServiceStorage = (*TenantWrapper)(nil)
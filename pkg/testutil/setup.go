@@ -1,21 +1,18 @@
 package testutil
 
 import (
-	"os"
-
 	"github.com/TBD54566975/ssi-sdk/schema"
+	"github.com/sirupsen/logrus"
 )
 
 func EnableSchemaCaching() {
 	s, err := schema.GetAllLocalSchemas()
 	if err != nil {
-		println(err)
-		os.Exit(1)
+		logrus.WithError(err).Fatal("could not load local schemas")
 	}
 	l, err := schema.NewCachingLoader(s)
 	if err != nil {
-		println(err)
-		os.Exit(1)
+		logrus.WithError(err).Fatal("could not create caching loader")
 	}
 	l.EnableHTTPCache()
 }
@@ -0,0 +1,131 @@
+// Package ssitest runs the whole SSI Service inside a Go test, for fast integration tests of programs and SDKs that use
+// its API. The service keeps its data in memory, keeps time with a mock clock the test controls, and is called through
+// an HTTP client that hands requests to its handler directly, so no ports are listened on.
+package ssitest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/client"
+	"github.com/tbd54566975/ssi-service/pkg/server"
+	"github.com/tbd54566975/ssi-service/pkg/service"
+	"github.com/tbd54566975/ssi-service/pkg/storage"
+)
+
+// Endpoint is the URL that requests to a Server are made to. Requests never leave the process, so it's never resolved.
+const Endpoint = "http://ssi-service.test"
+
+// drainTimeout is how long a Server waits for its background work to finish when its test ends.
+const drainTimeout = 10 * time.Second
+
+// Server is an SSI Service running inside a test.
+type Server struct {
+	*server.SSIServer
+
+	// Clock is the clock the services keep time with. It starts at the time the Server was created, and only moves
+	// when the test moves it.
+	Clock *clock.Mock
+	// Storage is where the services keep their data, for tests that look at, or seed, what's stored.
+	Storage storage.ServiceStorage
+}
+
+// Option customizes a Server.
+type Option func(*options)
+
+type options struct {
+	configure []func(cfg *config.SSIServiceConfig)
+	server    []server.Option
+}
+
+// WithConfig changes the config the service runs with, e.g. to enable optional services. The config starts out as the
+// default one.
+func WithConfig(configure func(cfg *config.SSIServiceConfig)) Option {
+	return func(o *options) {
+		o.configure = append(o.configure, configure)
+	}
+}
+
+// WithServerOptions creates the service with opts, like programs that embed it do.
+func WithServerOptions(opts ...server.Option) Option {
+	return func(o *options) {
+		o.server = append(o.server, opts...)
+	}
+}
+
+// New starts a Server for the test, which drains it and forgets its data when the test ends.
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg, err := config.LoadConfig("", nil)
+	require.NoError(t, err)
+	for _, configure := range o.configure {
+		configure(cfg)
+	}
+
+	db, err := storage.NewStorage(storage.Memory)
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Now())
+
+	serverOpts := append([]server.Option{
+		server.WithServiceOptions(service.WithStorage(db), service.WithClock(mockClock)),
+	}, o.server...)
+	ssi, err := server.NewSSIServer(make(chan os.Signal, 1), *cfg, serverOpts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		if err := ssi.Drain(ctx); err != nil {
+			t.Errorf("draining the ssi service: %s", err)
+		}
+		_ = db.Close()
+	})
+	return &Server{SSIServer: ssi, Clock: mockClock, Storage: db}
+}
+
+// HTTPClient returns an HTTP client whose requests are served by the Server's handler, whatever their URL.
+func (s *Server) HTTPClient() *http.Client {
+	return &http.Client{Transport: handlerTransport{handler: s.Handler}}
+}
+
+// Client returns a client of the Server's API, configured with opts, e.g. to authenticate its requests.
+func (s *Server) Client(t testing.TB, opts ...client.Option) *client.Client {
+	t.Helper()
+	c, err := client.New(Endpoint, append([]client.Option{client.WithHTTPClient(s.HTTPClient())}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+// handlerTransport serves requests with a handler instead of sending them over the network. Requests come from the
+// loopback address, like those of a client on the same host.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	served := req.Clone(req.Context())
+	served.RequestURI = req.URL.RequestURI()
+	served.RemoteAddr = "127.0.0.1:0"
+	if served.Body == nil {
+		served.Body = http.NoBody
+	}
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, served)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
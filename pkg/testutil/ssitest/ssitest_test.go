@@ -0,0 +1,82 @@
+package ssitest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tbd54566975/ssi-service/config"
+	"github.com/tbd54566975/ssi-service/pkg/client"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves the api without listening", func(tt *testing.T) {
+		s := New(tt)
+		c := s.Client(tt)
+
+		resp, err := c.Do(ctx, client.Request{
+			Method: http.MethodPut,
+			Path:   "/v1/dids/key",
+			Body:   map[string]any{"keyType": "Ed25519"},
+		})
+		require.NoError(tt, err)
+		var created struct {
+			DID struct {
+				ID string `json:"id"`
+			} `json:"did"`
+		}
+		require.NoError(tt, resp.Decode(&created))
+		require.NotEmpty(tt, created.DID.ID)
+
+		resp, err = c.Do(ctx, client.Request{Method: http.MethodGet, Path: "/v1/dids/key/" + created.DID.ID})
+		require.NoError(tt, err)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+
+		_, err = c.Do(ctx, client.Request{Method: http.MethodGet, Path: "/v1/schemas/missing"})
+		var apiErr *client.Error
+		require.True(tt, errors.As(err, &apiErr))
+		assert.Equal(tt, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("keeps time with the mock clock", func(tt *testing.T) {
+		s := New(tt, WithConfig(func(cfg *config.SSIServiceConfig) {
+			cfg.Services.ReplayConfig.Enabled = true
+			cfg.Services.ReplayConfig.TTL = time.Hour
+		}))
+		c := s.Client(tt)
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(tt, err)
+		token, err := jwt.NewBuilder().Issuer("did:example:alice").JwtID("jti-1").Build()
+		require.NoError(tt, err)
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.EdDSA, key))
+		require.NoError(tt, err)
+		submit := func() int {
+			_, err := c.Do(ctx, client.Request{
+				Method: http.MethodPut,
+				Path:   "/v1/presentations/submissions",
+				Body:   map[string]any{"submissionJwt": string(signed)},
+			})
+			var apiErr *client.Error
+			require.True(tt, errors.As(err, &apiErr))
+			return apiErr.StatusCode
+		}
+
+		// the jwt isn't a valid submission, but it's remembered until the ttl passes
+		assert.Equal(tt, http.StatusBadRequest, submit())
+		assert.Equal(tt, http.StatusConflict, submit())
+		s.Clock.Add(2 * time.Hour)
+		assert.Equal(tt, http.StatusBadRequest, submit())
+	})
+}
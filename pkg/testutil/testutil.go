@@ -21,6 +21,10 @@ var TestDatabases = []struct {
 		Name:           "Test with Redis DB",
 		ServiceStorage: setupRedisTestDB,
 	},
+	{
+		Name:           "Test with Memory DB",
+		ServiceStorage: setupMemoryTestDB,
+	},
 }
 
 func setupBoltTestDB(t *testing.T) storage.ServiceStorage {
@@ -65,3 +69,14 @@ func setupRedisTestDB(t *testing.T) storage.ServiceStorage {
 
 	return s
 }
+
+func setupMemoryTestDB(t *testing.T) storage.ServiceStorage {
+	s, err := storage.NewStorage(storage.Memory)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}